@@ -4,21 +4,59 @@
 package main
 
 import (
+    "bytes"
     "context"
+    "database/sql"
     "fmt"
+    "io"
     "log"
+    "net"
     "net/http"
     "os"
     "os/signal"
     "syscall"
     "time"
 
+    "google.golang.org/grpc"             // v1.59.0
+    "google.golang.org/grpc/credentials" // v1.59.0
+
+    "validation-service/internal/api/graphqlapi"
+    "validation-service/internal/api/grpcapi"
     "validation-service/internal/api/router"
     "validation-service/internal/api/handlers"
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/audit"
+    "validation-service/internal/cache"
+    rediscache "validation-service/internal/cache/redis"
     "validation-service/internal/config"
+    "validation-service/internal/corpus"
+    "validation-service/internal/grammar"
+    "validation-service/internal/models"
+    "validation-service/internal/notify"
+    "validation-service/internal/platform"
+    "validation-service/internal/refdata"
+    "validation-service/internal/schedule"
+    "validation-service/internal/search"
+    bleveindex "validation-service/internal/search/bleve"
+    pgindex "validation-service/internal/search/postgres"
+    "validation-service/internal/services/harness"
+    "validation-service/internal/services/lint"
+    "validation-service/internal/services/migration"
+    "validation-service/internal/services/rescore"
+    "validation-service/internal/services/rulepack"
+    "validation-service/internal/services/scorepolicy"
     "validation-service/internal/services/validation"
+    "validation-service/internal/spool"
+    "validation-service/internal/storage"
+    "validation-service/internal/storage/encryption"
+    "validation-service/internal/storage/memory"
+    "validation-service/internal/storage/postgres"
+    "validation-service/internal/tenant"
+    "validation-service/pkg/httpclient"
     "validation-service/pkg/logger"
     "validation-service/pkg/metrics"
+    "validation-service/pkg/mitre"
+    "validation-service/pkg/tracing"
 )
 
 // Global constants for server configuration
@@ -31,6 +69,19 @@ const (
 
     // Server defaults
     defaultPort = 8080
+
+    // serviceVersion is reported on trace spans
+    serviceVersion = "1.0.0"
+
+    // configWatchInterval is how often the config file is checked for changes
+    configWatchInterval = 10 * time.Second
+
+    // maxMemoryNonces bounds the in-memory replay cache of signed requests
+    maxMemoryNonces = 100000
+
+    // maxMemoryIdempotentSize bounds the in-memory responses recorded for
+    // requests made with an Idempotency-Key header
+    maxMemoryIdempotentSize = 256 << 20
 )
 
 func main() {
@@ -48,6 +99,11 @@ func main() {
         )
     }
 
+    // Apply or dry-run schema migrations and exit
+    if len(os.Args) > 1 && os.Args[1] == "migrate" {
+        os.Exit(runMigrate(cfg, os.Args[2:], os.Stdout, os.Stderr))
+    }
+
     // Initialize metrics collector
     if cfg.MetricsEnabled {
         if err := metrics.InitMetrics(); err != nil {
@@ -58,37 +114,951 @@ func main() {
         log.Info("Metrics collection enabled")
     }
 
+    // Initialize distributed tracing
+    if cfg.Tracing.Enabled {
+        shutdownTracing, err := tracing.Init(context.Background(), tracing.Options{
+            ServiceName:    cfg.Tracing.ServiceName,
+            ServiceVersion: serviceVersion,
+            Environment:    cfg.Environment,
+            Endpoint:       cfg.Tracing.Endpoint,
+            Protocol:       cfg.Tracing.Protocol,
+            Insecure:       cfg.Tracing.Insecure,
+            SampleRatio:    cfg.Tracing.SampleRatio,
+        })
+        if err != nil {
+            log.Fatal("Failed to initialize tracing",
+                "error", err,
+            )
+        }
+        defer func() {
+            // Flush spans buffered during shutdown with a fresh deadline
+            ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+            defer cancel()
+            if err := shutdownTracing(ctx); err != nil {
+                log.Error("Failed to flush traces",
+                    "error", err,
+                )
+            }
+        }()
+        log.Info("Tracing enabled",
+            "endpoint", cfg.Tracing.Endpoint,
+            "protocol", cfg.Tracing.Protocol,
+            "sample_ratio", cfg.Tracing.SampleRatio,
+        )
+    }
+
+    // Outbound integrations share pooled connections, per-host circuit
+    // breakers and retry budgets
+    outbound := httpclient.New(httpclient.Options{
+        MaxIdleConns:        cfg.Outbound.MaxIdleConns,
+        MaxIdleConnsPerHost: cfg.Outbound.MaxIdleConnsPerHost,
+        IdleConnTimeout:     cfg.Outbound.IdleConnTimeout,
+        FailureThreshold:    cfg.Outbound.FailureThreshold,
+        OpenDuration:        cfg.Outbound.OpenDuration,
+        MaxRetries:          cfg.Outbound.MaxRetries,
+        RetryBackoff:        cfg.Outbound.RetryBackoff,
+        RetryRatio:          cfg.Outbound.RetryRatio,
+    })
+    defer outbound.CloseIdleConnections()
+
+    // Load the ATT&CK dataset technique references are checked against
+    var attack *mitre.Dataset
+    if cfg.Validation.AttackDatasetFile != "" {
+        attack, err = mitre.LoadFile(cfg.Validation.AttackDatasetFile)
+        if err != nil {
+            log.Fatal("Failed to load ATT&CK dataset",
+                "error", err,
+            )
+        }
+        log.Info("ATT&CK dataset loaded",
+            "version", attack.Version(),
+            "techniques", attack.Len(),
+        )
+    }
+
+    // Connect the result cache shared by service instances
+    var resultCache cache.ResultCache
+    if cfg.Cache.Enabled {
+        redisCache, err := rediscache.NewResultCache(context.Background(), cfg.Cache.RedisURL)
+        if err != nil {
+            log.Fatal("Failed to initialize result cache",
+                "error", err,
+            )
+        }
+        defer redisCache.Close()
+        resultCache = redisCache
+        log.Info("Result cache enabled",
+            "ttl", cfg.Cache.TTL,
+        )
+    }
+
+    // Record the responses of requests made with an Idempotency-Key header,
+    // shared by service instances through Redis when the cache is enabled
+    var idempotencyStore cache.IdempotencyStore = cache.NewMemoryIdempotencyStore(maxMemoryIdempotentSize)
+    if cfg.Cache.Enabled {
+        redisIdempotency, err := rediscache.NewIdempotencyStore(context.Background(), cfg.Cache.RedisURL)
+        if err != nil {
+            log.Fatal("Failed to initialize idempotency store",
+                "error", err,
+            )
+        }
+        defer redisIdempotency.Close()
+        idempotencyStore = redisIdempotency
+    }
+    idempotency := apimiddleware.NewIdempotency(idempotencyStore, cfg.Cache.IdempotencyTTL)
+
+    // Lint content with the configured profiles unless disabled
+    var linter *lint.Linter
+    if !cfg.Validation.Lint.Disabled {
+        linter, err = lint.NewLinter(cfg.Validation.Lint)
+        if err != nil {
+            log.Fatal("Failed to initialize lint profiles",
+                "error", err,
+            )
+        }
+    }
+
+    // Raise logging of unhealthy formats and routes to debug during incidents
+    var adaptiveLogging *logger.AdaptiveDebug
+    if adaptive := cfg.Monitoring.AdaptiveLogging; adaptive.Enabled {
+        adaptiveLogging = logger.NewAdaptiveDebug(logger.AdaptiveConfig{
+            Window:        adaptive.Window,
+            MinRequests:   adaptive.MinRequests,
+            ErrorRate:     adaptive.ErrorRateThreshold,
+            LatencySLO:    adaptive.LatencySLO,
+            SlowRate:      adaptive.SlowRateThreshold,
+            BoostDuration: adaptive.BoostDuration,
+            MaxBoosts:     adaptive.MaxBoostedScopes,
+        })
+        log.Info("Adaptive debug logging enabled",
+            "error_rate_threshold", adaptive.ErrorRateThreshold,
+            "latency_slo", adaptive.LatencySLO,
+            "boost_duration", adaptive.BoostDuration,
+        )
+    }
+
+    // Judge validations with the validation profile each request selects
+    profiles, err := validation.NewProfiles(cfg.Validation)
+    if err != nil {
+        log.Fatal("Failed to initialize validation profiles",
+            "error", err,
+        )
+    }
+
     // Initialize validation service
+    scoring := validation.NewScoringPolicies(cfg.Validation.Scoring)
+    scoring.SetProfiles(profiles)
     validationService := validation.NewValidationService(validation.ValidationConfig{
         EnableDetailedFeedback: true,
         ValidationTimeout:     cfg.Validation.ValidationTimeout,
         StrictMode:           cfg.Validation.StrictValidation,
         MetricsEnabled:       cfg.MetricsEnabled,
+        Attack:               attack,
+        Cache:                resultCache,
+        CacheTTL:             cfg.Cache.TTL,
+        StaleWhileRevalidate: cfg.Cache.StaleWhileRevalidate,
+        StaleTTL:             cfg.Cache.StaleTTL,
+        Linter:               linter,
+        Scoring:              scoring,
+        Profiles:             profiles,
+        GrammarParsing:       cfg.Validation.GrammarParsing,
+        QRadarVersion:        cfg.Validation.QRadarVersion,
+        AdaptiveLogging:      adaptiveLogging,
+    })
+
+    if cfg.Validation.GrammarParsing && len(grammar.Formats()) == 0 {
+        log.Warn("Grammar parsing is enabled but the service was built without the antlr tag; targets are not parsed")
+    }
+
+    // Register format validators
+    if err := validationService.RegisterBuiltinValidators(); err != nil {
+        log.Fatal("Failed to register validators",
+            "error", err,
+        )
+    }
+
+    // Register external validator plugins
+    for _, pluginCfg := range cfg.Validation.Plugins {
+        plugin, err := validation.LoadPlugin(validation.PluginConfig{
+            Type: pluginCfg.Type,
+            Path: pluginCfg.Path,
+            Args: pluginCfg.Args,
+        }, validation.PluginOptions{StrictMode: cfg.Validation.StrictValidation})
+        if err != nil {
+            log.Fatal("Failed to load validator plugin",
+                "error", err,
+                "path", pluginCfg.Path,
+            )
+        }
+        if closer, ok := plugin.(io.Closer); ok {
+            defer closer.Close()
+        }
+        if err := validationService.RegisterFormatValidator(plugin); err != nil {
+            log.Fatal("Failed to register validator plugin",
+                "error", err,
+                "path", pluginCfg.Path,
+            )
+        }
+        metrics.AllowFormats(plugin.Describe().Format)
+        log.Info("Validator plugin loaded",
+            "format", plugin.Describe().Format,
+            "type", pluginCfg.Type,
+            "path", pluginCfg.Path,
+        )
+    }
+
+    // Check the fields of Sigma rules against the Sigma taxonomy
+    sigmaTaxonomy, err := validation.LoadSigmaTaxonomy(cfg.Validation.SigmaTaxonomyFile)
+    if err != nil {
+        log.Fatal("Failed to load Sigma taxonomy",
+            "error", err,
+        )
+    }
+    validationService.SetSigmaTaxonomy(sigmaTaxonomy)
+
+    // Check the organization's rule packs after the format validators
+    var rulePacks *rulepack.Manager
+    if cfg.Validation.RulePacksDir != "" {
+        rulePacks = rulepack.NewManager(cfg.Validation.RulePacksDir, validationService.SetRulePacks)
+        if err := rulePacks.Reload(); err != nil {
+            log.Fatal("Failed to load rule packs",
+                "error", err,
+                "dir", cfg.Validation.RulePacksDir,
+            )
+        }
+        log.Info("Rule packs loaded",
+            "packs", rulepack.Names(rulePacks.Packs()),
+        )
+    }
+
+    // Register cross-format validators
+    fieldMappings, err := validation.LoadFieldMappingTable(cfg.Validation.FieldMappingFile)
+    if err != nil {
+        log.Fatal("Failed to load field mapping table",
+            "error", err,
+        )
+    }
+    fieldMappingValidator := validation.NewSigmaSplunkFieldMappingValidator(fieldMappings)
+    if err := validationService.RegisterCrossFormatValidator(
+        models.DetectionFormatSigma,
+        models.DetectionFormatSplunk,
+        fieldMappingValidator,
+    ); err != nil {
+        log.Fatal("Failed to register field mapping validator",
+            "error", err,
+        )
+    }
+
+    // Suggest backend options for queries translated from Sigma
+    for _, target := range validation.BackendHintTargets {
+        if err := validationService.RegisterCrossFormatValidator(
+            models.DetectionFormatSigma,
+            target,
+            validation.NewSigmaBackendHintValidator(target),
+        ); err != nil {
+            log.Fatal("Failed to register backend hint validator",
+                "error", err,
+                "target_format", target,
+            )
+        }
+    }
+
+    // Report Sigma constructs the target backend cannot translate
+    for _, target := range validation.SigmaCompatibilityTargets {
+        if err := validationService.RegisterCrossFormatValidator(
+            models.DetectionFormatSigma,
+            target,
+            validation.NewSigmaCompatibilityValidator(target),
+        ); err != nil {
+            log.Fatal("Failed to register sigma compatibility validator",
+                "error", err,
+                "target_format", target,
+            )
+        }
+    }
+
+    // Submit targets to the query planners of their platforms
+    for _, platformCfg := range cfg.Validation.Platforms {
+        connector, err := platform.New(platformCfg, outbound.Client("platform."+platformCfg.Name, platformCfg.Timeout))
+        if err != nil {
+            log.Fatal("Failed to create platform connector",
+                "error", err,
+                "connector", platformCfg.Name,
+            )
+        }
+        if err := validationService.RegisterPlatformConnector(connector); err != nil {
+            log.Fatal("Failed to register platform connector",
+                "error", err,
+                "connector", platformCfg.Name,
+            )
+        }
+    }
+
+    // Apply reloaded settings. The config file is watched for changes and
+    // SIGHUP forces a reload.
+    config.OnReload(func(reloaded *config.Config) {
+        if err := logger.SetLevel(reloaded.LogLevel); err != nil {
+            log.Error("Failed to apply log level",
+                "error", err,
+            )
+        }
+        if err := validationService.ApplySettings(validation.Settings{
+            ValidationTimeout: reloaded.Validation.ValidationTimeout,
+            StrictMode:        reloaded.Validation.StrictValidation,
+        }); err != nil {
+            log.Error("Failed to apply validation settings",
+                "error", err,
+            )
+        }
+    })
+    watchCtx, stopWatch := context.WithCancel(context.Background())
+    defer stopWatch()
+    if configFile := config.FilePath(); configFile != "" {
+        go config.NewWatcher(configFile, configWatchInterval).Run(watchCtx)
+    }
+
+    var db *sql.DB
+    if cfg.Storage.Backend == config.StorageBackendPostgres || cfg.Search.Backend == config.SearchBackendPostgres {
+        db, err = postgres.Open(context.Background(), cfg.Database)
+        if err != nil {
+            log.Fatal("Failed to connect to database",
+                "error", err,
+            )
+        }
+        defer db.Close()
+
+        // Bring the schema up to date before the stores use it
+        if err := prepareSchema(context.Background(), cfg.Database, db); err != nil {
+            log.Fatal("Failed to prepare database schema",
+                "error", err,
+                "migration_mode", cfg.Database.MigrationMode,
+            )
+        }
+    }
+
+    // Initialize validation result store
+    var resultStore storage.ResultStore = memory.NewResultStore()
+    if cfg.Storage.Backend == config.StorageBackendPostgres {
+        resultStore, err = postgres.NewResultStore(context.Background(), db)
+        if err != nil {
+            log.Fatal("Failed to initialize result store",
+                "error", err,
+            )
+        }
+    }
+
+    // Initialize tenant translation memory
+    var translationMemory storage.TranslationMemoryStore = memory.NewTranslationMemoryStore()
+    if cfg.Storage.Backend == config.StorageBackendPostgres {
+        translationMemory, err = postgres.NewTranslationMemoryStore(context.Background(), db)
+        if err != nil {
+            log.Fatal("Failed to initialize translation memory",
+                "error", err,
+            )
+        }
+    }
+
+    // Initialize notification subscriptions and digest queues
+    var notificationStore storage.NotificationStore = memory.NewNotificationStore()
+    if cfg.Storage.Backend == config.StorageBackendPostgres {
+        notificationStore, err = postgres.NewNotificationStore(context.Background(), db)
+        if err != nil {
+            log.Fatal("Failed to initialize notification store",
+                "error", err,
+            )
+        }
+    }
+
+    // Initialize recurring job schedules
+    var scheduleStore storage.ScheduleStore = memory.NewScheduleStore()
+    if cfg.Storage.Backend == config.StorageBackendPostgres {
+        scheduleStore, err = postgres.NewScheduleStore(context.Background(), db)
+        if err != nil {
+            log.Fatal("Failed to initialize schedule store",
+                "error", err,
+            )
+        }
+    }
+
+    // Initialize rule collections of stored detections
+    var collectionStore storage.CollectionStore = memory.NewCollectionStore()
+    if cfg.Storage.Backend == config.StorageBackendPostgres {
+        collectionStore, err = postgres.NewCollectionStore(context.Background(), db)
+        if err != nil {
+            log.Fatal("Failed to initialize collection store",
+                "error", err,
+            )
+        }
+    }
+
+    // Initialize tenant scoring policies, applied after the severity weights
+    var scoringPolicyStore storage.ScoringPolicyStore = memory.NewScoringPolicyStore()
+    if cfg.Storage.Backend == config.StorageBackendPostgres {
+        scoringPolicyStore, err = postgres.NewScoringPolicyStore(context.Background(), db)
+        if err != nil {
+            log.Fatal("Failed to initialize scoring policy store",
+                "error", err,
+            )
+        }
+    }
+    scoringPolicies := scorepolicy.NewEvaluator(scoringPolicyStore, cfg.Validation.Scoring)
+    scoring.SetTenantScorer(scoringPolicies)
+
+    // Initialize tenant custom log source schemas, merged with the built-in
+    // field catalogs
+    var customSchemaStore storage.CustomSchemaStore = memory.NewCustomSchemaStore()
+    if cfg.Storage.Backend == config.StorageBackendPostgres {
+        customSchemaStore, err = postgres.NewCustomSchemaStore(context.Background(), db)
+        if err != nil {
+            log.Fatal("Failed to initialize custom schema store",
+                "error", err,
+            )
+        }
+    }
+    validationService.SetCustomSchemas(customSchemaStore)
+
+    // Initialize detection store with deduplicated rule content. Detections
+    // are held in memory, so their content blobs and data keys are as well;
+    // the PostgreSQL stores are used once detections are persisted alongside
+    // them.
+    blobStore := memory.NewBlobStore()
+    var rotator handlers.KeyRotator
+    if cfg.Storage.EncryptContent {
+        masterKey, err := encryption.ParseMasterKey(cfg.Security.EncryptionKey)
+        if err != nil {
+            log.Fatal("Invalid encryption key",
+                "error", err,
+            )
+        }
+        keyring, err := encryption.NewKeyring(memory.NewKeyStore(), masterKey)
+        if err != nil {
+            log.Fatal("Failed to initialize content encryption",
+                "error", err,
+            )
+        }
+        blobStore.SetCipher(keyring)
+        rotator = encryption.NewRotator(keyring, blobStore)
+        log.Info("Content encryption at rest enabled")
+    }
+    var detectionStore storage.DetectionStore = storage.NewContentAddressedDetectionStore(
+        memory.NewDetectionStore(),
+        blobStore,
+        storage.SharingPolicy{SharedLicenses: cfg.Storage.SharedLicenses},
+    )
+    gcCtx, stopGC := context.WithCancel(context.Background())
+    defer stopGC()
+    go runBlobGarbageCollector(gcCtx, blobStore, cfg.Storage.BlobGCInterval, cfg.Storage.BlobGCGracePeriod)
+
+    // Initialize search index
+    var reindexer handlers.SearchReindexer
+    index, err := openSearchIndex(cfg, db)
+    if err != nil {
+        log.Fatal("Failed to open search index",
+            "error", err,
+            "backend", cfg.Search.Backend,
+        )
+    }
+    if index != nil {
+        defer index.Close()
+        indexedStore := search.NewIndexedStore(detectionStore, index)
+        detectionStore = indexedStore
+        reindexer = indexedStore
+        log.Info("Search index enabled",
+            "backend", cfg.Search.Backend,
+        )
+    }
+
+    // Initialize upload spooling for archive uploads
+    spooler, err := spool.New(spool.Config{
+        Dir:             cfg.Upload.SpoolDir,
+        MemoryThreshold: cfg.Upload.MemoryThreshold,
+        MaxSize:         cfg.Upload.MaxSize,
+        TenantQuota:     cfg.Upload.TenantDiskQuota,
+        TotalQuota:      cfg.Upload.TotalDiskQuota,
     })
+    if err != nil {
+        log.Fatal("Failed to initialize upload spool",
+            "error", err,
+            "dir", cfg.Upload.SpoolDir,
+        )
+    }
+    detectionHandler := handlers.NewDetectionHandler(detectionStore)
+    detectionHandler.SetSpooler(spooler)
+    detectionHandler.SetValidator(validationService, resultStore)
 
     // Initialize validation handler
     validationHandler := handlers.NewValidationHandler(validationService)
+    validationHandler.SetResultStore(resultStore)
+    validationHandler.SetTranslationMemory(translationMemory)
+    validationHandler.SetCollections(collectionStore, detectionStore)
+    validationHandler.SetHarnessLimits(harness.Limits{
+        MaxEvents:    cfg.Harness.MaxEvents,
+        MaxEventSize: cfg.Harness.MaxEventSize,
+    }, cfg.Harness.Timeout)
+
+    // Persist, publish and stream results of stale cache entries that
+    // changed when validated again
+    validationService.SetRevalidationListener(validationHandler.Revalidated)
+
+    // Record who validated what in the hash-chained audit log
+    var auditLog *audit.Logger
+    if cfg.Security.EnableAuditLog {
+        auditLog, err = audit.Open(audit.Config{
+            Path:       cfg.Security.AuditLogPath,
+            MaxSize:    cfg.Security.AuditLogMaxSize,
+            MaxBackups: cfg.Security.AuditLogMaxBackups,
+        })
+        switch {
+        case err != nil && cfg.Environment == config.EnvProduction:
+            log.Fatal("Failed to open audit log",
+                "error", err,
+                "path", cfg.Security.AuditLogPath,
+            )
+        case err != nil:
+            log.Warn("Failed to open audit log, validations are not audited",
+                "error", err,
+                "path", cfg.Security.AuditLogPath,
+            )
+        default:
+            defer auditLog.Close()
+            validationHandler.SetAuditLog(auditLog)
+            log.Info("Audit log enabled",
+                "path", cfg.Security.AuditLogPath,
+            )
+        }
+    }
+
+    // Email reports, job summaries and notifications when an SMTP server is
+    // configured
+    var emailSender *notify.EmailSender
+    if cfg.Notifications.Email.Enabled() {
+        emailSender = notify.NewEmailSender(cfg.Notifications.Email, cfg.Notifications.DeliveryTimeout)
+        log.Info("Email delivery enabled",
+            "smtp_host", cfg.Notifications.Email.Host,
+            "tls", cfg.Notifications.Email.TLS,
+        )
+    }
+
+    // Deliver validation events to notification subscribers
+    var notifier handlers.Notifier
+    if cfg.Notifications.Enabled {
+        dispatcher := notify.NewDispatcher(notificationStore, cfg.Notifications)
+        dispatcher.RegisterSender(storage.ChannelWebhook, notify.NewWebhookSender(outbound.PublicClient("webhook", cfg.Notifications.DeliveryTimeout)))
+        dispatcher.RegisterSender(storage.ChannelSlack, notify.NewSlackSender(outbound.PublicClient("slack", cfg.Notifications.DeliveryTimeout)))
+        if emailSender != nil {
+            dispatcher.RegisterSender(storage.ChannelEmail, emailSender)
+        }
+        notifyCtx, stopNotify := context.WithCancel(context.Background())
+        defer stopNotify()
+        go dispatcher.Run(notifyCtx)
+        validationHandler.SetNotifier(dispatcher)
+        notifier = dispatcher
+        log.Info("Notifications enabled",
+            "daily_digest_hour", cfg.Notifications.DailyDigestHour,
+        )
+    }
+
+    // Initialize admin handler
+    adminHandler := handlers.NewAdminHandler(reindexer)
+    adminHandler.SetBlobStore(blobStore, cfg.Storage.BlobGCGracePeriod)
+    if rulePacks != nil {
+        adminHandler.SetRulePacks(rulePacks)
+    }
+    if auditLog != nil {
+        adminHandler.SetAuditLog(auditLog)
+    }
+    if rotator != nil {
+        adminHandler.SetKeyRotator(rotator)
+    }
+
+    // Import community rule repositories into the corpus tenant on demand
+    importer, err := corpus.NewImporter(detectionStore, cfg.Corpus, cfg.Validation.MaxRuleSize,
+        outbound.Client("corpus", cfg.Corpus.DownloadTimeout))
+    if err != nil {
+        log.Fatal("Failed to initialize corpus importer",
+            "error", err,
+        )
+    }
+    adminHandler.SetCorpusImporter(importer)
+    if emailSender != nil {
+        importer.SetCompletionHook(func(status corpus.ImportStatus) {
+            summary := &notify.JobSummary{
+                Job:        "corpus import",
+                StartedAt:  *status.StartedAt,
+                FinishedAt: *status.FinishedAt,
+                Counts:     make(map[string]int),
+            }
+            for _, source := range status.Sources {
+                summary.Counts["imported"] += source.Imported
+                summary.Counts["unchanged"] += source.Unchanged
+                summary.Counts["superseded"] += source.Superseded
+                summary.Counts["removed"] += source.Removed
+                summary.Counts["skipped"] += source.Skipped
+                if source.Error != "" {
+                    summary.Errors = append(summary.Errors, source.Name+": "+source.Error)
+                }
+            }
+            sendJobSummary(emailSender, summary)
+        })
+    }
+
+    // Resolve tenants from token claims and enforce their policies
+    tenants := tenant.NewRegistry(cfg.Tenancy)
+    log.Info("Tenancy configured",
+        "require_claim", cfg.Tenancy.RequireClaim,
+        "tenant_policies", len(cfg.Tenancy.Tenants),
+    )
+
+    // Re-score stored results after scoring policy changes on demand
+    rescorer := rescore.NewRescorer(resultStore, scoring, tenants)
+    if emailSender != nil {
+        rescorer.SetCompletionHook(func(status rescore.Status) {
+            job := "result re-scoring"
+            if status.Options.DryRun {
+                job += " (dry run)"
+            }
+            sendJobSummary(emailSender, &notify.JobSummary{
+                Job:        job,
+                TenantID:   status.Options.TenantID,
+                StartedAt:  *status.StartedAt,
+                FinishedAt: *status.FinishedAt,
+                Counts: map[string]int{
+                    "scanned":   status.Scanned,
+                    "rescored":  status.Rescored,
+                    "unchanged": status.Unchanged,
+                    "failed":    status.Failed,
+                },
+                Errors: status.Errors,
+            })
+        })
+    }
+    adminHandler.SetResultRescorer(rescorer)
+
+    // Run the recurring jobs tenants schedule
+    if cfg.Schedules.Enabled {
+        scheduler := schedule.NewScheduler(scheduleStore, cfg.Schedules, tenants)
+        revalidator := schedule.NewRevalidator(detectionStore, resultStore, validationService)
+        revalidator.SetCollections(collectionStore)
+        scheduler.RegisterJob(storage.JobRevalidate, revalidator)
+        scheduler.RegisterJob(storage.JobRescore, schedule.RescoreJob(rescorer))
+        scheduler.RegisterJob(storage.JobReview, schedule.NewReviewChecker(detectionStore, notifier))
+        scheduleCtx, stopSchedules := context.WithCancel(context.Background())
+        defer stopSchedules()
+        go scheduler.Run(scheduleCtx)
+        log.Info("Scheduler enabled",
+            "max_per_tenant", cfg.Schedules.MaxPerTenant,
+            "run_timeout", cfg.Schedules.RunTimeout,
+        )
+    }
+
+    // Guide tenants through migrating rule repositories to other formats;
+    // runs translate with the translation service when it is configured
+    var migrationStore storage.MigrationWizardStore = memory.NewMigrationWizardStore()
+    if cfg.Storage.Backend == config.StorageBackendPostgres {
+        migrationStore, err = postgres.NewMigrationWizardStore(context.Background(), db)
+        if err != nil {
+            log.Fatal("Failed to initialize migration wizard store",
+                "error", err,
+            )
+        }
+    }
+    migrationHandler := handlers.NewMigrationHandler(migrationStore, detectionStore, spooler, cfg.Migrations)
+    if cfg.Migrations.TranslationURL != "" {
+        translator := migration.NewTranslator(cfg.Migrations.TranslationURL, outbound.Client("translation", cfg.Migrations.TranslationTimeout))
+        migrationHandler.SetRunner(migration.NewRunner(migrationStore, translator, validationService, resultStore, tenants, cfg.Migrations))
+        log.Info("Migration wizard runs enabled",
+            "translation_url", cfg.Migrations.TranslationURL,
+            "run_timeout", cfg.Migrations.RunTimeout,
+        )
+    }
+
+    // Reload reference datasets from their URLs or files without a restart
+    docsHandler := handlers.NewDocsHandler(attack)
+    disambiguationHandler := handlers.NewDisambiguationHandler(fieldMappings)
+    refresher := refdata.NewRefresher(cfg.RefData, outbound.Client("refdata", cfg.RefData.DownloadTimeout))
+    if source := refDataSource(cfg.RefData, config.RefDataAttack, cfg.Validation.AttackDatasetFile); source != "" {
+        attackVersion := ""
+        if attack != nil {
+            attackVersion = attack.Version()
+        }
+        refresher.Register(config.RefDataAttack, source, attackVersion, func(data []byte) (string, error) {
+            dataset, err := mitre.Load(bytes.NewReader(data))
+            if err != nil {
+                return "", err
+            }
+            validationService.SetAttackDataset(dataset)
+            docsHandler.SetAttackDataset(dataset)
+            return dataset.Version(), nil
+        })
+    }
+    if source := refDataSource(cfg.RefData, config.RefDataFieldMappings, cfg.Validation.FieldMappingFile); source != "" {
+        refresher.Register(config.RefDataFieldMappings, source, fieldMappings.Version, func(data []byte) (string, error) {
+            table, err := validation.ParseFieldMappingTable(data)
+            if err != nil {
+                return "", err
+            }
+            fieldMappingValidator.SetTable(table)
+            disambiguationHandler.SetTable(table)
+            return table.Version, nil
+        })
+    }
+    if source := refDataSource(cfg.RefData, config.RefDataSigmaTaxonomy, cfg.Validation.SigmaTaxonomyFile); source != "" {
+        refresher.Register(config.RefDataSigmaTaxonomy, source, sigmaTaxonomy.Version, func(data []byte) (string, error) {
+            taxonomy, err := validation.ParseSigmaTaxonomy(data)
+            if err != nil {
+                return "", err
+            }
+            validationService.SetSigmaTaxonomy(taxonomy)
+            return taxonomy.Version, nil
+        })
+    }
+    refresher.SetUpdateHook(func(status refdata.DatasetStatus) {
+        validationService.SetReferenceDataDigest(status.Name, status.Digest)
+    })
+    adminHandler.SetRefDataReloader(refresher)
+    if len(cfg.RefData.URLs) > 0 {
+        // Datasets with a URL are fetched at startup, since they may be
+        // newer than the configured files
+        if err := refresher.StartReload(nil); err != nil {
+            log.Warn("Failed to start reference data refresh",
+                "error", err,
+            )
+        }
+        refreshCtx, stopRefresh := context.WithCancel(context.Background())
+        defer stopRefresh()
+        go refresher.Run(refreshCtx)
+        log.Info("Reference data refresh enabled",
+            "datasets", len(cfg.RefData.URLs),
+            "refresh_interval", cfg.RefData.RefreshInterval,
+        )
+    }
+
+    // Announce deprecated routes and track the clients still calling them
+    var deprecations *apimiddleware.DeprecationTracker
+    if len(cfg.Deprecation.Routes) > 0 {
+        deprecations = apimiddleware.NewDeprecationTracker(cfg.Deprecation.Routes)
+        adminHandler.SetDeprecationReporter(deprecations)
+    }
+
+    // Mint short-lived CI tokens when a signing key is configured
+    var tokenExchange *handlers.TokenExchangeHandler
+    if cfg.Security.TokenSigningKeyFile != "" {
+        issuer, err := apimiddleware.NewTokenIssuer(cfg.Security.TokenSigningKeyFile, cfg.Security.TokenExchangeMaxTTL)
+        if err != nil {
+            log.Fatal("Failed to initialize token exchange",
+                "error", err,
+            )
+        }
+        tokenExchange = handlers.NewTokenExchangeHandler(issuer)
+    }
+
+    // Accept tokens of the OIDC identity provider for interactive users
+    if cfg.Security.OIDC.IssuerURL != "" {
+        discoveryCtx, cancelDiscovery := context.WithTimeout(context.Background(), 30*time.Second)
+        _, err := apimiddleware.NewOIDCVerifier(discoveryCtx, cfg.Security.OIDC, outbound.Client("oidc", 10*time.Second))
+        cancelDiscovery()
+        if err != nil {
+            log.Fatal("Failed to initialize OIDC",
+                "error", err,
+                "issuer", cfg.Security.OIDC.IssuerURL,
+            )
+        }
+        log.Info("OIDC provider configured",
+            "issuer", cfg.Security.OIDC.IssuerURL,
+        )
+    }
+
+    // Verify service tokens with the keys published by their issuer
+    if cfg.Security.JWT.IssuerURL != "" {
+        discoveryCtx, cancelDiscovery := context.WithTimeout(context.Background(), 30*time.Second)
+        _, err := apimiddleware.NewServiceTokenVerifier(discoveryCtx, cfg.Security.JWT, outbound.Client("jwks", 10*time.Second))
+        cancelDiscovery()
+        if err != nil {
+            log.Fatal("Failed to initialize JWT verification",
+                "error", err,
+                "issuer", cfg.Security.JWT.IssuerURL,
+            )
+        }
+        log.Info("JWT issuer configured",
+            "issuer", cfg.Security.JWT.IssuerURL,
+            "key_refresh_interval", cfg.Security.JWT.KeyRefreshInterval,
+        )
+    } else {
+        log.Warn("No JWT issuer configured; service tokens are rejected")
+    }
+
+    // Restrict source addresses per route group
+    networkPolicies, err := apimiddleware.NewNetworkPolicies(cfg.NetworkPolicy)
+    if err != nil {
+        log.Fatal("Failed to initialize network policies",
+            "error", err,
+        )
+    }
+
+    // Accept HMAC-signed requests from integrations, rejecting replays
+    var signedRequests *apimiddleware.SignedRequests
+    if signing := cfg.Security.RequestSigning; len(signing.Keys) > 0 {
+        var nonces cache.NonceStore = cache.NewMemoryNonceStore(maxMemoryNonces)
+        if signing.ReplayCache == config.ReplayCacheRedis {
+            redisNonces, err := rediscache.NewNonceStore(context.Background(), cfg.Cache.RedisURL)
+            if err != nil {
+                log.Fatal("Failed to initialize replay cache",
+                    "error", err,
+                )
+            }
+            defer redisNonces.Close()
+            nonces = redisNonces
+        }
+        signedRequests, err = apimiddleware.NewSignedRequests(signing, nonces)
+        if err != nil {
+            log.Fatal("Failed to initialize request signing",
+                "error", err,
+            )
+        }
+        log.Info("Request signing configured",
+            "keys", len(signing.Keys),
+            "max_clock_skew", signing.MaxClockSkew,
+            "replay_cache", signing.ReplayCache,
+        )
+    }
+
+    // Serve the API and gRPC listeners over TLS, verifying client
+    // certificates when a client CA is configured
+    var serverTLS *apimiddleware.ServerTLS
+    if cfg.Security.TLS.CertFile != "" {
+        serverTLS, err = apimiddleware.NewServerTLS(cfg.Security.TLS)
+        if err != nil {
+            log.Fatal("Failed to initialize TLS",
+                "error", err,
+            )
+        }
+        tlsCtx, stopTLS := context.WithCancel(context.Background())
+        defer stopTLS()
+        go serverTLS.Run(tlsCtx)
+        log.Info("TLS configured",
+            "mutual_tls", serverTLS.MutualTLS(),
+            "client_auth", cfg.Security.TLS.ClientAuth,
+            "allowed_sans", len(cfg.Security.TLS.AllowedSANs),
+            "reload_interval", cfg.Security.TLS.ReloadInterval,
+        )
+    }
+
+    resultHandler := handlers.NewResultHandler(resultStore)
+    if emailSender != nil {
+        resultHandler.SetReportMailer(emailSender)
+    }
+
+    graphqlServer, err := graphqlapi.NewServer(validationService, resultStore, detectionStore)
+    if err != nil {
+        log.Fatal("Failed to initialize GraphQL API",
+            "error", err,
+        )
+    }
+    graphqlServer.SetTranslationMemory(translationMemory)
+    graphqlServer.SetAuditLog(auditLog)
 
     // Initialize router with middleware
-    router := router.NewRouter(validationHandler)
+    apiRouter := router.NewRouter(router.Handlers{
+        Validation:        validationHandler,
+        Detections:        detectionHandler,
+        Collections:       handlers.NewCollectionHandler(collectionStore, detectionStore),
+        Results:           resultHandler,
+        TranslationMemory: handlers.NewTranslationMemoryHandler(translationMemory),
+        Disambiguation:    disambiguationHandler,
+        TokenExchange:     tokenExchange,
+        Docs:              docsHandler,
+        Notifications:     handlers.NewNotificationHandler(notificationStore),
+        Schedules:         handlers.NewScheduleHandler(scheduleStore, cfg.Schedules),
+        ScoringPolicy:     handlers.NewScoringPolicyHandler(scoringPolicyStore, scoringPolicies),
+        CustomSchemas:     handlers.NewCustomSchemaHandler(customSchemaStore),
+        Migrations:        migrationHandler,
+        GraphQL:           graphqlServer,
+        Deprecations:      deprecations,
+        Network:           networkPolicies,
+        Signatures:        signedRequests,
+        AdaptiveLogging:   adaptiveLogging,
+        Tenants:           tenants,
+        Idempotency:       idempotency,
+        TLS:               serverTLS,
+    })
 
     // Configure and create HTTP server
-    server := setupServer(cfg, router)
+    server := setupServer(cfg, apiRouter, serverTLS)
+
+    // Serve metrics, probes, pprof and admin endpoints on the monitoring port
+    opsServer := setupOpsServer(cfg, router.NewOpsRouter(router.OpsHandlers{
+        Admin:           adminHandler,
+        Autoscaling:     handlers.NewAutoscalingHandler(validationService, cfg.Monitoring.AutoscalingTargetConcurrency),
+        MetricsEndpoint: cfg.Monitoring.MetricsEndpoint,
+        Profiling:       cfg.Monitoring.ProfilingEnabled,
+        Network:         networkPolicies,
+        Signatures:      signedRequests,
+    }))
+    go func() {
+        log.Info("Starting ops listener",
+            "address", opsServer.Addr,
+        )
+
+        if err := opsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+            log.Fatal("Ops server failed",
+                "error", err,
+            )
+        }
+    }()
 
     // Start server in a goroutine
     go func() {
         log.Info("Starting validation service",
             "address", server.Addr,
             "env", cfg.Environment,
+            "tls", server.TLSConfig != nil,
         )
 
-        if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+        var err error
+        if server.TLSConfig != nil {
+            // The certificate is served by the TLS configuration
+            err = server.ListenAndServeTLS("", "")
+        } else {
+            err = server.ListenAndServe()
+        }
+        if err != nil && err != http.ErrServerClosed {
             log.Fatal("Server failed",
                 "error", err,
             )
         }
     }()
 
+    // Start the gRPC API alongside HTTP
+    var grpcServer *grpc.Server
+    if cfg.GRPC.Enabled {
+        listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", cfg.ServerHost, cfg.GRPC.Port))
+        if err != nil {
+            log.Fatal("Failed to listen for gRPC",
+                "error", err,
+                "port", cfg.GRPC.Port,
+            )
+        }
+        grpcService := grpcapi.NewServer(validationService, resultStore)
+        grpcService.SetTranslationMemory(translationMemory)
+        grpcService.SetTenants(tenants)
+        grpcService.SetAuditLog(auditLog)
+        var grpcOptions []grpc.ServerOption
+        if serverTLS != nil {
+            grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(serverTLS.Config())))
+        }
+        grpcServer = grpcapi.NewGRPCServer(grpcService, grpcOptions...)
+        go func() {
+            log.Info("Starting gRPC API",
+                "address", listener.Addr().String(),
+            )
+            if err := grpcServer.Serve(listener); err != nil {
+                log.Fatal("gRPC server failed",
+                    "error", err,
+                )
+            }
+        }()
+    }
+
     // Set up signal handling for graceful shutdown
     quit := make(chan os.Signal, 1)
     signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
@@ -103,20 +1073,94 @@ func main() {
     ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
     defer cancel()
 
-    // Perform graceful shutdown
+    // Perform graceful shutdown. Readiness fails first so no new traffic is
+    // routed here; the ops listener stops last so probes and metrics stay
+    // available while the API listeners drain.
+    handlers.MarkDraining()
+    if grpcServer != nil {
+        stopGRPC(ctx, grpcServer)
+    }
     if err := gracefulShutdown(ctx, server); err != nil {
         log.Error("Server shutdown failed",
             "error", err,
         )
         os.Exit(1)
     }
+    if err := opsServer.Shutdown(ctx); err != nil {
+        log.Error("Ops server shutdown failed",
+            "error", err,
+        )
+    }
 
     log.Info("Server shutdown completed successfully")
 }
 
-// setupServer configures and creates the HTTP server with proper timeouts and settings
-func setupServer(cfg *config.Config, handler http.Handler) *http.Server {
-    return &http.Server{
+// openSearchIndex opens the configured search index backend. The memory
+// backend has no index and returns nil.
+func openSearchIndex(cfg *config.Config, db *sql.DB) (search.Index, error) {
+    switch cfg.Search.Backend {
+    case config.SearchBackendBleve:
+        return bleveindex.Open(cfg.Search.IndexPath)
+    case config.SearchBackendPostgres:
+        return pgindex.New(context.Background(), db)
+    default:
+        return nil, nil
+    }
+}
+
+// refDataSource returns the URL a reference dataset is refreshed from, or
+// its configured file when it has no URL; empty when it has neither
+func refDataSource(cfg config.RefDataConfig, name, file string) string {
+    if url := cfg.URLs[name]; url != "" {
+        return url
+    }
+    return file
+}
+
+// runBlobGarbageCollector periodically deletes content blobs that are no
+// longer referenced by any detection, until ctx is cancelled
+func runBlobGarbageCollector(ctx context.Context, blobs storage.BlobStore, interval, grace time.Duration) {
+    log := logger.GetLogger()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            deleted, err := blobs.CollectGarbage(ctx, grace)
+            if err != nil {
+                log.Error("Blob garbage collection failed",
+                    "error", err,
+                )
+                continue
+            }
+            if deleted > 0 {
+                log.Info("Collected unreferenced content blobs",
+                    "deleted", deleted,
+                )
+            }
+        }
+    }
+}
+
+// sendJobSummary emails the summary of a finished background job, logging
+// delivery failures
+func sendJobSummary(sender *notify.EmailSender, summary *notify.JobSummary) {
+    if err := sender.SendJobSummary(context.Background(), summary); err != nil {
+        logger.GetLogger().Error("Failed to email job summary",
+            "error", err,
+            "job", summary.Job,
+            "tenant_id", summary.TenantID,
+        )
+    }
+}
+
+// setupServer configures and creates the HTTP server with proper timeouts and
+// settings, serving TLS when serverTLS is set
+func setupServer(cfg *config.Config, handler http.Handler, serverTLS *apimiddleware.ServerTLS) *http.Server {
+    server := &http.Server{
         Addr:    fmt.Sprintf("%s:%d", cfg.ServerHost, cfg.ServerPort),
         Handler: handler,
         // Timeouts
@@ -128,6 +1172,39 @@ func setupServer(cfg *config.Config, handler http.Handler) *http.Server {
         MaxHeaderBytes:    1 << 20, // 1MB
         ErrorLog:          log.New(os.Stderr, "HTTP: ", log.LstdFlags),
     }
+    if serverTLS != nil {
+        server.TLSConfig = serverTLS.Config()
+    }
+    return server
+}
+
+// setupOpsServer creates the metrics and admin server with its own timeouts
+func setupOpsServer(cfg *config.Config, handler http.Handler) *http.Server {
+    return &http.Server{
+        Addr:              fmt.Sprintf("%s:%d", cfg.ServerHost, cfg.Monitoring.MetricsPort),
+        Handler:           handler,
+        ReadTimeout:       cfg.Monitoring.ReadTimeout,
+        WriteTimeout:      cfg.Monitoring.WriteTimeout,
+        IdleTimeout:       idleTimeout,
+        ReadHeaderTimeout: 5 * time.Second,
+        MaxHeaderBytes:    1 << 20, // 1MB
+        ErrorLog:          log.New(os.Stderr, "OPS: ", log.LstdFlags),
+    }
+}
+
+// stopGRPC drains in-flight gRPC calls, forcing a stop when ctx expires
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+    done := make(chan struct{})
+    go func() {
+        server.GracefulStop()
+        close(done)
+    }()
+
+    select {
+    case <-done:
+    case <-ctx.Done():
+        server.Stop()
+    }
 }
 
 // gracefulShutdown handles graceful server shutdown with connection draining
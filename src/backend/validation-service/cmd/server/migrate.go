@@ -0,0 +1,99 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+
+    "validation-service/internal/config"
+    "validation-service/internal/storage/postgres"
+    "validation-service/pkg/logger"
+)
+
+// prepareSchema applies pending schema migrations, or in verify mode refuses
+// to start on a database that is behind this build
+func prepareSchema(ctx context.Context, cfg config.DatabaseConfig, db *sql.DB) error {
+    migrator := postgres.NewMigrator(db)
+    if cfg.MigrationMode == config.MigrationModeVerify {
+        return migrator.Verify(ctx)
+    }
+
+    report, err := migrator.Migrate(ctx, postgres.MigrateOptions{})
+    if err != nil {
+        return err
+    }
+    if len(report.Applied) > 0 {
+        logger.GetLogger().Info("Database schema migrated",
+            "from_version", report.FromVersion,
+            "to_version", report.ToVersion,
+            "migrations", len(report.Applied),
+        )
+    }
+    return nil
+}
+
+// runMigrate implements the migrate command, which applies schema migrations
+// as a release step ahead of servers started in verify mode:
+//
+//	validation-service migrate [-dry-run] [-status] [-target <version>]
+//
+// The report is written to stdout as JSON.
+func runMigrate(cfg *config.Config, args []string, stdout, stderr io.Writer) int {
+    flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+    flags.SetOutput(stderr)
+    dryRun := flags.Bool("dry-run", false, "apply the pending migrations in a transaction that is rolled back")
+    status := flags.Bool("status", false, "report the schema version and pending migrations without applying them")
+    target := flags.Int("target", 0, "stop after this migration version; 0 applies all")
+    if err := flags.Parse(args); err != nil {
+        return 2
+    }
+    if cfg.Database.URL == "" {
+        fmt.Fprintln(stderr, "migrate: database URL is not configured")
+        return 2
+    }
+
+    ctx := context.Background()
+    db, err := postgres.Open(ctx, cfg.Database)
+    if err != nil {
+        fmt.Fprintf(stderr, "migrate: %v\n", err)
+        return 1
+    }
+    defer db.Close()
+
+    migrator := postgres.NewMigrator(db)
+    var report interface{}
+    if *status {
+        current, pending, err := migrator.Version(ctx)
+        if err != nil {
+            fmt.Fprintf(stderr, "migrate: %v\n", err)
+            return 1
+        }
+        versions := make([]int, 0, len(pending))
+        for _, migration := range pending {
+            versions = append(versions, migration.Version)
+        }
+        report = map[string]interface{}{
+            "version":        current,
+            "latest_version": migrator.LatestVersion(),
+            "pending":        versions,
+        }
+    } else {
+        migrated, err := migrator.Migrate(ctx, postgres.MigrateOptions{DryRun: *dryRun, TargetVersion: *target})
+        if err != nil {
+            fmt.Fprintf(stderr, "migrate: %v\n", err)
+            return 1
+        }
+        report = migrated
+    }
+
+    encoder := json.NewEncoder(stdout)
+    encoder.SetIndent("", "  ")
+    if err := encoder.Encode(report); err != nil {
+        fmt.Fprintf(stderr, "migrate: %v\n", err)
+        return 1
+    }
+    return 0
+}
@@ -0,0 +1,588 @@
+// Command valctl validates detection rule files locally with the validators
+// of the validation service, without a running server. Its exit code makes it
+// usable as a CI gate:
+//
+//	0  every detection passed
+//	1  a detection failed validation
+//	2  usage, input or configuration error
+//
+// Usage:
+//
+//	valctl [flags] <file|directory|glob>...
+//
+// Directories are searched recursively for files with a known extension and
+// globs may use ** to match any number of directories.
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "encoding/xml"
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strings"
+
+    "validation-service/internal/config"
+    "validation-service/internal/models"
+    "validation-service/internal/services/lint"
+    "validation-service/internal/services/report"
+    "validation-service/internal/services/validation"
+    "validation-service/pkg/logger"
+    "validation-service/pkg/mitre"
+)
+
+// Exit codes
+const (
+    exitPass    = 0
+    exitFail    = 1
+    exitInvalid = 2
+)
+
+// Output formats
+const (
+    outputText  = "text"
+    outputJSON  = "json"
+    outputSARIF = "sarif"
+    outputJUnit = "junit"
+)
+
+// failOnNone disables failing on issue severity
+const failOnNone = "none"
+
+// junitSuiteName names the test suite of JUnit reports
+const junitSuiteName = "valctl"
+
+// extensionFormats infers the detection format of a file from its extension.
+// YAML files hold Sigma rules unless they look like Sentinel analytics rules.
+var extensionFormats = map[string]string{
+    ".spl":   models.DetectionFormatSplunk,
+    ".aql":   models.DetectionFormatQRadar,
+    ".kql":   models.DetectionFormatKQL,
+    ".yar":   models.DetectionFormatYara,
+    ".yara":  models.DetectionFormatYara,
+    ".yaral": models.DetectionFormatYaraL,
+    ".rules": models.DetectionFormatSuricata,
+    ".sig":   models.DetectionFormatZeek,
+    ".zeek":  models.DetectionFormatZeek,
+    ".bro":   models.DetectionFormatZeek,
+    ".yml":   models.DetectionFormatSigma,
+    ".yaml":  models.DetectionFormatSigma,
+}
+
+// sentinelQueryRegex matches the query key of a Sentinel analytics rule
+var sentinelQueryRegex = regexp.MustCompile(`(?m)^query\s*:`)
+
+// options are the command line flags
+type options struct {
+    format        string
+    source        string
+    output        string
+    failOn        string
+    minConfidence float64
+    lintProfile   string
+    profile       string
+    configFile    string
+    logLevel      string
+}
+
+// fileResult is the validation of one file
+type fileResult struct {
+    Path   string                   `json:"path"`
+    Passed bool                     `json:"passed"`
+    Result *models.ValidationResult `json:"result,omitempty"`
+    Error  string                   `json:"error,omitempty"`
+
+    detection *models.Detection
+    err       error
+}
+
+func main() {
+    os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes valctl and returns its exit code
+func run(args []string, stdout, stderr io.Writer) int {
+    var opts options
+    flags := flag.NewFlagSet("valctl", flag.ContinueOnError)
+    flags.SetOutput(stderr)
+    flags.StringVar(&opts.format, "format", "", "detection format of every file; inferred from the file extension when empty")
+    flags.StringVar(&opts.source, "source", "", "source detection the files translate; enables cross-format checks")
+    flags.StringVar(&opts.output, "output", outputText, "output format: text, json, sarif or junit")
+    flags.StringVar(&opts.failOn, "fail-on", models.ValidationSeverityHigh, "fail on issues at or above this severity: high, medium, low or none; gate fails on the gate verdict of the validation profile")
+    flags.Float64Var(&opts.minConfidence, "min-confidence", 0, "fail when a confidence score is below this value; 0 disables the check")
+    flags.StringVar(&opts.lintProfile, "lint-profile", "", "lint profile; the configured default when empty")
+    flags.StringVar(&opts.profile, "profile", "", "validation profile: strict, standard, permissive or a configured one; the configured default when empty")
+    flags.StringVar(&opts.configFile, "config", "", "service configuration file providing validation settings, validation profiles and lint profiles")
+    flags.StringVar(&opts.logLevel, "log-level", "error", "level of log lines written to stderr")
+    flags.Usage = func() {
+        fmt.Fprintln(stderr, "Usage: valctl [flags] <file|directory|glob>...")
+        flags.PrintDefaults()
+    }
+    if err := flags.Parse(args); err != nil {
+        return exitInvalid
+    }
+    if flags.NArg() == 0 {
+        flags.Usage()
+        return exitInvalid
+    }
+    if err := opts.validate(); err != nil {
+        fmt.Fprintf(stderr, "valctl: %v\n", err)
+        return exitInvalid
+    }
+    if err := logger.InitCLILogger(opts.logLevel); err != nil {
+        fmt.Fprintf(stderr, "valctl: %v\n", err)
+        return exitInvalid
+    }
+
+    service, err := newValidationService(opts)
+    if err != nil {
+        fmt.Fprintf(stderr, "valctl: %v\n", err)
+        return exitInvalid
+    }
+
+    var source *models.Detection
+    if opts.source != "" {
+        if source, err = readDetection(opts.source, ""); err != nil {
+            fmt.Fprintf(stderr, "valctl: source: %v\n", err)
+            return exitInvalid
+        }
+    }
+
+    paths, err := expandPaths(flags.Args())
+    if err != nil {
+        fmt.Fprintf(stderr, "valctl: %v\n", err)
+        return exitInvalid
+    }
+    if len(paths) == 0 {
+        fmt.Fprintln(stderr, "valctl: no detection files found")
+        return exitInvalid
+    }
+
+    ctx := validation.WithLintProfile(context.Background(), opts.lintProfile)
+    ctx = validation.WithProfile(ctx, opts.profile)
+    results := make([]*fileResult, 0, len(paths))
+    for _, path := range paths {
+        results = append(results, validateFile(ctx, service, source, path, opts))
+    }
+
+    if err := writeResults(stdout, results, opts); err != nil {
+        fmt.Fprintf(stderr, "valctl: %v\n", err)
+        return exitInvalid
+    }
+
+    code := exitPass
+    for _, fr := range results {
+        if fr.err != nil {
+            return exitInvalid
+        }
+        if !fr.Passed {
+            code = exitFail
+        }
+    }
+    return code
+}
+
+// validate checks the flag values
+func (o *options) validate() error {
+    switch o.output {
+    case outputText, outputJSON, outputSARIF, outputJUnit:
+    default:
+        return fmt.Errorf("invalid output format: %s", o.output)
+    }
+    switch o.failOn {
+    case models.ValidationSeverityHigh, models.ValidationSeverityMedium, models.ValidationSeverityLow, failOnNone, report.FailOnGate:
+    default:
+        return fmt.Errorf("invalid fail-on severity: %s", o.failOn)
+    }
+    if o.minConfidence < 0 || o.minConfidence > 100 {
+        return fmt.Errorf("min-confidence must be between 0 and 100: %v", o.minConfidence)
+    }
+    return nil
+}
+
+// newValidationService creates a validation service with the built-in
+// validators, configured from the service configuration file when given
+func newValidationService(opts options) (*validation.ValidationService, error) {
+    validationCfg := config.ValidationConfig{StrictValidation: true}
+    if opts.configFile != "" {
+        if err := os.Setenv("CONFIG_FILE", opts.configFile); err != nil {
+            return nil, err
+        }
+        cfg, err := config.LoadConfig()
+        if err != nil {
+            return nil, fmt.Errorf("failed to load configuration: %w", err)
+        }
+        validationCfg = cfg.Validation
+    }
+
+    var attack *mitre.Dataset
+    if validationCfg.AttackDatasetFile != "" {
+        var err error
+        if attack, err = mitre.LoadFile(validationCfg.AttackDatasetFile); err != nil {
+            return nil, fmt.Errorf("failed to load ATT&CK dataset: %w", err)
+        }
+    }
+
+    var linter *lint.Linter
+    if !validationCfg.Lint.Disabled {
+        var err error
+        if linter, err = lint.NewLinter(validationCfg.Lint); err != nil {
+            return nil, fmt.Errorf("failed to initialize lint profiles: %w", err)
+        }
+        if !linter.HasProfile(opts.lintProfile) {
+            return nil, fmt.Errorf("unknown lint profile: %s", opts.lintProfile)
+        }
+    }
+
+    profiles, err := validation.NewProfiles(validationCfg)
+    if err != nil {
+        return nil, fmt.Errorf("failed to initialize validation profiles: %w", err)
+    }
+    if !profiles.Has(opts.profile) {
+        return nil, fmt.Errorf("unknown validation profile: %s", opts.profile)
+    }
+    scoring := validation.NewScoringPolicies(validationCfg.Scoring)
+    scoring.SetProfiles(profiles)
+
+    service := validation.NewValidationService(validation.ValidationConfig{
+        EnableDetailedFeedback: true,
+        ValidationTimeout:      validationCfg.ValidationTimeout,
+        StrictMode:             validationCfg.StrictValidation,
+        Attack:                 attack,
+        Linter:                 linter,
+        Scoring:                scoring,
+        Profiles:               profiles,
+    })
+    if err := service.RegisterBuiltinValidators(); err != nil {
+        return nil, fmt.Errorf("failed to register validators: %w", err)
+    }
+    if validationCfg.SigmaTaxonomyFile != "" {
+        taxonomy, err := validation.LoadSigmaTaxonomy(validationCfg.SigmaTaxonomyFile)
+        if err != nil {
+            return nil, err
+        }
+        service.SetSigmaTaxonomy(taxonomy)
+    }
+
+    fieldMappings, err := validation.LoadFieldMappingTable(validationCfg.FieldMappingFile)
+    if err != nil {
+        return nil, err
+    }
+    if err := service.RegisterCrossFormatValidator(
+        models.DetectionFormatSigma,
+        models.DetectionFormatSplunk,
+        validation.NewSigmaSplunkFieldMappingValidator(fieldMappings),
+    ); err != nil {
+        return nil, err
+    }
+    for _, target := range validation.BackendHintTargets {
+        if err := service.RegisterCrossFormatValidator(
+            models.DetectionFormatSigma,
+            target,
+            validation.NewSigmaBackendHintValidator(target),
+        ); err != nil {
+            return nil, err
+        }
+    }
+    for _, target := range validation.SigmaCompatibilityTargets {
+        if err := service.RegisterCrossFormatValidator(
+            models.DetectionFormatSigma,
+            target,
+            validation.NewSigmaCompatibilityValidator(target),
+        ); err != nil {
+            return nil, err
+        }
+    }
+    return service, nil
+}
+
+// validateFile validates the detection at path, against source when set
+func validateFile(ctx context.Context, service *validation.ValidationService, source *models.Detection, path string, opts options) *fileResult {
+    fr := &fileResult{Path: path}
+    detection, err := readDetection(path, opts.format)
+    if err != nil {
+        fr.fail(err)
+        return fr
+    }
+    fr.detection = detection
+
+    sourceDetection := source
+    if sourceDetection == nil {
+        sourceDetection = detection
+    }
+    result, err := service.ValidateDetection(ctx, sourceDetection, detection)
+    if result == nil {
+        fr.fail(err)
+        return fr
+    }
+    fr.Result = result
+    if opts.failOn == report.FailOnGate {
+        fr.Passed = result.Metadata.Gate == models.GatePass &&
+            (opts.minConfidence == 0 || result.ConfidenceScore >= opts.minConfidence)
+        return fr
+    }
+    fr.Passed = len(report.FailingIssues(result, opts.failOn)) == 0 &&
+        result.Status != models.ValidationStatusError &&
+        (opts.minConfidence == 0 || result.ConfidenceScore >= opts.minConfidence)
+    return fr
+}
+
+// fail records an error that prevented validation
+func (fr *fileResult) fail(err error) {
+    fr.err = err
+    fr.Error = err.Error()
+}
+
+// readDetection reads the detection at path. The format is inferred from the
+// file when format is empty.
+func readDetection(path, format string) (*models.Detection, error) {
+    content, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    if format == "" {
+        if format = inferFormat(path, string(content)); format == "" {
+            return nil, fmt.Errorf("%s: cannot infer the detection format; use --format", path)
+        }
+    }
+    detection, err := models.NewDetection(string(content), format)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", path, err)
+    }
+    // Reports name the file rather than a generated path
+    detection.Metadata, _ = json.Marshal(map[string]string{"path": filepath.ToSlash(path)})
+    return detection, nil
+}
+
+// inferFormat returns the detection format of a file from its extension and,
+// for YAML, its content
+func inferFormat(path, content string) string {
+    format := extensionFormats[strings.ToLower(filepath.Ext(path))]
+    if format == models.DetectionFormatSigma && sentinelQueryRegex.MatchString(content) {
+        return models.DetectionFormatSentinel
+    }
+    return format
+}
+
+// expandPaths resolves files, directories and globs to a sorted list of
+// detection files without duplicates
+func expandPaths(args []string) ([]string, error) {
+    seen := make(map[string]bool)
+    var paths []string
+    add := func(path string) {
+        if !seen[path] {
+            seen[path] = true
+            paths = append(paths, path)
+        }
+    }
+
+    for _, arg := range args {
+        if strings.ContainsAny(arg, "*?[") {
+            matches, err := expandGlob(arg)
+            if err != nil {
+                return nil, err
+            }
+            if len(matches) == 0 {
+                return nil, fmt.Errorf("no files match %s", arg)
+            }
+            for _, match := range matches {
+                add(match)
+            }
+            continue
+        }
+
+        info, err := os.Stat(arg)
+        if err != nil {
+            return nil, err
+        }
+        if !info.IsDir() {
+            add(arg)
+            continue
+        }
+        err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+            if err != nil {
+                return err
+            }
+            if !d.IsDir() && extensionFormats[strings.ToLower(filepath.Ext(path))] != "" {
+                add(path)
+            }
+            return nil
+        })
+        if err != nil {
+            return nil, err
+        }
+    }
+    sort.Strings(paths)
+    return paths, nil
+}
+
+// expandGlob returns the regular files matching pattern. Besides the
+// filepath.Match syntax, a ** path segment matches any number of directories.
+func expandGlob(pattern string) ([]string, error) {
+    pattern = filepath.ToSlash(pattern)
+    if !strings.Contains(pattern, "**") {
+        matches, err := filepath.Glob(pattern)
+        if err != nil {
+            return nil, err
+        }
+        files := matches[:0]
+        for _, match := range matches {
+            if info, err := os.Stat(match); err == nil && !info.IsDir() {
+                files = append(files, match)
+            }
+        }
+        return files, nil
+    }
+
+    // Walk from the longest directory prefix without wildcards
+    root := pattern[:strings.IndexAny(pattern, "*?[")]
+    if i := strings.LastIndex(root, "/"); i >= 0 {
+        root = root[:i]
+    } else {
+        root = "."
+    }
+    if root == "" {
+        root = "/"
+    }
+    matcher, err := globRegexp(pattern)
+    if err != nil {
+        return nil, err
+    }
+
+    var files []string
+    err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        if !d.IsDir() && matcher.MatchString(filepath.ToSlash(path)) {
+            files = append(files, path)
+        }
+        return nil
+    })
+    if err != nil && !errors.Is(err, fs.ErrNotExist) {
+        return nil, err
+    }
+    return files, nil
+}
+
+// globRegexp compiles a glob with ** segments into a regular expression
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+    var b strings.Builder
+    b.WriteString("^")
+    if strings.HasPrefix(pattern, "./") {
+        pattern = pattern[2:]
+        b.WriteString(`(?:\./)?`)
+    }
+    for i := 0; i < len(pattern); i++ {
+        switch c := pattern[i]; c {
+        case '*':
+            if strings.HasPrefix(pattern[i:], "**/") {
+                b.WriteString("(?:.*/)?")
+                i += 2
+            } else if strings.HasPrefix(pattern[i:], "**") {
+                b.WriteString(".*")
+                i++
+            } else {
+                b.WriteString("[^/]*")
+            }
+        case '?':
+            b.WriteString("[^/]")
+        case '[':
+            end := strings.IndexByte(pattern[i:], ']')
+            if end < 0 {
+                return nil, fmt.Errorf("invalid glob %s: unterminated [", pattern)
+            }
+            class := pattern[i+1 : i+end]
+            if strings.HasPrefix(class, "!") {
+                class = "^" + class[1:]
+            }
+            b.WriteString("[" + class + "]")
+            i += end
+        default:
+            b.WriteString(regexp.QuoteMeta(string(c)))
+        }
+    }
+    b.WriteString("$")
+    return regexp.Compile(b.String())
+}
+
+// writeResults writes the results in the selected output format
+func writeResults(w io.Writer, results []*fileResult, opts options) error {
+    switch opts.output {
+    case outputJSON:
+        encoder := json.NewEncoder(w)
+        encoder.SetIndent("", "  ")
+        return encoder.Encode(results)
+
+    case outputSARIF:
+        log := &report.SARIFLog{Schema: report.SARIFSchema, Version: report.SARIFVersion, Runs: []report.SARIFRun{}}
+        for _, fr := range results {
+            if fr.Result != nil {
+                log.Runs = append(log.Runs, report.ToSARIF(fr.Result, report.ArtifactURI(fr.detection)).Runs...)
+            }
+        }
+        encoder := json.NewEncoder(w)
+        encoder.SetIndent("", "  ")
+        return encoder.Encode(log)
+
+    case outputJUnit:
+        cases := make([]report.JUnitCase, 0, len(results))
+        for _, fr := range results {
+            cases = append(cases, report.JUnitCase{Name: fr.Path, Result: fr.Result, Err: fr.err})
+        }
+        failOn := opts.failOn
+        if failOn == failOnNone {
+            failOn = ""
+        }
+        if _, err := io.WriteString(w, xml.Header); err != nil {
+            return err
+        }
+        encoder := xml.NewEncoder(w)
+        encoder.Indent("", "  ")
+        if err := encoder.Encode(report.ToJUnit(junitSuiteName, cases, failOn)); err != nil {
+            return err
+        }
+        _, err := io.WriteString(w, "\n")
+        return err
+
+    default:
+        return writeText(w, results)
+    }
+}
+
+// writeText writes one line per file followed by its issues and a summary
+func writeText(w io.Writer, results []*fileResult) error {
+    failed := 0
+    for _, fr := range results {
+        switch {
+        case fr.err != nil:
+            failed++
+            fmt.Fprintf(w, "ERROR %s: %v\n", fr.Path, fr.err)
+            continue
+        case fr.Passed:
+            fmt.Fprintf(w, "PASS  %s (%s, confidence %.2f)\n", fr.Path, fr.Result.TargetFormat, fr.Result.ConfidenceScore)
+        default:
+            failed++
+            fmt.Fprintf(w, "FAIL  %s (%s, confidence %.2f)\n", fr.Path, fr.Result.TargetFormat, fr.Result.ConfidenceScore)
+        }
+        for _, issue := range fr.Result.Issues {
+            label := issue.Severity
+            if issue.IsOptimization() {
+                label = "hint"
+            }
+            fmt.Fprintf(w, "      %-6s %s %s: %s\n", label, issue.IssueCode, issue.Location, issue.Message)
+        }
+        for _, finding := range fr.Result.Lint {
+            fmt.Fprintf(w, "      %-6s %s %s: %s\n", "lint", finding.Rule, finding.Location, finding.Message)
+        }
+    }
+    _, err := fmt.Fprintf(w, "\n%d file(s) validated, %d passed, %d failed\n", len(results), len(results)-failed, failed)
+    return err
+}
@@ -0,0 +1,200 @@
+// Command validator-conformance runs the validator conformance suite against
+// a candidate validator plugin, for plugin authors and CI. Its exit code is:
+//
+//	0  every check passed
+//	1  a check failed
+//	2  usage error, or the plugin or a sample could not be loaded
+//
+// Usage:
+//
+//	validator-conformance [flags] <plugin> [sample...]
+//
+// Samples are detection files in the format of the plugin. A probe detection
+// is validated when none are given.
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "strings"
+    "time"
+
+    "validation-service/internal/models"
+    "validation-service/internal/services/validation"
+    "validation-service/pkg/logger"
+)
+
+// Exit codes
+const (
+    exitPass    = 0
+    exitFail    = 1
+    exitInvalid = 2
+)
+
+// Output formats
+const (
+    outputText = "text"
+    outputJSON = "json"
+)
+
+// options are the command line flags
+type options struct {
+    pluginType    string
+    args          stringList
+    strict        bool
+    output        string
+    timeout       time.Duration
+    cancelTimeout time.Duration
+    logLevel      string
+}
+
+// stringList is a flag that may be repeated
+type stringList []string
+
+// String returns the values of the flag
+func (l *stringList) String() string {
+    return strings.Join(*l, " ")
+}
+
+// Set adds a value of the flag
+func (l *stringList) Set(value string) error {
+    *l = append(*l, value)
+    return nil
+}
+
+func main() {
+    os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes validator-conformance and returns its exit code
+func run(args []string, stdout, stderr io.Writer) int {
+    var opts options
+    flags := flag.NewFlagSet("validator-conformance", flag.ContinueOnError)
+    flags.SetOutput(stderr)
+    flags.StringVar(&opts.pluginType, "type", validation.PluginTypeSidecar, "plugin type: sidecar or go")
+    flags.Var(&opts.args, "arg", "argument passed to a sidecar; may be repeated")
+    flags.BoolVar(&opts.strict, "strict", false, "build a Go plugin with strict mode enabled")
+    flags.StringVar(&opts.output, "output", outputText, "output format: text or json")
+    flags.DurationVar(&opts.timeout, "timeout", validation.DefaultConformanceTimeout, "time limit of every validation")
+    flags.DurationVar(&opts.cancelTimeout, "cancel-timeout", validation.DefaultConformanceCancelTimeout, "time a validation may take to return once its context is done")
+    flags.StringVar(&opts.logLevel, "log-level", "error", "level of log lines written to stderr")
+    flags.Usage = func() {
+        fmt.Fprintln(stderr, "Usage: validator-conformance [flags] <plugin> [sample...]")
+        flags.PrintDefaults()
+    }
+    if err := flags.Parse(args); err != nil {
+        return exitInvalid
+    }
+    if flags.NArg() == 0 {
+        flags.Usage()
+        return exitInvalid
+    }
+    if err := opts.validate(); err != nil {
+        fmt.Fprintf(stderr, "validator-conformance: %v\n", err)
+        return exitInvalid
+    }
+    if err := logger.InitCLILogger(opts.logLevel); err != nil {
+        fmt.Fprintf(stderr, "validator-conformance: %v\n", err)
+        return exitInvalid
+    }
+
+    validator, err := validation.LoadPlugin(validation.PluginConfig{
+        Type: opts.pluginType,
+        Path: flags.Arg(0),
+        Args: opts.args,
+    }, validation.PluginOptions{StrictMode: opts.strict})
+    if err != nil {
+        fmt.Fprintf(stderr, "validator-conformance: %v\n", err)
+        return exitInvalid
+    }
+    if closer, ok := validator.(io.Closer); ok {
+        defer closer.Close()
+    }
+
+    samples, err := readSamples(flags.Args()[1:], validator.Describe().Format)
+    if err != nil {
+        fmt.Fprintf(stderr, "validator-conformance: %v\n", err)
+        return exitInvalid
+    }
+
+    report := validation.RunConformance(context.Background(), validator, validation.ConformanceOptions{
+        Samples:       samples,
+        Timeout:       opts.timeout,
+        CancelTimeout: opts.cancelTimeout,
+    })
+    if err := writeReport(stdout, report, opts.output); err != nil {
+        fmt.Fprintf(stderr, "validator-conformance: %v\n", err)
+        return exitInvalid
+    }
+    if !report.Passed {
+        return exitFail
+    }
+    return exitPass
+}
+
+// validate checks the flag values
+func (o *options) validate() error {
+    switch o.pluginType {
+    case validation.PluginTypeSidecar, validation.PluginTypeGo:
+    default:
+        return fmt.Errorf("invalid plugin type: %s", o.pluginType)
+    }
+    if len(o.args) > 0 && o.pluginType != validation.PluginTypeSidecar {
+        return fmt.Errorf("arguments are only passed to sidecars")
+    }
+    switch o.output {
+    case outputText, outputJSON:
+    default:
+        return fmt.Errorf("invalid output format: %s", o.output)
+    }
+    if o.timeout <= 0 || o.cancelTimeout <= 0 {
+        return fmt.Errorf("timeouts must be positive")
+    }
+    return nil
+}
+
+// readSamples reads the sample files as detections in format
+func readSamples(paths []string, format string) ([]*models.Detection, error) {
+    samples := make([]*models.Detection, 0, len(paths))
+    for _, path := range paths {
+        content, err := os.ReadFile(path)
+        if err != nil {
+            return nil, err
+        }
+        detection, err := models.NewDetection(string(content), format)
+        if err != nil {
+            return nil, fmt.Errorf("%s: %w", path, err)
+        }
+        samples = append(samples, detection)
+    }
+    return samples, nil
+}
+
+// writeReport writes the conformance report in the output format
+func writeReport(w io.Writer, report *validation.ConformanceReport, output string) error {
+    if output == outputJSON {
+        encoder := json.NewEncoder(w)
+        encoder.SetIndent("", "  ")
+        return encoder.Encode(report)
+    }
+
+    fmt.Fprintf(w, "%s (%s %s)\n", report.Format, report.Name, report.Version)
+    failed := 0
+    for _, check := range report.Checks {
+        if check.Passed {
+            fmt.Fprintf(w, "PASS  %s\n", check.ID)
+            continue
+        }
+        failed++
+        fmt.Fprintf(w, "FAIL  %s: %s\n", check.ID, check.Description)
+        for _, failure := range check.Failures {
+            fmt.Fprintf(w, "      %s\n", failure)
+        }
+    }
+    _, err := fmt.Fprintf(w, "\n%d check(s) run, %d passed, %d failed\n", len(report.Checks), len(report.Checks)-failed, failed)
+    return err
+}
@@ -0,0 +1,807 @@
+package graphqlapi
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "time"
+
+    "github.com/google/uuid"                     // v1.4.0
+    "github.com/graphql-go/graphql"              // v0.8.1
+    "github.com/graphql-go/graphql/language/ast" // v0.8.1
+
+    "validation-service/internal/api/middleware"
+    "validation-service/internal/audit"
+    "validation-service/internal/config"
+    "validation-service/internal/models"
+    "validation-service/internal/services/remediation"
+    "validation-service/internal/services/translationmemory"
+    "validation-service/internal/services/validation"
+    "validation-service/internal/storage"
+    "validation-service/pkg/logger"
+)
+
+const (
+    // maxStatisticsResults bounds the results aggregated by a statistics
+    // query; statistics of larger sets cover the newest results
+    maxStatisticsResults = 10000
+    // defaultIssueCodeLimit is the number of issue codes statistics list
+    // unless the query asks for more
+    defaultIssueCodeLimit = 10
+)
+
+// jsonScalar passes arbitrary JSON values, such as history entry details,
+// through unchanged
+var jsonScalar = graphql.NewScalar(graphql.ScalarConfig{
+    Name:        "JSON",
+    Description: "An arbitrary JSON value",
+    Serialize:   func(value interface{}) interface{} { return value },
+    ParseValue:  func(value interface{}) interface{} { return value },
+    ParseLiteral: func(valueAST ast.Value) interface{} {
+        return valueAST.GetValue()
+    },
+})
+
+// newSchema builds the schema with resolvers bound to the server
+func (s *Server) newSchema() (graphql.Schema, error) {
+    issueType := graphql.NewObject(graphql.ObjectConfig{
+        Name: "ValidationIssue",
+        Fields: graphql.Fields{
+            "issueCode":      issueField(graphql.NewNonNull(graphql.String), func(i *models.ValidationIssue) interface{} { return i.IssueCode }),
+            "namespacedCode": issueField(graphql.String, func(i *models.ValidationIssue) interface{} { return optionalString(i.NamespacedCode) }),
+            "severity":       issueField(graphql.NewNonNull(graphql.String), func(i *models.ValidationIssue) interface{} { return i.Severity }),
+            "category":       issueField(graphql.NewNonNull(graphql.String), issueCategory),
+            "message":        issueField(graphql.NewNonNull(graphql.String), func(i *models.ValidationIssue) interface{} { return i.Message }),
+            "location":       issueField(graphql.String, func(i *models.ValidationIssue) interface{} { return i.Location }),
+            "line":           issueField(graphql.Int, func(i *models.ValidationIssue) interface{} { return optionalInt(i.Line) }),
+            "column":         issueField(graphql.Int, func(i *models.ValidationIssue) interface{} { return optionalInt(i.Column) }),
+            "remediation":    issueField(graphql.String, func(i *models.ValidationIssue) interface{} { return i.Remediation }),
+            "fixable":        issueField(graphql.NewNonNull(graphql.Boolean), func(i *models.ValidationIssue) interface{} { return i.Fixable }),
+            "timestamp":      issueField(graphql.DateTime, func(i *models.ValidationIssue) interface{} { return i.Timestamp }),
+            "id":             issueField(graphql.String, func(i *models.ValidationIssue) interface{} { return optionalString(i.ID) }),
+            "causedBy":       issueField(graphql.String, func(i *models.ValidationIssue) interface{} { return optionalString(i.CausedBy) }),
+            "relatedTo":      issueField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), issueRelatedTo),
+        },
+    })
+
+    historyType := graphql.NewObject(graphql.ObjectConfig{
+        Name: "HistoryEntry",
+        Fields: graphql.Fields{
+            "timestamp": historyField(graphql.NewNonNull(graphql.DateTime), func(e *models.ValidationHistoryEntry) interface{} { return e.Timestamp }),
+            "action":    historyField(graphql.NewNonNull(graphql.String), func(e *models.ValidationHistoryEntry) interface{} { return e.Action }),
+            "details":   historyField(jsonScalar, func(e *models.ValidationHistoryEntry) interface{} { return e.Details }),
+        },
+    })
+
+    resultType := graphql.NewObject(graphql.ObjectConfig{
+        Name: "ValidationResult",
+        Fields: graphql.Fields{
+            "id":              resultField(graphql.NewNonNull(graphql.ID), func(r *models.ValidationResult) interface{} { return r.ID.String() }),
+            "createdAt":       resultField(graphql.NewNonNull(graphql.DateTime), func(r *models.ValidationResult) interface{} { return r.CreatedAt }),
+            "status":          resultField(graphql.NewNonNull(graphql.String), func(r *models.ValidationResult) interface{} { return r.Status }),
+            "confidenceScore": resultField(graphql.NewNonNull(graphql.Float), func(r *models.ValidationResult) interface{} { return r.ConfidenceScore }),
+            "sourceFormat":    resultField(graphql.String, func(r *models.ValidationResult) interface{} { return r.SourceFormat }),
+            "targetFormat":    resultField(graphql.String, func(r *models.ValidationResult) interface{} { return r.TargetFormat }),
+            "formatDetails":   resultField(jsonScalar, func(r *models.ValidationResult) interface{} { return r.FormatSpecificDetails }),
+            "issueCount":      resultField(graphql.NewNonNull(graphql.Int), func(r *models.ValidationResult) interface{} { return len(r.Issues) }),
+            "issues": &graphql.Field{
+                Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(issueType))),
+                Description: "Issues of the result, optionally only those of a severity, issue code or category",
+                Args: graphql.FieldConfigArgument{
+                    "severity":  &graphql.ArgumentConfig{Type: graphql.String},
+                    "issueCode": &graphql.ArgumentConfig{Type: graphql.String},
+                    "category":  &graphql.ArgumentConfig{Type: graphql.String},
+                },
+                Resolve: resolveIssues,
+            },
+            "history": &graphql.Field{
+                Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(historyType))),
+                Description: "Validation history of the result, optionally only the entries of an action",
+                Args: graphql.FieldConfigArgument{
+                    "action": &graphql.ArgumentConfig{Type: graphql.String},
+                },
+                Resolve: resolveHistory,
+            },
+        },
+    })
+
+    resultConnectionType := graphql.NewObject(graphql.ObjectConfig{
+        Name: "ResultConnection",
+        Fields: graphql.Fields{
+            "total": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+            "items": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(resultType)))},
+        },
+    })
+
+    countType := graphql.NewObject(graphql.ObjectConfig{
+        Name: "Count",
+        Fields: graphql.Fields{
+            "key":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+            "count": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+        },
+    })
+
+    statisticsType := graphql.NewObject(graphql.ObjectConfig{
+        Name: "Statistics",
+        Fields: graphql.Fields{
+            "results":        &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "Number of results matching the filter"},
+            "aggregated":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Description: "Number of results aggregated, the newest when fewer than the matches"},
+            "meanScore":      &graphql.Field{Type: graphql.Float},
+            "minScore":       &graphql.Field{Type: graphql.Float},
+            "maxScore":       &graphql.Field{Type: graphql.Float},
+            "byStatus":       &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(countType)))},
+            "byTargetFormat": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(countType)))},
+            "issueCodes": &graphql.Field{
+                Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(countType))),
+                Description: "Most common issue codes, by the number of results reporting them",
+                Args: graphql.FieldConfigArgument{
+                    "limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultIssueCodeLimit},
+                },
+                Resolve: resolveIssueCodes,
+            },
+        },
+    })
+
+    tagType := graphql.NewObject(graphql.ObjectConfig{
+        Name: "Tag",
+        Fields: graphql.Fields{
+            "key":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+            "value": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+        },
+    })
+
+    detectionValidationType := graphql.NewObject(graphql.ObjectConfig{
+        Name: "DetectionValidation",
+        Fields: graphql.Fields{
+            "resultId":        validationField(graphql.NewNonNull(graphql.ID), func(v *storage.DetectionValidation) interface{} { return v.ResultID.String() }),
+            "status":          validationField(graphql.NewNonNull(graphql.String), func(v *storage.DetectionValidation) interface{} { return v.Status }),
+            "confidenceScore": validationField(graphql.NewNonNull(graphql.Float), func(v *storage.DetectionValidation) interface{} { return v.ConfidenceScore }),
+            "issues":          validationField(graphql.NewNonNull(graphql.Int), func(v *storage.DetectionValidation) interface{} { return v.Issues }),
+            "validatedAt":     validationField(graphql.NewNonNull(graphql.DateTime), func(v *storage.DetectionValidation) interface{} { return v.ValidatedAt }),
+            "result": &graphql.Field{
+                Type:        resultType,
+                Description: "The stored validation result",
+                Resolve:     s.resolveValidationResult,
+            },
+        },
+    })
+
+    detectionType := graphql.NewObject(graphql.ObjectConfig{
+        Name: "Detection",
+        Fields: graphql.Fields{
+            "id":          detectionField(graphql.NewNonNull(graphql.ID), func(d *storage.StoredDetection) interface{} { return d.ID().String() }),
+            "name":        detectionField(graphql.NewNonNull(graphql.String), func(d *storage.StoredDetection) interface{} { return d.Name }),
+            "description": detectionField(graphql.String, func(d *storage.StoredDetection) interface{} { return d.Description }),
+            "format":      detectionField(graphql.NewNonNull(graphql.String), func(d *storage.StoredDetection) interface{} { return d.Detection.Format }),
+            "content":     detectionField(graphql.NewNonNull(graphql.String), func(d *storage.StoredDetection) interface{} { return d.Detection.Content }),
+            "version":     detectionField(graphql.NewNonNull(graphql.Int), func(d *storage.StoredDetection) interface{} { return d.Version }),
+            "techniques":  detectionField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), detectionTechniques),
+            "tags":        detectionField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tagType))), detectionTags),
+            "confidenceScore": detectionField(graphql.Float, func(d *storage.StoredDetection) interface{} {
+                if d.ConfidenceScore == nil {
+                    return nil
+                }
+                return *d.ConfidenceScore
+            }),
+            "updatedBy": detectionField(graphql.String, func(d *storage.StoredDetection) interface{} { return d.UpdatedBy }),
+            "updatedAt": detectionField(graphql.NewNonNull(graphql.DateTime), func(d *storage.StoredDetection) interface{} { return d.UpdatedAt }),
+            "validation": detectionField(detectionValidationType, func(d *storage.StoredDetection) interface{} {
+                if d.Validation == nil {
+                    return nil
+                }
+                return d.Validation
+            }),
+        },
+    })
+
+    detectionConnectionType := graphql.NewObject(graphql.ObjectConfig{
+        Name: "DetectionConnection",
+        Fields: graphql.Fields{
+            "total": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+            "items": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(detectionType)))},
+        },
+    })
+
+    resultFilterType := graphql.NewInputObject(graphql.InputObjectConfig{
+        Name:        "ResultFilter",
+        Description: "Selects stored results; format matches either the source or the target format",
+        Fields: graphql.InputObjectConfigFieldMap{
+            "format": &graphql.InputObjectFieldConfig{Type: graphql.String},
+            "status": &graphql.InputObjectFieldConfig{Type: graphql.String},
+            "from":   &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+            "to":     &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+        },
+    })
+
+    detectionFilterType := graphql.NewInputObject(graphql.InputObjectConfig{
+        Name:        "DetectionFilter",
+        Description: "Selects stored detections; text matches name, description and content",
+        Fields: graphql.InputObjectConfigFieldMap{
+            "format":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+            "technique": &graphql.InputObjectFieldConfig{Type: graphql.String},
+            "text":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+        },
+    })
+
+    detectionInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+        Name: "DetectionInput",
+        Fields: graphql.InputObjectConfigFieldMap{
+            "content": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+            "format":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+        },
+    })
+
+    pageArgs := func(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+        args["limit"] = &graphql.ArgumentConfig{Type: graphql.Int}
+        args["offset"] = &graphql.ArgumentConfig{Type: graphql.Int}
+        return args
+    }
+
+    query := graphql.NewObject(graphql.ObjectConfig{
+        Name: "Query",
+        Fields: graphql.Fields{
+            "result": &graphql.Field{
+                Type:        resultType,
+                Description: "A stored validation result",
+                Args: graphql.FieldConfigArgument{
+                    "id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+                },
+                Resolve: s.resolveResult,
+            },
+            "results": &graphql.Field{
+                Type:        graphql.NewNonNull(resultConnectionType),
+                Description: "Stored validation results, newest first",
+                Args: pageArgs(graphql.FieldConfigArgument{
+                    "filter": &graphql.ArgumentConfig{Type: resultFilterType},
+                }),
+                Resolve: s.resolveResults,
+            },
+            "statistics": &graphql.Field{
+                Type:        graphql.NewNonNull(statisticsType),
+                Description: "Score, status, format and issue code statistics of stored results",
+                Args: graphql.FieldConfigArgument{
+                    "filter": &graphql.ArgumentConfig{Type: resultFilterType},
+                },
+                Resolve: s.resolveStatistics,
+            },
+            "detection": &graphql.Field{
+                Type:        detectionType,
+                Description: "A stored detection",
+                Args: graphql.FieldConfigArgument{
+                    "id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+                },
+                Resolve: s.resolveDetection,
+            },
+            "detections": &graphql.Field{
+                Type:        graphql.NewNonNull(detectionConnectionType),
+                Description: "Stored detections",
+                Args: pageArgs(graphql.FieldConfigArgument{
+                    "filter": &graphql.ArgumentConfig{Type: detectionFilterType},
+                }),
+                Resolve: s.resolveDetections,
+            },
+        },
+    })
+
+    mutation := graphql.NewObject(graphql.ObjectConfig{
+        Name: "Mutation",
+        Fields: graphql.Fields{
+            "validate": &graphql.Field{
+                Type:        graphql.NewNonNull(resultType),
+                Description: "Validates a translation the same way as POST /api/v1/validate and stores the result",
+                Args: graphql.FieldConfigArgument{
+                    "source": &graphql.ArgumentConfig{Type: graphql.NewNonNull(detectionInputType)},
+                    "target": &graphql.ArgumentConfig{Type: graphql.NewNonNull(detectionInputType)},
+                },
+                Resolve: s.resolveValidate,
+            },
+        },
+    })
+
+    return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// resultConnection, detectionConnection, statistics and count are the
+// sources of the object types without a model of their own; their fields
+// resolve by name
+type resultConnection struct {
+    Total int                        `json:"total"`
+    Items []*models.ValidationResult `json:"items"`
+}
+
+type detectionConnection struct {
+    Total int                        `json:"total"`
+    Items []*storage.StoredDetection `json:"items"`
+}
+
+type statistics struct {
+    Results        int      `json:"results"`
+    Aggregated     int      `json:"aggregated"`
+    MeanScore      *float64 `json:"meanScore"`
+    MinScore       *float64 `json:"minScore"`
+    MaxScore       *float64 `json:"maxScore"`
+    ByStatus       []count  `json:"byStatus"`
+    ByTargetFormat []count  `json:"byTargetFormat"`
+    // issueCodes counts the results reporting each issue code; the
+    // issueCodes field ranks and limits them
+    issueCodes map[string]int
+}
+
+type count struct {
+    Key   string `json:"key"`
+    Count int    `json:"count"`
+}
+
+type tag struct {
+    Key   string `json:"key"`
+    Value string `json:"value"`
+}
+
+// requireScope fails a field whose token does not grant scope, like the
+// RequireScope middleware of the REST endpoints
+func requireScope(ctx context.Context, scope string) error {
+    claims, ok := middleware.ClaimsFromContext(ctx)
+    if !ok {
+        return errors.New("invalid authentication token")
+    }
+    if !claims.HasScope(scope) {
+        return fmt.Errorf("token lacks required scope: %s", scope)
+    }
+    return nil
+}
+
+// resolveResult returns a stored result of the tenant, or null when there is
+// none with the ID
+func (s *Server) resolveResult(p graphql.ResolveParams) (interface{}, error) {
+    if err := requireScope(p.Context, middleware.ScopeResultsRead); err != nil {
+        return nil, err
+    }
+    if s.results == nil {
+        return nil, errors.New("result storage is not configured")
+    }
+    id, err := uuid.Parse(p.Args["id"].(string))
+    if err != nil {
+        return nil, errors.New("invalid result ID")
+    }
+    result, err := s.results.GetResult(p.Context, tenantID(p.Context), id)
+    if errors.Is(err, storage.ErrNotFound) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, s.internalError(p.Context, "failed to load result", err)
+    }
+    return result, nil
+}
+
+// resolveResults lists stored results of the tenant matching the filter
+func (s *Server) resolveResults(p graphql.ResolveParams) (interface{}, error) {
+    if err := requireScope(p.Context, middleware.ScopeResultsRead); err != nil {
+        return nil, err
+    }
+    if s.results == nil {
+        return nil, errors.New("result storage is not configured")
+    }
+    query := resultQuery(p.Context, p.Args)
+    query.Limit, query.Offset = pageArg(p.Args, "limit"), pageArg(p.Args, "offset")
+    if err := query.Normalize(); err != nil {
+        return nil, err
+    }
+    results, total, err := s.results.ListResults(p.Context, query)
+    if err != nil {
+        return nil, s.internalError(p.Context, "failed to list results", err)
+    }
+    return &resultConnection{Total: total, Items: results}, nil
+}
+
+// resolveStatistics aggregates the stored results of the tenant matching the
+// filter, newest first up to maxStatisticsResults
+func (s *Server) resolveStatistics(p graphql.ResolveParams) (interface{}, error) {
+    if err := requireScope(p.Context, middleware.ScopeResultsRead); err != nil {
+        return nil, err
+    }
+    if s.results == nil {
+        return nil, errors.New("result storage is not configured")
+    }
+    query := resultQuery(p.Context, p.Args)
+    query.Limit = storage.MaxSearchLimit
+    if err := query.Normalize(); err != nil {
+        return nil, err
+    }
+
+    stats := &statistics{issueCodes: make(map[string]int)}
+    statuses := make(map[string]int)
+    formats := make(map[string]int)
+    var total float64
+    for {
+        page, matches, err := s.results.ListResults(p.Context, query)
+        if err != nil {
+            return nil, s.internalError(p.Context, "failed to aggregate results", err)
+        }
+        stats.Results = matches
+        for _, result := range page {
+            if stats.Aggregated == maxStatisticsResults {
+                break
+            }
+            score := result.ConfidenceScore
+            if stats.Aggregated == 0 || score < *stats.MinScore {
+                stats.MinScore = &score
+            }
+            if stats.Aggregated == 0 || score > *stats.MaxScore {
+                stats.MaxScore = &score
+            }
+            stats.Aggregated++
+            total += score
+            statuses[result.Status]++
+            formats[result.TargetFormat]++
+
+            codes := make(map[string]bool)
+            for _, issue := range result.Issues {
+                codes[issue.IssueCode] = true
+            }
+            for code := range codes {
+                stats.issueCodes[code]++
+            }
+        }
+        query.Offset += len(page)
+        if len(page) == 0 || query.Offset >= matches || stats.Aggregated == maxStatisticsResults {
+            break
+        }
+    }
+    if stats.Aggregated > 0 {
+        mean := total / float64(stats.Aggregated)
+        stats.MeanScore = &mean
+    }
+    stats.ByStatus = sortedCounts(statuses)
+    stats.ByTargetFormat = sortedCounts(formats)
+    return stats, nil
+}
+
+// resolveIssueCodes ranks the issue codes of statistics by the number of
+// results reporting them
+func resolveIssueCodes(p graphql.ResolveParams) (interface{}, error) {
+    stats, ok := p.Source.(*statistics)
+    if !ok {
+        return nil, nil
+    }
+    limit, _ := p.Args["limit"].(int)
+    if limit < 0 {
+        return nil, errors.New("limit must not be negative")
+    }
+    counts := sortedCounts(stats.issueCodes)
+    if len(counts) > limit {
+        counts = counts[:limit]
+    }
+    return counts, nil
+}
+
+// resolveDetection returns a stored detection of the tenant, or null when
+// there is none with the ID
+func (s *Server) resolveDetection(p graphql.ResolveParams) (interface{}, error) {
+    if err := requireScope(p.Context, middleware.ScopeRulesRead); err != nil {
+        return nil, err
+    }
+    if s.detections == nil {
+        return nil, errors.New("detection storage is not configured")
+    }
+    id, err := uuid.Parse(p.Args["id"].(string))
+    if err != nil {
+        return nil, errors.New("invalid detection ID")
+    }
+    detection, err := s.detections.GetDetection(p.Context, tenantID(p.Context), id)
+    if errors.Is(err, storage.ErrNotFound) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, s.internalError(p.Context, "failed to load detection", err)
+    }
+    return detection, nil
+}
+
+// resolveDetections searches the stored detections of the tenant
+func (s *Server) resolveDetections(p graphql.ResolveParams) (interface{}, error) {
+    if err := requireScope(p.Context, middleware.ScopeRulesRead); err != nil {
+        return nil, err
+    }
+    if s.detections == nil {
+        return nil, errors.New("detection storage is not configured")
+    }
+    query := storage.DetectionQuery{
+        TenantID: tenantID(p.Context),
+        Limit:    pageArg(p.Args, "limit"),
+        Offset:   pageArg(p.Args, "offset"),
+    }
+    if filter, ok := p.Args["filter"].(map[string]interface{}); ok {
+        query.Format, _ = filter["format"].(string)
+        query.Technique, _ = filter["technique"].(string)
+        query.Text, _ = filter["text"].(string)
+    }
+    if err := query.Normalize(); err != nil {
+        return nil, err
+    }
+    detections, total, err := s.detections.SearchDetections(p.Context, query)
+    if err != nil {
+        return nil, s.internalError(p.Context, "failed to search detections", err)
+    }
+    return &detectionConnection{Total: total, Items: detections}, nil
+}
+
+// resolveValidationResult loads the result a detection validation summary
+// refers to
+func (s *Server) resolveValidationResult(p graphql.ResolveParams) (interface{}, error) {
+    summary, ok := p.Source.(*storage.DetectionValidation)
+    if !ok {
+        return nil, nil
+    }
+    p.Args = map[string]interface{}{"id": summary.ResultID.String()}
+    return s.resolveResult(p)
+}
+
+// resolveValidate validates a detection pair the same way as
+// POST /api/v1/validate: the translation memory is checked, tenant playbooks
+// are applied and the result is persisted
+func (s *Server) resolveValidate(p graphql.ResolveParams) (interface{}, error) {
+    ctx := p.Context
+    if err := requireScope(ctx, middleware.ScopeValidateRead); err != nil {
+        return nil, err
+    }
+    source, err := detectionInput(p.Args["source"])
+    if err != nil {
+        return nil, fmt.Errorf("source: %w", err)
+    }
+    target, err := detectionInput(p.Args["target"])
+    if err != nil {
+        return nil, fmt.Errorf("target: %w", err)
+    }
+
+    result, _, err := s.service.ValidateDetectionCached(ctx, source, target, false)
+    s.auditValidation(ctx, source, target, result, err)
+    if errors.Is(err, validation.ErrFormatNotAllowed) {
+        return nil, err
+    }
+    if err != nil {
+        if ctxErr := ctx.Err(); ctxErr != nil {
+            return nil, ctxErr
+        }
+        logger.FromContext(ctx).Error("Validation failed",
+            "error", err,
+            "source_format", source.Format,
+            "target_format", target.Format,
+        )
+        return nil, fmt.Errorf("validation error: %v", err)
+    }
+
+    tenantID := tenantID(ctx)
+    if err := translationmemory.Check(ctx, s.memory, tenantID, source, target, result); err != nil {
+        logger.FromContext(ctx).Error("Failed to check translation memory",
+            "error", err,
+            "result_id", result.ID,
+        )
+    }
+    remediation.ApplyPlaybooks(result, remediation.PlaybooksForTenant(
+        config.GetConfig().Remediation, tenantID))
+    result.Metadata.RequestID = middleware.RequestIDFromContext(ctx)
+    result.Metadata.TraceID = middleware.TraceIDFromContext(ctx)
+
+    if s.results != nil {
+        if err := s.results.SaveResult(ctx, tenantID, result); err != nil {
+            logger.FromContext(ctx).Error("Failed to persist validation result",
+                "error", err,
+                "result_id", result.ID,
+            )
+        }
+    }
+    return result, nil
+}
+
+// auditValidation records a validation, successful or not, in the audit
+// log. A record that cannot be written is logged without failing the
+// mutation.
+func (s *Server) auditValidation(ctx context.Context, source, target *models.Detection, result *models.ValidationResult, err error) {
+    if s.audit == nil {
+        return
+    }
+    rec := audit.ValidationRecord(source, target, result, err)
+    rec.Channel = audit.ChannelGraphQL
+    rec.TenantID = tenantID(ctx)
+    rec.IP = remoteAddr(ctx)
+    rec.RequestID = middleware.RequestIDFromContext(ctx)
+    if claims, ok := middleware.ClaimsFromContext(ctx); ok {
+        rec.UserID = claims.UserId
+    }
+    if err := s.audit.Record(rec); err != nil {
+        logger.FromContext(ctx).Error("Failed to write audit record",
+            "error", err,
+        )
+    }
+}
+
+// internalError logs a storage failure and returns the message shown to the
+// caller without its cause
+func (s *Server) internalError(ctx context.Context, message string, err error) error {
+    logger.FromContext(ctx).Error("GraphQL resolver failed",
+        "error", err,
+        "message", message,
+    )
+    return errors.New(message)
+}
+
+// resolveIssues filters the issues of a result by the field arguments
+func resolveIssues(p graphql.ResolveParams) (interface{}, error) {
+    result, ok := p.Source.(*models.ValidationResult)
+    if !ok {
+        return nil, nil
+    }
+    severity, _ := p.Args["severity"].(string)
+    code, _ := p.Args["issueCode"].(string)
+    category, _ := p.Args["category"].(string)
+    issues := make([]*models.ValidationIssue, 0, len(result.Issues))
+    for i := range result.Issues {
+        issue := &result.Issues[i]
+        if (severity == "" || issue.Severity == severity) &&
+            (code == "" || issue.IssueCode == code) &&
+            (category == "" || issueCategory(issue) == category) {
+            issues = append(issues, issue)
+        }
+    }
+    return issues, nil
+}
+
+// resolveHistory filters the history of a result by the action argument
+func resolveHistory(p graphql.ResolveParams) (interface{}, error) {
+    result, ok := p.Source.(*models.ValidationResult)
+    if !ok {
+        return nil, nil
+    }
+    action, _ := p.Args["action"].(string)
+    entries := make([]*models.ValidationHistoryEntry, 0, len(result.ValidationHistory))
+    for i := range result.ValidationHistory {
+        if entry := &result.ValidationHistory[i]; action == "" || entry.Action == action {
+            entries = append(entries, entry)
+        }
+    }
+    return entries, nil
+}
+
+// resultField, issueField, historyField, detectionField and validationField
+// resolve a field of the object type's source with fn
+func resultField(t graphql.Output, fn func(*models.ValidationResult) interface{}) *graphql.Field {
+    return &graphql.Field{Type: t, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+        if r, ok := p.Source.(*models.ValidationResult); ok {
+            return fn(r), nil
+        }
+        return nil, nil
+    }}
+}
+
+func issueField(t graphql.Output, fn func(*models.ValidationIssue) interface{}) *graphql.Field {
+    return &graphql.Field{Type: t, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+        if i, ok := p.Source.(*models.ValidationIssue); ok {
+            return fn(i), nil
+        }
+        return nil, nil
+    }}
+}
+
+func historyField(t graphql.Output, fn func(*models.ValidationHistoryEntry) interface{}) *graphql.Field {
+    return &graphql.Field{Type: t, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+        if e, ok := p.Source.(*models.ValidationHistoryEntry); ok {
+            return fn(e), nil
+        }
+        return nil, nil
+    }}
+}
+
+func detectionField(t graphql.Output, fn func(*storage.StoredDetection) interface{}) *graphql.Field {
+    return &graphql.Field{Type: t, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+        if d, ok := p.Source.(*storage.StoredDetection); ok {
+            return fn(d), nil
+        }
+        return nil, nil
+    }}
+}
+
+func validationField(t graphql.Output, fn func(*storage.DetectionValidation) interface{}) *graphql.Field {
+    return &graphql.Field{Type: t, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+        if v, ok := p.Source.(*storage.DetectionValidation); ok {
+            return fn(v), nil
+        }
+        return nil, nil
+    }}
+}
+
+// issueCategory returns the category of an issue, correctness when unset
+func issueCategory(issue *models.ValidationIssue) interface{} {
+    if issue.Category == "" {
+        return models.IssueCategoryCorrectness
+    }
+    return issue.Category
+}
+
+// issueRelatedTo returns the IDs of the related issues, never null
+func issueRelatedTo(issue *models.ValidationIssue) interface{} {
+    if issue.RelatedTo == nil {
+        return []string{}
+    }
+    return issue.RelatedTo
+}
+
+// optionalString returns nil for an unset issue ID
+func optionalString(s string) interface{} {
+    if s == "" {
+        return nil
+    }
+    return s
+}
+
+// optionalInt returns nil for an unset line or column
+func optionalInt(n int) interface{} {
+    if n == 0 {
+        return nil
+    }
+    return n
+}
+
+func detectionTechniques(d *storage.StoredDetection) interface{} {
+    if d.Techniques == nil {
+        return []string{}
+    }
+    return d.Techniques
+}
+
+// detectionTags lists the tags of a detection sorted by key
+func detectionTags(d *storage.StoredDetection) interface{} {
+    tags := make([]tag, 0, len(d.Tags))
+    for key, value := range d.Tags {
+        tags = append(tags, tag{Key: key, Value: value})
+    }
+    sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+    return tags
+}
+
+// resultQuery builds the result query of the tenant from a filter argument
+func resultQuery(ctx context.Context, args map[string]interface{}) storage.ResultQuery {
+    query := storage.ResultQuery{TenantID: tenantID(ctx)}
+    filter, ok := args["filter"].(map[string]interface{})
+    if !ok {
+        return query
+    }
+    query.Format, _ = filter["format"].(string)
+    query.Status, _ = filter["status"].(string)
+    if from, ok := filter["from"].(time.Time); ok {
+        query.From = &from
+    }
+    if to, ok := filter["to"].(time.Time); ok {
+        query.To = &to
+    }
+    return query
+}
+
+// pageArg returns an integer pagination argument, zero when absent
+func pageArg(args map[string]interface{}, name string) int {
+    n, _ := args[name].(int)
+    return n
+}
+
+// detectionInput converts a DetectionInput argument
+func detectionInput(arg interface{}) (*models.Detection, error) {
+    input, _ := arg.(map[string]interface{})
+    content, _ := input["content"].(string)
+    format, _ := input["format"].(string)
+    return models.NewDetection(content, format)
+}
+
+// sortedCounts orders counts by count, then key
+func sortedCounts(counts map[string]int) []count {
+    sorted := make([]count, 0, len(counts))
+    for key, n := range counts {
+        sorted = append(sorted, count{Key: key, Count: n})
+    }
+    sort.Slice(sorted, func(i, j int) bool {
+        if sorted[i].Count != sorted[j].Count {
+            return sorted[i].Count > sorted[j].Count
+        }
+        return sorted[i].Key < sorted[j].Key
+    })
+    return sorted
+}
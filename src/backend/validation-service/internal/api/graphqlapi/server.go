@@ -0,0 +1,168 @@
+// Package graphqlapi exposes validation results, stored detections and
+// result statistics over GraphQL, so dashboards can fetch the slices they
+// need, such as only the issues or the score of a result, in one request.
+// It shares the ValidationService, stores and remediation playbooks with the
+// HTTP API; authentication, tenants and network policies are applied by the
+// HTTP middleware of the route it is mounted on, and each field checks the
+// scope the equivalent REST endpoint requires.
+package graphqlapi
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/graphql-go/graphql" // v0.8.1
+
+    "validation-service/internal/audit"
+    "validation-service/internal/services/validation"
+    "validation-service/internal/storage"
+    "validation-service/internal/tenant"
+    "validation-service/pkg/logger"
+)
+
+const (
+    // maxRequestSize matches the HTTP API request size limit
+    maxRequestSize = 10 * 1024 * 1024
+    // requestTimeout matches the HTTP API request timeout
+    requestTimeout = 30 * time.Second
+
+    // tenantHeader carries the tenant ID when no tenant registry resolved
+    // it, as for the REST endpoints
+    tenantHeader = "X-Tenant-ID"
+)
+
+// Request is the body of a GraphQL request
+type Request struct {
+    Query         string                 `json:"query"`
+    OperationName string                 `json:"operationName,omitempty"`
+    Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Server executes GraphQL requests against the validation service and its
+// stores. It implements http.Handler.
+type Server struct {
+    service    *validation.ValidationService
+    results    storage.ResultStore
+    detections storage.DetectionStore
+    memory     storage.TranslationMemoryStore
+    audit      *audit.Logger
+    schema     graphql.Schema
+    log        *logger.Logger
+}
+
+// NewServer creates a GraphQL server. detections may be nil, leaving the
+// detection queries to fail; results may be nil, in which case validations
+// are not persisted and the result queries fail.
+func NewServer(service *validation.ValidationService, results storage.ResultStore, detections storage.DetectionStore) (*Server, error) {
+    s := &Server{
+        service:    service,
+        results:    results,
+        detections: detections,
+        log:        logger.GetLogger(),
+    }
+    schema, err := s.newSchema()
+    if err != nil {
+        return nil, fmt.Errorf("building GraphQL schema: %w", err)
+    }
+    s.schema = schema
+    return s, nil
+}
+
+// SetTranslationMemory enables checking translations validated through the
+// validate mutation against the approved mappings of the calling tenant
+func (s *Server) SetTranslationMemory(store storage.TranslationMemoryStore) {
+    s.memory = store
+}
+
+// SetAuditLog records who validated what through the validate mutation in
+// the audit log
+func (s *Server) SetAuditLog(log *audit.Logger) {
+    s.audit = log
+}
+
+// ServeHTTP executes the GraphQL request in the body of a POST request.
+// Responses follow the GraphQL over HTTP convention: a request that could be
+// read is answered with 200 OK and any field errors in the errors list.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        w.Header().Set("Allow", http.MethodPost)
+        writeError(w, http.StatusMethodNotAllowed, "GraphQL requests must be POSTed")
+        return
+    }
+
+    var req Request
+    decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+    if err := decoder.Decode(&req); err != nil {
+        writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+    if req.Query == "" {
+        writeError(w, http.StatusBadRequest, "query is required")
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+    defer cancel()
+    tenantID := r.Header.Get(tenantHeader)
+    if t, ok := tenant.FromContext(ctx); ok {
+        tenantID = t.ID
+    }
+    ctx = withTenantID(ctx, tenantID)
+    ctx = withRemoteAddr(ctx, r.RemoteAddr)
+
+    result := graphql.Do(graphql.Params{
+        Schema:         s.schema,
+        RequestString:  req.Query,
+        VariableValues: req.Variables,
+        OperationName:  req.OperationName,
+        Context:        ctx,
+    })
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    if err := json.NewEncoder(w).Encode(result); err != nil {
+        s.log.Error("Failed to encode GraphQL response",
+            "error", err,
+        )
+    }
+}
+
+// writeError writes a request that could not be executed in the shape of
+// a GraphQL error response
+func writeError(w http.ResponseWriter, status int, message string) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    _ = json.NewEncoder(w).Encode(map[string]interface{}{
+        "errors": []map[string]string{{"message": message}},
+    })
+}
+
+// tenantContextKey stores the tenant of the request in the resolver context
+type tenantContextKey struct{}
+
+func withTenantID(ctx context.Context, tenantID string) context.Context {
+    return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// tenantID returns the tenant the request was made on behalf of
+func tenantID(ctx context.Context) string {
+    id, _ := ctx.Value(tenantContextKey{}).(string)
+    return id
+}
+
+// remoteAddrContextKey stores the address of the client in the resolver
+// context for the audit log
+type remoteAddrContextKey struct{}
+
+func withRemoteAddr(ctx context.Context, addr string) context.Context {
+    return context.WithValue(ctx, remoteAddrContextKey{}, addr)
+}
+
+// remoteAddr returns the address of the client that made the request
+func remoteAddr(ctx context.Context) string {
+    addr, _ := ctx.Value(remoteAddrContextKey{}).(string)
+    return addr
+}
@@ -0,0 +1,84 @@
+package grpcapi
+
+import (
+    "encoding/json"
+    "fmt"
+
+    "google.golang.org/protobuf/types/known/durationpb" // v1.31.0
+    "google.golang.org/protobuf/types/known/structpb"
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "validation-service/internal/api/grpcapi/validationv1"
+    "validation-service/internal/models"
+)
+
+// resultToProto converts a validation result to its protobuf form
+func resultToProto(result *models.ValidationResult) (*validationv1.ValidationResult, error) {
+    validatorConfig, err := toStruct(result.Metadata.ValidatorConfig)
+    if err != nil {
+        return nil, fmt.Errorf("converting validator config: %w", err)
+    }
+    details, err := toStruct(result.FormatSpecificDetails)
+    if err != nil {
+        return nil, fmt.Errorf("converting format details: %w", err)
+    }
+
+    pb := &validationv1.ValidationResult{
+        Id:              result.ID.String(),
+        CreatedAt:       timestamppb.New(result.CreatedAt),
+        Status:          result.Status,
+        ConfidenceScore: result.ConfidenceScore,
+        Issues:          make([]*validationv1.ValidationIssue, 0, len(result.Issues)),
+        SourceFormat:    result.SourceFormat,
+        TargetFormat:    result.TargetFormat,
+        Metadata: &validationv1.ValidationMetadata{
+            ValidatorVersion: result.Metadata.ValidatorVersion,
+            ValidatorConfig:  validatorConfig,
+            ValidationTime:   durationpb.New(result.Metadata.ValidationTime),
+            ValidatedFields:  result.Metadata.ValidatedFields,
+        },
+        FormatSpecificDetails: details,
+    }
+
+    for i := range result.Issues {
+        issue := &result.Issues[i]
+        issueMetadata, err := toStruct(issue.IssueMetadata)
+        if err != nil {
+            return nil, fmt.Errorf("converting metadata of issue %d: %w", i, err)
+        }
+        pb.Issues = append(pb.Issues, &validationv1.ValidationIssue{
+            Message:       issue.Message,
+            Severity:      issue.Severity,
+            Location:      issue.Location,
+            Line:          int32(issue.Line),
+            Column:        int32(issue.Column),
+            Timestamp:     timestamppb.New(issue.Timestamp),
+            IssueCode:     issue.IssueCode,
+            Remediation:   issue.Remediation,
+            IssueMetadata: issueMetadata,
+            Fixable:       issue.Fixable,
+            Category:      issue.Category,
+            Id:            issue.ID,
+            CausedBy:      issue.CausedBy,
+            RelatedTo:     issue.RelatedTo,
+        })
+    }
+    return pb, nil
+}
+
+// toStruct converts a free-form map via its JSON encoding, so values such as
+// typed slices and structs convert the same way as in the HTTP API
+func toStruct(m map[string]interface{}) (*structpb.Struct, error) {
+    if len(m) == 0 {
+        return nil, nil
+    }
+    data, err := json.Marshal(m)
+    if err != nil {
+        return nil, err
+    }
+    s := &structpb.Struct{}
+    if err := s.UnmarshalJSON(data); err != nil {
+        return nil, err
+    }
+    return s, nil
+}
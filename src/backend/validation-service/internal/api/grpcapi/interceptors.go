@@ -0,0 +1,158 @@
+package grpcapi
+
+import (
+    "context"
+    "time"
+
+    "go.opentelemetry.io/otel/trace" // v1.21.0
+    "google.golang.org/grpc"         // v1.59.0
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/status"
+
+    "validation-service/internal/api/grpcapi/validationv1"
+    "validation-service/internal/api/middleware"
+    "validation-service/internal/tenant"
+    "validation-service/pkg/logger"
+    "validation-service/pkg/metrics"
+)
+
+// methodScopes are the token scopes required by each RPC, matching the
+// scopes of the equivalent HTTP endpoints
+var methodScopes = map[string]string{
+    "/validation.v1.ValidationService/Validate":       middleware.ScopeValidateRead,
+    "/validation.v1.ValidationService/ValidateBatch":  middleware.ScopeJobsCreate,
+    "/validation.v1.ValidationService/ValidateStream": middleware.ScopeJobsCreate,
+}
+
+// ClaimsFromContext returns the claims of the authenticated caller
+func ClaimsFromContext(ctx context.Context) (*middleware.Claims, bool) {
+    return middleware.ClaimsFromContext(ctx)
+}
+
+// authenticate validates the bearer token in the "authorization" metadata
+// with the same rules as the HTTP API, checks it grants the scope of method
+// and resolves the tenant of the call, enforcing its rate limit and quota
+func authenticate(ctx context.Context, method string, tenants *tenant.Registry) (context.Context, error) {
+    // Correlate the call like the HTTP RequestID middleware: the caller's
+    // request ID, or the trace ID, is returned in the response header
+    id := middleware.ResolveRequestID(firstMetadataValue(ctx, requestIDMetadataKey), trace.SpanContextFromContext(ctx))
+    ctx = logger.WithCorrelationID(middleware.WithRequestID(ctx, id), id)
+    grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadataKey, id))
+
+    claims, err := middleware.AuthenticateBearer(ctx, firstMetadataValue(ctx, "authorization"))
+    if err != nil {
+        logger.GetLogger().Error("Token validation failed",
+            "error", err,
+            "transport", "grpc",
+        )
+        return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+    }
+    scope, ok := methodScopes[method]
+    if !ok {
+        scope = middleware.ScopeAdmin
+    }
+    if !claims.HasScope(scope) {
+        logger.GetLogger().Warn("Insufficient token scope",
+            "user_id", claims.UserId,
+            "required_scope", scope,
+            "method", method,
+        )
+        return nil, status.Errorf(codes.PermissionDenied, "token lacks required scope: %s", scope)
+    }
+    ctx = middleware.WithClaims(ctx, claims)
+
+    // The tenant claim of the token is authoritative, like on the HTTP API
+    t, err := middleware.ResolveTenant(tenants, claims, firstMetadataValue(ctx, tenantMetadataKey))
+    if err != nil {
+        logger.GetLogger().Warn("Call rejected by tenant check",
+            "error", err,
+            "user_id", claims.UserId,
+            "method", method,
+        )
+        return nil, status.Error(codes.PermissionDenied, err.Error())
+    }
+    if _, err := tenants.Allow(t); err != nil {
+        return nil, status.Error(codes.ResourceExhausted, err.Error())
+    }
+    ctx = tenant.WithTenant(ctx, t)
+
+    if t.ID != "" {
+        ctx = logger.WithTenantID(ctx, t.ID)
+    }
+    return ctx, nil
+}
+
+// unaryAuthInterceptor authenticates unary calls
+func unaryAuthInterceptor(tenants *tenant.Registry) grpc.UnaryServerInterceptor {
+    return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+        ctx, err := authenticate(ctx, info.FullMethod, tenants)
+        if err != nil {
+            return nil, err
+        }
+        return handler(ctx, req)
+    }
+}
+
+// authenticatedStream overrides the context of a server stream
+type authenticatedStream struct {
+    grpc.ServerStream
+    ctx context.Context
+}
+
+// Context returns the authenticated context
+func (s *authenticatedStream) Context() context.Context {
+    return s.ctx
+}
+
+// streamAuthInterceptor authenticates streaming calls
+func streamAuthInterceptor(tenants *tenant.Registry) grpc.StreamServerInterceptor {
+    return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+        ctx, err := authenticate(ss.Context(), info.FullMethod, tenants)
+        if err != nil {
+            return err
+        }
+        return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+    }
+}
+
+// unaryMetricsInterceptor records request, duration and error metrics keyed by
+// the target format of single validations
+func unaryMetricsInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+    format := "unknown"
+    if r, ok := req.(*validationv1.ValidateRequest); ok {
+        format = r.GetTargetDetection().GetFormat()
+    }
+
+    start := time.Now()
+    resp, err := handler(ctx, req)
+    recordCall(format, time.Since(start), err)
+    return resp, err
+}
+
+// streamMetricsInterceptor records metrics for streaming calls
+func streamMetricsInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+    start := time.Now()
+    err := handler(srv, ss)
+    recordCall("unknown", time.Since(start), err)
+    return err
+}
+
+// recordCall records a completed call. Formats outside the allowlist of the
+// metrics package are recorded as "other".
+func recordCall(format string, duration time.Duration, err error) {
+    _ = metrics.RecordValidationRequest(format)
+    _ = metrics.RecordValidationDuration(format, duration)
+    if err == nil {
+        return
+    }
+
+    errorType := "internal"
+    switch status.Code(err) {
+    case codes.InvalidArgument:
+        errorType = "validation"
+    case codes.Unauthenticated, codes.PermissionDenied:
+        errorType = "configuration"
+    }
+    _ = metrics.RecordValidationError(format, errorType)
+}
@@ -0,0 +1,347 @@
+// Package grpcapi exposes the validation service over gRPC for high-volume
+// internal callers. It shares the ValidationService, result store, tenant
+// remediation playbooks, authentication and metrics with the HTTP API.
+package grpcapi
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"                                                      // v1.4.0
+    "go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc" // v0.46.1
+    "google.golang.org/grpc"                                                      // v1.59.0
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/peer"
+    "google.golang.org/grpc/status"
+
+    "validation-service/internal/api/grpcapi/validationv1"
+    "validation-service/internal/api/middleware"
+    "validation-service/internal/audit"
+    "validation-service/internal/config"
+    "validation-service/internal/models"
+    "validation-service/internal/services/remediation"
+    "validation-service/internal/services/translationmemory"
+    "validation-service/internal/services/validation"
+    "validation-service/internal/storage"
+    "validation-service/internal/tenant"
+    "validation-service/pkg/logger"
+)
+
+const (
+    // maxBatchSize bounds the number of requests in a batch or stream call
+    maxBatchSize = 100
+    // maxBatchConcurrency bounds concurrent validations within one call
+    maxBatchConcurrency = 8
+    // requestTimeout matches the HTTP API request timeout
+    requestTimeout = 30 * time.Second
+    // maxMessageSize matches the HTTP API request size limit
+    maxMessageSize = 10 * 1024 * 1024
+
+    // tenantMetadataKey carries the tenant ID, like the X-Tenant-ID header
+    tenantMetadataKey = "x-tenant-id"
+    // requestIDMetadataKey carries a caller-supplied request ID
+    requestIDMetadataKey = "x-request-id"
+)
+
+// Server implements validationv1.ValidationServiceServer
+type Server struct {
+    validationv1.UnimplementedValidationServiceServer
+
+    service *validation.ValidationService
+    results storage.ResultStore
+    memory  storage.TranslationMemoryStore
+    tenants *tenant.Registry
+    audit   *audit.Logger
+}
+
+// NewServer creates a gRPC validation server. results may be nil to disable
+// persistence.
+func NewServer(service *validation.ValidationService, results storage.ResultStore) *Server {
+    return &Server{
+        service: service,
+        results: results,
+    }
+}
+
+// SetTranslationMemory enables checking translations against the approved
+// mappings of the calling tenant
+func (s *Server) SetTranslationMemory(store storage.TranslationMemoryStore) {
+    s.memory = store
+}
+
+// SetTenants enforces the rate limits and quotas of tenants, which have no
+// limits otherwise
+func (s *Server) SetTenants(tenants *tenant.Registry) {
+    s.tenants = tenants
+}
+
+// SetAuditLog records who validated what in the audit log
+func (s *Server) SetAuditLog(log *audit.Logger) {
+    s.audit = log
+}
+
+// NewGRPCServer creates a grpc.Server with the service registered and the
+// authentication and metrics interceptors installed. Calls are traced,
+// continuing the trace of an incoming traceparent metadata entry. Options
+// such as transport credentials are added to these.
+func NewGRPCServer(srv *Server, opts ...grpc.ServerOption) *grpc.Server {
+    server := grpc.NewServer(append([]grpc.ServerOption{
+        grpc.MaxRecvMsgSize(maxMessageSize),
+        grpc.StatsHandler(otelgrpc.NewServerHandler()),
+        grpc.ChainUnaryInterceptor(unaryAuthInterceptor(srv.tenants), unaryMetricsInterceptor),
+        grpc.ChainStreamInterceptor(streamAuthInterceptor(srv.tenants), streamMetricsInterceptor),
+    }, opts...)...)
+    validationv1.RegisterValidationServiceServer(server, srv)
+    return server
+}
+
+// Validate validates a single detection pair
+func (s *Server) Validate(ctx context.Context, req *validationv1.ValidateRequest) (*validationv1.ValidateResponse, error) {
+    result, err := s.validate(ctx, req)
+    if err != nil {
+        return nil, err
+    }
+    return &validationv1.ValidateResponse{
+        Result:    result,
+        RequestId: requestID(ctx),
+    }, nil
+}
+
+// ValidateBatch validates every pair and returns the results in request order
+func (s *Server) ValidateBatch(ctx context.Context, req *validationv1.ValidateBatchRequest) (*validationv1.ValidateBatchResponse, error) {
+    if err := checkBatchSize(req); err != nil {
+        return nil, err
+    }
+
+    results := make([]*validationv1.BatchResult, len(req.GetRequests()))
+    s.validateAll(ctx, req, func(result *validationv1.BatchResult) error {
+        results[result.Index] = result
+        return nil
+    })
+    if err := ctx.Err(); err != nil {
+        return nil, status.FromContextError(err).Err()
+    }
+
+    return &validationv1.ValidateBatchResponse{
+        Results:   results,
+        RequestId: requestID(ctx),
+    }, nil
+}
+
+// ValidateStream validates every pair, sending each result as it completes
+func (s *Server) ValidateStream(req *validationv1.ValidateBatchRequest, stream validationv1.ValidationService_ValidateStreamServer) error {
+    if err := checkBatchSize(req); err != nil {
+        return err
+    }
+
+    var mu sync.Mutex
+    var sendErr error
+    s.validateAll(stream.Context(), req, func(result *validationv1.BatchResult) error {
+        mu.Lock()
+        defer mu.Unlock()
+        if sendErr == nil {
+            sendErr = stream.Send(result)
+        }
+        return sendErr
+    })
+    if sendErr != nil {
+        return sendErr
+    }
+    if err := stream.Context().Err(); err != nil {
+        return status.FromContextError(err).Err()
+    }
+    return nil
+}
+
+// validateAll validates the batch with bounded concurrency, passing each
+// result to emit. Validation stops early once emit fails or ctx is done.
+func (s *Server) validateAll(ctx context.Context, req *validationv1.ValidateBatchRequest, emit func(*validationv1.BatchResult) error) {
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+
+    // Items waiting for a worker count towards the queue depth reported to
+    // autoscalers
+    requests := req.GetRequests()
+    s.service.QueueValidations(len(requests))
+
+    sem := make(chan struct{}, maxBatchConcurrency)
+    var wg sync.WaitGroup
+    for i, pair := range requests {
+        select {
+        case sem <- struct{}{}:
+            s.service.DequeueValidations(1)
+        case <-ctx.Done():
+            s.service.DequeueValidations(len(requests) - i)
+            wg.Wait()
+            return
+        }
+
+        wg.Add(1)
+        go func(index int, pair *validationv1.ValidateRequest) {
+            defer wg.Done()
+            defer func() { <-sem }()
+
+            item := &validationv1.BatchResult{Index: int32(index)}
+            result, err := s.validate(ctx, pair)
+            if err != nil {
+                item.Error = status.Convert(err).Message()
+            } else {
+                item.Result = result
+            }
+            if err := emit(item); err != nil {
+                cancel()
+            }
+        }(i, pair)
+    }
+    wg.Wait()
+}
+
+// validate runs a single validation the same way as POST /api/v1/validate:
+// tenant playbooks are applied and the result is persisted
+func (s *Server) validate(ctx context.Context, req *validationv1.ValidateRequest) (*validationv1.ValidationResult, error) {
+    source, err := detectionFromProto(req.GetSourceDetection())
+    if err != nil {
+        return nil, status.Errorf(codes.InvalidArgument, "source_detection: %v", err)
+    }
+    target, err := detectionFromProto(req.GetTargetDetection())
+    if err != nil {
+        return nil, status.Errorf(codes.InvalidArgument, "target_detection: %v", err)
+    }
+
+    ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+    defer cancel()
+
+    result, err := s.service.ValidateDetection(ctx, source, target)
+    s.auditValidation(ctx, source, target, result, err)
+    if err != nil {
+        if ctxErr := ctx.Err(); ctxErr != nil {
+            return nil, status.FromContextError(ctxErr).Err()
+        }
+        if errors.Is(err, validation.ErrFormatNotAllowed) {
+            return nil, status.Error(codes.PermissionDenied, err.Error())
+        }
+        logger.FromContext(ctx).Error("Validation failed",
+            "error", err,
+            "source_format", source.Format,
+            "target_format", target.Format,
+        )
+        return nil, status.Errorf(codes.Internal, "validation error: %v", err)
+    }
+
+    tenantID := tenantID(ctx)
+    if err := translationmemory.Check(ctx, s.memory, tenantID, source, target, result); err != nil {
+        logger.FromContext(ctx).Error("Failed to check translation memory",
+            "error", err,
+            "result_id", result.ID,
+        )
+    }
+    remediation.ApplyPlaybooks(result, remediation.PlaybooksForTenant(
+        config.GetConfig().Remediation, tenantID))
+    result.Metadata.RequestID = requestID(ctx)
+    result.Metadata.TraceID = middleware.TraceIDFromContext(ctx)
+
+    if s.results != nil {
+        if err := s.results.SaveResult(ctx, tenantID, result); err != nil {
+            logger.FromContext(ctx).Error("Failed to persist validation result",
+                "error", err,
+                "result_id", result.ID,
+            )
+        }
+    }
+
+    return resultToProto(result)
+}
+
+// auditValidation records a validation, successful or not, in the audit
+// log. A record that cannot be written is logged without failing the call.
+func (s *Server) auditValidation(ctx context.Context, source, target *models.Detection, result *models.ValidationResult, err error) {
+    if s.audit == nil {
+        return
+    }
+    rec := audit.ValidationRecord(source, target, result, err)
+    rec.Channel = audit.ChannelGRPC
+    rec.TenantID = tenantID(ctx)
+    rec.RequestID = middleware.RequestIDFromContext(ctx)
+    if claims, ok := ClaimsFromContext(ctx); ok {
+        rec.UserID = claims.UserId
+    }
+    if p, ok := peer.FromContext(ctx); ok {
+        rec.IP = p.Addr.String()
+    }
+    if err := s.audit.Record(rec); err != nil {
+        logger.FromContext(ctx).Error("Failed to write audit record",
+            "error", err,
+        )
+    }
+}
+
+// checkBatchSize rejects empty and oversized batches
+func checkBatchSize(req *validationv1.ValidateBatchRequest) error {
+    switch n := len(req.GetRequests()); {
+    case n == 0:
+        return status.Error(codes.InvalidArgument, "requests must not be empty")
+    case n > maxBatchSize:
+        return status.Errorf(codes.InvalidArgument, "batch exceeds %d requests", maxBatchSize)
+    }
+    return nil
+}
+
+// tenantID returns the tenant resolved for the call by the authentication
+// interceptor
+func tenantID(ctx context.Context) string {
+    return tenant.IDFromContext(ctx)
+}
+
+// requestID returns the request ID assigned by the authentication
+// interceptor, or generates one
+func requestID(ctx context.Context) string {
+    if id := middleware.RequestIDFromContext(ctx); id != "" {
+        return id
+    }
+    return uuid.NewString()
+}
+
+// firstMetadataValue returns the first incoming metadata value for key
+func firstMetadataValue(ctx context.Context, key string) string {
+    md, ok := metadata.FromIncomingContext(ctx)
+    if !ok {
+        return ""
+    }
+    if values := md.Get(key); len(values) > 0 {
+        return values[0]
+    }
+    return ""
+}
+
+// detectionFromProto converts and validates a protobuf detection
+func detectionFromProto(pb *validationv1.Detection) (*models.Detection, error) {
+    if pb == nil {
+        return nil, fmt.Errorf("detection is required")
+    }
+
+    detection, err := models.NewDetection(pb.GetContent(), pb.GetFormat())
+    if err != nil {
+        return nil, err
+    }
+    if pb.GetId() != "" {
+        if detection.ID, err = uuid.Parse(pb.GetId()); err != nil {
+            return nil, fmt.Errorf("invalid id: %w", err)
+        }
+    }
+    if pb.GetUserId() != "" {
+        if detection.UserID, err = uuid.Parse(pb.GetUserId()); err != nil {
+            return nil, fmt.Errorf("invalid user_id: %w", err)
+        }
+    }
+    if pb.GetCreatedAt() != nil {
+        detection.CreatedAt = pb.GetCreatedAt().AsTime()
+    }
+    if len(pb.GetMetadata()) > 0 {
+        detection.Metadata = pb.GetMetadata()
+    }
+    return detection, nil
+}
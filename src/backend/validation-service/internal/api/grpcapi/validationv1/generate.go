@@ -0,0 +1,6 @@
+// Package validationv1 contains the Go bindings generated from
+// proto/validation/v1/validation.proto. Generated files are committed;
+// regenerate them with go generate after changing the proto definitions.
+package validationv1
+
+//go:generate protoc --proto_path=../../../../proto --go_out=. --go_opt=module=validation-service/internal/api/grpcapi/validationv1 --go-grpc_out=. --go-grpc_opt=module=validation-service/internal/api/grpcapi/validationv1 validation/v1/validation.proto
@@ -0,0 +1,222 @@
+// Protocol buffer definitions for the validation service gRPC API. Messages
+// mirror the JSON models served over HTTP under /api/v1.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: validation/v1/validation.proto
+
+package validationv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ValidationService_Validate_FullMethodName       = "/validation.v1.ValidationService/Validate"
+	ValidationService_ValidateBatch_FullMethodName  = "/validation.v1.ValidationService/ValidateBatch"
+	ValidationService_ValidateStream_FullMethodName = "/validation.v1.ValidationService/ValidateStream"
+)
+
+// ValidationServiceClient is the client API for ValidationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ValidationServiceClient interface {
+	// Validate validates a single target detection against its source
+	Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*ValidateResponse, error)
+	// ValidateBatch validates up to 100 detection pairs and returns all results
+	ValidateBatch(ctx context.Context, in *ValidateBatchRequest, opts ...grpc.CallOption) (*ValidateBatchResponse, error)
+	// ValidateStream validates detection pairs and streams each result as soon
+	// as it is available, in completion order
+	ValidateStream(ctx context.Context, in *ValidateBatchRequest, opts ...grpc.CallOption) (ValidationService_ValidateStreamClient, error)
+}
+
+type validationServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewValidationServiceClient(cc grpc.ClientConnInterface) ValidationServiceClient {
+	return &validationServiceClient{cc}
+}
+
+func (c *validationServiceClient) Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*ValidateResponse, error) {
+	out := new(ValidateResponse)
+	err := c.cc.Invoke(ctx, ValidationService_Validate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *validationServiceClient) ValidateBatch(ctx context.Context, in *ValidateBatchRequest, opts ...grpc.CallOption) (*ValidateBatchResponse, error) {
+	out := new(ValidateBatchResponse)
+	err := c.cc.Invoke(ctx, ValidationService_ValidateBatch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *validationServiceClient) ValidateStream(ctx context.Context, in *ValidateBatchRequest, opts ...grpc.CallOption) (ValidationService_ValidateStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ValidationService_ServiceDesc.Streams[0], ValidationService_ValidateStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &validationServiceValidateStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ValidationService_ValidateStreamClient interface {
+	Recv() (*BatchResult, error)
+	grpc.ClientStream
+}
+
+type validationServiceValidateStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *validationServiceValidateStreamClient) Recv() (*BatchResult, error) {
+	m := new(BatchResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ValidationServiceServer is the server API for ValidationService service.
+// All implementations must embed UnimplementedValidationServiceServer
+// for forward compatibility
+type ValidationServiceServer interface {
+	// Validate validates a single target detection against its source
+	Validate(context.Context, *ValidateRequest) (*ValidateResponse, error)
+	// ValidateBatch validates up to 100 detection pairs and returns all results
+	ValidateBatch(context.Context, *ValidateBatchRequest) (*ValidateBatchResponse, error)
+	// ValidateStream validates detection pairs and streams each result as soon
+	// as it is available, in completion order
+	ValidateStream(*ValidateBatchRequest, ValidationService_ValidateStreamServer) error
+	mustEmbedUnimplementedValidationServiceServer()
+}
+
+// UnimplementedValidationServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedValidationServiceServer struct {
+}
+
+func (UnimplementedValidationServiceServer) Validate(context.Context, *ValidateRequest) (*ValidateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Validate not implemented")
+}
+func (UnimplementedValidationServiceServer) ValidateBatch(context.Context, *ValidateBatchRequest) (*ValidateBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateBatch not implemented")
+}
+func (UnimplementedValidationServiceServer) ValidateStream(*ValidateBatchRequest, ValidationService_ValidateStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method ValidateStream not implemented")
+}
+func (UnimplementedValidationServiceServer) mustEmbedUnimplementedValidationServiceServer() {}
+
+// UnsafeValidationServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ValidationServiceServer will
+// result in compilation errors.
+type UnsafeValidationServiceServer interface {
+	mustEmbedUnimplementedValidationServiceServer()
+}
+
+func RegisterValidationServiceServer(s grpc.ServiceRegistrar, srv ValidationServiceServer) {
+	s.RegisterService(&ValidationService_ServiceDesc, srv)
+}
+
+func _ValidationService_Validate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ValidationServiceServer).Validate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ValidationService_Validate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ValidationServiceServer).Validate(ctx, req.(*ValidateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ValidationService_ValidateBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ValidationServiceServer).ValidateBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ValidationService_ValidateBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ValidationServiceServer).ValidateBatch(ctx, req.(*ValidateBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ValidationService_ValidateStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ValidateBatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ValidationServiceServer).ValidateStream(m, &validationServiceValidateStreamServer{stream})
+}
+
+type ValidationService_ValidateStreamServer interface {
+	Send(*BatchResult) error
+	grpc.ServerStream
+}
+
+type validationServiceValidateStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *validationServiceValidateStreamServer) Send(m *BatchResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ValidationService_ServiceDesc is the grpc.ServiceDesc for ValidationService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ValidationService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "validation.v1.ValidationService",
+	HandlerType: (*ValidationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Validate",
+			Handler:    _ValidationService_Validate_Handler,
+		},
+		{
+			MethodName: "ValidateBatch",
+			Handler:    _ValidationService_ValidateBatch_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ValidateStream",
+			Handler:       _ValidationService_ValidateStream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "validation/v1/validation.proto",
+}
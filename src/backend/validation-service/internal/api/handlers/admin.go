@@ -0,0 +1,616 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "time"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/config"
+    "validation-service/internal/corpus"
+    "validation-service/internal/refdata"
+    "validation-service/internal/search"
+    "validation-service/internal/services/rescore"
+    "validation-service/internal/services/rulepack"
+    "validation-service/internal/storage"
+    "validation-service/internal/storage/encryption"
+    "validation-service/pkg/logger"
+)
+
+// SearchReindexer rebuilds the detection search index in the background
+type SearchReindexer interface {
+    StartReindex() error
+    ReindexStatus() search.ReindexStatus
+}
+
+// KeyRotator rotates content encryption keys and re-encrypts stored blobs in
+// the background
+type KeyRotator interface {
+    StartRotation(tenantIDs []string, includeShared bool) error
+    RotationStatus() encryption.RotationStatus
+}
+
+// CorpusImporter imports public rule repositories into the stored corpus in
+// the background
+type CorpusImporter interface {
+    StartImport(sources []string) error
+    ImportStatus() corpus.ImportStatus
+}
+
+// ResultRescorer recomputes the scores of stored results with the current
+// scoring policy in the background
+type ResultRescorer interface {
+    StartRescore(opts rescore.Options) error
+    RescoreStatus() rescore.Status
+}
+
+// RefDataReloader reloads reference datasets in the background
+type RefDataReloader interface {
+    StartReload(datasets []string) error
+    ReloadStatus() refdata.ReloadStatus
+}
+
+// RulePackManager installs, removes and reloads the rule packs checked by
+// validations
+type RulePackManager interface {
+    Packs() []*rulepack.Pack
+    Reload() error
+    Install(name string, data []byte) error
+    Remove(name string) error
+}
+
+// maxRulePackSize bounds the YAML of an installed rule pack
+const maxRulePackSize = 1 << 20 // 1MB
+
+// DeprecationReporter reports which clients still call deprecated routes
+type DeprecationReporter interface {
+    Report() []apimiddleware.DeprecatedRouteUsage
+}
+
+// DeprecationReportResponse lists the usage of every deprecated route
+type DeprecationReportResponse struct {
+    Routes []apimiddleware.DeprecatedRouteUsage `json:"routes"`
+}
+
+// ConfigResponse is the active configuration, with secrets masked, and the
+// outcome of the most recent reload
+type ConfigResponse struct {
+    Config config.Config       `json:"config"`
+    Reload config.ReloadStatus `json:"reload"`
+}
+
+// RotateKeysRequest selects the data keys to rotate. With no tenants and
+// include_shared unset, the keys of every tenant and the shared namespace are
+// rotated.
+type RotateKeysRequest struct {
+    Tenants       []string `json:"tenants,omitempty"`
+    IncludeShared bool     `json:"include_shared,omitempty"`
+}
+
+// CorpusImportRequest selects the corpus sources to import; every configured
+// source when empty
+type CorpusImportRequest struct {
+    Sources []string `json:"sources,omitempty"`
+}
+
+// RescoreRequest selects the stored results to re-score; every result when
+// empty
+type RescoreRequest struct {
+    TenantID     string `json:"tenant_id,omitempty"`
+    TargetFormat string `json:"target_format,omitempty"`
+    DryRun       bool   `json:"dry_run,omitempty"`
+}
+
+// RefDataReloadRequest selects the reference datasets to reload; every
+// dataset when empty
+type RefDataReloadRequest struct {
+    Datasets []string `json:"datasets,omitempty"`
+}
+
+// RulePackListResponse lists the rule packs in effect
+type RulePackListResponse struct {
+    Packs []*rulepack.Pack `json:"packs"`
+}
+
+// BlobGCResponse reports the outcome of a blob garbage collection run
+type BlobGCResponse struct {
+    Deleted int               `json:"deleted"`
+    Stats   storage.BlobStats `json:"stats"`
+}
+
+// AdminHandler serves operational endpoints for service administrators
+type AdminHandler struct {
+    reindexer    SearchReindexer
+    blobs        storage.BlobStore
+    blobGrace    time.Duration
+    rotator      KeyRotator
+    deprecations DeprecationReporter
+    importer     CorpusImporter
+    rescorer     ResultRescorer
+    refdata      RefDataReloader
+    audit        AuditLogReader
+    rulePacks    RulePackManager
+    log          *logger.Logger
+}
+
+// NewAdminHandler creates an admin handler. reindexer may be nil when no
+// search index is configured.
+func NewAdminHandler(reindexer SearchReindexer) *AdminHandler {
+    return &AdminHandler{
+        reindexer: reindexer,
+        log:       logger.GetLogger(),
+    }
+}
+
+// SetBlobStore enables the blob storage endpoints. Manual collection uses the
+// same grace period as the background collector.
+func (h *AdminHandler) SetBlobStore(blobs storage.BlobStore, grace time.Duration) {
+    h.blobs = blobs
+    h.blobGrace = grace
+}
+
+// SetKeyRotator enables the key rotation endpoints
+func (h *AdminHandler) SetKeyRotator(rotator KeyRotator) {
+    h.rotator = rotator
+}
+
+// SetDeprecationReporter enables the deprecated route usage report
+func (h *AdminHandler) SetDeprecationReporter(reporter DeprecationReporter) {
+    h.deprecations = reporter
+}
+
+// SetCorpusImporter enables the corpus import endpoints
+func (h *AdminHandler) SetCorpusImporter(importer CorpusImporter) {
+    h.importer = importer
+}
+
+// SetResultRescorer enables the result re-scoring endpoints
+func (h *AdminHandler) SetResultRescorer(rescorer ResultRescorer) {
+    h.rescorer = rescorer
+}
+
+// SetRefDataReloader enables the reference data reload endpoints
+func (h *AdminHandler) SetRefDataReloader(reloader RefDataReloader) {
+    h.refdata = reloader
+}
+
+// SetAuditLog enables the audit log query and verification endpoints
+func (h *AdminHandler) SetAuditLog(reader AuditLogReader) {
+    h.audit = reader
+}
+
+// SetRulePacks enables the rule pack endpoints
+func (h *AdminHandler) SetRulePacks(manager RulePackManager) {
+    h.rulePacks = manager
+}
+
+// RegisterRoutes registers the admin endpoints with the router
+func (h *AdminHandler) RegisterRoutes(r chi.Router) {
+    r.Post("/search/reindex", h.StartReindexHandler)
+    r.Get("/search/reindex", h.ReindexStatusHandler)
+    r.Get("/blobs", h.BlobStatsHandler)
+    r.Post("/blobs/gc", h.BlobGCHandler)
+    r.Post("/keys/rotate", h.StartKeyRotationHandler)
+    r.Get("/keys/rotate", h.KeyRotationStatusHandler)
+    r.Get("/deprecations", h.DeprecationReportHandler)
+    r.Post("/corpus/import", h.StartCorpusImportHandler)
+    r.Get("/corpus/import", h.CorpusImportStatusHandler)
+    r.Post("/results/rescore", h.StartRescoreHandler)
+    r.Get("/results/rescore", h.RescoreStatusHandler)
+    r.Post("/refdata/reload", h.StartRefDataReloadHandler)
+    r.Get("/refdata/reload", h.RefDataReloadStatusHandler)
+    r.Get("/rulepacks", h.ListRulePacksHandler)
+    r.Post("/rulepacks/reload", h.ReloadRulePacksHandler)
+    r.Put("/rulepacks/{name}", h.InstallRulePackHandler)
+    r.Delete("/rulepacks/{name}", h.RemoveRulePackHandler)
+    r.Get("/config", h.ConfigHandler)
+    r.Post("/config/reload", h.ReloadConfigHandler)
+    r.Get("/audit", h.AuditQueryHandler)
+    r.Get("/audit/verify", h.AuditVerifyHandler)
+}
+
+// StartReindexHandler starts a full rebuild of the search index
+func (h *AdminHandler) StartReindexHandler(w http.ResponseWriter, r *http.Request) {
+    if h.reindexer == nil {
+        writeError(w, r, http.StatusNotImplemented, "no search index configured")
+        return
+    }
+
+    if err := h.reindexer.StartReindex(); err != nil {
+        if errors.Is(err, search.ErrReindexRunning) {
+            writeError(w, r, http.StatusConflict, err.Error())
+            return
+        }
+        h.log.Error("Failed to start search reindex",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to start reindex")
+        return
+    }
+
+    h.log.Info("Search reindex started")
+    writeJSON(w, r, http.StatusAccepted, h.reindexer.ReindexStatus())
+}
+
+// ReindexStatusHandler reports the progress of the current or last reindex
+func (h *AdminHandler) ReindexStatusHandler(w http.ResponseWriter, r *http.Request) {
+    if h.reindexer == nil {
+        writeError(w, r, http.StatusNotImplemented, "no search index configured")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, h.reindexer.ReindexStatus())
+}
+
+// BlobStatsHandler reports rule content blob storage usage
+func (h *AdminHandler) BlobStatsHandler(w http.ResponseWriter, r *http.Request) {
+    if h.blobs == nil {
+        writeError(w, r, http.StatusNotImplemented, "no blob store configured")
+        return
+    }
+
+    stats, err := h.blobs.Stats(r.Context())
+    if err != nil {
+        h.log.Error("Failed to load blob stats",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to load blob stats")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, stats)
+}
+
+// BlobGCHandler deletes unreferenced blobs past the grace period
+func (h *AdminHandler) BlobGCHandler(w http.ResponseWriter, r *http.Request) {
+    if h.blobs == nil {
+        writeError(w, r, http.StatusNotImplemented, "no blob store configured")
+        return
+    }
+
+    deleted, err := h.blobs.CollectGarbage(r.Context(), h.blobGrace)
+    if err != nil {
+        h.log.Error("Blob garbage collection failed",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "blob garbage collection failed")
+        return
+    }
+    stats, err := h.blobs.Stats(r.Context())
+    if err != nil {
+        h.log.Error("Failed to load blob stats",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to load blob stats")
+        return
+    }
+
+    h.log.Info("Blob garbage collection completed",
+        "deleted", deleted,
+    )
+    writeJSON(w, r, http.StatusOK, BlobGCResponse{Deleted: deleted, Stats: stats})
+}
+
+// StartKeyRotationHandler rotates data keys and starts re-encrypting blobs
+func (h *AdminHandler) StartKeyRotationHandler(w http.ResponseWriter, r *http.Request) {
+    if h.rotator == nil {
+        writeError(w, r, http.StatusNotImplemented, "content encryption is not enabled")
+        return
+    }
+
+    var req RotateKeysRequest
+    if r.ContentLength != 0 {
+        if err := decodeJSONBody(r, &req); err != nil {
+            writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+            return
+        }
+    }
+    for _, tenantID := range req.Tenants {
+        if tenantID == "" {
+            writeError(w, r, http.StatusBadRequest, "tenant IDs must not be empty")
+            return
+        }
+    }
+
+    if err := h.rotator.StartRotation(req.Tenants, req.IncludeShared); err != nil {
+        if errors.Is(err, encryption.ErrRotationRunning) {
+            writeError(w, r, http.StatusConflict, err.Error())
+            return
+        }
+        h.log.Error("Failed to start key rotation",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to start key rotation")
+        return
+    }
+
+    h.log.Info("Key rotation started",
+        "tenants", len(req.Tenants),
+        "include_shared", req.IncludeShared,
+    )
+    writeJSON(w, r, http.StatusAccepted, h.rotator.RotationStatus())
+}
+
+// KeyRotationStatusHandler reports per-tenant progress of the current or last
+// key rotation
+func (h *AdminHandler) KeyRotationStatusHandler(w http.ResponseWriter, r *http.Request) {
+    if h.rotator == nil {
+        writeError(w, r, http.StatusNotImplemented, "content encryption is not enabled")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, h.rotator.RotationStatus())
+}
+
+// StartCorpusImportHandler starts importing community rule repositories
+func (h *AdminHandler) StartCorpusImportHandler(w http.ResponseWriter, r *http.Request) {
+    if h.importer == nil {
+        writeError(w, r, http.StatusNotImplemented, "corpus import is not enabled")
+        return
+    }
+
+    var req CorpusImportRequest
+    if r.ContentLength != 0 {
+        if err := decodeJSONBody(r, &req); err != nil {
+            writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+            return
+        }
+    }
+
+    if err := h.importer.StartImport(req.Sources); err != nil {
+        switch {
+        case errors.Is(err, corpus.ErrUnknownSource):
+            writeError(w, r, http.StatusBadRequest, err.Error())
+        case errors.Is(err, corpus.ErrImportRunning):
+            writeError(w, r, http.StatusConflict, err.Error())
+        default:
+            h.log.Error("Failed to start corpus import",
+                "error", err,
+            )
+            writeError(w, r, http.StatusInternalServerError, "failed to start corpus import")
+        }
+        return
+    }
+
+    h.log.Info("Corpus import started",
+        "sources", req.Sources,
+    )
+    writeJSON(w, r, http.StatusAccepted, h.importer.ImportStatus())
+}
+
+// CorpusImportStatusHandler reports per-source progress of the current or
+// last corpus import
+func (h *AdminHandler) CorpusImportStatusHandler(w http.ResponseWriter, r *http.Request) {
+    if h.importer == nil {
+        writeError(w, r, http.StatusNotImplemented, "corpus import is not enabled")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, h.importer.ImportStatus())
+}
+
+// StartRescoreHandler starts re-scoring stored results with the current
+// scoring policy
+func (h *AdminHandler) StartRescoreHandler(w http.ResponseWriter, r *http.Request) {
+    if h.rescorer == nil {
+        writeError(w, r, http.StatusNotImplemented, "result re-scoring is not enabled")
+        return
+    }
+
+    var req RescoreRequest
+    if r.ContentLength != 0 {
+        if err := decodeJSONBody(r, &req); err != nil {
+            writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+            return
+        }
+    }
+
+    opts := rescore.Options{TenantID: req.TenantID, TargetFormat: req.TargetFormat, DryRun: req.DryRun}
+    if err := h.rescorer.StartRescore(opts); err != nil {
+        if errors.Is(err, rescore.ErrRescoreRunning) {
+            writeError(w, r, http.StatusConflict, err.Error())
+            return
+        }
+        h.log.Error("Failed to start result re-scoring",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to start re-scoring")
+        return
+    }
+
+    h.log.Info("Result re-scoring started",
+        "tenant_id", req.TenantID,
+        "target_format", req.TargetFormat,
+        "dry_run", req.DryRun,
+    )
+    writeJSON(w, r, http.StatusAccepted, h.rescorer.RescoreStatus())
+}
+
+// RescoreStatusHandler reports the progress of the current or last
+// re-scoring run
+func (h *AdminHandler) RescoreStatusHandler(w http.ResponseWriter, r *http.Request) {
+    if h.rescorer == nil {
+        writeError(w, r, http.StatusNotImplemented, "result re-scoring is not enabled")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, h.rescorer.RescoreStatus())
+}
+
+// StartRefDataReloadHandler starts reloading reference datasets. Datasets
+// that fail to load keep their previous version.
+func (h *AdminHandler) StartRefDataReloadHandler(w http.ResponseWriter, r *http.Request) {
+    if h.refdata == nil {
+        writeError(w, r, http.StatusNotImplemented, "reference data reload is not enabled")
+        return
+    }
+
+    var req RefDataReloadRequest
+    if r.ContentLength != 0 {
+        if err := decodeJSONBody(r, &req); err != nil {
+            writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+            return
+        }
+    }
+
+    if err := h.refdata.StartReload(req.Datasets); err != nil {
+        switch {
+        case errors.Is(err, refdata.ErrUnknownDataset):
+            writeError(w, r, http.StatusBadRequest, err.Error())
+        case errors.Is(err, refdata.ErrReloadRunning):
+            writeError(w, r, http.StatusConflict, err.Error())
+        default:
+            h.log.Error("Failed to start reference data reload",
+                "error", err,
+            )
+            writeError(w, r, http.StatusInternalServerError, "failed to start reference data reload")
+        }
+        return
+    }
+
+    h.log.Info("Reference data reload started",
+        "datasets", req.Datasets,
+    )
+    writeJSON(w, r, http.StatusAccepted, h.refdata.ReloadStatus())
+}
+
+// RefDataReloadStatusHandler reports the per-dataset outcome of the current
+// or last reference data reload
+func (h *AdminHandler) RefDataReloadStatusHandler(w http.ResponseWriter, r *http.Request) {
+    if h.refdata == nil {
+        writeError(w, r, http.StatusNotImplemented, "reference data reload is not enabled")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, h.refdata.ReloadStatus())
+}
+
+// ListRulePacksHandler lists the rule packs in effect with their rules
+func (h *AdminHandler) ListRulePacksHandler(w http.ResponseWriter, r *http.Request) {
+    if h.rulePacks == nil {
+        writeError(w, r, http.StatusNotImplemented, "no rule pack directory configured")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, &RulePackListResponse{Packs: h.rulePacks.Packs()})
+}
+
+// ReloadRulePacksHandler reads the rule pack directory again. When a pack is
+// invalid the request is rejected with 422 and the packs in effect are kept.
+func (h *AdminHandler) ReloadRulePacksHandler(w http.ResponseWriter, r *http.Request) {
+    if h.rulePacks == nil {
+        writeError(w, r, http.StatusNotImplemented, "no rule pack directory configured")
+        return
+    }
+    if err := h.rulePacks.Reload(); err != nil {
+        h.rulePackError(w, r, "reload", "", err)
+        return
+    }
+    packs := h.rulePacks.Packs()
+    h.log.Info("Rule packs reloaded",
+        "packs", rulepack.Names(packs),
+    )
+    writeJSON(w, r, http.StatusOK, &RulePackListResponse{Packs: packs})
+}
+
+// InstallRulePackHandler validates the YAML rule pack of the body and writes
+// it to the rule pack directory, replacing the pack of the same name
+func (h *AdminHandler) InstallRulePackHandler(w http.ResponseWriter, r *http.Request) {
+    if h.rulePacks == nil {
+        writeError(w, r, http.StatusNotImplemented, "no rule pack directory configured")
+        return
+    }
+    data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRulePackSize))
+    if err != nil {
+        writeError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("rule pack exceeds %d bytes", maxRulePackSize))
+        return
+    }
+
+    name := chi.URLParam(r, "name")
+    if err := h.rulePacks.Install(name, data); err != nil {
+        h.rulePackError(w, r, "install", name, err)
+        return
+    }
+    h.log.Info("Rule pack installed",
+        "pack", name,
+    )
+    writeJSON(w, r, http.StatusOK, &RulePackListResponse{Packs: h.rulePacks.Packs()})
+}
+
+// RemoveRulePackHandler deletes a rule pack from the rule pack directory
+func (h *AdminHandler) RemoveRulePackHandler(w http.ResponseWriter, r *http.Request) {
+    if h.rulePacks == nil {
+        writeError(w, r, http.StatusNotImplemented, "no rule pack directory configured")
+        return
+    }
+    name := chi.URLParam(r, "name")
+    if err := h.rulePacks.Remove(name); err != nil {
+        h.rulePackError(w, r, "remove", name, err)
+        return
+    }
+    h.log.Info("Rule pack removed",
+        "pack", name,
+    )
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// rulePackError writes the response of a failed rule pack change
+func (h *AdminHandler) rulePackError(w http.ResponseWriter, r *http.Request, action, name string, err error) {
+    switch {
+    case errors.Is(err, rulepack.ErrPackNotFound):
+        writeError(w, r, http.StatusNotFound, err.Error())
+    case errors.Is(err, rulepack.ErrInvalidPack):
+        writeError(w, r, http.StatusUnprocessableEntity, err.Error())
+    default:
+        h.log.Error("Failed to "+action+" rule packs",
+            "error", err,
+            "pack", name,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to "+action+" rule packs")
+    }
+}
+
+// DeprecationReportHandler lists the clients, by API key fingerprint, that
+// still call deprecated routes
+func (h *AdminHandler) DeprecationReportHandler(w http.ResponseWriter, r *http.Request) {
+    if h.deprecations == nil {
+        writeError(w, r, http.StatusNotImplemented, "no deprecated routes configured")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, DeprecationReportResponse{Routes: h.deprecations.Report()})
+}
+
+// ConfigHandler returns the active configuration
+func (h *AdminHandler) ConfigHandler(w http.ResponseWriter, r *http.Request) {
+    cfg := config.GetConfig()
+    if cfg == nil {
+        writeError(w, r, http.StatusServiceUnavailable, "configuration has not been loaded")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, ConfigResponse{Config: cfg.Redacted(), Reload: config.LastReload()})
+}
+
+// ReloadConfigHandler re-reads the configuration file and applies the settings
+// that can change at runtime. An invalid configuration is rejected with 422
+// and the active configuration is kept.
+func (h *AdminHandler) ReloadConfigHandler(w http.ResponseWriter, r *http.Request) {
+    status, err := config.Reload(config.ReloadTriggerAPI)
+    userID := ""
+    if claims, ok := apimiddleware.ClaimsFromContext(r.Context()); ok {
+        userID = claims.UserId
+    }
+    if err != nil {
+        h.log.Warn("Configuration reload rejected",
+            "error", err,
+            "user_id", userID,
+        )
+        writeError(w, r, http.StatusUnprocessableEntity, err.Error())
+        return
+    }
+
+    h.log.Info("Configuration reloaded",
+        "trigger", status.Trigger,
+        "applied", status.Applied,
+        "requires_restart", status.RequiresRestart,
+        "user_id", userID,
+    )
+    writeJSON(w, r, http.StatusOK, status)
+}
@@ -0,0 +1,341 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "archive/tar"
+    "archive/zip"
+    "bytes"
+    "compress/gzip"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "path"
+    "strings"
+    "time"
+
+    "github.com/google/uuid" // v1.4.0
+
+    "validation-service/internal/config"
+    "validation-service/internal/models"
+    "validation-service/internal/spool"
+    "validation-service/internal/storage"
+    "validation-service/pkg/mitre"
+)
+
+// Archive import limits
+const (
+    maxArchiveEntries = 10000
+    // maxArchiveExpansion bounds the uncompressed size of an archive relative
+    // to the upload size limit, guarding against decompression bombs
+    maxArchiveExpansion = 4
+    // maxReportedSkips bounds the skipped entries listed in a response
+    maxReportedSkips = 100
+)
+
+// Tags recording the import a detection came from and its path in the
+// archive
+const (
+    TagArchiveImport = "archive.import"
+    TagArchivePath   = "archive.path"
+)
+
+// archiveFormats maps rule file extensions to detection formats
+var archiveFormats = map[string]string{
+    ".yml":   models.DetectionFormatSigma,
+    ".yaml":  models.DetectionFormatSigma,
+    ".spl":   models.DetectionFormatSplunk,
+    ".kql":   models.DetectionFormatKQL,
+    ".aql":   models.DetectionFormatQRadar,
+    ".yar":   models.DetectionFormatYara,
+    ".yara":  models.DetectionFormatYara,
+    ".yaral": models.DetectionFormatYaraL,
+}
+
+// ArchiveEntryError describes an archive entry that was not imported
+type ArchiveEntryError struct {
+    Path  string `json:"path"`
+    Error string `json:"error"`
+}
+
+// ArchiveImportResponse reports the outcome of an archive import. ImportID
+// selects the imported rules for the aggregate endpoint; Validated counts
+// the rules validated when validation was requested.
+type ArchiveImportResponse struct {
+    ImportID     uuid.UUID           `json:"import_id"`
+    Imported     int                 `json:"imported"`
+    Validated    int                 `json:"validated,omitempty"`
+    DetectionIDs []uuid.UUID         `json:"detection_ids"`
+    SkippedTotal int                 `json:"skipped_total"`
+    Skipped      []ArchiveEntryError `json:"skipped"`
+}
+
+// archiveEntry is a regular file read from an archive
+type archiveEntry struct {
+    name    string
+    content []byte
+}
+
+// SetSpooler enables archive uploads, buffered through spooler
+func (h *DetectionHandler) SetSpooler(spooler *spool.Spooler) {
+    h.spooler = spooler
+}
+
+// ImportArchiveHandler stores every rule in an uploaded zip or (gzipped) tar
+// archive. Uploads above the memory threshold are spooled to disk. The
+// format of each rule is taken from the format query parameter or inferred
+// from its file extension. With validate=true every rule is validated as
+// it is stored, scanning the repository for ArchiveAggregateHandler.
+func (h *DetectionHandler) ImportArchiveHandler(w http.ResponseWriter, r *http.Request) {
+    if h.spooler == nil {
+        writeError(w, r, http.StatusNotImplemented, "archive uploads are not enabled")
+        return
+    }
+
+    format := r.URL.Query().Get("format")
+    if format != "" && !supportedFormat(format) {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("unsupported format: %s", format))
+        return
+    }
+    license := strings.TrimSpace(r.URL.Query().Get("license"))
+    if len(license) > maxTagLength {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("license exceeds %d characters", maxTagLength))
+        return
+    }
+    validate, err := parseBoolParam(r, "validate")
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    if r.ContentLength > h.spooler.MaxSize() {
+        writeError(w, r, http.StatusRequestEntityTooLarge, spool.ErrTooLarge.Error())
+        return
+    }
+
+    tenantID := tenantIDFromRequest(r)
+    upload, err := h.spooler.Spool(r.Context(), tenantID, r.Body)
+    switch {
+    case errors.Is(err, spool.ErrTooLarge):
+        writeError(w, r, http.StatusRequestEntityTooLarge, err.Error())
+        return
+    case errors.Is(err, spool.ErrQuotaExceeded):
+        w.Header().Set("Retry-After", "30")
+        writeError(w, r, http.StatusTooManyRequests, err.Error())
+        return
+    case err != nil:
+        h.log.Error("Failed to buffer archive upload",
+            "error", err,
+            "tenant_id", tenantID,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to read upload")
+        return
+    }
+    defer upload.Close()
+
+    resp := &ArchiveImportResponse{ImportID: uuid.New(), DetectionIDs: []uuid.UUID{}, Skipped: []ArchiveEntryError{}}
+    skip := func(name string, err error) {
+        resp.SkippedTotal++
+        if len(resp.Skipped) < maxReportedSkips {
+            resp.Skipped = append(resp.Skipped, ArchiveEntryError{Path: name, Error: err.Error()})
+        }
+    }
+
+    err = readArchive(upload, r.Header.Get("Content-Type"), maxArchiveExpansion*h.spooler.MaxSize(), func(entry archiveEntry) error {
+        entryFormat := format
+        if entryFormat == "" {
+            entryFormat = archiveFormats[strings.ToLower(path.Ext(entry.name))]
+        }
+        if entryFormat == "" {
+            skip(entry.name, errors.New("unrecognized rule file extension"))
+            return nil
+        }
+
+        stored, err := h.storeArchiveEntry(r.Context(), tenantID, license, entryFormat, resp.ImportID, validate, entry)
+        if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+            return err
+        }
+        if err != nil {
+            skip(entry.name, err)
+            return nil
+        }
+        resp.Imported++
+        resp.DetectionIDs = append(resp.DetectionIDs, stored.ID())
+        if stored.Validation != nil {
+            resp.Validated++
+        }
+        return nil
+    })
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid archive: %v", err))
+        return
+    }
+
+    h.log.Info("Imported detection archive",
+        "tenant_id", tenantID,
+        "import_id", resp.ImportID,
+        "imported", resp.Imported,
+        "skipped", resp.SkippedTotal,
+        "validated", resp.Validated,
+        "size_bytes", upload.Size(),
+        "spooled_to_disk", upload.OnDisk(),
+    )
+    writeJSON(w, r, http.StatusOK, resp)
+}
+
+// storeArchiveEntry checks and stores one rule from an archive, tagged with
+// the import and its path, validating it first when validate is set
+func (h *DetectionHandler) storeArchiveEntry(ctx context.Context, tenantID, license, format string, importID uuid.UUID, validate bool, entry archiveEntry) (*storage.StoredDetection, error) {
+    if maxRuleSize := config.GetConfig().Validation.MaxRuleSize; maxRuleSize > 0 && len(entry.content) > maxRuleSize {
+        return nil, fmt.Errorf("rule exceeds %d bytes", maxRuleSize)
+    }
+
+    content := string(entry.content)
+    detection, err := models.NewDetection(content, format)
+    if err != nil {
+        return nil, err
+    }
+
+    stored := &storage.StoredDetection{
+        Detection: detection,
+        TenantID:  tenantID,
+        Name:      strings.TrimSuffix(path.Base(entry.name), path.Ext(entry.name)),
+        License:   license,
+        Tags: map[string]string{
+            TagArchiveImport: importID.String(),
+            TagArchivePath:   entry.name,
+        },
+        Techniques: mitre.ExtractTechniques(content),
+        UpdatedAt:  time.Now().UTC(),
+    }
+    if validate {
+        h.validateVersion(ctx, stored)
+    }
+    if err := h.store.CreateDetection(ctx, stored); err != nil {
+        h.log.Error("Failed to store archived detection",
+            "error", err,
+            "path", entry.name,
+        )
+        return nil, errors.New("failed to store detection")
+    }
+    return stored, nil
+}
+
+// readArchive calls fn for each regular file of a zip, tar or gzipped tar
+// archive. The archive type is taken from contentType or sniffed from the
+// content. maxExpanded bounds the total uncompressed size.
+func readArchive(upload *spool.File, contentType string, maxExpanded int64, fn func(archiveEntry) error) error {
+    magic := make([]byte, 4)
+    n, _ := upload.ReadAt(magic, 0)
+    magic = magic[:n]
+
+    // gzip is matched first, as its media types also contain zip
+    switch {
+    case strings.Contains(contentType, "gzip") || bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+        gz, err := gzip.NewReader(upload)
+        if err != nil {
+            return err
+        }
+        defer gz.Close()
+        return readTar(gz, maxExpanded, fn)
+    case strings.Contains(contentType, "zip") || bytes.HasPrefix(magic, []byte("PK\x03\x04")):
+        return readZip(upload, maxExpanded, fn)
+    case strings.Contains(contentType, "tar"):
+        return readTar(upload, maxExpanded, fn)
+    default:
+        return errors.New("unsupported archive type; upload a zip, tar or tar.gz archive")
+    }
+}
+
+// readZip reads the regular files of a zip archive
+func readZip(upload *spool.File, maxExpanded int64, fn func(archiveEntry) error) error {
+    archive, err := zip.NewReader(upload, upload.Size())
+    if err != nil {
+        return err
+    }
+    if len(archive.File) > maxArchiveEntries {
+        return fmt.Errorf("archive has more than %d entries", maxArchiveEntries)
+    }
+
+    budget := maxExpanded
+    for _, file := range archive.File {
+        if !file.Mode().IsRegular() || ignoredArchivePath(file.Name) {
+            continue
+        }
+        rc, err := file.Open()
+        if err != nil {
+            return fmt.Errorf("%s: %w", file.Name, err)
+        }
+        content, err := readEntry(rc, &budget)
+        rc.Close()
+        if err != nil {
+            return fmt.Errorf("%s: %w", file.Name, err)
+        }
+        if err := fn(archiveEntry{name: file.Name, content: content}); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// readTar reads the regular files of a tar stream
+func readTar(r io.Reader, maxExpanded int64, fn func(archiveEntry) error) error {
+    archive := tar.NewReader(r)
+    budget := maxExpanded
+    for entries := 0; ; entries++ {
+        header, err := archive.Next()
+        if err == io.EOF {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        if entries >= maxArchiveEntries {
+            return fmt.Errorf("archive has more than %d entries", maxArchiveEntries)
+        }
+        if header.Typeflag != tar.TypeReg || ignoredArchivePath(header.Name) {
+            continue
+        }
+        content, err := readEntry(archive, &budget)
+        if err != nil {
+            return fmt.Errorf("%s: %w", header.Name, err)
+        }
+        if err := fn(archiveEntry{name: header.Name, content: content}); err != nil {
+            return err
+        }
+    }
+}
+
+// readEntry reads an entry, charging its size against the expansion budget
+func readEntry(r io.Reader, budget *int64) ([]byte, error) {
+    content, err := io.ReadAll(io.LimitReader(r, *budget+1))
+    if err != nil {
+        return nil, err
+    }
+    if int64(len(content)) > *budget {
+        return nil, errors.New("archive expands beyond the allowed size")
+    }
+    *budget -= int64(len(content))
+    return content, nil
+}
+
+// supportedFormat reports whether format is enabled in the configuration
+func supportedFormat(format string) bool {
+    for _, supported := range config.GetConfig().Validation.SupportedFormats {
+        if supported == format {
+            return true
+        }
+    }
+    return false
+}
+
+// ignoredArchivePath reports archive metadata and hidden files, such as
+// __MACOSX/ resource forks and .github/ workflows, that are not rules
+func ignoredArchivePath(name string) bool {
+    for _, part := range strings.Split(name, "/") {
+        if strings.HasPrefix(part, ".") && part != "." && part != ".." || part == "__MACOSX" {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+    "fmt"
+    "net/http"
+    "path"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+    "github.com/google/uuid"   // v1.4.0
+
+    "validation-service/internal/services/validation"
+    "validation-service/internal/storage"
+)
+
+// Aggregate list sizes
+const (
+    defaultAggregateLimit = 10
+    maxAggregateLimit     = 100
+)
+
+// ArchiveAggregateResponse summarizes the validation of the rules of an
+// archive import, so problems shared by many rules can be fixed at once
+type ArchiveAggregateResponse struct {
+    ImportID uuid.UUID `json:"import_id"`
+    // Rules counts the imported rules; Validated those with a validation
+    Rules     int            `json:"rules"`
+    Validated int            `json:"validated"`
+    MeanScore float64        `json:"mean_score"`
+    Statuses  map[string]int `json:"statuses"`
+    // IssueCodes are the most common issue codes, by the number of rules
+    // reporting them
+    IssueCodes []IssueCodeFrequency `json:"issue_codes"`
+    // LowestScoring are the validated rules with the lowest scores
+    LowestScoring []ArchiveRuleScore `json:"lowest_scoring"`
+    // Directories average the scores of the rules under each directory of
+    // the archive, lowest first
+    Directories []DirectoryScore `json:"directories"`
+    // Stale counts the rules past their review-by or expiry date;
+    // StaleRules lists them, longest overdue first
+    Stale      int         `json:"stale"`
+    StaleRules []StaleRule `json:"stale_rules"`
+}
+
+// IssueCodeFrequency counts an issue code across the rules of an import
+type IssueCodeFrequency struct {
+    IssueCode string `json:"issue_code"`
+    // Rules counts the rules reporting the code; Count every occurrence
+    Rules int `json:"rules"`
+    Count int `json:"count"`
+}
+
+// ArchiveRuleScore is the validation outcome of one imported rule
+type ArchiveRuleScore struct {
+    Path            string    `json:"path"`
+    DetectionID     uuid.UUID `json:"detection_id"`
+    ResultID        uuid.UUID `json:"result_id"`
+    Status          string    `json:"status"`
+    ConfidenceScore float64   `json:"confidence_score"`
+    Issues          int       `json:"issues"`
+}
+
+// StaleRule is an imported rule past its review-by or expiry date
+type StaleRule struct {
+    Path        string    `json:"path"`
+    DetectionID uuid.UUID `json:"detection_id"`
+    // Status is review_due or expired; Due is the date that passed
+    Status string    `json:"status"`
+    Due    time.Time `json:"due"`
+}
+
+// DirectoryScore averages the scores of the validated rules under a
+// directory, including its subdirectories
+type DirectoryScore struct {
+    Path      string  `json:"path"`
+    Rules     int     `json:"rules"`
+    MeanScore float64 `json:"mean_score"`
+    MinScore  float64 `json:"min_score"`
+}
+
+// ArchiveAggregateHandler aggregates the validation results of the rules of
+// an archive import imported with validate=true: the most common issue
+// codes, the lowest scoring rules, the mean score of each directory and the
+// rules past their review-by or expiry date. limit bounds the issue codes
+// and rules listed.
+func (h *DetectionHandler) ArchiveAggregateHandler(w http.ResponseWriter, r *http.Request) {
+    importID, err := uuid.Parse(chi.URLParam(r, "importID"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid import ID")
+        return
+    }
+    limit := defaultAggregateLimit
+    if value := r.URL.Query().Get("limit"); value != "" {
+        limit, err = strconv.Atoi(value)
+        if err != nil || limit < 1 || limit > maxAggregateLimit {
+            writeError(w, r, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxAggregateLimit))
+            return
+        }
+    }
+
+    query := storage.DetectionQuery{
+        TenantID: tenantIDFromRequest(r),
+        Tags:     map[string]string{TagArchiveImport: importID.String()},
+        Limit:    storage.MaxSearchLimit,
+    }
+    var detections []*storage.StoredDetection
+    for {
+        page, total, err := h.store.SearchDetections(r.Context(), query)
+        if err != nil {
+            h.log.Error("Failed to search archive import detections",
+                "error", err,
+                "import_id", importID,
+            )
+            writeError(w, r, http.StatusInternalServerError, "failed to aggregate archive import")
+            return
+        }
+        detections = append(detections, page...)
+        query.Offset += len(page)
+        if len(page) == 0 || query.Offset >= total {
+            break
+        }
+    }
+    if len(detections) == 0 {
+        writeError(w, r, http.StatusNotFound, "archive import not found")
+        return
+    }
+
+    resp := aggregateArchive(detections, limit, time.Now())
+    resp.ImportID = importID
+    writeJSON(w, r, http.StatusOK, resp)
+}
+
+// aggregateArchive summarizes the validations of imported detections and
+// lists those stale at now
+func aggregateArchive(detections []*storage.StoredDetection, limit int, now time.Time) *ArchiveAggregateResponse {
+    resp := &ArchiveAggregateResponse{
+        Rules:         len(detections),
+        Statuses:      map[string]int{},
+        IssueCodes:    []IssueCodeFrequency{},
+        LowestScoring: []ArchiveRuleScore{},
+        Directories:   []DirectoryScore{},
+        StaleRules:    []StaleRule{},
+    }
+
+    codes := make(map[string]*IssueCodeFrequency)
+    directories := make(map[string]*DirectoryScore)
+    var total float64
+    for _, stored := range detections {
+        rulePath := archivePath(stored)
+        if stale, ok := staleRule(stored, now); ok {
+            stale.Path = rulePath
+            resp.StaleRules = append(resp.StaleRules, stale)
+        }
+
+        validation := stored.Validation
+        if validation == nil {
+            continue
+        }
+        resp.Validated++
+        resp.Statuses[validation.Status]++
+        total += validation.ConfidenceScore
+
+        for code, count := range validation.IssueCodes {
+            frequency, ok := codes[code]
+            if !ok {
+                frequency = &IssueCodeFrequency{IssueCode: code}
+                codes[code] = frequency
+            }
+            frequency.Rules++
+            frequency.Count += count
+        }
+
+        resp.LowestScoring = append(resp.LowestScoring, ArchiveRuleScore{
+            Path:            rulePath,
+            DetectionID:     stored.ID(),
+            ResultID:        validation.ResultID,
+            Status:          validation.Status,
+            ConfidenceScore: validation.ConfidenceScore,
+            Issues:          validation.Issues,
+        })
+
+        // Count the rule in every directory above it
+        for dir := path.Dir(rulePath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+            score, ok := directories[dir]
+            if !ok {
+                score = &DirectoryScore{Path: dir, MinScore: validation.ConfidenceScore}
+                directories[dir] = score
+            }
+            score.Rules++
+            score.MeanScore += validation.ConfidenceScore
+            if validation.ConfidenceScore < score.MinScore {
+                score.MinScore = validation.ConfidenceScore
+            }
+        }
+    }
+    if resp.Validated > 0 {
+        resp.MeanScore = total / float64(resp.Validated)
+    }
+
+    for _, frequency := range codes {
+        resp.IssueCodes = append(resp.IssueCodes, *frequency)
+    }
+    sort.Slice(resp.IssueCodes, func(i, j int) bool {
+        a, b := resp.IssueCodes[i], resp.IssueCodes[j]
+        if a.Rules != b.Rules {
+            return a.Rules > b.Rules
+        }
+        if a.Count != b.Count {
+            return a.Count > b.Count
+        }
+        return a.IssueCode < b.IssueCode
+    })
+    if len(resp.IssueCodes) > limit {
+        resp.IssueCodes = resp.IssueCodes[:limit]
+    }
+
+    sort.Slice(resp.LowestScoring, func(i, j int) bool {
+        a, b := resp.LowestScoring[i], resp.LowestScoring[j]
+        if a.ConfidenceScore != b.ConfidenceScore {
+            return a.ConfidenceScore < b.ConfidenceScore
+        }
+        return a.Path < b.Path
+    })
+    if len(resp.LowestScoring) > limit {
+        resp.LowestScoring = resp.LowestScoring[:limit]
+    }
+
+    for _, score := range directories {
+        score.MeanScore /= float64(score.Rules)
+        resp.Directories = append(resp.Directories, *score)
+    }
+    sort.Slice(resp.Directories, func(i, j int) bool {
+        a, b := resp.Directories[i], resp.Directories[j]
+        if a.MeanScore != b.MeanScore {
+            return a.MeanScore < b.MeanScore
+        }
+        return a.Path < b.Path
+    })
+
+    resp.Stale = len(resp.StaleRules)
+    sort.Slice(resp.StaleRules, func(i, j int) bool {
+        a, b := resp.StaleRules[i], resp.StaleRules[j]
+        if !a.Due.Equal(b.Due) {
+            return a.Due.Before(b.Due)
+        }
+        return a.Path < b.Path
+    })
+    if len(resp.StaleRules) > limit {
+        resp.StaleRules = resp.StaleRules[:limit]
+    }
+    return resp
+}
+
+// staleRule reports whether an imported detection is past its review-by or
+// expiry date at now
+func staleRule(stored *storage.StoredDetection, now time.Time) (StaleRule, bool) {
+    if stored.Detection == nil {
+        return StaleRule{}, false
+    }
+    review := validation.ReviewDates(stored.Detection, stored.Detection.Format)
+    status := review.Status(now)
+    if status == "" {
+        return StaleRule{}, false
+    }
+    due := review.ReviewBy
+    if status == validation.RuleExpired {
+        due = review.Expires
+    }
+    return StaleRule{DetectionID: stored.ID(), Status: status, Due: *due}, true
+}
+
+// archivePath returns the cleaned archive path of an imported detection
+func archivePath(stored *storage.StoredDetection) string {
+    name := stored.Tags[TagArchivePath]
+    if name == "" {
+        return stored.Name
+    }
+    return path.Clean(strings.TrimPrefix(name, "/"))
+}
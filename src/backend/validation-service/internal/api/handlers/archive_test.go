@@ -0,0 +1,156 @@
+package handlers
+
+import (
+    "archive/tar"
+    "archive/zip"
+    "bytes"
+    "compress/gzip"
+    "context"
+    "fmt"
+    "reflect"
+    "strings"
+    "testing"
+
+    "validation-service/internal/spool"
+)
+
+// testFile is a file of a test archive
+type testFile struct {
+    name    string
+    content string
+}
+
+func zipArchive(t *testing.T, files []testFile) []byte {
+    t.Helper()
+    var buf bytes.Buffer
+    archive := zip.NewWriter(&buf)
+    for _, file := range files {
+        w, err := archive.Create(file.name)
+        if err != nil {
+            t.Fatalf("zip create: %v", err)
+        }
+        w.Write([]byte(file.content))
+    }
+    if err := archive.Close(); err != nil {
+        t.Fatalf("zip close: %v", err)
+    }
+    return buf.Bytes()
+}
+
+func tarArchive(t *testing.T, files []testFile, gzipped bool) []byte {
+    t.Helper()
+    var buf bytes.Buffer
+    var gz *gzip.Writer
+    archive := tar.NewWriter(&buf)
+    if gzipped {
+        gz = gzip.NewWriter(&buf)
+        archive = tar.NewWriter(gz)
+    }
+    for _, file := range files {
+        header := &tar.Header{Name: file.name, Mode: 0o644, Size: int64(len(file.content)), Typeflag: tar.TypeReg}
+        if err := archive.WriteHeader(header); err != nil {
+            t.Fatalf("tar header: %v", err)
+        }
+        archive.Write([]byte(file.content))
+    }
+    if err := archive.Close(); err != nil {
+        t.Fatalf("tar close: %v", err)
+    }
+    if gz != nil {
+        gz.Close()
+    }
+    return buf.Bytes()
+}
+
+func spoolUpload(t *testing.T, data []byte) *spool.File {
+    t.Helper()
+    spooler, err := spool.New(spool.Config{
+        Dir:             t.TempDir(),
+        MemoryThreshold: 1 << 20,
+        MaxSize:         64 << 20,
+        TenantQuota:     64 << 20,
+        TotalQuota:      64 << 20,
+    })
+    if err != nil {
+        t.Fatalf("spool.New: %v", err)
+    }
+    upload, err := spooler.Spool(context.Background(), "tenant", bytes.NewReader(data))
+    if err != nil {
+        t.Fatalf("Spool: %v", err)
+    }
+    t.Cleanup(func() { upload.Close() })
+    return upload
+}
+
+func TestReadArchive(t *testing.T) {
+    rules := []testFile{
+        {"rules/a.yml", strings.Repeat("a", 40)},
+        {"rules/b.spl", strings.Repeat("b", 60)},
+        {".github/workflows/ci.yml", "on: push"},
+        {"__MACOSX/rules/._a.yml", "fork"},
+    }
+    tooMany := make([]testFile, maxArchiveEntries+1)
+    for i := range tooMany {
+        tooMany[i] = testFile{name: fmt.Sprintf("rules/%d.yml", i)}
+    }
+
+    tests := []struct {
+        name        string
+        data        []byte
+        contentType string
+        maxExpanded int64
+        want        []string
+        wantErr     string
+    }{
+        {"zip", zipArchive(t, rules), "application/zip", 100, []string{"rules/a.yml", "rules/b.spl"}, ""},
+        {"zip sniffed", zipArchive(t, rules), "application/octet-stream", 100, []string{"rules/a.yml", "rules/b.spl"}, ""},
+        {"zip beyond expansion", zipArchive(t, rules), "application/zip", 99, nil, "expands beyond the allowed size"},
+        {"tar", tarArchive(t, rules, false), "application/x-tar", 100, []string{"rules/a.yml", "rules/b.spl"}, ""},
+        {"tar beyond expansion", tarArchive(t, rules, false), "application/x-tar", 99, nil, "expands beyond the allowed size"},
+        {"tar.gz sniffed", tarArchive(t, rules, true), "", 100, []string{"rules/a.yml", "rules/b.spl"}, ""},
+        {"tar.gz beyond expansion", tarArchive(t, rules, true), "application/gzip", 50, nil, "expands beyond the allowed size"},
+        {"zip with too many entries", zipArchive(t, tooMany), "application/zip", 1 << 20, nil, "more than 10000 entries"},
+        {"tar with too many entries", tarArchive(t, tooMany, true), "application/gzip", 1 << 20, nil, "more than 10000 entries"},
+        {"unsupported", []byte("plain text rules"), "text/plain", 100, nil, "unsupported archive type"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var names []string
+            err := readArchive(spoolUpload(t, tt.data), tt.contentType, tt.maxExpanded, func(entry archiveEntry) error {
+                names = append(names, entry.name)
+                return nil
+            })
+            if tt.wantErr != "" {
+                if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+                    t.Fatalf("readArchive error = %v, want %q", err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("readArchive: %v", err)
+            }
+            if !reflect.DeepEqual(names, tt.want) {
+                t.Errorf("entries = %v, want %v", names, tt.want)
+            }
+        })
+    }
+}
+
+func TestIgnoredArchivePath(t *testing.T) {
+    tests := []struct {
+        name string
+        want bool
+    }{
+        {"rules/windows/proc_creation.yml", false},
+        {"./rules/a.yml", false},
+        {"../rules/a.yml", false},
+        {".github/workflows/ci.yml", true},
+        {"rules/.hidden.yml", true},
+        {"__MACOSX/rules/._a.yml", true},
+    }
+    for _, tt := range tests {
+        if got := ignoredArchivePath(tt.name); got != tt.want {
+            t.Errorf("ignoredArchivePath(%q) = %v, want %v", tt.name, got, tt.want)
+        }
+    }
+}
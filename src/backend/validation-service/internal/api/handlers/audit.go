@@ -0,0 +1,101 @@
+package handlers
+
+import (
+    "fmt"
+    "net/http"
+
+    "validation-service/internal/audit"
+)
+
+// AuditLogReader queries and verifies the audit log
+type AuditLogReader interface {
+    Query(q audit.Query) ([]audit.Record, error)
+    Verify() (*audit.Verification, error)
+}
+
+// AuditQueryResponse lists audit records, newest first
+type AuditQueryResponse struct {
+    Records []audit.Record `json:"records"`
+    Count   int            `json:"count"`
+}
+
+// AuditQueryHandler returns the newest audit records matching the user_id,
+// tenant_id, action, target_format, detection_hash, since, until and limit
+// parameters
+func (h *AdminHandler) AuditQueryHandler(w http.ResponseWriter, r *http.Request) {
+    if h.audit == nil {
+        writeError(w, r, http.StatusNotImplemented, "audit log is not enabled")
+        return
+    }
+    query, err := parseAuditQuery(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    records, err := h.audit.Query(query)
+    if err != nil {
+        h.log.Error("Failed to query audit log",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to query audit log")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, &AuditQueryResponse{Records: records, Count: len(records)})
+}
+
+// AuditVerifyHandler checks the hash chain of the audit log and reports the
+// first record that breaks it
+func (h *AdminHandler) AuditVerifyHandler(w http.ResponseWriter, r *http.Request) {
+    if h.audit == nil {
+        writeError(w, r, http.StatusNotImplemented, "audit log is not enabled")
+        return
+    }
+    verification, err := h.audit.Verify()
+    if err != nil {
+        h.log.Error("Failed to verify audit log",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to verify audit log")
+        return
+    }
+    if !verification.Valid {
+        h.log.Warn("Audit log hash chain is broken",
+            "file", verification.Break.File,
+            "line", verification.Break.Line,
+            "reason", verification.Break.Reason,
+        )
+    }
+    writeJSON(w, r, http.StatusOK, verification)
+}
+
+// parseAuditQuery builds an audit query from URL parameters
+func parseAuditQuery(r *http.Request) (audit.Query, error) {
+    params := r.URL.Query()
+    query := audit.Query{
+        UserID:        params.Get("user_id"),
+        TenantID:      params.Get("tenant_id"),
+        Action:        params.Get("action"),
+        TargetFormat:  params.Get("target_format"),
+        DetectionHash: params.Get("detection_hash"),
+    }
+
+    since, err := parseOptionalTime(params.Get("since"))
+    if err != nil {
+        return query, fmt.Errorf("invalid since: %w", err)
+    }
+    if since != nil {
+        query.Since = *since
+    }
+    until, err := parseOptionalTime(params.Get("until"))
+    if err != nil {
+        return query, fmt.Errorf("invalid until: %w", err)
+    }
+    if until != nil {
+        query.Until = *until
+    }
+    if query.Limit, err = parseOptionalInt(params.Get("limit")); err != nil {
+        return query, fmt.Errorf("invalid limit: %w", err)
+    }
+    return query, query.Normalize()
+}
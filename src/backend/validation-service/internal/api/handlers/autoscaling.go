@@ -0,0 +1,54 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "math"
+    "net/http"
+    "time"
+
+    "validation-service/internal/services/validation"
+)
+
+// AutoscalingSignals is the load of this replica in a form autoscalers such
+// as the KEDA metrics-api scaler can target directly
+type AutoscalingSignals struct {
+    validation.LoadSignals
+    // TargetConcurrency is the number of running and queued validations one
+    // replica is sized for
+    TargetConcurrency int `json:"target_concurrency"`
+    // Utilization is the running and queued validations per target
+    // concurrency; above 1 the replica has more work than it is sized for
+    Utilization float64 `json:"utilization"`
+    // Draining is set once the replica is shutting down
+    Draining  bool      `json:"draining"`
+    Timestamp time.Time `json:"timestamp"`
+}
+
+// AutoscalingHandler serves load signals for horizontal autoscaling
+type AutoscalingHandler struct {
+    service           *validation.ValidationService
+    targetConcurrency int
+}
+
+// NewAutoscalingHandler creates an autoscaling handler. targetConcurrency is
+// the number of running and queued validations one replica is sized for.
+func NewAutoscalingHandler(service *validation.ValidationService, targetConcurrency int) *AutoscalingHandler {
+    if targetConcurrency < 1 {
+        targetConcurrency = 1
+    }
+    return &AutoscalingHandler{service: service, targetConcurrency: targetConcurrency}
+}
+
+// SignalsHandler returns the current in-flight validations, queue depth and
+// average validation cost
+func (h *AutoscalingHandler) SignalsHandler(w http.ResponseWriter, r *http.Request) {
+    load := h.service.Load()
+    utilization := float64(load.InFlight+load.QueueDepth) / float64(h.targetConcurrency)
+    writeJSON(w, r, http.StatusOK, &AutoscalingSignals{
+        LoadSignals:       load,
+        TargetConcurrency: h.targetConcurrency,
+        Utilization:       math.Round(utilization*1000) / 1000,
+        Draining:          draining.Load(),
+        Timestamp:         time.Now().UTC(),
+    })
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/config"
+    "validation-service/internal/models"
+    "validation-service/internal/notify"
+    "validation-service/internal/services/remediation"
+    "validation-service/internal/services/report"
+    "validation-service/internal/services/translationmemory"
+    "validation-service/pkg/logger"
+)
+
+const (
+    // maxBatchSize bounds the number of translations of a batch, like the
+    // gRPC ValidateBatch call
+    maxBatchSize = 100
+    // maxBatchConcurrency bounds concurrent validations within one batch
+    maxBatchConcurrency = 8
+
+    // failOnParam is the query parameter selecting the issue severity that
+    // fails a JUnit test case
+    failOnParam = "fail_on"
+    // batchSuiteName names the JUnit test suite of an unnamed batch
+    batchSuiteName = "validation"
+)
+
+// BatchValidationRequest is the body of a batch validation: translations
+// validated independently with shared options
+type BatchValidationRequest struct {
+    // Name names the JUnit test suite of the batch
+    Name     string                 `json:"name,omitempty"`
+    Requests []BatchItemRequest     `json:"requests"`
+    Options  map[string]interface{} `json:"options,omitempty"`
+}
+
+// BatchItemRequest is a translation of a batch
+type BatchItemRequest struct {
+    // Name identifies the translation in JUnit reports; requests[<index>]
+    // is used when empty
+    Name            string            `json:"name,omitempty"`
+    SourceDetection *models.Detection `json:"source_detection"`
+    TargetDetection *models.Detection `json:"target_detection"`
+}
+
+// BatchItemResult is the validation of a translation of a batch: its result,
+// or the error that prevented validation
+type BatchItemResult struct {
+    Index    int                      `json:"index"`
+    Name     string                   `json:"name,omitempty"`
+    Status   string                   `json:"status"`
+    Gate     string                   `json:"gate,omitempty"`
+    Result   *models.ValidationResult `json:"result,omitempty"`
+    Error    string                   `json:"error,omitempty"`
+    CacheHit bool                     `json:"cache_hit"`
+}
+
+// BatchValidationResponse is the validation of a batch, in request order
+type BatchValidationResponse struct {
+    Results   []BatchItemResult `json:"results"`
+    RequestID string            `json:"request_id"`
+    TraceID   string            `json:"trace_id,omitempty"`
+    Timestamp time.Time         `json:"timestamp"`
+}
+
+// ValidateBatchHandler validates up to maxBatchSize translations, each as by
+// ValidateHandler, and returns the results in request order. A translation
+// that cannot be validated is reported with its error without failing the
+// batch. With ?output=junit or an Accept header naming application/xml the
+// batch is returned as a JUnit XML report for CI pipelines, with one test
+// case per translation failing on issues at or above the fail_on severity.
+func (h *ValidationHandler) ValidateBatchHandler(w http.ResponseWriter, r *http.Request) {
+    ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+    defer cancel()
+
+    if r.ContentLength > maxRequestSize {
+        writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+        return
+    }
+
+    // Select JSON or JUnit output
+    output, err := parseBatchOutputFormat(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    failOn, err := parseFailOn(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    var req BatchValidationRequest
+    if err := h.parseJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+        return
+    }
+    switch n := len(req.Requests); {
+    case n == 0:
+        writeError(w, r, http.StatusBadRequest, "validation failed: at least one request is required")
+        return
+    case n > maxBatchSize:
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("validation failed: batch exceeds %d requests", maxBatchSize))
+        return
+    }
+
+    items := make([]batchItem, len(req.Requests))
+    for i, item := range req.Requests {
+        itemReq := &ValidationRequest{
+            SourceDetection: item.SourceDetection,
+            TargetDetection: item.TargetDetection,
+            Options:         req.Options,
+        }
+        if err := h.validateRequest(itemReq); err != nil {
+            writeError(w, r, http.StatusBadRequest, fmt.Sprintf("validation failed: requests[%d]: %v", i, err))
+            return
+        }
+        detections, applied, err := h.preprocessDetections(req.Options, itemReq.SourceDetection, itemReq.TargetDetection)
+        if err != nil {
+            writeError(w, r, http.StatusBadRequest, fmt.Sprintf("requests[%d]: %v", i, err))
+            return
+        }
+        itemReq.SourceDetection, itemReq.TargetDetection = detections[0], detections[1]
+        items[i] = batchItem{req: itemReq, preprocessors: applied}
+    }
+
+    ctx, err = h.withProfileOptions(ctx, req.Options)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    results := h.validateBatch(ctx, r, items, bypassCache(r, req.Options))
+    if err := ctx.Err(); err != nil {
+        writeError(w, r, http.StatusServiceUnavailable, fmt.Sprintf("batch validation did not complete: %v", err))
+        return
+    }
+    for i := range results {
+        results[i].Name = strings.TrimSpace(req.Requests[i].Name)
+    }
+
+    if output == outputJUnit {
+        name := strings.TrimSpace(req.Name)
+        if name == "" {
+            name = batchSuiteName
+        }
+        cases := make([]report.JUnitCase, len(results))
+        for i, item := range results {
+            cases[i] = report.JUnitCase{Name: item.Name, Result: item.Result}
+            if cases[i].Name == "" {
+                cases[i].Name = fmt.Sprintf("requests[%d]", i)
+            }
+            if item.Error != "" {
+                cases[i].Err = errors.New(item.Error)
+            }
+        }
+        writeJUnit(w, r, report.ToJUnit(name, cases, failOn))
+        return
+    }
+
+    writeJSON(w, r, http.StatusOK, &BatchValidationResponse{
+        Results:   results,
+        RequestID: apimiddleware.RequestIDFromContext(r.Context()),
+        TraceID:   apimiddleware.TraceIDFromContext(r.Context()),
+        Timestamp: time.Now().UTC(),
+    })
+}
+
+// batchItem is a translation of a batch ready for validation and the
+// pre-processors that unwrapped it
+type batchItem struct {
+    req           *ValidationRequest
+    preprocessors []string
+}
+
+// validateBatch validates items with bounded concurrency and returns their
+// results in order. Every result is checked, decorated, persisted and
+// published as by ValidateHandler. Validation stops early once ctx is done.
+func (h *ValidationHandler) validateBatch(ctx context.Context, r *http.Request, items []batchItem, bypass bool) []BatchItemResult {
+    // Items waiting for a worker count towards the queue depth reported to
+    // autoscalers
+    h.service.QueueValidations(len(items))
+
+    results := make([]BatchItemResult, len(items))
+    sem := make(chan struct{}, maxBatchConcurrency)
+    var wg sync.WaitGroup
+    for i, item := range items {
+        select {
+        case sem <- struct{}{}:
+            h.service.DequeueValidations(1)
+        case <-ctx.Done():
+            h.service.DequeueValidations(len(items) - i)
+            wg.Wait()
+            return results
+        }
+
+        wg.Add(1)
+        go func(index int, item batchItem) {
+            defer wg.Done()
+            defer func() { <-sem }()
+            results[index] = h.validateBatchItem(ctx, r, index, item, bypass)
+        }(i, item)
+    }
+    wg.Wait()
+    return results
+}
+
+// validateBatchItem validates one translation of a batch
+func (h *ValidationHandler) validateBatchItem(ctx context.Context, r *http.Request, index int, item batchItem, bypass bool) BatchItemResult {
+    out := BatchItemResult{Index: index}
+    source, target := item.req.SourceDetection, item.req.TargetDetection
+    result, cacheHit, err := h.service.ValidateDetectionCached(ctx, source, target, bypass)
+    h.auditValidation(r, item.req, result, err)
+    if err == nil && result == nil {
+        err = errors.New("no result")
+    }
+    if err != nil {
+        if ctx.Err() == nil {
+            logger.FromContext(ctx).Error("Batch validation failed",
+                "error", err,
+                "index", index,
+                "source_format", source.Format,
+                "target_format", target.Format,
+            )
+        }
+        out.Status = models.ValidationStatusError
+        out.Gate = models.GateFail
+        out.Error = err.Error()
+        return out
+    }
+
+    tenantID := tenantIDFromRequest(r)
+    if err := translationmemory.Check(ctx, h.memory, tenantID, source, target, result); err != nil {
+        logger.FromContext(ctx).Error("Failed to check translation memory",
+            "error", err,
+            "result_id", result.ID,
+        )
+    }
+    remediation.ApplyPlaybooks(result, remediation.PlaybooksForTenant(
+        config.GetConfig().Remediation, tenantID))
+    result.Metadata.Preprocessors = item.preprocessors
+    correlateResult(r.Context(), result)
+
+    if h.results != nil {
+        if err := h.results.SaveResult(ctx, tenantID, result); err != nil {
+            logger.FromContext(ctx).Error("Failed to persist validation result",
+                "error", err,
+                "result_id", result.ID,
+            )
+        }
+    }
+    if h.notifier != nil {
+        h.notifier.Publish(notify.ValidationEvent(tenantID, result))
+    }
+
+    out.Status = result.Status
+    out.Gate = result.Metadata.Gate
+    out.Result = result
+    out.CacheHit = cacheHit
+    return out
+}
@@ -0,0 +1,431 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+    "github.com/google/uuid"   // v1.4.0
+
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/config"
+    "validation-service/internal/models"
+    "validation-service/internal/services/remediation"
+    "validation-service/internal/services/translationmemory"
+    "validation-service/internal/services/validation"
+    "validation-service/internal/storage"
+    "validation-service/pkg/logger"
+)
+
+// CollectionRequest is the body of a collection create or update
+type CollectionRequest struct {
+    Name        string `json:"name"`
+    Description string `json:"description,omitempty"`
+    // Metadata applies to every detection of the collection when it is
+    // validated; a detection's own metadata takes precedence
+    Metadata map[string]interface{} `json:"metadata,omitempty"`
+    // DetectionIDs are stored detections of the tenant, in order
+    DetectionIDs []uuid.UUID `json:"detection_ids"`
+}
+
+// CollectionListResponse lists the collections of the caller's tenant
+type CollectionListResponse struct {
+    Collections []*storage.Collection `json:"collections"`
+}
+
+// CollectionHandler serves the rule collections of the caller's tenant:
+// ordered sets of stored detections migrated together
+type CollectionHandler struct {
+    store      storage.CollectionStore
+    detections storage.DetectionStore
+    log        *logger.Logger
+}
+
+// NewCollectionHandler creates a collection handler backed by store whose
+// collections reference detections of detections
+func NewCollectionHandler(store storage.CollectionStore, detections storage.DetectionStore) *CollectionHandler {
+    return &CollectionHandler{
+        store:      store,
+        detections: detections,
+        log:        logger.GetLogger(),
+    }
+}
+
+// RegisterRoutes registers the collection endpoints with the router
+func (h *CollectionHandler) RegisterRoutes(r chi.Router) {
+    r.Post("/collections", h.CreateCollectionHandler)
+    r.Get("/collections", h.ListCollectionsHandler)
+    r.Get("/collections/{id}", h.GetCollectionHandler)
+    r.Put("/collections/{id}", h.UpdateCollectionHandler)
+    r.Delete("/collections/{id}", h.DeleteCollectionHandler)
+}
+
+// CreateCollectionHandler creates a collection of stored detections for the
+// caller's tenant
+func (h *CollectionHandler) CreateCollectionHandler(w http.ResponseWriter, r *http.Request) {
+    var req CollectionRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+
+    now := time.Now().UTC()
+    c := &storage.Collection{
+        ID:        uuid.New(),
+        TenantID:  tenantIDFromRequest(r),
+        CreatedAt: now,
+        UpdatedAt: now,
+    }
+    if claims, ok := apimiddleware.ClaimsFromContext(r.Context()); ok {
+        c.CreatedBy = claims.UserId
+    }
+    if !h.applyCollectionRequest(w, r, c, &req) {
+        return
+    }
+    h.save(w, r, c, http.StatusCreated)
+}
+
+// ListCollectionsHandler lists the collections of the caller's tenant
+func (h *CollectionHandler) ListCollectionsHandler(w http.ResponseWriter, r *http.Request) {
+    collections, err := h.store.ListCollections(r.Context(), tenantIDFromRequest(r))
+    if err != nil {
+        h.log.Error("Failed to list collections",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to list collections")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, &CollectionListResponse{Collections: collections})
+}
+
+// GetCollectionHandler returns a collection by ID
+func (h *CollectionHandler) GetCollectionHandler(w http.ResponseWriter, r *http.Request) {
+    c, ok := loadCollection(w, r, h.store, h.log)
+    if !ok {
+        return
+    }
+    writeJSON(w, r, http.StatusOK, c)
+}
+
+// UpdateCollectionHandler replaces the name, description, metadata and
+// detections of a collection
+func (h *CollectionHandler) UpdateCollectionHandler(w http.ResponseWriter, r *http.Request) {
+    c, ok := loadCollection(w, r, h.store, h.log)
+    if !ok {
+        return
+    }
+
+    var req CollectionRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+    if !h.applyCollectionRequest(w, r, c, &req) {
+        return
+    }
+    c.UpdatedAt = time.Now().UTC()
+    h.save(w, r, c, http.StatusOK)
+}
+
+// DeleteCollectionHandler removes a collection; its detections are kept
+func (h *CollectionHandler) DeleteCollectionHandler(w http.ResponseWriter, r *http.Request) {
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid collection ID")
+        return
+    }
+
+    err = h.store.DeleteCollection(r.Context(), tenantIDFromRequest(r), id)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "collection not found")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to delete collection",
+            "error", err,
+            "collection_id", id,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to delete collection")
+        return
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// save stores the collection and writes it with status
+func (h *CollectionHandler) save(w http.ResponseWriter, r *http.Request, c *storage.Collection, status int) {
+    err := h.store.SaveCollection(r.Context(), c)
+    if errors.Is(err, storage.ErrAlreadyExists) {
+        writeError(w, r, http.StatusConflict, fmt.Sprintf("a collection named %q already exists", c.Name))
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to store collection",
+            "error", err,
+            "collection_id", c.ID,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to store collection")
+        return
+    }
+    writeJSON(w, r, status, c)
+}
+
+// applyCollectionRequest normalizes req into c and validates it, writing an
+// error response unless every detection exists in the caller's tenant
+func (h *CollectionHandler) applyCollectionRequest(w http.ResponseWriter, r *http.Request, c *storage.Collection, req *CollectionRequest) bool {
+    c.Name = strings.TrimSpace(req.Name)
+    c.Description = strings.TrimSpace(req.Description)
+    c.Metadata = req.Metadata
+    c.DetectionIDs = req.DetectionIDs
+    if c.DetectionIDs == nil {
+        c.DetectionIDs = []uuid.UUID{}
+    }
+    if err := c.Validate(); err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return false
+    }
+
+    for _, id := range c.DetectionIDs {
+        _, err := h.detections.GetDetection(r.Context(), c.TenantID, id)
+        if errors.Is(err, storage.ErrNotFound) {
+            writeError(w, r, http.StatusBadRequest, fmt.Sprintf("detection %s not found", id))
+            return false
+        }
+        if err != nil {
+            h.log.Error("Failed to load detection",
+                "error", err,
+                "detection_id", id,
+            )
+            writeError(w, r, http.StatusInternalServerError, "failed to load detection")
+            return false
+        }
+    }
+    return true
+}
+
+// loadCollection loads the collection named by the URL, writing an error
+// response unless it exists in the caller's tenant
+func loadCollection(w http.ResponseWriter, r *http.Request, store storage.CollectionStore, log *logger.Logger) (*storage.Collection, bool) {
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid collection ID")
+        return nil, false
+    }
+
+    c, err := store.GetCollection(r.Context(), tenantIDFromRequest(r), id)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "collection not found")
+        return nil, false
+    }
+    if err != nil {
+        log.Error("Failed to load collection",
+            "error", err,
+            "collection_id", id,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to load collection")
+        return nil, false
+    }
+    return c, true
+}
+
+// CollectionValidationRequest is the body of an inline collection
+// validation: the translations of a collection and its shared metadata
+type CollectionValidationRequest struct {
+    Name string `json:"name,omitempty"`
+    // Metadata applies to the source and target of every rule; a rule's own
+    // metadata takes precedence
+    Metadata map[string]interface{}  `json:"metadata,omitempty"`
+    Rules    []CollectionRuleRequest `json:"rules"`
+    Options  map[string]interface{}  `json:"options,omitempty"`
+}
+
+// CollectionRuleRequest is a translation of a collection
+type CollectionRuleRequest struct {
+    // Name identifies the rule in collection issues; the title of a Sigma
+    // or Sentinel target is used when empty
+    Name            string            `json:"name,omitempty"`
+    SourceDetection *models.Detection `json:"source_detection"`
+    TargetDetection *models.Detection `json:"target_detection"`
+}
+
+// CollectionValidationOptions is the optional body of a stored collection
+// validation
+type CollectionValidationOptions struct {
+    Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// CollectionValidationResponse is the validation of a collection
+type CollectionValidationResponse struct {
+    Status string `json:"status"`
+    // Gate is the pass or fail verdict of the collection, for CI pipelines
+    Gate string `json:"gate"`
+    // CollectionID is the stored collection that was validated
+    CollectionID *uuid.UUID                   `json:"collection_id,omitempty"`
+    Collection   *validation.CollectionResult `json:"collection"`
+    RequestID    string                       `json:"request_id"`
+    TraceID      string                       `json:"trace_id,omitempty"`
+    Timestamp    time.Time                    `json:"timestamp"`
+}
+
+// ValidateCollectionHandler validates the translations of a collection
+// together: every rule as by ValidateHandler, and the collection as a whole
+// for rules sharing a name or ID
+func (h *ValidationHandler) ValidateCollectionHandler(w http.ResponseWriter, r *http.Request) {
+    ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+    defer cancel()
+
+    if r.ContentLength > maxRequestSize {
+        writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+        return
+    }
+
+    var req CollectionValidationRequest
+    if err := h.parseJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+        return
+    }
+    if len(req.Rules) == 0 {
+        writeError(w, r, http.StatusBadRequest, "validation failed: at least one rule is required")
+        return
+    }
+    if len(req.Rules) > storage.MaxCollectionRules {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("validation failed: collection exceeds %d rules", storage.MaxCollectionRules))
+        return
+    }
+    collection := &validation.Collection{
+        Name:     strings.TrimSpace(req.Name),
+        Metadata: req.Metadata,
+        Rules:    make([]validation.CollectionRule, len(req.Rules)),
+    }
+    for i, rule := range req.Rules {
+        if err := h.validateRequest(&ValidationRequest{SourceDetection: rule.SourceDetection, TargetDetection: rule.TargetDetection}); err != nil {
+            writeError(w, r, http.StatusBadRequest, fmt.Sprintf("validation failed: rules[%d]: %v", i, err))
+            return
+        }
+        collection.Rules[i] = validation.CollectionRule{
+            Name:   rule.Name,
+            Source: rule.SourceDetection,
+            Target: rule.TargetDetection,
+        }
+    }
+
+    h.validateCollection(ctx, w, r, collection, nil, req.Options)
+}
+
+// ValidateStoredCollectionHandler validates the detections of a stored
+// collection of the caller's tenant together, in collection order
+func (h *ValidationHandler) ValidateStoredCollectionHandler(w http.ResponseWriter, r *http.Request) {
+    ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+    defer cancel()
+
+    if h.collections == nil {
+        writeError(w, r, http.StatusNotImplemented, "collections are not enabled")
+        return
+    }
+    var req CollectionValidationOptions
+    if r.ContentLength != 0 {
+        if err := decodeJSONBody(r, &req); err != nil {
+            writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+            return
+        }
+    }
+    stored, ok := loadCollection(w, r, h.collections, h.log)
+    if !ok {
+        return
+    }
+
+    collection := &validation.Collection{
+        Name:     stored.Name,
+        Metadata: stored.Metadata,
+        Rules:    make([]validation.CollectionRule, 0, len(stored.DetectionIDs)),
+    }
+    for _, id := range stored.DetectionIDs {
+        detection, err := h.detections.GetDetection(ctx, stored.TenantID, id)
+        if errors.Is(err, storage.ErrNotFound) {
+            writeError(w, r, http.StatusConflict, fmt.Sprintf("detection %s of the collection no longer exists", id))
+            return
+        }
+        if err != nil {
+            logger.FromContext(ctx).Error("Failed to load detection",
+                "error", err,
+                "collection_id", stored.ID,
+                "detection_id", id,
+            )
+            writeError(w, r, http.StatusInternalServerError, "failed to load detection")
+            return
+        }
+        collection.Rules = append(collection.Rules, validation.CollectionRule{
+            Name:   detection.Name,
+            Source: detection.Detection,
+            Target: detection.Detection,
+        })
+    }
+
+    h.validateCollection(ctx, w, r, collection, &stored.ID, req.Options)
+}
+
+// validateCollection validates collection with the profiles and cache
+// settings of options and writes the response. The result of every rule is
+// checked, decorated and persisted as by ValidateHandler.
+func (h *ValidationHandler) validateCollection(ctx context.Context, w http.ResponseWriter, r *http.Request, collection *validation.Collection, id *uuid.UUID, options map[string]interface{}) {
+    ctx, err := h.withProfileOptions(ctx, options)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    result, err := h.service.ValidateCollection(ctx, collection, bypassCache(r, options))
+    if err != nil {
+        logger.FromContext(ctx).Error("Collection validation failed",
+            "error", err,
+            "rules", len(collection.Rules),
+        )
+        writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("validation error: %v", err))
+        return
+    }
+
+    tenantID := tenantIDFromRequest(r)
+    playbooks := remediation.PlaybooksForTenant(config.GetConfig().Remediation, tenantID)
+    for i := range result.Rules {
+        rule := &result.Rules[i]
+        source, target := collection.Rules[rule.Index].Source, collection.Rules[rule.Index].Target
+        var ruleErr error
+        if rule.Error != "" {
+            ruleErr = errors.New(rule.Error)
+        }
+        h.auditValidation(r, &ValidationRequest{SourceDetection: source, TargetDetection: target}, rule.Result, ruleErr)
+        if rule.Result == nil {
+            continue
+        }
+
+        if err := translationmemory.Check(ctx, h.memory, tenantID, source, target, rule.Result); err != nil {
+            logger.FromContext(ctx).Error("Failed to check translation memory",
+                "error", err,
+                "result_id", rule.Result.ID,
+            )
+        }
+        remediation.ApplyPlaybooks(rule.Result, playbooks)
+        correlateResult(r.Context(), rule.Result)
+        if h.results != nil {
+            if err := h.results.SaveResult(ctx, tenantID, rule.Result); err != nil {
+                logger.FromContext(ctx).Error("Failed to persist validation result",
+                    "error", err,
+                    "result_id", rule.Result.ID,
+                )
+            }
+        }
+    }
+
+    writeJSON(w, r, http.StatusOK, &CollectionValidationResponse{
+        Status:       result.Status,
+        Gate:         result.Gate,
+        CollectionID: id,
+        Collection:   result,
+        RequestID:    apimiddleware.RequestIDFromContext(r.Context()),
+        TraceID:      apimiddleware.TraceIDFromContext(r.Context()),
+        Timestamp:    time.Now().UTC(),
+    })
+}
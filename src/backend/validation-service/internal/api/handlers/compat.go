@@ -0,0 +1,90 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/go-chi/chi/v5/middleware" // v5.0.8
+
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/models"
+    "validation-service/internal/services/report"
+    "validation-service/internal/services/validation"
+    "validation-service/pkg/logger"
+)
+
+// CompatibilityRequest asks whether a Sigma rule can be translated to a
+// target format
+type CompatibilityRequest struct {
+    SourceDetection *models.Detection `json:"source_detection"`
+    TargetFormat    string            `json:"target_format"`
+}
+
+// CompatibilityHandler checks, before translating, whether the backend of the
+// target format supports the constructs of a Sigma rule. Unsupported
+// constructs are returned as SIGMA-COMPAT issues of the source rule.
+func (h *ValidationHandler) CompatibilityHandler(w http.ResponseWriter, r *http.Request) {
+    ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+    defer cancel()
+
+    if r.ContentLength > maxRequestSize {
+        writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+        return
+    }
+
+    output, err := parseOutputFormat(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    var req CompatibilityRequest
+    if err := h.parseJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+        return
+    }
+    if req.SourceDetection == nil {
+        writeError(w, r, http.StatusBadRequest, "source detection is required")
+        return
+    }
+    if req.TargetFormat == "" {
+        writeError(w, r, http.StatusBadRequest, "target format is required")
+        return
+    }
+
+    result, err := h.service.CheckSigmaCompatibility(ctx, req.SourceDetection, req.TargetFormat)
+    if errors.Is(err, validation.ErrUnsupportedFormat) {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    if err != nil {
+        logger.FromContext(ctx).Error("Sigma compatibility check failed",
+            "error", err,
+            "target_format", req.TargetFormat,
+        )
+        writeError(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("compatibility error: %v", err))
+        return
+    }
+
+    correlateResult(r.Context(), result)
+    if output == outputSARIF {
+        writeSARIF(w, r, report.ToSARIF(result, report.ArtifactURI(req.SourceDetection)))
+        return
+    }
+    if output == outputAnnotated {
+        writeAnnotated(w, r, req.SourceDetection, result)
+        return
+    }
+    writeJSON(w, r, http.StatusOK, &ValidationResponse{
+        Status:    result.Status,
+        Gate:      result.Metadata.Gate,
+        Result:    result,
+        RequestID: middleware.GetReqID(r.Context()),
+        TraceID:   apimiddleware.TraceIDFromContext(r.Context()),
+        Timestamp: time.Now().UTC(),
+    })
+}
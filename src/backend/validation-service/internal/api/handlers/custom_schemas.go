@@ -0,0 +1,132 @@
+package handlers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/storage"
+    "validation-service/pkg/logger"
+)
+
+// CustomSchemaRequest is the body of a custom schema update; the schema
+// name is taken from the path
+type CustomSchemaRequest struct {
+    Description string                `json:"description,omitempty"`
+    Fields      []storage.SchemaField `json:"fields"`
+}
+
+// CustomSchemaListResponse lists the custom schemas of the caller's tenant
+type CustomSchemaListResponse struct {
+    Schemas []*storage.CustomSchema `json:"schemas"`
+}
+
+// CustomSchemaHandler serves the schemas of the caller's tenant's custom log
+// sources, whose fields the validators accept besides the built-in field
+// catalogs
+type CustomSchemaHandler struct {
+    store storage.CustomSchemaStore
+    log   *logger.Logger
+}
+
+// NewCustomSchemaHandler creates a custom schema handler backed by store
+func NewCustomSchemaHandler(store storage.CustomSchemaStore) *CustomSchemaHandler {
+    return &CustomSchemaHandler{
+        store: store,
+        log:   logger.GetLogger(),
+    }
+}
+
+// RegisterRoutes registers the custom schema endpoints with the router.
+// Schema names may be product/service pairs, so the name is the rest of
+// the path.
+func (h *CustomSchemaHandler) RegisterRoutes(r chi.Router) {
+    r.Get("/schemas", h.ListCustomSchemasHandler)
+    r.Get("/schemas/*", h.GetCustomSchemaHandler)
+    r.Put("/schemas/*", h.PutCustomSchemaHandler)
+    r.Delete("/schemas/*", h.DeleteCustomSchemaHandler)
+}
+
+// ListCustomSchemasHandler returns the custom schemas of the caller's tenant
+func (h *CustomSchemaHandler) ListCustomSchemasHandler(w http.ResponseWriter, r *http.Request) {
+    schemas, err := h.store.ListCustomSchemas(r.Context(), tenantIDFromRequest(r))
+    if err != nil {
+        h.log.Error("Failed to list custom schemas",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to list custom schemas")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, &CustomSchemaListResponse{Schemas: schemas})
+}
+
+// GetCustomSchemaHandler returns a custom schema of the caller's tenant
+func (h *CustomSchemaHandler) GetCustomSchemaHandler(w http.ResponseWriter, r *http.Request) {
+    schema, err := h.store.GetCustomSchema(r.Context(), tenantIDFromRequest(r), chi.URLParam(r, "*"))
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "custom schema not found")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to load custom schema",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to load custom schema")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, schema)
+}
+
+// PutCustomSchemaHandler creates or replaces a custom schema of the
+// caller's tenant. Validations started afterwards accept its fields.
+func (h *CustomSchemaHandler) PutCustomSchemaHandler(w http.ResponseWriter, r *http.Request) {
+    var req CustomSchemaRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+
+    schema := &storage.CustomSchema{
+        TenantID:    tenantIDFromRequest(r),
+        Name:        chi.URLParam(r, "*"),
+        Description: req.Description,
+        Fields:      req.Fields,
+        UpdatedAt:   time.Now().UTC(),
+    }
+    if err := schema.Validate(); err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    if claims, ok := apimiddleware.ClaimsFromContext(r.Context()); ok {
+        schema.UpdatedBy = claims.UserId
+    }
+    if err := h.store.SaveCustomSchema(r.Context(), schema); err != nil {
+        h.log.Error("Failed to store custom schema",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to store custom schema")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, schema)
+}
+
+// DeleteCustomSchemaHandler removes a custom schema of the caller's tenant
+func (h *CustomSchemaHandler) DeleteCustomSchemaHandler(w http.ResponseWriter, r *http.Request) {
+    err := h.store.DeleteCustomSchema(r.Context(), tenantIDFromRequest(r), chi.URLParam(r, "*"))
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "custom schema not found")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to delete custom schema",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to delete custom schema")
+        return
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
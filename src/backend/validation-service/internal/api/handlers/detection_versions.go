@@ -0,0 +1,143 @@
+package handlers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+    "github.com/google/uuid"   // v1.4.0
+
+    "validation-service/internal/services/rulediff"
+    "validation-service/internal/storage"
+)
+
+// DetectionVersionsResponse lists the versions of a detection, oldest first
+type DetectionVersionsResponse struct {
+    DetectionID uuid.UUID                  `json:"detection_id"`
+    Versions    []*storage.StoredDetection `json:"versions"`
+}
+
+// ListVersionsHandler returns the version history of a detection
+func (h *DetectionHandler) ListVersionsHandler(w http.ResponseWriter, r *http.Request) {
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid detection ID")
+        return
+    }
+
+    versions, err := h.store.ListDetectionVersions(r.Context(), tenantIDFromRequest(r), id)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "detection not found")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to list detection versions",
+            "error", err,
+            "detection_id", id,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to list detection versions")
+        return
+    }
+
+    writeJSON(w, r, http.StatusOK, &DetectionVersionsResponse{
+        DetectionID: id,
+        Versions:    versions,
+    })
+}
+
+// GetVersionHandler returns one version of a detection
+func (h *DetectionHandler) GetVersionHandler(w http.ResponseWriter, r *http.Request) {
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid detection ID")
+        return
+    }
+    version, err := strconv.Atoi(chi.URLParam(r, "version"))
+    if err != nil || version < 1 {
+        writeError(w, r, http.StatusBadRequest, "invalid version")
+        return
+    }
+
+    detection, ok := h.loadVersion(w, r, id, version)
+    if !ok {
+        return
+    }
+    writeJSON(w, r, http.StatusOK, detection)
+}
+
+// DiffHandler compares two versions of a detection. Supported parameters:
+// from and to, defaulting to the current version and the one before it.
+func (h *DetectionHandler) DiffHandler(w http.ResponseWriter, r *http.Request) {
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid detection ID")
+        return
+    }
+    params := r.URL.Query()
+    from, err := parseOptionalInt(params.Get("from"))
+    if err != nil || from < 0 {
+        writeError(w, r, http.StatusBadRequest, "invalid from version")
+        return
+    }
+    to, err := parseOptionalInt(params.Get("to"))
+    if err != nil || to < 0 {
+        writeError(w, r, http.StatusBadRequest, "invalid to version")
+        return
+    }
+
+    if to == 0 {
+        current, err := h.store.GetDetection(r.Context(), tenantIDFromRequest(r), id)
+        if errors.Is(err, storage.ErrNotFound) {
+            writeError(w, r, http.StatusNotFound, "detection not found")
+            return
+        }
+        if err != nil {
+            h.log.Error("Failed to load detection",
+                "error", err,
+                "detection_id", id,
+            )
+            writeError(w, r, http.StatusInternalServerError, "failed to load detection")
+            return
+        }
+        to = current.Version
+    }
+    if from == 0 {
+        from = to - 1
+    }
+    if from < 1 {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("version %d has no earlier version to compare with", to))
+        return
+    }
+
+    fromVersion, ok := h.loadVersion(w, r, id, from)
+    if !ok {
+        return
+    }
+    toVersion, ok := h.loadVersion(w, r, id, to)
+    if !ok {
+        return
+    }
+    writeJSON(w, r, http.StatusOK, rulediff.Compare(fromVersion, toVersion))
+}
+
+// loadVersion loads one version of a detection, writing an error response
+// when it cannot be loaded
+func (h *DetectionHandler) loadVersion(w http.ResponseWriter, r *http.Request, id uuid.UUID, version int) (*storage.StoredDetection, bool) {
+    detection, err := h.store.GetDetectionVersion(r.Context(), tenantIDFromRequest(r), id, version)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, fmt.Sprintf("version %d of detection not found", version))
+        return nil, false
+    }
+    if err != nil {
+        h.log.Error("Failed to load detection version",
+            "error", err,
+            "detection_id", id,
+            "version", version,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to load detection version")
+        return nil, false
+    }
+    return detection, true
+}
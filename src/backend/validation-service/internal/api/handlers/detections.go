@@ -0,0 +1,468 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+    "github.com/google/uuid"   // v1.4.0
+
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/models"
+    "validation-service/internal/services/validation"
+    "validation-service/internal/spool"
+    "validation-service/internal/storage"
+    "validation-service/pkg/logger"
+    "validation-service/pkg/mitre"
+)
+
+// Bulk operation limits
+const (
+    maxBulkTagDetections = 1000
+    maxTagsPerDetection  = 64
+    maxTagLength         = 128
+)
+
+// CreateDetectionRequest is the body of a detection create request
+type CreateDetectionRequest struct {
+    Content         string            `json:"content"`
+    Format          string            `json:"format"`
+    Name            string            `json:"name"`
+    Description     string            `json:"description,omitempty"`
+    // License is the SPDX identifier of the rule license; rules under a
+    // shareable license have their content deduplicated across tenants
+    License         string            `json:"license,omitempty"`
+    Tags            map[string]string `json:"tags,omitempty"`
+    ConfidenceScore *float64          `json:"confidence_score,omitempty"`
+}
+
+// UpdateDetectionRequest is the body of a detection update, which stores a
+// new version. Version, when set, must be the current version of the
+// detection; omitted tags keep the current tags.
+type UpdateDetectionRequest struct {
+    CreateDetectionRequest
+    Version int `json:"version,omitempty"`
+}
+
+// BulkTagRequest applies tag changes to many detections at once
+type BulkTagRequest struct {
+    DetectionIDs []uuid.UUID      `json:"detection_ids"`
+    Set          map[string]string `json:"set,omitempty"`
+    Remove       []string          `json:"remove,omitempty"`
+}
+
+// BulkTagResponse reports the outcome of a bulk tag request
+type BulkTagResponse struct {
+    Requested int `json:"requested"`
+    Updated   int `json:"updated"`
+}
+
+// DetectionSearchResponse is a page of search results
+type DetectionSearchResponse struct {
+    Total      int                        `json:"total"`
+    Limit      int                        `json:"limit"`
+    Offset     int                        `json:"offset"`
+    Detections []*storage.StoredDetection `json:"detections"`
+}
+
+// DetectionHandler serves the stored detection, tagging and search endpoints
+type DetectionHandler struct {
+    store     storage.DetectionStore
+    spooler   *spool.Spooler
+    validator *validation.ValidationService
+    results   storage.ResultStore
+    log       *logger.Logger
+}
+
+// NewDetectionHandler creates a detection handler backed by store
+func NewDetectionHandler(store storage.DetectionStore) *DetectionHandler {
+    return &DetectionHandler{
+        store: store,
+        log:   logger.GetLogger(),
+    }
+}
+
+// SetValidator validates every new detection version with service,
+// persisting the results in results when set
+func (h *DetectionHandler) SetValidator(service *validation.ValidationService, results storage.ResultStore) {
+    h.validator = service
+    h.results = results
+}
+
+// RegisterRoutes registers the detection endpoints with the router
+func (h *DetectionHandler) RegisterRoutes(r chi.Router) {
+    r.Post("/detections", h.CreateDetectionHandler)
+    r.Get("/detections", h.SearchDetectionsHandler)
+    r.Get("/detections/search", h.SearchDetectionsHandler)
+    r.Post("/detections/tags", h.BulkTagHandler)
+    r.Post("/detections/archive", h.ImportArchiveHandler)
+    r.Get("/detections/archive/{importID}/aggregate", h.ArchiveAggregateHandler)
+    r.Get("/detections/{id}", h.GetDetectionHandler)
+    r.Put("/detections/{id}", h.UpdateDetectionHandler)
+    r.Get("/detections/{id}/versions", h.ListVersionsHandler)
+    r.Get("/detections/{id}/versions/{version}", h.GetVersionHandler)
+    r.Get("/detections/{id}/diff", h.DiffHandler)
+}
+
+// CreateDetectionHandler stores a new detection
+func (h *DetectionHandler) CreateDetectionHandler(w http.ResponseWriter, r *http.Request) {
+    var req CreateDetectionRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+
+    stored, err := newStoredDetection(r, &req)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    if stored.Tags == nil {
+        stored.Tags = map[string]string{}
+    }
+    h.validateVersion(r.Context(), stored)
+
+    if err := h.store.CreateDetection(r.Context(), stored); err != nil {
+        h.log.Error("Failed to store detection",
+            "error", err,
+            "detection_id", stored.ID(),
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to store detection")
+        return
+    }
+
+    writeJSON(w, r, http.StatusCreated, stored)
+}
+
+// GetDetectionHandler returns a stored detection by ID
+func (h *DetectionHandler) GetDetectionHandler(w http.ResponseWriter, r *http.Request) {
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid detection ID")
+        return
+    }
+
+    detection, err := h.store.GetDetection(r.Context(), tenantIDFromRequest(r), id)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "detection not found")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to load detection",
+            "error", err,
+            "detection_id", id,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to load detection")
+        return
+    }
+
+    writeJSON(w, r, http.StatusOK, detection)
+}
+
+// UpdateDetectionHandler stores a new version of a detection, validated like
+// a new detection. The detection keeps its ID, creation time and owner.
+func (h *DetectionHandler) UpdateDetectionHandler(w http.ResponseWriter, r *http.Request) {
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid detection ID")
+        return
+    }
+    var req UpdateDetectionRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+    if req.Version < 0 {
+        writeError(w, r, http.StatusBadRequest, "version must not be negative")
+        return
+    }
+
+    current, err := h.store.GetDetection(r.Context(), tenantIDFromRequest(r), id)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "detection not found")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to load detection",
+            "error", err,
+            "detection_id", id,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to load detection")
+        return
+    }
+
+    stored, err := newStoredDetection(r, &req.CreateDetectionRequest)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    stored.Detection.ID = id
+    if current.Detection != nil {
+        stored.Detection.CreatedAt = current.Detection.CreatedAt
+        stored.Detection.UserID = current.Detection.UserID
+        stored.Detection.IsActive = current.Detection.IsActive
+        stored.Detection.Metadata = current.Detection.Metadata
+    }
+    if stored.Tags == nil {
+        stored.Tags = current.Tags
+    }
+    stored.Version = req.Version
+    h.validateVersion(r.Context(), stored)
+
+    err = h.store.UpdateDetection(r.Context(), stored)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "detection not found")
+        return
+    }
+    if errors.Is(err, storage.ErrVersionConflict) {
+        writeError(w, r, http.StatusConflict, fmt.Sprintf("detection was changed since version %d", req.Version))
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to update detection",
+            "error", err,
+            "detection_id", id,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to update detection")
+        return
+    }
+
+    writeJSON(w, r, http.StatusOK, stored)
+}
+
+// newStoredDetection checks a create or update request and builds the
+// detection it describes, owned by the requesting tenant and user
+func newStoredDetection(r *http.Request, req *CreateDetectionRequest) (*storage.StoredDetection, error) {
+    detection, err := models.NewDetection(req.Content, req.Format)
+    if err != nil {
+        return nil, err
+    }
+    if err := validateTags(req.Tags); err != nil {
+        return nil, err
+    }
+    if req.ConfidenceScore != nil && (*req.ConfidenceScore < 0 || *req.ConfidenceScore > 100) {
+        return nil, errors.New("confidence_score must be between 0 and 100")
+    }
+    if len(req.License) > maxTagLength {
+        return nil, fmt.Errorf("license exceeds %d characters", maxTagLength)
+    }
+
+    stored := &storage.StoredDetection{
+        Detection:       detection,
+        TenantID:        tenantIDFromRequest(r),
+        Name:            strings.TrimSpace(req.Name),
+        Description:     req.Description,
+        License:         strings.TrimSpace(req.License),
+        Tags:            req.Tags,
+        Techniques:      mitre.ExtractTechniques(req.Content),
+        ConfidenceScore: req.ConfidenceScore,
+        UpdatedAt:       time.Now().UTC(),
+    }
+    if claims, ok := apimiddleware.ClaimsFromContext(r.Context()); ok {
+        stored.UpdatedBy = claims.UserId
+    }
+    return stored, nil
+}
+
+// validateVersion validates the content of a new detection version on its
+// own and records the outcome on it. A version that cannot be validated,
+// such as one in a format the tenant may not validate, is stored without a
+// validation.
+func (h *DetectionHandler) validateVersion(ctx context.Context, stored *storage.StoredDetection) {
+    if h.validator == nil {
+        return
+    }
+    result, err := h.validator.ValidateDetection(ctx, stored.Detection, stored.Detection)
+    if result == nil {
+        logger.FromContext(ctx).Warn("Could not validate detection version",
+            "error", err,
+            "detection_id", stored.ID(),
+        )
+        return
+    }
+    correlateResult(ctx, result)
+    if h.results != nil {
+        if err := h.results.SaveResult(ctx, stored.TenantID, result); err != nil {
+            logger.FromContext(ctx).Error("Failed to persist detection validation result",
+                "error", err,
+                "detection_id", stored.ID(),
+                "result_id", result.ID,
+            )
+        }
+    }
+    stored.Validation = &storage.DetectionValidation{
+        ResultID:        result.ID,
+        Status:          result.Status,
+        ConfidenceScore: result.ConfidenceScore,
+        Issues:          len(result.Issues),
+        ValidatedAt:     result.CreatedAt,
+    }
+    for _, issue := range result.Issues {
+        if issue.IssueCode == "" {
+            continue
+        }
+        if stored.Validation.IssueCodes == nil {
+            stored.Validation.IssueCodes = make(map[string]int)
+        }
+        stored.Validation.IssueCodes[issue.IssueCode]++
+    }
+}
+
+// BulkTagHandler sets and removes tags on many detections in one request
+func (h *DetectionHandler) BulkTagHandler(w http.ResponseWriter, r *http.Request) {
+    var req BulkTagRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+
+    if len(req.DetectionIDs) == 0 {
+        writeError(w, r, http.StatusBadRequest, "detection_ids is required")
+        return
+    }
+    if len(req.DetectionIDs) > maxBulkTagDetections {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("at most %d detections can be tagged per request", maxBulkTagDetections))
+        return
+    }
+    if len(req.Set) == 0 && len(req.Remove) == 0 {
+        writeError(w, r, http.StatusBadRequest, "set or remove is required")
+        return
+    }
+    if err := validateTags(req.Set); err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    updated, err := h.store.UpdateTags(r.Context(), storage.TagOperation{
+        TenantID:     tenantIDFromRequest(r),
+        DetectionIDs: req.DetectionIDs,
+        Set:          req.Set,
+        Remove:       req.Remove,
+    })
+    if err != nil {
+        h.log.Error("Failed to update detection tags",
+            "error", err,
+            "detections", len(req.DetectionIDs),
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to update tags")
+        return
+    }
+
+    writeJSON(w, r, http.StatusOK, &BulkTagResponse{
+        Requested: len(req.DetectionIDs),
+        Updated:   updated,
+    })
+}
+
+// SearchDetectionsHandler searches stored detections. Supported parameters:
+// tag=key:value (repeatable; key alone matches any value), format, technique,
+// min_confidence, max_confidence, q (full text), limit and offset.
+func (h *DetectionHandler) SearchDetectionsHandler(w http.ResponseWriter, r *http.Request) {
+    query, err := parseDetectionQuery(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    if err := query.Normalize(); err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    detections, total, err := h.store.SearchDetections(r.Context(), query)
+    if errors.Is(err, storage.ErrInvalidQuery) {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to search detections",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to search detections")
+        return
+    }
+
+    writeJSON(w, r, http.StatusOK, &DetectionSearchResponse{
+        Total:      total,
+        Limit:      query.Limit,
+        Offset:     query.Offset,
+        Detections: detections,
+    })
+}
+
+// parseDetectionQuery builds a storage query from URL parameters
+func parseDetectionQuery(r *http.Request) (storage.DetectionQuery, error) {
+    params := r.URL.Query()
+    query := storage.DetectionQuery{
+        TenantID:  tenantIDFromRequest(r),
+        Format:    strings.ToLower(params.Get("format")),
+        Technique: strings.ToUpper(params.Get("technique")),
+        Text:      strings.TrimSpace(params.Get("q")),
+    }
+
+    if tags := params["tag"]; len(tags) > 0 {
+        query.Tags = make(map[string]string, len(tags))
+        for _, tag := range tags {
+            key, value, _ := strings.Cut(tag, ":")
+            if key == "" {
+                return query, fmt.Errorf("invalid tag filter %q", tag)
+            }
+            query.Tags[key] = value
+        }
+    }
+
+    var err error
+    if query.MinConfidence, err = parseOptionalFloat(params.Get("min_confidence")); err != nil {
+        return query, fmt.Errorf("invalid min_confidence: %w", err)
+    }
+    if query.MaxConfidence, err = parseOptionalFloat(params.Get("max_confidence")); err != nil {
+        return query, fmt.Errorf("invalid max_confidence: %w", err)
+    }
+    if query.Limit, err = parseOptionalInt(params.Get("limit")); err != nil {
+        return query, fmt.Errorf("invalid limit: %w", err)
+    }
+    if query.Offset, err = parseOptionalInt(params.Get("offset")); err != nil {
+        return query, fmt.Errorf("invalid offset: %w", err)
+    }
+
+    return query, nil
+}
+
+// validateTags enforces tag count and length limits
+func validateTags(tags map[string]string) error {
+    if len(tags) > maxTagsPerDetection {
+        return fmt.Errorf("at most %d tags are allowed", maxTagsPerDetection)
+    }
+    for key, value := range tags {
+        if key == "" || len(key) > maxTagLength || len(value) > maxTagLength {
+            return fmt.Errorf("invalid tag %q: keys must be 1-%d characters and values at most %d", key, maxTagLength, maxTagLength)
+        }
+        if strings.Contains(key, ":") {
+            return fmt.Errorf("invalid tag %q: keys must not contain ':'", key)
+        }
+    }
+    return nil
+}
+
+func parseOptionalFloat(value string) (*float64, error) {
+    if value == "" {
+        return nil, nil
+    }
+    f, err := strconv.ParseFloat(value, 64)
+    if err != nil {
+        return nil, err
+    }
+    return &f, nil
+}
+
+func parseOptionalInt(value string) (int, error) {
+    if value == "" {
+        return 0, nil
+    }
+    return strconv.Atoi(value)
+}
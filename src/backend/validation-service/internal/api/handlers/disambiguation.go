@@ -0,0 +1,111 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "fmt"
+    "net/http"
+    "strings"
+    "sync"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+
+    "validation-service/internal/models"
+    "validation-service/internal/services/validation"
+)
+
+// Disambiguation response statuses
+const (
+    DisambiguationStatusNeedsInput = "needs_input"
+    DisambiguationStatusResolved   = "resolved"
+)
+
+// DisambiguationRequest asks how the fields of a source detection map to a
+// target format. Resolutions answer questions of an earlier response by ID.
+type DisambiguationRequest struct {
+    SourceDetection *models.Detection `json:"source_detection"`
+    TargetFormat    string            `json:"target_format"`
+    Resolutions     map[string]string `json:"resolutions,omitempty"`
+}
+
+// DisambiguationResponse lists the open questions and the field mappings the
+// translation should use, with unanswered questions resolved to their default
+type DisambiguationResponse struct {
+    Status        string                              `json:"status"`
+    Questions     []validation.DisambiguationQuestion `json:"questions"`
+    FieldMappings []validation.FieldResolution        `json:"field_mappings"`
+    TableVersion  string                              `json:"table_version"`
+}
+
+// DisambiguationHandler drives the guided translation workflow: it returns
+// questions for source fields with several target candidates and accepts the
+// chosen resolutions in a follow-up request
+type DisambiguationHandler struct {
+    mu    sync.RWMutex
+    table *validation.FieldMappingTable
+}
+
+// NewDisambiguationHandler creates a disambiguation handler for the field
+// mapping table
+func NewDisambiguationHandler(table *validation.FieldMappingTable) *DisambiguationHandler {
+    return &DisambiguationHandler{table: table}
+}
+
+// SetTable replaces the field mapping table questions are asked from
+func (h *DisambiguationHandler) SetTable(table *validation.FieldMappingTable) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    h.table = table
+}
+
+// currentTable returns the field mapping table in use
+func (h *DisambiguationHandler) currentTable() *validation.FieldMappingTable {
+    h.mu.RLock()
+    defer h.mu.RUnlock()
+    return h.table
+}
+
+// RegisterRoutes registers the disambiguation endpoint with the router
+func (h *DisambiguationHandler) RegisterRoutes(r chi.Router) {
+    r.Post("/translate/disambiguate", h.DisambiguateHandler)
+}
+
+// DisambiguateHandler answers a disambiguation request. The status is
+// needs_input while questions remain open and resolved once every question
+// has been answered.
+func (h *DisambiguationHandler) DisambiguateHandler(w http.ResponseWriter, r *http.Request) {
+    var req DisambiguationRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+    if req.SourceDetection == nil {
+        writeError(w, r, http.StatusBadRequest, "source detection is required")
+        return
+    }
+
+    table := h.currentTable()
+    sourceFormat := strings.ToLower(req.SourceDetection.Format)
+    targetFormat := strings.ToLower(req.TargetFormat)
+    if !table.Supports(sourceFormat, targetFormat) {
+        writeError(w, r, http.StatusUnprocessableEntity,
+            fmt.Sprintf("no field mapping table from %s to %s", sourceFormat, targetFormat))
+        return
+    }
+
+    mappings, questions, err := table.Disambiguate(req.SourceDetection.Content, req.Resolutions)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    status := DisambiguationStatusResolved
+    if len(questions) > 0 {
+        status = DisambiguationStatusNeedsInput
+    }
+    writeJSON(w, r, http.StatusOK, &DisambiguationResponse{
+        Status:        status,
+        Questions:     questions,
+        FieldMappings: mappings,
+        TableVersion:  table.Version,
+    })
+}
@@ -0,0 +1,84 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "fmt"
+    "mime"
+    "net/http"
+    "strings"
+    "sync"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+
+    "validation-service/internal/models"
+    "validation-service/internal/services/ruledoc"
+    "validation-service/pkg/mitre"
+)
+
+// DocsRequest asks for the documentation of a detection
+type DocsRequest struct {
+    Detection *models.Detection `json:"detection"`
+}
+
+// DocsHandler generates catalog documentation for detection rules
+type DocsHandler struct {
+    mu     sync.RWMutex
+    attack *mitre.Dataset
+}
+
+// NewDocsHandler creates a documentation handler. Technique names are
+// resolved against attack, which may be nil.
+func NewDocsHandler(attack *mitre.Dataset) *DocsHandler {
+    return &DocsHandler{attack: attack}
+}
+
+// SetAttackDataset replaces the ATT&CK dataset technique names are resolved
+// against
+func (h *DocsHandler) SetAttackDataset(attack *mitre.Dataset) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    h.attack = attack
+}
+
+// attackDataset returns the ATT&CK dataset in use, which may be nil
+func (h *DocsHandler) attackDataset() *mitre.Dataset {
+    h.mu.RLock()
+    defer h.mu.RUnlock()
+    return h.attack
+}
+
+// RegisterRoutes registers the documentation endpoint with the router
+func (h *DocsHandler) RegisterRoutes(r chi.Router) {
+    r.Post("/docs", h.GenerateDocsHandler)
+}
+
+// GenerateDocsHandler documents a detection as Markdown, or as the JSON
+// document when the Accept header names application/json
+func (h *DocsHandler) GenerateDocsHandler(w http.ResponseWriter, r *http.Request) {
+    var req DocsRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+    if req.Detection == nil {
+        writeError(w, r, http.StatusBadRequest, "detection is required")
+        return
+    }
+
+    doc, err := ruledoc.Generate(req.Detection, h.attackDataset())
+    if err != nil {
+        writeError(w, r, http.StatusUnprocessableEntity, err.Error())
+        return
+    }
+
+    for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+        mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+        if err == nil && mediaType == "application/json" {
+            writeJSON(w, r, http.StatusOK, doc)
+            return
+        }
+    }
+    w.Header().Set("Content-Type", ruledoc.MarkdownContentType)
+    w.WriteHeader(http.StatusOK)
+    w.Write([]byte(doc.Markdown()))
+}
@@ -0,0 +1,118 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "strings"
+)
+
+// Sparse fieldset limits protecting against abusive query strings
+const (
+    fieldsQueryParam = "fields"
+    maxFieldPaths    = 64
+    maxFieldDepth    = 6
+)
+
+// fieldSet is a parsed sparse fieldset such as
+// `status,confidence_score,issues.severity`. A nil child set selects the
+// whole subtree below that key.
+type fieldSet map[string]fieldSet
+
+// parseFieldSet parses the `fields` query parameter of a request. It returns
+// nil when no fieldset was requested.
+func parseFieldSet(r *http.Request) (fieldSet, error) {
+    raw := strings.TrimSpace(r.URL.Query().Get(fieldsQueryParam))
+    if raw == "" {
+        return nil, nil
+    }
+
+    paths := strings.Split(raw, ",")
+    if len(paths) > maxFieldPaths {
+        return nil, fmt.Errorf("too many fields requested (max %d)", maxFieldPaths)
+    }
+
+    fs := fieldSet{}
+    for _, path := range paths {
+        path = strings.TrimSpace(path)
+        segments := strings.Split(path, ".")
+        if path == "" || len(segments) > maxFieldDepth {
+            return nil, fmt.Errorf("invalid field path: %q", path)
+        }
+        for _, segment := range segments {
+            if segment == "" {
+                return nil, fmt.Errorf("invalid field path: %q", path)
+            }
+        }
+        fs.add(segments)
+    }
+    return fs, nil
+}
+
+// add inserts a path into the fieldset; selecting a parent supersedes children
+func (fs fieldSet) add(segments []string) {
+    head := segments[0]
+    child, exists := fs[head]
+    if len(segments) == 1 {
+        fs[head] = nil
+        return
+    }
+    if exists && child == nil {
+        return // whole subtree already selected
+    }
+    if child == nil {
+        child = fieldSet{}
+        fs[head] = child
+    }
+    child.add(segments[1:])
+}
+
+// has reports whether the fieldset selects key at this level
+func (fs fieldSet) has(key string) bool {
+    _, ok := fs[key]
+    return ok
+}
+
+// filter returns v reduced to the selected fields. v is marshaled to its JSON
+// form first so struct tags determine the field names.
+func (fs fieldSet) filter(v interface{}) (interface{}, error) {
+    if fs == nil {
+        return v, nil
+    }
+    data, err := json.Marshal(v)
+    if err != nil {
+        return nil, fmt.Errorf("marshaling for field filtering: %w", err)
+    }
+    var generic interface{}
+    if err := json.Unmarshal(data, &generic); err != nil {
+        return nil, fmt.Errorf("decoding for field filtering: %w", err)
+    }
+    return fs.prune(generic), nil
+}
+
+// prune applies the fieldset to a decoded JSON value; arrays are filtered
+// element by element so `issues.severity` selects severity of every issue
+func (fs fieldSet) prune(v interface{}) interface{} {
+    if fs == nil {
+        return v
+    }
+    switch node := v.(type) {
+    case map[string]interface{}:
+        out := make(map[string]interface{}, len(fs))
+        for key, child := range fs {
+            if value, ok := node[key]; ok {
+                out[key] = child.prune(value)
+            }
+        }
+        return out
+    case []interface{}:
+        out := make([]interface{}, len(node))
+        for i, item := range node {
+            out[i] = fs.prune(item)
+        }
+        return out
+    default:
+        return v
+    }
+}
@@ -0,0 +1,135 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/go-chi/chi/v5/middleware" // v5.0.8
+
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/models"
+    "validation-service/internal/services/quickfix"
+    "validation-service/internal/services/report"
+    "validation-service/internal/services/validation"
+    "validation-service/pkg/logger"
+)
+
+// FixResponse is returned by the quick-fix endpoint. Detection is the
+// corrected target detection and Result its re-validation; both are omitted
+// when nothing could be fixed.
+type FixResponse struct {
+    Status    string                   `json:"status"`
+    Detection *models.Detection        `json:"detection,omitempty"`
+    Fixes     []quickfix.Fix           `json:"fixes"`
+    Original  *models.ValidationResult `json:"original"`
+    Result    *models.ValidationResult `json:"result,omitempty"`
+    RequestID string                   `json:"request_id"`
+    TraceID   string                   `json:"trace_id,omitempty"`
+    Timestamp time.Time                `json:"timestamp"`
+}
+
+// FixHandler applies the deterministic fixes available for a translation's
+// validation issues and returns the corrected target detection together with
+// its re-validation result
+func (h *ValidationHandler) FixHandler(w http.ResponseWriter, r *http.Request) {
+    ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+    defer cancel()
+
+    if r.ContentLength > maxRequestSize {
+        writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+        return
+    }
+
+    output, err := parseOutputFormat(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    var req ValidationRequest
+    if err := h.parseJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+        return
+    }
+    if err := h.validateRequest(&req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("validation failed: %v", err))
+        return
+    }
+
+    original, err := h.service.ValidateDetection(ctx, req.SourceDetection, req.TargetDetection)
+    if errors.Is(err, validation.ErrFormatNotAllowed) {
+        writeError(w, r, http.StatusForbidden, err.Error())
+        return
+    }
+    if err != nil {
+        h.logFixError(ctx, "Validation before fixing failed", err, &req)
+        writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("validation error: %v", err))
+        return
+    }
+
+    correlateResult(r.Context(), original)
+    resp := &FixResponse{
+        Status:    original.Status,
+        Fixes:     []quickfix.Fix{},
+        Original:  original,
+        RequestID: middleware.GetReqID(r.Context()),
+        TraceID:   apimiddleware.TraceIDFromContext(r.Context()),
+        Timestamp: time.Now().UTC(),
+    }
+
+    corrected, fixes, err := quickfix.Apply(req.TargetDetection, original.Issues)
+    if errors.Is(err, quickfix.ErrNothingToFix) {
+        if output == outputSARIF {
+            writeSARIF(w, r, report.ToSARIF(original, report.ArtifactURI(req.TargetDetection)))
+            return
+        }
+        if output == outputAnnotated {
+            writeAnnotated(w, r, req.TargetDetection, original)
+            return
+        }
+        writeJSON(w, r, http.StatusOK, resp)
+        return
+    }
+    if err != nil {
+        h.logFixError(ctx, "Applying fixes failed", err, &req)
+        writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("fix error: %v", err))
+        return
+    }
+
+    result, err := h.service.ValidateDetection(ctx, req.SourceDetection, corrected)
+    if err != nil {
+        h.logFixError(ctx, "Re-validation of fixed detection failed", err, &req)
+        writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("validation error: %v", err))
+        return
+    }
+
+    // SARIF and annotated output report the issues remaining after the fixes
+    if output == outputSARIF {
+        writeSARIF(w, r, report.ToSARIF(result, report.ArtifactURI(corrected)))
+        return
+    }
+    if output == outputAnnotated {
+        writeAnnotated(w, r, corrected, result)
+        return
+    }
+
+    correlateResult(r.Context(), result)
+    resp.Status = result.Status
+    resp.Detection = corrected
+    resp.Fixes = fixes
+    resp.Result = result
+    writeJSON(w, r, http.StatusOK, resp)
+}
+
+// logFixError logs a failed quick-fix request with its formats
+func (h *ValidationHandler) logFixError(ctx context.Context, message string, err error, req *ValidationRequest) {
+    logger.FromContext(ctx).Error(message,
+        "error", err,
+        "source_format", req.SourceDetection.Format,
+        "target_format", req.TargetDetection.Format,
+    )
+}
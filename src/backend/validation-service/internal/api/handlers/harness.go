@@ -0,0 +1,136 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/go-chi/chi/v5/middleware" // v5.0.8
+
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/models"
+    "validation-service/internal/services/harness"
+    "validation-service/pkg/logger"
+)
+
+// Default test harness limits, used until SetHarnessLimits is called
+const (
+    defaultHarnessMaxEvents    = 1000
+    defaultHarnessMaxEventSize = 64 << 10
+    defaultHarnessTimeout      = 5 * time.Second
+)
+
+// harnessSettings bounds test harness runs
+type harnessSettings struct {
+    limits  harness.Limits
+    timeout time.Duration
+}
+
+// defaultHarnessSettings returns the default test harness limits
+func defaultHarnessSettings() harnessSettings {
+    return harnessSettings{
+        limits: harness.Limits{
+            MaxEvents:    defaultHarnessMaxEvents,
+            MaxEventSize: defaultHarnessMaxEventSize,
+        },
+        timeout: defaultHarnessTimeout,
+    }
+}
+
+// TestRequest submits a detection and sample events to the test harness
+type TestRequest struct {
+    Detection *models.Detection `json:"detection"`
+    // Events are the sample events as JSON lines, one object per line
+    Events string `json:"events"`
+    // Expected optionally holds whether each event should match, in order
+    Expected []bool `json:"expected,omitempty"`
+}
+
+// TestResponse reports which sample events a detection matched. Status is
+// failed when an event's outcome differs from the expected one.
+type TestResponse struct {
+    Status    string          `json:"status"`
+    Report    *harness.Report `json:"report"`
+    RequestID string          `json:"request_id"`
+    TraceID   string          `json:"trace_id,omitempty"`
+    Timestamp time.Time       `json:"timestamp"`
+}
+
+// SetHarnessLimits bounds the events and run time of test harness requests
+func (h *ValidationHandler) SetHarnessLimits(limits harness.Limits, timeout time.Duration) {
+    if timeout <= 0 {
+        timeout = defaultHarnessTimeout
+    }
+    h.harness = harnessSettings{limits: limits, timeout: timeout}
+}
+
+// TestHandler runs a detection against submitted sample events and reports
+// which events it matched, so true and false positives can be checked before
+// the rule is deployed
+func (h *ValidationHandler) TestHandler(w http.ResponseWriter, r *http.Request) {
+    ctx, cancel := context.WithTimeout(r.Context(), h.harness.timeout)
+    defer cancel()
+
+    if r.ContentLength > maxRequestSize {
+        writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+        return
+    }
+
+    var req TestRequest
+    if err := h.parseJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+        return
+    }
+    if req.Detection == nil || strings.TrimSpace(req.Detection.Content) == "" {
+        writeError(w, r, http.StatusBadRequest, "detection content is required")
+        return
+    }
+
+    events, err := harness.ParseEvents([]byte(req.Events), h.harness.limits)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid events: %v", err))
+        return
+    }
+
+    result, err := harness.Run(ctx, req.Detection, events, req.Expected)
+    switch {
+    case errors.Is(err, harness.ErrUnsupportedFormat):
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("%v; supported formats: %s", err, strings.Join(harness.Formats(), ", ")))
+        return
+    case errors.Is(err, harness.ErrNoEvents):
+        writeError(w, r, http.StatusBadRequest, "at least one sample event is required")
+        return
+    case errors.Is(err, harness.ErrExpectedMismatch):
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    case errors.Is(err, context.DeadlineExceeded):
+        writeError(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("test run exceeded %s", h.harness.timeout))
+        return
+    case errors.Is(err, context.Canceled):
+        return
+    case err != nil:
+        logger.FromContext(ctx).Warn("Test harness run failed",
+            "error", err,
+            "format", req.Detection.Format,
+            "events", len(events),
+        )
+        writeError(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("test error: %v", err))
+        return
+    }
+
+    status := "success"
+    if result.Expectations != nil && len(result.Expectations.Failed) > 0 {
+        status = "failed"
+    }
+    writeJSON(w, r, http.StatusOK, &TestResponse{
+        Status:    status,
+        Report:    result,
+        RequestID: middleware.GetReqID(r.Context()),
+        TraceID:   apimiddleware.TraceIDFromContext(r.Context()),
+        Timestamp: time.Now().UTC(),
+    })
+}
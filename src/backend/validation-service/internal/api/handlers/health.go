@@ -5,8 +5,11 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp" // v1.17.0
+
 	"validation-service/pkg/logger"
 	"validation-service/pkg/metrics"
 )
@@ -17,6 +20,23 @@ const (
 	serviceName    = "validation-service"
 )
 
+// draining is set once shutdown begins so readiness probes fail while
+// in-flight requests complete
+var draining atomic.Bool
+
+// metricsHandler serves the Prometheus default registry
+var metricsHandler = promhttp.Handler()
+
+// MarkDraining makes readiness probes report the service as unavailable
+func MarkDraining() {
+	draining.Store(true)
+}
+
+// MetricsHandler serves Prometheus metrics
+func MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	metricsHandler.ServeHTTP(w, r)
+}
+
 // healthResponse defines the structure for health check responses
 type healthResponse struct {
 	Status       string                 `json:"status"`
@@ -96,6 +116,14 @@ func ReadinessHandler(w http.ResponseWriter, r *http.Request) {
 		details["metrics_error"] = "Metrics system not responding"
 	}
 
+	// Stop receiving traffic once shutdown has begun
+	dependencies["accepting_traffic"] = true
+	if draining.Load() {
+		dependencies["accepting_traffic"] = false
+		isReady = false
+		details["shutdown"] = "Service is draining"
+	}
+
 	// Prepare response status
 	status := "UP"
 	httpStatus := http.StatusOK
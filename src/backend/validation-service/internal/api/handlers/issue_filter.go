@@ -0,0 +1,172 @@
+package handlers
+
+import (
+    "fmt"
+    "net/http"
+    "path"
+    "strings"
+
+    "validation-service/internal/models"
+)
+
+// Query parameters filtering and paginating the issues of returned results
+const (
+    severityParam    = "severity"
+    issueCodeParam   = "issue_code"
+    issueLimitParam  = "issue_limit"
+    issueOffsetParam = "issue_offset"
+    // maxIssueLimit bounds the issues returned per result
+    maxIssueLimit = 500
+    // maxIssueCodePatterns bounds the issue code patterns of a request
+    maxIssueCodePatterns = 32
+)
+
+// IssueSummary counts the issues of a result. Total and BySeverity cover
+// every issue, so clients see what a filter left out.
+type IssueSummary struct {
+    Total      int            `json:"total"`
+    BySeverity map[string]int `json:"by_severity"`
+    // Matched is the number of issues matching the severity and issue code
+    // filters, before pagination
+    Matched  int `json:"matched"`
+    Returned int `json:"returned"`
+    // Limit is the page size; zero returns every matching issue
+    Limit  int `json:"limit"`
+    Offset int `json:"offset"`
+}
+
+// IssuePagedResult is a validation result whose issues are filtered and
+// paginated, with the counts of all its issues
+type IssuePagedResult struct {
+    *models.ValidationResult
+    IssueSummary IssueSummary `json:"issue_summary"`
+}
+
+// issueFilter selects the issues returned with a result
+type issueFilter struct {
+    severities map[string]bool
+    // codes are issue code patterns in which * matches any characters;
+    // patterns with a dot match namespaced codes such as sigma.core.*
+    codes  []string
+    limit  int
+    offset int
+}
+
+// parseIssueFilter reads the severity, issue_code, issue_limit and
+// issue_offset parameters. Severities and patterns may be repeated or
+// comma-separated; an issue matches when it has any of the severities and
+// any of the patterns.
+func parseIssueFilter(r *http.Request) (*issueFilter, error) {
+    params := r.URL.Query()
+    f := &issueFilter{}
+
+    for _, severity := range splitParams(params[severityParam]) {
+        severity = strings.ToLower(severity)
+        switch severity {
+        case models.ValidationSeverityHigh, models.ValidationSeverityMedium, models.ValidationSeverityLow:
+        default:
+            return nil, fmt.Errorf("invalid severity %q", severity)
+        }
+        if f.severities == nil {
+            f.severities = make(map[string]bool)
+        }
+        f.severities[severity] = true
+    }
+
+    codes := splitParams(params[issueCodeParam])
+    if len(codes) > maxIssueCodePatterns {
+        return nil, fmt.Errorf("too many issue codes requested (max %d)", maxIssueCodePatterns)
+    }
+    for _, code := range codes {
+        code = strings.ToUpper(code)
+        if _, err := path.Match(code, ""); err != nil {
+            return nil, fmt.Errorf("invalid issue code pattern %q", code)
+        }
+        f.codes = append(f.codes, code)
+    }
+
+    var err error
+    if f.limit, err = parseOptionalInt(params.Get(issueLimitParam)); err != nil || f.limit < 0 || f.limit > maxIssueLimit {
+        return nil, fmt.Errorf("invalid %s: must be between 0 and %d", issueLimitParam, maxIssueLimit)
+    }
+    if f.offset, err = parseOptionalInt(params.Get(issueOffsetParam)); err != nil || f.offset < 0 {
+        return nil, fmt.Errorf("invalid %s: must not be negative", issueOffsetParam)
+    }
+    return f, nil
+}
+
+// matches reports whether issue passes the severity and issue code filters
+func (f *issueFilter) matches(issue *models.ValidationIssue) bool {
+    if f.severities != nil && !f.severities[strings.ToLower(issue.Severity)] {
+        return false
+    }
+    if len(f.codes) == 0 {
+        return true
+    }
+    code := strings.ToUpper(issue.IssueCode)
+    namespaced := strings.ToUpper(issue.NamespacedCode)
+    for _, pattern := range f.codes {
+        subject := code
+        if strings.Contains(pattern, ".") {
+            subject = namespaced
+        }
+        if ok, _ := path.Match(pattern, subject); ok {
+            return true
+        }
+    }
+    return false
+}
+
+// apply returns result with the page of its issues passing the filters,
+// in their original order. The stored result is not changed.
+func (f *issueFilter) apply(result *models.ValidationResult) *IssuePagedResult {
+    summary := IssueSummary{
+        Total:      len(result.Issues),
+        BySeverity: make(map[string]int),
+        Limit:      f.limit,
+        Offset:     f.offset,
+    }
+    matched := make([]models.ValidationIssue, 0, len(result.Issues))
+    for i := range result.Issues {
+        issue := &result.Issues[i]
+        summary.BySeverity[issue.Severity]++
+        if f.matches(issue) {
+            matched = append(matched, *issue)
+        }
+    }
+    summary.Matched = len(matched)
+
+    page := matched[min(f.offset, len(matched)):]
+    if f.limit > 0 && len(page) > f.limit {
+        page = page[:f.limit]
+    }
+    summary.Returned = len(page)
+
+    paged := *result
+    paged.Issues = page
+    return &IssuePagedResult{ValidationResult: &paged, IssueSummary: summary}
+}
+
+// parseResultFieldSet parses the sparse fieldset of a result retrieval,
+// which always keeps the issue summary
+func parseResultFieldSet(r *http.Request) (fieldSet, error) {
+    fields, err := parseFieldSet(r)
+    if fields != nil {
+        fields.add([]string{"issue_summary"})
+    }
+    return fields, err
+}
+
+// splitParams splits repeated, comma-separated parameter values, dropping
+// empty entries
+func splitParams(values []string) []string {
+    var out []string
+    for _, value := range values {
+        for _, part := range strings.Split(value, ",") {
+            if part = strings.TrimSpace(part); part != "" {
+                out = append(out, part)
+            }
+        }
+    }
+    return out
+}
@@ -0,0 +1,145 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+
+    apimiddleware "internal/api/middleware"
+    "internal/config"
+    "internal/models"
+    "internal/services/remediation"
+    "internal/services/report"
+    "internal/services/ruledoc"
+    "internal/services/validation"
+)
+
+// LintRequest asks for the validation of a single detection, without a
+// translation source
+type LintRequest struct {
+    Detection *models.Detection      `json:"detection"`
+    Options   map[string]interface{} `json:"options,omitempty"`
+}
+
+// LintHandler validates a single detection with the validator of its format
+// alone. No cross-format comparison runs, so a detection can be checked
+// before or without translating it. The options, output formats and query
+// parameters are those of ValidateHandler.
+func (h *ValidationHandler) LintHandler(w http.ResponseWriter, r *http.Request) {
+    ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+    defer cancel()
+
+    if r.ContentLength > maxRequestSize {
+        writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+        return
+    }
+
+    fields, err := parseFieldSet(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    output, err := parseOutputFormat(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    trace, err := parseTraceFlag(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    if trace {
+        ctx = validation.WithExecutionTrace(ctx)
+    }
+
+    var req LintRequest
+    if err := h.parseJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+        return
+    }
+    if req.Detection == nil {
+        writeError(w, r, http.StatusBadRequest, "detection is required")
+        return
+    }
+    if err := req.Detection.Validate(); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid detection: %v", err))
+        return
+    }
+    detections, preprocessors, err := h.preprocessDetections(req.Options, req.Detection)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    req.Detection = detections[0]
+
+    ctx, err = h.withProfileOptions(ctx, req.Options)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    result, cacheHit, err := h.service.LintDetection(ctx, req.Detection, bypassCache(r, req.Options))
+    h.auditValidation(r, &ValidationRequest{SourceDetection: req.Detection, TargetDetection: req.Detection}, result, err)
+    switch {
+    case errors.Is(err, validation.ErrFormatNotAllowed):
+        writeError(w, r, http.StatusForbidden, err.Error())
+        return
+    case errors.Is(err, validation.ErrUnsupportedFormat):
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    case err != nil:
+        h.log.Error("Lint failed",
+            "error", err,
+            "format", req.Detection.Format,
+        )
+        writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("validation error: %v", err))
+        return
+    }
+
+    remediation.ApplyPlaybooks(result, remediation.PlaybooksForTenant(
+        config.GetConfig().Remediation, tenantIDFromRequest(r)))
+    result.Metadata.Preprocessors = preprocessors
+    correlateResult(r.Context(), result)
+
+    // Persist the result for later retrieval; a storage failure does not
+    // invalidate the outcome returned to the caller
+    if h.results != nil {
+        if err := h.results.SaveResult(ctx, tenantIDFromRequest(r), result); err != nil {
+            h.log.Error("Failed to persist validation result",
+                "error", err,
+                "result_id", result.ID,
+            )
+        }
+    }
+
+    detailedReport := result.GetDetailedReport()
+    detailedReport.TargetSummary = ruledoc.Summarize(req.Detection)
+    if output == outputSARIF {
+        writeSARIF(w, r, report.ReportToSARIF(&detailedReport, report.ArtifactURI(req.Detection)))
+        return
+    }
+    if output == outputAnnotated {
+        writeAnnotated(w, r, req.Detection, result)
+        return
+    }
+
+    resp := &ValidationResponse{
+        Status:    result.Status,
+        Gate:      result.Metadata.Gate,
+        Result:    result,
+        Report:    &detailedReport,
+        CacheHit:  cacheHit,
+        RequestID: apimiddleware.RequestIDFromContext(r.Context()),
+        TraceID:   apimiddleware.TraceIDFromContext(r.Context()),
+        Timestamp: time.Now().UTC(),
+    }
+    if fields != nil {
+        h.sendSparseResponse(w, resp, fields)
+        return
+    }
+    writeJSON(w, r, http.StatusOK, resp)
+}
@@ -0,0 +1,726 @@
+package handlers
+
+import (
+    "archive/zip"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "mime"
+    "net/http"
+    "path"
+    "strings"
+    "time"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+    "github.com/google/uuid"   // v1.4.0
+
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/config"
+    "validation-service/internal/models"
+    "validation-service/internal/services/migration"
+    "validation-service/internal/spool"
+    "validation-service/internal/storage"
+    "validation-service/pkg/logger"
+    "validation-service/pkg/mitre"
+)
+
+// Tags recording the migration wizard a deployed detection came from and
+// the path of its source rule
+const (
+    TagMigrationID     = "migration.id"
+    TagMigrationSource = "migration.source"
+)
+
+// migrationManifest is the name of the result listing in an export archive
+const migrationManifest = "manifest.json"
+
+// targetExtensions are the file extensions of exported translations;
+// formats without one are exported as .txt
+var targetExtensions = map[string]string{
+    models.DetectionFormatSigma:    ".yml",
+    models.DetectionFormatSentinel: ".yaml",
+    models.DetectionFormatSplunk:   ".spl",
+    models.DetectionFormatKQL:      ".kql",
+    models.DetectionFormatQRadar:   ".aql",
+    models.DetectionFormatYara:     ".yar",
+    models.DetectionFormatYaraL:    ".yaral",
+    models.DetectionFormatSuricata: ".rules",
+    models.DetectionFormatSnort:    ".rules",
+    models.DetectionFormatZeek:     ".sig",
+}
+
+// CreateMigrationRequest is the body of a migration wizard create
+type CreateMigrationRequest struct {
+    Name string `json:"name"`
+}
+
+// MigrationReviewRequest confirms the source formats of a wizard's rules.
+// Rules not listed keep their detected format.
+type MigrationReviewRequest struct {
+    // Version, when set, must be the current version of the wizard
+    Version int                 `json:"version,omitempty"`
+    Rules   []MigrationRuleEdit `json:"rules,omitempty"`
+}
+
+// MigrationRuleEdit sets the format of a rule or excludes it
+type MigrationRuleEdit struct {
+    Path     string `json:"path"`
+    Format   string `json:"format,omitempty"`
+    Excluded bool   `json:"excluded,omitempty"`
+}
+
+// MigrationTargetsRequest chooses the target formats of a wizard
+type MigrationTargetsRequest struct {
+    // Version, when set, must be the current version of the wizard
+    Version int                    `json:"version,omitempty"`
+    Targets []storage.WizardTarget `json:"targets"`
+}
+
+// MigrationDeployRequest selects the translations a wizard deploys
+type MigrationDeployRequest struct {
+    // Version, when set, must be the current version of the wizard
+    Version int `json:"version,omitempty"`
+    // Statuses are the validation statuses of the translations deployed;
+    // success and warning when empty
+    Statuses []string `json:"statuses,omitempty"`
+}
+
+// MigrationListResponse lists the migration wizards of the caller's tenant
+type MigrationListResponse struct {
+    Migrations []*storage.MigrationWizard `json:"migrations"`
+}
+
+// MigrationHandler serves the migration wizards of the caller's tenant: an
+// upload is scanned for rules whose formats are inferred, reviewed, then
+// translated to the chosen targets and validated, and the translations are
+// downloaded or deployed as stored detections. Every step is persisted, so
+// a wizard can be resumed later or from another session.
+type MigrationHandler struct {
+    store      storage.MigrationWizardStore
+    detections storage.DetectionStore
+    spooler    *spool.Spooler
+    runner     *migration.Runner
+    cfg        config.MigrationConfig
+    log        *logger.Logger
+}
+
+// NewMigrationHandler creates a migration wizard handler backed by store.
+// Uploads are buffered through spooler and translations are deployed to
+// detections.
+func NewMigrationHandler(store storage.MigrationWizardStore, detections storage.DetectionStore, spooler *spool.Spooler, cfg config.MigrationConfig) *MigrationHandler {
+    return &MigrationHandler{
+        store:      store,
+        detections: detections,
+        spooler:    spooler,
+        cfg:        cfg,
+        log:        logger.GetLogger(),
+    }
+}
+
+// SetRunner enables the run step, translating and validating rules with
+// runner
+func (h *MigrationHandler) SetRunner(runner *migration.Runner) {
+    h.runner = runner
+}
+
+// RegisterRoutes registers the migration wizard endpoints with the router.
+// RunHandler is registered by the router, as it needs the jobs scope.
+func (h *MigrationHandler) RegisterRoutes(r chi.Router) {
+    r.Post("/migrations", h.CreateMigrationHandler)
+    r.Get("/migrations", h.ListMigrationsHandler)
+    r.Get("/migrations/{id}", h.GetMigrationHandler)
+    r.Delete("/migrations/{id}", h.DeleteMigrationHandler)
+    r.Post("/migrations/{id}/upload", h.UploadHandler)
+    r.Put("/migrations/{id}/review", h.ReviewHandler)
+    r.Put("/migrations/{id}/targets", h.TargetsHandler)
+    r.Get("/migrations/{id}/export", h.ExportHandler)
+    r.Post("/migrations/{id}/deploy", h.DeployHandler)
+}
+
+// CreateMigrationHandler creates a migration wizard waiting on its upload
+func (h *MigrationHandler) CreateMigrationHandler(w http.ResponseWriter, r *http.Request) {
+    var req CreateMigrationRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+
+    tenantID := tenantIDFromRequest(r)
+    existing, err := h.store.ListMigrationWizards(r.Context(), tenantID)
+    if err != nil {
+        h.log.Error("Failed to list migration wizards",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to store migration wizard")
+        return
+    }
+    if h.cfg.MaxPerTenant > 0 && len(existing) >= h.cfg.MaxPerTenant {
+        writeError(w, r, http.StatusConflict, fmt.Sprintf("tenant already has the maximum of %d migration wizards", h.cfg.MaxPerTenant))
+        return
+    }
+
+    now := time.Now().UTC()
+    wizard := &storage.MigrationWizard{
+        ID:        uuid.New(),
+        TenantID:  tenantID,
+        Name:      strings.TrimSpace(req.Name),
+        Step:      storage.WizardStepUpload,
+        CreatedAt: now,
+        UpdatedAt: now,
+    }
+    if claims, ok := apimiddleware.ClaimsFromContext(r.Context()); ok {
+        wizard.CreatedBy = claims.UserId
+    }
+    if err := wizard.Validate(); err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    if err := h.store.CreateMigrationWizard(r.Context(), wizard); err != nil {
+        h.log.Error("Failed to store migration wizard",
+            "error", err,
+            "migration_id", wizard.ID,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to store migration wizard")
+        return
+    }
+    writeJSON(w, r, http.StatusCreated, wizard)
+}
+
+// ListMigrationsHandler lists the migration wizards of the caller's tenant
+// without their rules and results
+func (h *MigrationHandler) ListMigrationsHandler(w http.ResponseWriter, r *http.Request) {
+    wizards, err := h.store.ListMigrationWizards(r.Context(), tenantIDFromRequest(r))
+    if err != nil {
+        h.log.Error("Failed to list migration wizards",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to list migration wizards")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, &MigrationListResponse{Migrations: wizards})
+}
+
+// GetMigrationHandler returns a migration wizard with its rules, targets and
+// the progress and results of its run. Rule and translation content is
+// included with content=true.
+func (h *MigrationHandler) GetMigrationHandler(w http.ResponseWriter, r *http.Request) {
+    content, err := parseBoolParam(r, "content")
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    wizard, ok := h.loadMigration(w, r)
+    if !ok {
+        return
+    }
+    if !content {
+        withoutContent(wizard)
+    }
+    writeJSON(w, r, http.StatusOK, wizard)
+}
+
+// DeleteMigrationHandler removes a migration wizard. A run in progress stops
+// at its next save; deployed detections are kept.
+func (h *MigrationHandler) DeleteMigrationHandler(w http.ResponseWriter, r *http.Request) {
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid migration ID")
+        return
+    }
+
+    err = h.store.DeleteMigrationWizard(r.Context(), tenantIDFromRequest(r), id)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "migration not found")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to delete migration wizard",
+            "error", err,
+            "migration_id", id,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to delete migration wizard")
+        return
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// UploadHandler completes the upload step: the rules of an uploaded zip or
+// (gzipped) tar archive replace those of the wizard, each with the formats
+// inferred from its content and extension. Uploading again discards the
+// later steps.
+func (h *MigrationHandler) UploadHandler(w http.ResponseWriter, r *http.Request) {
+    if h.spooler == nil {
+        writeError(w, r, http.StatusNotImplemented, "archive uploads are not enabled")
+        return
+    }
+    if r.ContentLength > h.spooler.MaxSize() {
+        writeError(w, r, http.StatusRequestEntityTooLarge, spool.ErrTooLarge.Error())
+        return
+    }
+    wizard, ok := h.loadEditable(w, r, storage.WizardStepUpload)
+    if !ok {
+        return
+    }
+
+    upload, err := h.spooler.Spool(r.Context(), wizard.TenantID, r.Body)
+    switch {
+    case errors.Is(err, spool.ErrTooLarge):
+        writeError(w, r, http.StatusRequestEntityTooLarge, err.Error())
+        return
+    case errors.Is(err, spool.ErrQuotaExceeded):
+        w.Header().Set("Retry-After", "30")
+        writeError(w, r, http.StatusTooManyRequests, err.Error())
+        return
+    case err != nil:
+        h.log.Error("Failed to buffer migration upload",
+            "error", err,
+            "migration_id", wizard.ID,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to read upload")
+        return
+    }
+    defer upload.Close()
+
+    maxRuleSize := config.GetConfig().Validation.MaxRuleSize
+    rules := make([]storage.WizardRule, 0)
+    err = readArchive(upload, r.Header.Get("Content-Type"), maxArchiveExpansion*h.spooler.MaxSize(), func(entry archiveEntry) error {
+        if h.cfg.MaxRules > 0 && len(rules) >= h.cfg.MaxRules {
+            return fmt.Errorf("archive has more than %d rules", h.cfg.MaxRules)
+        }
+        if len(entry.content) == 0 || (maxRuleSize > 0 && len(entry.content) > maxRuleSize) {
+            return nil
+        }
+        rule := storage.WizardRule{Path: entry.name, Content: string(entry.content)}
+        detected, candidates := migration.DetectFormat(entry.name, rule.Content)
+        if detected == "" {
+            // Files that look like no rule, such as READMEs, are not
+            // migrated
+            return nil
+        }
+        rule.DetectedFormat = detected
+        rule.Candidates = candidates
+        if supportedFormat(detected) {
+            rule.Format = detected
+        }
+        rules = append(rules, rule)
+        return nil
+    })
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid archive: %v", err))
+        return
+    }
+    if len(rules) == 0 {
+        writeError(w, r, http.StatusUnprocessableEntity, "archive contains no recognized rules")
+        return
+    }
+
+    wizard.Reset(storage.WizardStepReview)
+    wizard.Rules = rules
+    h.update(w, r, wizard)
+}
+
+// ReviewHandler completes the review step, setting the source format of
+// rules or excluding them. Every rule that is not excluded needs a format.
+// Reviewing again discards the later steps.
+func (h *MigrationHandler) ReviewHandler(w http.ResponseWriter, r *http.Request) {
+    var req MigrationReviewRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+    wizard, ok := h.loadEditable(w, r, storage.WizardStepReview)
+    if !ok || !checkVersion(w, r, wizard, req.Version) {
+        return
+    }
+
+    index := make(map[string]int, len(wizard.Rules))
+    for i, rule := range wizard.Rules {
+        index[rule.Path] = i
+    }
+    for _, edit := range req.Rules {
+        i, ok := index[edit.Path]
+        if !ok {
+            writeError(w, r, http.StatusBadRequest, fmt.Sprintf("unknown rule: %s", edit.Path))
+            return
+        }
+        rule := &wizard.Rules[i]
+        rule.Excluded = edit.Excluded
+        if format := strings.ToLower(strings.TrimSpace(edit.Format)); format != "" {
+            if !supportedFormat(format) {
+                writeError(w, r, http.StatusBadRequest, fmt.Sprintf("unsupported format of %s: %s", edit.Path, format))
+                return
+            }
+            rule.Format = format
+        }
+    }
+
+    included, missing := 0, 0
+    for _, rule := range wizard.Rules {
+        switch {
+        case rule.Excluded:
+        case rule.Format == "":
+            missing++
+        default:
+            included++
+        }
+    }
+    if missing > 0 {
+        writeError(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("%d rules need a format or must be excluded", missing))
+        return
+    }
+    if included == 0 {
+        writeError(w, r, http.StatusUnprocessableEntity, "every rule is excluded")
+        return
+    }
+
+    wizard.Reset(storage.WizardStepTargets)
+    h.update(w, r, wizard)
+}
+
+// TargetsHandler completes the targets step, choosing the target formats
+// and the translation pipelines of each. Choosing again discards the
+// results of the run.
+func (h *MigrationHandler) TargetsHandler(w http.ResponseWriter, r *http.Request) {
+    var req MigrationTargetsRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+    wizard, ok := h.loadEditable(w, r, storage.WizardStepTargets)
+    if !ok || !checkVersion(w, r, wizard, req.Version) {
+        return
+    }
+
+    if len(req.Targets) == 0 {
+        writeError(w, r, http.StatusBadRequest, "at least one target is required")
+        return
+    }
+    if h.cfg.MaxTargets > 0 && len(req.Targets) > h.cfg.MaxTargets {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("a migration has at most %d targets", h.cfg.MaxTargets))
+        return
+    }
+    targets := make([]storage.WizardTarget, 0, len(req.Targets))
+    seen := make(map[string]bool, len(req.Targets))
+    for _, target := range req.Targets {
+        format := strings.ToLower(strings.TrimSpace(target.Format))
+        if !supportedFormat(format) {
+            writeError(w, r, http.StatusBadRequest, fmt.Sprintf("unsupported target format: %q", target.Format))
+            return
+        }
+        if seen[format] {
+            writeError(w, r, http.StatusBadRequest, fmt.Sprintf("target %s is listed more than once", format))
+            return
+        }
+        seen[format] = true
+        pipelines := make([]string, 0, len(target.Pipelines))
+        for _, pipeline := range target.Pipelines {
+            if pipeline = strings.TrimSpace(pipeline); pipeline == "" || len(pipeline) > maxTagLength {
+                writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid pipeline of target %s", format))
+                return
+            }
+            pipelines = append(pipelines, pipeline)
+        }
+        targets = append(targets, storage.WizardTarget{Format: format, Pipelines: pipelines})
+    }
+
+    wizard.Reset(storage.WizardStepRun)
+    wizard.Targets = targets
+    h.update(w, r, wizard)
+}
+
+// RunHandler starts the run step in the background: every included rule is
+// translated to every target and the translations are validated. Progress
+// is reported by GetMigrationHandler. Running again after a run stopped or
+// finished keeps the translated rules and retries the failed ones.
+func (h *MigrationHandler) RunHandler(w http.ResponseWriter, r *http.Request) {
+    if h.runner == nil {
+        writeError(w, r, http.StatusNotImplemented, "no translation service is configured")
+        return
+    }
+    wizard, ok := h.loadMigration(w, r)
+    if !ok {
+        return
+    }
+    if !wizard.Reached(storage.WizardStepRun) || wizard.Step == storage.WizardStepCompleted {
+        writeError(w, r, http.StatusConflict, fmt.Sprintf("migration is at the %s step", wizard.Step))
+        return
+    }
+
+    err := h.runner.Start(wizard)
+    if errors.Is(err, migration.ErrRunInProgress) || errors.Is(err, storage.ErrVersionConflict) {
+        writeError(w, r, http.StatusConflict, "a run of the migration is in progress")
+        return
+    }
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "migration not found")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to start migration run",
+            "error", err,
+            "migration_id", wizard.ID,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to start migration run")
+        return
+    }
+    withoutContent(wizard)
+    writeJSON(w, r, http.StatusAccepted, wizard)
+}
+
+// ExportHandler downloads the translations of a finished run as a zip
+// archive: one directory per target format holding the translated rules at
+// their source paths, and a manifest listing every result
+func (h *MigrationHandler) ExportHandler(w http.ResponseWriter, r *http.Request) {
+    wizard, ok := h.loadMigration(w, r)
+    if !ok {
+        return
+    }
+    if !wizard.Reached(storage.WizardStepExport) {
+        writeError(w, r, http.StatusConflict, fmt.Sprintf("migration is at the %s step", wizard.Step))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/zip")
+    w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+        "filename": fmt.Sprintf("migration-%s.zip", wizard.ID),
+    }))
+    archive := zip.NewWriter(w)
+    for _, result := range wizard.Results {
+        if result.Content == "" {
+            continue
+        }
+        file, err := archive.Create(exportPath(result))
+        if err == nil {
+            _, err = file.Write([]byte(result.Content))
+        }
+        if err != nil {
+            h.log.Warn("Failed to write migration export",
+                "error", err,
+                "migration_id", wizard.ID,
+            )
+            return
+        }
+    }
+    withoutContent(wizard)
+    manifest, err := archive.Create(migrationManifest)
+    if err == nil {
+        encoder := json.NewEncoder(manifest)
+        encoder.SetIndent("", "  ")
+        err = encoder.Encode(wizard.Results)
+    }
+    if err == nil {
+        err = archive.Close()
+    }
+    if err != nil {
+        h.log.Warn("Failed to write migration export",
+            "error", err,
+            "migration_id", wizard.ID,
+        )
+    }
+}
+
+// DeployHandler completes the wizard, storing the translations whose
+// validation status is selected as detections tagged with the migration and
+// their source path
+func (h *MigrationHandler) DeployHandler(w http.ResponseWriter, r *http.Request) {
+    var req MigrationDeployRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+    statuses := map[string]bool{}
+    for _, status := range req.Statuses {
+        switch status {
+        case models.ValidationStatusSuccess, models.ValidationStatusWarning, models.ValidationStatusError:
+            statuses[status] = true
+        default:
+            writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid status: %q", status))
+            return
+        }
+    }
+    if len(statuses) == 0 {
+        statuses[models.ValidationStatusSuccess] = true
+        statuses[models.ValidationStatusWarning] = true
+    }
+
+    wizard, ok := h.loadMigration(w, r)
+    if !ok || !checkVersion(w, r, wizard, req.Version) {
+        return
+    }
+    if wizard.Step != storage.WizardStepExport {
+        writeError(w, r, http.StatusConflict, fmt.Sprintf("migration is at the %s step", wizard.Step))
+        return
+    }
+
+    now := time.Now().UTC()
+    deployment := &storage.WizardDeployment{DetectionIDs: []uuid.UUID{}, DeployedAt: now}
+    if claims, ok := apimiddleware.ClaimsFromContext(r.Context()); ok {
+        deployment.DeployedBy = claims.UserId
+    }
+    for _, result := range wizard.Results {
+        if !statuses[result.Status] {
+            deployment.Skipped++
+            continue
+        }
+        stored, err := h.deployResult(r, wizard, result, deployment.DeployedBy, now)
+        if err != nil {
+            h.log.Error("Failed to deploy migrated detection",
+                "error", err,
+                "migration_id", wizard.ID,
+                "path", result.Path,
+            )
+            writeError(w, r, http.StatusInternalServerError, "failed to store detection")
+            return
+        }
+        deployment.DetectionIDs = append(deployment.DetectionIDs, stored.ID())
+    }
+
+    wizard.Step = storage.WizardStepCompleted
+    wizard.Deployment = deployment
+    h.update(w, r, wizard)
+}
+
+// deployResult stores a translation as a detection
+func (h *MigrationHandler) deployResult(r *http.Request, wizard *storage.MigrationWizard, result storage.WizardResult, deployedBy string, now time.Time) (*storage.StoredDetection, error) {
+    detection, err := models.NewDetection(result.Content, result.TargetFormat)
+    if err != nil {
+        return nil, err
+    }
+    stored := &storage.StoredDetection{
+        Detection: detection,
+        TenantID:  wizard.TenantID,
+        Name:      strings.TrimSuffix(path.Base(result.Path), path.Ext(result.Path)),
+        Tags: map[string]string{
+            TagMigrationID:     wizard.ID.String(),
+            TagMigrationSource: result.Path,
+        },
+        Techniques: mitre.ExtractTechniques(result.Content),
+        UpdatedBy:  deployedBy,
+        UpdatedAt:  now,
+    }
+    if result.ResultID != nil {
+        stored.Validation = &storage.DetectionValidation{
+            ResultID:        *result.ResultID,
+            Status:          result.Status,
+            ConfidenceScore: result.ConfidenceScore,
+            Issues:          result.Issues,
+            ValidatedAt:     now,
+        }
+        score := result.ConfidenceScore
+        stored.ConfidenceScore = &score
+    }
+    if err := h.detections.CreateDetection(r.Context(), stored); err != nil {
+        return nil, err
+    }
+    return stored, nil
+}
+
+// loadMigration loads the wizard named by the URL, writing an error
+// response unless it exists in the caller's tenant
+func (h *MigrationHandler) loadMigration(w http.ResponseWriter, r *http.Request) (*storage.MigrationWizard, bool) {
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid migration ID")
+        return nil, false
+    }
+
+    wizard, err := h.store.GetMigrationWizard(r.Context(), tenantIDFromRequest(r), id)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "migration not found")
+        return nil, false
+    }
+    if err != nil {
+        h.log.Error("Failed to load migration wizard",
+            "error", err,
+            "migration_id", id,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to load migration wizard")
+        return nil, false
+    }
+    return wizard, true
+}
+
+// loadEditable loads the wizard named by the URL for completing step,
+// rejecting steps not reached yet and wizards with a run in progress or
+// deployed translations
+func (h *MigrationHandler) loadEditable(w http.ResponseWriter, r *http.Request, step string) (*storage.MigrationWizard, bool) {
+    wizard, ok := h.loadMigration(w, r)
+    if !ok {
+        return nil, false
+    }
+    switch {
+    case wizard.Running(time.Now()):
+        writeError(w, r, http.StatusConflict, "a run of the migration is in progress")
+        return nil, false
+    case wizard.Step == storage.WizardStepCompleted:
+        writeError(w, r, http.StatusConflict, "migration is completed")
+        return nil, false
+    case !wizard.Reached(step):
+        writeError(w, r, http.StatusConflict, fmt.Sprintf("migration is at the %s step", wizard.Step))
+        return nil, false
+    }
+    return wizard, true
+}
+
+// update stores the wizard and writes it without content
+func (h *MigrationHandler) update(w http.ResponseWriter, r *http.Request, wizard *storage.MigrationWizard) {
+    if err := wizard.Validate(); err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    wizard.UpdatedAt = time.Now().UTC()
+    err := h.store.UpdateMigrationWizard(r.Context(), wizard)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "migration not found")
+        return
+    }
+    if errors.Is(err, storage.ErrVersionConflict) {
+        writeError(w, r, http.StatusConflict, fmt.Sprintf("migration was changed since version %d", wizard.Version))
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to update migration wizard",
+            "error", err,
+            "migration_id", wizard.ID,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to update migration wizard")
+        return
+    }
+    withoutContent(wizard)
+    writeJSON(w, r, http.StatusOK, wizard)
+}
+
+// checkVersion writes a conflict response unless version is zero or the
+// current version of the wizard
+func checkVersion(w http.ResponseWriter, r *http.Request, wizard *storage.MigrationWizard, version int) bool {
+    if version < 0 {
+        writeError(w, r, http.StatusBadRequest, "version must not be negative")
+        return false
+    }
+    if version != 0 && version != wizard.Version {
+        writeError(w, r, http.StatusConflict, fmt.Sprintf("migration was changed since version %d", version))
+        return false
+    }
+    return true
+}
+
+// withoutContent drops the rule and translation content of the wizard
+func withoutContent(wizard *storage.MigrationWizard) {
+    for i := range wizard.Rules {
+        wizard.Rules[i].Content = ""
+    }
+    for i := range wizard.Results {
+        wizard.Results[i].Content = ""
+    }
+}
+
+// exportPath is the path of a translation in an export archive: its source
+// path under a directory of its target format, with the target's extension
+func exportPath(result storage.WizardResult) string {
+    extension, ok := targetExtensions[result.TargetFormat]
+    if !ok {
+        extension = ".txt"
+    }
+    name := strings.TrimSuffix(path.Clean("/"+result.Path), path.Ext(result.Path))
+    return result.TargetFormat + name + extension
+}
@@ -0,0 +1,58 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+
+    "validation-service/internal/models"
+    "validation-service/internal/services/normalize"
+)
+
+// NormalizeRequest asks for a detection in the canonical layout of its
+// format
+type NormalizeRequest struct {
+    Detection *models.Detection `json:"detection"`
+}
+
+// NormalizeHandler returns the content of a detection in canonical layout,
+// the normalization rules that changed it and the lines that differ from
+// the input. Nothing is validated or stored, so pre-commit hooks can fail
+// on Changed and show the diff.
+func (h *ValidationHandler) NormalizeHandler(w http.ResponseWriter, r *http.Request) {
+    if r.ContentLength > maxRequestSize {
+        writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+        return
+    }
+
+    var req NormalizeRequest
+    if err := h.parseJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+        return
+    }
+    if req.Detection == nil {
+        writeError(w, r, http.StatusBadRequest, "detection is required")
+        return
+    }
+    if err := req.Detection.Validate(); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid detection: %v", err))
+        return
+    }
+
+    result, err := normalize.Normalize(req.Detection.Content, req.Detection.Format)
+    switch {
+    case errors.Is(err, normalize.ErrInvalidContent):
+        writeError(w, r, http.StatusUnprocessableEntity, err.Error())
+        return
+    case err != nil:
+        h.log.Error("Normalization failed",
+            "error", err,
+            "format", req.Detection.Format,
+        )
+        writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("normalization error: %v", err))
+        return
+    }
+
+    writeJSON(w, r, http.StatusOK, result)
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+    "github.com/google/uuid"   // v1.4.0
+
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/storage"
+    "validation-service/pkg/logger"
+)
+
+// Notifier receives events for delivery to notification subscriptions
+type Notifier interface {
+    Publish(event *storage.NotificationEvent)
+}
+
+// SubscriptionRequest is the body of a subscription create or update
+type SubscriptionRequest struct {
+    Channel  storage.NotificationChannel `json:"channel"`
+    Delivery string                      `json:"delivery"`
+    Filter   storage.SubscriptionFilter  `json:"filter"`
+}
+
+// SubscriptionListResponse lists the subscriptions of the caller
+type SubscriptionListResponse struct {
+    Subscriptions []*storage.Subscription `json:"subscriptions"`
+}
+
+// NotificationHandler serves the notification subscriptions of the
+// authenticated user. Channel secrets are write-only.
+type NotificationHandler struct {
+    store storage.NotificationStore
+    log   *logger.Logger
+}
+
+// NewNotificationHandler creates a notification handler backed by store
+func NewNotificationHandler(store storage.NotificationStore) *NotificationHandler {
+    return &NotificationHandler{
+        store: store,
+        log:   logger.GetLogger(),
+    }
+}
+
+// RegisterRoutes registers the subscription endpoints with the router
+func (h *NotificationHandler) RegisterRoutes(r chi.Router) {
+    r.Post("/notifications/subscriptions", h.CreateSubscriptionHandler)
+    r.Get("/notifications/subscriptions", h.ListSubscriptionsHandler)
+    r.Get("/notifications/subscriptions/{id}", h.GetSubscriptionHandler)
+    r.Put("/notifications/subscriptions/{id}", h.UpdateSubscriptionHandler)
+    r.Delete("/notifications/subscriptions/{id}", h.DeleteSubscriptionHandler)
+}
+
+// CreateSubscriptionHandler subscribes the caller to notifications
+func (h *NotificationHandler) CreateSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+    claims, ok := apimiddleware.ClaimsFromContext(r.Context())
+    if !ok {
+        writeError(w, r, http.StatusUnauthorized, "authentication required")
+        return
+    }
+
+    var req SubscriptionRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+
+    now := time.Now().UTC()
+    subscription := &storage.Subscription{
+        ID:        uuid.New(),
+        TenantID:  tenantIDFromRequest(r),
+        UserID:    claims.UserId,
+        CreatedAt: now,
+        UpdatedAt: now,
+    }
+    if status, err := applySubscriptionRequest(subscription, &req, claims); err != nil {
+        writeError(w, r, status, err.Error())
+        return
+    }
+
+    if err := h.store.SaveSubscription(r.Context(), subscription); err != nil {
+        h.log.Error("Failed to store subscription",
+            "error", err,
+            "subscription_id", subscription.ID,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to store subscription")
+        return
+    }
+    writeJSON(w, r, http.StatusCreated, redactSubscription(subscription))
+}
+
+// ListSubscriptionsHandler lists the caller's subscriptions
+func (h *NotificationHandler) ListSubscriptionsHandler(w http.ResponseWriter, r *http.Request) {
+    claims, ok := apimiddleware.ClaimsFromContext(r.Context())
+    if !ok {
+        writeError(w, r, http.StatusUnauthorized, "authentication required")
+        return
+    }
+
+    subscriptions, err := h.store.ListSubscriptions(r.Context(), tenantIDFromRequest(r), claims.UserId)
+    if err != nil {
+        h.log.Error("Failed to list subscriptions",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to list subscriptions")
+        return
+    }
+    for i, subscription := range subscriptions {
+        subscriptions[i] = redactSubscription(subscription)
+    }
+    writeJSON(w, r, http.StatusOK, &SubscriptionListResponse{Subscriptions: subscriptions})
+}
+
+// GetSubscriptionHandler returns a subscription of the caller by ID
+func (h *NotificationHandler) GetSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+    subscription, ok := h.loadOwnSubscription(w, r)
+    if !ok {
+        return
+    }
+    writeJSON(w, r, http.StatusOK, redactSubscription(subscription))
+}
+
+// UpdateSubscriptionHandler replaces the channel, delivery mode and filter of
+// a subscription of the caller. An empty channel secret keeps the stored one
+// when the target is unchanged.
+func (h *NotificationHandler) UpdateSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+    claims, ok := apimiddleware.ClaimsFromContext(r.Context())
+    if !ok {
+        writeError(w, r, http.StatusUnauthorized, "authentication required")
+        return
+    }
+    subscription, ok := h.loadOwnSubscription(w, r)
+    if !ok {
+        return
+    }
+
+    var req SubscriptionRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+    if req.Channel.Secret == "" && req.Channel.Type == subscription.Channel.Type &&
+        strings.TrimSpace(req.Channel.Target) == subscription.Channel.Target {
+        req.Channel.Secret = subscription.Channel.Secret
+    }
+    if status, err := applySubscriptionRequest(subscription, &req, claims); err != nil {
+        writeError(w, r, status, err.Error())
+        return
+    }
+    subscription.UpdatedAt = time.Now().UTC()
+
+    if err := h.store.SaveSubscription(r.Context(), subscription); err != nil {
+        h.log.Error("Failed to update subscription",
+            "error", err,
+            "subscription_id", subscription.ID,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to update subscription")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, redactSubscription(subscription))
+}
+
+// DeleteSubscriptionHandler removes a subscription of the caller
+func (h *NotificationHandler) DeleteSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+    subscription, ok := h.loadOwnSubscription(w, r)
+    if !ok {
+        return
+    }
+
+    err := h.store.DeleteSubscription(r.Context(), subscription.TenantID, subscription.ID)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "subscription not found")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to delete subscription",
+            "error", err,
+            "subscription_id", subscription.ID,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to delete subscription")
+        return
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// loadOwnSubscription loads the subscription named by the URL, writing an
+// error response unless it exists and belongs to the caller. Subscriptions
+// of other users are reported as not found.
+func (h *NotificationHandler) loadOwnSubscription(w http.ResponseWriter, r *http.Request) (*storage.Subscription, bool) {
+    claims, ok := apimiddleware.ClaimsFromContext(r.Context())
+    if !ok {
+        writeError(w, r, http.StatusUnauthorized, "authentication required")
+        return nil, false
+    }
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid subscription ID")
+        return nil, false
+    }
+
+    subscription, err := h.store.GetSubscription(r.Context(), tenantIDFromRequest(r), id)
+    if errors.Is(err, storage.ErrNotFound) || (err == nil && subscription.UserID != claims.UserId) {
+        writeError(w, r, http.StatusNotFound, "subscription not found")
+        return nil, false
+    }
+    if err != nil {
+        h.log.Error("Failed to load subscription",
+            "error", err,
+            "subscription_id", id,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to load subscription")
+        return nil, false
+    }
+    return subscription, true
+}
+
+// applySubscriptionRequest normalizes req into subscription and validates
+// it. Filtering on tenants other than the caller's own requires the admin
+// scope.
+func applySubscriptionRequest(subscription *storage.Subscription, req *SubscriptionRequest, claims *apimiddleware.Claims) (int, error) {
+    subscription.Channel = storage.NotificationChannel{
+        Type:   strings.ToLower(strings.TrimSpace(req.Channel.Type)),
+        Target: strings.TrimSpace(req.Channel.Target),
+        Secret: req.Channel.Secret,
+    }
+    subscription.Delivery = strings.ToLower(strings.TrimSpace(req.Delivery))
+    if subscription.Delivery == "" {
+        subscription.Delivery = storage.DeliveryImmediate
+    }
+    subscription.Filter = storage.SubscriptionFilter{
+        MinSeverity: strings.ToLower(strings.TrimSpace(req.Filter.MinSeverity)),
+    }
+    for _, format := range req.Filter.Formats {
+        subscription.Filter.Formats = append(subscription.Filter.Formats, strings.ToLower(strings.TrimSpace(format)))
+    }
+    for _, tenantID := range req.Filter.Tenants {
+        tenantID = strings.TrimSpace(tenantID)
+        if tenantID != subscription.TenantID && !claims.HasScope(apimiddleware.ScopeAdmin) {
+            return http.StatusForbidden, fmt.Errorf("subscribing to tenant %q requires scope %s", tenantID, apimiddleware.ScopeAdmin)
+        }
+        subscription.Filter.Tenants = append(subscription.Filter.Tenants, tenantID)
+    }
+
+    if err := subscription.Validate(); err != nil {
+        return http.StatusBadRequest, err
+    }
+    return http.StatusOK, nil
+}
+
+// redactSubscription returns a copy of the subscription without its channel
+// secret
+func redactSubscription(subscription *storage.Subscription) *storage.Subscription {
+    redacted := *subscription
+    redacted.Channel.Secret = ""
+    return &redacted
+}
@@ -0,0 +1,135 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "encoding/json"
+    "encoding/xml"
+    "fmt"
+    "io"
+    "mime"
+    "net/http"
+    "path"
+    "strings"
+
+    "github.com/go-chi/chi/v5/middleware" // v5.0.8
+
+    "validation-service/internal/models"
+    "validation-service/internal/services/report"
+    "validation-service/pkg/logger"
+)
+
+// outputParam is the query parameter selecting the response format
+const outputParam = "output"
+
+// Response formats of the validate endpoints
+const (
+    outputJSON      = "json"
+    outputSARIF     = "sarif"
+    outputAnnotated = "annotated"
+    outputJUnit     = "junit"
+)
+
+// parseOutputFormat selects the response format from the output query
+// parameter or, when it is absent, from an Accept header naming
+// application/sarif+json
+func parseOutputFormat(r *http.Request) (string, error) {
+    switch output := strings.ToLower(r.URL.Query().Get(outputParam)); output {
+    case outputJSON, outputSARIF, outputAnnotated:
+        return output, nil
+    case "":
+    default:
+        return "", fmt.Errorf("invalid %s parameter %q: use %s, %s or %s", outputParam, output, outputJSON, outputSARIF, outputAnnotated)
+    }
+
+    for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+        mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+        if err == nil && mediaType == report.SARIFContentType {
+            return outputSARIF, nil
+        }
+    }
+    return outputJSON, nil
+}
+
+// parseBatchOutputFormat selects the response format of the batch endpoint
+// from the output query parameter or, when it is absent, from an Accept
+// header naming an XML media type
+func parseBatchOutputFormat(r *http.Request) (string, error) {
+    switch output := strings.ToLower(r.URL.Query().Get(outputParam)); output {
+    case outputJSON, outputJUnit:
+        return output, nil
+    case "":
+    default:
+        return "", fmt.Errorf("invalid %s parameter %q: use %s or %s", outputParam, output, outputJSON, outputJUnit)
+    }
+
+    for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+        mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+        if err != nil {
+            continue
+        }
+        switch mediaType {
+        case report.JUnitContentType, "text/xml", "application/junit+xml":
+            return outputJUnit, nil
+        }
+    }
+    return outputJSON, nil
+}
+
+// parseFailOn reads the fail_on query parameter: the issue severity at or
+// above which a JUnit test case fails, high when absent, or gate to fail on
+// the gate verdict
+func parseFailOn(r *http.Request) (string, error) {
+    switch failOn := strings.ToLower(r.URL.Query().Get(failOnParam)); failOn {
+    case "":
+        return models.ValidationSeverityHigh, nil
+    case models.ValidationSeverityHigh, models.ValidationSeverityMedium, models.ValidationSeverityLow, report.FailOnGate:
+        return failOn, nil
+    default:
+        return "", fmt.Errorf("invalid %s parameter %q: use %s, %s, %s or %s", failOnParam, failOn,
+            models.ValidationSeverityHigh, models.ValidationSeverityMedium, models.ValidationSeverityLow, report.FailOnGate)
+    }
+}
+
+// writeSARIF sends a SARIF log
+func writeSARIF(w http.ResponseWriter, r *http.Request, log *report.SARIFLog) {
+    w.Header().Set("Content-Type", report.SARIFContentType)
+    w.WriteHeader(http.StatusOK)
+    if err := json.NewEncoder(w).Encode(log); err != nil {
+        logger.GetLogger().Error("Failed to encode SARIF response",
+            "error", err,
+            "request_id", middleware.GetReqID(r.Context()),
+        )
+    }
+}
+
+// writeAnnotated sends the content of detection annotated with the issues of
+// result, named after the detection so it can be saved and opened in an editor
+func writeAnnotated(w http.ResponseWriter, r *http.Request, detection *models.Detection, result *models.ValidationResult) {
+    w.Header().Set("Content-Type", report.AnnotatedContentType)
+    w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{
+        "filename": path.Base(report.ArtifactURI(detection)),
+    }))
+    w.WriteHeader(http.StatusOK)
+    if _, err := io.WriteString(w, report.Annotate(detection, result)); err != nil {
+        logger.GetLogger().Error("Failed to write annotated content",
+            "error", err,
+            "request_id", middleware.GetReqID(r.Context()),
+        )
+    }
+}
+
+// writeJUnit sends a JUnit XML report
+func writeJUnit(w http.ResponseWriter, r *http.Request, suites *report.JUnitTestSuites) {
+    w.Header().Set("Content-Type", report.JUnitContentType)
+    w.WriteHeader(http.StatusOK)
+    _, err := io.WriteString(w, xml.Header)
+    if err == nil {
+        err = xml.NewEncoder(w).Encode(suites)
+    }
+    if err != nil {
+        logger.GetLogger().Error("Failed to encode JUnit response",
+            "error", err,
+            "request_id", middleware.GetReqID(r.Context()),
+        )
+    }
+}
@@ -0,0 +1,62 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "time"
+
+    "github.com/go-chi/chi/v5/middleware" // v5.0.8
+
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/models"
+    "validation-service/pkg/logger"
+)
+
+// ErrorResponse is the error body returned by resource handlers
+type ErrorResponse struct {
+    Status    string    `json:"status"`
+    Error     string    `json:"error"`
+    RequestID string    `json:"request_id,omitempty"`
+    TraceID   string    `json:"trace_id,omitempty"`
+    Timestamp time.Time `json:"timestamp"`
+}
+
+// writeJSON encodes v as the JSON response body with the given status
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    if err := json.NewEncoder(w).Encode(v); err != nil {
+        logger.GetLogger().Error("Failed to encode response",
+            "error", err,
+            "status", status,
+            "request_id", middleware.GetReqID(r.Context()),
+        )
+    }
+}
+
+// writeError sends an ErrorResponse with the given status and message
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+    writeJSON(w, r, status, &ErrorResponse{
+        Status:    "error",
+        Error:     message,
+        RequestID: middleware.GetReqID(r.Context()),
+        TraceID:   apimiddleware.TraceIDFromContext(r.Context()),
+        Timestamp: time.Now().UTC(),
+    })
+}
+
+// correlateResult records the request and trace IDs of ctx on a result
+func correlateResult(ctx context.Context, result *models.ValidationResult) {
+    result.Metadata.RequestID = middleware.GetReqID(ctx)
+    result.Metadata.TraceID = apimiddleware.TraceIDFromContext(ctx)
+}
+
+// decodeJSONBody decodes a size-limited JSON request body into v
+func decodeJSONBody(r *http.Request, v interface{}) error {
+    defer r.Body.Close()
+    decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxRequestSize))
+    decoder.DisallowUnknownFields()
+    return decoder.Decode(v)
+}
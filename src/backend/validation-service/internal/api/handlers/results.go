@@ -0,0 +1,379 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+    "github.com/google/uuid"   // v1.4.0
+
+    "validation-service/internal/models"
+    "validation-service/internal/services/anonymize"
+    "validation-service/internal/services/validation"
+    "validation-service/internal/storage"
+    "validation-service/pkg/logger"
+)
+
+// ResultListResponse is a page of persisted validation results
+type ResultListResponse struct {
+    Total   int           `json:"total"`
+    Limit   int           `json:"limit"`
+    Offset  int           `json:"offset"`
+    Results []interface{} `json:"results"`
+}
+
+// ReportMailer emails validation reports
+type ReportMailer interface {
+    // Recipients returns the configured report recipients of a tenant
+    Recipients(tenantID string) []string
+    SendReport(ctx context.Context, tenantID string, recipients []string, report *models.ValidationReport) error
+}
+
+// EmailReportRequest is the body of a report email request
+type EmailReportRequest struct {
+    // Recipients defaults to all configured recipients of the tenant
+    Recipients []string `json:"recipients,omitempty"`
+}
+
+// EmailReportResponse confirms a sent report email
+type EmailReportResponse struct {
+    ResultID   uuid.UUID `json:"result_id"`
+    Recipients []string  `json:"recipients"`
+}
+
+// ScoreExplanationResponse explains the confidence score of a persisted
+// validation result
+type ScoreExplanationResponse struct {
+    ResultID        uuid.UUID `json:"result_id"`
+    Status          string    `json:"status"`
+    ConfidenceScore float64   `json:"confidence_score"`
+    *validation.ScoreExplanation
+}
+
+// ResultHandler serves persisted validation results
+type ResultHandler struct {
+    store  storage.ResultStore
+    mailer ReportMailer
+    log    *logger.Logger
+}
+
+// NewResultHandler creates a result handler backed by store
+func NewResultHandler(store storage.ResultStore) *ResultHandler {
+    return &ResultHandler{
+        store: store,
+        log:   logger.GetLogger(),
+    }
+}
+
+// RegisterRoutes registers the result endpoints with the router
+func (h *ResultHandler) RegisterRoutes(r chi.Router) {
+    r.Get("/validations", h.ListResultsHandler)
+    r.Get("/validations/{id}", h.GetResultHandler)
+    r.Get("/validations/{id}/explain", h.ExplainScoreHandler)
+    r.Post("/validations/{id}/email", h.EmailReportHandler)
+}
+
+// SetReportMailer enables emailing validation reports
+func (h *ResultHandler) SetReportMailer(mailer ReportMailer) {
+    h.mailer = mailer
+}
+
+// GetResultHandler returns a persisted validation result by ID, with
+// tenant-specific literals replaced when the anonymize parameter is set and
+// its issues filtered and paginated by the issue filter parameters
+func (h *ResultHandler) GetResultHandler(w http.ResponseWriter, r *http.Request) {
+    fields, err := parseResultFieldSet(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    issues, err := parseIssueFilter(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    anonymized, err := parseBoolParam(r, anonymizeParam)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid validation ID")
+        return
+    }
+
+    result, err := h.store.GetResult(r.Context(), tenantIDFromRequest(r), id)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "validation result not found")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to load validation result",
+            "error", err,
+            "result_id", id,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to load validation result")
+        return
+    }
+    if anonymized {
+        if result, err = anonymize.New().Result(result); err != nil {
+            h.log.Error("Failed to anonymize validation result",
+                "error", err,
+                "result_id", id,
+            )
+            writeError(w, r, http.StatusInternalServerError, "failed to anonymize validation result")
+            return
+        }
+    }
+
+    body, err := fields.filter(issues.apply(result))
+    if err != nil {
+        writeError(w, r, http.StatusInternalServerError, "failed to filter response fields")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, body)
+}
+
+// ExplainScoreHandler returns the deduction of every issue from the confidence
+// score of a persisted validation result and the fixes that would raise the
+// score to the threshold
+func (h *ResultHandler) ExplainScoreHandler(w http.ResponseWriter, r *http.Request) {
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid validation ID")
+        return
+    }
+
+    result, err := h.store.GetResult(r.Context(), tenantIDFromRequest(r), id)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "validation result not found")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to load validation result",
+            "error", err,
+            "result_id", id,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to load validation result")
+        return
+    }
+
+    writeJSON(w, r, http.StatusOK, &ScoreExplanationResponse{
+        ResultID:         result.ID,
+        Status:           result.Status,
+        ConfidenceScore:  result.ConfidenceScore,
+        ScoreExplanation: validation.ExplainScore(result),
+    })
+}
+
+// EmailReportHandler emails the HTML report of a persisted validation result.
+// Reports are only sent to the recipients configured for the tenant; with
+// anonymize, tenant-specific literals are replaced in the report.
+func (h *ResultHandler) EmailReportHandler(w http.ResponseWriter, r *http.Request) {
+    if h.mailer == nil {
+        writeError(w, r, http.StatusServiceUnavailable, "email delivery is not configured")
+        return
+    }
+    anonymized, err := parseBoolParam(r, anonymizeParam)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid validation ID")
+        return
+    }
+
+    var req EmailReportRequest
+    if err := decodeJSONBody(r, &req); err != nil && !errors.Is(err, io.EOF) {
+        writeError(w, r, http.StatusBadRequest, "invalid request body")
+        return
+    }
+
+    tenantID := tenantIDFromRequest(r)
+    configured := h.mailer.Recipients(tenantID)
+    if len(configured) == 0 {
+        writeError(w, r, http.StatusBadRequest, "no report recipients are configured for the tenant")
+        return
+    }
+    recipients := configured
+    if len(req.Recipients) > 0 {
+        allowed := make(map[string]bool, len(configured))
+        for _, recipient := range configured {
+            allowed[strings.ToLower(recipient)] = true
+        }
+        for _, recipient := range req.Recipients {
+            if !allowed[strings.ToLower(recipient)] {
+                writeError(w, r, http.StatusBadRequest, fmt.Sprintf("%s is not a configured report recipient", recipient))
+                return
+            }
+        }
+        recipients = req.Recipients
+    }
+
+    result, err := h.store.GetResult(r.Context(), tenantID, id)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "validation result not found")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to load validation result",
+            "error", err,
+            "result_id", id,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to load validation result")
+        return
+    }
+    if anonymized {
+        if result, err = anonymize.New().Result(result); err != nil {
+            h.log.Error("Failed to anonymize validation result",
+                "error", err,
+                "result_id", id,
+            )
+            writeError(w, r, http.StatusInternalServerError, "failed to anonymize validation result")
+            return
+        }
+    }
+
+    report := result.GetDetailedReport()
+    if err := h.mailer.SendReport(r.Context(), tenantID, recipients, &report); err != nil {
+        h.log.Error("Failed to email validation report",
+            "error", err,
+            "result_id", id,
+        )
+        writeError(w, r, http.StatusBadGateway, "failed to send report email")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, &EmailReportResponse{
+        ResultID:   id,
+        Recipients: recipients,
+    })
+}
+
+// ListResultsHandler lists persisted validation results. Supported parameters:
+// format (source or target), status, from and to (RFC 3339), limit, offset,
+// fields (sparse fieldset applied to each result), anonymize (replace
+// tenant-specific literals with the same placeholders across the page) and
+// the issue filter parameters applied to the issues of each result.
+func (h *ResultHandler) ListResultsHandler(w http.ResponseWriter, r *http.Request) {
+    fields, err := parseResultFieldSet(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    issues, err := parseIssueFilter(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    anonymized, err := parseBoolParam(r, anonymizeParam)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    query, err := parseResultQuery(r)
+    if err == nil {
+        err = query.Normalize()
+    }
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    results, total, err := h.store.ListResults(r.Context(), query)
+    if err != nil {
+        h.log.Error("Failed to list validation results",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to list validation results")
+        return
+    }
+
+    // One anonymizer covers the page, so a literal gets the same
+    // placeholder in every result
+    var anonymizer *anonymize.Anonymizer
+    if anonymized {
+        anonymizer = anonymize.New()
+        for _, result := range results {
+            anonymizer.LearnResult(result)
+        }
+    }
+
+    resp := &ResultListResponse{
+        Total:   total,
+        Limit:   query.Limit,
+        Offset:  query.Offset,
+        Results: make([]interface{}, 0, len(results)),
+    }
+    for _, result := range results {
+        if anonymizer != nil {
+            if result, err = anonymizer.Result(result); err != nil {
+                h.log.Error("Failed to anonymize validation result",
+                    "error", err,
+                )
+                writeError(w, r, http.StatusInternalServerError, "failed to anonymize validation results")
+                return
+            }
+        }
+        filtered, err := fields.filter(issues.apply(result))
+        if err != nil {
+            writeError(w, r, http.StatusInternalServerError, "failed to filter response fields")
+            return
+        }
+        resp.Results = append(resp.Results, filtered)
+    }
+    writeJSON(w, r, http.StatusOK, resp)
+}
+
+// parseResultQuery builds a storage query from URL parameters
+func parseResultQuery(r *http.Request) (storage.ResultQuery, error) {
+    params := r.URL.Query()
+    query := storage.ResultQuery{
+        TenantID: tenantIDFromRequest(r),
+        Format:   strings.ToLower(params.Get("format")),
+        Status:   strings.ToLower(params.Get("status")),
+    }
+
+    switch query.Status {
+    case "", models.ValidationStatusSuccess, models.ValidationStatusWarning, models.ValidationStatusError:
+    default:
+        return query, fmt.Errorf("invalid status %q", query.Status)
+    }
+
+    var err error
+    if query.From, err = parseOptionalTime(params.Get("from")); err != nil {
+        return query, fmt.Errorf("invalid from: %w", err)
+    }
+    if query.To, err = parseOptionalTime(params.Get("to")); err != nil {
+        return query, fmt.Errorf("invalid to: %w", err)
+    }
+    if query.Limit, err = parseOptionalInt(params.Get("limit")); err != nil {
+        return query, fmt.Errorf("invalid limit: %w", err)
+    }
+    if query.Offset, err = parseOptionalInt(params.Get("offset")); err != nil {
+        return query, fmt.Errorf("invalid offset: %w", err)
+    }
+
+    return query, nil
+}
+
+func parseOptionalTime(value string) (*time.Time, error) {
+    if value == "" {
+        return nil, nil
+    }
+    t, err := time.Parse(time.RFC3339, value)
+    if err != nil {
+        return nil, err
+    }
+    return &t, nil
+}
@@ -0,0 +1,190 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "sync"
+    "time"
+
+    "validation-service/internal/config"
+    "validation-service/internal/models"
+    "validation-service/internal/notify"
+    "validation-service/internal/services/remediation"
+    "validation-service/internal/services/validation"
+)
+
+// Revalidation event streaming limits
+const (
+    // revalidationStreamTimeout bounds a stream in place of the request
+    // timeout; EventSource clients reconnect when it ends
+    revalidationStreamTimeout = time.Hour
+    // revalidationKeepAlive is how often an idle stream sends a comment, so
+    // proxies keep it open
+    revalidationKeepAlive = 30 * time.Second
+    // revalidationBuffer is the number of events queued per stream; events
+    // for a stream whose client does not keep up are dropped
+    revalidationBuffer = 16
+
+    sseContentType = "text/event-stream"
+    // sseRevalidatedEvent is the event type of revalidation events
+    sseRevalidatedEvent = "revalidated"
+)
+
+// RevalidationEvent is the data of a revalidated event: the fresh result of
+// content whose stale result was served as StaleResultID
+type RevalidationEvent struct {
+    StaleResultID string                   `json:"stale_result_id"`
+    Result        *models.ValidationResult `json:"result"`
+}
+
+// revalidationBroker fans revalidation events out to the streams of their
+// tenant
+type revalidationBroker struct {
+    mu      sync.Mutex
+    streams map[string]map[chan *RevalidationEvent]struct{}
+}
+
+// newRevalidationBroker creates a broker without streams
+func newRevalidationBroker() *revalidationBroker {
+    return &revalidationBroker{streams: make(map[string]map[chan *RevalidationEvent]struct{})}
+}
+
+// subscribe returns a stream of the events of tenantID and the function
+// ending it
+func (b *revalidationBroker) subscribe(tenantID string) (<-chan *RevalidationEvent, func()) {
+    stream := make(chan *RevalidationEvent, revalidationBuffer)
+    b.mu.Lock()
+    if b.streams[tenantID] == nil {
+        b.streams[tenantID] = make(map[chan *RevalidationEvent]struct{})
+    }
+    b.streams[tenantID][stream] = struct{}{}
+    b.mu.Unlock()
+
+    return stream, func() {
+        b.mu.Lock()
+        delete(b.streams[tenantID], stream)
+        if len(b.streams[tenantID]) == 0 {
+            delete(b.streams, tenantID)
+        }
+        b.mu.Unlock()
+    }
+}
+
+// publish queues event on every stream of tenantID without blocking
+func (b *revalidationBroker) publish(tenantID string, event *RevalidationEvent) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    for stream := range b.streams[tenantID] {
+        select {
+        case stream <- event:
+        default:
+        }
+    }
+}
+
+// Revalidated handles the fresh result of a stale result served from the
+// cache: it is decorated and persisted like the results of ValidateHandler,
+// published to notification subscribers and sent to the revalidation
+// streams of the tenant. The validation service calls it only when the
+// fresh result differs from the stale one.
+func (h *ValidationHandler) Revalidated(revalidation *validation.Revalidation) {
+    result := revalidation.Result
+    remediation.ApplyPlaybooks(result, remediation.PlaybooksForTenant(
+        config.GetConfig().Remediation, revalidation.TenantID))
+
+    if h.results != nil {
+        ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+        defer cancel()
+        if err := h.results.SaveResult(ctx, revalidation.TenantID, result); err != nil {
+            h.log.Error("Failed to persist revalidated result",
+                "error", err,
+                "result_id", result.ID,
+                "stale_result_id", revalidation.StaleResultID,
+            )
+        }
+    }
+    if h.notifier != nil {
+        h.notifier.Publish(notify.RevalidationEvent(revalidation.TenantID, revalidation.StaleResultID, result))
+    }
+    h.revalidations.publish(revalidation.TenantID, &RevalidationEvent{
+        StaleResultID: revalidation.StaleResultID,
+        Result:        result,
+    })
+}
+
+// RevalidationStreamHandler streams the revalidated events of the caller's
+// tenant as server-sent events. Each event carries the fresh result of
+// content whose stale cached result was served, when the two differ, so
+// editors can replace the stale result they show. The stream is not bound
+// by the request timeout and ends after revalidationStreamTimeout. It fails
+// with 500 Internal Server Error when the response writer cannot flush or
+// extend its write deadline, as events would otherwise never arrive or the
+// stream would be cut by the server write timeout.
+func (h *ValidationHandler) RevalidationStreamHandler(w http.ResponseWriter, r *http.Request) {
+    ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), revalidationStreamTimeout)
+    defer cancel()
+    stop := context.AfterFunc(r.Context(), func() {
+        if errors.Is(r.Context().Err(), context.Canceled) {
+            cancel()
+        }
+    })
+    defer stop()
+
+    rc := http.NewResponseController(w)
+    if err := rc.SetWriteDeadline(time.Now().Add(revalidationStreamTimeout)); err != nil {
+        h.log.Error("Failed to extend write deadline of revalidation stream", "error", err)
+        writeError(w, r, http.StatusInternalServerError, "Revalidation streaming not supported")
+        return
+    }
+
+    events, unsubscribe := h.revalidations.subscribe(tenantIDFromRequest(r))
+    defer unsubscribe()
+
+    // Flushing sends the headers with 200 OK; a writer that cannot flush
+    // has sent nothing yet, so the request can still fail
+    w.Header().Set("Content-Type", sseContentType)
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Header().Set("X-Accel-Buffering", "no")
+    if err := rc.Flush(); err != nil {
+        if errors.Is(err, http.ErrNotSupported) {
+            h.log.Error("Failed to flush revalidation stream", "error", err)
+            w.Header().Del("Cache-Control")
+            w.Header().Del("X-Accel-Buffering")
+            writeError(w, r, http.StatusInternalServerError, "Revalidation streaming not supported")
+        }
+        return
+    }
+
+    keepAlive := time.NewTicker(revalidationKeepAlive)
+    defer keepAlive.Stop()
+    for {
+        var err error
+        select {
+        case <-ctx.Done():
+            return
+        case <-keepAlive.C:
+            _, err = fmt.Fprint(w, ": keep-alive\n\n")
+        case event := <-events:
+            var data []byte
+            if data, err = json.Marshal(event); err != nil {
+                h.log.Error("Failed to encode revalidation event",
+                    "error", err,
+                    "result_id", event.Result.ID,
+                )
+                continue
+            }
+            _, err = fmt.Fprintf(w, "event: %s\nid: %s\ndata: %s\n\n", sseRevalidatedEvent, event.Result.ID, data)
+        }
+        if err == nil {
+            err = rc.Flush()
+        }
+        if err != nil {
+            // The client is gone
+            return
+        }
+    }
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+    "github.com/google/uuid"   // v1.4.0
+
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/config"
+    "validation-service/internal/schedule"
+    "validation-service/internal/storage"
+    "validation-service/pkg/logger"
+)
+
+// defaultScheduleTimezone interprets cron expressions of schedules without a
+// timezone
+const defaultScheduleTimezone = "UTC"
+
+// ScheduleRequest is the body of a schedule create or update
+type ScheduleRequest struct {
+    Name string `json:"name"`
+    Cron string `json:"cron"`
+    // Timezone is the IANA timezone the cron expression is read in, such as
+    // Europe/Berlin; UTC when empty
+    Timezone string              `json:"timezone"`
+    Job      storage.ScheduleJob `json:"job"`
+}
+
+// ScheduleListResponse lists the schedules of the caller's tenant
+type ScheduleListResponse struct {
+    Schedules []*storage.Schedule `json:"schedules"`
+}
+
+// ScheduleHandler serves the recurring jobs of the caller's tenant. Runs
+// are started by the scheduler; the API manages their definitions and
+// reports their last outcome.
+type ScheduleHandler struct {
+    store storage.ScheduleStore
+    cfg   config.ScheduleConfig
+    log   *logger.Logger
+}
+
+// NewScheduleHandler creates a schedule handler backed by store
+func NewScheduleHandler(store storage.ScheduleStore, cfg config.ScheduleConfig) *ScheduleHandler {
+    return &ScheduleHandler{
+        store: store,
+        cfg:   cfg,
+        log:   logger.GetLogger(),
+    }
+}
+
+// RegisterRoutes registers the schedule endpoints with the router
+func (h *ScheduleHandler) RegisterRoutes(r chi.Router) {
+    r.Post("/schedules", h.CreateScheduleHandler)
+    r.Get("/schedules", h.ListSchedulesHandler)
+    r.Get("/schedules/{id}", h.GetScheduleHandler)
+    r.Put("/schedules/{id}", h.UpdateScheduleHandler)
+    r.Delete("/schedules/{id}", h.DeleteScheduleHandler)
+    r.Post("/schedules/{id}/pause", h.PauseScheduleHandler)
+    r.Post("/schedules/{id}/resume", h.ResumeScheduleHandler)
+}
+
+// CreateScheduleHandler creates a schedule for the caller's tenant
+func (h *ScheduleHandler) CreateScheduleHandler(w http.ResponseWriter, r *http.Request) {
+    var req ScheduleRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+
+    tenantID := tenantIDFromRequest(r)
+    existing, err := h.store.ListSchedules(r.Context(), tenantID)
+    if err != nil {
+        h.log.Error("Failed to list schedules",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to store schedule")
+        return
+    }
+    if h.cfg.MaxPerTenant > 0 && len(existing) >= h.cfg.MaxPerTenant {
+        writeError(w, r, http.StatusConflict, fmt.Sprintf("tenant already has the maximum of %d schedules", h.cfg.MaxPerTenant))
+        return
+    }
+
+    now := time.Now().UTC()
+    s := &storage.Schedule{
+        ID:        uuid.New(),
+        TenantID:  tenantID,
+        CreatedAt: now,
+        UpdatedAt: now,
+    }
+    if claims, ok := apimiddleware.ClaimsFromContext(r.Context()); ok {
+        s.CreatedBy = claims.UserId
+    }
+    if err := h.applyScheduleRequest(s, &req, now); err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    h.save(w, r, s, http.StatusCreated)
+}
+
+// ListSchedulesHandler lists the schedules of the caller's tenant
+func (h *ScheduleHandler) ListSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+    schedules, err := h.store.ListSchedules(r.Context(), tenantIDFromRequest(r))
+    if err != nil {
+        h.log.Error("Failed to list schedules",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to list schedules")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, &ScheduleListResponse{Schedules: schedules})
+}
+
+// GetScheduleHandler returns a schedule by ID with its next run time and the
+// outcome of its last run
+func (h *ScheduleHandler) GetScheduleHandler(w http.ResponseWriter, r *http.Request) {
+    s, ok := h.loadSchedule(w, r)
+    if !ok {
+        return
+    }
+    writeJSON(w, r, http.StatusOK, s)
+}
+
+// UpdateScheduleHandler replaces the name, cron expression, timezone and job
+// of a schedule. A paused schedule stays paused.
+func (h *ScheduleHandler) UpdateScheduleHandler(w http.ResponseWriter, r *http.Request) {
+    s, ok := h.loadSchedule(w, r)
+    if !ok {
+        return
+    }
+
+    var req ScheduleRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+    now := time.Now().UTC()
+    if err := h.applyScheduleRequest(s, &req, now); err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    s.UpdatedAt = now
+    h.save(w, r, s, http.StatusOK)
+}
+
+// DeleteScheduleHandler removes a schedule. A run in progress completes.
+func (h *ScheduleHandler) DeleteScheduleHandler(w http.ResponseWriter, r *http.Request) {
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid schedule ID")
+        return
+    }
+
+    err = h.store.DeleteSchedule(r.Context(), tenantIDFromRequest(r), id)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "schedule not found")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to delete schedule",
+            "error", err,
+            "schedule_id", id,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to delete schedule")
+        return
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// PauseScheduleHandler stops a schedule from starting runs until it is
+// resumed. A run in progress completes. Pausing a paused schedule has no
+// effect.
+func (h *ScheduleHandler) PauseScheduleHandler(w http.ResponseWriter, r *http.Request) {
+    s, ok := h.loadSchedule(w, r)
+    if !ok {
+        return
+    }
+    if s.Paused {
+        writeJSON(w, r, http.StatusOK, s)
+        return
+    }
+    s.Paused = true
+    s.NextRunAt = nil
+    s.UpdatedAt = time.Now().UTC()
+    h.save(w, r, s, http.StatusOK)
+}
+
+// ResumeScheduleHandler resumes a paused schedule from its next cron time;
+// runs missed while it was paused are not made up. Resuming an active
+// schedule has no effect.
+func (h *ScheduleHandler) ResumeScheduleHandler(w http.ResponseWriter, r *http.Request) {
+    s, ok := h.loadSchedule(w, r)
+    if !ok {
+        return
+    }
+    if !s.Paused {
+        writeJSON(w, r, http.StatusOK, s)
+        return
+    }
+    now := time.Now().UTC()
+    next, err := schedule.NextRun(s.Cron, s.Timezone, now)
+    if err != nil {
+        writeError(w, r, http.StatusConflict, fmt.Sprintf("schedule cannot be resumed: %v", err))
+        return
+    }
+    s.Paused = false
+    s.NextRunAt = &next
+    s.UpdatedAt = now
+    h.save(w, r, s, http.StatusOK)
+}
+
+// save stores the schedule and writes it with status
+func (h *ScheduleHandler) save(w http.ResponseWriter, r *http.Request, s *storage.Schedule, status int) {
+    err := h.store.SaveSchedule(r.Context(), s)
+    if errors.Is(err, storage.ErrAlreadyExists) {
+        writeError(w, r, http.StatusConflict, fmt.Sprintf("a schedule named %q already exists", s.Name))
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to store schedule",
+            "error", err,
+            "schedule_id", s.ID,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to store schedule")
+        return
+    }
+    writeJSON(w, r, status, s)
+}
+
+// loadSchedule loads the schedule named by the URL, writing an error
+// response unless it exists in the caller's tenant
+func (h *ScheduleHandler) loadSchedule(w http.ResponseWriter, r *http.Request) (*storage.Schedule, bool) {
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid schedule ID")
+        return nil, false
+    }
+
+    s, err := h.store.GetSchedule(r.Context(), tenantIDFromRequest(r), id)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "schedule not found")
+        return nil, false
+    }
+    if err != nil {
+        h.log.Error("Failed to load schedule",
+            "error", err,
+            "schedule_id", id,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to load schedule")
+        return nil, false
+    }
+    return s, true
+}
+
+// applyScheduleRequest normalizes req into s, validates it and computes the
+// next run of an active schedule
+func (h *ScheduleHandler) applyScheduleRequest(s *storage.Schedule, req *ScheduleRequest, now time.Time) error {
+    s.Name = strings.TrimSpace(req.Name)
+    s.Cron = strings.Join(strings.Fields(req.Cron), " ")
+    s.Timezone = strings.TrimSpace(req.Timezone)
+    if s.Timezone == "" {
+        s.Timezone = defaultScheduleTimezone
+    }
+    s.Job = storage.ScheduleJob{
+        Type:   strings.ToLower(strings.TrimSpace(req.Job.Type)),
+        Format: strings.ToLower(strings.TrimSpace(req.Job.Format)),
+        Tags:   req.Job.Tags,
+        // The collection is resolved when the job runs; a run fails when
+        // it no longer exists
+        CollectionID: req.Job.CollectionID,
+    }
+
+    if err := s.Validate(); err != nil {
+        return err
+    }
+    if err := schedule.Check(s.Cron, s.Timezone, h.cfg.MinInterval); err != nil {
+        return err
+    }
+    if s.Paused {
+        return nil
+    }
+    next, err := schedule.NextRun(s.Cron, s.Timezone, now)
+    if err != nil {
+        return err
+    }
+    s.NextRunAt = &next
+    return nil
+}
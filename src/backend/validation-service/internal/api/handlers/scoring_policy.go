@@ -0,0 +1,120 @@
+package handlers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/services/scorepolicy"
+    "validation-service/internal/storage"
+    "validation-service/pkg/logger"
+)
+
+// ScoringPolicyRequest is the body of a scoring policy update
+type ScoringPolicyRequest struct {
+    // Module is the Rego source of the policy, declaring package
+    // validation.scoring with a decision rule
+    Module string `json:"module"`
+}
+
+// ScoringPolicyHandler serves the Rego scoring policy of the caller's
+// tenant, which decides the status and confidence score of its validations
+// from their issues
+type ScoringPolicyHandler struct {
+    store     storage.ScoringPolicyStore
+    evaluator *scorepolicy.Evaluator
+    log       *logger.Logger
+}
+
+// NewScoringPolicyHandler creates a scoring policy handler backed by store.
+// Policies are compiled by evaluator before they are saved.
+func NewScoringPolicyHandler(store storage.ScoringPolicyStore, evaluator *scorepolicy.Evaluator) *ScoringPolicyHandler {
+    return &ScoringPolicyHandler{
+        store:     store,
+        evaluator: evaluator,
+        log:       logger.GetLogger(),
+    }
+}
+
+// RegisterRoutes registers the scoring policy endpoints with the router
+func (h *ScoringPolicyHandler) RegisterRoutes(r chi.Router) {
+    r.Get("/scoring-policy", h.GetScoringPolicyHandler)
+    r.Put("/scoring-policy", h.PutScoringPolicyHandler)
+    r.Delete("/scoring-policy", h.DeleteScoringPolicyHandler)
+}
+
+// GetScoringPolicyHandler returns the scoring policy of the caller's tenant
+func (h *ScoringPolicyHandler) GetScoringPolicyHandler(w http.ResponseWriter, r *http.Request) {
+    policy, err := h.store.GetScoringPolicy(r.Context(), tenantIDFromRequest(r))
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "tenant has no scoring policy")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to load scoring policy",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to load scoring policy")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, policy)
+}
+
+// PutScoringPolicyHandler replaces the scoring policy of the caller's
+// tenant. The policy is compiled first, so one that cannot be evaluated is
+// rejected rather than saved.
+func (h *ScoringPolicyHandler) PutScoringPolicyHandler(w http.ResponseWriter, r *http.Request) {
+    var req ScoringPolicyRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+    if err := h.evaluator.Check(r.Context(), req.Module); err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    tenantID := tenantIDFromRequest(r)
+    policy := &storage.ScoringPolicy{
+        TenantID:  tenantID,
+        Module:    req.Module,
+        Digest:    scorepolicy.Digest(req.Module),
+        UpdatedAt: time.Now().UTC(),
+    }
+    if claims, ok := apimiddleware.ClaimsFromContext(r.Context()); ok {
+        policy.UpdatedBy = claims.UserId
+    }
+    if err := h.store.SaveScoringPolicy(r.Context(), policy); err != nil {
+        h.log.Error("Failed to store scoring policy",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to store scoring policy")
+        return
+    }
+    h.evaluator.Invalidate(tenantID)
+    writeJSON(w, r, http.StatusOK, policy)
+}
+
+// DeleteScoringPolicyHandler removes the scoring policy of the caller's
+// tenant, returning its validations to the static severity weights
+func (h *ScoringPolicyHandler) DeleteScoringPolicyHandler(w http.ResponseWriter, r *http.Request) {
+    tenantID := tenantIDFromRequest(r)
+    err := h.store.DeleteScoringPolicy(r.Context(), tenantID)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "tenant has no scoring policy")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to delete scoring policy",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to delete scoring policy")
+        return
+    }
+    h.evaluator.Invalidate(tenantID)
+    w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,113 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/pkg/logger"
+)
+
+// TokenExchangeRequest asks for a CI token with a subset of the caller's
+// scopes. Scope is space-delimited; TTLSeconds defaults to 15 minutes.
+type TokenExchangeRequest struct {
+    Scope      string `json:"scope"`
+    TTLSeconds int    `json:"ttl_seconds,omitempty"`
+    // Pipeline identifies the CI pipeline the token is for in audit logs
+    Pipeline string `json:"pipeline"`
+}
+
+// TokenExchangeResponse carries a minted CI token
+type TokenExchangeResponse struct {
+    AccessToken string    `json:"access_token"`
+    TokenType   string    `json:"token_type"`
+    ExpiresIn   int       `json:"expires_in"`
+    ExpiresAt   time.Time `json:"expires_at"`
+    Scope       string    `json:"scope"`
+}
+
+// TokenExchangeHandler mints short-lived, narrowly scoped tokens for CI
+// pipelines in exchange for the caller's token
+type TokenExchangeHandler struct {
+    issuer *apimiddleware.TokenIssuer
+    log    *logger.Logger
+}
+
+// NewTokenExchangeHandler creates a token exchange handler
+func NewTokenExchangeHandler(issuer *apimiddleware.TokenIssuer) *TokenExchangeHandler {
+    return &TokenExchangeHandler{
+        issuer: issuer,
+        log:    logger.GetLogger(),
+    }
+}
+
+// RegisterRoutes registers the token exchange endpoint with the router
+func (h *TokenExchangeHandler) RegisterRoutes(r chi.Router) {
+    r.With(apimiddleware.RequireScope(apimiddleware.ScopeTokensExchange)).
+        Post("/auth/token-exchange", h.ExchangeHandler)
+}
+
+// ExchangeHandler mints a CI token. Every requested scope must be granted to
+// the caller's token; tokens:exchange itself is never delegated.
+func (h *TokenExchangeHandler) ExchangeHandler(w http.ResponseWriter, r *http.Request) {
+    claims, ok := apimiddleware.ClaimsFromContext(r.Context())
+    if !ok {
+        writeError(w, r, http.StatusUnauthorized, "authentication required")
+        return
+    }
+
+    var req TokenExchangeRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+    req.Pipeline = strings.TrimSpace(req.Pipeline)
+    if req.Pipeline == "" {
+        writeError(w, r, http.StatusBadRequest, "pipeline is required")
+        return
+    }
+    if req.TTLSeconds < 0 {
+        writeError(w, r, http.StatusBadRequest, "ttl_seconds must not be negative")
+        return
+    }
+
+    token, minted, err := h.issuer.Exchange(claims, apimiddleware.ParseScopes(req.Scope),
+        time.Duration(req.TTLSeconds)*time.Second, req.Pipeline)
+    switch {
+    case errors.Is(err, apimiddleware.ErrScopeNotGranted):
+        writeError(w, r, http.StatusForbidden, err.Error())
+        return
+    case errors.Is(err, apimiddleware.ErrInvalidTokenTTL):
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    case err != nil:
+        h.log.Error("Failed to mint CI token",
+            "error", err,
+            "user_id", claims.UserId,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to mint token")
+        return
+    }
+
+    expiresAt := minted.ExpiresAt.Time
+    h.log.Info("CI token minted",
+        "user_id", claims.UserId,
+        "pipeline", minted.Pipeline,
+        "scope", minted.Scope,
+        "token_id", minted.ID,
+        "expires_at", expiresAt,
+    )
+    writeJSON(w, r, http.StatusOK, &TokenExchangeResponse{
+        AccessToken: token,
+        TokenType:   "Bearer",
+        ExpiresIn:   int(expiresAt.Sub(minted.IssuedAt.Time).Seconds()),
+        ExpiresAt:   expiresAt,
+        Scope:       minted.Scope,
+    })
+}
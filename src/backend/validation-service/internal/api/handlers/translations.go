@@ -0,0 +1,185 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+    "github.com/google/uuid"   // v1.4.0
+
+    "validation-service/internal/storage"
+    "validation-service/pkg/logger"
+)
+
+// ApproveMappingRequest is the body of a translation mapping approval
+type ApproveMappingRequest struct {
+    SourceFormat     string `json:"source_format"`
+    TargetFormat     string `json:"target_format"`
+    SourceExpression string `json:"source_expression"`
+    TargetExpression string `json:"target_expression"`
+    TargetTable      string `json:"target_table,omitempty"`
+    ApprovedBy       string `json:"approved_by"`
+}
+
+// MappingListResponse is a page of approved mappings
+type MappingListResponse struct {
+    Total    int                        `json:"total"`
+    Limit    int                        `json:"limit"`
+    Offset   int                        `json:"offset"`
+    Mappings []*storage.ApprovedMapping `json:"mappings"`
+}
+
+// TranslationMemoryHandler serves the tenant translation memory. The
+// translation engine lists it to prefer remembered mappings; validation
+// checks translations against it.
+type TranslationMemoryHandler struct {
+    store storage.TranslationMemoryStore
+    log   *logger.Logger
+}
+
+// NewTranslationMemoryHandler creates a translation memory handler backed by store
+func NewTranslationMemoryHandler(store storage.TranslationMemoryStore) *TranslationMemoryHandler {
+    return &TranslationMemoryHandler{
+        store: store,
+        log:   logger.GetLogger(),
+    }
+}
+
+// RegisterRoutes registers the translation memory endpoints with the router
+func (h *TranslationMemoryHandler) RegisterRoutes(r chi.Router) {
+    r.Post("/translation-memory", h.ApproveMappingHandler)
+    r.Get("/translation-memory", h.ListMappingsHandler)
+    r.Get("/translation-memory/{id}", h.GetMappingHandler)
+    r.Delete("/translation-memory/{id}", h.DeleteMappingHandler)
+}
+
+// ApproveMappingHandler records an approved mapping, superseding an earlier
+// approval of the same source expression between the same formats
+func (h *TranslationMemoryHandler) ApproveMappingHandler(w http.ResponseWriter, r *http.Request) {
+    var req ApproveMappingRequest
+    if err := decodeJSONBody(r, &req); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+        return
+    }
+
+    mapping := &storage.ApprovedMapping{
+        ID:               uuid.New(),
+        TenantID:         tenantIDFromRequest(r),
+        SourceFormat:     strings.ToLower(strings.TrimSpace(req.SourceFormat)),
+        TargetFormat:     strings.ToLower(strings.TrimSpace(req.TargetFormat)),
+        SourceExpression: strings.TrimSpace(req.SourceExpression),
+        TargetExpression: strings.TrimSpace(req.TargetExpression),
+        TargetTable:      strings.TrimSpace(req.TargetTable),
+        ApprovedBy:       strings.TrimSpace(req.ApprovedBy),
+        ApprovedAt:       time.Now().UTC(),
+    }
+    if err := mapping.Validate(); err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    if err := h.store.SaveMapping(r.Context(), mapping); err != nil {
+        h.log.Error("Failed to store approved mapping",
+            "error", err,
+            "mapping_id", mapping.ID,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to store approved mapping")
+        return
+    }
+    writeJSON(w, r, http.StatusCreated, mapping)
+}
+
+// GetMappingHandler returns an approved mapping by ID
+func (h *TranslationMemoryHandler) GetMappingHandler(w http.ResponseWriter, r *http.Request) {
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid mapping ID")
+        return
+    }
+
+    mapping, err := h.store.GetMapping(r.Context(), tenantIDFromRequest(r), id)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "mapping not found")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to load approved mapping",
+            "error", err,
+            "mapping_id", id,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to load approved mapping")
+        return
+    }
+    writeJSON(w, r, http.StatusOK, mapping)
+}
+
+// DeleteMappingHandler revokes an approved mapping
+func (h *TranslationMemoryHandler) DeleteMappingHandler(w http.ResponseWriter, r *http.Request) {
+    id, err := uuid.Parse(chi.URLParam(r, "id"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "invalid mapping ID")
+        return
+    }
+
+    err = h.store.DeleteMapping(r.Context(), tenantIDFromRequest(r), id)
+    if errors.Is(err, storage.ErrNotFound) {
+        writeError(w, r, http.StatusNotFound, "mapping not found")
+        return
+    }
+    if err != nil {
+        h.log.Error("Failed to delete approved mapping",
+            "error", err,
+            "mapping_id", id,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to delete approved mapping")
+        return
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// ListMappingsHandler lists approved mappings. Supported parameters:
+// source_format, target_format, source_expression (exact match ignoring
+// case and whitespace), limit and offset.
+func (h *TranslationMemoryHandler) ListMappingsHandler(w http.ResponseWriter, r *http.Request) {
+    params := r.URL.Query()
+    query := storage.MappingQuery{
+        TenantID:         tenantIDFromRequest(r),
+        SourceFormat:     strings.ToLower(params.Get("source_format")),
+        TargetFormat:     strings.ToLower(params.Get("target_format")),
+        SourceExpression: params.Get("source_expression"),
+    }
+
+    var err error
+    if query.Limit, err = parseOptionalInt(params.Get("limit")); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid limit: %v", err))
+        return
+    }
+    if query.Offset, err = parseOptionalInt(params.Get("offset")); err != nil {
+        writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid offset: %v", err))
+        return
+    }
+    if err := query.Normalize(); err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    mappings, total, err := h.store.ListMappings(r.Context(), query)
+    if err != nil {
+        h.log.Error("Failed to list approved mappings",
+            "error", err,
+        )
+        writeError(w, r, http.StatusInternalServerError, "failed to list approved mappings")
+        return
+    }
+
+    writeJSON(w, r, http.StatusOK, &MappingListResponse{
+        Total:    total,
+        Limit:    query.Limit,
+        Offset:   query.Offset,
+        Mappings: mappings,
+    })
+}
@@ -9,13 +9,24 @@ import (
     "fmt"
     "io"
     "net/http"
+    "strconv"
+    "strings"
     "time"
 
-    "github.com/go-chi/chi/v5"      // v5.0.8
-    "github.com/go-chi/compress"    // v5.0.0
-    
+    apimiddleware "internal/api/middleware"
+    "internal/audit"
+    "internal/config"
     "internal/models"
+    "internal/notify"
+    "internal/services/anonymize"
+    "internal/services/preprocess"
+    "internal/services/remediation"
+    "internal/services/report"
+    "internal/services/ruledoc"
+    "internal/services/translationmemory"
     "internal/services/validation"
+    "internal/storage"
+    "internal/tenant"
     "pkg/logger"
 )
 
@@ -24,7 +35,28 @@ const (
     maxRequestSize    = 10 * 1024 * 1024 // 10MB max request size
     requestTimeout    = 30 * time.Second
     maxRetries       = 3
-    compressionLevel = 5
+
+    // tenantHeader carries the tenant ID forwarded by the API gateway
+    tenantHeader = "X-Tenant-ID"
+
+    // optionBypassCache is the request option that skips the result cache
+    optionBypassCache = "bypass_cache"
+
+    // optionLintProfile is the request option selecting the lint profile
+    optionLintProfile = "lint_profile"
+
+    // optionProfile is the request option selecting the validation profile
+    optionProfile = "profile"
+
+    // optionPreprocessor is the request option selecting the pre-processor
+    // that unwraps vendor export packaging
+    optionPreprocessor = "preprocessor"
+
+    // traceParam is the query parameter requesting an execution trace
+    traceParam = "trace"
+
+    // anonymizeParam is the query parameter requesting anonymized output
+    anonymizeParam = "anonymize"
 )
 
 // ValidationRequest represents the incoming validation request structure
@@ -37,39 +69,83 @@ type ValidationRequest struct {
 // ValidationResponse represents the API response structure
 type ValidationResponse struct {
     Status    string                 `json:"status"`
+    // Gate is the pass or fail verdict of the gate policy, for CI pipelines
+    Gate      string                 `json:"gate,omitempty"`
     Result    *models.ValidationResult `json:"result,omitempty"`
     Report    *models.ValidationReport `json:"report,omitempty"`
     Error     string                 `json:"error,omitempty"`
+    // CacheHit is set when the result was served from the result cache
+    CacheHit  bool                   `json:"cache_hit"`
+    // Anonymized is set when tenant-specific literals were replaced
+    Anonymized bool `json:"anonymized,omitempty"`
     RequestID string                 `json:"request_id"`
+    TraceID   string                 `json:"trace_id,omitempty"`
     Timestamp time.Time             `json:"timestamp"`
 }
 
 // ValidationHandler handles validation API requests with enhanced security and monitoring
 type ValidationHandler struct {
-    service    *validation.ValidationService
-    compressor *compress.Compressor
-    log        *logger.Logger
+    service  *validation.ValidationService
+    results  storage.ResultStore
+    memory   storage.TranslationMemoryStore
+    notifier Notifier
+    auditLog *audit.Logger
+    harness  harnessSettings
+    // collections and detections resolve the stored collections validated
+    // by ValidateStoredCollectionHandler
+    collections   storage.CollectionStore
+    detections    storage.DetectionStore
+    // preprocessors unwrap vendor export packaging before validation
+    preprocessors *preprocess.Registry
+    // revalidations streams revalidated results to their tenant
+    revalidations *revalidationBroker
+    log           *logger.Logger
 }
 
 // NewValidationHandler creates a new validation handler instance with all required dependencies
 func NewValidationHandler(service *validation.ValidationService) *ValidationHandler {
     return &ValidationHandler{
         service: service,
-        compressor: compress.New(compress.Config{
-            Level: compressionLevel,
-            Types: []string{
-                "application/json",
-                "text/plain",
-            },
-        }),
-        log: logger.GetLogger(),
+        harness: defaultHarnessSettings(),
+        preprocessors: preprocess.BuiltinRegistry(),
+        revalidations: newRevalidationBroker(),
+        log:           logger.GetLogger(),
     }
 }
 
-// RegisterRoutes registers all validation endpoints with the router
-func (h *ValidationHandler) RegisterRoutes(r chi.Router) {
-    r.Post("/validate", h.compressor.Handler(http.HandlerFunc(h.ValidateHandler)).ServeHTTP)
-    r.Post("/validate/batch", h.compressor.Handler(http.HandlerFunc(h.ValidateBatchHandler)).ServeHTTP)
+// SetPreprocessors replaces the pre-processors unwrapping vendor export
+// packaging, for deployments registering their own
+func (h *ValidationHandler) SetPreprocessors(registry *preprocess.Registry) {
+    h.preprocessors = registry
+}
+
+// SetResultStore enables persistence of validation results
+func (h *ValidationHandler) SetResultStore(store storage.ResultStore) {
+    h.results = store
+}
+
+// SetTranslationMemory enables checking translations against the approved
+// mappings of the requesting tenant
+func (h *ValidationHandler) SetTranslationMemory(store storage.TranslationMemoryStore) {
+    h.memory = store
+}
+
+// SetNotifier publishes an event to notification subscribers for every
+// completed validation
+func (h *ValidationHandler) SetNotifier(notifier Notifier) {
+    h.notifier = notifier
+}
+
+// SetCollections enables validation of the stored collections of
+// collections, whose detections are read from detections
+func (h *ValidationHandler) SetCollections(collections storage.CollectionStore, detections storage.DetectionStore) {
+    h.collections = collections
+    h.detections = detections
+}
+
+// SetAuditLog records who validated what in the audit log
+func (h *ValidationHandler) SetAuditLog(log *audit.Logger) {
+    h.auditLog = log
 }
 
 // ValidateHandler handles single detection validation requests
@@ -84,6 +160,38 @@ func (h *ValidationHandler) ValidateHandler(w http.ResponseWriter, r *http.Reque
         return
     }
 
+    // Parse sparse fieldset selection
+    fields, err := parseFieldSet(r)
+    if err != nil {
+        h.sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    // Select JSON, SARIF or annotated content output
+    output, err := parseOutputFormat(r)
+    if err != nil {
+        h.sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    // Record a stage-by-stage execution trace in the result metadata
+    trace, err := parseTraceFlag(r)
+    if err != nil {
+        h.sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        return
+    }
+    if trace {
+        ctx = validation.WithExecutionTrace(ctx)
+    }
+
+    // Replace tenant-specific literals in the response for sharing outside
+    // the tenant
+    anonymized, err := parseBoolParam(r, anonymizeParam)
+    if err != nil {
+        h.sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        return
+    }
+
     // Parse request body
     var req ValidationRequest
     if err := h.parseJSONBody(r, &req); err != nil {
@@ -97,19 +205,42 @@ func (h *ValidationHandler) ValidateHandler(w http.ResponseWriter, r *http.Reque
         return
     }
 
-    // Perform validation with retries
+    // Unwrap vendor export packaging into the rule content validated
+    detections, preprocessors, err := h.preprocessDetections(req.Options, req.SourceDetection, req.TargetDetection)
+    if err != nil {
+        h.sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        return
+    }
+    req.SourceDetection, req.TargetDetection = detections[0], detections[1]
+
+    // Select the lint and validation profiles the translation is judged
+    // with
+    ctx, err = h.withProfileOptions(ctx, req.Options)
+    if err != nil {
+        h.sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    // Perform validation with retries, serving identical content from the
+    // result cache unless the request bypasses it
     var result *models.ValidationResult
-    var err error
+    var cacheHit bool
+    bypass := bypassCache(r, req.Options)
     for i := 0; i < maxRetries; i++ {
-        result, err = h.service.ValidateDetection(ctx, req.SourceDetection, req.TargetDetection)
+        result, cacheHit, err = h.service.ValidateDetectionCached(ctx, req.SourceDetection, req.TargetDetection, bypass)
         if err == nil || !isRetryableError(err) {
             break
         }
         time.Sleep(time.Duration(i+1) * 100 * time.Millisecond)
     }
+    h.auditValidation(r, &req, result, err)
 
+    if errors.Is(err, validation.ErrFormatNotAllowed) {
+        h.sendErrorResponse(w, http.StatusForbidden, err.Error())
+        return
+    }
     if err != nil {
-        h.log.Error("Validation failed",
+        logger.FromContext(ctx).Error("Validation failed",
             "error", err,
             "source_format", req.SourceDetection.Format,
             "target_format", req.TargetDetection.Format,
@@ -118,29 +249,156 @@ func (h *ValidationHandler) ValidateHandler(w http.ResponseWriter, r *http.Reque
         return
     }
 
+    // Check the translation against the tenant's approved mappings; the
+    // result is still returned if the translation memory is unavailable
+    if err := translationmemory.Check(ctx, h.memory, tenantIDFromRequest(r), req.SourceDetection, req.TargetDetection, result); err != nil {
+        logger.FromContext(ctx).Error("Failed to check translation memory",
+            "error", err,
+            "result_id", result.ID,
+        )
+    }
+
+    // Decorate issues with tenant-specific remediation playbooks
+    remediation.ApplyPlaybooks(result, remediation.PlaybooksForTenant(
+        config.GetConfig().Remediation, tenantIDFromRequest(r)))
+
+    result.Metadata.Preprocessors = preprocessors
+    correlateResult(r.Context(), result)
+
+    // Persist the result for later retrieval; a storage failure does not
+    // invalidate the validation outcome returned to the caller
+    if h.results != nil {
+        if err := h.results.SaveResult(ctx, tenantIDFromRequest(r), result); err != nil {
+            logger.FromContext(ctx).Error("Failed to persist validation result",
+                "error", err,
+                "result_id", result.ID,
+            )
+        }
+    }
+    if h.notifier != nil {
+        h.notifier.Publish(notify.ValidationEvent(tenantIDFromRequest(r), result))
+    }
+
+    // Anonymize the response only; the persisted result and the
+    // notifications keep the original literals
+    sourceDetection, targetDetection := req.SourceDetection, req.TargetDetection
+    if anonymized {
+        a := anonymize.New()
+        a.Learn(sourceDetection.Content)
+        a.Learn(targetDetection.Content)
+        if result, err = a.Result(result); err != nil {
+            logger.FromContext(ctx).Error("Failed to anonymize validation result",
+                "error", err,
+            )
+            h.sendErrorResponse(w, http.StatusInternalServerError, "failed to anonymize validation result")
+            return
+        }
+        sourceDetection, targetDetection = a.Detection(sourceDetection), a.Detection(targetDetection)
+    }
+
     // Generate detailed report
-    report := result.GetDetailedReport()
+    detailedReport := result.GetDetailedReport()
+    detailedReport.SourceSummary = ruledoc.Summarize(sourceDetection)
+    detailedReport.TargetSummary = ruledoc.Summarize(targetDetection)
+    if output == outputSARIF {
+        writeSARIF(w, r, report.ReportToSARIF(&detailedReport, report.ArtifactURI(targetDetection)))
+        return
+    }
+    if output == outputAnnotated {
+        writeAnnotated(w, r, targetDetection, result)
+        return
+    }
 
     // Send success response
-    h.sendSuccessResponse(w, &ValidationResponse{
+    resp := &ValidationResponse{
         Status:    result.Status,
+        Gate:      result.Metadata.Gate,
         Result:    result,
-        Report:    &report,
-        RequestID: r.Context().Value("request_id").(string),
+        Report:    &detailedReport,
+        CacheHit:  cacheHit,
+        Anonymized: anonymized,
+        RequestID: apimiddleware.RequestIDFromContext(r.Context()),
+        TraceID:   apimiddleware.TraceIDFromContext(r.Context()),
         Timestamp: time.Now().UTC(),
-    })
+    }
+    if fields != nil {
+        h.sendSparseResponse(w, resp, fields)
+        return
+    }
+    h.sendSuccessResponse(w, resp)
+}
+
+// SupportedFormatsResponse lists the capabilities of the registered validators
+type SupportedFormatsResponse struct {
+    Formats          []validation.FormatInfo `json:"formats"`
+    CommonIssueCodes []string                `json:"common_issue_codes"`
+    // NamespacedCommonIssueCodes are the common issue codes in their
+    // namespaced form, such as service.core.LOW_CONFIDENCE
+    NamespacedCommonIssueCodes []string `json:"namespaced_common_issue_codes"`
+    // Profiles are the validation profiles options.profile can select
+    Profiles []string `json:"profiles"`
 }
 
-// ValidateBatchHandler handles batch validation requests
-func (h *ValidationHandler) ValidateBatchHandler(w http.ResponseWriter, r *http.Request) {
-    // Implementation for batch validation
-    // Similar to ValidateHandler but processes multiple detections
-    // Consider implementing streaming response for large batches
-    http.Error(w, "Batch validation not implemented", http.StatusNotImplemented)
+// GetSupportedFormatsHandler returns the formats supported by the registered
+// validators with their versions, issue codes and strictness options
+func (h *ValidationHandler) GetSupportedFormatsHandler(w http.ResponseWriter, r *http.Request) {
+    writeJSON(w, r, http.StatusOK, &SupportedFormatsResponse{
+        Formats:                    h.service.SupportedFormats(),
+        CommonIssueCodes:           validation.CommonIssueCodes,
+        NamespacedCommonIssueCodes: h.service.IssueCodes().Codes(validation.ServiceIssueFormat),
+        Profiles:                   h.service.Profiles(),
+    })
 }
 
 // Helper functions
 
+// withProfileOptions returns ctx selecting the lint profile of the content
+// hygiene findings and the validation profile named by options
+func (h *ValidationHandler) withProfileOptions(ctx context.Context, options map[string]interface{}) (context.Context, error) {
+    if profile, ok := options[optionLintProfile].(string); ok {
+        if !h.service.HasLintProfile(profile) {
+            return ctx, fmt.Errorf("unknown lint profile: %s", profile)
+        }
+        ctx = validation.WithLintProfile(ctx, profile)
+    }
+    if profile, ok := options[optionProfile].(string); ok {
+        if !h.service.HasProfile(profile) {
+            return ctx, fmt.Errorf("unknown validation profile: %s", profile)
+        }
+        ctx = validation.WithProfile(ctx, profile)
+    }
+    return ctx, nil
+}
+
+// preprocessDetections unwraps the vendor export packaging of detections
+// with the pre-processor named by the preprocessor option, auto-detecting
+// it when the option is absent. It returns the detections in order and the
+// names of the pre-processors that unwrapped any of them.
+func (h *ValidationHandler) preprocessDetections(options map[string]interface{}, detections ...*models.Detection) ([]*models.Detection, []string, error) {
+    mode := preprocess.ModeAuto
+    if value, ok := options[optionPreprocessor]; ok {
+        if mode, ok = value.(string); !ok {
+            return nil, nil, fmt.Errorf("option %s must be a string", optionPreprocessor)
+        }
+    }
+
+    unwrapped := make([]*models.Detection, len(detections))
+    var applied []string
+    seen := make(map[string]bool)
+    for i, detection := range detections {
+        d, name, err := h.preprocessors.Apply(detection, mode)
+        if err != nil {
+            return nil, nil, err
+        }
+        unwrapped[i] = d
+        if name != "" && !seen[name] {
+            seen[name] = true
+            applied = append(applied, name)
+        }
+    }
+    return unwrapped, applied, nil
+}
+
 func (h *ValidationHandler) parseJSONBody(r *http.Request, v interface{}) error {
     body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
     if err != nil {
@@ -184,13 +442,69 @@ func (h *ValidationHandler) sendSuccessResponse(w http.ResponseWriter, resp *Val
     }
 }
 
+// sendSparseResponse sends a response whose result is reduced to the requested
+// fieldset. Envelope fields, including the gate verdict, are always returned;
+// the report is only included when a `report` path is requested.
+func (h *ValidationHandler) sendSparseResponse(w http.ResponseWriter, resp *ValidationResponse, fields fieldSet) {
+    body := map[string]interface{}{
+        "status":     resp.Status,
+        "cache_hit":  resp.CacheHit,
+        "request_id": resp.RequestID,
+        "timestamp":  resp.Timestamp,
+    }
+    if resp.TraceID != "" {
+        body["trace_id"] = resp.TraceID
+    }
+    if resp.Gate != "" {
+        body["gate"] = resp.Gate
+    }
+    if resp.Anonymized {
+        body["anonymized"] = true
+    }
+
+    reportFields := fields["report"]
+    resultFields := make(fieldSet, len(fields))
+    for key, child := range fields {
+        if key != "report" {
+            resultFields[key] = child
+        }
+    }
+
+    if len(resultFields) > 0 && resp.Result != nil {
+        filtered, err := resultFields.filter(resp.Result)
+        if err != nil {
+            h.sendErrorResponse(w, http.StatusInternalServerError, "failed to filter response fields")
+            return
+        }
+        body["result"] = filtered
+    }
+    if fields.has("report") && resp.Report != nil {
+        filtered, err := reportFields.filter(resp.Report)
+        if err != nil {
+            h.sendErrorResponse(w, http.StatusInternalServerError, "failed to filter response fields")
+            return
+        }
+        body["report"] = filtered
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    if err := json.NewEncoder(w).Encode(body); err != nil {
+        h.log.Error("Failed to encode sparse response",
+            "error", err,
+            "request_id", resp.RequestID,
+        )
+    }
+}
+
 func (h *ValidationHandler) sendErrorResponse(w http.ResponseWriter, status int, message string) {
     w.Header().Set("Content-Type", "application/json")
     w.WriteHeader(status)
     resp := ValidationResponse{
         Status:    "error",
         Error:     message,
-        RequestID: w.Header().Get("X-Request-ID"),
+        RequestID: w.Header().Get(apimiddleware.RequestIDHeader),
+        TraceID:   w.Header().Get(apimiddleware.TraceIDHeader),
         Timestamp: time.Now().UTC(),
     }
     if err := json.NewEncoder(w).Encode(resp); err != nil {
@@ -202,6 +516,66 @@ func (h *ValidationHandler) sendErrorResponse(w http.ResponseWriter, status int,
     }
 }
 
+// bypassCache reports whether the request skips the result cache, either with
+// the bypass_cache option or a Cache-Control: no-cache header
+func bypassCache(r *http.Request, options map[string]interface{}) bool {
+    if bypass, ok := options[optionBypassCache].(bool); ok && bypass {
+        return true
+    }
+    return strings.Contains(strings.ToLower(r.Header.Get("Cache-Control")), "no-cache")
+}
+
+// parseTraceFlag reads the trace query parameter
+func parseTraceFlag(r *http.Request) (bool, error) {
+    return parseBoolParam(r, traceParam)
+}
+
+// parseBoolParam reads a boolean query parameter, false when absent
+func parseBoolParam(r *http.Request, name string) (bool, error) {
+    value := r.URL.Query().Get(name)
+    if value == "" {
+        return false, nil
+    }
+    flag, err := strconv.ParseBool(value)
+    if err != nil {
+        return false, fmt.Errorf("invalid %s parameter %q", name, value)
+    }
+    return flag, nil
+}
+
+// tenantIDFromRequest resolves the tenant the request is made on behalf of:
+// the tenant resolved from the caller's token by the tenant middleware, or
+// the X-Tenant-ID header on routes it does not cover
+func tenantIDFromRequest(r *http.Request) string {
+    if t, ok := tenant.FromContext(r.Context()); ok {
+        return t.ID
+    }
+    return r.Header.Get(tenantHeader)
+}
+
+// auditValidation records a validation, successful or not, in the audit
+// log. A record that cannot be written is logged; the validation outcome is
+// still returned to the caller.
+func (h *ValidationHandler) auditValidation(r *http.Request, req *ValidationRequest, result *models.ValidationResult, err error) {
+    if h.auditLog == nil {
+        return
+    }
+    rec := audit.ValidationRecord(req.SourceDetection, req.TargetDetection, result, err)
+    rec.Channel = audit.ChannelREST
+    rec.TenantID = tenantIDFromRequest(r)
+    rec.IP = r.RemoteAddr
+    rec.RequestID = apimiddleware.RequestIDFromContext(r.Context())
+    if claims, ok := apimiddleware.ClaimsFromContext(r.Context()); ok {
+        rec.UserID = claims.UserId
+    }
+    if err := h.auditLog.Record(rec); err != nil {
+        h.log.Error("Failed to write audit record",
+            "error", err,
+            "request_id", rec.RequestID,
+        )
+    }
+}
+
 func isRetryableError(err error) bool {
     // Add logic to determine if error is retryable
     // For example, timeout errors or temporary network issues
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http/httptest"
+    "reflect"
+    "testing"
+
+    "validation-service/internal/models"
+)
+
+func TestSendSparseResponse(t *testing.T) {
+    resp := &ValidationResponse{
+        Status:     "success",
+        Gate:       "fail",
+        Result:     &models.ValidationResult{Status: "failed", ConfidenceScore: 72},
+        Anonymized: true,
+        RequestID:  "req-1",
+    }
+
+    tests := []struct {
+        name   string
+        fields fieldSet
+        result map[string]interface{}
+    }{
+        {"envelope only", fieldSet{}, nil},
+        {"result fields", fieldSet{"confidence_score": nil}, map[string]interface{}{"confidence_score": 72.0}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rec := httptest.NewRecorder()
+            (&ValidationHandler{}).sendSparseResponse(rec, resp, tt.fields)
+
+            var body map[string]interface{}
+            if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+                t.Fatalf("decode: %v", err)
+            }
+            if body["status"] != "success" || body["gate"] != "fail" || body["anonymized"] != true || body["request_id"] != "req-1" {
+                t.Errorf("envelope = %v, want status, gate, anonymized and request ID", body)
+            }
+            result, _ := body["result"].(map[string]interface{})
+            if tt.result == nil && result != nil || tt.result != nil && !reflect.DeepEqual(result, tt.result) {
+                t.Errorf("result = %v, want %v", body["result"], tt.result)
+            }
+        })
+    }
+}
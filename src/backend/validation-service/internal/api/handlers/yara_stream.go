@@ -0,0 +1,297 @@
+// Package handlers provides HTTP handlers for the validation service API endpoints
+package handlers
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "mime"
+    "net/http"
+    "sync"
+    "time"
+
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/config"
+    "validation-service/internal/models"
+    "validation-service/internal/parser/yara"
+    "validation-service/pkg/logger"
+)
+
+// YARA bundle streaming limits
+const (
+    // maxYaraStreamSize bounds the bundle size; each rule is bounded by
+    // the maximum rule size
+    maxYaraStreamSize = 1 << 30 // 1GB
+    // yaraStreamTimeout bounds a streaming validation in place of the
+    // request timeout
+    yaraStreamTimeout = 30 * time.Minute
+    // yaraStreamWorkers is the number of rules validated concurrently
+    yaraStreamWorkers = 4
+    // yaraStreamFilePart is the multipart form field holding the bundle
+    yaraStreamFilePart = "file"
+
+    ndjsonContentType = "application/x-ndjson"
+)
+
+// Types of the lines of a streamed YARA validation
+const (
+    YaraStreamRuleLine    = "rule"
+    YaraStreamErrorLine   = "error"
+    YaraStreamSummaryLine = "summary"
+)
+
+// YaraStreamRule is the result of one rule of a streamed bundle. Lines are
+// those of the bundle; Index is the position of the rule in it, as results
+// are sent in the order the rules complete.
+type YaraStreamRule struct {
+    Type            string                   `json:"type"`
+    Index           int                      `json:"index"`
+    Rule            string                   `json:"rule"`
+    Line            int                      `json:"line"`
+    Status          string                   `json:"status,omitempty"`
+    Gate            string                   `json:"gate,omitempty"`
+    ConfidenceScore float64                  `json:"confidence_score"`
+    Issues          []models.ValidationIssue `json:"issues"`
+    Error           string                   `json:"error,omitempty"`
+}
+
+// YaraStreamError ends a stream whose bundle could not be read further
+type YaraStreamError struct {
+    Type  string `json:"type"`
+    Error string `json:"error"`
+}
+
+// YaraStreamSummary is the last line of a completed stream. Its gate fails
+// when the gate of a rule fails, a rule could not be validated or the stream
+// is incomplete.
+type YaraStreamSummary struct {
+    Type       string         `json:"type"`
+    Rules      int            `json:"rules"`
+    Statuses   map[string]int `json:"statuses"`
+    Failed     int            `json:"failed"`
+    Complete   bool           `json:"complete"`
+    Gate       string         `json:"gate"`
+    RequestID  string         `json:"request_id"`
+    DurationMs int64          `json:"duration_ms"`
+}
+
+// ValidateYaraStreamHandler validates a YARA bundle of any number of rules
+// rule by rule, as it is uploaded. The bundle is the request body, sent
+// chunked or with a length, or the file field of a multipart form. Each
+// rule is validated on its own with the import and include statements
+// before it, and its result is sent as a line of newline-delimited JSON as
+// soon as it completes; a summary line ends the stream. The stream is not
+// bound by the request size limit or timeout.
+func (h *ValidationHandler) ValidateYaraStreamHandler(w http.ResponseWriter, r *http.Request) {
+    body, err := yaraStreamBody(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+    body = http.MaxBytesReader(w, io.NopCloser(body), maxYaraStreamSize)
+
+    // The stream outlives the request timeout; it ends when the client
+    // goes away or yaraStreamTimeout passes
+    ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), yaraStreamTimeout)
+    defer cancel()
+    stop := context.AfterFunc(r.Context(), func() {
+        if errors.Is(r.Context().Err(), context.Canceled) {
+            cancel()
+        }
+    })
+    defer stop()
+
+    log := logger.FromContext(ctx)
+    rc := http.NewResponseController(w)
+    deadline := time.Now().Add(yaraStreamTimeout)
+    if err := rc.SetReadDeadline(deadline); err != nil {
+        log.Warn("Could not extend read deadline of YARA stream", "error", err)
+    }
+    if err := rc.SetWriteDeadline(deadline); err != nil {
+        log.Warn("Could not extend write deadline of YARA stream", "error", err)
+    }
+
+    w.Header().Set("Content-Type", ndjsonContentType)
+    w.Header().Set("X-Content-Type-Options", "nosniff")
+    w.WriteHeader(http.StatusOK)
+
+    // Lines are written by this goroutine only; a failed write means the
+    // client is gone, which stops the validation
+    encoder := json.NewEncoder(w)
+    gone := false
+    send := func(v interface{}) {
+        if gone {
+            return
+        }
+        err := encoder.Encode(v)
+        if err == nil {
+            if err = rc.Flush(); errors.Is(err, http.ErrNotSupported) {
+                err = nil
+            }
+        }
+        if err != nil {
+            gone = true
+            cancel()
+        }
+    }
+
+    start := time.Now()
+    summary := &YaraStreamSummary{
+        Type:      YaraStreamSummaryLine,
+        Statuses:  make(map[string]int),
+        RequestID: apimiddleware.RequestIDFromContext(r.Context()),
+    }
+
+    rules := make(chan indexedRule)
+    results := make(chan *YaraStreamRule)
+    readErr := make(chan error, 1)
+    go func() {
+        defer close(rules)
+        reader := yara.NewRuleReader(body, config.GetConfig().Validation.MaxRuleSize)
+        for index := 0; ; index++ {
+            source, err := reader.Next()
+            if err != nil {
+                if err != io.EOF {
+                    readErr <- err
+                }
+                return
+            }
+            select {
+            case rules <- indexedRule{index: index, source: source}:
+            case <-ctx.Done():
+                return
+            }
+        }
+    }()
+
+    gatePassed := true
+    var workers sync.WaitGroup
+    for i := 0; i < yaraStreamWorkers; i++ {
+        workers.Add(1)
+        go func() {
+            defer workers.Done()
+            for rule := range rules {
+                select {
+                case results <- h.validateStreamedRule(ctx, r, rule):
+                case <-ctx.Done():
+                    return
+                }
+            }
+        }()
+    }
+    go func() {
+        workers.Wait()
+        close(results)
+    }()
+
+    for result := range results {
+        summary.Rules++
+        if result.Error != "" {
+            summary.Failed++
+        } else {
+            summary.Statuses[result.Status]++
+        }
+        if result.Gate != models.GatePass {
+            gatePassed = false
+        }
+        send(result)
+    }
+
+    select {
+    case err := <-readErr:
+        streamErr := &YaraStreamError{Type: YaraStreamErrorLine, Error: err.Error()}
+        var maxBytesErr *http.MaxBytesError
+        if errors.As(err, &maxBytesErr) {
+            streamErr.Error = fmt.Sprintf("bundle exceeds %d bytes", maxYaraStreamSize)
+        }
+        send(streamErr)
+    default:
+        summary.Complete = ctx.Err() == nil
+    }
+    if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+        log.Warn("YARA stream exceeded its timeout",
+            "timeout", yaraStreamTimeout,
+            "rules", summary.Rules,
+        )
+    }
+    summary.Gate = models.GateFail
+    if gatePassed && summary.Complete {
+        summary.Gate = models.GatePass
+    }
+    summary.DurationMs = time.Since(start).Milliseconds()
+    send(summary)
+}
+
+// indexedRule is a rule of a streamed bundle with its position
+type indexedRule struct {
+    index  int
+    source *yara.RuleSource
+}
+
+// validateStreamedRule validates one rule of a bundle and moves the lines
+// of its issues to those of the bundle. Issues of the import and include
+// statements before the rule have no line.
+func (h *ValidationHandler) validateStreamedRule(ctx context.Context, r *http.Request, rule indexedRule) *YaraStreamRule {
+    line := &YaraStreamRule{
+        Type:   YaraStreamRuleLine,
+        Index:  rule.index,
+        Rule:   rule.source.Name,
+        Line:   rule.source.Line,
+        Issues: []models.ValidationIssue{},
+    }
+    detection := &models.Detection{
+        Format:  models.DetectionFormatYara,
+        Content: rule.source.Content(),
+    }
+    req := &ValidationRequest{SourceDetection: detection, TargetDetection: detection}
+    result, _, err := h.service.ValidateDetectionCached(ctx, detection, detection, false)
+    h.auditValidation(r, req, result, err)
+    if err != nil {
+        line.Error = err.Error()
+        return line
+    }
+
+    line.Status = result.Status
+    line.Gate = result.Metadata.Gate
+    line.ConfidenceScore = result.ConfidenceScore
+    headerLines := rule.source.HeaderLines()
+    // Results may be shared through the result cache; the issues are copied
+    // before their lines are moved
+    for _, issue := range result.Issues {
+        switch {
+        case issue.Line > headerLines:
+            issue.Line += rule.source.Line - 1 - headerLines
+        case issue.Line > 0:
+            issue.Line, issue.Column = 0, 0
+        }
+        line.Issues = append(line.Issues, issue)
+    }
+    return line
+}
+
+// yaraStreamBody returns the bundle of a streaming request: the file field
+// of a multipart form or the body itself
+func yaraStreamBody(r *http.Request) (io.Reader, error) {
+    mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+    if err != nil || mediaType != "multipart/form-data" {
+        return r.Body, nil
+    }
+    form, err := r.MultipartReader()
+    if err != nil {
+        return nil, fmt.Errorf("invalid multipart body: %v", err)
+    }
+    for {
+        part, err := form.NextPart()
+        if err == io.EOF {
+            return nil, fmt.Errorf("multipart body has no %s field", yaraStreamFilePart)
+        }
+        if err != nil {
+            return nil, fmt.Errorf("invalid multipart body: %v", err)
+        }
+        if part.FormName() == yaraStreamFilePart {
+            return part, nil
+        }
+    }
+}
@@ -0,0 +1,47 @@
+// Package middleware provides HTTP middleware components for the validation service
+package middleware
+
+import (
+    "net/http"
+    "time"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+
+    "validation-service/pkg/logger"
+)
+
+// AdaptiveLogging tags the request logger with the matched route and reports
+// the request outcome to adaptive, which raises logging of a route to debug
+// while its error rate or latency breaches the configured thresholds. Server
+// errors count as failures.
+func AdaptiveLogging(adaptive *logger.AdaptiveDebug) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            route := matchRoute(r)
+            if route == "" {
+                next.ServeHTTP(w, r)
+                return
+            }
+
+            start := time.Now()
+            rw := &responseWriter{ResponseWriter: w}
+            next.ServeHTTP(rw, r.WithContext(logger.WithRoute(r.Context(), route)))
+            adaptive.Observe(logger.RouteScope(route), rw.status >= http.StatusInternalServerError, time.Since(start))
+        })
+    }
+}
+
+// matchRoute returns the method and route pattern the request will be routed
+// to, such as "GET /api/v1/detections/{id}", so every request to a route
+// shares one scope. Unrouted requests return an empty string.
+func matchRoute(r *http.Request) string {
+    rctx := chi.RouteContext(r.Context())
+    if rctx == nil || rctx.Routes == nil {
+        return ""
+    }
+    match := chi.NewRouteContext()
+    if !rctx.Routes.Match(match, r.Method, r.URL.Path) {
+        return ""
+    }
+    return r.Method + " " + match.RoutePattern()
+}
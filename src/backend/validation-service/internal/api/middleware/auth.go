@@ -5,15 +5,14 @@ package middleware
 
 import (
     "context"
-    "crypto/rsa"
     "encoding/base64"
     "errors"
     "fmt"
+    "net/http"
     "regexp"
     "strings"
     "time"
 
-    "github.com/gin-gonic/gin" // v1.9.1
     "github.com/golang-jwt/jwt/v5" // v5.0.0
     "github.com/go-redis/redis/v8" // v8.11.5
     "golang.org/x/time/rate" // v0.0.0-20220922220347-f3bd1da661af
@@ -24,8 +23,6 @@ import (
 
 // Global variables for middleware configuration
 var (
-    jwtPublicKey *rsa.PublicKey
-    contextKeyUser = "user"
     tokenBlacklist *redis.Client
     authFailureLimit = rate.NewLimiter(rate.Every(1*time.Minute), 5)
     allowedRoles = map[string]bool{
@@ -43,6 +40,15 @@ type Claims struct {
     Role           string    `json:"role"`
     Permissions    []string  `json:"permissions"`
     TokenIssueTime time.Time `json:"token_issue_time"`
+    // Scope is a space-delimited list of scopes such as "validate:read
+    // rules:write" that narrows the scopes of the role
+    Scope string `json:"scope,omitempty"`
+    // Pipeline names the CI pipeline a token was minted for by the token
+    // exchange endpoint
+    Pipeline string `json:"pipeline,omitempty"`
+    // TenantID is the tenant the token was issued for; requests are made on
+    // behalf of this tenant whatever X-Tenant-ID header they carry
+    TenantID string `json:"tenant_id,omitempty"`
     jwt.RegisteredClaims
 }
 
@@ -60,11 +66,18 @@ func (c Claims) Validate() error {
     if c.TokenIssueTime.IsZero() {
         return errors.New("missing token issue time")
     }
+    for _, scope := range ParseScopes(c.Scope) {
+        if !validScope(scope) {
+            return fmt.Errorf("invalid scope: %s", scope)
+        }
+    }
     return nil
 }
 
-// AuthMiddleware returns a Gin middleware function that implements JWT authentication
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware returns a middleware function that implements JWT
+// authentication. The validated claims are available to handlers and to
+// RequireScope through ClaimsFromContext.
+func AuthMiddleware() func(http.Handler) http.Handler {
     // Initialize security logger
     log := logger.GetLogger()
     cfg := config.GetConfig()
@@ -75,65 +88,86 @@ func AuthMiddleware() gin.HandlerFunc {
         DB:   0,
     })
 
-    return func(c *gin.Context) {
-        // Extract token from request
-        tokenString, err := extractToken(c)
-        if err != nil {
-            log.Error("Failed to extract token",
-                "error", err,
-                "ip", c.ClientIP(),
-            )
-            c.AbortWithStatusJSON(401, gin.H{"error": "Invalid authentication token"})
-            return
-        }
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            // Extract token from request
+            tokenString, err := extractToken(r)
+            if err != nil {
+                log.Error("Failed to extract token",
+                    "error", err,
+                    "ip", r.RemoteAddr,
+                )
+                writeAuthError(w, http.StatusUnauthorized, "Invalid authentication token")
+                return
+            }
 
-        // Check rate limiting for auth failures
-        if !authFailureLimit.Allow() {
-            log.Warn("Rate limit exceeded for authentication attempts",
-                "ip", c.ClientIP(),
-            )
-            c.AbortWithStatusJSON(429, gin.H{"error": "Too many authentication attempts"})
-            return
-        }
+            // Check rate limiting for auth failures
+            if !authFailureLimit.Allow() {
+                log.Warn("Rate limit exceeded for authentication attempts",
+                    "ip", r.RemoteAddr,
+                )
+                writeAuthError(w, http.StatusTooManyRequests, "Too many authentication attempts")
+                return
+            }
 
-        // Check token blacklist
-        ctx := context.Background()
-        if exists, _ := tokenBlacklist.Exists(ctx, tokenString).Result(); exists == 1 {
-            log.Warn("Blacklisted token used",
-                "ip", c.ClientIP(),
-            )
-            c.AbortWithStatusJSON(401, gin.H{"error": "Token has been revoked"})
-            return
-        }
+            // Check token blacklist
+            if exists, _ := tokenBlacklist.Exists(r.Context(), tokenString).Result(); exists == 1 {
+                log.Warn("Blacklisted token used",
+                    "ip", r.RemoteAddr,
+                )
+                writeAuthError(w, http.StatusUnauthorized, "Token has been revoked")
+                return
+            }
+
+            // Validate token
+            claims, err := validateTokenContext(r.Context(), tokenString)
+            if err != nil {
+                log.Error("Token validation failed",
+                    "error", err,
+                    "ip", r.RemoteAddr,
+                )
+                writeAuthError(w, http.StatusUnauthorized, "Invalid or expired token")
+                return
+            }
 
-        // Validate token
-        claims, err := validateToken(tokenString)
-        if err != nil {
-            log.Error("Token validation failed",
-                "error", err,
-                "ip", c.ClientIP(),
+            // Audit log successful authentication
+            log.Info("Successful authentication",
+                "user_id", claims.UserId,
+                "role", claims.Role,
+                "scopes", claims.Scopes(),
+                "ip", r.RemoteAddr,
             )
-            c.AbortWithStatusJSON(401, gin.H{"error": "Invalid or expired token"})
-            return
-        }
 
-        // Store validated claims in context
-        c.Set(contextKeyUser, claims)
+            // Store validated claims in context
+            next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+        })
+    }
+}
 
-        // Audit log successful authentication
-        log.Info("Successful authentication",
-            "user_id", claims.UserId,
-            "role", claims.Role,
-            "ip", c.ClientIP(),
-        )
+// extractToken securely extracts the JWT token from the Authorization header
+func extractToken(r *http.Request) (string, error) {
+    return parseBearerToken(r.Header.Get("Authorization"))
+}
 
-        c.Next()
+// AuthenticateBearer validates an "Authorization: Bearer <token>" value for
+// transports other than HTTP, such as gRPC metadata
+func AuthenticateBearer(ctx context.Context, authHeader string) (*Claims, error) {
+    tokenString, err := parseBearerToken(authHeader)
+    if err != nil {
+        return nil, err
     }
+
+    if tokenBlacklist != nil {
+        if exists, _ := tokenBlacklist.Exists(ctx, tokenString).Result(); exists == 1 {
+            return nil, errors.New("token has been revoked")
+        }
+    }
+
+    return validateTokenContext(ctx, tokenString)
 }
 
-// extractToken securely extracts the JWT token from the Authorization header
-func extractToken(c *gin.Context) (string, error) {
-    authHeader := c.GetHeader("Authorization")
+// parseBearerToken extracts the JWT token from an Authorization header value
+func parseBearerToken(authHeader string) (string, error) {
     if authHeader == "" {
         return "", errors.New("missing authorization header")
     }
@@ -154,14 +188,40 @@ func extractToken(c *gin.Context) (string, error) {
     return token, nil
 }
 
-// validateToken performs comprehensive token validation
-func validateToken(tokenString string) (*Claims, error) {
+// validateTokenContext validates tokens of the configured OIDC provider with
+// its keys and every other token with validateToken
+func validateTokenContext(ctx context.Context, tokenString string) (*Claims, error) {
+    if oidcVerifier != nil && unverifiedIssuer(tokenString) == oidcVerifier.Issuer() {
+        return oidcVerifier.Verify(ctx, tokenString)
+    }
+    return validateToken(ctx, tokenString)
+}
+
+// validateToken performs comprehensive token validation. Tokens minted by
+// the token exchange are verified with its key and other tokens with the
+// keys of the configured JWT issuer.
+func validateToken(ctx context.Context, tokenString string) (*Claims, error) {
     token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
         // Validate signing method
         if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
             return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
         }
-        return jwtPublicKey, nil
+        claims, ok := token.Claims.(*Claims)
+        if !ok {
+            return nil, errors.New("invalid token claims")
+        }
+        // Tokens minted by the token exchange are signed with its own key
+        if claims.Issuer == TokenExchangeIssuer {
+            if exchangeVerifyKey == nil {
+                return nil, errors.New("token exchange is not enabled")
+            }
+            return exchangeVerifyKey, nil
+        }
+        if serviceTokens == nil {
+            return nil, errors.New("no JWT issuer is configured")
+        }
+        kid, _ := token.Header["kid"].(string)
+        return serviceTokens.key(ctx, claims.Issuer, kid)
     })
 
     if err != nil {
@@ -172,6 +232,11 @@ func validateToken(tokenString string) (*Claims, error) {
     if !ok || !token.Valid {
         return nil, errors.New("invalid token claims")
     }
+    if claims.Issuer != TokenExchangeIssuer {
+        if err := serviceTokens.checkAudience(claims.Audience); err != nil {
+            return nil, err
+        }
+    }
 
     // Validate custom claims
     if err := claims.Validate(); err != nil {
@@ -0,0 +1,220 @@
+// Package middleware provides HTTP middleware components for the validation service API
+// with deprecation announcements and usage tracking for retiring routes.
+package middleware
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "net/http"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"          // v1.17.0
+    "github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+
+    "validation-service/internal/config"
+)
+
+// APIKeyHeader identifies the calling client in the deprecation report
+const APIKeyHeader = "X-API-Key"
+
+// anonymousClient is the report key of calls without an API key
+const anonymousClient = "anonymous"
+
+// maxDeprecationClients bounds the clients tracked per route. Calls of
+// further clients are still counted in the route total.
+const maxDeprecationClients = 10000
+
+var deprecatedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name:        "deprecated_route_requests_total",
+    Help:        "Requests to deprecated API routes by method and route",
+    ConstLabels: prometheus.Labels{"service": "validation"},
+}, []string{"method", "route"})
+
+// DeprecatedClientUsage counts the calls of one client to a deprecated route
+type DeprecatedClientUsage struct {
+    // APIKeyID is a fingerprint of the client's API key, never the key itself
+    APIKeyID  string    `json:"api_key_id"`
+    Requests  int64     `json:"requests"`
+    FirstSeen time.Time `json:"first_seen"`
+    LastSeen  time.Time `json:"last_seen"`
+}
+
+// DeprecatedRouteUsage reports the calls made to one deprecated route since
+// the service started, most recently active clients first
+type DeprecatedRouteUsage struct {
+    Method        string                  `json:"method,omitempty"`
+    Path          string                  `json:"path"`
+    DeprecatedAt  time.Time               `json:"deprecated_at"`
+    Sunset        *time.Time              `json:"sunset,omitempty"`
+    Successor     string                  `json:"successor,omitempty"`
+    TotalRequests int64                   `json:"total_requests"`
+    Clients       []DeprecatedClientUsage `json:"clients"`
+}
+
+// deprecatedRoute is a configured route with its usage counters
+type deprecatedRoute struct {
+    config.DeprecatedRoute
+    segments    []string
+    deprecation string
+    sunset      string
+    link        string
+
+    total   int64
+    clients map[string]*DeprecatedClientUsage
+}
+
+// DeprecationTracker announces deprecated routes to clients and records who
+// still calls them
+type DeprecationTracker struct {
+    mu     sync.Mutex
+    routes []*deprecatedRoute
+    now    func() time.Time
+}
+
+// NewDeprecationTracker creates a tracker for the configured routes. The
+// first matching entry applies when several match a request.
+func NewDeprecationTracker(routes []config.DeprecatedRoute) *DeprecationTracker {
+    t := &DeprecationTracker{now: time.Now}
+    for _, route := range routes {
+        d := &deprecatedRoute{
+            DeprecatedRoute: route,
+            segments:        strings.Split(strings.Trim(route.Path, "/"), "/"),
+            // RFC 9745 structured date: @<unix seconds>
+            deprecation: fmt.Sprintf("@%d", route.DeprecatedAt.Unix()),
+            clients:     make(map[string]*DeprecatedClientUsage),
+        }
+        d.Method = strings.ToUpper(route.Method)
+        if !route.Sunset.IsZero() {
+            d.sunset = route.Sunset.UTC().Format(http.TimeFormat)
+        }
+        if route.Successor != "" {
+            d.link = fmt.Sprintf(`<%s>; rel="successor-version"`, route.Successor)
+        }
+        t.routes = append(t.routes, d)
+    }
+    return t
+}
+
+// Middleware adds Deprecation, Sunset and successor Link headers to responses
+// of deprecated routes and counts the call against the client's API key
+func (t *DeprecationTracker) Middleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        route := t.match(r.Method, r.URL.Path)
+        if route == nil {
+            next.ServeHTTP(w, r)
+            return
+        }
+
+        header := w.Header()
+        header.Set("Deprecation", route.deprecation)
+        if route.sunset != "" {
+            header.Set("Sunset", route.sunset)
+        }
+        if route.link != "" {
+            header.Add("Link", route.link)
+        }
+
+        deprecatedRequests.WithLabelValues(r.Method, route.Path).Inc()
+        t.record(route, apiKeyID(r.Header.Get(APIKeyHeader)))
+
+        next.ServeHTTP(w, r)
+    })
+}
+
+// Report returns the usage of every deprecated route, in configuration order
+func (t *DeprecationTracker) Report() []DeprecatedRouteUsage {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    report := make([]DeprecatedRouteUsage, 0, len(t.routes))
+    for _, route := range t.routes {
+        usage := DeprecatedRouteUsage{
+            Method:        route.Method,
+            Path:          route.Path,
+            DeprecatedAt:  route.DeprecatedAt,
+            Successor:     route.Successor,
+            TotalRequests: route.total,
+            Clients:       make([]DeprecatedClientUsage, 0, len(route.clients)),
+        }
+        if !route.Sunset.IsZero() {
+            sunset := route.Sunset
+            usage.Sunset = &sunset
+        }
+        for _, client := range route.clients {
+            usage.Clients = append(usage.Clients, *client)
+        }
+        sort.Slice(usage.Clients, func(i, j int) bool {
+            return usage.Clients[i].LastSeen.After(usage.Clients[j].LastSeen)
+        })
+        report = append(report, usage)
+    }
+    return report
+}
+
+// match returns the first deprecated route matching the request
+func (t *DeprecationTracker) match(method, path string) *deprecatedRoute {
+    segments := strings.Split(strings.Trim(path, "/"), "/")
+    for _, route := range t.routes {
+        if route.Method != "" && route.Method != method {
+            continue
+        }
+        if matchRouteSegments(route.segments, segments) {
+            return route
+        }
+    }
+    return nil
+}
+
+// matchRouteSegments matches path segments against a pattern in which {name}
+// matches one segment and a trailing * matches the remaining segments
+func matchRouteSegments(pattern, segments []string) bool {
+    for i, p := range pattern {
+        if p == "*" && i == len(pattern)-1 {
+            return true
+        }
+        if i >= len(segments) {
+            return false
+        }
+        if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+            continue
+        }
+        if p != segments[i] {
+            return false
+        }
+    }
+    return len(pattern) == len(segments)
+}
+
+// record counts a call of a client to a deprecated route
+func (t *DeprecationTracker) record(route *deprecatedRoute, client string) {
+    now := t.now()
+
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    route.total++
+    usage, ok := route.clients[client]
+    if !ok {
+        if len(route.clients) >= maxDeprecationClients {
+            return
+        }
+        usage = &DeprecatedClientUsage{APIKeyID: client, FirstSeen: now}
+        route.clients[client] = usage
+    }
+    usage.Requests++
+    usage.LastSeen = now
+}
+
+// apiKeyID fingerprints an API key so the report identifies clients without
+// disclosing their credentials
+func apiKeyID(key string) string {
+    if key == "" {
+        return anonymousClient
+    }
+    sum := sha256.Sum256([]byte(key))
+    return "sha256:" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,211 @@
+// Package middleware provides HTTP middleware components for the validation service API
+// with conditional request support for large, rarely changing GET payloads.
+package middleware
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "mime"
+    "net/http"
+    "strings"
+    "sync"
+)
+
+// etagBufferPool reuses response buffers for conditional request handling
+var etagBufferPool = sync.Pool{
+    New: func() interface{} {
+        return new(bytes.Buffer)
+    },
+}
+
+// etagMaxBufferSize bounds the response buffered for an entity tag; larger
+// responses, such as exports and report downloads, are sent as they are
+// written without one
+const etagMaxBufferSize = 4 << 20 // 4MB
+
+// etagStreamedTypes are the content types of responses written as a stream,
+// which are never buffered
+var etagStreamedTypes = map[string]bool{
+    "text/event-stream":    true,
+    "application/x-ndjson": true,
+}
+
+// etagResponseWriter buffers a response so an entity tag can be computed
+// before anything is sent to the client. Streamed responses, attachments,
+// responses outgrowing etagMaxBufferSize and responses the handler flushes
+// are passed through to the client instead.
+type etagResponseWriter struct {
+    http.ResponseWriter
+    buf    *bytes.Buffer
+    status int
+    // passthrough is set once the response is sent as it is written
+    passthrough bool
+}
+
+// WriteHeader records the status code without forwarding it, unless the
+// response is not to be buffered
+func (w *etagResponseWriter) WriteHeader(status int) {
+    if w.passthrough || w.status != 0 {
+        return
+    }
+    w.status = status
+    if w.streamed() {
+        w.startPassthrough()
+    }
+}
+
+// Write buffers the response body, or sends it once the response is passed
+// through
+func (w *etagResponseWriter) Write(b []byte) (int, error) {
+    if w.status == 0 {
+        w.WriteHeader(http.StatusOK)
+    }
+    if !w.passthrough && w.buf.Len()+len(b) > etagMaxBufferSize {
+        if err := w.startPassthrough(); err != nil {
+            return 0, err
+        }
+    }
+    if w.passthrough {
+        return w.ResponseWriter.Write(b)
+    }
+    return w.buf.Write(b)
+}
+
+// FlushError sends what was written so far and passes the rest of the
+// response through, as a handler flushing expects its output to reach the
+// client. It is used by http.ResponseController.
+func (w *etagResponseWriter) FlushError() error {
+    if w.status == 0 {
+        w.WriteHeader(http.StatusOK)
+    }
+    if err := w.startPassthrough(); err != nil {
+        return err
+    }
+    return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Flush implements http.Flusher
+func (w *etagResponseWriter) Flush() {
+    _ = w.FlushError()
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController
+func (w *etagResponseWriter) Unwrap() http.ResponseWriter {
+    return w.ResponseWriter
+}
+
+// streamed reports whether the response headers mark a response that must
+// not be buffered
+func (w *etagResponseWriter) streamed() bool {
+    header := w.Header()
+    mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+    if etagStreamedTypes[mediaType] {
+        return true
+    }
+    disposition, _, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+    return disposition == "attachment"
+}
+
+// startPassthrough sends the status and the buffered body, after which the
+// response is written to the client directly
+func (w *etagResponseWriter) startPassthrough() error {
+    if w.passthrough {
+        return nil
+    }
+    w.passthrough = true
+    w.ResponseWriter.WriteHeader(w.status)
+    if w.buf.Len() == 0 {
+        return nil
+    }
+    _, err := w.ResponseWriter.Write(w.buf.Bytes())
+    w.buf.Reset()
+    return err
+}
+
+// ETagMiddleware adds strong entity tags to successful GET responses and answers
+// If-None-Match requests with 304 Not Modified, so clients polling stored results
+// and rule lists do not re-transfer unchanged payloads. Handlers that already know
+// a resource version may set the ETag header themselves; it is then used verbatim.
+// Event streams, attachments, flushed responses and responses larger than
+// etagMaxBufferSize are sent unbuffered and without an entity tag.
+func ETagMiddleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if (r.Method != http.MethodGet && r.Method != http.MethodHead) || acceptsEventStream(r) {
+            next.ServeHTTP(w, r)
+            return
+        }
+
+        buf := etagBufferPool.Get().(*bytes.Buffer)
+        buf.Reset()
+        defer etagBufferPool.Put(buf)
+
+        ew := &etagResponseWriter{ResponseWriter: w, buf: buf}
+        next.ServeHTTP(ew, r)
+        if ew.passthrough {
+            return
+        }
+
+        status := ew.status
+        if status == 0 {
+            status = http.StatusOK
+        }
+
+        // Only successful full responses are cacheable
+        if status != http.StatusOK {
+            w.WriteHeader(status)
+            w.Write(buf.Bytes())
+            return
+        }
+
+        etag := w.Header().Get("ETag")
+        if etag == "" {
+            etag = computeETag(buf.Bytes())
+            w.Header().Set("ETag", etag)
+        }
+        w.Header().Set("Cache-Control", "private, no-cache")
+
+        if etagMatches(r.Header.Get("If-None-Match"), etag) {
+            w.Header().Del("Content-Type")
+            w.Header().Del("Content-Length")
+            w.WriteHeader(http.StatusNotModified)
+            return
+        }
+
+        w.WriteHeader(status)
+        w.Write(buf.Bytes())
+    })
+}
+
+// acceptsEventStream reports whether the request asks for server-sent
+// events, which are streamed for as long as the connection stays open
+func acceptsEventStream(r *http.Request) bool {
+    for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+        mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+        if err == nil && mediaType == "text/event-stream" {
+            return true
+        }
+    }
+    return false
+}
+
+// computeETag derives a strong entity tag from the response body
+func computeETag(body []byte) string {
+    sum := sha256.Sum256(body)
+    return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches implements the weak comparison required for If-None-Match
+func etagMatches(header, etag string) bool {
+    if header == "" {
+        return false
+    }
+    target := strings.TrimPrefix(etag, "W/")
+    for _, candidate := range strings.Split(header, ",") {
+        candidate = strings.TrimSpace(candidate)
+        if candidate == "*" || strings.TrimPrefix(candidate, "W/") == target {
+            return true
+        }
+    }
+    return false
+}
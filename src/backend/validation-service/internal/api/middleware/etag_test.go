@@ -0,0 +1,182 @@
+package middleware
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+func TestETagMiddleware(t *testing.T) {
+    body := `{"results":[]}`
+    etag := computeETag([]byte(body))
+
+    tests := []struct {
+        name        string
+        method      string
+        header      map[string]string
+        handler     http.HandlerFunc
+        wantStatus  int
+        wantBody    string
+        wantETag    string
+        wantNoCache bool
+    }{
+        {
+            name:        "tags a successful GET",
+            method:      http.MethodGet,
+            handler:     writeBody(body),
+            wantStatus:  http.StatusOK,
+            wantBody:    body,
+            wantETag:    etag,
+            wantNoCache: true,
+        },
+        {
+            name:        "answers a matching If-None-Match with 304",
+            method:      http.MethodGet,
+            header:      map[string]string{"If-None-Match": `"other", ` + etag},
+            handler:     writeBody(body),
+            wantStatus:  http.StatusNotModified,
+            wantETag:    etag,
+            wantNoCache: true,
+        },
+        {
+            name:        "matches a weak validator",
+            method:      http.MethodGet,
+            header:      map[string]string{"If-None-Match": "W/" + etag},
+            handler:     writeBody(body),
+            wantStatus:  http.StatusNotModified,
+            wantETag:    etag,
+            wantNoCache: true,
+        },
+        {
+            name:        "answers a stale If-None-Match with the body",
+            method:      http.MethodGet,
+            header:      map[string]string{"If-None-Match": `"stale"`},
+            handler:     writeBody(body),
+            wantStatus:  http.StatusOK,
+            wantBody:    body,
+            wantETag:    etag,
+            wantNoCache: true,
+        },
+        {
+            name:   "keeps an ETag set by the handler",
+            method: http.MethodGet,
+            handler: func(w http.ResponseWriter, r *http.Request) {
+                w.Header().Set("ETag", `"v7"`)
+                writeBody(body)(w, r)
+            },
+            wantStatus:  http.StatusOK,
+            wantBody:    body,
+            wantETag:    `"v7"`,
+            wantNoCache: true,
+        },
+        {
+            name:   "does not tag errors",
+            method: http.MethodGet,
+            handler: func(w http.ResponseWriter, r *http.Request) {
+                w.WriteHeader(http.StatusNotFound)
+                w.Write([]byte("missing"))
+            },
+            wantStatus: http.StatusNotFound,
+            wantBody:   "missing",
+        },
+        {
+            name:       "ignores POST",
+            method:     http.MethodPost,
+            handler:    writeBody(body),
+            wantStatus: http.StatusOK,
+            wantBody:   body,
+        },
+        {
+            name:       "passes event stream requests through",
+            method:     http.MethodGet,
+            header:     map[string]string{"Accept": "text/event-stream"},
+            handler:    writeBody(body),
+            wantStatus: http.StatusOK,
+            wantBody:   body,
+        },
+        {
+            name:   "passes streamed content types through",
+            method: http.MethodGet,
+            handler: func(w http.ResponseWriter, r *http.Request) {
+                w.Header().Set("Content-Type", "application/x-ndjson")
+                writeBody(body)(w, r)
+            },
+            wantStatus: http.StatusOK,
+            wantBody:   body,
+        },
+        {
+            name:   "passes attachments through",
+            method: http.MethodGet,
+            handler: func(w http.ResponseWriter, r *http.Request) {
+                w.Header().Set("Content-Disposition", `attachment; filename="export.zip"`)
+                writeBody(body)(w, r)
+            },
+            wantStatus: http.StatusOK,
+            wantBody:   body,
+        },
+        {
+            name:   "passes flushed responses through",
+            method: http.MethodGet,
+            handler: func(w http.ResponseWriter, r *http.Request) {
+                w.Write([]byte("event: a\n\n"))
+                if err := http.NewResponseController(w).Flush(); err != nil {
+                    t.Errorf("Flush() error = %v", err)
+                }
+                w.Write([]byte("event: b\n\n"))
+            },
+            wantStatus: http.StatusOK,
+            wantBody:   "event: a\n\nevent: b\n\n",
+        },
+        {
+            name:       "passes responses over the buffer limit through",
+            method:     http.MethodGet,
+            handler:    writeBody(strings.Repeat("x", etagMaxBufferSize+1)),
+            wantStatus: http.StatusOK,
+            wantBody:   strings.Repeat("x", etagMaxBufferSize+1),
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            req := httptest.NewRequest(tt.method, "/api/v1/results", nil)
+            for k, v := range tt.header {
+                req.Header.Set(k, v)
+            }
+            rec := httptest.NewRecorder()
+            ETagMiddleware(tt.handler).ServeHTTP(rec, req)
+
+            if rec.Code != tt.wantStatus {
+                t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+            }
+            if got := rec.Body.String(); got != tt.wantBody {
+                t.Errorf("body = %.40q, want %.40q", got, tt.wantBody)
+            }
+            if got := rec.Header().Get("ETag"); got != tt.wantETag {
+                t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+            }
+            if got := rec.Header().Get("Cache-Control") != ""; got != tt.wantNoCache {
+                t.Errorf("Cache-Control set = %v, want %v", got, tt.wantNoCache)
+            }
+        })
+    }
+}
+
+func TestETagResponseWriterUnwrap(t *testing.T) {
+    rec := httptest.NewRecorder()
+    ew := &etagResponseWriter{ResponseWriter: rec}
+    if got := ew.Unwrap(); got != rec {
+        t.Errorf("Unwrap() = %v, want the wrapped writer", got)
+    }
+}
+
+// writeBody returns a handler writing body, as JSON unless the test set
+// another content type
+func writeBody(body string) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if w.Header().Get("Content-Type") == "" {
+            w.Header().Set("Content-Type", "application/json")
+        }
+        w.Write([]byte(body))
+    }
+}
@@ -0,0 +1,256 @@
+// Package middleware provides HTTP middleware components for the validation service API
+// with idempotency keys for safely retried requests.
+package middleware
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "net/http"
+    "slices"
+    "strings"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"          // v1.17.0
+    "github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+
+    "validation-service/internal/cache"
+    "validation-service/internal/tenant"
+    "validation-service/pkg/logger"
+)
+
+// Headers of idempotent requests
+const (
+    IdempotencyKeyHeader = "Idempotency-Key"
+    // IdempotentReplayHeader is set on responses replayed from a previous
+    // request with the same key
+    IdempotentReplayHeader = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength bounds accepted idempotency keys
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotentResponseSize bounds the responses recorded for replay; larger
+// responses are not recorded and retries are processed again
+const maxIdempotentResponseSize = 10 << 20
+
+// fingerprintHeaders are the request headers that shape a response, so a
+// key reused with other values is a different request
+var fingerprintHeaders = []string{"Accept", "Accept-Encoding", "Content-Type"}
+
+// encodingHeaders are the response headers owned by the compression
+// middleware in front of the handler. They describe the encoded response,
+// while the recorded body is the handler's output, so they are not replayed;
+// replayed responses are encoded again.
+var encodingHeaders = []string{"Content-Encoding", "Content-Length", "Vary"}
+
+// idempotencyClaimTTL is how long a key stays claimed by a request that is
+// still running, so a replica failing mid-request does not block the key for
+// the whole retention window
+const idempotencyClaimTTL = 2 * time.Minute
+
+// Outcomes of requests made with an idempotency key
+const (
+    idempotencyOutcomeProcessed = "processed"
+    idempotencyOutcomeReplayed  = "replayed"
+    idempotencyOutcomeInFlight  = "in_flight"
+    idempotencyOutcomeMismatch  = "mismatch"
+    idempotencyOutcomeInvalid   = "invalid"
+    idempotencyOutcomeError     = "store_error"
+)
+
+var idempotentRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name:        "idempotent_requests_total",
+    Help:        "Requests made with an Idempotency-Key header by outcome",
+    ConstLabels: prometheus.Labels{"service": "validation"},
+}, []string{"outcome"})
+
+// Idempotency deduplicates requests made with an Idempotency-Key header.
+// The first request with a key is processed and its response recorded for
+// the retention window; retries with the same key and request are answered
+// with the recorded response instead of being processed again. Keys are
+// scoped to the tenant and caller.
+type Idempotency struct {
+    store cache.IdempotencyStore
+    ttl   time.Duration
+}
+
+// NewIdempotency records responses in store for ttl
+func NewIdempotency(store cache.IdempotencyStore, ttl time.Duration) *Idempotency {
+    return &Idempotency{store: store, ttl: ttl}
+}
+
+// idempotentResponseWriter forwards a response while recording it for replay
+type idempotentResponseWriter struct {
+    http.ResponseWriter
+    status   int
+    body     bytes.Buffer
+    overflow bool
+}
+
+// WriteHeader records and forwards the status code
+func (w *idempotentResponseWriter) WriteHeader(status int) {
+    if w.status == 0 {
+        w.status = status
+    }
+    w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records and forwards the response body
+func (w *idempotentResponseWriter) Write(b []byte) (int, error) {
+    if w.status == 0 {
+        w.status = http.StatusOK
+    }
+    if !w.overflow {
+        if w.body.Len()+len(b) > maxIdempotentResponseSize {
+            w.overflow = true
+            w.body.Reset()
+        } else {
+            w.body.Write(b)
+        }
+    }
+    return w.ResponseWriter.Write(b)
+}
+
+// Middleware deduplicates POST requests carrying an Idempotency-Key header.
+// A key reused with a different request is answered with 422 Unprocessable
+// Entity, and a retry while the first request runs with 409 Conflict.
+// Server errors are not recorded, so their retries are processed again. A
+// nil Idempotency passes requests through.
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+    if i == nil {
+        return next
+    }
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        key := r.Header.Get(IdempotencyKeyHeader)
+        if key == "" || r.Method != http.MethodPost {
+            next.ServeHTTP(w, r)
+            return
+        }
+        if len(key) > maxIdempotencyKeyLength || strings.TrimSpace(key) != key {
+            idempotentRequests.WithLabelValues(idempotencyOutcomeInvalid).Inc()
+            writeAuthError(w, http.StatusBadRequest, "Idempotency-Key must be 1 to 255 characters without surrounding whitespace")
+            return
+        }
+
+        digest, err := bodyDigest(r)
+        if err != nil {
+            idempotentRequests.WithLabelValues(idempotencyOutcomeInvalid).Inc()
+            writeAuthError(w, http.StatusBadRequest, "Invalid idempotent request: "+err.Error())
+            return
+        }
+        fingerprint := requestFingerprint(r, digest)
+        storeKey := idempotencyStoreKey(r, key)
+
+        log := logger.FromContext(r.Context())
+        recorded, claimed, err := i.store.Reserve(r.Context(), storeKey, fingerprint, idempotencyClaimTTL)
+        if err != nil {
+            idempotentRequests.WithLabelValues(idempotencyOutcomeError).Inc()
+            log.Error("Failed to claim idempotency key", "error", err)
+            writeAuthError(w, http.StatusServiceUnavailable, "Idempotency store unavailable")
+            return
+        }
+        if !claimed {
+            i.replay(w, recorded, fingerprint)
+            return
+        }
+
+        before := w.Header().Clone()
+        iw := &idempotentResponseWriter{ResponseWriter: w}
+        next.ServeHTTP(iw, r)
+        idempotentRequests.WithLabelValues(idempotencyOutcomeProcessed).Inc()
+
+        // The response is recorded even when the client gave up waiting for
+        // it, which is when it retries
+        ctx := context.WithoutCancel(r.Context())
+        status := iw.status
+        if status == 0 {
+            status = http.StatusOK
+        }
+        if status >= http.StatusInternalServerError || iw.overflow {
+            if err := i.store.Release(ctx, storeKey); err != nil {
+                log.Error("Failed to release idempotency key", "error", err)
+            }
+            return
+        }
+        response := &cache.IdempotentResponse{
+            Fingerprint: fingerprint,
+            Complete:    true,
+            Status:      status,
+            ContentType: w.Header().Get("Content-Type"),
+            Header:      handlerHeaders(before, w.Header()),
+            Body:        iw.body.Bytes(),
+        }
+        if err := i.store.Complete(ctx, storeKey, response, i.ttl); err != nil {
+            log.Error("Failed to record idempotent response", "error", err)
+            if err := i.store.Release(ctx, storeKey); err != nil {
+                log.Error("Failed to release idempotency key", "error", err)
+            }
+        }
+    })
+}
+
+// replay answers a request whose key is already claimed with the recorded
+// response
+func (i *Idempotency) replay(w http.ResponseWriter, recorded *cache.IdempotentResponse, fingerprint string) {
+    switch {
+    case recorded.Fingerprint != fingerprint:
+        idempotentRequests.WithLabelValues(idempotencyOutcomeMismatch).Inc()
+        writeAuthError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+    case !recorded.Complete:
+        idempotentRequests.WithLabelValues(idempotencyOutcomeInFlight).Inc()
+        w.Header().Set("Retry-After", "1")
+        writeAuthError(w, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+    default:
+        idempotentRequests.WithLabelValues(idempotencyOutcomeReplayed).Inc()
+        for name, values := range recorded.Header {
+            w.Header()[name] = values
+        }
+        if recorded.ContentType != "" {
+            w.Header().Set("Content-Type", recorded.ContentType)
+        }
+        w.Header().Set(IdempotentReplayHeader, "true")
+        w.WriteHeader(recorded.Status)
+        w.Write(recorded.Body)
+    }
+}
+
+// requestFingerprint identifies a request by its method, URI, the headers
+// that shape its response and its body digest
+func requestFingerprint(r *http.Request, bodyDigest string) string {
+    parts := []string{r.Method, r.URL.RequestURI()}
+    for _, name := range fingerprintHeaders {
+        parts = append(parts, strings.Join(r.Header.Values(name), ","))
+    }
+    parts = append(parts, bodyDigest)
+    sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+    return hex.EncodeToString(sum[:])
+}
+
+// handlerHeaders returns the response headers set or changed by the handler,
+// leaving out those set before it ran, such as the request ID, which belong
+// to each request, and the encoding headers
+func handlerHeaders(before, after http.Header) http.Header {
+    header := make(http.Header)
+    for name, values := range after {
+        if name == IdempotentReplayHeader || slices.Contains(encodingHeaders, name) || slices.Equal(before[name], values) {
+            continue
+        }
+        header[name] = slices.Clone(values)
+    }
+    if len(header) == 0 {
+        return nil
+    }
+    return header
+}
+
+// idempotencyStoreKey scopes an idempotency key to the tenant and caller of
+// the request, so callers cannot replay each other's responses
+func idempotencyStoreKey(r *http.Request, key string) string {
+    userID := ""
+    if claims, ok := ClaimsFromContext(r.Context()); ok {
+        userID = claims.UserId
+    }
+    return cache.Key(tenant.IDFromContext(r.Context()), userID, key)
+}
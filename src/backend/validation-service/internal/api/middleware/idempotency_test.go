@@ -0,0 +1,147 @@
+package middleware
+
+import (
+    "compress/gzip"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    chimiddleware "github.com/go-chi/chi/v5/middleware"
+
+    "validation-service/internal/cache"
+)
+
+func TestRequestFingerprint(t *testing.T) {
+    base := func() *http.Request {
+        r := httptest.NewRequest(http.MethodPost, "/api/v1/validate?format=splunk", nil)
+        r.Header.Set("Accept", "application/json")
+        r.Header.Set("Content-Type", "application/json")
+        return r
+    }
+    want := requestFingerprint(base(), "digest")
+
+    tests := []struct {
+        name   string
+        modify func(r *http.Request) (*http.Request, string)
+        same   bool
+    }{
+        {"identical", func(r *http.Request) (*http.Request, string) { return r, "digest" }, true},
+        {"unrelated header", func(r *http.Request) (*http.Request, string) {
+            r.Header.Set("X-Request-ID", "abc")
+            return r, "digest"
+        }, true},
+        {"body", func(r *http.Request) (*http.Request, string) { return r, "other" }, false},
+        {"uri", func(r *http.Request) (*http.Request, string) {
+            return httptest.NewRequest(http.MethodPost, "/api/v1/validate?format=kql", nil), "digest"
+        }, false},
+        {"accept", func(r *http.Request) (*http.Request, string) {
+            r.Header.Set("Accept", "application/sarif+json")
+            return r, "digest"
+        }, false},
+        {"accept encoding", func(r *http.Request) (*http.Request, string) {
+            r.Header.Set("Accept-Encoding", "gzip")
+            return r, "digest"
+        }, false},
+        {"content type", func(r *http.Request) (*http.Request, string) {
+            r.Header.Set("Content-Type", "application/yaml")
+            return r, "digest"
+        }, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            r, digest := tt.modify(base())
+            if got := requestFingerprint(r, digest) == want; got != tt.same {
+                t.Errorf("fingerprint equal = %v, want %v", got, tt.same)
+            }
+        })
+    }
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+    calls := 0
+    handler := NewIdempotency(cache.NewMemoryIdempotencyStore(1<<20), time.Hour).Middleware(
+        http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            calls++
+            w.Header().Set("Content-Type", "application/sarif+json")
+            w.Header().Set("Content-Disposition", `attachment; filename="report.sarif"`)
+            w.Header().Set("Deprecation", "true")
+            w.WriteHeader(http.StatusCreated)
+            w.Write([]byte(`{"runs":[]}`))
+        }))
+
+    send := func(body, accept, requestID string) *httptest.ResponseRecorder {
+        r := httptest.NewRequest(http.MethodPost, "/api/v1/validate", strings.NewReader(body))
+        r.Header.Set(IdempotencyKeyHeader, "key-1")
+        r.Header.Set("Accept", accept)
+        w := httptest.NewRecorder()
+        w.Header().Set("X-Request-ID", requestID)
+        handler.ServeHTTP(w, r)
+        return w
+    }
+
+    first := send(`{"content":"x"}`, "application/sarif+json", "req-1")
+    replayed := send(`{"content":"x"}`, "application/sarif+json", "req-2")
+    if calls != 1 {
+        t.Fatalf("handler called %d times, want 1", calls)
+    }
+    if replayed.Code != http.StatusCreated || replayed.Body.String() != first.Body.String() {
+        t.Fatalf("replay = %d %q, want %d %q", replayed.Code, replayed.Body.String(), first.Code, first.Body.String())
+    }
+    tests := []struct {
+        header string
+        want   string
+    }{
+        {IdempotentReplayHeader, "true"},
+        {"Content-Type", "application/sarif+json"},
+        {"Content-Disposition", `attachment; filename="report.sarif"`},
+        {"Deprecation", "true"},
+        {"X-Request-ID", "req-2"},
+    }
+    for _, tt := range tests {
+        if got := replayed.Header().Get(tt.header); got != tt.want {
+            t.Errorf("replayed %s = %q, want %q", tt.header, got, tt.want)
+        }
+    }
+
+    if mismatch := send(`{"content":"y"}`, "application/sarif+json", "req-3"); mismatch.Code != http.StatusUnprocessableEntity {
+        t.Errorf("other body status = %d, want %d", mismatch.Code, http.StatusUnprocessableEntity)
+    }
+    if mismatch := send(`{"content":"x"}`, "application/json", "req-4"); mismatch.Code != http.StatusUnprocessableEntity {
+        t.Errorf("other Accept status = %d, want %d", mismatch.Code, http.StatusUnprocessableEntity)
+    }
+}
+
+func TestIdempotencyReplayCompressed(t *testing.T) {
+    const body = `{"results":[]}`
+    handler := chimiddleware.Compress(5)(NewIdempotency(cache.NewMemoryIdempotencyStore(1<<20), time.Hour).Middleware(
+        http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            w.Header().Set("Content-Type", "application/json")
+            w.Write([]byte(body))
+        })))
+
+    for _, name := range []string{"first", "replayed"} {
+        r := httptest.NewRequest(http.MethodPost, "/api/v1/validate", strings.NewReader(`{"content":"x"}`))
+        r.Header.Set(IdempotencyKeyHeader, "key-1")
+        r.Header.Set("Accept-Encoding", "gzip")
+        w := httptest.NewRecorder()
+        handler.ServeHTTP(w, r)
+
+        if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+            t.Fatalf("%s Content-Encoding = %q, want gzip", name, got)
+        }
+        gz, err := gzip.NewReader(w.Body)
+        if err != nil {
+            t.Fatalf("%s response is not gzipped: %v", name, err)
+        }
+        decoded, err := io.ReadAll(gz)
+        if err != nil {
+            t.Fatalf("%s response: %v", name, err)
+        }
+        if string(decoded) != body {
+            t.Errorf("%s body = %q, want %q", name, decoded, body)
+        }
+    }
+}
@@ -0,0 +1,183 @@
+// Package middleware provides secure authentication and authorization middleware
+// for the validation service API endpoints.
+package middleware
+
+import (
+    "context"
+    "crypto/rsa"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "math/big"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+)
+
+// oidcDiscoveryPath is appended to an issuer URL to find its discovery
+// document
+const oidcDiscoveryPath = "/.well-known/openid-configuration"
+
+// jwksMinRefresh limits how often an unknown key ID triggers a refetch of a
+// key set
+const jwksMinRefresh = time.Minute
+
+// oidcDiscovery is the subset of the discovery document the service uses
+type oidcDiscovery struct {
+    Issuer  string `json:"issuer"`
+    JWKSURI string `json:"jwks_uri"`
+}
+
+// jsonWebKey is an RSA key of a JWK set
+type jsonWebKey struct {
+    Kty string `json:"kty"`
+    Kid string `json:"kid"`
+    Use string `json:"use"`
+    N   string `json:"n"`
+    E   string `json:"e"`
+}
+
+// jwkSet caches the RSA signing keys of a JWK set by key ID
+type jwkSet struct {
+    name            string
+    uri             string
+    client          *http.Client
+    refreshInterval time.Duration
+
+    mu        sync.RWMutex
+    keys      map[string]*rsa.PublicKey
+    fetchedAt time.Time
+}
+
+// newJWKSet fetches the key set at uri. name prefixes errors, as in "OIDC".
+func newJWKSet(ctx context.Context, name, uri string, client *http.Client, refreshInterval time.Duration) (*jwkSet, error) {
+    set := &jwkSet{name: name, uri: uri, client: client, refreshInterval: refreshInterval}
+    if err := set.refresh(ctx); err != nil {
+        return nil, err
+    }
+    return set, nil
+}
+
+// discoverJWKS reads the discovery document of issuer and returns the URI of
+// its key set
+func discoverJWKS(ctx context.Context, client *http.Client, issuer string) (string, error) {
+    var discovery oidcDiscovery
+    if err := getJSON(ctx, client, strings.TrimSuffix(issuer, "/")+oidcDiscoveryPath, &discovery); err != nil {
+        return "", fmt.Errorf("discovery failed: %w", err)
+    }
+    if discovery.Issuer != issuer {
+        return "", fmt.Errorf("discovery issuer %q does not match %q", discovery.Issuer, issuer)
+    }
+    if discovery.JWKSURI == "" {
+        return "", errors.New("discovery document has no jwks_uri")
+    }
+    return discovery.JWKSURI, nil
+}
+
+// key returns the key with ID kid. Keys are refetched when the cache is
+// older than the refresh interval or, at most once a minute, when kid is
+// unknown, so rotated keys are picked up without a restart.
+func (s *jwkSet) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+    s.mu.RLock()
+    key, ok := s.keys[kid]
+    age := time.Since(s.fetchedAt)
+    s.mu.RUnlock()
+
+    if (!ok && age > jwksMinRefresh) || age > s.refreshInterval {
+        if err := s.refresh(ctx); err != nil {
+            if ok {
+                // Keep using the cached key while the issuer is unavailable
+                return key, nil
+            }
+            return nil, err
+        }
+        s.mu.RLock()
+        key, ok = s.keys[kid]
+        s.mu.RUnlock()
+    }
+    if !ok {
+        return nil, fmt.Errorf("unknown %s signing key %q", s.name, kid)
+    }
+    return key, nil
+}
+
+// refresh fetches the key set
+func (s *jwkSet) refresh(ctx context.Context) error {
+    var set struct {
+        Keys []jsonWebKey `json:"keys"`
+    }
+    if err := getJSON(ctx, s.client, s.uri, &set); err != nil {
+        return fmt.Errorf("failed to fetch %s signing keys: %w", s.name, err)
+    }
+
+    keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+    for _, jwk := range set.Keys {
+        if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+            continue
+        }
+        key, err := jwk.rsaPublicKey()
+        if err != nil {
+            return fmt.Errorf("invalid %s signing key %q: %w", s.name, jwk.Kid, err)
+        }
+        keys[jwk.Kid] = key
+    }
+    if len(keys) == 0 {
+        return fmt.Errorf("%s key set has no RSA signing keys", s.name)
+    }
+
+    s.mu.Lock()
+    s.keys = keys
+    s.fetchedAt = time.Now()
+    s.mu.Unlock()
+    return nil
+}
+
+// rsaPublicKey decodes the modulus and exponent of the key
+func (k jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+    n, err := base64.RawURLEncoding.DecodeString(k.N)
+    if err != nil {
+        return nil, fmt.Errorf("invalid modulus: %w", err)
+    }
+    e, err := base64.RawURLEncoding.DecodeString(k.E)
+    if err != nil {
+        return nil, fmt.Errorf("invalid exponent: %w", err)
+    }
+    exponent := new(big.Int).SetBytes(e)
+    if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+        return nil, errors.New("unsupported exponent")
+    }
+    return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+// getJSON fetches and decodes a JSON document
+func getJSON(ctx context.Context, client *http.Client, url string, out interface{}) error {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Accept", "application/json")
+    resp, err := client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("GET %s: %s", url, resp.Status)
+    }
+    return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+// acceptsAudience reports whether any token audience is accepted
+func acceptsAudience(accepted, audiences []string) bool {
+    for _, audience := range audiences {
+        for _, a := range accepted {
+            if audience == a {
+                return true
+            }
+        }
+    }
+    return false
+}
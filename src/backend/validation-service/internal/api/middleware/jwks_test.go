@@ -0,0 +1,109 @@
+package middleware
+
+import (
+    "context"
+    "net/http"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5" // v5.0.0
+
+    "validation-service/internal/config"
+)
+
+func TestJWKSetRotation(t *testing.T) {
+    issuer := newTestIssuer(t)
+    ctx := context.Background()
+    keys, err := newJWKSet(ctx, "JWT", issuer.server.URL+"/keys", http.DefaultClient, time.Hour)
+    if err != nil {
+        t.Fatalf("newJWKSet() error = %v", err)
+    }
+    if _, err := keys.key(ctx, "key-1"); err != nil {
+        t.Fatalf("key(key-1) error = %v", err)
+    }
+
+    // An unknown key ID refetches the set at most once a minute
+    issuer.rotate(t, "key-2")
+    if _, err := keys.key(ctx, "key-2"); err == nil || issuer.keyRequests.Load() != 1 {
+        t.Fatalf("key(key-2) within a minute: error %v after %d fetches, want an error without refetching", err, issuer.keyRequests.Load())
+    }
+    keys.fetchedAt = time.Now().Add(-2 * jwksMinRefresh)
+    if _, err := keys.key(ctx, "key-2"); err != nil || issuer.keyRequests.Load() != 2 {
+        t.Fatalf("key(key-2) after a minute: error %v after %d fetches, want the rotated key", err, issuer.keyRequests.Load())
+    }
+
+    // Cached keys are kept while the issuer is unavailable
+    issuer.server.Close()
+    keys.fetchedAt = time.Now().Add(-2 * time.Hour)
+    if _, err := keys.key(ctx, "key-1"); err != nil {
+        t.Errorf("key(key-1) with the issuer down: error %v, want the cached key", err)
+    }
+    if _, err := keys.key(ctx, "key-3"); err == nil {
+        t.Error("key(key-3) with the issuer down returned a key")
+    }
+}
+
+func TestServiceTokenVerifier(t *testing.T) {
+    issuer := newTestIssuer(t)
+    _, err := NewServiceTokenVerifier(context.Background(), config.JWTConfig{
+        IssuerURL:          issuer.server.URL,
+        Audiences:          []string{"validation-api"},
+        KeyRefreshInterval: time.Hour,
+    }, http.DefaultClient)
+    if err != nil {
+        t.Fatalf("NewServiceTokenVerifier() error = %v", err)
+    }
+    t.Cleanup(func() { serviceTokens = nil })
+
+    valid := func() *Claims {
+        return &Claims{
+            UserId:         "svc-ci",
+            Role:           "engineer",
+            Permissions:    []string{"validate_detections"},
+            TokenIssueTime: time.Now(),
+            TenantID:       "acme",
+            RegisteredClaims: jwt.RegisteredClaims{
+                Issuer:    issuer.server.URL,
+                Audience:  jwt.ClaimStrings{"validation-api"},
+                ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+            },
+        }
+    }
+
+    tests := []struct {
+        name   string
+        modify func(c *Claims)
+        err    string
+    }{
+        {name: "valid"},
+        {name: "wrong audience", modify: func(c *Claims) { c.Audience = jwt.ClaimStrings{"other"} }, err: "audience"},
+        {name: "untrusted issuer", modify: func(c *Claims) { c.Issuer = "https://evil.example.com" }, err: "untrusted token issuer"},
+        {name: "unknown role", modify: func(c *Claims) { c.Role = "root" }, err: "invalid role"},
+        {name: "missing permission", modify: func(c *Claims) { c.Permissions = []string{"read"} }, err: "insufficient permissions"},
+        {name: "stale issue time", modify: func(c *Claims) { c.TokenIssueTime = time.Now().Add(-2 * time.Hour) }, err: "expired"},
+        {name: "expired", modify: func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) }, err: "expired"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            claims := valid()
+            if tt.modify != nil {
+                tt.modify(claims)
+            }
+            got, err := validateToken(context.Background(), issuer.sign(t, claims))
+            if tt.err != "" {
+                if err == nil || !strings.Contains(err.Error(), tt.err) {
+                    t.Fatalf("validateToken() error = %v, want %q", err, tt.err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("validateToken() error = %v", err)
+            }
+            if got.UserId != "svc-ci" || got.TenantID != "acme" {
+                t.Errorf("claims = %+v", got)
+            }
+        })
+    }
+}
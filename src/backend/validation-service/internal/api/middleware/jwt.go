@@ -0,0 +1,65 @@
+// Package middleware provides secure authentication and authorization middleware
+// for the validation service API endpoints.
+package middleware
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+
+    "validation-service/internal/config"
+)
+
+// serviceTokens verifies service tokens; nil until a ServiceTokenVerifier is
+// created, and service tokens are then rejected
+var serviceTokens *ServiceTokenVerifier
+
+// ServiceTokenVerifier holds the signing keys, issuer and audiences of
+// service tokens: tokens carrying the user_id, role and permissions claims,
+// issued by the platform's identity service
+type ServiceTokenVerifier struct {
+    cfg  config.JWTConfig
+    keys *jwkSet
+}
+
+// NewServiceTokenVerifier fetches the signing keys of the configured issuer,
+// from its JWK set URL or the jwks_uri of its discovery document. Tokens of
+// the issuer are then accepted by AuthMiddleware. Discovery and key requests
+// are sent with client.
+func NewServiceTokenVerifier(ctx context.Context, cfg config.JWTConfig, client *http.Client) (*ServiceTokenVerifier, error) {
+    jwksURI := cfg.JWKSURL
+    if jwksURI == "" {
+        var err error
+        if jwksURI, err = discoverJWKS(ctx, client, cfg.IssuerURL); err != nil {
+            return nil, fmt.Errorf("JWT issuer %w", err)
+        }
+    }
+    keys, err := newJWKSet(ctx, "JWT", jwksURI, client, cfg.KeyRefreshInterval)
+    if err != nil {
+        return nil, err
+    }
+    v := &ServiceTokenVerifier{cfg: cfg, keys: keys}
+    serviceTokens = v
+    return v, nil
+}
+
+// Issuer returns the issuer of the tokens the verifier accepts
+func (v *ServiceTokenVerifier) Issuer() string {
+    return v.cfg.IssuerURL
+}
+
+// key returns the signing key of a token of issuer with key ID kid
+func (v *ServiceTokenVerifier) key(ctx context.Context, issuer, kid string) (interface{}, error) {
+    if issuer != v.cfg.IssuerURL {
+        return nil, fmt.Errorf("untrusted token issuer %q", issuer)
+    }
+    return v.keys.key(ctx, kid)
+}
+
+// checkAudience checks the token was issued to a configured audience
+func (v *ServiceTokenVerifier) checkAudience(audiences []string) error {
+    if !acceptsAudience(v.cfg.Audiences, audiences) {
+        return fmt.Errorf("token audience %v not accepted", audiences)
+    }
+    return nil
+}
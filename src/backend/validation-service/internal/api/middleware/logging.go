@@ -4,6 +4,7 @@
 package middleware
 
 import (
+    "context"
     "net/http"
     "sync"
     "time"
@@ -14,6 +15,9 @@ import (
     "validation-service/pkg/metrics"
 )
 
+// tenantHeader carries the tenant ID forwarded by the API gateway
+const tenantHeader = "X-Tenant-ID"
+
 // responseWriterPool maintains a pool of custom response writers for performance optimization
 var responseWriterPool = sync.Pool{
     New: func() interface{} {
@@ -45,6 +49,12 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
     return rw.ResponseWriter.Write(b)
 }
 
+// Unwrap returns the original ResponseWriter, so http.ResponseController can
+// flush streamed responses and extend their deadlines
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+    return rw.ResponseWriter
+}
+
 // loggingHandler implements the core logging middleware functionality
 type loggingHandler struct {
     next http.Handler
@@ -57,8 +67,12 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 
 // ServeHTTP implements the http.Handler interface with comprehensive request tracking
 func (h *loggingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-    // Generate correlation ID
-    correlationID := uuid.New().String()
+    // Correlate log lines by the request ID set by RequestID, which honours
+    // IDs passed in by the API gateway
+    correlationID := RequestIDFromContext(r.Context())
+    if correlationID == "" {
+        correlationID = uuid.New().String()
+    }
 
     // Get response writer from pool
     rw := responseWriterPool.Get().(*responseWriter)
@@ -107,10 +121,13 @@ func (h *loggingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
         }
     }()
 
-    // Process request
-    h.next.ServeHTTP(rw, r.WithContext(
-        withCorrelationID(r.Context(), correlationID),
-    ))
+    // Process request with correlation fields available to logger.FromContext
+    ctx := withCorrelationID(r.Context(), correlationID)
+    ctx = logger.WithCorrelationID(ctx, correlationID)
+    if tenantID := r.Header.Get(tenantHeader); tenantID != "" {
+        ctx = logger.WithTenantID(ctx, tenantID)
+    }
+    h.next.ServeHTTP(rw, r.WithContext(ctx))
 
     // Calculate duration and record metrics
     duration := time.Since(startTime)
@@ -163,21 +180,3 @@ func withCorrelationID(ctx context.Context, correlationID string) context.Contex
 
 // correlationIDKey is the key type for correlation ID in context
 type correlationIDKey struct{}
-```
-
-This implementation provides a robust logging middleware that:
-
-1. Implements comprehensive request/response logging with correlation IDs
-2. Integrates with the metrics system for request counts, durations, and errors
-3. Uses a response writer pool for performance optimization
-4. Provides panic recovery with proper logging
-5. Includes detailed request context in logs
-6. Follows enterprise-grade logging practices with structured logging
-7. Implements proper cleanup and resource management
-8. Provides error classification for metrics
-9. Maintains compatibility with the ELK Stack logging format
-
-The middleware can be used in the HTTP server setup by wrapping handlers:
-
-```go
-handler = middleware.LoggingMiddleware(handler)
\ No newline at end of file
@@ -11,9 +11,9 @@ import (
     "validation-service/pkg/metrics" // v1.0.0 - Core metrics functionality
 )
 
-// responseWriterPool maintains a pool of response writer wrappers
+// statusWriterPool maintains a pool of response writer wrappers
 // to minimize memory allocations during request handling
-var responseWriterPool = sync.Pool{
+var statusWriterPool = sync.Pool{
     New: func() interface{} {
         return &statusResponseWriter{}
     },
@@ -43,6 +43,11 @@ func (w *statusResponseWriter) Write(b []byte) (int, error) {
     return w.ResponseWriter.Write(b)
 }
 
+// Unwrap returns the underlying ResponseWriter for http.ResponseController
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+    return w.ResponseWriter
+}
+
 // metricsHandler wraps the next handler with metrics collection capabilities
 type metricsHandler struct {
     next http.Handler
@@ -57,11 +62,11 @@ func MetricsMiddleware(next http.Handler) http.Handler {
 // ServeHTTP implements the http.Handler interface with comprehensive metrics collection
 func (h *metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
     // Get response writer wrapper from pool
-    sw := responseWriterPool.Get().(*statusResponseWriter)
+    sw := statusWriterPool.Get().(*statusResponseWriter)
     sw.ResponseWriter = w
     sw.wroteHeader = false
     sw.status = 0
-    defer responseWriterPool.Put(sw)
+    defer statusWriterPool.Put(sw)
 
     // Extract detection format from request
     // Default to "unknown" if format cannot be determined
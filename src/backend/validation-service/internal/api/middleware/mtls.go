@@ -0,0 +1,292 @@
+// Package middleware provides HTTP middleware components for the validation service API
+// with TLS and mutual TLS on the API listeners.
+package middleware
+
+import (
+    "context"
+    "crypto/tls"
+    "crypto/x509"
+    "errors"
+    "fmt"
+    "net/http"
+    "os"
+    "path"
+    "sync"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"          // v1.17.0
+    "github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+
+    "validation-service/internal/config"
+    "validation-service/pkg/logger"
+)
+
+// spiffeScheme is the URI scheme of SPIFFE IDs
+const spiffeScheme = "spiffe"
+
+// Reasons a client certificate is rejected
+const (
+    tlsReasonInvalid    = "invalid_certificate"
+    tlsReasonNotAllowed = "san_not_allowed"
+)
+
+var (
+    tlsReloads = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name:        "tls_certificate_reloads_total",
+        Help:        "Reloads of the TLS certificate, key and client CAs after their files changed, by outcome",
+        ConstLabels: prometheus.Labels{"service": "validation"},
+    }, []string{"outcome"})
+
+    tlsClientRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name:        "tls_client_rejections_total",
+        Help:        "Client certificates rejected during the TLS handshake by reason",
+        ConstLabels: prometheus.Labels{"service": "validation"},
+    }, []string{"reason"})
+)
+
+// spiffeIDKey is the context key of the SPIFFE ID of the client certificate
+type spiffeIDKey struct{}
+
+// ServerTLS provides the TLS configuration of the API and gRPC listeners.
+// Client certificates are verified against the client CAs and the SAN
+// allowlist. The certificate, key and client CAs are reloaded when their
+// files change; a reload that fails keeps the previous ones.
+type ServerTLS struct {
+    cfg config.TLSConfig
+
+    mu        sync.RWMutex
+    cert      *tls.Certificate
+    clientCAs *x509.CertPool
+    stamps    map[string]fileStamp
+}
+
+// fileStamp identifies a version of a file
+type fileStamp struct {
+    modTime time.Time
+    size    int64
+}
+
+// NewServerTLS loads the configured certificate, key and client CAs
+func NewServerTLS(cfg config.TLSConfig) (*ServerTLS, error) {
+    s := &ServerTLS{cfg: cfg}
+    stamps, err := s.stat()
+    if err != nil {
+        return nil, err
+    }
+    if err := s.load(stamps); err != nil {
+        return nil, err
+    }
+    return s, nil
+}
+
+// MutualTLS reports whether client certificates are verified
+func (s *ServerTLS) MutualTLS() bool {
+    return s.cfg.ClientCAFile != ""
+}
+
+// Config returns the TLS configuration of a listener. The certificate and
+// client CAs of every handshake are the latest loaded ones.
+func (s *ServerTLS) Config() *tls.Config {
+    cfg := &tls.Config{
+        MinVersion: tls.VersionTLS12,
+        GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+            s.mu.RLock()
+            defer s.mu.RUnlock()
+            return s.cert, nil
+        },
+    }
+    if s.MutualTLS() {
+        // Chains are verified by verifyClient rather than through ClientCAs,
+        // so rotated CAs apply to new handshakes
+        cfg.ClientAuth = tls.RequireAnyClientCert
+        if s.cfg.ClientAuth == config.TLSClientAuthOptional {
+            cfg.ClientAuth = tls.RequestClientCert
+        }
+        cfg.VerifyConnection = s.verifyClient
+    }
+    return cfg
+}
+
+// verifyClient verifies the client certificate chain against the client CAs
+// and the SAN allowlist. It is called for resumed sessions too.
+func (s *ServerTLS) verifyClient(state tls.ConnectionState) error {
+    if len(state.PeerCertificates) == 0 {
+        // Only reached in optional mode
+        return nil
+    }
+    s.mu.RLock()
+    roots := s.clientCAs
+    s.mu.RUnlock()
+
+    leaf := state.PeerCertificates[0]
+    intermediates := x509.NewCertPool()
+    for _, cert := range state.PeerCertificates[1:] {
+        intermediates.AddCert(cert)
+    }
+    if _, err := leaf.Verify(x509.VerifyOptions{
+        Roots:         roots,
+        Intermediates: intermediates,
+        KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+    }); err != nil {
+        tlsClientRejections.WithLabelValues(tlsReasonInvalid).Inc()
+        return fmt.Errorf("client certificate: %w", err)
+    }
+    if len(s.cfg.AllowedSANs) > 0 && !s.allowed(leaf) {
+        tlsClientRejections.WithLabelValues(tlsReasonNotAllowed).Inc()
+        logger.GetLogger().Warn("Client certificate rejected by SAN allowlist",
+            "subject", leaf.Subject.String(),
+            "dns_names", leaf.DNSNames,
+        )
+        return errors.New("client certificate SANs are not allowed")
+    }
+    return nil
+}
+
+// allowed reports whether a DNS name, URI or email SAN of cert matches an
+// allowlist pattern
+func (s *ServerTLS) allowed(cert *x509.Certificate) bool {
+    sans := append([]string(nil), cert.DNSNames...)
+    sans = append(sans, cert.EmailAddresses...)
+    for _, uri := range cert.URIs {
+        sans = append(sans, uri.String())
+    }
+    for _, pattern := range s.cfg.AllowedSANs {
+        for _, san := range sans {
+            if ok, _ := path.Match(pattern, san); ok {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// Run reloads the certificate, key and client CAs when their files change,
+// until ctx is done
+func (s *ServerTLS) Run(ctx context.Context) {
+    ticker := time.NewTicker(s.cfg.ReloadInterval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            s.reloadIfChanged()
+        }
+    }
+}
+
+// reloadIfChanged reloads the files when any of them changed. Rotation tools
+// may replace the certificate and key one after the other, so a pair that
+// does not match is retried at the next check.
+func (s *ServerTLS) reloadIfChanged() {
+    log := logger.GetLogger()
+    stamps, err := s.stat()
+    if err == nil {
+        s.mu.RLock()
+        unchanged := len(stamps) == len(s.stamps)
+        for file, stamp := range stamps {
+            unchanged = unchanged && s.stamps[file] == stamp
+        }
+        s.mu.RUnlock()
+        if unchanged {
+            return
+        }
+        err = s.load(stamps)
+    }
+    if err != nil {
+        tlsReloads.WithLabelValues("failed").Inc()
+        log.Warn("Failed to reload TLS certificates; serving the previous ones",
+            "error", err,
+        )
+        return
+    }
+    tlsReloads.WithLabelValues("reloaded").Inc()
+    log.Info("Reloaded TLS certificates",
+        "cert_file", s.cfg.CertFile,
+        "client_ca_file", s.cfg.ClientCAFile,
+    )
+}
+
+// stat returns the versions of the configured files
+func (s *ServerTLS) stat() (map[string]fileStamp, error) {
+    stamps := make(map[string]fileStamp)
+    for _, file := range []string{s.cfg.CertFile, s.cfg.KeyFile, s.cfg.ClientCAFile} {
+        if file == "" {
+            continue
+        }
+        info, err := os.Stat(file)
+        if err != nil {
+            return nil, err
+        }
+        stamps[file] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+    }
+    return stamps, nil
+}
+
+// load reads the certificate, key and client CAs and records the versions
+// they were read at
+func (s *ServerTLS) load(stamps map[string]fileStamp) error {
+    cert, err := tls.LoadX509KeyPair(s.cfg.CertFile, s.cfg.KeyFile)
+    if err != nil {
+        return fmt.Errorf("loading TLS certificate: %w", err)
+    }
+
+    var clientCAs *x509.CertPool
+    if s.cfg.ClientCAFile != "" {
+        pem, err := os.ReadFile(s.cfg.ClientCAFile)
+        if err != nil {
+            return fmt.Errorf("reading TLS client CAs: %w", err)
+        }
+        clientCAs = x509.NewCertPool()
+        if !clientCAs.AppendCertsFromPEM(pem) {
+            return fmt.Errorf("no certificates found in TLS client CA file %s", s.cfg.ClientCAFile)
+        }
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.cert = &cert
+    s.clientCAs = clientCAs
+    s.stamps = stamps
+    return nil
+}
+
+// PeerIdentity stores the SPIFFE ID of the client certificate in the request
+// context when SPIFFE extraction is enabled. It passes requests through when
+// s is nil.
+func (s *ServerTLS) PeerIdentity(next http.Handler) http.Handler {
+    if s == nil || !s.cfg.SPIFFE {
+        return next
+    }
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+            if id := spiffeID(r.TLS.PeerCertificates[0]); id != "" {
+                r = r.WithContext(context.WithValue(r.Context(), spiffeIDKey{}, id))
+            }
+        }
+        next.ServeHTTP(w, r)
+    })
+}
+
+// SPIFFEIDFromContext returns the SPIFFE ID of the client certificate of the
+// request
+func SPIFFEIDFromContext(ctx context.Context) (string, bool) {
+    id, ok := ctx.Value(spiffeIDKey{}).(string)
+    return id, ok
+}
+
+// spiffeID returns the SPIFFE ID of cert. An X.509 SVID has exactly one URI
+// SAN; certificates with several SPIFFE URIs have no ID.
+func spiffeID(cert *x509.Certificate) string {
+    var id string
+    for _, uri := range cert.URIs {
+        if uri.Scheme != spiffeScheme {
+            continue
+        }
+        if id != "" || uri.Host == "" {
+            return ""
+        }
+        id = uri.String()
+    }
+    return id
+}
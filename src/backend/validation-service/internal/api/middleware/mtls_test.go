@@ -0,0 +1,232 @@
+package middleware
+
+import (
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/tls"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "encoding/pem"
+    "io"
+    "log"
+    "math/big"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "validation-service/internal/config"
+)
+
+// testCA issues certificates for the mutual TLS tests
+type testCA struct {
+    cert   *x509.Certificate
+    key    *ecdsa.PrivateKey
+    serial int64
+}
+
+func newTestCA(t *testing.T) *testCA {
+    t.Helper()
+    ca := &testCA{}
+    ca.cert, ca.key = ca.issue(t, &x509.Certificate{
+        Subject:               pkix.Name{CommonName: "Test CA"},
+        IsCA:                  true,
+        BasicConstraintsValid: true,
+        KeyUsage:              x509.KeyUsageCertSign,
+    })
+    return ca
+}
+
+// issue signs template with the CA key, or self-signs it for the CA itself
+func (ca *testCA) issue(t *testing.T, template *x509.Certificate) (*x509.Certificate, *ecdsa.PrivateKey) {
+    t.Helper()
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        t.Fatal(err)
+    }
+    ca.serial++
+    template.SerialNumber = big.NewInt(ca.serial)
+    template.NotBefore = time.Now().Add(-time.Hour)
+    template.NotAfter = time.Now().Add(time.Hour)
+    parent, signer := template, key
+    if ca.cert != nil {
+        parent, signer = ca.cert, ca.key
+    }
+    der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+    if err != nil {
+        t.Fatal(err)
+    }
+    cert, err := x509.ParseCertificate(der)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return cert, key
+}
+
+// server issues a server certificate for 127.0.0.1
+func (ca *testCA) server(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+    return ca.issue(t, &x509.Certificate{
+        Subject:     pkix.Name{CommonName: "validation-service"},
+        IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+        ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+    })
+}
+
+// client issues a client certificate with the given DNS and URI SANs
+func (ca *testCA) client(t *testing.T, dnsName string, uris ...string) tls.Certificate {
+    t.Helper()
+    template := &x509.Certificate{
+        Subject:     pkix.Name{CommonName: "client"},
+        ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+    }
+    if dnsName != "" {
+        template.DNSNames = []string{dnsName}
+    }
+    for _, uri := range uris {
+        parsed, err := url.Parse(uri)
+        if err != nil {
+            t.Fatal(err)
+        }
+        template.URIs = append(template.URIs, parsed)
+    }
+    cert, key := ca.issue(t, template)
+    return tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key, Leaf: cert}
+}
+
+// writePEM writes a certificate and its key, or only a certificate when key
+// is nil
+func writePEM(t *testing.T, certFile, keyFile string, cert *x509.Certificate, key *ecdsa.PrivateKey) {
+    t.Helper()
+    if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    if key == nil {
+        return
+    }
+    der, err := x509.MarshalECPrivateKey(key)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+        t.Fatal(err)
+    }
+}
+
+// newTestServerTLS writes the server certificate and client CA of ca and
+// loads them
+func newTestServerTLS(t *testing.T, ca *testCA, cfg config.TLSConfig) *ServerTLS {
+    t.Helper()
+    dir := t.TempDir()
+    cfg.CertFile = filepath.Join(dir, "server.pem")
+    cfg.KeyFile = filepath.Join(dir, "server-key.pem")
+    cfg.ClientCAFile = filepath.Join(dir, "ca.pem")
+    cert, key := ca.server(t)
+    writePEM(t, cfg.CertFile, cfg.KeyFile, cert, key)
+    writePEM(t, cfg.ClientCAFile, "", ca.cert, nil)
+    s, err := NewServerTLS(cfg)
+    if err != nil {
+        t.Fatalf("NewServerTLS() error = %v", err)
+    }
+    return s
+}
+
+func TestServerTLSClientCertificates(t *testing.T) {
+    ca := newTestCA(t)
+    other := newTestCA(t)
+    const spiffe = "spiffe://prod.example.com/ns/payments/sa/api"
+
+    tests := []struct {
+        name       string
+        clientAuth string
+        cert       *tls.Certificate
+        ok         bool
+        spiffeID   string
+    }{
+        {name: "allowed DNS SAN", cert: ptr(ca.client(t, "api.payments.svc.cluster.local")), ok: true},
+        {name: "allowed SPIFFE ID", cert: ptr(ca.client(t, "", spiffe)), ok: true, spiffeID: spiffe},
+        {name: "SAN not allowed", cert: ptr(ca.client(t, "api.billing.svc.cluster.local"))},
+        {name: "untrusted issuer", cert: ptr(other.client(t, "api.payments.svc.cluster.local"))},
+        {name: "no certificate required", cert: nil},
+        {name: "no certificate optional", clientAuth: config.TLSClientAuthOptional, cert: nil, ok: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            s := newTestServerTLS(t, ca, config.TLSConfig{
+                ClientAuth:  tt.clientAuth,
+                AllowedSANs: []string{"*.payments.svc.cluster.local", "spiffe://prod.example.com/ns/payments/sa/*"},
+                SPIFFE:      true,
+            })
+            var spiffeID string
+            server := httptest.NewUnstartedServer(s.PeerIdentity(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                spiffeID, _ = SPIFFEIDFromContext(r.Context())
+            })))
+            // httptest.Server.StartTLS would serve its own certificate
+            server.Listener = tls.NewListener(server.Listener, s.Config())
+            server.Config.ErrorLog = log.New(io.Discard, "", 0)
+            server.Start()
+            defer server.Close()
+
+            roots := x509.NewCertPool()
+            roots.AddCert(ca.cert)
+            clientTLS := &tls.Config{RootCAs: roots}
+            if tt.cert != nil {
+                clientTLS.Certificates = []tls.Certificate{*tt.cert}
+            }
+            client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS}}
+            resp, err := client.Get("https://" + server.Listener.Addr().String())
+            if err == nil {
+                resp.Body.Close()
+            }
+            if (err == nil) != tt.ok {
+                t.Fatalf("request error = %v, want success %v", err, tt.ok)
+            }
+            if spiffeID != tt.spiffeID {
+                t.Errorf("SPIFFE ID = %q, want %q", spiffeID, tt.spiffeID)
+            }
+        })
+    }
+}
+
+func TestServerTLSReload(t *testing.T) {
+    ca := newTestCA(t)
+    s := newTestServerTLS(t, ca, config.TLSConfig{})
+    served := func() *big.Int {
+        cert, err := s.Config().GetCertificate(nil)
+        if err != nil {
+            t.Fatal(err)
+        }
+        leaf, err := x509.ParseCertificate(cert.Certificate[0])
+        if err != nil {
+            t.Fatal(err)
+        }
+        return leaf.SerialNumber
+    }
+    first := served()
+
+    // A certificate without its new key fails to load and keeps the old pair
+    cert, key := ca.server(t)
+    writePEM(t, s.cfg.CertFile, "", cert, nil)
+    future := time.Now().Add(time.Minute)
+    os.Chtimes(s.cfg.CertFile, future, future)
+    s.reloadIfChanged()
+    if served().Cmp(first) != 0 {
+        t.Fatal("mismatched certificate and key replaced the served pair")
+    }
+
+    writePEM(t, s.cfg.CertFile, s.cfg.KeyFile, cert, key)
+    os.Chtimes(s.cfg.KeyFile, future, future)
+    s.reloadIfChanged()
+    if got := served(); got.Cmp(cert.SerialNumber) != 0 {
+        t.Errorf("served serial %v after reload, want %v", got, cert.SerialNumber)
+    }
+}
+
+func ptr[T any](v T) *T {
+    return &v
+}
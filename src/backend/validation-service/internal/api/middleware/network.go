@@ -0,0 +1,187 @@
+// Package middleware provides HTTP middleware components for the validation service API
+// with source address restrictions per route group.
+package middleware
+
+import (
+    "context"
+    "fmt"
+    "net"
+    "net/http"
+    "strings"
+
+    "github.com/prometheus/client_golang/prometheus"          // v1.17.0
+    "github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+
+    "validation-service/internal/config"
+    "validation-service/pkg/logger"
+)
+
+// Reasons a request is rejected by a network policy
+const (
+    networkReasonDenied     = "denied"
+    networkReasonNotAllowed = "not_allowed"
+    networkReasonInvalid    = "invalid_address"
+)
+
+var networkRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name:        "network_policy_rejections_total",
+    Help:        "Requests rejected by a source address policy by route group and reason",
+    ConstLabels: prometheus.Labels{"service": "validation"},
+}, []string{"group", "reason"})
+
+// peerAddrKey stores the connection's peer address in the request context
+type peerAddrKey struct{}
+
+// PeerAddress records the connection's peer address before RealIP replaces
+// RemoteAddr with a client-supplied header. Network policies only trust
+// forwarding headers sent by a configured proxy.
+func PeerAddress(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), peerAddrKey{}, r.RemoteAddr)))
+    })
+}
+
+// NetworkPolicies holds the source address policy of each route group
+type NetworkPolicies struct {
+    groups  map[string]*networkPolicy
+    trusted []*net.IPNet
+}
+
+// networkPolicy is the parsed policy of one route group
+type networkPolicy struct {
+    group string
+    allow []*net.IPNet
+    deny  []*net.IPNet
+}
+
+// NewNetworkPolicies parses the configured policies
+func NewNetworkPolicies(cfg config.NetworkPolicyConfig) (*NetworkPolicies, error) {
+    trusted, err := parseNetworks(cfg.TrustedProxies)
+    if err != nil {
+        return nil, fmt.Errorf("trusted proxies: %w", err)
+    }
+    policies := &NetworkPolicies{groups: make(map[string]*networkPolicy), trusted: trusted}
+    for group, policy := range cfg.Groups {
+        allow, err := parseNetworks(policy.Allow)
+        if err != nil {
+            return nil, fmt.Errorf("network policy for %s: %w", group, err)
+        }
+        deny, err := parseNetworks(policy.Deny)
+        if err != nil {
+            return nil, fmt.Errorf("network policy for %s: %w", group, err)
+        }
+        if len(allow) > 0 || len(deny) > 0 {
+            policies.groups[group] = &networkPolicy{group: group, allow: allow, deny: deny}
+        }
+    }
+    return policies, nil
+}
+
+func parseNetworks(cidrs []string) ([]*net.IPNet, error) {
+    networks := make([]*net.IPNet, 0, len(cidrs))
+    for _, cidr := range cidrs {
+        network, err := config.ParseNetwork(cidr)
+        if err != nil {
+            return nil, err
+        }
+        networks = append(networks, network)
+    }
+    return networks, nil
+}
+
+// Middleware enforces the policy of group. Rejected requests are answered
+// with 403 Forbidden and written to the audit log. Groups without a policy,
+// and a nil NetworkPolicies, allow every address.
+func (p *NetworkPolicies) Middleware(group string) func(http.Handler) http.Handler {
+    var policy *networkPolicy
+    if p != nil {
+        policy = p.groups[group]
+    }
+    return func(next http.Handler) http.Handler {
+        if policy == nil {
+            return next
+        }
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            peer := peerAddr(r)
+            client := p.clientIP(r, peer)
+            if reason := policy.check(client); reason != "" {
+                networkRejections.WithLabelValues(group, reason).Inc()
+                // Audit log rejected attempts
+                logger.GetLogger().Warn("Request rejected by network policy",
+                    "route_group", group,
+                    "reason", reason,
+                    "client_ip", client.String(),
+                    "peer_addr", peer,
+                    "method", r.Method,
+                    "path", r.URL.Path,
+                    "user_agent", r.UserAgent(),
+                )
+                writeAuthError(w, http.StatusForbidden, "Access from this network is not allowed")
+                return
+            }
+            next.ServeHTTP(w, r)
+        })
+    }
+}
+
+// check returns why ip is rejected, or "" when it is allowed
+func (p *networkPolicy) check(ip net.IP) string {
+    if ip == nil {
+        return networkReasonInvalid
+    }
+    if containsIP(p.deny, ip) {
+        return networkReasonDenied
+    }
+    if len(p.allow) > 0 && !containsIP(p.allow, ip) {
+        return networkReasonNotAllowed
+    }
+    return ""
+}
+
+// clientIP returns the address of the client. The peer address is used
+// unless it is a trusted proxy, in which case X-Forwarded-For is walked from
+// the right, past any further trusted proxies.
+func (p *NetworkPolicies) clientIP(r *http.Request, peer string) net.IP {
+    ip := parseHostIP(peer)
+    if ip == nil || !containsIP(p.trusted, ip) {
+        return ip
+    }
+    hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+    for i := len(hops) - 1; i >= 0; i-- {
+        hop := net.ParseIP(strings.TrimSpace(hops[i]))
+        if hop == nil {
+            return ip
+        }
+        ip = hop
+        if !containsIP(p.trusted, hop) {
+            break
+        }
+    }
+    return ip
+}
+
+// peerAddr returns the connection's peer address recorded by PeerAddress,
+// falling back to RemoteAddr
+func peerAddr(r *http.Request) string {
+    if addr, ok := r.Context().Value(peerAddrKey{}).(string); ok {
+        return addr
+    }
+    return r.RemoteAddr
+}
+
+// parseHostIP parses the address of a "host:port" or bare host
+func parseHostIP(addr string) net.IP {
+    if host, _, err := net.SplitHostPort(addr); err == nil {
+        addr = host
+    }
+    return net.ParseIP(addr)
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+    for _, network := range networks {
+        if network.Contains(ip) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "validation-service/internal/config"
+)
+
+func TestNetworkPolicies(t *testing.T) {
+    policies, err := NewNetworkPolicies(config.NetworkPolicyConfig{
+        Groups: map[string]config.NetworkPolicy{
+            "admin":   {Allow: []string{"10.0.0.0/8", "192.168.1.10"}, Deny: []string{"10.66.0.0/16"}},
+            "metrics": {Deny: []string{"203.0.113.0/24"}},
+        },
+        TrustedProxies: []string{"172.16.0.0/12"},
+    })
+    if err != nil {
+        t.Fatalf("NewNetworkPolicies() error = %v", err)
+    }
+
+    tests := []struct {
+        name      string
+        group     string
+        peer      string
+        forwarded string
+        status    int
+    }{
+        {"allowed network", "admin", "10.1.2.3:4000", "", http.StatusOK},
+        {"allowed address", "admin", "192.168.1.10:4000", "", http.StatusOK},
+        {"not allowed", "admin", "192.168.1.11:4000", "", http.StatusForbidden},
+        {"deny wins over allow", "admin", "10.66.1.1:4000", "", http.StatusForbidden},
+        {"deny only", "metrics", "203.0.113.5:4000", "", http.StatusForbidden},
+        {"deny only allows others", "metrics", "198.51.100.1:4000", "", http.StatusOK},
+        {"no policy", "api", "203.0.113.5:4000", "", http.StatusOK},
+        {"forwarded by trusted proxy", "admin", "172.16.0.1:4000", "203.0.113.5, 10.1.2.3", http.StatusOK},
+        {"spoofed hop left of client", "admin", "172.16.0.1:4000", "10.1.2.3, 203.0.113.5", http.StatusForbidden},
+        {"chain of trusted proxies", "admin", "172.16.0.1:4000", "10.1.2.3, 172.20.0.1", http.StatusOK},
+        {"forwarded by untrusted peer", "admin", "198.51.100.1:4000", "10.1.2.3", http.StatusForbidden},
+        {"invalid forwarded hop", "admin", "172.16.0.1:4000", "garbage", http.StatusForbidden},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            handler := PeerAddress(policies.Middleware(tt.group)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})))
+            r := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+            r.RemoteAddr = tt.peer
+            if tt.forwarded != "" {
+                r.Header.Set("X-Forwarded-For", tt.forwarded)
+            }
+            rec := httptest.NewRecorder()
+            handler.ServeHTTP(rec, r)
+            if rec.Code != tt.status {
+                t.Errorf("status = %d, want %d", rec.Code, tt.status)
+            }
+        })
+    }
+}
+
+func TestNewNetworkPoliciesInvalid(t *testing.T) {
+    _, err := NewNetworkPolicies(config.NetworkPolicyConfig{
+        Groups: map[string]config.NetworkPolicy{"admin": {Allow: []string{"10.0.0.0/33"}}},
+    })
+    if err == nil {
+        t.Error("NewNetworkPolicies() accepted an invalid CIDR")
+    }
+}
@@ -0,0 +1,173 @@
+// Package middleware provides secure authentication and authorization middleware
+// for the validation service API endpoints.
+package middleware
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5" // v5.0.0
+
+    "validation-service/internal/config"
+)
+
+// roleRank orders roles from most to least privileged
+var roleRank = []string{"admin", "engineer", "analyst", "reader"}
+
+// oidcVerifier verifies tokens of the configured identity provider; nil
+// until an OIDCVerifier is created
+var oidcVerifier *OIDCVerifier
+
+// OIDCVerifier validates ID and access tokens issued by an OpenID Connect
+// identity provider and maps the user's IdP groups to a service role
+type OIDCVerifier struct {
+    cfg  config.OIDCConfig
+    keys *jwkSet
+}
+
+// NewOIDCVerifier discovers the provider's signing keys and returns a verifier
+// for its tokens. Tokens whose issuer is the provider are then accepted by
+// AuthMiddleware in place of service-issued tokens. Discovery and key
+// requests are sent with client.
+func NewOIDCVerifier(ctx context.Context, cfg config.OIDCConfig, client *http.Client) (*OIDCVerifier, error) {
+    for group, role := range cfg.GroupRoles {
+        if !allowedRoles[role] {
+            return nil, fmt.Errorf("OIDC group %q maps to unknown role %q", group, role)
+        }
+    }
+    if cfg.DefaultRole != "" && !allowedRoles[cfg.DefaultRole] {
+        return nil, fmt.Errorf("unknown OIDC default role %q", cfg.DefaultRole)
+    }
+
+    jwksURI, err := discoverJWKS(ctx, client, cfg.IssuerURL)
+    if err != nil {
+        return nil, fmt.Errorf("OIDC %w", err)
+    }
+    keys, err := newJWKSet(ctx, "OIDC", jwksURI, client, cfg.KeyRefreshInterval)
+    if err != nil {
+        return nil, err
+    }
+    v := &OIDCVerifier{cfg: cfg, keys: keys}
+    oidcVerifier = v
+    return v, nil
+}
+
+// Issuer returns the issuer of the tokens the verifier accepts
+func (v *OIDCVerifier) Issuer() string {
+    return v.cfg.IssuerURL
+}
+
+// Verify validates an IdP token and returns claims carrying the user's role.
+// The token must be signed by a provider key, unexpired, and issued to one of
+// the configured audiences.
+func (v *OIDCVerifier) Verify(ctx context.Context, tokenString string) (*Claims, error) {
+    token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+        kid, _ := token.Header["kid"].(string)
+        return v.keys.key(ctx, kid)
+    },
+        jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+        jwt.WithIssuer(v.cfg.IssuerURL),
+    )
+    if err != nil {
+        return nil, fmt.Errorf("failed to parse OIDC token: %w", err)
+    }
+    mapClaims, ok := token.Claims.(jwt.MapClaims)
+    if !ok || !token.Valid {
+        return nil, errors.New("invalid OIDC token claims")
+    }
+
+    audiences, err := mapClaims.GetAudience()
+    if err != nil || !acceptsAudience(v.cfg.Audiences, audiences) {
+        return nil, errors.New("OIDC token audience not accepted")
+    }
+
+    userID, _ := mapClaims[v.cfg.UserClaim].(string)
+    if userID == "" {
+        return nil, fmt.Errorf("OIDC token has no %s claim", v.cfg.UserClaim)
+    }
+    role := v.role(stringsClaim(mapClaims[v.cfg.GroupsClaim]))
+    if role == "" {
+        return nil, errors.New("OIDC user is in no group mapped to a role")
+    }
+
+    expiresAt, _ := mapClaims.GetExpirationTime()
+    if expiresAt == nil {
+        return nil, errors.New("OIDC token has no expiry")
+    }
+    subject, _ := mapClaims.GetSubject()
+    issuedAt, _ := mapClaims.GetIssuedAt()
+    tenantID, _ := mapClaims[v.cfg.TenantClaim].(string)
+    claims := &Claims{
+        UserId:      userID,
+        Role:        role,
+        Permissions: append([]string(nil), requiredPermissions...),
+        TenantID:    tenantID,
+        RegisteredClaims: jwt.RegisteredClaims{
+            Issuer:    v.cfg.IssuerURL,
+            Subject:   subject,
+            Audience:  audiences,
+            ExpiresAt: expiresAt,
+            IssuedAt:  issuedAt,
+        },
+    }
+    claims.ID, _ = mapClaims["jti"].(string)
+    claims.TokenIssueTime = time.Now()
+    if issuedAt != nil {
+        claims.TokenIssueTime = issuedAt.Time
+    }
+
+    if err := claims.Validate(); err != nil {
+        return nil, fmt.Errorf("claims validation failed: %w", err)
+    }
+    return claims, nil
+}
+
+// role returns the most privileged role mapped from groups, or the default
+// role when no group is mapped
+func (v *OIDCVerifier) role(groups []string) string {
+    mapped := make(map[string]bool)
+    for _, group := range groups {
+        if role, ok := v.cfg.GroupRoles[group]; ok {
+            mapped[role] = true
+        }
+    }
+    for _, role := range roleRank {
+        if mapped[role] {
+            return role
+        }
+    }
+    return v.cfg.DefaultRole
+}
+
+// stringsClaim reads a claim holding a list of strings. Some providers send a
+// single group as a string.
+func stringsClaim(value interface{}) []string {
+    switch value := value.(type) {
+    case string:
+        return []string{value}
+    case []interface{}:
+        values := make([]string, 0, len(value))
+        for _, v := range value {
+            if s, ok := v.(string); ok {
+                values = append(values, s)
+            }
+        }
+        return values
+    default:
+        return nil
+    }
+}
+
+// unverifiedIssuer returns the "iss" claim of a token without verifying it,
+// to select the key it must be verified with
+func unverifiedIssuer(tokenString string) string {
+    token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+    if err != nil {
+        return ""
+    }
+    issuer, _ := token.Claims.GetIssuer()
+    return issuer
+}
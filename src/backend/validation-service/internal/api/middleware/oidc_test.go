@@ -0,0 +1,200 @@
+package middleware
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/rsa"
+    "encoding/base64"
+    "encoding/json"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5" // v5.0.0
+
+    "validation-service/internal/config"
+)
+
+// testIssuer serves an OpenID Connect discovery document and JWK set and
+// signs tokens with its current key
+type testIssuer struct {
+    server *httptest.Server
+    keys   map[string]*rsa.PrivateKey
+    kid    string
+    // keyRequests counts requests of the JWK set
+    keyRequests atomic.Int32
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+    t.Helper()
+    issuer := &testIssuer{keys: make(map[string]*rsa.PrivateKey)}
+    issuer.rotate(t, "key-1")
+    mux := http.NewServeMux()
+    mux.HandleFunc(oidcDiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+        json.NewEncoder(w).Encode(map[string]string{
+            "issuer":   issuer.server.URL,
+            "jwks_uri": issuer.server.URL + "/keys",
+        })
+    })
+    mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+        issuer.keyRequests.Add(1)
+        var keys []map[string]string
+        for kid, key := range issuer.keys {
+            keys = append(keys, map[string]string{
+                "kty": "RSA",
+                "kid": kid,
+                "use": "sig",
+                "n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+                "e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+            })
+        }
+        json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+    })
+    issuer.server = httptest.NewServer(mux)
+    t.Cleanup(issuer.server.Close)
+    return issuer
+}
+
+// rotate adds a key and signs further tokens with it
+func (i *testIssuer) rotate(t *testing.T, kid string) {
+    t.Helper()
+    key, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatal(err)
+    }
+    i.keys[kid] = key
+    i.kid = kid
+}
+
+// sign returns a token with claims signed by the current key
+func (i *testIssuer) sign(t *testing.T, claims jwt.Claims) string {
+    t.Helper()
+    token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+    token.Header["kid"] = i.kid
+    signed, err := token.SignedString(i.keys[i.kid])
+    if err != nil {
+        t.Fatal(err)
+    }
+    return signed
+}
+
+func TestOIDCVerifier(t *testing.T) {
+    issuer := newTestIssuer(t)
+    cfg := config.OIDCConfig{
+        IssuerURL:          issuer.server.URL,
+        Audiences:          []string{"validation-api"},
+        UserClaim:          "email",
+        GroupsClaim:        "groups",
+        TenantClaim:        "tenant_id",
+        GroupRoles:         map[string]string{"secops": "engineer", "soc-admins": "admin", "soc": "analyst"},
+        KeyRefreshInterval: time.Hour,
+    }
+    v, err := NewOIDCVerifier(context.Background(), cfg, http.DefaultClient)
+    if err != nil {
+        t.Fatalf("NewOIDCVerifier() error = %v", err)
+    }
+    cfg.DefaultRole = "reader"
+    withDefault, err := NewOIDCVerifier(context.Background(), cfg, http.DefaultClient)
+    if err != nil {
+        t.Fatalf("NewOIDCVerifier() error = %v", err)
+    }
+
+    now := time.Now()
+    valid := func() jwt.MapClaims {
+        return jwt.MapClaims{
+            "iss":       issuer.server.URL,
+            "aud":       "validation-api",
+            "sub":       "00u1",
+            "email":     "analyst@example.com",
+            "groups":    []string{"soc", "soc-admins"},
+            "tenant_id": "acme",
+            "iat":       now.Unix(),
+            "exp":       now.Add(time.Hour).Unix(),
+        }
+    }
+    with := func(key string, value interface{}) jwt.MapClaims {
+        claims := valid()
+        if value == nil {
+            delete(claims, key)
+        } else {
+            claims[key] = value
+        }
+        return claims
+    }
+
+    tests := []struct {
+        name     string
+        verifier *OIDCVerifier
+        claims   jwt.MapClaims
+        role     string
+        err      string
+    }{
+        {name: "most privileged group", claims: valid(), role: "admin"},
+        {name: "single group as string", claims: with("groups", "secops"), role: "engineer"},
+        {name: "audience list", claims: with("aud", []string{"other", "validation-api"}), role: "admin"},
+        {name: "unmapped group", claims: with("groups", []string{"finance"}), err: "no group mapped to a role"},
+        {name: "default role", verifier: withDefault, claims: with("groups", nil), role: "reader"},
+        {name: "wrong audience", claims: with("aud", "other"), err: "audience not accepted"},
+        {name: "wrong issuer", claims: with("iss", "https://evil.example.com"), err: "failed to parse OIDC token"},
+        {name: "expired", claims: with("exp", now.Add(-time.Minute).Unix()), err: "failed to parse OIDC token"},
+        {name: "no expiry", claims: with("exp", nil), err: "no expiry"},
+        {name: "no user claim", claims: with("email", nil), err: "no email claim"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            verifier := v
+            if tt.verifier != nil {
+                verifier = tt.verifier
+            }
+            claims, err := verifier.Verify(context.Background(), issuer.sign(t, tt.claims))
+            if tt.err != "" {
+                if err == nil || !strings.Contains(err.Error(), tt.err) {
+                    t.Fatalf("Verify() error = %v, want %q", err, tt.err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("Verify() error = %v", err)
+            }
+            if claims.Role != tt.role || claims.UserId != "analyst@example.com" || claims.Subject != "00u1" {
+                t.Errorf("claims = %+v, want role %s", claims, tt.role)
+            }
+            if claims.TenantID != "acme" {
+                t.Errorf("tenant = %q, want acme", claims.TenantID)
+            }
+        })
+    }
+
+    // Tokens signed with a key outside the provider's key set are rejected
+    other := newTestIssuer(t)
+    if _, err := v.Verify(context.Background(), other.sign(t, valid())); err == nil {
+        t.Error("Verify() accepted a token signed with another key")
+    }
+}
+
+func TestNewOIDCVerifierInvalidConfig(t *testing.T) {
+    issuer := newTestIssuer(t)
+    tests := []struct {
+        name string
+        cfg  config.OIDCConfig
+        err  string
+    }{
+        {"unknown group role", config.OIDCConfig{IssuerURL: issuer.server.URL, GroupRoles: map[string]string{"soc": "root"}}, "unknown role"},
+        {"unknown default role", config.OIDCConfig{IssuerURL: issuer.server.URL, DefaultRole: "root"}, "unknown OIDC default role"},
+        {"issuer mismatch", config.OIDCConfig{IssuerURL: issuer.server.URL + "/"}, "does not match"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := NewOIDCVerifier(context.Background(), tt.cfg, http.DefaultClient)
+            if err == nil || !strings.Contains(err.Error(), tt.err) {
+                t.Errorf("NewOIDCVerifier() error = %v, want %q", err, tt.err)
+            }
+        })
+    }
+}
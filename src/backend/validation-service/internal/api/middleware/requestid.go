@@ -0,0 +1,96 @@
+// Package middleware provides HTTP middleware components for the validation service
+package middleware
+
+import (
+    "context"
+    "net/http"
+
+    chimiddleware "github.com/go-chi/chi/v5/middleware"
+    "github.com/google/uuid" // v1.4.0
+    "go.opentelemetry.io/otel/propagation"
+    "go.opentelemetry.io/otel/trace"
+)
+
+// Correlation headers. Requests may carry a request ID and a W3C traceparent
+// header; responses always carry the request ID and, when the request is
+// part of a trace, the trace ID.
+const (
+    RequestIDHeader   = "X-Request-ID"
+    TraceParentHeader = "traceparent"
+    TraceIDHeader     = "X-Trace-ID"
+)
+
+// maxRequestIDLength bounds accepted inbound request IDs
+const maxRequestIDLength = 128
+
+// traceIDKey is the context key of the trace ID of an incoming traceparent
+type traceIDKey struct{}
+
+// RequestID assigns every request its correlation ID: the inbound
+// X-Request-ID when it is well-formed, otherwise the trace ID of an inbound
+// traceparent header, otherwise a new UUID. The ID is stored where
+// chimiddleware.GetReqID finds it and echoed on the response.
+func RequestID(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        parent := trace.SpanContextFromContext(
+            propagation.TraceContext{}.Extract(r.Context(), propagation.HeaderCarrier(r.Header)))
+        id := ResolveRequestID(r.Header.Get(RequestIDHeader), parent)
+
+        ctx := WithRequestID(r.Context(), id)
+        w.Header().Set(RequestIDHeader, id)
+        if parent.HasTraceID() {
+            traceID := parent.TraceID().String()
+            ctx = context.WithValue(ctx, traceIDKey{}, traceID)
+            w.Header().Set(TraceIDHeader, traceID)
+        }
+        next.ServeHTTP(w, r.WithContext(ctx))
+    })
+}
+
+// ResolveRequestID returns inbound when it is a usable request ID, the trace
+// ID of parent when it has one, and a new UUID otherwise
+func ResolveRequestID(inbound string, parent trace.SpanContext) string {
+    if validRequestID(inbound) {
+        return inbound
+    }
+    if parent.HasTraceID() {
+        return parent.TraceID().String()
+    }
+    return uuid.NewString()
+}
+
+// WithRequestID returns a context carrying the request ID id
+func WithRequestID(ctx context.Context, id string) context.Context {
+    return context.WithValue(ctx, chimiddleware.RequestIDKey, id)
+}
+
+// RequestIDFromContext returns the request ID of ctx, empty when not set
+func RequestIDFromContext(ctx context.Context) string {
+    return chimiddleware.GetReqID(ctx)
+}
+
+// TraceIDFromContext returns the ID of the trace of ctx: that of the current
+// span, or that of the inbound traceparent header when the request is not
+// traced by this service. It is empty when the request is not part of a
+// trace.
+func TraceIDFromContext(ctx context.Context) string {
+    if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+        return spanContext.TraceID().String()
+    }
+    traceID, _ := ctx.Value(traceIDKey{}).(string)
+    return traceID
+}
+
+// validRequestID accepts IDs of printable ASCII without spaces, so gateway
+// IDs can be logged and echoed in headers safely
+func validRequestID(id string) bool {
+    if id == "" || len(id) > maxRequestIDLength {
+        return false
+    }
+    for i := 0; i < len(id); i++ {
+        if id[i] <= ' ' || id[i] > '~' {
+            return false
+        }
+    }
+    return true
+}
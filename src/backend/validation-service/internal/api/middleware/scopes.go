@@ -0,0 +1,159 @@
+// Package middleware provides secure authentication and authorization middleware
+// for the validation service API endpoints.
+package middleware
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "regexp"
+    "sort"
+    "strings"
+
+    "validation-service/pkg/logger"
+)
+
+// Scopes granted to API tokens. A scope is "<resource>:<action>".
+const (
+    ScopeValidateRead   = "validate:read"
+    ScopeResultsRead    = "results:read"
+    ScopeRulesRead      = "rules:read"
+    ScopeRulesWrite     = "rules:write"
+    ScopeJobsCreate     = "jobs:create"
+    ScopeTokensExchange = "tokens:exchange"
+    ScopeNotifications  = "notifications:write"
+    ScopeAdmin          = "admin:all"
+)
+
+// scopePattern restricts scope names to "<resource>:<action>"
+var scopePattern = regexp.MustCompile(`^[a-z][a-z_]*:[a-z][a-z_]*$`)
+
+// roleScopes are the scopes each role may hold. Tokens without a scope claim
+// are granted all scopes of their role; tokens with one are limited to the
+// listed scopes their role allows.
+var roleScopes = map[string][]string{
+    "admin": {
+        ScopeValidateRead, ScopeResultsRead, ScopeRulesRead, ScopeRulesWrite,
+        ScopeJobsCreate, ScopeTokensExchange, ScopeNotifications, ScopeAdmin,
+    },
+    "engineer": {
+        ScopeValidateRead, ScopeResultsRead, ScopeRulesRead, ScopeRulesWrite,
+        ScopeJobsCreate, ScopeTokensExchange, ScopeNotifications,
+    },
+    "analyst": {ScopeValidateRead, ScopeResultsRead, ScopeRulesRead, ScopeJobsCreate, ScopeNotifications},
+    "reader":  {ScopeValidateRead, ScopeResultsRead, ScopeRulesRead, ScopeNotifications},
+}
+
+// claimsContextKey stores the authenticated claims in the request context
+type claimsContextKey struct{}
+
+// WithClaims returns ctx carrying the claims of the authenticated caller
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+    return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims of the authenticated caller
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+    claims, ok := ctx.Value(claimsContextKey{}).(*Claims)
+    return claims, ok
+}
+
+// ParseScopes splits a space-delimited scope claim, dropping duplicates
+func ParseScopes(scope string) []string {
+    seen := make(map[string]bool)
+    scopes := make([]string, 0)
+    for _, s := range strings.Fields(scope) {
+        if !seen[s] {
+            seen[s] = true
+            scopes = append(scopes, s)
+        }
+    }
+    return scopes
+}
+
+// validScope reports whether scope is well-formed
+func validScope(scope string) bool {
+    return scopePattern.MatchString(scope)
+}
+
+// Scopes returns the effective scopes of the token, sorted
+func (c Claims) Scopes() []string {
+    allowed := make(map[string]bool)
+    for _, s := range roleScopes[c.Role] {
+        allowed[s] = true
+    }
+    if c.Scope == "" {
+        scopes := make([]string, 0, len(allowed))
+        for s := range allowed {
+            scopes = append(scopes, s)
+        }
+        sort.Strings(scopes)
+        return scopes
+    }
+
+    scopes := make([]string, 0)
+    for _, s := range ParseScopes(c.Scope) {
+        if allowed[s] {
+            scopes = append(scopes, s)
+        }
+    }
+    sort.Strings(scopes)
+    return scopes
+}
+
+// HasScope reports whether the token grants scope
+func (c Claims) HasScope(scope string) bool {
+    for _, s := range c.Scopes() {
+        if s == scope {
+            return true
+        }
+    }
+    return false
+}
+
+// RequireScope rejects requests whose token does not grant scope with 403
+// Forbidden. It must run after AuthMiddleware.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            claims, ok := ClaimsFromContext(r.Context())
+            if !ok {
+                writeAuthError(w, http.StatusUnauthorized, "Invalid authentication token")
+                return
+            }
+            if !claims.HasScope(scope) {
+                logger.GetLogger().Warn("Insufficient token scope",
+                    "user_id", claims.UserId,
+                    "required_scope", scope,
+                    "path", r.URL.Path,
+                )
+                writeAuthError(w, http.StatusForbidden, "Token lacks required scope: "+scope)
+                return
+            }
+            next.ServeHTTP(w, r)
+        })
+    }
+}
+
+// RequireReadWriteScope requires read for GET and HEAD requests and write for
+// every other method
+func RequireReadWriteScope(read, write string) func(http.Handler) http.Handler {
+    requireRead, requireWrite := RequireScope(read), RequireScope(write)
+    return func(next http.Handler) http.Handler {
+        readHandler, writeHandler := requireRead(next), requireWrite(next)
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            if r.Method == http.MethodGet || r.Method == http.MethodHead {
+                readHandler.ServeHTTP(w, r)
+                return
+            }
+            writeHandler.ServeHTTP(w, r)
+        })
+    }
+}
+
+// writeAuthError writes an authentication or authorization failure
+func writeAuthError(w http.ResponseWriter, status int, message string) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    _ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
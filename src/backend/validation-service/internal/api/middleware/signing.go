@@ -0,0 +1,252 @@
+// Package middleware provides HTTP middleware components for the validation service API
+// with HMAC request signing and replay protection.
+package middleware
+
+import (
+    "bytes"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "os"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"          // v1.17.0
+    "github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+
+    "validation-service/internal/cache"
+    "validation-service/internal/config"
+    "validation-service/pkg/logger"
+)
+
+// Headers of a signed request
+const (
+    SignatureHeader          = "X-Signature"
+    SignatureKeyHeader       = "X-Signature-Key"
+    SignatureTimestampHeader = "X-Signature-Timestamp"
+    SignatureNonceHeader     = "X-Signature-Nonce"
+)
+
+// signaturePrefix precedes the hex HMAC-SHA256 in the X-Signature header
+const signaturePrefix = "sha256="
+
+// maxSignedBodySize bounds the body read to compute its digest
+const maxSignedBodySize = 10 << 20
+
+// Nonces must be long enough to be unique and short enough to be cheap to
+// store
+const (
+    minNonceLength = 16
+    maxNonceLength = 128
+)
+
+// Reasons a signed request is rejected
+const (
+    signingReasonMalformed = "malformed"
+    signingReasonUnknown   = "unknown_key"
+    signingReasonSkew      = "clock_skew"
+    signingReasonSignature = "bad_signature"
+    signingReasonReplay    = "replay"
+    signingReasonCache     = "replay_cache_error"
+)
+
+var signedRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name:        "signed_request_rejections_total",
+    Help:        "Signed requests rejected by reason",
+    ConstLabels: prometheus.Labels{"service": "validation"},
+}, []string{"reason"})
+
+// SignedRequests verifies HMAC-signed requests. The signature covers the
+// timestamp, nonce, method, request URI and body digest:
+//
+//	sha256=hex(HMAC-SHA256(secret, timestamp + "\n" + nonce + "\n" + method +
+//	    "\n" + request URI + "\n" + hex(SHA-256(body))))
+//
+// Requests whose timestamp is outside the clock skew tolerance, or whose
+// nonce was already seen within twice the tolerance, are rejected, so a
+// captured request cannot be replayed.
+type SignedRequests struct {
+    keys   map[string]signingKey
+    skew   time.Duration
+    nonces cache.NonceStore
+    now    func() time.Time
+}
+
+// signingKey is a loaded shared secret
+type signingKey struct {
+    secret []byte
+    scope  string
+}
+
+// NewSignedRequests loads the configured secrets. Nonces are remembered in
+// nonces.
+func NewSignedRequests(cfg config.RequestSigningConfig, nonces cache.NonceStore) (*SignedRequests, error) {
+    s := &SignedRequests{
+        keys:   make(map[string]signingKey, len(cfg.Keys)),
+        skew:   cfg.MaxClockSkew,
+        nonces: nonces,
+        now:    time.Now,
+    }
+    for id, key := range cfg.Keys {
+        for _, scope := range ParseScopes(key.Scope) {
+            if !validScope(scope) {
+                return nil, fmt.Errorf("request signing key %s: invalid scope: %s", id, scope)
+            }
+        }
+        data, err := os.ReadFile(key.SecretFile)
+        if err != nil {
+            return nil, fmt.Errorf("request signing key %s: %w", id, err)
+        }
+        secret := bytes.TrimSpace(data)
+        if len(secret) < 32 {
+            return nil, fmt.Errorf("request signing key %s: secret must be at least 32 bytes", id)
+        }
+        s.keys[id] = signingKey{secret: secret, scope: key.Scope}
+    }
+    return s, nil
+}
+
+// OrBearer authenticates requests carrying an X-Signature header by their
+// signature and every other request with bearer. A nil SignedRequests
+// always uses bearer.
+func (s *SignedRequests) OrBearer(bearer func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+    if s == nil {
+        return bearer
+    }
+    return func(next http.Handler) http.Handler {
+        withBearer := bearer(next)
+        withSignature := s.Middleware(next)
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            if r.Header.Get(SignatureHeader) != "" {
+                withSignature.ServeHTTP(w, r)
+                return
+            }
+            withBearer.ServeHTTP(w, r)
+        })
+    }
+}
+
+// Middleware requires a valid signature. The caller is authenticated as
+// "hmac:<key ID>" with the scope of the key. Rejected requests are answered
+// with 401 Unauthorized and written to the audit log.
+func (s *SignedRequests) Middleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        keyID := r.Header.Get(SignatureKeyHeader)
+        claims, reason, err := s.verify(r)
+        if err != nil {
+            signedRejections.WithLabelValues(reason).Inc()
+            // Audit log rejected attempts
+            logger.GetLogger().Warn("Signed request rejected",
+                "reason", reason,
+                "error", err,
+                "key_id", keyID,
+                "ip", r.RemoteAddr,
+                "method", r.Method,
+                "path", r.URL.Path,
+            )
+            status := http.StatusUnauthorized
+            if reason == signingReasonCache {
+                status = http.StatusServiceUnavailable
+            }
+            writeAuthError(w, status, "Invalid request signature")
+            return
+        }
+
+        logger.GetLogger().Info("Successful authentication",
+            "user_id", claims.UserId,
+            "role", claims.Role,
+            "scopes", claims.Scopes(),
+            "ip", r.RemoteAddr,
+        )
+        next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+    })
+}
+
+// verify checks the signature of r and returns the caller's claims, or the
+// reason it is rejected. The body is restored for the next handler.
+func (s *SignedRequests) verify(r *http.Request) (*Claims, string, error) {
+    keyID := r.Header.Get(SignatureKeyHeader)
+    nonce := r.Header.Get(SignatureNonceHeader)
+    signature := r.Header.Get(SignatureHeader)
+    if keyID == "" || !strings.HasPrefix(signature, signaturePrefix) {
+        return nil, signingReasonMalformed, errors.New("missing signature key or signature")
+    }
+    if len(nonce) < minNonceLength || len(nonce) > maxNonceLength {
+        return nil, signingReasonMalformed, fmt.Errorf("nonce must be %d to %d characters", minNonceLength, maxNonceLength)
+    }
+    key, ok := s.keys[keyID]
+    if !ok {
+        return nil, signingReasonUnknown, fmt.Errorf("unknown signature key: %s", keyID)
+    }
+
+    timestamp := r.Header.Get(SignatureTimestampHeader)
+    seconds, err := strconv.ParseInt(timestamp, 10, 64)
+    if err != nil {
+        return nil, signingReasonMalformed, fmt.Errorf("invalid timestamp: %q", timestamp)
+    }
+    if skew := s.now().Sub(time.Unix(seconds, 0)); skew > s.skew || skew < -s.skew {
+        return nil, signingReasonSkew, fmt.Errorf("timestamp is %v from the service clock", skew.Round(time.Second))
+    }
+
+    digest, err := bodyDigest(r)
+    if err != nil {
+        return nil, signingReasonMalformed, err
+    }
+    expected := Sign(key.secret, timestamp, nonce, r.Method, r.URL.RequestURI(), digest)
+    if !hmac.Equal([]byte(signature), []byte(expected)) {
+        return nil, signingReasonSignature, errors.New("signature mismatch")
+    }
+
+    // Only remember nonces of authentic requests, so forged requests cannot
+    // fill the replay cache. Nonces outlive the window in which their
+    // timestamp is accepted.
+    fresh, err := s.nonces.Remember(r.Context(), keyID+":"+nonce, 2*s.skew)
+    if err != nil {
+        return nil, signingReasonCache, err
+    }
+    if !fresh {
+        return nil, signingReasonReplay, errors.New("nonce already used")
+    }
+
+    claims := &Claims{
+        UserId:         "hmac:" + keyID,
+        Role:           "admin",
+        Permissions:    append([]string(nil), requiredPermissions...),
+        TokenIssueTime: time.Unix(seconds, 0),
+        Scope:          key.scope,
+    }
+    return claims, "", nil
+}
+
+// bodyDigest returns the hex SHA-256 of the request body and restores the
+// body for the next handler
+func bodyDigest(r *http.Request) (string, error) {
+    var body []byte
+    if r.Body != nil {
+        data, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodySize+1))
+        r.Body.Close()
+        if err != nil {
+            return "", fmt.Errorf("failed to read body: %w", err)
+        }
+        if len(data) > maxSignedBodySize {
+            return "", errors.New("signed body exceeds maximum size")
+        }
+        body = data
+    }
+    r.Body = io.NopCloser(bytes.NewReader(body))
+    sum := sha256.Sum256(body)
+    return hex.EncodeToString(sum[:]), nil
+}
+
+// Sign returns the X-Signature header value of a request. Integrations and
+// tests use it to sign requests.
+func Sign(secret []byte, timestamp, nonce, method, requestURI, bodyDigest string) string {
+    mac := hmac.New(sha256.New, secret)
+    mac.Write([]byte(strings.Join([]string{timestamp, nonce, method, requestURI, bodyDigest}, "\n")))
+    return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,175 @@
+package middleware
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+
+    "validation-service/internal/cache"
+    "validation-service/internal/config"
+)
+
+const testSigningSecret = "0123456789abcdef0123456789abcdef"
+
+// newTestSignedRequests creates a verifier for key "ci" with a fixed clock
+func newTestSignedRequests(t *testing.T, now time.Time) *SignedRequests {
+    t.Helper()
+    secretFile := filepath.Join(t.TempDir(), "secret")
+    if err := os.WriteFile(secretFile, []byte(testSigningSecret+"\n"), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    s, err := NewSignedRequests(config.RequestSigningConfig{
+        Keys:         map[string]config.SigningKey{"ci": {SecretFile: secretFile, Scope: "validate:read"}},
+        MaxClockSkew: 5 * time.Minute,
+    }, cache.NewMemoryNonceStore(100))
+    if err != nil {
+        t.Fatalf("NewSignedRequests() error = %v", err)
+    }
+    s.now = func() time.Time { return now }
+    return s
+}
+
+// signedRequest builds a request signed with secret at timestamp
+func signedRequest(secret, keyID, nonce string, timestamp time.Time, body string) *http.Request {
+    r := httptest.NewRequest(http.MethodPost, "/api/v1/validate?strict=true", strings.NewReader(body))
+    ts := strconv.FormatInt(timestamp.Unix(), 10)
+    sum := sha256.Sum256([]byte(body))
+    r.Header.Set(SignatureKeyHeader, keyID)
+    r.Header.Set(SignatureTimestampHeader, ts)
+    r.Header.Set(SignatureNonceHeader, nonce)
+    r.Header.Set(SignatureHeader, Sign([]byte(secret), ts, nonce, r.Method, r.URL.RequestURI(), hex.EncodeToString(sum[:])))
+    return r
+}
+
+func TestSignedRequests(t *testing.T) {
+    now := time.Unix(1700000000, 0)
+    const body = `{"rule":"x"}`
+
+    tests := []struct {
+        name   string
+        secret string
+        keyID  string
+        nonce  string
+        skew   time.Duration
+        // tamper changes the request after it was signed
+        tamper func(r *http.Request)
+        status int
+    }{
+        {name: "valid", status: http.StatusOK},
+        {name: "within skew", skew: -4 * time.Minute, status: http.StatusOK},
+        {name: "outside skew", skew: 6 * time.Minute, status: http.StatusUnauthorized},
+        {name: "wrong secret", secret: strings.Repeat("x", 32), status: http.StatusUnauthorized},
+        {name: "unknown key", keyID: "other", status: http.StatusUnauthorized},
+        {name: "short nonce", nonce: "short", status: http.StatusUnauthorized},
+        {
+            name:   "tampered body",
+            tamper: func(r *http.Request) { r.Body = io.NopCloser(strings.NewReader(`{"rule":"y"}`)) },
+            status: http.StatusUnauthorized,
+        },
+        {
+            name:   "tampered query",
+            tamper: func(r *http.Request) { r.URL.RawQuery = "strict=false" },
+            status: http.StatusUnauthorized,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            s := newTestSignedRequests(t, now)
+            var claims *Claims
+            var gotBody string
+            handler := s.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                claims, _ = ClaimsFromContext(r.Context())
+                data, _ := io.ReadAll(r.Body)
+                gotBody = string(data)
+            }))
+
+            secret, keyID, nonce := testSigningSecret, "ci", "0123456789abcdef"
+            if tt.secret != "" {
+                secret = tt.secret
+            }
+            if tt.keyID != "" {
+                keyID = tt.keyID
+            }
+            if tt.nonce != "" {
+                nonce = tt.nonce
+            }
+            r := signedRequest(secret, keyID, nonce, now.Add(tt.skew), body)
+            if tt.tamper != nil {
+                tt.tamper(r)
+            }
+
+            rec := httptest.NewRecorder()
+            handler.ServeHTTP(rec, r)
+            if rec.Code != tt.status {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+            }
+            if tt.status != http.StatusOK {
+                return
+            }
+            if claims == nil || claims.UserId != "hmac:ci" || strings.Join(claims.Scopes(), " ") != "validate:read" {
+                t.Errorf("claims = %+v", claims)
+            }
+            if gotBody != body {
+                t.Errorf("handler read body %q, want %q", gotBody, body)
+            }
+        })
+    }
+}
+
+func TestSignedRequestsReplay(t *testing.T) {
+    now := time.Unix(1700000000, 0)
+    s := newTestSignedRequests(t, now)
+    handler := s.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+    for i, want := range []int{http.StatusOK, http.StatusUnauthorized} {
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, signedRequest(testSigningSecret, "ci", "fedcba9876543210", now, "{}"))
+        if rec.Code != want {
+            t.Errorf("request %d status = %d, want %d", i+1, rec.Code, want)
+        }
+    }
+}
+
+func TestOrBearer(t *testing.T) {
+    now := time.Unix(1700000000, 0)
+    s := newTestSignedRequests(t, now)
+    bearer := func(http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            w.WriteHeader(http.StatusTeapot)
+        })
+    }
+    handler := s.OrBearer(bearer)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+    rec := httptest.NewRecorder()
+    handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+    if rec.Code != http.StatusTeapot {
+        t.Errorf("unsigned request status = %d, want bearer authentication", rec.Code)
+    }
+    rec = httptest.NewRecorder()
+    handler.ServeHTTP(rec, signedRequest(testSigningSecret, "ci", "0123456789abcdef", now, ""))
+    if rec.Code != http.StatusOK {
+        t.Errorf("signed request status = %d, want %d", rec.Code, http.StatusOK)
+    }
+}
+
+func TestNewSignedRequestsShortSecret(t *testing.T) {
+    secretFile := filepath.Join(t.TempDir(), "secret")
+    if err := os.WriteFile(secretFile, []byte("too short"), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    _, err := NewSignedRequests(config.RequestSigningConfig{
+        Keys: map[string]config.SigningKey{"ci": {SecretFile: secretFile}},
+    }, cache.NewMemoryNonceStore(100))
+    if err == nil || !strings.Contains(err.Error(), "at least 32 bytes") {
+        t.Errorf("NewSignedRequests() error = %v, want short secret error", err)
+    }
+}
@@ -0,0 +1,96 @@
+// Package middleware provides HTTP middleware components for the validation service API
+// with tenant resolution and per-tenant rate limits and quotas.
+package middleware
+
+import (
+    "errors"
+    "math"
+    "net/http"
+    "strconv"
+
+    "github.com/prometheus/client_golang/prometheus"          // v1.17.0
+    "github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+
+    "validation-service/internal/tenant"
+    "validation-service/pkg/logger"
+)
+
+// Outcomes of tenant resolution
+const (
+    tenantOutcomeAllowed     = "allowed"
+    tenantOutcomeForbidden   = "forbidden"
+    tenantOutcomeRateLimited = "rate_limited"
+    tenantOutcomeQuota       = "quota_exceeded"
+)
+
+var tenantRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name:        "tenant_requests_total",
+    Help:        "API requests by tenant and outcome of the tenant's policy checks",
+    ConstLabels: prometheus.Labels{"service": "validation"},
+}, []string{"tenant", "outcome"})
+
+// ResolveTenant returns the tenant of an authenticated caller. The tenant
+// claim of the token is authoritative; the requested tenant, from the
+// X-Tenant-ID header or gRPC metadata, is only used for tokens without one
+// granting the admin:all scope, such as those of an API gateway acting for
+// every tenant. Other callers without a claim have no tenant.
+func ResolveTenant(tenants *tenant.Registry, claims *Claims, requested string) (*tenant.Tenant, error) {
+    claimed := ""
+    mayChoose := false
+    if claims != nil {
+        claimed = claims.TenantID
+        mayChoose = claims.HasScope(ScopeAdmin)
+    }
+    id, err := tenants.ResolveID(claimed, requested, mayChoose)
+    if err != nil {
+        return nil, err
+    }
+    return tenants.Resolve(id), nil
+}
+
+// TenantMiddleware resolves the tenant of each request, enforces its rate
+// limit and daily quota and stores it in the request context, where
+// handlers, stores and log lines find it. Requests naming a tenant other
+// than their token's are answered with 403 Forbidden; tenants over their
+// limits with 429 Too Many Requests and a Retry-After header.
+func TenantMiddleware(tenants *tenant.Registry) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            claims, _ := ClaimsFromContext(r.Context())
+            t, err := ResolveTenant(tenants, claims, r.Header.Get(tenantHeader))
+            if err != nil {
+                tenantRequests.WithLabelValues("none", tenantOutcomeForbidden).Inc()
+                userID := ""
+                if claims != nil {
+                    userID = claims.UserId
+                }
+                logger.FromContext(r.Context()).Warn("Request rejected by tenant check",
+                    "error", err,
+                    "user_id", userID,
+                    "requested_tenant", r.Header.Get(tenantHeader),
+                    "path", r.URL.Path,
+                )
+                writeAuthError(w, http.StatusForbidden, err.Error())
+                return
+            }
+
+            if retryAfter, err := tenants.Allow(t); err != nil {
+                outcome := tenantOutcomeRateLimited
+                if errors.Is(err, tenant.ErrQuotaExceeded) {
+                    outcome = tenantOutcomeQuota
+                }
+                tenantRequests.WithLabelValues(t.Label, outcome).Inc()
+                w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+                writeAuthError(w, http.StatusTooManyRequests, err.Error())
+                return
+            }
+            tenantRequests.WithLabelValues(t.Label, tenantOutcomeAllowed).Inc()
+
+            ctx := tenant.WithTenant(r.Context(), t)
+            if t.ID != "" {
+                ctx = logger.WithTenantID(ctx, t.ID)
+            }
+            next.ServeHTTP(w, r.WithContext(ctx))
+        })
+    }
+}
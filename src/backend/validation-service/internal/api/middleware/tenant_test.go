@@ -0,0 +1,69 @@
+package middleware
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "validation-service/internal/config"
+    "validation-service/internal/tenant"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+    tenants := tenant.NewRegistry(config.TenancyConfig{})
+
+    tests := []struct {
+        name   string
+        claims *Claims
+        header string
+        status int
+        tenant string
+    }{
+        {name: "tenant claim", claims: &Claims{UserId: "u1", TenantID: "acme"}, status: http.StatusOK, tenant: "acme"},
+        {name: "matching header", claims: &Claims{UserId: "u1", TenantID: "acme"}, header: "acme", status: http.StatusOK, tenant: "acme"},
+        {name: "header naming another tenant", claims: &Claims{UserId: "u1", TenantID: "acme"}, header: "globex", status: http.StatusForbidden},
+        {name: "header without claim", claims: &Claims{UserId: "u1", Role: "engineer"}, header: "globex", status: http.StatusOK},
+        {name: "header of administrator without claim", claims: &Claims{UserId: "u1", Role: "admin"}, header: "globex", status: http.StatusOK, tenant: "globex"},
+        {name: "header of administrator with narrowed scope", claims: &Claims{UserId: "u1", Role: "admin", Scope: ScopeValidateRead}, header: "globex", status: http.StatusOK},
+        {name: "no tenant", claims: &Claims{UserId: "u1"}, status: http.StatusOK},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var got string
+            handler := TenantMiddleware(tenants)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                got = tenant.IDFromContext(r.Context())
+            }))
+            r := httptest.NewRequest(http.MethodGet, "/api/v1/results", nil)
+            r = r.WithContext(WithClaims(r.Context(), tt.claims))
+            if tt.header != "" {
+                r.Header.Set(tenantHeader, tt.header)
+            }
+            rec := httptest.NewRecorder()
+            handler.ServeHTTP(rec, r)
+            if rec.Code != tt.status || got != tt.tenant {
+                t.Errorf("status %d, tenant %q, want %d, %q", rec.Code, got, tt.status, tt.tenant)
+            }
+        })
+    }
+}
+
+func TestTenantMiddlewareQuota(t *testing.T) {
+    tenants := tenant.NewRegistry(config.TenancyConfig{
+        Tenants: map[string]config.TenantPolicy{"limited": {DailyQuota: 1}},
+    })
+    handler := TenantMiddleware(tenants)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+    for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+        r := httptest.NewRequest(http.MethodGet, "/api/v1/results", nil)
+        r = r.WithContext(WithClaims(r.Context(), &Claims{UserId: "u1", TenantID: "limited"}))
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, r)
+        if rec.Code != want {
+            t.Errorf("request %d status = %d, want %d", i+1, rec.Code, want)
+        }
+        if want == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+            t.Error("quota rejection has no Retry-After header")
+        }
+    }
+}
@@ -0,0 +1,135 @@
+// Package middleware provides secure authentication and authorization middleware
+// for the validation service API endpoints.
+package middleware
+
+import (
+    "crypto/rsa"
+    "crypto/x509"
+    "encoding/pem"
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5" // v5.0.0
+    "github.com/google/uuid"       // v1.4.0
+)
+
+// TokenExchangeIssuer is the issuer of tokens minted by the token exchange.
+// They are verified with the exchange's own key rather than the keys of the
+// configured JWT issuer.
+const TokenExchangeIssuer = "validation-service/token-exchange"
+
+// TokenExchangeAudience is the audience of minted CI tokens
+const TokenExchangeAudience = "ci"
+
+// DefaultTokenExchangeTTL is the lifetime of minted tokens when none is requested
+const DefaultTokenExchangeTTL = 15 * time.Minute
+
+// Token exchange errors
+var (
+    ErrScopeNotGranted = errors.New("requested scope is not granted to the subject token")
+    ErrInvalidTokenTTL = errors.New("invalid token lifetime")
+)
+
+// exchangeVerifyKey verifies tokens minted by the token exchange; nil until
+// a TokenIssuer is created
+var exchangeVerifyKey *rsa.PublicKey
+
+// TokenIssuer mints short-lived, narrowly scoped tokens for CI pipelines in
+// exchange for a user's token
+type TokenIssuer struct {
+    key    *rsa.PrivateKey
+    maxTTL time.Duration
+    now    func() time.Time
+}
+
+// NewTokenIssuer creates a token issuer signing with the PEM-encoded RSA
+// private key in keyFile. Tokens it mints are accepted by AuthMiddleware.
+func NewTokenIssuer(keyFile string, maxTTL time.Duration) (*TokenIssuer, error) {
+    data, err := os.ReadFile(keyFile)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read token signing key: %w", err)
+    }
+    key, err := parseRSAPrivateKey(data)
+    if err != nil {
+        return nil, err
+    }
+    exchangeVerifyKey = &key.PublicKey
+    return &TokenIssuer{key: key, maxTTL: maxTTL, now: time.Now}, nil
+}
+
+// parseRSAPrivateKey decodes a PKCS#1 or PKCS#8 PEM RSA private key
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+    block, _ := pem.Decode(data)
+    if block == nil {
+        return nil, errors.New("token signing key is not PEM encoded")
+    }
+    if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+        return key, nil
+    }
+    parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+    if err != nil {
+        return nil, fmt.Errorf("failed to parse token signing key: %w", err)
+    }
+    key, ok := parsed.(*rsa.PrivateKey)
+    if !ok {
+        return nil, errors.New("token signing key is not an RSA key")
+    }
+    return key, nil
+}
+
+// MaxTTL returns the longest lifetime of a minted token
+func (i *TokenIssuer) MaxTTL() time.Duration {
+    return i.maxTTL
+}
+
+// Exchange mints a token for pipeline carrying the requested scopes, which
+// must all be granted to subject. Minted tokens cannot be exchanged again.
+// A zero ttl selects DefaultTokenExchangeTTL, capped at the maximum.
+func (i *TokenIssuer) Exchange(subject *Claims, scopes []string, ttl time.Duration, pipeline string) (string, *Claims, error) {
+    if ttl == 0 {
+        ttl = DefaultTokenExchangeTTL
+        if ttl > i.maxTTL {
+            ttl = i.maxTTL
+        }
+    }
+    if ttl < time.Minute || ttl > i.maxTTL {
+        return "", nil, fmt.Errorf("%w: must be between 1m and %v", ErrInvalidTokenTTL, i.maxTTL)
+    }
+    if len(scopes) == 0 {
+        return "", nil, fmt.Errorf("%w: at least one scope is required", ErrScopeNotGranted)
+    }
+    for _, scope := range scopes {
+        if scope == ScopeTokensExchange || !subject.HasScope(scope) {
+            return "", nil, fmt.Errorf("%w: %s", ErrScopeNotGranted, scope)
+        }
+    }
+
+    now := i.now()
+    claims := &Claims{
+        UserId:         subject.UserId,
+        Role:           subject.Role,
+        Permissions:    subject.Permissions,
+        TokenIssueTime: now,
+        Scope:          strings.Join(scopes, " "),
+        Pipeline:       pipeline,
+        TenantID:       subject.TenantID,
+        RegisteredClaims: jwt.RegisteredClaims{
+            Issuer:    TokenExchangeIssuer,
+            Subject:   subject.UserId,
+            Audience:  jwt.ClaimStrings{TokenExchangeAudience},
+            ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+            IssuedAt:  jwt.NewNumericDate(now),
+            NotBefore: jwt.NewNumericDate(now),
+            ID:        uuid.NewString(),
+        },
+    }
+
+    token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(i.key)
+    if err != nil {
+        return "", nil, fmt.Errorf("failed to sign token: %w", err)
+    }
+    return token, claims, nil
+}
@@ -0,0 +1,57 @@
+// Package middleware provides HTTP middleware components for the validation service
+package middleware
+
+import (
+    "fmt"
+    "net/http"
+
+    "github.com/go-chi/chi/v5" // v5.0.8
+    chimiddleware "github.com/go-chi/chi/v5/middleware"
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/codes"
+    "go.opentelemetry.io/otel/propagation"
+    semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+    "go.opentelemetry.io/otel/trace"
+
+    "validation-service/pkg/tracing"
+)
+
+// TracingMiddleware starts a server span for every request, continuing the
+// trace of an incoming traceparent header. The span is named after the
+// matched route once the request has been routed.
+func TracingMiddleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        ctx := tracing.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+        ctx, span := tracing.Tracer().Start(ctx, fmt.Sprintf("HTTP %s", r.Method),
+            trace.WithSpanKind(trace.SpanKindServer),
+            trace.WithAttributes(
+                semconv.HTTPMethod(r.Method),
+                attribute.String("url.path", r.URL.Path),
+                attribute.String("user_agent.original", r.UserAgent()),
+                attribute.String("http.request_id", chimiddleware.GetReqID(r.Context())),
+            ),
+        )
+        defer span.End()
+        if spanContext := span.SpanContext(); spanContext.HasTraceID() {
+            w.Header().Set(TraceIDHeader, spanContext.TraceID().String())
+        }
+
+        rw := &responseWriter{ResponseWriter: w}
+        next.ServeHTTP(rw, r.WithContext(ctx))
+
+        status := rw.status
+        if status == 0 {
+            status = http.StatusOK
+        }
+        span.SetAttributes(semconv.HTTPStatusCode(status))
+        if status >= http.StatusInternalServerError {
+            span.SetStatus(codes.Error, http.StatusText(status))
+        }
+        if rctx := chi.RouteContext(ctx); rctx != nil {
+            if pattern := rctx.RoutePattern(); pattern != "" {
+                span.SetName(fmt.Sprintf("HTTP %s %s", r.Method, pattern))
+                span.SetAttributes(semconv.HTTPRoute(pattern))
+            }
+        }
+    })
+}
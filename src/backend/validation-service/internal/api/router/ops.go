@@ -0,0 +1,75 @@
+package router
+
+import (
+    "github.com/go-chi/chi/v5"            // v5.0.8
+    "github.com/go-chi/chi/v5/middleware" // v5.0.8
+
+    "validation-service/internal/api/handlers"
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/config"
+    "validation-service/pkg/logger"
+)
+
+// OpsHandlers configures the routes of the ops listener
+type OpsHandlers struct {
+    Admin *handlers.AdminHandler
+    // Autoscaling serves load signals for horizontal pod autoscalers
+    Autoscaling *handlers.AutoscalingHandler
+    // MetricsEndpoint is the path Prometheus metrics are served on
+    MetricsEndpoint string
+    // Profiling mounts net/http/pprof under /debug/pprof
+    Profiling bool
+    // Network restricts the source addresses of the admin, metrics and
+    // pprof routes; probes are never restricted
+    Network *apimiddleware.NetworkPolicies
+    // Signatures accepts HMAC-signed admin requests from automation
+    Signatures *apimiddleware.SignedRequests
+}
+
+// NewOpsRouter creates the router of the ops listener, which serves metrics,
+// health probes, pprof and the administrative endpoints on the monitoring
+// port, away from the API listener.
+func NewOpsRouter(h OpsHandlers) *chi.Mux {
+    router := chi.NewRouter()
+    router.Use(apimiddleware.RequestID)
+    router.Use(apimiddleware.PeerAddress)
+    router.Use(middleware.RealIP)
+    router.Use(middleware.Recoverer)
+
+    setupHealthRoutes(router)
+
+    router.Group(func(r chi.Router) {
+        // Scrapes must never be served from a cache
+        r.Use(middleware.NoCache)
+        r.Use(h.Network.Middleware(config.NetworkGroupMetrics))
+        r.Get(h.MetricsEndpoint, handlers.MetricsHandler)
+        if h.Autoscaling != nil {
+            r.Get("/autoscaling", h.Autoscaling.SignalsHandler)
+        }
+    })
+
+    if h.Profiling {
+        router.Group(func(r chi.Router) {
+            r.Use(h.Network.Middleware(config.NetworkGroupMetrics))
+            r.Mount("/debug", middleware.Profiler())
+        })
+    }
+
+    if h.Admin != nil {
+        router.Route("/admin", func(r chi.Router) {
+            r.Use(apimiddleware.LoggingMiddleware)
+            r.Use(h.Network.Middleware(config.NetworkGroupAdmin))
+            r.Use(h.Signatures.OrBearer(apimiddleware.AuthMiddleware()))
+            r.Use(apimiddleware.RequireScope(apimiddleware.ScopeAdmin))
+            h.Admin.RegisterRoutes(r)
+        })
+    }
+
+    logger.GetLogger().Info("Ops router configured successfully",
+        "metrics_endpoint", h.MetricsEndpoint,
+        "profiling_enabled", h.Profiling,
+        "admin_enabled", h.Admin != nil,
+    )
+
+    return router
+}
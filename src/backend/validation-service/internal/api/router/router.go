@@ -11,10 +11,11 @@ import (
     "github.com/go-chi/chi/v5/middleware" // v5.0.8
     "github.com/go-chi/cors" // v5.0.8
 
+    "validation-service/internal/api/graphqlapi"
     "validation-service/internal/api/handlers"
-    "validation-service/internal/api/middleware/auth"
-    "validation-service/internal/api/middleware/logging"
-    "validation-service/internal/api/middleware/metrics"
+    apimiddleware "validation-service/internal/api/middleware"
+    "validation-service/internal/config"
+    "validation-service/internal/tenant"
     "validation-service/pkg/logger"
 )
 
@@ -28,9 +29,46 @@ const (
     apiVersion = "v1"
 )
 
+// Handlers groups the API handlers mounted by the router
+type Handlers struct {
+    Validation        *handlers.ValidationHandler
+    Detections        *handlers.DetectionHandler
+    Collections       *handlers.CollectionHandler
+    Results           *handlers.ResultHandler
+    TranslationMemory *handlers.TranslationMemoryHandler
+    Disambiguation    *handlers.DisambiguationHandler
+    TokenExchange     *handlers.TokenExchangeHandler
+    Docs              *handlers.DocsHandler
+    Notifications     *handlers.NotificationHandler
+    Schedules         *handlers.ScheduleHandler
+    ScoringPolicy     *handlers.ScoringPolicyHandler
+    CustomSchemas     *handlers.CustomSchemaHandler
+    Migrations        *handlers.MigrationHandler
+    // GraphQL serves queries of results, detections and statistics; its
+    // fields check the scopes of their REST equivalents
+    GraphQL *graphqlapi.Server
+    // Deprecations announces deprecated routes and tracks their callers
+    Deprecations *apimiddleware.DeprecationTracker
+    // Network restricts the source addresses of the API routes
+    Network *apimiddleware.NetworkPolicies
+    // Signatures accepts HMAC-signed requests in place of a bearer token
+    Signatures *apimiddleware.SignedRequests
+    // AdaptiveLogging, when set, raises logging of unhealthy routes to debug
+    AdaptiveLogging *logger.AdaptiveDebug
+    // Tenants resolves the tenant of API requests and enforces its rate
+    // limit and quota; tenants have no limits when nil
+    Tenants *tenant.Registry
+    // Idempotency replays the responses of retried validation requests
+    // carrying an Idempotency-Key header; keys are ignored when nil
+    Idempotency *apimiddleware.Idempotency
+    // TLS stores the SPIFFE ID of client certificates in the request
+    // context; nil when the API is served without TLS
+    TLS *apimiddleware.ServerTLS
+}
+
 // NewRouter creates and configures a new HTTP router with comprehensive middleware
 // stack, security controls, and API endpoints.
-func NewRouter(validationHandler *handlers.ValidationHandler) *chi.Mux {
+func NewRouter(h Handlers) *chi.Mux {
     // Initialize logger
     log := logger.GetLogger()
     
@@ -38,13 +76,13 @@ func NewRouter(validationHandler *handlers.ValidationHandler) *chi.Mux {
     router := chi.NewRouter()
 
     // Set up global middleware stack
-    setupMiddleware(router)
+    setupMiddleware(router, h.Network, h.Signatures, h.AdaptiveLogging, h.TLS)
 
     // Configure health check endpoints
     setupHealthRoutes(router)
 
     // Configure API routes
-    setupAPIRoutes(router, validationHandler)
+    setupAPIRoutes(router, h)
 
     log.Info("Router configured successfully",
         "api_version", apiVersion,
@@ -57,12 +95,19 @@ func NewRouter(validationHandler *handlers.ValidationHandler) *chi.Mux {
 
 // setupMiddleware configures the global middleware stack with security,
 // monitoring, and performance optimization.
-func setupMiddleware(router *chi.Mux) {
-    // Basic middleware
-    router.Use(middleware.RequestID)
+func setupMiddleware(router *chi.Mux, network *apimiddleware.NetworkPolicies, signatures *apimiddleware.SignedRequests, adaptive *logger.AdaptiveDebug, serverTLS *apimiddleware.ServerTLS) {
+    // Basic middleware; the peer address and identity are kept for network
+    // policies and handlers. Request IDs and trace IDs of the API gateway
+    // are passed through.
+    router.Use(apimiddleware.RequestID)
+    router.Use(apimiddleware.PeerAddress)
+    router.Use(serverTLS.PeerIdentity)
     router.Use(middleware.RealIP)
     router.Use(middleware.Recoverer)
 
+    // Distributed tracing; runs before logging so log lines carry the trace
+    router.Use(apimiddleware.TracingMiddleware)
+
     // Timeout control
     router.Use(middleware.Timeout(requestTimeout))
 
@@ -70,50 +115,191 @@ func setupMiddleware(router *chi.Mux) {
     router.Use(middleware.Compress(5))
 
     // Custom logging middleware
-    router.Use(logging.LoggingMiddleware)
+    router.Use(apimiddleware.LoggingMiddleware)
+    if adaptive != nil {
+        router.Use(apimiddleware.AdaptiveLogging(adaptive))
+    }
 
     // Metrics collection middleware
-    router.Use(metrics.MetricsMiddleware)
+    router.Use(apimiddleware.MetricsMiddleware)
 
     // Security middleware
     router.Use(middleware.StripSlashes)
-    router.Use(middleware.NoCache)
     router.Use(middleware.GetHead)
 
     // CORS configuration
     router.Use(cors.Handler(cors.Options{
         AllowedOrigins:   []string{"https://*"},
         AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
-        AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-ID"},
-        ExposedHeaders:   []string{"Link"},
+        AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", apimiddleware.RequestIDHeader, "If-None-Match", "Cache-Control", apimiddleware.TraceParentHeader, "tracestate", apimiddleware.APIKeyHeader,
+            apimiddleware.SignatureHeader, apimiddleware.SignatureKeyHeader, apimiddleware.SignatureTimestampHeader, apimiddleware.SignatureNonceHeader, apimiddleware.IdempotencyKeyHeader},
+        ExposedHeaders:   []string{"Link", "ETag", "Deprecation", "Sunset", apimiddleware.RequestIDHeader, apimiddleware.TraceIDHeader, apimiddleware.IdempotentReplayHeader},
         AllowCredentials: true,
         MaxAge:          300,
     }))
 
-    // Authentication middleware
-    router.Use(auth.AuthMiddleware())
+    // Authentication middleware; signed requests are verified instead of
+    // a bearer token
+    router.Use(signatures.OrBearer(apimiddleware.AuthMiddleware()))
 }
 
 // setupHealthRoutes configures kubernetes-compatible health check endpoints
-// with detailed status reporting.
-func setupHealthRoutes(router *chi.Mux) {
-    router.Get("/health/live", handlers.LivenessHandler)
-    router.Get("/health/ready", handlers.ReadinessHandler)
-    router.Get("/metrics", handlers.MetricsHandler)
+// with detailed status reporting. The probes are also served by the ops
+// listener; metrics are served there only.
+func setupHealthRoutes(router chi.Router) {
+    router.Group(func(r chi.Router) {
+        // Probes must never be served from a cache
+        r.Use(middleware.NoCache)
+
+        r.Get("/health/live", handlers.LivenessHandler)
+        r.Get("/health/ready", handlers.ReadinessHandler)
+    })
 }
 
 // setupAPIRoutes configures versioned API routes with proper middleware
 // and handler bindings.
-func setupAPIRoutes(router *chi.Mux, validationHandler *handlers.ValidationHandler) {
+func setupAPIRoutes(router *chi.Mux, h Handlers) {
     // API version group
     router.Route("/api/v1", func(r chi.Router) {
-        // Validation endpoints
-        r.Post("/validate", validationHandler.ValidateHandler)
-        r.Post("/validate/batch", validationHandler.ValidateBatchHandler)
+        // Source address policy of the API route group
+        r.Use(h.Network.Middleware(config.NetworkGroupAPI))
+
+        // Tenant of the caller's token, its rate limit and daily quota
+        r.Use(apimiddleware.TenantMiddleware(h.Tenants))
+
+        // Conditional GET support for stored results and rule resources;
+        // event streams and other streamed responses pass through unbuffered
+        r.Use(apimiddleware.ETagMiddleware)
+
+        // Deprecation and Sunset headers for routes being retired
+        if h.Deprecations != nil {
+            r.Use(h.Deprecations.Middleware)
+        }
+
+        // Validation endpoints. Retries of buffered responses carrying an
+        // Idempotency-Key header are answered with the first response;
+        // streamed responses are not recorded.
+        validate := r.With(apimiddleware.RequireScope(apimiddleware.ScopeValidateRead))
+        idempotent := validate.With(h.Idempotency.Middleware)
+        idempotent.Post("/validate", h.Validation.ValidateHandler)
+        idempotent.Post("/validate/fix", h.Validation.FixHandler)
+        idempotent.Post("/validate/compatibility", h.Validation.CompatibilityHandler)
+        idempotent.Post("/validate/collection", h.Validation.ValidateCollectionHandler)
+        idempotent.Post("/lint", h.Validation.LintHandler)
+        validate.Post("/normalize", h.Validation.NormalizeHandler)
+        validate.Post("/validate/yara/stream", h.Validation.ValidateYaraStreamHandler)
+        validate.Get("/validate/revalidations", h.Validation.RevalidationStreamHandler)
+        idempotent.Post("/test", h.Validation.TestHandler)
+        r.With(apimiddleware.RequireScope(apimiddleware.ScopeJobsCreate), h.Idempotency.Middleware).
+            Post("/validate/batch", h.Validation.ValidateBatchHandler)
+
+        // Stored detection, tagging and search endpoints
+        if h.Detections != nil {
+            r.Group(func(r chi.Router) {
+                r.Use(apimiddleware.RequireReadWriteScope(apimiddleware.ScopeRulesRead, apimiddleware.ScopeRulesWrite))
+                h.Detections.RegisterRoutes(r)
+            })
+        }
+
+        // Rule collections of stored detections; validating one needs the
+        // validate scope only
+        if h.Collections != nil {
+            r.Group(func(r chi.Router) {
+                r.Use(apimiddleware.RequireReadWriteScope(apimiddleware.ScopeRulesRead, apimiddleware.ScopeRulesWrite))
+                h.Collections.RegisterRoutes(r)
+            })
+            idempotent.Post("/collections/{id}/validate", h.Validation.ValidateStoredCollectionHandler)
+        }
+
+        // Persisted validation result endpoints
+        if h.Results != nil {
+            r.Group(func(r chi.Router) {
+                r.Use(apimiddleware.RequireScope(apimiddleware.ScopeResultsRead))
+                h.Results.RegisterRoutes(r)
+            })
+        }
+
+        // Tenant translation memory of approved mappings
+        if h.TranslationMemory != nil {
+            r.Group(func(r chi.Router) {
+                r.Use(apimiddleware.RequireReadWriteScope(apimiddleware.ScopeRulesRead, apimiddleware.ScopeRulesWrite))
+                h.TranslationMemory.RegisterRoutes(r)
+            })
+        }
+
+        // Guided translation questions for ambiguous field mappings
+        if h.Disambiguation != nil {
+            r.Group(func(r chi.Router) {
+                r.Use(apimiddleware.RequireScope(apimiddleware.ScopeValidateRead))
+                h.Disambiguation.RegisterRoutes(r)
+            })
+        }
+
+        // Markdown documentation of rules for detection catalogs
+        if h.Docs != nil {
+            r.Group(func(r chi.Router) {
+                r.Use(apimiddleware.RequireScope(apimiddleware.ScopeValidateRead))
+                h.Docs.RegisterRoutes(r)
+            })
+        }
+
+        // Notification subscriptions of the authenticated user
+        if h.Notifications != nil {
+            r.Group(func(r chi.Router) {
+                r.Use(apimiddleware.RequireScope(apimiddleware.ScopeNotifications))
+                h.Notifications.RegisterRoutes(r)
+            })
+        }
+
+        // Recurring validation jobs of the tenant
+        if h.Schedules != nil {
+            r.Group(func(r chi.Router) {
+                r.Use(apimiddleware.RequireReadWriteScope(apimiddleware.ScopeResultsRead, apimiddleware.ScopeJobsCreate))
+                h.Schedules.RegisterRoutes(r)
+            })
+        }
+
+        // Rego scoring policy of the tenant
+        if h.ScoringPolicy != nil {
+            r.Group(func(r chi.Router) {
+                r.Use(apimiddleware.RequireReadWriteScope(apimiddleware.ScopeValidateRead, apimiddleware.ScopeRulesWrite))
+                h.ScoringPolicy.RegisterRoutes(r)
+            })
+        }
+
+        // Custom log source schemas of the tenant
+        if h.CustomSchemas != nil {
+            r.Group(func(r chi.Router) {
+                r.Use(apimiddleware.RequireReadWriteScope(apimiddleware.ScopeRulesRead, apimiddleware.ScopeRulesWrite))
+                h.CustomSchemas.RegisterRoutes(r)
+            })
+        }
+
+        // Guided migration wizards; starting a run also needs the jobs scope
+        if h.Migrations != nil {
+            r.Group(func(r chi.Router) {
+                r.Use(apimiddleware.RequireReadWriteScope(apimiddleware.ScopeRulesRead, apimiddleware.ScopeRulesWrite))
+                h.Migrations.RegisterRoutes(r)
+            })
+            r.With(apimiddleware.RequireScope(apimiddleware.ScopeRulesWrite), apimiddleware.RequireScope(apimiddleware.ScopeJobsCreate)).
+                Post("/migrations/{id}/run", h.Migrations.RunHandler)
+        }
+
+        // GraphQL queries and the validate mutation; any authenticated
+        // token may post, and each field checks its own scope
+        if h.GraphQL != nil {
+            r.Post("/graphql", h.GraphQL.ServeHTTP)
+        }
+
+        // Short-lived, narrowly scoped tokens for CI pipelines
+        if h.TokenExchange != nil {
+            h.TokenExchange.RegisterRoutes(r)
+        }
 
-        // Additional API endpoints can be added here
-        r.Get("/formats", validationHandler.GetSupportedFormatsHandler)
-        r.Get("/status", validationHandler.GetServiceStatusHandler)
+        // Additional API endpoints can be added here; any authenticated token
+        // may read the formats and status
+        r.Get("/formats", h.Validation.GetSupportedFormatsHandler)
+        r.Get("/status", h.Validation.GetServiceStatusHandler)
     })
 }
 
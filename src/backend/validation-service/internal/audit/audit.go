@@ -0,0 +1,408 @@
+// Package audit writes an append-only, tamper-evident log of who validated
+// what. Records are JSON lines, each carrying the SHA-256 hash of the record
+// before it, so that editing, removing or reordering a record breaks the
+// chain at that point. The log is rotated by size; the chain continues
+// across rotated files.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"validation-service/internal/models"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionValidate = "validate"
+)
+
+// Channels through which validations are requested
+const (
+	ChannelREST    = "rest"
+	ChannelGraphQL = "graphql"
+	ChannelGRPC    = "grpc"
+)
+
+// Query limits
+const (
+	DefaultQueryLimit = 100
+	MaxQueryLimit     = 1000
+)
+
+// rotatedTimeFormat names rotated files so that they sort by age
+const rotatedTimeFormat = "20060102T150405.000000000"
+
+// maxRecordSize bounds the length of a record line read back from the log
+const maxRecordSize = 1 << 20
+
+// Record is an entry of the audit log
+type Record struct {
+	// Seq numbers the records of the log from 1
+	Seq    uint64    `json:"seq"`
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	// Channel is the API the request came through
+	Channel   string `json:"channel"`
+	UserID    string `json:"user_id,omitempty"`
+	TenantID  string `json:"tenant_id,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// DetectionHash is the SHA-256 of the target detection content, which
+	// identifies the rule without recording it
+	DetectionHash   string  `json:"detection_hash,omitempty"`
+	SourceFormat    string  `json:"source_format,omitempty"`
+	TargetFormat    string  `json:"target_format,omitempty"`
+	ResultID        string  `json:"result_id,omitempty"`
+	Status          string  `json:"status,omitempty"`
+	ConfidenceScore float64 `json:"confidence_score"`
+	// Error is set when the validation failed
+	Error string `json:"error,omitempty"`
+	// PrevHash is the hash of the previous record, empty for the first
+	PrevHash string `json:"prev_hash"`
+	// Hash is the SHA-256 of the record encoded without it
+	Hash string `json:"hash"`
+}
+
+// ValidationRecord returns the record of a validation of source into
+// target. result is nil when the validation failed with err. The caller
+// sets the channel and the identity of the requester.
+func ValidationRecord(source, target *models.Detection, result *models.ValidationResult, err error) Record {
+	rec := Record{Action: ActionValidate}
+	if source != nil {
+		rec.SourceFormat = source.Format
+	}
+	if target != nil {
+		rec.TargetFormat = target.Format
+		sum := sha256.Sum256([]byte(target.Content))
+		rec.DetectionHash = hex.EncodeToString(sum[:])
+	}
+	if result != nil {
+		rec.ResultID = result.ID.String()
+		rec.Status = result.Status
+		rec.ConfidenceScore = result.ConfidenceScore
+	}
+	if err != nil {
+		rec.Status = models.ValidationStatusError
+		rec.Error = err.Error()
+	}
+	return rec
+}
+
+// digest returns the hash of a record encoded without its hash
+func digest(rec Record) (string, error) {
+	rec.Hash = ""
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Config configures the audit log file
+type Config struct {
+	Path string
+	// MaxSize is the size in bytes at which the log is rotated; it is never
+	// rotated when zero
+	MaxSize int64
+	// MaxBackups is the number of rotated files kept; all are kept when zero
+	MaxBackups int
+}
+
+// Logger appends records to the audit log. It is safe for concurrent use.
+type Logger struct {
+	mu       sync.Mutex
+	cfg      Config
+	file     *os.File
+	size     int64
+	seq      uint64
+	lastHash string
+	// synced is the sequence number of the last record synced to disk
+	synced uint64
+	// syncMu serializes syncs, so that records written while a sync is in
+	// progress are synced together by the next one
+	syncMu sync.Mutex
+	now    func() time.Time
+}
+
+// Open opens the audit log at cfg.Path for appending, creating it and its
+// directory if needed. The chain continues from the last record of the
+// log or, for a new file, of the newest rotated file.
+func Open(cfg Config) (*Logger, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("audit log path is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o750); err != nil {
+		return nil, fmt.Errorf("creating audit log directory: %w", err)
+	}
+
+	l := &Logger{cfg: cfg, now: time.Now}
+	files, err := l.files()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		last, err := lastRecord(files[i])
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			l.seq, l.lastHash, l.synced = last.Seq, last.Hash, last.Seq
+			break
+		}
+	}
+
+	if err := l.openFile(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// openFile opens the current log file for appending
+func (l *Logger) openFile() error {
+	file, err := os.OpenFile(l.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+	l.file, l.size = file, info.Size()
+	return nil
+}
+
+// Record appends rec to the log, setting its sequence number, its time if
+// unset and its chain hashes. The record is synced to disk before Record
+// returns; concurrent records share a sync.
+func (l *Logger) Record(rec Record) error {
+	seq, err := l.write(rec)
+	if err != nil {
+		return err
+	}
+	return l.sync(seq)
+}
+
+// write appends rec to the current file and returns its sequence number
+func (l *Logger) write(rec Record) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return 0, errors.New("audit log is closed")
+	}
+
+	rec.Seq = l.seq + 1
+	if rec.Time.IsZero() {
+		rec.Time = l.now()
+	}
+	rec.Time = rec.Time.UTC()
+	rec.PrevHash = l.lastHash
+	hash, err := digest(rec)
+	if err != nil {
+		return 0, fmt.Errorf("encoding audit record: %w", err)
+	}
+	rec.Hash = hash
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return 0, fmt.Errorf("encoding audit record: %w", err)
+	}
+	line = append(line, '\n')
+
+	if l.cfg.MaxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.cfg.MaxSize {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := l.file.Write(line); err != nil {
+		return 0, fmt.Errorf("writing audit record: %w", err)
+	}
+	l.size += int64(len(line))
+	l.seq, l.lastHash = rec.Seq, rec.Hash
+	return rec.Seq, nil
+}
+
+// sync syncs the current file unless the record numbered seq was already
+// synced by another call. It does not hold mu while syncing, so records are
+// written meanwhile.
+func (l *Logger) sync(seq uint64) error {
+	l.syncMu.Lock()
+	defer l.syncMu.Unlock()
+
+	l.mu.Lock()
+	file, last, synced := l.file, l.seq, l.synced
+	l.mu.Unlock()
+	if synced >= seq {
+		return nil
+	}
+	err := file.Sync()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil {
+		// Rotating or closing the file syncs it first
+		if l.synced >= seq {
+			return nil
+		}
+		return fmt.Errorf("syncing audit log: %w", err)
+	}
+	if last > l.synced {
+		l.synced = last
+	}
+	return nil
+}
+
+// closeFile syncs and closes the current file
+func (l *Logger) closeFile() error {
+	err := l.file.Sync()
+	if err == nil {
+		l.synced = l.seq
+	}
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file = nil
+	return err
+}
+
+// rotate renames the current file with its rotation time, opens a new one
+// and removes the oldest rotated files beyond MaxBackups
+func (l *Logger) rotate() error {
+	if err := l.closeFile(); err != nil {
+		return l.reopen(fmt.Errorf("closing audit log: %w", err))
+	}
+	rotated := l.cfg.Path + "." + l.now().UTC().Format(rotatedTimeFormat)
+	if err := os.Rename(l.cfg.Path, rotated); err != nil {
+		return l.reopen(fmt.Errorf("rotating audit log: %w", err))
+	}
+	if err := l.openFile(); err != nil {
+		return err
+	}
+
+	if l.cfg.MaxBackups <= 0 {
+		return nil
+	}
+	backups, err := l.backups()
+	if err != nil {
+		return err
+	}
+	for len(backups) > l.cfg.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return fmt.Errorf("removing rotated audit log: %w", err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// reopen reopens the current file after rotating it failed with err, so
+// that later records are still appended to it
+func (l *Logger) reopen(err error) error {
+	if openErr := l.openFile(); openErr != nil {
+		return errors.Join(err, openErr)
+	}
+	return err
+}
+
+// Close closes the log file
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	return l.closeFile()
+}
+
+// backups returns the rotated files, oldest first
+func (l *Logger) backups() ([]string, error) {
+	matches, err := filepath.Glob(l.cfg.Path + ".*")
+	if err != nil {
+		return nil, fmt.Errorf("listing rotated audit logs: %w", err)
+	}
+	backups := matches[:0]
+	for _, match := range matches {
+		suffix := strings.TrimPrefix(match, l.cfg.Path+".")
+		if _, err := time.Parse(rotatedTimeFormat, suffix); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// files returns the rotated files, oldest first, followed by the current
+// file if it exists
+func (l *Logger) files() ([]string, error) {
+	files, err := l.backups()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(l.cfg.Path); err == nil {
+		files = append(files, l.cfg.Path)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	return files, nil
+}
+
+// snapshot returns the files of the log and the size of the current file,
+// so that readers stop at the last complete record written before
+func (l *Logger) snapshot() ([]string, int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	files, err := l.files()
+	return files, l.size, err
+}
+
+// scanFile calls fn with each line of a file and its line number, reading
+// at most limit bytes when limit is not negative
+func scanFile(path string, limit int64, fn func(line []byte, number int) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("reading audit log: %w", err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if limit >= 0 {
+		reader = io.LimitReader(file, limit)
+	}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxRecordSize)
+	for number := 1; scanner.Scan(); number++ {
+		if err := fn(scanner.Bytes(), number); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading audit log %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// lastRecord returns the last record of a file that can be decoded, or nil
+// when it has none
+func lastRecord(path string) (*Record, error) {
+	var last *Record
+	err := scanFile(path, -1, func(line []byte, _ int) error {
+		var rec Record
+		if json.Unmarshal(line, &rec) == nil {
+			last = &rec
+		}
+		return nil
+	})
+	return last, err
+}
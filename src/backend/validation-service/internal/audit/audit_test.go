@@ -0,0 +1,281 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// openTest opens a log in a temporary directory with a clock advancing one
+// second per call
+func openTest(t *testing.T, cfg Config) *Logger {
+	t.Helper()
+	if cfg.Path == "" {
+		cfg.Path = filepath.Join(t.TempDir(), "audit", "audit.log")
+	}
+	l, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func record(t *testing.T, l *Logger, recs ...Record) {
+	t.Helper()
+	for _, rec := range recs {
+		if err := l.Record(rec); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+}
+
+func TestRecordChain(t *testing.T) {
+	l := openTest(t, Config{})
+	record(t, l,
+		Record{Action: ActionValidate, UserID: "alice", TenantID: "acme"},
+		Record{Action: ActionValidate, UserID: "bob", TenantID: "acme"},
+		Record{Action: ActionValidate, UserID: "alice", TenantID: "globex"},
+	)
+
+	v, err := l.Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !v.Valid || v.Records != 3 || v.FirstSeq != 1 || v.LastSeq != 3 || v.Files != 1 {
+		t.Errorf("Verify() = %+v", v)
+	}
+
+	// Reopening continues the chain
+	path := l.cfg.Path
+	l.Close()
+	l = openTest(t, Config{Path: path})
+	record(t, l, Record{Action: ActionValidate, UserID: "carol"})
+	records, err := l.Query(Query{Limit: 1})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(records) != 1 || records[0].Seq != 4 || records[0].PrevHash != v.LastHash {
+		t.Errorf("record after reopening = %+v, want seq 4 following %s", records, v.LastHash)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines [][]byte) [][]byte
+		line   int
+		reason string
+	}{
+		{
+			name: "edited record",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[1] = bytes.Replace(lines[1], []byte(`"user_id":"bob"`), []byte(`"user_id":"eve"`), 1)
+				return lines
+			},
+			line:   2,
+			reason: "record does not match its hash",
+		},
+		{
+			name: "removed record",
+			tamper: func(lines [][]byte) [][]byte {
+				return append(lines[:1:1], lines[2:]...)
+			},
+			line:   2,
+			reason: "previous hash does not match record 1",
+		},
+		{
+			name: "reordered records",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			line:   2,
+			reason: "previous hash does not match record 1",
+		},
+		{
+			name: "garbage line",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[2] = []byte("not json")
+				return lines
+			},
+			line:   3,
+			reason: "record cannot be decoded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := openTest(t, Config{})
+			record(t, l,
+				Record{Action: ActionValidate, UserID: "alice"},
+				Record{Action: ActionValidate, UserID: "bob"},
+				Record{Action: ActionValidate, UserID: "carol"},
+			)
+			l.Close()
+
+			data, err := os.ReadFile(l.cfg.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := tt.tamper(bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")))
+			if err := os.WriteFile(l.cfg.Path, append(bytes.Join(lines, []byte("\n")), '\n'), 0o640); err != nil {
+				t.Fatal(err)
+			}
+
+			l = openTest(t, Config{Path: l.cfg.Path})
+			v, err := l.Verify()
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if v.Valid || v.Break == nil || v.Break.Line != tt.line || v.Break.Reason != tt.reason {
+				t.Errorf("Verify() break = %+v, want line %d %q", v.Break, tt.line, tt.reason)
+			}
+		})
+	}
+}
+
+func TestRotation(t *testing.T) {
+	// Records are about 200 bytes, so each file holds one
+	l := openTest(t, Config{MaxSize: 400, MaxBackups: 2})
+	for i := 0; i < 8; i++ {
+		record(t, l, Record{Action: ActionValidate, UserID: "alice"})
+	}
+
+	backups, err := l.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("got %d rotated files, want 2", len(backups))
+	}
+	for _, backup := range backups {
+		if !strings.HasPrefix(filepath.Base(backup), "audit.log.20240301T1200") {
+			t.Errorf("rotated file %s is not named after its rotation time", backup)
+		}
+	}
+
+	v, err := l.Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !v.Valid || v.Files != 3 || v.LastSeq != 8 || v.FirstSeq != 6 || v.Records != 3 {
+		t.Errorf("Verify() = %+v, want a valid chain of records 6 to 8 in 3 files", v)
+	}
+}
+
+func TestRotationRenameFailure(t *testing.T) {
+	l := openTest(t, Config{MaxSize: 300})
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	record(t, l, Record{Action: ActionValidate, UserID: "alice"})
+
+	// A non-empty directory in place of the rotated file fails the rename
+	rotated := l.cfg.Path + "." + now.Format(rotatedTimeFormat)
+	if err := os.MkdirAll(filepath.Join(rotated, "blocked"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		err := l.Record(Record{Action: ActionValidate, UserID: "bob"})
+		if err == nil || !strings.Contains(err.Error(), "rotating audit log") {
+			t.Fatalf("Record() error = %v, want a rotation error", err)
+		}
+	}
+
+	if err := os.RemoveAll(rotated); err != nil {
+		t.Fatal(err)
+	}
+	record(t, l, Record{Action: ActionValidate, UserID: "bob"})
+	v, err := l.Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !v.Valid || v.Files != 2 || v.Records != 2 || v.LastSeq != 2 {
+		t.Errorf("Verify() = %+v, want a valid chain of 2 records in 2 files", v)
+	}
+}
+
+func TestRecordConcurrent(t *testing.T) {
+	l := openTest(t, Config{MaxSize: 2000})
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Record(Record{Action: ActionValidate, UserID: "alice"}); err != nil {
+				t.Errorf("Record() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if l.synced != 50 {
+		t.Errorf("synced = %d, want 50", l.synced)
+	}
+	v, err := l.Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !v.Valid || v.Records != 50 || v.LastSeq != 50 {
+		t.Errorf("Verify() = %+v, want a valid chain of 50 records", v)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	l := openTest(t, Config{})
+	record(t, l,
+		Record{Action: ActionValidate, UserID: "alice", TenantID: "acme", TargetFormat: "splunk"},
+		Record{Action: ActionValidate, UserID: "bob", TenantID: "acme", TargetFormat: "sigma"},
+		Record{Action: ActionValidate, UserID: "alice", TenantID: "globex", TargetFormat: "splunk"},
+		Record{Action: ActionValidate, UserID: "alice", TenantID: "acme", TargetFormat: "kql"},
+	)
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []uint64
+	}{
+		{"all newest first", Query{}, []uint64{4, 3, 2, 1}},
+		{"user", Query{UserID: "alice"}, []uint64{4, 3, 1}},
+		{"user and tenant", Query{UserID: "alice", TenantID: "acme"}, []uint64{4, 1}},
+		{"format", Query{TargetFormat: "splunk"}, []uint64{3, 1}},
+		{"limit keeps newest", Query{UserID: "alice", Limit: 2}, []uint64{4, 3}},
+		{"time window", Query{Since: start.Add(2 * time.Second), Until: start.Add(3 * time.Second)}, []uint64{3, 2}},
+		{"no match", Query{TenantID: "initech"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := l.Query(tt.query)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			var got []uint64
+			for _, rec := range records {
+				got = append(got, rec.Seq)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Query() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Query() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	if _, err := l.Query(Query{Since: start.Add(time.Hour), Until: start}); err == nil {
+		t.Error("Query() with until before since returned no error")
+	}
+}
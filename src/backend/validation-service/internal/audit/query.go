@@ -0,0 +1,188 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// Query filters audit records. All set criteria must match.
+type Query struct {
+	UserID        string
+	TenantID      string
+	Action        string
+	TargetFormat  string
+	DetectionHash string
+	// Since and Until bound the record time; zero values are unbounded
+	Since time.Time
+	Until time.Time
+	Limit int
+}
+
+// Normalize applies default and maximum limits to the query
+func (q *Query) Normalize() error {
+	if q.Limit <= 0 {
+		q.Limit = DefaultQueryLimit
+	}
+	if q.Limit > MaxQueryLimit {
+		q.Limit = MaxQueryLimit
+	}
+	if !q.Since.IsZero() && !q.Until.IsZero() && q.Until.Before(q.Since) {
+		return fmt.Errorf("until must not be before since")
+	}
+	return nil
+}
+
+// Matches reports whether the record satisfies the query
+func (q *Query) Matches(rec *Record) bool {
+	switch {
+	case q.UserID != "" && rec.UserID != q.UserID:
+		return false
+	case q.TenantID != "" && rec.TenantID != q.TenantID:
+		return false
+	case q.Action != "" && rec.Action != q.Action:
+		return false
+	case q.TargetFormat != "" && rec.TargetFormat != q.TargetFormat:
+		return false
+	case q.DetectionHash != "" && rec.DetectionHash != q.DetectionHash:
+		return false
+	case !q.Since.IsZero() && rec.Time.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && rec.Time.After(q.Until):
+		return false
+	}
+	return true
+}
+
+// Query returns the newest records matching q, newest first. Lines that
+// cannot be decoded are skipped; Verify reports them.
+func (l *Logger) Query(q Query) ([]Record, error) {
+	if err := q.Normalize(); err != nil {
+		return nil, err
+	}
+	files, size, err := l.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the last Limit matches in a ring, since records are read oldest
+	// first
+	ring := make([]Record, 0, q.Limit)
+	next := 0
+	for _, path := range files {
+		err := scanFile(path, l.readLimit(path, size), func(line []byte, _ int) error {
+			var rec Record
+			if json.Unmarshal(line, &rec) != nil || !q.Matches(&rec) {
+				return nil
+			}
+			if len(ring) < q.Limit {
+				ring = append(ring, rec)
+				return nil
+			}
+			ring[next] = rec
+			next = (next + 1) % q.Limit
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	records := make([]Record, 0, len(ring))
+	for i := len(ring) - 1; i >= 0; i-- {
+		records = append(records, ring[(next+i)%len(ring)])
+	}
+	return records, nil
+}
+
+// readLimit returns how much of path may be read: the current file up to
+// its size when the snapshot was taken, rotated files entirely
+func (l *Logger) readLimit(path string, size int64) int64 {
+	if path == l.cfg.Path {
+		return size
+	}
+	return -1
+}
+
+// ChainBreak locates the first record that does not continue the chain
+type ChainBreak struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Seq    uint64 `json:"seq,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Verification is the outcome of checking the hash chain of the log
+type Verification struct {
+	Valid   bool `json:"valid"`
+	Records int  `json:"records"`
+	Files   int  `json:"files"`
+	// FirstSeq is the first record kept; records before it were rotated out
+	// and removed, so its previous hash cannot be checked
+	FirstSeq uint64      `json:"first_seq,omitempty"`
+	LastSeq  uint64      `json:"last_seq,omitempty"`
+	LastHash string      `json:"last_hash,omitempty"`
+	Break    *ChainBreak `json:"break,omitempty"`
+	// VerifiedAt is when the check completed
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+// Verify checks that every record hashes to its hash and links to the
+// record before it with consecutive sequence numbers. It stops at the
+// first record that does not.
+func (l *Logger) Verify() (*Verification, error) {
+	files, size, err := l.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Verification{Valid: true, Files: len(files)}
+	var prev *Record
+	for _, path := range files {
+		err := scanFile(path, l.readLimit(path, size), func(line []byte, number int) error {
+			if v.Break != nil {
+				return nil
+			}
+			fail := func(seq uint64, reason string) {
+				v.Valid = false
+				v.Break = &ChainBreak{File: filepath.Base(path), Line: number, Seq: seq, Reason: reason}
+			}
+
+			var rec Record
+			if err := json.Unmarshal(line, &rec); err != nil {
+				fail(0, "record cannot be decoded")
+				return nil
+			}
+			hash, err := digest(rec)
+			if err != nil || hash != rec.Hash {
+				fail(rec.Seq, "record does not match its hash")
+				return nil
+			}
+			if prev != nil && rec.PrevHash != prev.Hash {
+				fail(rec.Seq, fmt.Sprintf("previous hash does not match record %d", prev.Seq))
+				return nil
+			}
+			if prev != nil && rec.Seq != prev.Seq+1 {
+				fail(rec.Seq, fmt.Sprintf("sequence number follows %d", prev.Seq))
+				return nil
+			}
+			if prev == nil {
+				v.FirstSeq = rec.Seq
+				if rec.Seq == 1 && rec.PrevHash != "" {
+					fail(rec.Seq, "first record has a previous hash")
+					return nil
+				}
+			}
+			prev = &rec
+			v.Records++
+			v.LastSeq, v.LastHash = rec.Seq, rec.Hash
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	v.VerifiedAt = l.now().UTC()
+	return v, nil
+}
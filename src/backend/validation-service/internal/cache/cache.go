@@ -0,0 +1,39 @@
+// Package cache defines the validation result cache. Results are keyed by a
+// hash of everything that determines them, so identical rules validated again,
+// e.g. in CI, are served without re-running the validators.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"validation-service/internal/models"
+)
+
+// ErrMiss is returned by Get when no result is cached under the key
+var ErrMiss = errors.New("cache miss")
+
+// ResultCache stores validation results by key
+type ResultCache interface {
+	// Get returns the result cached under key or ErrMiss
+	Get(ctx context.Context, key string) (*models.ValidationResult, error)
+	// Set caches result under key for ttl
+	Set(ctx context.Context, key string, result *models.ValidationResult, ttl time.Duration) error
+}
+
+// Key returns the cache key of parts. Parts are length-prefixed before
+// hashing so no two distinct part lists produce the same input.
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		var length [8]byte
+		binary.LittleEndian.PutUint64(length[:], uint64(len(part)))
+		h.Write(length[:])
+		h.Write([]byte(part))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrIdempotencyStoreFull is returned by MemoryIdempotencyStore when the
+// unexpired responses it holds reach its size limit
+var ErrIdempotencyStoreFull = errors.New("idempotency store is full")
+
+// IdempotentResponse is the response recorded for a request made with an
+// idempotency key
+type IdempotentResponse struct {
+	// Fingerprint identifies the request the key was first used with
+	Fingerprint string `json:"fingerprint"`
+	// Complete is false while the request that claimed the key runs
+	Complete    bool   `json:"complete"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	// Header holds the response headers the handler set, such as
+	// Content-Disposition and Deprecation
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+// IdempotencyStore records the responses of requests made with an
+// idempotency key, so a retried request is answered with the response of
+// the first instead of being processed again
+type IdempotencyStore interface {
+	// Reserve claims key for the request with fingerprint for ttl. When the
+	// key is already claimed it reports false with the recorded response,
+	// which is incomplete while the claiming request runs.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotentResponse, bool, error)
+	// Complete records the response of the request that claimed key for ttl
+	Complete(ctx context.Context, key string, response *IdempotentResponse, ttl time.Duration) error
+	// Release drops the claim of key so the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore for a single replica
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	responses map[string]memoryIdempotentResponse
+	size      int
+	maxSize   int
+	now       func() time.Time
+}
+
+// memoryIdempotentResponse is a recorded response and its expiry
+type memoryIdempotentResponse struct {
+	response IdempotentResponse
+	expires  time.Time
+}
+
+// NewMemoryIdempotencyStore creates a store holding unexpired responses of at
+// most maxSize bytes in total
+func NewMemoryIdempotencyStore(maxSize int) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		responses: make(map[string]memoryIdempotentResponse),
+		maxSize:   maxSize,
+		now:       time.Now,
+	}
+}
+
+// Reserve claims key for ttl
+func (s *MemoryIdempotencyStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotentResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if entry, ok := s.responses[key]; ok && now.Before(entry.expires) {
+		response := entry.response
+		return &response, false, nil
+	}
+	response := IdempotentResponse{Fingerprint: fingerprint}
+	if err := s.store(key, response, now.Add(ttl)); err != nil {
+		return nil, false, err
+	}
+	return nil, true, nil
+}
+
+// Complete records the response of key for ttl. Expired responses are
+// dropped when the store reaches its size limit.
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, key string, response *IdempotentResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store(key, *response, s.now().Add(ttl))
+}
+
+// Release drops the claim of key
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drop(key)
+	return nil
+}
+
+// store records response under key until expires, replacing any previous
+// response; the caller holds the lock
+func (s *MemoryIdempotencyStore) store(key string, response IdempotentResponse, expires time.Time) error {
+	s.drop(key)
+	size := idempotentResponseSize(key, response)
+	if s.size+size > s.maxSize {
+		now := s.now()
+		for k, entry := range s.responses {
+			if !now.Before(entry.expires) {
+				s.drop(k)
+			}
+		}
+		if s.size+size > s.maxSize {
+			return ErrIdempotencyStoreFull
+		}
+	}
+	s.responses[key] = memoryIdempotentResponse{response: response, expires: expires}
+	s.size += size
+	return nil
+}
+
+// drop removes the response of key; the caller holds the lock
+func (s *MemoryIdempotencyStore) drop(key string) {
+	if entry, ok := s.responses[key]; ok {
+		s.size -= idempotentResponseSize(key, entry.response)
+		delete(s.responses, key)
+	}
+}
+
+// idempotentResponseSize approximates the memory held by a response
+func idempotentResponseSize(key string, response IdempotentResponse) int {
+	size := len(key) + len(response.Fingerprint) + len(response.ContentType) + len(response.Body)
+	for name, values := range response.Header {
+		size += len(name)
+		for _, value := range values {
+			size += len(value)
+		}
+	}
+	return size
+}
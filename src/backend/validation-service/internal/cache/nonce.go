@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNonceStoreFull is returned by MemoryNonceStore when it holds the maximum
+// number of unexpired nonces
+var ErrNonceStoreFull = errors.New("nonce store is full")
+
+// NonceStore remembers the nonces of signed requests so a captured request
+// cannot be replayed
+type NonceStore interface {
+	// Remember records nonce for ttl. It reports false when the nonce is
+	// already recorded and unexpired.
+	Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// MemoryNonceStore is a NonceStore for a single replica
+type MemoryNonceStore struct {
+	mu         sync.Mutex
+	nonces     map[string]time.Time
+	maxEntries int
+	now        func() time.Time
+}
+
+// NewMemoryNonceStore creates a store holding at most maxEntries unexpired
+// nonces
+func NewMemoryNonceStore(maxEntries int) *MemoryNonceStore {
+	return &MemoryNonceStore{
+		nonces:     make(map[string]time.Time),
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+// Remember records nonce for ttl. Expired nonces are dropped when the store
+// reaches its capacity.
+func (s *MemoryNonceStore) Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if expires, ok := s.nonces[nonce]; ok && now.Before(expires) {
+		return false, nil
+	}
+	if len(s.nonces) >= s.maxEntries {
+		for n, expires := range s.nonces {
+			if !now.Before(expires) {
+				delete(s.nonces, n)
+			}
+		}
+		if len(s.nonces) >= s.maxEntries {
+			return false, ErrNonceStoreFull
+		}
+	}
+	s.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
@@ -0,0 +1,95 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9" // v9.3.0
+
+	"validation-service/internal/cache"
+)
+
+// idempotencyKeyPrefix namespaces idempotent responses in a shared Redis
+const idempotencyKeyPrefix = "validation:idempotency:"
+
+// IdempotencyStore records the responses of requests made with an
+// idempotency key in Redis, so a retry reaching any replica is answered with
+// the recorded response
+type IdempotencyStore struct {
+	client *goredis.Client
+}
+
+// NewIdempotencyStore connects to the Redis server at url and verifies the
+// connection
+func NewIdempotencyStore(ctx context.Context, url string) (*IdempotencyStore, error) {
+	opts, err := goredis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	client := goredis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	return &IdempotencyStore{client: client}, nil
+}
+
+// Reserve claims key for ttl. A claim expiring between the attempt to set it
+// and the read of the recorded response is retried once.
+func (s *IdempotencyStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*cache.IdempotentResponse, bool, error) {
+	claim, err := json.Marshal(cache.IdempotentResponse{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode idempotency claim: %w", err)
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		stored, err := s.client.SetNX(ctx, idempotencyKeyPrefix+key, claim, ttl).Result()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+		}
+		if stored {
+			return nil, true, nil
+		}
+
+		data, err := s.client.Get(ctx, idempotencyKeyPrefix+key).Bytes()
+		if errors.Is(err, goredis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read idempotent response: %w", err)
+		}
+		var response cache.IdempotentResponse
+		if err := json.Unmarshal(data, &response); err != nil {
+			return nil, false, fmt.Errorf("failed to decode idempotent response: %w", err)
+		}
+		return &response, false, nil
+	}
+	return nil, false, errors.New("idempotency key claim expired while read")
+}
+
+// Complete records the response of key for ttl
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, response *cache.IdempotentResponse, ttl time.Duration) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotent response: %w", err)
+	}
+	if err := s.client.Set(ctx, idempotencyKeyPrefix+key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to record idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release drops the claim of key
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, idempotencyKeyPrefix+key).Err(); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Close closes the Redis connection pool
+func (s *IdempotencyStore) Close() error {
+	return s.client.Close()
+}
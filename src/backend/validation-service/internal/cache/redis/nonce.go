@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9" // v9.3.0
+)
+
+// nonceKeyPrefix namespaces request nonces in a shared Redis
+const nonceKeyPrefix = "validation:nonce:"
+
+// NonceStore records signed request nonces in Redis so every replica rejects
+// a replayed request
+type NonceStore struct {
+	client *goredis.Client
+}
+
+// NewNonceStore connects to the Redis server at url and verifies the
+// connection
+func NewNonceStore(ctx context.Context, url string) (*NonceStore, error) {
+	opts, err := goredis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	client := goredis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	return &NonceStore{client: client}, nil
+}
+
+// Remember records nonce for ttl, reporting false when it is already recorded
+func (s *NonceStore) Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	stored, err := s.client.SetNX(ctx, nonceKeyPrefix+nonce, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to record nonce: %w", err)
+	}
+	return stored, nil
+}
+
+// Close closes the Redis connection pool
+func (s *NonceStore) Close() error {
+	return s.client.Close()
+}
@@ -6,8 +6,13 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/mail"
 	"os"
+	"path"
+	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -40,7 +45,114 @@ const (
 	envLogLevel        = "LOG_LEVEL"
 	envMaxRuleSize     = "MAX_RULE_SIZE"
 	envEncryptionKey   = "ENCRYPTION_KEY"
+	envTokenSigningKey = "TOKEN_SIGNING_KEY_FILE"
+	envAuditLogPath    = "AUDIT_LOG_PATH"
+	envOIDCIssuerURL   = "OIDC_ISSUER_URL"
+	envOIDCAudiences   = "OIDC_AUDIENCES"
+	envJWTIssuerURL    = "JWT_ISSUER_URL"
+	envJWTJWKSURL      = "JWT_JWKS_URL"
+	envJWTAudiences    = "JWT_AUDIENCES"
+	envAdminAllowCIDRs = "ADMIN_ALLOWED_CIDRS"
+	envRequireTenant   = "TENANT_CLAIM_REQUIRED"
 	envConfigFile      = "CONFIG_FILE"
+	envDatabaseURL     = "DATABASE_URL"
+	envMigrationMode   = "DATABASE_MIGRATION_MODE"
+	envSearchBackend   = "SEARCH_BACKEND"
+	envSearchIndexPath = "SEARCH_INDEX_PATH"
+	envStorageBackend  = "STORAGE_BACKEND"
+	envSharedLicenses  = "BLOB_SHARED_LICENSES"
+	envBlobGCInterval  = "BLOB_GC_INTERVAL"
+	envBlobGCGrace     = "BLOB_GC_GRACE_PERIOD"
+	envEncryptContent  = "STORAGE_ENCRYPT_CONTENT"
+	envSpoolDir        = "UPLOAD_SPOOL_DIR"
+	envMaxUploadSize   = "UPLOAD_MAX_SIZE"
+	envTenantQuota     = "UPLOAD_TENANT_DISK_QUOTA"
+	envGRPCEnabled     = "GRPC_ENABLED"
+	envGRPCPort        = "GRPC_PORT"
+	envMetricsPort     = "METRICS_PORT"
+	envPprofEnabled    = "PPROF_ENABLED"
+	envAutoscaleTarget = "AUTOSCALING_TARGET_CONCURRENCY"
+	envAdaptiveLogging = "ADAPTIVE_LOGGING_ENABLED"
+	envPlugins         = "VALIDATOR_PLUGINS"
+	envGrammarParsing  = "GRAMMAR_PARSING"
+	envQRadarVersion   = "QRADAR_VERSION"
+	envDefaultProfile  = "VALIDATION_PROFILE"
+	envCacheEnabled    = "CACHE_ENABLED"
+	envRedisURL        = "REDIS_URL"
+	envCacheTTL        = "CACHE_TTL"
+	envIdempotencyTTL  = "IDEMPOTENCY_TTL"
+	envStaleCache      = "CACHE_STALE_WHILE_REVALIDATE"
+	envStaleTTL        = "CACHE_STALE_TTL"
+	envNotifications   = "NOTIFICATIONS_ENABLED"
+	envSMTPHost        = "SMTP_HOST"
+	envSMTPPort        = "SMTP_PORT"
+	envSMTPUsername    = "SMTP_USERNAME"
+	envSMTPPassword    = "SMTP_PASSWORD"
+	envSMTPFrom        = "SMTP_FROM"
+	envSchedules       = "SCHEDULES_ENABLED"
+	envTranslationURL  = "TRANSLATION_SERVICE_URL"
+	envTLSCertFile     = "TLS_CERT_FILE"
+	envTLSKeyFile      = "TLS_KEY_FILE"
+	envTLSClientCAFile = "TLS_CLIENT_CA_FILE"
+	envTLSAllowedSANs  = "TLS_ALLOWED_CLIENT_SANS"
+
+	// OpenTelemetry settings use the standard OTEL_* variable names
+	envTracingEnabled     = "TRACING_ENABLED"
+	envOTLPEndpoint       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	envOTLPProtocol       = "OTEL_EXPORTER_OTLP_PROTOCOL"
+	envOTLPInsecure       = "OTEL_EXPORTER_OTLP_INSECURE"
+	envTracesSampleRatio  = "OTEL_TRACES_SAMPLER_ARG"
+	envTracingServiceName = "OTEL_SERVICE_NAME"
+)
+
+// OTLP exporter protocols
+const (
+	OTLPProtocolGRPC = "grpc"
+	OTLPProtocolHTTP = "http"
+)
+
+// Storage backends for persisted records
+const (
+	StorageBackendMemory   = "memory"
+	StorageBackendPostgres = "postgres"
+)
+
+// Route groups a network policy can be configured for. Health probes are
+// never restricted.
+const (
+	NetworkGroupAPI     = "api"
+	NetworkGroupAdmin   = "admin"
+	NetworkGroupMetrics = "metrics"
+)
+
+// validSeverities are the issue severities a scoring profile can weigh
+var validSeverities = map[string]bool{"high": true, "medium": true, "low": true}
+
+// validLintLevels are the levels a lint profile can assign
+var validLintLevels = map[string]bool{"error": true, "warning": true, "info": true, "style": true}
+
+// qradarVersionPattern matches QRadar release numbers such as 7.4 or 7.5.0
+var qradarVersionPattern = regexp.MustCompile(`^\d+\.\d+(\.\d+)?$`)
+
+// Schema migration modes. Auto applies pending migrations at startup; verify
+// refuses to start while migrations are pending, for deployments that run
+// them as a separate release step.
+const (
+	MigrationModeAuto   = "auto"
+	MigrationModeVerify = "verify"
+)
+
+// Search index backends
+const (
+	SearchBackendMemory   = "memory"
+	SearchBackendBleve    = "bleve"
+	SearchBackendPostgres = "postgres"
+)
+
+// Replay caches remembering the nonces of signed requests
+const (
+	ReplayCacheMemory = "memory"
+	ReplayCacheRedis  = "redis"
 )
 
 // Config represents the complete service configuration
@@ -48,6 +160,7 @@ type Config struct {
 	Environment     string           `json:"environment"`
 	ServerHost      string           `json:"server_host"`
 	ServerPort      int             `json:"server_port"`
+	GRPC            GRPCConfig       `json:"grpc"`
 	RequestTimeout  time.Duration    `json:"request_timeout"`
 	ShutdownTimeout time.Duration    `json:"shutdown_timeout"`
 	MetricsEnabled  bool            `json:"metrics_enabled"`
@@ -55,6 +168,23 @@ type Config struct {
 	Validation      ValidationConfig `json:"validation"`
 	Security        SecurityConfig   `json:"security"`
 	Monitoring      MonitoringConfig `json:"monitoring"`
+	Tracing         TracingConfig    `json:"tracing"`
+	Cache           CacheConfig      `json:"cache"`
+	Remediation     RemediationConfig `json:"remediation"`
+	Database        DatabaseConfig    `json:"database"`
+	Search          SearchConfig      `json:"search"`
+	Storage         StorageConfig     `json:"storage"`
+	Upload          UploadConfig      `json:"upload"`
+	Harness         HarnessConfig     `json:"harness"`
+	Deprecation     DeprecationConfig `json:"deprecation"`
+	NetworkPolicy   NetworkPolicyConfig `json:"network_policy"`
+	Corpus          CorpusConfig        `json:"corpus"`
+	Tenancy         TenancyConfig       `json:"tenancy"`
+	Notifications   NotificationConfig  `json:"notifications"`
+	Schedules       ScheduleConfig      `json:"schedules"`
+	Migrations      MigrationConfig     `json:"migrations"`
+	RefData         RefDataConfig       `json:"refdata"`
+	Outbound        OutboundConfig      `json:"outbound"`
 }
 
 // ValidationConfig contains validation-specific settings
@@ -64,6 +194,209 @@ type ValidationConfig struct {
 	SupportedFormats []string         `json:"supported_formats"`
 	FormatMappings   map[string]string `json:"format_mappings"`
 	StrictValidation bool             `json:"strict_validation"`
+	FieldMappingFile string           `json:"field_mapping_file"`
+	// AttackDatasetFile is the MITRE ATT&CK STIX bundle technique and tactic
+	// references are checked against; references are only extracted when unset
+	AttackDatasetFile string `json:"attack_dataset_file"`
+	// SigmaTaxonomyFile is the Sigma taxonomy the fields of Sigma rules are
+	// checked against; the built-in taxonomy when unset
+	SigmaTaxonomyFile string `json:"sigma_taxonomy_file"`
+	// RulePacksDir holds the YAML rule packs of organization-specific
+	// validation rules, one pack per file; no packs are checked when unset
+	RulePacksDir string `json:"rule_packs_dir"`
+	// Plugins are external validators for formats the service does not ship
+	Plugins []PluginConfig `json:"plugins"`
+	// Lint configures the content lint profiles
+	Lint LintConfig `json:"lint"`
+	// Scoring sets the confidence penalties, threshold and complexity limits
+	Scoring ScoringConfig `json:"scoring"`
+	// GrammarParsing parses SPL, AQL, KQL and YARA-L targets with their
+	// generated grammar parsers before the format validators run
+	GrammarParsing bool `json:"grammar_parsing"`
+	// QRadarVersion is the QRadar release AQL targets must run on, such as
+	// 7.4.3; AQL features of later releases are reported when set
+	QRadarVersion string `json:"qradar_version"`
+	// DefaultProfile is the validation profile of requests that select none;
+	// "standard" when unset
+	DefaultProfile string `json:"default_profile"`
+	// Profiles are named validation profiles selectable per request. They
+	// add to the built-in strict, standard and permissive profiles; a profile
+	// named after a built-in one replaces it.
+	Profiles map[string]ValidationProfile `json:"profiles"`
+	// Platforms are outbound connectors that submit translated queries to
+	// the query planners of target platforms for server-side syntax
+	// validation
+	Platforms []PlatformConfig `json:"platforms"`
+}
+
+// Platform connector types
+const (
+	PlatformElasticsearch = "elasticsearch"
+	PlatformSentinel      = "sentinel"
+)
+
+// PlatformConfig configures a connector that validates translated queries
+// with the parse or plan API of a target platform
+type PlatformConfig struct {
+	// Name identifies the connector in issues, traces and metrics
+	Name string `json:"name"`
+	// Type is "elasticsearch" (the _validate/query API) or "sentinel" (the
+	// Log Analytics query API of the Sentinel workspace)
+	Type string `json:"type"`
+	// Formats are the target formats whose queries are submitted. Sentinel
+	// connectors default to kql and sentinel; Elasticsearch connectors
+	// require them, since the service ships no Lucene format.
+	Formats []string `json:"formats,omitempty"`
+	// URL is the base URL of the platform API; Sentinel connectors default
+	// to https://api.loganalytics.io
+	URL string `json:"url"`
+	// Index is the Elasticsearch index pattern queries are validated
+	// against; defaults to all indices
+	Index string `json:"index,omitempty"`
+	// WorkspaceID, TenantID and ClientID identify the Log Analytics
+	// workspace and the Azure AD application of a Sentinel connector
+	WorkspaceID string `json:"workspace_id,omitempty"`
+	TenantID    string `json:"tenant_id,omitempty"`
+	ClientID    string `json:"client_id,omitempty"`
+	// Username authenticates Elasticsearch requests with basic auth
+	Username string `json:"username,omitempty"`
+	// SecretFile holds the Elasticsearch password, or API key without a
+	// username, or the Azure AD client secret, so it is kept out of the
+	// configuration
+	SecretFile string `json:"secret_file,omitempty"`
+	// Timeout bounds each request to the platform; defaults to 10s
+	Timeout time.Duration `json:"timeout"`
+}
+
+// ValidationProfile bundles how rigorously a validation is judged, so teams
+// can select the rigor they need per request
+type ValidationProfile struct {
+	Description string `json:"description,omitempty"`
+	// Strict reports validations with warnings as errors. A strict tenant
+	// policy applies whatever the profile.
+	Strict bool `json:"strict,omitempty"`
+	// RequiredMeta are the meta fields the target detection must declare,
+	// in its metadata or in the meta of its content
+	RequiredMeta []string `json:"required_meta,omitempty"`
+	// Scoring overrides the severity weights, confidence threshold and
+	// complexity limits of every target format
+	Scoring ScoringProfile `json:"scoring"`
+	// IssueSeverities maps issue codes to the severity they are reported
+	// and weighed with
+	IssueSeverities map[string]string `json:"issue_severities,omitempty"`
+	// Gate classifies the issues of the validations judged with the profile
+	// as blocking or advisory
+	Gate GatePolicy `json:"gate"`
+}
+
+// Issue classes of a gate policy
+const (
+	IssueClassBlocking = "blocking"
+	IssueClassAdvisory = "advisory"
+)
+
+// GatePolicy classifies validation issues as blocking or advisory, so CI
+// pipelines can gate on the verdict of a validation instead of its score and
+// severities. A validation fails the gate when it has a blocking issue, its
+// status is error or its score is below MinConfidence. Settings left unset
+// keep those of the validation profile, or the built-in ones.
+type GatePolicy struct {
+	// BlockingSeverities are the severities of blocking issues; issues of
+	// other severities are advisory. High only when unset.
+	BlockingSeverities []string `json:"blocking_severities,omitempty"`
+	// Issues maps issue codes to "blocking" or "advisory" whatever their
+	// severity
+	Issues map[string]string `json:"issues,omitempty"`
+	// MinConfidence fails the gate below a confidence score; the score does
+	// not gate when unset
+	MinConfidence *float64 `json:"min_confidence,omitempty"`
+}
+
+// Overlay returns the gate policy with the settings override sets. Issue
+// classes are merged, those of override winning.
+func (g GatePolicy) Overlay(override GatePolicy) GatePolicy {
+	if len(override.BlockingSeverities) > 0 {
+		g.BlockingSeverities = override.BlockingSeverities
+	}
+	if len(override.Issues) > 0 {
+		issues := make(map[string]string, len(g.Issues)+len(override.Issues))
+		for code, class := range g.Issues {
+			issues[code] = class
+		}
+		for code, class := range override.Issues {
+			issues[code] = class
+		}
+		g.Issues = issues
+	}
+	if override.MinConfidence != nil {
+		g.MinConfidence = override.MinConfidence
+	}
+	return g
+}
+
+// ScoringConfig sets how validation issues reduce the confidence score.
+// Formats overrides Default by target format; settings left unset keep the
+// built-in values.
+type ScoringConfig struct {
+	Default ScoringProfile            `json:"default"`
+	Formats map[string]ScoringProfile `json:"formats"`
+	// PolicyTimeout bounds the evaluation of a tenant's Rego scoring
+	// policy; a policy that runs longer is ignored for the validation
+	PolicyTimeout time.Duration `json:"policy_timeout"`
+	// MaxPolicySize bounds the size of a tenant's Rego scoring policy
+	MaxPolicySize int `json:"max_policy_size"`
+	// PolicyCacheTTL is how long a replica uses a compiled tenant policy
+	// before checking the store for a newer one
+	PolicyCacheTTL time.Duration `json:"policy_cache_ttl"`
+}
+
+// ScoringProfile sets the confidence penalties and limits of validations
+type ScoringProfile struct {
+	// SeverityWeights are the confidence penalties of an issue by severity
+	// (high, medium or low)
+	SeverityWeights map[string]float64 `json:"severity_weights"`
+	// MinConfidence is the score below which a validation is a warning
+	MinConfidence *float64 `json:"min_confidence"`
+	// MaxComplexity bounds the logic of a rule, such as the SPL pipeline
+	// depth or the YARA-L condition complexity
+	MaxComplexity int `json:"max_complexity"`
+	// MaxNestingDepth bounds the nesting of parentheses, brackets and braces
+	// of a rule
+	MaxNestingDepth int `json:"max_nesting_depth"`
+}
+
+// LintConfig configures content linting. Lint findings are reported apart
+// from validation issues and never affect the confidence score.
+type LintConfig struct {
+	// Disabled turns linting off
+	Disabled bool `json:"disabled"`
+	// DefaultProfile is used when a request names no profile; "default"
+	// when unset
+	DefaultProfile string `json:"default_profile"`
+	// Profiles are named lint profiles selectable per request. A profile
+	// named "default" replaces the built-in one.
+	Profiles map[string]LintProfile `json:"profiles"`
+}
+
+// LintProfile tunes the lint rules for a team or use case
+type LintProfile struct {
+	// MinLevel drops findings below the level (error, warning, info or
+	// style); every level is reported when unset
+	MinLevel string `json:"min_level"`
+	// Rules overrides the level of lint rules by rule ID; "off" disables a rule
+	Rules map[string]string `json:"rules"`
+	// NamePattern is the naming convention detection names must match
+	NamePattern string `json:"name_pattern"`
+	// MaxLineLength is the longest line accepted; 120 when unset
+	MaxLineLength int `json:"max_line_length"`
+}
+
+// PluginConfig describes an external validator loaded at startup
+type PluginConfig struct {
+	// Type is "go" for a Go plugin (.so) or "sidecar" for a subprocess
+	Type string   `json:"type"`
+	Path string   `json:"path"`
+	Args []string `json:"args,omitempty"`
 }
 
 // SecurityConfig contains security-related settings
@@ -72,6 +405,134 @@ type SecurityConfig struct {
 	EnableAuditLog   bool   `json:"enable_audit_log"`
 	AuditLogPath     string `json:"audit_log_path"`
 	MaskSensitiveData bool  `json:"mask_sensitive_data"`
+	// AuditLogMaxSize is the size in bytes at which the audit log is rotated;
+	// defaults to 100MB
+	AuditLogMaxSize int64 `json:"audit_log_max_size"`
+	// AuditLogMaxBackups is the number of rotated audit logs kept; every
+	// rotated log is kept when zero
+	AuditLogMaxBackups int `json:"audit_log_max_backups"`
+	// TokenSigningKeyFile is the PEM RSA private key CI tokens minted by the
+	// token exchange endpoint are signed with; the endpoint is disabled when unset
+	TokenSigningKeyFile string `json:"token_signing_key_file"`
+	// TokenExchangeMaxTTL bounds the lifetime of minted CI tokens
+	TokenExchangeMaxTTL time.Duration `json:"token_exchange_max_ttl"`
+	// JWT configures verification of service tokens
+	JWT JWTConfig `json:"jwt"`
+	// OIDC accepts tokens issued to interactive users by an OpenID Connect
+	// identity provider
+	OIDC OIDCConfig `json:"oidc"`
+	// RequestSigning accepts HMAC-signed requests from integrations such as
+	// webhook verifiers and admin automation
+	RequestSigning RequestSigningConfig `json:"request_signing"`
+	// TLS serves the API and gRPC listeners over TLS, verifying client
+	// certificates when a client CA is configured
+	TLS TLSConfig `json:"tls"`
+}
+
+// Client certificate modes of the API listeners
+const (
+	// TLSClientAuthRequire rejects connections without a valid client
+	// certificate
+	TLSClientAuthRequire = "require"
+	// TLSClientAuthOptional verifies client certificates that are presented,
+	// for migrating callers to mTLS
+	TLSClientAuthOptional = "optional"
+)
+
+// TLSConfig configures TLS and mutual TLS on the API and gRPC listeners. The
+// certificate, key and client CA files are reloaded when they change, so
+// rotated certificates are served without a restart.
+type TLSConfig struct {
+	// CertFile and KeyFile are the PEM certificate chain and private key of
+	// the service; the listeners serve plain HTTP when unset
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+	// ClientCAFile holds the PEM CAs client certificates are verified
+	// against; client certificates are not requested when unset
+	ClientCAFile string `json:"client_ca_file"`
+	// ClientAuth is "require" or "optional"; defaults to "require"
+	ClientAuth string `json:"client_auth"`
+	// AllowedSANs restricts clients to certificates with a DNS name, URI or
+	// email SAN matching one of the patterns, in which * matches any
+	// characters but / as in *.payments.svc.cluster.local or
+	// spiffe://prod.example.com/ns/*/sa/*. Any certificate issued by the
+	// client CAs is accepted when empty.
+	AllowedSANs []string `json:"allowed_sans"`
+	// ReloadInterval is how often the files are checked for changes;
+	// defaults to 1m
+	ReloadInterval time.Duration `json:"reload_interval"`
+	// SPIFFE stores the SPIFFE ID of the client certificate in the request
+	// context, for audit logs and handlers
+	SPIFFE bool `json:"spiffe"`
+}
+
+// RequestSigningConfig configures verification of HMAC-signed requests. Each
+// request carries a timestamp and a nonce; requests outside the clock skew
+// tolerance or reusing a nonce are rejected, so a captured request cannot be
+// replayed.
+type RequestSigningConfig struct {
+	// Keys maps key IDs, sent in the X-Signature-Key header, to shared
+	// secrets; signed requests are rejected when empty
+	Keys map[string]SigningKey `json:"keys"`
+	// MaxClockSkew is how far a request timestamp may differ from the
+	// service clock; defaults to 5m
+	MaxClockSkew time.Duration `json:"max_clock_skew"`
+	// ReplayCache is "memory", or "redis" to share nonces between replicas
+	// through cache.redis_url
+	ReplayCache string `json:"replay_cache"`
+}
+
+// SigningKey is a shared secret for signed requests
+type SigningKey struct {
+	// SecretFile holds the secret, so it is kept out of the configuration
+	SecretFile string `json:"secret_file"`
+	// Scope is the token scope granted to requests signed with the key
+	Scope string `json:"scope"`
+}
+
+// JWTConfig configures verification of service tokens, which carry the
+// user_id, role and permissions claims and are issued by the platform's
+// identity service. Their signing keys are fetched from the issuer's JWK set.
+type JWTConfig struct {
+	// IssuerURL is the accepted "iss" claim; service tokens are rejected when
+	// unset
+	IssuerURL string `json:"issuer_url"`
+	// JWKSURL is the JWK set of the issuer; found through the issuer's
+	// OpenID Connect discovery document when unset
+	JWKSURL string `json:"jwks_url"`
+	// Audiences are the accepted "aud" values
+	Audiences []string `json:"audiences"`
+	// KeyRefreshInterval is how long signing keys are cached. Keys are also
+	// refreshed when a token is signed with an unknown key.
+	KeyRefreshInterval time.Duration `json:"key_refresh_interval"`
+}
+
+// OIDCConfig configures validation of ID and access tokens issued by an OpenID
+// Connect identity provider such as Auth0, Okta or Azure AD. The provider's
+// signing keys are found through its discovery document and IdP groups are
+// mapped to service roles.
+type OIDCConfig struct {
+	// IssuerURL is the issuer of the provider; OIDC is disabled when unset
+	IssuerURL string `json:"issuer_url"`
+	// Audiences are the accepted "aud" values, usually the client ID and
+	// API identifier registered for the service
+	Audiences []string `json:"audiences"`
+	// UserClaim identifies the user in audit logs; defaults to "sub"
+	UserClaim string `json:"user_claim"`
+	// GroupsClaim carries the user's groups; defaults to "groups". Auth0
+	// requires a namespaced custom claim here.
+	GroupsClaim string `json:"groups_claim"`
+	// TenantClaim carries the tenant of the user; defaults to "tenant_id"
+	TenantClaim string `json:"tenant_claim"`
+	// GroupRoles maps IdP groups to roles. A user in several mapped groups
+	// gets the most privileged role.
+	GroupRoles map[string]string `json:"group_roles"`
+	// DefaultRole is granted to users in no mapped group; such tokens are
+	// rejected when unset
+	DefaultRole string `json:"default_role"`
+	// KeyRefreshInterval is how long signing keys are cached. Keys are also
+	// refreshed when a token is signed with an unknown key.
+	KeyRefreshInterval time.Duration `json:"key_refresh_interval"`
 }
 
 // MonitoringConfig contains monitoring and observability settings
@@ -80,32 +541,393 @@ type MonitoringConfig struct {
 	MetricsPort      int           `json:"metrics_port"`
 	EnabledMetrics   []string      `json:"enabled_metrics"`
 	MetricsInterval  time.Duration `json:"metrics_interval"`
+	// ReadTimeout and WriteTimeout apply to the metrics and admin listener,
+	// independently of the API listener. The write timeout must allow for
+	// CPU profiles, which sample for 30 seconds by default.
+	ReadTimeout      time.Duration `json:"read_timeout"`
+	WriteTimeout     time.Duration `json:"write_timeout"`
+	ProfilingEnabled bool          `json:"profiling_enabled"`
+	// AutoscalingTargetConcurrency is the number of running and queued
+	// validations one replica is sized for; the autoscaling endpoint reports
+	// utilization against it
+	AutoscalingTargetConcurrency int `json:"autoscaling_target_concurrency"`
+	// AdaptiveLogging raises logging of an unhealthy format or route to debug
+	AdaptiveLogging AdaptiveLoggingConfig `json:"adaptive_logging"`
 }
 
-// LoadConfig loads and validates service configuration from environment
-// variables and optional configuration file.
-func LoadConfig() (*Config, error) {
-	var cfg Config
-	var err error
+// AdaptiveLoggingConfig raises logging of a detection format or API route to
+// debug for a bounded period while its error rate or latency breaches a
+// threshold, so incidents are captured without debug logging at steady state
+type AdaptiveLoggingConfig struct {
+	Enabled bool `json:"enabled"`
+	// Window is the period error and latency rates are measured over;
+	// defaults to 1m
+	Window time.Duration `json:"window"`
+	// MinRequests is the traffic a scope needs in a window before it can be
+	// boosted; defaults to 20
+	MinRequests int `json:"min_requests"`
+	// ErrorRateThreshold is the share of failed requests, between 0 and 1,
+	// that raises logging; defaults to 0.1
+	ErrorRateThreshold float64 `json:"error_rate_threshold"`
+	// LatencySLO is the latency objective of a request; latency does not
+	// raise logging when unset
+	LatencySLO time.Duration `json:"latency_slo"`
+	// SlowRateThreshold is the share of requests slower than LatencySLO
+	// that raises logging; defaults to 0.05
+	SlowRateThreshold float64 `json:"slow_rate_threshold"`
+	// BoostDuration is how long logging stays at debug; defaults to 10m
+	BoostDuration time.Duration `json:"boost_duration"`
+	// MaxBoostedScopes bounds the formats and routes logged at debug at
+	// once; defaults to 5
+	MaxBoostedScopes int `json:"max_boosted_scopes"`
+}
 
-	// Load configuration file if specified
-	if configFile := os.Getenv(envConfigFile); configFile != "" {
-		if err := loadConfigFile(configFile, &cfg); err != nil {
-			return nil, fmt.Errorf("failed to load config file: %w", err)
-		}
-	}
+// CacheConfig configures the Redis cache of validation results
+type CacheConfig struct {
+	Enabled  bool          `json:"enabled"`
+	RedisURL string        `json:"redis_url"`
+	TTL      time.Duration `json:"ttl"`
+	// IdempotencyTTL is how long the response of a request made with an
+	// Idempotency-Key header is replayed to its retries; defaults to 24h.
+	// Responses are kept in Redis when the cache is enabled and in memory
+	// otherwise.
+	IdempotencyTTL time.Duration `json:"idempotency_ttl"`
+	// StaleWhileRevalidate serves the cached result of content validated by
+	// an earlier validator version or with earlier catalogs while the
+	// content is validated again in the background. Those results are kept
+	// for StaleTTL, which defaults to 7 days.
+	StaleWhileRevalidate bool          `json:"stale_while_revalidate"`
+	StaleTTL             time.Duration `json:"stale_ttl"`
+}
 
-	// Load environment variables with precedence over file config
-	if err := loadEnvConfig(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to load environment config: %w", err)
-	}
+// TracingConfig configures OpenTelemetry tracing and its OTLP exporter
+type TracingConfig struct {
+	Enabled     bool   `json:"enabled"`
+	ServiceName string `json:"service_name"`
+	// Endpoint is the OTLP collector host:port
+	Endpoint string `json:"endpoint"`
+	// Protocol is "grpc" or "http"
+	Protocol string `json:"protocol"`
+	Insecure bool   `json:"insecure"`
+	// SampleRatio is the fraction of new traces sampled, between 0 and 1.
+	// Requests continuing a sampled trace are always traced.
+	SampleRatio float64 `json:"sample_ratio"`
+}
 
-	// Set defaults for unspecified values
-	setDefaults(&cfg)
+// DatabaseConfig contains PostgreSQL connection settings
+type DatabaseConfig struct {
+	URL             string        `json:"url"`
+	MaxOpenConns    int           `json:"max_open_conns"`
+	MaxIdleConns    int           `json:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `json:"conn_max_lifetime"`
+	// MigrationMode is MigrationModeAuto or MigrationModeVerify
+	MigrationMode string `json:"migration_mode"`
+}
 
-	// Validate configuration
-	if err := cfg.validate(); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
+// GRPCConfig configures the gRPC API served alongside HTTP
+type GRPCConfig struct {
+	Enabled bool `json:"enabled"`
+	Port    int  `json:"port"`
+}
+
+// SearchConfig selects and configures the detection search index. The memory
+// backend scans the detection store and needs no index.
+type SearchConfig struct {
+	Backend   string `json:"backend"`
+	IndexPath string `json:"index_path"`
+}
+
+// StorageConfig selects where validation results and rule content are
+// persisted
+type StorageConfig struct {
+	Backend string `json:"backend"`
+	// SharedLicenses lists SPDX license identifiers whose rule content may be
+	// deduplicated across tenants; other content is deduplicated per tenant
+	SharedLicenses []string `json:"shared_licenses"`
+	// BlobGCInterval is how often unreferenced content blobs are collected
+	BlobGCInterval time.Duration `json:"blob_gc_interval"`
+	// BlobGCGracePeriod is how long a blob stays unreferenced before deletion
+	BlobGCGracePeriod time.Duration `json:"blob_gc_grace_period"`
+	// EncryptContent encrypts rule content at rest with per-tenant data keys
+	// wrapped by Security.EncryptionKey
+	EncryptContent bool `json:"encrypt_content"`
+}
+
+// UploadConfig bounds archive and stream uploads. Uploads above
+// MemoryThreshold are spooled to SpoolDir instead of being buffered in memory.
+type UploadConfig struct {
+	SpoolDir        string `json:"spool_dir"`
+	MemoryThreshold int64  `json:"memory_threshold"`
+	MaxSize         int64  `json:"max_size"`
+	// TenantDiskQuota bounds the spooled bytes of one tenant's in-flight uploads
+	TenantDiskQuota int64 `json:"tenant_disk_quota"`
+	// TotalDiskQuota bounds the spooled bytes of all in-flight uploads
+	TotalDiskQuota int64 `json:"total_disk_quota"`
+}
+
+// HarnessConfig bounds test harness runs of detections against submitted
+// sample events
+type HarnessConfig struct {
+	MaxEvents    int           `json:"max_events"`
+	MaxEventSize int           `json:"max_event_size"`
+	Timeout      time.Duration `json:"timeout"`
+}
+
+// CorpusConfig configures the import of public rule repositories into the
+// stored corpus, for overlap and similarity analysis against community content
+type CorpusConfig struct {
+	// TenantID owns the imported rules; defaults to "community"
+	TenantID string `json:"tenant_id"`
+	// Sources are the repositories that can be imported; SigmaHQ,
+	// elastic/detection-rules and Chronicle community rules when unset
+	Sources []CorpusSource `json:"sources"`
+	// MaxArchiveSize bounds the compressed size of a downloaded repository
+	MaxArchiveSize int64 `json:"max_archive_size"`
+	// DownloadTimeout bounds the download of one repository archive
+	DownloadTimeout time.Duration `json:"download_timeout"`
+}
+
+// CorpusSource is a public rule repository downloaded as a gzipped tar archive
+type CorpusSource struct {
+	Name string `json:"name"`
+	// URL of the archive. {ref} is replaced by Ref, so a mirror or a pinned
+	// commit can be imported.
+	URL string `json:"url"`
+	Ref string `json:"ref"`
+	// License is the SPDX identifier recorded on every imported rule
+	License string `json:"license"`
+	// Paths restricts the import to these repository directories; the whole
+	// repository when empty
+	Paths []string `json:"paths,omitempty"`
+	// Formats maps rule file extensions to detection formats
+	Formats map[string]string `json:"formats"`
+}
+
+// TenancyConfig sets how callers are assigned to tenants and the validation
+// policy, rate limit and quota of each tenant. The tenant of a caller is the
+// tenant_id claim of its token; the X-Tenant-ID header is only trusted for
+// tokens without the claim granting the admin:all scope.
+type TenancyConfig struct {
+	// RequireClaim rejects tokens without a tenant claim, including those of
+	// administrators choosing the tenant with the X-Tenant-ID header
+	RequireClaim bool `json:"require_claim"`
+	// Default applies to every tenant; Tenants overrides it by tenant ID
+	Default TenantPolicy            `json:"default"`
+	Tenants map[string]TenantPolicy `json:"tenants"`
+}
+
+// TenantPolicy restricts the validations of a tenant. Settings left unset in
+// a tenant override keep the default policy.
+type TenantPolicy struct {
+	// AllowedFormats are the target formats the tenant may validate; every
+	// format when empty
+	AllowedFormats []string `json:"allowed_formats,omitempty"`
+	// Strict reports validations with warnings as errors
+	Strict *bool `json:"strict,omitempty"`
+	// MinConfidence overrides the confidence threshold of every format
+	MinConfidence *float64 `json:"min_confidence,omitempty"`
+	// RateLimit is the sustained request rate per second and Burst the
+	// requests allowed at once; unlimited when zero
+	RateLimit float64 `json:"rate_limit,omitempty"`
+	Burst     int     `json:"burst,omitempty"`
+	// DailyQuota bounds the API requests of a UTC day; unlimited when zero
+	DailyQuota int `json:"daily_quota,omitempty"`
+	// Gate overrides the gate policy of the validation profile
+	Gate GatePolicy `json:"gate"`
+}
+
+// NotificationConfig sets how events are delivered to the notification
+// subscriptions of users
+type NotificationConfig struct {
+	// Enabled starts the notification dispatcher and digest composer
+	Enabled bool `json:"enabled"`
+	// QueueSize bounds the events awaiting delivery; events published while
+	// the queue is full are dropped
+	QueueSize int `json:"queue_size"`
+	// DeliveryTimeout bounds the delivery of one message to a channel
+	DeliveryTimeout time.Duration `json:"delivery_timeout"`
+	// DailyDigestHour is the UTC hour daily digests are sent at
+	DailyDigestHour int `json:"daily_digest_hour"`
+	// DigestMaxEvents bounds the events listed in a digest; every event is
+	// counted in its summary
+	DigestMaxEvents int `json:"digest_max_events"`
+	// Email configures the SMTP server email notifications, validation
+	// reports and job summaries are sent through
+	Email EmailConfig `json:"email"`
+}
+
+// ScheduleConfig sets how the recurring jobs tenants schedule are run
+type ScheduleConfig struct {
+	// Enabled starts the scheduler on this replica; schedules can be managed
+	// either way, and due runs are claimed by one scheduling replica
+	Enabled bool `json:"enabled"`
+	// MaxPerTenant bounds the schedules of a tenant
+	MaxPerTenant int `json:"max_per_tenant"`
+	// MinInterval is the shortest time allowed between two runs of a
+	// schedule
+	MinInterval time.Duration `json:"min_interval"`
+	// RunTimeout bounds a run; until then the run blocks the next runs of
+	// its schedule
+	RunTimeout time.Duration `json:"run_timeout"`
+}
+
+// MigrationConfig sets how the migration wizards of tenants translate and
+// validate their rules
+type MigrationConfig struct {
+	// TranslationURL is the base URL of the translation service; wizards
+	// can be prepared but not run when unset
+	TranslationURL string `json:"translation_url"`
+	// TranslationTimeout bounds the translation of one rule
+	TranslationTimeout time.Duration `json:"translation_timeout"`
+	// MaxPerTenant bounds the wizards of a tenant
+	MaxPerTenant int `json:"max_per_tenant"`
+	// MaxRules bounds the rules uploaded to a wizard
+	MaxRules int `json:"max_rules"`
+	// MaxTargets bounds the target formats of a wizard
+	MaxTargets int `json:"max_targets"`
+	// RunTimeout bounds a run; a run that has not finished by then, such as
+	// one of a replica that stopped, can be resumed
+	RunTimeout time.Duration `json:"run_timeout"`
+}
+
+// Reference datasets that can be reloaded at runtime
+const (
+	RefDataAttack        = "attack"
+	RefDataFieldMappings = "field_mappings"
+	RefDataSigmaTaxonomy = "sigma_taxonomy"
+)
+
+// RefDataConfig sets where reference datasets are refreshed from. Datasets
+// without a URL are reloaded from their configured file.
+type RefDataConfig struct {
+	// URLs maps dataset names, attack, field_mappings or sigma_taxonomy, to
+	// the URL the dataset is downloaded from
+	URLs map[string]string `json:"urls"`
+	// RefreshInterval is how often datasets are checked for changes; they
+	// are only reloaded through the admin API when zero
+	RefreshInterval time.Duration `json:"refresh_interval"`
+	// DownloadTimeout bounds the download of one dataset
+	DownloadTimeout time.Duration `json:"download_timeout"`
+	// MaxSize bounds the size of a dataset
+	MaxSize int64 `json:"max_size"`
+}
+
+// OutboundConfig tunes the HTTP client shared by outbound integrations:
+// platform connectors, webhook and Slack notifications, reference data and
+// corpus downloads and OIDC discovery
+type OutboundConfig struct {
+	// MaxIdleConns bounds the idle connections kept across hosts and
+	// MaxIdleConnsPerHost those kept to one host
+	MaxIdleConns        int `json:"max_idle_conns"`
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host"`
+	// IdleConnTimeout is how long an idle connection is kept
+	IdleConnTimeout time.Duration `json:"idle_conn_timeout"`
+	// FailureThreshold consecutive failures of a host stop requests to it
+	// for OpenDuration, after which one request probes the host
+	FailureThreshold int           `json:"failure_threshold"`
+	OpenDuration     time.Duration `json:"open_duration"`
+	// MaxRetries bounds the retries of a transient failure; RetryBackoff is
+	// the wait before the first, doubled for each further retry
+	MaxRetries   int           `json:"max_retries"`
+	RetryBackoff time.Duration `json:"retry_backoff"`
+	// RetryRatio is the retries each request of an integration earns, so
+	// retries stay a fraction of its requests
+	RetryRatio float64 `json:"retry_ratio"`
+}
+
+// SMTP transport security modes
+const (
+	SMTPTLSStartTLS = "starttls"
+	SMTPTLSImplicit = "tls"
+	SMTPTLSNone     = "none"
+)
+
+// EmailConfig sets the SMTP server and addresses of outgoing email. Email is
+// disabled without a host.
+type EmailConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// TLS is starttls (default), tls for implicit TLS, or none
+	TLS string `json:"tls"`
+	// From is the sender address; TenantFrom overrides it by tenant ID
+	From       string            `json:"from"`
+	TenantFrom map[string]string `json:"tenant_from,omitempty"`
+	// Recipients receive validation reports and job summaries;
+	// TenantRecipients replaces them by tenant ID
+	Recipients       []string            `json:"recipients,omitempty"`
+	TenantRecipients map[string][]string `json:"tenant_recipients,omitempty"`
+	// MaxAttachmentSize bounds the total size of the attachments of one
+	// message in bytes; attachments beyond it are left out
+	MaxAttachmentSize int64 `json:"max_attachment_size"`
+}
+
+// Enabled reports whether an SMTP server is configured
+func (c EmailConfig) Enabled() bool {
+	return c.Host != ""
+}
+
+// DeprecationConfig lists API routes that are being retired
+type DeprecationConfig struct {
+	Routes []DeprecatedRoute `json:"routes"`
+}
+
+// DeprecatedRoute announces the deprecation of an API route. Responses carry
+// Deprecation, Sunset and successor Link headers, and calls are counted per
+// client for the deprecation report.
+type DeprecatedRoute struct {
+	// Method restricts the entry to one HTTP method; every method when empty
+	Method string `json:"method"`
+	// Path is the full request path. A {name} segment matches any single
+	// segment and a trailing /* matches the rest of the path.
+	Path         string    `json:"path"`
+	DeprecatedAt time.Time `json:"deprecated_at"`
+	// Sunset is when the route stops being served; no Sunset header when zero
+	Sunset time.Time `json:"sunset"`
+	// Successor is the URL of the replacement endpoint or its documentation
+	Successor string `json:"successor"`
+}
+
+// NetworkPolicyConfig restricts the source addresses allowed to call each
+// route group, in addition to token authentication
+type NetworkPolicyConfig struct {
+	// Groups maps a route group ("api", "admin" or "metrics") to its policy
+	Groups map[string]NetworkPolicy `json:"groups"`
+	// TrustedProxies are the load balancers whose X-Forwarded-For header is
+	// trusted to carry the client address. The connection's peer address is
+	// checked for requests from any other address.
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+// NetworkPolicy lists CIDRs, or single addresses, allowed and denied for a
+// route group. Deny entries take precedence; any address not denied is
+// allowed when Allow is empty.
+type NetworkPolicy struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// RemediationConfig contains tenant-specific remediation playbook mappings
+type RemediationConfig struct {
+	// Playbooks maps tenant ID -> issue code -> playbook entry. The issue code
+	// "*" acts as a tenant-wide fallback for codes without a dedicated entry.
+	Playbooks map[string]map[string]PlaybookEntry `json:"playbooks"`
+}
+
+// PlaybookEntry links an issue code to a tenant runbook. URL may contain the
+// placeholders {issue_code}, {location}, {severity} and {format}.
+type PlaybookEntry struct {
+	URL      string `json:"url"`
+	Guidance string `json:"guidance,omitempty"`
+}
+
+// LoadConfig loads and validates service configuration from environment
+// variables and optional configuration file.
+func LoadConfig() (*Config, error) {
+	cfg, err := readConfig()
+	if err != nil {
+		return nil, err
 	}
 
 	// Initialize logger with config settings
@@ -122,12 +944,47 @@ func LoadConfig() (*Config, error) {
 
 	// Store configuration globally
 	configMutex.Lock()
-	config = &cfg
+	config = cfg
 	configMutex.Unlock()
+	recordReload(ReloadStatus{LoadedAt: time.Now().UTC(), Trigger: ReloadTriggerStartup})
+
+	return cfg, nil
+}
 
+// readConfig reads the configuration file and environment, applies defaults
+// and validates the result
+func readConfig() (*Config, error) {
+	// Settings whose zero value is not their default
+	cfg := Config{Validation: ValidationConfig{StrictValidation: true}}
+
+	// Load configuration file if specified
+	if configFile := FilePath(); configFile != "" {
+		if err := loadConfigFile(configFile, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to load config file: %w", err)
+		}
+	}
+
+	// Load environment variables with precedence over file config
+	if err := loadEnvConfig(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to load environment config: %w", err)
+	}
+
+	// Set defaults for unspecified values
+	setDefaults(&cfg)
+
+	// Validate configuration
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
 	return &cfg, nil
 }
 
+// FilePath returns the configuration file named by CONFIG_FILE, or an empty
+// string when the configuration comes from the environment only
+func FilePath() string {
+	return os.Getenv(envConfigFile)
+}
+
 // GetConfig returns the global configuration instance in a thread-safe manner
 func GetConfig() *Config {
 	configMutex.RLock()
@@ -159,31 +1016,229 @@ func loadEnvConfig(cfg *Config) error {
 	cfg.Environment = getEnvOrDefault(envEnvironment, EnvDevelopment)
 	cfg.ServerHost = getEnvOrDefault(envServerHost, "0.0.0.0")
 	cfg.ServerPort = getEnvAsIntOrDefault(envServerPort, 8080)
+	cfg.GRPC.Enabled = getEnvAsBoolOrDefault(envGRPCEnabled, true)
+	cfg.GRPC.Port = getEnvAsIntOrDefault(envGRPCPort, 50051)
 	cfg.RequestTimeout = getEnvAsDurationOrDefault(envRequestTimeout, 30*time.Second)
 	cfg.ShutdownTimeout = getEnvAsDurationOrDefault(envShutdownTimeout, 10*time.Second)
 	cfg.MetricsEnabled = getEnvAsBoolOrDefault(envMetricsEnabled, true)
-	cfg.LogLevel = getEnvOrDefault(envLogLevel, "info")
+	// Settings that can be reloaded at runtime keep their file value unless
+	// overridden; their defaults are applied by setDefaults
+	cfg.LogLevel = getEnvOrDefault(envLogLevel, cfg.LogLevel)
 
 	// Validation settings
-	cfg.Validation.MaxRuleSize = getEnvAsIntOrDefault(envMaxRuleSize, 1024*1024) // 1MB
-	cfg.Validation.ValidationTimeout = getEnvAsDurationOrDefault("VALIDATION_TIMEOUT", 5*time.Second)
-	cfg.Validation.StrictValidation = getEnvAsBoolOrDefault("STRICT_VALIDATION", true)
+	cfg.Validation.MaxRuleSize = getEnvAsIntOrDefault(envMaxRuleSize, cfg.Validation.MaxRuleSize)
+	cfg.Validation.ValidationTimeout = getEnvAsDurationOrDefault("VALIDATION_TIMEOUT", cfg.Validation.ValidationTimeout)
+	cfg.Validation.StrictValidation = getEnvAsBoolOrDefault("STRICT_VALIDATION", cfg.Validation.StrictValidation)
+	cfg.Validation.GrammarParsing = getEnvAsBoolOrDefault(envGrammarParsing, cfg.Validation.GrammarParsing)
+	cfg.Validation.QRadarVersion = getEnvOrDefault(envQRadarVersion, cfg.Validation.QRadarVersion)
+	cfg.Validation.DefaultProfile = getEnvOrDefault(envDefaultProfile, cfg.Validation.DefaultProfile)
+	if mappingFile := os.Getenv("FIELD_MAPPING_FILE"); mappingFile != "" {
+		cfg.Validation.FieldMappingFile = mappingFile
+	}
+	if datasetFile := os.Getenv("ATTACK_DATASET_FILE"); datasetFile != "" {
+		cfg.Validation.AttackDatasetFile = datasetFile
+	}
+	if taxonomyFile := os.Getenv("SIGMA_TAXONOMY_FILE"); taxonomyFile != "" {
+		cfg.Validation.SigmaTaxonomyFile = taxonomyFile
+	}
+	cfg.Validation.RulePacksDir = getEnvOrDefault("RULE_PACKS_DIR", cfg.Validation.RulePacksDir)
+	if plugins := os.Getenv(envPlugins); plugins != "" {
+		// Comma separated type:path entries, e.g. sidecar:/opt/validators/acme
+		cfg.Validation.Plugins = nil
+		for _, entry := range strings.Split(plugins, ",") {
+			if entry = strings.TrimSpace(entry); entry == "" {
+				continue
+			}
+			pluginType, path, _ := strings.Cut(entry, ":")
+			cfg.Validation.Plugins = append(cfg.Validation.Plugins, PluginConfig{Type: pluginType, Path: path})
+		}
+	}
+
+	// Database settings
+	if databaseURL := os.Getenv(envDatabaseURL); databaseURL != "" {
+		cfg.Database.URL = databaseURL
+	}
+	if mode := os.Getenv(envMigrationMode); mode != "" {
+		cfg.Database.MigrationMode = mode
+	}
+
+	// Search settings
+	if backend := os.Getenv(envSearchBackend); backend != "" {
+		cfg.Search.Backend = backend
+	}
+	if indexPath := os.Getenv(envSearchIndexPath); indexPath != "" {
+		cfg.Search.IndexPath = indexPath
+	}
+
+	// Storage settings
+	if backend := os.Getenv(envStorageBackend); backend != "" {
+		cfg.Storage.Backend = backend
+	}
+	if licenses := os.Getenv(envSharedLicenses); licenses != "" {
+		cfg.Storage.SharedLicenses = nil
+		for _, license := range strings.Split(licenses, ",") {
+			if license = strings.TrimSpace(license); license != "" {
+				cfg.Storage.SharedLicenses = append(cfg.Storage.SharedLicenses, license)
+			}
+		}
+	}
+	cfg.Storage.BlobGCInterval = getEnvAsDurationOrDefault(envBlobGCInterval, cfg.Storage.BlobGCInterval)
+	cfg.Storage.BlobGCGracePeriod = getEnvAsDurationOrDefault(envBlobGCGrace, cfg.Storage.BlobGCGracePeriod)
+	cfg.Storage.EncryptContent = getEnvAsBoolOrDefault(envEncryptContent, cfg.Storage.EncryptContent)
+
+	// Upload settings
+	if spoolDir := os.Getenv(envSpoolDir); spoolDir != "" {
+		cfg.Upload.SpoolDir = spoolDir
+	}
+	cfg.Upload.MaxSize = int64(getEnvAsIntOrDefault(envMaxUploadSize, int(cfg.Upload.MaxSize)))
+	cfg.Upload.TenantDiskQuota = int64(getEnvAsIntOrDefault(envTenantQuota, int(cfg.Upload.TenantDiskQuota)))
+
+	// Metrics and admin listener settings
+	cfg.Monitoring.MetricsPort = getEnvAsIntOrDefault(envMetricsPort, cfg.Monitoring.MetricsPort)
+	cfg.Monitoring.ProfilingEnabled = getEnvAsBoolOrDefault(envPprofEnabled, cfg.Monitoring.ProfilingEnabled)
+	cfg.Monitoring.AutoscalingTargetConcurrency = getEnvAsIntOrDefault(envAutoscaleTarget, cfg.Monitoring.AutoscalingTargetConcurrency)
+	cfg.Monitoring.AdaptiveLogging.Enabled = getEnvAsBoolOrDefault(envAdaptiveLogging, cfg.Monitoring.AdaptiveLogging.Enabled)
+
+	// Result cache settings
+	cfg.Cache.Enabled = getEnvAsBoolOrDefault(envCacheEnabled, cfg.Cache.Enabled)
+	if redisURL := os.Getenv(envRedisURL); redisURL != "" {
+		cfg.Cache.RedisURL = redisURL
+	}
+	cfg.Cache.TTL = getEnvAsDurationOrDefault(envCacheTTL, cfg.Cache.TTL)
+	cfg.Cache.IdempotencyTTL = getEnvAsDurationOrDefault(envIdempotencyTTL, cfg.Cache.IdempotencyTTL)
+	cfg.Cache.StaleWhileRevalidate = getEnvAsBoolOrDefault(envStaleCache, cfg.Cache.StaleWhileRevalidate)
+	cfg.Cache.StaleTTL = getEnvAsDurationOrDefault(envStaleTTL, cfg.Cache.StaleTTL)
+	cfg.Notifications.Enabled = getEnvAsBoolOrDefault(envNotifications, cfg.Notifications.Enabled)
+	cfg.Notifications.Email.Host = getEnvOrDefault(envSMTPHost, cfg.Notifications.Email.Host)
+	cfg.Notifications.Email.Port = getEnvAsIntOrDefault(envSMTPPort, cfg.Notifications.Email.Port)
+	cfg.Notifications.Email.Username = getEnvOrDefault(envSMTPUsername, cfg.Notifications.Email.Username)
+	cfg.Notifications.Email.Password = getEnvOrDefault(envSMTPPassword, cfg.Notifications.Email.Password)
+	cfg.Notifications.Email.From = getEnvOrDefault(envSMTPFrom, cfg.Notifications.Email.From)
+	cfg.Schedules.Enabled = getEnvAsBoolOrDefault(envSchedules, cfg.Schedules.Enabled)
+	cfg.Migrations.TranslationURL = getEnvOrDefault(envTranslationURL, cfg.Migrations.TranslationURL)
+
+	// Tracing settings
+	cfg.Tracing.Enabled = getEnvAsBoolOrDefault(envTracingEnabled, cfg.Tracing.Enabled)
+	if endpoint := os.Getenv(envOTLPEndpoint); endpoint != "" {
+		cfg.Tracing.Endpoint = endpoint
+	}
+	if protocol := os.Getenv(envOTLPProtocol); protocol != "" {
+		cfg.Tracing.Protocol = protocol
+	}
+	cfg.Tracing.Insecure = getEnvAsBoolOrDefault(envOTLPInsecure, cfg.Tracing.Insecure)
+	if ratio := os.Getenv(envTracesSampleRatio); ratio != "" {
+		sampleRatio, err := strconv.ParseFloat(ratio, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", envTracesSampleRatio, err)
+		}
+		cfg.Tracing.SampleRatio = sampleRatio
+	}
+	if serviceName := os.Getenv(envTracingServiceName); serviceName != "" {
+		cfg.Tracing.ServiceName = serviceName
+	}
 
 	// Security settings
 	cfg.Security.EncryptionKey = os.Getenv(envEncryptionKey)
 	cfg.Security.EnableAuditLog = getEnvAsBoolOrDefault("ENABLE_AUDIT_LOG", true)
+	if auditLogPath := os.Getenv(envAuditLogPath); auditLogPath != "" {
+		cfg.Security.AuditLogPath = auditLogPath
+	}
 	cfg.Security.MaskSensitiveData = getEnvAsBoolOrDefault("MASK_SENSITIVE_DATA", true)
+	if keyFile := os.Getenv(envTokenSigningKey); keyFile != "" {
+		cfg.Security.TokenSigningKeyFile = keyFile
+	}
+	if cidrs := os.Getenv(envAdminAllowCIDRs); cidrs != "" {
+		if cfg.NetworkPolicy.Groups == nil {
+			cfg.NetworkPolicy.Groups = make(map[string]NetworkPolicy)
+		}
+		admin := cfg.NetworkPolicy.Groups[NetworkGroupAdmin]
+		admin.Allow = nil
+		for _, cidr := range strings.Split(cidrs, ",") {
+			if cidr = strings.TrimSpace(cidr); cidr != "" {
+				admin.Allow = append(admin.Allow, cidr)
+			}
+		}
+		cfg.NetworkPolicy.Groups[NetworkGroupAdmin] = admin
+	}
+	if issuerURL := os.Getenv(envOIDCIssuerURL); issuerURL != "" {
+		cfg.Security.OIDC.IssuerURL = issuerURL
+	}
+	if audiences := os.Getenv(envOIDCAudiences); audiences != "" {
+		cfg.Security.OIDC.Audiences = nil
+		for _, audience := range strings.Split(audiences, ",") {
+			if audience = strings.TrimSpace(audience); audience != "" {
+				cfg.Security.OIDC.Audiences = append(cfg.Security.OIDC.Audiences, audience)
+			}
+		}
+	}
+	if issuerURL := os.Getenv(envJWTIssuerURL); issuerURL != "" {
+		cfg.Security.JWT.IssuerURL = issuerURL
+	}
+	if jwksURL := os.Getenv(envJWTJWKSURL); jwksURL != "" {
+		cfg.Security.JWT.JWKSURL = jwksURL
+	}
+	if audiences := os.Getenv(envJWTAudiences); audiences != "" {
+		cfg.Security.JWT.Audiences = nil
+		for _, audience := range strings.Split(audiences, ",") {
+			if audience = strings.TrimSpace(audience); audience != "" {
+				cfg.Security.JWT.Audiences = append(cfg.Security.JWT.Audiences, audience)
+			}
+		}
+	}
+	cfg.Tenancy.RequireClaim = getEnvAsBoolOrDefault(envRequireTenant, cfg.Tenancy.RequireClaim)
+	if certFile := os.Getenv(envTLSCertFile); certFile != "" {
+		cfg.Security.TLS.CertFile = certFile
+	}
+	if keyFile := os.Getenv(envTLSKeyFile); keyFile != "" {
+		cfg.Security.TLS.KeyFile = keyFile
+	}
+	if caFile := os.Getenv(envTLSClientCAFile); caFile != "" {
+		cfg.Security.TLS.ClientCAFile = caFile
+	}
+	if sans := os.Getenv(envTLSAllowedSANs); sans != "" {
+		cfg.Security.TLS.AllowedSANs = nil
+		for _, san := range strings.Split(sans, ",") {
+			if san = strings.TrimSpace(san); san != "" {
+				cfg.Security.TLS.AllowedSANs = append(cfg.Security.TLS.AllowedSANs, san)
+			}
+		}
+	}
 
 	return nil
 }
 
 // setDefaults sets default values for unspecified configuration
 func setDefaults(cfg *Config) {
+	if cfg.LogLevel == "" {
+		cfg.LogLevel = "info"
+	}
+	if cfg.Validation.MaxRuleSize == 0 {
+		cfg.Validation.MaxRuleSize = 1024 * 1024 // 1MB
+	}
+	if cfg.Validation.ValidationTimeout == 0 {
+		cfg.Validation.ValidationTimeout = 5 * time.Second
+	}
+	for i := range cfg.Validation.Platforms {
+		platform := &cfg.Validation.Platforms[i]
+		if platform.Timeout == 0 {
+			platform.Timeout = 10 * time.Second
+		}
+		if platform.Type == PlatformSentinel {
+			if platform.URL == "" {
+				platform.URL = "https://api.loganalytics.io"
+			}
+			if len(platform.Formats) == 0 {
+				platform.Formats = []string{"kql", "sentinel"}
+			}
+		}
+	}
+
 	// Set default supported formats if not specified
 	if len(cfg.Validation.SupportedFormats) == 0 {
 		cfg.Validation.SupportedFormats = []string{
 			"splunk", "qradar", "sigma", "kql",
-			"paloalto", "crowdstrike", "yara", "yara-l",
+			"paloalto", "crowdstrike", "yara", "yara-l", "sentinel",
+			"suricata", "snort", "zeek",
 		}
 	}
 
@@ -197,11 +1252,299 @@ func setDefaults(cfg *Config) {
 	if cfg.Monitoring.MetricsInterval == 0 {
 		cfg.Monitoring.MetricsInterval = 15 * time.Second
 	}
+	if cfg.Monitoring.ReadTimeout == 0 {
+		cfg.Monitoring.ReadTimeout = 5 * time.Second
+	}
+	if cfg.Monitoring.WriteTimeout == 0 {
+		cfg.Monitoring.WriteTimeout = 60 * time.Second
+	}
+	if cfg.Monitoring.AutoscalingTargetConcurrency == 0 {
+		cfg.Monitoring.AutoscalingTargetConcurrency = 8
+	}
+
+	// Set default security configuration
+	if cfg.Security.TokenExchangeMaxTTL == 0 {
+		cfg.Security.TokenExchangeMaxTTL = time.Hour
+	}
+	if cfg.Security.OIDC.UserClaim == "" {
+		cfg.Security.OIDC.UserClaim = "sub"
+	}
+	if cfg.Security.OIDC.TenantClaim == "" {
+		cfg.Security.OIDC.TenantClaim = "tenant_id"
+	}
+	if cfg.Security.OIDC.GroupsClaim == "" {
+		cfg.Security.OIDC.GroupsClaim = "groups"
+	}
+	if cfg.Security.OIDC.KeyRefreshInterval == 0 {
+		cfg.Security.OIDC.KeyRefreshInterval = time.Hour
+	}
+	if cfg.Security.JWT.KeyRefreshInterval == 0 {
+		cfg.Security.JWT.KeyRefreshInterval = time.Hour
+	}
+	if cfg.Security.TLS.ClientAuth == "" {
+		cfg.Security.TLS.ClientAuth = TLSClientAuthRequire
+	}
+	if cfg.Security.TLS.ReloadInterval == 0 {
+		cfg.Security.TLS.ReloadInterval = time.Minute
+	}
+	if cfg.Security.RequestSigning.MaxClockSkew == 0 {
+		cfg.Security.RequestSigning.MaxClockSkew = 5 * time.Minute
+	}
+	if cfg.Security.RequestSigning.ReplayCache == "" {
+		cfg.Security.RequestSigning.ReplayCache = ReplayCacheMemory
+	}
+
+	// Set default cache configuration
+	if cfg.Cache.TTL == 0 {
+		cfg.Cache.TTL = 24 * time.Hour
+	}
+	if cfg.Cache.IdempotencyTTL == 0 {
+		cfg.Cache.IdempotencyTTL = 24 * time.Hour
+	}
+	if cfg.Cache.StaleTTL == 0 {
+		cfg.Cache.StaleTTL = 7 * 24 * time.Hour
+	}
+
+	// Set default tracing configuration. A zero sample ratio samples every
+	// trace; disable tracing to sample none.
+	if cfg.Tracing.ServiceName == "" {
+		cfg.Tracing.ServiceName = "validation-service"
+	}
+	if cfg.Tracing.Endpoint == "" {
+		cfg.Tracing.Endpoint = "localhost:4317"
+	}
+	if cfg.Tracing.Protocol == "" {
+		cfg.Tracing.Protocol = OTLPProtocolGRPC
+	}
+	if cfg.Tracing.SampleRatio == 0 {
+		cfg.Tracing.SampleRatio = 1
+	}
+
+	// Set default adaptive logging thresholds
+	if cfg.Monitoring.AdaptiveLogging.Window == 0 {
+		cfg.Monitoring.AdaptiveLogging.Window = time.Minute
+	}
+	if cfg.Monitoring.AdaptiveLogging.MinRequests == 0 {
+		cfg.Monitoring.AdaptiveLogging.MinRequests = 20
+	}
+	if cfg.Monitoring.AdaptiveLogging.ErrorRateThreshold == 0 {
+		cfg.Monitoring.AdaptiveLogging.ErrorRateThreshold = 0.1
+	}
+	if cfg.Monitoring.AdaptiveLogging.SlowRateThreshold == 0 {
+		cfg.Monitoring.AdaptiveLogging.SlowRateThreshold = 0.05
+	}
+	if cfg.Monitoring.AdaptiveLogging.BoostDuration == 0 {
+		cfg.Monitoring.AdaptiveLogging.BoostDuration = 10 * time.Minute
+	}
+	if cfg.Monitoring.AdaptiveLogging.MaxBoostedScopes == 0 {
+		cfg.Monitoring.AdaptiveLogging.MaxBoostedScopes = 5
+	}
+
+	// Set default database pool configuration
+	if cfg.Database.MaxOpenConns == 0 {
+		cfg.Database.MaxOpenConns = 20
+	}
+	if cfg.Database.MaxIdleConns == 0 {
+		cfg.Database.MaxIdleConns = 5
+	}
+	if cfg.Database.ConnMaxLifetime == 0 {
+		cfg.Database.ConnMaxLifetime = 30 * time.Minute
+	}
+	if cfg.Database.MigrationMode == "" {
+		cfg.Database.MigrationMode = MigrationModeAuto
+	}
+
+	// Set default search configuration
+	if cfg.Search.Backend == "" {
+		cfg.Search.Backend = SearchBackendMemory
+	}
+	if cfg.Search.Backend == SearchBackendBleve && cfg.Search.IndexPath == "" {
+		cfg.Search.IndexPath = "/var/lib/validation-service/search.bleve"
+	}
+
+	// Set default storage configuration
+	if cfg.Storage.Backend == "" {
+		cfg.Storage.Backend = StorageBackendMemory
+	}
+	if cfg.Storage.SharedLicenses == nil {
+		cfg.Storage.SharedLicenses = []string{"DRL-1.1", "MIT", "Apache-2.0"}
+	}
+	if cfg.Storage.BlobGCInterval == 0 {
+		cfg.Storage.BlobGCInterval = time.Hour
+	}
+	if cfg.Storage.BlobGCGracePeriod == 0 {
+		cfg.Storage.BlobGCGracePeriod = 24 * time.Hour
+	}
+
+	// Set default corpus sources
+	if cfg.Corpus.TenantID == "" {
+		cfg.Corpus.TenantID = "community"
+	}
+	if cfg.Corpus.Sources == nil {
+		cfg.Corpus.Sources = []CorpusSource{
+			{
+				Name:    "sigmahq",
+				URL:     "https://github.com/SigmaHQ/sigma/archive/{ref}.tar.gz",
+				Ref:     "master",
+				License: "DRL-1.1",
+				Paths:   []string{"rules", "rules-emerging-threats", "rules-threat-hunting", "rules-compliance", "rules-dfir"},
+				Formats: map[string]string{".yml": "sigma"},
+			},
+			{
+				Name:    "elastic",
+				URL:     "https://github.com/elastic/detection-rules/archive/{ref}.tar.gz",
+				Ref:     "main",
+				License: "Elastic-2.0",
+				Paths:   []string{"rules", "rules_building_block"},
+				Formats: map[string]string{".toml": "elastic"},
+			},
+			{
+				Name:    "chronicle",
+				URL:     "https://github.com/chronicle/detection-rules/archive/{ref}.tar.gz",
+				Ref:     "main",
+				License: "Apache-2.0",
+				Formats: map[string]string{".yaral": "yaral"},
+			},
+		}
+	}
+	if cfg.Corpus.MaxArchiveSize == 0 {
+		cfg.Corpus.MaxArchiveSize = 256 << 20 // 256MB
+	}
+	if cfg.Corpus.DownloadTimeout == 0 {
+		cfg.Corpus.DownloadTimeout = 5 * time.Minute
+	}
+
+	// Set default notification delivery
+	if cfg.Notifications.QueueSize == 0 {
+		cfg.Notifications.QueueSize = 1000
+	}
+	if cfg.Notifications.DeliveryTimeout == 0 {
+		cfg.Notifications.DeliveryTimeout = 10 * time.Second
+	}
+	if cfg.Notifications.DigestMaxEvents == 0 {
+		cfg.Notifications.DigestMaxEvents = 25
+	}
+	if cfg.Notifications.Email.TLS == "" {
+		cfg.Notifications.Email.TLS = SMTPTLSStartTLS
+	}
+	if cfg.Notifications.Email.Port == 0 {
+		cfg.Notifications.Email.Port = 587
+		if cfg.Notifications.Email.TLS == SMTPTLSImplicit {
+			cfg.Notifications.Email.Port = 465
+		}
+	}
+	if cfg.Notifications.Email.MaxAttachmentSize == 0 {
+		cfg.Notifications.Email.MaxAttachmentSize = 10 << 20
+	}
+
+	// Set default schedule limits
+	if cfg.Schedules.MaxPerTenant == 0 {
+		cfg.Schedules.MaxPerTenant = 50
+	}
+	if cfg.Schedules.MinInterval == 0 {
+		cfg.Schedules.MinInterval = 15 * time.Minute
+	}
+	if cfg.Schedules.RunTimeout == 0 {
+		cfg.Schedules.RunTimeout = 2 * time.Hour
+	}
+
+	// Set default migration wizard limits
+	if cfg.Migrations.TranslationTimeout == 0 {
+		cfg.Migrations.TranslationTimeout = 30 * time.Second
+	}
+	if cfg.Migrations.MaxPerTenant == 0 {
+		cfg.Migrations.MaxPerTenant = 20
+	}
+	if cfg.Migrations.MaxRules == 0 {
+		cfg.Migrations.MaxRules = 2000
+	}
+	if cfg.Migrations.MaxTargets == 0 {
+		cfg.Migrations.MaxTargets = 5
+	}
+	if cfg.Migrations.RunTimeout == 0 {
+		cfg.Migrations.RunTimeout = time.Hour
+	}
+
+	// Set default scoring policy limits
+	if cfg.Validation.Scoring.PolicyTimeout == 0 {
+		cfg.Validation.Scoring.PolicyTimeout = 250 * time.Millisecond
+	}
+	if cfg.Validation.Scoring.MaxPolicySize == 0 {
+		cfg.Validation.Scoring.MaxPolicySize = 64 << 10 // 64KB
+	}
+	if cfg.Validation.Scoring.PolicyCacheTTL == 0 {
+		cfg.Validation.Scoring.PolicyCacheTTL = 30 * time.Second
+	}
+
+	// Set default reference data limits
+	if cfg.RefData.DownloadTimeout == 0 {
+		cfg.RefData.DownloadTimeout = 2 * time.Minute
+	}
+	if cfg.RefData.MaxSize == 0 {
+		cfg.RefData.MaxSize = 128 << 20 // 128MB
+	}
+
+	// Set default outbound HTTP client settings
+	if cfg.Outbound.MaxIdleConns == 0 {
+		cfg.Outbound.MaxIdleConns = 100
+	}
+	if cfg.Outbound.MaxIdleConnsPerHost == 0 {
+		cfg.Outbound.MaxIdleConnsPerHost = 10
+	}
+	if cfg.Outbound.IdleConnTimeout == 0 {
+		cfg.Outbound.IdleConnTimeout = 90 * time.Second
+	}
+	if cfg.Outbound.FailureThreshold == 0 {
+		cfg.Outbound.FailureThreshold = 5
+	}
+	if cfg.Outbound.OpenDuration == 0 {
+		cfg.Outbound.OpenDuration = 30 * time.Second
+	}
+	if cfg.Outbound.MaxRetries == 0 {
+		cfg.Outbound.MaxRetries = 2
+	}
+	if cfg.Outbound.RetryBackoff == 0 {
+		cfg.Outbound.RetryBackoff = 200 * time.Millisecond
+	}
+	if cfg.Outbound.RetryRatio == 0 {
+		cfg.Outbound.RetryRatio = 0.2
+	}
+
+	// Set default upload limits
+	if cfg.Upload.SpoolDir == "" {
+		cfg.Upload.SpoolDir = "/tmp/validation-service/spool"
+	}
+	if cfg.Upload.MemoryThreshold == 0 {
+		cfg.Upload.MemoryThreshold = 8 << 20 // 8MB
+	}
+	if cfg.Upload.MaxSize == 0 {
+		cfg.Upload.MaxSize = 512 << 20 // 512MB
+	}
+	if cfg.Upload.TenantDiskQuota == 0 {
+		cfg.Upload.TenantDiskQuota = 1 << 30 // 1GB
+	}
+	if cfg.Upload.TotalDiskQuota == 0 {
+		cfg.Upload.TotalDiskQuota = 4 << 30 // 4GB
+	}
+
+	// Set default test harness limits
+	if cfg.Harness.MaxEvents == 0 {
+		cfg.Harness.MaxEvents = 1000
+	}
+	if cfg.Harness.MaxEventSize == 0 {
+		cfg.Harness.MaxEventSize = 64 << 10 // 64KB
+	}
+	if cfg.Harness.Timeout == 0 {
+		cfg.Harness.Timeout = 5 * time.Second
+	}
 
 	// Set default audit log path if enabled
 	if cfg.Security.EnableAuditLog && cfg.Security.AuditLogPath == "" {
 		cfg.Security.AuditLogPath = "/var/log/validation-service/audit.log"
 	}
+	if cfg.Security.AuditLogMaxSize == 0 {
+		cfg.Security.AuditLogMaxSize = 100 << 20 // 100MB
+	}
 }
 
 // validate performs comprehensive validation of all configuration settings
@@ -218,6 +1561,42 @@ func (c *Config) validate() error {
 	if c.ServerPort < 1 || c.ServerPort > 65535 {
 		return fmt.Errorf("invalid server port: %d", c.ServerPort)
 	}
+	if c.GRPC.Enabled && (c.GRPC.Port < 1 || c.GRPC.Port > 65535 || c.GRPC.Port == c.ServerPort) {
+		return fmt.Errorf("invalid gRPC port: %d", c.GRPC.Port)
+	}
+	if c.Monitoring.MetricsPort < 1 || c.Monitoring.MetricsPort > 65535 || c.Monitoring.MetricsPort == c.ServerPort ||
+		(c.GRPC.Enabled && c.Monitoring.MetricsPort == c.GRPC.Port) {
+		return fmt.Errorf("invalid metrics port: %d", c.Monitoring.MetricsPort)
+	}
+	if c.Monitoring.AutoscalingTargetConcurrency < 1 {
+		return fmt.Errorf("autoscaling target concurrency must be positive")
+	}
+
+	// Validate cache configuration
+	if c.Cache.Enabled {
+		if c.Cache.RedisURL == "" {
+			return fmt.Errorf("redis URL required for the result cache")
+		}
+		if c.Cache.TTL <= 0 {
+			return fmt.Errorf("invalid cache TTL: %v", c.Cache.TTL)
+		}
+		if c.Cache.StaleWhileRevalidate && c.Cache.StaleTTL <= 0 {
+			return fmt.Errorf("invalid stale cache TTL: %v", c.Cache.StaleTTL)
+		}
+	}
+	if c.Cache.IdempotencyTTL <= 0 {
+		return fmt.Errorf("invalid idempotency TTL: %v", c.Cache.IdempotencyTTL)
+	}
+
+	// Validate tracing configuration
+	if c.Tracing.Enabled {
+		if c.Tracing.Protocol != OTLPProtocolGRPC && c.Tracing.Protocol != OTLPProtocolHTTP {
+			return fmt.Errorf("invalid OTLP protocol: %s", c.Tracing.Protocol)
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			return fmt.Errorf("invalid trace sample ratio: %v", c.Tracing.SampleRatio)
+		}
+	}
 
 	// Validate timeouts
 	if c.RequestTimeout < time.Second {
@@ -234,16 +1613,430 @@ func (c *Config) validate() error {
 	if len(c.Validation.SupportedFormats) == 0 {
 		return fmt.Errorf("no supported formats specified")
 	}
+	for name, profile := range c.Validation.Lint.Profiles {
+		if profile.MinLevel != "" && !validLintLevels[profile.MinLevel] {
+			return fmt.Errorf("lint profile %s: invalid min_level %q", name, profile.MinLevel)
+		}
+		for rule, level := range profile.Rules {
+			if level != "off" && !validLintLevels[level] {
+				return fmt.Errorf("lint profile %s: invalid level %q for rule %s", name, level, rule)
+			}
+		}
+		if profile.NamePattern != "" {
+			if _, err := regexp.Compile(profile.NamePattern); err != nil {
+				return fmt.Errorf("lint profile %s: invalid name_pattern: %w", name, err)
+			}
+		}
+		if profile.MaxLineLength < 0 {
+			return fmt.Errorf("lint profile %s: max_line_length must not be negative", name)
+		}
+	}
+	if name := c.Validation.Lint.DefaultProfile; name != "" && name != "default" {
+		if _, ok := c.Validation.Lint.Profiles[name]; !ok {
+			return fmt.Errorf("unknown default lint profile: %s", name)
+		}
+	}
+	if c.Validation.Scoring.PolicyTimeout < 0 || c.Validation.Scoring.MaxPolicySize < 0 || c.Validation.Scoring.PolicyCacheTTL < 0 {
+		return fmt.Errorf("scoring policy settings must not be negative")
+	}
+	scoringProfiles := map[string]ScoringProfile{"default": c.Validation.Scoring.Default}
+	for format, profile := range c.Validation.Scoring.Formats {
+		scoringProfiles["format "+format] = profile
+	}
+	for name, profile := range c.Validation.Profiles {
+		scoringProfiles["of profile "+name] = profile.Scoring
+		for code, severity := range profile.IssueSeverities {
+			if !validSeverities[severity] {
+				return fmt.Errorf("validation profile %s: invalid severity %q for issue %s", name, severity, code)
+			}
+		}
+		for _, field := range profile.RequiredMeta {
+			if strings.TrimSpace(field) == "" {
+				return fmt.Errorf("validation profile %s: required_meta contains an empty field", name)
+			}
+		}
+		if err := validateGatePolicy(profile.Gate); err != nil {
+			return fmt.Errorf("validation profile %s: %w", name, err)
+		}
+	}
+	for name, profile := range scoringProfiles {
+		for severity, weight := range profile.SeverityWeights {
+			if !validSeverities[severity] {
+				return fmt.Errorf("scoring %s: invalid severity %q", name, severity)
+			}
+			if weight < 0 || weight > 100 {
+				return fmt.Errorf("scoring %s: weight of %s must be between 0 and 100", name, severity)
+			}
+		}
+		if minConfidence := profile.MinConfidence; minConfidence != nil && (*minConfidence < 0 || *minConfidence > 100) {
+			return fmt.Errorf("scoring %s: min_confidence must be between 0 and 100", name)
+		}
+		if profile.MaxComplexity < 0 {
+			return fmt.Errorf("scoring %s: max_complexity must not be negative", name)
+		}
+		if profile.MaxNestingDepth < 0 {
+			return fmt.Errorf("scoring %s: max_nesting_depth must not be negative", name)
+		}
+	}
+	if v := c.Validation.QRadarVersion; v != "" && !qradarVersionPattern.MatchString(v) {
+		return fmt.Errorf("invalid qradar_version %q: expected a release such as 7.5.0", v)
+	}
+	for _, plugin := range c.Validation.Plugins {
+		if (plugin.Type != "go" && plugin.Type != "sidecar") || plugin.Path == "" {
+			return fmt.Errorf("invalid validator plugin %s:%s", plugin.Type, plugin.Path)
+		}
+	}
+	platforms := make(map[string]bool, len(c.Validation.Platforms))
+	for _, platform := range c.Validation.Platforms {
+		if err := platform.validate(); err != nil {
+			return fmt.Errorf("platform connector %s: %w", platform.Name, err)
+		}
+		if platforms[platform.Name] {
+			return fmt.Errorf("duplicate platform connector: %s", platform.Name)
+		}
+		platforms[platform.Name] = true
+	}
 
 	// Validate security configuration
 	if c.Environment == EnvProduction && c.Security.EncryptionKey == "" {
 		return fmt.Errorf("encryption key required in production")
 	}
 
+	if c.Security.AuditLogMaxSize < 0 || c.Security.AuditLogMaxBackups < 0 {
+		return fmt.Errorf("audit log max size and max backups must not be negative")
+	}
+
+	if c.Security.TokenExchangeMaxTTL < time.Minute || c.Security.TokenExchangeMaxTTL > time.Hour {
+		return fmt.Errorf("token exchange max TTL must be between 1m and 1h: %v", c.Security.TokenExchangeMaxTTL)
+	}
+
+	if oidc := c.Security.OIDC; oidc.IssuerURL != "" {
+		if !strings.HasPrefix(oidc.IssuerURL, "https://") {
+			return fmt.Errorf("OIDC issuer URL must use https: %s", oidc.IssuerURL)
+		}
+		if len(oidc.Audiences) == 0 {
+			return fmt.Errorf("OIDC audiences required when an issuer is configured")
+		}
+		if len(oidc.GroupRoles) == 0 && oidc.DefaultRole == "" {
+			return fmt.Errorf("OIDC group roles or a default role required")
+		}
+	}
+
+	if jwt := c.Security.JWT; jwt.IssuerURL != "" {
+		if jwt.JWKSURL == "" && !strings.HasPrefix(jwt.IssuerURL, "https://") {
+			return fmt.Errorf("JWT issuer URL must use https for key discovery: %s", jwt.IssuerURL)
+		}
+		if jwt.JWKSURL != "" && !strings.HasPrefix(jwt.JWKSURL, "https://") {
+			return fmt.Errorf("JWT JWKS URL must use https: %s", jwt.JWKSURL)
+		}
+		if len(jwt.Audiences) == 0 {
+			return fmt.Errorf("JWT audiences required when an issuer is configured")
+		}
+		if jwt.IssuerURL == c.Security.OIDC.IssuerURL {
+			return fmt.Errorf("JWT issuer must differ from the OIDC issuer: %s", jwt.IssuerURL)
+		}
+		if jwt.KeyRefreshInterval < time.Minute {
+			return fmt.Errorf("JWT key refresh interval must be at least 1m: %v", jwt.KeyRefreshInterval)
+		}
+	}
+
+	if signing := c.Security.RequestSigning; len(signing.Keys) > 0 {
+		if signing.MaxClockSkew < time.Second || signing.MaxClockSkew > time.Hour {
+			return fmt.Errorf("request signing max clock skew must be between 1s and 1h: %v", signing.MaxClockSkew)
+		}
+		switch signing.ReplayCache {
+		case ReplayCacheMemory:
+		case ReplayCacheRedis:
+			if c.Cache.RedisURL == "" {
+				return fmt.Errorf("redis URL required for the redis replay cache")
+			}
+		default:
+			return fmt.Errorf("invalid replay cache: %s", signing.ReplayCache)
+		}
+		for id, key := range signing.Keys {
+			if key.SecretFile == "" || key.Scope == "" {
+				return fmt.Errorf("request signing key %s requires a secret file and a scope", id)
+			}
+		}
+	}
+
+	if err := c.Security.TLS.validate(); err != nil {
+		return err
+	}
+
+	// Validate database configuration
+	if c.Database.MigrationMode != MigrationModeAuto && c.Database.MigrationMode != MigrationModeVerify {
+		return fmt.Errorf("invalid database migration mode: %s", c.Database.MigrationMode)
+	}
+
+	// Validate search configuration
+	switch c.Search.Backend {
+	case SearchBackendMemory, SearchBackendBleve:
+		// Valid backend
+	case SearchBackendPostgres:
+		if c.Database.URL == "" {
+			return fmt.Errorf("database URL required for postgres search backend")
+		}
+	default:
+		return fmt.Errorf("invalid search backend: %s", c.Search.Backend)
+	}
+
+	// Validate storage configuration
+	switch c.Storage.Backend {
+	case StorageBackendMemory:
+		// Valid backend
+	case StorageBackendPostgres:
+		if c.Database.URL == "" {
+			return fmt.Errorf("database URL required for postgres storage backend")
+		}
+	default:
+		return fmt.Errorf("invalid storage backend: %s", c.Storage.Backend)
+	}
+	if c.Storage.BlobGCInterval < 0 || c.Storage.BlobGCGracePeriod < 0 {
+		return fmt.Errorf("blob garbage collection durations must not be negative")
+	}
+	if c.Upload.MemoryThreshold < 0 || c.Upload.MaxSize < 0 || c.Upload.TenantDiskQuota < 0 || c.Upload.TotalDiskQuota < 0 {
+		return fmt.Errorf("upload limits must not be negative")
+	}
+	if c.Harness.MaxEvents < 0 || c.Harness.MaxEventSize < 0 || c.Harness.Timeout < 0 {
+		return fmt.Errorf("test harness limits must not be negative")
+	}
+	if c.Storage.EncryptContent && c.Security.EncryptionKey == "" {
+		return fmt.Errorf("encryption key required for content encryption")
+	}
+
+	// Validate adaptive logging thresholds
+	adaptive := c.Monitoring.AdaptiveLogging
+	if adaptive.ErrorRateThreshold <= 0 || adaptive.ErrorRateThreshold > 1 ||
+		adaptive.SlowRateThreshold <= 0 || adaptive.SlowRateThreshold > 1 {
+		return fmt.Errorf("adaptive logging thresholds must be between 0 and 1")
+	}
+	if adaptive.Window < 0 || adaptive.LatencySLO < 0 || adaptive.BoostDuration < 0 || adaptive.MinRequests < 0 {
+		return fmt.Errorf("adaptive logging settings must not be negative")
+	}
+
+	// Validate corpus sources
+	if c.Corpus.MaxArchiveSize < 0 || c.Corpus.DownloadTimeout < 0 {
+		return fmt.Errorf("corpus import limits must not be negative")
+	}
+	corpusSources := make(map[string]bool, len(c.Corpus.Sources))
+	for _, source := range c.Corpus.Sources {
+		if source.Name == "" || corpusSources[source.Name] {
+			return fmt.Errorf("corpus sources require unique names: %q", source.Name)
+		}
+		corpusSources[source.Name] = true
+		if !strings.HasPrefix(source.URL, "https://") && !strings.HasPrefix(source.URL, "http://") {
+			return fmt.Errorf("invalid URL for corpus source %s: %q", source.Name, source.URL)
+		}
+		if len(source.Formats) == 0 {
+			return fmt.Errorf("corpus source %s maps no file extensions to formats", source.Name)
+		}
+	}
+
+	// Validate notification delivery
+	if c.Notifications.QueueSize < 0 || c.Notifications.DeliveryTimeout < 0 || c.Notifications.DigestMaxEvents < 0 {
+		return fmt.Errorf("notification settings must not be negative")
+	}
+	if c.Notifications.DailyDigestHour < 0 || c.Notifications.DailyDigestHour > 23 {
+		return fmt.Errorf("daily digest hour must be between 0 and 23")
+	}
+	if err := validateEmail(c.Notifications.Email); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+
+	// Validate schedule limits
+	if c.Schedules.MaxPerTenant < 0 || c.Schedules.MinInterval < 0 || c.Schedules.RunTimeout < 0 {
+		return fmt.Errorf("schedule settings must not be negative")
+	}
+
+	// Validate migration wizard settings
+	if c.Migrations.TranslationTimeout < 0 || c.Migrations.MaxPerTenant < 0 || c.Migrations.MaxRules < 0 ||
+		c.Migrations.MaxTargets < 0 || c.Migrations.RunTimeout < 0 {
+		return fmt.Errorf("migration settings must not be negative")
+	}
+	if url := c.Migrations.TranslationURL; url != "" && !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return fmt.Errorf("invalid translation service URL: %q", url)
+	}
+
+	// Validate reference data sources
+	if c.RefData.RefreshInterval < 0 || c.RefData.DownloadTimeout < 0 || c.RefData.MaxSize < 0 {
+		return fmt.Errorf("reference data settings must not be negative")
+	}
+	for name, url := range c.RefData.URLs {
+		if name != RefDataAttack && name != RefDataFieldMappings && name != RefDataSigmaTaxonomy {
+			return fmt.Errorf("unknown reference dataset %q", name)
+		}
+		if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+			return fmt.Errorf("invalid URL for reference dataset %s: %q", name, url)
+		}
+	}
+
+	// Validate outbound HTTP client settings
+	if c.Outbound.MaxIdleConns < 0 || c.Outbound.MaxIdleConnsPerHost < 0 || c.Outbound.IdleConnTimeout < 0 ||
+		c.Outbound.FailureThreshold < 0 || c.Outbound.OpenDuration < 0 || c.Outbound.MaxRetries < 0 ||
+		c.Outbound.RetryBackoff < 0 || c.Outbound.RetryRatio < 0 {
+		return fmt.Errorf("outbound HTTP client settings must not be negative")
+	}
+
+	// Validate tenant policies
+	if err := validateTenantPolicy(c.Tenancy.Default); err != nil {
+		return fmt.Errorf("default tenant policy: %w", err)
+	}
+	for tenant, policy := range c.Tenancy.Tenants {
+		if tenant == "" {
+			return fmt.Errorf("tenant policies require a tenant ID")
+		}
+		if err := validateTenantPolicy(policy); err != nil {
+			return fmt.Errorf("tenant policy for %s: %w", tenant, err)
+		}
+	}
+
+	// Validate deprecated routes
+	for _, route := range c.Deprecation.Routes {
+		if !strings.HasPrefix(route.Path, "/") {
+			return fmt.Errorf("invalid deprecated route path: %q", route.Path)
+		}
+		if route.DeprecatedAt.IsZero() {
+			return fmt.Errorf("deprecated route %s requires deprecated_at", route.Path)
+		}
+		if !route.Sunset.IsZero() && !route.Sunset.After(route.DeprecatedAt) {
+			return fmt.Errorf("sunset of deprecated route %s must be after deprecated_at", route.Path)
+		}
+	}
+
+	// Validate network policies
+	for group, policy := range c.NetworkPolicy.Groups {
+		switch group {
+		case NetworkGroupAPI, NetworkGroupAdmin, NetworkGroupMetrics:
+		default:
+			return fmt.Errorf("unknown network policy route group: %s", group)
+		}
+		for _, cidr := range append(append([]string{}, policy.Allow...), policy.Deny...) {
+			if _, err := ParseNetwork(cidr); err != nil {
+				return fmt.Errorf("network policy for %s: %w", group, err)
+			}
+		}
+	}
+	for _, cidr := range c.NetworkPolicy.TrustedProxies {
+		if _, err := ParseNetwork(cidr); err != nil {
+			return fmt.Errorf("trusted proxies: %w", err)
+		}
+	}
+
+	// Validate remediation playbooks
+	for tenant, entries := range c.Remediation.Playbooks {
+		for code, entry := range entries {
+			if entry.URL == "" && entry.Guidance == "" {
+				return fmt.Errorf("empty playbook entry for tenant %s, issue code %s", tenant, code)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateTenantPolicy checks the limits of a tenant policy
+func validateTenantPolicy(policy TenantPolicy) error {
+	if policy.RateLimit < 0 || policy.Burst < 0 || policy.DailyQuota < 0 {
+		return fmt.Errorf("rate limits and quotas must not be negative")
+	}
+	if policy.RateLimit > 0 && policy.Burst == 0 {
+		return fmt.Errorf("a rate limit requires a burst")
+	}
+	if policy.MinConfidence != nil && (*policy.MinConfidence < 0 || *policy.MinConfidence > 100) {
+		return fmt.Errorf("min_confidence must be between 0 and 100")
+	}
+	for _, format := range policy.AllowedFormats {
+		if format == "" {
+			return fmt.Errorf("allowed formats must not be empty")
+		}
+	}
+	return validateGatePolicy(policy.Gate)
+}
+
+// validateGatePolicy checks the severities, issue classes and threshold of a
+// gate policy
+func validateGatePolicy(gate GatePolicy) error {
+	for _, severity := range gate.BlockingSeverities {
+		if !validSeverities[severity] {
+			return fmt.Errorf("gate: invalid blocking severity %q", severity)
+		}
+	}
+	for code, class := range gate.Issues {
+		if code == "" {
+			return fmt.Errorf("gate: empty issue code")
+		}
+		if class != IssueClassBlocking && class != IssueClassAdvisory {
+			return fmt.Errorf("gate: issue %s must be %s or %s, not %q", code, IssueClassBlocking, IssueClassAdvisory, class)
+		}
+	}
+	if gate.MinConfidence != nil && (*gate.MinConfidence < 0 || *gate.MinConfidence > 100) {
+		return fmt.Errorf("gate: min_confidence must be between 0 and 100")
+	}
+	return nil
+}
+
+// validateEmail checks the SMTP settings and the addresses of the email
+// configuration
+func validateEmail(email EmailConfig) error {
+	switch email.TLS {
+	case SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone:
+	default:
+		return fmt.Errorf("unknown tls mode %q", email.TLS)
+	}
+	if email.Port < 0 || email.Port > 65535 || email.MaxAttachmentSize < 0 {
+		return fmt.Errorf("port and max attachment size must be valid")
+	}
+	if !email.Enabled() {
+		return nil
+	}
+	if email.TLS == SMTPTLSNone && email.Username != "" {
+		return fmt.Errorf("credentials require tls or starttls")
+	}
+	if _, err := mail.ParseAddress(email.From); err != nil {
+		return fmt.Errorf("from: %w", err)
+	}
+	for tenant, from := range email.TenantFrom {
+		if _, err := mail.ParseAddress(from); err != nil {
+			return fmt.Errorf("from of tenant %q: %w", tenant, err)
+		}
+	}
+	for _, recipient := range email.Recipients {
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			return fmt.Errorf("recipient %q: %w", recipient, err)
+		}
+	}
+	for tenant, recipients := range email.TenantRecipients {
+		for _, recipient := range recipients {
+			if _, err := mail.ParseAddress(recipient); err != nil {
+				return fmt.Errorf("recipient %q of tenant %q: %w", recipient, tenant, err)
+			}
+		}
+	}
 	return nil
 }
 
 // Helper functions for environment variable parsing
+// ParseNetwork parses a CIDR such as "10.0.0.0/8", or a single address as a
+// network of that address only
+func ParseNetwork(cidr string) (*net.IPNet, error) {
+	if strings.Contains(cidr, "/") {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", cidr)
+		}
+		return network, nil
+	}
+	ip := net.ParseIP(cidr)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid address %q", cidr)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -276,4 +2069,60 @@ func getEnvAsDurationOrDefault(key string, defaultValue time.Duration) time.Dura
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+// validate checks the settings a platform connector of the type requires
+func (p PlatformConfig) validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !strings.HasPrefix(p.URL, "https://") && !strings.HasPrefix(p.URL, "http://") {
+		return fmt.Errorf("invalid URL: %q", p.URL)
+	}
+	if len(p.Formats) == 0 {
+		return fmt.Errorf("formats are required")
+	}
+	if p.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	switch p.Type {
+	case PlatformElasticsearch:
+		if p.Username != "" && p.SecretFile == "" {
+			return fmt.Errorf("a secret file with the password is required with a username")
+		}
+	case PlatformSentinel:
+		if p.WorkspaceID == "" || p.TenantID == "" || p.ClientID == "" || p.SecretFile == "" {
+			return fmt.Errorf("workspace_id, tenant_id, client_id and secret_file are required")
+		}
+	default:
+		return fmt.Errorf("unknown type: %q", p.Type)
+	}
+	return nil
+}
+
+// validate checks the TLS settings are complete and consistent
+func (t TLSConfig) validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("TLS requires both a certificate and a key file")
+	}
+	if t.ClientCAFile != "" && t.CertFile == "" {
+		return fmt.Errorf("a TLS client CA requires a certificate and a key file")
+	}
+	if (len(t.AllowedSANs) > 0 || t.SPIFFE) && t.ClientCAFile == "" {
+		return fmt.Errorf("TLS allowed SANs and SPIFFE IDs require a client CA file")
+	}
+	for _, pattern := range t.AllowedSANs {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid TLS allowed SAN pattern %q", pattern)
+		}
+	}
+	switch t.ClientAuth {
+	case TLSClientAuthRequire, TLSClientAuthOptional:
+	default:
+		return fmt.Errorf("invalid TLS client auth: %s", t.ClientAuth)
+	}
+	if t.ReloadInterval < time.Second {
+		return fmt.Errorf("TLS reload interval must be at least 1s: %v", t.ReloadInterval)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reload triggers recorded in ReloadStatus
+const (
+	ReloadTriggerStartup = "startup"
+	ReloadTriggerFile    = "file"
+	ReloadTriggerSignal  = "sighup"
+	ReloadTriggerAPI     = "api"
+)
+
+// redactedValue replaces secrets in the active configuration
+const redactedValue = "[REDACTED]"
+
+// reloadableFields are the settings, by JSON path, applied by Reload. Every
+// other setting is read once at startup.
+var reloadableFields = map[string]func(dst, src *Config){
+	"log_level": func(dst, src *Config) { dst.LogLevel = src.LogLevel },
+	"validation.max_rule_size": func(dst, src *Config) {
+		dst.Validation.MaxRuleSize = src.Validation.MaxRuleSize
+	},
+	"validation.validation_timeout": func(dst, src *Config) {
+		dst.Validation.ValidationTimeout = src.Validation.ValidationTimeout
+	},
+	"validation.strict_validation": func(dst, src *Config) {
+		dst.Validation.StrictValidation = src.Validation.StrictValidation
+	},
+	"monitoring.enabled_metrics": func(dst, src *Config) {
+		dst.Monitoring.EnabledMetrics = src.Monitoring.EnabledMetrics
+	},
+	"monitoring.metrics_interval": func(dst, src *Config) {
+		dst.Monitoring.MetricsInterval = src.Monitoring.MetricsInterval
+	},
+}
+
+// ReloadStatus describes the most recent configuration load
+type ReloadStatus struct {
+	LoadedAt time.Time `json:"loaded_at"`
+	Trigger  string    `json:"trigger"`
+	// Applied lists the settings that changed and took effect
+	Applied []string `json:"applied,omitempty"`
+	// RequiresRestart lists the settings that changed but are only read at
+	// startup; they keep their previous value until the service restarts
+	RequiresRestart []string `json:"requires_restart,omitempty"`
+	// Error is set when the configuration could not be loaded; the previous
+	// configuration stays active
+	Error string `json:"error,omitempty"`
+}
+
+var (
+	reloadMutex  sync.Mutex
+	lastReload   ReloadStatus
+	reloadHooks  []func(*Config)
+	reloadHookMu sync.RWMutex
+)
+
+// OnReload registers fn to be called with the new configuration after a
+// reload changed a setting
+func OnReload(fn func(*Config)) {
+	reloadHookMu.Lock()
+	defer reloadHookMu.Unlock()
+	reloadHooks = append(reloadHooks, fn)
+}
+
+// LastReload returns the status of the most recent configuration load
+func LastReload() ReloadStatus {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+	return lastReload
+}
+
+func recordReload(status ReloadStatus) {
+	reloadMutex.Lock()
+	lastReload = status
+	reloadMutex.Unlock()
+}
+
+// Reload re-reads the configuration file and environment. Changed settings
+// that are safe to change at runtime are applied atomically by replacing the
+// configuration returned by GetConfig; the others are reported as requiring a
+// restart. An invalid configuration is rejected and the active one is kept.
+func Reload(trigger string) (ReloadStatus, error) {
+	status := ReloadStatus{LoadedAt: time.Now().UTC(), Trigger: trigger}
+
+	current := GetConfig()
+	if current == nil {
+		err := errors.New("configuration has not been loaded")
+		status.Error = err.Error()
+		recordReload(status)
+		return status, err
+	}
+
+	fresh, err := readConfig()
+	if err != nil {
+		status.Error = err.Error()
+		recordReload(status)
+		return status, err
+	}
+
+	next := *current
+	for _, field := range diffConfig(current, fresh) {
+		if apply, ok := reloadableFields[field]; ok {
+			apply(&next, fresh)
+			status.Applied = append(status.Applied, field)
+			continue
+		}
+		status.RequiresRestart = append(status.RequiresRestart, field)
+	}
+
+	if len(status.Applied) > 0 {
+		configMutex.Lock()
+		config = &next
+		configMutex.Unlock()
+
+		reloadHookMu.RLock()
+		hooks := append([]func(*Config){}, reloadHooks...)
+		reloadHookMu.RUnlock()
+		for _, hook := range hooks {
+			hook(&next)
+		}
+	}
+
+	recordReload(status)
+	return status, nil
+}
+
+// diffConfig returns the JSON paths of the settings that differ between a
+// and b, sorted. Nested structs are compared field by field; slices and maps
+// as a whole.
+func diffConfig(a, b *Config) []string {
+	var fields []string
+	diffStruct("", reflect.ValueOf(*a), reflect.ValueOf(*b), &fields)
+	sort.Strings(fields)
+	return fields
+}
+
+func diffStruct(prefix string, a, b reflect.Value, fields *[]string) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			name = field.Name
+		}
+		path := prefix + name
+
+		av, bv := a.Field(i), b.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			diffStruct(path+".", av, bv, fields)
+			continue
+		}
+		if !reflect.DeepEqual(av.Interface(), bv.Interface()) {
+			*fields = append(*fields, path)
+		}
+	}
+}
+
+// Redacted returns a copy of the configuration with secrets masked, for
+// display by the admin API
+func (c Config) Redacted() Config {
+	if c.Security.EncryptionKey != "" {
+		c.Security.EncryptionKey = redactedValue
+	}
+	if c.Notifications.Email.Password != "" {
+		c.Notifications.Email.Password = redactedValue
+	}
+	c.Database.URL = redactURL(c.Database.URL)
+	c.Cache.RedisURL = redactURL(c.Cache.RedisURL)
+	return c
+}
+
+// redactURL masks the password of a connection URL
+func redactURL(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redactedValue
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redactedValue)
+	}
+	return u.String()
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"validation-service/pkg/logger"
+)
+
+// Watcher reloads the configuration when the configuration file changes or
+// the process receives SIGHUP
+type Watcher struct {
+	path     string
+	interval time.Duration
+	digest   [sha256.Size]byte
+}
+
+// NewWatcher creates a watcher polling path every interval. The file is
+// compared by content, so editors replacing it and Kubernetes ConfigMap
+// symlink swaps are both detected.
+func NewWatcher(path string, interval time.Duration) *Watcher {
+	w := &Watcher{path: path, interval: interval}
+	w.digest, _ = fileDigest(path)
+	return w
+}
+
+// Run watches until ctx is done
+func (w *Watcher) Run(ctx context.Context) {
+	log := logger.GetLogger()
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		var trigger string
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			trigger = ReloadTriggerSignal
+		case <-ticker.C:
+			digest, err := fileDigest(w.path)
+			if err != nil || digest == w.digest {
+				continue
+			}
+			trigger = ReloadTriggerFile
+		}
+
+		// The digest is taken before reloading so a failed reload is not
+		// retried until the file changes again
+		w.digest, _ = fileDigest(w.path)
+		status, err := Reload(trigger)
+		if err != nil {
+			log.Error("Configuration reload failed",
+				"trigger", trigger,
+				"error", err,
+			)
+			continue
+		}
+		log.Info("Configuration reloaded",
+			"trigger", trigger,
+			"applied", status.Applied,
+			"requires_restart", status.RequiresRestart,
+		)
+	}
+}
+
+// fileDigest hashes the content of the file at path
+func fileDigest(path string) ([sha256.Size]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
@@ -0,0 +1,137 @@
+package corpus
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"validation-service/internal/config"
+)
+
+// Archive limits. Public repositories hold a few thousand rules next to
+// tests, tooling and documentation.
+const (
+	maxArchiveEntries = 100000
+	// maxArchiveExpansion bounds the uncompressed size of an archive
+	// relative to its compressed size limit
+	maxArchiveExpansion = 8
+)
+
+// ErrArchiveTooLarge is returned when a source archive exceeds the size limits
+var ErrArchiveTooLarge = errors.New("source archive exceeds the maximum size")
+
+// archiveURL returns the download URL of a source at its ref
+func archiveURL(source config.CorpusSource) string {
+	return strings.ReplaceAll(source.URL, "{ref}", source.Ref)
+}
+
+// download fetches the gzipped tar archive of a source and calls fn for each
+// regular file with its path relative to the repository root
+func (im *Importer) download(ctx context.Context, source config.CorpusSource, fn func(name string, content []byte) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, archiveURL(source), nil)
+	if err != nil {
+		return fmt.Errorf("building request: %w", err)
+	}
+	resp, err := im.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", source.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading %s: unexpected status %s", source.Name, resp.Status)
+	}
+	if im.cfg.MaxArchiveSize > 0 && resp.ContentLength > im.cfg.MaxArchiveSize {
+		return ErrArchiveTooLarge
+	}
+
+	body := io.Reader(resp.Body)
+	if im.cfg.MaxArchiveSize > 0 {
+		body = &limitedReader{r: resp.Body, remaining: im.cfg.MaxArchiveSize}
+	}
+	gz, err := gzip.NewReader(body)
+	if err != nil {
+		return fmt.Errorf("reading %s archive: %w", source.Name, err)
+	}
+	defer gz.Close()
+
+	budget := maxArchiveExpansion * im.cfg.MaxArchiveSize
+	archive := tar.NewReader(gz)
+	for entries := 0; ; entries++ {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s archive: %w", source.Name, err)
+		}
+		if entries >= maxArchiveEntries {
+			return fmt.Errorf("%s archive has more than %d entries", source.Name, maxArchiveEntries)
+		}
+		name := repositoryPath(header.Name)
+		if header.Typeflag != tar.TypeReg || name == "" || hiddenPath(name) {
+			continue
+		}
+
+		if im.cfg.MaxArchiveSize > 0 {
+			if header.Size > budget {
+				return ErrArchiveTooLarge
+			}
+			budget -= header.Size
+		}
+		content, err := io.ReadAll(archive)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", name, err)
+		}
+		if err := fn(name, content); err != nil {
+			return err
+		}
+	}
+}
+
+// repositoryPath strips the top-level directory, such as sigma-master/, that
+// GitHub archives put every file under
+func repositoryPath(name string) string {
+	_, rest, found := strings.Cut(strings.TrimPrefix(name, "./"), "/")
+	if !found {
+		return ""
+	}
+	return rest
+}
+
+// hiddenPath reports files under hidden directories, such as .github/
+// workflows, that are not rules
+func hiddenPath(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// limitedReader fails with ErrArchiveTooLarge once more than remaining bytes
+// are read, where io.LimitReader would silently truncate the archive
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrArchiveTooLarge
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrArchiveTooLarge
+	}
+	return n, err
+}
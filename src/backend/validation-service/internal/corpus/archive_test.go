@@ -0,0 +1,124 @@
+package corpus
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"validation-service/internal/config"
+)
+
+// testFile is a file of a test repository archive
+type testFile struct {
+	name    string
+	content string
+}
+
+func repositoryArchive(t *testing.T, files []testFile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(gz)
+	for _, file := range files {
+		header := &tar.Header{Name: file.name, Mode: 0o644, Size: int64(len(file.content)), Typeflag: tar.TypeReg}
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatalf("tar header: %v", err)
+		}
+		archive.Write([]byte(file.content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("tar close: %v", err)
+	}
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestDownload(t *testing.T) {
+	rules := []testFile{
+		{"sigma-master/rules/a.yml", "title: a"},
+		{"sigma-master/rules/b.yml", "title: b"},
+		{"sigma-master/.github/workflows/ci.yml", "on: push"},
+		{"sigma-master", ""},
+	}
+	rulesArchive := repositoryArchive(t, rules)
+	// A small archive expanding to far more than eight times its size
+	bomb := repositoryArchive(t, []testFile{{"repo/rules/bomb.yml", strings.Repeat("a", 1<<20)}})
+
+	tests := []struct {
+		name           string
+		body           []byte
+		status         int
+		chunked        bool
+		maxArchiveSize int64
+		want           []string
+		wantErr        error
+	}{
+		{"within limits", rulesArchive, http.StatusOK, false, 1 << 20, []string{"rules/a.yml", "rules/b.yml"}, nil},
+		{"unlimited", rulesArchive, http.StatusOK, false, 0, []string{"rules/a.yml", "rules/b.yml"}, nil},
+		{"declared size too large", rulesArchive, http.StatusOK, false, int64(len(rulesArchive)) - 1, nil, ErrArchiveTooLarge},
+		{"streamed size too large", rulesArchive, http.StatusOK, true, int64(len(rulesArchive)) / 2, nil, ErrArchiveTooLarge},
+		{"expansion too large", bomb, http.StatusOK, false, int64(len(bomb)), nil, ErrArchiveTooLarge},
+		{"not found", nil, http.StatusNotFound, false, 1 << 20, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.chunked {
+					w.Header().Set("Transfer-Encoding", "chunked")
+				}
+				w.WriteHeader(tt.status)
+				w.Write(tt.body)
+				if tt.chunked {
+					w.(http.Flusher).Flush()
+				}
+			}))
+			defer server.Close()
+
+			im := &Importer{cfg: config.CorpusConfig{MaxArchiveSize: tt.maxArchiveSize}, client: server.Client()}
+			source := config.CorpusSource{Name: "sigma", URL: server.URL + "/{ref}.tar.gz", Ref: "master"}
+			var names []string
+			err := im.download(context.Background(), source, func(name string, content []byte) error {
+				names = append(names, name)
+				return nil
+			})
+			switch {
+			case tt.status != http.StatusOK:
+				if err == nil {
+					t.Fatal("download succeeded, want an error")
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("download error = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("download: %v", err)
+			case !reflect.DeepEqual(names, tt.want):
+				t.Errorf("files = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepositoryPath(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"sigma-master/rules/a.yml", "rules/a.yml"},
+		{"./sigma-master/rules/a.yml", "rules/a.yml"},
+		{"sigma-master", ""},
+		{"sigma-master/", ""},
+	}
+	for _, tt := range tests {
+		if got := repositoryPath(tt.name); got != tt.want {
+			t.Errorf("repositoryPath(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
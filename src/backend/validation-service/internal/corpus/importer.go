@@ -0,0 +1,397 @@
+// Package corpus imports public rule repositories, such as SigmaHQ,
+// elastic/detection-rules and the Chronicle community rules, into the stored
+// detection corpus so tenant content can be compared against community rules.
+package corpus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/config"
+	"validation-service/internal/models"
+	"validation-service/internal/storage"
+	"validation-service/pkg/logger"
+	"validation-service/pkg/mitre"
+)
+
+// FormatElastic is the extension format of elastic/detection-rules TOML
+// rules. The service does not validate their content.
+const FormatElastic = "elastic"
+
+// Provenance tags recorded on every imported rule
+const (
+	TagSource  = "corpus.source"
+	TagPath    = "corpus.path"
+	TagRef     = "corpus.ref"
+	TagDigest  = "corpus.digest"
+	TagStatus  = "corpus.status"
+	TagUpdated = "corpus.imported_at"
+)
+
+// Values of the corpus.status tag. Superseded rules have been replaced by a
+// newer version of the same file; removed rules are no longer in the source.
+const (
+	StatusCurrent    = "current"
+	StatusSuperseded = "superseded"
+	StatusRemoved    = "removed"
+)
+
+var (
+	// ErrImportRunning is returned when an import is requested while one is
+	// in progress
+	ErrImportRunning = errors.New("corpus import already running")
+	// ErrUnknownSource is returned when an import names a source that is not
+	// configured
+	ErrUnknownSource = errors.New("unknown corpus source")
+)
+
+// ImportStatus reports the progress of the most recent import run
+type ImportStatus struct {
+	Running    bool           `json:"running"`
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	Sources    []SourceStatus `json:"sources"`
+}
+
+// SourceStatus reports the outcome of importing one source. Unchanged rules
+// were already imported with the same content.
+type SourceStatus struct {
+	Name       string `json:"name"`
+	Ref        string `json:"ref"`
+	License    string `json:"license"`
+	Imported   int    `json:"imported"`
+	Unchanged  int    `json:"unchanged"`
+	Superseded int    `json:"superseded"`
+	Removed    int    `json:"removed"`
+	Skipped    int    `json:"skipped"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Importer downloads configured sources and stores their rules under the
+// corpus tenant, one import at a time
+type Importer struct {
+	store       storage.DetectionStore
+	cfg         config.CorpusConfig
+	maxRuleSize int
+	client      *http.Client
+	log         *logger.Logger
+
+	mu       sync.Mutex
+	status   ImportStatus
+	complete func(ImportStatus)
+}
+
+// importedRule is a rule already in the corpus, keyed by its source path
+type importedRule struct {
+	id     uuid.UUID
+	digest string
+	status string
+}
+
+// NewImporter creates an importer for the configured sources, downloading
+// them with client. Rules larger than maxRuleSize are skipped when it is
+// positive.
+func NewImporter(store storage.DetectionStore, cfg config.CorpusConfig, maxRuleSize int, client *http.Client) (*Importer, error) {
+	for _, source := range cfg.Sources {
+		for _, format := range source.Formats {
+			if format != FormatElastic {
+				continue
+			}
+			if err := models.RegisterFormat(FormatElastic); err != nil {
+				return nil, fmt.Errorf("registering %s format: %w", FormatElastic, err)
+			}
+		}
+	}
+	return &Importer{
+		store:       store,
+		cfg:         cfg,
+		maxRuleSize: maxRuleSize,
+		client:      client,
+		log:         logger.GetLogger(),
+		status:      ImportStatus{Sources: []SourceStatus{}},
+	}, nil
+}
+
+// Sources returns the names of the configured sources
+func (im *Importer) Sources() []string {
+	names := make([]string, len(im.cfg.Sources))
+	for i, source := range im.cfg.Sources {
+		names[i] = source.Name
+	}
+	return names
+}
+
+// StartImport imports the named sources, or every configured source when
+// names is empty, in the background. Progress is available from ImportStatus.
+func (im *Importer) StartImport(names []string) error {
+	sources, err := im.selectSources(names)
+	if err != nil {
+		return err
+	}
+
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	if im.status.Running {
+		return ErrImportRunning
+	}
+	now := time.Now().UTC()
+	im.status = ImportStatus{Running: true, StartedAt: &now, Sources: make([]SourceStatus, len(sources))}
+	for i, source := range sources {
+		im.status.Sources[i] = SourceStatus{Name: source.Name, Ref: source.Ref, License: source.License}
+	}
+
+	go func() {
+		for i, source := range sources {
+			status := im.importSource(context.Background(), source)
+
+			im.mu.Lock()
+			im.status.Sources[i] = status
+			im.mu.Unlock()
+		}
+
+		im.mu.Lock()
+		finished := time.Now().UTC()
+		im.status.Running = false
+		im.status.FinishedAt = &finished
+		im.log.Info("Corpus import completed",
+			"sources", len(sources),
+			"duration", finished.Sub(*im.status.StartedAt),
+		)
+		complete := im.complete
+		im.mu.Unlock()
+
+		if complete != nil {
+			complete(im.ImportStatus())
+		}
+	}()
+	return nil
+}
+
+// SetCompletionHook calls complete with the final status of every import
+func (im *Importer) SetCompletionHook(complete func(ImportStatus)) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	im.complete = complete
+}
+
+// ImportStatus returns the status of the current or last import run
+func (im *Importer) ImportStatus() ImportStatus {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	status := im.status
+	status.Sources = append([]SourceStatus(nil), im.status.Sources...)
+	return status
+}
+
+// selectSources resolves source names against the configuration
+func (im *Importer) selectSources(names []string) ([]config.CorpusSource, error) {
+	if len(names) == 0 {
+		return im.cfg.Sources, nil
+	}
+	sources := make([]config.CorpusSource, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, source := range im.cfg.Sources {
+			if source.Name == name {
+				sources = append(sources, source)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownSource, name)
+		}
+	}
+	return sources, nil
+}
+
+// importSource downloads one source and reconciles its rules with the corpus.
+// A file whose content changed is stored as a new detection and the previous
+// version is marked superseded; files no longer in the source are marked
+// removed. Nothing is deleted, so earlier comparisons stay reproducible.
+func (im *Importer) importSource(ctx context.Context, source config.CorpusSource) SourceStatus {
+	status := SourceStatus{Name: source.Name, Ref: source.Ref, License: source.License}
+	fail := func(err error) SourceStatus {
+		status.Error = err.Error()
+		im.log.Error("Corpus import failed",
+			"error", err,
+			"source", source.Name,
+			"ref", source.Ref,
+		)
+		return status
+	}
+
+	existing, err := im.importedRules(ctx, source.Name)
+	if err != nil {
+		return fail(fmt.Errorf("loading imported rules: %w", err))
+	}
+
+	importedAt := time.Now().UTC().Format(time.RFC3339)
+	seen := make(map[string]bool)
+	var superseded, revived []uuid.UUID
+	err = im.download(ctx, source, func(name string, content []byte) error {
+		format := source.Formats[strings.ToLower(path.Ext(name))]
+		if format == "" || !inPaths(name, source.Paths) {
+			return nil
+		}
+		if im.maxRuleSize > 0 && len(content) > im.maxRuleSize {
+			status.Skipped++
+			return nil
+		}
+		seen[name] = true
+
+		sum := sha256.Sum256(content)
+		digest := hex.EncodeToString(sum[:])
+		previous, ok := existing[name]
+		if ok && previous.digest == digest {
+			if previous.status != StatusCurrent {
+				revived = append(revived, previous.id)
+			}
+			status.Unchanged++
+			return nil
+		}
+
+		detection, err := models.NewDetection(string(content), format)
+		if err != nil {
+			status.Skipped++
+			im.log.Debug("Skipped corpus rule",
+				"error", err,
+				"source", source.Name,
+				"path", name,
+			)
+			return nil
+		}
+		stored := &storage.StoredDetection{
+			Detection: detection,
+			TenantID:  im.cfg.TenantID,
+			Name:      strings.TrimSuffix(path.Base(name), path.Ext(name)),
+			License:   source.License,
+			Tags: map[string]string{
+				TagSource:  source.Name,
+				TagPath:    name,
+				TagRef:     source.Ref,
+				TagDigest:  digest,
+				TagStatus:  StatusCurrent,
+				TagUpdated: importedAt,
+			},
+			Techniques: mitre.ExtractTechniques(string(content)),
+			UpdatedAt:  time.Now().UTC(),
+		}
+		if err := im.store.CreateDetection(ctx, stored); err != nil {
+			return fmt.Errorf("storing %s: %w", name, err)
+		}
+		if ok {
+			superseded = append(superseded, previous.id)
+		}
+		status.Imported++
+		return nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	var removed []uuid.UUID
+	for name, rule := range existing {
+		if !seen[name] && rule.status == StatusCurrent {
+			removed = append(removed, rule.id)
+		}
+	}
+	if status.Superseded, err = im.setStatus(ctx, superseded, StatusSuperseded); err != nil {
+		return fail(err)
+	}
+	if status.Removed, err = im.setStatus(ctx, removed, StatusRemoved); err != nil {
+		return fail(err)
+	}
+	if _, err := im.setStatus(ctx, revived, StatusCurrent); err != nil {
+		return fail(err)
+	}
+
+	im.log.Info("Imported corpus source",
+		"source", source.Name,
+		"ref", source.Ref,
+		"imported", status.Imported,
+		"unchanged", status.Unchanged,
+		"superseded", status.Superseded,
+		"removed", status.Removed,
+		"skipped", status.Skipped,
+	)
+	return status
+}
+
+// importedRules returns the latest imported version of every file of a
+// source, keyed by path
+func (im *Importer) importedRules(ctx context.Context, sourceName string) (map[string]importedRule, error) {
+	type version struct {
+		rule       importedRule
+		importedAt string
+	}
+	latest := make(map[string]version)
+	err := im.store.ScanDetections(ctx, func(detection *storage.StoredDetection) error {
+		if detection.TenantID != im.cfg.TenantID || detection.Tags[TagSource] != sourceName {
+			return nil
+		}
+		name := detection.Tags[TagPath]
+		current := version{
+			rule: importedRule{
+				id:     detection.ID(),
+				digest: detection.Tags[TagDigest],
+				status: detection.Tags[TagStatus],
+			},
+			importedAt: detection.Tags[TagUpdated],
+		}
+		if previous, ok := latest[name]; !ok || previous.rule.status == StatusSuperseded ||
+			(current.rule.status != StatusSuperseded && current.importedAt > previous.importedAt) {
+			latest[name] = current
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make(map[string]importedRule, len(latest))
+	for name, v := range latest {
+		rules[name] = v.rule
+	}
+	return rules, nil
+}
+
+// setStatus sets the corpus.status tag of the given detections
+func (im *Importer) setStatus(ctx context.Context, ids []uuid.UUID, status string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	updated, err := im.store.UpdateTags(ctx, storage.TagOperation{
+		TenantID:     im.cfg.TenantID,
+		DetectionIDs: ids,
+		Set:          map[string]string{TagStatus: status},
+	})
+	if err != nil {
+		return updated, fmt.Errorf("marking rules %s: %w", status, err)
+	}
+	return updated, nil
+}
+
+// inPaths reports whether name lies in one of the directories, or whether
+// no directories are given
+func inPaths(name string, dirs []string) bool {
+	if len(dirs) == 0 {
+		return true
+	}
+	for _, dir := range dirs {
+		if strings.HasPrefix(name, strings.Trim(dir, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
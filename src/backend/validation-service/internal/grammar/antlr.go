@@ -0,0 +1,144 @@
+//go:build antlr
+
+package grammar
+
+import (
+	"github.com/antlr4-go/antlr/v4" // v4.13.0
+
+	"validation-service/internal/grammar/aql"
+	"validation-service/internal/grammar/kql"
+	"validation-service/internal/grammar/spl"
+	"validation-service/internal/grammar/yaral"
+	"validation-service/internal/models"
+)
+
+// languages are the parsers of the formats with a grammar
+var languages = map[string]parseFunc{
+	models.DetectionFormatSplunk: parseSPL,
+	models.DetectionFormatQRadar: parseAQL,
+	models.DetectionFormatKQL:    parseKQL,
+	models.DetectionFormatYaraL:  parseYaraL,
+}
+
+func parseSPL(content string) *Tree {
+	lexer := spl.NewSPLLexer(antlr.NewInputStream(content))
+	parser := spl.NewSPLParser(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+	return run(lexer, parser, func() antlr.ParserRuleContext { return parser.Query() })
+}
+
+func parseAQL(content string) *Tree {
+	lexer := aql.NewAQLLexer(antlr.NewInputStream(content))
+	parser := aql.NewAQLParser(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+	return run(lexer, parser, func() antlr.ParserRuleContext { return parser.Query() })
+}
+
+func parseKQL(content string) *Tree {
+	lexer := kql.NewKQLLexer(antlr.NewInputStream(content))
+	parser := kql.NewKQLParser(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+	return run(lexer, parser, func() antlr.ParserRuleContext { return parser.Query() })
+}
+
+func parseYaraL(content string) *Tree {
+	lexer := yaral.NewYaraLLexer(antlr.NewInputStream(content))
+	parser := yaral.NewYaraLParser(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+	return run(lexer, parser, func() antlr.ParserRuleContext { return parser.Ruleset() })
+}
+
+// run parses with the entry rule of a generated parser, collecting syntax
+// errors in place of the default console listeners
+func run(lexer antlr.Lexer, parser antlr.Parser, entry func() antlr.ParserRuleContext) *Tree {
+	errs := &errorCollector{DefaultErrorListener: antlr.NewDefaultErrorListener()}
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(errs)
+	parser.RemoveErrorListeners()
+	parser.AddErrorListener(errs)
+
+	root := entry()
+	return &Tree{Root: convert(root, parser), Errors: errs.errors}
+}
+
+// errorCollector records the syntax errors of the lexer and parser
+type errorCollector struct {
+	*antlr.DefaultErrorListener
+	errors []SyntaxError
+}
+
+func (c *errorCollector) SyntaxError(_ antlr.Recognizer, _ interface{}, line, column int, msg string, _ antlr.RecognitionException) {
+	c.errors = append(c.errors, SyntaxError{Line: line, Column: column + 1, Message: msg})
+}
+
+// convert copies a generated parse tree into a Node tree, dropping the end
+// of file token. The copy keeps an explicit stack of pending subtrees, so
+// deeply nested rules cost no call stack.
+func convert(tree antlr.Tree, recognizer antlr.Recognizer) *Node {
+	type pending struct {
+		tree   antlr.Tree
+		parent *Node
+	}
+	var root *Node
+	stack := []pending{{tree: tree}}
+	for len(stack) > 0 {
+		item := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		node := convertNode(item.tree, recognizer)
+		if node == nil {
+			continue
+		}
+		if item.parent == nil {
+			root = node
+		} else {
+			item.parent.Children = append(item.parent.Children, node)
+		}
+		if ctx, ok := item.tree.(antlr.ParserRuleContext); ok {
+			children := ctx.GetChildren()
+			for i := len(children) - 1; i >= 0; i-- {
+				stack = append(stack, pending{tree: children[i], parent: node})
+			}
+		}
+	}
+	return root
+}
+
+// convertNode copies one generated tree node without its children
+func convertNode(tree antlr.Tree, recognizer antlr.Recognizer) *Node {
+	switch t := tree.(type) {
+	case antlr.TerminalNode:
+		token := t.GetSymbol()
+		if token.GetTokenType() == antlr.TokenEOF {
+			return nil
+		}
+		return &Node{
+			Token:  tokenName(recognizer, token.GetTokenType()),
+			Text:   token.GetText(),
+			Line:   token.GetLine(),
+			Column: token.GetColumn() + 1,
+			Start:  token.GetStart(),
+			End:    token.GetStop() + 1,
+		}
+	case antlr.ParserRuleContext:
+		node := &Node{Rule: recognizer.GetRuleNames()[t.GetRuleIndex()]}
+		if start := t.GetStart(); start != nil {
+			node.Line = start.GetLine()
+			node.Column = start.GetColumn() + 1
+			node.Start = start.GetStart()
+		}
+		node.End = node.Start
+		if stop := t.GetStop(); stop != nil && stop.GetStop()+1 > node.Start {
+			node.End = stop.GetStop() + 1
+		}
+		return node
+	}
+	return nil
+}
+
+// tokenName returns the symbolic name of a token type, or its literal for
+// tokens the grammar only names by their text
+func tokenName(recognizer antlr.Recognizer, tokenType int) string {
+	if names := recognizer.GetSymbolicNames(); tokenType > 0 && tokenType < len(names) && names[tokenType] != "" {
+		return names[tokenType]
+	}
+	if names := recognizer.GetLiteralNames(); tokenType > 0 && tokenType < len(names) {
+		return names[tokenType]
+	}
+	return ""
+}
@@ -0,0 +1,231 @@
+// Grammar of QRadar Ariel Query Language searches: SELECT statements over
+// events and flows with subselects, property functions and the LAST and
+// START/STOP time clauses.
+grammar AQL;
+
+options { caseInsensitive = true; }
+
+query
+    : selectStatement SEMI? EOF
+    ;
+
+selectStatement
+    : SELECT DISTINCT? selectItem (COMMA selectItem)*
+      FROM source
+      whereClause?
+      groupByClause?
+      havingClause?
+      orderByClause?
+      limitClause?
+      timeClause?
+      parametersClause?
+    ;
+
+selectItem
+    : STAR
+    | expression (AS? alias)?
+    ;
+
+alias
+    : IDENTIFIER
+    | QUOTED_IDENTIFIER
+    ;
+
+source
+    : IDENTIFIER (AS? alias)?
+    | LPAREN selectStatement RPAREN (AS? alias)?
+    ;
+
+whereClause
+    : WHERE condition
+    ;
+
+groupByClause
+    : GROUP BY expression (COMMA expression)*
+    ;
+
+havingClause
+    : HAVING condition
+    ;
+
+orderByClause
+    : ORDER BY orderItem (COMMA orderItem)*
+    ;
+
+orderItem
+    : expression (ASC | DESC)?
+    ;
+
+limitClause
+    : LIMIT NUMBER
+    ;
+
+timeClause
+    : LAST NUMBER timeUnit                   # lastClause
+    | START timestamp STOP timestamp         # rangeClause
+    ;
+
+timeUnit
+    : MINUTES
+    | HOURS
+    | DAYS
+    ;
+
+timestamp
+    : STRING
+    | NUMBER
+    ;
+
+parametersClause
+    : PARAMETERS parameter (COMMA parameter)*
+    ;
+
+parameter
+    : IDENTIFIER EQ (STRING | NUMBER | IDENTIFIER)
+    ;
+
+condition
+    : LPAREN condition RPAREN                                           # groupCondition
+    | NOT condition                                                     # notCondition
+    | condition AND condition                                           # andCondition
+    | condition OR condition                                            # orCondition
+    | expression comparator expression                                  # comparisonCondition
+    | expression NOT? (LIKE | ILIKE) STRING                             # likeCondition
+    | expression NOT? (MATCHES | IMATCHES) STRING                       # matchesCondition
+    | expression NOT? BETWEEN expression AND expression                 # betweenCondition
+    | expression NOT? IN LPAREN (selectStatement | expressionList) RPAREN  # inCondition
+    | expression IS NOT? NULL                                           # nullCondition
+    | TEXT SEARCH STRING                                                # textSearchCondition
+    | expression                                                        # booleanCondition
+    ;
+
+comparator
+    : EQ
+    | NEQ
+    | LT
+    | LTE
+    | GT
+    | GTE
+    ;
+
+expressionList
+    : expression (COMMA expression)*
+    ;
+
+expression
+    : LPAREN expression RPAREN                                  # groupExpression
+    | LPAREN selectStatement RPAREN                             # subselectExpression
+    | MINUS expression                                          # negateExpression
+    | expression op=(STAR | SLASH | PERCENT) expression         # multiplicativeExpression
+    | expression op=(PLUS | MINUS) expression                   # additiveExpression
+    | expression CONCAT expression                              # concatExpression
+    | functionCall                                              # functionExpression
+    | CASE caseBranch+ (ELSE expression)? END                   # caseExpression
+    | STRING                                                    # stringExpression
+    | NUMBER                                                    # numberExpression
+    | NULL                                                      # nullExpression
+    | (TRUE | FALSE)                                            # booleanExpression
+    | QUOTED_IDENTIFIER                                         # quotedFieldExpression
+    | IDENTIFIER                                                # fieldExpression
+    ;
+
+caseBranch
+    : WHEN condition THEN expression
+    ;
+
+// Property and aggregate functions such as QIDNAME(qid), LOGSOURCENAME(logsourceid)
+// and COUNT(*)
+functionCall
+    : IDENTIFIER LPAREN (STAR | DISTINCT? expression (COMMA expression)*)? RPAREN
+    ;
+
+// Keywords
+
+SELECT     : 'SELECT';
+DISTINCT   : 'DISTINCT';
+FROM       : 'FROM';
+WHERE      : 'WHERE';
+GROUP      : 'GROUP';
+BY         : 'BY';
+HAVING     : 'HAVING';
+ORDER      : 'ORDER';
+ASC        : 'ASC';
+DESC       : 'DESC';
+LIMIT      : 'LIMIT';
+LAST       : 'LAST';
+START      : 'START';
+STOP       : 'STOP';
+MINUTES    : 'MINUTES';
+HOURS      : 'HOURS';
+DAYS       : 'DAYS';
+PARAMETERS : 'PARAMETERS';
+AS         : 'AS';
+AND        : 'AND';
+OR         : 'OR';
+NOT        : 'NOT';
+LIKE       : 'LIKE';
+ILIKE      : 'ILIKE';
+MATCHES    : 'MATCHES';
+IMATCHES   : 'IMATCHES';
+BETWEEN    : 'BETWEEN';
+IN         : 'IN';
+IS         : 'IS';
+NULL       : 'NULL';
+TRUE       : 'TRUE';
+FALSE      : 'FALSE';
+TEXT       : 'TEXT';
+SEARCH     : 'SEARCH';
+CASE       : 'CASE';
+WHEN       : 'WHEN';
+THEN       : 'THEN';
+ELSE       : 'ELSE';
+END        : 'END';
+
+// Punctuation and operators
+
+COMMA   : ',';
+SEMI    : ';';
+LPAREN  : '(';
+RPAREN  : ')';
+CONCAT  : '||';
+NEQ     : '!=' | '<>';
+LTE     : '<=';
+GTE     : '>=';
+EQ      : '=';
+LT      : '<';
+GT      : '>';
+PLUS    : '+';
+MINUS   : '-';
+STAR    : '*';
+SLASH   : '/';
+PERCENT : '%';
+
+// Literals
+
+STRING
+    : '\'' (~['\\] | '\\' . | '\'\'')* '\''
+    ;
+
+// Property names with spaces or punctuation, such as "Process Name"
+QUOTED_IDENTIFIER
+    : '"' ~["\r\n]+ '"'
+    ;
+
+NUMBER
+    : DIGIT+ ('.' DIGIT+)?
+    ;
+
+IDENTIFIER
+    : (LETTER | '_') (LETTER | DIGIT | '_')*
+    ;
+
+LINE_COMMENT
+    : '--' ~[\r\n]* -> skip
+    ;
+
+WS
+    : [ \t\r\n]+ -> skip
+    ;
+
+fragment DIGIT  : [0-9];
+fragment LETTER : [a-z];
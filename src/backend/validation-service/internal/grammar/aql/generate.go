@@ -0,0 +1,7 @@
+// Package aql contains the AQL lexer and parser generated from AQL.g4 with
+// ANTLR 4. Generated files are not committed; generate them with go
+// generate before building with the antlr tag, and after changing the
+// grammar.
+package aql
+
+//go:generate java -jar $ANTLR_JAR -Dlanguage=Go -package aql -no-listener -no-visitor AQL.g4
@@ -0,0 +1,147 @@
+// Package grammar parses detection queries with parsers generated from the
+// formal grammars of the supported languages and exposes their parse trees to
+// validators. The grammars live in the spl, aql, kql and yaral subpackages;
+// their parsers are generated with go generate and are only built with the
+// antlr build tag. Without it no format has a grammar.
+package grammar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrUnsupportedLanguage is returned for formats without a grammar
+var ErrUnsupportedLanguage = errors.New("no grammar for format")
+
+// SyntaxError is a position in the content the grammar rejects. Line and
+// Column are 1-based.
+type SyntaxError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// Node is a node of a parse tree. Rule nodes carry the grammar rule they
+// were matched by and their children; token nodes carry the token type and
+// text.
+type Node struct {
+	Rule  string `json:"rule,omitempty"`
+	Token string `json:"token,omitempty"`
+	Text  string `json:"text,omitempty"`
+	// Line and Column locate the first token of the node, 1-based
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	// Start and End are the character offsets the node spans in the content
+	Start    int     `json:"start"`
+	End      int     `json:"end"`
+	Children []*Node `json:"children,omitempty"`
+}
+
+// IsToken reports whether the node is a token
+func (n *Node) IsToken() bool {
+	return n.Rule == ""
+}
+
+// Walk calls fn for the node and its descendants in source order, skipping
+// the descendants of nodes fn returns false for. The walk keeps an explicit
+// stack, so deep trees cost no call stack.
+func (n *Node) Walk(fn func(*Node) bool) {
+	stack := []*Node{n}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if node == nil || !fn(node) {
+			continue
+		}
+		for i := len(node.Children) - 1; i >= 0; i-- {
+			stack = append(stack, node.Children[i])
+		}
+	}
+}
+
+// FindAll returns the descendants of the node matched by rule, outermost
+// first in source order
+func (n *Node) FindAll(rule string) []*Node {
+	var found []*Node
+	n.Walk(func(node *Node) bool {
+		if node.Rule == rule {
+			found = append(found, node)
+		}
+		return true
+	})
+	return found
+}
+
+// Source returns the text of content the node spans
+func (n *Node) Source(content string) string {
+	runes := []rune(content)
+	if n.Start < 0 || n.End > len(runes) || n.Start > n.End {
+		return ""
+	}
+	return string(runes[n.Start:n.End])
+}
+
+// Tree is the parse tree of a detection and the syntax errors the parser
+// recovered from. Root is set even when there are errors, with the rejected
+// input skipped.
+type Tree struct {
+	Format string        `json:"format"`
+	Root   *Node         `json:"root"`
+	Errors []SyntaxError `json:"errors,omitempty"`
+}
+
+// Valid reports whether the content matched the grammar without errors
+func (t *Tree) Valid() bool {
+	return len(t.Errors) == 0
+}
+
+// parseFunc parses content with the generated parser of a language
+type parseFunc func(content string) *Tree
+
+// Supports reports whether format has a grammar
+func Supports(format string) bool {
+	_, ok := languages[format]
+	return ok
+}
+
+// Formats returns the formats with a grammar, sorted
+func Formats() []string {
+	formats := make([]string, 0, len(languages))
+	for format := range languages {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Parse parses content with the grammar of format
+func Parse(format, content string) (*Tree, error) {
+	parse, ok := languages[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLanguage, format)
+	}
+	tree := parse(content)
+	tree.Format = format
+	return tree, nil
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the parse tree of the detection
+// being validated
+func NewContext(ctx context.Context, tree *Tree) context.Context {
+	return context.WithValue(ctx, contextKey{}, tree)
+}
+
+// FromContext returns the parse tree of the detection being validated, if it
+// was parsed
+func FromContext(ctx context.Context) (*Tree, bool) {
+	tree, ok := ctx.Value(contextKey{}).(*Tree)
+	return tree, ok && tree != nil
+}
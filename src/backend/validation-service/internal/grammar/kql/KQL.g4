@@ -0,0 +1,217 @@
+// Grammar of Kusto Query Language queries as used by Microsoft Sentinel and
+// Defender: let statements and tabular expressions piping a source through
+// operators. Operator arguments are parsed generically as expressions so
+// that every operator yields a tree. KQL is case-sensitive.
+grammar KQL;
+
+query
+    : statement (SEMI statement)* SEMI? EOF
+    ;
+
+statement
+    : letStatement
+    | tabularExpression
+    ;
+
+letStatement
+    : LET IDENTIFIER EQ (functionDefinition | expression | tabularExpression)
+    ;
+
+functionDefinition
+    : LPAREN (parameter (COMMA parameter)*)? RPAREN LBRACE statement (SEMI statement)* SEMI? RBRACE
+    ;
+
+parameter
+    : IDENTIFIER COLON (IDENTIFIER | LPAREN STAR RPAREN) (EQ expression)?
+    ;
+
+tabularExpression
+    : tabularSource (PIPE tabularOperator)*
+    ;
+
+tabularSource
+    : LPAREN tabularExpression RPAREN                                        # nestedSource
+    | DATATABLE LPAREN columnDefinition (COMMA columnDefinition)* RPAREN
+      LBRACK (expression (COMMA expression)*)? RBRACK                        # datatableSource
+    | sourceOperator argument*                                               # operatorSource
+    | functionCall                                                           # functionSource
+    | (IDENTIFIER | LBRACK STRING RBRACK)                                    # tableSource
+    ;
+
+// Operators that can start a tabular expression
+sourceOperator
+    : UNION
+    | PRINT
+    | RANGE
+    | SEARCH
+    | FIND
+    | EVALUATE
+    ;
+
+tabularOperator
+    : operatorName argument*
+    ;
+
+// Operator names, including hyphenated ones such as project-away and
+// mv-expand
+operatorName
+    : IDENTIFIER (MINUS IDENTIFIER)*
+    | sourceOperator
+    ;
+
+argument
+    : columnDefinition
+    | expression
+    | COMMA
+    ;
+
+columnDefinition
+    : IDENTIFIER COLON IDENTIFIER
+    ;
+
+expression
+    : primary                                                                # primaryExpression
+    | expression DOT IDENTIFIER                                              # memberExpression
+    | expression LBRACK expression RBRACK                                    # indexExpression
+    | op=(MINUS | PLUS) expression                                           # unaryExpression
+    | expression op=(STAR | SLASH | PERCENT) expression                      # multiplicativeExpression
+    | expression op=(PLUS | MINUS) expression                                # additiveExpression
+    | expression op=(EQEQ | NEQ | LT | LTE | GT | GTE | EQTILDE | NEQTILDE) expression  # comparisonExpression
+    | expression (STRING_OPERATOR | MATCHES_REGEX) expression                # stringExpression
+    | expression LIST_OPERATOR LPAREN (expression (COMMA expression)* | tabularExpression)? RPAREN  # listExpression
+    | expression BETWEEN LPAREN expression DOTDOT expression RPAREN          # betweenExpression
+    | expression AND expression                                              # andExpression
+    | expression OR expression                                               # orExpression
+    | expression EQ expression                                               # assignmentExpression
+    ;
+
+primary
+    : LPAREN expression RPAREN                                   # groupPrimary
+    | LPAREN tabularExpression RPAREN                            # tabularPrimary
+    | functionCall                                               # callPrimary
+    | LBRACK (expression (COMMA expression)*)? RBRACK            # arrayPrimary
+    | LBRACE (STRING COLON expression (COMMA STRING COLON expression)*)? RBRACE  # objectPrimary
+    | STRING                                                     # stringPrimary
+    | NUMBER                                                     # numberPrimary
+    | TIMESPAN                                                   # timespanPrimary
+    | DATETIME                                                   # datetimePrimary
+    | STAR                                                       # wildcardPrimary
+    | IDENTIFIER                                                 # namePrimary
+    ;
+
+functionCall
+    : IDENTIFIER LPAREN (callArgument (COMMA callArgument)*)? RPAREN
+    ;
+
+callArgument
+    : expression
+    | tabularExpression
+    ;
+
+// Keywords
+
+LET       : 'let';
+AND       : 'and';
+OR        : 'or';
+DATATABLE : 'datatable';
+UNION     : 'union';
+PRINT     : 'print';
+RANGE     : 'range';
+SEARCH    : 'search';
+FIND      : 'find';
+EVALUATE  : 'evaluate';
+
+BETWEEN
+    : '!'? 'between'
+    ;
+
+// Membership operators taking a parenthesized list
+LIST_OPERATOR
+    : '!'? 'in' '~'?
+    | '!'? 'has_any'
+    | 'has_all'
+    ;
+
+// String predicates; the _cs forms are case-sensitive and the ! forms negate
+STRING_OPERATOR
+    : '!'? ('contains' | 'has' | 'hasprefix' | 'hassuffix' | 'startswith' | 'endswith') '_cs'?
+    ;
+
+MATCHES_REGEX
+    : 'matches' [ \t]+ 'regex'
+    ;
+
+// Punctuation and operators
+
+PIPE     : '|';
+SEMI     : ';';
+COMMA    : ',';
+COLON    : ':';
+LPAREN   : '(';
+RPAREN   : ')';
+LBRACK   : '[';
+RBRACK   : ']';
+LBRACE   : '{';
+RBRACE   : '}';
+DOTDOT   : '..';
+DOT      : '.';
+EQEQ     : '==';
+NEQ      : '!=' | '<>';
+EQTILDE  : '=~';
+NEQTILDE : '!~';
+LTE      : '<=';
+GTE      : '>=';
+EQ       : '=';
+LT       : '<';
+GT       : '>';
+PLUS     : '+';
+MINUS    : '-';
+STAR     : '*';
+SLASH    : '/';
+PERCENT  : '%';
+
+// Literals
+
+STRING
+    : '@'? '"' (~["\\\r\n] | '\\' .)* '"'
+    | '@'? '\'' (~['\\\r\n] | '\\' .)* '\''
+    | '```' .*? '```'
+    ;
+
+// datetime(2024-01-01T00:00:00Z) holds an unquoted timestamp
+DATETIME
+    : 'datetime' [ \t]* '(' ~[)\r\n]* ')'
+    ;
+
+TIMESPAN
+    : DIGIT+ ('.' DIGIT+)? TIME_UNIT
+    ;
+
+NUMBER
+    : '0x' [0-9a-fA-F]+
+    | DIGIT+ ('.' DIGIT+)? ([eE] [+-]? DIGIT+)?
+    ;
+
+IDENTIFIER
+    : [a-zA-Z_$] [a-zA-Z0-9_]*
+    ;
+
+LINE_COMMENT
+    : '//' ~[\r\n]* -> skip
+    ;
+
+WS
+    : [ \t\r\n]+ -> skip
+    ;
+
+fragment DIGIT : [0-9];
+
+fragment TIME_UNIT
+    : 'd' | 'day' | 'days'
+    | 'h' | 'hr' | 'hrs' | 'hour' | 'hours'
+    | 'm' | 'min' | 'minute' | 'minutes'
+    | 's' | 'sec' | 'second' | 'seconds'
+    | 'ms' | 'milli' | 'millis' | 'millisecond' | 'milliseconds'
+    | 'microsecond' | 'microseconds'
+    | 'tick' | 'ticks'
+    ;
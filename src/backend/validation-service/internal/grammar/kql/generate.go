@@ -0,0 +1,7 @@
+// Package kql contains the KQL lexer and parser generated from KQL.g4 with
+// ANTLR 4. Generated files are not committed; generate them with go
+// generate before building with the antlr tag, and after changing the
+// grammar.
+package kql
+
+//go:generate java -jar $ANTLR_JAR -Dlanguage=Go -package kql -no-listener -no-visitor KQL.g4
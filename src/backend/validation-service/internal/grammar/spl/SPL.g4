@@ -0,0 +1,146 @@
+// Grammar of Splunk SPL searches. A search is a pipeline of commands; the
+// first command is an implicit search unless the pipeline starts with a pipe.
+// Command arguments are parsed generically as options, expressions and
+// subsearches so that every command, including custom ones, yields a tree.
+// Search terms side by side are joined by an implicit AND, which binds looser
+// than OR.
+grammar SPL;
+
+options { caseInsensitive = true; }
+
+query
+    : pipeline EOF
+    ;
+
+pipeline
+    : (PIPE command | searchTerms) (PIPE command)*
+    ;
+
+searchTerms
+    : argument+
+    ;
+
+command
+    : IDENTIFIER argument*
+    ;
+
+argument
+    : expression
+    | COMMA
+    ;
+
+subsearch
+    : LBRACK pipeline RBRACK
+    ;
+
+expression
+    : primary                                                                # primaryExpression
+    | op=(MINUS | PLUS) expression                                           # unaryExpression
+    | expression op=(STAR | SLASH | PERCENT) expression                      # multiplicativeExpression
+    | expression op=(PLUS | MINUS | DOT) expression                          # additiveExpression
+    | expression op=(EQ | EQEQ | NEQ | LT | LTE | GT | GTE) expression       # comparisonExpression
+    | expression NOT? IN LPAREN (expression (COMMA? expression)*)? RPAREN    # inExpression
+    | expression NOT? LIKE expression                                        # likeExpression
+    | NOT expression                                                         # notExpression
+    | expression AND expression                                              # andExpression
+    | expression XOR expression                                              # xorExpression
+    | expression OR expression                                               # orExpression
+    ;
+
+primary
+    : LPAREN argument+ RPAREN                                          # groupPrimary
+    | (IDENTIFIER | LIKE) LPAREN (expression (COMMA expression)*)? RPAREN  # callPrimary
+    | subsearch                                                        # subsearchPrimary
+    | MACRO                                                            # macroPrimary
+    | STRING                                                           # stringPrimary
+    | NUMBER                                                           # numberPrimary
+    | DURATION                                                         # durationPrimary
+    | RELATIVE_TIME                                                    # relativeTimePrimary
+    | IPV4                                                             # addressPrimary
+    | (WILDCARD | STAR)                                                # wildcardPrimary
+    | IDENTIFIER                                                       # fieldPrimary
+    ;
+
+// Keywords
+
+AND : 'AND';
+OR  : 'OR';
+XOR : 'XOR';
+NOT : 'NOT';
+IN  : 'IN';
+LIKE: 'LIKE';
+
+// Punctuation and operators
+
+PIPE    : '|';
+COMMA   : ',';
+LPAREN  : '(';
+RPAREN  : ')';
+LBRACK  : '[';
+RBRACK  : ']';
+EQEQ    : '==';
+NEQ     : '!=';
+LTE     : '<=';
+GTE     : '>=';
+EQ      : '=';
+LT      : '<';
+GT      : '>';
+PLUS    : '+';
+MINUS   : '-';
+STAR    : '*';
+SLASH   : '/';
+PERCENT : '%';
+DOT     : '.';
+
+// Literals
+
+STRING
+    : '"' (~["\\] | '\\' .)* '"'
+    | '\'' (~['\\] | '\\' .)* '\''
+    ;
+
+// Search macros, expanded by Splunk before the search runs
+MACRO
+    : '`' ~[`\r\n]+ '`'
+    ;
+
+IPV4
+    : DIGIT+ '.' DIGIT+ '.' DIGIT+ '.' DIGIT+ ('/' DIGIT+)?
+    ;
+
+// Time modifiers such as -24h@h and @d
+RELATIVE_TIME
+    : [+-] DIGIT+ LETTER+ ('@' LETTER+ DIGIT*)?
+    | '@' LETTER+ DIGIT*
+    ;
+
+DURATION
+    : DIGIT+ LETTER+
+    ;
+
+NUMBER
+    : DIGIT+ ('.' DIGIT+)? ('e' [+-]? DIGIT+)?
+    ;
+
+// Wildcarded search terms such as web* and *.exe
+WILDCARD
+    : TERM_CHAR+ '*' (TERM_CHAR | '*')*
+    | '*' (TERM_CHAR | '*')+
+    ;
+
+IDENTIFIER
+    : (LETTER | '_') (LETTER | DIGIT | [_.:])*
+    ;
+
+// Triple-backtick comments are removed by Splunk before parsing
+COMMENT
+    : '```' .*? '```' -> skip
+    ;
+
+WS
+    : [ \t\r\n]+ -> skip
+    ;
+
+fragment DIGIT     : [0-9];
+fragment LETTER    : [a-z];
+fragment TERM_CHAR : [a-z0-9_.];
@@ -0,0 +1,7 @@
+// Package spl contains the SPL lexer and parser generated from SPL.g4 with
+// ANTLR 4. Generated files are not committed; generate them with go
+// generate before building with the antlr tag, and after changing the
+// grammar.
+package spl
+
+//go:generate java -jar $ANTLR_JAR -Dlanguage=Go -package spl -no-listener -no-visitor SPL.g4
@@ -0,0 +1,8 @@
+//go:build !antlr
+
+package grammar
+
+// languages is empty in builds without the antlr tag, whose generated
+// parsers are not present; Parse returns ErrUnsupportedLanguage for every
+// format
+var languages = map[string]parseFunc{}
@@ -0,0 +1,182 @@
+// Grammar of Google SecOps YARA-L 2.0 rules: the meta, events, match,
+// outcome, condition and options sections of one or more rules. Event
+// statements side by side are joined by an implicit AND. YARA-L is
+// case-sensitive.
+grammar YaraL;
+
+ruleset
+    : ruleDeclaration+ EOF
+    ;
+
+ruleDeclaration
+    : RULE IDENTIFIER LBRACE section+ RBRACE
+    ;
+
+section
+    : META COLON metaEntry*                   # metaSection
+    | EVENTS COLON expression+                # eventsSection
+    | MATCH COLON matchClause                 # matchSection
+    | OUTCOME COLON outcomeAssignment+        # outcomeSection
+    | CONDITION COLON expression              # conditionSection
+    | OPTIONS COLON optionEntry+              # optionsSection
+    ;
+
+metaEntry
+    : name EQ literal
+    ;
+
+matchClause
+    : VARIABLE (COMMA VARIABLE)* OVER DURATION ((BEFORE | AFTER) VARIABLE)?
+    ;
+
+outcomeAssignment
+    : VARIABLE EQ expression
+    ;
+
+optionEntry
+    : name EQ literal
+    ;
+
+expression
+    : LPAREN expression RPAREN                                          # groupExpression
+    | functionCall                                                      # callExpression
+    | fieldPath                                                         # fieldExpression
+    | (VARIABLE | COUNT_VARIABLE)                                       # variableExpression
+    | literal                                                           # literalExpression
+    | REGEX_LITERAL NOCASE?                                             # regexExpression
+    | MINUS expression                                                  # negateExpression
+    | expression op=(STAR | SLASH) expression                           # multiplicativeExpression
+    | expression op=(PLUS | MINUS) expression                           # additiveExpression
+    | expression op=(EQ | NEQ | LT | LTE | GT | GTE) expression NOCASE?  # comparisonExpression
+    | expression IN (REFERENCE_LIST | REGEX REFERENCE_LIST | CIDR REFERENCE_LIST)  # referenceListExpression
+    | NOT expression                                                    # notExpression
+    | (ANY | ALL) expression                                            # quantifiedExpression
+    | expression AND expression                                         # andExpression
+    | expression OR expression                                          # orExpression
+    ;
+
+// UDM field references such as $e.principal.ip or $e.about.labels["key"]
+fieldPath
+    : VARIABLE (DOT name (LBRACK (STRING | NUMBER) RBRACK)?)+
+    ;
+
+// Functions are namespaced, such as re.regex, net.ip_in_range_cidr and
+// strings.lower, except the outcome aggregates such as max and count_distinct
+functionCall
+    : name (DOT name)* LPAREN (expression (COMMA expression)*)? RPAREN
+    ;
+
+literal
+    : STRING
+    | NUMBER
+    | TRUE
+    | FALSE
+    ;
+
+// Names may be keywords, since UDM fields and functions such as
+// security_result.outcome and re.regex reuse them
+name
+    : IDENTIFIER
+    | META | EVENTS | MATCH | OUTCOME | CONDITION | OPTIONS
+    | OVER | BEFORE | AFTER | ANY | ALL | IN | REGEX | CIDR | NOCASE
+    ;
+
+// Keywords
+
+RULE      : 'rule';
+META      : 'meta';
+EVENTS    : 'events';
+MATCH     : 'match';
+OUTCOME   : 'outcome';
+CONDITION : 'condition';
+OPTIONS   : 'options';
+OVER      : 'over';
+BEFORE    : 'before';
+AFTER     : 'after';
+AND       : 'and';
+OR        : 'or';
+NOT       : 'not';
+ANY       : 'any';
+ALL       : 'all';
+IN        : 'in';
+REGEX     : 'regex';
+CIDR      : 'cidr';
+NOCASE    : 'nocase';
+TRUE      : 'true';
+FALSE     : 'false';
+
+// Punctuation and operators
+
+LBRACE : '{';
+RBRACE : '}';
+LPAREN : '(';
+RPAREN : ')';
+LBRACK : '[';
+RBRACK : ']';
+COLON  : ':';
+COMMA  : ',';
+DOT    : '.';
+NEQ    : '!=';
+LTE    : '<=';
+GTE    : '>=';
+EQ     : '=';
+LT     : '<';
+GT     : '>';
+PLUS   : '+';
+MINUS  : '-';
+STAR   : '*';
+
+// Comments come before SLASH and REGEX_LITERAL so that // and /* are never
+// read as a division or a regular expression
+LINE_COMMENT
+    : '//' ~[\r\n]* -> skip
+    ;
+
+BLOCK_COMMENT
+    : '/*' .*? '*/' -> skip
+    ;
+
+REGEX_LITERAL
+    : '/' (~[/\\\r\n] | '\\' .)+ '/'
+    ;
+
+SLASH : '/';
+
+// Literals
+
+STRING
+    : '"' (~["\\\r\n] | '\\' .)* '"'
+    | '`' ~[`]* '`'
+    ;
+
+VARIABLE
+    : '$' [a-zA-Z_] [a-zA-Z0-9_]*
+    ;
+
+// Event counts such as #e in the condition section
+COUNT_VARIABLE
+    : '#' [a-zA-Z_] [a-zA-Z0-9_]*
+    ;
+
+// Reference lists such as %allowed_hosts
+REFERENCE_LIST
+    : '%' [a-zA-Z_] [a-zA-Z0-9_]*
+    ;
+
+DURATION
+    : DIGIT+ [smhd]
+    ;
+
+NUMBER
+    : DIGIT+ ('.' DIGIT+)?
+    ;
+
+IDENTIFIER
+    : [a-zA-Z_] [a-zA-Z0-9_]*
+    ;
+
+WS
+    : [ \t\r\n]+ -> skip
+    ;
+
+fragment DIGIT : [0-9];
@@ -0,0 +1,7 @@
+// Package yaral contains the YARA-L lexer and parser generated from
+// YaraL.g4 with ANTLR 4. Generated files are not committed; generate them
+// with go generate before building with the antlr tag, and after changing
+// the grammar.
+package yaral
+
+//go:generate java -jar $ANTLR_JAR -Dlanguage=Go -package yaral -no-listener -no-visitor YaraL.g4
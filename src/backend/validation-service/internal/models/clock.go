@@ -0,0 +1,92 @@
+// Package models provides core validation models and types for the validation service
+package models
+
+import (
+    "encoding/binary"
+    "sync"
+    "time"
+
+    "github.com/google/uuid" // v1.4.0
+)
+
+// Clock provides the current time for timestamps on results and issues
+type Clock interface {
+    Now() time.Time
+}
+
+// IDGenerator provides identifiers for results and detections
+type IDGenerator interface {
+    NewID() uuid.UUID
+}
+
+// systemClock reads the wall clock in UTC
+type systemClock struct{}
+
+// Now returns the current UTC time
+func (systemClock) Now() time.Time {
+    return time.Now().UTC()
+}
+
+// randomIDs generates random (version 4) UUIDs
+type randomIDs struct{}
+
+// NewID returns a random UUID
+func (randomIDs) NewID() uuid.UUID {
+    return uuid.New()
+}
+
+// Default time and ID sources used when none are injected
+var (
+    SystemClock Clock       = systemClock{}
+    RandomIDs   IDGenerator = randomIDs{}
+)
+
+// StepClock is a deterministic Clock for golden tests. Each call to Now
+// returns the current time and then advances it by a fixed step.
+type StepClock struct {
+    mu   sync.Mutex
+    now  time.Time
+    step time.Duration
+}
+
+// NewStepClock creates a clock starting at start and advancing by step on
+// every reading; a zero step returns start forever
+func NewStepClock(start time.Time, step time.Duration) *StepClock {
+    return &StepClock{now: start.UTC(), step: step}
+}
+
+// Now returns the current fake time and advances the clock
+func (c *StepClock) Now() time.Time {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    now := c.now
+    c.now = c.now.Add(c.step)
+    return now
+}
+
+// SequentialIDGenerator is a deterministic IDGenerator for golden tests. It
+// returns version 4 formatted UUIDs counting up from 1, e.g.
+// 00000000-0000-4000-8000-000000000001.
+type SequentialIDGenerator struct {
+    mu   sync.Mutex
+    next uint64
+}
+
+// NewSequentialIDGenerator creates a generator whose first ID is 1
+func NewSequentialIDGenerator() *SequentialIDGenerator {
+    return &SequentialIDGenerator{next: 1}
+}
+
+// NewID returns the next sequential UUID
+func (g *SequentialIDGenerator) NewID() uuid.UUID {
+    g.mu.Lock()
+    n := g.next
+    g.next++
+    g.mu.Unlock()
+
+    var id uuid.UUID
+    binary.BigEndian.PutUint64(id[8:], n)
+    id[6] = 0x40              // version 4
+    id[8] = id[8]&0x3f | 0x80 // RFC 4122 variant
+    return id
+}
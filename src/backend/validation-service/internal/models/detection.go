@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid" // v1.4.0
@@ -20,6 +23,10 @@ const (
 	DetectionFormatCrowdstrike = "crowdstrike"
 	DetectionFormatYara        = "yara"
 	DetectionFormatYaraL       = "yaral"
+	DetectionFormatSentinel    = "sentinel"
+	DetectionFormatSuricata    = "suricata"
+	DetectionFormatSnort       = "snort"
+	DetectionFormatZeek        = "zeek"
 )
 
 // Common validation errors
@@ -29,6 +36,32 @@ var (
 	ErrInvalidUserID  = errors.New("invalid user ID")
 )
 
+// extensionFormatPattern restricts the names of formats added by validator plugins
+var extensionFormatPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+// extensionFormats are formats registered at runtime by validator plugins.
+// Their content is checked by the plugin rather than validateFormatSpecific.
+var (
+	extensionFormatsMu sync.RWMutex
+	extensionFormats   = make(map[string]bool)
+)
+
+// RegisterFormat adds a detection format served by an external validator
+// plugin. Built-in formats cannot be registered.
+func RegisterFormat(format string) error {
+	if !extensionFormatPattern.MatchString(format) {
+		return fmt.Errorf("%w: %q: names must be 2-32 lowercase letters, digits, '-' or '_'", ErrInvalidFormat, format)
+	}
+	if isBuiltinFormat(format) {
+		return fmt.Errorf("%w: %s is a built-in format", ErrInvalidFormat, format)
+	}
+
+	extensionFormatsMu.Lock()
+	defer extensionFormatsMu.Unlock()
+	extensionFormats[format] = true
+	return nil
+}
+
 // Detection represents a security detection rule with comprehensive metadata
 type Detection struct {
 	ID        uuid.UUID       `json:"id"`
@@ -132,6 +165,21 @@ func (d *Detection) MarshalJSON() ([]byte, error) {
 
 // isValidFormat checks if the provided format is supported
 func isValidFormat(format string) bool {
+	if isBuiltinFormat(format) {
+		return true
+	}
+	return isExtensionFormat(format)
+}
+
+// isExtensionFormat reports whether format was added by RegisterFormat
+func isExtensionFormat(format string) bool {
+	extensionFormatsMu.RLock()
+	defer extensionFormatsMu.RUnlock()
+	return extensionFormats[format]
+}
+
+// isBuiltinFormat checks if the format is one the service ships validators for
+func isBuiltinFormat(format string) bool {
 	switch format {
 	case DetectionFormatSplunk,
 		DetectionFormatQRadar,
@@ -140,7 +188,11 @@ func isValidFormat(format string) bool {
 		DetectionFormatPaloAlto,
 		DetectionFormatCrowdstrike,
 		DetectionFormatYara,
-		DetectionFormatYaraL:
+		DetectionFormatYaraL,
+		DetectionFormatSentinel,
+		DetectionFormatSuricata,
+		DetectionFormatSnort,
+		DetectionFormatZeek:
 		return true
 	default:
 		return false
@@ -166,7 +218,18 @@ func (d *Detection) validateFormatSpecific() error {
 		return validateYaraDetection(d.Content)
 	case DetectionFormatYaraL:
 		return validateYaraLDetection(d.Content)
+	case DetectionFormatSentinel:
+		return validateSentinelDetection(d.Content)
+	case DetectionFormatSuricata:
+		return validateIDSDetection(d.Content, "Suricata")
+	case DetectionFormatSnort:
+		return validateIDSDetection(d.Content, "Snort")
+	case DetectionFormatZeek:
+		return validateZeekDetection(d.Content)
 	default:
+		if isExtensionFormat(d.Format) {
+			return nil
+		}
 		return ErrInvalidFormat
 	}
 }
@@ -228,6 +291,14 @@ func validateYaraDetection(content string) error {
 	return nil
 }
 
+func validateSentinelDetection(content string) error {
+	// Basic Sentinel analytics rule validation - YAML document with a query
+	if len(content) < 5 || !containsBasicSentinelComponents(content) {
+		return errors.New("invalid Sentinel analytics rule format")
+	}
+	return nil
+}
+
 func validateYaraLDetection(content string) error {
 	// Basic YARA-L validation
 	if len(content) < 5 || !containsBasicYaraLComponents(content) {
@@ -236,6 +307,22 @@ func validateYaraLDetection(content string) error {
 	return nil
 }
 
+func validateIDSDetection(content, engine string) error {
+	// Basic Suricata/Snort validation - rules carry their options in parentheses
+	if len(content) < 5 || !containsBasicIDSComponents(content) {
+		return fmt.Errorf("invalid %s rule format", engine)
+	}
+	return nil
+}
+
+func validateZeekDetection(content string) error {
+	// Basic Zeek validation - signatures and scripts are brace-delimited
+	if len(content) < 5 || !containsBasicZeekComponents(content) {
+		return errors.New("invalid Zeek signature or script format")
+	}
+	return nil
+}
+
 // Helper functions for basic format validation
 func containsBasicSPLComponents(content string) bool {
 	return true // Implement actual SPL validation logic
@@ -267,4 +354,16 @@ func containsBasicYaraComponents(content string) bool {
 
 func containsBasicYaraLComponents(content string) bool {
 	return true // Implement actual YARA-L validation logic
-}
\ No newline at end of file
+}
+
+func containsBasicSentinelComponents(content string) bool {
+	return strings.Contains(content, "query:")
+}
+
+func containsBasicIDSComponents(content string) bool {
+	return strings.Contains(content, "(") && strings.Contains(content, ")")
+}
+
+func containsBasicZeekComponents(content string) bool {
+	return strings.Contains(content, "{") && strings.Contains(content, "}")
+}
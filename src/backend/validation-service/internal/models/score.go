@@ -0,0 +1,56 @@
+package models
+
+// ScoreDeduction is the confidence penalty of one correctness issue
+type ScoreDeduction struct {
+    IssueCode string `json:"issue_code"`
+    Severity  string `json:"severity"`
+    // Weight is the confidence the issue costs under the scoring policy
+    Weight float64 `json:"weight"`
+    // Section is the part of the rule the issue was found in
+    Section string `json:"section"`
+    Line    int    `json:"line,omitempty"`
+    Message string `json:"message"`
+}
+
+// ScoreBreakdown explains a confidence score as the deductions of its
+// correctness issues from the base score of 100. Optimization hints never
+// deduct and are left out.
+type ScoreBreakdown struct {
+    BaseScore      float64          `json:"base_score"`
+    Deductions     []ScoreDeduction `json:"deductions"`
+    TotalDeduction float64          `json:"total_deduction"`
+    // Score is the base score less every deduction, bounded at 0
+    Score     float64 `json:"score"`
+    Threshold float64 `json:"threshold"`
+}
+
+// BreakDownScore returns the deductions of the result's correctness issues
+// under its scoring policy
+func (r *ValidationResult) BreakDownScore() *ScoreBreakdown {
+    policy := r.ScoringPolicy()
+    breakdown := &ScoreBreakdown{
+        BaseScore:  100.0,
+        Deductions: make([]ScoreDeduction, 0, len(r.Issues)),
+        Threshold:  policy.MinConfidence,
+    }
+    for _, issue := range r.Issues {
+        if issue.IsOptimization() {
+            continue
+        }
+        weight := policy.Weight(issue.Severity)
+        breakdown.Deductions = append(breakdown.Deductions, ScoreDeduction{
+            IssueCode: issue.IssueCode,
+            Severity:  issue.Severity,
+            Weight:    weight,
+            Section:   issue.Location,
+            Line:      issue.Line,
+            Message:   issue.Message,
+        })
+        breakdown.TotalDeduction += weight
+    }
+    breakdown.Score = breakdown.BaseScore - breakdown.TotalDeduction
+    if breakdown.Score < 0 {
+        breakdown.Score = 0
+    }
+    return breakdown
+}
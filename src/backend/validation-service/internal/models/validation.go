@@ -3,6 +3,7 @@ package models
 
 import (
     "encoding/json" // builtin
+    "strconv"      // builtin
     "time"         // builtin
     "github.com/google/uuid" // v1.4.0
 )
@@ -21,6 +22,31 @@ const (
     ValidationSeverityLow    = "low"
 )
 
+// Issue categories. Correctness issues, the default, describe defects of the
+// detection; optimization issues are advisory hints that would improve the
+// translated query and never affect the confidence score or status.
+const (
+    IssueCategoryCorrectness  = "correctness"
+    IssueCategoryOptimization = "optimization"
+)
+
+// Gate verdicts. A validation fails its gate when it has a blocking issue,
+// so CI pipelines can gate on the verdict alone.
+const (
+    GatePass = "pass"
+    GateFail = "fail"
+)
+
+// Lint levels of content findings, from most to least serious. Lint
+// findings are reported apart from issues and never affect the confidence
+// score or status.
+const (
+    LintLevelError   = "error"
+    LintLevelWarning = "warning"
+    LintLevelInfo    = "info"
+    LintLevelStyle   = "style"
+)
+
 // Validation confidence and weight thresholds
 const (
     ValidationConfidenceThreshold  = 95.0
@@ -35,6 +61,105 @@ type ValidationMetadata struct {
     ValidatorConfig  map[string]interface{} `json:"validator_config"`
     ValidationTime   time.Duration          `json:"validation_time"`
     ValidatedFields  []string              `json:"validated_fields"`
+    // Trace is the stage-by-stage execution log, recorded only on request
+    Trace []ExecutionStage `json:"trace,omitempty"`
+    // Scoring is the effective scoring policy of the target format
+    Scoring *ScoringPolicy `json:"scoring,omitempty"`
+    // PolicyDecision is the outcome of the tenant's Rego scoring policy,
+    // recorded when the tenant has one
+    PolicyDecision *PolicyDecision `json:"policy_decision,omitempty"`
+    // Profile is the validation profile the result was judged with
+    Profile string `json:"profile,omitempty"`
+    // Stale is set when the result was cached by an earlier validator
+    // version or with earlier catalogs and is being validated again
+    Stale bool `json:"stale,omitempty"`
+    // Preprocessors are the pre-processors that unwrapped vendor export
+    // packaging from the submitted content
+    Preprocessors []string `json:"preprocessors,omitempty"`
+    // Gate is the GatePass or GateFail verdict of the gate policy of the
+    // profile and tenant, and GateReasons explain a failure
+    Gate        string   `json:"gate,omitempty"`
+    GateReasons []string `json:"gate_reasons,omitempty"`
+    // ScoreRevisions records every re-scoring of the stored result, oldest
+    // first, so scores stay comparable across scoring policy changes
+    ScoreRevisions []ScoreRevision `json:"score_revisions,omitempty"`
+    // RequestID and TraceID correlate the result with the request and the
+    // trace it was produced in
+    RequestID string `json:"request_id,omitempty"`
+    TraceID   string `json:"trace_id,omitempty"`
+}
+
+// ScoreRevision records the score and status of a result before and after it
+// was re-scored with a changed scoring policy
+type ScoreRevision struct {
+    RescoredAt      time.Time      `json:"rescored_at"`
+    PreviousScore   float64        `json:"previous_score"`
+    Score           float64        `json:"score"`
+    PreviousStatus  string         `json:"previous_status"`
+    Status          string         `json:"status"`
+    PreviousScoring *ScoringPolicy `json:"previous_scoring,omitempty"`
+}
+
+// PolicyDecision records how a tenant's Rego scoring policy changed the
+// status and confidence score computed from the scoring weights. A policy
+// that failed or timed out leaves both unchanged and reports its error.
+type PolicyDecision struct {
+    // Digest identifies the version of the policy
+    Digest       string   `json:"digest"`
+    Applied      bool     `json:"applied"`
+    StaticScore  float64  `json:"static_score"`
+    StaticStatus string   `json:"static_status"`
+    Reasons      []string `json:"reasons,omitempty"`
+    Error        string   `json:"error,omitempty"`
+}
+
+// ScoringPolicy sets the confidence penalty of each issue severity, the
+// minimum confidence of a successful validation and the complexity and
+// nesting limits of a format
+type ScoringPolicy struct {
+    SeverityWeights map[string]float64 `json:"severity_weights"`
+    MinConfidence   float64            `json:"min_confidence"`
+    // MaxComplexity bounds the logic of a rule; 0 when the format has no
+    // complexity limit
+    MaxComplexity int `json:"max_complexity,omitempty"`
+    // MaxNestingDepth bounds the bracket nesting of a rule; deeper rules are
+    // rejected before they are parsed
+    MaxNestingDepth int `json:"max_nesting_depth,omitempty"`
+    // IssueSeverities maps issue codes to the severity issues added
+    // afterwards are reported and weighed with
+    IssueSeverities map[string]string `json:"issue_severities,omitempty"`
+}
+
+// DefaultScoringPolicy returns the built-in weights and threshold
+func DefaultScoringPolicy() *ScoringPolicy {
+    return &ScoringPolicy{
+        SeverityWeights: map[string]float64{
+            ValidationSeverityHigh:   ValidationSeverityWeightHigh,
+            ValidationSeverityMedium: ValidationSeverityWeightMedium,
+            ValidationSeverityLow:    ValidationSeverityWeightLow,
+        },
+        MinConfidence: ValidationConfidenceThreshold,
+    }
+}
+
+// Weight returns the confidence penalty of an issue of severity; unknown
+// severities weigh as low
+func (p *ScoringPolicy) Weight(severity string) float64 {
+    if weight, ok := p.SeverityWeights[severity]; ok {
+        return weight
+    }
+    return p.SeverityWeights[ValidationSeverityLow]
+}
+
+// ExecutionStage records one stage of a validation run for debugging scores
+type ExecutionStage struct {
+    Stage         string  `json:"stage"`
+    DurationMs    float64 `json:"duration_ms"`
+    IssuesEmitted int     `json:"issues_emitted"`
+    ScoreBefore   float64 `json:"score_before"`
+    ScoreAfter    float64 `json:"score_after"`
+    // ShortCircuit explains why validation stopped after this stage
+    ShortCircuit string `json:"short_circuit,omitempty"`
 }
 
 // ValidationHistoryEntry tracks individual validation steps
@@ -44,18 +169,80 @@ type ValidationHistoryEntry struct {
     Details   map[string]interface{} `json:"details"`
 }
 
+// Score adjustment history actions. Every change of a result's confidence
+// score after it is created is recorded under one of them with its amount
+// and the score before and after it.
+const (
+    // HistoryIssueAdded deducts the weight of an added issue
+    HistoryIssueAdded = "issue_added"
+    // HistoryConfidenceAdjusted records a score set through
+    // SetConfidenceScore, naming what set it and why
+    HistoryConfidenceAdjusted = "confidence_adjusted"
+)
+
 // ValidationIssue represents a detailed validation issue with enhanced tracking
 type ValidationIssue struct {
     Message      string                 `json:"message"`
     Severity     string                 `json:"severity"`
     Location     string                 `json:"location"`
+    Line         int                    `json:"line,omitempty"`
+    Column       int                    `json:"column,omitempty"`
     Timestamp    time.Time              `json:"timestamp"`
     IssueCode    string                 `json:"issue_code"`
+    // NamespacedCode is IssueCode qualified with the format and plugin that
+    // raised it, such as crowdstrike.core.CS001. IssueCode keeps the legacy
+    // code for existing clients.
+    NamespacedCode string `json:"namespaced_code,omitempty"`
     Remediation  string                 `json:"remediation"`
+    // Fixable is set when the quick-fix endpoint can correct the issue
+    Fixable      bool                   `json:"fixable"`
     IssueMetadata map[string]interface{} `json:"issue_metadata"`
+    // Category is IssueCategoryOptimization for advisory hints; empty for
+    // correctness issues
+    Category string `json:"category,omitempty"`
+    // Blocking is set for issues that fail the gate of the validation
+    Blocking bool `json:"blocking"`
+    // ID identifies the issue within its result; it is assigned when the
+    // issue is added
+    ID string `json:"id,omitempty"`
+    // CausedBy is the ID of the issue this one follows from, so a cascade
+    // of issues can be collapsed to its root cause
+    CausedBy string `json:"caused_by,omitempty"`
+    // RelatedTo are the IDs of issues about the same problem, neither of
+    // which causes the other
+    RelatedTo []string `json:"related_to,omitempty"`
+}
+
+// IsOptimization reports whether the issue is an advisory optimization hint
+func (i *ValidationIssue) IsOptimization() bool {
+    return i.Category == IssueCategoryOptimization
 }
 
-// GetSeverityWeight returns the numerical weight of the issue severity
+// LintFinding is a content hygiene finding such as a naming convention or
+// formatting violation
+type LintFinding struct {
+    Rule     string `json:"rule"`
+    Level    string `json:"level"`
+    Category string `json:"category"`
+    Message  string `json:"message"`
+    Location string `json:"location,omitempty"`
+    Line     int    `json:"line,omitempty"`
+    Column   int    `json:"column,omitempty"`
+}
+
+// RuleSummary describes in plain English what a detection does, so reviewers
+// who do not read the query language can sanity-check a translation
+type RuleSummary struct {
+    Text       string   `json:"text"`
+    Fields     []string `json:"fields,omitempty"`
+    Conditions []string `json:"conditions,omitempty"`
+    Exclusions []string `json:"exclusions,omitempty"`
+    Thresholds []string `json:"thresholds,omitempty"`
+    TimeWindow []string `json:"time_window,omitempty"`
+}
+
+// GetSeverityWeight returns the numerical weight of the issue severity under
+// the default scoring policy
 func (i *ValidationIssue) GetSeverityWeight() float64 {
     switch i.Severity {
     case ValidationSeverityHigh:
@@ -74,6 +261,8 @@ type ValidationResult struct {
     ID                   uuid.UUID               `json:"id"`
     CreatedAt            time.Time               `json:"created_at"`
     Status               string                  `json:"status"`
+    // ConfidenceScore changes only through AddIssue and SetConfidenceScore,
+    // which record every adjustment in ValidationHistory
     ConfidenceScore      float64                 `json:"confidence_score"`
     Issues               []ValidationIssue        `json:"issues"`
     SourceFormat         string                  `json:"source_format"`
@@ -81,6 +270,18 @@ type ValidationResult struct {
     Metadata             ValidationMetadata       `json:"metadata"`
     FormatSpecificDetails map[string]interface{} `json:"format_specific_details"`
     ValidationHistory    []ValidationHistoryEntry `json:"validation_history"`
+    // ScoreBreakdown lists the deduction of every issue from the confidence
+    // score; it is set once the result is scored
+    ScoreBreakdown *ScoreBreakdown `json:"score_breakdown,omitempty"`
+    // Lint lists content hygiene findings; they do not affect the score
+    Lint []LintFinding `json:"lint,omitempty"`
+    // LintProfile is the lint profile the findings were produced with
+    LintProfile string `json:"lint_profile,omitempty"`
+    // TenantID is the tenant the validation was made on behalf of
+    TenantID string `json:"tenant_id,omitempty"`
+
+    // clock timestamps issues added to the result
+    clock Clock
 }
 
 // ValidationReport provides a detailed summary of validation results
@@ -90,18 +291,31 @@ type ValidationReport struct {
     Recommendations []string              `json:"recommendations"`
     SuccessMetrics  map[string]float64    `json:"success_metrics"`
     FormatAnalysis  map[string]interface{} `json:"format_analysis"`
+    // LintSummary counts lint findings by level
+    LintSummary map[string]int `json:"lint_summary,omitempty"`
+    // SourceSummary and TargetSummary describe what the source detection
+    // and its translation do
+    SourceSummary *RuleSummary `json:"source_summary,omitempty"`
+    TargetSummary *RuleSummary `json:"target_summary,omitempty"`
 }
 
 // NewValidationResult creates a new enhanced validation result instance
 func NewValidationResult(detection *Detection) (*ValidationResult, error) {
+    return NewValidationResultWith(detection, SystemClock, RandomIDs)
+}
+
+// NewValidationResultWith creates a validation result taking its ID from ids
+// and its timestamps, including those of issues added later, from clock
+func NewValidationResultWith(detection *Detection, clock Clock, ids IDGenerator) (*ValidationResult, error) {
     sourceFormat, err := detection.GetFormat()
     if err != nil {
         return nil, err
     }
 
     result := &ValidationResult{
-        ID:                   uuid.New(),
-        CreatedAt:            time.Now().UTC(),
+        clock:                clock,
+        ID:                   ids.NewID(),
+        CreatedAt:            clock.Now(),
         Status:               ValidationStatusSuccess,
         ConfidenceScore:      100.0,
         Issues:               make([]ValidationIssue, 0),
@@ -117,7 +331,7 @@ func NewValidationResult(detection *Detection) (*ValidationResult, error) {
 
     // Add initial validation history entry
     result.ValidationHistory = append(result.ValidationHistory, ValidationHistoryEntry{
-        Timestamp: time.Now().UTC(),
+        Timestamp: result.CreatedAt,
         Action:    "validation_started",
         Details: map[string]interface{}{
             "source_format": sourceFormat,
@@ -131,18 +345,26 @@ func NewValidationResult(detection *Detection) (*ValidationResult, error) {
 func (r *ValidationResult) AddIssue(issue *ValidationIssue) {
     // Set timestamp if not already set
     if issue.Timestamp.IsZero() {
-        issue.Timestamp = time.Now().UTC()
+        issue.Timestamp = r.now()
+    }
+
+    // Report the issue with the severity the scoring policy maps its code to
+    policy := r.ScoringPolicy()
+    if severity, ok := policy.IssueSeverities[issue.IssueCode]; ok {
+        issue.Severity = severity
     }
 
     // Add issue to collection
+    issue.ID = r.nextIssueID()
     r.Issues = append(r.Issues, *issue)
 
     // Calculate confidence impact
-    severityWeight := issue.GetSeverityWeight()
+    severityWeight := policy.Weight(issue.Severity)
+    previousScore := r.ConfidenceScore
     r.ConfidenceScore -= severityWeight
 
     // Update validation status based on confidence score
-    if r.ConfidenceScore < ValidationConfidenceThreshold {
+    if r.ConfidenceScore < policy.MinConfidence {
         if r.Status != ValidationStatusError {
             r.Status = ValidationStatusWarning
         }
@@ -151,15 +373,228 @@ func (r *ValidationResult) AddIssue(issue *ValidationIssue) {
     // Add to validation history
     r.ValidationHistory = append(r.ValidationHistory, ValidationHistoryEntry{
         Timestamp: issue.Timestamp,
-        Action:    "issue_added",
+        Action:    HistoryIssueAdded,
         Details: map[string]interface{}{
-            "issue_code":    issue.IssueCode,
-            "severity":      issue.Severity,
+            "issue_code":        issue.IssueCode,
+            "severity":          issue.Severity,
             "confidence_impact": severityWeight,
+            "previous_score":    previousScore,
+            "score":             r.ConfidenceScore,
+        },
+    })
+}
+
+// Section returns an empty result sharing the clock and scoring policy of r,
+// to validate a section of a rule into apart from the other sections, such
+// as on a goroutine of its own. Merge adds its findings to r.
+func (r *ValidationResult) Section() *ValidationResult {
+    return &ValidationResult{
+        clock:                 r.clock,
+        Status:                ValidationStatusSuccess,
+        ConfidenceScore:       100.0,
+        Issues:                make([]ValidationIssue, 0),
+        FormatSpecificDetails: make(map[string]interface{}),
+        Metadata:              ValidationMetadata{Scoring: r.Metadata.Scoring},
+    }
+}
+
+// Merge adds the issues, lint findings and format-specific details of a
+// section returned by Section to r, as though they were added to r directly
+func (r *ValidationResult) Merge(section *ValidationResult) {
+    for i := range section.Issues {
+        issue := section.Issues[i]
+        if issue.IsOptimization() {
+            r.AddOptimizationHint(&issue)
+        } else {
+            r.AddIssue(&issue)
+        }
+    }
+    for _, finding := range section.Lint {
+        r.AddLintFinding(finding)
+    }
+    for key, value := range section.FormatSpecificDetails {
+        r.FormatSpecificDetails[key] = value
+    }
+}
+
+// SetConfidenceScore sets the confidence score, bounded to 0-100, and
+// records the adjustment in the history: by names what set the score, such
+// as a validator or the tenant scoring policy, and reason why. Setting the
+// current score records nothing. It returns the amount the score changed.
+func (r *ValidationResult) SetConfidenceScore(score float64, by, reason string) float64 {
+    if score < 0 {
+        score = 0
+    }
+    if score > 100 {
+        score = 100
+    }
+    previousScore := r.ConfidenceScore
+    amount := score - previousScore
+    if amount == 0 {
+        return 0
+    }
+    r.ConfidenceScore = score
+
+    r.ValidationHistory = append(r.ValidationHistory, ValidationHistoryEntry{
+        Timestamp: r.now(),
+        Action:    HistoryConfidenceAdjusted,
+        Details: map[string]interface{}{
+            "by":             by,
+            "reason":         reason,
+            "amount":         amount,
+            "previous_score": previousScore,
+            "score":          score,
+        },
+    })
+    return amount
+}
+
+// SetScoringPolicy sets the policy issues added afterwards are weighed with
+// and records it in the metadata
+func (r *ValidationResult) SetScoringPolicy(policy *ScoringPolicy) {
+    r.Metadata.Scoring = policy
+}
+
+// ScoringPolicy returns the scoring policy of the result, the default policy
+// when none was set
+func (r *ValidationResult) ScoringPolicy() *ScoringPolicy {
+    if r.Metadata.Scoring == nil {
+        return DefaultScoringPolicy()
+    }
+    return r.Metadata.Scoring
+}
+
+// RecalculateConfidence sets the confidence score to 100 less the weight of
+// every correctness issue, bounded at 0, recording any change
+func (r *ValidationResult) RecalculateConfidence() {
+    policy := r.ScoringPolicy()
+    confidence := 100.0
+    for _, issue := range r.Issues {
+        if !issue.IsOptimization() {
+            confidence -= policy.Weight(issue.Severity)
+        }
+    }
+    r.SetConfidenceScore(confidence, "recalculation", "score recomputed from the issue weights")
+}
+
+// MergeIssues adds issues collected apart from the result, such as those of
+// a format validator, with AddIssue. The issues are given IDs of the result
+// and their CausedBy and RelatedTo links are rewritten to match; links to
+// issues outside issues are dropped. prepare, if set, is applied to each
+// issue before it is added.
+func (r *ValidationResult) MergeIssues(issues []ValidationIssue, prepare func(*ValidationIssue)) {
+    ids := make(map[string]string, len(issues))
+    first := len(r.Issues)
+    for i := range issues {
+        issue := issues[i]
+        if prepare != nil {
+            prepare(&issue)
+        }
+        previous := issue.ID
+        r.AddIssue(&issue)
+        if previous != "" {
+            ids[previous] = issue.ID
+        }
+    }
+
+    for i := first; i < len(r.Issues); i++ {
+        issue := &r.Issues[i]
+        issue.CausedBy = ids[issue.CausedBy]
+        var related []string
+        for _, id := range issue.RelatedTo {
+            if mapped, ok := ids[id]; ok {
+                related = append(related, mapped)
+            }
+        }
+        issue.RelatedTo = related
+    }
+}
+
+// RelateIssues records that the issues with IDs a and b are about the same
+// problem. Unknown IDs are ignored.
+func (r *ValidationResult) RelateIssues(a, b string) {
+    first, second := r.issue(a), r.issue(b)
+    if first == nil || second == nil || a == b {
+        return
+    }
+    if !containsID(first.RelatedTo, b) {
+        first.RelatedTo = append(first.RelatedTo, b)
+    }
+    if !containsID(second.RelatedTo, a) {
+        second.RelatedTo = append(second.RelatedTo, a)
+    }
+}
+
+// issue returns the issue with the ID, or nil
+func (r *ValidationResult) issue(id string) *ValidationIssue {
+    if id == "" {
+        return nil
+    }
+    for i := range r.Issues {
+        if r.Issues[i].ID == id {
+            return &r.Issues[i]
+        }
+    }
+    return nil
+}
+
+// nextIssueID returns an ID no issue of the result has. IDs count issues
+// from I1; issues removed by re-scoring leave gaps.
+func (r *ValidationResult) nextIssueID() string {
+    for n := len(r.Issues) + 1; ; n++ {
+        id := "I" + strconv.Itoa(n)
+        if r.issue(id) == nil {
+            return id
+        }
+    }
+}
+
+// containsID reports whether ids contains id
+func containsID(ids []string, id string) bool {
+    for _, candidate := range ids {
+        if candidate == id {
+            return true
+        }
+    }
+    return false
+}
+
+// AddOptimizationHint records an advisory optimization issue. Hints are
+// always low severity and, unlike AddIssue, leave the confidence score and
+// status unchanged.
+func (r *ValidationResult) AddOptimizationHint(issue *ValidationIssue) {
+    if issue.Timestamp.IsZero() {
+        issue.Timestamp = r.now()
+    }
+    issue.Severity = ValidationSeverityLow
+    issue.Category = IssueCategoryOptimization
+    issue.ID = r.nextIssueID()
+    r.Issues = append(r.Issues, *issue)
+
+    r.ValidationHistory = append(r.ValidationHistory, ValidationHistoryEntry{
+        Timestamp: issue.Timestamp,
+        Action:    "hint_added",
+        Details: map[string]interface{}{
+            "issue_code": issue.IssueCode,
         },
     })
 }
 
+// AddLintFinding records a lint finding. Unlike AddIssue it leaves the
+// confidence score and status unchanged.
+func (r *ValidationResult) AddLintFinding(finding LintFinding) {
+    r.Lint = append(r.Lint, finding)
+}
+
+// now reads the result clock, falling back to the system clock for results
+// not created by NewValidationResultWith, such as decoded ones
+func (r *ValidationResult) now() time.Time {
+    if r.clock == nil {
+        return SystemClock.Now()
+    }
+    return r.clock.Now()
+}
+
 // GetDetailedReport generates a comprehensive validation report
 func (r *ValidationResult) GetDetailedReport() ValidationReport {
     report := ValidationReport{
@@ -177,11 +612,27 @@ func (r *ValidationResult) GetDetailedReport() ValidationReport {
         ValidationSeverityLow:    0,
     }
 
+    // Optimization hints are counted apart from correctness issues
+    optimizations := 0
     for _, issue := range r.Issues {
+        if issue.IsOptimization() {
+            optimizations++
+            continue
+        }
         severityCounts[issue.Severity]++
     }
 
     report.Summary = severityCounts
+    if optimizations > 0 {
+        report.Summary[IssueCategoryOptimization] = optimizations
+    }
+
+    if len(r.Lint) > 0 {
+        report.LintSummary = make(map[string]int)
+        for _, finding := range r.Lint {
+            report.LintSummary[finding.Level]++
+        }
+    }
 
     // Calculate success metrics
     report.SuccessMetrics = map[string]float64{
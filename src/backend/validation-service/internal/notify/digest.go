@@ -0,0 +1,154 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"validation-service/internal/storage"
+)
+
+// sendDigests composes and sends the digests of every subscription whose
+// hour or day has ended since its last digest
+func (d *Dispatcher) sendDigests(ctx context.Context) {
+	now := d.now().UTC()
+	err := d.store.ScanSubscriptions(ctx, func(subscription *storage.Subscription) error {
+		if !subscription.Digest() || !d.digestDue(subscription, now) {
+			return nil
+		}
+		d.sendDigest(ctx, subscription, now)
+		return nil
+	})
+	if err != nil {
+		d.log.Error("Failed to scan notification subscriptions for digests",
+			"error", err,
+		)
+	}
+}
+
+// digestDue reports whether a digest period ended after the subscription's
+// last digest, or after it was created for subscriptions without one
+func (d *Dispatcher) digestDue(subscription *storage.Subscription, now time.Time) bool {
+	last := subscription.CreatedAt
+	if subscription.LastDigestAt != nil {
+		last = *subscription.LastDigestAt
+	}
+	return d.periodStart(subscription.Delivery, now).After(last)
+}
+
+// periodStart returns the start of the digest period containing now: the
+// hour, or the day starting at the daily digest hour
+func (d *Dispatcher) periodStart(delivery string, now time.Time) time.Time {
+	if delivery == storage.DeliveryHourlyDigest {
+		return now.Truncate(time.Hour)
+	}
+	start := time.Date(now.Year(), now.Month(), now.Day(), d.cfg.DailyDigestHour, 0, 0, 0, time.UTC)
+	if start.After(now) {
+		start = start.AddDate(0, 0, -1)
+	}
+	return start
+}
+
+// sendDigest takes the queued events of a subscription and sends them as one
+// message. Events of a digest that could not be delivered are queued again
+// for the next one.
+func (d *Dispatcher) sendDigest(ctx context.Context, subscription *storage.Subscription, now time.Time) {
+	events, err := d.store.TakeDigestEvents(ctx, subscription.ID, now)
+	if err != nil {
+		d.log.Error("Failed to take digest events",
+			"error", err,
+			"subscription_id", subscription.ID,
+		)
+		return
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	if d.send(ctx, subscription, composeDigest(subscription.Delivery, events, d.cfg.DigestMaxEvents)) {
+		return
+	}
+	for _, event := range events {
+		if err := d.store.QueueDigestEvent(ctx, subscription.ID, event); err != nil {
+			d.log.Error("Failed to requeue digest event",
+				"error", err,
+				"subscription_id", subscription.ID,
+				"event_id", event.ID,
+			)
+			return
+		}
+	}
+}
+
+// composeDigest summarizes events in one message: the number of events by
+// type and severity, followed by the most severe events, newest first within
+// a severity
+func composeDigest(delivery string, events []*storage.NotificationEvent, maxEvents int) *Message {
+	period := "Hourly"
+	if delivery == storage.DeliveryDailyDigest {
+		period = "Daily"
+	}
+
+	byType := make(map[string]int)
+	bySeverity := make(map[string]int)
+	for _, event := range events {
+		byType[event.Type]++
+		severity := event.Severity
+		if severity == "" {
+			severity = "none"
+		}
+		bySeverity[severity]++
+	}
+
+	listed := append([]*storage.NotificationEvent(nil), events...)
+	sort.SliceStable(listed, func(i, j int) bool {
+		a, b := listed[i], listed[j]
+		if severityOrder[a.Severity] != severityOrder[b.Severity] {
+			return severityOrder[a.Severity] > severityOrder[b.Severity]
+		}
+		return a.OccurredAt.After(b.OccurredAt)
+	})
+	if maxEvents > 0 && len(listed) > maxEvents {
+		listed = listed[:maxEvents]
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "%d events between %s and %s\n",
+		len(events), events[0].OccurredAt.Format(time.RFC3339), events[len(events)-1].OccurredAt.Format(time.RFC3339))
+	for _, eventType := range sortedKeys(byType) {
+		fmt.Fprintf(&text, "  %s: %d\n", eventType, byType[eventType])
+	}
+	fmt.Fprintf(&text, "By highest severity:")
+	for _, severity := range []string{"high", "medium", "low", "none"} {
+		if count := bySeverity[severity]; count > 0 {
+			fmt.Fprintf(&text, " %s %d", severity, count)
+		}
+	}
+	text.WriteString("\n\n")
+	for _, event := range listed {
+		fmt.Fprintf(&text, "- %s\n", event.Title)
+	}
+	if hidden := len(events) - len(listed); hidden > 0 {
+		fmt.Fprintf(&text, "... and %d more\n", hidden)
+	}
+
+	return &Message{
+		Subject: fmt.Sprintf("%s notification digest: %d events", period, len(events)),
+		Text:    text.String(),
+		Digest:  true,
+		Events:  listed,
+		Total:   len(events),
+	}
+}
+
+// sortedKeys returns the keys of counts in order
+func sortedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,372 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"validation-service/internal/config"
+	"validation-service/internal/models"
+	"validation-service/internal/services/report"
+	"validation-service/internal/storage"
+)
+
+// ErrNoRecipients is returned when an email has no recipients
+var ErrNoRecipients = errors.New("no email recipients")
+
+// Attachment is a file attached to an email
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Email is a message sent through the SMTP server
+type Email struct {
+	// TenantID selects the sender address
+	TenantID    string
+	To          []string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// JobSummary describes a finished background job, such as a result
+// re-scoring run or a corpus import
+type JobSummary struct {
+	Job        string
+	TenantID   string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	// Counts are the job's outcome counters, such as rescored or imported
+	Counts map[string]int
+	Errors []string
+}
+
+// EmailSender sends notifications, validation reports and job summaries
+// through an SMTP server
+type EmailSender struct {
+	cfg     config.EmailConfig
+	timeout time.Duration
+}
+
+// NewEmailSender creates a sender for the SMTP server of cfg. Each message
+// must be delivered within timeout.
+func NewEmailSender(cfg config.EmailConfig, timeout time.Duration) *EmailSender {
+	return &EmailSender{cfg: cfg, timeout: timeout}
+}
+
+// FromAddress returns the sender address of a tenant
+func (s *EmailSender) FromAddress(tenantID string) string {
+	if from, ok := s.cfg.TenantFrom[tenantID]; ok {
+		return from
+	}
+	return s.cfg.From
+}
+
+// Recipients returns the configured report recipients of a tenant
+func (s *EmailSender) Recipients(tenantID string) []string {
+	if recipients, ok := s.cfg.TenantRecipients[tenantID]; ok {
+		return append([]string(nil), recipients...)
+	}
+	return append([]string(nil), s.cfg.Recipients...)
+}
+
+// Send emails a notification to the address of an email channel
+func (s *EmailSender) Send(ctx context.Context, channel storage.NotificationChannel, message *Message) error {
+	text := message.Text
+	var body strings.Builder
+	body.WriteString("<html><body style=\"font-family: Arial, sans-serif;\">")
+	fmt.Fprintf(&body, "<h3>%s</h3><pre style=\"font-family: inherit; white-space: pre-wrap;\">%s</pre>",
+		html.EscapeString(message.Subject), html.EscapeString(text))
+	body.WriteString("</body></html>")
+
+	return s.SendEmail(ctx, &Email{
+		TenantID: message.TenantID,
+		To:       []string{channel.Target},
+		Subject:  message.Subject,
+		Text:     text,
+		HTML:     body.String(),
+	})
+}
+
+// SendReport emails the rendered HTML report of a validation with the JSON
+// and SARIF reports attached
+func (s *EmailSender) SendReport(ctx context.Context, tenantID string, recipients []string, validationReport *models.ValidationReport) error {
+	result := validationReport.ValidationResult
+	body, err := report.RenderHTML(validationReport)
+	if err != nil {
+		return err
+	}
+	jsonReport, err := json.MarshalIndent(validationReport, "", "  ")
+	if err != nil {
+		return fmt.Errorf("serializing report: %w", err)
+	}
+	sarif, err := json.MarshalIndent(report.ReportToSARIF(validationReport, ""), "", "  ")
+	if err != nil {
+		return fmt.Errorf("serializing SARIF report: %w", err)
+	}
+
+	return s.SendEmail(ctx, &Email{
+		TenantID: tenantID,
+		To:       recipients,
+		Subject: fmt.Sprintf("Validation %s: %s to %s (confidence %.1f)",
+			result.Status, result.SourceFormat, result.TargetFormat, result.ConfidenceScore),
+		Text: fmt.Sprintf("Validation %s of a %s translation to %s finished with status %s, confidence %.1f and %d issues.\nThe full report is attached.",
+			result.ID, result.SourceFormat, result.TargetFormat, result.Status, result.ConfidenceScore, len(result.Issues)),
+		HTML: body,
+		Attachments: []Attachment{
+			{Name: fmt.Sprintf("validation-%s.json", result.ID), ContentType: "application/json", Data: jsonReport},
+			{Name: fmt.Sprintf("validation-%s.sarif", result.ID), ContentType: report.SARIFContentType, Data: sarif},
+		},
+	})
+}
+
+// SendJobSummary emails the summary of a finished job to the configured
+// recipients of its tenant
+func (s *EmailSender) SendJobSummary(ctx context.Context, summary *JobSummary) error {
+	outcome := "completed"
+	if len(summary.Errors) > 0 {
+		outcome = "completed with errors"
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "The %s job %s in %s.\n\n", summary.Job, outcome,
+		summary.FinishedAt.Sub(summary.StartedAt).Round(time.Second))
+	names := make([]string, 0, len(summary.Counts))
+	for name := range summary.Counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&text, "  %s: %d\n", name, summary.Counts[name])
+	}
+	if len(summary.Errors) > 0 {
+		text.WriteString("\nErrors:\n")
+		for _, e := range summary.Errors {
+			fmt.Fprintf(&text, "  %s\n", e)
+		}
+	}
+
+	subject := fmt.Sprintf("Job %s %s", summary.Job, outcome)
+	var body strings.Builder
+	fmt.Fprintf(&body, "<html><body style=\"font-family: Arial, sans-serif;\"><h3>%s</h3><pre style=\"font-family: inherit;\">%s</pre></body></html>",
+		html.EscapeString(subject), html.EscapeString(text.String()))
+
+	return s.SendEmail(ctx, &Email{
+		TenantID: summary.TenantID,
+		To:       s.Recipients(summary.TenantID),
+		Subject:  subject,
+		Text:     text.String(),
+		HTML:     body.String(),
+	})
+}
+
+// SendEmail delivers an email. Attachments that would take the message
+// beyond the attachment size limit are left out and listed in the text.
+func (s *EmailSender) SendEmail(ctx context.Context, email *Email) error {
+	if len(email.To) == 0 {
+		return ErrNoRecipients
+	}
+	from, err := mail.ParseAddress(s.FromAddress(email.TenantID))
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	to := make([]string, len(email.To))
+	for i, recipient := range email.To {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", recipient, err)
+		}
+		to[i] = address.Address
+	}
+
+	message, err := s.compose(from, to, email)
+	if err != nil {
+		return err
+	}
+	return s.deliver(ctx, from.Address, to, message)
+}
+
+// compose builds the MIME message: the text and HTML bodies as
+// alternatives, followed by the attachments that fit the size limit
+func (s *EmailSender) compose(from *mail.Address, to []string, email *Email) ([]byte, error) {
+	text := email.Text
+	var attachments []Attachment
+	var size int64
+	for _, attachment := range email.Attachments {
+		if s.cfg.MaxAttachmentSize > 0 && size+int64(len(attachment.Data)) > s.cfg.MaxAttachmentSize {
+			text += fmt.Sprintf("\n\n%s (%d bytes) was not attached: attachments are limited to %d bytes.",
+				attachment.Name, len(attachment.Data), s.cfg.MaxAttachmentSize)
+			continue
+		}
+		size += int64(len(attachment.Data))
+		attachments = append(attachments, attachment)
+	}
+
+	var buf bytes.Buffer
+	mixed := multipart.NewWriter(&buf)
+	header := textproto.MIMEHeader{}
+	header.Set("From", from.String())
+	header.Set("To", strings.Join(to, ", "))
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", stripLineBreaks(email.Subject)))
+	header.Set("Date", time.Now().UTC().Format(time.RFC1123Z))
+	header.Set("MIME-Version", "1.0")
+	header.Set("Content-Type", "multipart/mixed; boundary="+mixed.Boundary())
+	writeHeader(&buf, header)
+
+	var alternativeBuf bytes.Buffer
+	alternative := multipart.NewWriter(&alternativeBuf)
+	if err := writePart(alternative, "text/plain; charset=utf-8", "", []byte(text)); err != nil {
+		return nil, err
+	}
+	if email.HTML != "" {
+		if err := writePart(alternative, report.HTMLContentType, "", []byte(email.HTML)); err != nil {
+			return nil, err
+		}
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, err
+	}
+	part, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alternative.Boundary()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(alternativeBuf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range attachments {
+		if err := writePart(mixed, attachment.ContentType, attachment.Name, attachment.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// deliver sends a composed message over SMTP with the configured transport
+// security, within the sender timeout and the deadline of ctx
+func (s *EmailSender) deliver(ctx context.Context, from string, to []string, message []byte) error {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	address := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: s.cfg.Host, MinVersion: tls.VersionTLS12}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("connecting to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if s.cfg.TLS == config.SMTPTLSImplicit {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("starting SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if s.cfg.TLS == config.SMTPTLSStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starting TLS: %w", err)
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return fmt.Errorf("authenticating with SMTP server: %w", err)
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("setting sender: %w", err)
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("adding recipient %s: %w", recipient, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("starting message: %w", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		w.Close()
+		return fmt.Errorf("writing message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("sending message: %w", err)
+	}
+	return client.Quit()
+}
+
+// writeHeader writes MIME headers followed by the blank line ending them
+func writeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range header[key] {
+			fmt.Fprintf(buf, "%s: %s\r\n", key, value)
+		}
+	}
+	buf.WriteString("\r\n")
+}
+
+// writePart adds a base64-encoded part, as an attachment when name is set
+func writePart(w *multipart.Writer, contentType, name string, data []byte) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "base64")
+	if name != "" {
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	}
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := part.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = part.Write([]byte(encoded + "\r\n"))
+	return err
+}
+
+// stripLineBreaks keeps header values on one line
+func stripLineBreaks(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
@@ -0,0 +1,279 @@
+// Package notify delivers events such as completed validations to the
+// notification subscriptions of users. Immediate subscriptions receive each
+// event as it is published; digest subscriptions have their events queued in
+// the notification store and receive one summary message per hour or day,
+// composed by the digest worker.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"                                  // v1.4.0
+	"github.com/prometheus/client_golang/prometheus"          // v1.17.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+
+	"validation-service/internal/config"
+	"validation-service/internal/models"
+	"validation-service/internal/storage"
+	"validation-service/pkg/logger"
+)
+
+// Event types
+const (
+	EventValidationCompleted   = "validation.completed"
+	EventValidationRevalidated = "validation.revalidated"
+	EventRuleStale             = "rule.stale"
+)
+
+// digestCheckInterval is how often the digest worker looks for due digests
+const digestCheckInterval = time.Minute
+
+var (
+	notificationsSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:        "notifications_sent_total",
+		Help:        "Notification messages delivered by channel type, delivery mode and outcome",
+		ConstLabels: prometheus.Labels{"service": "validation"},
+	}, []string{"channel", "delivery", "outcome"})
+	notificationsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name:        "notification_events_dropped_total",
+		Help:        "Events dropped because the notification queue was full",
+		ConstLabels: prometheus.Labels{"service": "validation"},
+	})
+)
+
+// Message is a notification delivered to a channel: a single event, or the
+// digest of the events of a subscription
+type Message struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	Digest  bool   `json:"digest"`
+	// Events are the events of the message; digests list at most the
+	// configured number of events
+	Events []*storage.NotificationEvent `json:"events"`
+	// Total counts the events of a digest, including those not listed
+	Total int `json:"total"`
+	// TenantID is the tenant of the subscription the message is sent for
+	TenantID string `json:"tenant_id,omitempty"`
+}
+
+// Sender delivers messages to one type of channel
+type Sender interface {
+	Send(ctx context.Context, channel storage.NotificationChannel, message *Message) error
+}
+
+// Dispatcher matches published events to subscriptions and delivers them
+type Dispatcher struct {
+	store  storage.NotificationStore
+	cfg    config.NotificationConfig
+	events chan *storage.NotificationEvent
+	now    func() time.Time
+	log    *logger.Logger
+
+	mu      sync.RWMutex
+	senders map[string]Sender
+}
+
+// NewDispatcher creates a dispatcher for the subscriptions of store. Call
+// Run to start delivering.
+func NewDispatcher(store storage.NotificationStore, cfg config.NotificationConfig) *Dispatcher {
+	return &Dispatcher{
+		store:   store,
+		cfg:     cfg,
+		events:  make(chan *storage.NotificationEvent, cfg.QueueSize),
+		now:     time.Now,
+		log:     logger.GetLogger(),
+		senders: make(map[string]Sender),
+	}
+}
+
+// RegisterSender delivers the messages of a channel type with sender.
+// Messages for channel types without a sender are skipped.
+func (d *Dispatcher) RegisterSender(channelType string, sender Sender) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.senders[channelType] = sender
+}
+
+// Publish queues an event for delivery without blocking. Events published
+// while the queue is full are dropped and counted.
+func (d *Dispatcher) Publish(event *storage.NotificationEvent) {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = d.now().UTC()
+	}
+	select {
+	case d.events <- event:
+	default:
+		notificationsDropped.Inc()
+	}
+}
+
+// Run delivers published events and sends due digests until ctx is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-d.events:
+			d.dispatch(ctx, event)
+		case <-ticker.C:
+			d.sendDigests(ctx)
+		}
+	}
+}
+
+// dispatch sends the event to matching immediate subscriptions and queues it
+// for matching digest subscriptions
+func (d *Dispatcher) dispatch(ctx context.Context, event *storage.NotificationEvent) {
+	err := d.store.ScanSubscriptions(ctx, func(subscription *storage.Subscription) error {
+		if !subscription.Matches(event) {
+			return nil
+		}
+		if subscription.Digest() {
+			if err := d.store.QueueDigestEvent(ctx, subscription.ID, event); err != nil {
+				d.log.Error("Failed to queue digest event",
+					"error", err,
+					"subscription_id", subscription.ID,
+					"event_id", event.ID,
+				)
+			}
+			return nil
+		}
+		d.send(ctx, subscription, &Message{
+			Subject: event.Title,
+			Text:    eventText(event),
+			Events:  []*storage.NotificationEvent{event},
+			Total:   1,
+		})
+		return nil
+	})
+	if err != nil {
+		d.log.Error("Failed to match notification subscriptions",
+			"error", err,
+			"event_id", event.ID,
+		)
+	}
+}
+
+// send delivers a message to the channel of a subscription and reports
+// whether it was delivered
+func (d *Dispatcher) send(ctx context.Context, subscription *storage.Subscription, message *Message) bool {
+	d.mu.RLock()
+	sender, ok := d.senders[subscription.Channel.Type]
+	d.mu.RUnlock()
+	if !ok {
+		notificationsSent.WithLabelValues(subscription.Channel.Type, subscription.Delivery, "unsupported").Inc()
+		d.log.Warn("No sender for notification channel",
+			"channel", subscription.Channel.Type,
+			"subscription_id", subscription.ID,
+		)
+		return false
+	}
+
+	message.TenantID = subscription.TenantID
+	sendCtx, cancel := context.WithTimeout(ctx, d.cfg.DeliveryTimeout)
+	defer cancel()
+	if err := sender.Send(sendCtx, subscription.Channel, message); err != nil {
+		notificationsSent.WithLabelValues(subscription.Channel.Type, subscription.Delivery, "failed").Inc()
+		d.log.Error("Failed to deliver notification",
+			"error", err,
+			"channel", subscription.Channel.Type,
+			"subscription_id", subscription.ID,
+			"user_id", subscription.UserID,
+		)
+		return false
+	}
+	notificationsSent.WithLabelValues(subscription.Channel.Type, subscription.Delivery, "delivered").Inc()
+	return true
+}
+
+// ValidationEvent describes a completed validation of a tenant. Its
+// severity is that of the most severe issue of the result.
+func ValidationEvent(tenantID string, result *models.ValidationResult) *storage.NotificationEvent {
+	severity := ""
+	for _, issue := range result.Issues {
+		if issue.IsOptimization() {
+			continue
+		}
+		if severityOrder[issue.Severity] > severityOrder[severity] {
+			severity = issue.Severity
+		}
+	}
+	return &storage.NotificationEvent{
+		ID:       uuid.New(),
+		Type:     EventValidationCompleted,
+		TenantID: tenantID,
+		Format:   result.TargetFormat,
+		Severity: severity,
+		Title: fmt.Sprintf("Validation %s: %s to %s (confidence %.1f)",
+			result.Status, result.SourceFormat, result.TargetFormat, result.ConfidenceScore),
+		Summary: fmt.Sprintf("%d issues", len(result.Issues)),
+		Attributes: map[string]string{
+			"result_id": result.ID.String(),
+			"status":    result.Status,
+		},
+		OccurredAt: result.CreatedAt,
+	}
+}
+
+// RevalidationEvent describes the background re-validation of a stale cached
+// result of a tenant whose fresh result differs. Its severity is that of the
+// most severe issue of the fresh result.
+func RevalidationEvent(tenantID, staleResultID string, result *models.ValidationResult) *storage.NotificationEvent {
+	event := ValidationEvent(tenantID, result)
+	event.Type = EventValidationRevalidated
+	event.Title = fmt.Sprintf("Revalidation changed result: %s to %s now %s (confidence %.1f)",
+		result.SourceFormat, result.TargetFormat, result.Status, result.ConfidenceScore)
+	event.Attributes["stale_result_id"] = staleResultID
+	return event
+}
+
+// StaleRuleEvent describes a stored detection of a tenant past its review-by
+// date, or its expiry date when status is "expired". Expired rules are high
+// severity and rules due for review medium.
+func StaleRuleEvent(tenantID string, stored *storage.StoredDetection, status string, due time.Time) *storage.NotificationEvent {
+	severity, title, summary := models.ValidationSeverityMedium, "Rule due for review", "Review was due on %s"
+	if status == "expired" {
+		severity, title, summary = models.ValidationSeverityHigh, "Rule expired", "Expired on %s"
+	}
+	return &storage.NotificationEvent{
+		ID:       uuid.New(),
+		Type:     EventRuleStale,
+		TenantID: tenantID,
+		Format:   stored.Detection.Format,
+		Severity: severity,
+		Title:    fmt.Sprintf("%s: %s", title, stored.Name),
+		Summary:  fmt.Sprintf(summary, due.Format("2006-01-02")),
+		Attributes: map[string]string{
+			"detection_id": stored.ID().String(),
+			"status":       status,
+			"due":          due.Format("2006-01-02"),
+		},
+	}
+}
+
+// severityOrder ranks issue severities, most severe last
+var severityOrder = map[string]int{
+	models.ValidationSeverityLow:    1,
+	models.ValidationSeverityMedium: 2,
+	models.ValidationSeverityHigh:   3,
+}
+
+// eventText renders an event as plain text
+func eventText(event *storage.NotificationEvent) string {
+	text := event.Title
+	if event.Summary != "" {
+		text += "\n" + event.Summary
+	}
+	if event.Severity != "" {
+		text += fmt.Sprintf("\nHighest severity: %s", event.Severity)
+	}
+	return text
+}
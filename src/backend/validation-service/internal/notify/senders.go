@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"validation-service/internal/storage"
+)
+
+// Headers of a webhook delivery
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body,
+	// keyed with the channel secret
+	SignatureHeader = "X-Signature"
+	EventHeader     = "X-Notification-Type"
+)
+
+// WebhookSender posts messages as JSON to webhook channels
+type WebhookSender struct {
+	client *http.Client
+}
+
+// NewWebhookSender creates a webhook sender posting with client
+func NewWebhookSender(client *http.Client) *WebhookSender {
+	return &WebhookSender{client: client}
+}
+
+// Send posts the message, signed when the channel has a secret
+func (s *WebhookSender) Send(ctx context.Context, channel storage.NotificationChannel, message *Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("serializing notification: %w", err)
+	}
+	headers := http.Header{}
+	eventType := "digest"
+	if !message.Digest && len(message.Events) == 1 {
+		eventType = message.Events[0].Type
+	}
+	headers.Set(EventHeader, eventType)
+	if channel.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(channel.Secret))
+		mac.Write(body)
+		headers.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	return post(ctx, s.client, channel.Target, body, headers)
+}
+
+// SlackSender posts messages to Slack incoming webhooks
+type SlackSender struct {
+	client *http.Client
+}
+
+// NewSlackSender creates a Slack sender posting with client
+func NewSlackSender(client *http.Client) *SlackSender {
+	return &SlackSender{client: client}
+}
+
+// Send posts the subject and text of the message
+func (s *SlackSender) Send(ctx context.Context, channel storage.NotificationChannel, message *Message) error {
+	body, err := json.Marshal(map[string]string{
+		"text": fmt.Sprintf("*%s*\n%s", message.Subject, message.Text),
+	})
+	if err != nil {
+		return fmt.Errorf("serializing notification: %w", err)
+	}
+	return post(ctx, s.client, channel.Target, body, http.Header{})
+}
+
+// post sends a JSON body and fails on responses other than 2xx
+func post(ctx context.Context, client *http.Client, url string, body []byte, headers http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating notification request: %w", err)
+	}
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting notification: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
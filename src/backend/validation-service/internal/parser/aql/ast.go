@@ -0,0 +1,275 @@
+// Package aql provides a tokenizer and recursive-descent parser for IBM
+// QRadar Ariel Query Language (AQL) queries, producing an AST with
+// line/column positions for validation.
+package aql
+
+// Position identifies a location in the parsed source
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// IsValid reports whether the position was set by the parser
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// Node is implemented by all AST nodes
+type Node interface {
+	Pos() Position
+}
+
+// Query is a SELECT statement: a whole query or a subselect
+type Query struct {
+	Position Position
+	Distinct bool
+	Select   []*SelectItem
+	From     *Source
+	Where    Expr
+	GroupBy  []Expr
+	Having   Expr
+	OrderBy  []*OrderItem
+	// Limit is the LIMIT row count; nil when absent
+	Limit *Literal
+	// Time is the LAST or START/STOP clause; nil when absent
+	Time *TimeClause
+	// End is the position after the last clause
+	End Position
+}
+
+// Pos implements Node
+func (q *Query) Pos() Position { return q.Position }
+
+// SelectItem is a column of the SELECT list: `*` or an expression with an
+// optional alias
+type SelectItem struct {
+	Position Position
+	// Star is set for `*`; Expr is nil then
+	Star  bool
+	Expr  Expr
+	Alias string
+}
+
+// Source is the FROM clause: an Ariel database such as events or flows, or
+// a parenthesized subselect
+type Source struct {
+	Position Position
+	// Database is the lower-cased database name; empty for a subselect
+	Database  string
+	Subselect *Query
+}
+
+// OrderItem is an ORDER BY expression
+type OrderItem struct {
+	Position Position
+	Expr     Expr
+	Desc     bool
+}
+
+// TimeClause is `LAST <n> <unit>` or `START <time> STOP <time>`
+type TimeClause struct {
+	Position Position
+	// Last is set for LAST; Count and Unit hold its window, with Unit
+	// upper-cased such as MINUTES, HOURS or DAYS
+	Last  bool
+	Count *Literal
+	Unit  string
+	// Start and Stop hold the START/STOP times: quoted timestamps or epoch
+	// milliseconds. Stop is nil when the clause was cut short.
+	Start *Literal
+	Stop  *Literal
+}
+
+// Pos implementations
+func (s *SelectItem) Pos() Position { return s.Position }
+func (s *Source) Pos() Position     { return s.Position }
+func (o *OrderItem) Pos() Position  { return o.Position }
+func (t *TimeClause) Pos() Position { return t.Position }
+
+// Expr is implemented by expression nodes
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// LiteralKind distinguishes literal expression values
+type LiteralKind int
+
+// Literal kinds
+const (
+	LiteralNumber LiteralKind = iota
+	LiteralString
+	LiteralBool
+	LiteralNull
+)
+
+// Literal is a number, single-quoted string, boolean or null literal
+type Literal struct {
+	Position Position
+	Kind     LiteralKind
+	Value    string
+}
+
+// FieldRef is a property name, bare or double-quoted for custom properties
+// such as "Process Name"
+type FieldRef struct {
+	Position Position
+	Name     string
+	Quoted   bool
+}
+
+// Call is a function call such as `LOGSOURCENAME(logsourceid)`,
+// `COUNT(*)` or `GEO::LOOKUP(sourceip, 'city')`
+type Call struct {
+	Position Position
+	// Name is the upper-cased function name, including its namespace
+	Name string
+	Args []Expr
+	// Star is set for `COUNT(*)`; Distinct for `COUNT(DISTINCT x)`
+	Star     bool
+	Distinct bool
+}
+
+// UnaryExpr is `NOT x` or `-x`
+type UnaryExpr struct {
+	Position Position
+	Op       string
+	X        Expr
+}
+
+// BinaryExpr is an infix operation. Op is upper-cased for the keyword
+// operators AND, OR, LIKE, ILIKE, MATCHES and IMATCHES; Not is set for
+// their negated forms such as NOT LIKE.
+type BinaryExpr struct {
+	Position Position
+	Op       string
+	Not      bool
+	Left     Expr
+	Right    Expr
+}
+
+// InExpr is `x [NOT] IN (a, b, c)` or `x [NOT] IN (SELECT ...)`
+type InExpr struct {
+	Position  Position
+	X         Expr
+	Not       bool
+	List      []Expr
+	Subselect *Query
+}
+
+// BetweenExpr is `x [NOT] BETWEEN low AND high`
+type BetweenExpr struct {
+	Position Position
+	X        Expr
+	Not      bool
+	Low      Expr
+	High     Expr
+}
+
+// IsNullExpr is `x IS [NOT] NULL`
+type IsNullExpr struct {
+	Position Position
+	X        Expr
+	Not      bool
+}
+
+// TextSearch is the `TEXT SEARCH 'terms'` full-text predicate
+type TextSearch struct {
+	Position Position
+	Terms    string
+}
+
+// Pos implementations
+func (e *Literal) Pos() Position     { return e.Position }
+func (e *FieldRef) Pos() Position    { return e.Position }
+func (e *Call) Pos() Position        { return e.Position }
+func (e *UnaryExpr) Pos() Position   { return e.Position }
+func (e *BinaryExpr) Pos() Position  { return e.Position }
+func (e *InExpr) Pos() Position      { return e.Position }
+func (e *BetweenExpr) Pos() Position { return e.Position }
+func (e *IsNullExpr) Pos() Position  { return e.Position }
+func (e *TextSearch) Pos() Position  { return e.Position }
+
+func (*Literal) exprNode()     {}
+func (*FieldRef) exprNode()    {}
+func (*Call) exprNode()        {}
+func (*UnaryExpr) exprNode()   {}
+func (*BinaryExpr) exprNode()  {}
+func (*InExpr) exprNode()      {}
+func (*BetweenExpr) exprNode() {}
+func (*IsNullExpr) exprNode()  {}
+func (*TextSearch) exprNode()  {}
+
+// WalkExpr traverses an expression tree depth-first, calling fn for each
+// node. Traversal of a subtree stops when fn returns false. Subselects of IN
+// expressions are not entered; use Inspect for them.
+func WalkExpr(e Expr, fn func(Expr) bool) {
+	if e == nil || !fn(e) {
+		return
+	}
+	switch n := e.(type) {
+	case *Call:
+		for _, arg := range n.Args {
+			WalkExpr(arg, fn)
+		}
+	case *UnaryExpr:
+		WalkExpr(n.X, fn)
+	case *BinaryExpr:
+		WalkExpr(n.Left, fn)
+		WalkExpr(n.Right, fn)
+	case *InExpr:
+		WalkExpr(n.X, fn)
+		for _, el := range n.List {
+			WalkExpr(el, fn)
+		}
+	case *BetweenExpr:
+		WalkExpr(n.X, fn)
+		WalkExpr(n.Low, fn)
+		WalkExpr(n.High, fn)
+	case *IsNullExpr:
+		WalkExpr(n.X, fn)
+	}
+}
+
+// Exprs returns the expressions of the query's own clauses in source order,
+// without those of its subselects
+func (q *Query) Exprs() []Expr {
+	var exprs []Expr
+	for _, item := range q.Select {
+		if item.Expr != nil {
+			exprs = append(exprs, item.Expr)
+		}
+	}
+	if q.Where != nil {
+		exprs = append(exprs, q.Where)
+	}
+	exprs = append(exprs, q.GroupBy...)
+	if q.Having != nil {
+		exprs = append(exprs, q.Having)
+	}
+	for _, item := range q.OrderBy {
+		exprs = append(exprs, item.Expr)
+	}
+	return exprs
+}
+
+// Inspect calls fn for the query and every subselect in source order, those
+// in FROM first. Subselects of a query are not visited when fn returns
+// false for it.
+func Inspect(q *Query, fn func(*Query) bool) {
+	if q == nil || !fn(q) {
+		return
+	}
+	if q.From != nil {
+		Inspect(q.From.Subselect, fn)
+	}
+	for _, e := range q.Exprs() {
+		WalkExpr(e, func(x Expr) bool {
+			if in, ok := x.(*InExpr); ok {
+				Inspect(in.Subselect, fn)
+			}
+			return true
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package aql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TokenKind identifies the lexical class of a token
+type TokenKind int
+
+// Token kinds produced by the lexer
+const (
+	TokenEOF TokenKind = iota
+	TokenIdent
+	TokenQuotedIdent // "custom property"
+	TokenString      // 'literal'
+	TokenNumber
+	TokenOp
+	TokenPunct // ( ) , . ::
+	TokenIllegal
+)
+
+// Token is a single lexical token
+type Token struct {
+	Kind TokenKind
+	Text string
+	Pos  Position
+}
+
+// Error is a positioned lexing or parsing error
+type Error struct {
+	Pos Position
+	Msg string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Pos.Line, e.Pos.Column, e.Msg)
+}
+
+// lexer converts AQL source into tokens. Keywords are scanned as
+// identifiers and recognized by the parser, since AQL keywords are
+// case-insensitive and several double as property names.
+type lexer struct {
+	src    string
+	offset int
+	line   int
+	column int
+	errs   []*Error
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, column: 1}
+}
+
+func (l *lexer) pos() Position {
+	return Position{Offset: l.offset, Line: l.line, Column: l.column}
+}
+
+func (l *lexer) errorf(pos Position, format string, args ...interface{}) {
+	l.errs = append(l.errs, &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (l *lexer) peekByte(n int) byte {
+	if l.offset+n >= len(l.src) {
+		return 0
+	}
+	return l.src[l.offset+n]
+}
+
+func (l *lexer) advance() byte {
+	c := l.src[l.offset]
+	l.offset++
+	if c == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+	return c
+}
+
+// skipSpaceAndComments skips whitespace and /* block */ comments
+func (l *lexer) skipSpaceAndComments() {
+	for l.offset < len(l.src) {
+		c := l.src[l.offset]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			l.advance()
+		case c == '/' && l.peekByte(1) == '*':
+			start := l.pos()
+			length := len(l.src) - l.offset
+			if end := strings.Index(l.src[l.offset+2:], "*/"); end >= 0 {
+				length = end + 4
+			} else {
+				l.errorf(start, "unterminated comment")
+			}
+			for ; length > 0; length-- {
+				l.advance()
+			}
+		default:
+			return
+		}
+	}
+}
+
+// next scans the next token
+func (l *lexer) next() Token {
+	l.skipSpaceAndComments()
+	start := l.pos()
+	if l.offset >= len(l.src) {
+		return Token{Kind: TokenEOF, Pos: start}
+	}
+
+	c := l.src[l.offset]
+	switch {
+	case isIdentStart(c):
+		return Token{Kind: TokenIdent, Text: l.scanWhile(isIdentChar), Pos: start}
+	case isDigit(c) || (c == '.' && isDigit(l.peekByte(1))):
+		return l.scanNumber(start)
+	case c == '\'':
+		return l.scanString(start, '\'', TokenString)
+	case c == '"':
+		return l.scanString(start, '"', TokenQuotedIdent)
+	case c == ':' && l.peekByte(1) == ':':
+		l.advance()
+		l.advance()
+		return Token{Kind: TokenPunct, Text: "::", Pos: start}
+	case c == '(' || c == ')' || c == ',' || c == '.':
+		l.advance()
+		return Token{Kind: TokenPunct, Text: string(c), Pos: start}
+	}
+
+	for _, op := range []string{"!=", "<>", "<=", ">=", "||", "=", "<", ">", "+", "-", "*", "/", "%"} {
+		if strings.HasPrefix(l.src[l.offset:], op) {
+			for range op {
+				l.advance()
+			}
+			return Token{Kind: TokenOp, Text: op, Pos: start}
+		}
+	}
+
+	l.advance()
+	l.errorf(start, "unexpected character %q", c)
+	return Token{Kind: TokenIllegal, Text: string(c), Pos: start}
+}
+
+func (l *lexer) scanWhile(pred func(byte) bool) string {
+	begin := l.offset
+	for l.offset < len(l.src) && pred(l.src[l.offset]) {
+		l.advance()
+	}
+	return l.src[begin:l.offset]
+}
+
+func (l *lexer) scanNumber(start Position) Token {
+	begin := l.offset
+	l.scanWhile(isDigit)
+	if l.peekByte(0) == '.' && isDigit(l.peekByte(1)) {
+		l.advance()
+		l.scanWhile(isDigit)
+	}
+	return Token{Kind: TokenNumber, Text: l.src[begin:l.offset], Pos: start}
+}
+
+// scanString scans a string delimited by quote. A doubled quote or a
+// backslash escapes the quote.
+func (l *lexer) scanString(start Position, quote byte, kind TokenKind) Token {
+	l.advance() // opening quote
+	var b strings.Builder
+	for l.offset < len(l.src) {
+		c := l.advance()
+		switch {
+		case c == quote && l.peekByte(0) == quote:
+			b.WriteByte(l.advance())
+		case c == quote:
+			return Token{Kind: kind, Text: b.String(), Pos: start}
+		case c == '\\' && l.peekByte(0) == quote:
+			b.WriteByte(l.advance())
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if kind == TokenQuotedIdent {
+		l.errorf(start, "unterminated quoted property name")
+	} else {
+		l.errorf(start, "unterminated string literal")
+	}
+	return Token{Kind: kind, Text: b.String(), Pos: start}
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
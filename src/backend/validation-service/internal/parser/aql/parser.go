@@ -0,0 +1,650 @@
+package aql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxErrors bounds the number of errors collected before parsing stops
+const maxErrors = 50
+
+// maxDepth bounds the nesting of subselects and expressions. Deeper input
+// stops the parse with an error instead of recursing further.
+const maxDepth = 100
+
+// reserved are the keywords that cannot be bare property names
+var reserved = map[string]bool{
+	"SELECT": true, "DISTINCT": true, "FROM": true, "WHERE": true, "GROUP": true,
+	"BY": true, "HAVING": true, "ORDER": true, "ASC": true, "DESC": true,
+	"LIMIT": true, "LAST": true, "START": true, "STOP": true, "AS": true,
+	"AND": true, "OR": true, "NOT": true, "IN": true, "LIKE": true, "ILIKE": true,
+	"MATCHES": true, "IMATCHES": true, "BETWEEN": true, "IS": true, "NULL": true,
+}
+
+// clauseNames names the clauses whose keyword can appear out of order
+var clauseNames = map[string]string{
+	"SELECT": "SELECT", "FROM": "FROM", "WHERE": "WHERE", "GROUP": "GROUP BY",
+	"HAVING": "HAVING", "ORDER": "ORDER BY",
+}
+
+// parser is a recursive-descent parser over the lexer token stream
+type parser struct {
+	lex  *lexer
+	tok  Token
+	errs []*Error
+	// depth is the current nesting; stopped is set once it exceeded maxDepth
+	depth   int
+	stopped bool
+}
+
+// Parse parses an AQL query. It always returns a Query holding every clause
+// that could be parsed, together with any errors encountered.
+func Parse(src string) (*Query, []*Error) {
+	p := &parser{lex: newLexer(src)}
+	p.next()
+	query := p.parseQuery()
+	if p.tok.Kind != TokenEOF {
+		p.unexpected("query")
+	}
+
+	errs := append(p.lex.errs, p.errs...)
+	sortErrors(errs)
+	return query, errs
+}
+
+func (p *parser) next() {
+	p.tok = p.lex.next()
+}
+
+// errorf records an error. A second error at the position of the previous
+// one is dropped, since it follows from the first.
+func (p *parser) errorf(pos Position, format string, args ...interface{}) {
+	if len(p.errs) >= maxErrors || p.stopped {
+		return
+	}
+	if n := len(p.errs); n > 0 && p.errs[n-1].Pos.Offset == pos.Offset {
+		return
+	}
+	p.errs = append(p.errs, &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+// enter descends one nesting level. Past maxDepth it reports the excess and
+// moves the lexer to the end of input, so every caller unwinds without
+// recursing further. Each call is paired with a deferred leave.
+func (p *parser) enter() bool {
+	p.depth++
+	if p.depth <= maxDepth {
+		return true
+	}
+	p.errorf(p.tok.Pos, "nesting exceeds the maximum depth of %d", maxDepth)
+	p.stopped = true
+	p.lex.offset = len(p.lex.src)
+	p.tok = Token{Kind: TokenEOF, Pos: p.tok.Pos}
+	return false
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) isPunct(text string) bool {
+	return p.tok.Kind == TokenPunct && p.tok.Text == text
+}
+
+func (p *parser) isOp(text string) bool {
+	return p.tok.Kind == TokenOp && p.tok.Text == text
+}
+
+// isWord reports whether the current token is the identifier text, ignoring
+// case
+func (p *parser) isWord(text string) bool {
+	return p.tok.Kind == TokenIdent && strings.EqualFold(p.tok.Text, text)
+}
+
+// isReserved reports whether the current token is a reserved keyword
+func (p *parser) isReserved() bool {
+	return p.tok.Kind == TokenIdent && reserved[strings.ToUpper(p.tok.Text)]
+}
+
+// atClauseEnd reports whether the current token ends an expression list
+func (p *parser) atClauseEnd() bool {
+	if p.tok.Kind == TokenEOF || p.isPunct(")") {
+		return true
+	}
+	if p.tok.Kind != TokenIdent {
+		return false
+	}
+	switch strings.ToUpper(p.tok.Text) {
+	case "FROM", "WHERE", "GROUP", "HAVING", "ORDER", "LIMIT", "LAST", "START", "STOP":
+		return true
+	}
+	return false
+}
+
+// expectWord consumes the keyword text or reports its absence
+func (p *parser) expectWord(text, after string) bool {
+	if !p.isWord(text) {
+		p.errorf(p.tok.Pos, "expected %s after %s, found %s", text, after, describe(p.tok))
+		return false
+	}
+	p.next()
+	return true
+}
+
+// expectClose consumes the parenthesis closing the one opened at open
+func (p *parser) expectClose(open Position) {
+	if !p.isPunct(")") {
+		p.errorf(p.tok.Pos, "expected ')' to close '(' at line %d, column %d, found %s", open.Line, open.Column, describe(p.tok))
+		return
+	}
+	p.next()
+}
+
+// unexpected reports the token after the end of a query or subselect,
+// naming clauses given out of order
+func (p *parser) unexpected(context string) {
+	if p.tok.Kind == TokenIdent {
+		if name, ok := clauseNames[strings.ToUpper(p.tok.Text)]; ok {
+			p.errorf(p.tok.Pos, "%s clause is out of order; clauses follow SELECT, FROM, WHERE, GROUP BY, HAVING, ORDER BY, then LIMIT and the time clause", name)
+			return
+		}
+	}
+	p.errorf(p.tok.Pos, "unexpected %s after the end of the %s", describe(p.tok), context)
+}
+
+// parseQuery parses a SELECT statement:
+//
+//	query = "SELECT" ["DISTINCT"] item { "," item } "FROM" source
+//	        ["WHERE" expr] ["GROUP" "BY" exprs] ["HAVING" expr]
+//	        ["ORDER" "BY" order { "," order }] { "LIMIT" number | time }
+//	time  = "LAST" number unit | "START" value "STOP" value
+func (p *parser) parseQuery() *Query {
+	q := &Query{Position: p.tok.Pos}
+	defer p.leave()
+	if !p.enter() {
+		return q
+	}
+	if !p.expectWord("SELECT", "the start of the query") {
+		return q
+	}
+	if p.isWord("DISTINCT") {
+		q.Distinct = true
+		p.next()
+	}
+	for len(p.errs) < maxErrors {
+		q.Select = append(q.Select, p.parseSelectItem())
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+
+	if !p.isWord("FROM") {
+		p.errorf(p.tok.Pos, "expected FROM after the select list, found %s", describe(p.tok))
+		for !p.atClauseEnd() {
+			p.next()
+		}
+	}
+	if p.isWord("FROM") {
+		q.From = p.parseSource()
+	}
+
+	if p.isWord("WHERE") {
+		p.next()
+		q.Where = p.parseExpr()
+	}
+	if p.isWord("GROUP") {
+		p.next()
+		if p.expectWord("BY", "GROUP") {
+			q.GroupBy = p.parseExprs()
+		}
+	}
+	if p.isWord("HAVING") {
+		p.next()
+		q.Having = p.parseExpr()
+	}
+	if p.isWord("ORDER") {
+		p.next()
+		if p.expectWord("BY", "ORDER") {
+			q.OrderBy = p.parseOrderItems()
+		}
+	}
+	p.parseTail(q)
+	q.End = p.tok.Pos
+	return q
+}
+
+// parseSelectItem parses `*` or an expression with an optional AS alias
+func (p *parser) parseSelectItem() *SelectItem {
+	item := &SelectItem{Position: p.tok.Pos}
+	if p.isOp("*") {
+		item.Star = true
+		p.next()
+		return item
+	}
+	item.Expr = p.parseExpr()
+	if p.isWord("AS") {
+		p.next()
+		switch p.tok.Kind {
+		case TokenIdent, TokenQuotedIdent, TokenString:
+			item.Alias = p.tok.Text
+			p.next()
+		default:
+			p.errorf(p.tok.Pos, "expected alias after AS, found %s", describe(p.tok))
+		}
+	}
+	return item
+}
+
+// parseSource parses the FROM clause; the current token is FROM
+func (p *parser) parseSource() *Source {
+	source := &Source{Position: p.tok.Pos}
+	p.next()
+	switch {
+	case p.isPunct("("):
+		open := p.tok.Pos
+		p.next()
+		source.Subselect = p.parseQuery()
+		if !p.isPunct(")") && p.tok.Kind != TokenEOF {
+			p.unexpected("subselect")
+		}
+		p.expectClose(open)
+	case p.tok.Kind == TokenIdent && !p.isReserved():
+		source.Database = strings.ToLower(p.tok.Text)
+		p.next()
+	default:
+		p.errorf(p.tok.Pos, "expected database or subselect after FROM, found %s", describe(p.tok))
+	}
+	return source
+}
+
+// parseExprs parses a comma-separated expression list
+func (p *parser) parseExprs() []Expr {
+	var exprs []Expr
+	for len(p.errs) < maxErrors {
+		exprs = append(exprs, p.parseExpr())
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	return exprs
+}
+
+// parseOrderItems parses the expressions of ORDER BY with their direction
+func (p *parser) parseOrderItems() []*OrderItem {
+	var items []*OrderItem
+	for len(p.errs) < maxErrors {
+		item := &OrderItem{Position: p.tok.Pos, Expr: p.parseExpr()}
+		switch {
+		case p.isWord("DESC"):
+			item.Desc = true
+			p.next()
+		case p.isWord("ASC"):
+			p.next()
+		}
+		items = append(items, item)
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	return items
+}
+
+// parseTail parses LIMIT and the time clause, which may come in either order
+func (p *parser) parseTail(q *Query) {
+	for len(p.errs) < maxErrors {
+		switch {
+		case p.isWord("LIMIT"):
+			if q.Limit != nil {
+				p.errorf(p.tok.Pos, "duplicate LIMIT clause")
+			}
+			p.next()
+			if p.tok.Kind != TokenNumber {
+				p.errorf(p.tok.Pos, "expected row count after LIMIT, found %s", describe(p.tok))
+				continue
+			}
+			q.Limit = &Literal{Position: p.tok.Pos, Kind: LiteralNumber, Value: p.tok.Text}
+			p.next()
+		case p.isWord("LAST") || p.isWord("START"):
+			if q.Time != nil {
+				p.errorf(p.tok.Pos, "duplicate time clause; use either LAST or START/STOP once")
+			}
+			q.Time = p.parseTime()
+		case p.isWord("STOP"):
+			p.errorf(p.tok.Pos, "STOP without a preceding START")
+			p.next()
+		default:
+			return
+		}
+	}
+}
+
+// parseTime parses `LAST <n> <unit>` or `START <time> STOP <time>`
+func (p *parser) parseTime() *TimeClause {
+	t := &TimeClause{Position: p.tok.Pos}
+	if p.isWord("LAST") {
+		t.Last = true
+		p.next()
+		if p.tok.Kind != TokenNumber {
+			p.errorf(p.tok.Pos, "expected a number after LAST, found %s", describe(p.tok))
+			return t
+		}
+		t.Count = &Literal{Position: p.tok.Pos, Kind: LiteralNumber, Value: p.tok.Text}
+		p.next()
+		if p.tok.Kind != TokenIdent || p.isReserved() {
+			p.errorf(p.tok.Pos, "expected MINUTES, HOURS or DAYS after LAST %s, found %s", t.Count.Value, describe(p.tok))
+			return t
+		}
+		t.Unit = strings.ToUpper(p.tok.Text)
+		p.next()
+		return t
+	}
+
+	p.next() // START
+	if t.Start = p.parseTimeValue("START"); t.Start == nil {
+		return t
+	}
+	if !p.expectWord("STOP", "the START time") {
+		return t
+	}
+	t.Stop = p.parseTimeValue("STOP")
+	return t
+}
+
+// parseTimeValue parses a quoted timestamp or epoch milliseconds
+func (p *parser) parseTimeValue(clause string) *Literal {
+	var lit *Literal
+	switch p.tok.Kind {
+	case TokenString:
+		lit = &Literal{Position: p.tok.Pos, Kind: LiteralString, Value: p.tok.Text}
+	case TokenNumber:
+		lit = &Literal{Position: p.tok.Pos, Kind: LiteralNumber, Value: p.tok.Text}
+	default:
+		p.errorf(p.tok.Pos, "expected a quoted time or epoch milliseconds after %s, found %s", clause, describe(p.tok))
+		return nil
+	}
+	p.next()
+	return lit
+}
+
+// Expression grammar, lowest precedence first:
+//
+//	or      = and { "OR" and }
+//	and     = not { "AND" not }
+//	not     = "NOT" not | compare
+//	compare = sum [ op sum | ["NOT"] ("LIKE" | "ILIKE" | "MATCHES" | "IMATCHES") sum
+//	          | ["NOT"] "IN" "(" (exprs | query) ")" | ["NOT"] "BETWEEN" sum "AND" sum
+//	          | "IS" ["NOT"] "NULL" ]
+//	sum     = product { ("+" | "-") product }
+//	product = unary { ("*" | "/" | "%") unary }
+//	unary   = "-" unary | primary
+//	primary = number | string | "property" | property | call | "TEXT" "SEARCH" string | "(" or ")"
+//	call    = name ["::" name] "(" ["*" | ["DISTINCT"] exprs] ")"
+func (p *parser) parseExpr() Expr {
+	defer p.leave()
+	if !p.enter() {
+		return &Literal{Position: p.tok.Pos, Kind: LiteralNull, Value: "null"}
+	}
+	x := p.parseAnd()
+	for p.isWord("OR") {
+		op := p.tok
+		p.next()
+		x = &BinaryExpr{Position: op.Pos, Op: "OR", Left: x, Right: p.parseAnd()}
+	}
+	return x
+}
+
+func (p *parser) parseAnd() Expr {
+	x := p.parseNot()
+	for p.isWord("AND") {
+		op := p.tok
+		p.next()
+		x = &BinaryExpr{Position: op.Pos, Op: "AND", Left: x, Right: p.parseNot()}
+	}
+	return x
+}
+
+func (p *parser) parseNot() Expr {
+	if p.isWord("NOT") {
+		pos := p.tok.Pos
+		defer p.leave()
+		if !p.enter() {
+			return &Literal{Position: pos, Kind: LiteralNull, Value: "null"}
+		}
+		p.next()
+		return &UnaryExpr{Position: pos, Op: "NOT", X: p.parseNot()}
+	}
+	return p.parseCompare()
+}
+
+// comparisonOps are the comparison operators of the expression grammar
+var comparisonOps = map[string]bool{
+	"=": true, "!=": true, "<>": true, "<": true, "<=": true, ">": true, ">=": true,
+}
+
+// patternOps are the keyword operators matching a pattern
+var patternOps = map[string]bool{
+	"LIKE": true, "ILIKE": true, "MATCHES": true, "IMATCHES": true,
+}
+
+func (p *parser) parseCompare() Expr {
+	x := p.parseBinary(0)
+	if p.tok.Kind == TokenOp && comparisonOps[p.tok.Text] {
+		op := p.tok
+		p.next()
+		return &BinaryExpr{Position: op.Pos, Op: op.Text, Left: x, Right: p.parseBinary(0)}
+	}
+	if p.isWord("IS") {
+		is := &IsNullExpr{Position: p.tok.Pos, X: x}
+		p.next()
+		if p.isWord("NOT") {
+			is.Not = true
+			p.next()
+		}
+		p.expectWord("NULL", "IS")
+		return is
+	}
+
+	pos, not := p.tok.Pos, false
+	if p.isWord("NOT") {
+		not = true
+		p.next()
+	}
+	switch {
+	case p.tok.Kind == TokenIdent && patternOps[strings.ToUpper(p.tok.Text)]:
+		op := strings.ToUpper(p.tok.Text)
+		p.next()
+		return &BinaryExpr{Position: pos, Op: op, Not: not, Left: x, Right: p.parseBinary(0)}
+	case p.isWord("IN"):
+		return p.parseIn(pos, x, not)
+	case p.isWord("BETWEEN"):
+		between := &BetweenExpr{Position: pos, X: x, Not: not}
+		p.next()
+		between.Low = p.parseBinary(0)
+		if p.expectWord("AND", "the lower bound of BETWEEN") {
+			between.High = p.parseBinary(0)
+		}
+		return between
+	}
+	if not {
+		p.errorf(p.tok.Pos, "expected LIKE, ILIKE, MATCHES, IMATCHES, IN or BETWEEN after NOT, found %s", describe(p.tok))
+	}
+	return x
+}
+
+// parseIn parses the list or subselect of IN; the current token is IN
+func (p *parser) parseIn(pos Position, x Expr, not bool) Expr {
+	in := &InExpr{Position: pos, X: x, Not: not}
+	p.next()
+	if !p.isPunct("(") {
+		p.errorf(p.tok.Pos, "expected '(' after IN, found %s", describe(p.tok))
+		return in
+	}
+	open := p.tok.Pos
+	p.next()
+	if p.isWord("SELECT") {
+		in.Subselect = p.parseQuery()
+		if !p.isPunct(")") && p.tok.Kind != TokenEOF {
+			p.unexpected("subselect")
+		}
+	} else if !p.isPunct(")") {
+		in.List = p.parseExprs()
+	}
+	p.expectClose(open)
+	return in
+}
+
+// binaryLevels are the arithmetic operators by precedence
+var binaryLevels = []map[string]bool{
+	{"+": true, "-": true},
+	{"*": true, "/": true, "%": true},
+}
+
+// parseBinary parses the operators of binaryLevels from level upwards
+func (p *parser) parseBinary(level int) Expr {
+	if level == len(binaryLevels) {
+		return p.parseUnary()
+	}
+	x := p.parseBinary(level + 1)
+	for p.tok.Kind == TokenOp && binaryLevels[level][p.tok.Text] {
+		op := p.tok
+		p.next()
+		x = &BinaryExpr{Position: op.Pos, Op: op.Text, Left: x, Right: p.parseBinary(level + 1)}
+	}
+	return x
+}
+
+func (p *parser) parseUnary() Expr {
+	if p.isOp("-") || p.isOp("+") {
+		op := p.tok
+		defer p.leave()
+		if !p.enter() {
+			return &Literal{Position: op.Pos, Kind: LiteralNull, Value: "null"}
+		}
+		p.next()
+		return &UnaryExpr{Position: op.Pos, Op: op.Text, X: p.parseUnary()}
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() Expr {
+	tok := p.tok
+	switch tok.Kind {
+	case TokenNumber:
+		p.next()
+		return &Literal{Position: tok.Pos, Kind: LiteralNumber, Value: tok.Text}
+	case TokenString:
+		p.next()
+		return &Literal{Position: tok.Pos, Kind: LiteralString, Value: tok.Text}
+	case TokenQuotedIdent:
+		p.next()
+		return &FieldRef{Position: tok.Pos, Name: tok.Text, Quoted: true}
+	case TokenIdent:
+		upper := strings.ToUpper(tok.Text)
+		if upper == "NULL" {
+			p.next()
+			return &Literal{Position: tok.Pos, Kind: LiteralNull, Value: "null"}
+		}
+		if reserved[upper] {
+			break
+		}
+		p.next()
+		switch {
+		case p.isPunct("::"):
+			p.next()
+			if p.tok.Kind != TokenIdent {
+				p.errorf(p.tok.Pos, "expected function name after '%s::', found %s", tok.Text, describe(p.tok))
+				return &Literal{Position: tok.Pos, Kind: LiteralNull, Value: "null"}
+			}
+			name := upper + "::" + strings.ToUpper(p.tok.Text)
+			p.next()
+			if !p.isPunct("(") {
+				p.errorf(p.tok.Pos, "expected '(' after %s, found %s", name, describe(p.tok))
+				return &Literal{Position: tok.Pos, Kind: LiteralNull, Value: "null"}
+			}
+			return p.parseCall(tok.Pos, name)
+		case p.isPunct("("):
+			return p.parseCall(tok.Pos, upper)
+		case upper == "TEXT" && p.isWord("SEARCH"):
+			p.next()
+			search := &TextSearch{Position: tok.Pos}
+			if p.tok.Kind != TokenString {
+				p.errorf(p.tok.Pos, "expected quoted search terms after TEXT SEARCH, found %s", describe(p.tok))
+				return search
+			}
+			search.Terms = p.tok.Text
+			p.next()
+			return search
+		}
+		switch upper {
+		case "TRUE", "FALSE":
+			return &Literal{Position: tok.Pos, Kind: LiteralBool, Value: strings.ToLower(tok.Text)}
+		}
+		return &FieldRef{Position: tok.Pos, Name: tok.Text}
+	case TokenPunct:
+		if tok.Text == "(" {
+			p.next()
+			if p.isWord("SELECT") {
+				p.errorf(p.tok.Pos, "subselects are only supported in FROM and IN")
+			}
+			x := p.parseExpr()
+			p.expectClose(tok.Pos)
+			return x
+		}
+	}
+	p.errorf(tok.Pos, "expected expression, found %s", describe(tok))
+	if !p.atClauseEnd() && !p.isPunct(",") {
+		p.next()
+	}
+	return &Literal{Position: tok.Pos, Kind: LiteralNull, Value: "null"}
+}
+
+// parseCall parses the arguments of a function call; the current token is
+// the opening parenthesis
+func (p *parser) parseCall(pos Position, name string) Expr {
+	call := &Call{Position: pos, Name: name}
+	open := p.tok.Pos
+	p.next()
+	switch {
+	case p.isPunct(")"):
+	case p.isOp("*"):
+		call.Star = true
+		p.next()
+	default:
+		if p.isWord("DISTINCT") {
+			call.Distinct = true
+			p.next()
+		}
+		call.Args = p.parseExprs()
+	}
+	if !p.isPunct(")") {
+		p.errorf(p.tok.Pos, "expected ',' or ')' in the arguments of %s opened at line %d, column %d, found %s", name, open.Line, open.Column, describe(p.tok))
+		return call
+	}
+	p.next()
+	return call
+}
+
+// describe names a token for error messages
+func describe(tok Token) string {
+	switch tok.Kind {
+	case TokenEOF:
+		return "end of input"
+	case TokenString:
+		return "string literal"
+	case TokenQuotedIdent:
+		return "quoted property name"
+	default:
+		return "'" + tok.Text + "'"
+	}
+}
+
+// sortErrors orders errors by source position
+func sortErrors(errs []*Error) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Pos.Offset < errs[j].Pos.Offset
+	})
+}
@@ -0,0 +1,117 @@
+package aql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `SELECT DISTINCT sourceip, "Process Name" AS process, COUNT(*) AS total
+FROM events
+WHERE LOGSOURCENAME(logsourceid) ILIKE '%windows%'
+	AND username NOT IN (SELECT username FROM events WHERE category = 5)
+	AND magnitude BETWEEN 3 AND 10 AND destinationip IS NOT NULL
+GROUP BY sourceip HAVING total > 5
+ORDER BY total DESC
+LIMIT 100
+LAST 24 HOURS`
+
+	query, errs := Parse(src)
+	if len(errs) > 0 {
+		t.Fatalf("Parse() errors = %v", errs)
+	}
+	if !query.Distinct || len(query.Select) != 3 || query.Select[1].Alias != "process" {
+		t.Errorf("select = %+v", query.Select)
+	}
+	if ref, ok := query.Select[1].Expr.(*FieldRef); !ok || ref.Name != "Process Name" || !ref.Quoted {
+		t.Errorf("quoted property = %+v", query.Select[1].Expr)
+	}
+	if call, ok := query.Select[2].Expr.(*Call); !ok || call.Name != "COUNT" || !call.Star {
+		t.Errorf("COUNT(*) = %+v", query.Select[2].Expr)
+	}
+	if query.From == nil || query.From.Database != "events" {
+		t.Errorf("from = %+v", query.From)
+	}
+	if len(query.GroupBy) != 1 || query.Having == nil || len(query.OrderBy) != 1 || !query.OrderBy[0].Desc {
+		t.Errorf("group by %v, having %v, order by %+v", query.GroupBy, query.Having, query.OrderBy)
+	}
+	if query.Limit == nil || query.Limit.Value != "100" {
+		t.Errorf("limit = %+v", query.Limit)
+	}
+	if tc := query.Time; tc == nil || !tc.Last || tc.Count.Value != "24" || tc.Unit != "HOURS" {
+		t.Errorf("time clause = %+v", query.Time)
+	}
+
+	var kinds []string
+	WalkExpr(query.Where, func(e Expr) bool {
+		switch n := e.(type) {
+		case *BinaryExpr:
+			if n.Op == "ILIKE" {
+				kinds = append(kinds, "ilike")
+			}
+		case *InExpr:
+			if n.Not && n.Subselect != nil {
+				kinds = append(kinds, "not in")
+			}
+		case *BetweenExpr:
+			kinds = append(kinds, "between")
+		case *IsNullExpr:
+			if n.Not {
+				kinds = append(kinds, "is not null")
+			}
+		}
+		return true
+	})
+	if strings.Join(kinds, ",") != "ilike,not in,between,is not null" {
+		t.Errorf("where predicates = %v", kinds)
+	}
+
+	queries := 0
+	Inspect(query, func(*Query) bool {
+		queries++
+		return true
+	})
+	if queries != 2 {
+		t.Errorf("Inspect visited %d queries, want 2", queries)
+	}
+}
+
+func TestParseStartStop(t *testing.T) {
+	query, errs := Parse("SELECT * FROM flows START '2024-01-01 00:00' STOP 1704153600000")
+	if len(errs) > 0 {
+		t.Fatalf("Parse() errors = %v", errs)
+	}
+	tc := query.Time
+	if tc == nil || tc.Last || tc.Start.Kind != LiteralString || tc.Stop.Kind != LiteralNumber {
+		t.Errorf("time clause = %+v", tc)
+	}
+	if !query.Select[0].Star || query.From.Database != "flows" {
+		t.Errorf("query = %+v", query)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"missing from", "SELECT sourceip WHERE x = 1", "expected FROM after the select list"},
+		{"clause order", "SELECT * FROM events LIMIT 5 WHERE x = 1", "WHERE clause is out of order"},
+		{"missing last unit", "SELECT * FROM events LAST 5", "expected MINUTES, HOURS or DAYS"},
+		{"stop without start", "SELECT * FROM events STOP 1", "STOP without a preceding START"},
+		{"duplicate time", "SELECT * FROM events LAST 5 MINUTES LAST 1 DAYS", "duplicate time clause"},
+		{"unclosed call", "SELECT COUNT(sourceip FROM events", "expected ',' or ')'"},
+		{"unterminated string", "SELECT * FROM events WHERE username = 'admin", "unterminated string literal"},
+		{"nested too deep", "SELECT * FROM events WHERE " + strings.Repeat("(", 200) + "x = 1" + strings.Repeat(")", 200), "maximum depth"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := Parse(tt.src)
+			if len(errs) == 0 || !strings.Contains(errs[0].Msg, tt.want) {
+				t.Errorf("Parse() errors = %v, want %q first", errs, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// Package fql provides a tokenizer and recursive-descent parser for
+// CrowdStrike Falcon Query Language (FQL) filters, as used by event
+// searches, custom IOA rules and scheduled searches, producing an AST with
+// line/column positions for validation.
+package fql
+
+// Position identifies a location in the parsed source
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// IsValid reports whether the position was set by the parser
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// Node is implemented by all AST nodes
+type Node interface {
+	Pos() Position
+}
+
+// Query is a whole filter
+type Query struct {
+	Position Position
+	// Filter is nil when the query is empty or could not be parsed
+	Filter Expr
+}
+
+// Pos implements Node
+func (q *Query) Pos() Position { return q.Position }
+
+// Expr is implemented by expression nodes
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// Operators of a condition. OpEqual is the absence of an operator, as in
+// `name:'cmd.exe'`.
+const (
+	OpEqual        = ""
+	OpNot          = "!"
+	OpGreater      = ">"
+	OpGreaterEqual = ">="
+	OpLess         = "<"
+	OpLessEqual    = "<="
+	OpMatch        = "~"
+	OpNotMatch     = "!~"
+	OpWildcard     = "*"
+)
+
+// ValueKind distinguishes condition values
+type ValueKind int
+
+// Value kinds
+const (
+	ValueString ValueKind = iota // 'quoted' or "quoted"
+	ValueNumber
+	ValueBool
+	ValueNull
+	// ValueWord is any other unquoted value, such as an enumeration member
+	ValueWord
+)
+
+// Value is a condition value
+type Value struct {
+	Position Position
+	Kind     ValueKind
+	// Text is the value with quotes removed and escapes resolved
+	Text string
+}
+
+// Pos implements Node
+func (v *Value) Pos() Position { return v.Position }
+
+// Condition is `field:[op]value` or `field:[op][value, value]`
+type Condition struct {
+	Position Position
+	// Field is the field name, such as `event_simpleName` or
+	// `device.platform_name`
+	Field string
+	Op    string
+	// List is set for a bracketed value list; Values may then be empty
+	List   bool
+	Values []*Value
+}
+
+// BinaryExpr joins two expressions with `+` (and) or `,` (or)
+type BinaryExpr struct {
+	Position Position
+	Op       string
+	Left     Expr
+	Right    Expr
+}
+
+// ParenExpr is a parenthesized expression
+type ParenExpr struct {
+	Position Position
+	X        Expr
+}
+
+// Pos implementations
+func (e *Condition) Pos() Position  { return e.Position }
+func (e *BinaryExpr) Pos() Position { return e.Position }
+func (e *ParenExpr) Pos() Position  { return e.Position }
+
+func (*Condition) exprNode()  {}
+func (*BinaryExpr) exprNode() {}
+func (*ParenExpr) exprNode()  {}
+
+// IsComparison reports whether op orders values: >, >=, < or <=
+func IsComparison(op string) bool {
+	switch op {
+	case OpGreater, OpGreaterEqual, OpLess, OpLessEqual:
+		return true
+	}
+	return false
+}
+
+// WalkExpr traverses an expression tree depth-first, calling fn for each
+// node. Traversal of a subtree stops when fn returns false.
+func WalkExpr(e Expr, fn func(Expr) bool) {
+	if e == nil || !fn(e) {
+		return
+	}
+	switch n := e.(type) {
+	case *BinaryExpr:
+		WalkExpr(n.Left, fn)
+		WalkExpr(n.Right, fn)
+	case *ParenExpr:
+		WalkExpr(n.X, fn)
+	}
+}
+
+// Conditions returns the conditions of the query in source order
+func (q *Query) Conditions() []*Condition {
+	var conditions []*Condition
+	WalkExpr(q.Filter, func(e Expr) bool {
+		if c, ok := e.(*Condition); ok {
+			conditions = append(conditions, c)
+		}
+		return true
+	})
+	return conditions
+}
@@ -0,0 +1,167 @@
+package fql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TokenKind identifies the lexical class of a token
+type TokenKind int
+
+// Token kinds produced by the lexer
+const (
+	TokenEOF TokenKind = iota
+	TokenWord
+	TokenString // 'literal' or "literal"
+	TokenOp     // ! > >= < <= ~ !~ *
+	TokenPunct  // ( ) [ ] , + :
+	TokenIllegal
+)
+
+// Token is a single lexical token
+type Token struct {
+	Kind TokenKind
+	Text string
+	Pos  Position
+}
+
+// Error is a positioned lexing or parsing error
+type Error struct {
+	Pos Position
+	Msg string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Pos.Line, e.Pos.Column, e.Msg)
+}
+
+// lexer converts FQL source into tokens. Field names and unquoted values
+// are both scanned as words; the parser tells them apart by the colon
+// following a field name.
+type lexer struct {
+	src    string
+	offset int
+	line   int
+	column int
+	errs   []*Error
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, column: 1}
+}
+
+func (l *lexer) pos() Position {
+	return Position{Offset: l.offset, Line: l.line, Column: l.column}
+}
+
+func (l *lexer) errorf(pos Position, format string, args ...interface{}) {
+	l.errs = append(l.errs, &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (l *lexer) peekByte(n int) byte {
+	if l.offset+n >= len(l.src) {
+		return 0
+	}
+	return l.src[l.offset+n]
+}
+
+func (l *lexer) advance() byte {
+	c := l.src[l.offset]
+	l.offset++
+	if c == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+	return c
+}
+
+// skipSpace skips whitespace, which FQL allows around operators and
+// punctuation
+func (l *lexer) skipSpace() {
+	for l.offset < len(l.src) {
+		switch l.src[l.offset] {
+		case ' ', '\t', '\n', '\r':
+			l.advance()
+		default:
+			return
+		}
+	}
+}
+
+// next scans the next token
+func (l *lexer) next() Token {
+	l.skipSpace()
+	start := l.pos()
+	if l.offset >= len(l.src) {
+		return Token{Kind: TokenEOF, Pos: start}
+	}
+
+	c := l.src[l.offset]
+	switch {
+	case isWordStart(c):
+		return Token{Kind: TokenWord, Text: l.scanWhile(isWordChar), Pos: start}
+	case c == '\'' || c == '"':
+		return l.scanString(start, c)
+	case strings.IndexByte("()[],+:", c) >= 0:
+		l.advance()
+		return Token{Kind: TokenPunct, Text: string(c), Pos: start}
+	}
+
+	for _, op := range []string{"!~", ">=", "<=", "!", ">", "<", "~", "*"} {
+		if strings.HasPrefix(l.src[l.offset:], op) {
+			for range op {
+				l.advance()
+			}
+			return Token{Kind: TokenOp, Text: op, Pos: start}
+		}
+	}
+
+	l.advance()
+	l.errorf(start, "unexpected character %q", c)
+	return Token{Kind: TokenIllegal, Text: string(c), Pos: start}
+}
+
+func (l *lexer) scanWhile(pred func(byte) bool) string {
+	begin := l.offset
+	for l.offset < len(l.src) && pred(l.src[l.offset]) {
+		l.advance()
+	}
+	return l.src[begin:l.offset]
+}
+
+// scanString scans a string delimited by quote. A backslash escapes the
+// character after it.
+func (l *lexer) scanString(start Position, quote byte) Token {
+	l.advance() // opening quote
+	var b strings.Builder
+	for l.offset < len(l.src) {
+		c := l.advance()
+		switch {
+		case c == quote:
+			return Token{Kind: TokenString, Text: b.String(), Pos: start}
+		case c == '\\' && l.offset < len(l.src):
+			b.WriteByte(l.advance())
+		default:
+			b.WriteByte(c)
+		}
+	}
+	l.errorf(start, "unterminated string literal")
+	return Token{Kind: TokenString, Text: b.String(), Pos: start}
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || c == '-' || c == '.' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isWordChar reports whether c continues a word. A `*` inside an unquoted
+// value is a wildcard; a leading one is the wildcard operator.
+func isWordChar(c byte) bool {
+	return isWordStart(c) || c == '*'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,270 @@
+package fql
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxErrors bounds the number of errors collected before parsing stops
+const maxErrors = 50
+
+// maxDepth bounds the nesting of parenthesized expressions. Deeper input
+// stops the parse with an error instead of recursing further.
+const maxDepth = 100
+
+// parser is a recursive-descent parser over the lexer token stream
+type parser struct {
+	lex  *lexer
+	tok  Token
+	errs []*Error
+	// depth is the current nesting; stopped is set once it exceeded maxDepth
+	depth   int
+	stopped bool
+}
+
+// Parse parses an FQL filter. It always returns a Query holding every
+// condition that could be parsed, together with any errors encountered.
+func Parse(src string) (*Query, []*Error) {
+	p := &parser{lex: newLexer(src)}
+	p.next()
+	query := &Query{Position: p.tok.Pos}
+	if p.tok.Kind == TokenEOF {
+		p.errorf(p.tok.Pos, "empty query")
+	} else {
+		query.Filter = p.parseOr()
+	}
+	for p.tok.Kind != TokenEOF && !p.stopped && len(p.errs) < maxErrors {
+		// Resume after a stray token, such as an unbalanced ')'
+		p.errorf(p.tok.Pos, "unexpected %s after the end of the query", describe(p.tok))
+		p.next()
+		if p.tok.Kind != TokenEOF {
+			query.Filter = joinExprs(query.Filter, p.parseOr())
+		}
+	}
+
+	errs := append(p.lex.errs, p.errs...)
+	sortErrors(errs)
+	return query, errs
+}
+
+func (p *parser) next() {
+	p.tok = p.lex.next()
+}
+
+// errorf records an error. A second error at the position of the previous
+// one is dropped, since it follows from the first.
+func (p *parser) errorf(pos Position, format string, args ...interface{}) {
+	if len(p.errs) >= maxErrors || p.stopped {
+		return
+	}
+	if n := len(p.errs); n > 0 && p.errs[n-1].Pos.Offset == pos.Offset {
+		return
+	}
+	p.errs = append(p.errs, &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+// enter descends one nesting level. Past maxDepth it reports the excess and
+// moves the lexer to the end of input, so every caller unwinds without
+// recursing further. Each call is paired with a deferred leave.
+func (p *parser) enter() bool {
+	p.depth++
+	if p.depth <= maxDepth {
+		return true
+	}
+	p.errorf(p.tok.Pos, "nesting exceeds the maximum depth of %d", maxDepth)
+	p.stopped = true
+	p.lex.offset = len(p.lex.src)
+	p.tok = Token{Kind: TokenEOF, Pos: p.tok.Pos}
+	return false
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) isPunct(text string) bool {
+	return p.tok.Kind == TokenPunct && p.tok.Text == text
+}
+
+// atFactor reports whether the current token can start a condition or a
+// parenthesized expression
+func (p *parser) atFactor() bool {
+	return p.tok.Kind == TokenWord || p.isPunct("(")
+}
+
+// parseOr parses conditions joined by `,`, which binds loosest:
+//
+//	or     = and { "," and }
+//	and    = factor { "+" factor }
+//	factor = "(" or ")" | condition
+func (p *parser) parseOr() Expr {
+	left := p.parseAnd()
+	for p.isPunct(",") {
+		pos := p.tok.Pos
+		p.next()
+		left = &BinaryExpr{Position: pos, Op: ",", Left: left, Right: p.parseAnd()}
+	}
+	return left
+}
+
+// parseAnd parses conditions joined by `+`. Adjacent conditions without an
+// operator are reported and joined as if `+` separated them, unless the
+// first could not be parsed and was reported already.
+func (p *parser) parseAnd() Expr {
+	left := p.parseFactor()
+	for {
+		pos := p.tok.Pos
+		switch {
+		case p.isPunct("+"):
+			p.next()
+		case p.atFactor() && !p.stopped:
+			if left != nil {
+				p.errorf(pos, "expected '+' or ',' before %s", describe(p.tok))
+			}
+		default:
+			return left
+		}
+		left = &BinaryExpr{Position: pos, Op: "+", Left: left, Right: p.parseFactor()}
+	}
+}
+
+func (p *parser) parseFactor() Expr {
+	if !p.enter() {
+		return nil
+	}
+	defer p.leave()
+
+	switch {
+	case p.isPunct("("):
+		open := p.tok.Pos
+		p.next()
+		x := p.parseOr()
+		if !p.isPunct(")") {
+			p.errorf(p.tok.Pos, "expected ')' to close '(' at line %d, column %d, found %s", open.Line, open.Column, describe(p.tok))
+			return &ParenExpr{Position: open, X: x}
+		}
+		p.next()
+		return &ParenExpr{Position: open, X: x}
+	case p.tok.Kind == TokenWord:
+		return p.parseCondition()
+	}
+
+	p.errorf(p.tok.Pos, "expected a field name or '(', found %s", describe(p.tok))
+	// Skip the token unless it ends an enclosing expression
+	if p.tok.Kind != TokenEOF && !p.isPunct(")") && !p.isPunct(",") && !p.isPunct("+") {
+		p.next()
+	}
+	return nil
+}
+
+// parseCondition parses a field comparison:
+//
+//	condition = field ":" [op] ( value | "[" [ value { "," value } ] "]" )
+func (p *parser) parseCondition() Expr {
+	cond := &Condition{Position: p.tok.Pos, Field: p.tok.Text}
+	p.next()
+	if !p.isPunct(":") {
+		p.errorf(p.tok.Pos, "expected ':' after field name %q, found %s", cond.Field, describe(p.tok))
+		return cond
+	}
+	p.next()
+	if p.tok.Kind == TokenOp {
+		cond.Op = p.tok.Text
+		p.next()
+	}
+
+	if !p.isPunct("[") {
+		if v := p.parseValue(cond.Field); v != nil {
+			cond.Values = []*Value{v}
+		}
+		return cond
+	}
+
+	open := p.tok.Pos
+	cond.List = true
+	p.next()
+	for !p.isPunct("]") {
+		v := p.parseValue(cond.Field)
+		if v == nil {
+			// Give up on the list rather than reporting every token in it
+			for p.tok.Kind != TokenEOF && !p.isPunct("]") && !p.isPunct(")") {
+				p.next()
+			}
+			break
+		}
+		cond.Values = append(cond.Values, v)
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	if !p.isPunct("]") {
+		p.errorf(p.tok.Pos, "expected ']' to close '[' at line %d, column %d, found %s", open.Line, open.Column, describe(p.tok))
+		return cond
+	}
+	p.next()
+	return cond
+}
+
+// parseValue parses a quoted string or an unquoted word, classifying words
+// as numbers, booleans or null
+func (p *parser) parseValue(field string) *Value {
+	v := &Value{Position: p.tok.Pos, Text: p.tok.Text}
+	switch p.tok.Kind {
+	case TokenString:
+		v.Kind = ValueString
+	case TokenWord:
+		v.Kind = wordKind(p.tok.Text)
+	default:
+		p.errorf(p.tok.Pos, "expected a value for field %q, found %s", field, describe(p.tok))
+		return nil
+	}
+	p.next()
+	return v
+}
+
+// wordKind classifies an unquoted value
+func wordKind(text string) ValueKind {
+	switch strings.ToLower(text) {
+	case "true", "false":
+		return ValueBool
+	case "null":
+		return ValueNull
+	}
+	if _, err := strconv.ParseFloat(text, 64); err == nil {
+		return ValueNumber
+	}
+	return ValueWord
+}
+
+// joinExprs joins the expressions parsed on either side of a stray token
+func joinExprs(left, right Expr) Expr {
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	}
+	return &BinaryExpr{Position: right.Pos(), Op: "+", Left: left, Right: right}
+}
+
+// describe names a token for error messages
+func describe(tok Token) string {
+	switch tok.Kind {
+	case TokenEOF:
+		return "end of input"
+	case TokenString:
+		return "string literal"
+	default:
+		return "'" + tok.Text + "'"
+	}
+}
+
+// sortErrors orders errors by source position
+func sortErrors(errs []*Error) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Pos.Offset < errs[j].Pos.Offset
+	})
+}
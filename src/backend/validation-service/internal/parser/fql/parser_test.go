@@ -0,0 +1,94 @@
+package fql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `event_simpleName:'ProcessRollup2'+FileName:['cmd.exe','powershell.exe']
++(CommandLine:~'*-enc*',ParentBaseFileName:!'explorer.exe')+ContextTimeStamp:>=1700000000+device.platform_name:Windows`
+
+	query, errs := Parse(src)
+	if len(errs) > 0 {
+		t.Fatalf("Parse() errors = %v", errs)
+	}
+
+	want := []struct {
+		field  string
+		op     string
+		list   bool
+		values []string
+		kind   ValueKind
+	}{
+		{"event_simpleName", OpEqual, false, []string{"ProcessRollup2"}, ValueString},
+		{"FileName", OpEqual, true, []string{"cmd.exe", "powershell.exe"}, ValueString},
+		{"CommandLine", OpMatch, false, []string{"*-enc*"}, ValueString},
+		{"ParentBaseFileName", OpNot, false, []string{"explorer.exe"}, ValueString},
+		{"ContextTimeStamp", OpGreaterEqual, false, []string{"1700000000"}, ValueNumber},
+		{"device.platform_name", OpEqual, false, []string{"Windows"}, ValueWord},
+	}
+	conditions := query.Conditions()
+	if len(conditions) != len(want) {
+		t.Fatalf("got %d conditions, want %d", len(conditions), len(want))
+	}
+	for i, w := range want {
+		c := conditions[i]
+		var values []string
+		for _, v := range c.Values {
+			values = append(values, v.Text)
+			if v.Kind != w.kind {
+				t.Errorf("%s value %q kind = %d, want %d", c.Field, v.Text, v.Kind, w.kind)
+			}
+		}
+		if c.Field != w.field || c.Op != w.op || c.List != w.list || strings.Join(values, ",") != strings.Join(w.values, ",") {
+			t.Errorf("condition %d = %s:%s %v (list %v), want %s:%s %v", i, c.Field, c.Op, values, c.List, w.field, w.op, w.values)
+		}
+	}
+	if !IsComparison(conditions[4].Op) || IsComparison(conditions[2].Op) {
+		t.Error("IsComparison misclassifies >= or ~")
+	}
+
+	// `+` binds tighter than `,`, so the parenthesized OR is one operand
+	var ors int
+	WalkExpr(query.Filter, func(e Expr) bool {
+		if b, ok := e.(*BinaryExpr); ok && b.Op == "," {
+			ors++
+		}
+		return true
+	})
+	if ors != 1 {
+		t.Errorf("got %d OR expressions, want 1", ors)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		want   string
+		column int
+	}{
+		{"empty", "  ", "empty query", 3},
+		{"missing colon", "FileName'cmd.exe'", "expected ':' after field name", 9},
+		{"missing value", "FileName:+ImageFileName:'a'", "expected a value for field", 10},
+		{"unclosed list", "FileName:['a','b'", "expected ']' to close '['", 18},
+		{"unclosed paren", "(FileName:'a'", "expected ')' to close '('", 14},
+		{"missing operator", "FileName:'a' ImageFileName:'b'", "expected '+' or ','", 14},
+		{"stray paren", "FileName:'a')", "unexpected ')' after the end of the query", 13},
+		{"unterminated string", "FileName:'cmd.exe", "unterminated string literal", 10},
+		{"nested too deep", strings.Repeat("(", 200) + "a:1" + strings.Repeat(")", 200), "maximum depth", 101},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := Parse(tt.src)
+			if len(errs) == 0 {
+				t.Fatalf("Parse() returned no errors, want %q", tt.want)
+			}
+			if !strings.Contains(errs[0].Msg, tt.want) || errs[0].Pos.Column != tt.column {
+				t.Errorf("first error = %v, want %q at column %d", errs[0], tt.want, tt.column)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Package sigma provides a tokenizer and recursive-descent parser for the
+// condition of Sigma rules, such as `selection and not 1 of filter_*` or
+// `selection | count(User) by Computer > 5`, producing an AST with
+// positions so the search identifiers a condition references can be
+// resolved.
+package sigma
+
+import "strings"
+
+// Position identifies a location in the parsed condition
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// IsValid reports whether the position was set by the parser
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// Node is implemented by all AST nodes
+type Node interface {
+	Pos() Position
+}
+
+// Condition is a whole condition
+type Condition struct {
+	Position Position
+	// Expr is nil when the condition is empty or could not be parsed
+	Expr Expr
+	// Aggregation is the expression after `|`, if any
+	Aggregation *Aggregation
+}
+
+// Pos implements Node
+func (c *Condition) Pos() Position { return c.Position }
+
+// Expr is implemented by expression nodes
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// Identifier references a search identifier by name
+type Identifier struct {
+	Position Position
+	Name     string
+}
+
+// OfExpr is `1 of pattern`, `any of pattern`, `all of pattern` or the same
+// with `them`, which selects every search identifier not starting with an
+// underscore
+type OfExpr struct {
+	Position Position
+	// All is set for `all of`
+	All bool
+	// Pattern is a search identifier name in which `*` matches any run of
+	// characters; empty for `them`
+	Pattern string
+	Them    bool
+}
+
+// NotExpr negates an expression
+type NotExpr struct {
+	Position Position
+	X        Expr
+}
+
+// BinaryExpr joins two expressions with `and` or `or`
+type BinaryExpr struct {
+	Position Position
+	Op       string
+	Left     Expr
+	Right    Expr
+}
+
+// ParenExpr is a parenthesized expression
+type ParenExpr struct {
+	Position Position
+	X        Expr
+}
+
+// Pos implementations
+func (e *Identifier) Pos() Position { return e.Position }
+func (e *OfExpr) Pos() Position     { return e.Position }
+func (e *NotExpr) Pos() Position    { return e.Position }
+func (e *BinaryExpr) Pos() Position { return e.Position }
+func (e *ParenExpr) Pos() Position  { return e.Position }
+
+func (*Identifier) exprNode() {}
+func (*OfExpr) exprNode()     {}
+func (*NotExpr) exprNode()    {}
+func (*BinaryExpr) exprNode() {}
+func (*ParenExpr) exprNode()  {}
+
+// Aggregation functions
+const (
+	FuncCount = "count"
+	FuncMin   = "min"
+	FuncMax   = "max"
+	FuncAvg   = "avg"
+	FuncSum   = "sum"
+	// FuncNear is the near correlation of Sigma 1, `| near a and not b`
+	FuncNear = "near"
+)
+
+// Aggregation is the expression after `|`: `count([field]) [by field] [op
+// value]`, the other functions likewise with a field, or `near expr`
+type Aggregation struct {
+	Position Position
+	Function string
+	// Field is the aggregated field; optional for count
+	Field string
+	// GroupBy is the field after `by`, if any
+	GroupBy string
+	// Op and Value are the comparison of the aggregate, if any, such as >
+	// and 5
+	Op    string
+	Value string
+	// Near holds the expression of a near correlation
+	Near Expr
+}
+
+// Pos implements Node
+func (a *Aggregation) Pos() Position { return a.Position }
+
+// WalkExpr traverses an expression tree depth-first, calling fn for each
+// node. Traversal of a subtree stops when fn returns false.
+func WalkExpr(e Expr, fn func(Expr) bool) {
+	if e == nil || !fn(e) {
+		return
+	}
+	switch n := e.(type) {
+	case *NotExpr:
+		WalkExpr(n.X, fn)
+	case *BinaryExpr:
+		WalkExpr(n.Left, fn)
+		WalkExpr(n.Right, fn)
+	case *ParenExpr:
+		WalkExpr(n.X, fn)
+	}
+}
+
+// References returns the identifiers and `of` expressions of the condition,
+// those of a near correlation included, in source order
+func (c *Condition) References() []Expr {
+	var refs []Expr
+	collect := func(e Expr) bool {
+		switch e.(type) {
+		case *Identifier, *OfExpr:
+			refs = append(refs, e)
+		}
+		return true
+	}
+	WalkExpr(c.Expr, collect)
+	if c.Aggregation != nil {
+		WalkExpr(c.Aggregation.Near, collect)
+	}
+	return refs
+}
+
+// Selects reports whether the `of` expression selects the named search
+// identifier
+func (e *OfExpr) Selects(name string) bool {
+	if e.Them {
+		return !strings.HasPrefix(name, "_")
+	}
+	return MatchPattern(e.Pattern, name)
+}
+
+// MatchPattern reports whether a search identifier name matches a pattern in
+// which `*` matches any run of characters
+func MatchPattern(pattern, name string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == name
+	}
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(name, part)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(part):]
+	}
+	return strings.HasSuffix(name, last)
+}
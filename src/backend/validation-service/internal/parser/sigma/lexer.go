@@ -0,0 +1,127 @@
+package sigma
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TokenKind identifies the lexical class of a token
+type TokenKind int
+
+// Token kinds produced by the lexer
+const (
+	TokenEOF TokenKind = iota
+	// TokenWord is a keyword, search identifier, pattern, field or number
+	TokenWord
+	TokenOp    // == != > >= < <=
+	TokenPunct // ( ) , |
+	TokenIllegal
+)
+
+// Token is a single lexical token
+type Token struct {
+	Kind TokenKind
+	Text string
+	Pos  Position
+}
+
+// Error is a positioned lexing or parsing error
+type Error struct {
+	Pos Position
+	Msg string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Pos.Line, e.Pos.Column, e.Msg)
+}
+
+// lexer converts a condition into tokens. Keywords are scanned as words;
+// the parser recognizes them case-insensitively.
+type lexer struct {
+	src    string
+	offset int
+	line   int
+	column int
+	errs   []*Error
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, column: 1}
+}
+
+func (l *lexer) pos() Position {
+	return Position{Offset: l.offset, Line: l.line, Column: l.column}
+}
+
+func (l *lexer) errorf(pos Position, format string, args ...interface{}) {
+	l.errs = append(l.errs, &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (l *lexer) advance() byte {
+	c := l.src[l.offset]
+	l.offset++
+	if c == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+	return c
+}
+
+func (l *lexer) skipSpace() {
+	for l.offset < len(l.src) {
+		switch l.src[l.offset] {
+		case ' ', '\t', '\n', '\r':
+			l.advance()
+		default:
+			return
+		}
+	}
+}
+
+// next scans the next token
+func (l *lexer) next() Token {
+	l.skipSpace()
+	start := l.pos()
+	if l.offset >= len(l.src) {
+		return Token{Kind: TokenEOF, Pos: start}
+	}
+
+	c := l.src[l.offset]
+	switch {
+	case isWordChar(c):
+		return Token{Kind: TokenWord, Text: l.scanWhile(isWordChar), Pos: start}
+	case strings.IndexByte("(),|", c) >= 0:
+		l.advance()
+		return Token{Kind: TokenPunct, Text: string(c), Pos: start}
+	}
+
+	for _, op := range []string{"==", "!=", ">=", "<=", ">", "<"} {
+		if strings.HasPrefix(l.src[l.offset:], op) {
+			for range op {
+				l.advance()
+			}
+			return Token{Kind: TokenOp, Text: op, Pos: start}
+		}
+	}
+
+	l.advance()
+	l.errorf(start, "unexpected character %q", c)
+	return Token{Kind: TokenIllegal, Text: string(c), Pos: start}
+}
+
+func (l *lexer) scanWhile(pred func(byte) bool) string {
+	begin := l.offset
+	for l.offset < len(l.src) && pred(l.src[l.offset]) {
+		l.advance()
+	}
+	return l.src[begin:l.offset]
+}
+
+// isWordChar reports whether c belongs to a word. Search identifiers are
+// YAML keys, commonly with dashes and dots; `*` makes a name a pattern.
+func isWordChar(c byte) bool {
+	return c == '_' || c == '-' || c == '.' || c == '*' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,300 @@
+package sigma
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxErrors bounds the number of errors collected before parsing stops
+const maxErrors = 50
+
+// maxDepth bounds the nesting of parenthesized and negated expressions.
+// Deeper input stops the parse with an error instead of recursing further.
+const maxDepth = 100
+
+// keywords cannot be used as search identifiers
+var keywords = map[string]bool{
+	"and": true, "or": true, "not": true, "of": true, "them": true, "by": true,
+}
+
+// parser is a recursive-descent parser over the lexer token stream
+type parser struct {
+	lex  *lexer
+	tok  Token
+	errs []*Error
+	// depth is the current nesting; stopped is set once it exceeded maxDepth
+	depth   int
+	stopped bool
+}
+
+// Parse parses a condition:
+//
+//	condition   = or [ "|" aggregation ]
+//	or          = and { "or" and }
+//	and         = not { "and" not }
+//	not         = "not" not | primary
+//	primary     = "(" or ")" | quantifier "of" ( pattern | "them" ) | identifier
+//	quantifier  = "1" | "any" | "all"
+//	aggregation = "near" or | function "(" [ field ] ")" [ "by" field ] [ op number ]
+//
+// Keywords are case-insensitive. It always returns a Condition holding what
+// could be parsed, together with any errors encountered.
+func Parse(src string) (*Condition, []*Error) {
+	p := &parser{lex: newLexer(src)}
+	p.next()
+	cond := &Condition{Position: p.tok.Pos}
+	if p.tok.Kind == TokenEOF {
+		p.errorf(p.tok.Pos, "empty condition")
+	} else {
+		cond.Expr = p.parseOr()
+	}
+	if p.isPunct("|") {
+		p.next()
+		cond.Aggregation = p.parseAggregation()
+	}
+	if p.tok.Kind != TokenEOF && p.tok.Kind != TokenIllegal && !p.stopped {
+		p.errorf(p.tok.Pos, "unexpected %s after the end of the condition", describe(p.tok))
+	}
+
+	errs := append(p.lex.errs, p.errs...)
+	sortErrors(errs)
+	return cond, errs
+}
+
+func (p *parser) next() {
+	p.tok = p.lex.next()
+}
+
+// errorf records an error. A second error at the position of the previous
+// one is dropped, since it follows from the first.
+func (p *parser) errorf(pos Position, format string, args ...interface{}) {
+	if len(p.errs) >= maxErrors || p.stopped {
+		return
+	}
+	if n := len(p.errs); n > 0 && p.errs[n-1].Pos.Offset == pos.Offset {
+		return
+	}
+	p.errs = append(p.errs, &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+// enter descends one nesting level. Past maxDepth it reports the excess and
+// moves the lexer to the end of input, so every caller unwinds without
+// recursing further. Each call is paired with a deferred leave.
+func (p *parser) enter() bool {
+	p.depth++
+	if p.depth <= maxDepth {
+		return true
+	}
+	p.errorf(p.tok.Pos, "nesting exceeds the maximum depth of %d", maxDepth)
+	p.stopped = true
+	p.lex.offset = len(p.lex.src)
+	p.tok = Token{Kind: TokenEOF, Pos: p.tok.Pos}
+	return false
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) isPunct(text string) bool {
+	return p.tok.Kind == TokenPunct && p.tok.Text == text
+}
+
+// isKeyword reports whether the current token is the keyword kw
+func (p *parser) isKeyword(kw string) bool {
+	return p.tok.Kind == TokenWord && strings.EqualFold(p.tok.Text, kw)
+}
+
+// atPrimary reports whether the current token can start an expression
+func (p *parser) atPrimary() bool {
+	if p.isPunct("(") {
+		return true
+	}
+	return p.tok.Kind == TokenWord && (!keywords[strings.ToLower(p.tok.Text)] || p.isKeyword("not"))
+}
+
+func (p *parser) parseOr() Expr {
+	left := p.parseAnd()
+	for p.isKeyword("or") {
+		pos := p.tok.Pos
+		p.next()
+		left = &BinaryExpr{Position: pos, Op: "or", Left: left, Right: p.parseAnd()}
+	}
+	return left
+}
+
+// parseAnd parses expressions joined by `and`. Adjacent expressions
+// without an operator are reported and joined as if `and` separated them.
+func (p *parser) parseAnd() Expr {
+	left := p.parseNot()
+	for {
+		pos := p.tok.Pos
+		switch {
+		case p.isKeyword("and"):
+			p.next()
+		case p.atPrimary() && !p.stopped:
+			p.errorf(pos, "expected 'and' or 'or' before %s", describe(p.tok))
+		default:
+			return left
+		}
+		left = &BinaryExpr{Position: pos, Op: "and", Left: left, Right: p.parseNot()}
+	}
+}
+
+func (p *parser) parseNot() Expr {
+	if !p.isKeyword("not") {
+		return p.parsePrimary()
+	}
+	if !p.enter() {
+		return nil
+	}
+	defer p.leave()
+	pos := p.tok.Pos
+	p.next()
+	return &NotExpr{Position: pos, X: p.parseNot()}
+}
+
+func (p *parser) parsePrimary() Expr {
+	if !p.enter() {
+		return nil
+	}
+	defer p.leave()
+
+	pos := p.tok.Pos
+	if p.isPunct("(") {
+		p.next()
+		x := p.parseOr()
+		if !p.isPunct(")") {
+			p.errorf(p.tok.Pos, "expected ')' to close '(' at line %d, column %d, found %s", pos.Line, pos.Column, describe(p.tok))
+			return &ParenExpr{Position: pos, X: x}
+		}
+		p.next()
+		return &ParenExpr{Position: pos, X: x}
+	}
+
+	if p.tok.Kind != TokenWord || keywords[strings.ToLower(p.tok.Text)] {
+		// Illegal characters were reported by the lexer
+		if p.tok.Kind != TokenIllegal {
+			p.errorf(pos, "expected a search identifier, '(' or 'not', found %s", describe(p.tok))
+		}
+		// Skip the token unless it ends an enclosing expression
+		if p.tok.Kind != TokenEOF && !p.isPunct(")") && !p.isPunct("|") && !p.isKeyword("and") && !p.isKeyword("or") {
+			p.next()
+		}
+		return nil
+	}
+
+	word := p.tok.Text
+	p.next()
+	if p.isKeyword("of") {
+		return p.parseOf(pos, word)
+	}
+	return &Identifier{Position: pos, Name: word}
+}
+
+// parseOf parses the rest of `quantifier of pattern` once the quantifier
+// has been read
+func (p *parser) parseOf(pos Position, quantifier string) Expr {
+	of := &OfExpr{Position: pos}
+	switch strings.ToLower(quantifier) {
+	case "1", "any":
+	case "all":
+		of.All = true
+	default:
+		p.errorf(pos, "expected 1, any or all before 'of', found '%s'", quantifier)
+	}
+	p.next()
+
+	switch {
+	case p.isKeyword("them"):
+		of.Them = true
+	case p.tok.Kind == TokenWord && !keywords[strings.ToLower(p.tok.Text)]:
+		of.Pattern = p.tok.Text
+	default:
+		p.errorf(p.tok.Pos, "expected a search identifier pattern or 'them' after 'of', found %s", describe(p.tok))
+		return of
+	}
+	p.next()
+	return of
+}
+
+// parseAggregation parses the expression after `|`
+func (p *parser) parseAggregation() *Aggregation {
+	agg := &Aggregation{Position: p.tok.Pos}
+	if p.tok.Kind != TokenWord {
+		p.errorf(p.tok.Pos, "expected an aggregation function after '|', found %s", describe(p.tok))
+		return agg
+	}
+	agg.Function = strings.ToLower(p.tok.Text)
+	switch agg.Function {
+	case FuncNear:
+		p.next()
+		if p.tok.Kind == TokenEOF {
+			p.errorf(p.tok.Pos, "expected an expression after 'near'")
+			return agg
+		}
+		agg.Near = p.parseOr()
+		return agg
+	case FuncCount, FuncMin, FuncMax, FuncAvg, FuncSum:
+	default:
+		p.errorf(p.tok.Pos, "unknown aggregation function '%s'", p.tok.Text)
+		return agg
+	}
+	p.next()
+
+	if !p.isPunct("(") {
+		p.errorf(p.tok.Pos, "expected '(' after %s, found %s", agg.Function, describe(p.tok))
+		return agg
+	}
+	p.next()
+	if p.tok.Kind == TokenWord {
+		agg.Field = p.tok.Text
+		p.next()
+	} else if agg.Function != FuncCount {
+		p.errorf(p.tok.Pos, "%s needs a field, found %s", agg.Function, describe(p.tok))
+	}
+	if !p.isPunct(")") {
+		p.errorf(p.tok.Pos, "expected ')' after the field of %s, found %s", agg.Function, describe(p.tok))
+		return agg
+	}
+	p.next()
+
+	if p.isKeyword("by") {
+		p.next()
+		if p.tok.Kind != TokenWord {
+			p.errorf(p.tok.Pos, "expected a field after 'by', found %s", describe(p.tok))
+			return agg
+		}
+		agg.GroupBy = p.tok.Text
+		p.next()
+	}
+
+	if p.tok.Kind == TokenOp {
+		agg.Op = p.tok.Text
+		p.next()
+		if _, err := strconv.ParseFloat(p.tok.Text, 64); p.tok.Kind != TokenWord || err != nil {
+			p.errorf(p.tok.Pos, "expected a number after '%s', found %s", agg.Op, describe(p.tok))
+			return agg
+		}
+		agg.Value = p.tok.Text
+		p.next()
+	}
+	return agg
+}
+
+// describe names a token for error messages
+func describe(tok Token) string {
+	if tok.Kind == TokenEOF {
+		return "end of input"
+	}
+	return "'" + tok.Text + "'"
+}
+
+// sortErrors orders errors by source position
+func sortErrors(errs []*Error) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Pos.Offset < errs[j].Pos.Offset
+	})
+}
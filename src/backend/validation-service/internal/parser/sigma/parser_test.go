@@ -0,0 +1,137 @@
+package sigma
+
+import (
+	"strings"
+	"testing"
+)
+
+// describeRefs renders the references of a condition for comparison
+func describeRefs(cond *Condition) string {
+	var refs []string
+	for _, ref := range cond.References() {
+		switch r := ref.(type) {
+		case *Identifier:
+			refs = append(refs, r.Name)
+		case *OfExpr:
+			quantifier := "1"
+			if r.All {
+				quantifier = "all"
+			}
+			target := r.Pattern
+			if r.Them {
+				target = "them"
+			}
+			refs = append(refs, quantifier+" of "+target)
+		}
+	}
+	return strings.Join(refs, ",")
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		refs string
+		agg  *Aggregation
+	}{
+		{name: "identifier", src: "selection", refs: "selection"},
+		{name: "filters", src: "selection and not 1 of filter_*", refs: "selection,1 of filter_*"},
+		{name: "grouped", src: "(sel_a or sel_b) and not (filter)", refs: "sel_a,sel_b,filter"},
+		{name: "all of them", src: "all of them", refs: "all of them"},
+		{name: "any of", src: "any of sel*", refs: "1 of sel*"},
+		{
+			name: "count by",
+			src:  "selection | count(User) by Computer > 5",
+			refs: "selection",
+			agg:  &Aggregation{Function: FuncCount, Field: "User", GroupBy: "Computer", Op: ">", Value: "5"},
+		},
+		{
+			name: "count without field",
+			src:  "selection | count() > 10",
+			refs: "selection",
+			agg:  &Aggregation{Function: FuncCount, Op: ">", Value: "10"},
+		},
+		{
+			name: "near",
+			src:  "selection | near dns and not proxy",
+			refs: "selection,dns,proxy",
+			agg:  &Aggregation{Function: FuncNear},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, errs := Parse(tt.src)
+			if len(errs) > 0 {
+				t.Fatalf("Parse() errors = %v", errs)
+			}
+			if got := describeRefs(cond); got != tt.refs {
+				t.Errorf("references = %s, want %s", got, tt.refs)
+			}
+			agg := cond.Aggregation
+			if tt.agg == nil {
+				if agg != nil {
+					t.Errorf("aggregation = %+v, want none", agg)
+				}
+				return
+			}
+			if agg == nil || agg.Function != tt.agg.Function || agg.Field != tt.agg.Field ||
+				agg.GroupBy != tt.agg.GroupBy || agg.Op != tt.agg.Op || agg.Value != tt.agg.Value {
+				t.Errorf("aggregation = %+v, want %+v", agg, tt.agg)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		want   string
+		column int
+	}{
+		{"empty", "", "empty condition", 1},
+		{"missing operator", "selection filter", "expected 'and' or 'or'", 11},
+		{"unclosed paren", "(selection or filter", "expected ')' to close '('", 21},
+		{"bad quantifier", "2 of selection*", "expected 1, any or all before 'of'", 1},
+		{"of without pattern", "1 of", "expected a search identifier pattern or 'them'", 5},
+		{"unknown function", "selection | median(x)", "unknown aggregation function 'median'", 13},
+		{"sum without field", "selection | sum() > 5", "sum needs a field", 17},
+		{"non-numeric threshold", "selection | count() > many", "expected a number after '>'", 23},
+		{"nested too deep", strings.Repeat("(", 200) + "a" + strings.Repeat(")", 200), "maximum depth", 101},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := Parse(tt.src)
+			if len(errs) == 0 {
+				t.Fatalf("Parse() returned no errors, want %q", tt.want)
+			}
+			if !strings.Contains(errs[0].Msg, tt.want) || errs[0].Pos.Column != tt.column {
+				t.Errorf("first error = %v, want %q at column %d", errs[0], tt.want, tt.column)
+			}
+		})
+	}
+}
+
+func TestOfExprSelects(t *testing.T) {
+	tests := []struct {
+		expr *OfExpr
+		name string
+		want bool
+	}{
+		{&OfExpr{Pattern: "filter_*"}, "filter_admin", true},
+		{&OfExpr{Pattern: "filter_*"}, "selection", false},
+		{&OfExpr{Pattern: "sel*_win*"}, "sel_a_windows", true},
+		{&OfExpr{Pattern: "sel*_win*"}, "sel_a_linux", false},
+		{&OfExpr{Pattern: "selection"}, "selection", true},
+		{&OfExpr{Them: true}, "selection", true},
+		{&OfExpr{Them: true}, "_internal", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.expr.Selects(tt.name); got != tt.want {
+			t.Errorf("%+v.Selects(%q) = %v, want %v", tt.expr, tt.name, got, tt.want)
+		}
+	}
+}
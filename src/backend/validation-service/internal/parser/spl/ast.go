@@ -0,0 +1,335 @@
+// Package spl provides a tokenizer and recursive-descent parser for Splunk
+// SPL searches, producing an AST with line/column positions for validation.
+package spl
+
+// Position identifies a location in the parsed source
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// IsValid reports whether the position was set by the parser
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// Node is implemented by all AST nodes
+type Node interface {
+	Pos() Position
+}
+
+// Pipeline is a sequence of commands joined by pipes: a whole search or the
+// body of a subsearch
+type Pipeline struct {
+	Position Position
+	Commands []*Command
+	End      Position
+}
+
+// Pos implements Node
+func (p *Pipeline) Pos() Position { return p.Position }
+
+// Command is one stage of a pipeline
+type Command struct {
+	Position Position
+	// Name is the lower-cased command name; empty when the stage is a macro
+	Name    string
+	NamePos Position
+	// Implicit is set for a leading search whose `search` keyword is omitted
+	Implicit bool
+	// Generating is set for a leading command introduced by a pipe, such as
+	// `| tstats`
+	Generating bool
+	// Macro is set when the whole stage is a macro
+	Macro *Macro
+	// Args are the arguments in source order
+	Args []Arg
+	// Assignments are the assignments of eval
+	Assignments []*Assignment
+	// Expr is the predicate of where
+	Expr Expr
+	// Aggregations, By and Where describe stats-family commands and tstats
+	Aggregations []*Aggregation
+	By           []*Term
+	Where        []Arg
+	// Lookup describes the lookup command
+	Lookup *Lookup
+	// End is the position after the last argument
+	End Position
+}
+
+// Pos implements Node
+func (c *Command) Pos() Position { return c.Position }
+
+// Option returns the value of the first key=value argument named key
+func (c *Command) Option(key string) (*Option, bool) {
+	for _, arg := range c.Args {
+		if o, ok := arg.(*Option); ok && equalFold(o.Key, key) {
+			return o, true
+		}
+	}
+	return nil, false
+}
+
+// Arg is implemented by command argument nodes
+type Arg interface {
+	Node
+	argNode()
+}
+
+// Term is a bare word or quoted string such as `error`, `"a | b"` or `AND`
+type Term struct {
+	Position Position
+	Text     string
+	Quoted   bool
+}
+
+// Option is a `key=value` or `key!=value` argument; Op is one of
+// =, ==, !=, <, <=, >, >=
+type Option struct {
+	Position Position
+	Key      string
+	Op       string
+	Value    Arg
+}
+
+// Group is a parenthesized argument list
+type Group struct {
+	Position Position
+	Args     []Arg
+	// End is the position after the closing parenthesis
+	End Position
+}
+
+// Subsearch is a bracketed `[ ... ]` search
+type Subsearch struct {
+	Position Position
+	Pipeline *Pipeline
+}
+
+// Macro is a search macro such as `name` or `name(arg1, arg2)`
+type Macro struct {
+	Position Position
+	Name     string
+	Args     []string
+}
+
+// Pos implementations
+func (a *Term) Pos() Position      { return a.Position }
+func (a *Option) Pos() Position    { return a.Position }
+func (a *Group) Pos() Position     { return a.Position }
+func (a *Subsearch) Pos() Position { return a.Position }
+func (a *Macro) Pos() Position     { return a.Position }
+
+func (*Term) argNode()      {}
+func (*Option) argNode()    {}
+func (*Group) argNode()     {}
+func (*Subsearch) argNode() {}
+func (*Macro) argNode()     {}
+
+// Assignment is a `field = expression` clause of eval
+type Assignment struct {
+	Position Position
+	Field    string
+	Expr     Expr
+}
+
+// Aggregation is a statistical function such as `count`, `dc(user) AS users`
+// or `count(eval(status>=500))`
+type Aggregation struct {
+	Position Position
+	Func     string
+	Args     []Expr
+	Alias    string
+}
+
+// Lookup describes `lookup <table> <field> [AS <field>] OUTPUT <field>...`
+type Lookup struct {
+	Position Position
+	Table    string
+	Fields   []*LookupField
+	// OutputMode is OUTPUT, OUTPUTNEW or empty when every field is returned
+	OutputMode string
+	Outputs    []*LookupField
+}
+
+// LookupField is a lookup field optionally renamed with AS
+type LookupField struct {
+	Position Position
+	Name     string
+	As       string
+}
+
+// Expr is implemented by eval expression nodes
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// LiteralKind distinguishes literal expression values
+type LiteralKind int
+
+// Literal kinds
+const (
+	LiteralNumber LiteralKind = iota
+	LiteralString
+	LiteralBool
+	LiteralNull
+)
+
+// Literal is a number, string, boolean or null literal
+type Literal struct {
+	Position Position
+	Kind     LiteralKind
+	Value    string
+}
+
+// FieldRef is a field name, bare or single-quoted
+type FieldRef struct {
+	Position Position
+	Name     string
+}
+
+// Call is a function call such as `lower(user)`
+type Call struct {
+	Position Position
+	// Name is the lower-cased function name
+	Name string
+	Args []Expr
+}
+
+// UnaryExpr is `NOT x` or `-x`
+type UnaryExpr struct {
+	Position Position
+	Op       string
+	X        Expr
+}
+
+// BinaryExpr is an infix operation. Op is upper-cased for the keyword
+// operators AND, OR, XOR and LIKE.
+type BinaryExpr struct {
+	Position Position
+	Op       string
+	Left     Expr
+	Right    Expr
+}
+
+// InExpr is `x IN (a, b, c)`
+type InExpr struct {
+	Position Position
+	X        Expr
+	List     []Expr
+}
+
+// MacroExpr is a macro used inside an expression
+type MacroExpr struct {
+	Position Position
+	Macro    *Macro
+}
+
+// Pos implementations
+func (e *Literal) Pos() Position    { return e.Position }
+func (e *FieldRef) Pos() Position   { return e.Position }
+func (e *Call) Pos() Position       { return e.Position }
+func (e *UnaryExpr) Pos() Position  { return e.Position }
+func (e *BinaryExpr) Pos() Position { return e.Position }
+func (e *InExpr) Pos() Position     { return e.Position }
+func (e *MacroExpr) Pos() Position  { return e.Position }
+
+func (*Literal) exprNode()    {}
+func (*FieldRef) exprNode()   {}
+func (*Call) exprNode()       {}
+func (*UnaryExpr) exprNode()  {}
+func (*BinaryExpr) exprNode() {}
+func (*InExpr) exprNode()     {}
+func (*MacroExpr) exprNode()  {}
+
+// WalkExpr traverses an expression tree depth-first, calling fn for each
+// node. Traversal of a subtree stops when fn returns false.
+func WalkExpr(e Expr, fn func(Expr) bool) {
+	if e == nil || !fn(e) {
+		return
+	}
+	switch n := e.(type) {
+	case *Call:
+		for _, arg := range n.Args {
+			WalkExpr(arg, fn)
+		}
+	case *UnaryExpr:
+		WalkExpr(n.X, fn)
+	case *BinaryExpr:
+		WalkExpr(n.Left, fn)
+		WalkExpr(n.Right, fn)
+	case *InExpr:
+		WalkExpr(n.X, fn)
+		for _, el := range n.List {
+			WalkExpr(el, fn)
+		}
+	}
+}
+
+// Inspect calls fn for every command of the pipeline in source order,
+// descending into subsearches. Subsearches of a command are not visited
+// when fn returns false for it.
+func Inspect(p *Pipeline, fn func(*Command) bool) {
+	if p == nil {
+		return
+	}
+	for _, cmd := range p.Commands {
+		if !fn(cmd) {
+			continue
+		}
+		inspectArgs(cmd.Args, fn)
+		inspectArgs(cmd.Where, fn)
+	}
+}
+
+func inspectArgs(args []Arg, fn func(*Command) bool) {
+	for _, arg := range args {
+		switch a := arg.(type) {
+		case *Subsearch:
+			Inspect(a.Pipeline, fn)
+		case *Group:
+			inspectArgs(a.Args, fn)
+		case *Option:
+			inspectArgs([]Arg{a.Value}, fn)
+		}
+	}
+}
+
+// Exprs returns the expressions of a command: eval assignments, the where
+// predicate and aggregation arguments
+func (c *Command) Exprs() []Expr {
+	var exprs []Expr
+	for _, a := range c.Assignments {
+		exprs = append(exprs, a.Expr)
+	}
+	if c.Expr != nil {
+		exprs = append(exprs, c.Expr)
+	}
+	for _, agg := range c.Aggregations {
+		exprs = append(exprs, agg.Args...)
+	}
+	return exprs
+}
+
+// equalFold reports whether ASCII strings a and b are equal ignoring case
+func equalFold(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		if lower(a[i]) != lower(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func lower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -0,0 +1,308 @@
+package spl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TokenKind identifies the lexical class of a token
+type TokenKind int
+
+// Token kinds produced by the lexer
+const (
+	TokenEOF TokenKind = iota
+	TokenWord
+	TokenString
+	TokenField  // 'field name', expression mode only
+	TokenNumber // expression mode only
+	TokenMacro
+	TokenOp
+	TokenPunct // | [ ] ( ) ,
+	TokenIllegal
+)
+
+// Token is a single lexical token
+type Token struct {
+	Kind TokenKind
+	Text string
+	Pos  Position
+}
+
+// Error is a positioned lexing or parsing error
+type Error struct {
+	Pos Position
+	Msg string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Pos.Line, e.Pos.Column, e.Msg)
+}
+
+// lexer converts SPL source into tokens. Search arguments and eval
+// expressions tokenize differently, so the parser switches the lexer into
+// expression mode for eval, where and eval() aggregations.
+type lexer struct {
+	src    string
+	offset int
+	line   int
+	column int
+	errs   []*Error
+	// expr selects expression mode
+	expr bool
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, column: 1}
+}
+
+func (l *lexer) pos() Position {
+	return Position{Offset: l.offset, Line: l.line, Column: l.column}
+}
+
+func (l *lexer) errorf(pos Position, format string, args ...interface{}) {
+	l.errs = append(l.errs, &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (l *lexer) peekByte(n int) byte {
+	if l.offset+n >= len(l.src) {
+		return 0
+	}
+	return l.src[l.offset+n]
+}
+
+func (l *lexer) advance() byte {
+	c := l.src[l.offset]
+	l.offset++
+	if c == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+	return c
+}
+
+// skipSpaceAndComments skips whitespace and ```triple backtick``` comments
+func (l *lexer) skipSpaceAndComments() {
+	for l.offset < len(l.src) {
+		c := l.src[l.offset]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			l.advance()
+		case strings.HasPrefix(l.src[l.offset:], "```"):
+			start := l.pos()
+			length := len(l.src) - l.offset
+			if end := strings.Index(l.src[l.offset+3:], "```"); end >= 0 {
+				length = end + 6
+			} else {
+				l.errorf(start, "unterminated comment")
+			}
+			for ; length > 0; length-- {
+				l.advance()
+			}
+		default:
+			return
+		}
+	}
+}
+
+// peekChar returns the next significant character without consuming it
+func (l *lexer) peekChar() byte {
+	l.skipSpaceAndComments()
+	return l.peekByte(0)
+}
+
+// next scans the next token in the current mode
+func (l *lexer) next() Token {
+	l.skipSpaceAndComments()
+	start := l.pos()
+	if l.offset >= len(l.src) {
+		return Token{Kind: TokenEOF, Pos: start}
+	}
+
+	c := l.src[l.offset]
+	switch {
+	case c == '|' || c == '[' || c == ']' || c == '(' || c == ')' || c == ',':
+		l.advance()
+		return Token{Kind: TokenPunct, Text: string(c), Pos: start}
+	case c == '"':
+		return l.scanString(start, '"', TokenString)
+	case c == '`':
+		return l.scanMacro(start)
+	case c == '<' && l.peekByte(1) == '<':
+		// foreach templates such as <<FIELD>> are names in both modes
+		if end := strings.Index(l.src[l.offset:], ">>"); end > 2 && isTemplateName(l.src[l.offset+2:l.offset+end]) {
+			text := l.src[l.offset : l.offset+end+2]
+			for range text {
+				l.advance()
+			}
+			return Token{Kind: TokenWord, Text: text, Pos: start}
+		}
+	}
+
+	if l.expr {
+		return l.nextExpr(start)
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "=", "<", ">"} {
+		if strings.HasPrefix(l.src[l.offset:], op) {
+			for range op {
+				l.advance()
+			}
+			return Token{Kind: TokenOp, Text: op, Pos: start}
+		}
+	}
+	return Token{Kind: TokenWord, Text: l.scanWord(), Pos: start}
+}
+
+// nextExpr scans an eval expression token
+func (l *lexer) nextExpr(start Position) Token {
+	c := l.src[l.offset]
+	switch {
+	case isIdentStart(c):
+		return Token{Kind: TokenWord, Text: l.scanWhile(isIdentChar), Pos: start}
+	case isDigit(c) || (c == '.' && isDigit(l.peekByte(1))):
+		return l.scanNumber(start)
+	case c == '\'':
+		return l.scanString(start, '\'', TokenField)
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "=", "<", ">", "+", "-", "*", "/", "%", "."} {
+		if strings.HasPrefix(l.src[l.offset:], op) {
+			for range op {
+				l.advance()
+			}
+			return Token{Kind: TokenOp, Text: op, Pos: start}
+		}
+	}
+
+	l.advance()
+	l.errorf(start, "unexpected character %q in expression", c)
+	return Token{Kind: TokenIllegal, Text: string(c), Pos: start}
+}
+
+func (l *lexer) scanWhile(pred func(byte) bool) string {
+	begin := l.offset
+	for l.offset < len(l.src) && pred(l.src[l.offset]) {
+		l.advance()
+	}
+	return l.src[begin:l.offset]
+}
+
+// scanWord scans a search-mode word, which ends before whitespace,
+// punctuation, quotes and comparison operators
+func (l *lexer) scanWord() string {
+	begin := l.offset
+	for l.offset < len(l.src) && isWordChar(l.src[l.offset]) {
+		if l.src[l.offset] == '!' && l.peekByte(1) == '=' {
+			break
+		}
+		l.advance()
+	}
+	return l.src[begin:l.offset]
+}
+
+func (l *lexer) scanNumber(start Position) Token {
+	begin := l.offset
+	l.scanWhile(isDigit)
+	if l.peekByte(0) == '.' && isDigit(l.peekByte(1)) {
+		l.advance()
+		l.scanWhile(isDigit)
+	}
+	if (l.peekByte(0) == 'e' || l.peekByte(0) == 'E') && (isDigit(l.peekByte(1)) ||
+		((l.peekByte(1) == '-' || l.peekByte(1) == '+') && isDigit(l.peekByte(2)))) {
+		l.advance()
+		l.advance()
+		l.scanWhile(isDigit)
+	}
+	return Token{Kind: TokenNumber, Text: l.src[begin:l.offset], Pos: start}
+}
+
+// scanString scans a string delimited by quote. Backslash escapes the quote
+// and itself; other escapes are kept verbatim for rex and regex patterns.
+func (l *lexer) scanString(start Position, quote byte, kind TokenKind) Token {
+	l.advance() // opening quote
+	var b strings.Builder
+	for l.offset < len(l.src) {
+		c := l.advance()
+		switch c {
+		case quote:
+			return Token{Kind: kind, Text: b.String(), Pos: start}
+		case '\\':
+			if l.offset >= len(l.src) {
+				b.WriteByte(c)
+				continue
+			}
+			if esc := l.peekByte(0); esc == quote || esc == '\\' {
+				b.WriteByte(l.advance())
+				continue
+			}
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if kind == TokenField {
+		l.errorf(start, "unterminated quoted field name")
+	} else {
+		l.errorf(start, "unterminated string literal")
+	}
+	return Token{Kind: kind, Text: b.String(), Pos: start}
+}
+
+// scanMacro scans a `macro` or `macro(args)` reference
+func (l *lexer) scanMacro(start Position) Token {
+	l.advance() // opening backtick
+	begin := l.offset
+	for l.offset < len(l.src) {
+		switch l.src[l.offset] {
+		case '`':
+			text := l.src[begin:l.offset]
+			l.advance()
+			if strings.TrimSpace(text) == "" {
+				l.errorf(start, "empty macro reference")
+			}
+			return Token{Kind: TokenMacro, Text: text, Pos: start}
+		case '"':
+			l.scanString(l.pos(), '"', TokenString)
+			continue
+		case '\n':
+			l.errorf(start, "unterminated macro reference")
+			return Token{Kind: TokenMacro, Text: l.src[begin:l.offset], Pos: start}
+		}
+		l.advance()
+	}
+	l.errorf(start, "unterminated macro reference")
+	return Token{Kind: TokenMacro, Text: l.src[begin:], Pos: start}
+}
+
+// isWordChar reports whether c continues a search-mode word
+func isWordChar(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', '|', '[', ']', '(', ')', '"', '`', ',', '=', '<', '>':
+		return false
+	}
+	return true
+}
+
+func isTemplateName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isIdentChar(s[i]) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,795 @@
+package spl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxErrors bounds the number of errors collected before parsing stops
+const maxErrors = 50
+
+// maxDepth bounds the nesting of groups, subsearches and expressions. Deeper
+// input stops the parse with an error instead of recursing further.
+const maxDepth = 100
+
+// exprCommands are the commands whose arguments are eval expressions
+var exprCommands = map[string]bool{
+	"eval": true, "where": true,
+}
+
+// statsCommands are the commands whose arguments are aggregations
+var statsCommands = map[string]bool{
+	"stats": true, "eventstats": true, "streamstats": true, "chart": true,
+	"timechart": true, "tstats": true, "mstats": true, "sistats": true,
+	"sichart": true, "sitimechart": true, "geostats": true,
+}
+
+// subpipelineCommands take a bracketed pipeline whose first stage is a
+// command rather than a search
+var subpipelineCommands = map[string]bool{
+	"appendpipe": true, "foreach": true,
+}
+
+// parser is a recursive-descent parser over the lexer token stream. The
+// lexer is always positioned immediately after the current token, so the
+// parser can switch it between search and expression mode between tokens.
+type parser struct {
+	lex  *lexer
+	tok  Token
+	errs []*Error
+	// depth is the current nesting; stopped is set once it exceeded maxDepth
+	depth   int
+	stopped bool
+}
+
+// Parse parses an SPL search. It always returns a Pipeline containing every
+// command that could be parsed, together with any errors encountered.
+func Parse(src string) (*Pipeline, []*Error) {
+	p := &parser{lex: newLexer(src)}
+	p.next()
+	pipeline := p.parsePipeline(false)
+	for p.tok.Kind != TokenEOF && len(p.errs) < maxErrors {
+		// Only an unmatched ']' stops a top-level pipeline early
+		p.errorf(p.tok.Pos, "unexpected %s", describe(p.tok))
+		p.next()
+		resume := p.isPunct("|")
+		if resume {
+			p.next()
+		}
+		rest := p.parsePipeline(resume)
+		pipeline.Commands = append(pipeline.Commands, rest.Commands...)
+	}
+
+	errs := append(p.lex.errs, p.errs...)
+	sortErrors(errs)
+	return pipeline, errs
+}
+
+func (p *parser) next() {
+	p.tok = p.lex.next()
+}
+
+func (p *parser) errorf(pos Position, format string, args ...interface{}) {
+	if len(p.errs) >= maxErrors || p.stopped {
+		return
+	}
+	p.errs = append(p.errs, &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+// enter descends one nesting level. Past maxDepth it reports the excess and
+// moves the lexer to the end of input, so every caller unwinds without
+// recursing further. Each call is paired with a deferred leave.
+func (p *parser) enter() bool {
+	p.depth++
+	if p.depth <= maxDepth {
+		return true
+	}
+	p.errorf(p.tok.Pos, "nesting exceeds the maximum depth of %d", maxDepth)
+	p.stopped = true
+	p.lex.offset = len(p.lex.src)
+	p.tok = Token{Kind: TokenEOF, Pos: p.tok.Pos}
+	return false
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) isPunct(text string) bool {
+	return p.tok.Kind == TokenPunct && p.tok.Text == text
+}
+
+func (p *parser) isOp(text string) bool {
+	return p.tok.Kind == TokenOp && p.tok.Text == text
+}
+
+// isWord reports whether the current token is the bare word text, ignoring
+// case
+func (p *parser) isWord(text string) bool {
+	return p.tok.Kind == TokenWord && strings.EqualFold(p.tok.Text, text)
+}
+
+// atCommandEnd reports whether the current token ends a command
+func (p *parser) atCommandEnd() bool {
+	return p.tok.Kind == TokenEOF || p.isPunct("|") || p.isPunct("]")
+}
+
+// parsePipeline parses commands until the end of input or a closing bracket.
+// The first stage of a pipeline is a search unless it starts with a pipe or
+// firstIsCommand is set.
+func (p *parser) parsePipeline(firstIsCommand bool) *Pipeline {
+	pipeline := &Pipeline{Position: p.tok.Pos}
+	first := true
+	for len(p.errs) < maxErrors {
+		var cmd *Command
+		switch {
+		case first && p.isPunct("|"):
+			pipePos := p.tok.Pos
+			p.next()
+			if p.atCommandEnd() {
+				p.errorf(pipePos, "expected command after '|'")
+				break
+			}
+			cmd = p.parseCommand()
+			cmd.Generating = true
+		case first && p.atCommandEnd():
+			p.errorf(p.tok.Pos, "empty search")
+		case first && !firstIsCommand && !p.isWord("search"):
+			cmd = &Command{Position: p.tok.Pos, Name: "search", NamePos: p.tok.Pos, Implicit: true}
+			cmd.Args = p.parseArgs()
+			cmd.End = p.tok.Pos
+		default:
+			cmd = p.parseCommand()
+		}
+		if cmd != nil {
+			pipeline.Commands = append(pipeline.Commands, cmd)
+		}
+		first = false
+
+		if !p.isPunct("|") {
+			break
+		}
+		for p.isPunct("|") {
+			pipePos := p.tok.Pos
+			p.next()
+			if p.atCommandEnd() {
+				p.errorf(pipePos, "expected command after '|'")
+			}
+		}
+		if p.atCommandEnd() {
+			break
+		}
+	}
+	pipeline.End = p.tok.Pos
+	return pipeline
+}
+
+// parseCommand parses a named command or a macro stage
+func (p *parser) parseCommand() *Command {
+	cmd := &Command{Position: p.tok.Pos}
+	switch p.tok.Kind {
+	case TokenMacro:
+		cmd.Macro = p.parseMacro(p.tok)
+		p.next()
+		cmd.Args = p.parseArgs()
+		cmd.End = p.tok.Pos
+		return cmd
+	case TokenWord:
+		cmd.Name = strings.ToLower(p.tok.Text)
+		cmd.NamePos = p.tok.Pos
+	default:
+		p.errorf(p.tok.Pos, "expected command name, found %s", describe(p.tok))
+		cmd.Args = p.parseArgs()
+		cmd.End = p.tok.Pos
+		return cmd
+	}
+
+	switch {
+	case exprCommands[cmd.Name]:
+		errCount := len(p.errs)
+		p.lex.expr = true
+		p.next()
+		if cmd.Name == "eval" {
+			cmd.Assignments = p.parseAssignments(cmd)
+		} else if p.atCommandEnd() {
+			p.errorf(cmd.NamePos, "%s requires an expression", cmd.Name)
+		} else {
+			cmd.Expr = p.parseExpr()
+		}
+		p.lex.expr = false
+		p.skipToCommandEnd(cmd.Name, len(p.errs) == errCount)
+	case statsCommands[cmd.Name]:
+		p.next()
+		p.parseStats(cmd)
+	case subpipelineCommands[cmd.Name]:
+		p.next()
+		cmd.Args = p.parseArgsWith(true)
+	default:
+		p.next()
+		cmd.Args = p.parseArgs()
+		if cmd.Name == "lookup" {
+			cmd.Lookup = p.interpretLookup(cmd)
+		}
+	}
+	cmd.End = p.tok.Pos
+	return cmd
+}
+
+// skipToCommandEnd skips tokens left over after an expression, reporting
+// them unless the expression already had an error
+func (p *parser) skipToCommandEnd(name string, report bool) {
+	if p.atCommandEnd() {
+		return
+	}
+	if report {
+		p.errorf(p.tok.Pos, "unexpected %s in %s expression", describe(p.tok), name)
+	}
+	depth := 0
+	for p.tok.Kind != TokenEOF {
+		switch {
+		case p.isPunct("["):
+			depth++
+		case p.isPunct("]"):
+			if depth == 0 {
+				return
+			}
+			depth--
+		case p.isPunct("|") && depth == 0:
+			return
+		}
+		p.next()
+	}
+}
+
+// parseArgs parses generic command arguments up to the end of the command
+func (p *parser) parseArgs() []Arg {
+	return p.parseArgsWith(false)
+}
+
+// parseArgsWith parses generic arguments; subpipeline selects whether
+// bracketed arguments start with a command instead of a search
+func (p *parser) parseArgsWith(subpipeline bool) []Arg {
+	var args []Arg
+	for !p.atCommandEnd() && len(p.errs) < maxErrors {
+		if p.isPunct(",") {
+			p.next()
+			continue
+		}
+		if p.isPunct(")") {
+			p.errorf(p.tok.Pos, "unexpected ')' without matching '('")
+			p.next()
+			continue
+		}
+		if arg := p.parseArg(subpipeline); arg != nil {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+// parseArg parses one argument: a term, a key=value option, a parenthesized
+// group, a subsearch or a macro
+func (p *parser) parseArg(subpipeline bool) Arg {
+	tok := p.tok
+	switch {
+	case tok.Kind == TokenWord:
+		p.next()
+		if p.tok.Kind == TokenOp {
+			op := p.tok
+			p.next()
+			opt := &Option{Position: tok.Pos, Key: tok.Text, Op: op.Text}
+			if p.atCommandEnd() || p.isPunct(")") || p.isPunct(",") {
+				p.errorf(op.Pos, "missing value after %s%s", tok.Text, op.Text)
+				return opt
+			}
+			opt.Value = p.parseValue(subpipeline)
+			return opt
+		}
+		return &Term{Position: tok.Pos, Text: tok.Text}
+	case tok.Kind == TokenString:
+		p.next()
+		return &Term{Position: tok.Pos, Text: tok.Text, Quoted: true}
+	case tok.Kind == TokenOp:
+		// A comparison without a field name, such as `> 5`
+		p.next()
+		return &Term{Position: tok.Pos, Text: tok.Text}
+	}
+	return p.parseValue(subpipeline)
+}
+
+// parseValue parses the value of an option or a standalone compound argument
+func (p *parser) parseValue(subpipeline bool) Arg {
+	tok := p.tok
+	switch {
+	case tok.Kind == TokenWord || tok.Kind == TokenOp:
+		p.next()
+		return &Term{Position: tok.Pos, Text: tok.Text}
+	case tok.Kind == TokenString:
+		p.next()
+		return &Term{Position: tok.Pos, Text: tok.Text, Quoted: true}
+	case tok.Kind == TokenMacro:
+		p.next()
+		return p.parseMacro(tok)
+	case p.isPunct("("):
+		return p.parseGroup()
+	case p.isPunct("["):
+		return p.parseSubsearch(subpipeline)
+	}
+	p.errorf(tok.Pos, "unexpected %s", describe(tok))
+	p.next()
+	return nil
+}
+
+// parseGroup parses a parenthesized argument list
+func (p *parser) parseGroup() *Group {
+	group := &Group{Position: p.tok.Pos}
+	defer p.leave()
+	if !p.enter() {
+		return group
+	}
+	p.next()
+	for !p.isPunct(")") && !p.atCommandEnd() && len(p.errs) < maxErrors {
+		if p.isPunct(",") {
+			p.next()
+			continue
+		}
+		if arg := p.parseArg(false); arg != nil {
+			group.Args = append(group.Args, arg)
+		}
+	}
+	if !p.isPunct(")") {
+		p.errorf(group.Position, "unclosed '(' opened at line %d, column %d", group.Position.Line, group.Position.Column)
+		group.End = p.tok.Pos
+		return group
+	}
+	p.next()
+	group.End = p.tok.Pos
+	return group
+}
+
+// parseSubsearch parses a bracketed subsearch. The lexer mode is restored
+// afterwards so a subsearch may appear inside any command.
+func (p *parser) parseSubsearch(firstIsCommand bool) *Subsearch {
+	sub := &Subsearch{Position: p.tok.Pos, Pipeline: &Pipeline{Position: p.tok.Pos}}
+	defer p.leave()
+	if !p.enter() {
+		return sub
+	}
+	mode := p.lex.expr
+	p.lex.expr = false
+	p.next()
+	sub.Pipeline = p.parsePipeline(firstIsCommand)
+	p.lex.expr = mode
+	if !p.isPunct("]") {
+		p.errorf(sub.Position, "unclosed subsearch '[' opened at line %d, column %d", sub.Position.Line, sub.Position.Column)
+		return sub
+	}
+	p.next()
+	return sub
+}
+
+// parseMacro splits a macro token into its name and arguments
+func (p *parser) parseMacro(tok Token) *Macro {
+	macro := &Macro{Position: tok.Pos}
+	text := strings.TrimSpace(tok.Text)
+	open := strings.IndexByte(text, '(')
+	if open < 0 {
+		macro.Name = text
+		return macro
+	}
+	macro.Name = strings.TrimSpace(text[:open])
+	if !strings.HasSuffix(text, ")") {
+		p.errorf(tok.Pos, "macro %q has unbalanced parentheses", macro.Name)
+		return macro
+	}
+	inner := text[open+1 : len(text)-1]
+	if strings.TrimSpace(inner) == "" {
+		return macro
+	}
+	depth, quoted, begin := 0, false, 0
+	for i := 0; i < len(inner); i++ {
+		switch c := inner[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			macro.Args = append(macro.Args, strings.TrimSpace(inner[begin:i]))
+			begin = i + 1
+		}
+	}
+	macro.Args = append(macro.Args, strings.TrimSpace(inner[begin:]))
+	if macro.Name == "" {
+		p.errorf(tok.Pos, "macro reference has no name")
+	}
+	return macro
+}
+
+// parseAssignments parses the comma-separated `field = expression` clauses
+// of eval
+func (p *parser) parseAssignments(cmd *Command) []*Assignment {
+	var assignments []*Assignment
+	if p.atCommandEnd() {
+		p.errorf(cmd.NamePos, "eval requires at least one assignment")
+		return nil
+	}
+	for !p.atCommandEnd() && len(p.errs) < maxErrors {
+		field := p.tok
+		if field.Kind != TokenWord && field.Kind != TokenField && field.Kind != TokenString {
+			p.errorf(field.Pos, "expected field name in eval, found %s", describe(field))
+			return assignments
+		}
+		p.next()
+		if !p.isOp("=") {
+			p.errorf(p.tok.Pos, "expected '=' after eval field %q, found %s", field.Text, describe(p.tok))
+			return assignments
+		}
+		p.next()
+		if p.atCommandEnd() || p.isPunct(",") {
+			p.errorf(p.tok.Pos, "missing expression for eval field %q", field.Text)
+			return assignments
+		}
+		assignments = append(assignments, &Assignment{Position: field.Pos, Field: field.Text, Expr: p.parseExpr()})
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	return assignments
+}
+
+// parseStats parses the aggregations, options, where clause and by clause
+// of a stats-family command
+func (p *parser) parseStats(cmd *Command) {
+	var clause string
+	for !p.atCommandEnd() && len(p.errs) < maxErrors {
+		tok := p.tok
+		switch {
+		case p.isPunct(","):
+			p.next()
+		case p.isWord("by") || p.isWord("over"):
+			clause = "by"
+			p.next()
+		case p.isWord("where") && cmd.Name == "tstats":
+			clause = "where"
+			p.next()
+		case p.isWord("as") && clause == "" && len(cmd.Aggregations) > 0:
+			p.next()
+			agg := cmd.Aggregations[len(cmd.Aggregations)-1]
+			if p.tok.Kind != TokenWord && p.tok.Kind != TokenString {
+				p.errorf(p.tok.Pos, "expected alias after AS, found %s", describe(p.tok))
+				continue
+			}
+			agg.Alias = p.tok.Text
+			p.next()
+		case clause == "where":
+			if arg := p.parseArg(false); arg != nil {
+				cmd.Where = append(cmd.Where, arg)
+			}
+		case tok.Kind == TokenWord && p.lex.peekChar() == '=':
+			if arg := p.parseArg(false); arg != nil {
+				cmd.Args = append(cmd.Args, arg)
+			}
+		case tok.Kind == TokenWord && clause == "by":
+			p.next()
+			cmd.By = append(cmd.By, &Term{Position: tok.Pos, Text: tok.Text})
+		case tok.Kind == TokenWord && p.isWord("from") && cmd.Name == "tstats":
+			p.next()
+			cmd.Args = append(cmd.Args, &Term{Position: tok.Pos, Text: tok.Text})
+		case tok.Kind == TokenWord:
+			cmd.Aggregations = append(cmd.Aggregations, p.parseAggregation())
+		default:
+			if arg := p.parseArg(false); arg != nil {
+				cmd.Args = append(cmd.Args, arg)
+			}
+		}
+	}
+	if len(cmd.Aggregations) == 0 && cmd.Name != "tstats" && cmd.Name != "mstats" {
+		p.errorf(cmd.NamePos, "%s requires at least one aggregation", cmd.Name)
+	}
+}
+
+// parseAggregation parses a statistical function such as `count`,
+// `dc(user)` or `count(eval(status>=500))`
+func (p *parser) parseAggregation() *Aggregation {
+	agg := &Aggregation{Position: p.tok.Pos, Func: strings.ToLower(p.tok.Text)}
+	call := p.lex.peekChar() == '('
+	p.next()
+	if !call {
+		return agg
+	}
+	open := p.tok.Pos
+	p.next()
+	for !p.isPunct(")") && !p.atCommandEnd() && len(p.errs) < maxErrors {
+		switch {
+		case p.isPunct(","):
+			p.next()
+		case p.isWord("eval") && p.lex.peekChar() == '(':
+			p.next()
+			p.lex.expr = true
+			p.next()
+			expr := p.parseExpr()
+			p.lex.expr = false
+			if !p.isPunct(")") {
+				p.errorf(p.tok.Pos, "expected ')' to close eval in %s, found %s", agg.Func, describe(p.tok))
+				continue
+			}
+			p.next()
+			agg.Args = append(agg.Args, expr)
+		case p.tok.Kind == TokenWord || p.tok.Kind == TokenString:
+			agg.Args = append(agg.Args, &FieldRef{Position: p.tok.Pos, Name: p.tok.Text})
+			p.next()
+		default:
+			p.errorf(p.tok.Pos, "unexpected %s in %s arguments", describe(p.tok), agg.Func)
+			p.next()
+		}
+	}
+	if !p.isPunct(")") {
+		p.errorf(open, "unclosed '(' in %s opened at line %d, column %d", agg.Func, open.Line, open.Column)
+		return agg
+	}
+	p.next()
+	return agg
+}
+
+// interpretLookup reads the table, input fields and output fields of a
+// lookup command from its generic arguments
+func (p *parser) interpretLookup(cmd *Command) *Lookup {
+	lookup := &Lookup{Position: cmd.Position}
+	fields := &lookup.Fields
+	var last *LookupField
+	for i := 0; i < len(cmd.Args); i++ {
+		term, ok := cmd.Args[i].(*Term)
+		if !ok {
+			continue
+		}
+		switch {
+		case lookup.Table == "":
+			lookup.Table = term.Text
+		case !term.Quoted && (strings.EqualFold(term.Text, "OUTPUT") || strings.EqualFold(term.Text, "OUTPUTNEW")):
+			lookup.OutputMode = strings.ToUpper(term.Text)
+			fields = &lookup.Outputs
+			last = nil
+		case !term.Quoted && strings.EqualFold(term.Text, "AS"):
+			next, ok := nextTerm(cmd.Args, i+1)
+			if last == nil || !ok {
+				p.errorf(term.Position, "AS in lookup must follow a field and precede its new name")
+				continue
+			}
+			last.As = next.Text
+			i++
+		default:
+			last = &LookupField{Position: term.Position, Name: term.Text}
+			*fields = append(*fields, last)
+		}
+	}
+	if lookup.Table == "" {
+		p.errorf(cmd.NamePos, "lookup requires a lookup table name")
+	} else if len(lookup.Fields) == 0 {
+		p.errorf(cmd.NamePos, "lookup %q requires at least one lookup field", lookup.Table)
+	}
+	if lookup.OutputMode != "" && len(lookup.Outputs) == 0 {
+		p.errorf(cmd.NamePos, "%s in lookup %q requires at least one field", lookup.OutputMode, lookup.Table)
+	}
+	return lookup
+}
+
+// nextTerm returns the argument at i when it is a term
+func nextTerm(args []Arg, i int) (*Term, bool) {
+	if i >= len(args) {
+		return nil, false
+	}
+	term, ok := args[i].(*Term)
+	return term, ok
+}
+
+// Expression grammar, lowest precedence first:
+//
+//	or      = and { ("OR" | "XOR") and }
+//	and     = not { "AND" not }
+//	not     = "NOT" not | compare
+//	compare = concat [ ("=" | "==" | "!=" | "<" | "<=" | ">" | ">=" | "LIKE") concat | "IN" "(" list ")" ]
+//	concat  = sum { "." sum }
+//	sum     = product { ("+" | "-") product }
+//	product = unary { ("*" | "/" | "%") unary }
+//	unary   = "-" unary | primary
+//	primary = number | string | 'field' | field | call | macro | "(" or ")"
+func (p *parser) parseExpr() Expr {
+	defer p.leave()
+	if !p.enter() {
+		return &Literal{Position: p.tok.Pos, Kind: LiteralNull, Value: "null"}
+	}
+	x := p.parseAnd()
+	for p.isWord("OR") || p.isWord("XOR") {
+		op := p.tok
+		p.next()
+		x = &BinaryExpr{Position: op.Pos, Op: strings.ToUpper(op.Text), Left: x, Right: p.parseAnd()}
+	}
+	return x
+}
+
+func (p *parser) parseAnd() Expr {
+	x := p.parseNot()
+	for p.isWord("AND") {
+		op := p.tok
+		p.next()
+		x = &BinaryExpr{Position: op.Pos, Op: "AND", Left: x, Right: p.parseNot()}
+	}
+	return x
+}
+
+func (p *parser) parseNot() Expr {
+	if p.isWord("NOT") {
+		pos := p.tok.Pos
+		defer p.leave()
+		if !p.enter() {
+			return &Literal{Position: pos, Kind: LiteralNull, Value: "null"}
+		}
+		p.next()
+		return &UnaryExpr{Position: pos, Op: "NOT", X: p.parseNot()}
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() Expr {
+	x := p.parseBinary(0)
+	switch {
+	case p.tok.Kind == TokenOp && comparisonOps[p.tok.Text], p.isWord("LIKE"):
+		op := p.tok
+		p.next()
+		return &BinaryExpr{Position: op.Pos, Op: strings.ToUpper(op.Text), Left: x, Right: p.parseBinary(0)}
+	case p.isWord("IN"):
+		in := &InExpr{Position: p.tok.Pos, X: x}
+		p.next()
+		if !p.isPunct("(") {
+			p.errorf(p.tok.Pos, "expected '(' after IN, found %s", describe(p.tok))
+			return in
+		}
+		in.List = p.parseExprList()
+		return in
+	}
+	return x
+}
+
+// comparisonOps are the comparison operators of the expression grammar
+var comparisonOps = map[string]bool{
+	"=": true, "==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+}
+
+// binaryLevels are the arithmetic and concatenation operators by precedence
+var binaryLevels = []map[string]bool{
+	{".": true},
+	{"+": true, "-": true},
+	{"*": true, "/": true, "%": true},
+}
+
+// parseBinary parses the operators of binaryLevels from level upwards
+func (p *parser) parseBinary(level int) Expr {
+	if level == len(binaryLevels) {
+		return p.parseUnary()
+	}
+	x := p.parseBinary(level + 1)
+	for p.tok.Kind == TokenOp && binaryLevels[level][p.tok.Text] {
+		op := p.tok
+		p.next()
+		x = &BinaryExpr{Position: op.Pos, Op: op.Text, Left: x, Right: p.parseBinary(level + 1)}
+	}
+	return x
+}
+
+func (p *parser) parseUnary() Expr {
+	if p.isOp("-") || p.isOp("+") {
+		op := p.tok
+		defer p.leave()
+		if !p.enter() {
+			return &Literal{Position: op.Pos, Kind: LiteralNull, Value: "null"}
+		}
+		p.next()
+		return &UnaryExpr{Position: op.Pos, Op: op.Text, X: p.parseUnary()}
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() Expr {
+	tok := p.tok
+	switch tok.Kind {
+	case TokenNumber:
+		p.next()
+		return &Literal{Position: tok.Pos, Kind: LiteralNumber, Value: tok.Text}
+	case TokenString:
+		p.next()
+		return &Literal{Position: tok.Pos, Kind: LiteralString, Value: tok.Text}
+	case TokenField:
+		p.next()
+		return &FieldRef{Position: tok.Pos, Name: tok.Text}
+	case TokenMacro:
+		p.next()
+		return &MacroExpr{Position: tok.Pos, Macro: p.parseMacro(tok)}
+	case TokenWord:
+		p.next()
+		if p.isPunct("(") {
+			return &Call{Position: tok.Pos, Name: strings.ToLower(tok.Text), Args: p.parseExprList()}
+		}
+		switch strings.ToLower(tok.Text) {
+		case "true", "false":
+			return &Literal{Position: tok.Pos, Kind: LiteralBool, Value: strings.ToLower(tok.Text)}
+		case "null":
+			return &Literal{Position: tok.Pos, Kind: LiteralNull, Value: "null"}
+		}
+		return &FieldRef{Position: tok.Pos, Name: tok.Text}
+	case TokenPunct:
+		if tok.Text == "(" {
+			p.next()
+			x := p.parseExpr()
+			if !p.isPunct(")") {
+				p.errorf(p.tok.Pos, "expected ')' to close '(' at line %d, column %d, found %s", tok.Pos.Line, tok.Pos.Column, describe(p.tok))
+				return x
+			}
+			p.next()
+			return x
+		}
+	}
+	p.errorf(tok.Pos, "expected expression, found %s", describe(tok))
+	if !p.atCommandEnd() && !p.isPunct(")") && !p.isPunct(",") {
+		p.next()
+	}
+	return &Literal{Position: tok.Pos, Kind: LiteralNull, Value: "null"}
+}
+
+// parseExprList parses a parenthesized, comma-separated expression list; the
+// current token is the opening parenthesis
+func (p *parser) parseExprList() []Expr {
+	open := p.tok.Pos
+	p.next()
+	var list []Expr
+	if p.isPunct(")") {
+		p.next()
+		return list
+	}
+	for len(p.errs) < maxErrors {
+		list = append(list, p.parseExpr())
+		if p.isPunct(",") {
+			p.next()
+			continue
+		}
+		if p.isPunct(")") {
+			p.next()
+			return list
+		}
+		p.errorf(p.tok.Pos, "expected ',' or ')' in argument list opened at line %d, column %d, found %s", open.Line, open.Column, describe(p.tok))
+		if p.atCommandEnd() {
+			return list
+		}
+		p.next()
+	}
+	return list
+}
+
+// describe names a token for error messages
+func describe(tok Token) string {
+	switch tok.Kind {
+	case TokenEOF:
+		return "end of input"
+	case TokenString:
+		return "string literal"
+	case TokenField:
+		return "quoted field name"
+	case TokenMacro:
+		return "macro `" + tok.Text + "`"
+	default:
+		return "'" + tok.Text + "'"
+	}
+}
+
+// sortErrors orders errors by source position
+func sortErrors(errs []*Error) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Pos.Offset < errs[j].Pos.Offset
+	})
+}
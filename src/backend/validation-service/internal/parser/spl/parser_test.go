@@ -0,0 +1,122 @@
+package spl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `index=main sourcetype=linux_secure "failed password" NOT user=root
+| eval attempts=if(status=="fail", 1, 0), host=lower(host)
+| where attempts > 0
+| stats count AS failures dc(user) AS users BY host, src
+| lookup geo_ip ip AS src OUTPUT country
+| search [ search index=threat_intel | fields src ]`
+
+	pipeline, errs := Parse(src)
+	if len(errs) > 0 {
+		t.Fatalf("Parse() errors = %v", errs)
+	}
+
+	var names []string
+	Inspect(pipeline, func(cmd *Command) bool {
+		names = append(names, cmd.Name)
+		return true
+	})
+	want := "search,eval,where,stats,lookup,search,search,fields"
+	if strings.Join(names, ",") != want {
+		t.Fatalf("commands = %v, want %s", names, want)
+	}
+
+	search := pipeline.Commands[0]
+	if !search.Implicit {
+		t.Error("leading search is not implicit")
+	}
+	if opt, ok := search.Option("SOURCETYPE"); !ok || opt.Value.(*Term).Text != "linux_secure" {
+		t.Errorf("sourcetype option = %+v, %v", opt, ok)
+	}
+
+	eval := pipeline.Commands[1]
+	if len(eval.Assignments) != 2 || eval.Assignments[0].Field != "attempts" || eval.Assignments[1].Field != "host" {
+		t.Errorf("eval assignments = %+v", eval.Assignments)
+	}
+	var fields []string
+	for _, e := range eval.Exprs() {
+		WalkExpr(e, func(e Expr) bool {
+			if ref, ok := e.(*FieldRef); ok {
+				fields = append(fields, ref.Name)
+			}
+			return true
+		})
+	}
+	if strings.Join(fields, ",") != "status,host" {
+		t.Errorf("eval field references = %v, want [status host]", fields)
+	}
+
+	if pipeline.Commands[2].Expr == nil {
+		t.Error("where has no predicate")
+	}
+
+	stats := pipeline.Commands[3]
+	if len(stats.Aggregations) != 2 || stats.Aggregations[0].Alias != "failures" || stats.Aggregations[1].Func != "dc" {
+		t.Errorf("stats aggregations = %+v", stats.Aggregations)
+	}
+	if len(stats.By) != 2 || stats.By[0].Text != "host" || stats.By[1].Text != "src" {
+		t.Errorf("stats by = %+v", stats.By)
+	}
+
+	lookup := pipeline.Commands[4].Lookup
+	if lookup == nil || lookup.Table != "geo_ip" || lookup.OutputMode != "OUTPUT" ||
+		len(lookup.Fields) != 1 || lookup.Fields[0].As != "src" || len(lookup.Outputs) != 1 {
+		t.Errorf("lookup = %+v", lookup)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		want   string
+		column int
+	}{
+		{"empty pipe", "index=main | | stats count", "expected command after '|'", 12},
+		{"unclosed subsearch", "index=main [ search foo", "unclosed subsearch", 12},
+		{"unbalanced paren", "index=main (a OR b", "unclosed '('", 12},
+		{"eval without assignment", "index=main | eval", "eval requires at least one assignment", 14},
+		{"stats without aggregation", "index=main | stats BY host", "stats requires at least one aggregation", 14},
+		{"unterminated string", `index=main "failed`, "unterminated string literal", 12},
+		{"unterminated macro", "`macro", "unterminated macro reference", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := Parse(tt.src)
+			if len(errs) == 0 {
+				t.Fatalf("Parse() returned no errors, want %q", tt.want)
+			}
+			if !strings.Contains(errs[0].Msg, tt.want) || errs[0].Pos.Column != tt.column {
+				t.Errorf("first error = %v, want %q at column %d", errs[0], tt.want, tt.column)
+			}
+		})
+	}
+}
+
+func TestParseDepth(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"groups", "index=main " + strings.Repeat("(", 200) + "a" + strings.Repeat(")", 200)},
+		{"subsearches", "index=main " + strings.Repeat("[ search ", 200) + "a" + strings.Repeat(" ]", 200)},
+		{"expressions", "| makeresults | eval x=" + strings.Repeat("(", 200) + "1" + strings.Repeat(")", 200)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := Parse(tt.src)
+			if len(errs) != 1 || !strings.Contains(errs[0].Msg, "maximum depth of 100") {
+				t.Errorf("Parse() errors = %v, want one depth error", errs)
+			}
+		})
+	}
+}
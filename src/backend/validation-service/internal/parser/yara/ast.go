@@ -0,0 +1,370 @@
+// Package yara provides a lexer and recursive-descent parser for YARA rule
+// files, producing an AST with line/column positions for validation.
+package yara
+
+// Position identifies a location in the parsed source
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// IsValid reports whether the position was set by the parser
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// Node is implemented by all AST nodes
+type Node interface {
+	Pos() Position
+}
+
+// File is the root of a parsed YARA source file
+type File struct {
+	Imports  []*Import
+	Includes []*Include
+	Rules    []*Rule
+}
+
+// Import represents an `import "module"` statement
+type Import struct {
+	Position Position
+	Module   string
+}
+
+// Pos implements Node
+func (i *Import) Pos() Position { return i.Position }
+
+// Include represents an `include "path"` statement
+type Include struct {
+	Position Position
+	Path     string
+}
+
+// Pos implements Node
+func (i *Include) Pos() Position { return i.Position }
+
+// Rule represents a single YARA rule declaration
+type Rule struct {
+	Position     Position
+	Name         string
+	NamePos      Position
+	Modifiers    []string
+	Tags         []string
+	Meta         []*MetaEntry
+	MetaPos      Position
+	Strings      []*StringDef
+	StringsPos   Position
+	Condition    Expr
+	ConditionPos Position
+	End          Position
+}
+
+// Pos implements Node
+func (r *Rule) Pos() Position { return r.Position }
+
+// IsPrivate reports whether the rule is declared private
+func (r *Rule) IsPrivate() bool { return r.hasModifier("private") }
+
+// IsGlobal reports whether the rule is declared global
+func (r *Rule) IsGlobal() bool { return r.hasModifier("global") }
+
+func (r *Rule) hasModifier(name string) bool {
+	for _, m := range r.Modifiers {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
+// MetaEntry is a single `key = value` pair in the meta section
+type MetaEntry struct {
+	Position Position
+	Key      string
+	// Value is a string, int64 or bool
+	Value interface{}
+}
+
+// Pos implements Node
+func (m *MetaEntry) Pos() Position { return m.Position }
+
+// StringKind distinguishes text, hex and regular expression strings
+type StringKind int
+
+// String kinds
+const (
+	StringText StringKind = iota
+	StringHex
+	StringRegex
+)
+
+// String returns the kind name used in issue messages
+func (k StringKind) String() string {
+	switch k {
+	case StringHex:
+		return "hex"
+	case StringRegex:
+		return "regex"
+	default:
+		return "text"
+	}
+}
+
+// StringDef is a string definition such as `$a = "foo" wide ascii`
+type StringDef struct {
+	Position Position
+	ID       string
+	Kind     StringKind
+	// Value is the unquoted text, the hex tokens between braces, or the regex
+	// body between slashes
+	Value string
+	// ValuePos is the position of the opening quote, brace or slash
+	ValuePos Position
+	// RegexFlags holds trailing regex flags (i, s)
+	RegexFlags string
+	Modifiers  []*Modifier
+}
+
+// Pos implements Node
+func (s *StringDef) Pos() Position { return s.Position }
+
+// IsAnonymous reports whether the string uses the anonymous `$` identifier
+func (s *StringDef) IsAnonymous() bool { return s.ID == "$" }
+
+// HasModifier reports whether the string carries the named modifier
+func (s *StringDef) HasModifier(name string) bool {
+	for _, m := range s.Modifiers {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Modifier is a string modifier, optionally with arguments (e.g. xor(1-3))
+type Modifier struct {
+	Position Position
+	Name     string
+	Args     string
+}
+
+// Pos implements Node
+func (m *Modifier) Pos() Position { return m.Position }
+
+// Expr is implemented by all condition expression nodes
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// LiteralKind distinguishes literal expression values
+type LiteralKind int
+
+// Literal kinds
+const (
+	LiteralInt LiteralKind = iota
+	LiteralFloat
+	LiteralString
+	LiteralBool
+	LiteralRegex
+)
+
+// Literal is a number, string, boolean or regex literal
+type Literal struct {
+	Position Position
+	Kind     LiteralKind
+	Raw      string
+}
+
+// Ident is a bare identifier such as a rule name, keyword or module name
+type Ident struct {
+	Position Position
+	Name     string
+}
+
+// StringRef references a string as $a, #a, @a or !a (wildcards allowed)
+type StringRef struct {
+	Position Position
+	// Sigil is one of '$', '#', '@', '!'
+	Sigil byte
+	Name  string
+}
+
+// ID returns the reference in `$name` form
+func (s *StringRef) ID() string { return "$" + s.Name }
+
+// IsWildcard reports whether the reference ends in `*`
+func (s *StringRef) IsWildcard() bool {
+	return len(s.Name) > 0 && s.Name[len(s.Name)-1] == '*'
+}
+
+// UnaryExpr is a prefix operation such as `not x`, `-x`, `~x` or `defined x`
+type UnaryExpr struct {
+	Position Position
+	Op       string
+	X        Expr
+}
+
+// BinaryExpr is an infix operation
+type BinaryExpr struct {
+	Position Position
+	Op       string
+	Left     Expr
+	Right    Expr
+}
+
+// ParenExpr is a parenthesized expression
+type ParenExpr struct {
+	Position Position
+	X        Expr
+}
+
+// MemberExpr is a field access such as `pe.number_of_sections`
+type MemberExpr struct {
+	Position Position
+	X        Expr
+	Name     string
+}
+
+// IndexExpr is an index access such as `pe.sections[0]` or `@a[1]`
+type IndexExpr struct {
+	Position Position
+	X        Expr
+	Index    Expr
+}
+
+// CallExpr is a function call such as `uint16(0)` or `math.entropy(0, 10)`
+type CallExpr struct {
+	Position Position
+	Fun      Expr
+	Args     []Expr
+}
+
+// RangeExpr is a `(low..high)` range
+type RangeExpr struct {
+	Position Position
+	Low      Expr
+	High     Expr
+}
+
+// SetExpr is an explicit tuple such as `($a, $b*)` or `(rule_a, rule_b)`
+type SetExpr struct {
+	Position Position
+	Elems    []Expr
+}
+
+// OfExpr is `<quantifier> of <set> [in <range> | at <expr>]`
+type OfExpr struct {
+	Position   Position
+	Quantifier Expr
+	// Them is set for `of them`
+	Them bool
+	Set  *SetExpr
+	In   Expr
+	At   Expr
+}
+
+// StringMatchExpr is `$a at <expr>` or `$a in <range>`
+type StringMatchExpr struct {
+	Position Position
+	Ref      *StringRef
+	At       Expr
+	In       Expr
+}
+
+// ForExpr is `for <quantifier> <vars> in <iterable> : ( <body> )` or
+// `for <quantifier> of <set> : ( <body> )`
+type ForExpr struct {
+	Position   Position
+	Quantifier Expr
+	Vars       []string
+	Iterable   Expr
+	// Them and Set are used by the `for ... of` form
+	Them bool
+	Set  *SetExpr
+	Body Expr
+}
+
+// Pos implementations
+func (e *Literal) Pos() Position         { return e.Position }
+func (e *Ident) Pos() Position           { return e.Position }
+func (e *StringRef) Pos() Position       { return e.Position }
+func (e *UnaryExpr) Pos() Position       { return e.Position }
+func (e *BinaryExpr) Pos() Position      { return e.Position }
+func (e *ParenExpr) Pos() Position       { return e.Position }
+func (e *MemberExpr) Pos() Position      { return e.Position }
+func (e *IndexExpr) Pos() Position       { return e.Position }
+func (e *CallExpr) Pos() Position        { return e.Position }
+func (e *RangeExpr) Pos() Position       { return e.Position }
+func (e *SetExpr) Pos() Position         { return e.Position }
+func (e *OfExpr) Pos() Position          { return e.Position }
+func (e *StringMatchExpr) Pos() Position { return e.Position }
+func (e *ForExpr) Pos() Position         { return e.Position }
+
+func (*Literal) exprNode()         {}
+func (*Ident) exprNode()           {}
+func (*StringRef) exprNode()       {}
+func (*UnaryExpr) exprNode()       {}
+func (*BinaryExpr) exprNode()      {}
+func (*ParenExpr) exprNode()       {}
+func (*MemberExpr) exprNode()      {}
+func (*IndexExpr) exprNode()       {}
+func (*CallExpr) exprNode()        {}
+func (*RangeExpr) exprNode()       {}
+func (*SetExpr) exprNode()         {}
+func (*OfExpr) exprNode()          {}
+func (*StringMatchExpr) exprNode() {}
+func (*ForExpr) exprNode()         {}
+
+// Walk traverses an expression tree depth-first, calling fn for each node.
+// Traversal of a subtree stops when fn returns false.
+func Walk(e Expr, fn func(Expr) bool) {
+	if e == nil || !fn(e) {
+		return
+	}
+	switch n := e.(type) {
+	case *UnaryExpr:
+		Walk(n.X, fn)
+	case *BinaryExpr:
+		Walk(n.Left, fn)
+		Walk(n.Right, fn)
+	case *ParenExpr:
+		Walk(n.X, fn)
+	case *MemberExpr:
+		Walk(n.X, fn)
+	case *IndexExpr:
+		Walk(n.X, fn)
+		Walk(n.Index, fn)
+	case *CallExpr:
+		Walk(n.Fun, fn)
+		for _, a := range n.Args {
+			Walk(a, fn)
+		}
+	case *RangeExpr:
+		Walk(n.Low, fn)
+		Walk(n.High, fn)
+	case *SetExpr:
+		for _, el := range n.Elems {
+			Walk(el, fn)
+		}
+	case *OfExpr:
+		Walk(n.Quantifier, fn)
+		if n.Set != nil {
+			Walk(n.Set, fn)
+		}
+		Walk(n.In, fn)
+		Walk(n.At, fn)
+	case *StringMatchExpr:
+		Walk(n.Ref, fn)
+		Walk(n.At, fn)
+		Walk(n.In, fn)
+	case *ForExpr:
+		Walk(n.Quantifier, fn)
+		Walk(n.Iterable, fn)
+		if n.Set != nil {
+			Walk(n.Set, fn)
+		}
+		Walk(n.Body, fn)
+	}
+}
@@ -0,0 +1,398 @@
+package yara
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TokenKind identifies the lexical class of a token
+type TokenKind int
+
+// Token kinds produced by the lexer
+const (
+	TokenEOF TokenKind = iota
+	TokenIdent
+	TokenKeyword
+	TokenInt
+	TokenFloat
+	TokenString
+	TokenStringID     // $a, $, $a*
+	TokenStringCount  // #a
+	TokenStringOffset // @a
+	TokenStringLength // !a
+	TokenHexString
+	TokenRegex
+	TokenPunct
+	TokenIllegal
+)
+
+// keywords lists YARA reserved words recognised by the lexer
+var keywords = map[string]bool{
+	"all": true, "and": true, "any": true, "ascii": true, "at": true,
+	"base64": true, "base64wide": true, "condition": true, "contains": true,
+	"defined": true, "endswith": true, "entrypoint": true, "false": true,
+	"filesize": true, "for": true, "fullword": true, "global": true,
+	"icontains": true, "iendswith": true, "iequals": true, "import": true,
+	"in": true, "include": true, "istartswith": true, "matches": true,
+	"meta": true, "nocase": true, "none": true, "not": true, "of": true,
+	"or": true, "private": true, "rule": true, "startswith": true,
+	"strings": true, "them": true, "true": true, "wide": true, "xor": true,
+}
+
+// IsKeyword reports whether name is a reserved YARA keyword
+func IsKeyword(name string) bool {
+	return keywords[name]
+}
+
+// Token is a single lexical token
+type Token struct {
+	Kind  TokenKind
+	Text  string
+	Pos   Position
+	Flags string // regex flags for TokenRegex
+}
+
+// Error is a positioned lexing or parsing error
+type Error struct {
+	Pos Position
+	Msg string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Pos.Line, e.Pos.Column, e.Msg)
+}
+
+// lexer converts YARA source into tokens. Hex strings and regular expressions
+// are context dependent, so the parser requests them explicitly.
+type lexer struct {
+	src    string
+	offset int
+	line   int
+	column int
+	errs   []*Error
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, column: 1}
+}
+
+func (l *lexer) pos() Position {
+	return Position{Offset: l.offset, Line: l.line, Column: l.column}
+}
+
+func (l *lexer) errorf(pos Position, format string, args ...interface{}) {
+	l.errs = append(l.errs, &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (l *lexer) peekByte(n int) byte {
+	if l.offset+n >= len(l.src) {
+		return 0
+	}
+	return l.src[l.offset+n]
+}
+
+func (l *lexer) advance() byte {
+	c := l.src[l.offset]
+	l.offset++
+	if c == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+	return c
+}
+
+// skipSpaceAndComments skips whitespace, line comments and block comments
+func (l *lexer) skipSpaceAndComments() {
+	for l.offset < len(l.src) {
+		c := l.src[l.offset]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			l.advance()
+		case c == '/' && l.peekByte(1) == '/':
+			for l.offset < len(l.src) && l.src[l.offset] != '\n' {
+				l.advance()
+			}
+		case c == '/' && l.peekByte(1) == '*':
+			start := l.pos()
+			l.advance()
+			l.advance()
+			closed := false
+			for l.offset < len(l.src) {
+				if l.src[l.offset] == '*' && l.peekByte(1) == '/' {
+					l.advance()
+					l.advance()
+					closed = true
+					break
+				}
+				l.advance()
+			}
+			if !closed {
+				l.errorf(start, "unterminated block comment")
+			}
+		default:
+			return
+		}
+	}
+}
+
+// peekChar returns the next significant character without consuming it
+func (l *lexer) peekChar() byte {
+	l.skipSpaceAndComments()
+	return l.peekByte(0)
+}
+
+// next scans the next context-free token
+func (l *lexer) next() Token {
+	l.skipSpaceAndComments()
+	start := l.pos()
+	if l.offset >= len(l.src) {
+		return Token{Kind: TokenEOF, Pos: start}
+	}
+
+	c := l.src[l.offset]
+	switch {
+	case isIdentStart(c):
+		text := l.scanWhile(isIdentChar)
+		if keywords[text] {
+			return Token{Kind: TokenKeyword, Text: text, Pos: start}
+		}
+		return Token{Kind: TokenIdent, Text: text, Pos: start}
+	case isDigit(c):
+		return l.scanNumber(start)
+	case c == '"':
+		return l.scanString(start)
+	case c == '$' || c == '#' || c == '@' || c == '!':
+		if c == '!' && l.peekByte(1) == '=' {
+			break
+		}
+		l.advance()
+		name := l.scanWhile(isIdentChar)
+		if c == '$' && l.peekByte(0) == '*' {
+			l.advance()
+			name += "*"
+		}
+		if c != '$' && name == "" {
+			if c == '!' {
+				return Token{Kind: TokenPunct, Text: "!", Pos: start}
+			}
+			l.errorf(start, "expected string identifier after %q", c)
+			return Token{Kind: TokenIllegal, Text: string(c), Pos: start}
+		}
+		kind := map[byte]TokenKind{'$': TokenStringID, '#': TokenStringCount, '@': TokenStringOffset, '!': TokenStringLength}[c]
+		return Token{Kind: kind, Text: string(c) + name, Pos: start}
+	}
+
+	// Punctuation and operators, longest match first
+	for _, op := range []string{"..", "==", "!=", "<=", ">=", "<<", ">>"} {
+		if strings.HasPrefix(l.src[l.offset:], op) {
+			l.advance()
+			l.advance()
+			return Token{Kind: TokenPunct, Text: op, Pos: start}
+		}
+	}
+	if strings.ContainsRune("{}()[]:=,.<>+-*\\%&|^~", rune(c)) {
+		l.advance()
+		return Token{Kind: TokenPunct, Text: string(c), Pos: start}
+	}
+
+	l.advance()
+	l.errorf(start, "unexpected character %q", c)
+	return Token{Kind: TokenIllegal, Text: string(c), Pos: start}
+}
+
+func (l *lexer) scanWhile(pred func(byte) bool) string {
+	begin := l.offset
+	for l.offset < len(l.src) && pred(l.src[l.offset]) {
+		l.advance()
+	}
+	return l.src[begin:l.offset]
+}
+
+func (l *lexer) scanNumber(start Position) Token {
+	begin := l.offset
+	if l.src[l.offset] == '0' && (l.peekByte(1) == 'x' || l.peekByte(1) == 'X') {
+		l.advance()
+		l.advance()
+		if l.scanWhile(isHexDigit) == "" {
+			l.errorf(start, "malformed hexadecimal number")
+		}
+		return Token{Kind: TokenInt, Text: l.src[begin:l.offset], Pos: start}
+	}
+	if l.src[l.offset] == '0' && l.peekByte(1) == 'o' {
+		l.advance()
+		l.advance()
+		if l.scanWhile(func(b byte) bool { return b >= '0' && b <= '7' }) == "" {
+			l.errorf(start, "malformed octal number")
+		}
+		return Token{Kind: TokenInt, Text: l.src[begin:l.offset], Pos: start}
+	}
+
+	l.scanWhile(isDigit)
+	kind := TokenInt
+	// A single dot followed by a digit is a float; ".." is a range operator
+	if l.peekByte(0) == '.' && isDigit(l.peekByte(1)) {
+		l.advance()
+		l.scanWhile(isDigit)
+		kind = TokenFloat
+	}
+	if kind == TokenInt && (strings.HasPrefix(l.src[l.offset:], "KB") || strings.HasPrefix(l.src[l.offset:], "MB")) {
+		l.advance()
+		l.advance()
+	}
+	return Token{Kind: kind, Text: l.src[begin:l.offset], Pos: start}
+}
+
+func (l *lexer) scanString(start Position) Token {
+	l.advance() // opening quote
+	var b strings.Builder
+	for l.offset < len(l.src) {
+		c := l.advance()
+		switch c {
+		case '"':
+			return Token{Kind: TokenString, Text: b.String(), Pos: start}
+		case '\n':
+			l.errorf(start, "unterminated string literal")
+			return Token{Kind: TokenString, Text: b.String(), Pos: start}
+		case '\\':
+			if l.offset >= len(l.src) {
+				break
+			}
+			esc := l.advance()
+			switch esc {
+			case '"', '\\':
+				b.WriteByte(esc)
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'x':
+				h1, h2 := l.peekByte(0), l.peekByte(1)
+				if !isHexDigit(h1) || !isHexDigit(h2) {
+					l.errorf(l.pos(), "invalid \\x escape sequence")
+					continue
+				}
+				l.advance()
+				l.advance()
+				b.WriteByte(unhex(h1)<<4 | unhex(h2))
+			default:
+				l.errorf(l.pos(), "unknown escape sequence \\%c", esc)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	l.errorf(start, "unterminated string literal")
+	return Token{Kind: TokenString, Text: b.String(), Pos: start}
+}
+
+// scanHexString scans `{ ... }` hex string content. Nested braces are not
+// valid in hex strings, so the first closing brace terminates it.
+func (l *lexer) scanHexString() Token {
+	l.skipSpaceAndComments()
+	start := l.pos()
+	l.advance() // opening brace
+	var b strings.Builder
+	for l.offset < len(l.src) {
+		c := l.src[l.offset]
+		if c == '}' {
+			l.advance()
+			return Token{Kind: TokenHexString, Text: strings.TrimSpace(b.String()), Pos: start}
+		}
+		// Comments are permitted inside hex strings
+		if c == '/' && (l.peekByte(1) == '/' || l.peekByte(1) == '*') {
+			l.skipSpaceAndComments()
+			b.WriteByte(' ')
+			continue
+		}
+		b.WriteByte(l.advance())
+	}
+	l.errorf(start, "unterminated hex string")
+	return Token{Kind: TokenHexString, Text: strings.TrimSpace(b.String()), Pos: start}
+}
+
+// scanRegex scans `/body/flags`, honouring escaped slashes
+func (l *lexer) scanRegex() Token {
+	l.skipSpaceAndComments()
+	start := l.pos()
+	l.advance() // opening slash
+	var b strings.Builder
+	for l.offset < len(l.src) {
+		c := l.advance()
+		switch c {
+		case '\\':
+			b.WriteByte(c)
+			if l.offset < len(l.src) {
+				b.WriteByte(l.advance())
+			}
+		case '\n':
+			l.errorf(start, "unterminated regular expression")
+			return Token{Kind: TokenRegex, Text: b.String(), Pos: start}
+		case '/':
+			flags := l.scanWhile(func(b byte) bool { return b == 'i' || b == 's' })
+			return Token{Kind: TokenRegex, Text: b.String(), Pos: start, Flags: flags}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	l.errorf(start, "unterminated regular expression")
+	return Token{Kind: TokenRegex, Text: b.String(), Pos: start}
+}
+
+// scanModifierArgs scans a raw parenthesized modifier argument list
+func (l *lexer) scanModifierArgs() string {
+	start := l.pos()
+	l.advance() // opening paren
+	begin := l.offset
+	depth := 1
+	for l.offset < len(l.src) {
+		switch l.src[l.offset] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				args := l.src[begin:l.offset]
+				l.advance()
+				return strings.TrimSpace(args)
+			}
+		case '"':
+			l.scanString(l.pos())
+			continue
+		}
+		l.advance()
+	}
+	l.errorf(start, "unterminated modifier arguments")
+	return strings.TrimSpace(l.src[begin:])
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
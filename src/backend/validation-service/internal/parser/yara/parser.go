@@ -0,0 +1,751 @@
+package yara
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxErrors bounds the number of errors collected before parsing stops
+const maxErrors = 50
+
+// maxDepth bounds the nesting of expressions. Deeper input abandons the rule
+// with an error instead of recursing further.
+const maxDepth = 100
+
+// stringModifiers lists the modifiers accepted after a string value
+var stringModifiers = map[string]bool{
+	"nocase": true, "wide": true, "ascii": true, "fullword": true,
+	"private": true, "xor": true, "base64": true, "base64wide": true,
+}
+
+// comparisonOps lists keyword operators at comparison precedence
+var comparisonOps = map[string]bool{
+	"contains": true, "icontains": true, "startswith": true, "istartswith": true,
+	"endswith": true, "iendswith": true, "iequals": true, "matches": true,
+}
+
+// bailout aborts parsing of the current rule after an unrecoverable error
+type bailout struct{}
+
+// parser is a recursive-descent parser over the lexer token stream. The
+// lexer is always positioned immediately after the current token so that
+// context-dependent tokens (hex strings, regexes) can be scanned on demand.
+type parser struct {
+	lex  *lexer
+	tok  Token
+	errs []*Error
+	// noOf suppresses `<expr> of <set>` parsing while reading a for-quantifier
+	noOf bool
+	// depth is the current expression nesting
+	depth int
+}
+
+// Parse parses a YARA source file. It always returns a File containing every
+// rule that could be parsed, together with any errors encountered.
+func Parse(src string) (*File, []*Error) {
+	p := &parser{lex: newLexer(src)}
+	p.next()
+	file := p.parseFile()
+
+	errs := append(p.lex.errs, p.errs...)
+	sortErrors(errs)
+	return file, errs
+}
+
+func (p *parser) next() {
+	p.tok = p.lex.next()
+}
+
+func (p *parser) errorf(pos Position, format string, args ...interface{}) {
+	if len(p.errs) >= maxErrors {
+		return
+	}
+	p.errs = append(p.errs, &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+// fail records an error and abandons the current rule
+func (p *parser) fail(pos Position, format string, args ...interface{}) {
+	p.errorf(pos, format, args...)
+	panic(bailout{})
+}
+
+// enter descends one nesting level, abandoning the rule past maxDepth. Each
+// call is paired with a deferred leave.
+func (p *parser) enter() {
+	if p.depth++; p.depth > maxDepth {
+		p.fail(p.tok.Pos, "expression nesting exceeds the maximum depth of %d", maxDepth)
+	}
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) isPunct(text string) bool {
+	return p.tok.Kind == TokenPunct && p.tok.Text == text
+}
+
+func (p *parser) isKeyword(text string) bool {
+	return p.tok.Kind == TokenKeyword && p.tok.Text == text
+}
+
+func (p *parser) expectPunct(text string) Position {
+	pos := p.tok.Pos
+	if !p.isPunct(text) {
+		p.fail(pos, "expected %q, found %s", text, describe(p.tok))
+	}
+	p.next()
+	return pos
+}
+
+func (p *parser) expectKeyword(text string) Position {
+	pos := p.tok.Pos
+	if !p.isKeyword(text) {
+		p.fail(pos, "expected %q, found %s", text, describe(p.tok))
+	}
+	p.next()
+	return pos
+}
+
+func (p *parser) parseFile() *File {
+	file := &File{}
+	for p.tok.Kind != TokenEOF && len(p.errs) < maxErrors {
+		switch {
+		case p.isKeyword("import"):
+			pos := p.tok.Pos
+			p.next()
+			if p.tok.Kind != TokenString {
+				p.errorf(p.tok.Pos, "expected module name after import")
+				p.synchronize()
+				continue
+			}
+			file.Imports = append(file.Imports, &Import{Position: pos, Module: p.tok.Text})
+			p.next()
+		case p.isKeyword("include"):
+			pos := p.tok.Pos
+			p.next()
+			if p.tok.Kind != TokenString {
+				p.errorf(p.tok.Pos, "expected path after include")
+				p.synchronize()
+				continue
+			}
+			file.Includes = append(file.Includes, &Include{Position: pos, Path: p.tok.Text})
+			p.next()
+		case p.isKeyword("rule"), p.isKeyword("private"), p.isKeyword("global"):
+			if rule := p.parseRuleSafe(); rule != nil {
+				file.Rules = append(file.Rules, rule)
+			}
+		default:
+			p.errorf(p.tok.Pos, "unexpected %s at top level", describe(p.tok))
+			p.next()
+			p.synchronize()
+		}
+	}
+	return file
+}
+
+// synchronize skips tokens until the start of the next top-level declaration
+func (p *parser) synchronize() {
+	for p.tok.Kind != TokenEOF {
+		if p.isKeyword("rule") || p.isKeyword("import") || p.isKeyword("include") {
+			return
+		}
+		if (p.isKeyword("private") || p.isKeyword("global")) && p.tok.Pos.Column == 1 {
+			return
+		}
+		p.next()
+	}
+}
+
+// parseRuleSafe parses a rule, recovering from errors by skipping ahead
+func (p *parser) parseRuleSafe() (rule *Rule) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bailout); !ok {
+				panic(r)
+			}
+			rule = nil
+			p.depth = 0
+			// The failing token may itself start the next rule
+			if !p.isKeyword("rule") {
+				p.next()
+			}
+			p.synchronize()
+		}
+	}()
+	return p.parseRule()
+}
+
+func (p *parser) parseRule() *Rule {
+	rule := &Rule{Position: p.tok.Pos}
+	for p.isKeyword("private") || p.isKeyword("global") {
+		rule.Modifiers = append(rule.Modifiers, p.tok.Text)
+		p.next()
+	}
+	p.expectKeyword("rule")
+
+	if p.tok.Kind != TokenIdent {
+		p.fail(p.tok.Pos, "expected rule identifier, found %s", describe(p.tok))
+	}
+	rule.Name = p.tok.Text
+	rule.NamePos = p.tok.Pos
+	p.next()
+
+	if p.isPunct(":") {
+		p.next()
+		for p.tok.Kind == TokenIdent {
+			rule.Tags = append(rule.Tags, p.tok.Text)
+			p.next()
+		}
+		if len(rule.Tags) == 0 {
+			p.fail(p.tok.Pos, "expected at least one tag after ':'")
+		}
+	}
+
+	p.expectPunct("{")
+
+	if p.isKeyword("meta") {
+		rule.MetaPos = p.tok.Pos
+		p.next()
+		p.expectPunct(":")
+		rule.Meta = p.parseMeta()
+	}
+
+	if p.isKeyword("strings") {
+		rule.StringsPos = p.tok.Pos
+		p.next()
+		p.expectPunct(":")
+		rule.Strings = p.parseStrings()
+	}
+
+	if !p.isKeyword("condition") {
+		p.fail(p.tok.Pos, "expected condition section, found %s", describe(p.tok))
+	}
+	rule.ConditionPos = p.tok.Pos
+	p.next()
+	p.expectPunct(":")
+	rule.Condition = p.parseExpr()
+
+	rule.End = p.tok.Pos
+	if !p.isPunct("}") {
+		p.fail(p.tok.Pos, "expected '}' to close rule %s, found %s", rule.Name, describe(p.tok))
+	}
+	p.next()
+	return rule
+}
+
+func (p *parser) parseMeta() []*MetaEntry {
+	var entries []*MetaEntry
+	for p.tok.Kind == TokenIdent || (p.tok.Kind == TokenKeyword && !p.isKeyword("strings") && !p.isKeyword("condition")) {
+		entry := &MetaEntry{Position: p.tok.Pos, Key: p.tok.Text}
+		if p.tok.Kind == TokenKeyword {
+			p.errorf(p.tok.Pos, "meta identifier %q is a reserved keyword", p.tok.Text)
+		}
+		p.next()
+		p.expectPunct("=")
+
+		negative := false
+		if p.isPunct("-") {
+			negative = true
+			p.next()
+		}
+		switch {
+		case p.tok.Kind == TokenString && !negative:
+			entry.Value = p.tok.Text
+		case p.tok.Kind == TokenInt:
+			n, err := parseInt(p.tok.Text)
+			if err != nil {
+				p.errorf(p.tok.Pos, "invalid integer %q in meta value", p.tok.Text)
+			}
+			if negative {
+				n = -n
+			}
+			entry.Value = n
+		case (p.isKeyword("true") || p.isKeyword("false")) && !negative:
+			entry.Value = p.tok.Text == "true"
+		default:
+			p.fail(p.tok.Pos, "invalid meta value for %q: %s", entry.Key, describe(p.tok))
+		}
+		p.next()
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (p *parser) parseStrings() []*StringDef {
+	var defs []*StringDef
+	for p.tok.Kind == TokenStringID {
+		def := &StringDef{Position: p.tok.Pos, ID: p.tok.Text}
+		if strings.HasSuffix(def.ID, "*") {
+			p.errorf(p.tok.Pos, "wildcard identifier %s not allowed in string definition", def.ID)
+		}
+
+		// The value is context dependent, so scan it directly from the lexer
+		// instead of advancing past '='.
+		if !p.isPunct("=") {
+			p.next()
+		}
+		if !p.isPunct("=") {
+			p.fail(p.tok.Pos, "expected '=' after %s, found %s", def.ID, describe(p.tok))
+		}
+
+		var value Token
+		switch p.lex.peekChar() {
+		case '{':
+			value = p.lex.scanHexString()
+			def.Kind = StringHex
+		case '/':
+			value = p.lex.scanRegex()
+			def.Kind = StringRegex
+			def.RegexFlags = value.Flags
+		case '"':
+			value = p.lex.next()
+			def.Kind = StringText
+		default:
+			p.next()
+			p.fail(p.tok.Pos, "expected string value for %s, found %s", def.ID, describe(p.tok))
+		}
+		def.Value = value.Text
+		def.ValuePos = value.Pos
+		p.next()
+
+		for (p.tok.Kind == TokenKeyword || p.tok.Kind == TokenIdent) && stringModifiers[p.tok.Text] {
+			mod := &Modifier{Position: p.tok.Pos, Name: p.tok.Text}
+			if p.lex.peekChar() == '(' {
+				mod.Args = p.lex.scanModifierArgs()
+			}
+			def.Modifiers = append(def.Modifiers, mod)
+			p.next()
+		}
+		defs = append(defs, def)
+	}
+	if len(defs) == 0 {
+		p.errorf(p.tok.Pos, "empty strings section")
+	}
+	return defs
+}
+
+// Expression parsing follows YARA operator precedence, lowest first:
+// or, and, not/defined, comparisons, |, ^, &, shifts, + -, * \ %, unary - ~
+
+func (p *parser) parseExpr() Expr {
+	p.enter()
+	defer p.leave()
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() Expr {
+	left := p.parseAnd()
+	for p.isKeyword("or") {
+		pos := p.tok.Pos
+		p.next()
+		left = &BinaryExpr{Position: pos, Op: "or", Left: left, Right: p.parseAnd()}
+	}
+	return left
+}
+
+func (p *parser) parseAnd() Expr {
+	left := p.parseNot()
+	for p.isKeyword("and") {
+		pos := p.tok.Pos
+		p.next()
+		left = &BinaryExpr{Position: pos, Op: "and", Left: left, Right: p.parseNot()}
+	}
+	return left
+}
+
+func (p *parser) parseNot() Expr {
+	if p.isKeyword("not") || p.isKeyword("defined") {
+		pos, op := p.tok.Pos, p.tok.Text
+		p.enter()
+		defer p.leave()
+		p.next()
+		return &UnaryExpr{Position: pos, Op: op, X: p.parseNot()}
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() Expr {
+	left := p.parseBinary(0)
+	for {
+		switch {
+		case p.tok.Kind == TokenPunct && isComparisonPunct(p.tok.Text):
+			pos, op := p.tok.Pos, p.tok.Text
+			p.next()
+			left = &BinaryExpr{Position: pos, Op: op, Left: left, Right: p.parseBinary(0)}
+		case p.isKeyword("matches"):
+			pos := p.tok.Pos
+			if p.lex.peekChar() != '/' {
+				p.next()
+				p.fail(p.tok.Pos, "expected regular expression after 'matches'")
+			}
+			re := p.lex.scanRegex()
+			p.next()
+			right := &Literal{Position: re.Pos, Kind: LiteralRegex, Raw: re.Text}
+			left = &BinaryExpr{Position: pos, Op: "matches", Left: left, Right: right}
+		case p.tok.Kind == TokenKeyword && comparisonOps[p.tok.Text]:
+			pos, op := p.tok.Pos, p.tok.Text
+			p.next()
+			left = &BinaryExpr{Position: pos, Op: op, Left: left, Right: p.parseBinary(0)}
+		default:
+			return left
+		}
+	}
+}
+
+// binaryLevels lists arithmetic and bitwise operators by increasing precedence
+var binaryLevels = [][]string{
+	{"|"},
+	{"^"},
+	{"&"},
+	{"<<", ">>"},
+	{"+", "-"},
+	{"*", "\\", "%"},
+}
+
+func (p *parser) parseBinary(level int) Expr {
+	if level >= len(binaryLevels) {
+		return p.parseUnary()
+	}
+	left := p.parseBinary(level + 1)
+	for p.tok.Kind == TokenPunct && contains(binaryLevels[level], p.tok.Text) {
+		pos, op := p.tok.Pos, p.tok.Text
+		p.next()
+		left = &BinaryExpr{Position: pos, Op: op, Left: left, Right: p.parseBinary(level + 1)}
+	}
+	return left
+}
+
+func (p *parser) parseUnary() Expr {
+	if p.isPunct("-") || p.isPunct("~") {
+		pos, op := p.tok.Pos, p.tok.Text
+		p.enter()
+		defer p.leave()
+		p.next()
+		return &UnaryExpr{Position: pos, Op: op, X: p.parseUnary()}
+	}
+	return p.parsePostfix(p.parsePrimary())
+}
+
+func (p *parser) parsePostfix(x Expr) Expr {
+	for {
+		switch {
+		case p.isPunct("."):
+			p.next()
+			if p.tok.Kind != TokenIdent && p.tok.Kind != TokenKeyword {
+				p.fail(p.tok.Pos, "expected field name after '.', found %s", describe(p.tok))
+			}
+			x = &MemberExpr{Position: p.tok.Pos, X: x, Name: p.tok.Text}
+			p.next()
+		case p.isPunct("["):
+			pos := p.tok.Pos
+			p.next()
+			index := p.parseExpr()
+			p.expectPunct("]")
+			x = &IndexExpr{Position: pos, X: x, Index: index}
+		case p.isPunct("(") && isCallable(x):
+			pos := p.tok.Pos
+			p.next()
+			call := &CallExpr{Position: pos, Fun: x}
+			for !p.isPunct(")") {
+				call.Args = append(call.Args, p.parseExpr())
+				if !p.isPunct(",") {
+					break
+				}
+				p.next()
+			}
+			p.expectPunct(")")
+			x = call
+		default:
+			return x
+		}
+	}
+}
+
+func (p *parser) parsePrimary() Expr {
+	tok := p.tok
+	switch tok.Kind {
+	case TokenInt:
+		p.next()
+		lit := &Literal{Position: tok.Pos, Kind: LiteralInt, Raw: tok.Text}
+		if p.isPunct("%") && p.followedByOf() {
+			p.next()
+			return p.parseOf(&UnaryExpr{Position: tok.Pos, Op: "%", X: lit})
+		}
+		return p.maybeOf(lit)
+	case TokenFloat:
+		p.next()
+		return &Literal{Position: tok.Pos, Kind: LiteralFloat, Raw: tok.Text}
+	case TokenString:
+		p.next()
+		return &Literal{Position: tok.Pos, Kind: LiteralString, Raw: tok.Text}
+	case TokenStringID:
+		p.next()
+		ref := &StringRef{Position: tok.Pos, Sigil: '$', Name: tok.Text[1:]}
+		return p.parseStringMatch(ref)
+	case TokenStringCount:
+		p.next()
+		ref := &StringRef{Position: tok.Pos, Sigil: '#', Name: tok.Text[1:]}
+		if p.isKeyword("in") {
+			return p.parseStringMatch(ref)
+		}
+		return ref
+	case TokenStringOffset, TokenStringLength:
+		p.next()
+		return &StringRef{Position: tok.Pos, Sigil: tok.Text[0], Name: tok.Text[1:]}
+	case TokenIdent:
+		p.next()
+		return p.maybeOf(&Ident{Position: tok.Pos, Name: tok.Text})
+	case TokenKeyword:
+		switch tok.Text {
+		case "true", "false":
+			p.next()
+			return &Literal{Position: tok.Pos, Kind: LiteralBool, Raw: tok.Text}
+		case "filesize", "entrypoint", "them":
+			p.next()
+			return &Ident{Position: tok.Pos, Name: tok.Text}
+		case "all", "any", "none":
+			p.next()
+			quantifier := &Ident{Position: tok.Pos, Name: tok.Text}
+			if p.noOf {
+				return quantifier
+			}
+			if !p.isKeyword("of") {
+				p.fail(p.tok.Pos, "expected 'of' after %q, found %s", tok.Text, describe(p.tok))
+			}
+			return p.parseOf(quantifier)
+		case "for":
+			return p.parseFor()
+		}
+	case TokenPunct:
+		if tok.Text == "(" {
+			return p.maybeOf(p.parseGroup())
+		}
+	}
+	p.fail(tok.Pos, "unexpected %s in condition", describe(tok))
+	return nil
+}
+
+// maybeOf turns `<quantifier> of <set>` into an OfExpr when 'of' follows
+func (p *parser) maybeOf(quantifier Expr) Expr {
+	if p.noOf || !p.isKeyword("of") {
+		return quantifier
+	}
+	return p.parseOf(quantifier)
+}
+
+// followedByOf reports whether the token after the current one is 'of',
+// distinguishing `50% of them` from the modulo operator
+func (p *parser) followedByOf() bool {
+	saved := *p.lex
+	nextTok := p.lex.next()
+	*p.lex = saved
+	p.lex.errs = saved.errs
+	return nextTok.Kind == TokenKeyword && nextTok.Text == "of"
+}
+
+func (p *parser) parseOf(quantifier Expr) Expr {
+	of := &OfExpr{Position: p.tok.Pos, Quantifier: quantifier}
+	p.expectKeyword("of")
+	if p.isKeyword("them") {
+		of.Them = true
+		p.next()
+	} else {
+		of.Set = p.parseSet()
+	}
+	switch {
+	case p.isKeyword("in"):
+		p.next()
+		of.In = p.parseGroup()
+	case p.isKeyword("at"):
+		p.next()
+		of.At = p.parseBinary(0)
+	}
+	return of
+}
+
+func (p *parser) parseStringMatch(ref *StringRef) Expr {
+	switch {
+	case p.isKeyword("at"):
+		pos := p.tok.Pos
+		p.next()
+		return &StringMatchExpr{Position: pos, Ref: ref, At: p.parseBinary(0)}
+	case p.isKeyword("in"):
+		pos := p.tok.Pos
+		p.next()
+		return &StringMatchExpr{Position: pos, Ref: ref, In: p.parseGroup()}
+	}
+	return ref
+}
+
+// parseSet parses a parenthesized set of string or rule references
+func (p *parser) parseSet() *SetExpr {
+	set := &SetExpr{Position: p.expectPunct("(")}
+	for {
+		switch p.tok.Kind {
+		case TokenStringID:
+			set.Elems = append(set.Elems, &StringRef{Position: p.tok.Pos, Sigil: '$', Name: p.tok.Text[1:]})
+			p.next()
+		case TokenIdent:
+			ident := &Ident{Position: p.tok.Pos, Name: p.tok.Text}
+			p.next()
+			if p.isPunct("*") {
+				ident.Name += "*"
+				p.next()
+			}
+			set.Elems = append(set.Elems, ident)
+		default:
+			set.Elems = append(set.Elems, p.parseExpr())
+		}
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	p.expectPunct(")")
+	return set
+}
+
+// parseGroup parses a parenthesized expression, range or tuple
+func (p *parser) parseGroup() Expr {
+	pos := p.expectPunct("(")
+	first := p.parseExpr()
+	switch {
+	case p.isPunct(".."):
+		p.next()
+		high := p.parseExpr()
+		p.expectPunct(")")
+		return &RangeExpr{Position: pos, Low: first, High: high}
+	case p.isPunct(","):
+		set := &SetExpr{Position: pos, Elems: []Expr{first}}
+		for p.isPunct(",") {
+			p.next()
+			set.Elems = append(set.Elems, p.parseExpr())
+		}
+		p.expectPunct(")")
+		return set
+	}
+	p.expectPunct(")")
+	return &ParenExpr{Position: pos, X: first}
+}
+
+func (p *parser) parseFor() Expr {
+	p.enter()
+	defer p.leave()
+	f := &ForExpr{Position: p.expectKeyword("for")}
+
+	p.noOf = true
+	f.Quantifier = p.parsePrimary()
+	if p.isPunct("%") {
+		p.next()
+		f.Quantifier = &UnaryExpr{Position: f.Quantifier.Pos(), Op: "%", X: f.Quantifier}
+	}
+	p.noOf = false
+
+	if p.isKeyword("of") {
+		p.next()
+		if p.isKeyword("them") {
+			f.Them = true
+			p.next()
+		} else {
+			f.Set = p.parseSet()
+		}
+	} else {
+		for {
+			if p.tok.Kind != TokenIdent {
+				p.fail(p.tok.Pos, "expected loop variable, found %s", describe(p.tok))
+			}
+			f.Vars = append(f.Vars, p.tok.Text)
+			p.next()
+			if !p.isPunct(",") {
+				break
+			}
+			p.next()
+		}
+		p.expectKeyword("in")
+		if p.isPunct("(") {
+			f.Iterable = p.parseGroup()
+		} else {
+			f.Iterable = p.parsePostfix(p.parsePrimary())
+		}
+	}
+
+	p.expectPunct(":")
+	p.expectPunct("(")
+	f.Body = p.parseExpr()
+	p.expectPunct(")")
+	return f
+}
+
+// Helpers
+
+func isComparisonPunct(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
+
+func isCallable(x Expr) bool {
+	switch x.(type) {
+	case *Ident, *MemberExpr:
+		return true
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseInt parses decimal, hex and octal integers with optional KB/MB suffix
+func parseInt(text string) (int64, error) {
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(text, "KB"):
+		multiplier, text = 1024, strings.TrimSuffix(text, "KB")
+	case strings.HasSuffix(text, "MB"):
+		multiplier, text = 1024*1024, strings.TrimSuffix(text, "MB")
+	}
+	var n int64
+	var err error
+	switch {
+	case strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "0X"):
+		n, err = strconv.ParseInt(text[2:], 16, 64)
+	case strings.HasPrefix(text, "0o"):
+		n, err = strconv.ParseInt(text[2:], 8, 64)
+	default:
+		n, err = strconv.ParseInt(text, 10, 64)
+	}
+	return n * multiplier, err
+}
+
+func describe(tok Token) string {
+	switch tok.Kind {
+	case TokenEOF:
+		return "end of input"
+	case TokenString:
+		return "string literal"
+	case TokenHexString:
+		return "hex string"
+	case TokenRegex:
+		return "regular expression"
+	default:
+		return "'" + tok.Text + "'"
+	}
+}
+
+// sortErrors orders errors by source position
+func sortErrors(errs []*Error) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Pos.Offset < errs[j].Pos.Offset
+	})
+}
@@ -0,0 +1,150 @@
+package yara
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `import "pe"
+include "common.yar"
+
+private rule Example : apt loader {
+	meta:
+		author = "analyst"
+		score = 75
+		active = true
+	strings:
+		$a = "cmd.exe" wide ascii nocase
+		$h = { 4D 5A ?? 00 }
+		$r = /evil[0-9]+/i
+	condition:
+		uint16(0) == 0x5A4D and 2 of ($a, $h*) and $r at pe.entry_point
+}
+`
+	file, errs := Parse(src)
+	if len(errs) > 0 {
+		t.Fatalf("Parse() errors = %v", errs)
+	}
+	if len(file.Imports) != 1 || file.Imports[0].Module != "pe" {
+		t.Errorf("imports = %+v, want pe", file.Imports)
+	}
+	if len(file.Includes) != 1 || file.Includes[0].Path != "common.yar" {
+		t.Errorf("includes = %+v, want common.yar", file.Includes)
+	}
+	if len(file.Rules) != 1 {
+		t.Fatalf("got %d rules, want 1", len(file.Rules))
+	}
+
+	rule := file.Rules[0]
+	if rule.Name != "Example" || !rule.IsPrivate() || rule.IsGlobal() {
+		t.Errorf("rule = %s private=%v global=%v", rule.Name, rule.IsPrivate(), rule.IsGlobal())
+	}
+	if strings.Join(rule.Tags, ",") != "apt,loader" {
+		t.Errorf("tags = %v, want [apt loader]", rule.Tags)
+	}
+	if len(rule.Meta) != 3 || rule.Meta[0].Value != "analyst" || rule.Meta[1].Value != int64(75) || rule.Meta[2].Value != true {
+		t.Errorf("meta = %+v", rule.Meta)
+	}
+	if rule.NamePos.Line != 4 || rule.ConditionPos.Line != 13 {
+		t.Errorf("positions: name line %d, condition line %d", rule.NamePos.Line, rule.ConditionPos.Line)
+	}
+
+	kinds := []StringKind{StringText, StringHex, StringRegex}
+	if len(rule.Strings) != len(kinds) {
+		t.Fatalf("got %d strings, want %d", len(rule.Strings), len(kinds))
+	}
+	for i, kind := range kinds {
+		if rule.Strings[i].Kind != kind {
+			t.Errorf("string %s kind = %s, want %s", rule.Strings[i].ID, rule.Strings[i].Kind, kind)
+		}
+	}
+	if !rule.Strings[0].HasModifier("wide") || rule.Strings[0].HasModifier("xor") {
+		t.Errorf("modifiers of $a = %+v", rule.Strings[0].Modifiers)
+	}
+	if rule.Strings[2].Value != "evil[0-9]+" || rule.Strings[2].RegexFlags != "i" {
+		t.Errorf("regex $r = %q flags %q", rule.Strings[2].Value, rule.Strings[2].RegexFlags)
+	}
+
+	var refs []string
+	Walk(rule.Condition, func(e Expr) bool {
+		if ref, ok := e.(*StringRef); ok {
+			refs = append(refs, ref.ID())
+		}
+		return true
+	})
+	if strings.Join(refs, ",") != "$a,$h*,$r" {
+		t.Errorf("condition references = %v, want [$a $h* $r]", refs)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		rules int
+		want  string
+		line  int
+	}{
+		{
+			name: "missing condition",
+			src:  "rule a {\n\tstrings:\n\t\t$a = \"x\"\n}\n",
+			want: "expected condition section",
+			line: 4,
+		},
+		{
+			name:  "unterminated string",
+			src:   "rule a {\n\tstrings:\n\t\t$a = \"x\n\tcondition:\n\t\t$a\n}\n",
+			rules: 1,
+			want:  "unterminated string literal",
+			line:  3,
+		},
+		{
+			name:  "bad rule keeps the next",
+			src:   "rule a { condition: and }\nrule b { condition: true }\n",
+			rules: 1,
+			want:  "unexpected",
+			line:  1,
+		},
+		{
+			name:  "reserved meta key",
+			src:   "rule a {\n\tmeta:\n\t\tthem = 1\n\tcondition:\n\t\ttrue\n}\n",
+			rules: 1,
+			want:  "reserved keyword",
+			line:  3,
+		},
+		{
+			name: "empty tag list",
+			src:  "rule a : { condition: true }\n",
+			want: "expected at least one tag",
+			line: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, errs := Parse(tt.src)
+			if len(errs) == 0 {
+				t.Fatalf("Parse() returned no errors, want %q", tt.want)
+			}
+			if !strings.Contains(errs[0].Msg, tt.want) || errs[0].Pos.Line != tt.line {
+				t.Errorf("first error = %v, want %q on line %d", errs[0], tt.want, tt.line)
+			}
+			if len(file.Rules) != tt.rules {
+				t.Errorf("got %d rules, want %d", len(file.Rules), tt.rules)
+			}
+		})
+	}
+}
+
+func TestParseDepth(t *testing.T) {
+	src := "rule deep { condition: " + strings.Repeat("(", 200) + "true" + strings.Repeat(")", 200) + " }\n" +
+		"rule shallow { condition: true }\n"
+	file, errs := Parse(src)
+	if len(errs) != 1 || !strings.Contains(errs[0].Msg, "maximum depth of 100") {
+		t.Fatalf("Parse() errors = %v, want one depth error", errs)
+	}
+	if len(file.Rules) != 1 || file.Rules[0].Name != "shallow" {
+		t.Errorf("rules = %+v, want only shallow", file.Rules)
+	}
+}
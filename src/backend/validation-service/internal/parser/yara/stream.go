@@ -0,0 +1,259 @@
+package yara
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrRuleTooLarge is returned by RuleReader.Next for a rule larger than the
+// reader's limit; the rules after it cannot be read
+var ErrRuleTooLarge = errors.New("rule exceeds the maximum rule size")
+
+// RuleSource is one rule of a YARA source read by a RuleReader
+type RuleSource struct {
+	// Name is the rule identifier; empty for source that is not a rule
+	Name string
+	// Line is the line of the source the rule starts on
+	Line int
+	// Header holds the import and include statements before the rule, one
+	// per line, so the rule can be parsed on its own
+	Header string
+	// Text is the rule from its first modifier or keyword to its closing
+	// brace. Comments are left out; line breaks are kept.
+	Text string
+}
+
+// Content returns the rule preceded by its header
+func (s *RuleSource) Content() string {
+	return s.Header + s.Text
+}
+
+// HeaderLines counts the lines Content adds before Text
+func (s *RuleSource) HeaderLines() int {
+	return strings.Count(s.Header, "\n")
+}
+
+// RuleReader reads the rules of a YARA source one at a time, so bundles of
+// thousands of rules are validated without holding the source in memory.
+// It tracks comments, strings, regular expressions and braces only; the
+// rules it returns are parsed by Parse. Source left after the last complete
+// rule, such as a rule missing its closing brace, is returned as a final
+// rule so its errors are reported.
+type RuleReader struct {
+	r       *bufio.Reader
+	maxSize int
+	line    int
+	header  []string
+}
+
+// NewRuleReader creates a reader of the rules of r. Rules larger than
+// maxSize bytes fail with ErrRuleTooLarge; 0 means no limit.
+func NewRuleReader(r io.Reader, maxSize int) *RuleReader {
+	return &RuleReader{r: bufio.NewReader(r), maxSize: maxSize, line: 1}
+}
+
+// Next returns the next rule of the source, and io.EOF after the last.
+// Import and include statements are added to the header of the rules after
+// them.
+func (rr *RuleReader) Next() (*RuleSource, error) {
+	for {
+		source, statement, err := rr.readChunk()
+		if err != nil {
+			return nil, err
+		}
+		if !statement {
+			return source, nil
+		}
+		rr.header = append(rr.header, source.Text)
+	}
+}
+
+// readChunk reads the next top-level rule or statement
+func (rr *RuleReader) readChunk() (*RuleSource, bool, error) {
+	var (
+		text      strings.Builder
+		word      strings.Builder
+		source    = &RuleSource{}
+		depth     int
+		last      string // last significant word or punctuation
+		afterRule bool
+		statement bool
+	)
+	if len(rr.header) > 0 {
+		source.Header = strings.Join(rr.header, "\n") + "\n"
+	}
+	endWord := func() {
+		if word.Len() == 0 {
+			return
+		}
+		w := word.String()
+		word.Reset()
+		if afterRule && source.Name == "" {
+			source.Name = w
+		}
+		afterRule = w == "rule"
+		if text.Len() == len(w)+1 && (w == "import" || w == "include") {
+			statement = true
+		}
+		last = w
+	}
+	done := func() (*RuleSource, bool, error) {
+		source.Text = strings.TrimRight(text.String(), " \t\r\n")
+		return source, statement, nil
+	}
+
+	for {
+		c, err := rr.readByte()
+		if err == io.EOF {
+			endWord()
+			if text.Len() == 0 {
+				return nil, false, io.EOF
+			}
+			return done()
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		// Comments are dropped, keeping their line breaks inside a chunk
+		if c == '/' && (rr.peek() == '/' || rr.peek() == '*') {
+			if err := rr.skipComment(&text); err != nil {
+				return nil, false, err
+			}
+			continue
+		}
+
+		space := c == ' ' || c == '\t' || c == '\r' || c == '\n'
+		if text.Len() == 0 {
+			if space {
+				continue
+			}
+			source.Line = rr.line
+		}
+		text.WriteByte(c)
+		if rr.maxSize > 0 && text.Len() > rr.maxSize {
+			return nil, false, fmt.Errorf("%w of %d bytes at line %d", ErrRuleTooLarge, rr.maxSize, rr.line)
+		}
+
+		isWord := c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+		if isWord {
+			word.WriteByte(c)
+			continue
+		}
+		endWord()
+
+		switch c {
+		case '"':
+			if err := rr.readQuoted(&text, '"'); err != nil {
+				return nil, false, err
+			}
+			if statement && depth == 0 {
+				return done()
+			}
+		case '/':
+			if last == "=" || last == "matches" {
+				if err := rr.readQuoted(&text, '/'); err != nil {
+					return nil, false, err
+				}
+			}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth <= 0 && !statement {
+				return done()
+			}
+		}
+		if !space {
+			last = string(c)
+		}
+	}
+}
+
+// readByte reads a byte and counts lines
+func (rr *RuleReader) readByte() (byte, error) {
+	c, err := rr.r.ReadByte()
+	if err == nil && c == '\n' {
+		rr.line++
+	}
+	return c, err
+}
+
+// peek returns the next byte without reading it; 0 at the end
+func (rr *RuleReader) peek() byte {
+	next, err := rr.r.Peek(1)
+	if err != nil {
+		return 0
+	}
+	return next[0]
+}
+
+// readQuoted copies a string or regular expression up to its unescaped
+// closing delimiter. An unterminated one ends at the line break, which is
+// left for the parser to report.
+func (rr *RuleReader) readQuoted(text *strings.Builder, delim byte) error {
+	escaped := false
+	for {
+		if rr.peek() == '\n' {
+			return nil
+		}
+		c, err := rr.readByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		text.WriteByte(c)
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == delim:
+			return nil
+		}
+	}
+}
+
+// skipComment skips a line or block comment whose opening slash was read.
+// Line breaks are kept once a chunk has started, so the lines of its rule
+// match those of the source.
+func (rr *RuleReader) skipComment(text *strings.Builder) error {
+	c, err := rr.readByte()
+	if err != nil {
+		return err
+	}
+	if c == '/' {
+		for rr.peek() != '\n' && rr.peek() != 0 {
+			if _, err := rr.readByte(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var prev byte
+	for {
+		c, err := rr.readByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if c == '\n' && text.Len() > 0 {
+			text.WriteByte('\n')
+		}
+		if prev == '*' && c == '/' {
+			if text.Len() > 0 {
+				text.WriteByte(' ')
+			}
+			return nil
+		}
+		prev = c
+	}
+}
@@ -0,0 +1,89 @@
+package yara
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRuleReader(t *testing.T) {
+	src := `import "pe"
+
+// leading comment
+rule first {
+	strings:
+		$a = "}" // a brace in a string
+		$r = /\}{2}/
+	condition:
+		$a or $r
+}
+
+include "common.yar"
+/* block
+   comment */ rule second : tag { condition: pe.is_dll() }
+rule broken {
+	condition:
+		true
+`
+	reader := NewRuleReader(strings.NewReader(src), 0)
+	var rules []*RuleSource
+	for {
+		rule, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	want := []struct {
+		name   string
+		line   int
+		header string
+	}{
+		{"first", 4, "import \"pe\"\n"},
+		{"second", 14, "import \"pe\"\ninclude \"common.yar\"\n"},
+		{"broken", 15, "import \"pe\"\ninclude \"common.yar\"\n"},
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("got %d rules, want %d", len(rules), len(want))
+	}
+	for i, w := range want {
+		rule := rules[i]
+		if rule.Name != w.name || rule.Line != w.line || rule.Header != w.header {
+			t.Errorf("rule %d = %s line %d header %q, want %s line %d header %q",
+				i, rule.Name, rule.Line, rule.Header, w.name, w.line, w.header)
+		}
+		if rule.HeaderLines() != strings.Count(w.header, "\n") {
+			t.Errorf("rule %s HeaderLines() = %d", rule.Name, rule.HeaderLines())
+		}
+	}
+
+	// Complete rules parse on their own with lines matching the source
+	file, errs := Parse(rules[0].Content())
+	if len(errs) > 0 || len(file.Rules) != 1 {
+		t.Fatalf("Parse(first) = %d rules, errors %v", len(file.Rules), errs)
+	}
+	if got := file.Rules[0].ConditionPos.Line - rules[0].HeaderLines() + rules[0].Line - 1; got != 8 {
+		t.Errorf("condition of first maps to line %d, want 8", got)
+	}
+	if _, errs := Parse(rules[2].Content()); len(errs) == 0 {
+		t.Error("Parse(broken) returned no errors")
+	}
+}
+
+func TestRuleReaderMaxSize(t *testing.T) {
+	src := "rule small { condition: true }\nrule large { condition: " + strings.Repeat("true and ", 20) + "true }\n"
+	reader := NewRuleReader(strings.NewReader(src), 64)
+
+	rule, err := reader.Next()
+	if err != nil || rule.Name != "small" {
+		t.Fatalf("Next() = %+v, %v, want small", rule, err)
+	}
+	if _, err := reader.Next(); !errors.Is(err, ErrRuleTooLarge) {
+		t.Errorf("Next() error = %v, want ErrRuleTooLarge", err)
+	}
+}
@@ -0,0 +1,137 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"validation-service/internal/config"
+)
+
+// luceneErrorPosition finds the position Lucene query parser errors report
+var luceneErrorPosition = regexp.MustCompile(`at line (\d+), column (\d+)`)
+
+// elasticsearch validates query_string queries with the _validate/query API
+// of an Elasticsearch cluster
+type elasticsearch struct {
+	base
+	index    string
+	username string
+	secret   string
+}
+
+// newElasticsearch creates an Elasticsearch connector. Requests use basic
+// auth with a username, an API key with only a secret and no credentials
+// otherwise.
+func newElasticsearch(cfg config.PlatformConfig, secret string, client *http.Client) *elasticsearch {
+	return &elasticsearch{
+		base: base{
+			name:    cfg.Name,
+			formats: cfg.Formats,
+			url:     strings.TrimSuffix(cfg.URL, "/"),
+			client:  client,
+		},
+		index:    cfg.Index,
+		username: cfg.Username,
+		secret:   secret,
+	}
+}
+
+// esValidateResponse is the response of the _validate/query API
+type esValidateResponse struct {
+	Valid        bool   `json:"valid"`
+	Error        string `json:"error"`
+	Explanations []struct {
+		Index string `json:"index"`
+		Valid bool   `json:"valid"`
+		Error string `json:"error"`
+	} `json:"explanations"`
+}
+
+// Type implements Connector
+func (e *elasticsearch) Type() string {
+	return config.PlatformElasticsearch
+}
+
+// Validate submits the query as a query_string query, explaining the errors
+// of each index
+func (e *elasticsearch) Validate(ctx context.Context, query string) (*Check, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"query_string": map[string]string{"query": query},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	endpoint := e.url + "/_validate/query?explain=true"
+	if e.index != "" {
+		endpoint = e.url + "/" + url.PathEscape(e.index) + "/_validate/query?explain=true"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	switch {
+	case e.username != "":
+		req.SetBasicAuth(e.username, e.secret)
+	case e.secret != "":
+		req.Header.Set("Authorization", "ApiKey "+e.secret)
+	}
+
+	status, respBody, err := e.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, statusError(status, respBody)
+	}
+	var resp esValidateResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("invalid _validate/query response: %w", err)
+	}
+
+	check := &Check{Valid: resp.Valid}
+	if resp.Valid {
+		return check, nil
+	}
+	seen := make(map[string]bool)
+	addError := func(message string) {
+		if message == "" || seen[message] {
+			return
+		}
+		seen[message] = true
+		check.Errors = append(check.Errors, luceneError(message))
+	}
+	addError(resp.Error)
+	for _, explanation := range resp.Explanations {
+		if !explanation.Valid {
+			addError(explanation.Error)
+		}
+	}
+	if len(check.Errors) == 0 {
+		check.Errors = append(check.Errors, Error{Message: "query rejected without an explanation"})
+	}
+	return check, nil
+}
+
+// luceneError reads the position of a query parser error from its message
+func luceneError(message string) Error {
+	platformErr := Error{Message: message}
+	if match := luceneErrorPosition.FindStringSubmatch(message); match != nil {
+		platformErr.Line, _ = strconv.Atoi(match[1])
+		platformErr.Column, _ = strconv.Atoi(match[2])
+	}
+	if i := strings.Index(message, ":"); i > 0 && !strings.Contains(message[:i], " ") {
+		// Explanations start with the exception class
+		platformErr.Code = message[strings.LastIndex(message[:i], ".")+1 : i]
+	}
+	return platformErr
+}
@@ -0,0 +1,137 @@
+// Package platform submits translated queries to the parse and plan APIs of
+// target platforms, such as the Elasticsearch _validate/query API and the Log
+// Analytics query API of a Sentinel workspace, so syntax errors the built-in
+// validators miss are reported by the platform that will run the query.
+package platform
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.17.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+
+	"validation-service/internal/config"
+)
+
+// maxResponseSize bounds the platform responses read
+const maxResponseSize = 1 << 20 // 1MB
+
+var platformChecks = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:        "platform_validation_duration_seconds",
+	Help:        "Duration of query validations by target platforms, by connector and outcome",
+	Buckets:     prometheus.DefBuckets,
+	ConstLabels: prometheus.Labels{"service": "validation"},
+}, []string{"connector", "outcome"})
+
+// Error is a syntax error reported by a platform
+type Error struct {
+	Message string `json:"message"`
+	// Line and Column locate the error in the submitted query, when the
+	// platform reports them
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+	// Code is the platform's error code, such as SyntaxError
+	Code string `json:"code,omitempty"`
+}
+
+// Check is the verdict of a platform on a query
+type Check struct {
+	Valid  bool    `json:"valid"`
+	Errors []Error `json:"errors,omitempty"`
+}
+
+// Connector validates queries with a target platform
+type Connector interface {
+	// Name identifies the connector
+	Name() string
+	// Type is config.PlatformElasticsearch or config.PlatformSentinel
+	Type() string
+	// Formats are the target formats whose queries the connector validates
+	Formats() []string
+	// Validate submits a query to the platform. An error means the platform
+	// gave no verdict, not that the query is invalid.
+	Validate(ctx context.Context, query string) (*Check, error)
+}
+
+// New creates the connector of a platform configuration, sending its
+// requests with client
+func New(cfg config.PlatformConfig, client *http.Client) (Connector, error) {
+	secret := ""
+	if cfg.SecretFile != "" {
+		data, err := os.ReadFile(cfg.SecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading secret of platform connector %s: %w", cfg.Name, err)
+		}
+		secret = strings.TrimSpace(string(data))
+	}
+
+	switch cfg.Type {
+	case config.PlatformElasticsearch:
+		return newElasticsearch(cfg, secret, client), nil
+	case config.PlatformSentinel:
+		return newSentinel(cfg, secret, client), nil
+	}
+	return nil, fmt.Errorf("unknown platform connector type: %q", cfg.Type)
+}
+
+// Observe records the duration and outcome of a validation by a connector
+func Observe(connector Connector, start time.Time, check *Check, err error) {
+	outcome := "valid"
+	switch {
+	case err != nil:
+		outcome = "unavailable"
+	case !check.Valid:
+		outcome = "invalid"
+	}
+	platformChecks.WithLabelValues(connector.Name(), outcome).Observe(time.Since(start).Seconds())
+}
+
+// base holds the settings shared by connectors
+type base struct {
+	name    string
+	formats []string
+	url     string
+	client  *http.Client
+}
+
+// Name implements Connector
+func (b *base) Name() string {
+	return b.name
+}
+
+// Formats implements Connector
+func (b *base) Formats() []string {
+	return b.formats
+}
+
+// do sends a request and returns the status and body of the response
+func (b *base) do(req *http.Request) (int, []byte, error) {
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("reading response: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
+
+// statusError describes an unexpected response of a platform
+func statusError(status int, body []byte) error {
+	text := strings.TrimSpace(string(body))
+	if len(text) > 200 {
+		text = text[:200]
+	}
+	if text == "" {
+		return fmt.Errorf("platform returned %d", status)
+	}
+	return fmt.Errorf("platform returned %d: %s", status, text)
+}
@@ -0,0 +1,171 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"validation-service/internal/config"
+)
+
+// azureLoginURL is the Azure AD endpoint client credentials are exchanged at
+var azureLoginURL = "https://login.microsoftonline.com"
+
+// tokenRefreshMargin is how long before it expires an access token is
+// replaced
+const tokenRefreshMargin = time.Minute
+
+// sentinel validates KQL queries with the Log Analytics query API of a
+// Sentinel workspace. Queries are compiled by the workspace and run over the
+// last minute with "| take 0" appended, so no rows are returned.
+type sentinel struct {
+	base
+	workspaceID  string
+	tenantID     string
+	clientID     string
+	clientSecret string
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// newSentinel creates a Sentinel connector authenticating as an Azure AD
+// application with the client credentials flow
+func newSentinel(cfg config.PlatformConfig, secret string, client *http.Client) *sentinel {
+	return &sentinel{
+		base: base{
+			name:    cfg.Name,
+			formats: cfg.Formats,
+			url:     strings.TrimSuffix(cfg.URL, "/"),
+			client:  client,
+		},
+		workspaceID:  cfg.WorkspaceID,
+		tenantID:     cfg.TenantID,
+		clientID:     cfg.ClientID,
+		clientSecret: secret,
+	}
+}
+
+// laError is an error of the Log Analytics API. Syntax errors are nested in
+// innererror, the innermost carrying the position.
+type laError struct {
+	Code       string   `json:"code"`
+	Message    string   `json:"message"`
+	Line       int      `json:"line"`
+	Pos        int      `json:"pos"`
+	InnerError *laError `json:"innererror"`
+}
+
+// Type implements Connector
+func (s *sentinel) Type() string {
+	return config.PlatformSentinel
+}
+
+// Validate submits the query to the workspace. The workspace rejects queries
+// it cannot parse or resolve with 400.
+func (s *sentinel) Validate(ctx context.Context, query string) (*Check, error) {
+	token, err := s.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(map[string]string{
+		"query":    strings.TrimRight(query, "; \t\r\n") + "\n| take 0",
+		"timespan": "PT1M",
+	})
+	if err != nil {
+		return nil, err
+	}
+	endpoint := s.url + "/v1/workspaces/" + url.PathEscape(s.workspaceID) + "/query"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	status, respBody, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusOK {
+		return &Check{Valid: true}, nil
+	}
+	if status == http.StatusUnauthorized {
+		s.forgetToken()
+	}
+	if status != http.StatusBadRequest {
+		return nil, statusError(status, respBody)
+	}
+
+	var resp struct {
+		Error *laError `json:"error"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil || resp.Error == nil {
+		return nil, statusError(status, respBody)
+	}
+	innermost := resp.Error
+	for innermost.InnerError != nil && innermost.InnerError.Message != "" {
+		innermost = innermost.InnerError
+	}
+	return &Check{Errors: []Error{{
+		Message: innermost.Message,
+		Line:    innermost.Line,
+		Column:  innermost.Pos,
+		Code:    innermost.Code,
+	}}}, nil
+}
+
+// token returns an access token for the Log Analytics API, requesting a new
+// one when the cached token is about to expire
+func (s *sentinel) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.accessToken != "" && time.Now().Before(s.expiresAt) {
+		return s.accessToken, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {s.clientID},
+		"client_secret": {s.clientSecret},
+		"scope":         {s.url + "/.default"},
+	}
+	endpoint := azureLoginURL + "/" + url.PathEscape(s.tenantID) + "/oauth2/v2.0/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	status, body, err := s.do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting access token: %w", err)
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("requesting access token: %w", statusError(status, body))
+	}
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.AccessToken == "" {
+		return "", fmt.Errorf("requesting access token: invalid token response")
+	}
+	s.accessToken = resp.AccessToken
+	s.expiresAt = time.Now().Add(time.Duration(resp.ExpiresIn)*time.Second - tokenRefreshMargin)
+	return s.accessToken, nil
+}
+
+// forgetToken drops a cached token the API rejected
+func (s *sentinel) forgetToken() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accessToken = ""
+}
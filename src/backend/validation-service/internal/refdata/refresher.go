@@ -0,0 +1,346 @@
+// Package refdata keeps the reference datasets validation depends on, such as
+// the MITRE ATT&CK bundle and the field mapping table, current without a
+// restart. Datasets are reloaded on demand or refreshed periodically from
+// their configured URLs; a dataset that fails to download or parse keeps its
+// previous version.
+package refdata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"validation-service/internal/config"
+	"validation-service/pkg/logger"
+)
+
+// Outcomes of checking a dataset
+const (
+	OutcomeUpdated   = "updated"
+	OutcomeUnchanged = "unchanged"
+	OutcomeFailed    = "failed"
+)
+
+var (
+	// ErrReloadRunning is returned when a reload is requested while one is
+	// in progress
+	ErrReloadRunning = errors.New("reference data reload already running")
+	// ErrUnknownDataset is returned when a reload names a dataset that is not
+	// registered
+	ErrUnknownDataset = errors.New("unknown reference dataset")
+	// ErrDatasetTooLarge is returned when a download exceeds the configured
+	// maximum size
+	ErrDatasetTooLarge = errors.New("reference dataset exceeds maximum size")
+)
+
+// Loader parses a dataset and, when it is valid, puts it in use. It returns
+// the version of the dataset for the status report.
+type Loader func(data []byte) (version string, err error)
+
+// ReloadStatus reports the progress of the most recent reload
+type ReloadStatus struct {
+	Running    bool            `json:"running"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Datasets   []DatasetStatus `json:"datasets"`
+}
+
+// DatasetStatus reports the dataset in use and the outcome of its last check.
+// Source is the URL or file the dataset is loaded from.
+type DatasetStatus struct {
+	Name      string     `json:"name"`
+	Source    string     `json:"source"`
+	Version   string     `json:"version,omitempty"`
+	Digest    string     `json:"digest,omitempty"`
+	LoadedAt  *time.Time `json:"loaded_at,omitempty"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+	Outcome   string     `json:"outcome,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// dataset is a registered dataset with the cache validators of the download
+// in use
+type dataset struct {
+	status     DatasetStatus
+	load       Loader
+	validators validators
+}
+
+// validators are the ETag and Last-Modified headers of a download, sent back
+// so an unchanged dataset is not downloaded again
+type validators struct {
+	etag         string
+	lastModified string
+}
+
+// Refresher reloads registered datasets, one reload at a time
+type Refresher struct {
+	cfg    config.RefDataConfig
+	client *http.Client
+	log    *logger.Logger
+
+	mu       sync.Mutex
+	datasets []*dataset
+	status   ReloadStatus
+	updated  func(DatasetStatus)
+}
+
+// NewRefresher creates a refresher that downloads datasets with client,
+// within the configured size limit
+func NewRefresher(cfg config.RefDataConfig, client *http.Client) *Refresher {
+	return &Refresher{
+		cfg:    cfg,
+		client: client,
+		log:    logger.GetLogger(),
+		status: ReloadStatus{Datasets: []DatasetStatus{}},
+	}
+}
+
+// Register adds a dataset loaded from source, an http(s) URL or a file path.
+// version describes the dataset loaded at startup and is empty when none is.
+func (r *Refresher) Register(name, source, version string, load Loader) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.datasets = append(r.datasets, &dataset{
+		status: DatasetStatus{Name: name, Source: source, Version: version},
+		load:   load,
+	})
+}
+
+// SetUpdateHook calls updated with the status of every dataset a reload puts
+// in use
+func (r *Refresher) SetUpdateHook(updated func(DatasetStatus)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.updated = updated
+}
+
+// StartReload checks the named datasets, or every registered dataset when
+// names is empty, in the background and puts changed datasets in use.
+// Progress is available from ReloadStatus.
+func (r *Refresher) StartReload(names []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	selected, err := r.selectDatasets(names)
+	if err != nil {
+		return err
+	}
+	if r.status.Running {
+		return ErrReloadRunning
+	}
+	now := time.Now().UTC()
+	r.status = ReloadStatus{Running: true, StartedAt: &now, Datasets: make([]DatasetStatus, len(selected))}
+	for i, ds := range selected {
+		r.status.Datasets[i] = ds.status
+	}
+
+	go r.reload(context.Background(), selected)
+	return nil
+}
+
+// ReloadStatus returns the status of the current or last reload
+func (r *Refresher) ReloadStatus() ReloadStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := r.status
+	status.Datasets = append([]DatasetStatus(nil), r.status.Datasets...)
+	return status
+}
+
+// Run reloads every dataset at the configured refresh interval until ctx is
+// cancelled. A refresh due while a reload runs is skipped.
+func (r *Refresher) Run(ctx context.Context) {
+	if r.cfg.RefreshInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(r.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.StartReload(nil); err != nil && !errors.Is(err, ErrReloadRunning) {
+				r.log.Error("Failed to start reference data refresh",
+					"error", err,
+				)
+			}
+		}
+	}
+}
+
+// selectDatasets resolves dataset names against the registered datasets.
+// The caller holds r.mu.
+func (r *Refresher) selectDatasets(names []string) ([]*dataset, error) {
+	if len(names) == 0 {
+		return append([]*dataset(nil), r.datasets...), nil
+	}
+	selected := make([]*dataset, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, ds := range r.datasets {
+			if ds.status.Name == name {
+				selected = append(selected, ds)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownDataset, name)
+		}
+	}
+	return selected, nil
+}
+
+// reload checks the selected datasets in turn and records their outcome
+func (r *Refresher) reload(ctx context.Context, selected []*dataset) {
+	for i, ds := range selected {
+		status, changed := r.check(ctx, ds)
+
+		r.mu.Lock()
+		ds.status = status
+		r.status.Datasets[i] = status
+		updated := r.updated
+		r.mu.Unlock()
+
+		if changed && updated != nil {
+			updated(status)
+		}
+	}
+
+	r.mu.Lock()
+	finished := time.Now().UTC()
+	r.status.Running = false
+	r.status.FinishedAt = &finished
+	r.log.Info("Reference data reload completed",
+		"datasets", len(selected),
+		"duration", finished.Sub(*r.status.StartedAt),
+	)
+	r.mu.Unlock()
+}
+
+// check fetches one dataset and loads it when its content changed. It
+// returns the new status of the dataset and whether a new version was put in
+// use. Only this reload touches ds while it runs.
+func (r *Refresher) check(ctx context.Context, ds *dataset) (DatasetStatus, bool) {
+	r.mu.Lock()
+	status := ds.status
+	r.mu.Unlock()
+
+	now := time.Now().UTC()
+	status.CheckedAt = &now
+	status.Error = ""
+	fail := func(err error) (DatasetStatus, bool) {
+		status.Outcome = OutcomeFailed
+		status.Error = err.Error()
+		r.log.Error("Reference data reload failed",
+			"error", err,
+			"dataset", status.Name,
+			"source", status.Source,
+		)
+		return status, false
+	}
+
+	data, fetched, err := r.fetch(ctx, status.Name, status.Source, ds.validators)
+	if err != nil {
+		return fail(err)
+	}
+	if data == nil {
+		status.Outcome = OutcomeUnchanged
+		return status, false
+	}
+
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	if digest == status.Digest {
+		ds.validators = fetched
+		status.Outcome = OutcomeUnchanged
+		return status, false
+	}
+
+	version, err := ds.load(data)
+	if err != nil {
+		return fail(err)
+	}
+	// The validators are only kept once the content is in use, so a release
+	// that failed to load is downloaded and checked again
+	ds.validators = fetched
+	status.Version = version
+	status.Digest = digest
+	status.LoadedAt = &now
+	status.Outcome = OutcomeUpdated
+	r.log.Info("Reference dataset updated",
+		"dataset", status.Name,
+		"source", status.Source,
+		"version", version,
+	)
+	return status, true
+}
+
+// fetch returns the content of a dataset's source and the validators to send
+// on the next request. For URLs the validators of the download in use are
+// sent, and the content is nil when the server answers 304 Not Modified.
+func (r *Refresher) fetch(ctx context.Context, name, source string, previous validators) ([]byte, validators, error) {
+	if !strings.HasPrefix(source, "https://") && !strings.HasPrefix(source, "http://") {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, validators{}, fmt.Errorf("reading %s: %w", name, err)
+		}
+		if r.cfg.MaxSize > 0 && int64(len(data)) > r.cfg.MaxSize {
+			return nil, validators{}, ErrDatasetTooLarge
+		}
+		return data, validators{}, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, validators{}, fmt.Errorf("building request: %w", err)
+	}
+	if previous.etag != "" {
+		req.Header.Set("If-None-Match", previous.etag)
+	}
+	if previous.lastModified != "" {
+		req.Header.Set("If-Modified-Since", previous.lastModified)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, validators{}, fmt.Errorf("downloading %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, previous, nil
+	case http.StatusOK:
+	default:
+		return nil, validators{}, fmt.Errorf("downloading %s: unexpected status %s", name, resp.Status)
+	}
+	if r.cfg.MaxSize > 0 && resp.ContentLength > r.cfg.MaxSize {
+		return nil, validators{}, ErrDatasetTooLarge
+	}
+
+	body := io.Reader(resp.Body)
+	if r.cfg.MaxSize > 0 {
+		body = io.LimitReader(resp.Body, r.cfg.MaxSize+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, validators{}, fmt.Errorf("downloading %s: %w", name, err)
+	}
+	if r.cfg.MaxSize > 0 && int64(len(data)) > r.cfg.MaxSize {
+		return nil, validators{}, ErrDatasetTooLarge
+	}
+	return data, validators{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
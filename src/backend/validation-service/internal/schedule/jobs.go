@@ -0,0 +1,268 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"validation-service/internal/models"
+	"validation-service/internal/notify"
+	"validation-service/internal/services/rescore"
+	"validation-service/internal/services/validation"
+	"validation-service/internal/storage"
+	"validation-service/pkg/logger"
+)
+
+// Revalidator validates the stored detections of a schedule's tenant again
+// and saves the results, so rules are checked against the current
+// validators, field mappings and scoring
+type Revalidator struct {
+	detections  storage.DetectionStore
+	results     storage.ResultStore
+	validator   *validation.ValidationService
+	collections storage.CollectionStore
+}
+
+// NewRevalidator creates the revalidate job for detections stored in
+// detections; results are saved to results when set
+func NewRevalidator(detections storage.DetectionStore, results storage.ResultStore, validator *validation.ValidationService) *Revalidator {
+	return &Revalidator{
+		detections: detections,
+		results:    results,
+		validator:  validator,
+	}
+}
+
+// SetCollections enables jobs limited to the detections of a collection
+// stored in collections
+func (v *Revalidator) SetCollections(collections storage.CollectionStore) {
+	v.collections = collections
+}
+
+// Run validates every detection of the tenant matching the schedule's
+// format and tags, or those of its collection. Detections that cannot be
+// validated are counted as failed; the run fails when none could be
+// validated.
+func (v *Revalidator) Run(ctx context.Context, schedule *storage.Schedule) (map[string]int, error) {
+	if schedule.Job.CollectionID != nil {
+		return v.runCollection(ctx, schedule)
+	}
+	counts := map[string]int{"validated": 0, "failed": 0}
+	query := storage.DetectionQuery{
+		TenantID: schedule.TenantID,
+		Format:   schedule.Job.Format,
+		Tags:     schedule.Job.Tags,
+		Limit:    storage.MaxSearchLimit,
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return counts, err
+		}
+		page, total, err := v.detections.SearchDetections(ctx, query)
+		if err != nil {
+			return counts, fmt.Errorf("listing detections: %w", err)
+		}
+		for _, stored := range page {
+			v.validate(ctx, stored, counts)
+		}
+		query.Offset += len(page)
+		if len(page) == 0 || query.Offset >= total {
+			break
+		}
+	}
+
+	if counts["failed"] > 0 && counts["validated"] == 0 {
+		return counts, fmt.Errorf("none of %d detections could be validated", counts["failed"])
+	}
+	return counts, nil
+}
+
+// runCollection validates the detections of the schedule's collection in
+// order and checks the collection as a whole, counting its collection
+// issues. Detections removed since the collection was saved are counted as
+// failed.
+func (v *Revalidator) runCollection(ctx context.Context, schedule *storage.Schedule) (map[string]int, error) {
+	counts := map[string]int{"validated": 0, "failed": 0, "collection_issues": 0}
+	if v.collections == nil {
+		return counts, errors.New("collections are not enabled")
+	}
+	c, err := v.collections.GetCollection(ctx, schedule.TenantID, *schedule.Job.CollectionID)
+	if err != nil {
+		return counts, fmt.Errorf("loading collection %s: %w", *schedule.Job.CollectionID, err)
+	}
+
+	collection := &validation.Collection{Name: c.Name, Metadata: c.Metadata}
+	var detections []*storage.StoredDetection
+	for _, id := range c.DetectionIDs {
+		stored, err := v.detections.GetDetection(ctx, schedule.TenantID, id)
+		if err != nil {
+			counts["failed"]++
+			logger.FromContext(ctx).Warn("Could not load collection detection",
+				"error", err,
+				"collection_id", c.ID,
+				"detection_id", id,
+			)
+			continue
+		}
+		detections = append(detections, stored)
+		collection.Rules = append(collection.Rules, validation.CollectionRule{
+			Name:   stored.Name,
+			Source: stored.Detection,
+			Target: stored.Detection,
+		})
+	}
+
+	// Scheduled runs re-validate against the current validators rather
+	// than serving cached results
+	result, err := v.validator.ValidateCollection(ctx, collection, true)
+	if err != nil {
+		return counts, err
+	}
+	for _, rule := range result.Rules {
+		var ruleErr error
+		if rule.Error != "" {
+			ruleErr = errors.New(rule.Error)
+		}
+		v.record(ctx, detections[rule.Index], rule.Result, ruleErr, counts)
+	}
+	counts["collection_issues"] = len(result.Issues)
+
+	if counts["failed"] > 0 && counts["validated"] == 0 {
+		return counts, fmt.Errorf("none of %d detections could be validated", counts["failed"])
+	}
+	return counts, nil
+}
+
+// validate validates one detection, saves its result and counts the outcome
+func (v *Revalidator) validate(ctx context.Context, stored *storage.StoredDetection, counts map[string]int) {
+	result, err := v.validator.ValidateDetection(ctx, stored.Detection, stored.Detection)
+	v.record(ctx, stored, result, err, counts)
+}
+
+// record saves the result of one detection and counts the outcome
+func (v *Revalidator) record(ctx context.Context, stored *storage.StoredDetection, result *models.ValidationResult, err error, counts map[string]int) {
+	if result == nil {
+		counts["failed"]++
+		logger.FromContext(ctx).Warn("Could not revalidate detection",
+			"error", err,
+			"detection_id", stored.ID(),
+		)
+		return
+	}
+	counts["validated"]++
+	counts[result.Status]++
+	if v.results == nil {
+		return
+	}
+	if err := v.results.SaveResult(ctx, stored.TenantID, result); err != nil {
+		logger.FromContext(ctx).Error("Failed to persist revalidation result",
+			"error", err,
+			"detection_id", stored.ID(),
+			"result_id", result.ID,
+		)
+	}
+}
+
+// RescoreJob recomputes the confidence scores of a schedule's tenant's
+// stored results with the rescorer. It fails when a re-scoring run is
+// already in progress.
+func RescoreJob(rescorer *rescore.Rescorer) Job {
+	return JobFunc(func(ctx context.Context, schedule *storage.Schedule) (map[string]int, error) {
+		status, err := rescorer.RunRescore(ctx, rescore.Options{
+			TenantID:     schedule.TenantID,
+			TargetFormat: schedule.Job.Format,
+		})
+		if err != nil {
+			return nil, err
+		}
+		counts := map[string]int{
+			"scanned":   status.Scanned,
+			"rescored":  status.Rescored,
+			"unchanged": status.Unchanged,
+			"failed":    status.Failed,
+		}
+		if len(status.Errors) > 0 {
+			return counts, fmt.Errorf("%d errors, first: %s", len(status.Errors), status.Errors[0])
+		}
+		return counts, nil
+	})
+}
+
+// Publisher receives events for delivery to notification subscriptions
+type Publisher interface {
+	Publish(event *storage.NotificationEvent)
+}
+
+// ReviewChecker finds the stored detections of a schedule's tenant past
+// their review-by or expiry date and notifies subscribers of each, so
+// migrated rules do not go stale unnoticed
+type ReviewChecker struct {
+	detections storage.DetectionStore
+	publisher  Publisher
+	now        func() time.Time
+}
+
+// NewReviewChecker creates the review job for detections stored in
+// detections; events are published to publisher when set
+func NewReviewChecker(detections storage.DetectionStore, publisher Publisher) *ReviewChecker {
+	return &ReviewChecker{
+		detections: detections,
+		publisher:  publisher,
+		now:        time.Now,
+	}
+}
+
+// Run checks the review-by and expiry dates of every detection of the
+// tenant matching the schedule's format and tags, and counts the checked,
+// review_due and expired detections
+func (c *ReviewChecker) Run(ctx context.Context, schedule *storage.Schedule) (map[string]int, error) {
+	counts := map[string]int{"checked": 0, validation.RuleReviewDue: 0, validation.RuleExpired: 0}
+	now := c.now().UTC()
+	query := storage.DetectionQuery{
+		TenantID: schedule.TenantID,
+		Format:   schedule.Job.Format,
+		Tags:     schedule.Job.Tags,
+		Limit:    storage.MaxSearchLimit,
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return counts, err
+		}
+		page, total, err := c.detections.SearchDetections(ctx, query)
+		if err != nil {
+			return counts, fmt.Errorf("listing detections: %w", err)
+		}
+		for _, stored := range page {
+			c.check(stored, now, counts)
+		}
+		query.Offset += len(page)
+		if len(page) == 0 || query.Offset >= total {
+			break
+		}
+	}
+	return counts, nil
+}
+
+// check counts the review status of one detection and publishes an event
+// when it is stale
+func (c *ReviewChecker) check(stored *storage.StoredDetection, now time.Time, counts map[string]int) {
+	if stored.Detection == nil {
+		return
+	}
+	counts["checked"]++
+	review := validation.ReviewDates(stored.Detection, stored.Detection.Format)
+	status := review.Status(now)
+	if status == "" {
+		return
+	}
+	counts[status]++
+	if c.publisher == nil {
+		return
+	}
+	due := review.ReviewBy
+	if status == validation.RuleExpired {
+		due = review.Expires
+	}
+	c.publisher.Publish(notify.StaleRuleEvent(stored.TenantID, stored, status, *due))
+}
@@ -0,0 +1,247 @@
+// Package schedule runs the recurring jobs tenants define with cron
+// expressions, such as a nightly re-validation of their stored detections.
+// Each due time of a schedule is claimed in the schedule store before it
+// runs, so it runs once however many replicas schedule; a due time reached
+// while the previous run of the schedule is still in progress is skipped.
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.17.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+
+	"validation-service/internal/config"
+	"validation-service/internal/storage"
+	"validation-service/internal/tenant"
+	"validation-service/pkg/logger"
+)
+
+// checkInterval is how often the scheduler looks for due schedules; cron
+// expressions have minute resolution
+const checkInterval = time.Minute
+
+// intervalSamples is the number of upcoming runs checked against the minimum
+// interval of a schedule
+const intervalSamples = 50
+
+var scheduledRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name:        "scheduled_runs_total",
+	Help:        "Runs of scheduled jobs by job type and outcome",
+	ConstLabels: prometheus.Labels{"service": "validation"},
+}, []string{"job", "outcome"})
+
+// Job runs one type of scheduled job for the tenant of a schedule, which is
+// carried by ctx, and returns the tallies of the run
+type Job interface {
+	Run(ctx context.Context, schedule *storage.Schedule) (map[string]int, error)
+}
+
+// JobFunc adapts a function to the Job interface
+type JobFunc func(ctx context.Context, schedule *storage.Schedule) (map[string]int, error)
+
+// Run calls f
+func (f JobFunc) Run(ctx context.Context, schedule *storage.Schedule) (map[string]int, error) {
+	return f(ctx, schedule)
+}
+
+// Scheduler starts the runs of due schedules
+type Scheduler struct {
+	store   storage.ScheduleStore
+	cfg     config.ScheduleConfig
+	tenants *tenant.Registry
+	now     func() time.Time
+	log     *logger.Logger
+
+	mu   sync.RWMutex
+	jobs map[string]Job
+	wg   sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler for the schedules of store, running jobs
+// with the policies of tenants. Register jobs and call Run to start.
+func NewScheduler(store storage.ScheduleStore, cfg config.ScheduleConfig, tenants *tenant.Registry) *Scheduler {
+	return &Scheduler{
+		store:   store,
+		cfg:     cfg,
+		tenants: tenants,
+		now:     time.Now,
+		log:     logger.GetLogger(),
+		jobs:    make(map[string]Job),
+	}
+}
+
+// RegisterJob sets the job run by schedules of a job type
+func (s *Scheduler) RegisterJob(jobType string, job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[jobType] = job
+}
+
+// Run starts due schedules every minute until ctx is done, then waits for
+// the runs in progress, which are cancelled with ctx
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		s.startDue(ctx)
+		select {
+		case <-ctx.Done():
+			s.wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// startDue claims and starts the runs of every due schedule
+func (s *Scheduler) startDue(ctx context.Context) {
+	now := s.now().UTC()
+	due, err := s.store.DueSchedules(ctx, now)
+	if err != nil {
+		s.log.Error("Failed to list due schedules",
+			"error", err,
+		)
+		return
+	}
+	for _, schedule := range due {
+		s.start(ctx, schedule, now)
+	}
+}
+
+// start claims the due run of a schedule and runs it in the background.
+// The next run is computed from now rather than the due time, so runs
+// missed while no replica was scheduling are not made up.
+func (s *Scheduler) start(ctx context.Context, schedule *storage.Schedule, now time.Time) {
+	next, err := NextRun(schedule.Cron, schedule.Timezone, now)
+	if err != nil {
+		s.log.Error("Failed to compute next schedule run",
+			"error", err,
+			"schedule_id", schedule.ID,
+		)
+		return
+	}
+
+	run := &storage.ScheduleRun{
+		Status:      storage.RunRunning,
+		ScheduledAt: *schedule.NextRunAt,
+		StartedAt:   now,
+	}
+	started, err := s.store.ClaimScheduleRun(ctx, schedule.ID, *schedule.NextRunAt, next, run, now.Add(s.cfg.RunTimeout))
+	if err != nil {
+		s.log.Error("Failed to claim schedule run",
+			"error", err,
+			"schedule_id", schedule.ID,
+		)
+		return
+	}
+	if !started {
+		if schedule.Running(now) {
+			scheduledRuns.WithLabelValues(schedule.Job.Type, "skipped").Inc()
+			s.log.Warn("Skipped schedule run; the previous run is still in progress",
+				"schedule_id", schedule.ID,
+				"tenant_id", schedule.TenantID,
+				"scheduled_at", run.ScheduledAt,
+			)
+		}
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.execute(ctx, schedule, run)
+	}()
+}
+
+// execute runs the job of a schedule and records the outcome of the run
+func (s *Scheduler) execute(ctx context.Context, schedule *storage.Schedule, run *storage.ScheduleRun) {
+	s.mu.RLock()
+	job, ok := s.jobs[schedule.Job.Type]
+	s.mu.RUnlock()
+
+	var err error
+	if ok {
+		jobCtx, cancel := context.WithTimeout(ctx, s.cfg.RunTimeout)
+		jobCtx = tenant.WithTenant(jobCtx, s.tenants.Resolve(schedule.TenantID))
+		run.Counts, err = job.Run(jobCtx, schedule)
+		cancel()
+	} else {
+		err = fmt.Errorf("job type %q is not available", schedule.Job.Type)
+	}
+
+	finished := s.now().UTC()
+	run.FinishedAt = &finished
+	run.Status = storage.RunSucceeded
+	if err != nil {
+		run.Status = storage.RunFailed
+		run.Error = err.Error()
+	}
+	scheduledRuns.WithLabelValues(schedule.Job.Type, run.Status).Inc()
+	s.log.Info("Scheduled job completed",
+		"schedule_id", schedule.ID,
+		"tenant_id", schedule.TenantID,
+		"job", schedule.Job.Type,
+		"status", run.Status,
+		"duration", finished.Sub(run.StartedAt),
+	)
+
+	// The outcome is recorded even when the scheduler is stopping
+	if err := s.store.FinishScheduleRun(context.Background(), schedule.ID, run); err != nil {
+		s.log.Error("Failed to record schedule run",
+			"error", err,
+			"schedule_id", schedule.ID,
+		)
+	}
+}
+
+// Check validates a cron expression and timezone, and that the expression
+// does not run more often than minInterval
+func Check(expr, timezone string, minInterval time.Duration) error {
+	cron, err := ParseCron(expr)
+	if err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("unknown timezone %q", timezone)
+	}
+
+	prev := cron.Next(time.Now().In(loc))
+	if prev.IsZero() {
+		return errors.New("cron expression never matches")
+	}
+	for i := 0; i < intervalSamples && minInterval > 0; i++ {
+		next := cron.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		if next.Sub(prev) < minInterval {
+			return fmt.Errorf("cron expression runs more often than every %s", minInterval)
+		}
+		prev = next
+	}
+	return nil
+}
+
+// NextRun returns the first time after t, in UTC, that a cron expression
+// matches in a timezone
+func NextRun(expr, timezone string, t time.Time) (time.Time, error) {
+	cron, err := ParseCron(expr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unknown timezone %q", timezone)
+	}
+	next := cron.Next(t.In(loc))
+	if next.IsZero() {
+		return time.Time{}, errors.New("cron expression never matches")
+	}
+	return next.UTC(), nil
+}
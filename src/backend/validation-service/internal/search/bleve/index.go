@@ -0,0 +1,246 @@
+// Package bleve implements the detection search index on an embedded Bleve
+// index stored on local disk.
+package bleve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"                           // v2.3.10
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword" // v2.3.10
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/search"
+	"validation-service/internal/storage"
+)
+
+// Index field names
+const (
+	fieldTenant      = "tenant"
+	fieldFormat      = "format"
+	fieldName        = "name"
+	fieldDescription = "description"
+	fieldFields      = "fields"
+	fieldTechniques  = "techniques"
+	fieldTags        = "tags"
+	fieldTagKeys     = "tag_keys"
+	fieldConfidence  = "confidence"
+	fieldUpdatedAt   = "updated_at"
+)
+
+// document is the structure stored in Bleve
+type document struct {
+	Tenant      string    `json:"tenant"`
+	Format      string    `json:"format"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Fields      []string  `json:"fields"`
+	Techniques  []string  `json:"techniques"`
+	Tags        []string  `json:"tags"`
+	TagKeys     []string  `json:"tag_keys"`
+	Confidence  *float64  `json:"confidence,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Index is a search.Index backed by Bleve
+type Index struct {
+	path string
+
+	mu  sync.RWMutex
+	idx bleve.Index
+}
+
+// Open opens the index at path, creating it if it does not exist
+func Open(path string) (*Index, error) {
+	idx, err := bleve.Open(path)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		idx, err = bleve.New(path, newIndexMapping())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening bleve index %s: %w", path, err)
+	}
+	return &Index{path: path, idx: idx}, nil
+}
+
+// newIndexMapping maps exact-match attributes as keywords and descriptive
+// attributes as analyzed text
+func newIndexMapping() mapping.IndexMapping {
+	keywordField := bleve.NewTextFieldMapping()
+	keywordField.Analyzer = keyword.Name
+
+	textField := bleve.NewTextFieldMapping()
+	textField.Analyzer = standard.Name
+
+	doc := bleve.NewDocumentStaticMapping()
+	doc.AddFieldMappingsAt(fieldTenant, keywordField)
+	doc.AddFieldMappingsAt(fieldFormat, keywordField)
+	doc.AddFieldMappingsAt(fieldName, textField)
+	doc.AddFieldMappingsAt(fieldDescription, textField)
+	doc.AddFieldMappingsAt(fieldFields, textField)
+	doc.AddFieldMappingsAt(fieldTechniques, keywordField)
+	doc.AddFieldMappingsAt(fieldTags, keywordField)
+	doc.AddFieldMappingsAt(fieldTagKeys, keywordField)
+	doc.AddFieldMappingsAt(fieldConfidence, bleve.NewNumericFieldMapping())
+	doc.AddFieldMappingsAt(fieldUpdatedAt, bleve.NewDateTimeFieldMapping())
+
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultMapping = doc
+	return indexMapping
+}
+
+// Index adds or replaces documents in a single batch
+func (i *Index) Index(ctx context.Context, docs ...search.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	batch := i.idx.NewBatch()
+	for _, doc := range docs {
+		if err := batch.Index(doc.ID.String(), toDocument(doc)); err != nil {
+			return fmt.Errorf("indexing %s: %w", doc.ID, err)
+		}
+	}
+	return i.idx.Batch(batch)
+}
+
+// Delete removes documents by detection ID
+func (i *Index) Delete(ctx context.Context, ids ...uuid.UUID) error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	batch := i.idx.NewBatch()
+	for _, id := range ids {
+		batch.Delete(id.String())
+	}
+	return i.idx.Batch(batch)
+}
+
+// Search translates the detection query into a Bleve conjunction query
+func (i *Index) Search(ctx context.Context, q storage.DetectionQuery) ([]uuid.UUID, int, error) {
+	req := bleve.NewSearchRequestOptions(buildQuery(q), q.Limit, q.Offset, false)
+	req.SortBy([]string{"-" + fieldUpdatedAt, "_id"})
+
+	i.mu.RLock()
+	res, err := i.idx.SearchInContext(ctx, req)
+	i.mu.RUnlock()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		id, err := uuid.Parse(hit.ID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid document ID %q: %w", hit.ID, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, int(res.Total), nil
+}
+
+// Reset replaces the index with an empty one
+func (i *Index) Reset(ctx context.Context) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := i.idx.Close(); err != nil {
+		return fmt.Errorf("closing index: %w", err)
+	}
+	if err := os.RemoveAll(i.path); err != nil {
+		return fmt.Errorf("removing index: %w", err)
+	}
+	idx, err := bleve.New(i.path, newIndexMapping())
+	if err != nil {
+		return fmt.Errorf("recreating index: %w", err)
+	}
+	i.idx = idx
+	return nil
+}
+
+// Close closes the underlying index
+func (i *Index) Close() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.idx.Close()
+}
+
+// buildQuery converts a detection query to a Bleve query
+func buildQuery(q storage.DetectionQuery) query.Query {
+	conjuncts := []query.Query{termQuery(fieldTenant, tenantTerm(q.TenantID))}
+
+	if q.Format != "" {
+		conjuncts = append(conjuncts, termQuery(fieldFormat, q.Format))
+	}
+	if q.Technique != "" {
+		conjuncts = append(conjuncts, termQuery(fieldTechniques, strings.ToUpper(q.Technique)))
+	}
+	for key, value := range q.Tags {
+		if value == "" {
+			conjuncts = append(conjuncts, termQuery(fieldTagKeys, key))
+			continue
+		}
+		conjuncts = append(conjuncts, termQuery(fieldTags, tagTerm(key, value)))
+	}
+	if q.MinConfidence != nil || q.MaxConfidence != nil {
+		inclusive := true
+		rangeQuery := bleve.NewNumericRangeInclusiveQuery(q.MinConfidence, q.MaxConfidence, &inclusive, &inclusive)
+		rangeQuery.SetField(fieldConfidence)
+		conjuncts = append(conjuncts, rangeQuery)
+	}
+	if q.Text != "" {
+		disjuncts := make([]query.Query, 0, 4)
+		for _, field := range []string{fieldName, fieldDescription, fieldFields} {
+			match := bleve.NewMatchQuery(q.Text)
+			match.SetField(field)
+			disjuncts = append(disjuncts, match)
+		}
+		disjuncts = append(disjuncts, termQuery(fieldTechniques, strings.ToUpper(q.Text)))
+		conjuncts = append(conjuncts, bleve.NewDisjunctionQuery(disjuncts...))
+	}
+
+	return bleve.NewConjunctionQuery(conjuncts...)
+}
+
+func termQuery(field, term string) query.Query {
+	q := bleve.NewTermQuery(term)
+	q.SetField(field)
+	return q
+}
+
+// tenantTerm encodes the tenant so the default tenant ("") is still indexable
+func tenantTerm(tenantID string) string {
+	return "t:" + tenantID
+}
+
+func tagTerm(key, value string) string {
+	return key + "=" + search.NormalizeTagValue(value)
+}
+
+func toDocument(doc search.Document) *document {
+	d := &document{
+		Tenant:      tenantTerm(doc.TenantID),
+		Format:      doc.Format,
+		Name:        doc.Name,
+		Description: doc.Description,
+		Fields:      doc.Fields,
+		Techniques:  doc.Techniques,
+		Confidence:  doc.Confidence,
+		UpdatedAt:   doc.UpdatedAt,
+	}
+	for key, value := range doc.Tags {
+		d.Tags = append(d.Tags, tagTerm(key, value))
+		d.TagKeys = append(d.TagKeys, key)
+	}
+	return d
+}
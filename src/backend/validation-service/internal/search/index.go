@@ -0,0 +1,69 @@
+// Package search provides a pluggable full-text and structured search index
+// over stored detections.
+package search
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/services/validation"
+	"validation-service/internal/storage"
+)
+
+// Document is the indexed representation of a stored detection
+type Document struct {
+	ID          uuid.UUID
+	TenantID    string
+	Format      string
+	Name        string
+	Description string
+	Fields      []string
+	Techniques  []string
+	Tags        map[string]string
+	Confidence  *float64
+	UpdatedAt   time.Time
+}
+
+// Index is a search index over detections. Implementations must apply every
+// criterion of storage.DetectionQuery, scoped to the query tenant, and order
+// results by most recently updated first.
+type Index interface {
+	// Index adds or replaces documents
+	Index(ctx context.Context, docs ...Document) error
+	// Delete removes documents by detection ID
+	Delete(ctx context.Context, ids ...uuid.UUID) error
+	// Search returns matching detection IDs and the total number of matches
+	Search(ctx context.Context, query storage.DetectionQuery) ([]uuid.UUID, int, error)
+	// Reset removes all documents from the index
+	Reset(ctx context.Context) error
+	// Close releases index resources
+	Close() error
+}
+
+// NewDocument builds the index document for a stored detection
+func NewDocument(detection *storage.StoredDetection) Document {
+	doc := Document{
+		ID:          detection.ID(),
+		TenantID:    detection.TenantID,
+		Name:        detection.Name,
+		Description: detection.Description,
+		Techniques:  detection.Techniques,
+		Tags:        detection.Tags,
+		Confidence:  detection.ConfidenceScore,
+		UpdatedAt:   detection.UpdatedAt,
+	}
+	if detection.Detection != nil {
+		doc.Format = detection.Detection.Format
+		doc.Fields = validation.ExtractFieldNames(detection.Detection)
+	}
+	return doc
+}
+
+// NormalizeTagValue returns the case-insensitive form of a tag value used for
+// index matching
+func NormalizeTagValue(value string) string {
+	return strings.ToLower(value)
+}
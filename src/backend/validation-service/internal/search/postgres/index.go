@@ -0,0 +1,224 @@
+// Package postgres implements the detection search index with PostgreSQL
+// full-text search.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/search"
+	"validation-service/internal/storage"
+)
+
+// schema creates the search table. The tsvector is computed on write so the
+// text search configuration is applied consistently to every column.
+const schema = `
+CREATE TABLE IF NOT EXISTS detection_search_index (
+	detection_id UUID PRIMARY KEY,
+	tenant_id    TEXT NOT NULL,
+	format       TEXT NOT NULL,
+	name         TEXT NOT NULL DEFAULT '',
+	description  TEXT NOT NULL DEFAULT '',
+	fields       TEXT[] NOT NULL DEFAULT '{}',
+	techniques   TEXT[] NOT NULL DEFAULT '{}',
+	tags         JSONB NOT NULL DEFAULT '{}',
+	confidence   DOUBLE PRECISION,
+	updated_at   TIMESTAMPTZ NOT NULL,
+	document     TSVECTOR NOT NULL
+);
+CREATE INDEX IF NOT EXISTS detection_search_document_idx ON detection_search_index USING GIN (document);
+CREATE INDEX IF NOT EXISTS detection_search_tags_idx ON detection_search_index USING GIN (tags);
+CREATE INDEX IF NOT EXISTS detection_search_techniques_idx ON detection_search_index USING GIN (techniques);
+CREATE INDEX IF NOT EXISTS detection_search_tenant_idx ON detection_search_index (tenant_id, updated_at DESC);
+`
+
+const upsertDocument = `
+INSERT INTO detection_search_index
+	(detection_id, tenant_id, format, name, description, fields, techniques, tags, confidence, updated_at, document)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+	setweight(to_tsvector('simple', $4), 'A') ||
+	setweight(to_tsvector('english', $5), 'B') ||
+	setweight(to_tsvector('simple', $11), 'C'))
+ON CONFLICT (detection_id) DO UPDATE SET
+	tenant_id = EXCLUDED.tenant_id,
+	format = EXCLUDED.format,
+	name = EXCLUDED.name,
+	description = EXCLUDED.description,
+	fields = EXCLUDED.fields,
+	techniques = EXCLUDED.techniques,
+	tags = EXCLUDED.tags,
+	confidence = EXCLUDED.confidence,
+	updated_at = EXCLUDED.updated_at,
+	document = EXCLUDED.document`
+
+// Index is a search.Index backed by a PostgreSQL table
+type Index struct {
+	db *sql.DB
+}
+
+// New creates the index, ensuring its table exists
+func New(ctx context.Context, db *sql.DB) (*Index, error) {
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		return nil, fmt.Errorf("creating search schema: %w", err)
+	}
+	return &Index{db: db}, nil
+}
+
+// Index upserts documents in a single transaction
+func (i *Index) Index(ctx context.Context, docs ...search.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, upsertDocument)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, doc := range docs {
+		tags := make(map[string]string, len(doc.Tags))
+		for key, value := range doc.Tags {
+			tags[key] = search.NormalizeTagValue(value)
+		}
+		tagsJSON, err := json.Marshal(tags)
+		if err != nil {
+			return err
+		}
+		keywords := strings.Join(append(append([]string{}, doc.Fields...), doc.Techniques...), " ")
+
+		if _, err := stmt.ExecContext(ctx,
+			doc.ID, doc.TenantID, doc.Format, doc.Name, doc.Description,
+			textArray(doc.Fields), textArray(doc.Techniques), tagsJSON,
+			doc.Confidence, doc.UpdatedAt, keywords,
+		); err != nil {
+			return fmt.Errorf("indexing %s: %w", doc.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes documents by detection ID
+func (i *Index) Delete(ctx context.Context, ids ...uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	values := make([]string, len(ids))
+	for n, id := range ids {
+		values[n] = id.String()
+	}
+	_, err := i.db.ExecContext(ctx,
+		`DELETE FROM detection_search_index WHERE detection_id = ANY($1::uuid[])`,
+		textArray(values))
+	return err
+}
+
+// Search builds a parameterized SQL query from the detection query
+func (i *Index) Search(ctx context.Context, q storage.DetectionQuery) ([]uuid.UUID, int, error) {
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{q.TenantID}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if q.Format != "" {
+		conditions = append(conditions, "format = "+arg(q.Format))
+	}
+	if q.Technique != "" {
+		conditions = append(conditions, arg(strings.ToUpper(q.Technique))+" = ANY(techniques)")
+	}
+	for key, value := range q.Tags {
+		if value == "" {
+			conditions = append(conditions, "tags ? "+arg(key))
+			continue
+		}
+		tagJSON, err := json.Marshal(map[string]string{key: search.NormalizeTagValue(value)})
+		if err != nil {
+			return nil, 0, err
+		}
+		conditions = append(conditions, "tags @> "+arg(string(tagJSON))+"::jsonb")
+	}
+	if q.MinConfidence != nil {
+		conditions = append(conditions, "confidence >= "+arg(*q.MinConfidence))
+	}
+	if q.MaxConfidence != nil {
+		conditions = append(conditions, "confidence <= "+arg(*q.MaxConfidence))
+	}
+	if q.Text != "" {
+		text := arg(q.Text)
+		conditions = append(conditions, fmt.Sprintf(
+			"(document @@ websearch_to_tsquery('simple', %[1]s) OR document @@ websearch_to_tsquery('english', %[1]s))", text))
+	}
+
+	sqlQuery := fmt.Sprintf(`
+SELECT detection_id, COUNT(*) OVER () AS total
+FROM detection_search_index
+WHERE %s
+ORDER BY updated_at DESC, detection_id
+LIMIT %s OFFSET %s`, strings.Join(conditions, " AND "), arg(q.Limit), arg(q.Offset))
+
+	rows, err := i.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0, q.Limit)
+	total := 0
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id, &total); err != nil {
+			return nil, 0, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// An offset past the last match returns no rows and therefore no total
+	if len(ids) == 0 && q.Offset > 0 {
+		err := i.db.QueryRowContext(ctx,
+			fmt.Sprintf("SELECT COUNT(*) FROM detection_search_index WHERE %s", strings.Join(conditions, " AND ")),
+			args[:len(args)-2]...).Scan(&total)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return ids, total, nil
+}
+
+// Reset removes all documents
+func (i *Index) Reset(ctx context.Context) error {
+	_, err := i.db.ExecContext(ctx, `TRUNCATE detection_search_index`)
+	return err
+}
+
+// Close is a no-op; the connection pool is owned by the caller
+func (i *Index) Close() error {
+	return nil
+}
+
+// textArray formats values as a PostgreSQL text array literal
+func textArray(values []string) string {
+	quoted := make([]string, len(values))
+	for n, value := range values {
+		value = strings.ReplaceAll(value, `\`, `\\`)
+		value = strings.ReplaceAll(value, `"`, `\"`)
+		quoted[n] = `"` + value + `"`
+	}
+	return "{" + strings.Join(quoted, ",") + "}"
+}
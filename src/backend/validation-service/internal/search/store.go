@@ -0,0 +1,203 @@
+// Package search provides a pluggable full-text and structured search index
+// over stored detections.
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"validation-service/internal/storage"
+	"validation-service/pkg/logger"
+)
+
+// reindexBatchSize bounds the number of documents written per index call
+const reindexBatchSize = 500
+
+// IndexedStore wraps a storage.DetectionStore, keeping an Index up to date on
+// every write and answering searches from the index.
+type IndexedStore struct {
+	storage.DetectionStore
+	index Index
+	log   *logger.Logger
+
+	reindexMu sync.Mutex
+	status    ReindexStatus
+}
+
+// ReindexStatus reports the progress of the most recent reindex run
+type ReindexStatus struct {
+	Running    bool       `json:"running"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Indexed    int        `json:"indexed"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// ErrReindexRunning is returned when a reindex is requested while one is in progress
+var ErrReindexRunning = errors.New("reindex already running")
+
+// NewIndexedStore creates a store that maintains index for store
+func NewIndexedStore(store storage.DetectionStore, index Index) *IndexedStore {
+	return &IndexedStore{
+		DetectionStore: store,
+		index:          index,
+		log:            logger.GetLogger(),
+	}
+}
+
+// CreateDetection stores the detection and indexes it
+func (s *IndexedStore) CreateDetection(ctx context.Context, detection *storage.StoredDetection) error {
+	if err := s.DetectionStore.CreateDetection(ctx, detection); err != nil {
+		return err
+	}
+	if err := s.index.Index(ctx, NewDocument(detection)); err != nil {
+		return fmt.Errorf("indexing detection %s: %w", detection.ID(), err)
+	}
+	return nil
+}
+
+// UpdateDetection stores the new version and re-indexes the detection
+func (s *IndexedStore) UpdateDetection(ctx context.Context, detection *storage.StoredDetection) error {
+	if err := s.DetectionStore.UpdateDetection(ctx, detection); err != nil {
+		return err
+	}
+	if err := s.index.Index(ctx, NewDocument(detection)); err != nil {
+		return fmt.Errorf("indexing detection %s: %w", detection.ID(), err)
+	}
+	return nil
+}
+
+// UpdateTags applies the tag operation and re-indexes the changed detections
+func (s *IndexedStore) UpdateTags(ctx context.Context, op storage.TagOperation) (int, error) {
+	updated, err := s.DetectionStore.UpdateTags(ctx, op)
+	if err != nil || updated == 0 {
+		return updated, err
+	}
+
+	docs := make([]Document, 0, updated)
+	for _, id := range op.DetectionIDs {
+		detection, err := s.DetectionStore.GetDetection(ctx, op.TenantID, id)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return updated, fmt.Errorf("loading detection %s for indexing: %w", id, err)
+		}
+		docs = append(docs, NewDocument(detection))
+	}
+	if err := s.index.Index(ctx, docs...); err != nil {
+		return updated, fmt.Errorf("indexing tagged detections: %w", err)
+	}
+	return updated, nil
+}
+
+// SearchDetections resolves the query against the index and loads the
+// matching detections from the store
+func (s *IndexedStore) SearchDetections(ctx context.Context, query storage.DetectionQuery) ([]*storage.StoredDetection, int, error) {
+	if err := query.Normalize(); err != nil {
+		return nil, 0, err
+	}
+
+	ids, total, err := s.index.Search(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("searching index: %w", err)
+	}
+
+	detections := make([]*storage.StoredDetection, 0, len(ids))
+	for _, id := range ids {
+		detection, err := s.DetectionStore.GetDetection(ctx, query.TenantID, id)
+		if errors.Is(err, storage.ErrNotFound) {
+			// Index is ahead of the store; the next reindex drops the entry
+			continue
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("loading detection %s: %w", id, err)
+		}
+		detections = append(detections, detection)
+	}
+	return detections, total, nil
+}
+
+// StartReindex rebuilds the index from the store in the background. Progress
+// is available from ReindexStatus.
+func (s *IndexedStore) StartReindex() error {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+
+	if s.status.Running {
+		return ErrReindexRunning
+	}
+	now := time.Now().UTC()
+	s.status = ReindexStatus{Running: true, StartedAt: &now}
+
+	go func() {
+		indexed, err := s.Reindex(context.Background())
+
+		s.reindexMu.Lock()
+		defer s.reindexMu.Unlock()
+		finished := time.Now().UTC()
+		s.status.Running = false
+		s.status.FinishedAt = &finished
+		s.status.Indexed = indexed
+		if err != nil {
+			s.status.Error = err.Error()
+			s.log.Error("Search reindex failed",
+				"error", err,
+				"indexed", indexed,
+			)
+			return
+		}
+		s.log.Info("Search reindex completed",
+			"indexed", indexed,
+			"duration", finished.Sub(*s.status.StartedAt),
+		)
+	}()
+	return nil
+}
+
+// ReindexStatus returns the status of the current or last reindex run
+func (s *IndexedStore) ReindexStatus() ReindexStatus {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+	return s.status
+}
+
+// Reindex clears the index and indexes every stored detection, returning the
+// number of documents written
+func (s *IndexedStore) Reindex(ctx context.Context) (int, error) {
+	if err := s.index.Reset(ctx); err != nil {
+		return 0, fmt.Errorf("resetting index: %w", err)
+	}
+
+	indexed := 0
+	batch := make([]Document, 0, reindexBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.index.Index(ctx, batch...); err != nil {
+			return err
+		}
+		indexed += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	err := s.DetectionStore.ScanDetections(ctx, func(detection *storage.StoredDetection) error {
+		batch = append(batch, NewDocument(detection))
+		if len(batch) == reindexBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return indexed, fmt.Errorf("reindexing detections: %w", err)
+	}
+	return indexed, nil
+}
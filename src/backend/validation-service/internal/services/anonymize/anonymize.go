@@ -0,0 +1,417 @@
+// Package anonymize replaces tenant-specific literals in rule content and
+// validation results, such as hostnames, usernames, internal IP addresses
+// and index names, with placeholders, so results can be shared outside the
+// tenant. A literal is replaced by the same placeholder everywhere it occurs
+// in the output of one Anonymizer.
+package anonymize
+
+import (
+    "encoding/json"
+    "fmt"
+    "net"
+    "regexp"
+    "sort"
+    "strings"
+
+    "validation-service/internal/models"
+)
+
+// Kinds of anonymized literals, used as placeholder prefixes
+const (
+    KindHost  = "HOST"
+    KindUser  = "USER"
+    KindIndex = "INDEX"
+    KindIP    = "IP"
+)
+
+// minLiteralLength is the length below which a field value is not learned;
+// shorter values would replace unrelated words in issue messages
+const minLiteralLength = 3
+
+var (
+    // fieldValueRegex matches a comparison of a field with a value in SPL,
+    // KQL, AQL, Lucene and similar query languages
+    fieldValueRegex = regexp.MustCompile(`(?i)["']?\b([A-Za-z_][\w.]*)["']?\s*(?:==|!=|=~|!~|=|:|\s(?:has|has_cs|contains|startswith|endswith|like|ilike)\s)\s*("[^"]*"|'[^']*'|[^\s|),;\]]+)`)
+    // fieldListRegex matches a field compared with a parenthesized value
+    // list, such as `user IN ("a", "b")`
+    fieldListRegex = regexp.MustCompile(`(?i)["']?\b([A-Za-z_][\w.]*)["']?\s+(?:in~?|has_any|not\s+in)\s*\(([^)]*)\)`)
+    // sigmaKeyRegex matches a Sigma detection key with its modifiers and
+    // inline value
+    sigmaKeyRegex = regexp.MustCompile(`^(\s*)(?:-\s+)?([A-Za-z_][\w.]*)(?:\|[\w|]+)?\s*:\s*(.*)$`)
+    // sigmaListItemRegex matches an item of a YAML block list
+    sigmaListItemRegex = regexp.MustCompile(`^(\s*)-\s+(.+)$`)
+    // listValueRegex matches the values of an inline list
+    listValueRegex = regexp.MustCompile(`"[^"]*"|'[^']*'|[^\s,\[\]]+`)
+    // literalRegex matches values that can be learned: they start and end
+    // with a word character, so they can be matched as whole words, and
+    // contain no wildcards or variables
+    literalRegex = regexp.MustCompile("^\\w(?:[^*%$`]*\\w)?$")
+    // ipv4Regex matches IPv4 addresses with an optional prefix length
+    ipv4Regex = regexp.MustCompile(`\b(\d{1,3}(?:\.\d{1,3}){3})(/\d{1,2})?\b`)
+    // ipv6Regex matches candidate IPv6 addresses with an optional prefix
+    // length; candidates are confirmed with net.ParseIP
+    ipv6Regex = regexp.MustCompile(`(?i)\b([0-9a-f]{1,4}(?::[0-9a-f]{0,4}){2,7})(/\d{1,3})?`)
+    // hostnameRegex matches dotted hostnames
+    hostnameRegex = regexp.MustCompile(`(?i)\b[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?(?:\.[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?)+\b`)
+)
+
+// fieldKinds maps lower-cased field names to the kind of their values. Field
+// names with a dotted prefix, such as `host.name`, are listed in full.
+var fieldKinds = map[string]string{
+    "host": KindHost, "hostname": KindHost, "host.name": KindHost, "host.hostname": KindHost,
+    "computer": KindHost, "computername": KindHost, "devicename": KindHost,
+    "dest_host": KindHost, "src_host": KindHost, "dest_nt_host": KindHost, "src_nt_host": KindHost,
+    "dvc": KindHost, "dvc_host": KindHost, "workstation": KindHost, "workstationname": KindHost,
+    "machinename": KindHost, "sourcehostname": KindHost, "destinationhostname": KindHost,
+
+    "user": KindUser, "username": KindUser, "user_name": KindUser, "user.name": KindUser,
+    "src_user": KindUser, "dest_user": KindUser, "targetusername": KindUser, "subjectusername": KindUser,
+    "accountname": KindUser, "account": KindUser, "samaccountname": KindUser,
+    "userprincipalname": KindUser, "initiatingprocessaccountname": KindUser, "caller": KindUser,
+
+    "index": KindIndex, "_index": KindIndex,
+}
+
+// sharedIndexes are Splunk indexes every deployment has; they identify no
+// tenant and are kept
+var sharedIndexes = map[string]bool{
+    "main": true, "summary": true, "history": true, "notable": true, "risk": true,
+}
+
+// wellKnownNetworks are address blocks rules commonly name as a whole, such
+// as the RFC 1918 ranges; they identify no tenant and are kept
+var wellKnownNetworks = map[string]bool{
+    "10.0.0.0/8": true, "172.16.0.0/12": true, "192.168.0.0/16": true,
+    "100.64.0.0/10": true, "169.254.0.0/16": true, "127.0.0.0/8": true,
+    "fc00::/7": true, "fe80::/10": true,
+}
+
+// internalSuffixes are DNS suffixes reserved for or commonly used on private
+// networks; names ending in them are replaced as hostnames
+var internalSuffixes = []string{
+    ".local", ".localdomain", ".internal", ".intranet", ".corp", ".lan", ".home.arpa",
+}
+
+// Anonymizer learns tenant-specific literals from rule content and replaces
+// them with placeholders. It is not safe for concurrent use.
+type Anonymizer struct {
+    // literals maps lower-cased literals to their placeholder
+    literals map[string]string
+    // addresses maps IP addresses to their placeholder address
+    addresses map[string]string
+    // domains are DNS suffixes learned from hostnames; names ending in them
+    // are replaced as hostnames
+    domains map[string]bool
+    counts  map[string]int
+    // pattern matches every learned literal; rebuilt when literals change
+    pattern *regexp.Regexp
+    dirty   bool
+}
+
+// New creates an anonymizer that has learned no literals
+func New() *Anonymizer {
+    return &Anonymizer{
+        literals:  make(map[string]string),
+        addresses: make(map[string]string),
+        domains:   make(map[string]bool),
+        counts:    make(map[string]int),
+    }
+}
+
+// Learn records the hostnames, usernames and index names compared with
+// host, user and index fields in text, which may be rule content of any
+// format or an issue message
+func (a *Anonymizer) Learn(text string) {
+    for _, match := range fieldValueRegex.FindAllStringSubmatch(text, -1) {
+        a.learnValue(match[1], match[2])
+    }
+    for _, match := range fieldListRegex.FindAllStringSubmatch(text, -1) {
+        for _, value := range listValueRegex.FindAllString(match[2], -1) {
+            a.learnValue(match[1], value)
+        }
+    }
+    a.learnSigma(text)
+}
+
+// learnSigma records the values of host, user and index keys of a Sigma
+// rule, including the items of block lists below a key
+func (a *Anonymizer) learnSigma(content string) {
+    listField, listIndent := "", -1
+    for _, line := range strings.Split(content, "\n") {
+        if listField != "" {
+            if match := sigmaListItemRegex.FindStringSubmatch(line); match != nil && len(match[1]) >= listIndent {
+                a.learnValue(listField, match[2])
+                continue
+            }
+            listField = ""
+        }
+        match := sigmaKeyRegex.FindStringSubmatch(line)
+        if match == nil {
+            continue
+        }
+        value := strings.TrimSpace(match[3])
+        switch {
+        case value == "":
+            listField, listIndent = match[2], len(match[1])
+        case strings.HasPrefix(value, "["):
+            for _, item := range listValueRegex.FindAllString(value, -1) {
+                a.learnValue(match[2], item)
+            }
+        default:
+            a.learnValue(match[2], value)
+        }
+    }
+}
+
+// learnValue records value when field holds hostnames, usernames or index
+// names. Quotes and leading and trailing wildcards are removed first; values
+// that still contain wildcards or variables are not learned.
+func (a *Anonymizer) learnValue(field, value string) {
+    kind, ok := fieldKinds[strings.ToLower(field)]
+    if !ok {
+        return
+    }
+    value = strings.Trim(strings.TrimSpace(value), `"'`)
+    value = strings.Trim(value, "*%")
+    if len(value) < minLiteralLength || !literalRegex.MatchString(value) {
+        return
+    }
+    if kind == KindIndex && (sharedIndexes[strings.ToLower(value)] || strings.HasPrefix(value, "_")) {
+        return
+    }
+    if kind == KindHost && net.ParseIP(value) != nil {
+        return
+    }
+    // Windows and UPN account names are learned with and without their
+    // domain, which often appears on its own in other fields
+    if kind == KindUser {
+        if i := strings.LastIndex(value, `\`); i >= 0 && len(value)-i-1 >= minLiteralLength {
+            a.addLiteral(value, kind)
+            value = value[i+1:]
+        }
+    }
+    if kind == KindHost {
+        if i := strings.Index(value, "."); i > 0 && i < len(value)-1 {
+            a.domains[strings.ToLower(value[i:])] = true
+        }
+    }
+    a.addLiteral(value, kind)
+}
+
+// addLiteral assigns the next placeholder of kind to literal unless it has
+// one
+func (a *Anonymizer) addLiteral(literal, kind string) {
+    key := strings.ToLower(literal)
+    if _, ok := a.literals[key]; ok {
+        return
+    }
+    a.literals[key] = a.next(kind)
+    a.dirty = true
+}
+
+// next returns the next placeholder of kind, such as HOST_3
+func (a *Anonymizer) next(kind string) string {
+    a.counts[kind]++
+    return fmt.Sprintf("%s_%d", kind, a.counts[kind])
+}
+
+// Text replaces the learned literals, private IP addresses and hostnames in
+// learned or internal domains in text
+func (a *Anonymizer) Text(text string) string {
+    if text == "" {
+        return text
+    }
+    text = a.replaceIPs(text)
+    text = a.replaceDomains(text)
+    if pattern := a.literalPattern(); pattern != nil {
+        text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+            return a.literals[strings.ToLower(match)]
+        })
+    }
+    return text
+}
+
+// literalPattern returns a pattern matching every learned literal as a whole
+// word, longest first, or nil when none has been learned
+func (a *Anonymizer) literalPattern() *regexp.Regexp {
+    if !a.dirty {
+        return a.pattern
+    }
+    literals := make([]string, 0, len(a.literals))
+    for literal := range a.literals {
+        literals = append(literals, regexp.QuoteMeta(literal))
+    }
+    sort.Slice(literals, func(i, j int) bool {
+        if len(literals[i]) != len(literals[j]) {
+            return len(literals[i]) > len(literals[j])
+        }
+        return literals[i] < literals[j]
+    })
+    a.pattern = nil
+    if len(literals) > 0 {
+        a.pattern = regexp.MustCompile(`(?i)\b(?:` + strings.Join(literals, "|") + `)\b`)
+    }
+    a.dirty = false
+    return a.pattern
+}
+
+// replaceIPs replaces private, link-local and shared address space IPv4 and
+// IPv6 addresses with placeholder addresses from the benchmarking and
+// documentation ranges. Well-known networks, such as 10.0.0.0/8, are kept.
+func (a *Anonymizer) replaceIPs(text string) string {
+    replace := func(match []string, full string) string {
+        ip := net.ParseIP(match[1])
+        if ip == nil || !isInternal(ip) {
+            return full
+        }
+        if match[2] != "" {
+            if _, network, err := net.ParseCIDR(match[1] + match[2]); err == nil && wellKnownNetworks[network.String()] && network.IP.Equal(ip) {
+                return full
+            }
+        }
+        return a.ipPlaceholder(ip) + match[2]
+    }
+    text = ipv4Regex.ReplaceAllStringFunc(text, func(full string) string {
+        return replace(ipv4Regex.FindStringSubmatch(full), full)
+    })
+    return ipv6Regex.ReplaceAllStringFunc(text, func(full string) string {
+        match := ipv6Regex.FindStringSubmatch(full)
+        if strings.Count(match[1], ":") < 2 || net.ParseIP(match[1]).To4() != nil {
+            return full
+        }
+        return replace(match, full)
+    })
+}
+
+// ipPlaceholder returns the placeholder address of ip: the n-th address of
+// 198.18.0.0/15 for IPv4 and of 2001:db8::/32 for IPv6
+func (a *Anonymizer) ipPlaceholder(ip net.IP) string {
+    key := ip.String()
+    if placeholder, ok := a.addresses[key]; ok {
+        return placeholder
+    }
+    a.counts[KindIP]++
+    n := a.counts[KindIP]
+    var placeholder string
+    if ip.To4() != nil {
+        placeholder = fmt.Sprintf("198.%d.%d.%d", 18+n/65536%2, n/256%256, n%256)
+    } else {
+        placeholder = fmt.Sprintf("2001:db8::%x", n)
+    }
+    a.addresses[key] = placeholder
+    return placeholder
+}
+
+// isInternal reports whether ip is in private, link-local or carrier-grade
+// NAT address space
+func isInternal(ip net.IP) bool {
+    if ip.IsPrivate() || ip.IsLinkLocalUnicast() {
+        return true
+    }
+    ip4 := ip.To4()
+    return ip4 != nil && ip4[0] == 100 && ip4[1]&0xc0 == 64
+}
+
+// replaceDomains replaces hostnames in a learned domain or an internal DNS
+// suffix with host placeholders
+func (a *Anonymizer) replaceDomains(text string) string {
+    return hostnameRegex.ReplaceAllStringFunc(text, func(name string) string {
+        lower := strings.ToLower(name)
+        if placeholder, ok := a.literals[lower]; ok {
+            return placeholder
+        }
+        if !a.internalName(lower) {
+            return name
+        }
+        a.literals[lower] = a.next(KindHost)
+        a.dirty = true
+        return a.literals[lower]
+    })
+}
+
+// internalName reports whether the lower-cased hostname is in a learned
+// domain or ends in an internal DNS suffix
+func (a *Anonymizer) internalName(name string) bool {
+    for _, suffix := range internalSuffixes {
+        if strings.HasSuffix(name, suffix) {
+            return true
+        }
+    }
+    for domain := range a.domains {
+        if strings.HasSuffix(name, domain) && len(name) > len(domain) {
+            return true
+        }
+    }
+    return false
+}
+
+// Detection returns a copy of detection with its content anonymized
+func (a *Anonymizer) Detection(detection *models.Detection) *models.Detection {
+    if detection == nil {
+        return nil
+    }
+    anonymized := *detection
+    anonymized.Content = a.Text(detection.Content)
+    return &anonymized
+}
+
+// LearnResult records the literals compared with host, user and index
+// fields in the issue messages of result
+func (a *Anonymizer) LearnResult(result *models.ValidationResult) {
+    for _, issue := range result.Issues {
+        a.Learn(issue.Message)
+    }
+}
+
+// Result returns a copy of result with every string anonymized: issue
+// messages, locations and remediations, lint findings and format-specific
+// details. Literals in its issue messages are learned first.
+func (a *Anonymizer) Result(result *models.ValidationResult) (*models.ValidationResult, error) {
+    if result == nil {
+        return nil, nil
+    }
+    a.LearnResult(result)
+
+    var anonymized models.ValidationResult
+    if err := a.transcode(result, &anonymized); err != nil {
+        return nil, err
+    }
+    return &anonymized, nil
+}
+
+// transcode encodes in as JSON, anonymizes every string value and decodes
+// the outcome into out. Map keys, which name fields and details, are kept.
+func (a *Anonymizer) transcode(in, out interface{}) error {
+    data, err := json.Marshal(in)
+    if err != nil {
+        return fmt.Errorf("encoding for anonymization: %w", err)
+    }
+    var tree interface{}
+    if err := json.Unmarshal(data, &tree); err != nil {
+        return fmt.Errorf("decoding for anonymization: %w", err)
+    }
+    if data, err = json.Marshal(a.value(tree)); err != nil {
+        return fmt.Errorf("encoding anonymized value: %w", err)
+    }
+    return json.Unmarshal(data, out)
+}
+
+// value anonymizes the strings of a decoded JSON value
+func (a *Anonymizer) value(v interface{}) interface{} {
+    switch v := v.(type) {
+    case string:
+        return a.Text(v)
+    case []interface{}:
+        for i := range v {
+            v[i] = a.value(v[i])
+        }
+        return v
+    case map[string]interface{}:
+        for key := range v {
+            v[key] = a.value(v[key])
+        }
+        return v
+    default:
+        return v
+    }
+}
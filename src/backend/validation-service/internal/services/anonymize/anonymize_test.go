@@ -0,0 +1,121 @@
+package anonymize
+
+import (
+    "strings"
+    "testing"
+
+    "validation-service/internal/models"
+)
+
+func TestText(t *testing.T) {
+    tests := []struct {
+        name    string
+        learn   string
+        text    string
+        want    string
+        notWant []string
+    }{
+        {
+            name:  "spl fields",
+            learn: `index=acme_prod host="web01.acme.example" user=jdoe`,
+            text:  `index=acme_prod host="web01.acme.example" user=jdoe | stats count by JDOE`,
+            want:  `index=INDEX_1 host="HOST_1" user=USER_1 | stats count by USER_1`,
+        },
+        {
+            name:  "shared index kept",
+            learn: `index=main sourcetype=syslog`,
+            text:  `index=main sourcetype=syslog`,
+            want:  `index=main sourcetype=syslog`,
+        },
+        {
+            name:  "wildcards and variables not learned",
+            learn: `user=ad*min host=$dest$`,
+            text:  `user=ad*min host=$dest$ admin`,
+            want:  `user=ad*min host=$dest$ admin`,
+        },
+        {
+            name:  "value lists",
+            learn: `TargetUserName in ("alice", "bobby")`,
+            text:  `alice and bobby logged on`,
+            want:  `USER_1 and USER_2 logged on`,
+        },
+        {
+            name:  "windows account",
+            learn: `user="ACME\svc_backup"`,
+            text:  `ACME\svc_backup and svc_backup`,
+            want:  `USER_1 and USER_2`,
+        },
+        {
+            name:  "sigma block list",
+            learn: "detection:\n  selection:\n    Computer:\n      - dc01\n      - dc02\n  condition: selection\n",
+            text:  "Computer: dc01 or dc02",
+            want:  "Computer: HOST_1 or HOST_2",
+        },
+        {
+            name:    "private addresses",
+            text:    "src_ip=10.1.2.3 or src_ip=10.1.2.3 or dest_ip=8.8.8.8 or net=10.0.0.0/8 or fd12::1",
+            want:    "src_ip=198.18.0.1 or src_ip=198.18.0.1 or dest_ip=8.8.8.8 or net=10.0.0.0/8 or 2001:db8::2",
+            notWant: []string{"10.1.2.3", "fd12::1"},
+        },
+        {
+            name:  "learned and internal domains",
+            learn: `host=web01.acme.example`,
+            text:  `db02.acme.example, fs.corp and www.example.org`,
+            want:  `HOST_2, HOST_3 and www.example.org`,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            a := New()
+            a.Learn(tt.learn)
+            got := a.Text(tt.text)
+            if got != tt.want {
+                t.Errorf("Text() = %q, want %q", got, tt.want)
+            }
+            for _, literal := range tt.notWant {
+                if strings.Contains(got, literal) {
+                    t.Errorf("Text() kept %q", literal)
+                }
+            }
+        })
+    }
+}
+
+func TestResult(t *testing.T) {
+    a := New()
+    // Detection replaces literals without learning them
+    if got := a.Detection(&models.Detection{Content: "user=jdoe"}).Content; got != "user=jdoe" {
+        t.Errorf("Detection() before learning = %q", got)
+    }
+
+    result := &models.ValidationResult{
+        Issues: []models.ValidationIssue{{
+            Message:     `Field host="fs01.acme.local" is not indexed for user=jdoe`,
+            Location:    "search",
+            Remediation: "Replace jdoe with a role",
+        }},
+        FormatSpecificDetails: map[string]interface{}{
+            "hosts": []interface{}{"fs01.acme.local", "10.20.30.40"},
+        },
+    }
+    anonymized, err := a.Result(result)
+    if err != nil {
+        t.Fatalf("Result() error = %v", err)
+    }
+
+    issue := anonymized.Issues[0]
+    if issue.Message != `Field host="HOST_1" is not indexed for user=USER_1` || issue.Remediation != "Replace USER_1 with a role" {
+        t.Errorf("issue = %q / %q", issue.Message, issue.Remediation)
+    }
+    hosts := anonymized.FormatSpecificDetails["hosts"].([]interface{})
+    if hosts[0] != "HOST_1" || hosts[1] != "198.18.0.1" {
+        t.Errorf("details hosts = %v", hosts)
+    }
+    if result.Issues[0].Remediation != "Replace jdoe with a role" {
+        t.Error("Result() modified its input")
+    }
+    if got := a.Detection(&models.Detection{Content: "user=jdoe"}).Content; got != "user=USER_1" {
+        t.Errorf("Detection() after learning = %q", got)
+    }
+}
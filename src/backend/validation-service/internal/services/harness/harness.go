@@ -0,0 +1,402 @@
+// Package harness runs detections against sample log events to check which
+// events they match, so true and false positives can be tested before a rule
+// is deployed. Sigma rules, KQL queries (including Sentinel rules) and the
+// filtering subset of SPL are evaluated in-process: evaluation reads only the
+// submitted events, regular expressions use the linear-time RE2 engine, and
+// every run is bounded by the event limits and a deadline.
+package harness
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+
+    "gopkg.in/yaml.v3" // v3.0.1
+
+    "validation-service/internal/models"
+)
+
+var (
+    // ErrUnsupportedFormat is returned for formats the harness cannot evaluate
+    ErrUnsupportedFormat = errors.New("format cannot be evaluated against events")
+    // ErrNoEvents is returned when no sample events were submitted
+    ErrNoEvents = errors.New("no sample events")
+    // ErrExpectedMismatch is returned when the expected outcomes do not
+    // match the events one to one
+    ErrExpectedMismatch = errors.New("expected outcomes must match the events one to one")
+)
+
+// maxRegexLength bounds regular expressions compiled from detections
+const maxRegexLength = 4096
+
+// maxExpressionDepth bounds the nesting of parsed predicates and conditions
+const maxExpressionDepth = 100
+
+// Event is a sample log event. Nested objects are addressed with dotted
+// field names, such as process.name.
+type Event map[string]interface{}
+
+// Limits bound one harness run
+type Limits struct {
+    // MaxEvents bounds the number of events of a run
+    MaxEvents int
+    // MaxEventSize bounds the size of one JSON event in bytes
+    MaxEventSize int
+}
+
+// EventResult reports whether one event matched
+type EventResult struct {
+    // Index is the 0-based position of the event in the submission
+    Index   int   `json:"index"`
+    Matched bool  `json:"matched"`
+    Event   Event `json:"event"`
+    // Expected is the expected outcome submitted for the event, if any
+    Expected *bool `json:"expected,omitempty"`
+}
+
+// Report is the outcome of running a detection against sample events
+type Report struct {
+    Format  string        `json:"format"`
+    Total   int           `json:"total"`
+    Matched int           `json:"matched"`
+    Events  []EventResult `json:"events"`
+    // Expectations compares matches with the expected outcomes; it is only
+    // set when expectations were submitted
+    Expectations *Expectations `json:"expectations,omitempty"`
+    // Notes lists parts of the detection that were not evaluated, such as
+    // transforming SPL commands, so matches may differ from the SIEM
+    Notes []string `json:"notes,omitempty"`
+}
+
+// Expectations counts matches against submitted expected outcomes
+type Expectations struct {
+    TruePositives  int `json:"true_positives"`
+    FalsePositives int `json:"false_positives"`
+    TrueNegatives  int `json:"true_negatives"`
+    FalseNegatives int `json:"false_negatives"`
+    // Failed lists the indexes of events whose outcome differs from the
+    // expected one
+    Failed []int `json:"failed"`
+}
+
+// matcher decides whether an event matches a compiled detection
+type matcher interface {
+    match(event Event) (bool, error)
+}
+
+// compiled is a detection prepared for evaluation
+type compiled struct {
+    matcher matcher
+    notes   []string
+}
+
+// Formats returns the detection formats the harness can evaluate
+func Formats() []string {
+    return []string{
+        models.DetectionFormatSigma,
+        models.DetectionFormatKQL,
+        models.DetectionFormatSentinel,
+        models.DetectionFormatSplunk,
+    }
+}
+
+// ParseEvents reads JSON-lines events. Blank lines are skipped; every other
+// line must be a JSON object.
+func ParseEvents(data []byte, limits Limits) ([]Event, error) {
+    scanner := bufio.NewScanner(bytes.NewReader(data))
+    maxLine := limits.MaxEventSize
+    if maxLine <= 0 {
+        maxLine = bufio.MaxScanTokenSize
+    }
+    scanner.Buffer(make([]byte, 0, 64*1024), maxLine+1)
+
+    var events []Event
+    line := 0
+    for scanner.Scan() {
+        line++
+        text := bytes.TrimSpace(scanner.Bytes())
+        if len(text) == 0 {
+            continue
+        }
+        if limits.MaxEventSize > 0 && len(text) > limits.MaxEventSize {
+            return nil, fmt.Errorf("line %d: event exceeds %d bytes", line, limits.MaxEventSize)
+        }
+        var event Event
+        if err := json.Unmarshal(text, &event); err != nil || event == nil {
+            return nil, fmt.Errorf("line %d: event is not a JSON object", line)
+        }
+        events = append(events, event)
+        if limits.MaxEvents > 0 && len(events) > limits.MaxEvents {
+            return nil, fmt.Errorf("more than %d events", limits.MaxEvents)
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        if errors.Is(err, bufio.ErrTooLong) {
+            return nil, fmt.Errorf("line %d: event exceeds %d bytes", line+1, limits.MaxEventSize)
+        }
+        return nil, err
+    }
+    return events, nil
+}
+
+// Run evaluates a detection against events. expected, when not empty, holds
+// the expected outcome of each event in order. The run stops with the
+// context's error once ctx is done.
+func Run(ctx context.Context, detection *models.Detection, events []Event, expected []bool) (*Report, error) {
+    if len(events) == 0 {
+        return nil, ErrNoEvents
+    }
+    if len(expected) > 0 && len(expected) != len(events) {
+        return nil, fmt.Errorf("%w: %d expected outcomes for %d events", ErrExpectedMismatch, len(expected), len(events))
+    }
+    c, err := compile(detection)
+    if err != nil {
+        return nil, err
+    }
+
+    report := &Report{
+        Format: detection.Format,
+        Total:  len(events),
+        Events: make([]EventResult, len(events)),
+        Notes:  c.notes,
+    }
+    for i, event := range events {
+        if err := ctx.Err(); err != nil {
+            return nil, err
+        }
+        matched, err := c.matcher.match(event)
+        if err != nil {
+            return nil, fmt.Errorf("event %d: %w", i, err)
+        }
+        report.Events[i] = EventResult{Index: i, Matched: matched, Event: event}
+        if matched {
+            report.Matched++
+        }
+    }
+    if len(expected) > 0 {
+        report.Expectations = compare(report.Events, expected)
+    }
+    return report, nil
+}
+
+// compile prepares a detection for evaluation by format
+func compile(detection *models.Detection) (*compiled, error) {
+    switch detection.Format {
+    case models.DetectionFormatSigma:
+        return compileSigma(detection.Content)
+    case models.DetectionFormatKQL:
+        return compileKQL(detection.Content)
+    case models.DetectionFormatSentinel:
+        var rule struct {
+            Query string `yaml:"query"`
+        }
+        if err := yaml.Unmarshal([]byte(detection.Content), &rule); err != nil {
+            return nil, fmt.Errorf("invalid Sentinel rule: %w", err)
+        }
+        if strings.TrimSpace(rule.Query) == "" {
+            return nil, fmt.Errorf("Sentinel rule has no query")
+        }
+        return compileKQL(rule.Query)
+    case models.DetectionFormatSplunk:
+        return compileSPL(detection.Content)
+    }
+    return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, detection.Format)
+}
+
+// compare counts matches against expected outcomes
+func compare(results []EventResult, expected []bool) *Expectations {
+    e := &Expectations{Failed: []int{}}
+    for i := range results {
+        want := expected[i]
+        results[i].Expected = &want
+        switch {
+        case results[i].Matched && want:
+            e.TruePositives++
+        case results[i].Matched:
+            e.FalsePositives++
+            e.Failed = append(e.Failed, i)
+        case want:
+            e.FalseNegatives++
+            e.Failed = append(e.Failed, i)
+        default:
+            e.TrueNegatives++
+        }
+    }
+    return e
+}
+
+// lookup returns the value of a field: an exact key, a dotted path into
+// nested objects, or a key differing only in case
+func (e Event) lookup(field string) (interface{}, bool) {
+    if v, ok := e[field]; ok {
+        return v, true
+    }
+    if strings.Contains(field, ".") {
+        var current interface{} = map[string]interface{}(e)
+        for _, part := range strings.Split(field, ".") {
+            object, ok := current.(map[string]interface{})
+            if !ok {
+                current = nil
+                break
+            }
+            if current, ok = object[part]; !ok {
+                break
+            }
+        }
+        if current != nil {
+            return current, true
+        }
+    }
+    for key, v := range e {
+        if strings.EqualFold(key, field) {
+            return v, true
+        }
+    }
+    return nil, false
+}
+
+// values returns the string forms of a field value; arrays yield one value
+// per element
+func values(v interface{}) []string {
+    switch value := v.(type) {
+    case nil:
+        return nil
+    case []interface{}:
+        var out []string
+        for _, element := range value {
+            out = append(out, values(element)...)
+        }
+        return out
+    }
+    return []string{stringify(v)}
+}
+
+// stringify formats a JSON value as a string
+func stringify(v interface{}) string {
+    switch value := v.(type) {
+    case string:
+        return value
+    case float64:
+        return strconv.FormatFloat(value, 'f', -1, 64)
+    case bool:
+        return strconv.FormatBool(value)
+    case int:
+        return strconv.Itoa(value)
+    case nil:
+        return ""
+    }
+    data, _ := json.Marshal(v)
+    return string(data)
+}
+
+// number parses a value as a number
+func number(v interface{}) (float64, bool) {
+    switch value := v.(type) {
+    case float64:
+        return value, true
+    case int:
+        return float64(value), true
+    case string:
+        f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+        return f, err == nil
+    }
+    return 0, false
+}
+
+// eventText returns the searchable text of an event for keyword searches:
+// its _raw field when present, otherwise all of its values
+func eventText(e Event) []string {
+    if raw, ok := e["_raw"]; ok {
+        return values(raw)
+    }
+    var out []string
+    var collect func(v interface{})
+    collect = func(v interface{}) {
+        switch value := v.(type) {
+        case map[string]interface{}:
+            for _, nested := range value {
+                collect(nested)
+            }
+        case []interface{}:
+            for _, element := range value {
+                collect(element)
+            }
+        default:
+            out = append(out, stringify(value))
+        }
+    }
+    for _, v := range e {
+        collect(v)
+    }
+    return out
+}
+
+// pattern is a value to compare field values with, ignoring case. In
+// wildcard patterns * matches any sequence and ? one character; a backslash
+// escapes the next wildcard.
+type pattern struct {
+    literal string
+    re      *regexp.Regexp
+}
+
+// newPattern compiles a value; wildcards are only honoured when wildcards is
+// set
+func newPattern(value string, wildcards bool) pattern {
+    if !wildcards || !strings.ContainsAny(value, "*?") {
+        return pattern{literal: value}
+    }
+    return pattern{literal: value, re: regexp.MustCompile(wildcardExpr(value, "is"))}
+}
+
+// wildcardExpr converts a wildcard pattern into an anchored regular
+// expression with the given flags
+func wildcardExpr(value, flags string) string {
+    var b strings.Builder
+    b.WriteString("(?" + flags + ")^")
+    for i := 0; i < len(value); i++ {
+        switch c := value[i]; c {
+        case '*':
+            b.WriteString(".*")
+        case '?':
+            b.WriteString(".")
+        case '\\':
+            if i+1 < len(value) && strings.IndexByte(`*?\`, value[i+1]) >= 0 {
+                i++
+            }
+            b.WriteString(regexp.QuoteMeta(value[i : i+1]))
+        default:
+            b.WriteString(regexp.QuoteMeta(string(c)))
+        }
+    }
+    b.WriteString("$")
+    return b.String()
+}
+
+// match reports whether s matches the pattern
+func (p pattern) match(s string) bool {
+    if p.re != nil {
+        return p.re.MatchString(s)
+    }
+    return strings.EqualFold(p.literal, s)
+}
+
+// compileRegex compiles a regular expression of a detection
+func compileRegex(pattern string, caseInsensitive bool) (*regexp.Regexp, error) {
+    if len(pattern) > maxRegexLength {
+        return nil, fmt.Errorf("regular expression exceeds %d characters", maxRegexLength)
+    }
+    if caseInsensitive {
+        pattern = "(?i)" + pattern
+    }
+    re, err := regexp.Compile(pattern)
+    if err != nil {
+        return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+    }
+    return re, nil
+}
@@ -0,0 +1,698 @@
+package harness
+
+import (
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+    "unicode"
+)
+
+// kqlPassThrough are tabular operators that do not filter rows
+var kqlPassThrough = map[string]bool{
+    "project": true, "project-away": true, "project-keep": true, "project-rename": true,
+    "project-reorder": true, "sort": true, "order": true, "extend": true,
+}
+
+// kqlExpr evaluates an expression against an event; predicates yield bool
+type kqlExpr func(event Event) interface{}
+
+// kqlQuery is a compiled KQL query: the where filters of its pipeline
+type kqlQuery struct {
+    filters []kqlExpr
+}
+
+// match implements matcher
+func (q *kqlQuery) match(event Event) (bool, error) {
+    for _, filter := range q.filters {
+        if !truthy(filter(event)) {
+            return false, nil
+        }
+    }
+    return true, nil
+}
+
+// compileKQL compiles the filters of a KQL query. The leading table
+// expression selects no events, since samples belong to the queried table;
+// evaluation stops at the first operator that transforms rows.
+func compileKQL(query string) (*compiled, error) {
+    stages := splitKQLPipeline(stripKQLComments(query))
+    c := &compiled{}
+    q := &kqlQuery{}
+    for i, stage := range stages {
+        stage = strings.TrimSpace(stage)
+        if i == 0 {
+            if strings.HasPrefix(strings.ToLower(stage), "let ") {
+                return nil, fmt.Errorf("let statements are not supported by the test harness")
+            }
+            continue
+        }
+        operator, rest := splitKQLOperator(stage)
+        switch {
+        case operator == "where" || operator == "filter":
+            expr, err := parseKQLExpr(rest)
+            if err != nil {
+                return nil, fmt.Errorf("stage %d (%s): %w", i+1, operator, err)
+            }
+            q.filters = append(q.filters, expr)
+        case operator == "search":
+            expr, err := parseKQLSearch(rest)
+            if err != nil {
+                return nil, fmt.Errorf("stage %d (search): %w", i+1, err)
+            }
+            q.filters = append(q.filters, expr)
+        case kqlPassThrough[operator]:
+            if operator == "extend" {
+                c.notes = append(c.notes, fmt.Sprintf("stage %d (extend) is not evaluated; later filters see only the event's own fields", i+1))
+            }
+        default:
+            c.notes = append(c.notes, fmt.Sprintf("evaluation stops at stage %d (%s); events reaching it are reported as matches", i+1, operator))
+            c.matcher = q
+            return c, nil
+        }
+    }
+    c.matcher = q
+    return c, nil
+}
+
+// parseKQLSearch parses the predicate of a search operator, where a lone
+// string literal matches events containing it in any field
+func parseKQLSearch(src string) (kqlExpr, error) {
+    tokens, err := lexKQL(src)
+    if err != nil {
+        return nil, err
+    }
+    if len(tokens) != 1 || tokens[0].kind != 's' {
+        return parseKQLExpr(src)
+    }
+    term := newPattern(tokens[0].value, true)
+    if term.re == nil {
+        term = newPattern("*"+tokens[0].value+"*", true)
+    }
+    return func(e Event) interface{} {
+        for _, s := range eventText(e) {
+            if term.match(s) {
+                return true
+            }
+        }
+        return false
+    }, nil
+}
+
+// stripKQLComments removes // comments outside string literals
+func stripKQLComments(query string) string {
+    var b strings.Builder
+    var quote byte
+    for i := 0; i < len(query); i++ {
+        ch := query[i]
+        switch {
+        case quote != 0:
+            if ch == '\\' && i+1 < len(query) {
+                b.WriteByte(ch)
+                i++
+                ch = query[i]
+            } else if ch == quote {
+                quote = 0
+            }
+        case ch == '"' || ch == '\'':
+            quote = ch
+        case ch == '/' && i+1 < len(query) && query[i+1] == '/':
+            for i < len(query) && query[i] != '\n' {
+                i++
+            }
+            if i < len(query) {
+                b.WriteByte('\n')
+            }
+            continue
+        }
+        b.WriteByte(ch)
+    }
+    return b.String()
+}
+
+// splitKQLPipeline splits a query at the pipes outside strings and brackets
+func splitKQLPipeline(query string) []string {
+    var stages []string
+    var quote byte
+    depth, start := 0, 0
+    for i := 0; i < len(query); i++ {
+        ch := query[i]
+        switch {
+        case quote != 0:
+            if ch == '\\' {
+                i++
+            } else if ch == quote {
+                quote = 0
+            }
+        case ch == '"' || ch == '\'':
+            quote = ch
+        case ch == '(' || ch == '[' || ch == '{':
+            depth++
+        case ch == ')' || ch == ']' || ch == '}':
+            depth--
+        case ch == '|' && depth == 0:
+            stages = append(stages, query[start:i])
+            start = i + 1
+        }
+    }
+    return append(stages, query[start:])
+}
+
+// splitKQLOperator splits a stage into its lower-cased operator name and
+// arguments
+func splitKQLOperator(stage string) (string, string) {
+    end := strings.IndexFunc(stage, func(r rune) bool {
+        return !(unicode.IsLetter(r) || r == '-' || r == '_')
+    })
+    if end < 0 {
+        return strings.ToLower(stage), ""
+    }
+    return strings.ToLower(stage[:end]), stage[end:]
+}
+
+// kqlToken is a lexical token of a KQL expression
+type kqlToken struct {
+    kind  byte // 'i' identifier, 's' string, 'n' number, 'o' operator or punctuation
+    text  string
+    value string
+}
+
+// lexKQL splits an expression into tokens
+func lexKQL(src string) ([]kqlToken, error) {
+    var tokens []kqlToken
+    for i := 0; i < len(src); {
+        ch := src[i]
+        switch {
+        case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+            i++
+        case ch == '"' || ch == '\'' || (ch == '@' && i+1 < len(src) && (src[i+1] == '"' || src[i+1] == '\'')):
+            verbatim := ch == '@'
+            if verbatim {
+                i++
+            }
+            quote := src[i]
+            var b strings.Builder
+            j := i + 1
+            for ; j < len(src) && src[j] != quote; j++ {
+                if src[j] == '\\' && !verbatim && j+1 < len(src) {
+                    j++
+                    switch src[j] {
+                    case 'n':
+                        b.WriteByte('\n')
+                    case 't':
+                        b.WriteByte('\t')
+                    default:
+                        b.WriteByte(src[j])
+                    }
+                    continue
+                }
+                b.WriteByte(src[j])
+            }
+            if j >= len(src) {
+                return nil, fmt.Errorf("unterminated string literal")
+            }
+            tokens = append(tokens, kqlToken{kind: 's', text: src[i : j+1], value: b.String()})
+            i = j + 1
+        case ch >= '0' && ch <= '9':
+            j := i
+            for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+                j++
+            }
+            if j < len(src) && unicode.IsLetter(rune(src[j])) {
+                return nil, fmt.Errorf("timespan literal %q is not supported by the test harness", src[i:j+1])
+            }
+            tokens = append(tokens, kqlToken{kind: 'n', text: src[i:j], value: src[i:j]})
+            i = j
+        case ch == '_' || unicode.IsLetter(rune(ch)):
+            j := i
+            for j < len(src) && (src[j] == '_' || src[j] == '.' || unicode.IsLetter(rune(src[j])) || src[j] >= '0' && src[j] <= '9') {
+                j++
+            }
+            if strings.EqualFold(src[i:j], "in") && j < len(src) && src[j] == '~' {
+                j++
+            }
+            tokens = append(tokens, kqlToken{kind: 'i', text: src[i:j], value: src[i:j]})
+            i = j
+        case ch == '!' && i+1 < len(src) && unicode.IsLetter(rune(src[i+1])):
+            j := i + 1
+            for j < len(src) && (src[j] == '_' || src[j] == '~' || unicode.IsLetter(rune(src[j]))) {
+                j++
+            }
+            tokens = append(tokens, kqlToken{kind: 'o', text: strings.ToLower(src[i:j])})
+            i = j
+        default:
+            op := string(ch)
+            if i+1 < len(src) {
+                switch two := src[i : i+2]; two {
+                case "==", "!=", "=~", "!~", "<=", ">=", "<>":
+                    op = two
+                }
+            }
+            if !strings.Contains("=!~<>(),", op[:1]) {
+                return nil, fmt.Errorf("unexpected %q", op)
+            }
+            tokens = append(tokens, kqlToken{kind: 'o', text: op})
+            i += len(op)
+        }
+    }
+    return tokens, nil
+}
+
+// kqlParser is a recursive-descent parser of KQL predicates:
+//
+//	or      = and { "or" and }
+//	and     = not { "and" not }
+//	not     = "not" "(" or ")" | compare
+//	compare = primary [ op primary | ("in" | "!in" | "in~" | "!in~" | "has_any" | "has_all") list | "matches" "regex" string ]
+//	primary = "(" or ")" | string | number | "true" | "false" | call | field
+type kqlParser struct {
+    tokens []kqlToken
+    pos    int
+    depth  int
+}
+
+// parseKQLExpr parses a predicate
+func parseKQLExpr(src string) (kqlExpr, error) {
+    tokens, err := lexKQL(src)
+    if err != nil {
+        return nil, err
+    }
+    if len(tokens) == 0 {
+        return nil, fmt.Errorf("missing predicate")
+    }
+    p := &kqlParser{tokens: tokens}
+    expr, err := p.parseOr()
+    if err != nil {
+        return nil, err
+    }
+    if p.pos < len(p.tokens) {
+        return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+    }
+    return expr, nil
+}
+
+func (p *kqlParser) peek() string {
+    if p.pos < len(p.tokens) {
+        return strings.ToLower(p.tokens[p.pos].text)
+    }
+    return ""
+}
+
+func (p *kqlParser) expect(text string) error {
+    if p.peek() != text {
+        if p.pos >= len(p.tokens) {
+            return fmt.Errorf("expected %q, found end of expression", text)
+        }
+        return fmt.Errorf("expected %q, found %q", text, p.tokens[p.pos].text)
+    }
+    p.pos++
+    return nil
+}
+
+func (p *kqlParser) parseOr() (kqlExpr, error) {
+    if p.depth++; p.depth > maxExpressionDepth {
+        return nil, fmt.Errorf("expression nested deeper than %d levels", maxExpressionDepth)
+    }
+    defer func() { p.depth-- }()
+    left, err := p.parseAnd()
+    if err != nil {
+        return nil, err
+    }
+    for p.peek() == "or" {
+        p.pos++
+        right, err := p.parseAnd()
+        if err != nil {
+            return nil, err
+        }
+        l := left
+        left = func(e Event) interface{} { return truthy(l(e)) || truthy(right(e)) }
+    }
+    return left, nil
+}
+
+func (p *kqlParser) parseAnd() (kqlExpr, error) {
+    left, err := p.parseNot()
+    if err != nil {
+        return nil, err
+    }
+    for p.peek() == "and" {
+        p.pos++
+        right, err := p.parseNot()
+        if err != nil {
+            return nil, err
+        }
+        l := left
+        left = func(e Event) interface{} { return truthy(l(e)) && truthy(right(e)) }
+    }
+    return left, nil
+}
+
+func (p *kqlParser) parseNot() (kqlExpr, error) {
+    if p.peek() == "not" && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == "(" {
+        p.pos += 2
+        inner, err := p.parseOr()
+        if err != nil {
+            return nil, err
+        }
+        if err := p.expect(")"); err != nil {
+            return nil, err
+        }
+        return func(e Event) interface{} { return !truthy(inner(e)) }, nil
+    }
+    return p.parseCompare()
+}
+
+func (p *kqlParser) parseCompare() (kqlExpr, error) {
+    left, err := p.parsePrimary()
+    if err != nil {
+        return nil, err
+    }
+    op := p.peek()
+    switch op {
+    case "":
+        return left, nil
+    case "in", "!in", "in~", "!in~", "has_any", "has_all":
+        p.pos++
+        list, err := p.parseList()
+        if err != nil {
+            return nil, err
+        }
+        return kqlMembership(op, left, list), nil
+    case "matches":
+        p.pos++
+        if err := p.expect("regex"); err != nil {
+            return nil, err
+        }
+        right, err := p.parsePrimary()
+        if err != nil {
+            return nil, err
+        }
+        pattern, ok := constant(right)
+        if !ok {
+            return nil, fmt.Errorf("matches regex requires a string literal")
+        }
+        re, err := compileRegex(pattern, false)
+        if err != nil {
+            return nil, err
+        }
+        return func(e Event) interface{} {
+            for _, s := range values(left(e)) {
+                if re.MatchString(s) {
+                    return true
+                }
+            }
+            return false
+        }, nil
+    }
+
+    compare, ok := kqlOperators[op]
+    if !ok {
+        return left, nil
+    }
+    p.pos++
+    right, err := p.parsePrimary()
+    if err != nil {
+        return nil, err
+    }
+    if op == "has" || op == "!has" || op == "has_cs" || op == "!has_cs" {
+        if term, ok := constant(right); ok {
+            re, err := compileRegex(hasExpr(term), !strings.HasSuffix(op, "_cs"))
+            if err != nil {
+                return nil, err
+            }
+            negate := strings.HasPrefix(op, "!")
+            return func(e Event) interface{} {
+                v := left(e)
+                if v == nil {
+                    return negate
+                }
+                for _, s := range values(v) {
+                    if re.MatchString(s) {
+                        return !negate
+                    }
+                }
+                return negate
+            }, nil
+        }
+    }
+    return func(e Event) interface{} {
+        return compare(left(e), right(e))
+    }, nil
+}
+
+// parseList parses a parenthesized list of expressions
+func (p *kqlParser) parseList() ([]kqlExpr, error) {
+    if err := p.expect("("); err != nil {
+        return nil, err
+    }
+    var list []kqlExpr
+    for p.peek() != ")" {
+        element, err := p.parsePrimary()
+        if err != nil {
+            return nil, err
+        }
+        list = append(list, element)
+        if p.peek() == "," {
+            p.pos++
+        } else if p.peek() != ")" {
+            return nil, p.expect(")")
+        }
+    }
+    p.pos++
+    return list, nil
+}
+
+func (p *kqlParser) parsePrimary() (kqlExpr, error) {
+    if p.pos >= len(p.tokens) {
+        return nil, fmt.Errorf("unexpected end of expression")
+    }
+    tok := p.tokens[p.pos]
+    p.pos++
+    switch tok.kind {
+    case 's':
+        value := tok.value
+        return func(Event) interface{} { return value }, nil
+    case 'n':
+        n, err := strconv.ParseFloat(tok.value, 64)
+        if err != nil {
+            return nil, fmt.Errorf("invalid number %q", tok.text)
+        }
+        return func(Event) interface{} { return n }, nil
+    case 'i':
+        switch strings.ToLower(tok.text) {
+        case "true":
+            return func(Event) interface{} { return true }, nil
+        case "false":
+            return func(Event) interface{} { return false }, nil
+        }
+        if p.peek() == "(" {
+            return p.parseCall(strings.ToLower(tok.text))
+        }
+        field := tok.text
+        return func(e Event) interface{} {
+            v, _ := e.lookup(field)
+            return v
+        }, nil
+    case 'o':
+        if tok.text == "(" {
+            inner, err := p.parseOr()
+            if err != nil {
+                return nil, err
+            }
+            if err := p.expect(")"); err != nil {
+                return nil, err
+            }
+            return inner, nil
+        }
+    }
+    return nil, fmt.Errorf("unexpected %q", tok.text)
+}
+
+// parseCall parses a scalar function call
+func (p *kqlParser) parseCall(name string) (kqlExpr, error) {
+    args, err := p.parseList()
+    if err != nil {
+        return nil, err
+    }
+    fn, ok := kqlFunctions[name]
+    if !ok {
+        return nil, fmt.Errorf("function %s is not supported by the test harness", name)
+    }
+    if len(args) != 1 {
+        return nil, fmt.Errorf("%s takes one argument", name)
+    }
+    arg := args[0]
+    return func(e Event) interface{} { return fn(arg(e)) }, nil
+}
+
+// kqlFunctions are the supported scalar functions of one argument
+var kqlFunctions = map[string]func(interface{}) interface{}{
+    "isempty":    func(v interface{}) interface{} { return v == nil || stringify(v) == "" },
+    "isnotempty": func(v interface{}) interface{} { return v != nil && stringify(v) != "" },
+    "isnull":     func(v interface{}) interface{} { return v == nil },
+    "isnotnull":  func(v interface{}) interface{} { return v != nil },
+    "tolower":    func(v interface{}) interface{} { return strings.ToLower(stringify(v)) },
+    "toupper":    func(v interface{}) interface{} { return strings.ToUpper(stringify(v)) },
+    "tostring":   func(v interface{}) interface{} { return stringify(v) },
+    "strlen":     func(v interface{}) interface{} { return float64(len([]rune(stringify(v)))) },
+    "toint":      toNumber,
+    "tolong":     toNumber,
+    "todouble":   toNumber,
+    "toreal":     toNumber,
+}
+
+// toNumber converts a value to a number, null when it is not numeric
+func toNumber(v interface{}) interface{} {
+    if n, ok := number(v); ok {
+        return n
+    }
+    return nil
+}
+
+// kqlOperators are the binary comparison and string operators
+var kqlOperators = map[string]func(a, b interface{}) interface{}{
+    "==":           func(a, b interface{}) interface{} { return equal(a, b, false) },
+    "!=":           func(a, b interface{}) interface{} { return a != nil && !equal(a, b, false) },
+    "<>":           func(a, b interface{}) interface{} { return a != nil && !equal(a, b, false) },
+    "=~":           func(a, b interface{}) interface{} { return equal(a, b, true) },
+    "!~":           func(a, b interface{}) interface{} { return a != nil && !equal(a, b, true) },
+    "<":            ordered(func(c int) bool { return c < 0 }),
+    "<=":           ordered(func(c int) bool { return c <= 0 }),
+    ">":            ordered(func(c int) bool { return c > 0 }),
+    ">=":           ordered(func(c int) bool { return c >= 0 }),
+    "contains":     stringOp(strings.Contains, true, false),
+    "!contains":    stringOp(strings.Contains, true, true),
+    "contains_cs":  stringOp(strings.Contains, false, false),
+    "!contains_cs": stringOp(strings.Contains, false, true),
+    "startswith":   stringOp(strings.HasPrefix, true, false),
+    "!startswith":  stringOp(strings.HasPrefix, true, true),
+    "endswith":     stringOp(strings.HasSuffix, true, false),
+    "!endswith":    stringOp(strings.HasSuffix, true, true),
+    "has":          func(a, b interface{}) interface{} { return hasTerm(a, b, true) },
+    "!has":         func(a, b interface{}) interface{} { return !hasTerm(a, b, true) },
+    "has_cs":       func(a, b interface{}) interface{} { return hasTerm(a, b, false) },
+    "!has_cs":      func(a, b interface{}) interface{} { return !hasTerm(a, b, false) },
+}
+
+// kqlMembership compiles in, !in, in~, !in~, has_any and has_all
+func kqlMembership(op string, left kqlExpr, list []kqlExpr) kqlExpr {
+    return func(e Event) interface{} {
+        v := left(e)
+        switch op {
+        case "has_any", "has_all":
+            for _, element := range list {
+                found := hasTerm(v, element(e), true)
+                if found && op == "has_any" {
+                    return true
+                }
+                if !found && op == "has_all" {
+                    return false
+                }
+            }
+            return op == "has_all"
+        }
+        ignoreCase := strings.HasSuffix(op, "~")
+        found := false
+        for _, element := range list {
+            if equal(v, element(e), ignoreCase) {
+                found = true
+                break
+            }
+        }
+        if strings.HasPrefix(op, "!") {
+            return v != nil && !found
+        }
+        return found
+    }
+}
+
+// equal compares values as numbers when both are numeric, as strings
+// otherwise
+func equal(a, b interface{}, ignoreCase bool) bool {
+    if a == nil || b == nil {
+        return false
+    }
+    if x, ok := number(a); ok {
+        if y, ok := number(b); ok {
+            return x == y
+        }
+    }
+    if ignoreCase {
+        return strings.EqualFold(stringify(a), stringify(b))
+    }
+    return stringify(a) == stringify(b)
+}
+
+// ordered compiles a comparison of numbers, or of strings when either value
+// is not numeric
+func ordered(accept func(int) bool) func(a, b interface{}) interface{} {
+    return func(a, b interface{}) interface{} {
+        if a == nil || b == nil {
+            return false
+        }
+        if x, ok := number(a); ok {
+            if y, ok := number(b); ok {
+                switch {
+                case x < y:
+                    return accept(-1)
+                case x > y:
+                    return accept(1)
+                }
+                return accept(0)
+            }
+        }
+        return accept(strings.Compare(stringify(a), stringify(b)))
+    }
+}
+
+// stringOp compiles a substring operator; negated operators also match
+// missing fields
+func stringOp(op func(s, substr string) bool, ignoreCase, negate bool) func(a, b interface{}) interface{} {
+    return func(a, b interface{}) interface{} {
+        if a == nil {
+            return negate
+        }
+        s, substr := stringify(a), stringify(b)
+        if ignoreCase {
+            s, substr = strings.ToLower(s), strings.ToLower(substr)
+        }
+        return op(s, substr) != negate
+    }
+}
+
+// hasTerm reports whether a value contains term as a whole term
+func hasTerm(a, term interface{}, ignoreCase bool) bool {
+    if a == nil || term == nil {
+        return false
+    }
+    re, err := compileRegex(hasExpr(stringify(term)), ignoreCase)
+    if err != nil {
+        return false
+    }
+    for _, s := range values(a) {
+        if re.MatchString(s) {
+            return true
+        }
+    }
+    return false
+}
+
+// hasExpr is the regular expression of a whole-term match of term
+func hasExpr(term string) string {
+    return `(^|[^\pL\pN])` + regexp.QuoteMeta(term) + `($|[^\pL\pN])`
+}
+
+// constant returns the string value of a literal expression
+func constant(expr kqlExpr) (string, bool) {
+    v := expr(Event{})
+    if s, ok := v.(string); ok {
+        return s, true
+    }
+    return "", false
+}
+
+// truthy reports whether a predicate value is true
+func truthy(v interface{}) bool {
+    b, ok := v.(bool)
+    return ok && b
+}
@@ -0,0 +1,527 @@
+package harness
+
+import (
+    "fmt"
+    "net"
+    "sort"
+    "strings"
+
+    "gopkg.in/yaml.v3" // v3.0.1
+)
+
+// windashVariants are the characters the windash modifier accepts in place
+// of a leading dash of command-line flags
+var windashVariants = []string{"-", "/", "–", "—", "―"}
+
+// sigmaSearch matches a search identifier of a Sigma detection
+type sigmaSearch func(event Event) bool
+
+// sigmaRule is a compiled Sigma rule
+type sigmaRule struct {
+    condition sigmaSearch
+}
+
+// match implements matcher
+func (r *sigmaRule) match(event Event) (bool, error) {
+    return r.condition(event), nil
+}
+
+// compileSigma compiles the detection section of a Sigma rule. A list of
+// conditions matches when any of them does; aggregations after a pipe and
+// the timeframe are reported as notes.
+func compileSigma(content string) (*compiled, error) {
+    var rule struct {
+        Detection map[string]interface{} `yaml:"detection"`
+    }
+    if err := yaml.Unmarshal([]byte(content), &rule); err != nil {
+        return nil, fmt.Errorf("invalid Sigma rule: %w", err)
+    }
+    if len(rule.Detection) == 0 {
+        return nil, fmt.Errorf("Sigma rule has no detection section")
+    }
+
+    c := &compiled{}
+    searches := make(map[string]sigmaSearch)
+    var conditions []string
+    for name, value := range rule.Detection {
+        switch name {
+        case "condition":
+            switch v := value.(type) {
+            case string:
+                conditions = []string{v}
+            case []interface{}:
+                for _, condition := range v {
+                    conditions = append(conditions, fmt.Sprint(condition))
+                }
+            default:
+                return nil, fmt.Errorf("condition must be a string or a list of strings")
+            }
+        case "timeframe":
+            c.notes = append(c.notes, fmt.Sprintf("timeframe %v is not evaluated; every event is tested on its own", value))
+        default:
+            search, err := compileSigmaSearch(value)
+            if err != nil {
+                return nil, fmt.Errorf("search %s: %w", name, err)
+            }
+            searches[name] = search
+        }
+    }
+    if len(conditions) == 0 {
+        return nil, fmt.Errorf("Sigma rule has no condition")
+    }
+
+    var compiledConditions []sigmaSearch
+    for _, condition := range conditions {
+        expr := condition
+        if i := strings.Index(condition, "|"); i >= 0 {
+            expr = condition[:i]
+            c.notes = append(c.notes, fmt.Sprintf("aggregation %q is not evaluated; matching events are reported", strings.TrimSpace(condition[i+1:])))
+        }
+        parsed, err := parseSigmaCondition(expr, searches)
+        if err != nil {
+            return nil, fmt.Errorf("condition %q: %w", condition, err)
+        }
+        compiledConditions = append(compiledConditions, parsed)
+    }
+    c.matcher = &sigmaRule{condition: anyOf(compiledConditions)}
+    return c, nil
+}
+
+// compileSigmaSearch compiles a search identifier: a map of field matches
+// that must all match, a list of maps of which one must match, or keywords
+func compileSigmaSearch(value interface{}) (sigmaSearch, error) {
+    switch v := value.(type) {
+    case map[string]interface{}:
+        return compileSigmaMap(v)
+    case []interface{}:
+        if len(v) == 0 {
+            return nil, fmt.Errorf("empty search")
+        }
+        var alternatives []sigmaSearch
+        var keywords []interface{}
+        for _, element := range v {
+            if m, ok := element.(map[string]interface{}); ok {
+                search, err := compileSigmaMap(m)
+                if err != nil {
+                    return nil, err
+                }
+                alternatives = append(alternatives, search)
+            } else {
+                keywords = append(keywords, element)
+            }
+        }
+        if len(keywords) > 0 {
+            alternatives = append(alternatives, compileSigmaKeywords(keywords))
+        }
+        return anyOf(alternatives), nil
+    case nil:
+        return nil, fmt.Errorf("empty search")
+    }
+    return compileSigmaKeywords([]interface{}{value}), nil
+}
+
+// compileSigmaKeywords matches events containing any of the keywords
+func compileSigmaKeywords(keywords []interface{}) sigmaSearch {
+    patterns := make([]pattern, len(keywords))
+    for i, keyword := range keywords {
+        patterns[i] = newPattern("*"+stringify(keyword)+"*", true)
+    }
+    return func(event Event) bool {
+        for _, text := range eventText(event) {
+            for _, p := range patterns {
+                if p.match(text) {
+                    return true
+                }
+            }
+        }
+        return false
+    }
+}
+
+// compileSigmaMap compiles field matches that must all match
+func compileSigmaMap(fields map[string]interface{}) (sigmaSearch, error) {
+    keys := make([]string, 0, len(fields))
+    for key := range fields {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+
+    var all []sigmaSearch
+    for _, key := range keys {
+        search, err := compileSigmaField(key, fields[key])
+        if err != nil {
+            return nil, err
+        }
+        all = append(all, search)
+    }
+    return allOf(all), nil
+}
+
+// compileSigmaField compiles one field match, such as
+// `CommandLine|contains|all: [a, b]`
+func compileSigmaField(key string, value interface{}) (sigmaSearch, error) {
+    parts := strings.Split(key, "|")
+    field := parts[0]
+    modifiers := make(map[string]bool, len(parts)-1)
+    for _, modifier := range parts[1:] {
+        modifiers[strings.ToLower(modifier)] = true
+    }
+    for modifier := range modifiers {
+        switch modifier {
+        case "contains", "startswith", "endswith", "all", "re", "i", "m", "s",
+            "cidr", "exists", "gt", "gte", "lt", "lte", "windash", "cased":
+        default:
+            return nil, fmt.Errorf("%s: modifier %s is not supported by the test harness", key, modifier)
+        }
+    }
+
+    var list []interface{}
+    switch v := value.(type) {
+    case []interface{}:
+        list = v
+    default:
+        list = []interface{}{v}
+    }
+
+    if modifiers["exists"] {
+        want, ok := value.(bool)
+        if !ok {
+            return nil, fmt.Errorf("%s: exists takes true or false", key)
+        }
+        return func(event Event) bool {
+            v, found := event.lookup(field)
+            return (found && v != nil) == want
+        }, nil
+    }
+
+    var checks []func(string) bool
+    matchNull := false
+    for _, element := range list {
+        if element == nil {
+            matchNull = true
+            continue
+        }
+        check, err := compileSigmaValue(key, stringify(element), modifiers)
+        if err != nil {
+            return nil, err
+        }
+        checks = append(checks, check)
+    }
+
+    matchAll := modifiers["all"]
+    return func(event Event) bool {
+        v, found := event.lookup(field)
+        if !found || v == nil {
+            return matchNull
+        }
+        fieldValues := values(v)
+        anyValue := func(check func(string) bool) bool {
+            for _, fieldValue := range fieldValues {
+                if check(fieldValue) {
+                    return true
+                }
+            }
+            return false
+        }
+        if len(checks) == 0 {
+            return false
+        }
+        for _, check := range checks {
+            if anyValue(check) {
+                if !matchAll {
+                    return true
+                }
+            } else if matchAll {
+                return false
+            }
+        }
+        return matchAll
+    }, nil
+}
+
+// compileSigmaValue compiles one value of a field match with its modifiers.
+// With windash, the value matches in any of its dash variants.
+func compileSigmaValue(key, value string, modifiers map[string]bool) (func(string) bool, error) {
+    switch {
+    case modifiers["re"]:
+        flags := ""
+        for _, flag := range []string{"i", "m", "s"} {
+            if modifiers[flag] {
+                flags += flag
+            }
+        }
+        if flags != "" {
+            value = "(?" + flags + ")" + value
+        }
+        re, err := compileRegex(value, false)
+        if err != nil {
+            return nil, fmt.Errorf("%s: %w", key, err)
+        }
+        return re.MatchString, nil
+    case modifiers["cidr"]:
+        _, network, err := net.ParseCIDR(value)
+        if err != nil {
+            return nil, fmt.Errorf("%s: invalid CIDR %q", key, value)
+        }
+        return func(s string) bool {
+            ip := net.ParseIP(s)
+            return ip != nil && network.Contains(ip)
+        }, nil
+    case modifiers["gt"], modifiers["gte"], modifiers["lt"], modifiers["lte"]:
+        limit, ok := number(value)
+        if !ok {
+            return nil, fmt.Errorf("%s: %q is not a number", key, value)
+        }
+        return func(s string) bool {
+            n, ok := number(s)
+            switch {
+            case !ok:
+                return false
+            case modifiers["gt"]:
+                return n > limit
+            case modifiers["gte"]:
+                return n >= limit
+            case modifiers["lt"]:
+                return n < limit
+            }
+            return n <= limit
+        }, nil
+    }
+
+    variants := []string{value}
+    if modifiers["windash"] && strings.Contains(value, "-") {
+        variants = variants[:0]
+        for _, dash := range windashVariants {
+            variants = append(variants, strings.ReplaceAll(value, "-", dash))
+        }
+    }
+
+    var checks []func(string) bool
+    for _, variant := range variants {
+        switch {
+        case modifiers["contains"]:
+            variant = "*" + variant + "*"
+        case modifiers["startswith"]:
+            variant += "*"
+        case modifiers["endswith"]:
+            variant = "*" + variant
+        }
+        if modifiers["cased"] {
+            re, err := compileRegex(wildcardExpr(variant, "s"), false)
+            if err != nil {
+                return nil, fmt.Errorf("%s: %w", key, err)
+            }
+            checks = append(checks, re.MatchString)
+            continue
+        }
+        checks = append(checks, newPattern(variant, true).match)
+    }
+    if len(checks) == 1 {
+        return checks[0], nil
+    }
+    return func(s string) bool {
+        for _, check := range checks {
+            if check(s) {
+                return true
+            }
+        }
+        return false
+    }, nil
+}
+
+// anyOf matches when any search matches
+func anyOf(searches []sigmaSearch) sigmaSearch {
+    return func(event Event) bool {
+        for _, search := range searches {
+            if search(event) {
+                return true
+            }
+        }
+        return false
+    }
+}
+
+// allOf matches when every search matches
+func allOf(searches []sigmaSearch) sigmaSearch {
+    return func(event Event) bool {
+        for _, search := range searches {
+            if !search(event) {
+                return false
+            }
+        }
+        return true
+    }
+}
+
+// sigmaConditionParser parses Sigma conditions:
+//
+//	or      = and { "or" and }
+//	and     = not { "and" not }
+//	not     = "not" not | primary
+//	primary = "(" or ")" | ("1" | "any" | "all") "of" (pattern | "them") | identifier
+type sigmaConditionParser struct {
+    tokens   []string
+    pos      int
+    depth    int
+    searches map[string]sigmaSearch
+}
+
+// parseSigmaCondition parses a condition over the compiled searches
+func parseSigmaCondition(condition string, searches map[string]sigmaSearch) (sigmaSearch, error) {
+    replacer := strings.NewReplacer("(", " ( ", ")", " ) ")
+    p := &sigmaConditionParser{tokens: strings.Fields(replacer.Replace(condition)), searches: searches}
+    if len(p.tokens) == 0 {
+        return nil, fmt.Errorf("empty condition")
+    }
+    search, err := p.parseOr()
+    if err != nil {
+        return nil, err
+    }
+    if p.pos < len(p.tokens) {
+        return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+    }
+    return search, nil
+}
+
+func (p *sigmaConditionParser) peek() string {
+    if p.pos < len(p.tokens) {
+        return strings.ToLower(p.tokens[p.pos])
+    }
+    return ""
+}
+
+// enter descends one nesting level, failing past maxExpressionDepth. Each
+// successful call is paired with a deferred leave.
+func (p *sigmaConditionParser) enter() error {
+    if p.depth++; p.depth > maxExpressionDepth {
+        return fmt.Errorf("condition nested deeper than %d levels", maxExpressionDepth)
+    }
+    return nil
+}
+
+func (p *sigmaConditionParser) leave() {
+    p.depth--
+}
+
+func (p *sigmaConditionParser) parseOr() (sigmaSearch, error) {
+    if err := p.enter(); err != nil {
+        return nil, err
+    }
+    defer p.leave()
+    left, err := p.parseAnd()
+    if err != nil {
+        return nil, err
+    }
+    alternatives := []sigmaSearch{left}
+    for p.peek() == "or" {
+        p.pos++
+        right, err := p.parseAnd()
+        if err != nil {
+            return nil, err
+        }
+        alternatives = append(alternatives, right)
+    }
+    if len(alternatives) == 1 {
+        return left, nil
+    }
+    return anyOf(alternatives), nil
+}
+
+func (p *sigmaConditionParser) parseAnd() (sigmaSearch, error) {
+    left, err := p.parseNot()
+    if err != nil {
+        return nil, err
+    }
+    all := []sigmaSearch{left}
+    for p.peek() == "and" {
+        p.pos++
+        right, err := p.parseNot()
+        if err != nil {
+            return nil, err
+        }
+        all = append(all, right)
+    }
+    if len(all) == 1 {
+        return left, nil
+    }
+    return allOf(all), nil
+}
+
+func (p *sigmaConditionParser) parseNot() (sigmaSearch, error) {
+    if p.peek() == "not" {
+        if err := p.enter(); err != nil {
+            return nil, err
+        }
+        defer p.leave()
+        p.pos++
+        inner, err := p.parseNot()
+        if err != nil {
+            return nil, err
+        }
+        return func(event Event) bool { return !inner(event) }, nil
+    }
+    return p.parsePrimary()
+}
+
+func (p *sigmaConditionParser) parsePrimary() (sigmaSearch, error) {
+    token := p.peek()
+    switch token {
+    case "":
+        return nil, fmt.Errorf("unexpected end of condition")
+    case "(":
+        p.pos++
+        inner, err := p.parseOr()
+        if err != nil {
+            return nil, err
+        }
+        if p.peek() != ")" {
+            return nil, fmt.Errorf("missing closing parenthesis")
+        }
+        p.pos++
+        return inner, nil
+    case "1", "any", "all":
+        if p.pos+2 < len(p.tokens) && strings.ToLower(p.tokens[p.pos+1]) == "of" {
+            target := p.tokens[p.pos+2]
+            p.pos += 3
+            selected := p.selectSearches(target)
+            if len(selected) == 0 {
+                return nil, fmt.Errorf("%q matches no search identifier", target)
+            }
+            if token == "all" {
+                return allOf(selected), nil
+            }
+            return anyOf(selected), nil
+        }
+    }
+
+    name := p.tokens[p.pos]
+    search, ok := p.searches[name]
+    if !ok {
+        return nil, fmt.Errorf("unknown search identifier %q", name)
+    }
+    p.pos++
+    return search, nil
+}
+
+// selectSearches returns the searches matching a name pattern, or all
+// searches not starting with an underscore for "them"
+func (p *sigmaConditionParser) selectSearches(target string) []sigmaSearch {
+    names := make([]string, 0, len(p.searches))
+    for name := range p.searches {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    var selected []sigmaSearch
+    namePattern := newPattern(target, true)
+    for _, name := range names {
+        if strings.EqualFold(target, "them") {
+            if !strings.HasPrefix(name, "_") {
+                selected = append(selected, p.searches[name])
+            }
+        } else if namePattern.match(name) {
+            selected = append(selected, p.searches[name])
+        }
+    }
+    return selected
+}
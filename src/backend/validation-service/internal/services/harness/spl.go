@@ -0,0 +1,599 @@
+package harness
+
+import (
+    "fmt"
+    "math"
+    "net"
+    "regexp"
+    "strconv"
+    "strings"
+
+    "validation-service/internal/parser/spl"
+)
+
+// splMetadata are search options that select the data source; sample
+// events without the field match them
+var splMetadata = map[string]bool{
+    "index": true, "sourcetype": true, "source": true, "host": true,
+    "eventtype": true, "splunk_server": true,
+}
+
+// splTimeOptions bound the search time range, which sample events ignore
+var splTimeOptions = map[string]bool{
+    "earliest": true, "latest": true, "_index_earliest": true, "_index_latest": true,
+}
+
+// splPassThrough are commands that do not filter events
+var splPassThrough = map[string]bool{
+    "fields": true, "table": true, "sort": true, "rename": true, "reverse": true,
+    "fieldformat": true,
+}
+
+// splNoted are commands passed through with a note because they change
+// fields or the number of results in ways the harness does not model
+var splNoted = map[string]bool{
+    "eval": true, "rex": true, "dedup": true, "head": true, "tail": true,
+    "fillnull": true, "convert": true, "lookup": true, "spath": true,
+    "makemv": true, "mvexpand": true, "iplocation": true,
+}
+
+// splComparisons are the ordering comparisons of searches and where
+var splComparisons = map[string]func(a, b interface{}) interface{}{
+    "<":  ordered(func(c int) bool { return c < 0 }),
+    "<=": ordered(func(c int) bool { return c <= 0 }),
+    ">":  ordered(func(c int) bool { return c > 0 }),
+    ">=": ordered(func(c int) bool { return c >= 0 }),
+}
+
+// splSearch matches an event against a search or where clause
+type splSearch func(event Event) bool
+
+// splExpr evaluates an eval expression against an event
+type splExpr func(event Event) interface{}
+
+// splQuery is a compiled SPL pipeline: the filters of its commands
+type splQuery struct {
+    filters []splSearch
+}
+
+// match implements matcher
+func (q *splQuery) match(event Event) (bool, error) {
+    for _, filter := range q.filters {
+        if !filter(event) {
+            return false, nil
+        }
+    }
+    return true, nil
+}
+
+// compileSPL compiles the filtering commands of an SPL search: search and
+// where. Evaluation stops at the first transforming command, such as stats.
+func compileSPL(search string) (*compiled, error) {
+    pipeline, errs := spl.Parse(search)
+    if len(errs) > 0 {
+        return nil, fmt.Errorf("invalid SPL: %s", errs[0].Error())
+    }
+    c := &compiled{}
+    q := &splQuery{}
+    timeNoted := false
+    for i, cmd := range pipeline.Commands {
+        switch {
+        case cmd.Macro != nil:
+            return nil, fmt.Errorf("command %d: macro `%s` cannot be expanded by the test harness", i+1, cmd.Macro.Name)
+        case cmd.Generating:
+            return nil, fmt.Errorf("command %d: generating command %s cannot be evaluated against events", i+1, cmd.Name)
+        case cmd.Implicit || cmd.Name == "search":
+            filter, usesTime, err := compileSPLArgs(cmd.Args)
+            if err != nil {
+                return nil, fmt.Errorf("command %d (search): %w", i+1, err)
+            }
+            if usesTime && !timeNoted {
+                c.notes = append(c.notes, "time range options are ignored; every sample event is in range")
+                timeNoted = true
+            }
+            q.filters = append(q.filters, filter)
+        case cmd.Name == "where":
+            if cmd.Expr == nil {
+                return nil, fmt.Errorf("command %d (where): missing predicate", i+1)
+            }
+            expr, err := compileSPLExpr(cmd.Expr)
+            if err != nil {
+                return nil, fmt.Errorf("command %d (where): %w", i+1, err)
+            }
+            q.filters = append(q.filters, func(e Event) bool { return truthy(expr(e)) })
+        case splPassThrough[cmd.Name]:
+        case splNoted[cmd.Name]:
+            c.notes = append(c.notes, fmt.Sprintf("command %d (%s) is not evaluated; later filters see only the event's own fields", i+1, cmd.Name))
+        default:
+            c.notes = append(c.notes, fmt.Sprintf("evaluation stops at command %d (%s); events reaching it are reported as matches", i+1, cmd.Name))
+            c.matcher = q
+            return c, nil
+        }
+    }
+    c.matcher = q
+    return c, nil
+}
+
+// compileSPLArgs compiles search arguments. Adjacent arguments are ANDed,
+// OR binds tighter than the implicit AND, and NOT negates the next argument.
+// usesTime reports whether a time range option was ignored.
+func compileSPLArgs(args []spl.Arg) (filter splSearch, usesTime bool, err error) {
+    var all []splSearch
+    var either []splSearch
+    for i := 0; i < len(args); i++ {
+        negate := false
+        for i < len(args) && isSPLKeyword(args[i], "NOT") {
+            negate = !negate
+            i++
+        }
+        if i == len(args) {
+            return nil, false, fmt.Errorf("NOT without an operand")
+        }
+
+        var search splSearch
+        if term, ok := args[i].(*spl.Term); ok && i+2 < len(args) && isSPLKeyword(args[i+1], "IN") {
+            group, ok := args[i+2].(*spl.Group)
+            if !ok {
+                return nil, false, fmt.Errorf("IN requires a parenthesized list")
+            }
+            search, err = compileSPLIn(term.Text, group)
+            i += 2
+        } else {
+            var time bool
+            search, time, err = compileSPLArg(args[i])
+            usesTime = usesTime || time
+        }
+        if err != nil {
+            return nil, false, err
+        }
+        if negate {
+            inner := search
+            search = func(e Event) bool { return !inner(e) }
+        }
+        either = append(either, search)
+
+        if i+1 < len(args) && isSPLKeyword(args[i+1], "OR") {
+            i++
+            continue
+        }
+        if i+1 < len(args) && isSPLKeyword(args[i+1], "AND") {
+            i++
+        }
+        all = append(all, orSPL(either))
+        either = nil
+    }
+    if len(either) > 0 {
+        return nil, false, fmt.Errorf("OR without a right operand")
+    }
+    return func(e Event) bool {
+        for _, search := range all {
+            if !search(e) {
+                return false
+            }
+        }
+        return true
+    }, usesTime, nil
+}
+
+// orSPL combines searches of which any must match
+func orSPL(searches []splSearch) splSearch {
+    if len(searches) == 1 {
+        return searches[0]
+    }
+    return func(e Event) bool {
+        for _, search := range searches {
+            if search(e) {
+                return true
+            }
+        }
+        return false
+    }
+}
+
+// isSPLKeyword reports whether arg is the unquoted boolean keyword
+func isSPLKeyword(arg spl.Arg, keyword string) bool {
+    term, ok := arg.(*spl.Term)
+    return ok && !term.Quoted && term.Text == keyword
+}
+
+// compileSPLArg compiles one search argument
+func compileSPLArg(arg spl.Arg) (splSearch, bool, error) {
+    switch a := arg.(type) {
+    case *spl.Term:
+        keyword := newPattern("*"+a.Text+"*", true)
+        return func(e Event) bool {
+            for _, s := range eventText(e) {
+                if keyword.match(s) {
+                    return true
+                }
+            }
+            return false
+        }, false, nil
+    case *spl.Group:
+        return compileSPLArgs(a.Args)
+    case *spl.Option:
+        return compileSPLOption(a)
+    case *spl.Subsearch:
+        return nil, false, fmt.Errorf("subsearches cannot be evaluated by the test harness")
+    case *spl.Macro:
+        return nil, false, fmt.Errorf("macro `%s` cannot be expanded by the test harness", a.Name)
+    }
+    return nil, false, fmt.Errorf("unsupported search argument")
+}
+
+// compileSPLOption compiles a field comparison such as user=admin*
+func compileSPLOption(o *spl.Option) (splSearch, bool, error) {
+    key := strings.ToLower(o.Key)
+    if splTimeOptions[key] {
+        return func(Event) bool { return true }, true, nil
+    }
+    term, ok := o.Value.(*spl.Term)
+    if !ok {
+        return nil, false, fmt.Errorf("value of %s must be a single term", o.Key)
+    }
+    field, metadata := o.Key, splMetadata[key]
+    present := func(e Event) (interface{}, bool) {
+        v, ok := e.lookup(field)
+        return v, ok && v != nil
+    }
+
+    switch o.Op {
+    case "=", "==", "!=":
+        value := newPattern(term.Text, true)
+        negate := o.Op == "!="
+        return func(e Event) bool {
+            v, ok := present(e)
+            if !ok {
+                return metadata
+            }
+            for _, s := range values(v) {
+                if value.match(s) {
+                    return !negate
+                }
+            }
+            return negate
+        }, false, nil
+    }
+
+    compare, ok := splComparisons[o.Op]
+    if !ok {
+        return nil, false, fmt.Errorf("unsupported comparison %s%s", o.Key, o.Op)
+    }
+    limit := term.Text
+    return func(e Event) bool {
+        v, ok := present(e)
+        if !ok {
+            return false
+        }
+        for _, s := range values(v) {
+            if truthy(compare(s, limit)) {
+                return true
+            }
+        }
+        return false
+    }, false, nil
+}
+
+// compileSPLIn compiles field IN (a, b, c)
+func compileSPLIn(field string, group *spl.Group) (splSearch, error) {
+    var options []pattern
+    for _, arg := range group.Args {
+        term, ok := arg.(*spl.Term)
+        if !ok {
+            return nil, fmt.Errorf("IN list of %s must contain terms", field)
+        }
+        options = append(options, newPattern(term.Text, true))
+    }
+    return func(e Event) bool {
+        v, ok := e.lookup(field)
+        if !ok {
+            return false
+        }
+        for _, s := range values(v) {
+            for _, option := range options {
+                if option.match(s) {
+                    return true
+                }
+            }
+        }
+        return false
+    }, nil
+}
+
+// compileSPLExpr compiles an eval expression
+func compileSPLExpr(expr spl.Expr) (splExpr, error) {
+    switch x := expr.(type) {
+    case *spl.Literal:
+        var value interface{}
+        switch x.Kind {
+        case spl.LiteralNumber:
+            n, err := strconv.ParseFloat(x.Value, 64)
+            if err != nil {
+                return nil, fmt.Errorf("invalid number %q", x.Value)
+            }
+            value = n
+        case spl.LiteralString:
+            value = x.Value
+        case spl.LiteralBool:
+            value = x.Value == "true"
+        }
+        return func(Event) interface{} { return value }, nil
+    case *spl.FieldRef:
+        field := x.Name
+        return func(e Event) interface{} {
+            v, _ := e.lookup(field)
+            return v
+        }, nil
+    case *spl.UnaryExpr:
+        operand, err := compileSPLExpr(x.X)
+        if err != nil {
+            return nil, err
+        }
+        switch x.Op {
+        case "NOT":
+            return func(e Event) interface{} { return !truthy(operand(e)) }, nil
+        case "-":
+            return func(e Event) interface{} {
+                if n, ok := number(operand(e)); ok {
+                    return -n
+                }
+                return nil
+            }, nil
+        }
+        return operand, nil
+    case *spl.InExpr:
+        operand, err := compileSPLExpr(x.X)
+        if err != nil {
+            return nil, err
+        }
+        list := make([]splExpr, len(x.List))
+        for i, element := range x.List {
+            if list[i], err = compileSPLExpr(element); err != nil {
+                return nil, err
+            }
+        }
+        return func(e Event) interface{} {
+            v := operand(e)
+            for _, element := range list {
+                if equal(v, element(e), false) {
+                    return true
+                }
+            }
+            return false
+        }, nil
+    case *spl.BinaryExpr:
+        return compileSPLBinary(x)
+    case *spl.Call:
+        return compileSPLCall(x)
+    case *spl.MacroExpr:
+        return nil, fmt.Errorf("macro `%s` cannot be expanded by the test harness", x.Macro.Name)
+    }
+    return nil, fmt.Errorf("unsupported expression")
+}
+
+// compileSPLBinary compiles a logical, comparison or arithmetic operation
+func compileSPLBinary(x *spl.BinaryExpr) (splExpr, error) {
+    left, err := compileSPLExpr(x.Left)
+    if err != nil {
+        return nil, err
+    }
+    if x.Op == "LIKE" {
+        like, err := splLike(x.Right)
+        if err != nil {
+            return nil, err
+        }
+        return func(e Event) interface{} {
+            v := left(e)
+            return v != nil && like.MatchString(stringify(v))
+        }, nil
+    }
+    right, err := compileSPLExpr(x.Right)
+    if err != nil {
+        return nil, err
+    }
+
+    switch x.Op {
+    case "AND":
+        return func(e Event) interface{} { return truthy(left(e)) && truthy(right(e)) }, nil
+    case "OR":
+        return func(e Event) interface{} { return truthy(left(e)) || truthy(right(e)) }, nil
+    case "XOR":
+        return func(e Event) interface{} { return truthy(left(e)) != truthy(right(e)) }, nil
+    case "=", "==":
+        return func(e Event) interface{} { return equal(left(e), right(e), false) }, nil
+    case "!=":
+        return func(e Event) interface{} {
+            l, r := left(e), right(e)
+            return l != nil && r != nil && !equal(l, r, false)
+        }, nil
+    case "<", "<=", ">", ">=":
+        compare := splComparisons[x.Op]
+        return func(e Event) interface{} { return compare(left(e), right(e)) }, nil
+    case ".":
+        return func(e Event) interface{} { return stringify(left(e)) + stringify(right(e)) }, nil
+    case "+", "-", "*", "/", "%":
+        op := x.Op
+        return func(e Event) interface{} { return arithmetic(op, left(e), right(e)) }, nil
+    }
+    return nil, fmt.Errorf("unsupported operator %s", x.Op)
+}
+
+// arithmetic applies an arithmetic operator; + concatenates non-numeric
+// operands and other operators yield null for them
+func arithmetic(op string, a, b interface{}) interface{} {
+    x, okA := number(a)
+    y, okB := number(b)
+    if !okA || !okB {
+        if op == "+" && a != nil && b != nil {
+            return stringify(a) + stringify(b)
+        }
+        return nil
+    }
+    switch op {
+    case "+":
+        return x + y
+    case "-":
+        return x - y
+    case "*":
+        return x * y
+    case "/":
+        if y == 0 {
+            return nil
+        }
+        return x / y
+    }
+    if y == 0 {
+        return nil
+    }
+    return math.Mod(x, y)
+}
+
+// splLike compiles the pattern of LIKE or like(), where % matches any
+// sequence and _ one character
+func splLike(expr spl.Expr) (*regexp.Regexp, error) {
+    literal, ok := expr.(*spl.Literal)
+    if !ok || literal.Kind != spl.LiteralString {
+        return nil, fmt.Errorf("LIKE requires a string literal pattern")
+    }
+    var b strings.Builder
+    b.WriteString("(?s)^")
+    for _, r := range literal.Value {
+        switch r {
+        case '%':
+            b.WriteString(".*")
+        case '_':
+            b.WriteString(".")
+        default:
+            b.WriteString(regexp.QuoteMeta(string(r)))
+        }
+    }
+    b.WriteString("$")
+    return compileRegex(b.String(), false)
+}
+
+// compileSPLCall compiles the eval functions useful in where predicates
+func compileSPLCall(call *spl.Call) (splExpr, error) {
+    switch call.Name {
+    case "like", "match", "cidrmatch":
+        if len(call.Args) != 2 {
+            return nil, fmt.Errorf("%s takes two arguments", call.Name)
+        }
+    }
+    switch call.Name {
+    case "like":
+        operand, err := compileSPLExpr(call.Args[0])
+        if err != nil {
+            return nil, err
+        }
+        like, err := splLike(call.Args[1])
+        if err != nil {
+            return nil, err
+        }
+        return func(e Event) interface{} {
+            v := operand(e)
+            return v != nil && like.MatchString(stringify(v))
+        }, nil
+    case "match":
+        operand, err := compileSPLExpr(call.Args[0])
+        if err != nil {
+            return nil, err
+        }
+        literal, ok := call.Args[1].(*spl.Literal)
+        if !ok || literal.Kind != spl.LiteralString {
+            return nil, fmt.Errorf("match requires a string literal regular expression")
+        }
+        re, err := compileRegex(literal.Value, false)
+        if err != nil {
+            return nil, err
+        }
+        return func(e Event) interface{} {
+            v := operand(e)
+            return v != nil && re.MatchString(stringify(v))
+        }, nil
+    case "cidrmatch":
+        literal, ok := call.Args[0].(*spl.Literal)
+        if !ok || literal.Kind != spl.LiteralString {
+            return nil, fmt.Errorf("cidrmatch requires a string literal CIDR")
+        }
+        _, network, err := net.ParseCIDR(literal.Value)
+        if err != nil {
+            return nil, fmt.Errorf("invalid CIDR %q", literal.Value)
+        }
+        operand, err := compileSPLExpr(call.Args[1])
+        if err != nil {
+            return nil, err
+        }
+        return func(e Event) interface{} {
+            ip := net.ParseIP(stringify(operand(e)))
+            return ip != nil && network.Contains(ip)
+        }, nil
+    case "if":
+        if len(call.Args) != 3 {
+            return nil, fmt.Errorf("if takes three arguments")
+        }
+        args, err := compileSPLArgsExprs(call.Args)
+        if err != nil {
+            return nil, err
+        }
+        return func(e Event) interface{} {
+            if truthy(args[0](e)) {
+                return args[1](e)
+            }
+            return args[2](e)
+        }, nil
+    case "coalesce":
+        args, err := compileSPLArgsExprs(call.Args)
+        if err != nil {
+            return nil, err
+        }
+        return func(e Event) interface{} {
+            for _, arg := range args {
+                if v := arg(e); v != nil {
+                    return v
+                }
+            }
+            return nil
+        }, nil
+    }
+
+    fn, ok := splFunctions[call.Name]
+    if !ok {
+        return nil, fmt.Errorf("function %s is not supported by the test harness", call.Name)
+    }
+    if len(call.Args) != 1 {
+        return nil, fmt.Errorf("%s takes one argument", call.Name)
+    }
+    operand, err := compileSPLExpr(call.Args[0])
+    if err != nil {
+        return nil, err
+    }
+    return func(e Event) interface{} { return fn(operand(e)) }, nil
+}
+
+// compileSPLArgsExprs compiles function arguments
+func compileSPLArgsExprs(args []spl.Expr) ([]splExpr, error) {
+    compiled := make([]splExpr, len(args))
+    for i, arg := range args {
+        var err error
+        if compiled[i], err = compileSPLExpr(arg); err != nil {
+            return nil, err
+        }
+    }
+    return compiled, nil
+}
+
+// splFunctions are the supported eval functions of one argument
+var splFunctions = map[string]func(interface{}) interface{}{
+    "isnull":    func(v interface{}) interface{} { return v == nil },
+    "isnotnull": func(v interface{}) interface{} { return v != nil },
+    "lower":     func(v interface{}) interface{} { return strings.ToLower(stringify(v)) },
+    "upper":     func(v interface{}) interface{} { return strings.ToUpper(stringify(v)) },
+    "len":       func(v interface{}) interface{} { return float64(len([]rune(stringify(v)))) },
+    "tostring":  func(v interface{}) interface{} { return stringify(v) },
+    "tonumber":  toNumber,
+    "trim":      func(v interface{}) interface{} { return strings.TrimSpace(stringify(v)) },
+    "isnum": func(v interface{}) interface{} {
+        _, ok := number(v)
+        return ok
+    },
+}
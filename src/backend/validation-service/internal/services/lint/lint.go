@@ -0,0 +1,240 @@
+// Package lint reports content hygiene findings, such as naming, comment
+// header and formatting conventions, that are kept apart from validation
+// issues and never affect the confidence score.
+package lint
+
+import (
+    "encoding/json"
+    "fmt"
+    "regexp"
+    "sort"
+    "strings"
+
+    "validation-service/internal/config"
+    "validation-service/internal/models"
+)
+
+// DefaultProfile is the name of the built-in profile, which reports every
+// rule at its default level
+const DefaultProfile = "default"
+
+// levelOff disables a rule in a profile
+const levelOff = "off"
+
+// defaultMaxLineLength is the longest line accepted when a profile sets none
+const defaultMaxLineLength = 120
+
+// maxFindingsPerRule bounds the findings one rule reports for a detection
+const maxFindingsPerRule = 20
+
+// levelRank orders lint levels from most to least serious
+var levelRank = map[string]int{
+    models.LintLevelError:   0,
+    models.LintLevelWarning: 1,
+    models.LintLevelInfo:    2,
+    models.LintLevelStyle:   3,
+}
+
+// Rule is a lint check and the level its findings are reported at unless a
+// profile overrides it
+type Rule struct {
+    ID          string `json:"id"`
+    Category    string `json:"category"`
+    Level       string `json:"level"`
+    Description string `json:"description"`
+    // Formats restricts the rule to detection formats; every format when empty
+    Formats []string `json:"formats,omitempty"`
+
+    check func(d *document) []models.LintFinding
+}
+
+// appliesTo reports whether the rule checks detections of format
+func (r *Rule) appliesTo(format string) bool {
+    if len(r.Formats) == 0 {
+        return true
+    }
+    for _, f := range r.Formats {
+        if f == format {
+            return true
+        }
+    }
+    return false
+}
+
+// profile is a parsed lint profile
+type profile struct {
+    minLevel      string
+    levels        map[string]string
+    namePattern   *regexp.Regexp
+    maxLineLength int
+}
+
+// Linter runs the lint rules with the configured profiles
+type Linter struct {
+    profiles       map[string]*profile
+    defaultProfile string
+}
+
+// NewLinter creates a linter with the configured profiles and the built-in
+// default profile. Profiles naming unknown rules are rejected.
+func NewLinter(cfg config.LintConfig) (*Linter, error) {
+    l := &Linter{
+        profiles:       map[string]*profile{DefaultProfile: {maxLineLength: defaultMaxLineLength}},
+        defaultProfile: cfg.DefaultProfile,
+    }
+    if l.defaultProfile == "" {
+        l.defaultProfile = DefaultProfile
+    }
+
+    for name, p := range cfg.Profiles {
+        parsed := &profile{
+            minLevel:      p.MinLevel,
+            levels:        make(map[string]string, len(p.Rules)),
+            maxLineLength: p.MaxLineLength,
+        }
+        if parsed.maxLineLength == 0 {
+            parsed.maxLineLength = defaultMaxLineLength
+        }
+        for id, level := range p.Rules {
+            if findRule(id) == nil {
+                return nil, fmt.Errorf("lint profile %s: unknown rule %s", name, id)
+            }
+            parsed.levels[id] = level
+        }
+        if p.NamePattern != "" {
+            pattern, err := regexp.Compile(p.NamePattern)
+            if err != nil {
+                return nil, fmt.Errorf("lint profile %s: invalid name pattern: %w", name, err)
+            }
+            parsed.namePattern = pattern
+        }
+        l.profiles[name] = parsed
+    }
+    if _, ok := l.profiles[l.defaultProfile]; !ok {
+        return nil, fmt.Errorf("unknown default lint profile: %s", l.defaultProfile)
+    }
+    return l, nil
+}
+
+// HasProfile reports whether a profile is configured. The empty name selects
+// the default profile.
+func (l *Linter) HasProfile(name string) bool {
+    if name == "" {
+        return true
+    }
+    _, ok := l.profiles[name]
+    return ok
+}
+
+// Profiles returns the names of the configured profiles, sorted
+func (l *Linter) Profiles() []string {
+    names := make([]string, 0, len(l.profiles))
+    for name := range l.profiles {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// Lint checks detection with the named profile, or the default profile when
+// name is empty, and records the findings on result
+func (l *Linter) Lint(name string, detection *models.Detection, result *models.ValidationResult) error {
+    if name == "" {
+        name = l.defaultProfile
+    }
+    p, ok := l.profiles[name]
+    if !ok {
+        return fmt.Errorf("unknown lint profile: %s", name)
+    }
+
+    content, err := detection.GetContent()
+    if err != nil {
+        return err
+    }
+    format, err := detection.GetFormat()
+    if err != nil {
+        return err
+    }
+    doc := newDocument(format, content, DetectionName(detection), p)
+
+    result.LintProfile = name
+    for i := range rules {
+        rule := &rules[i]
+        if !rule.appliesTo(format) {
+            continue
+        }
+        level := rule.Level
+        if override, ok := p.levels[rule.ID]; ok {
+            level = override
+        }
+        if level == levelOff || (p.minLevel != "" && levelRank[level] > levelRank[p.minLevel]) {
+            continue
+        }
+
+        findings := rule.check(doc)
+        if len(findings) > maxFindingsPerRule {
+            findings = findings[:maxFindingsPerRule]
+        }
+        for _, finding := range findings {
+            finding.Rule = rule.ID
+            finding.Category = rule.Category
+            finding.Level = level
+            result.AddLintFinding(finding)
+        }
+    }
+    return nil
+}
+
+// Rules returns the lint rules, ordered by ID
+func Rules() []Rule {
+    out := make([]Rule, len(rules))
+    copy(out, rules)
+    return out
+}
+
+// findRule returns the rule with ID id, or nil
+func findRule(id string) *Rule {
+    for i := range rules {
+        if rules[i].ID == id {
+            return &rules[i]
+        }
+    }
+    return nil
+}
+
+// document is a detection prepared for the lint rules
+type document struct {
+    format  string
+    content string
+    lines   []string
+    name    string
+    profile *profile
+}
+
+func newDocument(format, content, name string, p *profile) *document {
+    content = strings.ReplaceAll(content, "\r\n", "\n")
+    return &document{
+        format:  format,
+        content: content,
+        lines:   strings.Split(content, "\n"),
+        name:    name,
+        profile: p,
+    }
+}
+
+// DetectionName returns the "name" of the detection metadata, falling back
+// to the name declared in the content
+func DetectionName(detection *models.Detection) string {
+    var metadata struct {
+        Name string `json:"name"`
+    }
+    if len(detection.Metadata) > 0 && json.Unmarshal(detection.Metadata, &metadata) == nil && metadata.Name != "" {
+        return metadata.Name
+    }
+    if match := contentNamePatterns[detection.Format]; match != nil {
+        if m := match.FindStringSubmatch(detection.Content); m != nil {
+            return strings.TrimSpace(m[1])
+        }
+    }
+    return ""
+}
@@ -0,0 +1,273 @@
+package lint
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+
+    "validation-service/internal/models"
+)
+
+// Rule categories
+const (
+    categoryNaming       = "naming"
+    categoryComments     = "comments"
+    categoryFormatting   = "formatting"
+    categoryIndexHygiene = "index_hygiene"
+)
+
+var (
+    // contentNamePatterns extract the declared name of a detection by format
+    contentNamePatterns = map[string]*regexp.Regexp{
+        models.DetectionFormatSigma:    regexp.MustCompile(`(?m)^title\s*:\s*['"]?([^'"\n]+)`),
+        models.DetectionFormatSentinel: regexp.MustCompile(`(?m)^(?:name|displayName)\s*:\s*['"]?([^'"\n]+)`),
+        models.DetectionFormatYara:     regexp.MustCompile(`(?m)^\s*(?:(?:private|global)\s+)*rule\s+(\w+)`),
+        models.DetectionFormatYaraL:    regexp.MustCompile(`(?m)^\s*rule\s+(\w+)`),
+        models.DetectionFormatSuricata: regexp.MustCompile(`msg\s*:\s*"([^"]+)"`),
+        models.DetectionFormatSnort:    regexp.MustCompile(`msg\s*:\s*"([^"]+)"`),
+        models.DetectionFormatZeek:     regexp.MustCompile(`(?m)^\s*(?:signature|module)\s+([\w.-]+)`),
+    }
+
+    // commentPrefixes are the line comment openers of each format a comment
+    // header is expected for
+    commentPrefixes = map[string][]string{
+        models.DetectionFormatSplunk:   {"```"},
+        models.DetectionFormatKQL:      {"//"},
+        models.DetectionFormatYara:     {"//", "/*"},
+        models.DetectionFormatYaraL:    {"//", "/*"},
+        models.DetectionFormatSuricata: {"#"},
+        models.DetectionFormatSnort:    {"#"},
+        models.DetectionFormatZeek:     {"#"},
+    }
+
+    // splIndexTerm matches an index constraint of a search
+    splIndexTerm = regexp.MustCompile(`(?i)\bindex\s*=`)
+    // splSourcetypeTerm matches a sourcetype constraint of a search
+    splSourcetypeTerm = regexp.MustCompile(`(?i)\bsourcetype\s*=`)
+    // splUppercaseCommand matches a piped command written in upper case
+    splUppercaseCommand = regexp.MustCompile(`\|\s*([A-Z][A-Z0-9_]+)\b`)
+    // splComment matches an SPL triple backtick comment
+    splComment = regexp.MustCompile("(?s)```.*?```")
+)
+
+// rules are the lint rules, ordered by ID
+var rules = []Rule{
+    {
+        ID:          "LINT001",
+        Category:    categoryNaming,
+        Level:       models.LintLevelInfo,
+        Description: "Detection has no name in its metadata or content",
+        check:       checkNamePresent,
+    },
+    {
+        ID:          "LINT002",
+        Category:    categoryNaming,
+        Level:       models.LintLevelStyle,
+        Description: "Detection name does not follow the profile naming convention",
+        check:       checkNameConvention,
+    },
+    {
+        ID:          "LINT010",
+        Category:    categoryComments,
+        Level:       models.LintLevelStyle,
+        Description: "Detection does not start with a comment header",
+        Formats: []string{
+            models.DetectionFormatSplunk, models.DetectionFormatKQL, models.DetectionFormatYara,
+            models.DetectionFormatYaraL, models.DetectionFormatSuricata, models.DetectionFormatSnort,
+            models.DetectionFormatZeek,
+        },
+        check: checkCommentHeader,
+    },
+    {
+        ID:          "LINT020",
+        Category:    categoryFormatting,
+        Level:       models.LintLevelStyle,
+        Description: "Line ends with whitespace",
+        check:       checkTrailingWhitespace,
+    },
+    {
+        ID:          "LINT021",
+        Category:    categoryFormatting,
+        Level:       models.LintLevelStyle,
+        Description: "Line exceeds the profile maximum length",
+        check:       checkLineLength,
+    },
+    {
+        ID:          "LINT022",
+        Category:    categoryFormatting,
+        Level:       models.LintLevelStyle,
+        Description: "Indentation mixes tabs and spaces",
+        check:       checkMixedIndentation,
+    },
+    {
+        ID:          "LINT030",
+        Category:    categoryIndexHygiene,
+        Level:       models.LintLevelWarning,
+        Description: "SPL search does not restrict the index",
+        Formats:     []string{models.DetectionFormatSplunk},
+        check:       checkSPLIndex,
+    },
+    {
+        ID:          "LINT031",
+        Category:    categoryIndexHygiene,
+        Level:       models.LintLevelInfo,
+        Description: "SPL search restricts the index but not the sourcetype",
+        Formats:     []string{models.DetectionFormatSplunk},
+        check:       checkSPLSourcetype,
+    },
+    {
+        ID:          "LINT032",
+        Category:    categoryNaming,
+        Level:       models.LintLevelStyle,
+        Description: "SPL command is written in upper case",
+        Formats:     []string{models.DetectionFormatSplunk},
+        check:       checkSPLCommandCase,
+    },
+}
+
+func checkNamePresent(d *document) []models.LintFinding {
+    if d.name != "" {
+        return nil
+    }
+    return []models.LintFinding{{
+        Message:  "Detection has no name; set \"name\" in the metadata or declare one in the rule",
+        Location: "name",
+    }}
+}
+
+func checkNameConvention(d *document) []models.LintFinding {
+    if d.name == "" || d.profile.namePattern == nil || d.profile.namePattern.MatchString(d.name) {
+        return nil
+    }
+    return []models.LintFinding{{
+        Message:  fmt.Sprintf("Name %q does not match the naming convention %s", d.name, d.profile.namePattern),
+        Location: "name",
+    }}
+}
+
+func checkCommentHeader(d *document) []models.LintFinding {
+    for i, line := range d.lines {
+        trimmed := strings.TrimSpace(line)
+        if trimmed == "" {
+            continue
+        }
+        for _, prefix := range commentPrefixes[d.format] {
+            if strings.HasPrefix(trimmed, prefix) {
+                return nil
+            }
+        }
+        return []models.LintFinding{{
+            Message:  "Start the detection with a comment describing its purpose, author and references",
+            Location: "header",
+            Line:     i + 1,
+        }}
+    }
+    return nil
+}
+
+func checkTrailingWhitespace(d *document) []models.LintFinding {
+    var findings []models.LintFinding
+    for i, line := range d.lines {
+        if trimmed := strings.TrimRight(line, " \t"); len(trimmed) != len(line) {
+            findings = append(findings, models.LintFinding{
+                Message:  "Remove trailing whitespace",
+                Location: fmt.Sprintf("line %d", i+1),
+                Line:     i + 1,
+                Column:   len(trimmed) + 1,
+            })
+        }
+    }
+    return findings
+}
+
+func checkLineLength(d *document) []models.LintFinding {
+    var findings []models.LintFinding
+    for i, line := range d.lines {
+        if length := len([]rune(line)); length > d.profile.maxLineLength {
+            findings = append(findings, models.LintFinding{
+                Message:  fmt.Sprintf("Line is %d characters long, longer than %d", length, d.profile.maxLineLength),
+                Location: fmt.Sprintf("line %d", i+1),
+                Line:     i + 1,
+                Column:   d.profile.maxLineLength + 1,
+            })
+        }
+    }
+    return findings
+}
+
+// checkMixedIndentation reports the first line indented differently from
+// the first indented line, or indented with both tabs and spaces
+func checkMixedIndentation(d *document) []models.LintFinding {
+    first := ""
+    for i, line := range d.lines {
+        indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+        if indent == "" || strings.TrimSpace(line) == "" {
+            continue
+        }
+        mixed := strings.Contains(indent, " ") && strings.Contains(indent, "\t")
+        if first == "" && !mixed {
+            first = indent[:1]
+            continue
+        }
+        if mixed || indent[:1] != first {
+            return []models.LintFinding{{
+                Message:  "Indent consistently with either tabs or spaces",
+                Location: fmt.Sprintf("line %d", i+1),
+                Line:     i + 1,
+                Column:   1,
+            }}
+        }
+    }
+    return nil
+}
+
+// splBaseSearch returns the search before the first pipe, without comments,
+// and whether the search starts with a generating command instead
+func splBaseSearch(content string) (string, bool) {
+    search := strings.TrimSpace(splComment.ReplaceAllString(content, " "))
+    if strings.HasPrefix(search, "|") {
+        return "", false
+    }
+    base, _, _ := strings.Cut(search, "|")
+    return base, true
+}
+
+func checkSPLIndex(d *document) []models.LintFinding {
+    base, ok := splBaseSearch(d.content)
+    if !ok || splIndexTerm.MatchString(base) {
+        return nil
+    }
+    return []models.LintFinding{{
+        Message:  "Restrict the base search to the indexes holding the data, e.g. index=security",
+        Location: "base_search",
+        Line:     1,
+    }}
+}
+
+func checkSPLSourcetype(d *document) []models.LintFinding {
+    base, ok := splBaseSearch(d.content)
+    if !ok || !splIndexTerm.MatchString(base) || splSourcetypeTerm.MatchString(base) {
+        return nil
+    }
+    return []models.LintFinding{{
+        Message:  "Restrict the base search to a sourcetype as well as an index",
+        Location: "base_search",
+        Line:     1,
+    }}
+}
+
+func checkSPLCommandCase(d *document) []models.LintFinding {
+    var findings []models.LintFinding
+    for i, line := range d.lines {
+        for _, m := range splUppercaseCommand.FindAllStringSubmatchIndex(line, -1) {
+            command := line[m[2]:m[3]]
+            findings = append(findings, models.LintFinding{
+                Message:  fmt.Sprintf("Write the %s command in lower case: %s", command, strings.ToLower(command)),
+                Location: fmt.Sprintf("line %d", i+1),
+                Line:     i + 1,
+                Column:   m[2] + 1,
+            })
+        }
+    }
+    return findings
+}
@@ -0,0 +1,131 @@
+// Package migration drives the guided migration of a rule repository to
+// other formats: it infers the formats of uploaded rules, translates them
+// with the translation service and validates the translations.
+package migration
+
+import (
+    "path"
+    "regexp"
+    "strings"
+
+    "validation-service/internal/models"
+)
+
+// extensionFormats are the formats a rule file extension suggests, most
+// likely first
+var extensionFormats = map[string][]string{
+    ".yml":   {models.DetectionFormatSigma, models.DetectionFormatSentinel},
+    ".yaml":  {models.DetectionFormatSigma, models.DetectionFormatSentinel},
+    ".json":  {models.DetectionFormatSentinel},
+    ".spl":   {models.DetectionFormatSplunk},
+    ".kql":   {models.DetectionFormatKQL},
+    ".aql":   {models.DetectionFormatQRadar},
+    ".yar":   {models.DetectionFormatYara},
+    ".yara":  {models.DetectionFormatYara},
+    ".yaral": {models.DetectionFormatYaraL},
+    ".rules": {models.DetectionFormatSuricata, models.DetectionFormatSnort},
+    ".sig":   {models.DetectionFormatZeek},
+    ".zeek":  {models.DetectionFormatZeek},
+    ".bro":   {models.DetectionFormatZeek},
+}
+
+// contentSignature recognizes rules of a format by their content
+type contentSignature struct {
+    format string
+    match  func(content string) bool
+}
+
+var (
+    sigmaDetectionRegex   = regexp.MustCompile(`(?m)^detection:\s*$`)
+    sigmaLogsourceRegex   = regexp.MustCompile(`(?m)^logsource:\s*$`)
+    sentinelQueryRegex    = regexp.MustCompile(`(?m)^\s*"?query"?\s*:`)
+    sentinelScheduleRegex = regexp.MustCompile(`(?m)^\s*"?queryFrequency"?\s*:`)
+    yaraRuleRegex         = regexp.MustCompile(`(?m)^\s*(?:(?:private|global)\s+)*rule\s+\w+`)
+    yaraConditionRegex    = regexp.MustCompile(`(?m)^\s*condition\s*:`)
+    yaralEventsRegex      = regexp.MustCompile(`(?m)^\s*events\s*:`)
+    networkRuleRegex      = regexp.MustCompile(`(?m)^\s*(?:alert|drop|reject|pass)\s+(?:tcp|udp|icmp|ip|http|tls|dns|smb|ftp|ssh)\b.*(?:->|<>).*\(`)
+    suricataBufferRegex   = regexp.MustCompile(`\b(?:http|tls|dns|file|ja3|smb)\.[a-z_]+;`)
+    aqlSelectRegex        = regexp.MustCompile(`(?is)^\s*select\b.+\bfrom\s+(?:events|flows|assets)\b`)
+    splSearchRegex        = regexp.MustCompile(`(?i)(?:^|\s)(?:index|sourcetype|source|eventtype)\s*=|^\s*\|\s*(?:tstats|inputlookup|from|makeresults)\b`)
+    kqlPipeRegex          = regexp.MustCompile(`(?m)^\s*\|\s*(?:where|project|extend|summarize|join|take|top)\b`)
+    crowdstrikeRegex      = regexp.MustCompile(`\b#?event_simpleName\s*=`)
+    zeekSignatureRegex    = regexp.MustCompile(`(?m)^\s*signature\s+[\w.-]+\s*\{`)
+    zeekScriptRegex       = regexp.MustCompile(`(?m)^\s*(?:@load\s|redef\s+enum\s+Notice::Type|event\s+\w+\s*\()`)
+    paloAltoRegex         = regexp.MustCompile(`(?m)^\s*"?(?:log_type|rule_name)"?\s*[:=]`)
+)
+
+// contentSignatures are checked in order; earlier signatures are more
+// specific
+var contentSignatures = []contentSignature{
+    {models.DetectionFormatSigma, func(c string) bool {
+        return sigmaDetectionRegex.MatchString(c) && sigmaLogsourceRegex.MatchString(c)
+    }},
+    {models.DetectionFormatSentinel, func(c string) bool {
+        return sentinelQueryRegex.MatchString(c) && sentinelScheduleRegex.MatchString(c)
+    }},
+    {models.DetectionFormatYaraL, func(c string) bool {
+        return yaraRuleRegex.MatchString(c) && yaralEventsRegex.MatchString(c)
+    }},
+    {models.DetectionFormatYara, func(c string) bool {
+        return yaraRuleRegex.MatchString(c) && yaraConditionRegex.MatchString(c)
+    }},
+    {models.DetectionFormatSuricata, func(c string) bool {
+        return networkRuleRegex.MatchString(c) && suricataBufferRegex.MatchString(c)
+    }},
+    {models.DetectionFormatSnort, networkRuleRegex.MatchString},
+    {models.DetectionFormatSuricata, networkRuleRegex.MatchString},
+    {models.DetectionFormatZeek, zeekSignatureRegex.MatchString},
+    {models.DetectionFormatZeek, zeekScriptRegex.MatchString},
+    {models.DetectionFormatQRadar, aqlSelectRegex.MatchString},
+    {models.DetectionFormatCrowdstrike, crowdstrikeRegex.MatchString},
+    {models.DetectionFormatPaloAlto, paloAltoRegex.MatchString},
+    {models.DetectionFormatSplunk, splSearchRegex.MatchString},
+    {models.DetectionFormatKQL, kqlPipeRegex.MatchString},
+}
+
+// DetectFormat infers the format of a rule file from its content and
+// extension. It returns the inferred format, empty when none matched, and
+// every candidate format, most likely first. Formats matched by the content
+// come before those only suggested by the extension; a content match the
+// extension also suggests is preferred.
+func DetectFormat(name, content string) (string, []string) {
+    suggested := extensionFormats[strings.ToLower(path.Ext(name))]
+
+    var matched []string
+    for _, signature := range contentSignatures {
+        if !contains(matched, signature.format) && signature.match(content) {
+            matched = append(matched, signature.format)
+        }
+    }
+
+    candidates := make([]string, 0, len(matched)+len(suggested))
+    for _, format := range matched {
+        if contains(suggested, format) {
+            candidates = append(candidates, format)
+        }
+    }
+    for _, format := range matched {
+        if !contains(candidates, format) {
+            candidates = append(candidates, format)
+        }
+    }
+    for _, format := range suggested {
+        if !contains(candidates, format) {
+            candidates = append(candidates, format)
+        }
+    }
+    if len(candidates) == 0 {
+        return "", nil
+    }
+    return candidates[0], candidates
+}
+
+// contains reports whether formats holds format
+func contains(formats []string, format string) bool {
+    for _, f := range formats {
+        if f == format {
+            return true
+        }
+    }
+    return false
+}
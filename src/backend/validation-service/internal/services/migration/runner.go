@@ -0,0 +1,266 @@
+package migration
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"          // v1.17.0
+    "github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+
+    "validation-service/internal/config"
+    "validation-service/internal/models"
+    "validation-service/internal/services/validation"
+    "validation-service/internal/storage"
+    "validation-service/internal/tenant"
+    "validation-service/pkg/logger"
+)
+
+// progressInterval is how often a run saves its results
+const progressInterval = 5 * time.Second
+
+// ErrRunInProgress is returned when a run of the wizard holds its lease
+var ErrRunInProgress = errors.New("a run of the migration wizard is in progress")
+
+var wizardRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name:        "migration_wizard_runs_total",
+    Help:        "Translation and validation runs of migration wizards by outcome",
+    ConstLabels: prometheus.Labels{"service": "validation"},
+}, []string{"outcome"})
+
+// Runner translates the rules of migration wizards to their target formats
+// and validates the translations in the background. Progress is saved to
+// the wizard as the run goes, so a run lost with its replica can be resumed
+// from its last saved result.
+type Runner struct {
+    store      storage.MigrationWizardStore
+    translator *Translator
+    validator  *validation.ValidationService
+    results    storage.ResultStore
+    tenants    *tenant.Registry
+    cfg        config.MigrationConfig
+    log        *logger.Logger
+}
+
+// NewRunner creates a runner saving wizards to store. Validation results are
+// saved to results when set.
+func NewRunner(store storage.MigrationWizardStore, translator *Translator, validator *validation.ValidationService, results storage.ResultStore, tenants *tenant.Registry, cfg config.MigrationConfig) *Runner {
+    return &Runner{
+        store:      store,
+        translator: translator,
+        validator:  validator,
+        results:    results,
+        tenants:    tenants,
+        cfg:        cfg,
+        log:        logger.GetLogger(),
+    }
+}
+
+// Start claims the run of the wizard and runs it in the background. Rules
+// translated by an earlier run are kept; failed ones are retried. The wizard
+// is updated to the claimed version, so ErrVersionConflict is returned when
+// it changed since it was read.
+func (r *Runner) Start(wizard *storage.MigrationWizard) error {
+    now := time.Now().UTC()
+    if wizard.Running(now) {
+        return ErrRunInProgress
+    }
+
+    kept := make([]storage.WizardResult, 0, len(wizard.Results))
+    for _, result := range wizard.Results {
+        if result.Status != storage.WizardStatusFailed {
+            kept = append(kept, result)
+        }
+    }
+    leaseUntil := now.Add(r.cfg.RunTimeout)
+    wizard.Results = kept
+    wizard.Deployment = nil
+    wizard.Step = storage.WizardStepRun
+    wizard.Run = &storage.WizardRun{
+        Status:     storage.RunRunning,
+        StartedAt:  now,
+        LeaseUntil: &leaseUntil,
+        Total:      len(pairs(wizard)),
+    }
+    tally(wizard)
+    wizard.UpdatedAt = now
+    if err := r.store.UpdateMigrationWizard(context.Background(), wizard); err != nil {
+        return err
+    }
+
+    // The run works on its own copy, so the caller may keep reading wizard
+    running := *wizard
+    state := *wizard.Run
+    running.Run = &state
+    running.Results = append([]storage.WizardResult(nil), wizard.Results...)
+    go r.run(&running)
+    return nil
+}
+
+// pair is a rule and a target format it is translated to
+type pair struct {
+    rule   *storage.WizardRule
+    target storage.WizardTarget
+}
+
+// pairs returns the rule and target pairs of a run, by rule and target in
+// order. Excluded rules and rules without a format are skipped, as are
+// targets in the format of the rule.
+func pairs(wizard *storage.MigrationWizard) []pair {
+    var all []pair
+    for i := range wizard.Rules {
+        rule := &wizard.Rules[i]
+        if rule.Excluded || rule.Format == "" {
+            continue
+        }
+        for _, target := range wizard.Targets {
+            if target.Format == rule.Format {
+                continue
+            }
+            all = append(all, pair{rule: rule, target: target})
+        }
+    }
+    return all
+}
+
+// run translates and validates every pair without a result, saving the
+// results every progressInterval and when done
+func (r *Runner) run(wizard *storage.MigrationWizard) {
+    ctx, cancel := context.WithDeadline(context.Background(), *wizard.Run.LeaseUntil)
+    defer cancel()
+    ctx = tenant.WithTenant(ctx, r.tenants.Resolve(wizard.TenantID))
+    ctx = logger.WithCorrelationID(ctx, wizard.ID.String())
+    ctx = logger.WithTenantID(ctx, wizard.TenantID)
+
+    done := make(map[string]bool, len(wizard.Results))
+    for _, result := range wizard.Results {
+        done[result.Path+"\x00"+result.TargetFormat] = true
+    }
+
+    var err error
+    saved := time.Now()
+    for _, p := range pairs(wizard) {
+        if done[p.rule.Path+"\x00"+p.target.Format] {
+            continue
+        }
+        if err = ctx.Err(); err != nil {
+            break
+        }
+        wizard.Results = append(wizard.Results, r.migrate(ctx, wizard, p))
+
+        if time.Since(saved) >= progressInterval {
+            tally(wizard)
+            wizard.UpdatedAt = time.Now().UTC()
+            if err = r.store.UpdateMigrationWizard(context.Background(), wizard); err != nil {
+                // The wizard was deleted or another run took it over
+                r.log.Warn("Migration wizard run stopped",
+                    "error", err,
+                    "migration_id", wizard.ID,
+                )
+                wizardRuns.WithLabelValues("abandoned").Inc()
+                return
+            }
+            saved = time.Now()
+        }
+    }
+
+    tally(wizard)
+    finished := time.Now().UTC()
+    run := wizard.Run
+    run.FinishedAt = &finished
+    run.LeaseUntil = nil
+    run.Status = storage.RunSucceeded
+    switch {
+    case err != nil:
+        run.Status = storage.RunFailed
+        run.Error = fmt.Sprintf("run stopped after %d of %d translations: %v", run.Completed, run.Total, err)
+    case run.Total > 0 && run.Counts[storage.WizardStatusFailed] == run.Total:
+        run.Status = storage.RunFailed
+        run.Error = "no rule could be translated and validated"
+    default:
+        wizard.Step = storage.WizardStepExport
+    }
+    wizard.UpdatedAt = finished
+    wizardRuns.WithLabelValues(run.Status).Inc()
+
+    // The outcome is recorded even when the run timed out
+    if err := r.store.UpdateMigrationWizard(context.Background(), wizard); err != nil {
+        r.log.Error("Failed to record migration wizard run",
+            "error", err,
+            "migration_id", wizard.ID,
+        )
+        return
+    }
+    r.log.Info("Migration wizard run completed",
+        "migration_id", wizard.ID,
+        "tenant_id", wizard.TenantID,
+        "status", run.Status,
+        "translations", run.Completed,
+        "failed", run.Counts[storage.WizardStatusFailed],
+        "duration", finished.Sub(run.StartedAt),
+    )
+}
+
+// migrate translates the rule of p to its target, validates the translation
+// and saves the validation result
+func (r *Runner) migrate(ctx context.Context, wizard *storage.MigrationWizard, p pair) storage.WizardResult {
+    result := storage.WizardResult{
+        Path:         p.rule.Path,
+        TargetFormat: p.target.Format,
+        Status:       storage.WizardStatusFailed,
+    }
+
+    translateCtx, cancel := context.WithTimeout(ctx, r.cfg.TranslationTimeout)
+    translation, err := r.translator.Translate(translateCtx, p.rule.Content, p.rule.Format, p.target.Format, p.target.Pipelines, wizard.ID.String())
+    cancel()
+    if err != nil {
+        result.Error = err.Error()
+        return result
+    }
+    result.Content = translation.Content
+    result.TranslationConfidence = translation.Confidence
+
+    source, err := models.NewDetection(p.rule.Content, p.rule.Format)
+    if err != nil {
+        result.Error = err.Error()
+        return result
+    }
+    target, err := models.NewDetection(translation.Content, p.target.Format)
+    if err != nil {
+        result.Error = err.Error()
+        return result
+    }
+    validated, err := r.validator.ValidateDetection(ctx, source, target)
+    if err != nil {
+        result.Error = fmt.Sprintf("validation error: %v", err)
+        return result
+    }
+
+    result.Status = validated.Status
+    result.ConfidenceScore = validated.ConfidenceScore
+    result.Issues = len(validated.Issues)
+    if r.results != nil {
+        if err := r.results.SaveResult(ctx, wizard.TenantID, validated); err != nil {
+            r.log.Error("Failed to persist migration validation result",
+                "error", err,
+                "migration_id", wizard.ID,
+                "result_id", validated.ID,
+            )
+        } else {
+            id := validated.ID
+            result.ResultID = &id
+        }
+    }
+    return result
+}
+
+// tally counts the results of the wizard's run
+func tally(wizard *storage.MigrationWizard) {
+    counts := make(map[string]int)
+    for _, result := range wizard.Results {
+        counts[result.Status]++
+    }
+    wizard.Run.Completed = len(wizard.Results)
+    wizard.Run.Counts = counts
+}
@@ -0,0 +1,103 @@
+package migration
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "strings"
+)
+
+// maxTranslationResponseSize bounds the translation service responses read
+const maxTranslationResponseSize = 4 << 20 // 4MB
+
+// translateRequest is the body of a translation service request
+type translateRequest struct {
+    DetectionText string                 `json:"detection_text"`
+    SourceFormat  string                 `json:"source_format"`
+    TargetFormat  string                 `json:"target_format"`
+    CorrelationID string                 `json:"correlation_id,omitempty"`
+    Metadata      map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// translateResponse is the subset of a translation service response the
+// wizard uses
+type translateResponse struct {
+    TranslatedText  string  `json:"translated_text"`
+    ConfidenceScore float64 `json:"confidence_score"`
+}
+
+// Translation is a rule translated by the translation service
+type Translation struct {
+    Content string
+    // Confidence is the confidence reported by the translation service
+    Confidence float64
+}
+
+// Translator translates rules with the translate endpoint of the
+// translation service
+type Translator struct {
+    url    string
+    client *http.Client
+}
+
+// NewTranslator creates a translator for the translation service at
+// baseURL, sending its requests with client
+func NewTranslator(baseURL string, client *http.Client) *Translator {
+    return &Translator{
+        url:    strings.TrimSuffix(baseURL, "/") + "/api/v1/translate",
+        client: client,
+    }
+}
+
+// Translate translates content from sourceFormat to targetFormat, applying
+// pipelines in order. correlationID is logged by the translation service.
+func (t *Translator) Translate(ctx context.Context, content, sourceFormat, targetFormat string, pipelines []string, correlationID string) (*Translation, error) {
+    request := translateRequest{
+        DetectionText: content,
+        SourceFormat:  sourceFormat,
+        TargetFormat:  targetFormat,
+        CorrelationID: correlationID,
+    }
+    if len(pipelines) > 0 {
+        request.Metadata = map[string]interface{}{"pipelines": pipelines}
+    }
+    body, err := json.Marshal(request)
+    if err != nil {
+        return nil, err
+    }
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("Content-Type", "application/json")
+
+    resp, err := t.client.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("translation service unavailable: %w", err)
+    }
+    defer resp.Body.Close()
+    data, err := io.ReadAll(io.LimitReader(resp.Body, maxTranslationResponseSize))
+    if err != nil {
+        return nil, fmt.Errorf("reading translation: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        text := strings.TrimSpace(string(data))
+        if len(text) > 200 {
+            text = text[:200]
+        }
+        return nil, fmt.Errorf("translation service returned %d: %s", resp.StatusCode, text)
+    }
+
+    var translated translateResponse
+    if err := json.Unmarshal(data, &translated); err != nil {
+        return nil, fmt.Errorf("decoding translation: %w", err)
+    }
+    if strings.TrimSpace(translated.TranslatedText) == "" {
+        return nil, errors.New("translation service returned an empty translation")
+    }
+    return &Translation{Content: translated.TranslatedText, Confidence: translated.ConfidenceScore}, nil
+}
@@ -0,0 +1,150 @@
+// Package normalize rewrites detection content in the canonical layout of its
+// format: pipe and operator spacing for SPL and KQL, indentation for Sigma
+// and Sentinel YAML, and brace layout and indentation for YARA and YARA-L.
+// Only layout changes; quoted strings, regular expressions, macros and
+// comments are left as written.
+package normalize
+
+import (
+    "errors"
+    "strings"
+
+    "validation-service/internal/models"
+    "validation-service/internal/services/rulediff"
+)
+
+// Normalization rules, reported for the rules that changed the content
+const (
+    RuleLineEndings        = "line_endings"
+    RuleTrailingWhitespace = "trailing_whitespace"
+    RuleBlankLines         = "blank_lines"
+    RulePipeSpacing        = "pipe_spacing"
+    RuleOperatorSpacing    = "operator_spacing"
+    RuleIndentation        = "indentation"
+    RuleBraceLayout        = "brace_layout"
+)
+
+// ErrInvalidContent is returned when content cannot be parsed far enough to
+// be normalized
+var ErrInvalidContent = errors.New("content cannot be normalized")
+
+// Result is content in canonical layout and how it differs from the input
+type Result struct {
+    Format  string `json:"format"`
+    Content string `json:"content"`
+    // Changed is set when the content was not in canonical layout
+    Changed bool `json:"changed"`
+    // Rules are the normalization rules that changed the content, in the
+    // order they were applied
+    Rules []string `json:"rules"`
+    // Diff lists the lines removed from the input and added to the
+    // normalized content
+    Diff []rulediff.LineChange `json:"diff"`
+}
+
+// step applies one normalization rule
+type step struct {
+    rule  string
+    apply func(string) (string, error)
+}
+
+// formatSteps are the format-specific rules; formats without an entry only
+// have their whitespace normalized
+var formatSteps = map[string][]step{
+    models.DetectionFormatSplunk: {
+        {RulePipeSpacing, splunkPipes},
+    },
+    models.DetectionFormatKQL: {
+        {RulePipeSpacing, kqlPipes},
+        {RuleOperatorSpacing, kqlOperators},
+    },
+    models.DetectionFormatSigma: {
+        {RuleIndentation, yamlIndent(sigmaIndent)},
+    },
+    models.DetectionFormatSentinel: {
+        {RuleIndentation, yamlIndent(sentinelIndent)},
+        {RulePipeSpacing, sentinelQuery(kqlPipes)},
+        {RuleOperatorSpacing, sentinelQuery(kqlOperators)},
+    },
+    models.DetectionFormatYara: {
+        {RuleBraceLayout, yaraBraces},
+        {RuleIndentation, yaraIndent},
+    },
+    models.DetectionFormatYaraL: {
+        {RuleBraceLayout, yaraBraces},
+        {RuleIndentation, yaraIndent},
+    },
+}
+
+// Normalize returns content of format in canonical layout. Line endings are
+// normalized first and trailing whitespace and blank lines last, so every
+// format ends up without trailing whitespace, runs of blank lines or a
+// final newline.
+func Normalize(content, format string) (*Result, error) {
+    steps := []step{{RuleLineEndings, normalizeLineEndings}}
+    steps = append(steps, formatSteps[format]...)
+    steps = append(steps,
+        step{RuleTrailingWhitespace, trimTrailingWhitespace},
+        step{RuleBlankLines, collapseBlankLines},
+    )
+
+    result := &Result{Format: format, Rules: []string{}}
+    normalized := content
+    for _, s := range steps {
+        next, err := s.apply(normalized)
+        if err != nil {
+            return nil, err
+        }
+        if next != normalized && !containsRule(result.Rules, s.rule) {
+            result.Rules = append(result.Rules, s.rule)
+        }
+        normalized = next
+    }
+
+    result.Content = normalized
+    result.Changed = normalized != content
+    result.Diff = rulediff.CompareLines(content, normalized)
+    return result, nil
+}
+
+// containsRule reports whether rules contains rule
+func containsRule(rules []string, rule string) bool {
+    for _, r := range rules {
+        if r == rule {
+            return true
+        }
+    }
+    return false
+}
+
+// normalizeLineEndings converts CRLF and CR line endings to LF
+func normalizeLineEndings(content string) (string, error) {
+    content = strings.ReplaceAll(content, "\r\n", "\n")
+    return strings.ReplaceAll(content, "\r", "\n"), nil
+}
+
+// trimTrailingWhitespace removes spaces and tabs at the end of lines
+func trimTrailingWhitespace(content string) (string, error) {
+    lines := strings.Split(content, "\n")
+    for i, line := range lines {
+        lines[i] = strings.TrimRight(line, " \t")
+    }
+    return strings.Join(lines, "\n"), nil
+}
+
+// collapseBlankLines reduces runs of blank lines to one and removes blank
+// lines and newlines at the start and end of content
+func collapseBlankLines(content string) (string, error) {
+    lines := strings.Split(content, "\n")
+    kept := lines[:0]
+    for _, line := range lines {
+        if line == "" && (len(kept) == 0 || kept[len(kept)-1] == "") {
+            continue
+        }
+        kept = append(kept, line)
+    }
+    for len(kept) > 0 && kept[len(kept)-1] == "" {
+        kept = kept[:len(kept)-1]
+    }
+    return strings.Join(kept, "\n"), nil
+}
@@ -0,0 +1,150 @@
+package normalize
+
+import (
+    "bytes"
+    "strings"
+)
+
+// pipeContext describes where a pipe appears in a query
+type pipeContext struct {
+    // opens is set when the pipe starts the query or a subsearch
+    opens bool
+    // newline is set when the pipe started a line of its own
+    newline bool
+    // indent is the indentation of that line
+    indent string
+    // depth counts the brackets the pipe is nested in
+    depth int
+}
+
+// splunkPipes spaces SPL pipes as " | ". Pipes starting a line keep their
+// line and indentation, and pipes opening a search or subsearch are
+// written "| ".
+func splunkPipes(content string) (string, error) {
+    return rewritePipes(content, splSyntax, func(pipe pipeContext) string {
+        switch {
+        case pipe.opens:
+            return "| "
+        case pipe.newline:
+            return "\n" + pipe.indent + "| "
+        }
+        return " | "
+    }), nil
+}
+
+// kqlPipes puts every top-level KQL pipe at the start of its own line.
+// Pipes nested in parentheses or braces stay where they are, spaced as
+// " | ".
+func kqlPipes(content string) (string, error) {
+    return rewritePipes(content, kqlSyntax, func(pipe pipeContext) string {
+        switch {
+        case pipe.opens:
+            return "| "
+        case pipe.depth == 0:
+            return "\n| "
+        case pipe.newline:
+            return "\n" + pipe.indent + "| "
+        }
+        return " | "
+    }), nil
+}
+
+// rewritePipes replaces each pipe outside literals, and the whitespace
+// around it, with what layout returns for the pipe
+func rewritePipes(content string, syn syntax, layout func(pipeContext) string) string {
+    code := syn.code(content)
+    out := make([]byte, 0, len(content))
+    depth := 0
+    for i := 0; i < len(content); i++ {
+        c := content[i]
+        if !code[i] {
+            out = append(out, c)
+            continue
+        }
+        switch c {
+        case '(', '[', '{':
+            depth++
+        case ')', ']', '}':
+            if depth > 0 {
+                depth--
+            }
+        }
+        if c != '|' || (i+1 < len(content) && content[i+1] == '|') || (i > 0 && content[i-1] == '|') {
+            out = append(out, c)
+            continue
+        }
+
+        trimmed := bytes.TrimRight(out, " \t\n")
+        space := string(out[len(trimmed):])
+        pipe := pipeContext{depth: depth}
+        if idx := strings.LastIndexByte(space, '\n'); idx >= 0 {
+            pipe.newline = true
+            pipe.indent = space[idx+1:]
+        }
+        pipe.opens = len(trimmed) == 0 || bytes.HasSuffix(trimmed, []byte("[")) || bytes.HasSuffix(trimmed, []byte("("))
+        if len(trimmed) == 0 {
+            // Whitespace before the query is dropped later
+            pipe.newline = false
+        }
+
+        out = append(out[:len(trimmed)], layout(pipe)...)
+        for i+1 < len(content) && code[i+1] && isSpace(content[i+1]) {
+            i++
+        }
+    }
+    return string(out)
+}
+
+// kqlOperators lists the KQL comparison operators spaced as " op "
+var kqlOperators = operatorSpacing(kqlSyntax, []string{"==", "!=", "=~", "!~", "<=", ">="})
+
+// operatorSpacing returns a rule putting single spaces around operators
+// outside literals. Operators starting a line keep its indentation.
+func operatorSpacing(syn syntax, operators []string) func(string) (string, error) {
+    return func(content string) (string, error) {
+        code := syn.code(content)
+        out := make([]byte, 0, len(content))
+        for i := 0; i < len(content); i++ {
+            op := ""
+            if code[i] {
+                op = operatorAt(content, i, operators)
+            }
+            if op == "" || (i+len(op) < len(content) && code[i+len(op)] && strings.ContainsRune("=~<>!", rune(content[i+len(op)]))) {
+                out = append(out, content[i])
+                continue
+            }
+
+            trimmed := bytes.TrimRight(out, " \t")
+            if len(trimmed) > 0 && trimmed[len(trimmed)-1] != '\n' {
+                out = append(out[:len(trimmed)], ' ')
+            }
+            out = append(out, op...)
+            i += len(op) - 1
+            for i+1 < len(content) && code[i+1] && (content[i+1] == ' ' || content[i+1] == '\t') {
+                i++
+            }
+            if i+1 < len(content) && content[i+1] != '\n' {
+                out = append(out, ' ')
+            }
+        }
+        return string(out), nil
+    }
+}
+
+// operatorAt returns the operator starting at i, if any
+func operatorAt(content string, i int, operators []string) string {
+    if i > 0 && strings.ContainsRune("=~<>!", rune(content[i-1])) {
+        return ""
+    }
+    for _, op := range operators {
+        if strings.HasPrefix(content[i:], op) {
+            return op
+        }
+    }
+    return ""
+}
+
+// isSpace reports whether c is a space, tab or newline
+func isSpace(c byte) bool {
+    return c == ' ' || c == '\t' || c == '\n'
+}
@@ -0,0 +1,121 @@
+package normalize
+
+import "strings"
+
+// syntax describes the literals and comments of a query language, whose
+// content layout rules must not touch
+type syntax struct {
+    // lineComment starts a comment running to the end of the line
+    lineComment string
+    // blockComments enables /* */ comments
+    blockComments bool
+    // tripleBacktickComments enables SPL ```comments```
+    tripleBacktickComments bool
+    // backticks enables backtick-quoted literals
+    backticks bool
+    // verbatimStrings enables KQL @"..." strings, where quotes are doubled
+    // rather than escaped
+    verbatimStrings bool
+    // regexLiterals enables /.../ regular expressions after = or matches
+    regexLiterals bool
+}
+
+var (
+    splSyntax  = syntax{tripleBacktickComments: true, backticks: true}
+    kqlSyntax  = syntax{lineComment: "//", verbatimStrings: true}
+    yaraSyntax = syntax{lineComment: "//", blockComments: true, backticks: true, regexLiterals: true}
+)
+
+// code marks the bytes of content outside literals and comments
+func (s syntax) code(content string) []bool {
+    code := make([]bool, len(content))
+    for i := 0; i < len(content); {
+        end := s.literalEnd(content, i)
+        if end == i {
+            code[i] = true
+            i++
+            continue
+        }
+        i = end
+    }
+    return code
+}
+
+// literalEnd returns the end of the literal or comment starting at i, or i
+// when none starts there
+func (s syntax) literalEnd(content string, i int) int {
+    rest := content[i:]
+    switch {
+    case s.lineComment != "" && strings.HasPrefix(rest, s.lineComment):
+        return lineEnd(content, i)
+    case s.blockComments && strings.HasPrefix(rest, "/*"):
+        return closingEnd(content, i+2, "*/")
+    case s.tripleBacktickComments && strings.HasPrefix(rest, "```"):
+        return closingEnd(content, i+3, "```")
+    case s.verbatimStrings && (strings.HasPrefix(rest, `@"`) || strings.HasPrefix(rest, `@'`)):
+        return verbatimEnd(content, i+1)
+    case rest[0] == '"' || rest[0] == '\'':
+        return quotedEnd(content, i)
+    case s.backticks && rest[0] == '`':
+        return quotedEnd(content, i)
+    case s.regexLiterals && rest[0] == '/' && regexAllowed(content[:i]):
+        if end := quotedEnd(content, i); end <= lineEnd(content, i) {
+            return end
+        }
+    }
+    return i
+}
+
+// quotedEnd returns the end of the literal opened by the quote at i, which
+// runs to the next unescaped occurrence of that quote
+func quotedEnd(content string, i int) int {
+    quote := content[i]
+    for j := i + 1; j < len(content); j++ {
+        switch content[j] {
+        case '\\':
+            j++
+        case quote:
+            return j + 1
+        }
+    }
+    return len(content)
+}
+
+// verbatimEnd returns the end of the verbatim string whose quote is at i
+func verbatimEnd(content string, i int) int {
+    quote := content[i]
+    for j := i + 1; j < len(content); j++ {
+        if content[j] != quote {
+            continue
+        }
+        if j+1 < len(content) && content[j+1] == quote {
+            j++
+            continue
+        }
+        return j + 1
+    }
+    return len(content)
+}
+
+// closingEnd returns the end of closing after from, or the end of content
+func closingEnd(content string, from int, closing string) int {
+    if idx := strings.Index(content[from:], closing); idx >= 0 {
+        return from + idx + len(closing)
+    }
+    return len(content)
+}
+
+// lineEnd returns the index of the newline ending the line of i
+func lineEnd(content string, i int) int {
+    if idx := strings.IndexByte(content[i:], '\n'); idx >= 0 {
+        return i + idx
+    }
+    return len(content)
+}
+
+// regexAllowed reports whether a / after before opens a YARA regular
+// expression: it must follow = or the matches operator
+func regexAllowed(before string) bool {
+    before = strings.TrimRight(before, " \t")
+    return strings.HasSuffix(before, "=") || strings.HasSuffix(before, "matches")
+}
@@ -0,0 +1,111 @@
+package normalize
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+    "strings"
+
+    "gopkg.in/yaml.v3" // v3.0.1
+)
+
+// Indentation of the YAML formats
+const (
+    sigmaIndent    = 4
+    sentinelIndent = 2
+)
+
+// yamlIndent returns a rule re-encoding every document of YAML content with
+// indent spaces per level. Comments, key order and scalar styles are kept.
+func yamlIndent(indent int) func(string) (string, error) {
+    return func(content string) (string, error) {
+        docs, err := decodeYAML(content)
+        if err != nil {
+            return "", err
+        }
+        return encodeYAML(docs, indent)
+    }
+}
+
+// sentinelQuery returns a rule applying rule to the KQL query of a
+// Sentinel rule. A rewritten query is written as a literal block.
+func sentinelQuery(rule func(string) (string, error)) func(string) (string, error) {
+    return func(content string) (string, error) {
+        docs, err := decodeYAML(content)
+        if err != nil {
+            return "", err
+        }
+        changed := false
+        for _, doc := range docs {
+            query := mappingValue(doc, "query")
+            if query == nil || query.Kind != yaml.ScalarNode {
+                continue
+            }
+            rewritten, err := rule(query.Value)
+            if err != nil {
+                return "", err
+            }
+            if rewritten != query.Value {
+                // Literal blocks cannot hold trailing whitespace
+                query.Value, _ = trimTrailingWhitespace(rewritten)
+                query.Style = yaml.LiteralStyle
+                changed = true
+            }
+        }
+        if !changed {
+            return content, nil
+        }
+        return encodeYAML(docs, sentinelIndent)
+    }
+}
+
+// decodeYAML decodes every document of content
+func decodeYAML(content string) ([]*yaml.Node, error) {
+    var docs []*yaml.Node
+    decoder := yaml.NewDecoder(strings.NewReader(content))
+    for {
+        doc := &yaml.Node{}
+        err := decoder.Decode(doc)
+        if errors.Is(err, io.EOF) {
+            break
+        }
+        if err != nil {
+            return nil, fmt.Errorf("%w: %v", ErrInvalidContent, err)
+        }
+        docs = append(docs, doc)
+    }
+    return docs, nil
+}
+
+// encodeYAML encodes docs as a YAML stream with indent spaces per level
+func encodeYAML(docs []*yaml.Node, indent int) (string, error) {
+    var buf bytes.Buffer
+    encoder := yaml.NewEncoder(&buf)
+    encoder.SetIndent(indent)
+    for _, doc := range docs {
+        if err := encoder.Encode(doc); err != nil {
+            return "", fmt.Errorf("%w: %v", ErrInvalidContent, err)
+        }
+    }
+    if err := encoder.Close(); err != nil {
+        return "", fmt.Errorf("%w: %v", ErrInvalidContent, err)
+    }
+    return buf.String(), nil
+}
+
+// mappingValue returns the value of key in the top-level mapping of doc
+func mappingValue(doc *yaml.Node, key string) *yaml.Node {
+    if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+        doc = doc.Content[0]
+    }
+    if doc.Kind != yaml.MappingNode {
+        return nil
+    }
+    for i := 0; i+1 < len(doc.Content); i += 2 {
+        if doc.Content[i].Value == key {
+            return doc.Content[i+1]
+        }
+    }
+    return nil
+}
@@ -0,0 +1,174 @@
+package normalize
+
+import (
+    "bytes"
+    "strings"
+)
+
+// yaraSections are the section labels of YARA and YARA-L rules
+var yaraSections = []string{"meta", "strings", "condition", "events", "match", "outcome", "options"}
+
+// yaraBraces puts the opening brace of each rule at the end of its header
+// and the closing brace on a line of its own, and gives every section label
+// a line of its own
+func yaraBraces(content string) (string, error) {
+    code := yaraSyntax.code(content)
+    out := make([]byte, 0, len(content))
+    depth := 0
+    for i := 0; i < len(content); i++ {
+        c := content[i]
+        if !code[i] {
+            out = append(out, c)
+            continue
+        }
+
+        switch {
+        case c == '{' && depth == 0:
+            out = append(bytes.TrimRight(out, " \t\n"), " {\n"...)
+            i = skipSpace(content, code, i, " \t\n")
+            depth++
+        case c == '}' && depth == 1:
+            out = append(bytes.TrimRight(out, " \t\n"), "\n}"...)
+            i = skipSpace(content, code, i, " \t")
+            if i+1 < len(content) && content[i+1] != '\n' {
+                out = append(out, '\n')
+            }
+            depth--
+        case depth == 1 && sectionLabelAt(content, i) > 0:
+            label := sectionLabelAt(content, i)
+            out = bytes.TrimRight(out, " \t")
+            if len(out) > 0 && out[len(out)-1] != '\n' {
+                out = append(out, '\n')
+            }
+            out = append(out, strings.TrimRight(content[i:i+label-1], " \t")+":"...)
+            i = skipSpace(content, code, i+label-1, " \t")
+            if i+1 < len(content) && content[i+1] != '\n' {
+                out = append(out, '\n')
+            }
+        default:
+            switch c {
+            case '{':
+                depth++
+            case '}':
+                if depth > 0 {
+                    depth--
+                }
+            }
+            out = append(out, c)
+        }
+    }
+    return string(out), nil
+}
+
+// yaraIndent indents rule sections by four spaces and their content by
+// four more, with four further spaces per nested brace or parenthesis.
+// Lines starting inside a comment or string are left as they are.
+func yaraIndent(content string) (string, error) {
+    code := yaraSyntax.code(content)
+    lines := strings.Split(content, "\n")
+    braces, parens := 0, 0
+    inSection := false
+    start := 0
+    for n, line := range lines {
+        lineStart := start
+        start += len(line) + 1
+        if lineStart > 0 && !code[lineStart-1] {
+            braces, parens = countNesting(content, code, lineStart, lineStart+len(line), braces, parens)
+            continue
+        }
+
+        text := strings.TrimLeft(line, " \t")
+        if text == "" {
+            lines[n] = ""
+            continue
+        }
+        offset := lineStart + len(line) - len(text)
+        lineBraces, lineParens := braces, parens
+        if code[offset] && text[0] == '}' && lineBraces > 0 {
+            lineBraces--
+        }
+        if code[offset] && text[0] == ')' && lineParens > 0 {
+            lineParens--
+        }
+        if lineBraces == 0 {
+            inSection = false
+        }
+
+        indent := 0
+        switch {
+        case lineBraces == 1 && code[offset] && sectionLabelAt(content, offset) > 0:
+            indent = 4
+            inSection = true
+        case lineBraces > 0:
+            indent = 4*lineBraces + 4*lineParens
+            if inSection {
+                indent += 4
+            }
+        }
+        lines[n] = strings.Repeat(" ", indent) + text
+        braces, parens = countNesting(content, code, offset, lineStart+len(line), braces, parens)
+    }
+    return strings.Join(lines, "\n"), nil
+}
+
+// countNesting updates the brace and parenthesis depths with the code
+// between from and to
+func countNesting(content string, code []bool, from, to, braces, parens int) (int, int) {
+    for i := from; i < to; i++ {
+        if !code[i] {
+            continue
+        }
+        switch content[i] {
+        case '{':
+            braces++
+        case '}':
+            if braces > 0 {
+                braces--
+            }
+        case '(':
+            parens++
+        case ')':
+            if parens > 0 {
+                parens--
+            }
+        }
+    }
+    return braces, parens
+}
+
+// sectionLabelAt returns the length of the section label, up to and
+// including its colon, starting at i, or 0 when none starts there
+func sectionLabelAt(content string, i int) int {
+    if i > 0 && isIdentByte(content[i-1]) {
+        return 0
+    }
+    for _, section := range yaraSections {
+        if !strings.HasPrefix(content[i:], section) {
+            continue
+        }
+        j := i + len(section)
+        for j < len(content) && (content[j] == ' ' || content[j] == '\t') {
+            j++
+        }
+        if j < len(content) && content[j] == ':' {
+            return j + 1 - i
+        }
+    }
+    return 0
+}
+
+// isIdentByte reports whether c can precede a section label without
+// starting it, as in identifiers, fields and string references
+func isIdentByte(c byte) bool {
+    return c == '_' || c == '.' || c == '$' || c == '#' || c == '@' || c == '!' ||
+        (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// skipSpace returns the index of the last of the code bytes in space
+// following i
+func skipSpace(content string, code []bool, i int, space string) int {
+    for i+1 < len(content) && code[i+1] && strings.IndexByte(space, content[i+1]) >= 0 {
+        i++
+    }
+    return i
+}
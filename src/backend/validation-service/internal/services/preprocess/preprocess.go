@@ -0,0 +1,201 @@
+// Package preprocess unwraps vendor export packaging, such as Splunk
+// savedsearches.conf stanzas, Sentinel ARM templates and QRadar XML exports,
+// into the clean rule content the validators expect. Pre-processors are
+// registered per source system and either selected by name or detected from
+// the content.
+package preprocess
+
+import (
+    "errors"
+    "fmt"
+    "sort"
+    "sync"
+
+    "validation-service/internal/models"
+)
+
+// Pre-processor selection modes besides a pre-processor name
+const (
+    // ModeAuto unwraps content any pre-processor of its format detects
+    ModeAuto = "auto"
+    // ModeNone leaves content as submitted
+    ModeNone = "none"
+)
+
+var (
+    // ErrDuplicatePreprocessor is returned when two pre-processors share a name
+    ErrDuplicatePreprocessor = errors.New("pre-processor already registered")
+    // ErrUnknownPreprocessor is returned for a mode naming no pre-processor
+    ErrUnknownPreprocessor = errors.New("unknown pre-processor")
+    // ErrInvalidExport is returned when an export cannot be unwrapped into a
+    // single rule
+    ErrInvalidExport = errors.New("invalid vendor export")
+)
+
+// Preprocessor unwraps the exports of one source system
+type Preprocessor interface {
+    // Name identifies the pre-processor in request options
+    Name() string
+    // Format is the detection format of the unwrapped content
+    Format() string
+    // Detect reports whether content is packaged as the export
+    Detect(content string) bool
+    // Unwrap returns the rule content of the export
+    Unwrap(content string) (string, error)
+}
+
+// funcPreprocessor adapts detection and unwrap functions to Preprocessor
+type funcPreprocessor struct {
+    name   string
+    format string
+    detect func(string) bool
+    unwrap func(string) (string, error)
+}
+
+// New creates a Preprocessor from its name, format and functions
+func New(name, format string, detect func(content string) bool, unwrap func(content string) (string, error)) Preprocessor {
+    return &funcPreprocessor{name: name, format: format, detect: detect, unwrap: unwrap}
+}
+
+// Name returns the pre-processor name
+func (p *funcPreprocessor) Name() string {
+    return p.name
+}
+
+// Format returns the format of the unwrapped content
+func (p *funcPreprocessor) Format() string {
+    return p.format
+}
+
+// Detect runs the detection function
+func (p *funcPreprocessor) Detect(content string) bool {
+    return p.detect(content)
+}
+
+// Unwrap runs the unwrap function
+func (p *funcPreprocessor) Unwrap(content string) (string, error) {
+    return p.unwrap(content)
+}
+
+// Registry holds pre-processors by name. Registration order is preserved so
+// auto-detection tries them deterministically.
+type Registry struct {
+    mu            sync.RWMutex
+    preprocessors map[string]Preprocessor
+    order         []string
+}
+
+// NewRegistry creates an empty pre-processor registry
+func NewRegistry() *Registry {
+    return &Registry{
+        preprocessors: make(map[string]Preprocessor),
+    }
+}
+
+// BuiltinRegistry returns a registry of the pre-processors shipped with the
+// service
+func BuiltinRegistry() *Registry {
+    registry := NewRegistry()
+    for _, p := range []Preprocessor{
+        New(SplunkSavedSearches, models.DetectionFormatSplunk, detectSavedSearches, unwrapSavedSearches),
+        New(SentinelARM, models.DetectionFormatSentinel, detectARMTemplate, unwrapARMTemplate),
+        New(QRadarXML, models.DetectionFormatQRadar, detectQRadarXML, unwrapQRadarXML),
+    } {
+        if err := registry.Register(p); err != nil {
+            panic(fmt.Sprintf("registering built-in pre-processor %s: %v", p.Name(), err))
+        }
+    }
+    return registry
+}
+
+// Register adds a pre-processor
+func (r *Registry) Register(p Preprocessor) error {
+    if p == nil || p.Name() == "" {
+        return fmt.Errorf("pre-processor name cannot be empty")
+    }
+    if p.Name() == ModeAuto || p.Name() == ModeNone {
+        return fmt.Errorf("pre-processor name %q is reserved", p.Name())
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if _, exists := r.preprocessors[p.Name()]; exists {
+        return fmt.Errorf("%w: %s", ErrDuplicatePreprocessor, p.Name())
+    }
+    r.preprocessors[p.Name()] = p
+    r.order = append(r.order, p.Name())
+    return nil
+}
+
+// Names returns the registered pre-processor names in ascending order
+func (r *Registry) Names() []string {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    names := append([]string(nil), r.order...)
+    sort.Strings(names)
+    return names
+}
+
+// CheckMode returns ErrUnknownPreprocessor unless mode is ModeAuto, ModeNone
+// or the name of a registered pre-processor
+func (r *Registry) CheckMode(mode string) error {
+    if mode == ModeAuto || mode == ModeNone {
+        return nil
+    }
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    if _, ok := r.preprocessors[mode]; !ok {
+        return fmt.Errorf("%w: %s", ErrUnknownPreprocessor, mode)
+    }
+    return nil
+}
+
+// Apply returns detection with its export packaging unwrapped and the name
+// of the pre-processor that unwrapped it. A named pre-processor unwraps
+// every detection of its format and leaves others as they are; ModeAuto
+// unwraps with the first pre-processor of the format detecting its export.
+// The detection is returned unchanged, with an empty name, when no
+// pre-processor applies.
+func (r *Registry) Apply(detection *models.Detection, mode string) (*models.Detection, string, error) {
+    if err := r.CheckMode(mode); err != nil {
+        return nil, "", err
+    }
+    p := r.selectPreprocessor(detection, mode)
+    if p == nil {
+        return detection, "", nil
+    }
+
+    content, err := p.Unwrap(detection.Content)
+    if err != nil {
+        return nil, "", fmt.Errorf("%s: %w", p.Name(), err)
+    }
+    unwrapped := *detection
+    unwrapped.Content = content
+    return &unwrapped, p.Name(), nil
+}
+
+// selectPreprocessor returns the pre-processor mode selects for detection,
+// or nil
+func (r *Registry) selectPreprocessor(detection *models.Detection, mode string) Preprocessor {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    switch mode {
+    case ModeNone:
+        return nil
+    case ModeAuto:
+        for _, name := range r.order {
+            p := r.preprocessors[name]
+            if p.Format() == detection.Format && p.Detect(detection.Content) {
+                return p
+            }
+        }
+        return nil
+    }
+    if p := r.preprocessors[mode]; p.Format() == detection.Format {
+        return p
+    }
+    return nil
+}
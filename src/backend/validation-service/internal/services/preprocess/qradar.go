@@ -0,0 +1,62 @@
+package preprocess
+
+import (
+    "encoding/xml"
+    "errors"
+    "fmt"
+    "io"
+    "regexp"
+    "strings"
+)
+
+// QRadarXML unwraps the AQL query of a QRadar XML export
+const QRadarXML = "qradar_xml"
+
+// aqlQuery matches the text of an element holding an AQL query
+var aqlQuery = regexp.MustCompile(`(?is)^select\s.+\sfrom\s`)
+
+// detectQRadarXML reports whether content is XML; AQL never starts with an
+// angle bracket
+func detectQRadarXML(content string) bool {
+    return strings.HasPrefix(strings.TrimSpace(content), "<")
+}
+
+// unwrapQRadarXML returns the AQL query of a QRadar XML export: the text of
+// the only element holding a SELECT statement. Entities and CDATA sections
+// are decoded.
+func unwrapQRadarXML(content string) (string, error) {
+    var queries []string
+    var text strings.Builder
+    decoder := xml.NewDecoder(strings.NewReader(content))
+    for {
+        token, err := decoder.Token()
+        if errors.Is(err, io.EOF) {
+            break
+        }
+        if err != nil {
+            return "", fmt.Errorf("%w: QRadar export is not valid XML: %v", ErrInvalidExport, err)
+        }
+
+        switch t := token.(type) {
+        case xml.StartElement:
+            text.Reset()
+        case xml.CharData:
+            text.Write(t)
+        case xml.EndElement:
+            query := strings.TrimSpace(text.String())
+            if aqlQuery.MatchString(query) {
+                queries = append(queries, query)
+            }
+            text.Reset()
+        }
+    }
+
+    switch len(queries) {
+    case 0:
+        return "", fmt.Errorf("%w: QRadar export holds no AQL query", ErrInvalidExport)
+    case 1:
+        return queries[0], nil
+    }
+    return "", fmt.Errorf("%w: QRadar export holds %d AQL queries; submit them one at a time",
+        ErrInvalidExport, len(queries))
+}
@@ -0,0 +1,144 @@
+package preprocess
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "strings"
+
+    "gopkg.in/yaml.v3" // v3.0.1
+)
+
+// SentinelARM unwraps the analytics rule of an ARM template or resource
+const SentinelARM = "sentinel_arm"
+
+// armAlertRuleType ends the resource type of Sentinel analytics rules, both
+// Microsoft.SecurityInsights/alertRules and the workspace provider form
+const armAlertRuleType = "/alertrules"
+
+// armResource is an ARM resource, or a template whose resources it lists
+type armResource struct {
+    Type       string        `json:"type"`
+    Name       string        `json:"name"`
+    Kind       string        `json:"kind"`
+    Properties *armAlertRule `json:"properties"`
+    Resources  []armResource `json:"resources"`
+}
+
+// armAlertRule holds the properties of an analytics rule resource
+type armAlertRule struct {
+    DisplayName      string              `json:"displayName"`
+    Description      string              `json:"description"`
+    Severity         string              `json:"severity"`
+    Enabled          *bool               `json:"enabled"`
+    Query            string              `json:"query"`
+    QueryFrequency   string              `json:"queryFrequency"`
+    QueryPeriod      string              `json:"queryPeriod"`
+    TriggerOperator  string              `json:"triggerOperator"`
+    TriggerThreshold *int                `json:"triggerThreshold"`
+    Tactics          []string            `json:"tactics"`
+    Techniques       []string            `json:"techniques"`
+    EntityMappings   []sentinelEntityMap `json:"entityMappings"`
+}
+
+// sentinelRule is a Sentinel analytics rule YAML document
+type sentinelRule struct {
+    ID                 string              `yaml:"id,omitempty"`
+    Name               string              `yaml:"name,omitempty"`
+    Description        string              `yaml:"description,omitempty"`
+    Kind               string              `yaml:"kind,omitempty"`
+    Severity           string              `yaml:"severity,omitempty"`
+    Enabled            *bool               `yaml:"enabled,omitempty"`
+    QueryFrequency     string              `yaml:"queryFrequency,omitempty"`
+    QueryPeriod        string              `yaml:"queryPeriod,omitempty"`
+    TriggerOperator    string              `yaml:"triggerOperator,omitempty"`
+    TriggerThreshold   *int                `yaml:"triggerThreshold,omitempty"`
+    Tactics            []string            `yaml:"tactics,omitempty"`
+    RelevantTechniques []string            `yaml:"relevantTechniques,omitempty"`
+    Query              string              `yaml:"query"`
+    EntityMappings     []sentinelEntityMap `yaml:"entityMappings,omitempty"`
+}
+
+// sentinelEntityMap is an entity mapping, spelled alike in ARM and YAML
+type sentinelEntityMap struct {
+    EntityType    string `json:"entityType" yaml:"entityType"`
+    FieldMappings []struct {
+        Identifier string `json:"identifier" yaml:"identifier"`
+        ColumnName string `json:"columnName" yaml:"columnName"`
+    } `json:"fieldMappings" yaml:"fieldMappings"`
+}
+
+// detectARMTemplate reports whether content is a JSON ARM template or
+// resource declaring an analytics rule
+func detectARMTemplate(content string) bool {
+    trimmed := strings.TrimSpace(content)
+    return strings.HasPrefix(trimmed, "{") &&
+        strings.Contains(strings.ToLower(trimmed), `alertrules"`)
+}
+
+// unwrapARMTemplate returns the only analytics rule of an ARM template or
+// resource as Sentinel rule YAML. ISO 8601 durations and ARM trigger
+// operators are kept; the Sentinel validator accepts both spellings.
+func unwrapARMTemplate(content string) (string, error) {
+    var root armResource
+    if err := json.Unmarshal([]byte(content), &root); err != nil {
+        return "", fmt.Errorf("%w: ARM template is not valid JSON: %v", ErrInvalidExport, err)
+    }
+
+    var rules []armResource
+    for _, resource := range append([]armResource{root}, root.Resources...) {
+        if strings.HasSuffix(strings.ToLower(resource.Type), armAlertRuleType) && resource.Properties != nil {
+            rules = append(rules, resource)
+        }
+    }
+    switch len(rules) {
+    case 0:
+        return "", fmt.Errorf("%w: ARM template declares no analytics rule", ErrInvalidExport)
+    case 1:
+    default:
+        return "", fmt.Errorf("%w: ARM template declares %d analytics rules; submit them one at a time",
+            ErrInvalidExport, len(rules))
+    }
+
+    resource := rules[0]
+    props := resource.Properties
+    if strings.TrimSpace(props.Query) == "" {
+        return "", fmt.Errorf("%w: analytics rule %q has no query", ErrInvalidExport, props.DisplayName)
+    }
+    rule := sentinelRule{
+        ID:                 armRuleID(resource.Name),
+        Name:               props.DisplayName,
+        Description:        props.Description,
+        Kind:               resource.Kind,
+        Severity:           props.Severity,
+        Enabled:            props.Enabled,
+        QueryFrequency:     props.QueryFrequency,
+        QueryPeriod:        props.QueryPeriod,
+        TriggerOperator:    props.TriggerOperator,
+        TriggerThreshold:   props.TriggerThreshold,
+        Tactics:            props.Tactics,
+        RelevantTechniques: props.Techniques,
+        Query:              props.Query,
+        EntityMappings:     props.EntityMappings,
+    }
+
+    var buf bytes.Buffer
+    encoder := yaml.NewEncoder(&buf)
+    encoder.SetIndent(2)
+    if err := encoder.Encode(&rule); err != nil {
+        return "", fmt.Errorf("%w: %v", ErrInvalidExport, err)
+    }
+    if err := encoder.Close(); err != nil {
+        return "", fmt.Errorf("%w: %v", ErrInvalidExport, err)
+    }
+    return buf.String(), nil
+}
+
+// armRuleID returns the rule ID ending a resource name, which is empty when
+// the name is a template expression
+func armRuleID(name string) string {
+    if strings.HasPrefix(name, "[") {
+        return ""
+    }
+    return name[strings.LastIndex(name, "/")+1:]
+}
@@ -0,0 +1,73 @@
+package preprocess
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+)
+
+// SplunkSavedSearches unwraps the search of a savedsearches.conf stanza
+const SplunkSavedSearches = "splunk_savedsearches"
+
+var (
+    // savedSearchStanza matches a stanza header line
+    savedSearchStanza = regexp.MustCompile(`(?m)^[ \t]*\[[^\]\r\n]+\][ \t]*\r?$`)
+    // savedSearchKey matches the search setting of a stanza
+    savedSearchKey = regexp.MustCompile(`(?m)^[ \t]*search[ \t]*=`)
+)
+
+// detectSavedSearches reports whether content is a savedsearches.conf
+// export: a stanza header and a search setting
+func detectSavedSearches(content string) bool {
+    return savedSearchStanza.MatchString(content) && savedSearchKey.MatchString(content)
+}
+
+// unwrapSavedSearches returns the search of the only stanza of a
+// savedsearches.conf export that has one. Values continued on the next line
+// with a trailing backslash keep their line breaks.
+func unwrapSavedSearches(content string) (string, error) {
+    type stanza struct {
+        name   string
+        search string
+    }
+    var searches []stanza
+    current := ""
+
+    lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+    for i := 0; i < len(lines); i++ {
+        line := strings.TrimSpace(lines[i])
+        // A trailing backslash continues the value on the next line
+        for strings.HasSuffix(line, `\`) && i+1 < len(lines) {
+            i++
+            line = strings.TrimRight(strings.TrimSuffix(line, `\`), " \t") + "\n" + strings.TrimRight(lines[i], " \t")
+        }
+
+        switch {
+        case line == "" || strings.HasPrefix(line, "#"):
+            continue
+        case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+            current = strings.TrimSpace(line[1 : len(line)-1])
+            continue
+        }
+        key, value, ok := strings.Cut(line, "=")
+        if ok && strings.TrimSpace(key) == "search" {
+            searches = append(searches, stanza{name: current, search: strings.TrimSpace(value)})
+        }
+    }
+
+    switch len(searches) {
+    case 0:
+        return "", fmt.Errorf("%w: no stanza has a search setting", ErrInvalidExport)
+    case 1:
+        if searches[0].search == "" {
+            return "", fmt.Errorf("%w: stanza [%s] has an empty search", ErrInvalidExport, searches[0].name)
+        }
+        return searches[0].search, nil
+    }
+    names := make([]string, len(searches))
+    for i, s := range searches {
+        names[i] = "[" + s.name + "]"
+    }
+    return "", fmt.Errorf("%w: %d stanzas have a search (%s); submit them one at a time",
+        ErrInvalidExport, len(searches), strings.Join(names, ", "))
+}
@@ -0,0 +1,195 @@
+package quickfix
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+
+    "validation-service/internal/models"
+)
+
+var (
+    // sigmaTitleLine matches the top-level title line of a Sigma rule
+    sigmaTitleLine = regexp.MustCompile(`(?m)^title\s*:.*$`)
+    // yaralRuleHeader matches the opening of a YARA-L rule
+    yaralRuleHeader = regexp.MustCompile(`rule\s+[\w_]+\s*\{`)
+    // yaralMetaSection matches the label line of a YARA-L 2.0 meta section
+    yaralMetaSection = regexp.MustCompile(`(?m)^[ \t]*meta[ \t]*:[ \t]*$`)
+    // yaralNextSection matches the label of a section following meta
+    yaralNextSection = regexp.MustCompile(`(?m)^[ \t]*(?:events|match|outcome|condition|options)[ \t]*:`)
+)
+
+// sigmaPlaceholders are inserted for missing required Sigma fields that can
+// be filled without changing detection logic
+var sigmaPlaceholders = map[string]string{
+    "title":       "Untitled detection",
+    "description": "TODO describe what this rule detects",
+}
+
+// yaralMetaPlaceholders are inserted for missing required YARA-L meta fields,
+// in the order they are added to a new meta section
+var yaralMetaPlaceholders = []struct {
+    field string
+    value string
+}{
+    {"author", "unknown"},
+    {"description", "TODO"},
+    {"severity", "medium"},
+    {"reference", "TODO"},
+}
+
+// delimiterPairs maps opening delimiters to their closing counterpart
+var delimiterPairs = map[rune]rune{'(': ')', '[': ']', '{': '}'}
+
+// Normalize strips a byte order mark, converts line endings to LF, removes
+// trailing whitespace and surrounding blank lines, and for YAML based formats
+// replaces indentation tabs with two spaces and ends the content with a
+// newline
+func Normalize(format, content string) string {
+    content = strings.TrimPrefix(content, "\ufeff")
+    content = strings.ReplaceAll(content, "\r\n", "\n")
+    content = strings.ReplaceAll(content, "\r", "\n")
+
+    yaml := format == models.DetectionFormatSigma || format == models.DetectionFormatSentinel
+    lines := strings.Split(content, "\n")
+    for i, line := range lines {
+        line = strings.TrimRight(line, " \t")
+        if yaml {
+            indent := len(line) - len(strings.TrimLeft(line, " \t"))
+            line = strings.ReplaceAll(line[:indent], "\t", "  ") + line[indent:]
+        }
+        lines[i] = line
+    }
+
+    content = strings.Trim(strings.Join(lines, "\n"), "\n")
+    if yaml && content != "" {
+        content += "\n"
+    }
+    return content
+}
+
+// fixDelimiters appends the closing delimiters missing at the end of
+// content. Content with mismatched or surplus closing delimiters, or an
+// unterminated string, is not fixable.
+func fixDelimiters(content string, _ models.ValidationIssue) (string, bool) {
+    var stack []rune
+    var quote rune
+    escaped := false
+
+    for _, char := range content {
+        if quote != 0 {
+            switch {
+            case escaped:
+                escaped = false
+            case char == '\\':
+                escaped = true
+            case char == quote:
+                quote = 0
+            }
+            continue
+        }
+
+        switch char {
+        case '"', '\'':
+            quote = char
+        case '(', '[', '{':
+            stack = append(stack, char)
+        case ')', ']', '}':
+            if len(stack) == 0 || delimiterPairs[stack[len(stack)-1]] != char {
+                return content, false
+            }
+            stack = stack[:len(stack)-1]
+        }
+    }
+    if quote != 0 || len(stack) == 0 {
+        return content, false
+    }
+
+    var b strings.Builder
+    b.WriteString(strings.TrimRight(content, " \t\n"))
+    for i := len(stack) - 1; i >= 0; i-- {
+        // Blocks close on their own line; parentheses and brackets inline
+        if stack[i] == '{' {
+            b.WriteByte('\n')
+        }
+        b.WriteRune(delimiterPairs[stack[i]])
+    }
+    return b.String(), true
+}
+
+// fixSigmaRequiredField inserts a placeholder for a missing title or
+// description. The issue location is the missing field.
+func fixSigmaRequiredField(content string, issue models.ValidationIssue) (string, bool) {
+    placeholder, ok := sigmaPlaceholders[issue.Location]
+    if !ok || hasTopLevelKey(content, issue.Location) {
+        return content, false
+    }
+
+    line := fmt.Sprintf("%s: %s\n", issue.Location, placeholder)
+    if issue.Location == "description" {
+        // Sigma rules conventionally list the description after the title
+        if loc := sigmaTitleLine.FindStringIndex(content); loc != nil {
+            end := loc[1]
+            if end < len(content) {
+                end++ // keep the title's newline
+            } else {
+                line = "\n" + line
+            }
+            return content[:end] + line + content[end:], true
+        }
+    }
+    return line + content, true
+}
+
+// hasTopLevelKey reports whether YAML content defines key at the top level
+func hasTopLevelKey(content, key string) bool {
+    return regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(key) + `\s*:`).MatchString(content)
+}
+
+// fixYaraLMetaSection adds a meta section with every required field to a
+// YARA-L rule that has none
+func fixYaraLMetaSection(content string, _ models.ValidationIssue) (string, bool) {
+    if yaralMetaSection.MatchString(content) {
+        return content, false
+    }
+    loc := yaralRuleHeader.FindStringIndex(content)
+    if loc == nil {
+        return content, false
+    }
+
+    var b strings.Builder
+    b.WriteString("\n  meta:")
+    for _, entry := range yaralMetaPlaceholders {
+        fmt.Fprintf(&b, "\n    %s = %q", entry.field, entry.value)
+    }
+    return content[:loc[1]] + b.String() + content[loc[1]:], true
+}
+
+// fixYaraLMetaField adds a placeholder for a missing required meta field. The
+// issue location is "meta.<field>".
+func fixYaraLMetaField(content string, issue models.ValidationIssue) (string, bool) {
+    field := strings.TrimPrefix(issue.Location, "meta.")
+    value := ""
+    for _, entry := range yaralMetaPlaceholders {
+        if entry.field == field {
+            value = entry.value
+        }
+    }
+    if value == "" {
+        return content, false
+    }
+
+    loc := yaralMetaSection.FindStringIndex(content)
+    if loc == nil {
+        return content, false
+    }
+    body := content[loc[1]:]
+    if next := yaralNextSection.FindStringIndex(body); next != nil {
+        body = body[:next[0]]
+    }
+    if regexp.MustCompile(`(?m)^\s*` + regexp.QuoteMeta(field) + `\s*=`).MatchString(body) {
+        return content, false
+    }
+    insert := fmt.Sprintf("\n    %s = %q", field, value)
+    return content[:loc[1]] + insert + content[loc[1]:], true
+}
@@ -0,0 +1,120 @@
+// Package quickfix applies deterministic corrections for validation issues,
+// such as inserting missing metadata fields, closing unbalanced delimiters and
+// normalizing whitespace, and reports which issues can be fixed.
+package quickfix
+
+import (
+    "errors"
+
+    "validation-service/internal/models"
+)
+
+// IssueCodeNormalize identifies the whitespace normalization applied to every
+// fixed detection
+const IssueCodeNormalize = "NORMALIZE"
+
+// ErrNothingToFix is returned when none of the issues can be fixed and the
+// content is already normalized
+var ErrNothingToFix = errors.New("no fixable issues")
+
+// Fix describes a correction applied to a detection
+type Fix struct {
+    IssueCode   string `json:"issue_code"`
+    Location    string `json:"location,omitempty"`
+    Description string `json:"description"`
+}
+
+// fixer corrects content for an issue. It returns false when it cannot fix
+// the issue for this content, and must return the content unchanged then.
+type fixer struct {
+    description string
+    apply       func(content string, issue models.ValidationIssue) (string, bool)
+}
+
+// fixers are the registered fixers by format and issue code
+var fixers = map[string]map[string]fixer{
+    models.DetectionFormatSigma: {
+        "SIGMA003": {"Added a placeholder for the missing required field", fixSigmaRequiredField},
+    },
+    models.DetectionFormatYaraL: {
+        "YARAL001": {"Closed unbalanced delimiters", fixDelimiters},
+        "YARAL002": {"Added a meta section with placeholder values", fixYaraLMetaSection},
+        "YARAL003": {"Added a placeholder for the missing meta field", fixYaraLMetaField},
+    },
+    models.DetectionFormatKQL: {
+        "KQL001": {"Closed unbalanced delimiters", fixDelimiters},
+    },
+    models.DetectionFormatSplunk: {
+        "SPL_SYNTAX": {"Closed unbalanced delimiters", fixDelimiters},
+    },
+    models.DetectionFormatQRadar: {
+        "QR001": {"Closed unbalanced delimiters", fixDelimiters},
+    },
+}
+
+// MarkFixable sets the Fixable flag of every issue a fixer can correct in
+// content. Issues are checked independently against the normalized content.
+func MarkFixable(format, content string, result *models.ValidationResult) {
+    formatFixers := fixers[format]
+    if len(formatFixers) == 0 || result == nil {
+        return
+    }
+
+    normalized := Normalize(format, content)
+    for i := range result.Issues {
+        f, ok := formatFixers[result.Issues[i].IssueCode]
+        if !ok {
+            continue
+        }
+        if _, fixed := f.apply(normalized, result.Issues[i]); fixed {
+            result.Issues[i].Fixable = true
+        }
+    }
+}
+
+// Apply returns a copy of detection with every fixable issue corrected and
+// its content normalized, and the fixes applied. ErrNothingToFix is returned
+// when the content would not change.
+func Apply(detection *models.Detection, issues []models.ValidationIssue) (*models.Detection, []Fix, error) {
+    content, err := detection.GetContent()
+    if err != nil {
+        return nil, nil, err
+    }
+
+    applied := make([]Fix, 0)
+    fixed := Normalize(detection.Format, content)
+    if fixed != content {
+        applied = append(applied, Fix{
+            IssueCode:   IssueCodeNormalize,
+            Description: "Normalized line endings, indentation and trailing whitespace",
+        })
+    }
+
+    formatFixers := fixers[detection.Format]
+    for _, issue := range issues {
+        f, ok := formatFixers[issue.IssueCode]
+        if !ok {
+            continue
+        }
+        // Earlier fixes may already have corrected the issue, in which case
+        // the fixer reports nothing to do
+        next, ok := f.apply(fixed, issue)
+        if !ok || next == fixed {
+            continue
+        }
+        fixed = next
+        applied = append(applied, Fix{
+            IssueCode:   issue.IssueCode,
+            Location:    issue.Location,
+            Description: f.description,
+        })
+    }
+
+    if len(applied) == 0 {
+        return nil, nil, ErrNothingToFix
+    }
+
+    corrected := *detection
+    corrected.Content = fixed
+    return &corrected, applied, nil
+}
@@ -0,0 +1,97 @@
+// Package remediation decorates validation issues with tenant-specific
+// remediation guidance such as internal runbook links.
+package remediation
+
+import (
+    "net/url"
+    "strings"
+
+    "validation-service/internal/config"
+    "validation-service/internal/models"
+)
+
+// Wildcard issue code used as a tenant-wide fallback playbook
+const wildcardIssueCode = "*"
+
+// Issue metadata keys populated when a playbook is applied
+const (
+    MetadataPlaybookURL      = "playbook_url"
+    MetadataPlaybookGuidance = "playbook_guidance"
+)
+
+// ApplyPlaybooks appends tenant playbook links and guidance to the remediation
+// text of every issue with a matching playbook entry. The issue slice is copied
+// so results shared with other consumers are left untouched.
+func ApplyPlaybooks(result *models.ValidationResult, playbooks map[string]config.PlaybookEntry) {
+    if result == nil || len(playbooks) == 0 || len(result.Issues) == 0 {
+        return
+    }
+
+    issues := make([]models.ValidationIssue, len(result.Issues))
+    copy(issues, result.Issues)
+
+    for i := range issues {
+        entry, ok := lookupPlaybook(playbooks, issues[i].IssueCode)
+        if !ok {
+            continue
+        }
+        applyEntry(&issues[i], entry, result.TargetFormat)
+    }
+
+    result.Issues = issues
+}
+
+// PlaybooksForTenant returns the configured playbook entries for a tenant
+func PlaybooksForTenant(cfg config.RemediationConfig, tenantID string) map[string]config.PlaybookEntry {
+    if tenantID == "" {
+        return nil
+    }
+    return cfg.Playbooks[tenantID]
+}
+
+// lookupPlaybook resolves an issue code, falling back to the wildcard entry
+func lookupPlaybook(playbooks map[string]config.PlaybookEntry, issueCode string) (config.PlaybookEntry, bool) {
+    if entry, ok := playbooks[issueCode]; ok {
+        return entry, true
+    }
+    entry, ok := playbooks[wildcardIssueCode]
+    return entry, ok
+}
+
+// applyEntry renders the playbook entry into the issue remediation and metadata
+func applyEntry(issue *models.ValidationIssue, entry config.PlaybookEntry, format string) {
+    metadata := make(map[string]interface{}, len(issue.IssueMetadata)+2)
+    for k, v := range issue.IssueMetadata {
+        metadata[k] = v
+    }
+
+    parts := make([]string, 0, 3)
+    if issue.Remediation != "" {
+        parts = append(parts, strings.TrimSuffix(issue.Remediation, "."))
+    }
+
+    if entry.URL != "" {
+        link := renderURL(entry.URL, issue, format)
+        parts = append(parts, "Runbook: "+link)
+        metadata[MetadataPlaybookURL] = link
+    }
+    if entry.Guidance != "" {
+        parts = append(parts, strings.TrimSuffix(entry.Guidance, "."))
+        metadata[MetadataPlaybookGuidance] = entry.Guidance
+    }
+
+    issue.Remediation = strings.Join(parts, ". ") + "."
+    issue.IssueMetadata = metadata
+}
+
+// renderURL expands supported placeholders in a playbook URL template,
+// escaping substituted values so they are safe inside a URL path or query
+func renderURL(template string, issue *models.ValidationIssue, format string) string {
+    replacer := strings.NewReplacer(
+        "{issue_code}", url.QueryEscape(issue.IssueCode),
+        "{location}", url.QueryEscape(issue.Location),
+        "{severity}", url.QueryEscape(issue.Severity),
+        "{format}", url.QueryEscape(format),
+    )
+    return replacer.Replace(template)
+}
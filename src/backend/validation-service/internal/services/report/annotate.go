@@ -0,0 +1,120 @@
+package report
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+
+    "validation-service/internal/models"
+)
+
+// AnnotatedContentType is the media type of annotated rule content
+const AnnotatedContentType = "text/plain; charset=utf-8"
+
+// annotationMarker starts every inserted comment, so annotations can be
+// found and removed once the issues are fixed
+const annotationMarker = "VALIDATION"
+
+// commentSyntax is the line comment syntax of a detection format
+type commentSyntax struct {
+    open  string
+    close string
+}
+
+// commentSyntaxes are the comment syntaxes by format; formats not listed use
+// `#` comments
+var commentSyntaxes = map[string]commentSyntax{
+    models.DetectionFormatSplunk:      {"```", "```"},
+    models.DetectionFormatQRadar:      {"/*", "*/"},
+    models.DetectionFormatKQL:         {"//", ""},
+    models.DetectionFormatCrowdstrike: {"//", ""},
+    models.DetectionFormatYara:        {"//", ""},
+    models.DetectionFormatYaraL:       {"//", ""},
+}
+
+// annotation is an issue or lint finding to insert into the content
+type annotation struct {
+    line   int
+    column int
+    text   string
+}
+
+// Annotate returns the content of detection with a comment above every line
+// a validation issue or lint finding of result points at. Findings without a
+// line, and a summary of the result, are listed in comments at the top.
+func Annotate(detection *models.Detection, result *models.ValidationResult) string {
+    syntax, ok := commentSyntaxes[strings.ToLower(detection.Format)]
+    if !ok {
+        syntax = commentSyntax{open: "#"}
+    }
+
+    var annotations []annotation
+    for _, issue := range result.Issues {
+        text := fmt.Sprintf("[%s] %s", issue.Severity, issue.IssueCode)
+        if issue.IsOptimization() {
+            text = fmt.Sprintf("[hint] %s", issue.IssueCode)
+        }
+        text += ": " + issue.Message
+        if issue.Remediation != "" {
+            text += " Fix: " + issue.Remediation
+        }
+        annotations = append(annotations, annotation{line: issue.Line, column: issue.Column, text: text})
+    }
+    for _, finding := range result.Lint {
+        annotations = append(annotations, annotation{
+            line:   finding.Line,
+            column: finding.Column,
+            text:   fmt.Sprintf("[lint %s] %s: %s", finding.Level, finding.Rule, finding.Message),
+        })
+    }
+    sort.SliceStable(annotations, func(i, j int) bool {
+        if annotations[i].line != annotations[j].line {
+            return annotations[i].line < annotations[j].line
+        }
+        return annotations[i].column < annotations[j].column
+    })
+
+    lines := strings.Split(strings.TrimRight(detection.Content, "\n"), "\n")
+    byLine := make(map[int][]annotation)
+    var header []string
+    header = append(header, syntax.comment("", fmt.Sprintf("%s status=%s confidence_score=%.2f issues=%d lint=%d",
+        annotationMarker, result.Status, result.ConfidenceScore, len(result.Issues), len(result.Lint))))
+    for _, a := range annotations {
+        if a.line < 1 || a.line > len(lines) {
+            header = append(header, syntax.comment("", annotationMarker+" "+a.text))
+            continue
+        }
+        byLine[a.line] = append(byLine[a.line], a)
+    }
+
+    var b strings.Builder
+    for _, line := range header {
+        b.WriteString(line)
+        b.WriteString("\n")
+    }
+    for i, line := range lines {
+        indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+        for _, a := range byLine[i+1] {
+            text := annotationMarker + " " + a.text
+            if a.column > 0 {
+                text = fmt.Sprintf("%s (line %d, column %d) %s", annotationMarker, a.line, a.column, a.text)
+            }
+            b.WriteString(syntax.comment(indent, text))
+            b.WriteString("\n")
+        }
+        b.WriteString(line)
+        b.WriteString("\n")
+    }
+    return b.String()
+}
+
+// comment formats text as a single-line comment. Line breaks and closing
+// delimiters in text are removed so the comment cannot end early.
+func (s commentSyntax) comment(indent, text string) string {
+    text = strings.Join(strings.Fields(text), " ")
+    if s.close != "" {
+        text = strings.ReplaceAll(text, s.close, "")
+        return indent + s.open + " " + text + " " + s.close
+    }
+    return indent + s.open + " " + text
+}
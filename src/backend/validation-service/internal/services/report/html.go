@@ -0,0 +1,101 @@
+package report
+
+import (
+    "bytes"
+    "fmt"
+    "html/template"
+    "sort"
+
+    "validation-service/internal/models"
+)
+
+// HTMLContentType is the media type of HTML reports
+const HTMLContentType = "text/html; charset=utf-8"
+
+// htmlReport renders a validation report for email clients, so styles are
+// inline and the layout is a single table
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body style="font-family: Arial, sans-serif; color: #222;">
+<h2 style="margin-bottom: 4px;">{{.Title}}</h2>
+<p style="margin-top: 0; color: #555;">Result {{.Result.ID}} &middot; {{.Result.CreatedAt.UTC.Format "2006-01-02 15:04 MST"}}</p>
+<p>
+<strong style="color: {{.StatusColor}};">{{.Result.Status}}</strong>
+&middot; confidence {{printf "%.1f" .Result.ConfidenceScore}}
+&middot; {{.Summary}}
+</p>
+{{if .Target}}<p><strong>Translation:</strong> {{.Target}}</p>{{end}}
+{{if .Source}}<p><strong>Source:</strong> {{.Source}}</p>{{end}}
+{{if .Issues}}
+<table style="border-collapse: collapse; width: 100%; font-size: 13px;">
+<tr style="background: #f0f0f0; text-align: left;">
+<th style="padding: 6px;">Severity</th><th style="padding: 6px;">Code</th><th style="padding: 6px;">Location</th><th style="padding: 6px;">Issue</th><th style="padding: 6px;">Remediation</th>
+</tr>
+{{range .Issues}}<tr style="border-top: 1px solid #ddd; vertical-align: top;">
+<td style="padding: 6px;">{{.Severity}}</td>
+<td style="padding: 6px;">{{.IssueCode}}</td>
+<td style="padding: 6px;">{{.Location}}{{if .Line}}:{{.Line}}{{end}}</td>
+<td style="padding: 6px;">{{.Message}}</td>
+<td style="padding: 6px;">{{.Remediation}}</td>
+</tr>
+{{end}}</table>
+{{else}}<p>No issues found.</p>{{end}}
+{{if .Recommendations}}
+<h3>Recommendations</h3>
+<ul>{{range .Recommendations}}<li>{{.}}</li>{{end}}</ul>
+{{end}}
+</body>
+</html>
+`))
+
+// statusColors colors the status of the rendered report
+var statusColors = map[string]string{
+    models.ValidationStatusSuccess: "#1a7f37",
+    models.ValidationStatusWarning: "#9a6700",
+    models.ValidationStatusError:   "#cf222e",
+}
+
+// RenderHTML renders a validation report as a self-contained HTML page.
+// Issues are listed most severe first.
+func RenderHTML(report *models.ValidationReport) (string, error) {
+    result := report.ValidationResult
+    if result == nil {
+        return "", fmt.Errorf("report has no validation result")
+    }
+
+    issues := append([]models.ValidationIssue(nil), result.Issues...)
+    sort.SliceStable(issues, func(i, j int) bool {
+        return severityRank[issues[i].Severity] > severityRank[issues[j].Severity]
+    })
+
+    data := struct {
+        Title           string
+        Result          *models.ValidationResult
+        StatusColor     string
+        Summary         string
+        Source          string
+        Target          string
+        Issues          []models.ValidationIssue
+        Recommendations []string
+    }{
+        Title:           fmt.Sprintf("Validation of %s translation to %s", result.SourceFormat, result.TargetFormat),
+        Result:          result,
+        StatusColor:     statusColors[result.Status],
+        Summary:         fmt.Sprintf("%d high, %d medium, %d low", report.Summary[models.ValidationSeverityHigh], report.Summary[models.ValidationSeverityMedium], report.Summary[models.ValidationSeverityLow]),
+        Issues:          issues,
+        Recommendations: report.Recommendations,
+    }
+    if report.SourceSummary != nil {
+        data.Source = report.SourceSummary.Text
+    }
+    if report.TargetSummary != nil {
+        data.Target = report.TargetSummary.Text
+    }
+
+    var buf bytes.Buffer
+    if err := htmlReport.Execute(&buf, data); err != nil {
+        return "", fmt.Errorf("rendering report: %w", err)
+    }
+    return buf.String(), nil
+}
@@ -0,0 +1,156 @@
+package report
+
+import (
+    "encoding/xml"
+    "fmt"
+    "strings"
+
+    "validation-service/internal/models"
+)
+
+// JUnitContentType is the media type of JUnit XML reports
+const JUnitContentType = "application/xml"
+
+// FailOnGate fails a detection on the verdict of its gate policy instead of
+// an issue severity
+const FailOnGate = "gate"
+
+// severityRank orders issue severities from least to most serious
+var severityRank = map[string]int{
+    models.ValidationSeverityLow:    1,
+    models.ValidationSeverityMedium: 2,
+    models.ValidationSeverityHigh:   3,
+}
+
+// JUnitTestSuites is a JUnit XML report
+type JUnitTestSuites struct {
+    XMLName  xml.Name         `xml:"testsuites"`
+    Name     string           `xml:"name,attr"`
+    Tests    int              `xml:"tests,attr"`
+    Failures int              `xml:"failures,attr"`
+    Errors   int              `xml:"errors,attr"`
+    Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite groups the validated detections
+type JUnitTestSuite struct {
+    Name      string          `xml:"name,attr"`
+    Tests     int             `xml:"tests,attr"`
+    Failures  int             `xml:"failures,attr"`
+    Errors    int             `xml:"errors,attr"`
+    Time      string          `xml:"time,attr"`
+    TestCases []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is the validation of one detection
+type JUnitTestCase struct {
+    Name      string        `xml:"name,attr"`
+    ClassName string        `xml:"classname,attr"`
+    Time      string        `xml:"time,attr"`
+    Failure   *JUnitProblem `xml:"failure,omitempty"`
+    Error     *JUnitProblem `xml:"error,omitempty"`
+    SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// JUnitProblem is a failure or error of a test case
+type JUnitProblem struct {
+    Message string `xml:"message,attr"`
+    Type    string `xml:"type,attr"`
+    Text    string `xml:",chardata"`
+}
+
+// JUnitCase is a detection to report: its validation result, or the error
+// that prevented validation
+type JUnitCase struct {
+    Name   string
+    Result *models.ValidationResult
+    Err    error
+}
+
+// ToJUnit converts validation results into a JUnit XML report with one test
+// case per detection. A detection fails when it has a correctness issue at
+// or above failOn severity, or when its gate fails if failOn is FailOnGate;
+// optimization hints never fail.
+func ToJUnit(name string, cases []JUnitCase, failOn string) *JUnitTestSuites {
+    suite := JUnitTestSuite{Name: name, TestCases: make([]JUnitTestCase, 0, len(cases))}
+    var total float64
+    for _, c := range cases {
+        testCase := JUnitTestCase{Name: c.Name, ClassName: name, Time: "0"}
+        switch {
+        case c.Err != nil:
+            testCase.Error = &JUnitProblem{Message: c.Err.Error(), Type: "error"}
+            suite.Errors++
+        case c.Result != nil:
+            seconds := c.Result.Metadata.ValidationTime.Seconds()
+            total += seconds
+            testCase.Time = fmt.Sprintf("%.3f", seconds)
+            testCase.ClassName = name + "." + c.Result.TargetFormat
+            failures := FailingIssues(c.Result, failOn)
+            switch {
+            case failOn == FailOnGate && c.Result.Metadata.Gate == models.GateFail:
+                testCase.Failure = &JUnitProblem{
+                    Message: "gate failed: " + strings.Join(c.Result.Metadata.GateReasons, "; "),
+                    Type:    FailOnGate,
+                    Text:    formatIssues(failures),
+                }
+                if len(failures) > 0 {
+                    testCase.Failure.Type = failures[0].IssueCode
+                }
+                suite.Failures++
+            case failOn != FailOnGate && len(failures) > 0:
+                testCase.Failure = &JUnitProblem{
+                    Message: fmt.Sprintf("%d issue(s) at or above %s severity", len(failures), failOn),
+                    Type:    failures[0].IssueCode,
+                    Text:    formatIssues(failures),
+                }
+                suite.Failures++
+            }
+            testCase.SystemOut = fmt.Sprintf("status=%s confidence_score=%.2f issues=%d", c.Result.Status, c.Result.ConfidenceScore, len(c.Result.Issues))
+        }
+        suite.TestCases = append(suite.TestCases, testCase)
+    }
+    suite.Tests = len(suite.TestCases)
+    suite.Time = fmt.Sprintf("%.3f", total)
+
+    return &JUnitTestSuites{
+        Name:     name,
+        Tests:    suite.Tests,
+        Failures: suite.Failures,
+        Errors:   suite.Errors,
+        Suites:   []JUnitTestSuite{suite},
+    }
+}
+
+// FailingIssues returns the correctness issues of result at or above failOn
+// severity, or its blocking issues if failOn is FailOnGate
+func FailingIssues(result *models.ValidationResult, failOn string) []models.ValidationIssue {
+    if failOn == FailOnGate {
+        var blocking []models.ValidationIssue
+        for _, issue := range result.Issues {
+            if issue.Blocking {
+                blocking = append(blocking, issue)
+            }
+        }
+        return blocking
+    }
+    threshold, ok := severityRank[failOn]
+    if !ok {
+        return nil
+    }
+    var failures []models.ValidationIssue
+    for _, issue := range result.Issues {
+        if !issue.IsOptimization() && severityRank[issue.Severity] >= threshold {
+            failures = append(failures, issue)
+        }
+    }
+    return failures
+}
+
+// formatIssues lists issues one per line as "[severity] CODE location: message"
+func formatIssues(issues []models.ValidationIssue) string {
+    var b strings.Builder
+    for _, issue := range issues {
+        fmt.Fprintf(&b, "[%s] %s %s: %s\n", issue.Severity, issue.IssueCode, issue.Location, issue.Message)
+    }
+    return b.String()
+}
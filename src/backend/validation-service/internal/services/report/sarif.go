@@ -0,0 +1,327 @@
+// Package report serializes validation results into report formats consumed
+// by CI systems
+package report
+
+import (
+    "encoding/json"
+    "fmt"
+    "sort"
+
+    "validation-service/internal/models"
+    "validation-service/internal/services/remediation"
+)
+
+// SARIF identification
+const (
+    SARIFVersion     = "2.1.0"
+    SARIFSchema      = "https://json.schemastore.org/sarif-2.1.0.json"
+    SARIFContentType = "application/sarif+json"
+
+    // sarifToolName names the service as the SARIF analysis tool
+    sarifToolName = "detection-validation-service"
+    // sarifDefaultRule identifies issues raised without an issue code
+    sarifDefaultRule = "VALIDATION"
+)
+
+// SARIF result levels
+const (
+    sarifLevelError   = "error"
+    sarifLevelWarning = "warning"
+    sarifLevelNote    = "note"
+)
+
+// SARIFLog is a SARIF 2.1.0 log file
+type SARIFLog struct {
+    Schema  string     `json:"$schema"`
+    Version string     `json:"version"`
+    Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun is one validation of a detection
+type SARIFRun struct {
+    Tool       SARIFTool              `json:"tool"`
+    Artifacts  []SARIFArtifact        `json:"artifacts"`
+    Results    []SARIFResult          `json:"results"`
+    Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// SARIFTool describes the validation service
+type SARIFTool struct {
+    Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver describes the validator and the issue codes it reported
+type SARIFDriver struct {
+    Name    string      `json:"name"`
+    Version string      `json:"version,omitempty"`
+    Rules   []SARIFRule `json:"rules"`
+}
+
+// SARIFRule describes an issue code
+type SARIFRule struct {
+    ID                   string                 `json:"id"`
+    ShortDescription     *SARIFMessage          `json:"shortDescription,omitempty"`
+    HelpURI              string                 `json:"helpUri,omitempty"`
+    DefaultConfiguration SARIFRuleConfiguration `json:"defaultConfiguration"`
+}
+
+// SARIFRuleConfiguration sets the default level of a rule
+type SARIFRuleConfiguration struct {
+    Level string `json:"level"`
+}
+
+// SARIFArtifact is the validated detection
+type SARIFArtifact struct {
+    Location       SARIFArtifactLocation `json:"location"`
+    SourceLanguage string                `json:"sourceLanguage,omitempty"`
+}
+
+// SARIFResult is one validation issue
+type SARIFResult struct {
+    RuleID     string                 `json:"ruleId"`
+    RuleIndex  int                    `json:"ruleIndex"`
+    Level      string                 `json:"level"`
+    Message    SARIFMessage           `json:"message"`
+    Locations  []SARIFLocation        `json:"locations"`
+    Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// SARIFMessage is a plain text message
+type SARIFMessage struct {
+    Text string `json:"text"`
+}
+
+// SARIFLocation places an issue in the detection
+type SARIFLocation struct {
+    PhysicalLocation SARIFPhysicalLocation  `json:"physicalLocation"`
+    LogicalLocations []SARIFLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+// SARIFPhysicalLocation is a region of the detection artifact
+type SARIFPhysicalLocation struct {
+    ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+    Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+// SARIFArtifactLocation identifies the detection artifact
+type SARIFArtifactLocation struct {
+    URI   string `json:"uri"`
+    Index int    `json:"index"`
+}
+
+// SARIFRegion is the line and column an issue was raised at
+type SARIFRegion struct {
+    StartLine   int `json:"startLine"`
+    StartColumn int `json:"startColumn,omitempty"`
+}
+
+// SARIFLogicalLocation is the validator's location of an issue, such as a
+// rule section or field
+type SARIFLogicalLocation struct {
+    FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// ArtifactURI returns the path the detection is reported under: the "path"
+// of its metadata when set, otherwise one derived from its ID and format
+func ArtifactURI(detection *models.Detection) string {
+    if detection == nil {
+        return "detection"
+    }
+    var metadata struct {
+        Path string `json:"path"`
+    }
+    if len(detection.Metadata) > 0 && json.Unmarshal(detection.Metadata, &metadata) == nil && metadata.Path != "" {
+        return metadata.Path
+    }
+    return fmt.Sprintf("detections/%s.%s", detection.ID, detection.Format)
+}
+
+// ToSARIF converts a validation result of the detection at artifactURI into a
+// SARIF log with one run. Issue codes become rules and issues become results.
+func ToSARIF(result *models.ValidationResult, artifactURI string) *SARIFLog {
+    return &SARIFLog{
+        Schema:  SARIFSchema,
+        Version: SARIFVersion,
+        Runs:    []SARIFRun{toSARIFRun(result, artifactURI)},
+    }
+}
+
+// ReportToSARIF converts a validation report into a SARIF log. The report
+// summary and success metrics are kept as run properties.
+func ReportToSARIF(report *models.ValidationReport, artifactURI string) *SARIFLog {
+    log := ToSARIF(report.ValidationResult, artifactURI)
+    run := &log.Runs[0]
+    run.Properties["summary"] = report.Summary
+    run.Properties["success_metrics"] = report.SuccessMetrics
+    if len(report.Recommendations) > 0 {
+        run.Properties["recommendations"] = report.Recommendations
+    }
+    return log
+}
+
+func toSARIFRun(result *models.ValidationResult, artifactURI string) SARIFRun {
+    artifact := SARIFArtifactLocation{URI: artifactURI, Index: 0}
+    run := SARIFRun{
+        Tool: SARIFTool{Driver: SARIFDriver{
+            Name:    sarifToolName,
+            Version: result.Metadata.ValidatorVersion,
+            Rules:   []SARIFRule{},
+        }},
+        Artifacts: []SARIFArtifact{{Location: artifact, SourceLanguage: result.TargetFormat}},
+        Results:   make([]SARIFResult, 0, len(result.Issues)),
+        Properties: map[string]interface{}{
+            "result_id":        result.ID.String(),
+            "status":           result.Status,
+            "confidence_score": result.ConfidenceScore,
+            "source_format":    result.SourceFormat,
+            "target_format":    result.TargetFormat,
+        },
+    }
+
+    // Rules are ordered by issue code so ruleIndex is stable across runs
+    codes := make(map[string]*models.ValidationIssue)
+    for i := range result.Issues {
+        code := issueRuleID(&result.Issues[i])
+        if _, ok := codes[code]; !ok {
+            codes[code] = &result.Issues[i]
+        }
+    }
+    ids := make([]string, 0, len(codes))
+    for code := range codes {
+        ids = append(ids, code)
+    }
+    sort.Strings(ids)
+    ruleIndex := make(map[string]int, len(ids))
+    for i, code := range ids {
+        issue := codes[code]
+        rule := SARIFRule{
+            ID:                   code,
+            DefaultConfiguration: SARIFRuleConfiguration{Level: sarifLevel(issue.Severity)},
+        }
+        if issue.IssueCode != "" {
+            rule.ShortDescription = &SARIFMessage{Text: issue.Message}
+        }
+        if url, ok := issue.IssueMetadata[remediation.MetadataPlaybookURL].(string); ok {
+            rule.HelpURI = url
+        }
+        run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+        ruleIndex[code] = i
+    }
+
+    for _, issue := range result.Issues {
+        code := issueRuleID(&issue)
+        location := SARIFLocation{
+            PhysicalLocation: SARIFPhysicalLocation{ArtifactLocation: artifact},
+        }
+        if issue.Line > 0 {
+            location.PhysicalLocation.Region = &SARIFRegion{StartLine: issue.Line, StartColumn: issue.Column}
+        }
+        if issue.Location != "" {
+            location.LogicalLocations = []SARIFLogicalLocation{{FullyQualifiedName: issue.Location}}
+        }
+
+        sarifResult := SARIFResult{
+            RuleID:    code,
+            RuleIndex: ruleIndex[code],
+            Level:     sarifLevel(issue.Severity),
+            Message:   SARIFMessage{Text: issue.Message},
+            Locations: []SARIFLocation{location},
+            Properties: map[string]interface{}{
+                "severity": issue.Severity,
+            },
+        }
+        if issue.Remediation != "" {
+            sarifResult.Properties["remediation"] = issue.Remediation
+        }
+        if issue.Fixable {
+            sarifResult.Properties["fixable"] = true
+        }
+        if issue.Category != "" {
+            sarifResult.Properties["category"] = issue.Category
+        }
+        run.Results = append(run.Results, sarifResult)
+    }
+
+    addLintResults(&run, result.Lint, artifact)
+    return run
+}
+
+// addLintResults reports lint findings as results of their lint rule. Lint
+// rules follow the issue code rules, ordered by rule ID.
+func addLintResults(run *SARIFRun, findings []models.LintFinding, artifact SARIFArtifactLocation) {
+    ruleIndex := make(map[string]int)
+    for _, finding := range findings {
+        ruleIndex[finding.Rule] = 0
+    }
+    ids := make([]string, 0, len(ruleIndex))
+    for id := range ruleIndex {
+        ids = append(ids, id)
+    }
+    sort.Strings(ids)
+    levels := make(map[string]string, len(findings))
+    for _, finding := range findings {
+        levels[finding.Rule] = lintSARIFLevel(finding.Level)
+    }
+    for _, id := range ids {
+        ruleIndex[id] = len(run.Tool.Driver.Rules)
+        run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, SARIFRule{
+            ID:                   id,
+            DefaultConfiguration: SARIFRuleConfiguration{Level: levels[id]},
+        })
+    }
+
+    for _, finding := range findings {
+        location := SARIFLocation{
+            PhysicalLocation: SARIFPhysicalLocation{ArtifactLocation: artifact},
+        }
+        if finding.Line > 0 {
+            location.PhysicalLocation.Region = &SARIFRegion{StartLine: finding.Line, StartColumn: finding.Column}
+        }
+        if finding.Location != "" {
+            location.LogicalLocations = []SARIFLogicalLocation{{FullyQualifiedName: finding.Location}}
+        }
+        run.Results = append(run.Results, SARIFResult{
+            RuleID:    finding.Rule,
+            RuleIndex: ruleIndex[finding.Rule],
+            Level:     lintSARIFLevel(finding.Level),
+            Message:   SARIFMessage{Text: finding.Message},
+            Locations: []SARIFLocation{location},
+            Properties: map[string]interface{}{
+                "lint_level": finding.Level,
+                "category":   finding.Category,
+            },
+        })
+    }
+}
+
+// issueRuleID returns the SARIF rule of an issue
+func issueRuleID(issue *models.ValidationIssue) string {
+    if issue.IssueCode == "" {
+        return sarifDefaultRule
+    }
+    return issue.IssueCode
+}
+
+// lintSARIFLevel maps a lint level to a SARIF result level
+func lintSARIFLevel(level string) string {
+    switch level {
+    case models.LintLevelError:
+        return sarifLevelError
+    case models.LintLevelWarning:
+        return sarifLevelWarning
+    default:
+        return sarifLevelNote
+    }
+}
+
+// sarifLevel maps an issue severity to a SARIF result level
+func sarifLevel(severity string) string {
+    switch severity {
+    case models.ValidationSeverityHigh:
+        return sarifLevelError
+    case models.ValidationSeverityMedium:
+        return sarifLevelWarning
+    default:
+        return sarifLevelNote
+    }
+}
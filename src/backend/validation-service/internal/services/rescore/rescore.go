@@ -0,0 +1,191 @@
+// Package rescore recomputes the confidence scores of stored validation
+// results after the scoring policy changed, from their persisted issues and
+// without re-running validators, so historical scores stay comparable.
+package rescore
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "time"
+
+    "validation-service/internal/models"
+    "validation-service/internal/services/validation"
+    "validation-service/internal/storage"
+    "validation-service/internal/tenant"
+    "validation-service/pkg/logger"
+)
+
+// maxErrors bounds the failure messages kept in the status
+const maxErrors = 20
+
+// ErrRescoreRunning is returned when a re-scoring run is already in progress
+var ErrRescoreRunning = errors.New("result re-scoring is already running")
+
+// Options selects the results to re-score
+type Options struct {
+    // TenantID limits the run to one tenant; every tenant when empty
+    TenantID string `json:"tenant_id,omitempty"`
+    // TargetFormat limits the run to results of one target format
+    TargetFormat string `json:"target_format,omitempty"`
+    // DryRun computes the new scores without saving them
+    DryRun bool `json:"dry_run,omitempty"`
+}
+
+// Status reports the progress of the current or last re-scoring run
+type Status struct {
+    Running    bool       `json:"running"`
+    StartedAt  *time.Time `json:"started_at,omitempty"`
+    FinishedAt *time.Time `json:"finished_at,omitempty"`
+    Options    Options    `json:"options"`
+    // Scanned counts the results matching the options
+    Scanned   int `json:"scanned"`
+    Rescored  int `json:"rescored"`
+    Unchanged int `json:"unchanged"`
+    Failed    int `json:"failed"`
+    // StatusChanges counts rescored results by status transition, such as
+    // "warning->success"
+    StatusChanges map[string]int `json:"status_changes"`
+    // MeanScoreChange is the average score change of rescored results
+    MeanScoreChange float64  `json:"mean_score_change"`
+    Errors          []string `json:"errors,omitempty"`
+}
+
+// Rescorer re-scores stored results in the background
+type Rescorer struct {
+    results storage.ResultStore
+    scoring *validation.ScoringPolicies
+    tenants *tenant.Registry
+    clock   models.Clock
+    log     *logger.Logger
+
+    mu       sync.Mutex
+    status   Status
+    complete func(Status)
+}
+
+// NewRescorer creates a rescorer applying scoring, and the policies of
+// tenants, to the results of store
+func NewRescorer(store storage.ResultStore, scoring *validation.ScoringPolicies, tenants *tenant.Registry) *Rescorer {
+    return &Rescorer{
+        results: store,
+        scoring: scoring,
+        tenants: tenants,
+        clock:   models.SystemClock,
+        log:     logger.GetLogger(),
+    }
+}
+
+// StartRescore re-scores the results selected by opts in the background.
+// Progress is available from RescoreStatus.
+func (r *Rescorer) StartRescore(opts Options) error {
+    if err := r.begin(opts); err != nil {
+        return err
+    }
+    go r.run(context.Background(), opts)
+    return nil
+}
+
+// RunRescore re-scores the results selected by opts and returns the final
+// status, for callers such as scheduled jobs that wait for the run
+func (r *Rescorer) RunRescore(ctx context.Context, opts Options) (Status, error) {
+    if err := r.begin(opts); err != nil {
+        return Status{}, err
+    }
+    r.run(ctx, opts)
+    return r.RescoreStatus(), nil
+}
+
+// begin marks a run with opts as started, unless one is already running
+func (r *Rescorer) begin(opts Options) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if r.status.Running {
+        return ErrRescoreRunning
+    }
+    now := r.clock.Now().UTC()
+    r.status = Status{Running: true, StartedAt: &now, Options: opts, StatusChanges: make(map[string]int)}
+    return nil
+}
+
+// SetCompletionHook calls complete with the final status of every run
+func (r *Rescorer) SetCompletionHook(complete func(Status)) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.complete = complete
+}
+
+// RescoreStatus returns the status of the current or last run
+func (r *Rescorer) RescoreStatus() Status {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    status := r.status
+    status.StatusChanges = make(map[string]int, len(r.status.StatusChanges))
+    for transition, count := range r.status.StatusChanges {
+        status.StatusChanges[transition] = count
+    }
+    status.Errors = append([]string(nil), r.status.Errors...)
+    return status
+}
+
+// run re-scores every matching result and saves the changed ones
+func (r *Rescorer) run(ctx context.Context, opts Options) {
+    var scoreChange float64
+    err := r.results.ScanResults(ctx, func(tenantID string, result *models.ValidationResult) error {
+        if (opts.TenantID != "" && tenantID != opts.TenantID) ||
+            (opts.TargetFormat != "" && result.TargetFormat != opts.TargetFormat) {
+            return nil
+        }
+
+        revision, changed := r.scoring.Rescore(ctx, result, r.tenants.Resolve(tenantID), r.clock.Now().UTC())
+        var saveErr error
+        if changed && !opts.DryRun {
+            saveErr = r.results.SaveResult(ctx, tenantID, result)
+        }
+
+        r.mu.Lock()
+        defer r.mu.Unlock()
+        r.status.Scanned++
+        switch {
+        case saveErr != nil:
+            r.status.Failed++
+            if len(r.status.Errors) < maxErrors {
+                r.status.Errors = append(r.status.Errors, result.ID.String()+": "+saveErr.Error())
+            }
+        case changed:
+            r.status.Rescored++
+            scoreChange += revision.Score - revision.PreviousScore
+            r.status.MeanScoreChange = scoreChange / float64(r.status.Rescored)
+            if revision.Status != revision.PreviousStatus {
+                r.status.StatusChanges[revision.PreviousStatus+"->"+revision.Status]++
+            }
+        default:
+            r.status.Unchanged++
+        }
+        return nil
+    })
+
+    r.mu.Lock()
+    if err != nil {
+        r.status.Errors = append(r.status.Errors, err.Error())
+        r.log.Error("Result re-scoring failed",
+            "error", err,
+        )
+    }
+    finished := r.clock.Now().UTC()
+    r.status.Running = false
+    r.status.FinishedAt = &finished
+    r.log.Info("Result re-scoring completed",
+        "dry_run", opts.DryRun,
+        "scanned", r.status.Scanned,
+        "rescored", r.status.Rescored,
+        "failed", r.status.Failed,
+        "duration", finished.Sub(*r.status.StartedAt),
+    )
+    complete := r.complete
+    r.mu.Unlock()
+
+    if complete != nil {
+        complete(r.RescoreStatus())
+    }
+}
@@ -0,0 +1,279 @@
+// Package rulediff compares two versions of a stored detection: the
+// metadata fields that changed, the tags and ATT&CK techniques added or
+// removed, the conditions, exclusions, thresholds and time windows of the
+// rule logic, and the lines of content that changed.
+package rulediff
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+
+    "github.com/google/uuid" // v1.4.0
+
+    "validation-service/internal/models"
+    "validation-service/internal/services/ruledoc"
+    "validation-service/internal/storage"
+)
+
+// maxLineDiffCells bounds the work of the content line diff; larger content
+// is reported as entirely replaced
+const maxLineDiffCells = 4 << 20
+
+// Line change operations
+const (
+    OpAdded   = "added"
+    OpRemoved = "removed"
+)
+
+// Diff is the structural difference between two versions of a detection
+type Diff struct {
+    DetectionID uuid.UUID     `json:"detection_id"`
+    From        int           `json:"from"`
+    To          int           `json:"to"`
+    Fields      []FieldChange `json:"fields"`
+    Tags        TagChanges    `json:"tags"`
+    Techniques  ListChanges   `json:"techniques"`
+    // Logic compares the plain-English description of the rule logic of
+    // both versions, as produced by the rule documentation generator
+    Logic   LogicChanges `json:"logic"`
+    Content []LineChange `json:"content"`
+}
+
+// FieldChange is a changed scalar field
+type FieldChange struct {
+    Field string      `json:"field"`
+    From  interface{} `json:"from"`
+    To    interface{} `json:"to"`
+}
+
+// TagChanges lists added, removed and changed tags
+type TagChanges struct {
+    Added   map[string]string      `json:"added,omitempty"`
+    Removed map[string]string      `json:"removed,omitempty"`
+    Changed map[string]FieldChange `json:"changed,omitempty"`
+}
+
+// ListChanges lists the entries only present in one version
+type ListChanges struct {
+    Added   []string `json:"added,omitempty"`
+    Removed []string `json:"removed,omitempty"`
+}
+
+// LogicChanges compares the parts of the rule logic
+type LogicChanges struct {
+    Fields     ListChanges `json:"fields"`
+    Conditions ListChanges `json:"conditions"`
+    Exclusions ListChanges `json:"exclusions"`
+    Thresholds ListChanges `json:"thresholds"`
+    TimeWindow ListChanges `json:"time_window"`
+}
+
+// LineChange is a line added to or removed from the content. Line is the
+// 1-based line number in the version the line belongs to.
+type LineChange struct {
+    Op   string `json:"op"`
+    Line int    `json:"line"`
+    Text string `json:"text"`
+}
+
+// Compare returns the difference from one version of a detection to another
+func Compare(from, to *storage.StoredDetection) *Diff {
+    diff := &Diff{
+        DetectionID: to.ID(),
+        From:        from.Version,
+        To:          to.Version,
+        Fields:      []FieldChange{},
+        Content:     []LineChange{},
+    }
+
+    fromDetection, toDetection := detectionOf(from), detectionOf(to)
+    diff.addField("name", from.Name, to.Name)
+    diff.addField("description", from.Description, to.Description)
+    diff.addField("license", from.License, to.License)
+    diff.addField("format", fromDetection.Format, toDetection.Format)
+    diff.addField("confidence_score", scoreOf(from.ConfidenceScore), scoreOf(to.ConfidenceScore))
+    diff.addField("is_active", fromDetection.IsActive, toDetection.IsActive)
+    if from.Validation != nil && to.Validation != nil {
+        diff.addField("validation.status", from.Validation.Status, to.Validation.Status)
+        diff.addField("validation.confidence_score", from.Validation.ConfidenceScore, to.Validation.ConfidenceScore)
+    }
+
+    diff.Tags = compareTags(from.Tags, to.Tags)
+    diff.Techniques = compareLists(from.Techniques, to.Techniques)
+    diff.Logic = compareLogic(ruledoc.Summarize(fromDetection), ruledoc.Summarize(toDetection))
+    diff.Content = CompareLines(fromDetection.Content, toDetection.Content)
+    return diff
+}
+
+// addField records a scalar field whose values differ
+func (d *Diff) addField(field string, from, to interface{}) {
+    if fmt.Sprint(from) != fmt.Sprint(to) {
+        d.Fields = append(d.Fields, FieldChange{Field: field, From: from, To: to})
+    }
+}
+
+// detectionOf returns the detection of a stored record, empty when missing
+func detectionOf(stored *storage.StoredDetection) *models.Detection {
+    if stored.Detection == nil {
+        return &models.Detection{}
+    }
+    return stored.Detection
+}
+
+// scoreOf dereferences an optional score
+func scoreOf(score *float64) interface{} {
+    if score == nil {
+        return nil
+    }
+    return *score
+}
+
+// compareTags compares two tag sets
+func compareTags(from, to map[string]string) TagChanges {
+    var changes TagChanges
+    for key, value := range from {
+        toValue, ok := to[key]
+        switch {
+        case !ok:
+            if changes.Removed == nil {
+                changes.Removed = make(map[string]string)
+            }
+            changes.Removed[key] = value
+        case toValue != value:
+            if changes.Changed == nil {
+                changes.Changed = make(map[string]FieldChange)
+            }
+            changes.Changed[key] = FieldChange{Field: key, From: value, To: toValue}
+        }
+    }
+    for key, value := range to {
+        if _, ok := from[key]; !ok {
+            if changes.Added == nil {
+                changes.Added = make(map[string]string)
+            }
+            changes.Added[key] = value
+        }
+    }
+    return changes
+}
+
+// compareLogic compares the summaries of both versions; a version whose
+// logic cannot be described contributes no entries
+func compareLogic(from, to *models.RuleSummary) LogicChanges {
+    if from == nil {
+        from = &models.RuleSummary{}
+    }
+    if to == nil {
+        to = &models.RuleSummary{}
+    }
+    return LogicChanges{
+        Fields:     compareLists(from.Fields, to.Fields),
+        Conditions: compareLists(from.Conditions, to.Conditions),
+        Exclusions: compareLists(from.Exclusions, to.Exclusions),
+        Thresholds: compareLists(from.Thresholds, to.Thresholds),
+        TimeWindow: compareLists(from.TimeWindow, to.TimeWindow),
+    }
+}
+
+// compareLists returns the entries only present in one list, sorted
+func compareLists(from, to []string) ListChanges {
+    fromSet, toSet := setOf(from), setOf(to)
+    var changes ListChanges
+    for value := range toSet {
+        if !fromSet[value] {
+            changes.Added = append(changes.Added, value)
+        }
+    }
+    for value := range fromSet {
+        if !toSet[value] {
+            changes.Removed = append(changes.Removed, value)
+        }
+    }
+    sort.Strings(changes.Added)
+    sort.Strings(changes.Removed)
+    return changes
+}
+
+// setOf returns the set of values
+func setOf(values []string) map[string]bool {
+    set := make(map[string]bool, len(values))
+    for _, value := range values {
+        set[value] = true
+    }
+    return set
+}
+
+// CompareLines diffs content line by line using the longest common
+// subsequence. Removed lines are numbered in from, added lines in to.
+func CompareLines(from, to string) []LineChange {
+    changes := []LineChange{}
+    if from == to {
+        return changes
+    }
+    a, b := splitLines(from), splitLines(to)
+
+    // Trim the common prefix and suffix, which covers most edits cheaply
+    prefix := 0
+    for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+        prefix++
+    }
+    suffix := 0
+    for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+        suffix++
+    }
+    a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+    if (len(a)+1)*(len(b)+1) > maxLineDiffCells {
+        for i, line := range a {
+            changes = append(changes, LineChange{Op: OpRemoved, Line: prefix + i + 1, Text: line})
+        }
+        for j, line := range b {
+            changes = append(changes, LineChange{Op: OpAdded, Line: prefix + j + 1, Text: line})
+        }
+        return changes
+    }
+
+    // lcs[i][j] is the length of the longest common subsequence of a[i:]
+    // and b[j:]
+    lcs := make([][]int, len(a)+1)
+    for i := range lcs {
+        lcs[i] = make([]int, len(b)+1)
+    }
+    for i := len(a) - 1; i >= 0; i-- {
+        for j := len(b) - 1; j >= 0; j-- {
+            if a[i] == b[j] {
+                lcs[i][j] = lcs[i+1][j+1] + 1
+            } else if lcs[i+1][j] >= lcs[i][j+1] {
+                lcs[i][j] = lcs[i+1][j]
+            } else {
+                lcs[i][j] = lcs[i][j+1]
+            }
+        }
+    }
+
+    i, j := 0, 0
+    for i < len(a) || j < len(b) {
+        switch {
+        case i < len(a) && j < len(b) && a[i] == b[j]:
+            i++
+            j++
+        case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+            changes = append(changes, LineChange{Op: OpAdded, Line: prefix + j + 1, Text: b[j]})
+            j++
+        default:
+            changes = append(changes, LineChange{Op: OpRemoved, Line: prefix + i + 1, Text: a[i]})
+            i++
+        }
+    }
+    return changes
+}
+
+// splitLines splits content into lines, ignoring a trailing newline
+func splitLines(content string) []string {
+    content = strings.TrimSuffix(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+    if content == "" {
+        return nil
+    }
+    return strings.Split(content, "\n")
+}
@@ -0,0 +1,616 @@
+package ruledoc
+
+import (
+    "fmt"
+    "regexp"
+    "sort"
+    "strings"
+
+    "gopkg.in/yaml.v3" // v3.0.1
+
+    yaraparser "validation-service/internal/parser/yara"
+)
+
+// sigmaModifierPhrases describe Sigma value modifiers
+var sigmaModifierPhrases = map[string]string{
+    "contains":   "contains",
+    "startswith": "starts with",
+    "endswith":   "ends with",
+    "re":         "matches the regular expression",
+    "cidr":       "is in the network",
+    "base64":     "contains the base64 encoding of",
+    "gt":         "is greater than",
+    "gte":        "is at least",
+    "lt":         "is less than",
+    "lte":        "is at most",
+}
+
+// Splunk and KQL pipeline commands with a plain-English description of what
+// they do to the results
+var (
+    splunkCommandPhrases = map[string]string{
+        "search":      "keeps events matching",
+        "where":       "keeps results where",
+        "eval":        "computes",
+        "stats":       "aggregates results with",
+        "tstats":      "aggregates indexed fields with",
+        "eventstats":  "annotates events with aggregates",
+        "streamstats": "annotates events with running aggregates",
+        "table":       "outputs the fields",
+        "fields":      "keeps the fields",
+        "rename":      "renames",
+        "rex":         "extracts fields with the regular expression",
+        "dedup":       "removes duplicates of",
+        "sort":        "sorts by",
+        "head":        "keeps the first results:",
+        "lookup":      "enriches results from the lookup",
+        "bin":         "buckets",
+        "bucket":      "buckets",
+        "transaction": "groups events into transactions by",
+    }
+    kqlOperatorPhrases = map[string]string{
+        "where":     "keeps rows where",
+        "filter":    "keeps rows where",
+        "extend":    "computes",
+        "project":   "outputs the columns",
+        "summarize": "aggregates rows with",
+        "join":      "joins with",
+        "lookup":    "enriches rows from",
+        "parse":     "parses",
+        "distinct":  "keeps distinct values of",
+        "sort":      "sorts by",
+        "order":     "sorts by",
+        "top":       "keeps the top rows:",
+        "take":      "keeps the first rows:",
+        "limit":     "keeps the first rows:",
+        "mv-expand": "expands the array",
+    }
+)
+
+var (
+    // splunkDataRegex matches index, sourcetype and source constraints
+    splunkDataRegex = regexp.MustCompile(`(?i)\b(index|sourcetype|source)\s*=\s*("[^"]*"|[^\s|)]+)`)
+    // splunkExclusionRegex matches NOT terms and != comparisons
+    splunkExclusionRegex = regexp.MustCompile(`(?i)\bNOT\s+(\([^)]*\)|"[^"]*"|\S+)|\b([\w.]+)\s*!=\s*("[^"]*"|[^\s|)]+)`)
+    // kqlExclusionRegex matches negated KQL comparisons and not() calls
+    kqlExclusionRegex = regexp.MustCompile(`\b([A-Za-z_][\w.]*)\s*(!in~?|!=|!~|!contains(?:_cs)?|!has(?:_cs)?|!startswith(?:_cs)?|!endswith(?:_cs)?)\s*(\([^)]*\)|"[^"]*"|'[^']*'|\S+)|\bnot\s*\(([^)]*)\)`)
+    // kqlFieldRegex matches columns compared in KQL predicates
+    kqlFieldRegex = regexp.MustCompile(`\b([A-Za-z_][\w]*)\s*(?:==|!=|=~|!~|!?in~?\s*\(|!?has(?:_any|_all|_cs)?\b|!?contains(?:_cs)?\b|!?startswith(?:_cs)?\b|!?endswith(?:_cs)?\b|matches\s+regex\b)`)
+    // kqlTableRegex matches a table name at the start of a query
+    kqlTableRegex = regexp.MustCompile(`^[A-Za-z_][\w]*$`)
+    // sigmaConditionTokenRegex splits a Sigma condition into tokens
+    sigmaConditionTokenRegex = regexp.MustCompile(`\(|\)|[^\s()]+`)
+)
+
+// kqlKeywords are words kqlFieldRegex matches that are not columns
+var kqlKeywords = map[string]bool{
+    "where": true, "and": true, "or": true, "not": true, "by": true,
+}
+
+// sigmaRule is the part of a Sigma rule that is documented
+type sigmaRule struct {
+    Title          string                 `yaml:"title"`
+    Description    string                 `yaml:"description"`
+    Author         string                 `yaml:"author"`
+    Status         string                 `yaml:"status"`
+    Level          string                 `yaml:"level"`
+    References     []string               `yaml:"references"`
+    Logsource      map[string]string      `yaml:"logsource"`
+    Detection      map[string]interface{} `yaml:"detection"`
+    FalsePositives []string               `yaml:"falsepositives"`
+}
+
+// describeSigma documents a Sigma rule from its metadata, logsource and
+// detection section
+func describeSigma(doc *Document, content string) error {
+    var rule sigmaRule
+    if err := yaml.Unmarshal([]byte(content), &rule); err != nil {
+        return fmt.Errorf("invalid Sigma rule: %w", err)
+    }
+    doc.Title = rule.Title
+    doc.Description = strings.TrimSpace(rule.Description)
+    doc.Author = rule.Author
+    doc.Status = rule.Status
+    doc.Level = rule.Level
+    doc.References = rule.References
+    doc.FalsePositives = rule.FalsePositives
+
+    for _, key := range []string{"product", "category", "service"} {
+        if value := rule.Logsource[key]; value != "" {
+            doc.DataSources = append(doc.DataSources, fmt.Sprintf("%s: %s", key, value))
+        }
+    }
+
+    var conditions []string
+    switch condition := rule.Detection["condition"].(type) {
+    case string:
+        conditions = []string{condition}
+    case []interface{}:
+        for _, c := range condition {
+            if s, ok := c.(string); ok {
+                conditions = append(conditions, s)
+            }
+        }
+    }
+    negated := make(map[string]bool)
+    for _, condition := range conditions {
+        phrase, excluded := describeSigmaCondition(condition)
+        doc.Logic = append(doc.Logic, "Alerts when "+phrase+".")
+        for _, name := range excluded {
+            negated[name] = true
+        }
+    }
+
+    names := make([]string, 0, len(rule.Detection))
+    for name := range rule.Detection {
+        if name != "condition" && name != "timeframe" {
+            names = append(names, name)
+        }
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        clause := describeSigmaSearch(rule.Detection[name])
+        if negated[name] || matchesAny(name, negated) || strings.HasPrefix(name, "filter") {
+            doc.Tuning = append(doc.Tuning, fmt.Sprintf("Excludes events where %s (`%s`).", clause, name))
+            continue
+        }
+        doc.Logic = append(doc.Logic, fmt.Sprintf("`%s` matches events where %s.", name, clause))
+    }
+    if timeframe, ok := rule.Detection["timeframe"].(string); ok {
+        doc.Logic = append(doc.Logic, fmt.Sprintf("Events are correlated within %s.", timeframe))
+    }
+    return nil
+}
+
+// describeSigmaCondition translates a Sigma condition into English and
+// returns the search identifiers, or patterns, that it negates
+func describeSigmaCondition(condition string) (string, []string) {
+    tokens := sigmaConditionTokenRegex.FindAllString(condition, -1)
+    var words, negated []string
+    negate := false
+    for i := 0; i < len(tokens); i++ {
+        token := tokens[i]
+        switch lower := strings.ToLower(token); {
+        case lower == "and" || lower == "or" || lower == "(" || lower == ")":
+            words = append(words, lower)
+        case lower == "not":
+            words = append(words, "not")
+            negate = true
+            continue
+        case (lower == "1" || lower == "all" || lower == "any") && i+2 < len(tokens) && strings.EqualFold(tokens[i+1], "of"):
+            target := tokens[i+2]
+            i += 2
+            quantifier := "any"
+            if lower == "all" {
+                quantifier = "all"
+            }
+            if strings.EqualFold(target, "them") {
+                words = append(words, quantifier+" of the searches")
+            } else {
+                words = append(words, fmt.Sprintf("%s of the searches named `%s`", quantifier, target))
+            }
+            if negate {
+                negated = append(negated, target)
+            }
+        case strings.HasPrefix(token, "|"):
+            // Deprecated aggregation expressions are kept verbatim
+            words = append(words, "`"+strings.Join(tokens[i:], " ")+"`")
+            i = len(tokens)
+        default:
+            words = append(words, "`"+token+"`")
+            if negate {
+                negated = append(negated, token)
+            }
+        }
+        negate = false
+    }
+    phrase := strings.Join(words, " ")
+    phrase = strings.ReplaceAll(phrase, "( ", "(")
+    phrase = strings.ReplaceAll(phrase, " )", ")")
+    return phrase, negated
+}
+
+// matchesAny reports whether name matches a negated `x*` pattern
+func matchesAny(name string, patterns map[string]bool) bool {
+    for pattern := range patterns {
+        if strings.HasSuffix(pattern, "*") && strings.HasPrefix(name, strings.TrimSuffix(pattern, "*")) {
+            return true
+        }
+    }
+    return false
+}
+
+// describeSigmaSearch describes a search identifier: field maps are ANDed,
+// lists of maps are ORed, and plain lists are keywords
+func describeSigmaSearch(value interface{}) string {
+    switch v := value.(type) {
+    case map[string]interface{}:
+        keys := make([]string, 0, len(v))
+        for key := range v {
+            keys = append(keys, key)
+        }
+        sort.Strings(keys)
+        clauses := make([]string, 0, len(keys))
+        for _, key := range keys {
+            clauses = append(clauses, describeSigmaField(key, v[key]))
+        }
+        return strings.Join(clauses, " and ")
+    case []interface{}:
+        var maps, keywords []string
+        for _, item := range v {
+            if m, ok := item.(map[string]interface{}); ok {
+                maps = append(maps, describeSigmaSearch(m))
+            } else {
+                keywords = append(keywords, quote(item))
+            }
+        }
+        if len(keywords) > 0 {
+            maps = append(maps, "the event contains "+oneOf(keywords, false))
+        }
+        if len(maps) > 1 {
+            return "(" + strings.Join(maps, ") or (") + ")"
+        }
+        return strings.Join(maps, "")
+    default:
+        return "the event contains " + quote(v)
+    }
+}
+
+// describeSigmaField describes a `field|modifier: value` clause
+func describeSigmaField(key string, value interface{}) string {
+    parts := strings.Split(key, "|")
+    field := "`" + parts[0] + "`"
+    if parts[0] == "" {
+        field = "the event"
+    }
+    phrase := "is"
+    all := false
+    for _, modifier := range parts[1:] {
+        if modifier == "all" {
+            all = true
+        } else if p, ok := sigmaModifierPhrases[modifier]; ok {
+            phrase = p
+        }
+    }
+
+    var values []string
+    switch v := value.(type) {
+    case nil:
+        return field + " is empty"
+    case []interface{}:
+        for _, item := range v {
+            values = append(values, quote(item))
+        }
+    default:
+        values = []string{quote(v)}
+    }
+    return fmt.Sprintf("%s %s %s", field, phrase, oneOf(values, all))
+}
+
+// oneOf joins values as "x", "any of x, y" or "all of x, y"
+func oneOf(values []string, all bool) string {
+    if len(values) == 1 {
+        return values[0]
+    }
+    quantifier := "any of "
+    if all {
+        quantifier = "all of "
+    }
+    return quantifier + strings.Join(values, ", ")
+}
+
+// quote formats a value as inline code
+func quote(v interface{}) string {
+    return fmt.Sprintf("`%v`", v)
+}
+
+// describeSplunk documents an SPL search from its base search and commands
+func describeSplunk(doc *Document, content string) {
+    stages := splitPipeline(content)
+    if len(stages) == 0 {
+        return
+    }
+    for _, match := range splunkDataRegex.FindAllStringSubmatch(content, -1) {
+        doc.DataSources = appendUnique(doc.DataSources, fmt.Sprintf("%s: %s", strings.ToLower(match[1]), strings.Trim(match[2], `"`)))
+    }
+    for i, stage := range stages {
+        if i == 0 && !strings.HasPrefix(strings.ToLower(stage), "tstats") {
+            doc.Logic = append(doc.Logic, fmt.Sprintf("Searches events matching `%s`.", stage))
+            continue
+        }
+        doc.Logic = append(doc.Logic, describeCommand(stage, splunkCommandPhrases, "Then"))
+    }
+    for _, match := range splunkExclusionRegex.FindAllStringSubmatch(content, -1) {
+        if match[1] != "" {
+            doc.Tuning = append(doc.Tuning, fmt.Sprintf("Excludes events matching `%s`.", match[1]))
+        } else {
+            doc.Tuning = append(doc.Tuning, fmt.Sprintf("Excludes events where `%s` is `%s`.", match[2], strings.Trim(match[3], `"`)))
+        }
+    }
+}
+
+// describeKQL documents a KQL query from its table and operators
+func describeKQL(doc *Document, query string) {
+    stages := splitPipeline(query)
+    if len(stages) == 0 {
+        return
+    }
+    for i, stage := range stages {
+        if i == 0 && kqlTableRegex.MatchString(stage) {
+            doc.DataSources = append(doc.DataSources, "table: "+stage)
+            doc.Logic = append(doc.Logic, fmt.Sprintf("Reads the `%s` table.", stage))
+            continue
+        }
+        if i == 0 {
+            doc.Logic = append(doc.Logic, fmt.Sprintf("Starts from `%s`.", stage))
+            continue
+        }
+        doc.Logic = append(doc.Logic, describeCommand(stage, kqlOperatorPhrases, "Then"))
+    }
+
+    seen := make(map[string]bool)
+    for _, match := range kqlFieldRegex.FindAllStringSubmatch(query, -1) {
+        if !kqlKeywords[strings.ToLower(match[1])] && !seen[match[1]] {
+            seen[match[1]] = true
+            doc.Fields = append(doc.Fields, match[1])
+        }
+    }
+    sort.Strings(doc.Fields)
+
+    for _, match := range kqlExclusionRegex.FindAllStringSubmatch(query, -1) {
+        if match[4] != "" {
+            doc.Tuning = append(doc.Tuning, fmt.Sprintf("Excludes rows where `%s`.", strings.TrimSpace(match[4])))
+        } else {
+            doc.Tuning = append(doc.Tuning, fmt.Sprintf("Excludes rows where `%s %s %s`.", match[1], match[2], match[3]))
+        }
+    }
+}
+
+// describeSentinel documents a Sentinel analytics rule from its metadata
+// and KQL query
+func describeSentinel(doc *Document, content string) error {
+    var rule struct {
+        Name        string   `yaml:"name"`
+        Description string   `yaml:"description"`
+        Severity    string   `yaml:"severity"`
+        Tactics     []string `yaml:"tactics"`
+        Query       string   `yaml:"query"`
+    }
+    if err := yaml.Unmarshal([]byte(content), &rule); err != nil {
+        return fmt.Errorf("invalid Sentinel rule: %w", err)
+    }
+    doc.Title = rule.Name
+    doc.Description = strings.TrimSpace(rule.Description)
+    doc.Level = rule.Severity
+    doc.Tactics = rule.Tactics
+    describeKQL(doc, rule.Query)
+    return nil
+}
+
+// describeCommand describes one pipeline stage using phrases keyed by
+// command name
+func describeCommand(stage string, phrases map[string]string, lead string) string {
+    command, args := stage, ""
+    if i := strings.IndexAny(stage, " \t\n"); i >= 0 {
+        command, args = stage[:i], strings.TrimSpace(stage[i+1:])
+    }
+    phrase, ok := phrases[strings.ToLower(command)]
+    if !ok {
+        return fmt.Sprintf("%s runs `%s`.", lead, stage)
+    }
+    if args == "" {
+        return fmt.Sprintf("%s %s.", lead, strings.TrimSuffix(phrase, ":"))
+    }
+    return fmt.Sprintf("%s %s `%s`.", lead, phrase, args)
+}
+
+// splitPipeline splits a query on the pipes outside of quotes, parentheses
+// and brackets
+func splitPipeline(query string) []string {
+    var stages []string
+    var quote rune
+    depth, start := 0, 0
+    for i, c := range query {
+        switch {
+        case quote != 0:
+            if c == quote {
+                quote = 0
+            }
+        case c == '"' || c == '\'':
+            quote = c
+        case c == '(' || c == '[':
+            depth++
+        case c == ')' || c == ']':
+            depth--
+        case c == '|' && depth <= 0:
+            stages = append(stages, query[start:i])
+            start = i + 1
+        }
+    }
+    stages = append(stages, query[start:])
+
+    result := stages[:0]
+    for _, stage := range stages {
+        if stage = strings.Join(strings.Fields(stage), " "); stage != "" {
+            result = append(result, stage)
+        }
+    }
+    return result
+}
+
+// appendUnique appends value unless list already contains it
+func appendUnique(list []string, value string) []string {
+    for _, v := range list {
+        if v == value {
+            return list
+        }
+    }
+    return append(list, value)
+}
+
+// describeYara documents YARA rules from their parsed meta, strings and
+// conditions
+func describeYara(doc *Document, content string) error {
+    file, errs := yaraparser.Parse(content)
+    if len(errs) > 0 {
+        return fmt.Errorf("invalid YARA rule: %s", errs[0].Error())
+    }
+    if len(file.Rules) == 0 {
+        return fmt.Errorf("invalid YARA rule: no rules declared")
+    }
+    doc.Title = file.Rules[0].Name
+    for _, module := range file.Imports {
+        doc.DataSources = append(doc.DataSources, "YARA module: "+module.Module)
+    }
+    if len(doc.DataSources) == 0 {
+        doc.DataSources = []string{"file or memory contents"}
+    }
+
+    for _, rule := range file.Rules {
+        for _, meta := range rule.Meta {
+            value := fmt.Sprint(meta.Value)
+            switch strings.ToLower(meta.Key) {
+            case "description":
+                if doc.Description == "" {
+                    doc.Description = value
+                }
+            case "author":
+                if doc.Author == "" {
+                    doc.Author = value
+                }
+            case "reference", "references":
+                doc.References = append(doc.References, value)
+            }
+        }
+
+        kinds := make(map[yaraparser.StringKind]int)
+        for _, s := range rule.Strings {
+            kinds[s.Kind]++
+        }
+        strs := fmt.Sprintf("%d text, %d hex and %d regex strings", kinds[yaraparser.StringText], kinds[yaraparser.StringHex], kinds[yaraparser.StringRegex])
+        if len(rule.Strings) == 0 {
+            strs = "no strings"
+        }
+        doc.Logic = append(doc.Logic, fmt.Sprintf("Rule `%s` defines %s and matches when %s.", rule.Name, strs, describeYaraExpr(rule.Condition)))
+        yaraparser.Walk(rule.Condition, func(e yaraparser.Expr) bool {
+            if u, ok := e.(*yaraparser.UnaryExpr); ok && u.Op == "not" {
+                doc.Tuning = append(doc.Tuning, fmt.Sprintf("Rule `%s` does not match when %s.", rule.Name, describeYaraExpr(u.X)))
+                return false
+            }
+            return true
+        })
+    }
+    if len(file.Rules) > 1 {
+        doc.Title = fmt.Sprintf("%s (+%d rules)", doc.Title, len(file.Rules)-1)
+    }
+    return nil
+}
+
+// describeYaraExpr renders a YARA condition, phrasing string matches and
+// boolean operators in English
+func describeYaraExpr(e yaraparser.Expr) string {
+    switch n := e.(type) {
+    case nil:
+        return "nothing"
+    case *yaraparser.BinaryExpr:
+        if n.Op == "and" || n.Op == "or" {
+            return describeYaraExpr(n.Left) + " " + n.Op + " " + describeYaraExpr(n.Right)
+        }
+        return "`" + yaraText(n) + "`"
+    case *yaraparser.UnaryExpr:
+        if n.Op == "not" {
+            return "not " + describeYaraExpr(n.X)
+        }
+        return "`" + yaraText(n) + "`"
+    case *yaraparser.ParenExpr:
+        return "(" + describeYaraExpr(n.X) + ")"
+    case *yaraparser.StringRef:
+        if n.Sigil == '$' {
+            return "`" + n.ID() + "` is found"
+        }
+        return "`" + yaraText(n) + "`"
+    case *yaraparser.OfExpr:
+        set := "the strings"
+        if !n.Them && n.Set != nil {
+            set = "`" + yaraText(n.Set) + "`"
+        }
+        phrase := yaraText(n.Quantifier) + " of " + set + " are found"
+        if n.In != nil {
+            phrase += " in `" + yaraText(n.In) + "`"
+        }
+        if n.At != nil {
+            phrase += " at `" + yaraText(n.At) + "`"
+        }
+        return phrase
+    default:
+        return "`" + yaraText(e) + "`"
+    }
+}
+
+// yaraText renders an expression back to YARA syntax
+func yaraText(e yaraparser.Expr) string {
+    switch n := e.(type) {
+    case nil:
+        return ""
+    case *yaraparser.Literal:
+        return n.Raw
+    case *yaraparser.Ident:
+        return n.Name
+    case *yaraparser.StringRef:
+        return string(n.Sigil) + n.Name
+    case *yaraparser.UnaryExpr:
+        if n.Op == "not" || n.Op == "defined" {
+            return n.Op + " " + yaraText(n.X)
+        }
+        return n.Op + yaraText(n.X)
+    case *yaraparser.BinaryExpr:
+        return yaraText(n.Left) + " " + n.Op + " " + yaraText(n.Right)
+    case *yaraparser.ParenExpr:
+        return "(" + yaraText(n.X) + ")"
+    case *yaraparser.MemberExpr:
+        return yaraText(n.X) + "." + n.Name
+    case *yaraparser.IndexExpr:
+        return yaraText(n.X) + "[" + yaraText(n.Index) + "]"
+    case *yaraparser.CallExpr:
+        args := make([]string, len(n.Args))
+        for i, arg := range n.Args {
+            args[i] = yaraText(arg)
+        }
+        return yaraText(n.Fun) + "(" + strings.Join(args, ", ") + ")"
+    case *yaraparser.RangeExpr:
+        return "(" + yaraText(n.Low) + ".." + yaraText(n.High) + ")"
+    case *yaraparser.SetExpr:
+        elems := make([]string, len(n.Elems))
+        for i, el := range n.Elems {
+            elems[i] = yaraText(el)
+        }
+        return "(" + strings.Join(elems, ", ") + ")"
+    case *yaraparser.OfExpr:
+        set := "them"
+        if !n.Them && n.Set != nil {
+            set = yaraText(n.Set)
+        }
+        return yaraText(n.Quantifier) + " of " + set
+    case *yaraparser.StringMatchExpr:
+        if n.At != nil {
+            return yaraText(n.Ref) + " at " + yaraText(n.At)
+        }
+        return yaraText(n.Ref) + " in " + yaraText(n.In)
+    case *yaraparser.ForExpr:
+        return "for " + yaraText(n.Quantifier) + " ... : (" + yaraText(n.Body) + ")"
+    default:
+        return "..."
+    }
+}
+
+// describeGeneric documents a rule of a format without a dedicated
+// summarizer
+func describeGeneric(doc *Document, content string) {
+    lines := 0
+    for _, line := range strings.Split(content, "\n") {
+        if strings.TrimSpace(line) != "" {
+            lines++
+        }
+    }
+    doc.Logic = []string{fmt.Sprintf("The %s rule is documented verbatim below (%d lines); a plain-English summary is not available for this format.", doc.Format, lines)}
+}
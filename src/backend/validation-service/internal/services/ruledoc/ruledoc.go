@@ -0,0 +1,264 @@
+// Package ruledoc generates human-readable documentation for detection rules,
+// for inclusion in detection catalogs
+package ruledoc
+
+import (
+    "encoding/json"
+    "fmt"
+    "strings"
+
+    "validation-service/internal/models"
+    "validation-service/internal/services/validation"
+    "validation-service/pkg/mitre"
+)
+
+// MarkdownContentType is the media type of generated documentation
+const MarkdownContentType = "text/markdown; charset=utf-8"
+
+// Document is the documentation of one detection rule
+type Document struct {
+    Title       string   `json:"title"`
+    Format      string   `json:"format"`
+    Description string   `json:"description,omitempty"`
+    Author      string   `json:"author,omitempty"`
+    Status      string   `json:"status,omitempty"`
+    Level       string   `json:"level,omitempty"`
+    References  []string `json:"references,omitempty"`
+    // Logic describes in plain English what the rule matches, one step per
+    // entry
+    Logic []string `json:"logic"`
+    // DataSources are the log sources, indexes or tables the rule reads
+    DataSources []string `json:"data_sources,omitempty"`
+    // Fields are the event fields the data source must provide
+    Fields     []string       `json:"fields,omitempty"`
+    Techniques []TechniqueRef `json:"techniques,omitempty"`
+    Tactics    []string       `json:"tactics,omitempty"`
+    // Tuning describes the exclusions the rule applies
+    Tuning         []string `json:"tuning,omitempty"`
+    FalsePositives []string `json:"false_positives,omitempty"`
+    Content        string   `json:"content"`
+}
+
+// TechniqueRef is an ATT&CK technique the rule is mapped to. Name is empty
+// when no ATT&CK dataset is loaded or the ID is unknown.
+type TechniqueRef struct {
+    ID   string `json:"id"`
+    Name string `json:"name,omitempty"`
+}
+
+// ruleMetadata are the detection metadata keys that override what is read
+// from the rule content
+type ruleMetadata struct {
+    Name        string `json:"name"`
+    Description string `json:"description"`
+    Author      string `json:"author"`
+}
+
+// Generate documents detection. Technique and tactic names are resolved
+// against attack, which may be nil.
+func Generate(detection *models.Detection, attack *mitre.Dataset) (*Document, error) {
+    if detection == nil || strings.TrimSpace(detection.Content) == "" {
+        return nil, fmt.Errorf("detection content is required")
+    }
+    format := strings.ToLower(detection.Format)
+
+    doc := &Document{Format: format, Content: detection.Content}
+    var err error
+    switch format {
+    case models.DetectionFormatSigma:
+        err = describeSigma(doc, detection.Content)
+    case models.DetectionFormatSplunk:
+        describeSplunk(doc, detection.Content)
+    case models.DetectionFormatKQL:
+        describeKQL(doc, detection.Content)
+    case models.DetectionFormatSentinel:
+        err = describeSentinel(doc, detection.Content)
+    case models.DetectionFormatYara:
+        err = describeYara(doc, detection.Content)
+    default:
+        describeGeneric(doc, detection.Content)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if len(doc.Fields) == 0 {
+        doc.Fields = validation.ExtractFieldNames(&models.Detection{Content: detection.Content, Format: format})
+    }
+    applyMetadata(doc, detection.Metadata)
+    if doc.Title == "" {
+        doc.Title = "Untitled " + format + " rule"
+    }
+    mapAttack(doc, attack)
+    return doc, nil
+}
+
+// applyMetadata lets the detection metadata name, describe and attribute the
+// rule
+func applyMetadata(doc *Document, raw json.RawMessage) {
+    if len(raw) == 0 {
+        return
+    }
+    var meta ruleMetadata
+    if err := json.Unmarshal(raw, &meta); err != nil {
+        return
+    }
+    if meta.Name != "" {
+        doc.Title = meta.Name
+    }
+    if meta.Description != "" {
+        doc.Description = meta.Description
+    }
+    if meta.Author != "" {
+        doc.Author = meta.Author
+    }
+}
+
+// mapAttack fills in the ATT&CK techniques and tactics referenced by the
+// rule, named after the dataset entries
+func mapAttack(doc *Document, attack *mitre.Dataset) {
+    refs := mitre.ExtractReferences(doc.Content + "\n" + strings.Join(doc.Tactics, "\n"))
+    doc.Techniques = nil
+    for _, id := range refs.Techniques {
+        ref := TechniqueRef{ID: id}
+        if attack != nil {
+            if technique, ok := attack.Technique(id); ok {
+                ref.Name = technique.Name
+            }
+        }
+        doc.Techniques = append(doc.Techniques, ref)
+    }
+
+    tactics := append([]string(nil), doc.Tactics...)
+    tactics = append(tactics, refs.Tactics...)
+    doc.Tactics = nil
+    seen := make(map[string]bool)
+    for _, ref := range tactics {
+        name := ref
+        if attack != nil {
+            if tactic, ok := attack.Tactic(ref); ok {
+                name = fmt.Sprintf("%s (%s)", tactic.Name, tactic.ID)
+            }
+        }
+        if !seen[name] {
+            seen[name] = true
+            doc.Tactics = append(doc.Tactics, name)
+        }
+    }
+}
+
+// Markdown renders the document as a catalog page
+func (d *Document) Markdown() string {
+    var b strings.Builder
+    fmt.Fprintf(&b, "# %s\n\n", d.Title)
+
+    details := []string{"**Format:** " + d.Format}
+    if d.Status != "" {
+        details = append(details, "**Status:** "+d.Status)
+    }
+    if d.Level != "" {
+        details = append(details, "**Level:** "+d.Level)
+    }
+    if d.Author != "" {
+        details = append(details, "**Author:** "+d.Author)
+    }
+    b.WriteString(strings.Join(details, " | "))
+    b.WriteString("\n\n")
+
+    b.WriteString("## Purpose\n\n")
+    if d.Description != "" {
+        b.WriteString(d.Description)
+    } else {
+        b.WriteString("_No description provided._")
+    }
+    b.WriteString("\n\n")
+
+    b.WriteString("## Logic\n\n")
+    writeList(&b, d.Logic, "_The rule logic could not be summarized._")
+
+    b.WriteString("## Data Requirements\n\n")
+    if len(d.DataSources) == 0 && len(d.Fields) == 0 {
+        b.WriteString("_No data requirements could be determined._\n\n")
+    }
+    if len(d.DataSources) > 0 {
+        b.WriteString("**Data sources:**\n\n")
+        writeList(&b, d.DataSources, "")
+    }
+    if len(d.Fields) > 0 {
+        b.WriteString("**Fields:**\n\n")
+        fields := make([]string, len(d.Fields))
+        for i, field := range d.Fields {
+            fields[i] = "`" + field + "`"
+        }
+        writeList(&b, fields, "")
+    }
+
+    b.WriteString("## MITRE ATT&CK\n\n")
+    if len(d.Techniques) == 0 && len(d.Tactics) == 0 {
+        b.WriteString("_The rule is not mapped to ATT&CK._\n\n")
+    }
+    if len(d.Tactics) > 0 {
+        fmt.Fprintf(&b, "**Tactics:** %s\n\n", strings.Join(d.Tactics, ", "))
+    }
+    if len(d.Techniques) > 0 {
+        b.WriteString("| Technique | Name |\n|---|---|\n")
+        for _, t := range d.Techniques {
+            name := t.Name
+            if name == "" {
+                name = "-"
+            }
+            fmt.Fprintf(&b, "| %s | %s |\n", t.ID, name)
+        }
+        b.WriteString("\n")
+    }
+
+    b.WriteString("## Tuning Notes\n\n")
+    writeList(&b, d.Tuning, "_The rule defines no exclusions._")
+    if len(d.FalsePositives) > 0 {
+        b.WriteString("**Known false positives:**\n\n")
+        writeList(&b, d.FalsePositives, "")
+    }
+
+    if len(d.References) > 0 {
+        b.WriteString("## References\n\n")
+        writeList(&b, d.References, "")
+    }
+
+    fence := "```"
+    for strings.Contains(d.Content, fence) {
+        fence += "`"
+    }
+    fmt.Fprintf(&b, "## Rule\n\n%s%s\n%s\n%s\n", fence, codeLanguage(d.Format), strings.TrimRight(d.Content, "\n"), fence)
+    return b.String()
+}
+
+// writeList writes items as a bullet list, or empty when there are none
+func writeList(b *strings.Builder, items []string, empty string) {
+    if len(items) == 0 {
+        if empty != "" {
+            b.WriteString(empty)
+            b.WriteString("\n\n")
+        }
+        return
+    }
+    for _, item := range items {
+        fmt.Fprintf(b, "- %s\n", item)
+    }
+    b.WriteString("\n")
+}
+
+// codeLanguage returns the fenced code block language of a format
+func codeLanguage(format string) string {
+    switch format {
+    case models.DetectionFormatSigma, models.DetectionFormatSentinel:
+        return "yaml"
+    case models.DetectionFormatSplunk:
+        return "spl"
+    case models.DetectionFormatKQL:
+        return "kql"
+    case models.DetectionFormatYara:
+        return "yara"
+    default:
+        return ""
+    }
+}
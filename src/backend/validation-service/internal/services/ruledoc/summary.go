@@ -0,0 +1,208 @@
+package ruledoc
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+
+    "gopkg.in/yaml.v3" // v3.0.1
+
+    "validation-service/internal/models"
+)
+
+var (
+    // numericComparisonRegex matches comparisons of a field with a number,
+    // such as `count > 5`
+    numericComparisonRegex = regexp.MustCompile(`\b([A-Za-z_][\w.()]*)\s*(>=|<=|==|!=|>|<|=)\s*(\d+(?:\.\d+)?)\b`)
+    // sigmaAggregationRegex matches legacy Sigma aggregation conditions
+    sigmaAggregationRegex = regexp.MustCompile(`\|\s*(\w+\([^)]*\)(?:\s+by\s+\S+)?\s*(?:>=|<=|==|>|<|=)\s*\d+)`)
+    // sigmaCorrelationRegex matches the threshold of a Sigma correlation rule
+    sigmaCorrelationRegex = regexp.MustCompile(`(?m)^\s+(gte|gt|lte|lt|eq)\s*:\s*(\d+)`)
+    // splunkTimeRegex matches SPL time ranges and bucket spans
+    splunkTimeRegex = regexp.MustCompile(`(?i)\b(earliest|latest|span)\s*=\s*("[^"]*"|[^\s|)]+)`)
+    // kqlAgoRegex matches KQL lookbacks such as ago(1h)
+    kqlAgoRegex = regexp.MustCompile(`\bago\(\s*([^)]+?)\s*\)`)
+    // kqlBinRegex matches KQL time buckets such as bin(TimeGenerated, 5m)
+    kqlBinRegex = regexp.MustCompile(`\bbin\(\s*[^,]+,\s*([^)]+?)\s*\)`)
+)
+
+// sigmaOperatorPhrases describe Sigma correlation threshold operators
+var sigmaOperatorPhrases = map[string]string{
+    "gte": ">=",
+    "gt":  ">",
+    "lte": "<=",
+    "lt":  "<",
+    "eq":  "==",
+}
+
+// Summarize describes in plain English what detection does: the fields it
+// reads, its conditions and exclusions, its thresholds and its time window.
+// It returns nil when the detection cannot be parsed.
+func Summarize(detection *models.Detection) *models.RuleSummary {
+    if detection == nil {
+        return nil
+    }
+    doc, err := Generate(detection, nil)
+    if err != nil {
+        return nil
+    }
+    summary := &models.RuleSummary{
+        Fields:     doc.Fields,
+        Conditions: doc.Logic,
+        Exclusions: doc.Tuning,
+    }
+
+    switch doc.Format {
+    case models.DetectionFormatSigma:
+        summarizeSigmaThresholds(summary, doc.Content)
+    case models.DetectionFormatSplunk:
+        summarizePipelineThresholds(summary, doc.Content, "stats", "tstats", "eventstats", "streamstats")
+        for _, match := range splunkTimeRegex.FindAllStringSubmatch(doc.Content, -1) {
+            summary.TimeWindow = appendUnique(summary.TimeWindow, describeSplunkTime(strings.ToLower(match[1]), strings.Trim(match[2], `"`)))
+        }
+    case models.DetectionFormatKQL:
+        summarizeKQLTime(summary, doc.Content)
+    case models.DetectionFormatSentinel:
+        summarizeSentinel(summary, doc.Content)
+    }
+
+    summary.Text = summaryText(doc, summary)
+    return summary
+}
+
+// summaryText joins the parts of a summary into one paragraph
+func summaryText(doc *Document, summary *models.RuleSummary) string {
+    var parts []string
+    if doc.Title != "" && !strings.HasPrefix(doc.Title, "Untitled ") {
+        parts = append(parts, fmt.Sprintf("%q (%s).", doc.Title, doc.Format))
+    }
+    parts = append(parts, doc.Logic...)
+    parts = append(parts, doc.Tuning...)
+    if len(summary.Thresholds) > 0 {
+        parts = append(parts, fmt.Sprintf("It only fires when %s.", strings.Join(summary.Thresholds, " and ")))
+    }
+    if len(summary.TimeWindow) > 0 {
+        parts = append(parts, fmt.Sprintf("It covers %s.", strings.Join(summary.TimeWindow, ", ")))
+    }
+    if len(summary.Fields) > 0 {
+        parts = append(parts, fmt.Sprintf("It reads the fields %s.", strings.Join(summary.Fields, ", ")))
+    }
+    return strings.Join(parts, " ")
+}
+
+// summarizeSigmaThresholds reads the timeframe and the aggregation or
+// correlation threshold of a Sigma rule
+func summarizeSigmaThresholds(summary *models.RuleSummary, content string) {
+    var rule struct {
+        Detection struct {
+            Condition interface{} `yaml:"condition"`
+            Timeframe string      `yaml:"timeframe"`
+        } `yaml:"detection"`
+        Correlation struct {
+            Timespan string `yaml:"timespan"`
+        } `yaml:"correlation"`
+    }
+    if err := yaml.Unmarshal([]byte(content), &rule); err != nil {
+        return
+    }
+    if condition, ok := rule.Detection.Condition.(string); ok {
+        for _, match := range sigmaAggregationRegex.FindAllStringSubmatch(condition, -1) {
+            summary.Thresholds = append(summary.Thresholds, "`"+match[1]+"`")
+        }
+    }
+    if rule.Correlation.Timespan != "" {
+        for _, match := range sigmaCorrelationRegex.FindAllStringSubmatch(content, -1) {
+            summary.Thresholds = append(summary.Thresholds, fmt.Sprintf("the correlated count is %s %s", sigmaOperatorPhrases[match[1]], match[2]))
+        }
+    }
+    for _, window := range []string{rule.Detection.Timeframe, rule.Correlation.Timespan} {
+        if window != "" {
+            summary.TimeWindow = append(summary.TimeWindow, "a window of "+window)
+        }
+    }
+}
+
+// summarizePipelineThresholds reads the numeric comparisons that follow an
+// aggregation command in an SPL or KQL pipeline
+func summarizePipelineThresholds(summary *models.RuleSummary, query string, aggregations ...string) {
+    aggregated := false
+    for _, stage := range splitPipeline(query) {
+        command := strings.ToLower(strings.SplitN(stage, " ", 2)[0])
+        for _, aggregation := range aggregations {
+            if command == aggregation {
+                aggregated = true
+            }
+        }
+        if !aggregated || (command != "where" && command != "search" && command != "filter") {
+            continue
+        }
+        for _, match := range numericComparisonRegex.FindAllStringSubmatch(stage, -1) {
+            summary.Thresholds = append(summary.Thresholds, fmt.Sprintf("`%s %s %s`", match[1], match[2], match[3]))
+        }
+    }
+}
+
+// describeSplunkTime describes an earliest, latest or span setting
+func describeSplunkTime(setting, value string) string {
+    switch setting {
+    case "earliest":
+        return "events since " + value
+    case "latest":
+        return "events until " + value
+    default:
+        return "buckets of " + value
+    }
+}
+
+// summarizeKQLTime reads the thresholds, lookbacks and time buckets of a
+// KQL query
+func summarizeKQLTime(summary *models.RuleSummary, query string) {
+    summarizePipelineThresholds(summary, query, "summarize", "make-series")
+    for _, match := range kqlAgoRegex.FindAllStringSubmatch(query, -1) {
+        summary.TimeWindow = appendUnique(summary.TimeWindow, "the last "+match[1])
+    }
+    for _, match := range kqlBinRegex.FindAllStringSubmatch(query, -1) {
+        summary.TimeWindow = appendUnique(summary.TimeWindow, "buckets of "+match[1])
+    }
+}
+
+// summarizeSentinel reads the query schedule and alert threshold of a
+// Sentinel analytics rule
+func summarizeSentinel(summary *models.RuleSummary, content string) {
+    var rule struct {
+        Query            string `yaml:"query"`
+        QueryFrequency   string `yaml:"queryFrequency"`
+        QueryPeriod      string `yaml:"queryPeriod"`
+        TriggerOperator  string `yaml:"triggerOperator"`
+        TriggerThreshold *int   `yaml:"triggerThreshold"`
+    }
+    if err := yaml.Unmarshal([]byte(content), &rule); err != nil {
+        return
+    }
+    summarizeKQLTime(summary, rule.Query)
+    if rule.QueryPeriod != "" {
+        summary.TimeWindow = append(summary.TimeWindow, "a lookback of "+rule.QueryPeriod)
+    }
+    if rule.QueryFrequency != "" {
+        summary.TimeWindow = append(summary.TimeWindow, "a run every "+rule.QueryFrequency)
+    }
+    if rule.TriggerThreshold != nil && rule.TriggerOperator != "" {
+        summary.Thresholds = append(summary.Thresholds, fmt.Sprintf("the query returns %s %d results", sentinelTriggerPhrase(rule.TriggerOperator), *rule.TriggerThreshold))
+    }
+}
+
+// sentinelTriggerPhrase describes a Sentinel trigger operator
+func sentinelTriggerPhrase(operator string) string {
+    switch strings.ToLower(operator) {
+    case "gt":
+        return "more than"
+    case "lt":
+        return "fewer than"
+    case "eq":
+        return "exactly"
+    case "ne":
+        return "other than"
+    default:
+        return operator
+    }
+}
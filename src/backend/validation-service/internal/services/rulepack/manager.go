@@ -0,0 +1,180 @@
+package rulepack
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+)
+
+// packExtension is the extension of the pack files the manager writes; .yml
+// files are read as well
+const packExtension = ".yaml"
+
+// ErrPackNotFound is returned when removing a pack that is not installed
+var ErrPackNotFound = errors.New("rule pack not found")
+
+// LoadDir reads the rule packs of the .yaml and .yml files in dir. Each file
+// holds one pack named after the file. Hidden files are skipped.
+func LoadDir(dir string) ([]*Pack, error) {
+    entries, err := os.ReadDir(dir)
+    if err != nil {
+        return nil, fmt.Errorf("reading rule pack directory: %w", err)
+    }
+    var packs []*Pack
+    for _, entry := range entries {
+        name := entry.Name()
+        ext := filepath.Ext(name)
+        if entry.IsDir() || strings.HasPrefix(name, ".") || (ext != ".yaml" && ext != ".yml") {
+            continue
+        }
+        data, err := os.ReadFile(filepath.Join(dir, name))
+        if err != nil {
+            return nil, fmt.Errorf("reading rule pack %s: %w", name, err)
+        }
+        pack, err := Parse(data)
+        if err != nil {
+            return nil, fmt.Errorf("%s: %w", name, err)
+        }
+        if pack.Name != strings.TrimSuffix(name, ext) {
+            return nil, fmt.Errorf("%w: %s: file of pack %s must be named %s%s", ErrInvalidPack, name, pack.Name, pack.Name, packExtension)
+        }
+        packs = append(packs, pack)
+    }
+    return packs, nil
+}
+
+// Manager loads the rule packs of a directory and applies them whenever
+// they change. Packs are installed and removed through the directory, so
+// they survive restarts.
+type Manager struct {
+    mu    sync.Mutex
+    dir   string
+    apply func(*Set)
+    set   *Set
+}
+
+// NewManager creates a manager of the packs in dir, calling apply with the
+// packs in effect after each change
+func NewManager(dir string, apply func(*Set)) *Manager {
+    return &Manager{dir: dir, apply: apply}
+}
+
+// Reload reads the packs of the directory again. The packs in effect are
+// kept when a pack is invalid.
+func (m *Manager) Reload() error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    packs, err := LoadDir(m.dir)
+    if err != nil {
+        return err
+    }
+    return m.use(packs)
+}
+
+// Install validates the pack in data and writes it to the directory,
+// replacing the pack of the same name
+func (m *Manager) Install(name string, data []byte) error {
+    pack, err := Parse(data)
+    if err != nil {
+        return err
+    }
+    if pack.Name != name {
+        return fmt.Errorf("%w: pack is named %s, not %s", ErrInvalidPack, pack.Name, name)
+    }
+
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    packs, err := LoadDir(m.dir)
+    if err != nil {
+        return err
+    }
+    kept := packs[:0]
+    for _, p := range packs {
+        if p.Name != name {
+            kept = append(kept, p)
+        }
+    }
+    packs = append(kept, pack)
+    if _, err := NewSet(packs); err != nil {
+        return err
+    }
+
+    // Write through a hidden temporary file, which LoadDir skips, so a
+    // crash leaves no partial pack behind
+    tmp := filepath.Join(m.dir, "."+name+packExtension+".tmp")
+    if err := os.WriteFile(tmp, data, 0o644); err != nil {
+        return fmt.Errorf("writing rule pack %s: %w", name, err)
+    }
+    if err := os.Rename(tmp, filepath.Join(m.dir, name+packExtension)); err != nil {
+        os.Remove(tmp)
+        return fmt.Errorf("writing rule pack %s: %w", name, err)
+    }
+    if err := os.Remove(filepath.Join(m.dir, name+".yml")); err != nil && !errors.Is(err, os.ErrNotExist) {
+        return fmt.Errorf("replacing rule pack %s: %w", name, err)
+    }
+    return m.use(packs)
+}
+
+// Remove deletes the pack from the directory
+func (m *Manager) Remove(name string) error {
+    if !packNamePattern.MatchString(name) {
+        return ErrPackNotFound
+    }
+
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    removed := false
+    for _, ext := range []string{".yml", packExtension} {
+        err := os.Remove(filepath.Join(m.dir, name+ext))
+        if err == nil {
+            removed = true
+        } else if !errors.Is(err, os.ErrNotExist) {
+            return fmt.Errorf("removing rule pack %s: %w", name, err)
+        }
+    }
+    if !removed {
+        return ErrPackNotFound
+    }
+    packs, err := LoadDir(m.dir)
+    if err != nil {
+        return err
+    }
+    return m.use(packs)
+}
+
+// Packs returns the packs in effect, ordered by name
+func (m *Manager) Packs() []*Pack {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    return m.set.Packs()
+}
+
+// use puts packs into effect
+func (m *Manager) use(packs []*Pack) error {
+    set, err := NewSet(packs)
+    if err != nil {
+        return err
+    }
+    m.set = set
+    if m.apply != nil {
+        m.apply(set)
+    }
+    return nil
+}
+
+// Names returns the names of packs, sorted
+func Names(packs []*Pack) []string {
+    names := make([]string, 0, len(packs))
+    for _, pack := range packs {
+        names = append(names, pack.Name)
+    }
+    sort.Strings(names)
+    return names
+}
@@ -0,0 +1,394 @@
+// Package rulepack evaluates organization-specific validation rules, such as
+// required meta fields, banned commands and naming conventions, declared in
+// YAML rule packs. Packs check a format-neutral view of the parsed detection,
+// so one rule can apply to every format.
+package rulepack
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "regexp"
+    "sort"
+    "strings"
+
+    "github.com/prometheus/client_golang/prometheus"          // v1.17.0
+    "github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+    "gopkg.in/yaml.v3"                                        // v3.0.1
+
+    "validation-service/internal/models"
+)
+
+// maxViolationsPerRule bounds the violations one rule reports for a detection
+const maxViolationsPerRule = 20
+
+// ErrInvalidPack is returned for rule packs that cannot be parsed or
+// conflict with other packs
+var ErrInvalidPack = errors.New("invalid rule pack")
+
+var (
+    packNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+    ruleIDPattern   = regexp.MustCompile(`^[A-Z][A-Z0-9_-]{1,31}$`)
+)
+
+var violations = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name:        "rule_pack_violations_total",
+    Help:        "Violations of rule pack rules reported by validations, by pack",
+    ConstLabels: prometheus.Labels{"service": "validation"},
+}, []string{"pack"})
+
+// Pack is a named set of validation rules
+type Pack struct {
+    Name        string `yaml:"name" json:"name"`
+    Description string `yaml:"description,omitempty" json:"description,omitempty"`
+    // Formats restricts every rule of the pack to detection formats; every
+    // format when empty
+    Formats []string `yaml:"formats,omitempty" json:"formats,omitempty"`
+    Rules   []*Rule  `yaml:"rules" json:"rules"`
+}
+
+// Rule is a check of a rule pack. A rule may combine several checks, each
+// reporting its violations under the rule's ID.
+type Rule struct {
+    // ID is the issue code of the rule's violations
+    ID          string `yaml:"id" json:"id"`
+    Description string `yaml:"description,omitempty" json:"description,omitempty"`
+    // Severity of the violations: high, medium (the default) or low
+    Severity    string `yaml:"severity,omitempty" json:"severity"`
+    Remediation string `yaml:"remediation,omitempty" json:"remediation,omitempty"`
+    // Formats restricts the rule to detection formats; the pack's formats
+    // when empty
+    Formats []string `yaml:"formats,omitempty" json:"formats,omitempty"`
+
+    // RequireMeta are meta fields every rule of the detection must declare
+    RequireMeta []string `yaml:"require_meta,omitempty" json:"require_meta,omitempty"`
+    // MetaPatterns are patterns the declared values of meta fields must match
+    MetaPatterns map[string]string `yaml:"meta_patterns,omitempty" json:"meta_patterns,omitempty"`
+    // BannedCommands are pipeline commands or operators the query must not use
+    BannedCommands []string `yaml:"banned_commands,omitempty" json:"banned_commands,omitempty"`
+    // NamePattern is the naming convention of detection names
+    NamePattern string `yaml:"name_pattern,omitempty" json:"name_pattern,omitempty"`
+    // ForbiddenPattern must not match the content
+    ForbiddenPattern string `yaml:"forbidden_pattern,omitempty" json:"forbidden_pattern,omitempty"`
+    // RequiredPattern must match the content
+    RequiredPattern string `yaml:"required_pattern,omitempty" json:"required_pattern,omitempty"`
+
+    metaPatterns map[string]*regexp.Regexp
+    banned       map[string]bool
+    namePattern  *regexp.Regexp
+    forbidden    *regexp.Regexp
+    required     *regexp.Regexp
+}
+
+// Document is the view of a parsed detection the rules check
+type Document struct {
+    Format  string
+    Content string
+    // Name is the declared name of the detection; empty when it has none
+    Name string
+    // Meta are the meta fields of each rule of the detection
+    Meta []map[string]interface{}
+    // Commands are the lower-cased pipeline commands or operators of the
+    // query in source order, including those of subsearches
+    Commands []string
+}
+
+// Violation is a rule a detection does not comply with
+type Violation struct {
+    Pack        string
+    Rule        string
+    Severity    string
+    Message     string
+    Location    string
+    Remediation string
+}
+
+// Parse reads a rule pack from YAML. Unknown keys are rejected, so a
+// misspelled check is not silently ignored.
+func Parse(data []byte) (*Pack, error) {
+    decoder := yaml.NewDecoder(bytes.NewReader(data))
+    decoder.KnownFields(true)
+    var pack Pack
+    if err := decoder.Decode(&pack); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidPack, err)
+    }
+    if err := pack.compile(); err != nil {
+        return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPack, pack.Name, err)
+    }
+    return &pack, nil
+}
+
+// compile checks the pack and compiles the patterns of its rules
+func (p *Pack) compile() error {
+    if !packNamePattern.MatchString(p.Name) {
+        return fmt.Errorf("name %q must be 1-64 lowercase letters, digits, '-' or '_'", p.Name)
+    }
+    if len(p.Rules) == 0 {
+        return errors.New("pack has no rules")
+    }
+    ids := make(map[string]bool, len(p.Rules))
+    for _, rule := range p.Rules {
+        if rule == nil {
+            return errors.New("empty rule")
+        }
+        if !ruleIDPattern.MatchString(rule.ID) {
+            return fmt.Errorf("rule ID %q must be 2-32 uppercase letters, digits, '-' or '_'", rule.ID)
+        }
+        if ids[rule.ID] {
+            return fmt.Errorf("rule %s is declared more than once", rule.ID)
+        }
+        ids[rule.ID] = true
+        if len(rule.Formats) == 0 {
+            rule.Formats = p.Formats
+        }
+        if err := rule.compile(); err != nil {
+            return fmt.Errorf("rule %s: %v", rule.ID, err)
+        }
+    }
+    return nil
+}
+
+// compile checks the rule and compiles its patterns
+func (r *Rule) compile() error {
+    switch r.Severity {
+    case "":
+        r.Severity = models.ValidationSeverityMedium
+    case models.ValidationSeverityHigh, models.ValidationSeverityMedium, models.ValidationSeverityLow:
+    default:
+        return fmt.Errorf("invalid severity %q", r.Severity)
+    }
+    if len(r.RequireMeta) == 0 && len(r.MetaPatterns) == 0 && len(r.BannedCommands) == 0 &&
+        r.NamePattern == "" && r.ForbiddenPattern == "" && r.RequiredPattern == "" {
+        return errors.New("rule has no check")
+    }
+
+    var err error
+    r.metaPatterns = make(map[string]*regexp.Regexp, len(r.MetaPatterns))
+    for field, pattern := range r.MetaPatterns {
+        if r.metaPatterns[field], err = regexp.Compile(pattern); err != nil {
+            return fmt.Errorf("meta pattern of %s: %v", field, err)
+        }
+    }
+    r.banned = make(map[string]bool, len(r.BannedCommands))
+    for _, command := range r.BannedCommands {
+        r.banned[strings.ToLower(strings.TrimSpace(command))] = true
+    }
+    for _, p := range []struct {
+        pattern string
+        re      **regexp.Regexp
+        name    string
+    }{
+        {r.NamePattern, &r.namePattern, "name pattern"},
+        {r.ForbiddenPattern, &r.forbidden, "forbidden pattern"},
+        {r.RequiredPattern, &r.required, "required pattern"},
+    } {
+        if p.pattern == "" {
+            continue
+        }
+        if *p.re, err = regexp.Compile(p.pattern); err != nil {
+            return fmt.Errorf("%s: %v", p.name, err)
+        }
+    }
+    return nil
+}
+
+// appliesTo reports whether the rule checks detections of format
+func (r *Rule) appliesTo(format string) bool {
+    if len(r.Formats) == 0 {
+        return true
+    }
+    for _, f := range r.Formats {
+        if f == format {
+            return true
+        }
+    }
+    return false
+}
+
+// Set is the rule packs in effect
+type Set struct {
+    packs  []*Pack
+    digest string
+}
+
+// NewSet combines rule packs, ordered by name. Pack names and rule IDs must
+// be unique across the packs.
+func NewSet(packs []*Pack) (*Set, error) {
+    sorted := append([]*Pack(nil), packs...)
+    sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+    names := make(map[string]bool, len(sorted))
+    owners := make(map[string]string)
+    for _, pack := range sorted {
+        if names[pack.Name] {
+            return nil, fmt.Errorf("%w: pack %s is declared more than once", ErrInvalidPack, pack.Name)
+        }
+        names[pack.Name] = true
+        for _, rule := range pack.Rules {
+            if owner, ok := owners[rule.ID]; ok {
+                return nil, fmt.Errorf("%w: rule %s is declared by packs %s and %s", ErrInvalidPack, rule.ID, owner, pack.Name)
+            }
+            owners[rule.ID] = pack.Name
+        }
+    }
+
+    data, err := json.Marshal(sorted)
+    if err != nil {
+        return nil, err
+    }
+    sum := sha256.Sum256(data)
+    return &Set{packs: sorted, digest: hex.EncodeToString(sum[:])}, nil
+}
+
+// Packs returns the packs of the set, ordered by name
+func (s *Set) Packs() []*Pack {
+    if s == nil {
+        return []*Pack{}
+    }
+    return append([]*Pack(nil), s.packs...)
+}
+
+// Digest identifies the content of the set, so results validated with other
+// packs are told apart
+func (s *Set) Digest() string {
+    if s == nil {
+        return ""
+    }
+    return s.digest
+}
+
+// Evaluate checks doc against every rule of the set that applies to its
+// format
+func (s *Set) Evaluate(doc *Document) []Violation {
+    if s == nil {
+        return nil
+    }
+    var all []Violation
+    for _, pack := range s.packs {
+        count := 0
+        for _, rule := range pack.Rules {
+            if !rule.appliesTo(doc.Format) {
+                continue
+            }
+            found := rule.check(doc)
+            if len(found) > maxViolationsPerRule {
+                found = found[:maxViolationsPerRule]
+            }
+            for _, v := range found {
+                v.Pack = pack.Name
+                v.Rule = rule.ID
+                v.Severity = rule.Severity
+                v.Remediation = rule.Remediation
+                v.Message = rule.message(pack, v.Message)
+                all = append(all, v)
+            }
+            count += len(found)
+        }
+        if count > 0 {
+            violations.WithLabelValues(pack.Name).Add(float64(count))
+        }
+    }
+    return all
+}
+
+// message prefixes detail with the rule's description, or its pack and ID
+func (r *Rule) message(pack *Pack, detail string) string {
+    if r.Description != "" {
+        return r.Description + ": " + detail
+    }
+    return fmt.Sprintf("Rule %s of rule pack %s: %s", r.ID, pack.Name, detail)
+}
+
+// check runs every check of the rule on doc
+func (r *Rule) check(doc *Document) []Violation {
+    var found []Violation
+    for _, field := range r.RequireMeta {
+        if !declared(doc.Meta, field) {
+            found = append(found, Violation{
+                Message:  "missing meta field " + field,
+                Location: "meta." + field,
+            })
+        }
+    }
+    for _, field := range sortedKeys(r.metaPatterns) {
+        pattern := r.metaPatterns[field]
+        for _, meta := range doc.Meta {
+            if !hasValue(meta[field]) {
+                continue
+            }
+            if value := fmt.Sprint(meta[field]); !pattern.MatchString(value) {
+                found = append(found, Violation{
+                    Message:  fmt.Sprintf("meta field %s value %q does not match %s", field, value, pattern),
+                    Location: "meta." + field,
+                })
+            }
+        }
+    }
+    for i, command := range doc.Commands {
+        if r.banned[command] {
+            found = append(found, Violation{
+                Message:  "banned command " + command,
+                Location: fmt.Sprintf("command:%d", i+1),
+            })
+        }
+    }
+    if r.namePattern != nil && doc.Name != "" && !r.namePattern.MatchString(doc.Name) {
+        found = append(found, Violation{
+            Message:  fmt.Sprintf("name %q does not match %s", doc.Name, r.namePattern),
+            Location: "name",
+        })
+    }
+    if r.forbidden != nil {
+        for _, match := range r.forbidden.FindAllStringIndex(doc.Content, maxViolationsPerRule) {
+            found = append(found, Violation{
+                Message:  fmt.Sprintf("content matches forbidden pattern %s", r.forbidden),
+                Location: fmt.Sprintf("line:%d", strings.Count(doc.Content[:match[0]], "\n")+1),
+            })
+        }
+    }
+    if r.required != nil && !r.required.MatchString(doc.Content) {
+        found = append(found, Violation{
+            Message:  fmt.Sprintf("content does not match required pattern %s", r.required),
+            Location: "content",
+        })
+    }
+    return found
+}
+
+// declared reports whether every rule of a detection declares field with a
+// value
+func declared(meta []map[string]interface{}, field string) bool {
+    if len(meta) == 0 {
+        return false
+    }
+    for _, m := range meta {
+        if !hasValue(m[field]) {
+            return false
+        }
+    }
+    return true
+}
+
+// hasValue reports whether a meta value is set; empty strings are not
+func hasValue(value interface{}) bool {
+    switch v := value.(type) {
+    case nil:
+        return false
+    case string:
+        return strings.TrimSpace(v) != ""
+    }
+    return true
+}
+
+// sortedKeys returns the keys of m, sorted
+func sortedKeys(m map[string]*regexp.Regexp) []string {
+    keys := make([]string, 0, len(m))
+    for key := range m {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    return keys
+}
@@ -0,0 +1,347 @@
+// Package scorepolicy evaluates the Rego scoring policies tenants supply to
+// decide the status and confidence score of their validations from the
+// issue list, in place of the static severity weights. Policies run in a
+// sandbox without network access and within a time limit; a policy that
+// fails leaves the static scoring in place.
+package scorepolicy
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "sync"
+    "time"
+
+    "github.com/open-policy-agent/opa/ast"                    // v0.58.0
+    "github.com/open-policy-agent/opa/rego"                   // v0.58.0
+    "github.com/prometheus/client_golang/prometheus"          // v1.17.0
+    "github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+
+    "validation-service/internal/config"
+    "validation-service/internal/models"
+    "validation-service/internal/storage"
+    "validation-service/pkg/logger"
+)
+
+// PackagePath is the package a scoring policy declares; its decision rule
+// is queried
+const PackagePath = "data.validation.scoring"
+
+const decisionQuery = PackagePath + ".decision"
+
+// Outcomes of evaluating a policy
+const (
+    outcomeApplied   = "applied"
+    outcomeUndefined = "undefined"
+    outcomeFailed    = "failed"
+    outcomeTimeout   = "timeout"
+)
+
+// unsafeBuiltins are the builtins a policy may not call, since they reach
+// the network or the runtime environment of the service
+var unsafeBuiltins = map[string]struct{}{
+    "http.send":          {},
+    "net.lookup_ip_addr": {},
+    "opa.runtime":        {},
+}
+
+var (
+    // ErrPolicyTooLarge is returned for a policy over the configured maximum
+    // size
+    ErrPolicyTooLarge = errors.New("scoring policy exceeds maximum size")
+    // ErrInvalidPolicy is returned for a policy that does not compile
+    ErrInvalidPolicy = errors.New("invalid scoring policy")
+)
+
+var policyEvaluations = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name:        "scoring_policy_evaluations_total",
+    Help:        "Evaluations of tenant scoring policies by outcome",
+    ConstLabels: prometheus.Labels{"service": "validation"},
+}, []string{"outcome"})
+
+// Decision is the document a policy's decision rule produces. Fields left
+// unset keep the status and score computed from the scoring weights.
+type Decision struct {
+    Status          *string  `json:"status"`
+    ConfidenceScore *float64 `json:"confidence_score"`
+    Reasons         []string `json:"reasons"`
+}
+
+// compiled is the policy of a tenant as last loaded from the store. An
+// empty digest means the tenant has no policy; err is set when the stored
+// policy no longer compiles.
+type compiled struct {
+    digest   string
+    query    rego.PreparedEvalQuery
+    err      error
+    loadedAt time.Time
+}
+
+// Evaluator scores validation results with the policies of their tenants.
+// Compiled policies are kept for the configured cache TTL, so a policy
+// saved on another replica is picked up within it.
+type Evaluator struct {
+    store storage.ScoringPolicyStore
+    cfg   config.ScoringConfig
+    log   *logger.Logger
+    now   func() time.Time
+
+    mu       sync.Mutex
+    policies map[string]*compiled
+}
+
+// NewEvaluator creates an evaluator of the policies in store
+func NewEvaluator(store storage.ScoringPolicyStore, cfg config.ScoringConfig) *Evaluator {
+    return &Evaluator{
+        store:    store,
+        cfg:      cfg,
+        log:      logger.GetLogger(),
+        now:      time.Now,
+        policies: make(map[string]*compiled),
+    }
+}
+
+// Digest returns the SHA-256 of a policy's source
+func Digest(module string) string {
+    sum := sha256.Sum256([]byte(module))
+    return hex.EncodeToString(sum[:])
+}
+
+// Check compiles module as a scoring policy, reporting why it cannot be
+// used
+func (e *Evaluator) Check(ctx context.Context, module string) error {
+    _, err := e.compile(ctx, module)
+    return err
+}
+
+// Invalidate drops the compiled policy of a tenant, so the next validation
+// loads it from the store
+func (e *Evaluator) Invalidate(tenantID string) {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    delete(e.policies, tenantID)
+}
+
+// PolicyDigest returns the digest of the tenant's policy, or an empty
+// string when it has none
+func (e *Evaluator) PolicyDigest(ctx context.Context, tenantID string) string {
+    policy, err := e.load(ctx, tenantID)
+    if err != nil {
+        return ""
+    }
+    return policy.digest
+}
+
+// Score evaluates the tenant's policy against result and applies its
+// decision, recording it in the result's metadata. Results of tenants
+// without a policy are left untouched, as are error results, which record
+// validator failures a policy cannot revisit.
+func (e *Evaluator) Score(ctx context.Context, tenantID string, result *models.ValidationResult) {
+    if result.Status == models.ValidationStatusError {
+        return
+    }
+    policy, err := e.load(ctx, tenantID)
+    if err != nil {
+        e.log.Warn("Failed to load scoring policy; using static scoring",
+            "error", err,
+            "tenant_id", tenantID,
+        )
+        return
+    }
+    if policy.digest == "" {
+        return
+    }
+
+    decision := &models.PolicyDecision{
+        Digest:       policy.digest,
+        StaticScore:  result.ConfidenceScore,
+        StaticStatus: result.Status,
+    }
+    result.Metadata.PolicyDecision = decision
+    if policy.err != nil {
+        decision.Error = policy.err.Error()
+        policyEvaluations.WithLabelValues(outcomeFailed).Inc()
+        return
+    }
+
+    d, err := e.evaluate(ctx, policy, result)
+    if err != nil {
+        outcome := outcomeFailed
+        if errors.Is(err, context.DeadlineExceeded) {
+            outcome = outcomeTimeout
+        }
+        policyEvaluations.WithLabelValues(outcome).Inc()
+        decision.Error = err.Error()
+        e.log.Warn("Scoring policy failed; using static scoring",
+            "error", err,
+            "tenant_id", tenantID,
+            "digest", policy.digest,
+        )
+        return
+    }
+    if d == nil {
+        policyEvaluations.WithLabelValues(outcomeUndefined).Inc()
+        return
+    }
+
+    policyEvaluations.WithLabelValues(outcomeApplied).Inc()
+    decision.Applied = true
+    decision.Reasons = d.Reasons
+    if d.Status != nil {
+        result.Status = *d.Status
+    }
+    if d.ConfidenceScore != nil {
+        result.SetConfidenceScore(*d.ConfidenceScore, "scoring_policy", "decision of tenant scoring policy "+policy.digest)
+    }
+}
+
+// load returns the tenant's compiled policy, reloading it from the store
+// once the cached copy is older than the cache TTL. A policy whose digest
+// is unchanged is not compiled again. When the store fails, a cached copy
+// is used regardless of its age.
+func (e *Evaluator) load(ctx context.Context, tenantID string) (*compiled, error) {
+    now := e.now()
+    e.mu.Lock()
+    cached, ok := e.policies[tenantID]
+    e.mu.Unlock()
+    if ok && now.Sub(cached.loadedAt) < e.cfg.PolicyCacheTTL {
+        return cached, nil
+    }
+
+    stored, err := e.store.GetScoringPolicy(ctx, tenantID)
+    var policy *compiled
+    switch {
+    case errors.Is(err, storage.ErrNotFound):
+        policy = &compiled{loadedAt: now}
+    case err != nil:
+        if ok {
+            return cached, nil
+        }
+        return nil, err
+    case ok && cached.digest == stored.Digest:
+        policy = &compiled{digest: cached.digest, query: cached.query, err: cached.err, loadedAt: now}
+    default:
+        query, err := e.compile(ctx, stored.Module)
+        policy = &compiled{digest: stored.Digest, query: query, err: err, loadedAt: now}
+    }
+
+    e.mu.Lock()
+    e.policies[tenantID] = policy
+    e.mu.Unlock()
+    return policy, nil
+}
+
+// compile parses module, checks it declares the scoring package and
+// prepares its decision query with the unsafe builtins disabled
+func (e *Evaluator) compile(ctx context.Context, module string) (rego.PreparedEvalQuery, error) {
+    if e.cfg.MaxPolicySize > 0 && len(module) > e.cfg.MaxPolicySize {
+        return rego.PreparedEvalQuery{}, ErrPolicyTooLarge
+    }
+    parsed, err := ast.ParseModule("policy.rego", module)
+    if err != nil {
+        return rego.PreparedEvalQuery{}, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+    }
+    if parsed == nil {
+        return rego.PreparedEvalQuery{}, fmt.Errorf("%w: empty policy", ErrInvalidPolicy)
+    }
+    if path := parsed.Package.Path.String(); path != PackagePath {
+        return rego.PreparedEvalQuery{}, fmt.Errorf("%w: package must be %s, not %s",
+            ErrInvalidPolicy, PackagePath[len("data."):], path[len("data."):])
+    }
+
+    query, err := rego.New(
+        rego.Query(decisionQuery),
+        rego.ParsedModule(parsed),
+        rego.UnsafeBuiltins(unsafeBuiltins),
+        rego.StrictBuiltinErrors(true),
+    ).PrepareForEval(ctx)
+    if err != nil {
+        return rego.PreparedEvalQuery{}, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+    }
+    return query, nil
+}
+
+// evaluate runs the decision query of policy against result within the
+// policy timeout. It returns nil when the decision is undefined.
+func (e *Evaluator) evaluate(ctx context.Context, policy *compiled, result *models.ValidationResult) (*Decision, error) {
+    if e.cfg.PolicyTimeout > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, e.cfg.PolicyTimeout)
+        defer cancel()
+    }
+
+    rs, err := policy.query.Eval(ctx, rego.EvalInput(policyInput(result)))
+    if err != nil {
+        if ctx.Err() != nil {
+            return nil, fmt.Errorf("evaluating scoring policy: %w", ctx.Err())
+        }
+        return nil, fmt.Errorf("evaluating scoring policy: %w", err)
+    }
+    if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+        return nil, nil
+    }
+    return decodeDecision(rs[0].Expressions[0].Value)
+}
+
+// decodeDecision converts the value of the decision rule and checks the
+// status and score it sets
+func decodeDecision(value interface{}) (*Decision, error) {
+    data, err := json.Marshal(value)
+    if err != nil {
+        return nil, fmt.Errorf("encoding decision: %w", err)
+    }
+    var d Decision
+    if err := json.Unmarshal(data, &d); err != nil {
+        return nil, fmt.Errorf("decision must be an object with status, confidence_score and reasons: %w", err)
+    }
+    if d.Status != nil {
+        switch *d.Status {
+        case models.ValidationStatusSuccess, models.ValidationStatusWarning, models.ValidationStatusError:
+        default:
+            return nil, fmt.Errorf("decision status must be success, warning or error, not %q", *d.Status)
+        }
+    }
+    if d.ConfidenceScore != nil && (*d.ConfidenceScore < 0 || *d.ConfidenceScore > 100) {
+        return nil, fmt.Errorf("decision confidence_score must be between 0 and 100, not %g", *d.ConfidenceScore)
+    }
+    return &d, nil
+}
+
+// policyInput is the input document of a policy: the issues of result with
+// the status and score the scoring weights gave it
+func policyInput(result *models.ValidationResult) map[string]interface{} {
+    issues := make([]interface{}, 0, len(result.Issues))
+    for _, issue := range result.Issues {
+        category := issue.Category
+        if category == "" {
+            category = models.IssueCategoryCorrectness
+        }
+        issues = append(issues, map[string]interface{}{
+            "issue_code": issue.IssueCode,
+            "severity":   issue.Severity,
+            "category":   category,
+            "message":    issue.Message,
+            "location":   issue.Location,
+            "line":       issue.Line,
+            "column":     issue.Column,
+        })
+    }
+
+    scoring := result.ScoringPolicy()
+    weights := make(map[string]interface{}, len(scoring.SeverityWeights))
+    for severity, weight := range scoring.SeverityWeights {
+        weights[severity] = weight
+    }
+    return map[string]interface{}{
+        "issues":           issues,
+        "status":           result.Status,
+        "confidence_score": result.ConfidenceScore,
+        "min_confidence":   scoring.MinConfidence,
+        "severity_weights": weights,
+        "source_format":    result.SourceFormat,
+        "target_format":    result.TargetFormat,
+    }
+}
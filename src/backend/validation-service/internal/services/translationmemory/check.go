@@ -0,0 +1,118 @@
+// Package translationmemory checks translations against the mappings a
+// tenant's reviewers have approved before, so that a remembered translation of
+// a source expression is not silently replaced by a different one.
+package translationmemory
+
+import (
+    "context"
+    "fmt"
+    "regexp"
+    "strings"
+
+    "validation-service/internal/models"
+    "validation-service/internal/storage"
+)
+
+// Issue codes raised against approved mappings
+const (
+    IssueCodeMappingNotApplied = "TM001"
+    IssueCodeTableMismatch     = "TM002"
+)
+
+// IssueCodes lists the issue codes raised by Check
+var IssueCodes = []string{IssueCodeMappingNotApplied, IssueCodeTableMismatch}
+
+// DetailsKey is the format-specific details entry describing the approved
+// mappings that applied to a translation
+const DetailsKey = "translation_memory"
+
+// maxMappings bounds the approved mappings compared per validation
+const maxMappings = storage.MaxSearchLimit
+
+// Details summarizes the approved mappings relevant to a translation
+type Details struct {
+    Matched  []string `json:"matched"`
+    Violated []string `json:"violated"`
+}
+
+// Check compares a translation with the approved mappings of the tenant
+// between its formats. A mapping applies when the source detection contains
+// its source expression; the target must then contain the approved target
+// expression and, when one was recorded, reference the approved table.
+// Expressions are compared ignoring case and whitespace.
+func Check(ctx context.Context, store storage.TranslationMemoryStore, tenantID string, source, target *models.Detection, result *models.ValidationResult) error {
+    if store == nil || result == nil || source == nil || target == nil {
+        return nil
+    }
+
+    mappings, _, err := store.ListMappings(ctx, storage.MappingQuery{
+        TenantID:     tenantID,
+        SourceFormat: result.SourceFormat,
+        TargetFormat: result.TargetFormat,
+        Limit:        maxMappings,
+    })
+    if err != nil {
+        return fmt.Errorf("loading approved mappings: %w", err)
+    }
+    if len(mappings) == 0 {
+        return nil
+    }
+
+    sourceContent := storage.NormalizeExpression(source.Content)
+    targetContent := storage.NormalizeExpression(target.Content)
+    details := Details{Matched: []string{}, Violated: []string{}}
+
+    for _, mapping := range mappings {
+        if !strings.Contains(sourceContent, storage.NormalizeExpression(mapping.SourceExpression)) {
+            continue
+        }
+        details.Matched = append(details.Matched, mapping.ID.String())
+
+        violated := false
+        if !strings.Contains(targetContent, storage.NormalizeExpression(mapping.TargetExpression)) {
+            violated = true
+            result.AddIssue(&models.ValidationIssue{
+                Message:     fmt.Sprintf("Translation of %q differs from the approved mapping %q", mapping.SourceExpression, mapping.TargetExpression),
+                Severity:    models.ValidationSeverityMedium,
+                Location:    "translation_memory." + mapping.ID.String(),
+                IssueCode:   IssueCodeMappingNotApplied,
+                Remediation: fmt.Sprintf("Use the approved translation %q or approve the new mapping", mapping.TargetExpression),
+                IssueMetadata: map[string]interface{}{
+                    "mapping_id":  mapping.ID.String(),
+                    "approved_by": mapping.ApprovedBy,
+                },
+            })
+        }
+        if mapping.TargetTable != "" && !referencesTable(target.Content, mapping.TargetTable) {
+            violated = true
+            result.AddIssue(&models.ValidationIssue{
+                Message:     fmt.Sprintf("Translation of %q does not query the approved table %s", mapping.SourceExpression, mapping.TargetTable),
+                Severity:    models.ValidationSeverityLow,
+                Location:    "translation_memory." + mapping.ID.String(),
+                IssueCode:   IssueCodeTableMismatch,
+                Remediation: fmt.Sprintf("Query %s as in the approved mapping", mapping.TargetTable),
+                IssueMetadata: map[string]interface{}{
+                    "mapping_id":  mapping.ID.String(),
+                    "approved_by": mapping.ApprovedBy,
+                },
+            })
+        }
+        if violated {
+            details.Violated = append(details.Violated, mapping.ID.String())
+        }
+    }
+
+    if len(details.Matched) > 0 {
+        if result.FormatSpecificDetails == nil {
+            result.FormatSpecificDetails = make(map[string]interface{})
+        }
+        result.FormatSpecificDetails[DetailsKey] = details
+    }
+    return nil
+}
+
+// referencesTable reports whether content names the table as a whole word
+func referencesTable(content, table string) bool {
+    pattern := `(?i)(^|[^\w.])` + regexp.QuoteMeta(table) + `($|[^\w])`
+    return regexp.MustCompile(pattern).MatchString(content)
+}
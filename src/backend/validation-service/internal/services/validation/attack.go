@@ -0,0 +1,79 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    "fmt"
+
+    "validation-service/internal/models"
+    "validation-service/pkg/mitre"
+)
+
+// ATT&CK issue codes raised for every format
+const (
+    issueCodeUnknownTechnique = "ATTACK001"
+    issueCodeRetiredTechnique = "ATTACK002"
+    issueCodeUnknownTactic    = "ATTACK003"
+)
+
+// Format specific detail keys set by the ATT&CK stage
+const (
+    detailAttackReferences = "attack_references"
+    detailAttackCoverage   = "attack_coverage"
+)
+
+// applyAttackCoverage records the ATT&CK references of the target detection
+// and, when a dataset is loaded, checks them against it. Unknown techniques
+// and tactics and deprecated or revoked techniques are reported as issues and
+// the resolved coverage is added to the format specific details.
+func applyAttackCoverage(dataset *mitre.Dataset, targetDetection *models.Detection, result *models.ValidationResult) error {
+    content, err := targetDetection.GetContent()
+    if err != nil {
+        return fmt.Errorf("failed to get detection content: %w", err)
+    }
+
+    refs := mitre.ExtractReferences(content)
+    result.FormatSpecificDetails[detailAttackReferences] = refs
+    if dataset == nil || refs.Empty() {
+        return nil
+    }
+
+    coverage := dataset.Coverage(refs)
+    result.FormatSpecificDetails[detailAttackCoverage] = coverage
+
+    for _, id := range coverage.UnknownTechniques {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("Unknown MITRE ATT&CK technique: %s", id),
+            Severity:    models.ValidationSeverityMedium,
+            Location:    "attack.techniques",
+            IssueCode:   issueCodeUnknownTechnique,
+            Remediation: "Reference a technique from the current ATT&CK release",
+        })
+    }
+    for _, technique := range coverage.Techniques {
+        if !technique.Retired() {
+            continue
+        }
+        remediation := "Remove the reference or map the detection to a current technique"
+        if technique.RevokedBy != "" {
+            remediation = fmt.Sprintf("Reference %s, which replaced %s", technique.RevokedBy, technique.ID)
+        }
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("MITRE ATT&CK technique %s (%s) is deprecated or revoked", technique.ID, technique.Name),
+            Severity:    models.ValidationSeverityLow,
+            Location:    "attack.techniques",
+            IssueCode:   issueCodeRetiredTechnique,
+            Remediation: remediation,
+        })
+    }
+    for _, ref := range coverage.UnknownTactics {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("Unknown MITRE ATT&CK tactic: %s", ref),
+            Severity:    models.ValidationSeverityLow,
+            Location:    "attack.tactics",
+            IssueCode:   issueCodeUnknownTactic,
+            Remediation: "Use a tactic ID such as TA0002 or a tactic name such as attack.execution",
+        })
+    }
+
+    return nil
+}
@@ -0,0 +1,216 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    "context"
+    "fmt"
+    "regexp"
+    "strings"
+
+    "gopkg.in/yaml.v3" // v3.0.1
+
+    "validation-service/internal/models"
+)
+
+// Backend options suggested by hints, named after the pySigma backend and
+// pipeline settings that produce them
+const (
+    backendOptionCaseInsensitive = "case_insensitive"
+    backendOptionIndexHint       = "index_hint"
+    backendOptionTableHint       = "table_hint"
+    backendOptionTermMatch       = "term_match"
+)
+
+var (
+    // splunkCaseSensitiveRegex matches where and eval comparisons against
+    // string literals, which SPL evaluates case-sensitively
+    splunkCaseSensitiveRegex = regexp.MustCompile(`(?i)(?:\|\s*(?:where|eval)\b[^|]*?[!=]=?\s*"[^"]*[A-Za-z][^"]*"|\blike\s*\()`)
+    // splunkIndexRegex matches an index or sourcetype constraint
+    splunkIndexRegex = regexp.MustCompile(`(?i)\b(?:index|sourcetype)\s*=`)
+    // kqlCaseSensitiveRegex matches case-sensitive KQL string operators
+    kqlCaseSensitiveRegex = regexp.MustCompile(`(?:==|!=|\b(?:has|contains|startswith|endswith|in|has_any)_cs\b)\s*\(?\s*"[^"]*[A-Za-z]`)
+    // kqlContainsRegex matches contains comparisons against string literals
+    kqlContainsRegex = regexp.MustCompile(`\bcontains\s+"([^"]*)"`)
+    // kqlUnscopedRegex matches queries searching every table
+    kqlUnscopedRegex = regexp.MustCompile(`(?i)^\s*(?:search\b|union\s+\*)`)
+    // kqlTermRegex matches values the has operator finds through the term index
+    kqlTermRegex = regexp.MustCompile(`^[A-Za-z0-9_]{3,}$`)
+)
+
+// sigmaLogsourceHints maps Sigma logsources, keyed like the logsource
+// schema as "category <name>" or "service <product>/<service>", to the
+// Splunk constraint and KQL table a backend pipeline should target
+var sigmaLogsourceHints = map[string]struct {
+    splunk string
+    kql    string
+}{
+    "category process_creation":   {`sourcetype="XmlWinEventLog:Microsoft-Windows-Sysmon/Operational" EventCode=1`, "DeviceProcessEvents"},
+    "category network_connection": {`sourcetype="XmlWinEventLog:Microsoft-Windows-Sysmon/Operational" EventCode=3`, "DeviceNetworkEvents"},
+    "category image_load":         {`sourcetype="XmlWinEventLog:Microsoft-Windows-Sysmon/Operational" EventCode=7`, "DeviceImageLoadEvents"},
+    "category file_event":         {`sourcetype="XmlWinEventLog:Microsoft-Windows-Sysmon/Operational" EventCode=11`, "DeviceFileEvents"},
+    "category registry_set":       {`sourcetype="XmlWinEventLog:Microsoft-Windows-Sysmon/Operational" EventCode=13`, "DeviceRegistryEvents"},
+    "category registry_event":     {`sourcetype="XmlWinEventLog:Microsoft-Windows-Sysmon/Operational" EventCode IN (12, 13, 14)`, "DeviceRegistryEvents"},
+    "service windows/security":    {`sourcetype="XmlWinEventLog:Security"`, "SecurityEvent"},
+    "service windows/sysmon":      {`sourcetype="XmlWinEventLog:Microsoft-Windows-Sysmon/Operational"`, "Event"},
+    "service windows/powershell":  {`sourcetype="XmlWinEventLog:Microsoft-Windows-PowerShell/Operational"`, "Event"},
+    "service azure/signinlogs":    {`sourcetype="azure:aad:signin"`, "SigninLogs"},
+    "service azure/activitylogs":  {`sourcetype="azure:monitor:activity"`, "AzureActivity"},
+}
+
+// BackendHintTargets are the target formats backend hints are produced for
+var BackendHintTargets = []string{
+    models.DetectionFormatSplunk,
+    models.DetectionFormatKQL,
+    models.DetectionFormatSentinel,
+}
+
+// SigmaBackendHintValidator suggests backend options that would improve a
+// query translated from Sigma, such as case-insensitive matching or index
+// and table hints. Its findings are optimization hints and never affect the
+// confidence score.
+type SigmaBackendHintValidator struct {
+    targetFormat string
+}
+
+// NewSigmaBackendHintValidator creates a hint validator for Sigma rules
+// translated to targetFormat, one of splunk, kql or sentinel
+func NewSigmaBackendHintValidator(targetFormat string) *SigmaBackendHintValidator {
+    return &SigmaBackendHintValidator{targetFormat: targetFormat}
+}
+
+// Validate implements the Validator interface for Sigma -> target pairs
+func (v *SigmaBackendHintValidator) Validate(ctx context.Context, sourceDetection *models.Detection, targetDetection *models.Detection, result *models.ValidationResult) error {
+    var rule map[string]interface{}
+    if err := yaml.Unmarshal([]byte(sourceDetection.Content), &rule); err != nil {
+        // The Sigma validator and field mapping checks report the source
+        return nil
+    }
+    query := targetDetection.Content
+    if v.targetFormat == models.DetectionFormatSentinel {
+        var sentinel struct {
+            Query string `yaml:"query"`
+        }
+        if err := yaml.Unmarshal([]byte(query), &sentinel); err != nil {
+            return nil
+        }
+        query = sentinel.Query
+    }
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+
+    logsource, _ := rule["logsource"].(map[string]interface{})
+    hint, hasHint := sigmaLogsourceHints[logsourceKey(logsource)]
+
+    switch v.targetFormat {
+    case models.DetectionFormatSplunk:
+        if splunkCaseSensitiveRegex.MatchString(query) {
+            result.AddOptimizationHint(&models.ValidationIssue{
+                Message:       "Sigma matches strings case-insensitively but the query compares them in where, eval or like(), which are case-sensitive in SPL",
+                Location:      "target.backend_option:" + backendOptionCaseInsensitive,
+                IssueCode:     "BHINT001",
+                Remediation:   "Keep string conditions in the base search, or have the backend wrap compared fields in lower() with lower-cased values",
+                IssueMetadata: map[string]interface{}{"backend_option": backendOptionCaseInsensitive},
+            })
+        }
+        if hasHint && !splunkIndexRegex.MatchString(query) {
+            result.AddOptimizationHint(&models.ValidationIssue{
+                Message:       "The query is not restricted to the data of the Sigma logsource; searching every index is slow",
+                Location:      "target.backend_option:" + backendOptionIndexHint,
+                IssueCode:     "BHINT002",
+                Remediation:   fmt.Sprintf("Add a processing pipeline that prefixes the query with the index and %s", hint.splunk),
+                IssueMetadata: map[string]interface{}{"backend_option": backendOptionIndexHint, "suggested_constraint": hint.splunk},
+            })
+        }
+
+    case models.DetectionFormatKQL, models.DetectionFormatSentinel:
+        if kqlCaseSensitiveRegex.MatchString(query) {
+            result.AddOptimizationHint(&models.ValidationIssue{
+                Message:       "Sigma matches strings case-insensitively but the query uses case-sensitive operators such as == or *_cs",
+                Location:      "target.backend_option:" + backendOptionCaseInsensitive,
+                IssueCode:     "BHINT001",
+                Remediation:   "Configure the backend to emit case-insensitive operators: =~, !~, in~, has and contains",
+                IssueMetadata: map[string]interface{}{"backend_option": backendOptionCaseInsensitive},
+            })
+        }
+        if hasHint && kqlUnscopedRegex.MatchString(query) {
+            result.AddOptimizationHint(&models.ValidationIssue{
+                Message:       "The query searches every table instead of the table of the Sigma logsource",
+                Location:      "target.backend_option:" + backendOptionTableHint,
+                IssueCode:     "BHINT002",
+                Remediation:   fmt.Sprintf("Add a processing pipeline that sets the query table to %s", hint.kql),
+                IssueMetadata: map[string]interface{}{"backend_option": backendOptionTableHint, "suggested_table": hint.kql},
+            })
+        }
+        if terms := kqlContainsTerms(query); len(terms) > 0 && sigmaUsesModifier(rule, "contains") {
+            result.AddOptimizationHint(&models.ValidationIssue{
+                Message:       fmt.Sprintf("contains is used for whole terms (%s), which has matches through the term index", strings.Join(terms, ", ")),
+                Location:      "target.backend_option:" + backendOptionTermMatch,
+                IssueCode:     "BHINT003",
+                Remediation:   "Configure the backend to translate contains on whole terms to has, which is much faster on large tables",
+                IssueMetadata: map[string]interface{}{"backend_option": backendOptionTermMatch, "terms": terms},
+            })
+        }
+    }
+    return nil
+}
+
+// logsourceKey returns the hint key of a Sigma logsource. The category takes
+// precedence over the product/service pair, as in the logsource schema.
+func logsourceKey(logsource map[string]interface{}) string {
+    if category, _ := logsource["category"].(string); category != "" {
+        return "category " + category
+    }
+    product, _ := logsource["product"].(string)
+    service, _ := logsource["service"].(string)
+    return "service " + product + "/" + service
+}
+
+// kqlContainsTerms returns the values of contains comparisons that are whole
+// terms and could use has instead
+func kqlContainsTerms(query string) []string {
+    var terms []string
+    for _, match := range kqlContainsRegex.FindAllStringSubmatch(query, -1) {
+        if kqlTermRegex.MatchString(match[1]) {
+            terms = append(terms, match[1])
+        }
+    }
+    return terms
+}
+
+// sigmaUsesModifier reports whether any field condition of the Sigma
+// detection section uses modifier
+func sigmaUsesModifier(rule map[string]interface{}, modifier string) bool {
+    detection, ok := rule["detection"].(map[string]interface{})
+    if !ok {
+        return false
+    }
+    for key, value := range detection {
+        if key != "condition" && key != "timeframe" && hasSigmaModifier(value, modifier) {
+            return true
+        }
+    }
+    return false
+}
+
+// hasSigmaModifier walks a search identifier value looking for a field key
+// with modifier
+func hasSigmaModifier(value interface{}, modifier string) bool {
+    switch v := value.(type) {
+    case map[string]interface{}:
+        for key := range v {
+            for _, m := range strings.Split(key, "|")[1:] {
+                if m == modifier {
+                    return true
+                }
+            }
+        }
+    case []interface{}:
+        for _, item := range v {
+            if hasSigmaModifier(item, modifier) {
+                return true
+            }
+        }
+    }
+    return false
+}
@@ -0,0 +1,170 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    "context"
+    "errors"
+    "strconv"
+
+    "validation-service/internal/cache"
+    "validation-service/internal/models"
+    "validation-service/internal/tenant"
+    "validation-service/pkg/logger"
+    "validation-service/pkg/utils"
+)
+
+// cacheKeyVersion is bumped when the cached result encoding changes
+const cacheKeyVersion = "2"
+
+// ValidateDetectionCached validates a translation like ValidateDetection, but
+// serves the cached result of identical content when a cache is configured
+// and bypass is false. It reports whether the result came from the cache.
+// Cached results are returned with a new ID and creation time. Traced
+// validations always run, so that their trace describes this request.
+//
+// With StaleWhileRevalidate, content whose result was cached by an earlier
+// validator version or with earlier catalogs is served that stale result,
+// marked in its metadata, while it is validated again in the background.
+func (s *ValidationService) ValidateDetectionCached(ctx context.Context, sourceDetection, targetDetection *models.Detection, bypass bool) (*models.ValidationResult, bool, error) {
+    if s.config.Cache == nil || bypass || executionTraceEnabled(ctx) || sourceDetection == nil || targetDetection == nil {
+        result, err := s.ValidateDetection(ctx, sourceDetection, targetDetection)
+        return result, false, err
+    }
+
+    ctx = s.withCustomSchemas(ctx)
+    key, staleKey, err := s.cacheKey(ctx, sourceDetection, targetDetection)
+    if err != nil {
+        result, err := s.ValidateDetection(ctx, sourceDetection, targetDetection)
+        return result, false, err
+    }
+
+    if cached, ok := s.cachedResult(ctx, key); ok {
+        return cached, true, nil
+    }
+    if s.config.StaleWhileRevalidate {
+        if stale, ok := s.cachedResult(ctx, staleKey); ok {
+            stale.Metadata.Stale = true
+            s.revalidate(ctx, key, staleKey, sourceDetection, targetDetection, stale)
+            return stale, true, nil
+        }
+    }
+
+    result, err := s.ValidateDetection(ctx, sourceDetection, targetDetection)
+    if err != nil || result.Status == models.ValidationStatusError {
+        return result, false, err
+    }
+    s.cacheResult(ctx, key, staleKey, result)
+    return result, false, nil
+}
+
+// cachedResult returns the result cached under key with a new ID and
+// creation time
+func (s *ValidationService) cachedResult(ctx context.Context, key string) (*models.ValidationResult, bool) {
+    cached, err := s.config.Cache.Get(ctx, key)
+    if err != nil {
+        if !errors.Is(err, cache.ErrMiss) {
+            // A cache outage degrades to uncached validation
+            logger.FromContext(ctx).Warn("Validation cache lookup failed",
+                "error", err,
+            )
+        }
+        return nil, false
+    }
+    cached.ID = s.config.IDs.NewID()
+    cached.CreatedAt = s.config.Clock.Now()
+    return cached, true
+}
+
+// cacheResult caches result under key and, with StaleWhileRevalidate, as
+// the stale result of its content for StaleTTL
+func (s *ValidationService) cacheResult(ctx context.Context, key, staleKey string, result *models.ValidationResult) {
+    if err := s.config.Cache.Set(ctx, key, result, s.config.CacheTTL); err != nil {
+        logger.FromContext(ctx).Warn("Failed to cache validation result",
+            "error", err,
+            "result_id", result.ID,
+        )
+        return
+    }
+    if !s.config.StaleWhileRevalidate {
+        return
+    }
+    if err := s.config.Cache.Set(ctx, staleKey, result, s.config.StaleTTL); err != nil {
+        logger.FromContext(ctx).Warn("Failed to cache stale validation result",
+            "error", err,
+            "result_id", result.ID,
+        )
+    }
+}
+
+// cacheKey hashes everything that determines a validation result: the
+// sanitized content and format of both detections, the target validator
+// version, the strictness setting, the ATT&CK dataset version, the reference
+// datasets reloaded since startup, the lint profile and the tenant, whose
+// policy and scoring policy may change the score and status and whose custom
+// schemas extend the field catalogs, the review status of the rule and the
+// platform connectors of the target format. The stale key leaves out the
+// validator version, ATT&CK dataset, reference datasets and platform
+// connectors, so it matches the results of earlier versions.
+func (s *ValidationService) cacheKey(ctx context.Context, sourceDetection, targetDetection *models.Detection) (string, string, error) {
+    targetFormat, err := targetDetection.GetFormat()
+    if err != nil {
+        return "", "", err
+    }
+    validator, err := s.GetValidator(targetFormat)
+    if err != nil {
+        return "", "", err
+    }
+
+    validatorVersion := ""
+    if describer, ok := validator.(Describer); ok {
+        validatorVersion = describer.Describe().Version
+    }
+    attackVersion := ""
+    if attack := s.attackDataset(); attack != nil {
+        attackVersion = attack.Version()
+    }
+
+    sourceContent := utils.SanitizeInput(sourceDetection.Content)
+    targetContent := utils.SanitizeInput(targetDetection.Content)
+    strict := strconv.FormatBool(s.Settings().StrictMode)
+    tenantID := tenant.IDFromContext(ctx)
+    profileDigest := s.config.Profiles.cacheDigest(profileName(ctx))
+    policyDigest := s.config.Scoring.tenantPolicyDigest(ctx, tenantID)
+    schemasDigest := customSchemasFromContext(ctx).cacheDigest()
+    reviewStatus := s.reviewStatus(sourceDetection, targetDetection, targetFormat)
+
+    key := cache.Key(
+        cacheKeyVersion,
+        sourceDetection.Format,
+        sourceContent,
+        targetFormat,
+        targetContent,
+        validatorVersion,
+        strict,
+        attackVersion,
+        s.referenceDataDigests(),
+        lintProfile(ctx),
+        profileDigest,
+        tenantID,
+        policyDigest,
+        schemasDigest,
+        reviewStatus,
+        s.platformDigest(targetFormat),
+    )
+    staleKey := cache.Key(
+        staleCacheKeyPrefix,
+        cacheKeyVersion,
+        sourceDetection.Format,
+        sourceContent,
+        targetFormat,
+        targetContent,
+        strict,
+        lintProfile(ctx),
+        profileDigest,
+        tenantID,
+        policyDigest,
+        schemasDigest,
+        reviewStatus,
+    )
+    return key, staleKey, nil
+}
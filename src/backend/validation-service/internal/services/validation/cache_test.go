@@ -0,0 +1,176 @@
+package validation
+
+import (
+    "context"
+    "sync"
+    "testing"
+    "time"
+
+    "validation-service/internal/cache"
+    "validation-service/internal/models"
+)
+
+// memoryResultCache is a ResultCache holding results in a map
+type memoryResultCache struct {
+    mu      sync.Mutex
+    results map[string]*models.ValidationResult
+}
+
+func newMemoryResultCache() *memoryResultCache {
+    return &memoryResultCache{results: make(map[string]*models.ValidationResult)}
+}
+
+func (c *memoryResultCache) Get(ctx context.Context, key string) (*models.ValidationResult, error) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    result, ok := c.results[key]
+    if !ok {
+        return nil, cache.ErrMiss
+    }
+    copied := *result
+    return &copied, nil
+}
+
+func (c *memoryResultCache) Set(ctx context.Context, key string, result *models.ValidationResult, ttl time.Duration) error {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    copied := *result
+    c.results[key] = &copied
+    return nil
+}
+
+// versionedValidator is a Splunk validator of a given version that reports
+// issueCode when set
+type versionedValidator struct {
+    version   string
+    issueCode string
+}
+
+func (v *versionedValidator) Validate(ctx context.Context, sourceDetection, targetDetection *models.Detection, result *models.ValidationResult) error {
+    if v.issueCode != "" {
+        result.AddIssue(&models.ValidationIssue{
+            Message:   "test issue",
+            Severity:  models.ValidationSeverityLow,
+            Location:  "query",
+            IssueCode: v.issueCode,
+        })
+    }
+    return nil
+}
+
+func (v *versionedValidator) Describe() FormatInfo {
+    return FormatInfo{Format: models.DetectionFormatSplunk, Name: "Splunk SPL", Version: v.version}
+}
+
+func newCachedService(t *testing.T, resultCache cache.ResultCache, validator *versionedValidator, strict bool) *ValidationService {
+    t.Helper()
+    s := NewValidationService(ValidationConfig{
+        StrictMode:           strict,
+        Cache:                resultCache,
+        CacheTTL:             time.Hour,
+        StaleWhileRevalidate: true,
+        StaleTTL:             24 * time.Hour,
+    })
+    if err := s.RegisterValidator(models.DetectionFormatSplunk, validator); err != nil {
+        t.Fatalf("RegisterValidator: %v", err)
+    }
+    return s
+}
+
+func testDetections(target string) (*models.Detection, *models.Detection) {
+    return &models.Detection{Format: models.DetectionFormatSigma, Content: "title: test\ndetection:\n  sel:\n    a: b\n  condition: sel\n"},
+        &models.Detection{Format: models.DetectionFormatSplunk, Content: target}
+}
+
+func TestCacheKey(t *testing.T) {
+    base := newCachedService(t, newMemoryResultCache(), &versionedValidator{version: "1.0.0"}, false)
+    source, target := testDetections(`index=main sourcetype=sysmon EventCode=1`)
+    wantKey, wantStale, err := base.cacheKey(context.Background(), source, target)
+    if err != nil {
+        t.Fatalf("cacheKey: %v", err)
+    }
+
+    tests := []struct {
+        name      string
+        service   *ValidationService
+        target    string
+        sameKey   bool
+        sameStale bool
+    }{
+        {"identical", base, `index=main sourcetype=sysmon EventCode=1`, true, true},
+        {"target content", base, `index=main sourcetype=sysmon EventCode=3`, false, false},
+        {"validator version", newCachedService(t, newMemoryResultCache(), &versionedValidator{version: "1.1.0"}, false),
+            `index=main sourcetype=sysmon EventCode=1`, false, true},
+        {"strict mode", newCachedService(t, newMemoryResultCache(), &versionedValidator{version: "1.0.0"}, true),
+            `index=main sourcetype=sysmon EventCode=1`, false, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            source, target := testDetections(tt.target)
+            key, stale, err := tt.service.cacheKey(context.Background(), source, target)
+            if err != nil {
+                t.Fatalf("cacheKey: %v", err)
+            }
+            if (key == wantKey) != tt.sameKey {
+                t.Errorf("key equal = %v, want %v", key == wantKey, tt.sameKey)
+            }
+            if (stale == wantStale) != tt.sameStale {
+                t.Errorf("stale key equal = %v, want %v", stale == wantStale, tt.sameStale)
+            }
+            if key == stale {
+                t.Error("key equals stale key")
+            }
+        })
+    }
+}
+
+func TestValidateDetectionCachedStaleWhileRevalidate(t *testing.T) {
+    resultCache := newMemoryResultCache()
+    ctx := context.Background()
+
+    old := newCachedService(t, resultCache, &versionedValidator{version: "1.0.0"}, false)
+    source, target := testDetections(`index=main sourcetype=sysmon EventCode=1`)
+    first, cached, err := old.ValidateDetectionCached(ctx, source, target, false)
+    if err != nil || cached {
+        t.Fatalf("first validation: cached = %v, err = %v", cached, err)
+    }
+    second, cached, err := old.ValidateDetectionCached(ctx, source, target, false)
+    if err != nil || !cached || second.Metadata.Stale {
+        t.Fatalf("second validation: cached = %v, stale = %v, err = %v", cached, second.Metadata.Stale, err)
+    }
+    if second.ID == first.ID {
+        t.Error("cached result kept the ID of the first result")
+    }
+
+    // A new validator version serves the stale result and validates again
+    // in the background
+    current := newCachedService(t, resultCache, &versionedValidator{version: "2.0.0", issueCode: "SPL_SEMANTIC"}, false)
+    revalidated := make(chan *Revalidation, 1)
+    current.SetRevalidationListener(func(r *Revalidation) { revalidated <- r })
+
+    stale, cached, err := current.ValidateDetectionCached(ctx, source, target, false)
+    if err != nil || !cached || !stale.Metadata.Stale {
+        t.Fatalf("stale validation: cached = %v, stale = %v, err = %v", cached, stale.Metadata.Stale, err)
+    }
+    select {
+    case r := <-revalidated:
+        if r.StaleResultID != stale.ID.String() {
+            t.Errorf("StaleResultID = %s, want %s", r.StaleResultID, stale.ID)
+        }
+        if len(r.Result.Issues) != 1 {
+            t.Errorf("revalidated issues = %d, want 1", len(r.Result.Issues))
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("stale result was not revalidated")
+    }
+
+    fresh, cached, err := current.ValidateDetectionCached(ctx, source, target, false)
+    if err != nil || !cached || fresh.Metadata.Stale || len(fresh.Issues) != 1 {
+        t.Fatalf("fresh validation: cached = %v, stale = %v, issues = %d, err = %v", cached, fresh.Metadata.Stale, len(fresh.Issues), err)
+    }
+
+    // Bypassing the cache always validates
+    if _, cached, err := current.ValidateDetectionCached(ctx, source, target, true); err != nil || cached {
+        t.Errorf("bypass: cached = %v, err = %v", cached, err)
+    }
+}
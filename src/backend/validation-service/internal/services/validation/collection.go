@@ -0,0 +1,374 @@
+package validation
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "gopkg.in/yaml.v3" // v3.0.1
+
+    "validation-service/internal/models"
+    yaraparser "validation-service/internal/parser/yara"
+    "validation-service/internal/tenant"
+)
+
+// Issue codes raised against a collection as a whole rather than one rule
+const (
+    // collectionDuplicateNameCode is raised for rules of a collection
+    // sharing a name, which SIEMs reject or silently overwrite on import
+    collectionDuplicateNameCode = "COLL001"
+    // collectionConflictingIDCode is raised for rules of a collection
+    // sharing an identifier of their target format, such as a Sigma ID, a
+    // YARA rule name or an IDS signature ID
+    collectionConflictingIDCode = "COLL002"
+)
+
+// Collection is an ordered set of rules migrated together, with metadata
+// shared by every rule
+type Collection struct {
+    Name string
+    // Metadata applies to the source and target of every rule; a rule's own
+    // metadata takes precedence
+    Metadata map[string]interface{}
+    Rules    []CollectionRule
+}
+
+// CollectionRule is a translation of a collection
+type CollectionRule struct {
+    // Name identifies the rule within the collection; the title of a Sigma
+    // or Sentinel target is used when empty
+    Name   string
+    Source *models.Detection
+    Target *models.Detection
+}
+
+// CollectionRuleResult is the validation of one rule of a collection
+type CollectionRuleResult struct {
+    // Index is the position of the rule in the collection
+    Index    int                      `json:"index"`
+    Name     string                   `json:"name,omitempty"`
+    Status   string                   `json:"status"`
+    Gate     string                   `json:"gate"`
+    Result   *models.ValidationResult `json:"result,omitempty"`
+    CacheHit bool                     `json:"cache_hit"`
+    // Error is set when the rule could not be validated
+    Error string `json:"error,omitempty"`
+}
+
+// CollectionSummary tallies the rule results of a collection
+type CollectionSummary struct {
+    Rules int `json:"rules"`
+    // ByStatus counts the rules by status; rules that could not be
+    // validated count as errors
+    ByStatus   map[string]int `json:"by_status"`
+    GateFailed int            `json:"gate_failed"`
+    // RuleIssues is the number of issues of the rules
+    RuleIssues int `json:"rule_issues"`
+}
+
+// CollectionResult is the validation of a collection: the result of every
+// rule and the issues of the collection as a whole
+type CollectionResult struct {
+    Name        string   `json:"name,omitempty"`
+    Status      string   `json:"status"`
+    Gate        string   `json:"gate"`
+    GateReasons []string `json:"gate_reasons,omitempty"`
+    // Issues concern the collection rather than one rule, such as rules
+    // sharing a name or ID; the issues of each rule are in its result
+    Issues  []models.ValidationIssue `json:"issues"`
+    Rules   []CollectionRuleResult   `json:"rules"`
+    Summary CollectionSummary        `json:"summary"`
+}
+
+// ValidateCollection validates the rules of collection in order, serving
+// identical content from the result cache unless bypassCache is set, and
+// checks the collection as a whole. A rule that cannot be validated is
+// reported in its rule result and fails the gate of the collection; an
+// error is returned only when ctx ends.
+func (s *ValidationService) ValidateCollection(ctx context.Context, collection *Collection, bypassCache bool) (*CollectionResult, error) {
+    rules := make([]CollectionRuleResult, len(collection.Rules))
+    results := make([]*models.ValidationResult, len(collection.Rules))
+    for i, rule := range collection.Rules {
+        if err := ctx.Err(); err != nil {
+            return nil, err
+        }
+        source := withSharedMetadata(rule.Source, collection.Metadata)
+        target := withSharedMetadata(rule.Target, collection.Metadata)
+        rules[i] = CollectionRuleResult{Index: i, Name: collectionRuleName(rule)}
+
+        result, cacheHit, err := s.ValidateDetectionCached(ctx, source, target, bypassCache)
+        if err != nil || result == nil {
+            if ctxErr := ctx.Err(); ctxErr != nil {
+                return nil, ctxErr
+            }
+            if err == nil {
+                err = errors.New("no result")
+            }
+            rules[i].Status = models.ValidationStatusError
+            rules[i].Gate = models.GateFail
+            rules[i].Error = err.Error()
+            continue
+        }
+        results[i] = result
+        rules[i].Status = result.Status
+        rules[i].Gate = result.Metadata.Gate
+        rules[i].Result = result
+        rules[i].CacheHit = cacheHit
+    }
+
+    out := &CollectionResult{
+        Name:   collection.Name,
+        Issues: CollectionIssues(collection.Rules, results),
+        Rules:  rules,
+        Summary: CollectionSummary{
+            Rules:    len(rules),
+            ByStatus: make(map[string]int),
+        },
+    }
+    for _, rule := range rules {
+        out.Summary.ByStatus[rule.Status]++
+        if rule.Gate == models.GateFail {
+            out.Summary.GateFailed++
+        }
+        if rule.Result != nil {
+            out.Summary.RuleIssues += len(rule.Result.Issues)
+        }
+    }
+    s.issueCodes.namespaceIssues(out.Issues)
+    t, _ := tenant.FromContext(ctx)
+    s.config.Profiles.applyCollectionGate(out, profileName(ctx), t)
+    return out, nil
+}
+
+// applyCollectionGate classifies the collection issues of result under the
+// gate policy of the profile and tenant and records the verdict: the
+// collection fails when any rule fails or a collection issue blocks
+func (p *Profiles) applyCollectionGate(result *CollectionResult, profile string, t *tenant.Tenant) {
+    gate := p.gatePolicy(profile, t)
+    blocking, codes := classifyIssues(gate, result.Issues)
+
+    var reasons []string
+    if result.Summary.GateFailed > 0 {
+        reasons = append(reasons, fmt.Sprintf("%d of %d rule(s) failed the gate", result.Summary.GateFailed, result.Summary.Rules))
+    }
+    if blocking > 0 {
+        reasons = append(reasons, fmt.Sprintf("%d blocking collection issue(s): %s", blocking, gateReasonCodes(codes)))
+    }
+    result.GateReasons = reasons
+    result.Gate = models.GatePass
+    if len(reasons) > 0 {
+        result.Gate = models.GateFail
+    }
+
+    switch {
+    case result.Summary.ByStatus[models.ValidationStatusError] > 0:
+        result.Status = models.ValidationStatusError
+    case result.Summary.ByStatus[models.ValidationStatusWarning] > 0 || len(result.Issues) > 0:
+        result.Status = models.ValidationStatusWarning
+    default:
+        result.Status = models.ValidationStatusSuccess
+    }
+}
+
+// CollectionIssues returns the issues of rules as a whole: rules sharing a
+// name, and rules sharing an identifier of their target format. results
+// holds the validation of each rule, nil for rules that could not be
+// validated. Each issue is reported once, at the later rule.
+func CollectionIssues(rules []CollectionRule, results []*models.ValidationResult) []models.ValidationIssue {
+    now := time.Now().UTC()
+    issues := make([]models.ValidationIssue, 0)
+    names := make(map[string]int)
+    ids := make(map[string]int)
+    for i, rule := range rules {
+        if name := collectionRuleName(rule); name != "" {
+            key := strings.ToLower(name)
+            if first, ok := names[key]; ok {
+                issues = append(issues, models.ValidationIssue{
+                    Message:     fmt.Sprintf("Rule %q has the same name as rule %d", name, first),
+                    Severity:    models.ValidationSeverityMedium,
+                    Location:    fmt.Sprintf("rules[%d]", i),
+                    Timestamp:   now,
+                    IssueCode:   collectionDuplicateNameCode,
+                    Remediation: "Give every rule of the collection a distinct name",
+                    IssueMetadata: map[string]interface{}{
+                        "rule":          i,
+                        "conflict_with": first,
+                        "name":          name,
+                    },
+                })
+            } else {
+                names[key] = i
+            }
+        }
+
+        var result *models.ValidationResult
+        if i < len(results) {
+            result = results[i]
+        }
+        declared := make(map[string]bool)
+        for _, id := range collectionRuleIDs(rule.Target, result) {
+            key := id.format + "\x00" + id.kind + "\x00" + id.value
+            if declared[key] {
+                // Rules of one detection sharing an ID are reported by its
+                // format validator
+                continue
+            }
+            declared[key] = true
+            first, ok := ids[key]
+            if !ok {
+                ids[key] = i
+                continue
+            }
+            issues = append(issues, models.ValidationIssue{
+                Message:     fmt.Sprintf("Rule %d declares %s %s, already declared by rule %d", i, id.kind, id.value, first),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    fmt.Sprintf("rules[%d]", i),
+                Timestamp:   now,
+                IssueCode:   collectionConflictingIDCode,
+                Remediation: fmt.Sprintf("Assign a new %s to one of the rules; deploying both makes one replace or shadow the other", id.kind),
+                IssueMetadata: map[string]interface{}{
+                    "rule":          i,
+                    "conflict_with": first,
+                    "format":        id.format,
+                    "identifier":    id.value,
+                },
+            })
+        }
+    }
+    return issues
+}
+
+// collectionRuleID is an identifier a rule declares in its target format
+type collectionRuleID struct {
+    format string
+    kind   string
+    value  string
+}
+
+// collectionRuleIDs returns the identifiers target declares that must be
+// unique among the rules deployed with it: Sigma and Sentinel IDs, YARA
+// and YARA-L rule names and IDS signature IDs. The signature IDs are read
+// from the validation result, which is nil when the rule could not be
+// validated.
+func collectionRuleIDs(target *models.Detection, result *models.ValidationResult) []collectionRuleID {
+    if target == nil {
+        return nil
+    }
+    format := strings.ToLower(target.Format)
+    var ids []collectionRuleID
+    switch format {
+    case models.DetectionFormatSigma, models.DetectionFormatSentinel:
+        if id := yamlRuleString(target.Content, "id"); id != "" {
+            ids = append(ids, collectionRuleID{format: format, kind: "id", value: id})
+        }
+    case models.DetectionFormatYara:
+        file, _ := yaraparser.Parse(target.Content)
+        if file == nil {
+            break
+        }
+        for _, rule := range file.Rules {
+            ids = append(ids, collectionRuleID{format: format, kind: "rule name", value: rule.Name})
+        }
+    case models.DetectionFormatYaraL:
+        if rule, issue := parseYARALRule(target.Content); issue == nil && rule.name != "" {
+            ids = append(ids, collectionRuleID{format: format, kind: "rule name", value: rule.name})
+        }
+    case models.DetectionFormatSuricata, models.DetectionFormatSnort:
+        if result == nil {
+            break
+        }
+        for _, sid := range detailInts(result.FormatSpecificDetails["sids"]) {
+            ids = append(ids, collectionRuleID{format: format, kind: "sid", value: fmt.Sprint(sid)})
+        }
+    case models.DetectionFormatZeek:
+        if !zeekSignatureStart.MatchString(target.Content) {
+            break
+        }
+        signatures, _ := parseZeekSignatures(target.Content)
+        for _, sig := range signatures {
+            ids = append(ids, collectionRuleID{format: format, kind: "signature", value: sig.id})
+        }
+    }
+    return ids
+}
+
+// collectionRuleName returns the name of rule, falling back to the title of
+// a Sigma or Sentinel target
+func collectionRuleName(rule CollectionRule) string {
+    if name := strings.TrimSpace(rule.Name); name != "" {
+        return name
+    }
+    if rule.Target == nil {
+        return ""
+    }
+    switch strings.ToLower(rule.Target.Format) {
+    case models.DetectionFormatSigma, models.DetectionFormatSentinel:
+        return yamlRuleString(rule.Target.Content, "title")
+    }
+    return ""
+}
+
+// yamlRuleString returns the trimmed top-level scalar key of a YAML rule
+func yamlRuleString(content, key string) string {
+    var rule map[string]interface{}
+    if yaml.Unmarshal([]byte(content), &rule) != nil {
+        return ""
+    }
+    switch value := rule[key].(type) {
+    case string:
+        return strings.TrimSpace(value)
+    case int, float64:
+        return fmt.Sprint(value)
+    }
+    return ""
+}
+
+// detailInts reads a list of integers from a format-specific detail, which
+// holds float64 values once a cached result was decoded from JSON
+func detailInts(value interface{}) []int {
+    switch v := value.(type) {
+    case []int:
+        return v
+    case []interface{}:
+        ints := make([]int, 0, len(v))
+        for _, item := range v {
+            if f, ok := item.(float64); ok {
+                ints = append(ints, int(f))
+            }
+        }
+        sort.Ints(ints)
+        return ints
+    }
+    return nil
+}
+
+// withSharedMetadata returns a copy of detection whose metadata is extended
+// with the keys of shared it does not set. Detections with metadata that is
+// not a JSON object are returned unchanged.
+func withSharedMetadata(detection *models.Detection, shared map[string]interface{}) *models.Detection {
+    if detection == nil || len(shared) == 0 {
+        return detection
+    }
+    metadata := make(map[string]interface{}, len(shared))
+    if len(detection.Metadata) > 0 {
+        if err := json.Unmarshal(detection.Metadata, &metadata); err != nil || metadata == nil {
+            return detection
+        }
+    }
+    for key, value := range shared {
+        if !hasMetaValue(metadata[key]) {
+            metadata[key] = value
+        }
+    }
+    encoded, err := json.Marshal(metadata)
+    if err != nil {
+        return detection
+    }
+    merged := *detection
+    merged.Metadata = encoded
+    return &merged
+}
@@ -0,0 +1,505 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "math"
+    "regexp"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "validation-service/internal/models"
+)
+
+// Conformance check IDs. Every FormatValidator, and in particular every
+// third-party plugin, is expected to pass the whole suite.
+const (
+    ConformanceDescribe     = "describe"
+    ConformanceResults      = "results"
+    ConformanceCancellation = "context_cancellation"
+    ConformanceIssueCodes   = "issue_code_namespacing"
+    ConformanceLocations    = "location_format"
+    ConformanceScoreBounds  = "score_bounds"
+    ConformanceConcurrency  = "concurrency"
+)
+
+// Conformance run defaults
+const (
+    DefaultConformanceTimeout       = 10 * time.Second
+    DefaultConformanceCancelTimeout = time.Second
+    // conformanceParallelism is the number of concurrent validations of
+    // every sample in the concurrency check
+    conformanceParallelism = 4
+    // conformanceProbe is validated when no samples are given
+    conformanceProbe = "conformance probe"
+)
+
+var (
+    // formatNameRegex matches the formats a validator may describe
+    formatNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+    // pluginIssueCodeRegex matches a namespace of at least two upper case
+    // letters followed by a three digit number or an underscore suffix, as in
+    // ACME001 or ACME_SYNTAX. The namespace is the first submatch.
+    pluginIssueCodeRegex = regexp.MustCompile(`^([A-Z]{2,})([0-9]{3}|_[A-Z0-9_]+)$`)
+    // issueLocationRegex matches dotted paths with optional indexes, followed
+    // by an optional qualifier, as in detection.selection, rules[2].condition,
+    // line:12 or target.field:CommandLine
+    issueLocationRegex = regexp.MustCompile(`^[A-Za-z_$][\w$|-]*(\[\d+\])?(\.[\w$|-]+(\[\d+\])?)*(:\S.*)?$`)
+)
+
+// ConformanceCheck is a behavior required of format validators
+type ConformanceCheck struct {
+    ID          string `json:"id"`
+    Description string `json:"description"`
+
+    check func(run *conformanceRun) []string
+}
+
+// ConformanceSuite lists the checks run by RunConformance, in order
+var ConformanceSuite = []ConformanceCheck{
+    {
+        ID:          ConformanceDescribe,
+        Description: "Describe reports a lower case format that is not built in, a lower case plugin other than core when one is named, a name, a version and unique issue codes",
+        check:       checkDescribe,
+    },
+    {
+        ID:          ConformanceResults,
+        Description: "ValidateDetection returns a result for every sample within the timeout, without an error or a panic",
+        check:       checkResults,
+    },
+    {
+        ID:          ConformanceCancellation,
+        Description: "ValidateDetection returns the context error promptly when its context is canceled or past its deadline",
+        check:       checkCancellation,
+    },
+    {
+        ID:          ConformanceIssueCodes,
+        Description: "Issue codes share one namespace of their own, such as ACME in ACME001, and every raised code is declared",
+        check:       checkIssueCodes,
+    },
+    {
+        ID:          ConformanceLocations,
+        Description: "Issues have a message, a known severity and a location path such as detection.selection or line:12; line and column are not negative",
+        check:       checkLocations,
+    },
+    {
+        ID:          ConformanceScoreBounds,
+        Description: "Confidence scores are between 0 and 100 and statuses are success, warning or error",
+        check:       checkScoreBounds,
+    },
+    {
+        ID:          ConformanceConcurrency,
+        Description: "Concurrent validations of a sample raise the same issue codes as a single one",
+        check:       checkConcurrency,
+    },
+}
+
+// ConformanceOptions configure a conformance run
+type ConformanceOptions struct {
+    // Samples are detections in the format of the validator. A probe
+    // detection is validated when there are none.
+    Samples []*models.Detection
+    // Timeout bounds every call of the validator; DefaultConformanceTimeout
+    // when zero
+    Timeout time.Duration
+    // CancelTimeout is how long the validator may take to return once its
+    // context is done; DefaultConformanceCancelTimeout when zero
+    CancelTimeout time.Duration
+}
+
+// ConformanceCheckResult is the outcome of one check
+type ConformanceCheckResult struct {
+    ID          string   `json:"id"`
+    Description string   `json:"description"`
+    Passed      bool     `json:"passed"`
+    Failures    []string `json:"failures,omitempty"`
+}
+
+// ConformanceReport is the outcome of a conformance run
+type ConformanceReport struct {
+    Format  string                   `json:"format"`
+    Name    string                   `json:"name"`
+    Version string                   `json:"version"`
+    Passed  bool                     `json:"passed"`
+    Checks  []ConformanceCheckResult `json:"checks"`
+}
+
+// conformanceRun holds the validator under test and its sequential
+// validation of every sample, shared by the checks
+type conformanceRun struct {
+    ctx       context.Context
+    validator FormatValidator
+    info      FormatInfo
+    opts      ConformanceOptions
+    outcomes  []conformanceOutcome
+}
+
+// conformanceOutcome is one validation of a sample
+type conformanceOutcome struct {
+    sample *models.Detection
+    result *models.ValidationResult
+    err    error
+}
+
+// RunConformance runs ConformanceSuite against validator. A failure of
+// Describe fails every check, as none can be interpreted without it.
+func RunConformance(ctx context.Context, validator FormatValidator, opts ConformanceOptions) *ConformanceReport {
+    if opts.Timeout <= 0 {
+        opts.Timeout = DefaultConformanceTimeout
+    }
+    if opts.CancelTimeout <= 0 {
+        opts.CancelTimeout = DefaultConformanceCancelTimeout
+    }
+    run := &conformanceRun{ctx: ctx, validator: validator, opts: opts}
+    report := &ConformanceReport{Passed: true}
+
+    info, err := describeSafely(validator)
+    if err != nil {
+        report.Passed = false
+        for _, check := range ConformanceSuite {
+            report.Checks = append(report.Checks, ConformanceCheckResult{
+                ID:          check.ID,
+                Description: check.Description,
+                Failures:    []string{err.Error()},
+            })
+        }
+        return report
+    }
+    run.info = info
+    report.Format, report.Name, report.Version = info.Format, info.Name, info.Version
+
+    if len(run.opts.Samples) == 0 {
+        run.opts.Samples = []*models.Detection{{Content: conformanceProbe, Format: info.Format}}
+    }
+    for _, sample := range run.opts.Samples {
+        result, err := run.validate(ctx, sample)
+        run.outcomes = append(run.outcomes, conformanceOutcome{sample: sample, result: result, err: err})
+    }
+
+    for _, check := range ConformanceSuite {
+        failures := check.check(run)
+        report.Checks = append(report.Checks, ConformanceCheckResult{
+            ID:          check.ID,
+            Description: check.Description,
+            Passed:      len(failures) == 0,
+            Failures:    failures,
+        })
+        if len(failures) > 0 {
+            report.Passed = false
+        }
+    }
+    return report
+}
+
+// describeSafely calls Describe, reporting a panic as an error
+func describeSafely(validator FormatValidator) (info FormatInfo, err error) {
+    defer func() {
+        if r := recover(); r != nil {
+            err = fmt.Errorf("Describe panicked: %v", r)
+        }
+    }()
+    return validator.Describe(), nil
+}
+
+// validate calls the validator with the run timeout, reporting a panic or a
+// call that does not return in time as an error
+func (run *conformanceRun) validate(ctx context.Context, sample *models.Detection) (*models.ValidationResult, error) {
+    ctx, cancel := context.WithTimeout(ctx, run.opts.Timeout)
+    defer cancel()
+    return run.call(ctx, sample, run.opts.Timeout+run.opts.CancelTimeout)
+}
+
+// call runs ValidateDetection in its own goroutine and waits up to wait for
+// it to return. A call that does not return is abandoned.
+func (run *conformanceRun) call(ctx context.Context, sample *models.Detection, wait time.Duration) (*models.ValidationResult, error) {
+    type outcome struct {
+        result *models.ValidationResult
+        err    error
+    }
+    done := make(chan outcome, 1)
+    go func() {
+        defer func() {
+            if r := recover(); r != nil {
+                done <- outcome{err: fmt.Errorf("ValidateDetection panicked: %v", r)}
+            }
+        }()
+        // Every call gets its own copy, so a validator changing the detection
+        // cannot affect the other calls
+        detection := *sample
+        result, err := run.validator.ValidateDetection(ctx, &detection)
+        done <- outcome{result: result, err: err}
+    }()
+
+    timer := time.NewTimer(wait)
+    defer timer.Stop()
+    select {
+    case o := <-done:
+        return o.result, o.err
+    case <-timer.C:
+        return nil, fmt.Errorf("ValidateDetection did not return within %s", wait)
+    }
+}
+
+// sampleName names a sample in failures
+func sampleName(i int) string {
+    return fmt.Sprintf("sample %d", i+1)
+}
+
+// checkDescribe checks the capabilities reported by the validator
+func checkDescribe(run *conformanceRun) []string {
+    var failures []string
+    info := run.info
+    if !formatNameRegex.MatchString(info.Format) {
+        failures = append(failures, fmt.Sprintf("format %q must be a lower case name such as acme-edr", info.Format))
+    }
+    for _, format := range BuiltinRegistry().Formats() {
+        if info.Format == format {
+            failures = append(failures, fmt.Sprintf("format %q is served by a built-in validator", info.Format))
+        }
+    }
+    switch {
+    case info.Plugin == CorePlugin:
+        failures = append(failures, fmt.Sprintf("plugin %q names the built-in validators", info.Plugin))
+    case info.Plugin != "" && !formatNameRegex.MatchString(info.Plugin):
+        failures = append(failures, fmt.Sprintf("plugin %q must be a lower case name such as acme", info.Plugin))
+    }
+    if strings.TrimSpace(info.Name) == "" {
+        failures = append(failures, "name is empty")
+    }
+    if strings.TrimSpace(info.Version) == "" {
+        failures = append(failures, "version is empty")
+    }
+    if len(info.IssueCodes) == 0 {
+        failures = append(failures, "no issue codes are declared")
+    }
+    seen := make(map[string]bool, len(info.IssueCodes))
+    for _, code := range info.IssueCodes {
+        if seen[code] {
+            failures = append(failures, fmt.Sprintf("issue code %s is declared twice", code))
+        }
+        seen[code] = true
+    }
+    return failures
+}
+
+// checkResults checks that every sample was validated
+func checkResults(run *conformanceRun) []string {
+    var failures []string
+    for i, o := range run.outcomes {
+        switch {
+        case o.err != nil:
+            failures = append(failures, fmt.Sprintf("%s: %v", sampleName(i), o.err))
+        case o.result == nil:
+            failures = append(failures, fmt.Sprintf("%s: neither a result nor an error was returned", sampleName(i)))
+        }
+    }
+    return failures
+}
+
+// checkCancellation validates the first sample with a canceled context and
+// with an expired one
+func checkCancellation(run *conformanceRun) []string {
+    canceled, cancel := context.WithCancel(context.Background())
+    cancel()
+    expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+    defer cancelExpired()
+
+    var failures []string
+    for _, tc := range []struct {
+        name string
+        ctx  context.Context
+        want error
+    }{
+        {"canceled context", canceled, context.Canceled},
+        {"expired context", expired, context.DeadlineExceeded},
+    } {
+        result, err := run.call(tc.ctx, run.opts.Samples[0], run.opts.CancelTimeout)
+        switch {
+        case err == nil && result != nil:
+            failures = append(failures, fmt.Sprintf("%s: a result was returned instead of %v", tc.name, tc.want))
+        case err == nil:
+            failures = append(failures, fmt.Sprintf("%s: no error was returned", tc.name))
+        case !errors.Is(err, tc.want):
+            failures = append(failures, fmt.Sprintf("%s: got %q, want an error wrapping %v", tc.name, err, tc.want))
+        }
+    }
+    return failures
+}
+
+// reservedIssueNamespaces returns the namespaces of the issue codes of the
+// built-in validators and of the codes the service raises for any format
+func reservedIssueNamespaces() map[string]bool {
+    reserved := make(map[string]bool)
+    add := func(codes []string) {
+        for _, code := range codes {
+            if match := pluginIssueCodeRegex.FindStringSubmatch(code); match != nil {
+                reserved[match[1]] = true
+            }
+        }
+    }
+    add(CommonIssueCodes)
+    if validators, err := BuiltinRegistry().Build(PluginOptions{}); err == nil {
+        for _, validator := range validators {
+            add(validator.Describe().IssueCodes)
+        }
+    }
+    return reserved
+}
+
+// checkIssueCodes checks the declared codes share a namespace of their own
+// and that raised codes are declared
+func checkIssueCodes(run *conformanceRun) []string {
+    var failures []string
+    reserved := reservedIssueNamespaces()
+    namespaces := make(map[string]bool)
+    declared := make(map[string]bool, len(run.info.IssueCodes))
+    for _, code := range run.info.IssueCodes {
+        declared[code] = true
+        match := pluginIssueCodeRegex.FindStringSubmatch(code)
+        if match == nil {
+            failures = append(failures, fmt.Sprintf("issue code %q is not a namespace followed by a number or suffix, as in ACME001", code))
+            continue
+        }
+        namespaces[match[1]] = true
+        if reserved[match[1]] {
+            failures = append(failures, fmt.Sprintf("issue code %s uses the namespace %s of the service", code, match[1]))
+        }
+    }
+    if len(namespaces) > 1 {
+        names := make([]string, 0, len(namespaces))
+        for namespace := range namespaces {
+            names = append(names, namespace)
+        }
+        sort.Strings(names)
+        failures = append(failures, fmt.Sprintf("issue codes use several namespaces: %s", strings.Join(names, ", ")))
+    }
+
+    for i, o := range run.outcomes {
+        if o.result == nil {
+            continue
+        }
+        for _, issue := range o.result.Issues {
+            if !declared[issue.IssueCode] {
+                failures = append(failures, fmt.Sprintf("%s: issue code %q is not declared by Describe", sampleName(i), issue.IssueCode))
+            }
+        }
+    }
+    return failures
+}
+
+// checkLocations checks the issues raised for every sample
+func checkLocations(run *conformanceRun) []string {
+    var failures []string
+    for i, o := range run.outcomes {
+        if o.result == nil {
+            continue
+        }
+        for _, issue := range o.result.Issues {
+            prefix := fmt.Sprintf("%s: %s", sampleName(i), issue.IssueCode)
+            if strings.TrimSpace(issue.Message) == "" {
+                failures = append(failures, prefix+": message is empty")
+            }
+            switch issue.Severity {
+            case models.ValidationSeverityHigh, models.ValidationSeverityMedium, models.ValidationSeverityLow:
+            default:
+                failures = append(failures, fmt.Sprintf("%s: invalid severity %q", prefix, issue.Severity))
+            }
+            if !issueLocationRegex.MatchString(issue.Location) {
+                failures = append(failures, fmt.Sprintf("%s: location %q is not a path such as detection.selection or line:12", prefix, issue.Location))
+            }
+            if issue.Line < 0 || issue.Column < 0 {
+                failures = append(failures, fmt.Sprintf("%s: negative line or column %d:%d", prefix, issue.Line, issue.Column))
+            }
+            if issue.Column > 0 && issue.Line == 0 {
+                failures = append(failures, fmt.Sprintf("%s: column %d is set without a line", prefix, issue.Column))
+            }
+        }
+    }
+    return failures
+}
+
+// checkScoreBounds checks the score and status of every result
+func checkScoreBounds(run *conformanceRun) []string {
+    var failures []string
+    for i, o := range run.outcomes {
+        if o.result == nil {
+            continue
+        }
+        score := o.result.ConfidenceScore
+        if math.IsNaN(score) || score < 0 || score > 100 {
+            failures = append(failures, fmt.Sprintf("%s: confidence score %v is outside 0-100", sampleName(i), score))
+        }
+        switch o.result.Status {
+        case models.ValidationStatusSuccess, models.ValidationStatusWarning, models.ValidationStatusError:
+        default:
+            failures = append(failures, fmt.Sprintf("%s: invalid status %q", sampleName(i), o.result.Status))
+        }
+    }
+    return failures
+}
+
+// checkConcurrency validates every sample concurrently and compares the
+// issue codes raised with those of the sequential validation
+func checkConcurrency(run *conformanceRun) []string {
+    type concurrentOutcome struct {
+        sample int
+        codes  string
+        err    error
+    }
+    outcomes := make(chan concurrentOutcome, len(run.outcomes)*conformanceParallelism)
+    var wg sync.WaitGroup
+    for i, o := range run.outcomes {
+        if o.result == nil {
+            continue
+        }
+        for n := 0; n < conformanceParallelism; n++ {
+            wg.Add(1)
+            go func(i int, sample *models.Detection) {
+                defer wg.Done()
+                result, err := run.validate(run.ctx, sample)
+                if err == nil && result == nil {
+                    err = errors.New("neither a result nor an error was returned")
+                }
+                co := concurrentOutcome{sample: i, err: err}
+                if result != nil {
+                    co.codes = issueCodeList(result)
+                }
+                outcomes <- co
+            }(i, o.sample)
+        }
+    }
+    wg.Wait()
+    close(outcomes)
+
+    failed := make(map[string]bool)
+    var failures []string
+    for co := range outcomes {
+        var failure string
+        switch want := issueCodeList(run.outcomes[co.sample].result); {
+        case co.err != nil:
+            failure = fmt.Sprintf("%s: %v", sampleName(co.sample), co.err)
+        case co.codes != want:
+            failure = fmt.Sprintf("%s: concurrent validation raised [%s], a single one [%s]", sampleName(co.sample), co.codes, want)
+        }
+        if failure != "" && !failed[failure] {
+            failed[failure] = true
+            failures = append(failures, failure)
+        }
+    }
+    sort.Strings(failures)
+    return failures
+}
+
+// issueCodeList returns the sorted issue codes of result
+func issueCodeList(result *models.ValidationResult) string {
+    codes := make([]string, 0, len(result.Issues))
+    for _, issue := range result.Issues {
+        codes = append(codes, issue.IssueCode)
+    }
+    sort.Strings(codes)
+    return strings.Join(codes, " ")
+}
@@ -3,13 +3,15 @@ package validation
 
 import (
     "encoding/json" // builtin
+    "fmt"           // builtin
     "regexp"        // builtin
     "strings"       // builtin
-    "time"         // builtin
+    "time"          // builtin
 
-    "github.com/your-org/detection-translator/internal/models"
-    "github.com/your-org/detection-translator/pkg/utils"
-    "github.com/your-org/detection-translator/pkg/logger"
+    "validation-service/internal/models"
+    "validation-service/pkg/logger"
+    "validation-service/pkg/mitre"
+    "validation-service/pkg/utils"
 )
 
 // Constants for Crowdstrike detection validation
@@ -65,6 +67,14 @@ func ValidateCrowdstrikeDetection(detection *models.Detection) (*models.Validati
     go func() {
         defer close(doneChan)
 
+        // Custom IOA and scheduled search rules are bare FQL event search
+        // queries without the JSON envelope
+        if !strings.HasPrefix(strings.TrimSpace(detection.Content), "{") {
+            validateFQLQuery(detection.Content, "", result)
+            result.RecalculateConfidence()
+            return
+        }
+
         // Parse detection content
         var content map[string]interface{}
         if err := json.Unmarshal([]byte(detection.Content), &content); err != nil {
@@ -130,8 +140,13 @@ func ValidateCrowdstrikeDetection(detection *models.Detection) (*models.Validati
             validateMitreMapping(mitre, result)
         }
 
+        // Validate the event search query of the envelope
+        if query, ok := content["query"].(string); ok {
+            validateFQLQuery(query, "query", result)
+        }
+
         // Calculate final confidence score based on validation results
-        calculateConfidenceScore(result)
+        result.RecalculateConfidence()
     }()
 
     // Wait for validation completion or timeout
@@ -272,31 +287,7 @@ func validateMitreMapping(mitre []interface{}, result *models.ValidationResult)
 
 // isMitreTechniqueValid validates MITRE ATT&CK technique ID format
 func isMitreTechniqueValid(id string) bool {
-    // Basic format validation for MITRE technique IDs (e.g., T1234)
-    return regexp.MustCompile(`^T\d{4}(\.\d{3})?$`).MatchString(id)
+    // Format only; IDs are checked against the ATT&CK dataset for every
+    // format by the validation service
+    return mitre.IsTechniqueID(id)
 }
-
-// calculateConfidenceScore computes the final confidence score
-func calculateConfidenceScore(result *models.ValidationResult) {
-    // Start with maximum confidence
-    confidence := 100.0
-
-    // Reduce confidence based on issue severity
-    for _, issue := range result.Issues {
-        switch issue.Severity {
-        case models.ValidationSeverityHigh:
-            confidence -= 20.0
-        case models.ValidationSeverityMedium:
-            confidence -= 10.0
-        case models.ValidationSeverityLow:
-            confidence -= 5.0
-        }
-    }
-
-    // Ensure confidence stays within bounds
-    if confidence < 0 {
-        confidence = 0
-    }
-
-    result.SetConfidence(confidence)
-}
\ No newline at end of file
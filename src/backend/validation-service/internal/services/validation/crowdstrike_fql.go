@@ -0,0 +1,134 @@
+package validation
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+    "time"
+
+    "validation-service/internal/models"
+    fqlparser "validation-service/internal/parser/fql"
+)
+
+// fqlFieldPattern matches FQL field names, which may be dotted paths into
+// nested objects such as device.platform_name
+var fqlFieldPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// fqlRelativeTime matches relative timestamps such as now or now-7d
+var fqlRelativeTime = regexp.MustCompile(`^now([+-][0-9]+[smhdwMy])?$`)
+
+// fqlTimeLayouts are the accepted absolute timestamp layouts
+var fqlTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+// validateFQLQuery parses an FQL event search query and validates its
+// conditions. location is the envelope field holding the query, or empty
+// for a bare query, whose issues carry their line and column.
+func validateFQLQuery(src, location string, result *models.ValidationResult) {
+    query, parseErrs := fqlparser.Parse(src)
+    for _, perr := range parseErrs {
+        addFQLIssue(result, location, perr.Pos, &models.ValidationIssue{
+            Message:     fmt.Sprintf("FQL syntax error: %s", perr.Msg),
+            Severity:    models.ValidationSeverityHigh,
+            IssueCode:   "CS010",
+            Remediation: "Write conditions as field:value, optionally with an operator such as field:>5 or field:*'*value*', joined by + (and) or , (or)",
+        })
+    }
+
+    for _, cond := range query.Conditions() {
+        validateFQLCondition(cond, location, result)
+    }
+}
+
+// validateFQLCondition checks the field name of a condition and that its
+// operator suits its values
+func validateFQLCondition(cond *fqlparser.Condition, location string, result *models.ValidationResult) {
+    if !fqlFieldPattern.MatchString(cond.Field) {
+        addFQLIssue(result, location, cond.Position, &models.ValidationIssue{
+            Message:     "Invalid FQL field name: " + cond.Field,
+            Severity:    models.ValidationSeverityHigh,
+            IssueCode:   "CS011",
+            Remediation: "Field names start with a letter or underscore and contain only letters, numbers and underscores, with dots between nested names",
+        })
+    }
+
+    if cond.List && len(cond.Values) == 0 {
+        addFQLIssue(result, location, cond.Position, &models.ValidationIssue{
+            Message:     fmt.Sprintf("Empty value list for field %s matches nothing", cond.Field),
+            Severity:    models.ValidationSeverityMedium,
+            IssueCode:   "CS014",
+            Remediation: "List at least one value, or remove the condition",
+        })
+    }
+
+    if fqlparser.IsComparison(cond.Op) {
+        if cond.List {
+            addFQLIssue(result, location, cond.Position, &models.ValidationIssue{
+                Message:     fmt.Sprintf("Comparison operator %s cannot take a value list for field %s", cond.Op, cond.Field),
+                Severity:    models.ValidationSeverityMedium,
+                IssueCode:   "CS012",
+                Remediation: "Compare against a single number or timestamp, joining several comparisons with , (or)",
+            })
+        } else {
+            for _, value := range cond.Values {
+                if !isFQLOrderedValue(value) {
+                    addFQLIssue(result, location, value.Position, &models.ValidationIssue{
+                        Message:     fmt.Sprintf("Comparison operator %s needs a number or timestamp for field %s, not %q", cond.Op, cond.Field, value.Text),
+                        Severity:    models.ValidationSeverityMedium,
+                        IssueCode:   "CS012",
+                        Remediation: "Compare against a number, an RFC 3339 timestamp such as '2024-01-01T00:00:00Z' or a relative time such as 'now-7d'",
+                    })
+                }
+            }
+        }
+    }
+
+    if cond.Op != fqlparser.OpWildcard {
+        for _, value := range cond.Values {
+            if strings.Contains(value.Text, "*") {
+                addFQLIssue(result, location, value.Position, &models.ValidationIssue{
+                    Message:     fmt.Sprintf("Wildcard in value %q of field %s is matched literally without the :* operator", value.Text, cond.Field),
+                    Severity:    models.ValidationSeverityMedium,
+                    IssueCode:   "CS013",
+                    Remediation: fmt.Sprintf("Use the wildcard operator, as in %s:*'%s'", cond.Field, value.Text),
+                })
+                break
+            }
+        }
+    }
+}
+
+// isFQLOrderedValue reports whether a value can be compared: a number, an
+// absolute timestamp or a relative time
+func isFQLOrderedValue(value *fqlparser.Value) bool {
+    if value.Kind == fqlparser.ValueNumber {
+        return true
+    }
+    if fqlRelativeTime.MatchString(value.Text) {
+        return true
+    }
+    for _, layout := range fqlTimeLayouts {
+        if _, err := time.Parse(layout, value.Text); err == nil {
+            return true
+        }
+    }
+    return false
+}
+
+// addFQLIssue adds an issue at pos of a query. Issues of a bare query carry
+// the line and column; those of an envelope query name its field and the
+// position in the query.
+func addFQLIssue(result *models.ValidationResult, location string, pos fqlparser.Position, issue *models.ValidationIssue) {
+    switch {
+    case location == "":
+        issue.Location = fmt.Sprintf("line:%d", pos.Line)
+        if pos.IsValid() {
+            issue.Line = pos.Line
+            issue.Column = pos.Column
+        }
+    case pos.IsValid():
+        issue.Location = fmt.Sprintf("%s:%d:%d", location, pos.Line, pos.Column)
+    default:
+        issue.Location = location
+    }
+    result.AddIssue(issue)
+}
@@ -0,0 +1,154 @@
+package validation
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "sort"
+    "strings"
+
+    "validation-service/internal/storage"
+    "validation-service/internal/tenant"
+    "validation-service/pkg/logger"
+)
+
+// CustomSchemaSource supplies the custom log source schemas tenants
+// registered for their own tables and logsources
+type CustomSchemaSource interface {
+    // ListCustomSchemas returns the schemas of a tenant
+    ListCustomSchemas(ctx context.Context, tenantID string) ([]*storage.CustomSchema, error)
+}
+
+// SetCustomSchemas merges the custom schemas of each tenant with the
+// built-in field catalogs when its detections are validated. It is set
+// before validations run.
+func (s *ValidationService) SetCustomSchemas(source CustomSchemaSource) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.customSchemas = source
+}
+
+// customSchemaFields holds the fields of a tenant's custom schemas by schema
+// name. A nil *customSchemaFields has no schemas.
+type customSchemaFields struct {
+    schemas map[string]map[string]bool
+    // digest identifies the schemas for the cache key
+    digest string
+}
+
+// customSchemasKey carries the custom schemas of the tenant of a validation
+type customSchemasKey struct{}
+
+// withCustomSchemas returns ctx carrying the custom schemas of its tenant.
+// Schemas already loaded into ctx are kept; when the source fails, the
+// validation proceeds with the built-in catalogs only.
+func (s *ValidationService) withCustomSchemas(ctx context.Context) context.Context {
+    if _, ok := ctx.Value(customSchemasKey{}).(*customSchemaFields); ok {
+        return ctx
+    }
+    s.mu.RLock()
+    source := s.customSchemas
+    s.mu.RUnlock()
+    if source == nil {
+        return ctx
+    }
+
+    schemas, err := source.ListCustomSchemas(ctx, tenant.IDFromContext(ctx))
+    if err != nil {
+        logger.FromContext(ctx).Warn("Failed to load custom schemas, validating against the built-in catalogs",
+            "error", err,
+        )
+        return ctx
+    }
+    return context.WithValue(ctx, customSchemasKey{}, newCustomSchemaFields(schemas))
+}
+
+// customSchemasFromContext returns the custom schemas carried by ctx, or nil
+func customSchemasFromContext(ctx context.Context) *customSchemaFields {
+    fields, _ := ctx.Value(customSchemasKey{}).(*customSchemaFields)
+    return fields
+}
+
+// newCustomSchemaFields indexes the fields of schemas and digests their
+// names, fields and types
+func newCustomSchemaFields(schemas []*storage.CustomSchema) *customSchemaFields {
+    c := &customSchemaFields{schemas: make(map[string]map[string]bool, len(schemas))}
+    parts := make([]string, 0, len(schemas))
+    for _, schema := range schemas {
+        fields := make(map[string]bool, len(schema.Fields))
+        typed := make([]string, 0, len(schema.Fields))
+        for _, field := range schema.Fields {
+            fields[field.Name] = true
+            typed = append(typed, field.Name+":"+field.Type)
+        }
+        c.schemas[schema.Name] = fields
+        sort.Strings(typed)
+        parts = append(parts, schema.Name+"="+strings.Join(typed, ","))
+    }
+    if len(parts) > 0 {
+        sort.Strings(parts)
+        sum := sha256.Sum256([]byte(strings.Join(parts, ";")))
+        c.digest = hex.EncodeToString(sum[:])
+    }
+    return c
+}
+
+// cacheDigest returns the digest of the schemas, empty when there are none
+func (c *customSchemaFields) cacheDigest() string {
+    if c == nil {
+        return ""
+    }
+    return c.digest
+}
+
+// logsourceFields returns the custom fields of a Sigma logsource: those of
+// the schemas named after its category and its product/service pair.
+// name is the schema the fields were taken from; ok is false when no schema
+// describes the logsource.
+func (c *customSchemaFields) logsourceFields(logsource map[string]interface{}) (fields map[string]bool, name string, ok bool) {
+    if c == nil {
+        return nil, "", false
+    }
+    var names []string
+    if category, _ := logsource["category"].(string); category != "" {
+        names = append(names, category)
+    }
+    product, _ := logsource["product"].(string)
+    service, _ := logsource["service"].(string)
+    if product != "" && service != "" {
+        names = append(names, product+"/"+service)
+    }
+
+    for _, candidate := range names {
+        schema, found := c.schemas[candidate]
+        if !found {
+            continue
+        }
+        if fields == nil {
+            fields = make(map[string]bool, len(schema))
+            name = candidate
+        }
+        for field := range schema {
+            fields[field] = true
+        }
+        ok = true
+    }
+    return fields, name, ok
+}
+
+// knownUDMPath reports whether a UDM path is a field of a custom schema or
+// lies below one. UDM is one schema for every log type, so the fields of
+// all the tenant's schemas extend it.
+func (c *customSchemaFields) knownUDMPath(path string) bool {
+    if c == nil {
+        return false
+    }
+    for _, fields := range c.schemas {
+        for field := range fields {
+            if path == field || strings.HasPrefix(path, field+".") {
+                return true
+            }
+        }
+    }
+    return false
+}
@@ -0,0 +1,104 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    "fmt"
+    "strings"
+)
+
+// questionIDPrefix prefixes the IDs of field mapping questions
+const questionIDPrefix = "field:"
+
+// DisambiguationQuestion asks which of several target fields a source field
+// should be translated to
+type DisambiguationQuestion struct {
+    ID         string   `json:"id"`
+    Field      string   `json:"field"`
+    Prompt     string   `json:"prompt"`
+    Candidates []string `json:"candidates"`
+    // Default is the candidate used when the question is not answered
+    Default string `json:"default"`
+}
+
+// FieldResolution is the target field chosen for a source field
+type FieldResolution struct {
+    Field  string `json:"field"`
+    Target string `json:"target"`
+    // Source is "table" for unambiguous mappings, "resolution" for answered
+    // questions and "default" for unanswered ones
+    Source string `json:"source"`
+}
+
+// Field resolution sources
+const (
+    ResolutionSourceTable      = "table"
+    ResolutionSourceResolution = "resolution"
+    ResolutionSourceDefault    = "default"
+)
+
+// Supports reports whether the table maps sourceFormat to targetFormat
+func (t *FieldMappingTable) Supports(sourceFormat, targetFormat string) bool {
+    return t.Source == sourceFormat && t.Target == targetFormat
+}
+
+// Disambiguate maps every field of a Sigma rule through the table. Fields
+// with a single candidate are resolved directly; fields with several are
+// resolved from resolutions, keyed by question ID, and otherwise returned as
+// open questions and resolved to their default. A resolution that does not
+// name a question of the rule or one of its candidates is an error.
+func (t *FieldMappingTable) Disambiguate(sigmaContent string, resolutions map[string]string) ([]FieldResolution, []DisambiguationQuestion, error) {
+    fields, err := extractSigmaFields(sigmaContent)
+    if err != nil {
+        return nil, nil, fmt.Errorf("extracting Sigma fields: %w", err)
+    }
+
+    resolved := make([]FieldResolution, 0, len(fields))
+    questions := make([]DisambiguationQuestion, 0)
+    answered := make(map[string]bool, len(resolutions))
+
+    for _, field := range fields {
+        candidates := t.Mappings[field]
+        switch len(candidates) {
+        case 0:
+            continue
+        case 1:
+            resolved = append(resolved, FieldResolution{Field: field, Target: candidates[0], Source: ResolutionSourceTable})
+            continue
+        }
+
+        id := questionIDPrefix + field
+        if choice, ok := resolutions[id]; ok {
+            answered[id] = true
+            if !containsString(candidates, choice) {
+                return nil, nil, fmt.Errorf("%s is not a candidate for %s (expected %s)", choice, field, strings.Join(candidates, " or "))
+            }
+            resolved = append(resolved, FieldResolution{Field: field, Target: choice, Source: ResolutionSourceResolution})
+            continue
+        }
+
+        questions = append(questions, DisambiguationQuestion{
+            ID:         id,
+            Field:      field,
+            Prompt:     fmt.Sprintf("%s field %s maps to several %s fields. Which one should the translation use?", t.Source, field, t.Target),
+            Candidates: candidates,
+            Default:    candidates[0],
+        })
+        resolved = append(resolved, FieldResolution{Field: field, Target: candidates[0], Source: ResolutionSourceDefault})
+    }
+
+    for id := range resolutions {
+        if !answered[id] {
+            return nil, nil, fmt.Errorf("unknown question %s", id)
+        }
+    }
+    return resolved, questions, nil
+}
+
+func containsString(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,95 @@
+package validation
+
+import (
+    "sort"
+
+    "validation-service/internal/models"
+)
+
+// ScoreSuggestion is an issue whose fix would raise the confidence score
+type ScoreSuggestion struct {
+    IssueCode   string `json:"issue_code"`
+    Severity    string `json:"severity"`
+    Section     string `json:"section"`
+    Line        int    `json:"line,omitempty"`
+    Message     string `json:"message"`
+    Remediation string `json:"remediation,omitempty"`
+    // Gain is the confidence the fix restores
+    Gain float64 `json:"gain"`
+    // Fixable is set when the quick-fix endpoint can correct the issue
+    Fixable bool `json:"fixable"`
+}
+
+// ScoreExplanation explains the confidence score of a result and the fewest
+// issue fixes that would bring it to the threshold of its scoring policy
+type ScoreExplanation struct {
+    Breakdown *models.ScoreBreakdown `json:"breakdown"`
+    // MeetsThreshold is set when the score is at or above the threshold
+    MeetsThreshold bool `json:"meets_threshold"`
+    // Gap is the confidence missing to reach the threshold
+    Gap float64 `json:"gap"`
+    // Suggestions are the issues to fix, heaviest first; empty when the
+    // score meets the threshold
+    Suggestions []ScoreSuggestion `json:"suggestions"`
+    // ProjectedScore is the score once every suggestion is fixed
+    ProjectedScore float64 `json:"projected_score"`
+}
+
+// ExplainScore breaks down the confidence score of result and suggests the
+// fewest issue fixes that raise it to the threshold. Fixing them also clears
+// the low confidence issue, so its deduction is never suggested.
+func ExplainScore(result *models.ValidationResult) *ScoreExplanation {
+    breakdown := result.BreakDownScore()
+    explanation := &ScoreExplanation{
+        Breakdown:      breakdown,
+        MeetsThreshold: breakdown.Score >= breakdown.Threshold,
+        Suggestions:    make([]ScoreSuggestion, 0),
+        ProjectedScore: breakdown.Score,
+    }
+    if explanation.MeetsThreshold {
+        return explanation
+    }
+    explanation.Gap = breakdown.Threshold - breakdown.Score
+
+    policy := result.ScoringPolicy()
+    candidates := make([]ScoreSuggestion, 0, len(result.Issues))
+    for _, issue := range result.Issues {
+        if issue.IsOptimization() || issue.IssueCode == lowConfidenceCode {
+            continue
+        }
+        candidates = append(candidates, ScoreSuggestion{
+            IssueCode:   issue.IssueCode,
+            Severity:    issue.Severity,
+            Section:     issue.Location,
+            Line:        issue.Line,
+            Message:     issue.Message,
+            Remediation: issue.Remediation,
+            Gain:        policy.Weight(issue.Severity),
+            Fixable:     issue.Fixable,
+        })
+    }
+    // Heaviest issues first so the fewest fixes reach the threshold;
+    // quick-fixable ones first among equals
+    sort.SliceStable(candidates, func(i, j int) bool {
+        if candidates[i].Gain != candidates[j].Gain {
+            return candidates[i].Gain > candidates[j].Gain
+        }
+        return candidates[i].Fixable && !candidates[j].Fixable
+    })
+
+    remaining := 0.0
+    for _, deduction := range breakdown.Deductions {
+        if deduction.IssueCode != lowConfidenceCode {
+            remaining += deduction.Weight
+        }
+    }
+    for _, candidate := range candidates {
+        if breakdown.BaseScore-remaining >= breakdown.Threshold {
+            break
+        }
+        explanation.Suggestions = append(explanation.Suggestions, candidate)
+        remaining -= candidate.Gain
+    }
+    explanation.ProjectedScore = breakdown.BaseScore - remaining
+    return explanation
+}
@@ -0,0 +1,347 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "os/exec"
+    "plugin"
+    "strings"
+    "sync"
+    "time"
+
+    "validation-service/internal/models"
+    "validation-service/pkg/logger"
+)
+
+// Plugin types accepted by LoadPlugin
+const (
+    PluginTypeGo      = "go"
+    PluginTypeSidecar = "sidecar"
+)
+
+// GoPluginSymbol is the factory a Go plugin must export, with the signature
+// of FormatValidatorFactory
+const GoPluginSymbol = "NewFormatValidator"
+
+// Sidecar protocol limits
+const (
+    maxSidecarMessageSize  = 16 << 20 // 16MB
+    sidecarDescribeTimeout = 10 * time.Second
+)
+
+// ErrSidecarExited is returned for calls in flight when a sidecar exits
+var ErrSidecarExited = errors.New("validator sidecar exited")
+
+// PluginConfig describes an external validator
+type PluginConfig struct {
+    // Type is PluginTypeGo or PluginTypeSidecar
+    Type string   `json:"type"`
+    Path string   `json:"path"`
+    Args []string `json:"args,omitempty"`
+}
+
+// LoadPlugin loads an external validator and registers its format so
+// detections in it are accepted
+func LoadPlugin(cfg PluginConfig, opts PluginOptions) (FormatValidator, error) {
+    var validator FormatValidator
+    var err error
+    switch cfg.Type {
+    case PluginTypeGo:
+        validator, err = loadGoPlugin(cfg.Path, opts)
+    case PluginTypeSidecar:
+        validator, err = startSidecar(cfg.Path, cfg.Args)
+    default:
+        return nil, fmt.Errorf("unknown plugin type %q for %s", cfg.Type, cfg.Path)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if err := models.RegisterFormat(validator.Describe().Format); err != nil {
+        if closer, ok := validator.(io.Closer); ok {
+            closer.Close()
+        }
+        return nil, fmt.Errorf("plugin %s: %w", cfg.Path, err)
+    }
+    return validator, nil
+}
+
+// loadGoPlugin opens a Go plugin built with -buildmode=plugin against the
+// same version of this module and calls its NewFormatValidator factory
+func loadGoPlugin(path string, opts PluginOptions) (FormatValidator, error) {
+    p, err := plugin.Open(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to open Go plugin %s: %w", path, err)
+    }
+    symbol, err := p.Lookup(GoPluginSymbol)
+    if err != nil {
+        return nil, fmt.Errorf("Go plugin %s: %w", path, err)
+    }
+    factory, ok := symbol.(func(PluginOptions) (FormatValidator, error))
+    if !ok {
+        return nil, fmt.Errorf("Go plugin %s: %s has type %T, want func(PluginOptions) (FormatValidator, error)", path, GoPluginSymbol, symbol)
+    }
+
+    validator, err := factory(opts)
+    if err != nil {
+        return nil, fmt.Errorf("Go plugin %s: %w", path, err)
+    }
+    if validator == nil {
+        return nil, fmt.Errorf("Go plugin %s: %w", path, ErrInvalidValidator)
+    }
+    return validator, nil
+}
+
+// sidecarRequest is a request line sent to a sidecar on stdin
+type sidecarRequest struct {
+    ID        uint64            `json:"id"`
+    Method    string            `json:"method"`
+    Detection *models.Detection `json:"detection,omitempty"`
+}
+
+// sidecarResponse is a response line read from a sidecar's stdout
+type sidecarResponse struct {
+    ID     uint64          `json:"id"`
+    Result json.RawMessage `json:"result,omitempty"`
+    Error  string          `json:"error,omitempty"`
+}
+
+// sidecarValidation is the result of a sidecar validate call
+type sidecarValidation struct {
+    Issues []models.ValidationIssue `json:"issues"`
+    // ConfidenceScore overrides the score derived from the issues
+    ConfidenceScore *float64               `json:"confidence_score,omitempty"`
+    Details         map[string]interface{} `json:"details,omitempty"`
+}
+
+// sidecarValidator runs validation in a subprocess speaking newline
+// delimited JSON over stdin and stdout. Calls are multiplexed by ID, so
+// concurrent validations share one process. A process that exits is
+// restarted by the next call.
+type sidecarValidator struct {
+    path string
+    args []string
+    info FormatInfo
+    log  *logger.Logger
+
+    mu      sync.Mutex
+    cmd     *exec.Cmd
+    stdin   io.WriteCloser
+    nextID  uint64
+    pending map[uint64]chan sidecarResponse
+    closed  bool
+}
+
+// startSidecar starts a sidecar and reads its capabilities
+func startSidecar(path string, args []string) (*sidecarValidator, error) {
+    v := &sidecarValidator{
+        path:    path,
+        args:    args,
+        log:     logger.GetLogger(),
+        pending: make(map[uint64]chan sidecarResponse),
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), sidecarDescribeTimeout)
+    defer cancel()
+
+    raw, err := v.call(ctx, sidecarRequest{Method: "describe"})
+    if err != nil {
+        v.Close()
+        return nil, fmt.Errorf("sidecar %s: describe: %w", path, err)
+    }
+    if err := json.Unmarshal(raw, &v.info); err != nil {
+        v.Close()
+        return nil, fmt.Errorf("sidecar %s: invalid describe result: %w", path, err)
+    }
+    if v.info.Format == "" {
+        v.Close()
+        return nil, fmt.Errorf("sidecar %s: describe returned no format", path)
+    }
+    return v, nil
+}
+
+// Describe returns the capabilities reported by the sidecar
+func (v *sidecarValidator) Describe() FormatInfo {
+    return v.info
+}
+
+// ValidateDetection sends the detection to the sidecar
+func (v *sidecarValidator) ValidateDetection(ctx context.Context, detection *models.Detection) (*models.ValidationResult, error) {
+    raw, err := v.call(ctx, sidecarRequest{Method: "validate", Detection: detection})
+    if err != nil {
+        return nil, err
+    }
+
+    var validation sidecarValidation
+    if err := json.Unmarshal(raw, &validation); err != nil {
+        return nil, fmt.Errorf("invalid validate result: %w", err)
+    }
+
+    result, err := models.NewValidationResult(detection)
+    if err != nil {
+        return nil, err
+    }
+    result.MergeIssues(validation.Issues, nil)
+    if validation.ConfidenceScore != nil {
+        result.SetConfidenceScore(*validation.ConfidenceScore, "external:"+v.info.Format, "score reported by the validator")
+    }
+    for key, value := range validation.Details {
+        result.FormatSpecificDetails[key] = value
+    }
+    return result, nil
+}
+
+// Close stops the sidecar process
+func (v *sidecarValidator) Close() error {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    v.closed = true
+    if v.cmd == nil {
+        return nil
+    }
+    v.stdin.Close()
+    return v.cmd.Process.Kill()
+}
+
+// call sends a request and waits for its response or ctx
+func (v *sidecarValidator) call(ctx context.Context, req sidecarRequest) (json.RawMessage, error) {
+    // A request is not sent for a done context, whose error would otherwise
+    // race with a fast response
+    if err := ctx.Err(); err != nil {
+        return nil, err
+    }
+    ch := make(chan sidecarResponse, 1)
+
+    v.mu.Lock()
+    if v.closed {
+        v.mu.Unlock()
+        return nil, ErrSidecarExited
+    }
+    if v.cmd == nil {
+        if err := v.start(); err != nil {
+            v.mu.Unlock()
+            return nil, err
+        }
+    }
+    v.nextID++
+    req.ID = v.nextID
+    v.pending[req.ID] = ch
+
+    line, err := json.Marshal(req)
+    if err == nil {
+        _, err = v.stdin.Write(append(line, '\n'))
+    }
+    v.mu.Unlock()
+
+    if err != nil {
+        v.forget(req.ID)
+        return nil, fmt.Errorf("failed to send request to sidecar: %w", err)
+    }
+
+    select {
+    case resp := <-ch:
+        if resp.Error != "" {
+            return nil, errors.New(resp.Error)
+        }
+        return resp.Result, nil
+    case <-ctx.Done():
+        v.forget(req.ID)
+        return nil, ctx.Err()
+    }
+}
+
+// forget drops a pending call whose caller stopped waiting
+func (v *sidecarValidator) forget(id uint64) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+    delete(v.pending, id)
+}
+
+// start launches the process; v.mu must be held
+func (v *sidecarValidator) start() error {
+    cmd := exec.Command(v.path, v.args...)
+    stdin, err := cmd.StdinPipe()
+    if err != nil {
+        return err
+    }
+    stdout, err := cmd.StdoutPipe()
+    if err != nil {
+        return err
+    }
+    stderr, err := cmd.StderrPipe()
+    if err != nil {
+        return err
+    }
+    if err := cmd.Start(); err != nil {
+        return fmt.Errorf("failed to start sidecar %s: %w", v.path, err)
+    }
+
+    v.cmd = cmd
+    v.stdin = stdin
+    go v.readResponses(cmd, stdout)
+    go v.logStderr(stderr)
+    return nil
+}
+
+// readResponses dispatches response lines to their callers until the process
+// exits, then fails the calls still in flight
+func (v *sidecarValidator) readResponses(cmd *exec.Cmd, stdout io.Reader) {
+    scanner := bufio.NewScanner(stdout)
+    scanner.Buffer(make([]byte, 64*1024), maxSidecarMessageSize)
+    for scanner.Scan() {
+        var resp sidecarResponse
+        if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+            v.log.Error("Invalid response from validator sidecar",
+                "error", err,
+                "path", v.path,
+            )
+            continue
+        }
+
+        v.mu.Lock()
+        ch, ok := v.pending[resp.ID]
+        delete(v.pending, resp.ID)
+        v.mu.Unlock()
+        if ok {
+            ch <- resp
+        }
+    }
+
+    err := cmd.Wait()
+
+    v.mu.Lock()
+    defer v.mu.Unlock()
+    if v.cmd == cmd {
+        v.cmd = nil
+    }
+    for id, ch := range v.pending {
+        ch <- sidecarResponse{ID: id, Error: ErrSidecarExited.Error()}
+        delete(v.pending, id)
+    }
+    if !v.closed {
+        v.log.Error("Validator sidecar exited",
+            "error", err,
+            "path", v.path,
+            "format", v.info.Format,
+        )
+    }
+}
+
+// logStderr forwards the sidecar's diagnostics to the service log
+func (v *sidecarValidator) logStderr(stderr io.Reader) {
+    scanner := bufio.NewScanner(stderr)
+    for scanner.Scan() {
+        if line := strings.TrimSpace(scanner.Text()); line != "" {
+            v.log.Info("Validator sidecar output",
+                "path", v.path,
+                "line", line,
+            )
+        }
+    }
+}
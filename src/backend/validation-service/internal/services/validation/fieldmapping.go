@@ -0,0 +1,380 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    "context"
+    _ "embed"
+    "encoding/json"
+    "fmt"
+    "os"
+    "regexp"
+    "sort"
+    "strings"
+    "sync"
+
+    "gopkg.in/yaml.v3" // v3.0.1
+
+    "validation-service/internal/models"
+)
+
+// defaultSigmaSplunkMappings is the built-in Sigma taxonomy to Splunk CIM table
+//
+//go:embed mappings/sigma_splunk_cim.json
+var defaultSigmaSplunkMappings []byte
+
+// splunkProjectionRegex matches the field list of a table or fields command
+var splunkProjectionRegex = regexp.MustCompile(`\|\s*(?:table|fields)\s+([^|]+)`)
+
+// splunkFieldRegex matches a field compared to a value
+var splunkFieldRegex = regexp.MustCompile(`([\w\.]+)\s*=\s*["']?([^"'\s]+)["']?`)
+
+// SPL fields that select data rather than describe events and are therefore
+// excluded from field mapping checks
+var splunkNonEventFields = map[string]bool{
+    "index": true, "sourcetype": true, "source": true, "eventtype": true,
+    "tag": true, "earliest": true, "latest": true, "host": true,
+}
+
+// FieldMappingTable maps source format fields to acceptable target fields
+type FieldMappingTable struct {
+    Version  string              `json:"version"`
+    Source   string              `json:"source"`
+    Target   string              `json:"target"`
+    Mappings map[string][]string `json:"mappings"`
+}
+
+// LoadFieldMappingTable loads a mapping table from path, falling back to the
+// built-in Sigma to Splunk CIM table when path is empty
+func LoadFieldMappingTable(path string) (*FieldMappingTable, error) {
+    data := defaultSigmaSplunkMappings
+    if path != "" {
+        var err error
+        if data, err = os.ReadFile(path); err != nil {
+            return nil, fmt.Errorf("failed to read field mapping table: %w", err)
+        }
+    }
+    return ParseFieldMappingTable(data)
+}
+
+// ParseFieldMappingTable parses a JSON mapping table
+func ParseFieldMappingTable(data []byte) (*FieldMappingTable, error) {
+    var table FieldMappingTable
+    if err := json.Unmarshal(data, &table); err != nil {
+        return nil, fmt.Errorf("failed to parse field mapping table: %w", err)
+    }
+    if len(table.Mappings) == 0 {
+        return nil, fmt.Errorf("field mapping table contains no mappings")
+    }
+    return &table, nil
+}
+
+// targetFields returns every target field that appears in the table
+func (t *FieldMappingTable) targetFields() map[string]bool {
+    fields := make(map[string]bool)
+    for _, targets := range t.Mappings {
+        for _, target := range targets {
+            fields[target] = true
+        }
+    }
+    return fields
+}
+
+// SigmaSplunkFieldMappingValidator checks that fields used by a Sigma source
+// rule are translated to CIM-compliant Splunk fields in the target query
+type SigmaSplunkFieldMappingValidator struct {
+    mu    sync.RWMutex
+    table *FieldMappingTable
+    cim   map[string]bool
+}
+
+// NewSigmaSplunkFieldMappingValidator creates a cross-format field mapping validator
+func NewSigmaSplunkFieldMappingValidator(table *FieldMappingTable) *SigmaSplunkFieldMappingValidator {
+    return &SigmaSplunkFieldMappingValidator{
+        table: table,
+        cim:   table.targetFields(),
+    }
+}
+
+// SetTable replaces the mapping table. Validations already running finish
+// with the previous table.
+func (v *SigmaSplunkFieldMappingValidator) SetTable(table *FieldMappingTable) {
+    cim := table.targetFields()
+    v.mu.Lock()
+    defer v.mu.Unlock()
+    v.table = table
+    v.cim = cim
+}
+
+// current returns the mapping table in use and its CIM fields
+func (v *SigmaSplunkFieldMappingValidator) current() (*FieldMappingTable, map[string]bool) {
+    v.mu.RLock()
+    defer v.mu.RUnlock()
+    return v.table, v.cim
+}
+
+// Validate implements the Validator interface for Sigma -> Splunk pairs
+func (v *SigmaSplunkFieldMappingValidator) Validate(ctx context.Context, sourceDetection *models.Detection, targetDetection *models.Detection, result *models.ValidationResult) error {
+    sigmaFields, err := extractSigmaFields(sourceDetection.Content)
+    if err != nil {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("Unable to extract Sigma fields for mapping check: %v", err),
+            Severity:    models.ValidationSeverityMedium,
+            Location:    "source.detection",
+            IssueCode:   "FIELDMAP001",
+            Remediation: "Ensure the source Sigma rule is valid YAML with a detection section",
+        })
+        return nil
+    }
+
+    table, cim := v.current()
+    custom, _, _ := customSchemasFromContext(ctx).logsourceFields(sigmaRuleLogsource(sourceDetection.Content))
+    splunkFields := extractSplunkFields(targetDetection.Content)
+    expectedTargets := make(map[string]bool)
+    // unmapped holds the IDs of the FIELDMAP002 issues by field
+    unmapped := make(map[string]string)
+
+    for _, field := range sigmaFields {
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+
+        candidates, known := customMapping(table, custom, field)
+        if !known {
+            issue := &models.ValidationIssue{
+                Message:     fmt.Sprintf("Sigma field %s has no known CIM mapping", field),
+                Severity:    models.ValidationSeverityLow,
+                Location:    "source.detection." + field,
+                IssueCode:   "FIELDMAP002",
+                Remediation: "Verify the target field manually or extend the field mapping table",
+            }
+            result.AddIssue(issue)
+            unmapped[field] = issue.ID
+            continue
+        }
+        for _, candidate := range candidates {
+            expectedTargets[candidate] = true
+        }
+
+        if containsAny(splunkFields, candidates) {
+            continue
+        }
+
+        if splunkFields[field] {
+            result.AddIssue(&models.ValidationIssue{
+                Message:     fmt.Sprintf("Sigma field %s used verbatim in Splunk query; CIM field is %s", field, strings.Join(candidates, " or ")),
+                Severity:    models.ValidationSeverityMedium,
+                Location:    "target.field:" + field,
+                IssueCode:   "FIELDMAP003",
+                Remediation: fmt.Sprintf("Rename %s to the CIM-compliant field %s", field, candidates[0]),
+            })
+            continue
+        }
+
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("Sigma field %s is not mapped in the Splunk query (expected %s)", field, strings.Join(candidates, " or ")),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "target.field:" + field,
+            IssueCode:   "FIELDMAP004",
+            Remediation: fmt.Sprintf("Add a condition on %s equivalent to the Sigma %s selection", candidates[0], field),
+        })
+    }
+
+    // Flag CIM fields in the target that no source field maps to
+    for _, field := range sortedKeys(splunkFields) {
+        if cim[field] && !expectedTargets[field] {
+            result.AddIssue(&models.ValidationIssue{
+                Message:     fmt.Sprintf("Splunk field %s does not correspond to any Sigma field in the source rule", field),
+                Severity:    models.ValidationSeverityMedium,
+                Location:    "target.field:" + field,
+                IssueCode:   "FIELDMAP005",
+                Remediation: "Check that the field was not mapped from the wrong Sigma field",
+            })
+        }
+    }
+
+    projection := v.validateProjection(table, custom, sourceDetection.Content, targetDetection.Content, unmapped, result)
+
+    result.FormatSpecificDetails["field_mapping"] = map[string]interface{}{
+        "table_version":    table.Version,
+        "sigma_fields":     sigmaFields,
+        "splunk_fields":    sortedKeys(splunkFields),
+        "projected_fields": projection,
+    }
+
+    return nil
+}
+
+// validateProjection checks that the fields projected by the Sigma rule exist
+// in Splunk and, when the query projects its output with table or fields, that
+// they are kept. Unknown projected fields are related to the issues of
+// unmapped, the IDs of the unknown fields the detection matches on. It returns
+// the Sigma projection.
+func (v *SigmaSplunkFieldMappingValidator) validateProjection(table *FieldMappingTable, custom map[string]bool, sigmaContent, splunkContent string, unmapped map[string]string, result *models.ValidationResult) []string {
+    var rule map[string]interface{}
+    if err := yaml.Unmarshal([]byte(sigmaContent), &rule); err != nil {
+        return nil
+    }
+    projection, ok := sigmaProjection(rule)
+    if !ok || len(projection) == 0 {
+        return projection
+    }
+
+    splunkProjection, projected := extractSplunkProjection(splunkContent)
+    for _, field := range projection {
+        candidates, known := customMapping(table, custom, field)
+        if !known {
+            issue := &models.ValidationIssue{
+                Message:     fmt.Sprintf("Projected Sigma field %s has no known CIM mapping and may not exist in Splunk", field),
+                Severity:    models.ValidationSeverityLow,
+                Location:    "source.fields." + field,
+                IssueCode:   "FIELDMAP006",
+                Remediation: "Verify the field exists in the target index or extend the field mapping table",
+            }
+            result.AddIssue(issue)
+            result.RelateIssues(unmapped[field], issue.ID)
+            continue
+        }
+        if projected && !containsAny(splunkProjection, candidates) && !splunkProjection[field] {
+            result.AddIssue(&models.ValidationIssue{
+                Message:     fmt.Sprintf("Projected Sigma field %s is dropped by the Splunk output projection (expected %s)", field, strings.Join(candidates, " or ")),
+                Severity:    models.ValidationSeverityMedium,
+                Location:    "target.projection:" + field,
+                IssueCode:   "FIELDMAP007",
+                Remediation: fmt.Sprintf("Add %s to the table or fields command", candidates[0]),
+            })
+        }
+    }
+    return projection
+}
+
+// customMapping returns the Splunk fields a Sigma field maps to. Fields of
+// the tenant's custom schema for the logsource that the table does not map
+// keep their name in Splunk.
+func customMapping(table *FieldMappingTable, custom map[string]bool, field string) ([]string, bool) {
+    if candidates, known := table.Mappings[field]; known {
+        return candidates, true
+    }
+    if custom[field] {
+        return []string{field}, true
+    }
+    return nil, false
+}
+
+// sigmaRuleLogsource returns the logsource of a Sigma rule, or nil when the
+// rule cannot be parsed or has none
+func sigmaRuleLogsource(content string) map[string]interface{} {
+    var rule map[string]interface{}
+    if err := yaml.Unmarshal([]byte(content), &rule); err != nil {
+        return nil
+    }
+    logsource, _ := rule["logsource"].(map[string]interface{})
+    return logsource
+}
+
+// extractSplunkProjection returns the fields kept by the table and fields
+// commands of an SPL query. ok is false when the query has no projection.
+// Fields removed with `fields -` are not included.
+func extractSplunkProjection(content string) (fields map[string]bool, ok bool) {
+    fields = make(map[string]bool)
+    for _, match := range splunkProjectionRegex.FindAllStringSubmatch(content, -1) {
+        list := strings.TrimSpace(match[1])
+        if strings.HasPrefix(list, "-") {
+            continue
+        }
+        ok = true
+        list = strings.TrimPrefix(list, "+")
+        for _, field := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ' ' }) {
+            fields[field] = true
+        }
+    }
+    return fields, ok
+}
+
+// ExtractFieldNames returns the event fields referenced by a detection for
+// formats that have a field extractor; other formats yield no fields
+func ExtractFieldNames(detection *models.Detection) []string {
+    switch detection.Format {
+    case models.DetectionFormatSigma:
+        fields, err := extractSigmaFields(detection.Content)
+        if err != nil {
+            return nil
+        }
+        return fields
+    case models.DetectionFormatSplunk:
+        return sortedKeys(extractSplunkFields(detection.Content))
+    default:
+        return nil
+    }
+}
+
+// extractSigmaFields returns the field names referenced in a Sigma detection
+// section, with value modifiers (e.g. `|contains`) stripped
+func extractSigmaFields(content string) ([]string, error) {
+    var rule map[string]interface{}
+    if err := yaml.Unmarshal([]byte(content), &rule); err != nil {
+        return nil, err
+    }
+    detection, ok := rule["detection"].(map[string]interface{})
+    if !ok {
+        return nil, fmt.Errorf("missing detection section")
+    }
+
+    fields := make(map[string]bool)
+    for key, value := range detection {
+        if key == "condition" || key == "timeframe" {
+            continue
+        }
+        collectSigmaFields(value, fields)
+    }
+    return sortedKeys(fields), nil
+}
+
+// collectSigmaFields walks a search identifier value collecting field names
+func collectSigmaFields(value interface{}, fields map[string]bool) {
+    switch v := value.(type) {
+    case map[string]interface{}:
+        for key := range v {
+            name := strings.SplitN(key, "|", 2)[0]
+            if name != "" {
+                fields[name] = true
+            }
+        }
+    case []interface{}:
+        for _, item := range v {
+            collectSigmaFields(item, fields)
+        }
+    }
+}
+
+// extractSplunkFields returns the event fields compared in an SPL query
+func extractSplunkFields(content string) map[string]bool {
+    fields := make(map[string]bool)
+    for _, match := range splunkFieldRegex.FindAllStringSubmatch(content, -1) {
+        if len(match) < 2 {
+            continue
+        }
+        name := match[1]
+        if !splunkNonEventFields[strings.ToLower(name)] {
+            fields[name] = true
+        }
+    }
+    return fields
+}
+
+func containsAny(set map[string]bool, candidates []string) bool {
+    for _, c := range candidates {
+        if set[c] {
+            return true
+        }
+    }
+    return false
+}
+
+func sortedKeys(m map[string]bool) []string {
+    keys := make([]string, 0, len(m))
+    for k := range m {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    return keys
+}
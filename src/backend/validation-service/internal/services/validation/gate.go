@@ -0,0 +1,107 @@
+package validation
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+
+    "validation-service/internal/config"
+    "validation-service/internal/models"
+    "validation-service/internal/tenant"
+)
+
+// maxGateReasonCodes bounds the issue codes a gate failure names
+const maxGateReasonCodes = 10
+
+// builtinGate blocks on high severity issues alone
+func builtinGate() config.GatePolicy {
+    return config.GatePolicy{BlockingSeverities: []string{models.ValidationSeverityHigh}}
+}
+
+// gatePolicy returns the gate policy of a validation judged with the
+// profile selected by name on behalf of t: the built-in policy overlaid with
+// that of the profile and then with that of the tenant
+func (p *Profiles) gatePolicy(name string, t *tenant.Tenant) config.GatePolicy {
+    gate := builtinGate()
+    if _, profile, ok := p.resolve(name); ok {
+        gate = gate.Overlay(profile.Gate)
+    }
+    if t != nil {
+        gate = gate.Overlay(t.Policy.Gate)
+    }
+    return gate
+}
+
+// applyGate classifies the issues of result as blocking or advisory under
+// the gate policy of its profile and tenant and records the verdict.
+// Optimization hints are always advisory.
+func (p *Profiles) applyGate(result *models.ValidationResult, t *tenant.Tenant) {
+    gate := p.gatePolicy(result.Metadata.Profile, t)
+    blocking, codes := classifyIssues(gate, result.Issues)
+
+    var reasons []string
+    if result.Status == models.ValidationStatusError {
+        reasons = append(reasons, "validation status is error")
+    }
+    if blocking > 0 {
+        reasons = append(reasons, fmt.Sprintf("%d blocking issue(s): %s", blocking, gateReasonCodes(codes)))
+    }
+    if gate.MinConfidence != nil && result.ConfidenceScore < *gate.MinConfidence {
+        reasons = append(reasons, fmt.Sprintf("confidence score %.1f is below the gate threshold of %.1f", result.ConfidenceScore, *gate.MinConfidence))
+    }
+
+    result.Metadata.GateReasons = reasons
+    result.Metadata.Gate = models.GatePass
+    if len(reasons) > 0 {
+        result.Metadata.Gate = models.GateFail
+    }
+}
+
+// classifyIssues marks issues as blocking or advisory under gate and
+// returns the number of blocking issues and their codes. Optimization hints
+// are always advisory.
+func classifyIssues(gate config.GatePolicy, issues []models.ValidationIssue) (int, map[string]bool) {
+    blockingSeverities := make(map[string]bool, len(gate.BlockingSeverities))
+    for _, severity := range gate.BlockingSeverities {
+        blockingSeverities[severity] = true
+    }
+
+    codes := make(map[string]bool)
+    blocking := 0
+    for i := range issues {
+        issue := &issues[i]
+        issue.Blocking = false
+        if issue.IsOptimization() {
+            continue
+        }
+        switch gate.Issues[issue.IssueCode] {
+        case config.IssueClassBlocking:
+            issue.Blocking = true
+        case config.IssueClassAdvisory:
+        default:
+            issue.Blocking = blockingSeverities[issue.Severity]
+        }
+        if issue.Blocking {
+            blocking++
+            codes[issue.IssueCode] = true
+        }
+    }
+    return blocking, codes
+}
+
+// gateReasonCodes lists the issue codes of blocking issues, sorted and
+// bounded by maxGateReasonCodes
+func gateReasonCodes(codes map[string]bool) string {
+    names := make([]string, 0, len(codes))
+    for code := range codes {
+        if code == "" {
+            code = noIssueCode
+        }
+        names = append(names, code)
+    }
+    sort.Strings(names)
+    if len(names) > maxGateReasonCodes {
+        return strings.Join(names[:maxGateReasonCodes], ", ") + fmt.Sprintf(" and %d more", len(names)-maxGateReasonCodes)
+    }
+    return strings.Join(names, ", ")
+}
@@ -0,0 +1,48 @@
+package validation
+
+import (
+    "context"
+    "fmt"
+
+    "validation-service/internal/grammar"
+    "validation-service/internal/models"
+)
+
+// grammarIssueLimit bounds the grammar issues of one detection, since the
+// parser keeps reporting follow-on errors after recovering from the first
+const grammarIssueLimit = 5
+
+// parseGrammar parses the target detection with the generated parser of its
+// format and records the errors the grammar rejects as GRAMMAR_SYNTAX issues.
+// The returned context carries the parse tree for the format validators,
+// which read it with grammar.FromContext.
+func (s *ValidationService) parseGrammar(ctx context.Context, format string, detection *models.Detection, result *models.ValidationResult) context.Context {
+    content, err := detection.GetContent()
+    if err != nil {
+        return ctx
+    }
+    tree, err := grammar.Parse(format, content)
+    if err != nil {
+        return ctx
+    }
+
+    for i, syntaxErr := range tree.Errors {
+        if i == grammarIssueLimit {
+            break
+        }
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("Query does not match the %s grammar: %s", format, syntaxErr.Message),
+            Severity:    models.ValidationSeverityMedium,
+            Location:    "grammar",
+            Line:        syntaxErr.Line,
+            Column:      syntaxErr.Column,
+            IssueCode:   "GRAMMAR_SYNTAX",
+            Remediation: "Correct the query at the reported position",
+        })
+    }
+    result.FormatSpecificDetails["grammar"] = map[string]interface{}{
+        "valid":  tree.Valid(),
+        "errors": len(tree.Errors),
+    }
+    return grammar.NewContext(ctx, tree)
+}
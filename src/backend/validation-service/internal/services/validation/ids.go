@@ -0,0 +1,679 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    "fmt"
+    "net"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+
+    "validation-service/internal/models"
+    "validation-service/pkg/utils"
+)
+
+// idsDialect describes the rule language of an IDS engine
+type idsDialect struct {
+    format    string
+    name      string
+    actions   map[string]bool
+    protocols map[string]bool
+    // serviceRules allows Snort 3 rules with only an action and a service
+    serviceRules bool
+}
+
+var (
+    suricataDialect = &idsDialect{
+        format: models.DetectionFormatSuricata,
+        name:   "Suricata",
+        actions: toSet([]string{
+            "alert", "pass", "drop", "reject", "rejectsrc", "rejectdst", "rejectboth",
+        }),
+        protocols: toSet([]string{
+            "ip", "tcp", "udp", "icmp", "tcp-pkt", "tcp-stream", "pkthdr",
+            "http", "http1", "http2", "ftp", "ftp-data", "tls", "smb", "dns", "dcerpc",
+            "ssh", "smtp", "imap", "pop3", "modbus", "dnp3", "enip", "nfs", "ikev2",
+            "krb5", "ntp", "dhcp", "rfb", "rdp", "snmp", "tftp", "sip", "mqtt",
+            "pgsql", "telnet", "websocket", "quic", "bittorrent-dht",
+        }),
+    }
+    snortDialect = &idsDialect{
+        format: models.DetectionFormatSnort,
+        name:   "Snort",
+        actions: toSet([]string{
+            "alert", "log", "pass", "activate", "dynamic", "drop", "sdrop", "reject",
+            "block", "react", "rewrite",
+        }),
+        protocols: toSet([]string{
+            "ip", "tcp", "udp", "icmp",
+            "http", "http2", "ssl", "dns", "ftp", "smtp", "imap", "pop3", "ssh",
+            "smb", "dcerpc", "sip", "file",
+        }),
+        serviceRules: true,
+    }
+)
+
+// idsClasstypes are the classtypes of the default classification.config
+var idsClasstypes = toSet([]string{
+    "not-suspicious", "unknown", "bad-unknown", "attempted-recon",
+    "successful-recon-limited", "successful-recon-largescale", "attempted-dos",
+    "successful-dos", "attempted-user", "unsuccessful-user", "successful-user",
+    "attempted-admin", "successful-admin", "rpc-portmap-decode", "shellcode-detect",
+    "string-detect", "suspicious-filename-detect", "suspicious-login",
+    "system-call-detect", "tcp-connection", "trojan-activity",
+    "unusual-client-port-connection", "network-scan", "denial-of-service",
+    "non-standard-protocol", "protocol-command-decode", "web-application-activity",
+    "web-application-attack", "misc-activity", "misc-attack", "icmp-event",
+    "inappropriate-content", "policy-violation", "default-login-attempt",
+    "targeted-activity", "exploit-kit", "external-ip-check", "domain-c2",
+    "pup-activity", "credential-theft", "social-engineering", "coin-mining",
+    "command-and-control", "sdf",
+})
+
+// idsFlowbitCommands maps flowbits commands to whether they take a name
+var idsFlowbitCommands = map[string]bool{
+    "set": true, "setx": true, "unset": true, "toggle": true,
+    "isset": true, "isnotset": true, "noalert": false,
+}
+
+// idsPCREFlags are the modifiers accepted after a pcre pattern
+const idsPCREFlags = "ismxAEGRUBOIPQHDMCSYVWXZ"
+
+var (
+    // idsVariable matches an address or port variable such as $HOME_NET
+    idsVariable = regexp.MustCompile(`^\$[A-Za-z_][A-Za-z0-9_]*$`)
+    // idsPCREUnsupported matches PCRE constructs the Go regexp engine cannot
+    // compile, such as lookaround and backreferences
+    idsPCREUnsupported = regexp.MustCompile(`\(\?[=!<>]|\\[1-9]|\(\?[a-zA-Z]*\)|\\[GKQER]|\(\*|\+\+|\*\+|\?\+|\}\+`)
+)
+
+// idsLine is a rule with the line it starts on
+type idsLine struct {
+    text string
+    line int
+}
+
+// idsRule is a parsed rule with the line it starts on
+type idsRule struct {
+    line    int
+    header  []string
+    options []idsOption
+    sid     int
+}
+
+// idsOption is a rule option keyword with its optional value
+type idsOption struct {
+    keyword string
+    value   string
+}
+
+// ValidateSuricataRule validates a Suricata rule file
+func ValidateSuricataRule(detection *models.Detection) (*models.ValidationResult, error) {
+    return validateIDSRules(detection, suricataDialect)
+}
+
+// ValidateSnortRule validates a Snort 2 or Snort 3 rule file
+func ValidateSnortRule(detection *models.Detection) (*models.ValidationResult, error) {
+    return validateIDSRules(detection, snortDialect)
+}
+
+// validateIDSRules validates every rule of a rule file: its header, the
+// required options, flowbits references across the file and pcre options
+func validateIDSRules(detection *models.Detection, dialect *idsDialect) (*models.ValidationResult, error) {
+    result, err := models.NewValidationResult(detection)
+    if err != nil {
+        return nil, utils.WrapError(err, "failed to create validation result")
+    }
+    content, err := detection.GetContent()
+    if err != nil {
+        return nil, utils.WrapError(err, "failed to get detection content")
+    }
+    if format, _ := detection.GetFormat(); format != dialect.format {
+        return nil, utils.ErrInvalidFormat
+    }
+    content = utils.SanitizeInput(content)
+
+    lines := idsRuleLines(content)
+    if len(lines) == 0 {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("No %s rules found", dialect.name),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "rule",
+            IssueCode:   "IDS001",
+            Remediation: "Add a rule such as: alert tcp any any -> any 80 (msg:\"...\"; sid:1000001; rev:1;)",
+        })
+        return result, nil
+    }
+
+    rules := make([]*idsRule, 0, len(lines))
+    sids := make(map[int]int)
+    flowbitsSet := make(map[string]bool)
+    flowbitsChecked := make(map[string]int)
+    protocols := make(map[string]bool)
+
+    for _, l := range lines {
+        rule, issue := parseIDSRule(l.text, l.line)
+        if issue != nil {
+            result.AddIssue(issue)
+            continue
+        }
+        rules = append(rules, rule)
+        for _, issue := range validateIDSHeader(rule, dialect) {
+            result.AddIssue(&issue)
+        }
+        if len(rule.header) > 1 {
+            protocols[strings.ToLower(rule.header[1])] = true
+        }
+        for _, issue := range validateIDSOptions(rule) {
+            result.AddIssue(&issue)
+        }
+
+        if rule.sid > 0 {
+            if first, dup := sids[rule.sid]; dup {
+                result.AddIssue(&models.ValidationIssue{
+                    Message:     fmt.Sprintf("Duplicate sid %d (first used on line %d)", rule.sid, first),
+                    Severity:    models.ValidationSeverityHigh,
+                    Location:    "options.sid",
+                    Line:        rule.line,
+                    IssueCode:   "IDS013",
+                    Remediation: "Give every rule a unique sid",
+                })
+            } else {
+                sids[rule.sid] = rule.line
+            }
+        }
+
+        set, checked, issues := idsFlowbits(rule)
+        for _, issue := range issues {
+            result.AddIssue(&issue)
+        }
+        for _, name := range set {
+            flowbitsSet[name] = true
+        }
+        for _, name := range checked {
+            if _, seen := flowbitsChecked[name]; !seen {
+                flowbitsChecked[name] = rule.line
+            }
+        }
+    }
+
+    // Flowbits checked but never set can only match when another rule file
+    // sets them
+    for _, name := range sortedKeys(toSetKeys(flowbitsChecked)) {
+        if flowbitsSet[name] {
+            continue
+        }
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("Flowbit %s is checked but never set in this rule set", name),
+            Severity:    models.ValidationSeverityLow,
+            Location:    "options.flowbits",
+            Line:        flowbitsChecked[name],
+            IssueCode:   "IDS015",
+            Remediation: fmt.Sprintf("Add the rule that sets %s or make sure it is deployed with this rule", name),
+        })
+    }
+
+    sidList := make([]int, 0, len(sids))
+    for sid := range sids {
+        sidList = append(sidList, sid)
+    }
+    sort.Ints(sidList)
+    result.FormatSpecificDetails["engine"] = dialect.name
+    result.FormatSpecificDetails["rule_count"] = len(rules)
+    result.FormatSpecificDetails["sids"] = sidList
+    result.FormatSpecificDetails["protocols"] = sortedKeys(protocols)
+    result.FormatSpecificDetails["flowbits_set"] = sortedKeys(flowbitsSet)
+    result.FormatSpecificDetails["flowbits_checked"] = sortedKeys(toSetKeys(flowbitsChecked))
+
+    return result, nil
+}
+
+// idsRuleLines joins backslash-continued lines and drops blank lines and
+// comments, keeping the line each rule starts on
+func idsRuleLines(content string) []idsLine {
+    var rules []idsLine
+    var current strings.Builder
+    start := 0
+    for i, raw := range strings.Split(content, "\n") {
+        text := strings.TrimSpace(raw)
+        if current.Len() == 0 {
+            if text == "" || strings.HasPrefix(text, "#") {
+                continue
+            }
+            start = i + 1
+        }
+        if strings.HasSuffix(text, "\\") {
+            current.WriteString(strings.TrimSuffix(text, "\\"))
+            current.WriteString(" ")
+            continue
+        }
+        current.WriteString(text)
+        rules = append(rules, idsLine{text: current.String(), line: start})
+        current.Reset()
+    }
+    if current.Len() > 0 {
+        rules = append(rules, idsLine{text: current.String(), line: start})
+    }
+    return rules
+}
+
+// parseIDSRule splits a rule into its header fields and options
+func parseIDSRule(text string, line int) (*idsRule, *models.ValidationIssue) {
+    open := strings.IndexByte(text, '(')
+    if open < 0 || !strings.HasSuffix(text, ")") {
+        return nil, &models.ValidationIssue{
+            Message:     "Rule options must be enclosed in parentheses at the end of the rule",
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "rule",
+            Line:        line,
+            IssueCode:   "IDS001",
+            Remediation: "Write the rule as: action protocol src_ip src_port -> dst_ip dst_port (options)",
+        }
+    }
+
+    rule := &idsRule{line: line, header: idsHeaderFields(text[:open])}
+    options, err := splitIDSOptions(text[open+1 : len(text)-1])
+    if err != nil {
+        return nil, &models.ValidationIssue{
+            Message:     fmt.Sprintf("Malformed rule options: %v", err),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "options",
+            Line:        line,
+            IssueCode:   "IDS007",
+            Remediation: "Terminate every option with ';' and escape ';' and '\"' inside values",
+        }
+    }
+    rule.options = options
+    return rule, nil
+}
+
+// idsHeaderFields splits a rule header on whitespace outside of brackets, so
+// that address and port lists may contain spaces
+func idsHeaderFields(header string) []string {
+    var fields []string
+    var current strings.Builder
+    depth := 0
+    for _, r := range header {
+        switch {
+        case r == '[':
+            depth++
+        case r == ']':
+            depth--
+        case (r == ' ' || r == '\t') && depth <= 0:
+            if current.Len() > 0 {
+                fields = append(fields, current.String())
+                current.Reset()
+            }
+            continue
+        }
+        current.WriteRune(r)
+    }
+    if current.Len() > 0 {
+        fields = append(fields, current.String())
+    }
+    return fields
+}
+
+// splitIDSOptions splits the option list on unescaped semicolons outside of
+// quoted strings
+func splitIDSOptions(body string) ([]idsOption, error) {
+    var options []idsOption
+    var current strings.Builder
+    quoted := false
+    for i := 0; i < len(body); i++ {
+        c := body[i]
+        switch {
+        case c == '\\' && i+1 < len(body):
+            current.WriteByte(c)
+            current.WriteByte(body[i+1])
+            i++
+            continue
+        case c == '"':
+            quoted = !quoted
+        case c == ';' && !quoted:
+            if option, ok := parseIDSOption(current.String()); ok {
+                options = append(options, option)
+            }
+            current.Reset()
+            continue
+        }
+        current.WriteByte(c)
+    }
+    if quoted {
+        return nil, fmt.Errorf("unterminated quoted string")
+    }
+    if rest := strings.TrimSpace(current.String()); rest != "" {
+        return nil, fmt.Errorf("option %q is not terminated by ';'", rest)
+    }
+    return options, nil
+}
+
+func parseIDSOption(text string) (idsOption, bool) {
+    text = strings.TrimSpace(text)
+    if text == "" {
+        return idsOption{}, false
+    }
+    keyword, value, _ := strings.Cut(text, ":")
+    return idsOption{keyword: strings.TrimSpace(keyword), value: strings.TrimSpace(value)}, true
+}
+
+// validateIDSHeader checks the action, protocol, addresses, ports and direction
+func validateIDSHeader(rule *idsRule, dialect *idsDialect) []models.ValidationIssue {
+    issues := make([]models.ValidationIssue, 0)
+    add := func(code, location, message, remediation string) {
+        issues = append(issues, models.ValidationIssue{
+            Message:     message,
+            Severity:    models.ValidationSeverityHigh,
+            Location:    location,
+            Line:        rule.line,
+            IssueCode:   code,
+            Remediation: remediation,
+        })
+    }
+
+    header := rule.header
+    if len(header) != 7 && !(dialect.serviceRules && len(header) == 2) {
+        add("IDS001", "header", fmt.Sprintf("Rule header has %d fields, expected 7", len(header)),
+            "Write the header as: action protocol src_ip src_port -> dst_ip dst_port")
+        return issues
+    }
+
+    if action := strings.ToLower(header[0]); !dialect.actions[action] {
+        add("IDS002", "header.action", fmt.Sprintf("Unknown %s action: %s", dialect.name, header[0]),
+            "Use one of: "+strings.Join(sortedKeys(dialect.actions), ", "))
+    }
+    if protocol := strings.ToLower(header[1]); !dialect.protocols[protocol] {
+        add("IDS003", "header.protocol", fmt.Sprintf("Unknown %s protocol: %s", dialect.name, header[1]),
+            "Use one of: "+strings.Join(sortedKeys(dialect.protocols), ", "))
+    }
+    if len(header) == 2 {
+        return issues
+    }
+
+    endpoints := []struct {
+        address, port                 int
+        addressLocation, portLocation string
+    }{
+        {2, 3, "header.source", "header.source_port"},
+        {5, 6, "header.destination", "header.destination_port"},
+    }
+    for _, e := range endpoints {
+        if err := validateIDSAddress(header[e.address]); err != nil {
+            add("IDS004", e.addressLocation, fmt.Sprintf("Invalid address %s: %v", header[e.address], err),
+                "Use any, a variable such as $HOME_NET, an IP or CIDR, or a [list] of them, optionally negated with !")
+        }
+        if err := validateIDSPort(header[e.port]); err != nil {
+            add("IDS005", e.portLocation, fmt.Sprintf("Invalid port %s: %v", header[e.port], err),
+                "Use any, a variable, a port, a range such as 1024:65535, or a [list] of them")
+        }
+    }
+    if header[4] != "->" && header[4] != "<>" {
+        add("IDS006", "header.direction", fmt.Sprintf("Invalid direction: %s", header[4]),
+            "Use -> for one direction or <> for both")
+    }
+    return issues
+}
+
+// validateIDSAddress checks an address, variable, CIDR or bracketed list
+func validateIDSAddress(value string) error {
+    return validateIDSList(value, func(item string) error {
+        if item == "any" || idsVariable.MatchString(item) {
+            return nil
+        }
+        if strings.Contains(item, "/") {
+            _, _, err := net.ParseCIDR(item)
+            return err
+        }
+        if net.ParseIP(item) == nil {
+            return fmt.Errorf("%q is not an IP address", item)
+        }
+        return nil
+    })
+}
+
+// validateIDSPort checks a port, range, variable or bracketed list
+func validateIDSPort(value string) error {
+    return validateIDSList(value, func(item string) error {
+        if item == "any" || idsVariable.MatchString(item) {
+            return nil
+        }
+        low, high, isRange := strings.Cut(item, ":")
+        if !isRange {
+            return validatePortNumber(item)
+        }
+        if low == "" && high == "" {
+            return fmt.Errorf("empty port range")
+        }
+        for _, bound := range []string{low, high} {
+            if bound == "" {
+                continue
+            }
+            if err := validatePortNumber(bound); err != nil {
+                return err
+            }
+        }
+        if low != "" && high != "" {
+            l, _ := strconv.Atoi(low)
+            h, _ := strconv.Atoi(high)
+            if l > h {
+                return fmt.Errorf("range %s is reversed", item)
+            }
+        }
+        return nil
+    })
+}
+
+func validatePortNumber(value string) error {
+    port, err := strconv.Atoi(value)
+    if err != nil || port < 0 || port > 65535 {
+        return fmt.Errorf("%q is not a port number", value)
+    }
+    return nil
+}
+
+// validateIDSList applies check to a possibly negated value or each item of
+// a possibly nested bracketed list
+func validateIDSList(value string, check func(string) error) error {
+    value = strings.TrimPrefix(strings.TrimSpace(value), "!")
+    if !strings.HasPrefix(value, "[") {
+        if value == "" {
+            return fmt.Errorf("empty value")
+        }
+        return check(value)
+    }
+    if !strings.HasSuffix(value, "]") {
+        return fmt.Errorf("unterminated list")
+    }
+
+    inner := value[1 : len(value)-1]
+    depth, start := 0, 0
+    for i := 0; i <= len(inner); i++ {
+        if i < len(inner) {
+            switch inner[i] {
+            case '[':
+                depth++
+                continue
+            case ']':
+                depth--
+                continue
+            case ',':
+                if depth > 0 {
+                    continue
+                }
+            default:
+                continue
+            }
+        }
+        if err := validateIDSList(inner[start:i], check); err != nil {
+            return err
+        }
+        start = i + 1
+    }
+    return nil
+}
+
+// validateIDSOptions checks msg, sid, rev, classtype and pcre options
+func validateIDSOptions(rule *idsRule) []models.ValidationIssue {
+    issues := make([]models.ValidationIssue, 0)
+    add := func(code, severity, location, message, remediation string) {
+        issues = append(issues, models.ValidationIssue{
+            Message:     message,
+            Severity:    severity,
+            Location:    location,
+            Line:        rule.line,
+            IssueCode:   code,
+            Remediation: remediation,
+        })
+    }
+
+    values := make(map[string]string)
+    hasContent := false
+    for _, option := range rule.options {
+        keyword := strings.ToLower(option.keyword)
+        if _, seen := values[keyword]; !seen {
+            values[keyword] = option.value
+        }
+        switch keyword {
+        case "content", "uricontent":
+            hasContent = true
+        case "pcre":
+            if err := validateIDSPCRE(option.value); err != nil {
+                add("IDS016", models.ValidationSeverityHigh, "options.pcre",
+                    fmt.Sprintf("Invalid pcre %s: %v", option.value, err),
+                    `Write pcre as "/pattern/flags" with a valid PCRE pattern and modifiers`)
+            }
+        }
+    }
+
+    msg, hasMsg := values["msg"]
+    if !hasMsg || !strings.HasPrefix(msg, `"`) || !strings.HasSuffix(msg, `"`) || len(msg) < 3 {
+        add("IDS008", models.ValidationSeverityHigh, "options.msg", "Missing or empty msg option",
+            `Describe the alert with msg:"..."`)
+    }
+
+    if sid, ok := values["sid"]; !ok {
+        add("IDS008", models.ValidationSeverityHigh, "options.sid", "Missing sid option",
+            "Assign a unique signature ID, e.g. sid:1000001; (1000000-1999999 is reserved for local rules)")
+    } else if n, err := strconv.Atoi(sid); err != nil || n <= 0 {
+        add("IDS009", models.ValidationSeverityHigh, "options.sid", fmt.Sprintf("Invalid sid: %s", sid),
+            "Use a positive integer sid")
+    } else {
+        rule.sid = n
+    }
+
+    if rev, ok := values["rev"]; !ok {
+        add("IDS010", models.ValidationSeverityMedium, "options.rev", "Missing rev option",
+            "Add rev:1; and increment it whenever the rule changes")
+    } else if n, err := strconv.Atoi(rev); err != nil || n <= 0 {
+        add("IDS009", models.ValidationSeverityHigh, "options.rev", fmt.Sprintf("Invalid rev: %s", rev),
+            "Use a positive integer rev")
+    }
+
+    if classtype, ok := values["classtype"]; !ok {
+        add("IDS011", models.ValidationSeverityLow, "options.classtype", "Missing classtype option",
+            "Classify the rule, e.g. classtype:trojan-activity;")
+    } else if !idsClasstypes[strings.ToLower(classtype)] {
+        add("IDS012", models.ValidationSeverityMedium, "options.classtype", fmt.Sprintf("Unknown classtype: %s", classtype),
+            "Use a classtype from classification.config, or add it there before deploying the rule")
+    }
+
+    if _, hasPCRE := values["pcre"]; hasPCRE && !hasContent {
+        add("IDS017", models.ValidationSeverityMedium, "options.pcre", "pcre without a content match is evaluated on every packet",
+            "Add a content option the pcre match is anchored to, so the fast pattern matcher can prefilter")
+    }
+    return issues
+}
+
+// idsFlowbits returns the flowbits a rule sets and checks. isset and
+// isnotset accept several names separated by | or &.
+func idsFlowbits(rule *idsRule) (set, checked []string, issues []models.ValidationIssue) {
+    for _, option := range rule.options {
+        if strings.ToLower(option.keyword) != "flowbits" {
+            continue
+        }
+        command, names, _ := strings.Cut(option.value, ",")
+        command = strings.ToLower(strings.TrimSpace(command))
+        names = strings.TrimSpace(names)
+
+        takesName, known := idsFlowbitCommands[command]
+        switch {
+        case !known:
+            issues = append(issues, models.ValidationIssue{
+                Message:     fmt.Sprintf("Unknown flowbits command: %s", command),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    "options.flowbits",
+                Line:        rule.line,
+                IssueCode:   "IDS014",
+                Remediation: "Use set, unset, toggle, isset, isnotset or noalert",
+            })
+            continue
+        case takesName && names == "":
+            issues = append(issues, models.ValidationIssue{
+                Message:     fmt.Sprintf("flowbits:%s requires a flowbit name", command),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    "options.flowbits",
+                Line:        rule.line,
+                IssueCode:   "IDS014",
+                Remediation: fmt.Sprintf("Name the flowbit, e.g. flowbits:%s,my.flowbit;", command),
+            })
+            continue
+        case !takesName:
+            continue
+        }
+
+        for _, name := range strings.FieldsFunc(names, func(r rune) bool { return r == '|' || r == '&' }) {
+            name = strings.TrimSpace(name)
+            switch command {
+            case "isset", "isnotset":
+                checked = append(checked, name)
+            case "set", "setx", "toggle":
+                set = append(set, name)
+            }
+        }
+    }
+    return set, checked, issues
+}
+
+// validateIDSPCRE checks the delimiters, modifiers and, where the Go regexp
+// engine supports its constructs, the syntax of a pcre option
+func validateIDSPCRE(value string) error {
+    value = strings.TrimSpace(value)
+    if len(value) < 2 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+        return fmt.Errorf("pattern must be quoted")
+    }
+    value = strings.TrimPrefix(value[1:len(value)-1], "!")
+    if !strings.HasPrefix(value, "/") {
+        return fmt.Errorf("pattern must start with /")
+    }
+    end := strings.LastIndexByte(value, '/')
+    if end == 0 {
+        return fmt.Errorf("pattern is not terminated by /")
+    }
+
+    pattern, flags := value[1:end], value[end+1:]
+    for _, flag := range flags {
+        if !strings.ContainsRune(idsPCREFlags, flag) {
+            return fmt.Errorf("unknown modifier %q", flag)
+        }
+    }
+    if pattern == "" {
+        return fmt.Errorf("empty pattern")
+    }
+    if idsPCREUnsupported.MatchString(pattern) {
+        return nil
+    }
+    if _, err := regexp.Compile(pattern); err != nil {
+        return err
+    }
+    return nil
+}
+
+// toSetKeys converts a map keyed by name to a set of its keys
+func toSetKeys(m map[string]int) map[string]bool {
+    set := make(map[string]bool, len(m))
+    for k := range m {
+        set[k] = true
+    }
+    return set
+}
@@ -0,0 +1,168 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+
+    "validation-service/internal/models"
+)
+
+// Issue code namespaces. A namespaced issue code is format.plugin.code, such
+// as crowdstrike.core.CS001, so codes of different plugins and formats do
+// not collide however their legacy codes are spelled.
+const (
+    // CorePlugin names the plugin of the built-in validators, and of plugins
+    // that do not name themselves
+    CorePlugin = "core"
+    // ServiceIssueFormat is the format segment of the codes the service
+    // raises for any format, such as service.core.LOW_CONFIDENCE
+    ServiceIssueFormat = "service"
+    // RulePackPlugin is the plugin segment of the codes of rule pack
+    // violations, such as splunk.rulepack.ACME_NAMING
+    RulePackPlugin = "rulepack"
+
+    issueCodeSeparator = "."
+)
+
+// ErrDuplicateIssueCode is returned when an issue code namespace or an issue
+// code within it is registered twice
+var ErrDuplicateIssueCode = errors.New("issue code already registered")
+
+// NamespacedIssueCode returns the namespaced form format.plugin.code of an
+// issue code
+func NamespacedIssueCode(format, plugin, code string) string {
+    return format + issueCodeSeparator + plugin + issueCodeSeparator + code
+}
+
+// ParseIssueCode splits a namespaced issue code into its format, plugin and
+// legacy code. ok is false for a legacy code.
+func ParseIssueCode(code string) (format, plugin, legacy string, ok bool) {
+    parts := strings.SplitN(code, issueCodeSeparator, 3)
+    if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+        return "", "", code, false
+    }
+    return parts[0], parts[1], parts[2], true
+}
+
+// IssueCodeRegistry reserves the issue code namespace of every registered
+// validator. Each format.plugin namespace is registered once, with the codes
+// it declares, and namespaced codes are assigned to raised issues from it.
+type IssueCodeRegistry struct {
+    mu sync.RWMutex
+    // plugins maps a format to the plugin whose validator serves it
+    plugins map[string]string
+    // codes holds the declared codes of each namespace
+    codes map[string]map[string]bool
+}
+
+// NewIssueCodeRegistry creates a registry holding the namespace of the codes
+// the service raises for any format
+func NewIssueCodeRegistry() *IssueCodeRegistry {
+    r := &IssueCodeRegistry{
+        plugins: make(map[string]string),
+        codes:   make(map[string]map[string]bool),
+    }
+    // The common codes are distinct, so registration cannot fail
+    _ = r.Register(ServiceIssueFormat, CorePlugin, CommonIssueCodes)
+    return r
+}
+
+// Register reserves the namespace format.plugin for codes. A namespace, or a
+// code within it, registered twice is rejected with ErrDuplicateIssueCode.
+func (r *IssueCodeRegistry) Register(format, plugin string, codes []string) error {
+    if !formatNameRegex.MatchString(format) {
+        return fmt.Errorf("invalid issue code format %q", format)
+    }
+    // Plugin names share the syntax of formats
+    if !formatNameRegex.MatchString(plugin) {
+        return fmt.Errorf("invalid issue code plugin %q", plugin)
+    }
+
+    declared := make(map[string]bool, len(codes))
+    for _, code := range codes {
+        if code == "" || strings.Contains(code, issueCodeSeparator) {
+            return fmt.Errorf("invalid issue code %q of %s.%s", code, format, plugin)
+        }
+        if declared[code] {
+            return fmt.Errorf("%w: %s", ErrDuplicateIssueCode, NamespacedIssueCode(format, plugin, code))
+        }
+        declared[code] = true
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    // A format has one namespace, as it is served by one validator
+    if existing, exists := r.plugins[format]; exists {
+        return fmt.Errorf("%w: namespace %s%s%s", ErrDuplicateIssueCode, format, issueCodeSeparator, existing)
+    }
+    r.plugins[format] = plugin
+    r.codes[format+issueCodeSeparator+plugin] = declared
+    return nil
+}
+
+// Codes returns the namespaced codes registered for format, in ascending
+// order
+func (r *IssueCodeRegistry) Codes(format string) []string {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    plugin, ok := r.plugins[format]
+    if !ok {
+        return []string{}
+    }
+    codes := make([]string, 0, len(r.codes[format+issueCodeSeparator+plugin]))
+    for code := range r.codes[format+issueCodeSeparator+plugin] {
+        codes = append(codes, NamespacedIssueCode(format, plugin, code))
+    }
+    sort.Strings(codes)
+    return codes
+}
+
+// Namespace returns the namespaced form of code raised while validating a
+// translation in formats, most specific first. It is taken from the first
+// format whose namespace declares code, then from the codes of the service;
+// codes nobody declares are raised by the service.
+func (r *IssueCodeRegistry) Namespace(code string, formats ...string) string {
+    if _, _, _, ok := ParseIssueCode(code); ok {
+        return code
+    }
+
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    for _, format := range formats {
+        plugin, ok := r.plugins[format]
+        if ok && r.codes[format+issueCodeSeparator+plugin][code] {
+            return NamespacedIssueCode(format, plugin, code)
+        }
+    }
+    return NamespacedIssueCode(ServiceIssueFormat, CorePlugin, code)
+}
+
+// namespaceIssues sets the namespaced code of every issue that has none
+func (r *IssueCodeRegistry) namespaceIssues(issues []models.ValidationIssue, formats ...string) {
+    for i := range issues {
+        if issues[i].NamespacedCode == "" && issues[i].IssueCode != "" {
+            issues[i].NamespacedCode = r.Namespace(issues[i].IssueCode, formats...)
+        }
+    }
+}
+
+// issuePlugin returns the plugin the issue codes of a validator are
+// namespaced under
+func issuePlugin(info FormatInfo) string {
+    if info.Plugin == "" {
+        return CorePlugin
+    }
+    return info.Plugin
+}
+
+// IssueCodes returns the issue code registry of the service
+func (s *ValidationService) IssueCodes() *IssueCodeRegistry {
+    return s.issueCodes
+}
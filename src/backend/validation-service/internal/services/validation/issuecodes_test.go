@@ -0,0 +1,140 @@
+package validation
+
+import (
+    "errors"
+    "reflect"
+    "testing"
+
+    "validation-service/pkg/metrics"
+)
+
+func TestParseIssueCode(t *testing.T) {
+    tests := []struct {
+        code                   string
+        format, plugin, legacy string
+        ok                     bool
+    }{
+        {"crowdstrike.core.CS001", "crowdstrike", "core", "CS001", true},
+        {"splunk.rulepack.ACME.NAMING", "splunk", "rulepack", "ACME.NAMING", true},
+        {"CS001", "", "", "CS001", false},
+        {"splunk.core", "", "", "splunk.core", false},
+        {"splunk..SPL_SYNTAX", "", "", "splunk..SPL_SYNTAX", false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.code, func(t *testing.T) {
+            format, plugin, legacy, ok := ParseIssueCode(tt.code)
+            if format != tt.format || plugin != tt.plugin || legacy != tt.legacy || ok != tt.ok {
+                t.Errorf("ParseIssueCode(%q) = %q, %q, %q, %v, want %q, %q, %q, %v",
+                    tt.code, format, plugin, legacy, ok, tt.format, tt.plugin, tt.legacy, tt.ok)
+            }
+        })
+    }
+}
+
+func TestIssueCodeRegistryNamespace(t *testing.T) {
+    r := NewIssueCodeRegistry()
+    if err := r.Register("sentinel", CorePlugin, []string{"SENT001", "KQL001"}); err != nil {
+        t.Fatalf("Register sentinel: %v", err)
+    }
+    if err := r.Register("kql", CorePlugin, []string{"KQL001"}); err != nil {
+        t.Fatalf("Register kql: %v", err)
+    }
+    if err := r.Register("acme", "vendor", []string{"ACME001"}); err != nil {
+        t.Fatalf("Register acme: %v", err)
+    }
+
+    tests := []struct {
+        name    string
+        code    string
+        formats []string
+        want    string
+    }{
+        {"target format", "SENT001", []string{"sentinel", "kql"}, "sentinel.core.SENT001"},
+        {"most specific format first", "KQL001", []string{"sentinel", "kql"}, "sentinel.core.KQL001"},
+        {"source format", "KQL001", []string{"splunk", "kql"}, "kql.core.KQL001"},
+        {"plugin namespace", "ACME001", []string{"acme"}, "acme.vendor.ACME001"},
+        {"service code", "LOW_CONFIDENCE", []string{"sentinel"}, "service.core.LOW_CONFIDENCE"},
+        {"undeclared code", "XYZ999", []string{"sentinel"}, "service.core.XYZ999"},
+        {"already namespaced", "kql.core.KQL001", []string{"sentinel"}, "kql.core.KQL001"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := r.Namespace(tt.code, tt.formats...); got != tt.want {
+                t.Errorf("Namespace(%q, %v) = %q, want %q", tt.code, tt.formats, got, tt.want)
+            }
+        })
+    }
+
+    if got, want := r.Codes("sentinel"), []string{"sentinel.core.KQL001", "sentinel.core.SENT001"}; !reflect.DeepEqual(got, want) {
+        t.Errorf("Codes(sentinel) = %v, want %v", got, want)
+    }
+    if got := r.Codes("yara"); len(got) != 0 {
+        t.Errorf("Codes(yara) = %v, want none", got)
+    }
+}
+
+func TestIssueCodeRegistryRegister(t *testing.T) {
+    tests := []struct {
+        name      string
+        format    string
+        plugin    string
+        codes     []string
+        duplicate bool
+        wantErr   bool
+    }{
+        {"valid", "acme", "vendor", []string{"ACME001", "ACME002"}, false, false},
+        {"namespace taken", "acme", "other", []string{"ACME001"}, true, true},
+        {"service namespace taken", ServiceIssueFormat, CorePlugin, []string{"X001"}, true, true},
+        {"duplicate code", "beta", CorePlugin, []string{"B001", "B001"}, true, true},
+        {"dotted code", "gamma", CorePlugin, []string{"G.001"}, false, true},
+        {"empty code", "delta", CorePlugin, []string{""}, false, true},
+        {"invalid format", "Bad Format", CorePlugin, []string{"B001"}, false, true},
+        {"invalid plugin", "epsilon", "Bad Plugin", []string{"E001"}, false, true},
+    }
+    r := NewIssueCodeRegistry()
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := r.Register(tt.format, tt.plugin, tt.codes)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("Register error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if errors.Is(err, ErrDuplicateIssueCode) != tt.duplicate {
+                t.Errorf("Register error = %v, want ErrDuplicateIssueCode %v", err, tt.duplicate)
+            }
+        })
+    }
+}
+
+func TestBuiltinValidatorsNamespaceIssueCodes(t *testing.T) {
+    s := NewValidationService(ValidationConfig{})
+    if err := s.RegisterBuiltinValidators(); err != nil {
+        t.Fatalf("RegisterBuiltinValidators: %v", err)
+    }
+    for _, info := range s.SupportedFormats() {
+        if len(info.NamespacedIssueCodes) != len(info.IssueCodes) {
+            t.Errorf("%s: %d namespaced codes for %d issue codes", info.Format, len(info.NamespacedIssueCodes), len(info.IssueCodes))
+        }
+        for _, code := range info.NamespacedIssueCodes {
+            if format, plugin, _, ok := ParseIssueCode(code); !ok || format != info.Format || plugin != CorePlugin {
+                t.Errorf("%s: issue code %q is not in the %s.%s namespace", info.Format, code, info.Format, CorePlugin)
+            }
+        }
+    }
+
+    tests := []struct {
+        code string
+        want string
+    }{
+        {"CS001", "CS001"},
+        {"ZEEK016", "ZEEK016"},
+        {"LOW_CONFIDENCE", "LOW_CONFIDENCE"},
+        {noIssueCode, noIssueCode},
+        {"ACME_NAMING", metrics.OtherLabelValue},
+        {"splunk.core.SPL_SYNTAX", metrics.OtherLabelValue},
+    }
+    for _, tt := range tests {
+        if got := issueCodeLabels.Sanitize(tt.code); got != tt.want {
+            t.Errorf("issue_code label of %q = %q, want %q", tt.code, got, tt.want)
+        }
+    }
+}
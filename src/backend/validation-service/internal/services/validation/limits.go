@@ -0,0 +1,108 @@
+package validation
+
+import (
+    "fmt"
+    "strings"
+
+    "validation-service/internal/models"
+)
+
+// resourceLimitCode is the issue recorded for rules that exceed a resource
+// limit and are rejected without running the format validators
+const resourceLimitCode = "RESOURCE_LIMIT"
+
+// nestingQuotes are the string delimiters of each format; brackets between
+// them do not nest. Formats not listed quote with double and single quotes.
+var nestingQuotes = map[string]string{
+    models.DetectionFormatYara:  `"`,
+    models.DetectionFormatYaraL: "\"`",
+    models.DetectionFormatKQL:   "\"'`",
+}
+
+// nestingExcess locates the first bracket that nests deeper than a limit
+type nestingExcess struct {
+    Line   int
+    Column int
+}
+
+// findNestingExcess scans content for parentheses, brackets and braces
+// nested deeper than limit, outside quoted strings. The scan is iterative
+// and stops at the first excess, so adversarial input costs one pass and no
+// stack. Strings end at a line break so that an apostrophe in a comment or
+// YAML scalar cannot hide the rest of the rule.
+func findNestingExcess(content, quotes string, limit int) (nestingExcess, bool) {
+    depth, line, column := 0, 1, 0
+    var quote rune
+    escaped := false
+    for _, c := range content {
+        column++
+        if c == '\n' {
+            line, column = line+1, 0
+            quote, escaped = 0, false
+            continue
+        }
+        if quote != 0 {
+            switch {
+            case escaped:
+                escaped = false
+            case c == '\\':
+                escaped = true
+            case c == quote:
+                quote = 0
+            }
+            continue
+        }
+        switch {
+        case strings.ContainsRune(quotes, c):
+            quote = c
+        case c == '(' || c == '[' || c == '{':
+            if depth++; depth > limit {
+                return nestingExcess{Line: line, Column: column}, true
+            }
+        case c == ')' || c == ']' || c == '}':
+            if depth > 0 {
+                depth--
+            }
+        }
+    }
+    return nestingExcess{}, false
+}
+
+// withinNestingLimit checks the target detection against the nesting limit
+// of its scoring policy. A rule nested deeper fails with a RESOURCE_LIMIT
+// issue and false is returned, so that no recursive parser or validator
+// reads it.
+func withinNestingLimit(format string, detection *models.Detection, result *models.ValidationResult) bool {
+    limit := result.ScoringPolicy().MaxNestingDepth
+    if limit <= 0 {
+        return true
+    }
+    content, err := detection.GetContent()
+    if err != nil {
+        return true
+    }
+    quotes, ok := nestingQuotes[format]
+    if !ok {
+        quotes = `"'`
+    }
+    excess, exceeded := findNestingExcess(content, quotes, limit)
+    if !exceeded {
+        return true
+    }
+
+    result.Status = models.ValidationStatusError
+    result.AddIssue(&models.ValidationIssue{
+        Message:     fmt.Sprintf("Nesting exceeds the maximum depth of %d for %s rules", limit, format),
+        Severity:    models.ValidationSeverityHigh,
+        Location:    "resource_limits",
+        Line:        excess.Line,
+        Column:      excess.Column,
+        IssueCode:   resourceLimitCode,
+        Remediation: "Flatten nested conditions, groups and subsearches",
+        IssueMetadata: map[string]interface{}{
+            "limit":             "max_nesting_depth",
+            "max_nesting_depth": limit,
+        },
+    })
+    return false
+}
@@ -0,0 +1,46 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    "context"
+
+    "validation-service/internal/models"
+    "validation-service/pkg/logger"
+)
+
+// lintProfileKey carries the lint profile requested for a validation
+type lintProfileKey struct{}
+
+// WithLintProfile returns ctx selecting the lint profile of validations run
+// with it
+func WithLintProfile(ctx context.Context, profile string) context.Context {
+    return context.WithValue(ctx, lintProfileKey{}, profile)
+}
+
+// lintProfile returns the lint profile selected by ctx; empty for the default
+func lintProfile(ctx context.Context) string {
+    profile, _ := ctx.Value(lintProfileKey{}).(string)
+    return profile
+}
+
+// HasLintProfile reports whether profile can be selected. The empty name
+// selects the default profile.
+func (s *ValidationService) HasLintProfile(profile string) bool {
+    if s.config.Linter == nil {
+        return profile == ""
+    }
+    return s.config.Linter.HasProfile(profile)
+}
+
+// lintDetection records the lint findings of the target detection. Lint
+// findings do not affect the score, so a lint failure is only logged.
+func (s *ValidationService) lintDetection(ctx context.Context, targetDetection *models.Detection, result *models.ValidationResult) {
+    if s.config.Linter == nil {
+        return
+    }
+    if err := s.config.Linter.Lint(lintProfile(ctx), targetDetection, result); err != nil {
+        logger.FromContext(ctx).Warn("Failed to lint detection",
+            "error", err,
+        )
+    }
+}
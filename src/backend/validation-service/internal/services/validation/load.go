@@ -0,0 +1,116 @@
+package validation
+
+import (
+    "math"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"          // v1.17.0
+    "github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+)
+
+// costSmoothing is the weight of the latest validation in the moving
+// average of validation cost
+const costSmoothing = 0.1
+
+var (
+    validationsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+        Name:        "validations_in_flight",
+        Help:        "Validations currently running",
+        ConstLabels: prometheus.Labels{"service": "validation"},
+    })
+    validationsQueued = promauto.NewGauge(prometheus.GaugeOpts{
+        Name:        "validations_queued",
+        Help:        "Batch validations accepted and waiting for a worker",
+        ConstLabels: prometheus.Labels{"service": "validation"},
+    })
+    validationCost = promauto.NewGauge(prometheus.GaugeOpts{
+        Name:        "validation_cost_seconds",
+        Help:        "Moving average of the duration of one validation",
+        ConstLabels: prometheus.Labels{"service": "validation"},
+    })
+)
+
+// LoadSignals is a snapshot of the validation load of this replica, for
+// autoscalers such as KEDA
+type LoadSignals struct {
+    // InFlight counts validations currently running
+    InFlight int64 `json:"in_flight"`
+    // QueueDepth counts batch validations accepted and waiting for a worker
+    QueueDepth int64 `json:"queue_depth"`
+    // AvgValidationSeconds is the moving average duration of one validation
+    AvgValidationSeconds float64 `json:"avg_validation_seconds"`
+    // BacklogSeconds estimates the validation time of the running and queued
+    // validations
+    BacklogSeconds float64 `json:"backlog_seconds"`
+}
+
+// loadTracker counts running and queued validations and averages their cost
+type loadTracker struct {
+    inFlight int64
+    queued   int64
+
+    mu   sync.Mutex
+    cost float64
+}
+
+// begin records the start of a validation
+func (t *loadTracker) begin() {
+    atomic.AddInt64(&t.inFlight, 1)
+    validationsInFlight.Inc()
+}
+
+// end records the end of a validation that took d
+func (t *loadTracker) end(d time.Duration) {
+    atomic.AddInt64(&t.inFlight, -1)
+    validationsInFlight.Dec()
+
+    t.mu.Lock()
+    if t.cost == 0 {
+        t.cost = d.Seconds()
+    } else {
+        t.cost += costSmoothing * (d.Seconds() - t.cost)
+    }
+    cost := t.cost
+    t.mu.Unlock()
+    validationCost.Set(cost)
+}
+
+// queue adds delta to the queued validations
+func (t *loadTracker) queue(delta int) {
+    atomic.AddInt64(&t.queued, int64(delta))
+    validationsQueued.Add(float64(delta))
+}
+
+// snapshot returns the current load signals
+func (t *loadTracker) snapshot() LoadSignals {
+    t.mu.Lock()
+    cost := t.cost
+    t.mu.Unlock()
+
+    signals := LoadSignals{
+        InFlight:             atomic.LoadInt64(&t.inFlight),
+        QueueDepth:           atomic.LoadInt64(&t.queued),
+        AvgValidationSeconds: cost,
+    }
+    signals.BacklogSeconds = math.Round(float64(signals.InFlight+signals.QueueDepth)*cost*1000) / 1000
+    return signals
+}
+
+// Load returns the current validation load signals
+func (s *ValidationService) Load() LoadSignals {
+    return s.load.snapshot()
+}
+
+// QueueValidations records n validations accepted for asynchronous
+// processing; DequeueValidations is called as they start or are dropped
+func (s *ValidationService) QueueValidations(n int) {
+    s.load.queue(n)
+}
+
+// DequeueValidations records that n queued validations started or were
+// dropped
+func (s *ValidationService) DequeueValidations(n int) {
+    s.load.queue(-n)
+}
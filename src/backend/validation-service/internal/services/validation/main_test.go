@@ -0,0 +1,15 @@
+package validation
+
+import (
+    "os"
+    "testing"
+
+    "validation-service/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+    if err := logger.InitCLILogger("error"); err != nil {
+        panic(err)
+    }
+    os.Exit(m.Run())
+}
@@ -0,0 +1,86 @@
+package validation
+
+import (
+    "github.com/prometheus/client_golang/prometheus"          // v1.17.0
+    "github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+
+    "validation-service/internal/models"
+    "validation-service/pkg/metrics"
+)
+
+// noIssueCode labels issues reported without an issue code
+const noIssueCode = "none"
+
+var (
+    confidenceScores = promauto.NewHistogramVec(prometheus.HistogramOpts{
+        Name:        "validation_confidence_score",
+        Help:        "Confidence scores of completed validations by target format",
+        Buckets:     prometheus.LinearBuckets(10, 10, 10),
+        ConstLabels: prometheus.Labels{"service": "validation"},
+    }, []string{"target_format"})
+    validationIssues = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name:        "validation_issues_total",
+        Help:        "Issues reported by completed validations by target format, issue code and severity",
+        ConstLabels: prometheus.Labels{"service": "validation"},
+    }, []string{"target_format", "issue_code", "severity"})
+    validationGates = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name:        "validation_gate_total",
+        Help:        "Gate verdicts of completed validations by target format",
+        ConstLabels: prometheus.Labels{"service": "validation"},
+    }, []string{"target_format", "gate"})
+    formatValidationsInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+        Name:        "format_validations_in_flight",
+        Help:        "Validations currently running by target format",
+        ConstLabels: prometheus.Labels{"service": "validation"},
+    }, []string{"target_format"})
+    revalidationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+        Name:        "validation_revalidations_total",
+        Help:        "Background re-validations of stale cached results by outcome",
+        ConstLabels: prometheus.Labels{"service": "validation"},
+    }, []string{"outcome"})
+
+    // issueCodeLabels bounds the issue_code label to the codes the service
+    // and its built-in validators declare. Codes of plugins, rule packs and
+    // other sources are recorded as other.
+    issueCodeLabels = metrics.NewLabelAllowlist("issue_code", append([]string{noIssueCode}, CommonIssueCodes...)...)
+)
+
+// allowBuiltinIssueCodes adds the codes the validators of formats declare
+// to the issue_code label
+func (s *ValidationService) allowBuiltinIssueCodes(formats []string) {
+    for _, format := range formats {
+        for _, namespaced := range s.issueCodes.Codes(format) {
+            _, _, code, _ := ParseIssueCode(namespaced)
+            issueCodeLabels.Allow(code)
+        }
+    }
+}
+
+// trackFormatInFlight counts a running validation of the target format
+// until the returned function is called
+func trackFormatInFlight(targetFormat string) func() {
+    gauge := formatValidationsInFlight.WithLabelValues(targetFormat)
+    gauge.Inc()
+    return gauge.Dec
+}
+
+// recordResultMetrics observes the confidence score and counts the issues
+// of a completed validation. Formats come from the registered validators
+// and issue codes pass through issueCodeLabels, which keeps label
+// cardinality bounded.
+func recordResultMetrics(targetFormat string, result *models.ValidationResult) {
+    if result == nil {
+        return
+    }
+    confidenceScores.WithLabelValues(targetFormat).Observe(result.ConfidenceScore)
+    if result.Metadata.Gate != "" {
+        validationGates.WithLabelValues(targetFormat, result.Metadata.Gate).Inc()
+    }
+    for _, issue := range result.Issues {
+        code := issue.IssueCode
+        if code == "" {
+            code = noIssueCode
+        }
+        validationIssues.WithLabelValues(targetFormat, issueCodeLabels.Sanitize(code), issue.Severity).Inc()
+    }
+}
@@ -4,8 +4,10 @@ package validation
 
 import (
     "context"
+    "errors"
+    "fmt"
     "regexp"
-    "strings"
+    "sort"
     "sync"
 
     "validation-service/internal/models"
@@ -21,9 +23,6 @@ type PaloAltoValidator struct {
     patternCache         *sync.RWMutex
 }
 
-// Global validator instance
-var paloAltoValidator *PaloAltoValidator
-
 // Required field patterns for Palo Alto Networks rules
 var requiredFieldPatterns = map[string]string{
     "rule_name":      `^[a-zA-Z0-9-_]{1,64}$`,
@@ -67,11 +66,13 @@ var fieldWeights = map[string]float64{
     "destination_address": 12.0,
     "application":    10.0,
     "service":        10.0,
+    // content weighs a rule that cannot be parsed at all
+    "content":        100.0,
 }
 
-// init initializes the Palo Alto validator with enhanced validation patterns
-func init() {
-    paloAltoValidator = &PaloAltoValidator{
+// NewPaloAltoValidator creates a Palo Alto validator with its field patterns compiled
+func NewPaloAltoValidator() *PaloAltoValidator {
+    validator := &PaloAltoValidator{
         requiredFieldPatterns: make(map[string]*regexp.Regexp),
         validLogTypes:        validLogTypes,
         fieldWeights:         fieldWeights,
@@ -89,15 +90,16 @@ func init() {
             )
             continue
         }
-        paloAltoValidator.requiredFieldPatterns[field] = compiled
+        validator.requiredFieldPatterns[field] = compiled
     }
+    return validator
 }
 
 // Validate performs comprehensive validation of Palo Alto Networks format detection rules
 func (v *PaloAltoValidator) Validate(ctx context.Context, detection *models.Detection) (*models.ValidationResult, error) {
     // Record validation request metric
     if err := metrics.RecordValidationRequest("paloalto"); err != nil {
-        logger.GetLogger().Error("Failed to record validation request metric", "error", err)
+        logger.FromContext(ctx).Error("Failed to record validation request metric", "error", err)
     }
 
     // Create validation result
@@ -112,29 +114,56 @@ func (v *PaloAltoValidator) Validate(ctx context.Context, detection *models.Dete
         return nil, err
     }
 
+    // Parse the rule from its XML or set command export
+    rule, err := parsePaloAltoRule(content)
+    if err == nil && rule.count == 0 {
+        err = errors.New("no rule found")
+    }
+    if err != nil {
+        issue := models.ValidationIssue{
+            Message:     "Unparseable Palo Alto rule: " + err.Error(),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "content",
+            IssueCode:   "PA005",
+            Remediation: "Provide a PAN-OS XML rule export, such as a rulebase <entry>, or the set commands of the rule",
+        }
+        result.AddIssue(&issue)
+        result.SetConfidenceScore(v.calculateConfidenceScore([]models.ValidationIssue{issue}), "paloalto", "Palo Alto field weights")
+        return result, nil
+    }
+
     // Validate log type
-    if logType, issue := v.validateLogType(content); !logType {
+    if logType, issue := v.validateLogType(rule); !logType {
         result.AddIssue(&issue)
     }
 
     // Validate required fields
-    issues := v.validateRequiredFields(content)
+    issues := v.validateRequiredFields(rule)
+    if rule.count > 1 {
+        issues = append(issues, models.ValidationIssue{
+            Message:     fmt.Sprintf("Export contains %d rules; only the first, %s, is validated", rule.count, rule.name),
+            Severity:    models.ValidationSeverityLow,
+            Location:    "rule_name",
+            IssueCode:   "PA006",
+            Remediation: "Validate each rule of the export separately",
+        })
+    }
     for _, issue := range issues {
         result.AddIssue(&issue)
     }
 
     // Calculate confidence score
-    result.ConfidenceScore = v.calculateConfidenceScore(issues)
+    result.SetConfidenceScore(v.calculateConfidenceScore(issues), "paloalto", "Palo Alto field weights")
 
     // Record validation metrics
     duration := result.Metadata.ValidationTime
     if err := metrics.RecordValidationDuration("paloalto", duration); err != nil {
-        logger.GetLogger().Error("Failed to record validation duration metric", "error", err)
+        logger.FromContext(ctx).Error("Failed to record validation duration metric", "error", err)
     }
 
     if len(issues) > 0 {
         if err := metrics.RecordValidationError("paloalto", "validation"); err != nil {
-            logger.GetLogger().Error("Failed to record validation error metric", "error", err)
+            logger.FromContext(ctx).Error("Failed to record validation error metric", "error", err)
         }
     }
 
@@ -142,8 +171,8 @@ func (v *PaloAltoValidator) Validate(ctx context.Context, detection *models.Dete
 }
 
 // validateLogType validates if the log type specified in the rule is supported
-func (v *PaloAltoValidator) validateLogType(content string) (bool, models.ValidationIssue) {
-    logType := extractLogType(content)
+func (v *PaloAltoValidator) validateLogType(rule *paloAltoRule) (bool, models.ValidationIssue) {
+    logType := rule.value("log_type")
     if logType == "" {
         return false, models.ValidationIssue{
             Message:     "Missing required log type",
@@ -167,13 +196,21 @@ func (v *PaloAltoValidator) validateLogType(content string) (bool, models.Valida
     return true, models.ValidationIssue{}
 }
 
-// validateRequiredFields validates presence and format of required fields
-func (v *PaloAltoValidator) validateRequiredFields(content string) []models.ValidationIssue {
+// validateRequiredFields validates presence and format of required fields.
+// Every member of a list field must match the field's pattern.
+func (v *PaloAltoValidator) validateRequiredFields(rule *paloAltoRule) []models.ValidationIssue {
     var issues []models.ValidationIssue
 
-    for field, pattern := range v.requiredFieldPatterns {
-        value := extractFieldValue(content, field)
-        if value == "" {
+    fields := make([]string, 0, len(v.requiredFieldPatterns))
+    for field := range v.requiredFieldPatterns {
+        fields = append(fields, field)
+    }
+    sort.Strings(fields)
+
+    for _, field := range fields {
+        pattern := v.requiredFieldPatterns[field]
+        values := rule.values(field)
+        if len(values) == 0 {
             issues = append(issues, models.ValidationIssue{
                 Message:     "Missing required field: " + field,
                 Severity:    models.ValidationSeverityHigh,
@@ -184,14 +221,17 @@ func (v *PaloAltoValidator) validateRequiredFields(content string) []models.Vali
             continue
         }
 
-        if !pattern.MatchString(value) {
-            issues = append(issues, models.ValidationIssue{
-                Message:     "Invalid format for field: " + field,
-                Severity:    models.ValidationSeverityMedium,
-                Location:    field,
-                IssueCode:   "PA004",
-                Remediation: "Update field format to match required pattern: " + pattern.String(),
-            })
+        for _, value := range values {
+            if !pattern.MatchString(value) {
+                issues = append(issues, models.ValidationIssue{
+                    Message:     fmt.Sprintf("Invalid format for field: %s (%q)", field, value),
+                    Severity:    models.ValidationSeverityMedium,
+                    Location:    field,
+                    IssueCode:   "PA004",
+                    Remediation: "Update field format to match required pattern: " + pattern.String(),
+                })
+                break
+            }
         }
     }
 
@@ -223,17 +263,3 @@ func (v *PaloAltoValidator) calculateConfidenceScore(issues []models.ValidationI
     }
     return baseScore
 }
-
-// Helper function to extract log type from content
-func extractLogType(content string) string {
-    // Implementation would parse the content to extract log type
-    // This is a placeholder - actual implementation would depend on the rule format
-    return ""
-}
-
-// Helper function to extract field value from content
-func extractFieldValue(content string, field string) string {
-    // Implementation would parse the content to extract field value
-    // This is a placeholder - actual implementation would depend on the rule format
-    return ""
-}
\ No newline at end of file
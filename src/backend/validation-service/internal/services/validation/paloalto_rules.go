@@ -0,0 +1,259 @@
+package validation
+
+import (
+    "encoding/xml"
+    "errors"
+    "strings"
+)
+
+// errPaloAltoFormat is returned for content that is neither a PAN-OS XML
+// export nor set commands
+var errPaloAltoFormat = errors.New("content is neither PAN-OS XML nor set commands")
+
+// paloAltoKeywords maps the XML elements and set command keywords of a rule
+// to the validator's field names. Both exports use the same names.
+var paloAltoKeywords = map[string]string{
+    "log-type":    "log_type",
+    "description": "description",
+    "severity":    "severity",
+    "from":        "source_zone",
+    "to":          "destination_zone",
+    "source":      "source_address",
+    "destination": "destination_address",
+    "application": "application",
+    "service":     "service",
+}
+
+// paloAltoRule is the first rule of a PAN-OS export with its field values
+// by validator field name. Fields holding member lists have one value per
+// member.
+type paloAltoRule struct {
+    name   string
+    fields map[string][]string
+    // count is the number of rules in the export
+    count int
+}
+
+// values returns the values of a field; the rule name is the single value
+// of rule_name
+func (r *paloAltoRule) values(field string) []string {
+    if field == "rule_name" {
+        if r.name == "" {
+            return nil
+        }
+        return []string{r.name}
+    }
+    return r.fields[field]
+}
+
+// value returns the first value of a field, or an empty string
+func (r *paloAltoRule) value(field string) string {
+    if values := r.values(field); len(values) > 0 {
+        return values[0]
+    }
+    return ""
+}
+
+// parsePaloAltoRule parses a PAN-OS XML rule export, such as an <entry>
+// element or a configuration containing one, or the set commands of a rule
+func parsePaloAltoRule(content string) (*paloAltoRule, error) {
+    trimmed := strings.TrimSpace(content)
+    switch {
+    case strings.HasPrefix(trimmed, "<"):
+        return parsePaloAltoXML(trimmed)
+    case strings.HasPrefix(trimmed, "set "):
+        return parsePaloAltoSet(trimmed), nil
+    }
+    return nil, errPaloAltoFormat
+}
+
+// paloAltoNode is an element of a PAN-OS XML export
+type paloAltoNode struct {
+    XMLName  xml.Name
+    Attrs    []xml.Attr     `xml:",any,attr"`
+    Text     string         `xml:",chardata"`
+    Children []paloAltoNode `xml:",any"`
+}
+
+// attr returns the value of an attribute of the element
+func (n *paloAltoNode) attr(name string) string {
+    for _, attr := range n.Attrs {
+        if attr.Name.Local == name {
+            return attr.Value
+        }
+    }
+    return ""
+}
+
+// isRule reports whether the element has rule fields as children
+func (n *paloAltoNode) isRule() bool {
+    for _, child := range n.Children {
+        if _, ok := paloAltoKeywords[child.XMLName.Local]; ok {
+            return true
+        }
+    }
+    return false
+}
+
+// parsePaloAltoXML finds the rule elements of an XML export and reads the
+// fields of the first
+func parsePaloAltoXML(content string) (*paloAltoRule, error) {
+    var root paloAltoNode
+    if err := xml.Unmarshal([]byte(content), &root); err != nil {
+        return nil, err
+    }
+
+    var rules []*paloAltoNode
+    var find func(n *paloAltoNode)
+    find = func(n *paloAltoNode) {
+        if n.isRule() {
+            rules = append(rules, n)
+            return
+        }
+        for i := range n.Children {
+            find(&n.Children[i])
+        }
+    }
+    find(&root)
+
+    rule := &paloAltoRule{fields: make(map[string][]string), count: len(rules)}
+    if len(rules) == 0 {
+        return rule, nil
+    }
+    first := rules[0]
+    rule.name = first.attr("name")
+    for _, child := range first.Children {
+        field, ok := paloAltoKeywords[child.XMLName.Local]
+        if !ok {
+            continue
+        }
+        // List fields hold <member> elements; the others hold text
+        var values []string
+        for _, member := range child.Children {
+            if member.XMLName.Local == "member" {
+                if value := strings.TrimSpace(member.Text); value != "" {
+                    values = append(values, value)
+                }
+            }
+        }
+        if len(values) == 0 {
+            if value := strings.TrimSpace(child.Text); value != "" {
+                values = append(values, value)
+            }
+        }
+        rule.fields[field] = append(rule.fields[field], values...)
+    }
+    return rule, nil
+}
+
+// parsePaloAltoSet reads the fields of the first rule of set commands such
+// as `set rulebase security rules "Block C2" from trust`. Commands outside
+// a rulebase, such as those of a log forwarding profile, contribute the
+// first field keyword they contain. Lines other than set commands are
+// ignored.
+func parsePaloAltoSet(content string) *paloAltoRule {
+    rule := &paloAltoRule{fields: make(map[string][]string)}
+    names := make(map[string]bool)
+    for _, line := range strings.Split(content, "\n") {
+        tokens := tokenizeSetCommand(strings.TrimSpace(line))
+        if len(tokens) < 2 || tokens[0] != "set" {
+            continue
+        }
+
+        rest := tokens[1:]
+        if i := indexOf(rest, "rules"); i >= 0 && i+1 < len(rest) {
+            name := rest[i+1]
+            if !names[name] {
+                names[name] = true
+                if rule.name == "" {
+                    rule.name = name
+                }
+            }
+            if name != rule.name {
+                continue
+            }
+            rest = rest[i+2:]
+            if len(rest) == 0 {
+                continue
+            }
+            if field, ok := paloAltoKeywords[rest[0]]; ok {
+                rule.fields[field] = append(rule.fields[field], setValues(rest[1:])...)
+            }
+            continue
+        }
+
+        for i, token := range rest {
+            if field, ok := paloAltoKeywords[token]; ok {
+                rule.fields[field] = append(rule.fields[field], setValues(rest[i+1:])...)
+                break
+            }
+        }
+    }
+    rule.count = len(names)
+    if rule.count == 0 && len(rule.fields) > 0 {
+        rule.count = 1
+    }
+    return rule
+}
+
+// setValues returns the values of a set command: one value, or the members
+// of a bracketed list
+func setValues(tokens []string) []string {
+    var values []string
+    for _, token := range tokens {
+        if token == "[" || token == "]" {
+            continue
+        }
+        values = append(values, token)
+    }
+    return values
+}
+
+// tokenizeSetCommand splits a set command into words. Quoted words keep
+// their spaces, and list brackets are words of their own.
+func tokenizeSetCommand(line string) []string {
+    var tokens []string
+    var word strings.Builder
+    inWord := false
+    var quote rune
+    flush := func() {
+        if inWord {
+            tokens = append(tokens, word.String())
+            word.Reset()
+            inWord = false
+        }
+    }
+    for _, r := range line {
+        switch {
+        case quote != 0:
+            if r == quote {
+                quote = 0
+                continue
+            }
+            word.WriteRune(r)
+        case r == '"' || r == '\'':
+            quote = r
+            inWord = true
+        case r == '[' || r == ']':
+            flush()
+            tokens = append(tokens, string(r))
+        case r == ' ' || r == '\t' || r == '\r':
+            flush()
+        default:
+            word.WriteRune(r)
+            inWord = true
+        }
+    }
+    flush()
+    return tokens
+}
+
+// indexOf returns the index of the first token equal to s, or -1
+func indexOf(tokens []string, s string) int {
+    for i, token := range tokens {
+        if token == s {
+            return i
+        }
+    }
+    return -1
+}
@@ -0,0 +1,165 @@
+package validation
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "gopkg.in/yaml.v3" // v3.0.1
+
+    "validation-service/internal/models"
+    "validation-service/internal/platform"
+    "validation-service/pkg/logger"
+)
+
+// platformRejectedCode is the issue raised for each error a target platform
+// reports for a submitted query
+const platformRejectedCode = "PLATFORM001"
+
+// platformDetailsKey is the format-specific details entry listing the
+// verdicts of the platform connectors
+const platformDetailsKey = "platform_validation"
+
+// PlatformValidation records how a platform connector judged the query of a
+// target detection
+type PlatformValidation struct {
+    Connector string `json:"connector"`
+    Platform  string `json:"platform"`
+    // Valid is the verdict of the platform; nil when the platform could not
+    // be asked, in which case Error says why
+    Valid      *bool            `json:"valid,omitempty"`
+    Errors     []platform.Error `json:"errors,omitempty"`
+    Error      string           `json:"error,omitempty"`
+    DurationMs int64            `json:"duration_ms"`
+}
+
+// RegisterPlatformConnector submits the targets of the connector's formats
+// to its platform after the format validator has completed
+func (s *ValidationService) RegisterPlatformConnector(connector platform.Connector) error {
+    if connector == nil || connector.Name() == "" {
+        return ErrInvalidValidator
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    for _, format := range connector.Formats() {
+        for _, registered := range s.platforms[format] {
+            if registered.Name() == connector.Name() {
+                return fmt.Errorf("platform connector already registered for format %s: %s", format, connector.Name())
+            }
+        }
+        s.platforms[format] = append(s.platforms[format], connector)
+    }
+    s.log.Info("Platform connector registered successfully",
+        "connector", connector.Name(),
+        "platform", connector.Type(),
+        "formats", connector.Formats(),
+    )
+    return nil
+}
+
+// getPlatformConnectors returns the connectors registered for a format
+func (s *ValidationService) getPlatformConnectors(format string) []platform.Connector {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    return s.platforms[format]
+}
+
+// platformDigest names the connectors of a format, so results cached before
+// a connector was added are not served
+func (s *ValidationService) platformDigest(format string) string {
+    connectors := s.getPlatformConnectors(format)
+    names := make([]string, 0, len(connectors))
+    for _, connector := range connectors {
+        names = append(names, connector.Name())
+    }
+    sort.Strings(names)
+    return strings.Join(names, ",")
+}
+
+// validatePlatforms submits the query of the target to the connectors of its
+// format. Each error a platform reports is a high issue carrying the
+// connector and platform it came from; platforms that cannot be reached are
+// recorded in the details without affecting the score.
+func (s *ValidationService) validatePlatforms(ctx context.Context, targetDetection *models.Detection, targetFormat string, result *models.ValidationResult) {
+    connectors := s.getPlatformConnectors(targetFormat)
+    if len(connectors) == 0 {
+        return
+    }
+    query := platformQuery(targetDetection, targetFormat)
+    if strings.TrimSpace(query) == "" {
+        return
+    }
+
+    validations := make([]PlatformValidation, 0, len(connectors))
+    for _, connector := range connectors {
+        stage := s.startStage(ctx, "platform."+connector.Name(), result)
+        start := time.Now()
+        check, err := connector.Validate(ctx, query)
+        platform.Observe(connector, start, check, err)
+
+        validation := PlatformValidation{
+            Connector:  connector.Name(),
+            Platform:   connector.Type(),
+            DurationMs: time.Since(start).Milliseconds(),
+        }
+        if err != nil {
+            validation.Error = err.Error()
+            logger.FromContext(ctx).Warn("Platform connector could not validate query",
+                "error", err,
+                "connector", connector.Name(),
+                "target_format", targetFormat,
+            )
+        } else {
+            validation.Valid = &check.Valid
+            validation.Errors = check.Errors
+            for _, platformErr := range check.Errors {
+                result.AddIssue(platformIssue(connector, platformErr))
+            }
+        }
+        validations = append(validations, validation)
+        stage.end(result, "")
+    }
+    result.FormatSpecificDetails[platformDetailsKey] = validations
+}
+
+// platformIssue reports an error of a platform with its provenance
+func platformIssue(connector platform.Connector, platformErr platform.Error) *models.ValidationIssue {
+    metadata := map[string]interface{}{
+        "provenance": "platform",
+        "platform":   connector.Type(),
+        "connector":  connector.Name(),
+    }
+    if platformErr.Code != "" {
+        metadata["platform_code"] = platformErr.Code
+    }
+    return &models.ValidationIssue{
+        Message:       fmt.Sprintf("%s rejected the query: %s", connector.Name(), platformErr.Message),
+        Severity:      models.ValidationSeverityHigh,
+        Location:      "platform." + connector.Name(),
+        Line:          platformErr.Line,
+        Column:        platformErr.Column,
+        IssueCode:     platformRejectedCode,
+        Remediation:   "Correct the query where the platform reports the error; the platform that runs the query does not accept it",
+        IssueMetadata: metadata,
+    }
+}
+
+// platformQuery returns the query a platform validates: the query of a
+// Sentinel analytics rule and the content of other formats
+func platformQuery(detection *models.Detection, format string) string {
+    if format != models.DetectionFormatSentinel {
+        return detection.Content
+    }
+    var rule struct {
+        Query string `yaml:"query"`
+    }
+    if yaml.Unmarshal([]byte(detection.Content), &rule) != nil {
+        return ""
+    }
+    return rule.Query
+}
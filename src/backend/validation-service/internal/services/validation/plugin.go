@@ -0,0 +1,158 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "sync"
+
+    "validation-service/internal/models"
+)
+
+// ErrDuplicateFormat is returned when two plugins provide the same format
+var ErrDuplicateFormat = errors.New("format validator already registered")
+
+// FormatValidator is the plugin interface implemented by every format
+// validator, built-in or external. A FormatValidator checks a single
+// detection; the validation service merges its findings into the result of
+// the translation being validated.
+type FormatValidator interface {
+    // Describe returns the format and capabilities of the validator
+    Describe() FormatInfo
+    // ValidateDetection validates a single detection into a new result
+    ValidateDetection(ctx context.Context, detection *models.Detection) (*models.ValidationResult, error)
+}
+
+// PluginOptions are passed to every FormatValidatorFactory
+type PluginOptions struct {
+    StrictMode bool
+    // Scoring sets the complexity limits of the built-in validators
+    Scoring *ScoringPolicies
+    // QRadarVersion is the QRadar release AQL targets are checked against
+    QRadarVersion string
+}
+
+// FormatValidatorFactory creates a format validator from the service options
+type FormatValidatorFactory func(opts PluginOptions) (FormatValidator, error)
+
+// funcValidator adapts a validation function to FormatValidator
+type funcValidator struct {
+    info     FormatInfo
+    validate detectionValidateFunc
+}
+
+// NewFormatValidator creates a FormatValidator from its capabilities and a
+// validation function
+func NewFormatValidator(info FormatInfo, validate func(ctx context.Context, detection *models.Detection) (*models.ValidationResult, error)) FormatValidator {
+    return &funcValidator{info: info, validate: validate}
+}
+
+// Describe returns the validator capabilities
+func (v *funcValidator) Describe() FormatInfo {
+    return v.info
+}
+
+// ValidateDetection runs the validation function
+func (v *funcValidator) ValidateDetection(ctx context.Context, detection *models.Detection) (*models.ValidationResult, error) {
+    return v.validate(ctx, detection)
+}
+
+// Registry holds format validator factories by format. Registration order is
+// preserved so validators are built deterministically.
+type Registry struct {
+    mu        sync.RWMutex
+    factories map[string]FormatValidatorFactory
+    order     []string
+}
+
+// NewRegistry creates an empty validator registry
+func NewRegistry() *Registry {
+    return &Registry{
+        factories: make(map[string]FormatValidatorFactory),
+    }
+}
+
+// Register adds the factory for format
+func (r *Registry) Register(format string, factory FormatValidatorFactory) error {
+    if format == "" {
+        return fmt.Errorf("format cannot be empty")
+    }
+    if factory == nil {
+        return ErrInvalidValidator
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if _, exists := r.factories[format]; exists {
+        return fmt.Errorf("%w: %s", ErrDuplicateFormat, format)
+    }
+    r.factories[format] = factory
+    r.order = append(r.order, format)
+    return nil
+}
+
+// Formats returns the registered formats in ascending order
+func (r *Registry) Formats() []string {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    formats := append([]string(nil), r.order...)
+    sort.Strings(formats)
+    return formats
+}
+
+// Build creates a validator from every registered factory, in registration
+// order. A validator describing a different format than it was registered
+// under is rejected.
+func (r *Registry) Build(opts PluginOptions) ([]FormatValidator, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    validators := make([]FormatValidator, 0, len(r.order))
+    for _, format := range r.order {
+        validator, err := r.factories[format](opts)
+        if err != nil {
+            return nil, fmt.Errorf("building %s validator: %w", format, err)
+        }
+        if described := validator.Describe().Format; described != format {
+            return nil, fmt.Errorf("%s validator describes format %q", format, described)
+        }
+        validators = append(validators, validator)
+    }
+    return validators, nil
+}
+
+// RegisterFormatValidator registers a FormatValidator for the format it
+// describes and reserves the namespace of its issue codes. A validator whose
+// namespace is taken is rejected with ErrDuplicateIssueCode.
+func (s *ValidationService) RegisterFormatValidator(validator FormatValidator) error {
+    if validator == nil {
+        return ErrInvalidValidator
+    }
+    info := validator.Describe()
+    if err := s.issueCodes.Register(info.Format, issuePlugin(info), info.IssueCodes); err != nil {
+        return err
+    }
+    return s.RegisterValidator(info.Format, &formatValidator{plugin: validator})
+}
+
+// RegisterRegistry builds and registers every validator in registry
+func (s *ValidationService) RegisterRegistry(registry *Registry) error {
+    validators, err := registry.Build(PluginOptions{
+        StrictMode:    s.config.StrictMode,
+        Scoring:       s.config.Scoring,
+        QRadarVersion: s.config.QRadarVersion,
+    })
+    if err != nil {
+        return err
+    }
+    for _, validator := range validators {
+        if err := s.RegisterFormatValidator(validator); err != nil {
+            return fmt.Errorf("registering %s validator: %w", validator.Describe().Format, err)
+        }
+    }
+    return nil
+}
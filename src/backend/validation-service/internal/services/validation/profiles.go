@@ -0,0 +1,311 @@
+package validation
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "sort"
+    "strings"
+
+    "gopkg.in/yaml.v3" // v3.0.1
+
+    "validation-service/internal/config"
+    "validation-service/internal/models"
+    yaraparser "validation-service/internal/parser/yara"
+)
+
+// Built-in validation profiles
+const (
+    ProfileStrict     = "strict"
+    ProfileStandard   = "standard"
+    ProfilePermissive = "permissive"
+)
+
+// requiredMetaCode is the issue raised for a meta field the validation
+// profile requires and the target detection does not declare
+const requiredMetaCode = "PROFILE001"
+
+// builtinProfiles returns the built-in validation profiles. The standard
+// profile overrides nothing, so it judges validations by the scoring
+// configuration alone.
+func builtinProfiles() map[string]config.ValidationProfile {
+    strictMinConfidence, permissiveMinConfidence := 98.0, 80.0
+    return map[string]config.ValidationProfile{
+        ProfileStrict: {
+            Description:  "Warnings fail the validation, issues weigh double, medium issues block the gate and rules must declare an author and a description",
+            Strict:       true,
+            RequiredMeta: []string{"author", "description"},
+            Scoring: config.ScoringProfile{
+                SeverityWeights: map[string]float64{
+                    models.ValidationSeverityHigh:   20,
+                    models.ValidationSeverityMedium: 10,
+                    models.ValidationSeverityLow:    4,
+                },
+                MinConfidence:   &strictMinConfidence,
+                MaxNestingDepth: 16,
+            },
+            Gate: config.GatePolicy{
+                BlockingSeverities: []string{models.ValidationSeverityHigh, models.ValidationSeverityMedium},
+            },
+        },
+        ProfileStandard: {
+            Description: "The configured scoring of each target format",
+        },
+        ProfilePermissive: {
+            Description: "A lower confidence threshold and lighter penalties for exploratory translations",
+            Scoring: config.ScoringProfile{
+                SeverityWeights: map[string]float64{
+                    models.ValidationSeverityHigh:   10,
+                    models.ValidationSeverityMedium: 3,
+                    models.ValidationSeverityLow:    1,
+                },
+                MinConfidence:   &permissiveMinConfidence,
+                MaxNestingDepth: 64,
+            },
+        },
+    }
+}
+
+// Profiles resolves the validation profiles requests select. A nil
+// *Profiles knows no profile and judges every validation by the scoring
+// configuration alone.
+type Profiles struct {
+    defaultName string
+    profiles    map[string]config.ValidationProfile
+}
+
+// NewProfiles creates the built-in validation profiles extended with the
+// profiles of cfg
+func NewProfiles(cfg config.ValidationConfig) (*Profiles, error) {
+    p := &Profiles{defaultName: cfg.DefaultProfile, profiles: builtinProfiles()}
+    if p.defaultName == "" {
+        p.defaultName = ProfileStandard
+    }
+    for name, profile := range cfg.Profiles {
+        if name == "" {
+            return nil, fmt.Errorf("validation profile name is empty")
+        }
+        p.profiles[name] = profile
+    }
+    if _, ok := p.profiles[p.defaultName]; !ok {
+        return nil, fmt.Errorf("unknown default validation profile: %s", p.defaultName)
+    }
+    return p, nil
+}
+
+// Has reports whether profile can be selected. The empty name selects the
+// default profile.
+func (p *Profiles) Has(profile string) bool {
+    if profile == "" {
+        return true
+    }
+    if p == nil {
+        return false
+    }
+    _, ok := p.profiles[profile]
+    return ok
+}
+
+// Names returns the names of the profiles, sorted
+func (p *Profiles) Names() []string {
+    if p == nil {
+        return nil
+    }
+    names := make([]string, 0, len(p.profiles))
+    for name := range p.profiles {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// resolve returns the name and definition of the profile selected by name,
+// the default profile when name is empty. ok is false for a nil *Profiles
+// and unknown names.
+func (p *Profiles) resolve(name string) (string, config.ValidationProfile, bool) {
+    if p == nil {
+        return "", config.ValidationProfile{}, false
+    }
+    if name == "" {
+        name = p.defaultName
+    }
+    profile, ok := p.profiles[name]
+    return name, profile, ok
+}
+
+// cacheDigest identifies the profile selected by name and its definition,
+// so that results cached before the definition changed are not served
+func (p *Profiles) cacheDigest(name string) string {
+    name, profile, ok := p.resolve(name)
+    if !ok {
+        return ""
+    }
+    data, err := json.Marshal(profile)
+    if err != nil {
+        return name
+    }
+    sum := sha256.Sum256(data)
+    return name + ":" + hex.EncodeToString(sum[:])
+}
+
+// applyStrictness reports warnings as errors when the profile of the result
+// is strict
+func (p *Profiles) applyStrictness(result *models.ValidationResult) {
+    if _, profile, ok := p.resolve(result.Metadata.Profile); ok && profile.Strict && result.Status == models.ValidationStatusWarning {
+        result.Status = models.ValidationStatusError
+    }
+}
+
+// checkRequiredMeta reports each meta field the profile of the result
+// requires that the target detection does not declare
+func (p *Profiles) checkRequiredMeta(detection *models.Detection, format string, result *models.ValidationResult) {
+    name, profile, ok := p.resolve(result.Metadata.Profile)
+    if !ok || len(profile.RequiredMeta) == 0 {
+        return
+    }
+    declared := declaredMeta(detection, format)
+    for _, field := range profile.RequiredMeta {
+        if declared[field] {
+            continue
+        }
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("Missing meta field %s required by the %s profile", field, name),
+            Severity:    models.ValidationSeverityMedium,
+            Location:    "meta." + field,
+            IssueCode:   requiredMetaCode,
+            Remediation: fmt.Sprintf("Declare %s in the detection metadata or in the meta of the rule", field),
+        })
+    }
+}
+
+// applyValidationProfile overrides the settings of policy that profile sets
+func applyValidationProfile(policy *models.ScoringPolicy, profile config.ValidationProfile) {
+    applyScoringProfile(policy, profile.Scoring)
+    if len(profile.IssueSeverities) == 0 {
+        return
+    }
+    policy.IssueSeverities = make(map[string]string, len(profile.IssueSeverities))
+    for code, severity := range profile.IssueSeverities {
+        policy.IssueSeverities[code] = severity
+    }
+}
+
+// declaredMeta returns the meta fields a detection declares with a value in
+// every rule of its content
+func declaredMeta(detection *models.Detection, format string) map[string]bool {
+    rules := ruleMeta(detection, format)
+    counts := make(map[string]int)
+    for _, meta := range rules {
+        for key, value := range meta {
+            if hasMetaValue(value) {
+                counts[key]++
+            }
+        }
+    }
+    declared := make(map[string]bool, len(counts))
+    for key, count := range counts {
+        if count == len(rules) {
+            declared[key] = true
+        }
+    }
+    return declared
+}
+
+// ruleMeta returns the meta of each rule of a detection: the keys of its
+// metadata and the meta of its content, which are the top-level keys of
+// Sigma and Sentinel rules and the meta sections of YARA and YARA-L rules.
+// The metadata applies to every rule, and a detection whose content has no
+// rules has the metadata alone.
+func ruleMeta(detection *models.Detection, format string) []map[string]interface{} {
+    var metadata map[string]interface{}
+    if len(detection.Metadata) > 0 {
+        _ = json.Unmarshal(detection.Metadata, &metadata)
+    }
+
+    var rules []map[string]interface{}
+    switch format {
+    case models.DetectionFormatSigma, models.DetectionFormatSentinel:
+        var rule map[string]interface{}
+        if yaml.Unmarshal([]byte(detection.Content), &rule) == nil && rule != nil {
+            rules = append(rules, rule)
+        }
+    case models.DetectionFormatYara:
+        file, _ := yaraparser.Parse(detection.Content)
+        if file == nil {
+            break
+        }
+        for _, rule := range file.Rules {
+            meta := make(map[string]interface{}, len(rule.Meta))
+            for _, entry := range rule.Meta {
+                if _, ok := meta[entry.Key]; !ok {
+                    meta[entry.Key] = entry.Value
+                }
+            }
+            rules = append(rules, meta)
+        }
+    case models.DetectionFormatYaraL:
+        rule, issue := parseYARALRule(detection.Content)
+        if issue != nil || rule.sections["meta"] == nil {
+            break
+        }
+        meta := make(map[string]interface{})
+        for _, line := range rule.sections["meta"].lines {
+            if match := yaralMetaEntry.FindStringSubmatch(line.text); match != nil {
+                meta[match[1]] = strings.Trim(match[2], `"`)
+            }
+        }
+        rules = append(rules, meta)
+    }
+
+    if len(rules) == 0 {
+        rules = append(rules, make(map[string]interface{}))
+    }
+    for _, meta := range rules {
+        for key, value := range metadata {
+            if !hasMetaValue(meta[key]) {
+                meta[key] = value
+            }
+        }
+    }
+    return rules
+}
+
+// hasMetaValue reports whether a meta value is set; empty strings are not
+func hasMetaValue(value interface{}) bool {
+    switch v := value.(type) {
+    case nil:
+        return false
+    case string:
+        return strings.TrimSpace(v) != ""
+    }
+    return true
+}
+
+// profileKey carries the validation profile requested for a validation
+type profileKey struct{}
+
+// WithProfile returns ctx selecting the validation profile of validations
+// run with it
+func WithProfile(ctx context.Context, profile string) context.Context {
+    return context.WithValue(ctx, profileKey{}, profile)
+}
+
+// profileName returns the validation profile selected by ctx; empty for the
+// default
+func profileName(ctx context.Context) string {
+    profile, _ := ctx.Value(profileKey{}).(string)
+    return profile
+}
+
+// HasProfile reports whether a validation profile can be selected. The
+// empty name selects the default profile.
+func (s *ValidationService) HasProfile(profile string) bool {
+    return s.config.Profiles.Has(profile)
+}
+
+// Profiles returns the names of the validation profiles requests can select
+func (s *ValidationService) Profiles() []string {
+    return s.config.Profiles.Names()
+}
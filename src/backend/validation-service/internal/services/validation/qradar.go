@@ -2,36 +2,109 @@
 package validation
 
 import (
-    "regexp"
+    "fmt"
+    "strconv"
     "strings"
+    "time"
 
     "internal/models"
+    aqlparser "internal/parser/aql"
     "pkg/utils"
-    "pkg/utils/helpers"
 )
 
-// Regular expression patterns for QRadar AQL validation
-var (
-    // Pattern for valid QRadar field names (alphanumeric and underscore)
-    qradarFieldPattern = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+// qradarDatabases are the Ariel databases a query can select from
+var qradarDatabases = map[string]bool{"events": true, "flows": true}
 
-    // Pattern for QRadar function calls (uppercase with parentheses)
-    qradarFunctionPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*\(`)
+// qradarTimeUnits are the units of a LAST clause
+var qradarTimeUnits = map[string]bool{
+    "MINUTE": true, "MINUTES": true, "HOUR": true, "HOURS": true, "DAY": true, "DAYS": true,
+}
 
-    // Pattern for valid QRadar operators
-    qradarOperatorPattern = regexp.MustCompile(`(=|!=|>|<|>=|<=|IN|LIKE|MATCHES)`)
+// qradarTimeLayouts are the accepted START/STOP timestamp layouts
+var qradarTimeLayouts = []string{"2006-01-02 15:04", "2006-01-02 15:04:05"}
+
+// aqlFunction describes an AQL function: its argument count, whether it
+// aggregates rows, and the QRadar release that introduced it when later
+// than the releases the validator otherwise assumes
+type aqlFunction struct {
+    MinArgs   int
+    MaxArgs   int // -1 when variadic
+    Aggregate bool
+    Since     string
+}
 
-    // Pattern for SELECT statement validation
-    qradarSelectPattern = regexp.MustCompile(`^\s*SELECT\s+([\w\s,*]+)\s+FROM`)
+// qradarFunctions are the supported AQL functions by upper-cased name
+var qradarFunctions = map[string]aqlFunction{
+    // Aggregates
+    "COUNT":       {MinArgs: 1, MaxArgs: 1, Aggregate: true},
+    "SUM":         {MinArgs: 1, MaxArgs: 1, Aggregate: true},
+    "AVG":         {MinArgs: 1, MaxArgs: 1, Aggregate: true},
+    "MIN":         {MinArgs: 1, MaxArgs: 1, Aggregate: true},
+    "MAX":         {MinArgs: 1, MaxArgs: 1, Aggregate: true},
+    "STDEV":       {MinArgs: 1, MaxArgs: 1, Aggregate: true},
+    "STDEVP":      {MinArgs: 1, MaxArgs: 1, Aggregate: true},
+    "UNIQUECOUNT": {MinArgs: 1, MaxArgs: 1, Aggregate: true, Since: "7.3.0"},
+
+    // Property functions resolving IDs and addresses to names
+    "LOGSOURCENAME":      {MinArgs: 1, MaxArgs: 1},
+    "LOGSOURCETYPENAME":  {MinArgs: 1, MaxArgs: 1},
+    "LOGSOURCEGROUPNAME": {MinArgs: 1, MaxArgs: 1},
+    "QIDNAME":            {MinArgs: 1, MaxArgs: 1},
+    "QIDDESCRIPTION":     {MinArgs: 1, MaxArgs: 1},
+    "CATEGORYNAME":       {MinArgs: 1, MaxArgs: 1},
+    "PROTOCOLNAME":       {MinArgs: 1, MaxArgs: 1},
+    "RULENAME":           {MinArgs: 1, MaxArgs: 1},
+    "APPLICATIONNAME":    {MinArgs: 1, MaxArgs: 1},
+    "DOMAINNAME":         {MinArgs: 1, MaxArgs: 1},
+    "NETWORKNAME":        {MinArgs: 1, MaxArgs: 2},
+    "ASSETHOSTNAME":      {MinArgs: 1, MaxArgs: 2},
+    "ASSETUSER":          {MinArgs: 1, MaxArgs: 2},
+    "ASSETPROPERTY":      {MinArgs: 2, MaxArgs: 3, Since: "7.3.0"},
+    "INCIDR":             {MinArgs: 2, MaxArgs: 2},
+    "INOFFENSE":          {MinArgs: 1, MaxArgs: 1, Since: "7.3.0"},
+
+    // Reference data lookups
+    "REFERENCEMAP":            {MinArgs: 2, MaxArgs: 3},
+    "REFERENCETABLE":          {MinArgs: 3, MaxArgs: 4},
+    "REFERENCESETCONTAINS":    {MinArgs: 2, MaxArgs: 2},
+    "REFERENCEMAPSETCONTAINS": {MinArgs: 3, MaxArgs: 3, Since: "7.3.0"},
+
+    // Geographic lookups
+    "GEO::LOOKUP":   {MinArgs: 2, MaxArgs: -1, Since: "7.3.2"},
+    "GEO::DISTANCE": {MinArgs: 2, MaxArgs: 2, Since: "7.3.2"},
+
+    // Scalar functions
+    "DATEFORMAT":    {MinArgs: 2, MaxArgs: 2},
+    "PARSEDATETIME": {MinArgs: 1, MaxArgs: 1},
+    "NOW":           {MinArgs: 0, MaxArgs: 0},
+    "CONCAT":        {MinArgs: 1, MaxArgs: -1},
+    "UPPER":         {MinArgs: 1, MaxArgs: 1},
+    "LOWER":         {MinArgs: 1, MaxArgs: 1},
+    "STRLEN":        {MinArgs: 1, MaxArgs: 1},
+    "SUBSTRING":     {MinArgs: 3, MaxArgs: 3},
+    "STRPOS":        {MinArgs: 2, MaxArgs: 3, Since: "7.3.0"},
+    "REPLACEFIRST":  {MinArgs: 3, MaxArgs: 3, Since: "7.3.0"},
+    "REPLACEALL":    {MinArgs: 3, MaxArgs: 3, Since: "7.3.0"},
+    "STR":           {MinArgs: 1, MaxArgs: 1},
+    "LONG":          {MinArgs: 1, MaxArgs: 1},
+    "DOUBLE":        {MinArgs: 1, MaxArgs: 1},
+    "UTF8":          {MinArgs: 1, MaxArgs: 1},
+    "BASE64":        {MinArgs: 1, MaxArgs: 1},
+}
 
-    // Pattern for FROM clause validation
-    qradarFromPattern = regexp.MustCompile(`FROM\s+([\w\s,]+)(\s+WHERE|\s+GROUP BY|$)`)
-)
+// qradarSubselectSince is the QRadar release that introduced subselects
+const qradarSubselectSince = "7.3.0"
 
-// ValidateQRadarDetection validates a QRadar AQL detection rule for syntax correctness,
-// field naming conventions, function usage, and operator placement while calculating
-// a confidence score.
+// ValidateQRadarDetection validates a QRadar AQL detection rule without a
+// target QRadar release; see validateQRadar
 func ValidateQRadarDetection(detection *models.Detection) (*models.ValidationResult, error) {
+    return validateQRadar(detection, "")
+}
+
+// validateQRadar parses an AQL query and validates its clauses, time range,
+// Ariel database and function usage. When version is set, features
+// introduced by later QRadar releases are reported.
+func validateQRadar(detection *models.Detection, version string) (*models.ValidationResult, error) {
     // Create new validation result
     result, err := models.NewValidationResult(detection)
     if err != nil {
@@ -44,203 +117,318 @@ func ValidateQRadarDetection(detection *models.Detection) (*models.ValidationRes
         return nil, utils.WrapError(err, "failed to get detection content")
     }
 
-    // Sanitize input
-    content = helpers.SanitizeInput(content)
-
-    // Validate basic AQL syntax structure
-    if err := validateAQLSyntax(content); err != nil {
-        result.AddIssue(&models.ValidationIssue{
-            Message:     "Invalid AQL syntax structure",
+    // Parse the query and report syntax errors at their position
+    query, parseErrs := aqlparser.Parse(content)
+    for _, perr := range parseErrs {
+        addAQLIssue(result, perr.Pos, &models.ValidationIssue{
+            Message:     fmt.Sprintf("Syntax error: %s", perr.Msg),
             Severity:    models.ValidationSeverityHigh,
-            Location:    "query",
+            Location:    fmt.Sprintf("line:%d", perr.Pos.Line),
             IssueCode:   "QR001",
-            Remediation: "Ensure query follows basic AQL structure: SELECT ... FROM ... [WHERE] [GROUP BY]",
+            Remediation: "Follow the AQL clause order SELECT ... FROM ... [WHERE] [GROUP BY] [HAVING] [ORDER BY] [LIMIT] [LAST | START/STOP] and check quoting and parentheses",
         })
     }
 
-    // Validate field names
-    if err := validateFieldNames(content, result); err != nil {
-        result.AddIssue(&models.ValidationIssue{
-            Message:     "Invalid field name detected",
-            Severity:    models.ValidationSeverityHigh,
-            Location:    "fields",
-            IssueCode:   "QR002",
-            Remediation: "Use only alphanumeric characters and underscores in field names",
-        })
+    // Validate every query and subselect
+    databases := make(map[string]bool)
+    functions := make(map[string]bool)
+    subselects := 0
+    aqlparser.Inspect(query, func(q *aqlparser.Query) bool {
+        if q != query {
+            subselects++
+        }
+        if q.From != nil && q.From.Database != "" {
+            databases[q.From.Database] = true
+        }
+        validateAQLSource(q, result)
+        validateAQLTime(q.Time, result)
+        validateAQLAggregates(q, result)
+        for _, expr := range q.Exprs() {
+            aqlparser.WalkExpr(expr, func(e aqlparser.Expr) bool {
+                if call, ok := e.(*aqlparser.Call); ok {
+                    functions[call.Name] = true
+                    validateAQLCall(call, result)
+                }
+                return true
+            })
+        }
+        return true
+    })
+
+    // Report features of releases after the target version
+    if version != "" {
+        validateQRadarVersion(version, query, result)
     }
 
-    // Validate functions
-    if err := validateFunctions(content, result); err != nil {
-        result.AddIssue(&models.ValidationIssue{
-            Message:     "Invalid function usage detected",
-            Severity:    models.ValidationSeverityMedium,
-            Location:    "functions",
-            IssueCode:   "QR003",
-            Remediation: "Verify function names and parameter usage",
-        })
+    // Add format-specific metadata
+    result.FormatSpecificDetails["databases"] = sortedKeys(databases)
+    result.FormatSpecificDetails["functions"] = sortedKeys(functions)
+    result.FormatSpecificDetails["subselect_count"] = subselects
+    if query.Time != nil {
+        result.FormatSpecificDetails["time_range"] = describeAQLTime(query.Time)
+    }
+    if version != "" {
+        result.Metadata.ValidatorConfig["qradar_version"] = version
     }
 
     // Calculate final confidence score
-    result.ConfidenceScore = calculateConfidenceScore(result)
+    result.RecalculateConfidence()
 
     return result, nil
 }
 
-// validateAQLSyntax validates the basic syntax structure of an AQL query
-func validateAQLSyntax(content string) error {
-    // Check for SELECT statement
-    if !qradarSelectPattern.MatchString(content) {
-        return utils.NewValidationError("missing or invalid SELECT statement", 2001)
+// validateAQLSource checks that FROM names an Ariel database
+func validateAQLSource(q *aqlparser.Query, result *models.ValidationResult) {
+    if q.From == nil || q.From.Database == "" || qradarDatabases[q.From.Database] {
+        return
     }
+    addAQLIssue(result, q.From.Position, &models.ValidationIssue{
+        Message:     fmt.Sprintf("Unknown Ariel database: %s", q.From.Database),
+        Severity:    models.ValidationSeverityHigh,
+        Location:    "from",
+        IssueCode:   "QR002",
+        Remediation: "Select FROM events or flows, or from a subselect",
+    })
+}
 
-    // Check for FROM clause
-    if !qradarFromPattern.MatchString(content) {
-        return utils.NewValidationError("missing or invalid FROM clause", 2002)
+// validateAQLTime checks the window of LAST and the timestamps of START/STOP
+func validateAQLTime(t *aqlparser.TimeClause, result *models.ValidationResult) {
+    if t == nil {
+        return
     }
-
-    // Validate clause ordering
-    clauses := strings.Fields(strings.ToUpper(content))
-    selectIdx := indexOf(clauses, "SELECT")
-    fromIdx := indexOf(clauses, "FROM")
-    whereIdx := indexOf(clauses, "WHERE")
-    groupByIdx := indexOf(clauses, "GROUP")
-
-    if selectIdx == -1 || fromIdx == -1 || selectIdx >= fromIdx {
-        return utils.NewValidationError("invalid clause ordering", 2003)
+    report := func(pos aqlparser.Position, message, remediation string) {
+        addAQLIssue(result, pos, &models.ValidationIssue{
+            Message:     message,
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "time",
+            IssueCode:   "QR004",
+            Remediation: remediation,
+        })
     }
 
-    if whereIdx != -1 && whereIdx < fromIdx {
-        return utils.NewValidationError("WHERE clause must follow FROM", 2004)
+    if t.Last {
+        if t.Count == nil {
+            return
+        }
+        if n, err := strconv.Atoi(t.Count.Value); err != nil || n <= 0 {
+            report(t.Count.Position, fmt.Sprintf("Invalid LAST window: %s", t.Count.Value), "Use a positive whole number, such as LAST 24 HOURS")
+        }
+        if t.Unit != "" && !qradarTimeUnits[t.Unit] {
+            report(t.Position, fmt.Sprintf("Invalid LAST unit: %s", t.Unit), "Use MINUTES, HOURS or DAYS")
+        }
+        return
     }
 
-    if groupByIdx != -1 && (whereIdx != -1 && groupByIdx < whereIdx) {
-        return utils.NewValidationError("GROUP BY must follow WHERE", 2005)
+    start, startOK := parseAQLTime(t.Start)
+    stop, stopOK := parseAQLTime(t.Stop)
+    for _, bound := range []struct {
+        name string
+        lit  *aqlparser.Literal
+        ok   bool
+    }{{"START", t.Start, startOK}, {"STOP", t.Stop, stopOK}} {
+        if bound.lit != nil && !bound.ok {
+            report(bound.lit.Position, fmt.Sprintf("Invalid %s time: %s", bound.name, bound.lit.Value), "Use 'yyyy-MM-dd HH:mm[:ss]' or epoch milliseconds")
+        }
+    }
+    if startOK && stopOK && !start.Before(stop) {
+        report(t.Position, "START time is not before STOP time", "Swap the START and STOP times")
     }
-
-    return nil
 }
 
-// validateFieldNames validates field names against QRadar naming conventions
-func validateFieldNames(content string, result *models.ValidationResult) error {
-    // Extract field names from SELECT clause
-    selectMatch := qradarSelectPattern.FindStringSubmatch(content)
-    if len(selectMatch) < 2 {
-        return utils.NewValidationError("failed to extract field names", 2006)
+// parseAQLTime parses a START/STOP value; ok is false when it is missing or
+// malformed
+func parseAQLTime(lit *aqlparser.Literal) (time.Time, bool) {
+    if lit == nil {
+        return time.Time{}, false
     }
-
-    fields := strings.Split(selectMatch[1], ",")
-    for _, field := range fields {
-        field = strings.TrimSpace(field)
-        if field == "*" {
-            continue
+    if lit.Kind == aqlparser.LiteralNumber {
+        ms, err := strconv.ParseInt(lit.Value, 10, 64)
+        if err != nil || ms < 0 {
+            return time.Time{}, false
         }
-
-        // Check for alias
-        if strings.Contains(field, " AS ") {
-            parts := strings.Split(field, " AS ")
-            field = strings.TrimSpace(parts[0])
-        }
-
-        if !qradarFieldPattern.MatchString(field) {
-            result.AddIssue(&models.ValidationIssue{
-                Message:     "Invalid field name: " + field,
-                Severity:    models.ValidationSeverityHigh,
-                Location:    "field:" + field,
-                IssueCode:   "QR004",
-                Remediation: "Field names must be alphanumeric with underscores",
-            })
+        return time.UnixMilli(ms), true
+    }
+    for _, layout := range qradarTimeLayouts {
+        if t, err := time.Parse(layout, lit.Value); err == nil {
+            return t, true
         }
     }
-
-    return nil
+    return time.Time{}, false
 }
 
-// validateFunctions validates QRadar function usage and parameters
-func validateFunctions(content string, result *models.ValidationResult) error {
-    // Find all function calls
-    matches := qradarFunctionPattern.FindAllString(content, -1)
-    for _, match := range matches {
-        // Remove trailing parenthesis
-        funcName := strings.TrimSuffix(match, "(")
-
-        // Validate function name format
-        if !isValidQRadarFunction(funcName) {
-            result.AddIssue(&models.ValidationIssue{
-                Message:     "Invalid function name: " + funcName,
-                Severity:    models.ValidationSeverityMedium,
-                Location:    "function:" + funcName,
-                IssueCode:   "QR005",
-                Remediation: "Use valid QRadar function names",
-            })
+// describeAQLTime renders a time clause for rule details
+func describeAQLTime(t *aqlparser.TimeClause) string {
+    if t.Last {
+        if t.Count == nil {
+            return "LAST"
         }
-
-        // Validate function parameters (basic check)
-        if !hasValidFunctionParams(content, funcName) {
-            result.AddIssue(&models.ValidationIssue{
-                Message:     "Invalid function parameters for: " + funcName,
-                Severity:    models.ValidationSeverityMedium,
-                Location:    "function:" + funcName,
-                IssueCode:   "QR006",
-                Remediation: "Check function parameter count and types",
-            })
+        return strings.TrimSpace(fmt.Sprintf("LAST %s %s", t.Count.Value, t.Unit))
+    }
+    var parts []string
+    for _, bound := range []struct {
+        name string
+        lit  *aqlparser.Literal
+    }{{"START", t.Start}, {"STOP", t.Stop}} {
+        if bound.lit == nil {
+            continue
         }
+        value := bound.lit.Value
+        if bound.lit.Kind == aqlparser.LiteralString {
+            value = "'" + value + "'"
+        }
+        parts = append(parts, bound.name+" "+value)
     }
-
-    return nil
+    return strings.Join(parts, " ")
 }
 
-// calculateConfidenceScore calculates the validation confidence score
-func calculateConfidenceScore(result *models.ValidationResult) float64 {
-    baseScore := 100.0
-    
-    // Apply penalties based on issue severity
-    for _, issue := range result.Issues {
-        switch issue.Severity {
-        case models.ValidationSeverityHigh:
-            baseScore -= 20.0
-        case models.ValidationSeverityMedium:
-            baseScore -= 10.0
-        case models.ValidationSeverityLow:
-            baseScore -= 5.0
+// validateAQLAggregates reports HAVING without GROUP BY and aggregates in
+// WHERE, which QRadar rejects
+func validateAQLAggregates(q *aqlparser.Query, result *models.ValidationResult) {
+    if q.Having != nil && len(q.GroupBy) == 0 {
+        addAQLIssue(result, q.Having.Pos(), &models.ValidationIssue{
+            Message:     "HAVING without GROUP BY",
+            Severity:    models.ValidationSeverityMedium,
+            Location:    "having",
+            IssueCode:   "QR003",
+            Remediation: "Add a GROUP BY clause or move the condition to WHERE",
+        })
+    }
+    aqlparser.WalkExpr(q.Where, func(e aqlparser.Expr) bool {
+        call, ok := e.(*aqlparser.Call)
+        if !ok || !qradarFunctions[call.Name].Aggregate {
+            return true
         }
+        addAQLIssue(result, call.Position, &models.ValidationIssue{
+            Message:     fmt.Sprintf("Aggregate function %s in WHERE", call.Name),
+            Severity:    models.ValidationSeverityMedium,
+            Location:    "where",
+            IssueCode:   "QR003",
+            Remediation: "Filter aggregated values with GROUP BY and HAVING",
+        })
+        return false
+    })
+}
+
+// validateAQLCall checks a function name and its argument count
+func validateAQLCall(call *aqlparser.Call, result *models.ValidationResult) {
+    fn, ok := qradarFunctions[call.Name]
+    if !ok {
+        addAQLIssue(result, call.Position, &models.ValidationIssue{
+            Message:     "Invalid function name: " + call.Name,
+            Severity:    models.ValidationSeverityMedium,
+            Location:    "function:" + call.Name,
+            IssueCode:   "QR005",
+            Remediation: "Use valid QRadar function names",
+        })
+        return
     }
 
-    // Ensure score stays within 0-100 range
-    if baseScore < 0 {
-        baseScore = 0
+    args := len(call.Args)
+    if call.Star {
+        args = 1
+    }
+    var problem string
+    switch {
+    case call.Star && call.Name != "COUNT":
+        problem = "only COUNT accepts *"
+    case call.Distinct && !fn.Aggregate:
+        problem = "DISTINCT is only valid in aggregates"
+    case args < fn.MinArgs || (fn.MaxArgs >= 0 && args > fn.MaxArgs):
+        problem = fmt.Sprintf("takes %s, got %d", describeArgCount(fn), args)
+    default:
+        return
     }
-    
-    return baseScore
+    addAQLIssue(result, call.Position, &models.ValidationIssue{
+        Message:     fmt.Sprintf("Invalid function parameters for %s: %s", call.Name, problem),
+        Severity:    models.ValidationSeverityMedium,
+        Location:    "function:" + call.Name,
+        IssueCode:   "QR006",
+        Remediation: "Check function parameter count and types",
+    })
 }
 
-// Helper functions
+// describeArgCount renders the argument count a function takes
+func describeArgCount(fn aqlFunction) string {
+    switch {
+    case fn.MaxArgs < 0:
+        return fmt.Sprintf("at least %d arguments", fn.MinArgs)
+    case fn.MinArgs == fn.MaxArgs && fn.MinArgs == 1:
+        return "1 argument"
+    case fn.MinArgs == fn.MaxArgs:
+        return fmt.Sprintf("%d arguments", fn.MinArgs)
+    }
+    return fmt.Sprintf("%d to %d arguments", fn.MinArgs, fn.MaxArgs)
+}
 
-func indexOf(slice []string, item string) int {
-    for i, s := range slice {
-        if s == item {
-            return i
+// validateQRadarVersion reports functions and subselects introduced after
+// the target QRadar release, once per feature
+func validateQRadarVersion(version string, query *aqlparser.Query, result *models.ValidationResult) {
+    reported := make(map[string]bool)
+    report := func(pos aqlparser.Position, feature, since, location string) {
+        if reported[feature] || !qradarVersionBefore(version, since) {
+            return
         }
+        reported[feature] = true
+        addAQLIssue(result, pos, &models.ValidationIssue{
+            Message:     fmt.Sprintf("%s requires QRadar %s or later; the target release is %s", feature, since, version),
+            Severity:    models.ValidationSeverityMedium,
+            Location:    location,
+            IssueCode:   "QR007",
+            Remediation: fmt.Sprintf("Rewrite the query without %s or upgrade QRadar to %s", feature, since),
+            IssueMetadata: map[string]interface{}{
+                "qradar_version":  version,
+                "minimum_version": since,
+            },
+        })
     }
-    return -1
+
+    aqlparser.Inspect(query, func(q *aqlparser.Query) bool {
+        if q.From != nil && q.From.Subselect != nil {
+            report(q.From.Subselect.Position, "Subselects", qradarSubselectSince, "subselect")
+        }
+        for _, expr := range q.Exprs() {
+            aqlparser.WalkExpr(expr, func(e aqlparser.Expr) bool {
+                switch n := e.(type) {
+                case *aqlparser.Call:
+                    if since := qradarFunctions[n.Name].Since; since != "" {
+                        report(n.Position, n.Name, since, "function:"+n.Name)
+                    }
+                case *aqlparser.InExpr:
+                    if n.Subselect != nil {
+                        report(n.Subselect.Position, "Subselects", qradarSubselectSince, "subselect")
+                    }
+                }
+                return true
+            })
+        }
+        return true
+    })
 }
 
-func isValidQRadarFunction(funcName string) bool {
-    // List of common QRadar functions
-    validFunctions := map[string]bool{
-        "COUNT": true,
-        "SUM":   true,
-        "AVG":   true,
-        "MIN":   true,
-        "MAX":   true,
-        "DATEFORMAT": true,
-        "CONCAT": true,
-        "UPPER":  true,
-        "LOWER":  true,
-    }
-    return validFunctions[funcName]
+// qradarVersionBefore reports whether release a precedes release b; missing
+// components count as zero, so 7.3 equals 7.3.0
+func qradarVersionBefore(a, b string) bool {
+    pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+    for i := 0; i < len(pa) || i < len(pb); i++ {
+        var na, nb int
+        if i < len(pa) {
+            na, _ = strconv.Atoi(pa[i])
+        }
+        if i < len(pb) {
+            nb, _ = strconv.Atoi(pb[i])
+        }
+        if na != nb {
+            return na < nb
+        }
+    }
+    return false
 }
 
-func hasValidFunctionParams(content string, funcName string) bool {
-    // Basic parameter validation - could be enhanced for specific functions
-    return true
-}
\ No newline at end of file
+// addAQLIssue records an issue at a parser position
+func addAQLIssue(result *models.ValidationResult, pos aqlparser.Position, issue *models.ValidationIssue) {
+    if pos.IsValid() {
+        issue.Line = pos.Line
+        issue.Column = pos.Column
+    }
+    result.AddIssue(issue)
+}
@@ -0,0 +1,49 @@
+package validation
+
+import (
+    "sort"
+    "strings"
+
+    "validation-service/pkg/mitre"
+)
+
+// SetAttackDataset replaces the ATT&CK dataset technique and tactic
+// references are checked against. Validations already running finish with
+// the previous dataset.
+func (s *ValidationService) SetAttackDataset(dataset *mitre.Dataset) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.config.Attack = dataset
+}
+
+// attackDataset returns the ATT&CK dataset in use, which may be nil
+func (s *ValidationService) attackDataset() *mitre.Dataset {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    return s.config.Attack
+}
+
+// SetReferenceDataDigest records the content digest of a reference dataset
+// reloaded at runtime, so results cached with the previous dataset are not
+// served afterwards
+func (s *ValidationService) SetReferenceDataDigest(name, digest string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.refDigests == nil {
+        s.refDigests = make(map[string]string)
+    }
+    s.refDigests[name] = digest
+}
+
+// referenceDataDigests returns the recorded reference data digests as one
+// string for the cache key
+func (s *ValidationService) referenceDataDigests() string {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    parts := make([]string, 0, len(s.refDigests))
+    for name, digest := range s.refDigests {
+        parts = append(parts, name+"="+digest)
+    }
+    sort.Strings(parts)
+    return strings.Join(parts, ",")
+}
@@ -0,0 +1,318 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "time"
+
+    "go.opentelemetry.io/otel/attribute" // v1.21.0
+
+    "validation-service/internal/models"
+    "validation-service/pkg/tracing"
+)
+
+// StrictnessOption describes a validator setting that changes how strictly a
+// format is checked
+type StrictnessOption struct {
+    Name        string `json:"name"`
+    Description string `json:"description"`
+    Enabled     bool   `json:"enabled"`
+}
+
+// FormatInfo describes the capabilities of a registered format validator
+type FormatInfo struct {
+    Format            string             `json:"format"`
+    Name              string             `json:"name"`
+    Version           string             `json:"version"`
+    IssueCodes        []string           `json:"issue_codes"`
+    StrictnessOptions []StrictnessOption `json:"strictness_options"`
+    // CrossFormatValidation reports whether dedicated cross-format validators
+    // are registered from this format to the formats in CrossFormatTargets
+    CrossFormatValidation bool     `json:"cross_format_validation"`
+    CrossFormatTargets    []string `json:"cross_format_targets"`
+    // Plugin names the plugin providing the validator; its issue codes are
+    // namespaced as format.plugin.code. CorePlugin when empty.
+    Plugin string `json:"plugin,omitempty"`
+    // NamespacedIssueCodes are the issue codes in their namespaced form
+    NamespacedIssueCodes []string `json:"namespaced_issue_codes"`
+}
+
+// Describer is implemented by validators that publish capability metadata
+type Describer interface {
+    Describe() FormatInfo
+}
+
+// CommonIssueCodes are issue codes raised by the service for any format
+var CommonIssueCodes = []string{
+    "VALIDATION_FAILED", "LOW_CONFIDENCE",
+    issueCodeUnknownTechnique, issueCodeRetiredTechnique, issueCodeUnknownTactic,
+    // Raised against the tenant translation memory
+    "TM001", "TM002",
+    // Raised for meta fields the validation profile requires
+    requiredMetaCode,
+    // Raised for rules past their review-by or expiry date
+    reviewDueCode, expiredCode, invalidDateCode,
+    // Raised for errors reported by platform connectors
+    platformRejectedCode,
+    // Raised against collections of rules validated together
+    collectionDuplicateNameCode, collectionConflictingIDCode,
+}
+
+// detectionValidateFunc validates a single detection into a new result
+type detectionValidateFunc func(ctx context.Context, detection *models.Detection) (*models.ValidationResult, error)
+
+// formatValidator adapts a FormatValidator plugin to the Validator
+// interface. The target detection is validated and its issues and details are
+// merged into the translation result.
+type formatValidator struct {
+    plugin FormatValidator
+}
+
+// Validate runs the format validator against the target detection
+func (v *formatValidator) Validate(ctx context.Context, sourceDetection *models.Detection, targetDetection *models.Detection, result *models.ValidationResult) error {
+    info := v.plugin.Describe()
+    ctx, span := tracing.Start(ctx, "validator.ValidateDetection",
+        attribute.String("validator.format", info.Format),
+        attribute.String("validator.version", info.Version),
+    )
+    formatResult, err := v.plugin.ValidateDetection(ctx, targetDetection)
+    tracing.End(span, err)
+    if err != nil {
+        return fmt.Errorf("%s validation: %w", targetDetection.Format, err)
+    }
+
+    // Issues are stamped with the clock of the translation result
+    result.MergeIssues(formatResult.Issues, func(issue *models.ValidationIssue) {
+        issue.Timestamp = time.Time{}
+    })
+    for key, value := range formatResult.FormatSpecificDetails {
+        result.FormatSpecificDetails[key] = value
+    }
+    result.Metadata.ValidatedFields = append(result.Metadata.ValidatedFields, formatResult.Metadata.ValidatedFields...)
+    return nil
+}
+
+// Describe returns the validator capabilities
+func (v *formatValidator) Describe() FormatInfo {
+    return v.plugin.Describe()
+}
+
+// ignoreContext adapts validators that do not take a context
+func ignoreContext(fn func(*models.Detection) (*models.ValidationResult, error)) detectionValidateFunc {
+    return func(ctx context.Context, detection *models.Detection) (*models.ValidationResult, error) {
+        if err := ctx.Err(); err != nil {
+            return nil, err
+        }
+        return fn(detection)
+    }
+}
+
+// issueCodes builds a sequential list of issue codes such as YARA001..YARA009
+func issueCodes(prefix string, count int) []string {
+    codes := make([]string, count)
+    for i := range codes {
+        codes[i] = fmt.Sprintf("%s%03d", prefix, i+1)
+    }
+    return codes
+}
+
+// RegisterBuiltinValidators registers the validators shipped with the service
+func (s *ValidationService) RegisterBuiltinValidators() error {
+    registry := BuiltinRegistry()
+    if err := s.RegisterRegistry(registry); err != nil {
+        return err
+    }
+    s.allowBuiltinIssueCodes(registry.Formats())
+    return nil
+}
+
+// strictOption describes the strict_mode setting shared by every validator
+func strictOption(opts PluginOptions) StrictnessOption {
+    return StrictnessOption{
+        Name:        "strict_mode",
+        Description: "Treat recoverable findings as validation failures",
+        Enabled:     opts.StrictMode,
+    }
+}
+
+// builtinValidator creates a factory for a validator that only offers the
+// strict_mode option
+func builtinValidator(info FormatInfo, validate detectionValidateFunc) FormatValidatorFactory {
+    return func(opts PluginOptions) (FormatValidator, error) {
+        info := info
+        info.StrictnessOptions = []StrictnessOption{strictOption(opts)}
+        return NewFormatValidator(info, validate), nil
+    }
+}
+
+// BuiltinRegistry returns a registry of the validators shipped with the service
+func BuiltinRegistry() *Registry {
+    registry := NewRegistry()
+    factories := []struct {
+        format  string
+        factory FormatValidatorFactory
+    }{
+        {models.DetectionFormatSplunk, func(opts PluginOptions) (FormatValidator, error) {
+            splunk := NewSplunkValidator(SplunkValidatorConfig{
+                Version:           "1.0.0",
+                StrictMode:        opts.StrictMode,
+                MaxPipelineDepth:  opts.Scoring.maxComplexity(models.DetectionFormatSplunk, 10),
+                TimeRangeRequired: opts.StrictMode,
+                CIMCompliance:     true,
+            })
+            return NewFormatValidator(FormatInfo{
+                Format:     models.DetectionFormatSplunk,
+                Name:       "Splunk SPL",
+                Version:    "1.0.0",
+                IssueCodes: append([]string{"SPL_SYNTAX", "SPL_SEMANTIC"}, issueCodes("SPLPERF", 5)...),
+                StrictnessOptions: []StrictnessOption{
+                    strictOption(opts),
+                    {Name: "time_range_required", Description: "Require earliest/latest time bounds", Enabled: opts.StrictMode},
+                    {Name: "cim_compliance", Description: "Require CIM-compliant field names", Enabled: true},
+                },
+            }, splunk.Validate), nil
+        }},
+        {models.DetectionFormatSigma, func(opts PluginOptions) (FormatValidator, error) {
+            sigma := NewSigmaValidator(map[string]float64{
+                "yaml_structure":  weightYAMLStructure,
+                "required_fields": weightRequiredFields,
+                "detection_logic": weightDetectionLogic,
+                "logsource":       weightLogsource,
+                "field_mappings":  weightFieldMappings,
+            }, defaultValidationTimeout)
+            return NewFormatValidator(FormatInfo{
+                Format:            models.DetectionFormatSigma,
+                Name:              "Sigma",
+                Version:           "1.0.0",
+                IssueCodes:        issueCodes("SIGMA", 15),
+                StrictnessOptions: []StrictnessOption{strictOption(opts)},
+            }, sigma.Validate), nil
+        }},
+        {models.DetectionFormatQRadar, func(opts PluginOptions) (FormatValidator, error) {
+            version := opts.QRadarVersion
+            return builtinValidator(FormatInfo{
+                Format:     models.DetectionFormatQRadar,
+                Name:       "IBM QRadar AQL",
+                Version:    "1.0.0",
+                IssueCodes: issueCodes("QR", 7),
+            }, ignoreContext(func(detection *models.Detection) (*models.ValidationResult, error) {
+                return validateQRadar(detection, version)
+            }))(opts)
+        }},
+        {models.DetectionFormatKQL, builtinValidator(FormatInfo{
+            Format:     models.DetectionFormatKQL,
+            Name:       "Microsoft KQL",
+            Version:    "2.0",
+            IssueCodes: issueCodes("KQL", 5),
+        }, ignoreContext(ValidateKQLDetection))},
+        {models.DetectionFormatSentinel, builtinValidator(FormatInfo{
+            Format:     models.DetectionFormatSentinel,
+            Name:       "Microsoft Sentinel Analytics Rule",
+            Version:    "1.0.0",
+            IssueCodes: append(issueCodes("SENT", 9), issueCodes("KQL", 5)...),
+        }, ignoreContext(ValidateSentinelDetection))},
+        {models.DetectionFormatPaloAlto, func(opts PluginOptions) (FormatValidator, error) {
+            return builtinValidator(FormatInfo{
+                Format:     models.DetectionFormatPaloAlto,
+                Name:       "Palo Alto Networks",
+                Version:    "1.0.0",
+                IssueCodes: issueCodes("PA", 6),
+            }, NewPaloAltoValidator().Validate)(opts)
+        }},
+        {models.DetectionFormatCrowdstrike, builtinValidator(FormatInfo{
+            Format:     models.DetectionFormatCrowdstrike,
+            Name:       "CrowdStrike NG-SIEM",
+            Version:    "1.0.0",
+            IssueCodes: issueCodes("CS", 14),
+        }, ignoreContext(ValidateCrowdstrikeDetection))},
+        {models.DetectionFormatYara, builtinValidator(FormatInfo{
+            Format:     models.DetectionFormatYara,
+            Name:       "YARA",
+            Version:    "1.0.0",
+            IssueCodes: issueCodes("YARA", 11),
+        }, ignoreContext(ValidateYARARule))},
+        {models.DetectionFormatYaraL, func(opts PluginOptions) (FormatValidator, error) {
+            maxComplexity := opts.Scoring.maxComplexity(models.DetectionFormatYaraL, maxConditionComplexity)
+            return builtinValidator(FormatInfo{
+                Format:     models.DetectionFormatYaraL,
+                Name:       "Chronicle YARA-L",
+                Version:    "1.0.0",
+                IssueCodes: issueCodes("YARAL", 15),
+            }, func(ctx context.Context, detection *models.Detection) (*models.ValidationResult, error) {
+                if err := ctx.Err(); err != nil {
+                    return nil, err
+                }
+                return validateYARAL(detection, complexityLimit(ctx, maxComplexity), customSchemasFromContext(ctx))
+            })(opts)
+        }},
+        {models.DetectionFormatSuricata, builtinValidator(FormatInfo{
+            Format:     models.DetectionFormatSuricata,
+            Name:       "Suricata",
+            Version:    "1.0.0",
+            IssueCodes: issueCodes("IDS", 17),
+        }, ignoreContext(ValidateSuricataRule))},
+        {models.DetectionFormatSnort, builtinValidator(FormatInfo{
+            Format:     models.DetectionFormatSnort,
+            Name:       "Snort",
+            Version:    "1.0.0",
+            IssueCodes: issueCodes("IDS", 17),
+        }, ignoreContext(ValidateSnortRule))},
+        {models.DetectionFormatZeek, builtinValidator(FormatInfo{
+            Format:     models.DetectionFormatZeek,
+            Name:       "Zeek",
+            Version:    "1.0.0",
+            IssueCodes: issueCodes("ZEEK", 16),
+        }, ignoreContext(ValidateZeekDetection))},
+    }
+
+    for _, builtin := range factories {
+        // Built-in formats are distinct, so registration cannot fail
+        _ = registry.Register(builtin.format, builtin.factory)
+    }
+    return registry
+}
+
+// SupportedFormats describes every registered validator, ordered by format.
+// Cross-format targets are derived from the registered cross-format validators.
+func (s *ValidationService) SupportedFormats() []FormatInfo {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    crossTargets := make(map[string]map[string]bool)
+    for key := range s.crossValidators {
+        source, target, ok := splitCrossFormatKey(key)
+        if !ok {
+            continue
+        }
+        if crossTargets[source] == nil {
+            crossTargets[source] = make(map[string]bool)
+        }
+        crossTargets[source][target] = true
+    }
+
+    formats := make([]FormatInfo, 0, len(s.validators))
+    for format, validator := range s.validators {
+        info := FormatInfo{Format: format, Name: format, Version: "1.0.0"}
+        if describer, ok := validator.(Describer); ok {
+            info = describer.Describe()
+            info.Format = format
+        }
+        info.NamespacedIssueCodes = s.issueCodes.Codes(format)
+        info.CrossFormatTargets = sortedKeys(crossTargets[format])
+        info.CrossFormatValidation = len(info.CrossFormatTargets) > 0
+        if info.IssueCodes == nil {
+            info.IssueCodes = []string{}
+        }
+        if info.StrictnessOptions == nil {
+            info.StrictnessOptions = []StrictnessOption{}
+        }
+        formats = append(formats, info)
+    }
+
+    sort.Slice(formats, func(i, j int) bool {
+        return formats[i].Format < formats[j].Format
+    })
+    return formats
+}
@@ -0,0 +1,109 @@
+package validation
+
+import (
+    "context"
+    "fmt"
+    "reflect"
+    "time"
+
+    "validation-service/internal/models"
+    "validation-service/internal/tenant"
+)
+
+// lowConfidenceCode is the issue recorded when the confidence score of a
+// result falls below the minimum of its target format
+const lowConfidenceCode = "LOW_CONFIDENCE"
+
+// addLowConfidenceIssue records that the confidence score of result is below
+// the minimum of its scoring policy. A zero timestamp is taken from the
+// result's clock.
+func addLowConfidenceIssue(result *models.ValidationResult, timestamp time.Time) {
+    minConfidence := result.ScoringPolicy().MinConfidence
+    result.AddIssue(&models.ValidationIssue{
+        Message:   fmt.Sprintf("Confidence score %.2f below minimum threshold %.2f", result.ConfidenceScore, minConfidence),
+        Severity:  models.ValidationSeverityMedium,
+        Location:  "confidence_check",
+        IssueCode: lowConfidenceCode,
+        Timestamp: timestamp,
+    })
+}
+
+// Rescore recomputes the confidence score and status of a stored result from
+// its issues with the current scoring policy of its target format, validation
+// profile and tenant, without re-running validators. The low confidence issue
+// is re-evaluated against the new threshold and the tenant's scoring policy,
+// if it has one, is applied again. Error results stay errors, since they record validator
+// failures that re-scoring cannot revisit.
+//
+// When the score, status or policy changed, the revision is appended to the
+// result's metadata and history and returned with true; otherwise result is
+// left untouched.
+func (p *ScoringPolicies) Rescore(ctx context.Context, result *models.ValidationResult, t *tenant.Tenant, now time.Time) (models.ScoreRevision, bool) {
+    policy := p.ForTenant(result.TargetFormat, result.Metadata.Profile, t)
+
+    rescored := *result
+    rescored.Issues = make([]models.ValidationIssue, 0, len(result.Issues))
+    for _, issue := range result.Issues {
+        if issue.IssueCode != lowConfidenceCode {
+            rescored.Issues = append(rescored.Issues, issue)
+        }
+    }
+    rescored.ValidationHistory = append([]models.ValidationHistoryEntry(nil), result.ValidationHistory...)
+    rescored.Metadata.PolicyDecision = nil
+    rescored.SetScoringPolicy(policy)
+    rescored.RecalculateConfidence()
+
+    lowConfidence := rescored.ConfidenceScore < policy.MinConfidence
+    if rescored.Status != models.ValidationStatusError {
+        rescored.Status = models.ValidationStatusSuccess
+        if lowConfidence {
+            rescored.Status = models.ValidationStatusWarning
+        }
+    }
+    if lowConfidence {
+        addLowConfidenceIssue(&rescored, now)
+    }
+    rescored.ScoreBreakdown = rescored.BreakDownScore()
+    if t != nil {
+        p.scoreForTenant(ctx, t.ID, &rescored)
+    }
+    if t.Strict() && rescored.Status == models.ValidationStatusWarning {
+        rescored.Status = models.ValidationStatusError
+    }
+    if p != nil {
+        p.profiles.applyStrictness(&rescored)
+        p.profiles.applyGate(&rescored, t)
+    }
+
+    revision := models.ScoreRevision{
+        RescoredAt:      now,
+        PreviousScore:   result.ConfidenceScore,
+        Score:           rescored.ConfidenceScore,
+        PreviousStatus:  result.Status,
+        Status:          rescored.Status,
+        PreviousScoring: result.Metadata.Scoring,
+    }
+    if revision.Score == revision.PreviousScore && revision.Status == revision.PreviousStatus &&
+        rescored.Metadata.Gate == result.Metadata.Gate && sameScoring(policy, result.ScoringPolicy()) {
+        return revision, false
+    }
+
+    rescored.Metadata.ScoreRevisions = append(append([]models.ScoreRevision(nil), result.Metadata.ScoreRevisions...), revision)
+    rescored.ValidationHistory = append(rescored.ValidationHistory, models.ValidationHistoryEntry{
+        Timestamp: now,
+        Action:    "confidence_rescored",
+        Details: map[string]interface{}{
+            "previous_score":  revision.PreviousScore,
+            "score":           revision.Score,
+            "previous_status": revision.PreviousStatus,
+            "status":          revision.Status,
+        },
+    })
+    *result = rescored
+    return revision, true
+}
+
+// sameScoring reports whether two policies weigh issues alike
+func sameScoring(a, b *models.ScoringPolicy) bool {
+    return a.MinConfidence == b.MinConfidence && reflect.DeepEqual(a.SeverityWeights, b.SeverityWeights)
+}
@@ -0,0 +1,96 @@
+package validation
+
+import (
+    "context"
+    "fmt"
+    "sort"
+
+    "validation-service/internal/models"
+    "validation-service/internal/tenant"
+    "validation-service/pkg/logger"
+)
+
+// staleCacheKeyPrefix separates the keys of stale results from those of
+// current results
+const staleCacheKeyPrefix = "stale"
+
+// Revalidation is the outcome of the background re-validation of a stale
+// result served from the cache
+type Revalidation struct {
+    TenantID string
+    // StaleResultID is the ID the stale result was served with
+    StaleResultID string
+    // Result is the fresh result of the current validators and catalogs
+    Result *models.ValidationResult
+}
+
+// SetRevalidationListener calls listener with every background
+// re-validation whose fresh result differs from the stale result served.
+// Listeners run on the re-validation goroutine.
+func (s *ValidationService) SetRevalidationListener(listener func(*Revalidation)) {
+    s.mu.Lock()
+    s.onRevalidated = listener
+    s.mu.Unlock()
+}
+
+// revalidationListener returns the listener of re-validations, which may be
+// nil
+func (s *ValidationService) revalidationListener() func(*Revalidation) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    return s.onRevalidated
+}
+
+// revalidate validates a translation whose stale result was served, in the
+// background, and caches the fresh result. At most one re-validation runs
+// per cache key. The listener is notified when the fresh result differs
+// from the stale one.
+func (s *ValidationService) revalidate(ctx context.Context, key, staleKey string, sourceDetection, targetDetection *models.Detection, stale *models.ValidationResult) {
+    if _, running := s.revalidating.LoadOrStore(key, true); running {
+        return
+    }
+
+    // The fingerprint is taken before the stale result is handed to the
+    // caller, which may decorate it
+    staleFingerprint := resultFingerprint(stale)
+    staleID := stale.ID.String()
+    ctx = context.WithoutCancel(ctx)
+    go func() {
+        defer s.revalidating.Delete(key)
+
+        result, err := s.ValidateDetection(ctx, sourceDetection, targetDetection)
+        if err != nil || result.Status == models.ValidationStatusError {
+            revalidationsTotal.WithLabelValues("failed").Inc()
+            logger.FromContext(ctx).Warn("Background re-validation of stale result failed",
+                "error", err,
+                "stale_result_id", staleID,
+            )
+            return
+        }
+        s.cacheResult(ctx, key, staleKey, result)
+
+        if staleFingerprint == resultFingerprint(result) {
+            revalidationsTotal.WithLabelValues("unchanged").Inc()
+            return
+        }
+        revalidationsTotal.WithLabelValues("changed").Inc()
+        if listener := s.revalidationListener(); listener != nil {
+            listener(&Revalidation{
+                TenantID:      tenant.IDFromContext(ctx),
+                StaleResultID: staleID,
+                Result:        result,
+            })
+        }
+    }()
+}
+
+// resultFingerprint summarizes what a caller acts on in a result: its
+// status, score and issues
+func resultFingerprint(result *models.ValidationResult) string {
+    issues := make([]string, 0, len(result.Issues))
+    for _, issue := range result.Issues {
+        issues = append(issues, fmt.Sprintf("%s|%s|%s|%d|%s", issue.IssueCode, issue.Severity, issue.Location, issue.Line, issue.Message))
+    }
+    sort.Strings(issues)
+    return fmt.Sprintf("%s|%.2f|%q", result.Status, result.ConfidenceScore, issues)
+}
@@ -0,0 +1,162 @@
+package validation
+
+import (
+    "fmt"
+    "strings"
+    "time"
+
+    "validation-service/internal/models"
+)
+
+// Meta fields holding the dates a rule is due for review and stops being
+// trusted
+const (
+    MetaReviewBy = "review_by"
+    MetaExpires  = "expires"
+)
+
+// Review statuses of a rule
+const (
+    RuleReviewDue = "review_due"
+    RuleExpired   = "expired"
+)
+
+// Issue codes raised for rules past their review-by or expiry date, and for
+// dates that cannot be read
+const (
+    reviewDueCode   = "REVIEW001"
+    expiredCode     = "REVIEW002"
+    invalidDateCode = "REVIEW003"
+)
+
+// reviewDateLayouts are the accepted layouts of review-by and expiry dates;
+// Sigma rules write dates with slashes
+var reviewDateLayouts = []string{"2006-01-02", "2006/01/02", time.RFC3339}
+
+// RuleReview holds the review-by and expiry dates a detection declares. A
+// detection with several rules takes the earliest date of any rule.
+type RuleReview struct {
+    ReviewBy *time.Time `json:"review_by,omitempty"`
+    Expires  *time.Time `json:"expires,omitempty"`
+    // Invalid lists the meta fields whose dates could not be read
+    Invalid []string `json:"invalid,omitempty"`
+}
+
+// ReviewDates returns the review-by and expiry dates declared in the
+// metadata or the rule meta of a detection
+func ReviewDates(detection *models.Detection, format string) RuleReview {
+    var review RuleReview
+    invalid := make(map[string]bool)
+    for _, meta := range ruleMeta(detection, format) {
+        for _, field := range []string{MetaReviewBy, MetaExpires} {
+            value, ok := meta[field]
+            if !ok || !hasMetaValue(value) {
+                continue
+            }
+            date, ok := parseReviewDate(value)
+            if !ok {
+                if !invalid[field] {
+                    invalid[field] = true
+                    review.Invalid = append(review.Invalid, field)
+                }
+                continue
+            }
+            target := &review.ReviewBy
+            if field == MetaExpires {
+                target = &review.Expires
+            }
+            if *target == nil || date.Before(**target) {
+                *target = &date
+            }
+        }
+    }
+    return review
+}
+
+// Declared reports whether the detection declares a review-by or expiry
+// date, readable or not
+func (r RuleReview) Declared() bool {
+    return r.ReviewBy != nil || r.Expires != nil || len(r.Invalid) > 0
+}
+
+// Status returns RuleExpired when the rule has expired at now, RuleReviewDue
+// when it is due for review and the empty string otherwise. Dates are due
+// from the start of their day.
+func (r RuleReview) Status(now time.Time) string {
+    switch {
+    case r.Expires != nil && !now.Before(*r.Expires):
+        return RuleExpired
+    case r.ReviewBy != nil && !now.Before(*r.ReviewBy):
+        return RuleReviewDue
+    }
+    return ""
+}
+
+// parseReviewDate reads a date meta value: a date YAML already decoded or a
+// string in one of reviewDateLayouts
+func parseReviewDate(value interface{}) (time.Time, bool) {
+    switch v := value.(type) {
+    case time.Time:
+        return v.UTC(), true
+    case string:
+        for _, layout := range reviewDateLayouts {
+            if date, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+                return date.UTC(), true
+            }
+        }
+    }
+    return time.Time{}, false
+}
+
+// translationReview returns the review dates of a translation: those of the
+// target detection, or of its source when the target declares none, so
+// migrated rules keep the review cycle of the original
+func translationReview(sourceDetection, targetDetection *models.Detection, targetFormat string) RuleReview {
+    review := ReviewDates(targetDetection, targetFormat)
+    if !review.Declared() && sourceDetection != nil && sourceDetection != targetDetection {
+        review = ReviewDates(sourceDetection, sourceDetection.Format)
+    }
+    return review
+}
+
+// reviewStatus returns the review status of a translation now, so results
+// cached before a review-by or expiry date passed are not served after it
+func (s *ValidationService) reviewStatus(sourceDetection, targetDetection *models.Detection, targetFormat string) string {
+    return translationReview(sourceDetection, targetDetection, targetFormat).Status(s.config.Clock.Now())
+}
+
+// checkReview reports a translation past its review-by or expiry date and
+// review dates that cannot be read
+func (s *ValidationService) checkReview(sourceDetection, targetDetection *models.Detection, targetFormat string, result *models.ValidationResult) {
+    review := translationReview(sourceDetection, targetDetection, targetFormat)
+
+    for _, field := range review.Invalid {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("Meta field %s is not a date", field),
+            Severity:    models.ValidationSeverityLow,
+            Location:    "meta." + field,
+            IssueCode:   invalidDateCode,
+            Remediation: fmt.Sprintf("Write %s as YYYY-MM-DD", field),
+        })
+    }
+
+    now := s.config.Clock.Now()
+    if review.Expires != nil && !now.Before(*review.Expires) {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("Rule expired on %s", review.Expires.Format("2006-01-02")),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "meta." + MetaExpires,
+            IssueCode:   expiredCode,
+            Remediation: "Retire the rule, or confirm it still applies and move expires to a later date",
+        })
+    }
+    if review.ReviewBy != nil && !now.Before(*review.ReviewBy) {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("Rule was due for review on %s", review.ReviewBy.Format("2006-01-02")),
+            Severity:    models.ValidationSeverityMedium,
+            Location:    "meta." + MetaReviewBy,
+            IssueCode:   reviewDueCode,
+            Remediation: "Review the rule against current telemetry and move review_by to the next review date",
+        })
+    }
+}
@@ -0,0 +1,90 @@
+package validation
+
+import (
+    "regexp"
+    "strings"
+
+    "internal/models"
+    splparser "internal/parser/spl"
+    "internal/services/lint"
+    "internal/services/rulepack"
+)
+
+// rulePackDigest names the rule packs among the reference data digests, so
+// results cached with other packs are not served
+const rulePackDigest = "rule_packs"
+
+// kqlPipeOperator matches the operator after each pipe of a KQL query
+var kqlPipeOperator = regexp.MustCompile(`\|\s*([A-Za-z][A-Za-z-]*)`)
+
+// SetRulePacks replaces the rule packs checked after the format validators.
+// Validations already running finish with the previous packs.
+func (s *ValidationService) SetRulePacks(packs *rulepack.Set) {
+    s.mu.Lock()
+    s.rulePacks = packs
+    s.mu.Unlock()
+    s.SetReferenceDataDigest(rulePackDigest, packs.Digest())
+}
+
+// rulePackSet returns the rule packs in use, which may be nil
+func (s *ValidationService) rulePackSet() *rulepack.Set {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    return s.rulePacks
+}
+
+// checkRulePacks records the violations of the rule packs as issues coded
+// with their rule IDs
+func (s *ValidationService) checkRulePacks(detection *models.Detection, format string, result *models.ValidationResult) {
+    packs := s.rulePackSet()
+    if packs == nil {
+        return
+    }
+    for _, violation := range packs.Evaluate(rulePackDocument(detection, format)) {
+        result.AddIssue(&models.ValidationIssue{
+            Message:        violation.Message,
+            Severity:       violation.Severity,
+            Location:       violation.Location,
+            IssueCode:      violation.Rule,
+            NamespacedCode: NamespacedIssueCode(format, RulePackPlugin, violation.Rule),
+            Remediation:    violation.Remediation,
+        })
+    }
+}
+
+// rulePackDocument builds the view of a detection rule packs check: its
+// name, the meta of each of its rules and the commands of its query
+func rulePackDocument(detection *models.Detection, format string) *rulepack.Document {
+    doc := &rulepack.Document{
+        Format:  format,
+        Content: detection.Content,
+        Name:    lint.DetectionName(detection),
+        Meta:    ruleMeta(detection, format),
+    }
+
+    switch format {
+    case models.DetectionFormatSplunk:
+        pipeline, _ := splparser.Parse(detection.Content)
+        splparser.Inspect(pipeline, func(cmd *splparser.Command) bool {
+            doc.Commands = append(doc.Commands, strings.ToLower(splunkCommandName(cmd)))
+            return true
+        })
+    case models.DetectionFormatKQL:
+        doc.Commands = kqlPipeOperators(detection.Content)
+    case models.DetectionFormatSentinel:
+        if query, ok := doc.Meta[0]["query"].(string); ok {
+            doc.Commands = kqlPipeOperators(query)
+        }
+    }
+    return doc
+}
+
+// kqlPipeOperators returns the lower-cased operators after the pipes of a
+// KQL query
+func kqlPipeOperators(query string) []string {
+    var operators []string
+    for _, match := range kqlPipeOperator.FindAllStringSubmatch(query, -1) {
+        operators = append(operators, strings.ToLower(match[1]))
+    }
+    return operators
+}
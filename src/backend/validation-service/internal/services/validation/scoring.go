@@ -0,0 +1,136 @@
+package validation
+
+import (
+    "context"
+
+    "validation-service/internal/config"
+    "validation-service/internal/models"
+)
+
+// builtinMaxComplexity are the complexity limits of the formats that have
+// one: the SPL pipeline depth and the YARA-L condition complexity
+var builtinMaxComplexity = map[string]int{
+    models.DetectionFormatSplunk: 10,
+    models.DetectionFormatYaraL:  maxConditionComplexity,
+}
+
+// defaultMaxNestingDepth bounds the bracket nesting of every format unless
+// the scoring configuration sets another limit
+const defaultMaxNestingDepth = 32
+
+// ScoringPolicies resolves the scoring policy of each target format. A nil
+// ScoringPolicies applies the built-in policy to every format.
+type ScoringPolicies struct {
+    defaults config.ScoringProfile
+    formats  map[string]config.ScoringProfile
+    tenants  TenantScorer
+    profiles *Profiles
+}
+
+// TenantScorer decides the status and confidence score of results with
+// policies supplied by their tenants
+type TenantScorer interface {
+    // Score applies the tenant's policy to a scored result; results of
+    // tenants without a policy are left untouched
+    Score(ctx context.Context, tenantID string, result *models.ValidationResult)
+    // PolicyDigest identifies the version of the tenant's policy, empty
+    // when it has none
+    PolicyDigest(ctx context.Context, tenantID string) string
+}
+
+// NewScoringPolicies creates the scoring policies of cfg
+func NewScoringPolicies(cfg config.ScoringConfig) *ScoringPolicies {
+    return &ScoringPolicies{defaults: cfg.Default, formats: cfg.Formats}
+}
+
+// SetTenantScorer applies the scoring policies of tenants after the
+// severity weights. It is set before validations run.
+func (p *ScoringPolicies) SetTenantScorer(scorer TenantScorer) {
+    p.tenants = scorer
+}
+
+// SetProfiles applies the settings of the validation profile of each result
+// over those of its target format. It is set before validations run.
+func (p *ScoringPolicies) SetProfiles(profiles *Profiles) {
+    p.profiles = profiles
+}
+
+// scoreForTenant applies the scoring policy of the tenant, if it has one
+func (p *ScoringPolicies) scoreForTenant(ctx context.Context, tenantID string, result *models.ValidationResult) {
+    if p == nil || p.tenants == nil {
+        return
+    }
+    p.tenants.Score(ctx, tenantID, result)
+}
+
+// tenantPolicyDigest returns the digest of the tenant's scoring policy, or
+// an empty string when it has none
+func (p *ScoringPolicies) tenantPolicyDigest(ctx context.Context, tenantID string) string {
+    if p == nil || p.tenants == nil {
+        return ""
+    }
+    return p.tenants.PolicyDigest(ctx, tenantID)
+}
+
+// For returns the effective scoring policy of format: the built-in policy,
+// overridden by the configured default and then by the format's settings
+func (p *ScoringPolicies) For(format string) *models.ScoringPolicy {
+    policy := models.DefaultScoringPolicy()
+    policy.MaxComplexity = builtinMaxComplexity[format]
+    policy.MaxNestingDepth = defaultMaxNestingDepth
+    if p == nil {
+        return policy
+    }
+    applyScoringProfile(policy, p.defaults)
+    if profile, ok := p.formats[format]; ok {
+        applyScoringProfile(policy, profile)
+    }
+    return policy
+}
+
+// maxComplexity returns the complexity limit of format, or fallback when the
+// format has none
+func (p *ScoringPolicies) maxComplexity(format string, fallback int) int {
+    if limit := p.For(format).MaxComplexity; limit > 0 {
+        return limit
+    }
+    return fallback
+}
+
+// complexityLimitKey carries the complexity limit of the scoring policy of a
+// validation to the format validators
+type complexityLimitKey struct{}
+
+// withComplexityLimit returns ctx carrying the complexity limit of a
+// validation; a limit of 0 carries none
+func withComplexityLimit(ctx context.Context, limit int) context.Context {
+    if limit <= 0 {
+        return ctx
+    }
+    return context.WithValue(ctx, complexityLimitKey{}, limit)
+}
+
+// complexityLimit returns the complexity limit carried by ctx, or fallback
+// when it carries none
+func complexityLimit(ctx context.Context, fallback int) int {
+    if limit, ok := ctx.Value(complexityLimitKey{}).(int); ok {
+        return limit
+    }
+    return fallback
+}
+
+// applyScoringProfile overrides the settings of policy that profile sets
+func applyScoringProfile(policy *models.ScoringPolicy, profile config.ScoringProfile) {
+    for severity, weight := range profile.SeverityWeights {
+        policy.SeverityWeights[severity] = weight
+    }
+    if profile.MinConfidence != nil {
+        policy.MinConfidence = *profile.MinConfidence
+    }
+    if profile.MaxComplexity > 0 {
+        policy.MaxComplexity = profile.MaxComplexity
+    }
+    if profile.MaxNestingDepth > 0 {
+        policy.MaxNestingDepth = profile.MaxNestingDepth
+    }
+}
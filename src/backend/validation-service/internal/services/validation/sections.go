@@ -0,0 +1,51 @@
+package validation
+
+import (
+    "runtime"
+
+    "golang.org/x/sync/errgroup" // v0.3.0
+)
+
+// parallelSectionMinSize is the size in bytes from which the independent
+// sections of a rule are validated concurrently; below it scheduling them
+// costs more than it saves
+const parallelSectionMinSize = 16 * 1024
+
+// sectionGroup validates the independent sections of a rule, such as the
+// meta, strings and condition of a YARA rule, on at most GOMAXPROCS
+// goroutines. Sections of smaller rules run in turn on the calling
+// goroutine. A section only reads what it shares with the others and
+// records its issues on its own, so callers merge them in a fixed order
+// once Wait returns and results do not depend on scheduling.
+type sectionGroup struct {
+    group    errgroup.Group
+    parallel bool
+    err      error
+}
+
+// newSectionGroup returns a group for the sections of a rule of size bytes
+func newSectionGroup(size int) *sectionGroup {
+    procs := runtime.GOMAXPROCS(0)
+    g := &sectionGroup{parallel: procs > 1 && size >= parallelSectionMinSize}
+    g.group.SetLimit(procs)
+    return g
+}
+
+// Go validates a section. Sections run in turn are skipped once one failed.
+func (g *sectionGroup) Go(validate func() error) {
+    if g.parallel {
+        g.group.Go(validate)
+        return
+    }
+    if g.err == nil {
+        g.err = validate()
+    }
+}
+
+// Wait waits for the sections and returns the first error one returned
+func (g *sectionGroup) Wait() error {
+    if err := g.group.Wait(); err != nil {
+        return err
+    }
+    return g.err
+}
@@ -0,0 +1,428 @@
+// Package validation provides validation services for various detection formats
+package validation
+
+import (
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    "gopkg.in/yaml.v3" // v3.0.1
+
+    "validation-service/internal/models"
+    "validation-service/pkg/logger"
+    "validation-service/pkg/utils"
+)
+
+// Microsoft Sentinel scheduled analytics rule limits
+const (
+    sentinelMinInterval         = 5 * time.Minute
+    sentinelMaxQueryFrequency   = 14 * 24 * time.Hour
+    sentinelMaxQueryPeriod      = 14 * 24 * time.Hour
+    sentinelMaxTriggerThreshold = 10000
+    sentinelMaxEntityMappings   = 10
+    sentinelMaxFieldMappings    = 3
+    sentinelKindScheduled       = "Scheduled"
+)
+
+var (
+    // Sentinel YAML uses compact durations (5m, 1h, 1d); ARM exports use ISO 8601 (PT5M, P1D)
+    sentinelCompactDurationPattern = regexp.MustCompile(`^([0-9]+)([mhd])$`)
+    sentinelISODurationPattern     = regexp.MustCompile(`^P(?:([0-9]+)D)?(?:T(?:([0-9]+)H)?(?:([0-9]+)M)?)?$`)
+
+    // MITRE ATT&CK technique and sub-technique identifiers
+    sentinelTechniquePattern = regexp.MustCompile(`^T[0-9]{4}(\.[0-9]{3})?$`)
+
+    // sentinelTriggerOperators accepts both YAML and ARM operator spellings
+    sentinelTriggerOperators = map[string]bool{
+        "gt": true, "lt": true, "eq": true, "ne": true,
+        "GreaterThan": true, "LessThan": true, "Equal": true, "NotEqual": true,
+    }
+
+    sentinelSeverities = map[string]bool{
+        "Informational": true, "Low": true, "Medium": true, "High": true,
+    }
+
+    // sentinelTactics lists tactic names as spelled by Sentinel
+    sentinelTactics = map[string]bool{
+        "Reconnaissance": true, "ResourceDevelopment": true, "InitialAccess": true,
+        "Execution": true, "Persistence": true, "PrivilegeEscalation": true,
+        "DefenseEvasion": true, "CredentialAccess": true, "Discovery": true,
+        "LateralMovement": true, "Collection": true, "CommandAndControl": true,
+        "Exfiltration": true, "Impact": true, "PreAttack": true,
+        "ImpairProcessControl": true, "InhibitResponseFunction": true,
+    }
+
+    sentinelEntityTypes = map[string]bool{
+        "Account": true, "Host": true, "IP": true, "URL": true, "FileHash": true,
+        "File": true, "Process": true, "CloudApplication": true, "DNS": true,
+        "AzureResource": true, "RegistryKey": true, "RegistryValue": true,
+        "SecurityGroup": true, "Mailbox": true, "MailCluster": true,
+        "MailMessage": true, "SubmissionMail": true, "Malware": true, "IoTDevice": true,
+    }
+)
+
+// sentinelRule mirrors the fields of a Sentinel analytics rule YAML document
+// that are relevant for validation
+type sentinelRule struct {
+    ID                 string                  `yaml:"id"`
+    Name               string                  `yaml:"name"`
+    Description        string                  `yaml:"description"`
+    Kind               string                  `yaml:"kind"`
+    Severity           string                  `yaml:"severity"`
+    QueryFrequency     string                  `yaml:"queryFrequency"`
+    QueryPeriod        string                  `yaml:"queryPeriod"`
+    TriggerOperator    string                  `yaml:"triggerOperator"`
+    TriggerThreshold   *int                    `yaml:"triggerThreshold"`
+    Tactics            []string                `yaml:"tactics"`
+    RelevantTechniques []string                `yaml:"relevantTechniques"`
+    Query              string                  `yaml:"query"`
+    EntityMappings     []sentinelEntityMapping `yaml:"entityMappings"`
+}
+
+type sentinelEntityMapping struct {
+    EntityType    string                 `yaml:"entityType"`
+    FieldMappings []sentinelFieldMapping `yaml:"fieldMappings"`
+}
+
+type sentinelFieldMapping struct {
+    Identifier string `yaml:"identifier"`
+    ColumnName string `yaml:"columnName"`
+}
+
+// ValidateSentinelDetection validates a full Microsoft Sentinel analytics rule,
+// including scheduling, trigger, tactic and entity mapping settings. The query
+// body is delegated to the KQL validator and its issues are reported under the
+// "query" location.
+func ValidateSentinelDetection(detection *models.Detection) (*models.ValidationResult, error) {
+    log := logger.GetLogger()
+    log.Info("Starting Sentinel analytics rule validation")
+
+    result, err := models.NewValidationResult(detection)
+    if err != nil {
+        return nil, utils.WrapError(err, "failed to create validation result")
+    }
+
+    content, err := detection.GetContent()
+    if err != nil {
+        return nil, utils.WrapError(err, "failed to get detection content")
+    }
+
+    var rule sentinelRule
+    if err := yaml.Unmarshal([]byte(content), &rule); err != nil {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("Analytics rule is not valid YAML: %v", err),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "syntax",
+            IssueCode:   "SENT001",
+            Remediation: "Correct the YAML syntax of the analytics rule",
+        })
+        return result, nil
+    }
+
+    if rule.Kind == "" {
+        rule.Kind = sentinelKindScheduled
+    }
+
+    validateSentinelRequiredFields(&rule, result)
+    validateSentinelSchedule(&rule, result)
+    validateSentinelTrigger(&rule, result)
+    validateSentinelClassification(&rule, result)
+    validateSentinelEntityMappings(&rule, result)
+
+    if strings.TrimSpace(rule.Query) != "" {
+        if err := validateSentinelQuery(rule.Query, result); err != nil {
+            return nil, err
+        }
+    }
+
+    result.FormatSpecificDetails["sentinel_kind"] = rule.Kind
+    result.FormatSpecificDetails["query_frequency"] = rule.QueryFrequency
+    result.FormatSpecificDetails["query_period"] = rule.QueryPeriod
+    result.FormatSpecificDetails["tactics"] = rule.Tactics
+    result.FormatSpecificDetails["entity_mapping_count"] = len(rule.EntityMappings)
+
+    log.Info("Completed Sentinel analytics rule validation",
+        "confidence_score", result.ConfidenceScore,
+        "issues_count", len(result.Issues))
+
+    return result, nil
+}
+
+// validateSentinelRequiredFields checks that mandatory rule attributes are present
+func validateSentinelRequiredFields(rule *sentinelRule, result *models.ValidationResult) {
+    type requiredField struct{ name, value string }
+    required := []requiredField{
+        {"id", rule.ID},
+        {"name", rule.Name},
+        {"severity", rule.Severity},
+        {"query", strings.TrimSpace(rule.Query)},
+    }
+    if rule.Kind == sentinelKindScheduled {
+        required = append(required,
+            requiredField{"queryFrequency", rule.QueryFrequency},
+            requiredField{"queryPeriod", rule.QueryPeriod},
+            requiredField{"triggerOperator", rule.TriggerOperator},
+        )
+    }
+
+    for _, field := range required {
+        if field.value != "" {
+            continue
+        }
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("Required field %q is missing", field.name),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    field.name,
+            IssueCode:   "SENT002",
+            Remediation: fmt.Sprintf("Add the %q attribute to the analytics rule", field.name),
+        })
+    }
+
+    if rule.Kind == sentinelKindScheduled && rule.TriggerThreshold == nil {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     `Required field "triggerThreshold" is missing`,
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "triggerThreshold",
+            IssueCode:   "SENT002",
+            Remediation: `Add the "triggerThreshold" attribute to the analytics rule`,
+        })
+    }
+}
+
+// validateSentinelSchedule checks queryFrequency and queryPeriod against the
+// limits enforced by Sentinel for scheduled rules
+func validateSentinelSchedule(rule *sentinelRule, result *models.ValidationResult) {
+    if rule.Kind != sentinelKindScheduled {
+        return
+    }
+
+    frequency, freqOK := checkSentinelDuration("queryFrequency", rule.QueryFrequency, sentinelMaxQueryFrequency, result)
+    period, periodOK := checkSentinelDuration("queryPeriod", rule.QueryPeriod, sentinelMaxQueryPeriod, result)
+
+    if freqOK && periodOK && period < frequency {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("queryPeriod %s is shorter than queryFrequency %s; events between runs will be missed", rule.QueryPeriod, rule.QueryFrequency),
+            Severity:    models.ValidationSeverityMedium,
+            Location:    "queryPeriod",
+            IssueCode:   "SENT003",
+            Remediation: "Set queryPeriod to at least the queryFrequency",
+        })
+    }
+}
+
+// checkSentinelDuration parses and range-checks a schedule duration
+func checkSentinelDuration(field, value string, max time.Duration, result *models.ValidationResult) (time.Duration, bool) {
+    if value == "" {
+        return 0, false
+    }
+
+    d, err := parseSentinelDuration(value)
+    if err != nil {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("%s %q is not a valid duration", field, value),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    field,
+            IssueCode:   "SENT003",
+            Remediation: "Use a duration such as 5m, 1h, 1d or PT1H",
+        })
+        return 0, false
+    }
+
+    if d < sentinelMinInterval || d > max {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("%s %s is outside the allowed range %s to %s", field, value, sentinelMinInterval, max),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    field,
+            IssueCode:   "SENT003",
+            Remediation: fmt.Sprintf("Set %s between 5 minutes and 14 days", field),
+        })
+        return d, false
+    }
+
+    return d, true
+}
+
+// parseSentinelDuration parses compact (1h) or ISO 8601 (PT1H) durations
+func parseSentinelDuration(value string) (time.Duration, error) {
+    if m := sentinelCompactDurationPattern.FindStringSubmatch(value); m != nil {
+        n, err := strconv.Atoi(m[1])
+        if err != nil {
+            return 0, err
+        }
+        switch m[2] {
+        case "m":
+            return time.Duration(n) * time.Minute, nil
+        case "h":
+            return time.Duration(n) * time.Hour, nil
+        default:
+            return time.Duration(n) * 24 * time.Hour, nil
+        }
+    }
+
+    if m := sentinelISODurationPattern.FindStringSubmatch(value); m != nil && value != "P" && value != "PT" {
+        var d time.Duration
+        units := []time.Duration{24 * time.Hour, time.Hour, time.Minute}
+        for i, unit := range units {
+            if m[i+1] == "" {
+                continue
+            }
+            n, err := strconv.Atoi(m[i+1])
+            if err != nil {
+                return 0, err
+            }
+            d += time.Duration(n) * unit
+        }
+        return d, nil
+    }
+
+    return 0, fmt.Errorf("unrecognized duration %q", value)
+}
+
+// validateSentinelTrigger checks the alert trigger configuration
+func validateSentinelTrigger(rule *sentinelRule, result *models.ValidationResult) {
+    if rule.TriggerOperator != "" && !sentinelTriggerOperators[rule.TriggerOperator] {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("Unknown triggerOperator %q", rule.TriggerOperator),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "triggerOperator",
+            IssueCode:   "SENT004",
+            Remediation: "Use one of gt, lt, eq or ne",
+        })
+    }
+
+    if rule.TriggerThreshold != nil && (*rule.TriggerThreshold < 0 || *rule.TriggerThreshold > sentinelMaxTriggerThreshold) {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("triggerThreshold %d is outside the allowed range 0 to %d", *rule.TriggerThreshold, sentinelMaxTriggerThreshold),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "triggerThreshold",
+            IssueCode:   "SENT004",
+            Remediation: fmt.Sprintf("Set triggerThreshold between 0 and %d", sentinelMaxTriggerThreshold),
+        })
+    }
+}
+
+// validateSentinelClassification checks severity, tactics and techniques
+func validateSentinelClassification(rule *sentinelRule, result *models.ValidationResult) {
+    if rule.Severity != "" && !sentinelSeverities[rule.Severity] {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("Unknown severity %q", rule.Severity),
+            Severity:    models.ValidationSeverityMedium,
+            Location:    "severity",
+            IssueCode:   "SENT005",
+            Remediation: "Use one of Informational, Low, Medium or High",
+        })
+    }
+
+    if len(rule.Tactics) == 0 {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     "No MITRE ATT&CK tactics are assigned",
+            Severity:    models.ValidationSeverityLow,
+            Location:    "tactics",
+            IssueCode:   "SENT006",
+            Remediation: "Assign the tactics covered by the detection",
+        })
+    }
+    for _, tactic := range rule.Tactics {
+        if !sentinelTactics[tactic] {
+            result.AddIssue(&models.ValidationIssue{
+                Message:     fmt.Sprintf("Unknown tactic %q", tactic),
+                Severity:    models.ValidationSeverityMedium,
+                Location:    "tactics",
+                IssueCode:   "SENT006",
+                Remediation: "Use Sentinel tactic names such as InitialAccess or LateralMovement",
+            })
+        }
+    }
+
+    for _, technique := range rule.RelevantTechniques {
+        if !sentinelTechniquePattern.MatchString(technique) {
+            result.AddIssue(&models.ValidationIssue{
+                Message:     fmt.Sprintf("Invalid technique identifier %q", technique),
+                Severity:    models.ValidationSeverityLow,
+                Location:    "relevantTechniques",
+                IssueCode:   "SENT007",
+                Remediation: "Use ATT&CK technique IDs such as T1059 or T1059.001",
+            })
+        }
+    }
+}
+
+// validateSentinelEntityMappings checks entity types, identifiers and that the
+// mapped columns are produced by the query
+func validateSentinelEntityMappings(rule *sentinelRule, result *models.ValidationResult) {
+    if len(rule.EntityMappings) > sentinelMaxEntityMappings {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("Rule defines %d entity mappings; at most %d are allowed", len(rule.EntityMappings), sentinelMaxEntityMappings),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "entityMappings",
+            IssueCode:   "SENT008",
+            Remediation: "Reduce the number of entity mappings",
+        })
+    }
+
+    for i, mapping := range rule.EntityMappings {
+        location := fmt.Sprintf("entityMappings[%d]", i)
+
+        if !sentinelEntityTypes[mapping.EntityType] {
+            result.AddIssue(&models.ValidationIssue{
+                Message:     fmt.Sprintf("Unknown entityType %q", mapping.EntityType),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    location,
+                IssueCode:   "SENT008",
+                Remediation: "Use a supported Sentinel entity type such as Account, Host or IP",
+            })
+        }
+
+        if len(mapping.FieldMappings) == 0 || len(mapping.FieldMappings) > sentinelMaxFieldMappings {
+            result.AddIssue(&models.ValidationIssue{
+                Message:     fmt.Sprintf("Entity mapping must define between 1 and %d field mappings", sentinelMaxFieldMappings),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    location,
+                IssueCode:   "SENT008",
+                Remediation: "Adjust the fieldMappings of the entity",
+            })
+        }
+
+        for _, field := range mapping.FieldMappings {
+            if field.Identifier == "" || field.ColumnName == "" {
+                result.AddIssue(&models.ValidationIssue{
+                    Message:     "Field mapping requires both identifier and columnName",
+                    Severity:    models.ValidationSeverityHigh,
+                    Location:    location,
+                    IssueCode:   "SENT008",
+                    Remediation: "Set identifier and columnName for every field mapping",
+                })
+                continue
+            }
+            if rule.Query != "" && !strings.Contains(rule.Query, field.ColumnName) {
+                result.AddIssue(&models.ValidationIssue{
+                    Message:     fmt.Sprintf("Mapped column %q does not appear in the query", field.ColumnName),
+                    Severity:    models.ValidationSeverityMedium,
+                    Location:    location,
+                    IssueCode:   "SENT009",
+                    Remediation: "Project or extend the mapped column in the query",
+                })
+            }
+        }
+    }
+}
+
+// validateSentinelQuery runs the KQL validator on the query body and merges
+// its findings into the analytics rule result
+func validateSentinelQuery(query string, result *models.ValidationResult) error {
+    kqlResult, err := ValidateKQLDetection(&models.Detection{
+        Content: query,
+        Format:  models.DetectionFormatKQL,
+    })
+    if err != nil {
+        return utils.WrapError(err, "failed to validate Sentinel query")
+    }
+
+    result.MergeIssues(kqlResult.Issues, func(issue *models.ValidationIssue) {
+        issue.Location = "query." + issue.Location
+    })
+    result.FormatSpecificDetails["query"] = kqlResult.FormatSpecificDetails
+    return nil
+}
@@ -0,0 +1,61 @@
+package validation
+
+import "time"
+
+// Settings are the parts of the service configuration that can change while
+// it runs
+type Settings struct {
+    ValidationTimeout time.Duration
+    StrictMode        bool
+}
+
+// Settings returns the settings currently in effect
+func (s *ValidationService) Settings() Settings {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    return Settings{
+        ValidationTimeout: s.config.ValidationTimeout,
+        StrictMode:        s.config.StrictMode,
+    }
+}
+
+// ApplySettings replaces the settings of the service. Validations already
+// running finish with the previous settings. When strict mode changes, the
+// built-in validators are rebuilt; external plugins keep the strictness they
+// were loaded with until restart.
+func (s *ValidationService) ApplySettings(settings Settings) error {
+    current := s.Settings()
+
+    var rebuilt []FormatValidator
+    if settings.StrictMode != current.StrictMode {
+        var err error
+        rebuilt, err = BuiltinRegistry().Build(PluginOptions{
+            StrictMode:    settings.StrictMode,
+            Scoring:       s.config.Scoring,
+            QRadarVersion: s.config.QRadarVersion,
+        })
+        if err != nil {
+            return err
+        }
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.config.ValidationTimeout = settings.ValidationTimeout
+    s.config.StrictMode = settings.StrictMode
+    for _, validator := range rebuilt {
+        format := validator.Describe().Format
+        if _, exists := s.validators[format]; exists {
+            s.validators[format] = &formatValidator{plugin: validator}
+        }
+    }
+
+    s.log.Info("Validation settings applied",
+        "validation_timeout", settings.ValidationTimeout,
+        "strict_mode", settings.StrictMode,
+        "rebuilt_validators", len(rebuilt),
+    )
+    return nil
+}
@@ -5,6 +5,7 @@ package validation
 import (
     "context"
     "fmt"
+    "strings"
     "time"
 
     "gopkg.in/yaml.v3" // v3.0.1
@@ -41,20 +42,6 @@ type SigmaValidator struct {
     timeout          time.Duration
 }
 
-// init registers the SIGMA validator with confidence score weights
-func init() {
-    weights := map[string]float64{
-        "yaml_structure":  weightYAMLStructure,
-        "required_fields": weightRequiredFields,
-        "detection_logic": weightDetectionLogic,
-        "logsource":      weightLogsource,
-        "field_mappings": weightFieldMappings,
-    }
-
-    validator := NewSigmaValidator(weights, defaultValidationTimeout)
-    logger.GetLogger().Info("Registered SIGMA validator with confidence scoring")
-}
-
 // NewSigmaValidator creates a new SIGMA validator instance with configured weights
 func NewSigmaValidator(weights map[string]float64, timeout time.Duration) *SigmaValidator {
     return &SigmaValidator{
@@ -68,7 +55,7 @@ func NewSigmaValidator(weights map[string]float64, timeout time.Duration) *Sigma
 func (v *SigmaValidator) Validate(ctx context.Context, detection *models.Detection) (*models.ValidationResult, error) {
     // Record validation request metric
     if err := metrics.RecordValidationRequest("sigma"); err != nil {
-        v.logger.Error("Failed to record validation request", "error", err)
+        logger.FromContext(ctx).Error("Failed to record validation request", "error", err)
     }
 
     // Start validation timer
@@ -76,7 +63,7 @@ func (v *SigmaValidator) Validate(ctx context.Context, detection *models.Detecti
     defer func() {
         duration := time.Since(startTime)
         if err := metrics.RecordValidationDuration("sigma", duration); err != nil {
-            v.logger.Error("Failed to record validation duration", "error", err)
+            logger.FromContext(ctx).Error("Failed to record validation duration", "error", err)
         }
     }()
 
@@ -107,7 +94,7 @@ func (v *SigmaValidator) Validate(ctx context.Context, detection *models.Detecti
     }
 
     // Validate SIGMA fields
-    issues, confidenceScore, err := v.validateSigmaFields(parsedYAML)
+    issues, confidenceScore, err := v.validateSigmaFields(parsedYAML, len(content), sigmaTaxonomyFromContext(ctx), customSchemasFromContext(ctx))
     if err != nil {
         metrics.RecordValidationError("sigma", "validation")
         result.AddIssue(&models.ValidationIssue{
@@ -121,12 +108,10 @@ func (v *SigmaValidator) Validate(ctx context.Context, detection *models.Detecti
     }
 
     // Add field validation issues to result
-    for _, issue := range issues {
-        result.AddIssue(&issue)
-    }
+    result.MergeIssues(issues, nil)
 
     // Set final confidence score
-    result.SetConfidenceScore(confidenceScore)
+    result.SetConfidenceScore(confidenceScore, "sigma", "SIGMA field weights")
 
     return result, nil
 }
@@ -145,8 +130,19 @@ func (v *SigmaValidator) validateYAMLStructure(content string) (map[string]inter
     return parsedYAML, nil
 }
 
-// validateSigmaFields performs comprehensive validation of SIGMA rule fields
-func (v *SigmaValidator) validateSigmaFields(rule map[string]interface{}) ([]models.ValidationIssue, float64, error) {
+// sigmaSection collects the issues and confidence deductions of one section
+// of a Sigma rule
+type sigmaSection struct {
+    issues []models.ValidationIssue
+    score  float64
+}
+
+// validateSigmaFields performs comprehensive validation of SIGMA rule
+// fields against the taxonomy. custom holds the tenant's custom logsource
+// schemas, if any. The logsource, the detection, its fields and the fields
+// projection are validated concurrently for rules of size bytes or more and
+// their issues recorded in that order.
+func (v *SigmaValidator) validateSigmaFields(rule map[string]interface{}, size int, taxonomy *SigmaLogsourceSchema, custom *customSchemaFields) ([]models.ValidationIssue, float64, error) {
     var issues []models.ValidationIssue
     confidenceScore := 100.0
 
@@ -164,18 +160,42 @@ func (v *SigmaValidator) validateSigmaFields(rule map[string]interface{}) ([]mod
         }
     }
 
+    logsourceSection, detectionSection, fieldsSection, projectionSection := &sigmaSection{}, &sigmaSection{}, &sigmaSection{}, &sigmaSection{}
+    sections := newSectionGroup(size)
+
     // Validate logsource configuration
     if logsource, ok := rule["logsource"].(map[string]interface{}); ok {
-        if err := v.validateLogsource(logsource, &issues, &confidenceScore); err != nil {
-            return issues, confidenceScore, err
-        }
+        sections.Go(func() error {
+            return v.validateLogsource(logsource, &logsourceSection.issues, &logsourceSection.score)
+        })
     }
 
     // Validate detection section
     if detection, ok := rule["detection"].(map[string]interface{}); ok {
-        if err := v.validateDetection(detection, &issues, &confidenceScore); err != nil {
-            return issues, confidenceScore, err
-        }
+        sections.Go(func() error {
+            return v.validateDetection(detection, &detectionSection.issues, &detectionSection.score)
+        })
+    }
+
+    // Check the fields the detection matches on against the taxonomy
+    sections.Go(func() error {
+        v.validateTaxonomyFields(rule, taxonomy, custom, &fieldsSection.issues, &fieldsSection.score)
+        return nil
+    })
+
+    // Validate the optional fields projection
+    sections.Go(func() error {
+        v.validateProjection(rule, taxonomy, custom, &projectionSection.issues, &projectionSection.score)
+        return nil
+    })
+
+    err := sections.Wait()
+    for _, section := range []*sigmaSection{logsourceSection, detectionSection, fieldsSection, projectionSection} {
+        issues = append(issues, section.issues...)
+        confidenceScore += section.score
+    }
+    if err != nil {
+        return issues, confidenceScore, err
     }
 
     // Ensure confidence score doesn't go below 0
@@ -223,7 +243,7 @@ func (v *SigmaValidator) validateDetection(detection map[string]interface{}, iss
     // Validate search identifiers
     hasSearchIdentifiers := false
     for key, value := range detection {
-        if key != "condition" {
+        if !sigmaReservedKeys[key] {
             hasSearchIdentifiers = true
             if err := v.validateSearchIdentifier(key, value, issues, confidenceScore); err != nil {
                 return err
@@ -240,6 +260,8 @@ func (v *SigmaValidator) validateDetection(detection map[string]interface{}, iss
             Remediation: "Add at least one search identifier with detection criteria",
         })
         *confidenceScore -= v.confidenceWeights["detection_logic"]
+    } else {
+        v.validateCondition(detection, issues, confidenceScore)
     }
 
     return nil
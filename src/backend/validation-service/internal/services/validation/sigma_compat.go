@@ -0,0 +1,371 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    "context"
+    "fmt"
+    "regexp"
+    "sort"
+    "strings"
+
+    "gopkg.in/yaml.v3" // v3.0.1
+
+    "validation-service/internal/models"
+)
+
+// sigmaBackends are the target formats pySigma backends translate Sigma
+// rules to. Sigma rules cannot be translated to other formats.
+var sigmaBackends = map[string]bool{
+    models.DetectionFormatSplunk:      true,
+    models.DetectionFormatKQL:         true,
+    models.DetectionFormatSentinel:    true,
+    models.DetectionFormatQRadar:      true,
+    models.DetectionFormatCrowdstrike: true,
+    models.DetectionFormatPaloAlto:    true,
+    models.DetectionFormatYaraL:       true,
+}
+
+var (
+    // sigmaKeywordUnsupported are the backends whose query languages need a
+    // field for every value, so keyword lists cannot be translated
+    sigmaKeywordUnsupported = map[string]bool{
+        models.DetectionFormatKQL:      true,
+        models.DetectionFormatSentinel: true,
+        models.DetectionFormatPaloAlto: true,
+        models.DetectionFormatYaraL:    true,
+    }
+    // sigmaRE2Backends match regular expressions with RE2, which has no
+    // lookaround or backreferences
+    sigmaRE2Backends = map[string]bool{
+        models.DetectionFormatKQL:      true,
+        models.DetectionFormatSentinel: true,
+        models.DetectionFormatPaloAlto: true,
+        models.DetectionFormatYaraL:    true,
+    }
+    // sigmaRegexFlagBackends translate the re modifier's i, m and s flags;
+    // others only match them when the backend emits inline flags
+    sigmaRegexFlagBackends = map[string]bool{
+        models.DetectionFormatSplunk:   true,
+        models.DetectionFormatKQL:      true,
+        models.DetectionFormatSentinel: true,
+    }
+    // sigmaIPv4CIDRBackends only match IPv4 ranges with the function the
+    // cidr modifier translates to
+    sigmaIPv4CIDRBackends = map[string]bool{
+        models.DetectionFormatKQL:      true,
+        models.DetectionFormatSentinel: true,
+    }
+    // sigmaFieldRefUnsupported cannot compare one field with another
+    sigmaFieldRefUnsupported = map[string]bool{
+        models.DetectionFormatQRadar:      true,
+        models.DetectionFormatCrowdstrike: true,
+    }
+
+    // sigmaNearRegex matches the near operator of a Sigma 1 condition
+    sigmaNearRegex = regexp.MustCompile(`\|\s*near\b`)
+    // sigmaRE2UnsupportedRegex matches lookaround, atomic groups and
+    // backreferences
+    sigmaRE2UnsupportedRegex = regexp.MustCompile(`\(\?<?[=!]|\(\?>|\\[1-9]`)
+)
+
+// SigmaCompatibilityTargets are the target formats Sigma compatibility is
+// checked for
+var SigmaCompatibilityTargets = []string{
+    models.DetectionFormatSplunk,
+    models.DetectionFormatKQL,
+    models.DetectionFormatSentinel,
+    models.DetectionFormatQRadar,
+    models.DetectionFormatCrowdstrike,
+    models.DetectionFormatPaloAlto,
+    models.DetectionFormatYaraL,
+}
+
+// sigmaFieldUse is a field condition of a Sigma search identifier
+type sigmaFieldUse struct {
+    identifier string
+    field      string
+    modifiers  []string
+    values     []interface{}
+}
+
+// SigmaCompatibilityValidator reports the constructs of a Sigma rule that
+// the backend of the target format cannot translate, or translates with
+// caveats, as SIGMA-COMPAT issues
+type SigmaCompatibilityValidator struct {
+    targetFormat string
+}
+
+// NewSigmaCompatibilityValidator creates a compatibility validator for Sigma
+// rules translated to targetFormat
+func NewSigmaCompatibilityValidator(targetFormat string) *SigmaCompatibilityValidator {
+    return &SigmaCompatibilityValidator{targetFormat: targetFormat}
+}
+
+// Validate implements the Validator interface for Sigma -> target pairs
+func (v *SigmaCompatibilityValidator) Validate(ctx context.Context, sourceDetection *models.Detection, targetDetection *models.Detection, result *models.ValidationResult) error {
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+    var rule map[string]interface{}
+    if err := yaml.Unmarshal([]byte(sourceDetection.Content), &rule); err != nil {
+        // The Sigma validator reports the source
+        return nil
+    }
+    features := checkSigmaCompatibility(rule, v.targetFormat, result)
+    result.FormatSpecificDetails["sigma_features"] = features
+    return nil
+}
+
+// CheckSigmaCompatibility checks, before translating, whether the backend of
+// targetFormat supports the constructs of a Sigma rule. The result lists the
+// unsupported constructs as SIGMA-COMPAT issues, scored like a validation
+// of targetFormat.
+func (s *ValidationService) CheckSigmaCompatibility(ctx context.Context, sourceDetection *models.Detection, targetFormat string) (*models.ValidationResult, error) {
+    if sourceDetection == nil {
+        return nil, fmt.Errorf("source detection cannot be nil")
+    }
+    if sourceDetection.Format != models.DetectionFormatSigma {
+        return nil, fmt.Errorf("%w: compatibility is checked for sigma rules, not %s", ErrUnsupportedFormat, sourceDetection.Format)
+    }
+    if err := ctx.Err(); err != nil {
+        return nil, err
+    }
+
+    var rule map[string]interface{}
+    if err := yaml.Unmarshal([]byte(sourceDetection.Content), &rule); err != nil {
+        return nil, fmt.Errorf("invalid sigma rule: %w", err)
+    }
+
+    result, err := models.NewValidationResultWith(sourceDetection, s.config.Clock, s.config.IDs)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create validation result: %w", err)
+    }
+    result.TargetFormat = targetFormat
+    result.SetScoringPolicy(s.config.Scoring.For(targetFormat))
+
+    result.FormatSpecificDetails["sigma_features"] = checkSigmaCompatibility(rule, targetFormat, result)
+    // Unsupported constructs fail the translation; caveats need review
+    for _, issue := range result.Issues {
+        if issue.Severity == models.ValidationSeverityHigh {
+            result.Status = models.ValidationStatusError
+            break
+        }
+        result.Status = models.ValidationStatusWarning
+    }
+    return result, nil
+}
+
+// checkSigmaCompatibility records the compatibility issues of rule for
+// targetFormat on result and returns the constructs the rule uses
+func checkSigmaCompatibility(rule map[string]interface{}, targetFormat string, result *models.ValidationResult) []string {
+    detection, _ := rule["detection"].(map[string]interface{})
+    features := make(map[string]bool)
+
+    if !sigmaBackends[targetFormat] {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("No Sigma backend translates rules to %s", targetFormat),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "target_format",
+            IssueCode:   "SIGMA-COMPAT009",
+            Remediation: fmt.Sprintf("Translate the rule to one of %s, or write the %s rule by hand", strings.Join(SigmaCompatibilityTargets, ", "), targetFormat),
+        })
+        return []string{}
+    }
+
+    for _, condition := range sigmaConditions(detection["condition"]) {
+        switch {
+        case sigmaNearRegex.MatchString(condition):
+            features["near"] = true
+            result.AddIssue(&models.ValidationIssue{
+                Message:     "The near operator is not supported by any pySigma backend",
+                Severity:    models.ValidationSeverityHigh,
+                Location:    "detection.condition",
+                IssueCode:   "SIGMA-COMPAT002",
+                Remediation: "Rewrite the rule as a temporal correlation rule over the search identifiers",
+            })
+        case strings.Contains(condition, "|"):
+            features["aggregation"] = true
+            result.AddIssue(&models.ValidationIssue{
+                Message:     "Aggregation expressions such as | count() by are not supported by any pySigma backend",
+                Severity:    models.ValidationSeverityHigh,
+                Location:    "detection.condition",
+                IssueCode:   "SIGMA-COMPAT001",
+                Remediation: "Rewrite the aggregation as an event_count or value_count correlation rule",
+            })
+        }
+    }
+
+    identifiers := make([]string, 0, len(detection))
+    for identifier := range detection {
+        if identifier != "condition" && identifier != "timeframe" {
+            identifiers = append(identifiers, identifier)
+        }
+    }
+    sort.Strings(identifiers)
+
+    for _, identifier := range identifiers {
+        uses, keywords := sigmaFieldUses(identifier, detection[identifier])
+        if keywords {
+            features["keywords"] = true
+            if sigmaKeywordUnsupported[targetFormat] {
+                result.AddIssue(&models.ValidationIssue{
+                    Message:     fmt.Sprintf("Keyword list %s matches values in any field, which %s cannot search without a field name", identifier, targetFormat),
+                    Severity:    models.ValidationSeverityHigh,
+                    Location:    fmt.Sprintf("detection.%s", identifier),
+                    IssueCode:   "SIGMA-COMPAT003",
+                    Remediation: "Replace the keywords with field conditions, e.g. CommandLine|contains",
+                })
+            }
+        }
+        for _, use := range uses {
+            checkSigmaFieldUse(use, targetFormat, features, result)
+        }
+    }
+
+    names := make([]string, 0, len(features))
+    for feature := range features {
+        names = append(names, feature)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// checkSigmaFieldUse records the compatibility issues of the modifiers of a
+// field condition
+func checkSigmaFieldUse(use sigmaFieldUse, targetFormat string, features map[string]bool, result *models.ValidationResult) {
+    location := fmt.Sprintf("detection.%s.%s", use.identifier, use.field)
+    hasModifier := func(modifier string) bool {
+        for _, m := range use.modifiers {
+            if m == modifier {
+                return true
+            }
+        }
+        return false
+    }
+
+    if hasModifier("re") {
+        features["re"] = true
+        if sigmaRE2Backends[targetFormat] {
+            for _, value := range use.values {
+                pattern, _ := value.(string)
+                if construct := sigmaRE2UnsupportedRegex.FindString(pattern); construct != "" {
+                    result.AddIssue(&models.ValidationIssue{
+                        Message:     fmt.Sprintf("Regular expression uses %s, which the RE2 engine of %s does not support", construct, targetFormat),
+                        Severity:    models.ValidationSeverityHigh,
+                        Location:    location,
+                        IssueCode:   "SIGMA-COMPAT004",
+                        Remediation: "Rewrite the pattern without lookaround, atomic groups or backreferences, or split it into several conditions",
+                    })
+                    break
+                }
+            }
+        }
+        for _, flag := range []string{"i", "m", "s"} {
+            if !hasModifier(flag) {
+                continue
+            }
+            features["re|"+flag] = true
+            if !sigmaRegexFlagBackends[targetFormat] {
+                result.AddIssue(&models.ValidationIssue{
+                    Message:     fmt.Sprintf("The %s backend may not translate the re|%s flag; the pattern could match differently", targetFormat, flag),
+                    Severity:    models.ValidationSeverityMedium,
+                    Location:    location,
+                    IssueCode:   "SIGMA-COMPAT005",
+                    Remediation: fmt.Sprintf("Prefix the pattern with the inline flag (?%s) instead of the modifier, and check the translated query", flag),
+                })
+            }
+        }
+    }
+
+    if hasModifier("cidr") {
+        features["cidr"] = true
+        if sigmaIPv4CIDRBackends[targetFormat] {
+            for _, value := range use.values {
+                if cidr, _ := value.(string); strings.Contains(cidr, ":") {
+                    result.AddIssue(&models.ValidationIssue{
+                        Message:     fmt.Sprintf("IPv6 range %s is translated to ipv4_is_in_range, which never matches it", cidr),
+                        Severity:    models.ValidationSeverityMedium,
+                        Location:    location,
+                        IssueCode:   "SIGMA-COMPAT006",
+                        Remediation: "Move IPv6 ranges to a separate condition and translate it with ipv6_is_in_range",
+                    })
+                    break
+                }
+            }
+        }
+    }
+
+    if hasModifier("fieldref") {
+        features["fieldref"] = true
+        if sigmaFieldRefUnsupported[targetFormat] {
+            result.AddIssue(&models.ValidationIssue{
+                Message:     fmt.Sprintf("The fieldref modifier compares two fields, which the %s backend cannot translate", targetFormat),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    location,
+                IssueCode:   "SIGMA-COMPAT007",
+                Remediation: "Compare the fields in the translated query by hand, or drop the condition",
+            })
+        }
+    }
+
+    if hasModifier("expand") {
+        features["expand"] = true
+        result.AddIssue(&models.ValidationIssue{
+            Message:     "Placeholders of the expand modifier are only translated when the processing pipeline defines them",
+            Severity:    models.ValidationSeverityMedium,
+            Location:    location,
+            IssueCode:   "SIGMA-COMPAT008",
+            Remediation: "Add a value_placeholders or query_expression_placeholders transformation to the pipeline",
+        })
+    }
+}
+
+// sigmaConditions returns the conditions of a detection, which may be a
+// single string or a list
+func sigmaConditions(condition interface{}) []string {
+    switch c := condition.(type) {
+    case string:
+        return []string{c}
+    case []interface{}:
+        conditions := make([]string, 0, len(c))
+        for _, item := range c {
+            if s, ok := item.(string); ok {
+                conditions = append(conditions, s)
+            }
+        }
+        return conditions
+    }
+    return nil
+}
+
+// sigmaFieldUses returns the field conditions of a search identifier, and
+// whether it is, or contains, a keyword list of values without fields
+func sigmaFieldUses(identifier string, value interface{}) ([]sigmaFieldUse, bool) {
+    var uses []sigmaFieldUse
+    keywords := false
+    switch v := value.(type) {
+    case map[string]interface{}:
+        keys := make([]string, 0, len(v))
+        for key := range v {
+            keys = append(keys, key)
+        }
+        sort.Strings(keys)
+        for _, key := range keys {
+            parts := strings.Split(key, "|")
+            values, ok := v[key].([]interface{})
+            if !ok {
+                values = []interface{}{v[key]}
+            }
+            uses = append(uses, sigmaFieldUse{identifier: identifier, field: parts[0], modifiers: parts[1:], values: values})
+        }
+    case []interface{}:
+        for _, item := range v {
+            itemUses, itemKeywords := sigmaFieldUses(identifier, item)
+            uses = append(uses, itemUses...)
+            keywords = keywords || itemKeywords
+        }
+    case string, int, float64, bool:
+        keywords = true
+    }
+    return uses, keywords
+}
@@ -0,0 +1,190 @@
+package validation
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+
+    "validation-service/internal/models"
+    sigmaparser "validation-service/internal/parser/sigma"
+)
+
+// sigmaReservedKeys are the keys of a detection section that are not search
+// identifiers
+var sigmaReservedKeys = map[string]bool{
+    "condition": true,
+    "timeframe": true,
+}
+
+// validateCondition parses the conditions of a detection section and
+// resolves the search identifiers they reference. Every identifier and
+// pattern referenced must name a search identifier, and every search
+// identifier must be reachable from a condition. Reachability is not
+// checked when a condition cannot be parsed. An identifier left unused by a
+// reference differing from it only in case is reported as caused by that
+// reference.
+func (v *SigmaValidator) validateCondition(detection map[string]interface{}, issues *[]models.ValidationIssue, confidenceScore *float64) {
+    value, exists := detection["condition"]
+    if !exists || value == "" {
+        // Reported as SIGMA005
+        return
+    }
+
+    conditions, ok := sigmaConditionList(value)
+    if !ok {
+        *issues = append(*issues, models.ValidationIssue{
+            Message:     "Detection condition must be a string or a list of strings",
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "detection.condition",
+            IssueCode:   "SIGMA012",
+            Remediation: "Write the condition as an expression such as `selection and not filter`",
+        })
+        *confidenceScore -= v.confidenceWeights["detection_logic"]
+        return
+    }
+
+    identifiers := make([]string, 0, len(detection))
+    for key := range detection {
+        if !sigmaReservedKeys[key] {
+            identifiers = append(identifiers, key)
+        }
+    }
+    sort.Strings(identifiers)
+
+    // misspelled maps search identifiers to the first SIGMA013 issue of a
+    // reference differing from them only in case, which leaves them unused
+    misspelled := make(map[string]string)
+    reachable := make(map[string]bool)
+    parsedAll := true
+    for i, condition := range conditions {
+        location := "detection.condition"
+        if len(conditions) > 1 {
+            location = fmt.Sprintf("detection.condition[%d]", i)
+        }
+
+        parsed, errs := sigmaparser.Parse(condition)
+        for _, perr := range errs {
+            *issues = append(*issues, models.ValidationIssue{
+                Message:     fmt.Sprintf("Condition syntax error at %s: %s", sigmaConditionPosition(perr.Pos), perr.Msg),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    location,
+                IssueCode:   "SIGMA012",
+                Remediation: "Join search identifiers with and, or and not, select several with `1 of selection_*` or `all of them`, and aggregate after | with count(), min, max, avg or sum",
+            })
+        }
+        if len(errs) > 0 {
+            parsedAll = false
+            *confidenceScore -= v.confidenceWeights["detection_logic"]
+        }
+
+        for _, ref := range parsed.References() {
+            switch ref := ref.(type) {
+            case *sigmaparser.Identifier:
+                if _, defined := detection[ref.Name]; defined && !sigmaReservedKeys[ref.Name] {
+                    reachable[ref.Name] = true
+                    continue
+                }
+                issue := models.ValidationIssue{
+                    Message:     fmt.Sprintf("Condition references undefined search identifier %s at %s", ref.Name, sigmaConditionPosition(ref.Position)),
+                    Severity:    models.ValidationSeverityHigh,
+                    Location:    location,
+                    IssueCode:   "SIGMA013",
+                    Remediation: "Define the search identifier in the detection section or correct its name in the condition",
+                }
+                if match := sigmaIdentifierFold(identifiers, ref.Name); match != "" {
+                    issue.Remediation = fmt.Sprintf("Search identifiers are case-sensitive; did you mean %s?", match)
+                    if _, seen := misspelled[match]; !seen {
+                        issue.ID = fmt.Sprintf("condition:%d", len(*issues))
+                        misspelled[match] = issue.ID
+                    }
+                }
+                *issues = append(*issues, issue)
+                *confidenceScore -= v.confidenceWeights["detection_logic"] / 2
+            case *sigmaparser.OfExpr:
+                if !ref.Them && ref.Pattern == "" {
+                    // Reported as a syntax error
+                    continue
+                }
+                selected := 0
+                for _, name := range identifiers {
+                    if ref.Selects(name) {
+                        reachable[name] = true
+                        selected++
+                    }
+                }
+                if selected == 0 && !ref.Them {
+                    *issues = append(*issues, models.ValidationIssue{
+                        Message:     fmt.Sprintf("Condition pattern %s at %s matches no search identifier", ref.Pattern, sigmaConditionPosition(ref.Position)),
+                        Severity:    models.ValidationSeverityHigh,
+                        Location:    location,
+                        IssueCode:   "SIGMA013",
+                        Remediation: "Name at least one search identifier so the pattern matches it, or correct the pattern",
+                    })
+                    *confidenceScore -= v.confidenceWeights["detection_logic"] / 2
+                }
+            }
+        }
+    }
+
+    if !parsedAll {
+        return
+    }
+    for _, name := range identifiers {
+        if reachable[name] {
+            continue
+        }
+        remediation := "Reference the search identifier in the condition, or remove it"
+        if strings.HasPrefix(name, "_") {
+            remediation = "Search identifiers starting with _ are not selected by `of them`; reference it by name or by a pattern, or remove it"
+        }
+        *issues = append(*issues, models.ValidationIssue{
+            Message:     fmt.Sprintf("Search identifier %s is not used by the condition", name),
+            Severity:    models.ValidationSeverityMedium,
+            Location:    fmt.Sprintf("detection.%s", name),
+            IssueCode:   "SIGMA014",
+            Remediation: remediation,
+            CausedBy:    misspelled[name],
+        })
+        *confidenceScore -= v.confidenceWeights["field_mappings"] / 2
+    }
+}
+
+// sigmaConditionList returns the conditions of a detection, a string or a
+// list of strings, reporting whether the value has one of these forms
+func sigmaConditionList(value interface{}) ([]string, bool) {
+    switch c := value.(type) {
+    case string:
+        return []string{c}, true
+    case []interface{}:
+        conditions := make([]string, 0, len(c))
+        for _, item := range c {
+            s, ok := item.(string)
+            if !ok {
+                return nil, false
+            }
+            conditions = append(conditions, s)
+        }
+        return conditions, len(conditions) > 0
+    }
+    return nil, false
+}
+
+// sigmaConditionPosition describes a position in a condition, which is
+// usually a single line
+func sigmaConditionPosition(pos sigmaparser.Position) string {
+    if pos.Line > 1 {
+        return fmt.Sprintf("line %d, column %d", pos.Line, pos.Column)
+    }
+    return fmt.Sprintf("column %d", pos.Column)
+}
+
+// sigmaIdentifierFold returns the search identifier equal to name but for
+// case, or an empty string
+func sigmaIdentifierFold(identifiers []string, name string) string {
+    for _, identifier := range identifiers {
+        if strings.EqualFold(identifier, name) {
+            return identifier
+        }
+    }
+    return ""
+}
@@ -0,0 +1,184 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    _ "embed"
+    "encoding/json"
+    "fmt"
+    "strings"
+    "sync"
+
+    "validation-service/internal/models"
+)
+
+// defaultSigmaLogsourceSchema lists the fields known to exist for common Sigma
+// logsource categories and product/service pairs
+//
+//go:embed mappings/sigma_logsource_fields.json
+var defaultSigmaLogsourceSchema []byte
+
+// SigmaLogsourceSchema maps Sigma logsources to the event fields they provide.
+// Services are keyed by "product/service".
+type SigmaLogsourceSchema struct {
+    Version    string              `json:"version"`
+    Categories map[string][]string `json:"categories"`
+    Services   map[string][]string `json:"services"`
+}
+
+var (
+    sigmaSchemaOnce sync.Once
+    sigmaSchema     *SigmaLogsourceSchema
+)
+
+// sigmaLogsourceSchema returns the built-in logsource schema, the Sigma
+// taxonomy used until another is set, or nil when it cannot be parsed
+func sigmaLogsourceSchema() *SigmaLogsourceSchema {
+    sigmaSchemaOnce.Do(func() {
+        var schema SigmaLogsourceSchema
+        if err := json.Unmarshal(defaultSigmaLogsourceSchema, &schema); err == nil {
+            sigmaSchema = &schema
+        }
+    })
+    return sigmaSchema
+}
+
+// knownFields returns the fields of the logsource and the schema entry they
+// were taken from. The category takes precedence over the product/service
+// pair; ok is false for logsources the schema does not describe.
+func (s *SigmaLogsourceSchema) knownFields(logsource map[string]interface{}) (fields map[string]bool, entry string, ok bool) {
+    var list []string
+    if category, _ := logsource["category"].(string); category != "" {
+        list, ok = s.Categories[category]
+        entry = "category " + category
+    }
+    if !ok {
+        product, _ := logsource["product"].(string)
+        service, _ := logsource["service"].(string)
+        entry = product + "/" + service
+        list, ok = s.Services[entry]
+        entry = "service " + entry
+    }
+    if !ok {
+        return nil, "", false
+    }
+
+    fields = make(map[string]bool, len(list))
+    for _, field := range list {
+        fields[field] = true
+    }
+    return fields, entry, true
+}
+
+// sigmaProjection returns the fields listed in a rule's optional `fields`
+// section. ok is false when the section is present but not a list of strings.
+func sigmaProjection(rule map[string]interface{}) (fields []string, ok bool) {
+    raw, exists := rule["fields"]
+    if !exists || raw == nil {
+        return nil, true
+    }
+    list, isList := raw.([]interface{})
+    if !isList {
+        return nil, false
+    }
+    for _, item := range list {
+        field, isString := item.(string)
+        if !isString || strings.TrimSpace(field) == "" {
+            return nil, false
+        }
+        fields = append(fields, field)
+    }
+    return fields, true
+}
+
+// sigmaLogsourceFields returns the fields known for a logsource: those of
+// the taxonomy merged with those of the tenant's custom schemas for it.
+// entry names where the fields were taken from; ok is false when neither
+// describes the logsource.
+func sigmaLogsourceFields(logsource map[string]interface{}, taxonomy *SigmaLogsourceSchema, custom *customSchemaFields) (fields map[string]bool, entry string, ok bool) {
+    if taxonomy != nil {
+        fields, entry, ok = taxonomy.knownFields(logsource)
+    }
+    customFields, name, customOK := custom.logsourceFields(logsource)
+    switch {
+    case !customOK:
+        return fields, entry, ok
+    case !ok:
+        return customFields, "custom schema " + name, true
+    }
+    for field := range customFields {
+        fields[field] = true
+    }
+    return fields, entry + " and custom schema " + name, true
+}
+
+// validateProjection checks the `fields` projection against the fields the
+// detection constrains and the fields known for its logsource
+func (v *SigmaValidator) validateProjection(rule map[string]interface{}, taxonomy *SigmaLogsourceSchema, custom *customSchemaFields, issues *[]models.ValidationIssue, confidenceScore *float64) {
+    projection, ok := sigmaProjection(rule)
+    if !ok {
+        *issues = append(*issues, models.ValidationIssue{
+            Message:     "The fields section must be a list of field names",
+            Severity:    models.ValidationSeverityMedium,
+            Location:    "fields",
+            IssueCode:   "SIGMA009",
+            Remediation: "List each output field name as a string under fields",
+        })
+        *confidenceScore -= v.confidenceWeights["field_mappings"] / 2
+        return
+    }
+    if len(projection) == 0 {
+        return
+    }
+
+    constrained := make(map[string]bool)
+    if detection, ok := rule["detection"].(map[string]interface{}); ok {
+        for key, value := range detection {
+            if key != "condition" && key != "timeframe" {
+                collectSigmaFields(value, constrained)
+            }
+        }
+    }
+
+    var known map[string]bool
+    var entry string
+    if logsource, ok := rule["logsource"].(map[string]interface{}); ok {
+        known, entry, _ = sigmaLogsourceFields(logsource, taxonomy, custom)
+    }
+
+    for _, field := range projection {
+        if known != nil && !known[field] {
+            *issues = append(*issues, models.ValidationIssue{
+                Message:     fmt.Sprintf("Projected field %s does not exist for logsource %s", field, entry),
+                Severity:    models.ValidationSeverityMedium,
+                Location:    "fields." + field,
+                IssueCode:   "SIGMA011",
+                Remediation: "Check the field name against the logsource taxonomy, register it in a custom schema or remove it from fields",
+                ID:          sigmaProjectionIssueID(field),
+                RelatedTo:   []string{sigmaTaxonomyIssueID(field)},
+            })
+            *confidenceScore -= v.confidenceWeights["field_mappings"] / float64(2*len(projection))
+            continue
+        }
+        if !constrained[field] {
+            *issues = append(*issues, models.ValidationIssue{
+                Message:     fmt.Sprintf("Projected field %s is not constrained by the detection", field),
+                Severity:    models.ValidationSeverityLow,
+                Location:    "fields." + field,
+                IssueCode:   "SIGMA010",
+                Remediation: "Confirm the field is intended as analyst context; it is not used to match events",
+            })
+        }
+    }
+}
+
+// sigmaTaxonomyIssueID and sigmaProjectionIssueID are the provisional IDs of
+// the SIGMA015 and SIGMA011 issues of a field, relating the two when a field
+// unknown for the logsource is both matched on and projected. The result
+// replaces them with its own IDs and drops links to issues not reported.
+func sigmaTaxonomyIssueID(field string) string {
+    return "taxonomy:" + field
+}
+
+func sigmaProjectionIssueID(field string) string {
+    return "projection:" + field
+}
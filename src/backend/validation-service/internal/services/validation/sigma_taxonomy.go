@@ -0,0 +1,184 @@
+package validation
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "os"
+    "sort"
+    "strings"
+
+    "validation-service/internal/models"
+)
+
+// maxTaxonomySuggestionDistance bounds the edit distance of a suggested
+// taxonomy field from an unknown field
+const maxTaxonomySuggestionDistance = 3
+
+// ParseSigmaTaxonomy parses a Sigma taxonomy dataset, the fields of
+// logsource categories and product/service pairs in the format of the
+// built-in mappings/sigma_logsource_fields.json
+func ParseSigmaTaxonomy(data []byte) (*SigmaLogsourceSchema, error) {
+    var schema SigmaLogsourceSchema
+    if err := json.Unmarshal(data, &schema); err != nil {
+        return nil, fmt.Errorf("parsing Sigma taxonomy: %w", err)
+    }
+    if len(schema.Categories) == 0 && len(schema.Services) == 0 {
+        return nil, fmt.Errorf("Sigma taxonomy describes no logsource")
+    }
+    for name, fields := range schema.Categories {
+        if len(fields) == 0 {
+            return nil, fmt.Errorf("Sigma taxonomy category %s has no fields", name)
+        }
+    }
+    for name, fields := range schema.Services {
+        if len(fields) == 0 || !strings.Contains(name, "/") {
+            return nil, fmt.Errorf("Sigma taxonomy service %s must be keyed product/service and have fields", name)
+        }
+    }
+    return &schema, nil
+}
+
+// LoadSigmaTaxonomy reads the Sigma taxonomy from path, or returns the
+// built-in taxonomy when path is empty
+func LoadSigmaTaxonomy(path string) (*SigmaLogsourceSchema, error) {
+    if path == "" {
+        return ParseSigmaTaxonomy(defaultSigmaLogsourceSchema)
+    }
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("reading Sigma taxonomy: %w", err)
+    }
+    return ParseSigmaTaxonomy(data)
+}
+
+// SetSigmaTaxonomy replaces the Sigma taxonomy the fields of Sigma rules are
+// checked against. Validations already running finish with the previous
+// taxonomy.
+func (s *ValidationService) SetSigmaTaxonomy(taxonomy *SigmaLogsourceSchema) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.sigmaTaxonomy = taxonomy
+}
+
+// sigmaTaxonomyKey carries the Sigma taxonomy of a validation
+type sigmaTaxonomyKey struct{}
+
+// withSigmaTaxonomy returns ctx carrying the Sigma taxonomy in use, when
+// one was set
+func (s *ValidationService) withSigmaTaxonomy(ctx context.Context) context.Context {
+    s.mu.RLock()
+    taxonomy := s.sigmaTaxonomy
+    s.mu.RUnlock()
+    if taxonomy == nil {
+        return ctx
+    }
+    return context.WithValue(ctx, sigmaTaxonomyKey{}, taxonomy)
+}
+
+// sigmaTaxonomyFromContext returns the Sigma taxonomy carried by ctx, or
+// the built-in one, which may be nil
+func sigmaTaxonomyFromContext(ctx context.Context) *SigmaLogsourceSchema {
+    if taxonomy, ok := ctx.Value(sigmaTaxonomyKey{}).(*SigmaLogsourceSchema); ok {
+        return taxonomy
+    }
+    return sigmaLogsourceSchema()
+}
+
+// validateTaxonomyFields checks the fields the search identifiers of the
+// detection match on against the fields the taxonomy and the tenant's
+// custom schemas know for the logsource, suggesting the closest known field
+// for each unknown one. Logsources neither describes are not checked.
+func (v *SigmaValidator) validateTaxonomyFields(rule map[string]interface{}, taxonomy *SigmaLogsourceSchema, custom *customSchemaFields, issues *[]models.ValidationIssue, confidenceScore *float64) {
+    logsource, ok := rule["logsource"].(map[string]interface{})
+    if !ok {
+        return
+    }
+    detection, ok := rule["detection"].(map[string]interface{})
+    if !ok {
+        return
+    }
+    known, entry, ok := sigmaLogsourceFields(logsource, taxonomy, custom)
+    if !ok {
+        return
+    }
+
+    identifiers := make([]string, 0, len(detection))
+    for key := range detection {
+        if !sigmaReservedKeys[key] {
+            identifiers = append(identifiers, key)
+        }
+    }
+    sort.Strings(identifiers)
+
+    // Each unknown field is reported once, under the first identifier
+    // matching on it
+    reported := make(map[string]bool)
+    var unknown []models.ValidationIssue
+    for _, identifier := range identifiers {
+        fields := make(map[string]bool)
+        collectSigmaFields(detection[identifier], fields)
+        for _, field := range sortedKeys(fields) {
+            if known[field] || reported[field] {
+                continue
+            }
+            reported[field] = true
+            issue := models.ValidationIssue{
+                Message:     fmt.Sprintf("Field %s is not in the Sigma taxonomy for %s", field, entry),
+                Severity:    models.ValidationSeverityMedium,
+                Location:    fmt.Sprintf("detection.%s.%s", identifier, field),
+                IssueCode:   "SIGMA015",
+                Remediation: "Use the field name of the Sigma taxonomy for the logsource, or register the field in a custom schema",
+                ID:          sigmaTaxonomyIssueID(field),
+                RelatedTo:   []string{sigmaProjectionIssueID(field)},
+            }
+            if suggestion := closestTaxonomyField(field, known); suggestion != "" {
+                issue.Remediation = fmt.Sprintf("Did you mean %s? %s", suggestion, issue.Remediation)
+                issue.IssueMetadata = map[string]interface{}{"suggestion": suggestion}
+            }
+            unknown = append(unknown, issue)
+        }
+    }
+    for _, issue := range unknown {
+        *issues = append(*issues, issue)
+        *confidenceScore -= v.confidenceWeights["field_mappings"] / float64(2*len(unknown))
+    }
+}
+
+// closestTaxonomyField returns the known field closest to field: one
+// differing only in case, or else the nearest by edit distance within
+// maxTaxonomySuggestionDistance. Ties go to the first field in sorted
+// order; it returns an empty string when no field is close.
+func closestTaxonomyField(field string, known map[string]bool) string {
+    best, bestDistance := "", maxTaxonomySuggestionDistance+1
+    for _, candidate := range sortedKeys(known) {
+        if strings.EqualFold(candidate, field) {
+            return candidate
+        }
+        if distance := editDistance(strings.ToLower(field), strings.ToLower(candidate)); distance < bestDistance {
+            best, bestDistance = candidate, distance
+        }
+    }
+    return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+    previous := make([]int, len(b)+1)
+    current := make([]int, len(b)+1)
+    for j := range previous {
+        previous[j] = j
+    }
+    for i := 1; i <= len(a); i++ {
+        current[0] = i
+        for j := 1; j <= len(b); j++ {
+            cost := 1
+            if a[i-1] == b[j-1] {
+                cost = 0
+            }
+            current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+        }
+        previous, current = current, previous
+    }
+    return previous[len(b)]
+}
@@ -3,34 +3,47 @@ package validation
 
 import (
     "context"
+    "fmt"
     "regexp"
     "strings"
-    "fmt"
-    "time"
 
     "internal/models" // v1.0.0
+    splparser "internal/parser/spl"
 )
 
-// Regular expressions for SPL syntax validation
-var (
-    splunkCommandRegex = regexp.MustCompile(`^\s*(\w+)\s*`)
-    splunkPipelineRegex = regexp.MustCompile(`\|\s*(\w+)\s*`)
-    splunkFieldRegex = regexp.MustCompile(`([\w\.]+)\s*=\s*["']?([^"'\s]+)["']?`)
-    splunkFunctionRegex = regexp.MustCompile(`(\w+)\s*\(([^)]*)\)`)
-    splunkTimeRangeRegex = regexp.MustCompile(`earliest\s*=\s*([^\s]+)\s+latest\s*=\s*([^\s]+)`)
-)
+// splunkPercentileRegex matches percentile statistical functions such as
+// perc95, p99 and exactperc99.9
+var splunkPercentileRegex = regexp.MustCompile(`^(perc|p|exactperc|upperperc)\d+(\.\d+)?$`)
+
+// splunkSearchModifiers are search-time options that are not event fields
+var splunkSearchModifiers = map[string]bool{
+    "index": true, "sourcetype": true, "source": true, "host": true,
+    "earliest": true, "latest": true, "eventtype": true, "tag": true,
+    "splunk_server": true, "savedsearch": true, "_index_earliest": true,
+    "_index_latest": true,
+}
+
+// splunkGeneratingCommands may start a search after a leading pipe
+var splunkGeneratingCommands = map[string]bool{
+    "search": true, "tstats": true, "mstats": true, "inputlookup": true,
+    "inputcsv": true, "makeresults": true, "rest": true, "metadata": true,
+    "metasearch": true, "datamodel": true, "from": true, "dbinspect": true,
+    "eventcount": true, "gentimes": true, "loadjob": true, "savedsearch": true,
+    "multisearch": true, "union": true, "pivot": true, "set": true,
+    "mcatalog": true, "history": true,
+}
 
 // SplunkValidator implements format-specific validation for Splunk SPL
 type SplunkValidator struct {
     supportedCommands map[string]bool
-    supportedFunctions map[string]bool
-    fieldMappings map[string]string
-    commandDependencies map[string][]string
-    config ValidationConfig
+    evalFunctions     map[string]bool
+    statsFunctions    map[string]bool
+    fieldMappings     map[string]string
+    config            SplunkValidatorConfig
 }
 
-// ValidationConfig holds configuration for the validator
-type ValidationConfig struct {
+// SplunkValidatorConfig holds configuration for the validator
+type SplunkValidatorConfig struct {
     Version string
     StrictMode bool
     MaxPipelineDepth int
@@ -39,13 +52,13 @@ type ValidationConfig struct {
 }
 
 // NewSplunkValidator creates a new validator instance with configuration
-func NewSplunkValidator(config ValidationConfig) *SplunkValidator {
+func NewSplunkValidator(config SplunkValidatorConfig) *SplunkValidator {
     v := &SplunkValidator{
         supportedCommands: make(map[string]bool),
-        supportedFunctions: make(map[string]bool),
-        fieldMappings: make(map[string]string),
-        commandDependencies: make(map[string][]string),
-        config: config,
+        evalFunctions:     make(map[string]bool),
+        statsFunctions:    make(map[string]bool),
+        fieldMappings:     make(map[string]string),
+        config:            config,
     }
 
     // Initialize supported SPL commands
@@ -53,17 +66,60 @@ func NewSplunkValidator(config ValidationConfig) *SplunkValidator {
         "search", "where", "stats", "eval", "rename",
         "table", "dedup", "sort", "head", "tail",
         "top", "rare", "fields", "transaction",
+        "tstats", "mstats", "eventstats", "streamstats", "chart",
+        "timechart", "lookup", "inputlookup", "outputlookup", "rex",
+        "regex", "spath", "bin", "bucket", "fillnull",
+        "filldown", "makemv", "mvexpand", "mvcombine", "nomv",
+        "join", "append", "appendcols", "appendpipe", "union",
+        "multisearch", "return", "format", "makeresults", "iplocation",
+        "convert", "foreach", "map", "datamodel", "from",
+        "inputcsv", "outputcsv", "collect", "fieldformat", "addinfo",
+        "addtotals", "accum", "delta", "autoregress", "trendline",
+        "xyseries", "untable", "transpose", "metadata", "rest",
+        "extract", "kv", "replace", "strcat", "reverse",
+        "uniq", "geostats", "sistats", "sichart", "sitimechart",
+        "tags", "cluster", "outlier", "anomalydetection", "rangemap",
+        "eventcount", "dbinspect", "loadjob", "savedsearch", "gentimes",
+        "erex", "concurrency", "fieldsummary", "set", "tojson",
     } {
         v.supportedCommands[cmd] = true
     }
 
-    // Initialize supported functions
+    // Initialize supported eval functions
+    for _, fn := range []string{
+        "abs", "case", "ceil", "ceiling", "cidrmatch",
+        "coalesce", "commands", "exact", "exp", "false",
+        "floor", "if", "in", "ipmask", "isbool",
+        "isint", "isnotnull", "isnull", "isnum", "isstr",
+        "json_array", "json_extract", "json_keys", "json_object", "json_valid",
+        "len", "like", "ln", "log", "lookup",
+        "lower", "ltrim", "match", "max", "md5",
+        "min", "mvappend", "mvcount", "mvdedup", "mvfilter",
+        "mvfind", "mvindex", "mvjoin", "mvmap", "mvrange",
+        "mvsort", "mvzip", "now", "null", "nullif",
+        "pi", "pow", "printf", "random", "relative_time",
+        "replace", "round", "rtrim", "searchmatch", "sha1",
+        "sha256", "sha512", "sigfig", "spath", "split",
+        "sqrt", "strftime", "strptime", "substr", "time",
+        "tonumber", "tostring", "trim", "true", "typeof",
+        "upper", "urldecode", "validate", "acos", "asin",
+        "atan", "atan2", "cos", "sin", "tan",
+        "hypot",
+    } {
+        v.evalFunctions[fn] = true
+    }
+
+    // Initialize supported statistical functions
     for _, fn := range []string{
-        "count", "sum", "avg", "min", "max",
-        "earliest", "latest", "list", "values",
-        "upper", "lower", "len", "substr",
+        "count", "c", "dc", "distinct_count", "estdc",
+        "estdc_error", "sum", "sumsq", "avg", "mean",
+        "median", "mode", "min", "max", "range",
+        "stdev", "stdevp", "var", "varp", "earliest",
+        "latest", "earliest_time", "latest_time", "first", "last",
+        "list", "values", "rate", "per_second", "per_minute",
+        "per_hour", "per_day", "sparkline",
     } {
-        v.supportedFunctions[fn] = true
+        v.statsFunctions[fn] = true
     }
 
     // Initialize CIM field mappings
@@ -76,13 +132,6 @@ func NewSplunkValidator(config ValidationConfig) *SplunkValidator {
         "process": "process.name",
     }
 
-    // Initialize command dependencies
-    v.commandDependencies = map[string][]string{
-        "stats": {"by", "groupby"},
-        "eval": {"as"},
-        "rename": {"as"},
-    }
-
     return v
 }
 
@@ -106,50 +155,51 @@ func (v *SplunkValidator) Validate(ctx context.Context, detection *models.Detect
         return nil, fmt.Errorf("failed to get detection content: %w", err)
     }
 
-    // Perform syntax validation
-    syntaxIssues, err := v.validateSPLSyntax(content, detection.GetMetadata())
-    if err != nil {
-        return nil, fmt.Errorf("syntax validation failed: %w", err)
-    }
-
-    // Add syntax issues to result
-    for _, issue := range syntaxIssues {
-        result.AddIssue(&models.ValidationIssue{
-            Message:     issue.Message,
-            Severity:    issue.Severity,
-            Location:    issue.Location,
-            IssueCode:   "SPL_SYNTAX",
-            Remediation: issue.Remediation,
-        })
-    }
+    // Parse the search and perform syntax validation
+    pipeline, parseErrs := splparser.Parse(content)
+    maxDepth := complexityLimit(ctx, v.config.MaxPipelineDepth)
+    v.validateSPLSyntax(pipeline, parseErrs, maxDepth, result)
 
     // Perform semantic validation
-    semanticIssues, err := v.validateSPLSemantics(content, detection.GetMetadata())
-    if err != nil {
-        return nil, fmt.Errorf("semantic validation failed: %w", err)
-    }
+    v.validateSPLSemantics(pipeline, result)
 
-    // Add semantic issues to result
-    for _, issue := range semanticIssues {
-        result.AddIssue(&models.ValidationIssue{
-            Message:     issue.Message,
-            Severity:    issue.Severity,
-            Location:    issue.Location,
-            IssueCode:   "SPL_SEMANTIC",
-            Remediation: issue.Remediation,
-        })
-    }
+    // Flag expensive patterns and estimate the search cost
+    v.analyzeSPLPerformance(pipeline, result)
 
     // Add format-specific metadata
-    result.FormatSpecificDetails["pipeline_depth"] = len(strings.Split(content, "|"))
-    result.FormatSpecificDetails["command_count"] = len(splunkCommandRegex.FindAllString(content, -1))
+    commandCount, subsearchCount := 0, 0
+    macros := make([]string, 0)
+    splparser.Inspect(pipeline, func(cmd *splparser.Command) bool {
+        commandCount++
+        if cmd.Macro != nil {
+            macros = append(macros, cmd.Macro.Name)
+        }
+        for _, arg := range cmd.Args {
+            switch a := arg.(type) {
+            case *splparser.Subsearch:
+                subsearchCount++
+            case *splparser.Macro:
+                macros = append(macros, a.Name)
+            }
+        }
+        return true
+    })
+    commands := make([]string, 0, len(pipeline.Commands))
+    for _, cmd := range pipeline.Commands {
+        commands = append(commands, splunkCommandName(cmd))
+    }
+    result.FormatSpecificDetails["pipeline_depth"] = len(pipeline.Commands)
+    result.FormatSpecificDetails["command_count"] = commandCount
+    result.FormatSpecificDetails["commands"] = commands
+    result.FormatSpecificDetails["subsearch_count"] = subsearchCount
+    result.FormatSpecificDetails["macros"] = macros
     result.FormatSpecificDetails["field_mappings"] = v.fieldMappings
 
     // Update validation metadata
     result.Metadata.ValidatorVersion = v.config.Version
     result.Metadata.ValidatorConfig = map[string]interface{}{
         "strict_mode":         v.config.StrictMode,
-        "max_pipeline_depth":  v.config.MaxPipelineDepth,
+        "max_pipeline_depth":  maxDepth,
         "time_range_required": v.config.TimeRangeRequired,
         "cim_compliance":      v.config.CIMCompliance,
     }
@@ -166,97 +216,237 @@ func (v *SplunkValidator) Validate(ctx context.Context, detection *models.Detect
     return result, nil
 }
 
-// validateSPLSyntax performs detailed syntax validation
-func (v *SplunkValidator) validateSPLSyntax(content string, metadata map[string]interface{}) ([]models.ValidationIssue, error) {
-    var issues []models.ValidationIssue
-
-    // Validate initial search command
-    if !splunkCommandRegex.MatchString(content) {
-        issues = append(issues, models.ValidationIssue{
-            Message:     "Missing or invalid initial search command",
+// validateSPLSyntax reports parse errors, pipeline depth, non-CIM fields and
+// a missing time range
+func (v *SplunkValidator) validateSPLSyntax(pipeline *splparser.Pipeline, parseErrs []*splparser.Error, maxDepth int, result *models.ValidationResult) {
+    for _, perr := range parseErrs {
+        addSPLIssue(result, perr.Pos, &models.ValidationIssue{
+            Message:     fmt.Sprintf("Syntax error: %s", perr.Msg),
             Severity:    models.ValidationSeverityHigh,
-            Location:    "line:1",
-            Remediation: "Add 'search' command at the beginning of the SPL query",
+            Location:    fmt.Sprintf("line:%d", perr.Pos.Line),
+            IssueCode:   "SPL_SYNTAX",
+            Remediation: "Check quoting, brackets and the arguments of the command at this position",
         })
     }
 
-    // Validate pipeline operators
-    pipelines := splunkPipelineRegex.FindAllString(content, -1)
-    if len(pipelines) > v.config.MaxPipelineDepth {
-        issues = append(issues, models.ValidationIssue{
-            Message:     fmt.Sprintf("Pipeline depth exceeds maximum allowed (%d)", v.config.MaxPipelineDepth),
+    // Validate pipeline depth
+    if maxDepth > 0 && len(pipeline.Commands) > maxDepth {
+        last := pipeline.Commands[len(pipeline.Commands)-1]
+        addSPLIssue(result, last.Position, &models.ValidationIssue{
+            Message:     fmt.Sprintf("Pipeline depth exceeds maximum allowed (%d)", maxDepth),
             Severity:    models.ValidationSeverityMedium,
-            Location:    fmt.Sprintf("pipeline:%d", len(pipelines)),
+            Location:    fmt.Sprintf("pipeline:%d", len(pipeline.Commands)),
+            IssueCode:   "SPL_SYNTAX",
             Remediation: "Simplify the search by reducing the number of pipeline stages",
         })
     }
 
-    // Validate field extractions
-    fields := splunkFieldRegex.FindAllStringSubmatch(content, -1)
-    for _, field := range fields {
-        if len(field) >= 3 {
-            fieldName := field[1]
-            if _, exists := v.fieldMappings[fieldName]; !exists && v.config.CIMCompliance {
-                issues = append(issues, models.ValidationIssue{
-                    Message:     fmt.Sprintf("Non-CIM compliant field name: %s", fieldName),
+    // Validate field names against the CIM and look for a time range
+    reported := make(map[string]bool)
+    hasEarliest, hasLatest := false, false
+    splparser.Inspect(pipeline, func(cmd *splparser.Command) bool {
+        for _, opt := range splunkOptions(cmd.Args, cmd.Where) {
+            switch strings.ToLower(opt.Key) {
+            case "earliest":
+                hasEarliest = true
+            case "latest":
+                hasLatest = true
+            }
+        }
+        if !v.config.CIMCompliance {
+            return true
+        }
+
+        // Field filters are the options of a search and the where clause
+        // of tstats
+        var filters []*splparser.Option
+        switch cmd.Name {
+        case "search":
+            filters = splunkOptions(cmd.Args)
+        case "tstats":
+            filters = splunkOptions(cmd.Where)
+        }
+        for _, opt := range filters {
+            if splunkSearchModifiers[strings.ToLower(opt.Key)] || reported[opt.Key] {
+                continue
+            }
+            fieldName := opt.Key
+            if cmd.Name == "tstats" {
+                // Data model fields are qualified by their dataset
+                fieldName = fieldName[strings.LastIndex(fieldName, ".")+1:]
+            }
+            if _, exists := v.fieldMappings[fieldName]; !exists {
+                reported[opt.Key] = true
+                addSPLIssue(result, opt.Position, &models.ValidationIssue{
+                    Message:     fmt.Sprintf("Non-CIM compliant field name: %s", opt.Key),
                     Severity:    models.ValidationSeverityMedium,
-                    Location:    fmt.Sprintf("field:%s", fieldName),
+                    Location:    fmt.Sprintf("field:%s", opt.Key),
+                    IssueCode:   "SPL_SYNTAX",
                     Remediation: "Use CIM-compliant field names for better compatibility",
                 })
             }
         }
-    }
+        return true
+    })
 
     // Validate time range if required
-    if v.config.TimeRangeRequired && !splunkTimeRangeRegex.MatchString(content) {
-        issues = append(issues, models.ValidationIssue{
+    if v.config.TimeRangeRequired && !(hasEarliest && hasLatest) {
+        result.AddIssue(&models.ValidationIssue{
             Message:     "Missing time range specification",
             Severity:    models.ValidationSeverityHigh,
             Location:    "timerange",
+            IssueCode:   "SPL_SYNTAX",
             Remediation: "Add 'earliest' and 'latest' time range parameters",
         })
     }
-
-    return issues, nil
 }
 
-// validateSPLSemantics performs semantic validation
-func (v *SplunkValidator) validateSPLSemantics(content string, metadata map[string]interface{}) ([]models.ValidationIssue, error) {
-    var issues []models.ValidationIssue
-
-    // Validate command dependencies
-    commands := splunkCommandRegex.FindAllString(content, -1)
-    for _, cmd := range commands {
-        cmd = strings.TrimSpace(cmd)
-        if deps, exists := v.commandDependencies[cmd]; exists {
-            for _, dep := range deps {
-                if !strings.Contains(content, dep) {
-                    issues = append(issues, models.ValidationIssue{
-                        Message:     fmt.Sprintf("Missing required dependency '%s' for command '%s'", dep, cmd),
-                        Severity:    models.ValidationSeverityHigh,
-                        Location:    fmt.Sprintf("command:%s", cmd),
-                        Remediation: fmt.Sprintf("Add required '%s' clause with '%s' command", dep, cmd),
-                    })
-                }
-            }
-        }
+// validateSPLSemantics checks command names and placement, eval and
+// statistical functions, and the clauses commands require
+func (v *SplunkValidator) validateSPLSemantics(pipeline *splparser.Pipeline, result *models.ValidationResult) {
+    unknownSeverity := models.ValidationSeverityLow
+    if v.config.StrictMode {
+        unknownSeverity = models.ValidationSeverityMedium
     }
 
-    // Validate function calls
-    functions := splunkFunctionRegex.FindAllStringSubmatch(content, -1)
-    for _, fn := range functions {
-        if len(fn) >= 2 {
-            funcName := fn[1]
-            if !v.supportedFunctions[funcName] {
-                issues = append(issues, models.ValidationIssue{
-                    Message:     fmt.Sprintf("Unsupported function: %s", funcName),
+    index := 0
+    splparser.Inspect(pipeline, func(cmd *splparser.Command) bool {
+        index++
+        location := fmt.Sprintf("command:%d:%s", index, splunkCommandName(cmd))
+        if cmd.Macro != nil || cmd.Name == "" {
+            return true
+        }
+
+        if !v.supportedCommands[cmd.Name] {
+            addSPLIssue(result, cmd.NamePos, &models.ValidationIssue{
+                Message:     fmt.Sprintf("Unknown command: %s", cmd.Name),
+                Severity:    unknownSeverity,
+                Location:    location,
+                IssueCode:   "SPL_SEMANTIC",
+                Remediation: "Check the command name; custom commands must be installed on the search head",
+            })
+        }
+
+        // Generating commands must start a search, and only they may follow
+        // a leading pipe
+        if cmd.Generating && v.supportedCommands[cmd.Name] && !splunkGeneratingCommands[cmd.Name] {
+            addSPLIssue(result, cmd.NamePos, &models.ValidationIssue{
+                Message:     fmt.Sprintf("%s is not a generating command and cannot start a search", cmd.Name),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    location,
+                IssueCode:   "SPL_SEMANTIC",
+                Remediation: "Start the search with a base search or a generating command such as tstats or inputlookup",
+            })
+        }
+        if cmd.Name == "tstats" && !cmd.Generating && !splunkOptionIs(cmd, "append", "true") {
+            addSPLIssue(result, cmd.NamePos, &models.ValidationIssue{
+                Message:     "tstats must be the first command of a search, introduced by a pipe",
+                Severity:    models.ValidationSeverityHigh,
+                Location:    location,
+                IssueCode:   "SPL_SEMANTIC",
+                Remediation: "Start the search with '| tstats', or set prestats=true append=true to combine results",
+            })
+        }
+
+        if cmd.Name == "rename" && !splunkHasTerm(cmd, "as") {
+            addSPLIssue(result, cmd.NamePos, &models.ValidationIssue{
+                Message:     "rename requires an 'AS' clause",
+                Severity:    models.ValidationSeverityHigh,
+                Location:    location,
+                IssueCode:   "SPL_SEMANTIC",
+                Remediation: "Use 'rename <field> AS <new name>'",
+            })
+        }
+
+        // Validate statistical functions
+        for _, agg := range cmd.Aggregations {
+            if !v.statsFunctions[agg.Func] && !splunkPercentileRegex.MatchString(agg.Func) {
+                addSPLIssue(result, agg.Position, &models.ValidationIssue{
+                    Message:     fmt.Sprintf("Unsupported statistical function in %s: %s", cmd.Name, agg.Func),
                     Severity:    models.ValidationSeverityMedium,
-                    Location:    fmt.Sprintf("function:%s", funcName),
-                    Remediation: "Use only supported SPL functions",
+                    Location:    location,
+                    IssueCode:   "SPL_SEMANTIC",
+                    Remediation: "Use a supported statistical function such as count, dc, values or avg",
                 })
             }
         }
+
+        // Validate eval functions, including eval() inside aggregations
+        for _, expr := range cmd.Exprs() {
+            splparser.WalkExpr(expr, func(e splparser.Expr) bool {
+                call, ok := e.(*splparser.Call)
+                if ok && !v.evalFunctions[call.Name] {
+                    addSPLIssue(result, call.Position, &models.ValidationIssue{
+                        Message:     fmt.Sprintf("Unsupported function: %s", call.Name),
+                        Severity:    models.ValidationSeverityMedium,
+                        Location:    location,
+                        IssueCode:   "SPL_SEMANTIC",
+                        Remediation: "Use only supported SPL eval functions",
+                    })
+                }
+                return true
+            })
+        }
+        return true
+    })
+}
+
+// addSPLIssue adds issue to result at the source position pos
+func addSPLIssue(result *models.ValidationResult, pos splparser.Position, issue *models.ValidationIssue) {
+    if pos.IsValid() {
+        issue.Line = pos.Line
+        issue.Column = pos.Column
+    }
+    result.AddIssue(issue)
+}
+
+// splunkCommandName names a pipeline stage for locations and details
+func splunkCommandName(cmd *splparser.Command) string {
+    if cmd.Macro != nil {
+        return "`" + cmd.Macro.Name + "`"
+    }
+    return cmd.Name
+}
+
+// splunkOptions returns the key=value arguments in argument lists,
+// including those inside parenthesized groups
+func splunkOptions(argLists ...[]splparser.Arg) []*splparser.Option {
+    var options []*splparser.Option
+    for _, args := range argLists {
+        for _, arg := range args {
+            collectSPLOptions(arg, &options)
+        }
+    }
+    return options
+}
+
+func collectSPLOptions(arg splparser.Arg, options *[]*splparser.Option) {
+    switch a := arg.(type) {
+    case *splparser.Option:
+        *options = append(*options, a)
+    case *splparser.Group:
+        for _, inner := range a.Args {
+            collectSPLOptions(inner, options)
+        }
+    }
+}
+
+// splunkOptionIs reports whether cmd sets option key to value
+func splunkOptionIs(cmd *splparser.Command, key, value string) bool {
+    opt, ok := cmd.Option(key)
+    if !ok {
+        return false
     }
+    term, ok := opt.Value.(*splparser.Term)
+    return ok && strings.EqualFold(term.Text, value)
+}
 
-    return issues, nil
-}
\ No newline at end of file
+// splunkHasTerm reports whether cmd has the unquoted argument word
+func splunkHasTerm(cmd *splparser.Command, word string) bool {
+    for _, arg := range cmd.Args {
+        if term, ok := arg.(*splparser.Term); ok && !term.Quoted && strings.EqualFold(term.Text, word) {
+            return true
+        }
+    }
+    return false
+}
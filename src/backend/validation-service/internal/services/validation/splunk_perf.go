@@ -0,0 +1,298 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    "internal/models"
+    splparser "internal/parser/spl"
+)
+
+// Performance heuristics. Each finding adds cost points to the category of
+// resource it wastes, and the total selects the estimated cost of the search.
+const (
+    // splunkTransactionMaxSpan is the largest transaction window that does
+    // not hold a significant share of events in memory
+    splunkTransactionMaxSpan = time.Hour
+    // splunkMaxJoins is the number of join-like subsearches a search may
+    // use before it is considered join-heavy
+    splunkMaxJoins = 1
+)
+
+// Cost categories of the resources an expensive pattern consumes
+const (
+    splunkCostIndexScan    = "index_scan"
+    splunkCostWildcardScan = "wildcard_scan"
+    splunkCostTimeRange    = "time_range"
+    splunkCostMemory       = "memory"
+    splunkCostSubsearch    = "subsearch"
+)
+
+// splunkCostLevels map a total cost score to an estimated cost, lowest first
+var splunkCostLevels = []struct {
+    minScore int
+    level    string
+}{
+    {0, "low"},
+    {2, "moderate"},
+    {4, "high"},
+    {7, "very_high"},
+}
+
+// splunkJoinCommands run a subsearch whose results are combined with the
+// main search
+var splunkJoinCommands = map[string]bool{
+    "join": true, "append": true, "appendcols": true, "map": true,
+}
+
+// splunkSpanRegex matches a Splunk time span such as 30m, 4h or 3600
+var splunkSpanRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(s|secs?|seconds?|m|mins?|minutes?|h|hrs?|hours?|d|days?)?$`)
+
+// splunkPerformance is the estimated cost of a search
+type splunkPerformance struct {
+    Cost       string         `json:"cost"`
+    Score      int            `json:"score"`
+    Categories map[string]int `json:"categories"`
+}
+
+// add records cost points against category
+func (p *splunkPerformance) add(category string, points int) {
+    p.Categories[category] += points
+    p.Score += points
+}
+
+// analyzeSPLPerformance flags search patterns that are dangerous or expensive
+// to run and estimates the cost of the search
+func (v *SplunkValidator) analyzeSPLPerformance(pipeline *splparser.Pipeline, result *models.ValidationResult) {
+    perf := &splunkPerformance{Categories: make(map[string]int)}
+    hasEarliest, hasLatest := false, false
+    var joins []*splparser.Command
+
+    splparser.Inspect(pipeline, func(cmd *splparser.Command) bool {
+        for _, opt := range splunkOptions(cmd.Args, cmd.Where) {
+            value := splunkOptionValue(opt)
+            switch strings.ToLower(opt.Key) {
+            case "earliest":
+                hasEarliest = true
+                if value == "0" || value == "1" {
+                    perf.add(splunkCostTimeRange, 3)
+                    addSPLIssue(result, opt.Position, &models.ValidationIssue{
+                        Message:     fmt.Sprintf("earliest=%s searches all time", value),
+                        Severity:    models.ValidationSeverityMedium,
+                        Location:    "option:earliest",
+                        IssueCode:   "SPLPERF003",
+                        Remediation: "Bound the search to the detection window, e.g. earliest=-24h",
+                    })
+                }
+            case "latest":
+                hasLatest = true
+            case "index":
+                if strings.HasPrefix(value, "*") && opt.Op != "!=" {
+                    perf.add(splunkCostIndexScan, 3)
+                    addSPLIssue(result, opt.Position, &models.ValidationIssue{
+                        Message:     fmt.Sprintf("index=%s searches every index the user can read", value),
+                        Severity:    models.ValidationSeverityHigh,
+                        Location:    "option:index",
+                        IssueCode:   "SPLPERF002",
+                        Remediation: "Name the indexes that hold the data, e.g. index=wineventlog",
+                    })
+                }
+            default:
+                if (cmd.Name == "search" || cmd.Name == "tstats") && splunkLeadingWildcard(value) {
+                    perf.add(splunkCostWildcardScan, 2)
+                    addSPLIssue(result, opt.Position, &models.ValidationIssue{
+                        Message:     fmt.Sprintf("Leading wildcard in %s=%s cannot use the index and scans every event", opt.Key, value),
+                        Severity:    models.ValidationSeverityMedium,
+                        Location:    fmt.Sprintf("field:%s", opt.Key),
+                        IssueCode:   "SPLPERF001",
+                        Remediation: "Remove the leading wildcard or match a longer literal prefix",
+                    })
+                }
+            }
+        }
+
+        switch {
+        case cmd.Name == "search":
+            for _, arg := range cmd.Args {
+                if term, ok := arg.(*splparser.Term); ok && splunkLeadingWildcard(term.Text) {
+                    perf.add(splunkCostWildcardScan, 2)
+                    addSPLIssue(result, term.Position, &models.ValidationIssue{
+                        Message:     fmt.Sprintf("Leading wildcard in search term %s cannot use the index and scans every event", term.Text),
+                        Severity:    models.ValidationSeverityMedium,
+                        Location:    "term:" + term.Text,
+                        IssueCode:   "SPLPERF001",
+                        Remediation: "Remove the leading wildcard or match a longer literal prefix",
+                    })
+                }
+            }
+        case cmd.Name == "where":
+            splparser.WalkExpr(cmd.Expr, func(e splparser.Expr) bool {
+                if pattern, pos, ok := splunkLikePattern(e); ok && strings.HasPrefix(pattern, "%") && len(pattern) > 1 {
+                    perf.add(splunkCostWildcardScan, 1)
+                    addSPLIssue(result, pos, &models.ValidationIssue{
+                        Message:     fmt.Sprintf("like pattern %q starts with a wildcard and compares every event", pattern),
+                        Severity:    models.ValidationSeverityLow,
+                        Location:    "command:where",
+                        IssueCode:   "SPLPERF001",
+                        Remediation: "Filter on an indexed term in the base search before the where command",
+                    })
+                }
+                return true
+            })
+        case cmd.Name == "transaction":
+            v.checkTransactionWindow(cmd, perf, result)
+        case splunkJoinCommands[cmd.Name]:
+            joins = append(joins, cmd)
+        }
+        return true
+    })
+
+    // A missing time range is already an error when it is required
+    if !hasEarliest && !hasLatest {
+        perf.add(splunkCostTimeRange, 2)
+    }
+    if !hasEarliest && !hasLatest && !v.config.TimeRangeRequired {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     "Search has no earliest or latest time bound and relies on the time range it is scheduled with",
+            Severity:    models.ValidationSeverityLow,
+            Location:    "timerange",
+            IssueCode:   "SPLPERF003",
+            Remediation: "Add earliest and latest to the base search so the rule cannot run over all time",
+        })
+    }
+
+    if len(joins) > splunkMaxJoins {
+        names := make([]string, len(joins))
+        for i, join := range joins {
+            names[i] = join.Name
+        }
+        perf.add(splunkCostSubsearch, 2*len(joins))
+        addSPLIssue(result, joins[splunkMaxJoins].NamePos, &models.ValidationIssue{
+            Message:     fmt.Sprintf("Search combines %d subsearches (%s); each is limited to 50,000 results and runs to completion first", len(joins), strings.Join(names, ", ")),
+            Severity:    models.ValidationSeverityMedium,
+            Location:    fmt.Sprintf("command:%s", joins[splunkMaxJoins].Name),
+            IssueCode:   "SPLPERF005",
+            Remediation: "Combine the datasets in one base search and correlate them with stats ... by <key>",
+        })
+    } else if len(joins) > 0 {
+        perf.add(splunkCostSubsearch, 1)
+    }
+
+    for _, level := range splunkCostLevels {
+        if perf.Score >= level.minScore {
+            perf.Cost = level.level
+        }
+    }
+    result.FormatSpecificDetails["performance"] = perf
+}
+
+// checkTransactionWindow flags transactions that are unbounded or span more
+// than splunkTransactionMaxSpan
+func (v *SplunkValidator) checkTransactionWindow(cmd *splparser.Command, perf *splunkPerformance, result *models.ValidationResult) {
+    maxSpan, hasSpan := cmd.Option("maxspan")
+    _, hasPause := cmd.Option("maxpause")
+    if !hasSpan {
+        if hasPause {
+            return
+        }
+        perf.add(splunkCostMemory, 3)
+        addSPLIssue(result, cmd.NamePos, &models.ValidationIssue{
+            Message:     "transaction has no maxspan or maxpause and keeps open transactions in memory for the whole search",
+            Severity:    models.ValidationSeverityMedium,
+            Location:    "command:transaction",
+            IssueCode:   "SPLPERF004",
+            Remediation: fmt.Sprintf("Set maxspan to at most %s, or use stats ... by <key> instead", formatSplunkSpan(splunkTransactionMaxSpan)),
+        })
+        return
+    }
+
+    span, ok := parseSplunkSpan(splunkOptionValue(maxSpan))
+    if !ok || span <= splunkTransactionMaxSpan {
+        return
+    }
+    perf.add(splunkCostMemory, 2)
+    addSPLIssue(result, maxSpan.Position, &models.ValidationIssue{
+        Message:     fmt.Sprintf("transaction maxspan=%s exceeds %s and holds events in memory for the whole window", splunkOptionValue(maxSpan), formatSplunkSpan(splunkTransactionMaxSpan)),
+        Severity:    models.ValidationSeverityMedium,
+        Location:    "command:transaction",
+        IssueCode:   "SPLPERF004",
+        Remediation: "Shorten maxspan, or use stats ... by <key> with a time bucket instead",
+    })
+}
+
+// splunkOptionValue returns the text of an option value, or "" when the
+// value is not a term
+func splunkOptionValue(opt *splparser.Option) string {
+    if term, ok := opt.Value.(*splparser.Term); ok {
+        return term.Text
+    }
+    return ""
+}
+
+// splunkLeadingWildcard reports whether a search value starts with a
+// wildcard; a lone * matches any value and is cheap
+func splunkLeadingWildcard(value string) bool {
+    return strings.HasPrefix(value, "*") && strings.Trim(value, "*") != ""
+}
+
+// splunkLikePattern returns the pattern of a like() call or LIKE comparison
+func splunkLikePattern(e splparser.Expr) (string, splparser.Position, bool) {
+    var pattern splparser.Expr
+    switch n := e.(type) {
+    case *splparser.Call:
+        if n.Name == "like" && len(n.Args) == 2 {
+            pattern = n.Args[1]
+        }
+    case *splparser.BinaryExpr:
+        if n.Op == "LIKE" {
+            pattern = n.Right
+        }
+    }
+    lit, ok := pattern.(*splparser.Literal)
+    if !ok || lit.Kind != splparser.LiteralString {
+        return "", splparser.Position{}, false
+    }
+    return lit.Value, lit.Position, true
+}
+
+// parseSplunkSpan parses a time span such as 30m or 4h; bare numbers are
+// seconds
+func parseSplunkSpan(span string) (time.Duration, bool) {
+    match := splunkSpanRegex.FindStringSubmatch(strings.ToLower(strings.TrimSpace(span)))
+    if match == nil {
+        return 0, false
+    }
+    value, err := strconv.ParseFloat(match[1], 64)
+    if err != nil {
+        return 0, false
+    }
+    unit := time.Second
+    switch {
+    case match[2] == "":
+    case strings.HasPrefix(match[2], "m"):
+        unit = time.Minute
+    case strings.HasPrefix(match[2], "h"):
+        unit = time.Hour
+    case strings.HasPrefix(match[2], "d"):
+        unit = 24 * time.Hour
+    }
+    return time.Duration(value * float64(unit)), true
+}
+
+// formatSplunkSpan formats a duration as a Splunk time span such as 1h
+func formatSplunkSpan(d time.Duration) string {
+    switch {
+    case d%(24*time.Hour) == 0:
+        return fmt.Sprintf("%dd", d/(24*time.Hour))
+    case d%time.Hour == 0:
+        return fmt.Sprintf("%dh", d/time.Hour)
+    case d%time.Minute == 0:
+        return fmt.Sprintf("%dm", d/time.Minute)
+    default:
+        return fmt.Sprintf("%ds", d/time.Second)
+    }
+}
@@ -0,0 +1,61 @@
+package validation
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/prometheus/client_golang/prometheus"          // v1.17.0
+    "github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+
+    "validation-service/internal/models"
+    "validation-service/internal/tenant"
+)
+
+var tenantValidations = promauto.NewCounterVec(prometheus.CounterOpts{
+    Name:        "tenant_validations_total",
+    Help:        "Validations by tenant, target format and result status",
+    ConstLabels: prometheus.Labels{"service": "validation"},
+}, []string{"tenant", "target_format", "status"})
+
+// ForTenant returns the scoring policy of format overridden by the settings
+// of the validation profile and then by the confidence threshold of the
+// tenant's policy, when it sets one. The empty profile name selects the
+// default profile.
+func (p *ScoringPolicies) ForTenant(format, profile string, t *tenant.Tenant) *models.ScoringPolicy {
+    policy := p.For(format)
+    if p != nil {
+        if _, settings, ok := p.profiles.resolve(profile); ok {
+            applyValidationProfile(policy, settings)
+        }
+    }
+    if t != nil && t.Policy.MinConfidence != nil {
+        policy.MinConfidence = *t.Policy.MinConfidence
+    }
+    return policy
+}
+
+// checkTenantFormat rejects target formats the tenant of ctx may not validate
+func checkTenantFormat(ctx context.Context, targetFormat string) error {
+    t, _ := tenant.FromContext(ctx)
+    if !t.AllowsFormat(targetFormat) {
+        return fmt.Errorf("%w: %s", ErrFormatNotAllowed, targetFormat)
+    }
+    return nil
+}
+
+// applyTenantStrictness reports warnings as errors when the tenant of ctx
+// has a strict policy
+func applyTenantStrictness(ctx context.Context, result *models.ValidationResult) {
+    if t, _ := tenant.FromContext(ctx); t.Strict() && result.Status == models.ValidationStatusWarning {
+        result.Status = models.ValidationStatusError
+    }
+}
+
+// recordTenantValidation counts a validation of the tenant of ctx
+func recordTenantValidation(ctx context.Context, targetFormat string, result *models.ValidationResult) {
+    status := models.ValidationStatusError
+    if result != nil {
+        status = result.Status
+    }
+    tenantValidations.WithLabelValues(tenant.LabelFromContext(ctx), targetFormat, status).Inc()
+}
@@ -0,0 +1,87 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    "context"
+    "reflect"
+    "time"
+
+    "validation-service/internal/models"
+)
+
+// executionTraceKey marks contexts whose validations record an execution trace
+type executionTraceKey struct{}
+
+// WithExecutionTrace returns ctx with stage-by-stage execution tracing
+// enabled. Validations run with it record their stages in the result
+// metadata and bypass the result cache.
+func WithExecutionTrace(ctx context.Context) context.Context {
+    return context.WithValue(ctx, executionTraceKey{}, true)
+}
+
+// executionTraceEnabled reports whether ctx requests an execution trace
+func executionTraceEnabled(ctx context.Context) bool {
+    enabled, _ := ctx.Value(executionTraceKey{}).(bool)
+    return enabled
+}
+
+// stageTimer measures one validation stage for the execution trace
+type stageTimer struct {
+    clock   models.Clock
+    enabled bool
+    name    string
+    start   time.Time
+    issues  int
+    score   float64
+}
+
+// startStage begins timing a stage. result may be nil for stages that create
+// it; the issue count and score then start from an empty result.
+func (s *ValidationService) startStage(ctx context.Context, name string, result *models.ValidationResult) *stageTimer {
+    t := &stageTimer{clock: s.config.Clock, enabled: executionTraceEnabled(ctx), name: name, score: 100.0}
+    if !t.enabled {
+        return t
+    }
+    t.start = t.clock.Now()
+    if result != nil {
+        t.issues = len(result.Issues)
+        t.score = result.ConfidenceScore
+    }
+    return t
+}
+
+// end records the stage in the result metadata. shortCircuit is empty unless
+// the stage stopped the validation.
+func (t *stageTimer) end(result *models.ValidationResult, shortCircuit string) {
+    if !t.enabled || result == nil {
+        return
+    }
+    result.Metadata.Trace = append(result.Metadata.Trace, models.ExecutionStage{
+        Stage:         t.name,
+        DurationMs:    float64(t.clock.Now().Sub(t.start).Microseconds()) / 1000,
+        IssuesEmitted: len(result.Issues) - t.issues,
+        ScoreBefore:   t.score,
+        ScoreAfter:    result.ConfidenceScore,
+        ShortCircuit:  shortCircuit,
+    })
+}
+
+// shortCircuitReason describes the error that stopped a validation
+func shortCircuitReason(err error) string {
+    if err == nil {
+        return ""
+    }
+    return err.Error()
+}
+
+// validatorName names a cross-format validator in the execution trace
+func validatorName(v Validator) string {
+    if describer, ok := v.(Describer); ok {
+        return describer.Describe().Name
+    }
+    t := reflect.TypeOf(v)
+    if t.Kind() == reflect.Ptr {
+        t = t.Elem()
+    }
+    return t.Name()
+}
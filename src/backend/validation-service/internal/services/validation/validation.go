@@ -6,11 +6,23 @@ import (
     "context"
     "errors"
     "fmt"
+    "strings"
     "sync"
     "time"
 
+    "go.opentelemetry.io/otel/attribute" // v1.21.0
+
+    "internal/cache"
+    "internal/grammar"
     "internal/models"
+    "internal/platform"
+    "internal/services/lint"
+    "internal/services/quickfix"
+    "internal/services/rulepack"
+    "internal/tenant"
     "pkg/logger"
+    "pkg/mitre"
+    "pkg/tracing"
 )
 
 // Global error definitions
@@ -18,11 +30,17 @@ var (
     ErrUnsupportedFormat = errors.New("unsupported detection format")
     ErrValidationFailed  = errors.New("validation failed with detailed feedback")
     ErrInvalidValidator  = errors.New("invalid validator implementation")
+    // ErrFormatNotAllowed is returned for target formats the tenant's policy
+    // does not allow
+    ErrFormatNotAllowed = errors.New("target format not allowed for tenant")
 )
 
 // Constants for validation configuration
 const (
-    MinConfidenceScore = 95.0 // Minimum required confidence score for validation success
+    MinConfidenceScore = models.ValidationConfidenceThreshold // Default minimum confidence score for validation success
+
+    // crossFormatSeparator joins the formats of a cross-format registry key
+    crossFormatSeparator = "->"
 )
 
 // Validator defines the interface for format-specific validation implementations
@@ -37,22 +55,78 @@ type ValidationConfig struct {
     ValidationTimeout     time.Duration
     StrictMode           bool
     MetricsEnabled       bool
+    // Clock and IDs default to the wall clock and random UUIDs; golden tests
+    // inject models.StepClock and models.SequentialIDGenerator
+    Clock models.Clock
+    IDs   models.IDGenerator
+    // Attack is the ATT&CK dataset technique and tactic references are
+    // checked against; when nil, references are only extracted
+    Attack *mitre.Dataset
+    // Cache, when set, serves results of previously validated content for
+    // CacheTTL through ValidateDetectionCached
+    Cache    cache.ResultCache
+    CacheTTL time.Duration
+    // StaleWhileRevalidate serves the cached result of content validated by
+    // an earlier validator version or with earlier catalogs, kept for
+    // StaleTTL, while the content is validated again in the background
+    StaleWhileRevalidate bool
+    StaleTTL             time.Duration
+    // Linter, when set, records lint findings of the target detection
+    Linter *lint.Linter
+    // Scoring sets the severity weights, confidence threshold and complexity
+    // limits per target format; the built-in values apply when nil
+    Scoring *ScoringPolicies
+    // Profiles are the validation profiles requests select with WithProfile;
+    // the scoring configuration alone applies when nil
+    Profiles *Profiles
+    // GrammarParsing parses targets of formats with a grammar before their
+    // validators run and passes the parse tree in the context
+    GrammarParsing bool
+    // QRadarVersion is the QRadar release AQL targets are checked against;
+    // AQL features of every release are accepted when empty
+    QRadarVersion string
+    // AdaptiveLogging, when set, raises logging of a target format to debug
+    // while its validations fail or run slow
+    AdaptiveLogging *logger.AdaptiveDebug
 }
 
 // ValidationService provides thread-safe validation orchestration
 type ValidationService struct {
-    mu         sync.RWMutex
-    validators map[string]Validator
-    config     ValidationConfig
-    log        *logger.Logger
+    mu              sync.RWMutex
+    validators      map[string]Validator
+    crossValidators map[string][]Validator
+    platforms       map[string][]platform.Connector
+    config          ValidationConfig
+    refDigests      map[string]string
+    customSchemas   CustomSchemaSource
+    rulePacks       *rulepack.Set
+    sigmaTaxonomy   *SigmaLogsourceSchema
+    load            loadTracker
+    // revalidating holds the cache keys of running background
+    // re-validations, and onRevalidated is told of changed results
+    revalidating    sync.Map
+    onRevalidated   func(*Revalidation)
+    // issueCodes reserves the issue code namespace of every format
+    // validator
+    issueCodes      *IssueCodeRegistry
+    log             *logger.Logger
 }
 
 // NewValidationService creates a new validation service instance
 func NewValidationService(config ValidationConfig) *ValidationService {
+    if config.Clock == nil {
+        config.Clock = models.SystemClock
+    }
+    if config.IDs == nil {
+        config.IDs = models.RandomIDs
+    }
     return &ValidationService{
-        validators: make(map[string]Validator),
-        config:     config,
-        log:        logger.GetLogger(),
+        validators:      make(map[string]Validator),
+        crossValidators: make(map[string][]Validator),
+        platforms:       make(map[string][]platform.Connector),
+        config:          config,
+        issueCodes:      NewIssueCodeRegistry(),
+        log:             logger.GetLogger(),
     }
 }
 
@@ -82,6 +156,48 @@ func (s *ValidationService) RegisterValidator(format string, validator Validator
     return nil
 }
 
+// RegisterCrossFormatValidator registers a validator that runs only for
+// translation pairs from sourceFormat to targetFormat, after the target
+// format validator has completed
+func (s *ValidationService) RegisterCrossFormatValidator(sourceFormat, targetFormat string, validator Validator) error {
+    if sourceFormat == "" || targetFormat == "" {
+        return fmt.Errorf("source and target formats cannot be empty")
+    }
+    if validator == nil {
+        return ErrInvalidValidator
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    key := crossFormatKey(sourceFormat, targetFormat)
+    s.crossValidators[key] = append(s.crossValidators[key], validator)
+    s.log.Info("Cross-format validator registered successfully",
+        "source_format", sourceFormat,
+        "target_format", targetFormat,
+    )
+
+    return nil
+}
+
+// getCrossFormatValidators returns the validators registered for a format pair
+func (s *ValidationService) getCrossFormatValidators(sourceFormat, targetFormat string) []Validator {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    return s.crossValidators[crossFormatKey(sourceFormat, targetFormat)]
+}
+
+// crossFormatKey builds the registry key for a format pair
+func crossFormatKey(sourceFormat, targetFormat string) string {
+    return sourceFormat + crossFormatSeparator + targetFormat
+}
+
+// splitCrossFormatKey splits a registry key into its format pair
+func splitCrossFormatKey(key string) (sourceFormat, targetFormat string, ok bool) {
+    return strings.Cut(key, crossFormatSeparator)
+}
+
 // GetValidator retrieves a registered validator for the specified format
 func (s *ValidationService) GetValidator(format string) (Validator, error) {
     s.mu.RLock()
@@ -96,41 +212,100 @@ func (s *ValidationService) GetValidator(format string) (Validator, error) {
 }
 
 // ValidateDetection performs comprehensive validation of a detection translation
-func (s *ValidationService) ValidateDetection(ctx context.Context, sourceDetection, targetDetection *models.Detection) (*models.ValidationResult, error) {
+func (s *ValidationService) ValidateDetection(ctx context.Context, sourceDetection, targetDetection *models.Detection) (result *models.ValidationResult, err error) {
     if sourceDetection == nil || targetDetection == nil {
         return nil, errors.New("source and target detections cannot be nil")
     }
 
+    // Running validations and their cost are load signals for autoscaling
+    s.load.begin()
+    defer func(start time.Time) {
+        s.load.end(s.config.Clock.Now().Sub(start))
+    }(s.config.Clock.Now())
+
+    ctx, span := tracing.Start(ctx, "validation.ValidateDetection",
+        attribute.String("validation.source_format", sourceDetection.Format),
+        attribute.String("validation.target_format", targetDetection.Format),
+    )
+    defer func() { tracing.End(span, err) }()
+
     // Create validation context with timeout
-    if s.config.ValidationTimeout > 0 {
+    if timeout := s.Settings().ValidationTimeout; timeout > 0 {
         var cancel context.CancelFunc
-        ctx, cancel = context.WithTimeout(ctx, s.config.ValidationTimeout)
+        ctx, cancel = context.WithTimeout(ctx, timeout)
         defer cancel()
     }
 
-    // Get target format validator
-    targetFormat, err := targetDetection.GetFormat()
-    if err != nil {
-        return nil, fmt.Errorf("invalid target format: %w", err)
-    }
-
-    validator, err := s.GetValidator(targetFormat)
+    // Parse the target format and initialize the result
+    stage := s.startStage(ctx, "parse", nil)
+    phaseCtx, phase := tracing.Start(ctx, "validation.parse")
+    targetFormat, validator, result, err := s.prepareValidation(phaseCtx, sourceDetection, targetDetection)
+    tracing.End(phase, err)
     if err != nil {
         return nil, err
     }
-
-    // Initialize validation result
-    result, err := models.NewValidationResult(sourceDetection)
-    if err != nil {
-        return nil, fmt.Errorf("failed to create validation result: %w", err)
+    stage.end(result, "")
+    defer func() { recordTenantValidation(ctx, targetFormat, result) }()
+    defer func() {
+        if result != nil {
+            s.issueCodes.namespaceIssues(result.Issues, targetFormat, result.SourceFormat)
+        }
+    }()
+
+    // Field checks accept the fields of the tenant's custom log sources
+    ctx = s.withCustomSchemas(ctx)
+    ctx = s.withSigmaTaxonomy(ctx)
+    defer trackFormatInFlight(targetFormat)()
+    defer func() {
+        if err == nil {
+            recordResultMetrics(targetFormat, result)
+        }
+    }()
+
+    // Every log line written by the validators carries the format and detection
+    ctx = logger.WithFormat(ctx, targetFormat)
+    ctx = logger.WithDetectionID(ctx, targetDetection.ID.String())
+    if s.config.AdaptiveLogging != nil {
+        defer func(start time.Time) {
+            s.config.AdaptiveLogging.Observe(logger.FormatScope(targetFormat), err != nil, s.config.Clock.Now().Sub(start))
+        }(s.config.Clock.Now())
     }
-    result.TargetFormat = targetFormat
 
     // Start validation timer
-    startTime := time.Now()
+    startTime := s.config.Clock.Now()
+
+    // Reject rules nested deeper than the format allows before any
+    // recursive parser reads them
+    stage = s.startStage(ctx, "limits", result)
+    if !withinNestingLimit(targetFormat, targetDetection, result) {
+        stage.end(result, "nesting depth limit exceeded")
+        result.Metadata.ValidationTime = s.config.Clock.Now().Sub(startTime)
+        result.ScoreBreakdown = result.BreakDownScore()
+        t, _ := tenant.FromContext(ctx)
+        s.config.Profiles.applyGate(result, t)
+        logger.FromContext(ctx).Warn("Detection rejected by resource limits",
+            "target_format", targetFormat,
+            "max_nesting_depth", result.ScoringPolicy().MaxNestingDepth,
+        )
+        return result, nil
+    }
+    stage.end(result, "")
+
+    // Parse the target with its grammar so validators can walk the tree
+    if s.config.GrammarParsing && grammar.Supports(targetFormat) {
+        stage = s.startStage(ctx, "grammar."+targetFormat, result)
+        ctx = s.parseGrammar(ctx, targetFormat, targetDetection, result)
+        stage.end(result, "")
+    }
 
-    // Perform format-specific validation
-    if err := validator.Validate(ctx, sourceDetection, targetDetection, result); err != nil {
+    // Perform format-specific syntax validation within the complexity limit
+    // of the scoring policy
+    ctx = withComplexityLimit(ctx, result.ScoringPolicy().MaxComplexity)
+    stage = s.startStage(ctx, "syntax."+targetFormat, result)
+    phaseCtx, phase = tracing.Start(ctx, "validation.syntax", attribute.String("validation.format", targetFormat))
+    err = validator.Validate(phaseCtx, sourceDetection, targetDetection, result)
+    tracing.End(phase, err)
+    if err != nil {
         result.Status = models.ValidationStatusError
         result.AddIssue(&models.ValidationIssue{
             Message:   fmt.Sprintf("Validation failed: %v", err),
@@ -138,25 +313,67 @@ func (s *ValidationService) ValidateDetection(ctx context.Context, sourceDetecti
             Location:  "validation_service",
             IssueCode: "VALIDATION_FAILED",
         })
+        stage.end(result, shortCircuitReason(err))
         return result, fmt.Errorf("%w: %v", ErrValidationFailed, err)
     }
+    stage.end(result, "")
 
-    // Update validation metadata
-    result.Metadata.ValidationTime = time.Since(startTime)
+    // Submit the target to the query planners of its platform
+    s.validatePlatforms(ctx, targetDetection, targetFormat, result)
 
-    // Check confidence threshold
-    if result.ConfidenceScore < MinConfidenceScore {
-        result.Status = models.ValidationStatusWarning
-        result.AddIssue(&models.ValidationIssue{
-            Message:   fmt.Sprintf("Confidence score %.2f below minimum threshold %.2f", result.ConfidenceScore, MinConfidenceScore),
-            Severity:  models.ValidationSeverityMedium,
-            Location:  "confidence_check",
-            IssueCode: "LOW_CONFIDENCE",
-        })
+    // Run cross-format and ATT&CK checks on the translation's meaning
+    phaseCtx, phase = tracing.Start(ctx, "validation.semantic")
+    err = s.validateSemantics(phaseCtx, sourceDetection, targetDetection, targetFormat, result)
+    tracing.End(phase, err)
+    if err != nil {
+        if errors.Is(err, ErrValidationFailed) {
+            return result, err
+        }
+        return nil, err
     }
 
+    // Check the meta fields the validation profile requires
+    stage = s.startStage(ctx, "profile", result)
+    s.config.Profiles.checkRequiredMeta(targetDetection, targetFormat, result)
+    stage.end(result, "")
+
+    // Check the organization's rule packs
+    stage = s.startStage(ctx, "rulepacks", result)
+    s.checkRulePacks(targetDetection, targetFormat, result)
+    stage.end(result, "")
+
+    // Flag rules past their review-by or expiry date
+    stage = s.startStage(ctx, "review", result)
+    s.checkReview(sourceDetection, targetDetection, targetFormat, result)
+    stage.end(result, "")
+
+    // Update validation metadata
+    result.Metadata.ValidationTime = s.config.Clock.Now().Sub(startTime)
+
+    // Score the result and flag the issues the quick-fix endpoint can correct
+    stage = s.startStage(ctx, "scoring", result)
+    _, phase = tracing.Start(ctx, "validation.scoring")
+    s.scoreResult(targetDetection, targetFormat, result)
+    s.config.Scoring.scoreForTenant(ctx, tenant.IDFromContext(ctx), result)
+    applyTenantStrictness(ctx, result)
+    s.config.Profiles.applyStrictness(result)
+    t, _ := tenant.FromContext(ctx)
+    s.config.Profiles.applyGate(result, t)
+    phase.SetAttributes(
+        attribute.Float64("validation.confidence_score", result.ConfidenceScore),
+        attribute.String("validation.status", result.Status),
+        attribute.Int("validation.issues", len(result.Issues)),
+    )
+    tracing.End(phase, nil)
+    stage.end(result, "")
+
+    // Report content hygiene findings, which never affect the score
+    stage = s.startStage(ctx, "lint", result)
+    s.lintDetection(ctx, targetDetection, result)
+    stage.end(result, "")
+
     // Log validation completion
-    s.log.Info("Detection validation completed",
+    logger.FromContext(ctx).Info("Detection validation completed",
         "source_format", result.SourceFormat,
         "target_format", result.TargetFormat,
         "status", result.Status,
@@ -167,6 +384,96 @@ func (s *ValidationService) ValidateDetection(ctx context.Context, sourceDetecti
     return result, nil
 }
 
+// LintDetection validates a single detection with the validator of its
+// format, without a translation to compare it with. The detection stands in
+// as its own source, so no cross-format validator runs. Results are served
+// from the result cache unless bypass is set.
+func (s *ValidationService) LintDetection(ctx context.Context, detection *models.Detection, bypass bool) (*models.ValidationResult, bool, error) {
+    if detection == nil {
+        return nil, false, errors.New("detection cannot be nil")
+    }
+    return s.ValidateDetectionCached(ctx, detection, detection, bypass)
+}
+
+// prepareValidation resolves the target format and its validator and creates
+// the validation result
+func (s *ValidationService) prepareValidation(ctx context.Context, sourceDetection, targetDetection *models.Detection) (string, Validator, *models.ValidationResult, error) {
+    // Get target format validator
+    targetFormat, err := targetDetection.GetFormat()
+    if err != nil {
+        return "", nil, nil, fmt.Errorf("invalid target format: %w", err)
+    }
+
+    validator, err := s.GetValidator(targetFormat)
+    if err != nil {
+        return "", nil, nil, err
+    }
+    if err := checkTenantFormat(ctx, targetFormat); err != nil {
+        return "", nil, nil, err
+    }
+
+    // Initialize validation result
+    result, err := models.NewValidationResultWith(sourceDetection, s.config.Clock, s.config.IDs)
+    if err != nil {
+        return "", nil, nil, fmt.Errorf("failed to create validation result: %w", err)
+    }
+    result.TargetFormat = targetFormat
+    t, _ := tenant.FromContext(ctx)
+    if t != nil {
+        result.TenantID = t.ID
+    }
+    if name, _, ok := s.config.Profiles.resolve(profileName(ctx)); ok {
+        result.Metadata.Profile = name
+    }
+    result.SetScoringPolicy(s.config.Scoring.ForTenant(targetFormat, result.Metadata.Profile, t))
+
+    return targetFormat, validator, result, nil
+}
+
+// validateSemantics runs the cross-format validators for the translation pair
+// and the ATT&CK checks. Validator failures are recorded on the result and
+// wrap ErrValidationFailed.
+func (s *ValidationService) validateSemantics(ctx context.Context, sourceDetection, targetDetection *models.Detection, targetFormat string, result *models.ValidationResult) error {
+    // Run cross-format validators for this translation pair
+    for _, crossValidator := range s.getCrossFormatValidators(result.SourceFormat, targetFormat) {
+        stage := s.startStage(ctx, "semantic."+validatorName(crossValidator), result)
+        if err := crossValidator.Validate(ctx, sourceDetection, targetDetection, result); err != nil {
+            result.Status = models.ValidationStatusError
+            result.AddIssue(&models.ValidationIssue{
+                Message:   fmt.Sprintf("Cross-format validation failed: %v", err),
+                Severity:  models.ValidationSeverityHigh,
+                Location:  "cross_format_validation",
+                IssueCode: "VALIDATION_FAILED",
+            })
+            stage.end(result, shortCircuitReason(err))
+            return fmt.Errorf("%w: %v", ErrValidationFailed, err)
+        }
+        stage.end(result, "")
+    }
+
+    // Check ATT&CK references for every format
+    stage := s.startStage(ctx, "semantic.attack", result)
+    err := applyAttackCoverage(s.attackDataset(), targetDetection, result)
+    stage.end(result, shortCircuitReason(err))
+    return err
+}
+
+// scoreResult applies the confidence threshold and marks fixable issues
+func (s *ValidationService) scoreResult(targetDetection *models.Detection, targetFormat string, result *models.ValidationResult) {
+    // Check confidence threshold of the target format
+    minConfidence := result.ScoringPolicy().MinConfidence
+    if result.ConfidenceScore < minConfidence {
+        result.Status = models.ValidationStatusWarning
+        addLowConfidenceIssue(result, time.Time{})
+    }
+    result.ScoreBreakdown = result.BreakDownScore()
+
+    // Flag the issues the quick-fix endpoint can correct
+    if content, err := targetDetection.GetContent(); err == nil {
+        quickfix.MarkFixable(targetFormat, content, result)
+    }
+}
+
 // ValidateDetectionBatch performs batch validation of multiple detections
 func (s *ValidationService) ValidateDetectionBatch(ctx context.Context, batch []struct {
     Source *models.Detection
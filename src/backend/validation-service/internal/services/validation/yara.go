@@ -2,31 +2,18 @@
 package validation
 
 import (
+    "errors"
+    "fmt"
     "regexp"
     "strings"
-    "fmt"
 
     "internal/models"
+    yaraparser "internal/parser/yara"
     "pkg/utils"
 )
 
-// Regular expression patterns for YARA rule validation
-var (
-    // Validates overall YARA rule structure
-    yaraRulePattern = regexp.MustCompile(`^(?:(?:global|private)\s+)?rule\s+[a-zA-Z0-9_]+\s*(?::\s*[a-zA-Z0-9_]+)?\s*{[\s\S]*}`)
-
-    // Validates YARA rule identifier naming
-    yaraIdentifierPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]{0,127}$`)
-
-    // Validates string definitions in YARA rules
-    yaraStringPattern = regexp.MustCompile(`\$[a-zA-Z0-9_]*\s*=\s*(?:"[^"]*"|{[^}]*}|/[^/]*/|/[^/]*/[ismx]*)`)
-
-    // Validates condition section syntax
-    yaraConditionPattern = regexp.MustCompile(`condition:\s*(?:[\s\S]*?)(?:(?=meta:)|(?=strings:)|$)`)
-
-    // Validates meta section format
-    yaraMetaPattern = regexp.MustCompile(`meta:\s*(?:[a-zA-Z_][a-zA-Z0-9_]*\s*=\s*(?:"[^"]*"|\d+|true|false)\s*)*`)
-)
+// Validates YARA rule identifier naming
+var yaraIdentifierPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]{0,127}$`)
 
 // Reserved keywords that cannot be used as rule identifiers
 var yaraReservedKeywords = map[string]bool{
@@ -41,7 +28,26 @@ var yaraReservedKeywords = map[string]bool{
     "uint8be": true, "uint16be": true, "uint32be": true, "wide": true,
 }
 
-// ValidateYARARule performs comprehensive validation of a YARA rule
+// Built-in functions and identifiers usable in conditions without an import
+var yaraBuiltinIdentifiers = map[string]bool{
+    "filesize": true, "entrypoint": true, "them": true,
+    "all": true, "any": true, "none": true,
+    "int8": true, "int16": true, "int32": true,
+    "int8be": true, "int16be": true, "int32be": true,
+    "uint8": true, "uint16": true, "uint32": true,
+    "uint8be": true, "uint16be": true, "uint32be": true,
+}
+
+// Modules shipped with YARA that may be imported
+var yaraKnownModules = map[string]bool{
+    "pe": true, "elf": true, "math": true, "hash": true, "cuckoo": true,
+    "magic": true, "dotnet": true, "time": true, "console": true,
+    "string": true, "macho": true, "dex": true, "lnk": true,
+}
+
+// ValidateYARARule performs comprehensive validation of a YARA rule file. The
+// content is parsed into an AST so that multi-rule files, comments and nested
+// braces are handled and issues carry accurate line/column positions.
 func ValidateYARARule(detection *models.Detection) (*models.ValidationResult, error) {
     // Create new validation result
     result, err := models.NewValidationResult(detection)
@@ -49,100 +55,155 @@ func ValidateYARARule(detection *models.Detection) (*models.ValidationResult, er
         return nil, utils.WrapError(err, "failed to create validation result")
     }
 
-    // Get and sanitize content
+    // Get content; whitespace is preserved so positions stay accurate
     content, err := detection.GetContent()
     if err != nil {
         return nil, utils.WrapError(err, "failed to get detection content")
     }
-    content = utils.SanitizeInput(content)
 
     // Validate content size
     if err := utils.ValidateDetectionSize(content); err != nil {
         return nil, utils.WrapError(err, "content size validation failed")
     }
 
-    // Validate overall rule structure
-    if !yaraRulePattern.MatchString(content) {
-        result.AddIssue(&models.ValidationIssue{
-            Message:     "Invalid YARA rule structure",
+    // Parse the rule file and report syntax errors
+    file, parseErrs := yaraparser.Parse(content)
+    for _, perr := range parseErrs {
+        addYARAIssue(result, perr.Pos, &models.ValidationIssue{
+            Message:     fmt.Sprintf("Syntax error: %s", perr.Msg),
             Severity:    models.ValidationSeverityHigh,
             Location:    "rule",
             IssueCode:   "YARA001",
             Remediation: "Ensure rule follows the format: [private|global] rule name [: tag] { ... }",
         })
     }
-
-    // Extract and validate rule identifier
-    identifier := extractRuleIdentifier(content)
-    if err := validateRuleIdentifier(identifier); err != nil {
+    if len(file.Rules) == 0 && len(parseErrs) == 0 {
         result.AddIssue(&models.ValidationIssue{
-            Message:     fmt.Sprintf("Invalid rule identifier: %s", err.Error()),
+            Message:     "No YARA rules found in content",
             Severity:    models.ValidationSeverityHigh,
-            Location:    "identifier",
-            IssueCode:   "YARA002",
-            Remediation: "Use alphanumeric characters and underscores, start with letter/underscore",
+            Location:    "rule",
+            IssueCode:   "YARA001",
+            Remediation: "Add at least one rule declaration",
         })
     }
 
-    // Validate meta section if present
-    if strings.Contains(content, "meta:") {
-        if !yaraMetaPattern.MatchString(content) {
-            result.AddIssue(&models.ValidationIssue{
-                Message:     "Invalid meta section format",
+    // Score string atoms and record compilation statistics alongside the
+    // rule checks
+    atoms := result.Section()
+    fileSections := newSectionGroup(len(content))
+    fileSections.Go(func() error {
+        analyzeAtoms(file, atoms)
+        return nil
+    })
+
+    // Validate imports and each rule against the file-level context
+    imported := validateImports(file, result)
+    declared := make(map[string]bool, len(file.Rules))
+    stringCount := 0
+    ruleNames := make([]string, 0, len(file.Rules))
+    for _, rule := range file.Rules {
+        validateYARARuleDecl(rule, content, declared, imported, result)
+        declared[rule.Name] = true
+        stringCount += len(rule.Strings)
+        ruleNames = append(ruleNames, rule.Name)
+    }
+
+    fileSections.Wait()
+    result.Merge(atoms)
+
+    // Add format-specific details
+    result.FormatSpecificDetails["rule_count"] = len(file.Rules)
+    result.FormatSpecificDetails["rule_names"] = ruleNames
+    result.FormatSpecificDetails["string_count"] = stringCount
+    result.FormatSpecificDetails["imports"] = importNames(file)
+
+    // Calculate final confidence score based on validation results
+    result.RecalculateConfidence()
+
+    return result, nil
+}
+
+// validateImports checks imported modules and returns the set of imported names
+func validateImports(file *yaraparser.File, result *models.ValidationResult) map[string]bool {
+    imported := make(map[string]bool, len(file.Imports))
+    for _, imp := range file.Imports {
+        if !yaraKnownModules[imp.Module] {
+            addYARAIssue(result, imp.Position, &models.ValidationIssue{
+                Message:     fmt.Sprintf("Unknown module imported: %s", imp.Module),
                 Severity:    models.ValidationSeverityMedium,
-                Location:    "meta",
-                IssueCode:   "YARA003",
-                Remediation: "Ensure meta entries follow format: identifier = value",
+                Location:    "import",
+                IssueCode:   "YARA009",
+                Remediation: "Verify the module is available in the target YARA build",
             })
         }
+        imported[imp.Module] = true
     }
+    return imported
+}
 
-    // Validate string definitions
-    stringIssues, err := validateStringDefinitions(content)
-    if err != nil {
-        result.AddIssue(&models.ValidationIssue{
-            Message:     fmt.Sprintf("String validation error: %s", err.Error()),
-            Severity:    models.ValidationSeverityHigh,
-            Location:    "strings",
-            IssueCode:   "YARA004",
-            Remediation: "Check string syntax and ensure unique identifiers",
-        })
-    }
-    for _, issue := range stringIssues {
-        result.AddIssue(&models.ValidationIssue{
-            Message:     issue,
-            Severity:    models.ValidationSeverityMedium,
-            Location:    "strings",
-            IssueCode:   "YARA005",
-            Remediation: "Review string definition syntax and modifiers",
+// validateYARARuleDecl validates a single rule's identifier, meta, strings and
+// condition. The sections are validated concurrently for large rules and their
+// issues recorded in that order.
+func validateYARARuleDecl(rule *yaraparser.Rule, source string, declared, imported map[string]bool, result *models.ValidationResult) {
+    defined := definedStrings(rule)
+    header, definitions, condition := result.Section(), result.Section(), result.Section()
+
+    sections := newSectionGroup(rule.End.Offset - rule.Position.Offset)
+    sections.Go(func() error {
+        validateRuleHeader(rule, declared, header)
+        return nil
+    })
+    sections.Go(func() error {
+        validateStringDefinitions(rule, source, definitions)
+        return nil
+    })
+    if rule.Condition != nil {
+        sections.Go(func() error {
+            validateCondition(rule, defined, declared, imported, condition)
+            return nil
         })
     }
+    sections.Wait()
 
-    // Validate condition section
-    conditionIssues, err := validateCondition(content)
-    if err != nil {
-        result.AddIssue(&models.ValidationIssue{
-            Message:     fmt.Sprintf("Condition validation error: %s", err.Error()),
+    result.Merge(header)
+    result.Merge(definitions)
+    result.Merge(condition)
+}
+
+// validateRuleHeader validates a rule's identifier and meta section
+func validateRuleHeader(rule *yaraparser.Rule, declared map[string]bool, result *models.ValidationResult) {
+    // Validate rule identifier
+    if err := validateRuleIdentifier(rule.Name); err != nil {
+        addYARAIssue(result, rule.NamePos, &models.ValidationIssue{
+            Message:     fmt.Sprintf("Invalid rule identifier: %s", err.Error()),
             Severity:    models.ValidationSeverityHigh,
-            Location:    "condition",
-            IssueCode:   "YARA006",
-            Remediation: "Check condition syntax and referenced string variables",
+            Location:    "identifier",
+            IssueCode:   "YARA002",
+            Remediation: "Use alphanumeric characters and underscores, start with letter/underscore",
         })
     }
-    for _, issue := range conditionIssues {
-        result.AddIssue(&models.ValidationIssue{
-            Message:     issue,
-            Severity:    models.ValidationSeverityMedium,
-            Location:    "condition",
-            IssueCode:   "YARA007",
-            Remediation: "Review condition logic and operators",
+    if declared[rule.Name] {
+        addYARAIssue(result, rule.NamePos, &models.ValidationIssue{
+            Message:     fmt.Sprintf("Duplicate rule identifier: %s", rule.Name),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "identifier",
+            IssueCode:   "YARA002",
+            Remediation: "Rule identifiers must be unique within a file",
         })
     }
 
-    // Calculate final confidence score based on validation results
-    calculateConfidenceScore(result)
-
-    return result, nil
+    // Validate meta section
+    for _, entry := range rule.Meta {
+        if value, ok := entry.Value.(string); ok && strings.TrimSpace(value) == "" {
+            addYARAIssue(result, entry.Position, &models.ValidationIssue{
+                Message:     fmt.Sprintf("Empty meta value for %s in rule %s", entry.Key, rule.Name),
+                Severity:    models.ValidationSeverityLow,
+                Location:    "meta." + entry.Key,
+                IssueCode:   "YARA003",
+                Remediation: "Provide a value or remove the meta entry",
+            })
+        }
+    }
 }
 
 // validateRuleIdentifier validates the YARA rule identifier
@@ -166,159 +227,254 @@ func validateRuleIdentifier(identifier string) error {
     return nil
 }
 
-// validateStringDefinitions performs comprehensive validation of YARA string definitions
-func validateStringDefinitions(content string) ([]string, error) {
-    var issues []string
-    
-    // Extract string section
-    stringSection := extractStringSection(content)
-    if stringSection == "" {
-        return nil, nil // No strings section, which is valid
+// definedStrings returns the named strings of a rule by identifier; of
+// duplicates the last definition wins
+func definedStrings(rule *yaraparser.Rule) map[string]*yaraparser.StringDef {
+    defined := make(map[string]*yaraparser.StringDef, len(rule.Strings))
+    for _, def := range rule.Strings {
+        if !def.IsAnonymous() {
+            defined[def.ID] = def
+        }
     }
+    return defined
+}
 
-    // Track string identifiers for uniqueness
-    stringIDs := make(map[string]bool)
-
-    // Find all string definitions
-    matches := yaraStringPattern.FindAllString(stringSection, -1)
-    for _, match := range matches {
-        // Extract string identifier
-        parts := strings.SplitN(match, "=", 2)
-        if len(parts) != 2 {
-            issues = append(issues, "Invalid string definition format")
-            continue
+// validateStringDefinitions validates string definitions of a rule. Errors in
+// hex and regex strings are reported at their position within the value in
+// source.
+func validateStringDefinitions(rule *yaraparser.Rule, source string, result *models.ValidationResult) {
+    defined := make(map[string]bool, len(rule.Strings))
+
+    for _, def := range rule.Strings {
+        location := "strings." + def.ID
+
+        // Track string identifiers for uniqueness
+        if !def.IsAnonymous() {
+            if _, exists := defined[def.ID]; exists {
+                addYARAIssue(result, def.Position, &models.ValidationIssue{
+                    Message:     fmt.Sprintf("Duplicate string identifier: %s", def.ID),
+                    Severity:    models.ValidationSeverityMedium,
+                    Location:    location,
+                    IssueCode:   "YARA005",
+                    Remediation: "Use unique identifiers for string definitions",
+                })
+            }
+            defined[def.ID] = true
         }
 
-        identifier := strings.TrimSpace(parts[0])
-        if stringIDs[identifier] {
-            issues = append(issues, fmt.Sprintf("Duplicate string identifier: %s", identifier))
+        // Validate string content
+        if err := validateStringSource(def, source); err != nil {
+            pos := def.ValuePos
+            var serr *yaraStringError
+            if errors.As(err, &serr) {
+                pos = valuePosition(source, def, serr.Offset)
+            }
+            addYARAIssue(result, pos, &models.ValidationIssue{
+                Message:     fmt.Sprintf("String validation error in %s: %s", def.ID, err.Error()),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    location,
+                IssueCode:   "YARA004",
+                Remediation: "Check string syntax and ensure unique identifiers",
+            })
         }
-        stringIDs[identifier] = true
 
-        // Validate string content
-        content := strings.TrimSpace(parts[1])
-        if err := validateStringContent(content); err != nil {
-            issues = append(issues, err.Error())
+        // Validate modifiers are not repeated
+        seen := make(map[string]bool, len(def.Modifiers))
+        for _, mod := range def.Modifiers {
+            if seen[mod.Name] {
+                addYARAIssue(result, mod.Position, &models.ValidationIssue{
+                    Message:     fmt.Sprintf("Duplicate modifier %s on %s", mod.Name, def.ID),
+                    Severity:    models.ValidationSeverityMedium,
+                    Location:    location,
+                    IssueCode:   "YARA005",
+                    Remediation: "Review string definition syntax and modifiers",
+                })
+            }
+            seen[mod.Name] = true
+        }
+        for _, problem := range validateStringModifiers(def) {
+            addYARAIssue(result, problem.modifier.Position, &models.ValidationIssue{
+                Message:     fmt.Sprintf("Invalid modifier on %s: %s", def.ID, problem.message),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    location,
+                IssueCode:   "YARA012",
+                Remediation: "Remove modifiers the string kind does not support or that conflict with each other",
+            })
         }
     }
-
-    return issues, nil
 }
 
-// validateCondition validates the YARA rule condition section
-func validateCondition(content string) ([]string, error) {
-    var issues []string
-
-    // Extract condition section
-    match := yaraConditionPattern.FindString(content)
-    if match == "" {
-        return nil, fmt.Errorf("missing condition section")
-    }
-
-    // Remove 'condition:' prefix and trim
-    condition := strings.TrimPrefix(match, "condition:")
-    condition = strings.TrimSpace(condition)
+// validateCondition validates string references, identifiers and string usage
+// in a rule condition
+func validateCondition(rule *yaraparser.Rule, defined map[string]*yaraparser.StringDef, declared, imported map[string]bool, result *models.ValidationResult) {
+    used := make(map[string]bool, len(defined))
+    usesThem := false
+    loopVars := make(map[string]bool)
+
+    // Collect loop variables first so they resolve anywhere in the condition
+    yaraparser.Walk(rule.Condition, func(e yaraparser.Expr) bool {
+        if f, ok := e.(*yaraparser.ForExpr); ok {
+            for _, v := range f.Vars {
+                loopVars[v] = true
+            }
+        }
+        return true
+    })
+
+    yaraparser.Walk(rule.Condition, func(e yaraparser.Expr) bool {
+        switch n := e.(type) {
+        case *yaraparser.OfExpr:
+            usesThem = usesThem || n.Them
+        case *yaraparser.ForExpr:
+            usesThem = usesThem || n.Them
+        case *yaraparser.StringRef:
+            // Anonymous references are only valid inside `for ... of` bodies
+            if n.Name == "" {
+                return true
+            }
+            matched := markStringUsage(n, defined, used)
+            if !matched {
+                addYARAIssue(result, n.Position, &models.ValidationIssue{
+                    Message:     fmt.Sprintf("Referenced string not defined: %s", n.ID()),
+                    Severity:    models.ValidationSeverityMedium,
+                    Location:    "condition",
+                    IssueCode:   "YARA007",
+                    Remediation: "Review condition logic and referenced string variables",
+                })
+            }
+        case *yaraparser.MemberExpr:
+            if root, ok := n.X.(*yaraparser.Ident); ok {
+                if !imported[root.Name] {
+                    addYARAIssue(result, root.Position, &models.ValidationIssue{
+                        Message:     fmt.Sprintf("Module %s used without import in rule %s", root.Name, rule.Name),
+                        Severity:    models.ValidationSeverityHigh,
+                        Location:    "condition",
+                        IssueCode:   "YARA006",
+                        Remediation: fmt.Sprintf("Add `import \"%s\"` at the top of the file", root.Name),
+                    })
+                }
+                // The root identifier has been handled; skip generic ident checks
+                return false
+            }
+        case *yaraparser.Ident:
+            if !isKnownConditionIdent(n.Name, declared, loopVars) {
+                addYARAIssue(result, n.Position, &models.ValidationIssue{
+                    Message:     fmt.Sprintf("Unknown identifier in condition: %s", n.Name),
+                    Severity:    models.ValidationSeverityMedium,
+                    Location:    "condition",
+                    IssueCode:   "YARA009",
+                    Remediation: "Reference only previously declared rules, loop variables or built-in functions",
+                })
+            }
+        }
+        return true
+    })
 
-    // Validate condition syntax
-    if err := validateConditionSyntax(condition); err != nil {
-        issues = append(issues, err.Error())
+    // YARA rejects rules with unreferenced strings
+    if usesThem {
+        return
     }
-
-    // Validate referenced strings exist
-    stringRefs := extractStringReferences(condition)
-    definedStrings := extractDefinedStrings(content)
-    for _, ref := range stringRefs {
-        if !definedStrings[ref] {
-            issues = append(issues, fmt.Sprintf("Referenced string not defined: %s", ref))
+    for _, def := range rule.Strings {
+        if def.IsAnonymous() || used[def.ID] {
+            continue
         }
+        addYARAIssue(result, def.Position, &models.ValidationIssue{
+            Message:     fmt.Sprintf("String %s is defined but never used in the condition", def.ID),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "strings." + def.ID,
+            IssueCode:   "YARA008",
+            Remediation: "Reference the string in the condition or remove it",
+        })
     }
-
-    return issues, nil
 }
 
-// Helper functions
+// markStringUsage marks the strings matched by a reference as used and reports
+// whether at least one defined string matched
+func markStringUsage(ref *yaraparser.StringRef, defined map[string]*yaraparser.StringDef, used map[string]bool) bool {
+    if !ref.IsWildcard() {
+        if _, ok := defined[ref.ID()]; ok {
+            used[ref.ID()] = true
+            return true
+        }
+        return false
+    }
 
-func extractRuleIdentifier(content string) string {
-    // Extract identifier from rule declaration
-    ruleDecl := regexp.MustCompile(`rule\s+([a-zA-Z0-9_]+)`).FindStringSubmatch(content)
-    if len(ruleDecl) > 1 {
-        return ruleDecl[1]
+    prefix := strings.TrimSuffix(ref.ID(), "*")
+    matched := false
+    for id := range defined {
+        if strings.HasPrefix(id, prefix) {
+            used[id] = true
+            matched = true
+        }
     }
-    return ""
+    return matched
 }
 
-func extractStringSection(content string) string {
-    // Extract strings section between 'strings:' and next section or end
-    re := regexp.MustCompile(`strings:\s*([\s\S]*?)(?:(?=meta:)|(?=condition:)|$)`)
-    match := re.FindStringSubmatch(content)
-    if len(match) > 1 {
-        return match[1]
+// isKnownConditionIdent reports whether an identifier resolves in a condition
+func isKnownConditionIdent(name string, declared, loopVars map[string]bool) bool {
+    if yaraBuiltinIdentifiers[name] || loopVars[name] || declared[name] {
+        return true
+    }
+    // Rule sets may use wildcards, e.g. `any of (apt_*)`
+    if strings.HasSuffix(name, "*") {
+        prefix := strings.TrimSuffix(name, "*")
+        for rule := range declared {
+            if strings.HasPrefix(rule, prefix) {
+                return true
+            }
+        }
     }
-    return ""
+    return false
 }
 
-func validateStringContent(content string) error {
-    // Validate string content based on type (text, hex, regex)
-    switch {
-    case strings.HasPrefix(content, "\""):
-        return validateTextString(content)
-    case strings.HasPrefix(content, "{"):
-        return validateHexString(content)
-    case strings.HasPrefix(content, "/"):
-        return validateRegexString(content)
+// validateStringContent validates string content based on type (text, hex, regex)
+func validateStringContent(def *yaraparser.StringDef) error {
+    switch def.Kind {
+    case yaraparser.StringText:
+        return validateTextString(def.Value)
+    case yaraparser.StringHex:
+        return validateHexString(def.Value)
+    case yaraparser.StringRegex:
+        return validateRegexString(def.Value, def.RegexFlags)
     default:
         return fmt.Errorf("invalid string content format")
     }
 }
 
-func validateConditionSyntax(condition string) error {
-    // Basic condition syntax validation
-    if condition == "" {
-        return fmt.Errorf("empty condition")
+// validateStringSource validates string content against its source text, so
+// error offsets of hex strings account for comments and line breaks
+func validateStringSource(def *yaraparser.StringDef, source string) error {
+    if def.Kind == yaraparser.StringHex {
+        if raw, ok := rawStringValue(source, def); ok {
+            return validateHexString(raw)
+        }
     }
+    return validateStringContent(def)
+}
 
-    // Check for balanced parentheses
-    if !hasBalancedParentheses(condition) {
-        return fmt.Errorf("unbalanced parentheses in condition")
+// validateTextString validates a text string value
+func validateTextString(value string) error {
+    if value == "" {
+        return fmt.Errorf("empty text string")
     }
-
     return nil
 }
 
-func hasBalancedParentheses(s string) bool {
-    count := 0
-    for _, c := range s {
-        if c == '(' {
-            count++
-        } else if c == ')' {
-            count--
-            if count < 0 {
-                return false
-            }
-        }
-    }
-    return count == 0
-}
+// Helper functions
 
-func calculateConfidenceScore(result *models.ValidationResult) {
-    // Start with maximum confidence
-    confidence := 100.0
-
-    // Reduce confidence based on issue severity
-    for _, issue := range result.Issues {
-        switch issue.Severity {
-        case models.ValidationSeverityHigh:
-            confidence -= 20.0
-        case models.ValidationSeverityMedium:
-            confidence -= 10.0
-        case models.ValidationSeverityLow:
-            confidence -= 5.0
-        }
+// addYARAIssue attaches the source position to an issue before recording it
+func addYARAIssue(result *models.ValidationResult, pos yaraparser.Position, issue *models.ValidationIssue) {
+    if pos.IsValid() {
+        issue.Line = pos.Line
+        issue.Column = pos.Column
     }
+    result.AddIssue(issue)
+}
 
-    // Ensure confidence stays within bounds
-    if confidence < 0 {
-        confidence = 0
+func importNames(file *yaraparser.File) []string {
+    names := make([]string, 0, len(file.Imports))
+    for _, imp := range file.Imports {
+        names = append(names, imp.Module)
     }
-    result.SetConfidenceScore(confidence)
-}
\ No newline at end of file
+    return names
+}
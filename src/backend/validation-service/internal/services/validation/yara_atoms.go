@@ -0,0 +1,421 @@
+// Package validation provides validation services for various detection formats
+package validation
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+
+    "internal/models"
+    yaraparser "internal/parser/yara"
+)
+
+// Atom heuristics. YARA indexes every string by a short literal, its atom,
+// and only runs the full matcher where an atom hits. Quality is scored like
+// the YARA compiler does: distinct specific bytes score highest, common
+// filler bytes and letters less, wildcards count against the atom.
+const (
+    maxAtomLength = 4
+
+    // atomQualityWarningThreshold is roughly the quality of two specific
+    // bytes; weaker atoms hit so often that the string slows every scan
+    atomQualityWarningThreshold = 38
+    // maxAtomQuality is the quality of four distinct specific bytes
+    maxAtomQuality = maxAtomLength*20 + 2*maxAtomLength
+)
+
+// atomByte is a byte of a string pattern; mask selects the bits that must
+// match, so a ?? wildcard has mask 0x00
+type atomByte struct {
+    value byte
+    mask  byte
+}
+
+// atomInfo describes the best atom of a string
+type atomInfo struct {
+    Rule    string `json:"rule"`
+    String  string `json:"string"`
+    Kind    string `json:"kind"`
+    Atom    string `json:"atom"`
+    Quality int    `json:"quality"`
+}
+
+// yaraCompilationStats summarizes a rule set as the YARA compiler sees it
+type yaraCompilationStats struct {
+    Rules          int `json:"rules"`
+    Strings        int `json:"strings"`
+    TextStrings    int `json:"text_strings"`
+    HexStrings     int `json:"hex_strings"`
+    RegexStrings   int `json:"regex_strings"`
+    SlowStrings    int `json:"slow_strings"`
+    MinAtomQuality int `json:"min_atom_quality"`
+}
+
+// analyzeAtoms scores the best atom of every string, flags strings that would
+// degrade scan performance and records compilation statistics
+func analyzeAtoms(file *yaraparser.File, result *models.ValidationResult) {
+    stats := yaraCompilationStats{Rules: len(file.Rules), MinAtomQuality: maxAtomQuality}
+    atoms := make([]atomInfo, 0)
+
+    for _, rule := range file.Rules {
+        for _, def := range rule.Strings {
+            stats.Strings++
+            switch def.Kind {
+            case yaraparser.StringHex:
+                stats.HexStrings++
+            case yaraparser.StringRegex:
+                stats.RegexStrings++
+            default:
+                stats.TextStrings++
+            }
+
+            // Malformed strings are reported by the string content checks
+            if validateStringContent(def) != nil {
+                continue
+            }
+
+            atom, quality := bestAtom(stringRuns(def))
+            if quality < stats.MinAtomQuality {
+                stats.MinAtomQuality = quality
+            }
+            atoms = append(atoms, atomInfo{
+                Rule:    rule.Name,
+                String:  def.ID,
+                Kind:    def.Kind.String(),
+                Atom:    formatAtom(atom),
+                Quality: quality,
+            })
+
+            location := "strings." + def.ID
+            switch {
+            case len(atom) == 0 || fixedBytes(atom) == 0:
+                stats.SlowStrings++
+                addYARAIssue(result, def.ValuePos, &models.ValidationIssue{
+                    Message:     fmt.Sprintf("String %s in rule %s has no literal bytes to extract an atom from", def.ID, rule.Name),
+                    Severity:    models.ValidationSeverityMedium,
+                    Location:    location,
+                    IssueCode:   "YARA010",
+                    Remediation: "Anchor the string with at least a few fixed bytes; wildcard-only patterns are matched at every offset of every file",
+                })
+            case quality < atomQualityWarningThreshold:
+                stats.SlowStrings++
+                addYARAIssue(result, def.ValuePos, &models.ValidationIssue{
+                    Message:     fmt.Sprintf("String %s in rule %s may slow down scanning (best atom %s, quality %d)", def.ID, rule.Name, formatAtom(atom), quality),
+                    Severity:    models.ValidationSeverityLow,
+                    Location:    location,
+                    IssueCode:   "YARA011",
+                    Remediation: "Use a longer run of distinctive bytes, avoiding 00, 20, CC and FF fillers and leading wildcards",
+                })
+            }
+        }
+    }
+
+    if stats.Strings == 0 {
+        stats.MinAtomQuality = 0
+    }
+    result.FormatSpecificDetails["atom_quality"] = atoms
+    result.FormatSpecificDetails["compilation_stats"] = stats
+}
+
+// stringRuns returns the runs of contiguous pattern bytes atoms can be taken
+// from. Jumps, alternations and variable-length regex constructs end a run.
+func stringRuns(def *yaraparser.StringDef) [][]atomByte {
+    var runs [][]atomByte
+    switch def.Kind {
+    case yaraparser.StringHex:
+        runs = hexRuns(def.Value)
+    case yaraparser.StringRegex:
+        runs = regexRuns(def.Value)
+    default:
+        runs = [][]atomByte{literalBytes(unescapeYARAText(def.Value))}
+    }
+
+    // Wide strings interleave every character with a zero byte; atoms are
+    // drawn from the wide form when ascii is not also requested
+    if def.HasModifier("wide") && !def.HasModifier("ascii") {
+        for i, run := range runs {
+            wide := make([]atomByte, 0, 2*len(run))
+            for _, b := range run {
+                wide = append(wide, b, atomByte{value: 0x00, mask: 0xFF})
+            }
+            runs[i] = wide
+        }
+    }
+    return runs
+}
+
+// bestAtom returns the highest quality window of up to maxAtomLength bytes
+func bestAtom(runs [][]atomByte) ([]atomByte, int) {
+    var best []atomByte
+    bestQuality := 0
+    for _, run := range runs {
+        length := maxAtomLength
+        if len(run) < length {
+            length = len(run)
+        }
+        for start := 0; start+length <= len(run) && length > 0; start++ {
+            window := run[start : start+length]
+            if quality := atomQuality(window); best == nil || quality > bestQuality {
+                best, bestQuality = window, quality
+            }
+        }
+    }
+    return best, bestQuality
+}
+
+// atomQuality scores an atom following the YARA compiler heuristics
+func atomQuality(atom []atomByte) int {
+    quality := 0
+    unique := make(map[byte]bool, len(atom))
+    fixed := 0
+    for _, b := range atom {
+        switch b.mask {
+        case 0x00:
+            quality -= 10
+        case 0xFF:
+            fixed++
+            unique[b.value] = true
+            switch {
+            case b.value == 0x00 || b.value == 0x20 || b.value == 0xCC || b.value == 0xFF:
+                quality += 12
+            case (b.value >= 'a' && b.value <= 'z') || (b.value >= 'A' && b.value <= 'Z'):
+                quality += 18
+            default:
+                quality += 20
+            }
+        default:
+            quality += 4
+        }
+    }
+
+    // A repeated byte such as 00 00 00 00 hits as often as the byte alone
+    if fixed > 1 && len(unique) == 1 {
+        return quality / 2
+    }
+    return quality + 2*len(unique)
+}
+
+// fixedBytes counts the bytes of an atom that are not wildcarded
+func fixedBytes(atom []atomByte) int {
+    count := 0
+    for _, b := range atom {
+        if b.mask != 0x00 {
+            count++
+        }
+    }
+    return count
+}
+
+// formatAtom renders an atom in hex string notation
+func formatAtom(atom []atomByte) string {
+    parts := make([]string, 0, len(atom))
+    for _, b := range atom {
+        switch b.mask {
+        case 0x00:
+            parts = append(parts, "??")
+        case 0x0F:
+            parts = append(parts, fmt.Sprintf("?%X", b.value&0x0F))
+        case 0xF0:
+            parts = append(parts, fmt.Sprintf("%X?", b.value>>4))
+        default:
+            parts = append(parts, fmt.Sprintf("%02X", b.value))
+        }
+    }
+    return strings.Join(parts, " ")
+}
+
+// literalBytes converts a literal to fully specified pattern bytes
+func literalBytes(literal string) []atomByte {
+    run := make([]atomByte, 0, len(literal))
+    for i := 0; i < len(literal); i++ {
+        run = append(run, atomByte{value: literal[i], mask: 0xFF})
+    }
+    return run
+}
+
+// unescapeYARAText resolves the escape sequences of a text string
+func unescapeYARAText(value string) string {
+    var b strings.Builder
+    for i := 0; i < len(value); i++ {
+        if value[i] != '\\' || i+1 == len(value) {
+            b.WriteByte(value[i])
+            continue
+        }
+        i++
+        switch value[i] {
+        case 'n':
+            b.WriteByte('\n')
+        case 't':
+            b.WriteByte('\t')
+        case 'r':
+            b.WriteByte('\r')
+        case 'x':
+            if i+2 < len(value) {
+                if n, err := strconv.ParseUint(value[i+1:i+3], 16, 8); err == nil {
+                    b.WriteByte(byte(n))
+                    i += 2
+                    continue
+                }
+            }
+            b.WriteByte('x')
+        default:
+            b.WriteByte(value[i])
+        }
+    }
+    return b.String()
+}
+
+// hexRuns splits hex string tokens into runs. Wildcards stay in a run as
+// masked bytes; jumps, alternations and negations end it.
+func hexRuns(value string) [][]atomByte {
+    tokens := strings.Join(strings.Fields(value), "")
+    var runs [][]atomByte
+    var run []atomByte
+    flush := func() {
+        if len(run) > 0 {
+            runs = append(runs, run)
+            run = nil
+        }
+    }
+
+    for i := 0; i < len(tokens); {
+        switch tokens[i] {
+        case '[':
+            flush()
+            end := strings.IndexByte(tokens[i:], ']')
+            if end < 0 {
+                return runs
+            }
+            i += end + 1
+        case '(':
+            // Alternatives are not scored; skip to the matching parenthesis
+            flush()
+            depth := 0
+            for ; i < len(tokens); i++ {
+                if tokens[i] == '(' {
+                    depth++
+                } else if tokens[i] == ')' {
+                    if depth--; depth == 0 {
+                        i++
+                        break
+                    }
+                }
+            }
+        case '~':
+            flush()
+            i += 3
+        default:
+            if i+1 >= len(tokens) {
+                i = len(tokens)
+                continue
+            }
+            run = append(run, hexByte(tokens[i], tokens[i+1]))
+            i += 2
+        }
+    }
+    flush()
+    return runs
+}
+
+// hexByte converts a two nibble hex token, either of which may be ?
+func hexByte(hi, lo byte) atomByte {
+    var b atomByte
+    if v, ok := hexNibble(hi); ok {
+        b.value |= v << 4
+        b.mask |= 0xF0
+    }
+    if v, ok := hexNibble(lo); ok {
+        b.value |= v
+        b.mask |= 0x0F
+    }
+    return b
+}
+
+func hexNibble(c byte) (byte, bool) {
+    switch {
+    case c >= '0' && c <= '9':
+        return c - '0', true
+    case c >= 'a' && c <= 'f':
+        return c - 'a' + 10, true
+    case c >= 'A' && c <= 'F':
+        return c - 'A' + 10, true
+    default:
+        return 0, false
+    }
+}
+
+// regexRuns extracts the literal runs of a regular expression. Character
+// classes, groups, anchors and optional characters end a run.
+func regexRuns(value string) [][]atomByte {
+    var runs [][]atomByte
+    var run []atomByte
+    flush := func() {
+        if len(run) > 0 {
+            runs = append(runs, run)
+            run = nil
+        }
+    }
+    dropLast := func() {
+        if len(run) > 0 {
+            run = run[:len(run)-1]
+        }
+    }
+
+    for i := 0; i < len(value); i++ {
+        c := value[i]
+        switch c {
+        case '\\':
+            if i+1 == len(value) {
+                flush()
+                continue
+            }
+            i++
+            switch value[i] {
+            case 'd', 'D', 'w', 'W', 's', 'S', 'b', 'B':
+                flush()
+            case 'n':
+                run = append(run, atomByte{value: '\n', mask: 0xFF})
+            case 't':
+                run = append(run, atomByte{value: '\t', mask: 0xFF})
+            case 'r':
+                run = append(run, atomByte{value: '\r', mask: 0xFF})
+            case 'x':
+                if i+2 < len(value) {
+                    if n, err := strconv.ParseUint(value[i+1:i+3], 16, 8); err == nil {
+                        run = append(run, atomByte{value: byte(n), mask: 0xFF})
+                        i += 2
+                        continue
+                    }
+                }
+                flush()
+            default:
+                run = append(run, atomByte{value: value[i], mask: 0xFF})
+            }
+        case '[':
+            flush()
+            if end := strings.IndexByte(value[i+1:], ']'); end >= 0 {
+                i += end + 1
+            } else {
+                i = len(value)
+            }
+        case '?', '*':
+            dropLast()
+            flush()
+        case '{':
+            // {0,n} makes the previous character optional
+            if strings.HasPrefix(value[i+1:], "0") || strings.HasPrefix(value[i+1:], ",") {
+                dropLast()
+            }
+            flush()
+            if end := strings.IndexByte(value[i:], '}'); end >= 0 {
+                i += end
+            }
+        case '+', '.', '^', '$', '(', ')', '|':
+            flush()
+        default:
+            run = append(run, atomByte{value: c, mask: 0xFF})
+        }
+    }
+    flush()
+    return runs
+}
@@ -0,0 +1,512 @@
+package validation
+
+import (
+    "fmt"
+    "regexp"
+    "regexp/syntax"
+    "strconv"
+    "strings"
+
+    yaraparser "validation-service/internal/parser/yara"
+)
+
+// maxYARARepeat is the largest repeat count of YARA regular expressions,
+// which exceeds the limit of Go's engine
+const maxYARARepeat = 32767
+
+// yaraRepeatPattern matches the {n}, {n,} and {n,m} quantifiers of a regex
+var yaraRepeatPattern = regexp.MustCompile(`\{(\d*)(,?)(\d*)\}`)
+
+// yaraModifierKinds lists the string kinds each modifier applies to
+var yaraModifierKinds = map[string][]yaraparser.StringKind{
+    "nocase":     {yaraparser.StringText, yaraparser.StringRegex},
+    "wide":       {yaraparser.StringText, yaraparser.StringRegex},
+    "ascii":      {yaraparser.StringText, yaraparser.StringRegex},
+    "fullword":   {yaraparser.StringText, yaraparser.StringRegex},
+    "private":    {yaraparser.StringText, yaraparser.StringHex, yaraparser.StringRegex},
+    "xor":        {yaraparser.StringText},
+    "base64":     {yaraparser.StringText},
+    "base64wide": {yaraparser.StringText},
+}
+
+// yaraModifierConflicts lists the modifiers each modifier cannot be combined
+// with
+var yaraModifierConflicts = map[string][]string{
+    "xor":        {"nocase", "base64", "base64wide"},
+    "base64":     {"nocase", "xor", "fullword"},
+    "base64wide": {"nocase", "xor", "fullword"},
+}
+
+// yaraStringError is a malformed string value. Offset is the byte offset of
+// the problem within the value.
+type yaraStringError struct {
+    Offset int
+    Msg    string
+}
+
+// Error implements error
+func (e *yaraStringError) Error() string {
+    return e.Msg
+}
+
+// stringErrorf creates a string value error at offset
+func stringErrorf(offset int, format string, args ...interface{}) error {
+    return &yaraStringError{Offset: offset, Msg: fmt.Sprintf(format, args...)}
+}
+
+// validateHexString validates the tokens of a hex string: bytes with
+// optional nibble wildcards and ~ negation, jumps such as [2-4] and
+// alternatives such as (01 | 02 03). Comments are allowed between tokens.
+func validateHexString(value string) error {
+    p := &hexStringParser{src: value}
+    p.skipSpace()
+    if p.pos == len(p.src) {
+        return stringErrorf(0, "empty hex string")
+    }
+    items, err := p.parseSequence(false)
+    if err != nil {
+        return err
+    }
+    if p.pos < len(p.src) {
+        return stringErrorf(p.pos, "unexpected %q in hex string", p.src[p.pos])
+    }
+    if items[0].jump {
+        return stringErrorf(items[0].offset, "hex string cannot start with a jump")
+    }
+    if last := items[len(items)-1]; last.jump {
+        return stringErrorf(last.offset, "hex string cannot end with a jump")
+    }
+    return nil
+}
+
+// hexItem is a parsed token of a hex string sequence
+type hexItem struct {
+    offset int
+    jump   bool
+}
+
+// hexStringParser is a recursive-descent parser of hex string tokens
+type hexStringParser struct {
+    src string
+    pos int
+}
+
+// skipSpace skips whitespace and comments
+func (p *hexStringParser) skipSpace() {
+    for p.pos < len(p.src) {
+        switch {
+        case strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0:
+            p.pos++
+        case strings.HasPrefix(p.src[p.pos:], "//"):
+            end := strings.IndexByte(p.src[p.pos:], '\n')
+            if end < 0 {
+                p.pos = len(p.src)
+            } else {
+                p.pos += end + 1
+            }
+        case strings.HasPrefix(p.src[p.pos:], "/*"):
+            end := strings.Index(p.src[p.pos+2:], "*/")
+            if end < 0 {
+                p.pos = len(p.src)
+            } else {
+                p.pos += end + 4
+            }
+        default:
+            return
+        }
+    }
+}
+
+// parseSequence parses tokens up to the end of the value, or up to the
+// closing parenthesis or bar of an alternative
+func (p *hexStringParser) parseSequence(inAlternative bool) ([]hexItem, error) {
+    var items []hexItem
+    for {
+        p.skipSpace()
+        if p.pos == len(p.src) {
+            return items, nil
+        }
+        start := p.pos
+        switch c := p.src[p.pos]; {
+        case c == ')' || c == '|':
+            if !inAlternative {
+                return nil, stringErrorf(start, "%q outside of an alternative", c)
+            }
+            return items, nil
+        case c == '(':
+            if err := p.parseAlternatives(); err != nil {
+                return nil, err
+            }
+            items = append(items, hexItem{offset: start})
+        case c == '[':
+            if err := p.parseJump(inAlternative); err != nil {
+                return nil, err
+            }
+            items = append(items, hexItem{offset: start, jump: true})
+        case c == '~':
+            p.pos++
+            if p.pos == len(p.src) || !isHexNibble(p.src[p.pos]) {
+                return nil, stringErrorf(start, "~ must be followed by a byte")
+            }
+            if strings.HasPrefix(p.src[p.pos:], "??") {
+                return nil, stringErrorf(start, "~?? negates a full wildcard and can never match")
+            }
+            if err := p.parseByte(); err != nil {
+                return nil, err
+            }
+            items = append(items, hexItem{offset: start})
+        case isHexNibble(c):
+            if err := p.parseByte(); err != nil {
+                return nil, err
+            }
+            items = append(items, hexItem{offset: start})
+        default:
+            return nil, stringErrorf(start, "invalid character %q in hex string", c)
+        }
+    }
+}
+
+// parseByte parses two nibbles, each a hex digit or ? wildcard
+func (p *hexStringParser) parseByte() error {
+    if p.pos+1 >= len(p.src) || !isHexNibble(p.src[p.pos+1]) {
+        return stringErrorf(p.pos, "incomplete byte %q; bytes are two hex digits or wildcards", p.src[p.pos])
+    }
+    p.pos += 2
+    return nil
+}
+
+// parseAlternatives parses ( A | B | ... )
+func (p *hexStringParser) parseAlternatives() error {
+    open := p.pos
+    p.pos++
+    for {
+        start := p.pos
+        items, err := p.parseSequence(true)
+        if err != nil {
+            return err
+        }
+        if p.pos == len(p.src) {
+            return stringErrorf(open, "unclosed alternative")
+        }
+        if len(items) == 0 {
+            return stringErrorf(start, "empty alternative")
+        }
+        if items[0].jump || items[len(items)-1].jump {
+            return stringErrorf(start, "alternatives cannot start or end with a jump")
+        }
+        c := p.src[p.pos]
+        p.pos++
+        if c == ')' {
+            return nil
+        }
+    }
+}
+
+// parseJump parses [n], [n-m], [n-] and [-]. Unbounded jumps are not
+// allowed inside alternatives.
+func (p *hexStringParser) parseJump(inAlternative bool) error {
+    open := p.pos
+    end := strings.IndexByte(p.src[p.pos:], ']')
+    if end < 0 {
+        return stringErrorf(open, "unclosed jump")
+    }
+    body := strings.TrimSpace(p.src[p.pos+1 : p.pos+end])
+    p.pos += end + 1
+    if body == "" {
+        return stringErrorf(open, "empty jump []")
+    }
+
+    low, high, ranged := body, "", false
+    if dash := strings.IndexByte(body, '-'); dash >= 0 {
+        low, high, ranged = strings.TrimSpace(body[:dash]), strings.TrimSpace(body[dash+1:]), true
+    }
+    parse := func(s string) (int, error) {
+        n, err := strconv.Atoi(s)
+        if err != nil || n < 0 {
+            return 0, stringErrorf(open, "invalid jump [%s]", body)
+        }
+        return n, nil
+    }
+
+    if !ranged {
+        _, err := parse(low)
+        return err
+    }
+    lower := 0
+    if low != "" {
+        n, err := parse(low)
+        if err != nil {
+            return err
+        }
+        lower = n
+    }
+    if high == "" {
+        if inAlternative {
+            return stringErrorf(open, "unbounded jump [%s] is not allowed inside an alternative", body)
+        }
+        return nil
+    }
+    upper, err := parse(high)
+    if err != nil {
+        return err
+    }
+    if lower > upper {
+        return stringErrorf(open, "jump [%s] has a lower bound above its upper bound", body)
+    }
+    return nil
+}
+
+// isHexNibble reports whether c is a hex digit or ? wildcard
+func isHexNibble(c byte) bool {
+    return c == '?' || c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// validateRegexString validates a regular expression with its trailing
+// flags (i, s). Constructs YARA's regex engine lacks are reported even where
+// Go's engine would accept them.
+func validateRegexString(value, flags string) error {
+    if value == "" {
+        return stringErrorf(0, "empty regular expression")
+    }
+    if err := checkYARARegexSyntax(value); err != nil {
+        return err
+    }
+
+    prefix := ""
+    if flags != "" {
+        prefix = "(?" + flags + ")"
+    }
+    _, err := regexp.Compile(prefix + value)
+    if err == nil {
+        return nil
+    }
+    serr, ok := err.(*syntax.Error)
+    if !ok {
+        return stringErrorf(0, "invalid regular expression: %v", err)
+    }
+    // Go limits repeat counts to 1000; YARA allows up to 32767
+    if serr.Code == syntax.ErrInvalidRepeatSize {
+        return checkYARARepeats(value)
+    }
+    offset := strings.Index(value, serr.Expr)
+    if offset < 0 {
+        offset = 0
+    }
+    return stringErrorf(offset, "invalid regular expression: %s: `%s`", serr.Code, serr.Expr)
+}
+
+// checkYARARegexSyntax rejects escapes and group syntax that YARA's regex
+// engine does not support
+func checkYARARegexSyntax(value string) error {
+    inClass := false
+    for i := 0; i < len(value); i++ {
+        switch value[i] {
+        case '\\':
+            if i+1 == len(value) {
+                return stringErrorf(i, "trailing backslash in regular expression")
+            }
+            i++
+            switch value[i] {
+            case 'p', 'P', 'Q', 'E', 'A', 'z', 'C':
+                return stringErrorf(i-1, "escape \\%c is not supported by YARA regular expressions", value[i])
+            }
+        case '[':
+            if inClass && strings.HasPrefix(value[i:], "[:") {
+                return stringErrorf(i, "POSIX character classes are not supported by YARA regular expressions")
+            }
+            if !inClass {
+                inClass = true
+                // A leading ] or ^] is a literal inside the class
+                if strings.HasPrefix(value[i+1:], "^]") {
+                    i += 2
+                } else if strings.HasPrefix(value[i+1:], "]") {
+                    i++
+                }
+            }
+        case ']':
+            inClass = false
+        case '(':
+            if !inClass && strings.HasPrefix(value[i:], "(?") {
+                return stringErrorf(i, "group syntax (? is not supported by YARA regular expressions; use flags after the closing slash")
+            }
+        }
+    }
+    return nil
+}
+
+// checkYARARepeats checks the {n,m} quantifiers against YARA's limits
+func checkYARARepeats(value string) error {
+    for _, m := range yaraRepeatPattern.FindAllStringSubmatchIndex(value, -1) {
+        expr := value[m[0]:m[1]]
+        low, high := value[m[2]:m[3]], value[m[6]:m[7]]
+        lower, upper := 0, 0
+        if low != "" {
+            lower, _ = strconv.Atoi(low)
+        }
+        if high != "" {
+            upper, _ = strconv.Atoi(high)
+        } else if m[4] == m[5] {
+            upper = lower
+        }
+        if lower > maxYARARepeat || upper > maxYARARepeat {
+            return stringErrorf(m[0], "repeat count %s exceeds YARA's limit of %d", expr, maxYARARepeat)
+        }
+        if high != "" && lower > upper {
+            return stringErrorf(m[0], "repeat %s has a lower bound above its upper bound", expr)
+        }
+    }
+    return nil
+}
+
+// modifierProblem is an invalid modifier of a string definition
+type modifierProblem struct {
+    modifier *yaraparser.Modifier
+    message  string
+}
+
+// validateStringModifiers checks that modifiers apply to the string kind,
+// are not combined with conflicting modifiers and have valid arguments
+func validateStringModifiers(def *yaraparser.StringDef) []modifierProblem {
+    var problems []modifierProblem
+    for i, mod := range def.Modifiers {
+        if kinds, ok := yaraModifierKinds[mod.Name]; ok && !containsKind(kinds, def.Kind) {
+            problems = append(problems, modifierProblem{mod, fmt.Sprintf("modifier %s cannot be used with %s strings", mod.Name, stringKindName(def.Kind))})
+            continue
+        }
+        // Conflicts are reported once, on the later modifier of a pair
+        for _, earlier := range def.Modifiers[:i] {
+            if yaraModifiersConflict(earlier.Name, mod.Name) {
+                problems = append(problems, modifierProblem{mod, fmt.Sprintf("modifier %s cannot be combined with %s", mod.Name, earlier.Name)})
+            }
+        }
+        if err := validateModifierArgs(mod); err != nil {
+            problems = append(problems, modifierProblem{mod, err.Error()})
+        }
+    }
+    return problems
+}
+
+// yaraModifiersConflict reports whether two modifiers cannot be combined
+func yaraModifiersConflict(a, b string) bool {
+    for _, pair := range [][2]string{{a, b}, {b, a}} {
+        for _, conflict := range yaraModifierConflicts[pair[0]] {
+            if conflict == pair[1] {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// validateModifierArgs checks the arguments of xor and base64 modifiers;
+// other modifiers take none
+func validateModifierArgs(mod *yaraparser.Modifier) error {
+    args := strings.TrimSpace(mod.Args)
+    switch mod.Name {
+    case "xor":
+        if args == "" {
+            return nil
+        }
+        low, high := args, args
+        if dash := strings.IndexByte(args, '-'); dash >= 0 {
+            low, high = strings.TrimSpace(args[:dash]), strings.TrimSpace(args[dash+1:])
+        }
+        lower, errLow := strconv.ParseUint(low, 0, 8)
+        upper, errHigh := strconv.ParseUint(high, 0, 8)
+        if errLow != nil || errHigh != nil {
+            return fmt.Errorf("xor(%s) keys must be between 0 and 255", args)
+        }
+        if lower > upper {
+            return fmt.Errorf("xor(%s) has a lower bound above its upper bound", args)
+        }
+    case "base64", "base64wide":
+        if args == "" {
+            return nil
+        }
+        alphabet, err := strconv.Unquote(args)
+        if err != nil {
+            return fmt.Errorf("%s alphabet must be a quoted string", mod.Name)
+        }
+        if len(alphabet) != 64 {
+            return fmt.Errorf("%s alphabet must be 64 bytes, got %d", mod.Name, len(alphabet))
+        }
+    default:
+        if args != "" {
+            return fmt.Errorf("modifier %s takes no arguments", mod.Name)
+        }
+    }
+    return nil
+}
+
+// containsKind reports whether kinds contains kind
+func containsKind(kinds []yaraparser.StringKind, kind yaraparser.StringKind) bool {
+    for _, k := range kinds {
+        if k == kind {
+            return true
+        }
+    }
+    return false
+}
+
+// stringKindName names a string kind in messages
+func stringKindName(kind yaraparser.StringKind) string {
+    switch kind {
+    case yaraparser.StringHex:
+        return "hex"
+    case yaraparser.StringRegex:
+        return "regex"
+    }
+    return "text"
+}
+
+// rawStringValue returns the source text of a string value between its
+// delimiters, so error offsets map onto the source. Hex strings keep their
+// comments and line breaks, which the parsed value does not.
+func rawStringValue(source string, def *yaraparser.StringDef) (string, bool) {
+    start := def.ValuePos.Offset
+    if !def.ValuePos.IsValid() || start >= len(source) {
+        return "", false
+    }
+    switch def.Kind {
+    case yaraparser.StringHex:
+        if source[start] != '{' {
+            return "", false
+        }
+        p := &hexStringParser{src: source, pos: start + 1}
+        for p.pos < len(source) {
+            p.skipSpace()
+            if p.pos < len(source) && source[p.pos] == '}' {
+                return source[start+1 : p.pos], true
+            }
+            if p.pos < len(source) {
+                p.pos++
+            }
+        }
+    case yaraparser.StringRegex:
+        if source[start] == '/' && strings.HasPrefix(source[start+1:], def.Value) {
+            return def.Value, true
+        }
+    }
+    return "", false
+}
+
+// valuePosition returns the source position of a byte offset within the raw
+// value of a string, counting from the character after the opening
+// delimiter
+func valuePosition(source string, def *yaraparser.StringDef, offset int) yaraparser.Position {
+    pos := def.ValuePos
+    end := pos.Offset + 1 + offset
+    if end > len(source) {
+        return pos
+    }
+    for i := pos.Offset; i < end; i++ {
+        if source[i] == '\n' {
+            pos.Line++
+            pos.Column = 1
+        } else {
+            pos.Column++
+        }
+    }
+    pos.Offset = end
+    return pos
+}
@@ -2,25 +2,58 @@
 package validation
 
 import (
+    _ "embed"
+    "encoding/json"
     "fmt"
     "regexp"
+    "strconv"
     "strings"
+    "sync"
     "time"
 
     "internal/models"
     "pkg/utils"
 )
 
-// Regular expression patterns for YARA-L syntax validation
-var (
-    // Basic YARA-L rule structure pattern
-    yaralSyntaxPattern = regexp.MustCompile(`^rule\s+[\w_]+\s*{[\s\S]*}$`)
+// defaultYARALUDMSchema lists the UDM fields YARA-L event variables may use
+//
+//go:embed mappings/yaral_udm_fields.json
+var defaultYARALUDMSchema []byte
 
-    // Required YARA-L keywords
-    yaralKeywords = []string{
-        "rule", "meta", "strings", "condition",
-        "and", "or", "not", "them", "for", "all", "of",
-    }
+// YARA-L 2.0 sections in the order they must appear
+var yaralSectionOrder = []string{"meta", "events", "match", "outcome", "condition", "options"}
+
+// Regular expression patterns for YARA-L 2.0 syntax validation
+var (
+    // yaralRuleHeader matches the rule declaration at the start of a rule
+    yaralRuleHeader = regexp.MustCompile(`^\s*rule\s+([A-Za-z_]\w*)\s*\{`)
+    // yaralSectionHeader matches a section label at the start of a line and
+    // captures any statement following it on the same line
+    yaralSectionHeader = regexp.MustCompile(`^\s*([A-Za-z_]\w*)\s*:(?:\s*(.*))?$`)
+    // yaralMetaEntry matches a `key = value` meta or options entry
+    yaralMetaEntry = regexp.MustCompile(`^\s*([A-Za-z_]\w*)\s*=\s*(.+?)\s*$`)
+    // yaralMetaValue matches a quoted string, number or boolean value
+    yaralMetaValue = regexp.MustCompile(`^(?:"(?:[^"\\]|\\.)*"|-?\d+(?:\.\d+)?|true|false)$`)
+    // yaralFieldRef matches a UDM field reference such as $e.principal.hostname
+    yaralFieldRef = regexp.MustCompile(`\$([A-Za-z_]\w*)((?:\.[A-Za-z_]\w*)+)`)
+    // yaralVariable matches a variable; field references are removed before
+    // it is applied so that only placeholder variables remain
+    yaralVariable = regexp.MustCompile(`\$([A-Za-z_]\w*)`)
+    // yaralConditionVariable matches existence ($e), count (#e) and outcome
+    // ($risk) references in a condition
+    yaralConditionVariable = regexp.MustCompile(`[$#]([A-Za-z_]\w*)`)
+    // yaralMatchClause matches `$a, $b over 10m` with an optional sliding
+    // window pivot such as `after $e1`
+    yaralMatchClause = regexp.MustCompile(`^((?:\$[A-Za-z_]\w*\s*,\s*)*\$[A-Za-z_]\w*)\s+over\s+(\d+)([smhd])(?:\s+(before|after)\s+\$([A-Za-z_]\w*))?$`)
+    // yaralOutcomeEntry matches an outcome variable assignment
+    yaralOutcomeEntry = regexp.MustCompile(`^\s*\$([A-Za-z_]\w*)\s*=\s*(.+?)\s*$`)
+    // yaralAggregate matches an outcome expression wrapped in an aggregation
+    yaralAggregate = regexp.MustCompile(`^(?:sum|count|count_distinct|max|min|array|array_distinct|avg|stddev|earliest|latest)\s*\(`)
+    // yaralRegexLiteral matches regular expression literals, which may contain
+    // $ anchors that are not variables
+    yaralRegexLiteral = regexp.MustCompile(`(^|[=(,]\s*)/(?:\\.|[^/\n])+/(\s+nocase)?`)
+    // yaralStringLiteral matches double-quoted and backquoted strings
+    yaralStringLiteral = regexp.MustCompile("\"(?:[^\"\\\\]|\\\\.)*\"|`[^`]*`")
 
     // Required meta section fields
     metaRequiredFields = []string{
@@ -30,12 +63,130 @@ var (
         "reference",
     }
 
-    // Maximum allowed complexity for condition section
+    // Default maximum complexity of the condition section
     maxConditionComplexity = 100
 )
 
-// ValidateYARAL performs comprehensive validation of YARA-L format detection rules
+// yaralMaxMatchWindow is the longest match window Chronicle accepts
+const yaralMaxMatchWindow = 48 * time.Hour
+
+// yaralLine is a statement line of a rule with its 1-based line number
+type yaralLine struct {
+    text string
+    line int
+}
+
+// yaralSection is a labelled section of a YARA-L 2.0 rule
+type yaralSection struct {
+    name  string
+    line  int
+    lines []yaralLine
+}
+
+// text joins the statements of a section; a missing section has no text
+func (s *yaralSection) text() string {
+    if s == nil {
+        return ""
+    }
+    parts := make([]string, 0, len(s.lines))
+    for _, l := range s.lines {
+        parts = append(parts, l.text)
+    }
+    return strings.Join(parts, " ")
+}
+
+// yaralRule is a YARA-L 2.0 rule split into its sections. Sections holds the
+// first occurrence of each section; order lists every occurrence, including
+// statements outside of any section under an empty name.
+type yaralRule struct {
+    name     string
+    sections map[string]*yaralSection
+    order    []*yaralSection
+}
+
+// sectionNames lists the sections of the rule in order of appearance
+func (r *yaralRule) sectionNames() []string {
+    names := make([]string, 0, len(r.order))
+    for _, section := range r.order {
+        if section.name != "" {
+            names = append(names, section.name)
+        }
+    }
+    return names
+}
+
+// yaralEvents holds the variables and UDM fields declared in the events section
+type yaralEvents struct {
+    eventVars    map[string]bool
+    placeholders map[string]bool
+    fields       map[string]bool
+}
+
+// YARALUDMSchema lists the UDM fields known to the validator. Noun fields
+// apply below every noun such as principal or target; Fields lists the
+// children of the other top-level UDM fields.
+type YARALUDMSchema struct {
+    Version    string              `json:"version"`
+    Nouns      []string            `json:"nouns"`
+    NounFields []string            `json:"noun_fields"`
+    Fields     map[string][]string `json:"fields"`
+
+    nouns      map[string]bool
+    nounFields map[string]bool
+    fields     map[string]map[string]bool
+}
+
+var (
+    yaralSchemaOnce sync.Once
+    yaralSchema     *YARALUDMSchema
+)
+
+// yaralUDMSchema returns the built-in UDM schema, or nil when it cannot be
+// parsed
+func yaralUDMSchema() *YARALUDMSchema {
+    yaralSchemaOnce.Do(func() {
+        var schema YARALUDMSchema
+        if err := json.Unmarshal(defaultYARALUDMSchema, &schema); err != nil {
+            return
+        }
+        schema.nouns = toSet(schema.Nouns)
+        schema.nounFields = toSet(schema.NounFields)
+        schema.fields = make(map[string]map[string]bool, len(schema.Fields))
+        for root, children := range schema.Fields {
+            schema.fields[root] = toSet(children)
+        }
+        yaralSchema = &schema
+    })
+    return yaralSchema
+}
+
+// knownPath reports whether the first two segments of a UDM path exist
+func (s *YARALUDMSchema) knownPath(path []string) bool {
+    if len(path) == 0 {
+        return false
+    }
+    root := path[0]
+    var children map[string]bool
+    switch {
+    case s.nouns[root]:
+        children = s.nounFields
+    case s.fields[root] != nil:
+        children = s.fields[root]
+    default:
+        return false
+    }
+    return len(path) == 1 || children[path[1]]
+}
+
+// ValidateYARAL performs comprehensive validation of YARA-L 2.0 detection rules
 func ValidateYARAL(detection *models.Detection) (*models.ValidationResult, error) {
+    return validateYARAL(detection, maxConditionComplexity, nil)
+}
+
+// validateYARAL validates a YARA-L 2.0 rule, reporting conditions more
+// complex than maxComplexity. UDM fields of the tenant's custom schemas in
+// custom, which may be nil, are accepted besides the built-in ones.
+func validateYARAL(detection *models.Detection, maxComplexity int, custom *customSchemaFields) (*models.ValidationResult, error) {
     // Create new validation result
     result, err := models.NewValidationResult(detection)
     if err != nil {
@@ -56,269 +207,632 @@ func ValidateYARAL(detection *models.Detection) (*models.ValidationResult, error
     // Sanitize input
     content = utils.SanitizeInput(content)
 
-    // Basic syntax validation
-    if !yaralSyntaxPattern.MatchString(content) {
-        result.AddIssue(&models.ValidationIssue{
-            Message:   "Invalid YARA-L rule syntax",
-            Severity:  models.ValidationSeverityHigh,
-            Location:  "rule",
-            IssueCode: "YARAL001",
-            Remediation: "Ensure rule follows basic YARA-L syntax: rule rule_name { ... }",
-        })
+    // Split the rule into its sections
+    rule, issue := parseYARALRule(stripYARALComments(content))
+    if issue != nil {
+        result.AddIssue(issue)
         return result, nil
     }
+    for _, issue := range validateSectionLayout(rule) {
+        result.AddIssue(&issue)
+    }
+
+    // Validate meta section
+    for _, issue := range validateMetaSection(rule.sections["meta"]) {
+        result.AddIssue(&issue)
+    }
 
-    // Extract rule sections
-    sections := extractRuleSections(content)
+    // Validate events section and collect its variables
+    events, issues := validateEventsSection(rule.sections["events"], custom)
+    for _, issue := range issues {
+        result.AddIssue(&issue)
+    }
 
-    // Validate rule name
-    if issues := validateRuleName(sections["ruleName"]); len(issues) > 0 {
-        for _, issue := range issues {
-            result.AddIssue(&issue)
-        }
+    // Validate match section
+    window, issues := validateMatchSection(rule.sections["match"], events)
+    for _, issue := range issues {
+        result.AddIssue(&issue)
     }
 
-    // Validate meta section
-    if issues := validateMetaSection(sections["meta"]); len(issues) > 0 {
-        for _, issue := range issues {
-            result.AddIssue(&issue)
+    // Validate outcome section
+    outcomes, issues := validateOutcomeSection(rule.sections["outcome"], events, rule.sections["match"] != nil)
+    for _, issue := range issues {
+        result.AddIssue(&issue)
+    }
+
+    // Validate condition section against the declared variables
+    for _, issue := range validateConditionSection(rule.sections["condition"], events, outcomes, maxComplexity) {
+        result.AddIssue(&issue)
+    }
+
+    // Validate options section
+    for _, issue := range validateOptionsSection(rule.sections["options"]) {
+        result.AddIssue(&issue)
+    }
+
+    // Add format-specific details to result
+    result.FormatSpecificDetails["rule_name"] = rule.name
+    result.FormatSpecificDetails["yaral_version"] = "2.0"
+    result.FormatSpecificDetails["sections"] = rule.sectionNames()
+    result.FormatSpecificDetails["event_variables"] = sortedKeys(events.eventVars)
+    result.FormatSpecificDetails["placeholder_variables"] = sortedKeys(events.placeholders)
+    result.FormatSpecificDetails["outcome_variables"] = sortedKeys(outcomes)
+    result.FormatSpecificDetails["udm_fields"] = sortedKeys(events.fields)
+    if window > 0 {
+        result.FormatSpecificDetails["match_window"] = window.String()
+    }
+    result.FormatSpecificDetails["condition_complexity"] = calculateConditionComplexity(rule.sections["condition"].text())
+
+    return result, nil
+}
+
+// parseYARALRule checks the rule declaration and its braces and splits the
+// body into sections
+func parseYARALRule(content string) (*yaralRule, *models.ValidationIssue) {
+    header := yaralRuleHeader.FindStringSubmatchIndex(content)
+    if header == nil {
+        return nil, &models.ValidationIssue{
+            Message:     "Invalid YARA-L rule syntax",
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "rule",
+            Line:        1,
+            IssueCode:   "YARAL001",
+            Remediation: "Ensure rule follows basic YARA-L syntax: rule rule_name { ... }",
         }
     }
 
-    // Validate strings section
-    if issues := validateStringsSection(sections["strings"]); len(issues) > 0 {
-        for _, issue := range issues {
-            result.AddIssue(&issue)
+    bodyStart := header[1]
+    bodyEnd := matchingBrace(content, bodyStart-1)
+    if bodyEnd < 0 {
+        return nil, &models.ValidationIssue{
+            Message:     "Unbalanced braces in YARA-L rule",
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "rule",
+            Line:        lineAt(content, bodyStart),
+            IssueCode:   "YARAL001",
+            Remediation: "Close every opened brace; the rule body must end with }",
+        }
+    }
+    if trailing := strings.TrimSpace(content[bodyEnd+1:]); trailing != "" {
+        return nil, &models.ValidationIssue{
+            Message:     "Unexpected content after the end of the rule",
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "rule",
+            Line:        lineAt(content, bodyEnd+1),
+            IssueCode:   "YARAL001",
+            Remediation: "Define one rule per detection and remove content after its closing brace",
         }
     }
 
-    // Validate condition section
-    if issues := validateConditionSection(sections["condition"]); len(issues) > 0 {
-        for _, issue := range issues {
-            result.AddIssue(&issue)
+    rule := &yaralRule{
+        name:     content[header[2]:header[3]],
+        sections: make(map[string]*yaralSection),
+    }
+
+    var current *yaralSection
+    firstLine := lineAt(content, bodyStart)
+    for i, text := range strings.Split(content[bodyStart:bodyEnd], "\n") {
+        line := firstLine + i
+        if match := yaralSectionHeader.FindStringSubmatch(text); match != nil {
+            current = &yaralSection{name: match[1], line: line}
+            rule.order = append(rule.order, current)
+            if rest := strings.TrimSpace(match[2]); rest != "" {
+                current.lines = append(current.lines, yaralLine{text: rest, line: line})
+            }
+            continue
+        }
+        if strings.TrimSpace(text) == "" {
+            continue
+        }
+        if current == nil {
+            current = &yaralSection{line: line}
+            rule.order = append(rule.order, current)
         }
+        current.lines = append(current.lines, yaralLine{text: strings.TrimSpace(text), line: line})
     }
 
-    // Cross-reference validation between sections
-    if issues := validateCrossReferences(sections); len(issues) > 0 {
-        for _, issue := range issues {
-            result.AddIssue(&issue)
+    for _, section := range rule.order {
+        if _, seen := rule.sections[section.name]; !seen && section.name != "" {
+            rule.sections[section.name] = section
         }
     }
+    return rule, nil
+}
 
-    // Add format-specific details to result
-    result.FormatSpecificDetails["rule_name"] = sections["ruleName"]
-    result.FormatSpecificDetails["has_strings"] = len(sections["strings"]) > 0
-    result.FormatSpecificDetails["condition_complexity"] = calculateConditionComplexity(sections["condition"])
+// validateSectionLayout reports statements outside of sections and unknown,
+// duplicate, misplaced or brace-enclosed sections
+func validateSectionLayout(rule *yaralRule) []models.ValidationIssue {
+    issues := make([]models.ValidationIssue, 0)
+    rank := make(map[string]int, len(yaralSectionOrder))
+    for i, name := range yaralSectionOrder {
+        rank[name] = i
+    }
 
-    return result, nil
+    seen := make(map[string]bool)
+    last := -1
+    for _, section := range rule.order {
+        issue := models.ValidationIssue{
+            Severity:  models.ValidationSeverityHigh,
+            Location:  "rule",
+            Line:      section.line,
+            IssueCode: "YARAL013",
+        }
+        position, known := rank[section.name]
+        switch {
+        case section.name == "":
+            issue.Message = "Statement outside of a rule section"
+            issue.Remediation = "Place statements under one of the sections: " + strings.Join(yaralSectionOrder, ", ")
+        case !known:
+            issue.Message = fmt.Sprintf("Unknown YARA-L section: %s", section.name)
+            issue.Location = section.name
+            issue.Remediation = "YARA-L 2.0 rules use the sections " + strings.Join(yaralSectionOrder, ", ") + "; YARA strings sections are not supported"
+        case seen[section.name]:
+            issue.Message = fmt.Sprintf("Duplicate %s section", section.name)
+            issue.Location = section.name
+            issue.Remediation = "Merge the statements into a single section"
+        case position < last:
+            issue.Message = fmt.Sprintf("Section %s is out of order", section.name)
+            issue.Location = section.name
+            issue.Remediation = "Order sections as " + strings.Join(yaralSectionOrder, ", ")
+        case len(section.lines) > 0 && section.lines[0].line == section.line && strings.HasPrefix(section.lines[0].text, "{"):
+            issue.Message = fmt.Sprintf("Section %s is enclosed in braces", section.name)
+            issue.Location = section.name
+            issue.Remediation = "Remove the braces; YARA-L 2.0 sections run until the next section label"
+        default:
+            seen[section.name] = true
+            last = position
+            continue
+        }
+        if known {
+            seen[section.name] = true
+        }
+        issues = append(issues, issue)
+    }
+    return issues
 }
 
-// validateMetaSection validates the meta section of a YARA-L rule
-func validateMetaSection(metaSection string) []models.ValidationIssue {
+// validateMetaSection validates the `key = value` entries of the meta section
+func validateMetaSection(section *yaralSection) []models.ValidationIssue {
     issues := make([]models.ValidationIssue, 0)
 
-    if metaSection == "" {
+    if section == nil {
         issues = append(issues, models.ValidationIssue{
             Message:     "Missing meta section",
             Severity:    models.ValidationSeverityHigh,
             Location:    "meta",
             IssueCode:   "YARAL002",
-            Remediation: "Add meta section with required fields: author, description, severity, reference",
-            Timestamp:   time.Now(),
+            Remediation: "Add meta section with required fields: " + strings.Join(metaRequiredFields, ", "),
         })
         return issues
     }
 
+    entries := make(map[string]string)
+    for _, line := range section.lines {
+        match := yaralMetaEntry.FindStringSubmatch(line.text)
+        if match == nil || !yaralMetaValue.MatchString(match[2]) {
+            issues = append(issues, models.ValidationIssue{
+                Message:     fmt.Sprintf("Malformed meta entry: %s", line.text),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    "meta",
+                Line:        line.line,
+                IssueCode:   "YARAL013",
+                Remediation: `Write meta entries as key = "value"`,
+            })
+            continue
+        }
+        entries[match[1]] = strings.Trim(match[2], `"`)
+    }
+
     // Check for required fields
     for _, field := range metaRequiredFields {
-        if !strings.Contains(metaSection, field+":") {
+        if _, ok := entries[field]; !ok {
             issues = append(issues, models.ValidationIssue{
                 Message:     fmt.Sprintf("Missing required meta field: %s", field),
                 Severity:    models.ValidationSeverityHigh,
                 Location:    "meta." + field,
+                Line:        section.line,
                 IssueCode:   "YARAL003",
                 Remediation: fmt.Sprintf("Add required field '%s' to meta section", field),
-                Timestamp:   time.Now(),
             })
         }
     }
 
     // Validate severity values
-    if strings.Contains(metaSection, "severity:") {
-        severity := extractMetaValue(metaSection, "severity")
-        if !isValidSeverity(severity) {
+    if severity, ok := entries["severity"]; ok && !isValidSeverity(severity) {
+        issues = append(issues, models.ValidationIssue{
+            Message:     fmt.Sprintf("Invalid severity value: %s", severity),
+            Severity:    models.ValidationSeverityMedium,
+            Location:    "meta.severity",
+            Line:        section.line,
+            IssueCode:   "YARAL004",
+            Remediation: "Use valid severity values: informational, low, medium, high, critical",
+        })
+    }
+
+    return issues
+}
+
+// validateEventsSection collects the event and placeholder variables of the
+// events section and checks the UDM fields they reference
+func validateEventsSection(section *yaralSection, custom *customSchemaFields) (*yaralEvents, []models.ValidationIssue) {
+    events := &yaralEvents{
+        eventVars:    make(map[string]bool),
+        placeholders: make(map[string]bool),
+        fields:       make(map[string]bool),
+    }
+    issues := make([]models.ValidationIssue, 0)
+
+    if section == nil || len(section.lines) == 0 {
+        issues = append(issues, models.ValidationIssue{
+            Message:     "Missing events section",
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "events",
+            IssueCode:   "YARAL005",
+            Remediation: "Add an events section filtering UDM events, e.g. $e.metadata.event_type = \"USER_LOGIN\"",
+        })
+        return events, issues
+    }
+
+    schema := yaralUDMSchema()
+    reported := make(map[string]bool)
+    for _, line := range section.lines {
+        text := stripYARALLiterals(line.text)
+
+        for _, match := range yaralFieldRef.FindAllStringSubmatch(text, -1) {
+            path := strings.TrimPrefix(match[2], ".")
+            events.eventVars[match[1]] = true
+            events.fields[path] = true
+
+            if schema == nil || reported[path] || schema.knownPath(strings.Split(path, ".")) || custom.knownUDMPath(path) {
+                continue
+            }
+            reported[path] = true
             issues = append(issues, models.ValidationIssue{
-                Message:     "Invalid severity value",
+                Message:     fmt.Sprintf("Unknown UDM field: %s", path),
                 Severity:    models.ValidationSeverityMedium,
-                Location:    "meta.severity",
-                IssueCode:   "YARAL004",
-                Remediation: "Use valid severity values: low, medium, high, critical",
-                Timestamp:   time.Now(),
+                Location:    "events." + path,
+                Line:        line.line,
+                IssueCode:   "YARAL006",
+                Remediation: "Reference a UDM field such as metadata.event_type, principal.hostname or target.user.userid, or register custom fields in a custom schema",
             })
         }
+
+        for _, match := range yaralVariable.FindAllStringSubmatch(yaralFieldRef.ReplaceAllString(text, ""), -1) {
+            events.placeholders[match[1]] = true
+        }
     }
 
-    return issues
+    if len(events.eventVars) == 0 {
+        issues = append(issues, models.ValidationIssue{
+            Message:     "Events section declares no event variables",
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "events",
+            Line:        section.line,
+            IssueCode:   "YARAL005",
+            Remediation: "Filter events through an event variable, e.g. $e.metadata.event_type = \"USER_LOGIN\"",
+        })
+    }
+    return events, issues
 }
 
-// validateStringsSection validates the strings section with pattern complexity analysis
-func validateStringsSection(stringsSection string) []models.ValidationIssue {
+// validateMatchSection checks the match variables and window and returns the
+// window length
+func validateMatchSection(section *yaralSection, events *yaralEvents) (time.Duration, []models.ValidationIssue) {
     issues := make([]models.ValidationIssue, 0)
+    if section == nil {
+        return 0, issues
+    }
 
-    if stringsSection == "" {
-        return issues // Strings section is optional
+    clause := strings.Join(strings.Fields(section.text()), " ")
+    match := yaralMatchClause.FindStringSubmatch(clause)
+    if match == nil {
+        issues = append(issues, models.ValidationIssue{
+            Message:     fmt.Sprintf("Invalid match section: %s", clause),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "match",
+            Line:        section.line,
+            IssueCode:   "YARAL010",
+            Remediation: "Group by placeholder variables over a window, e.g. $user, $host over 10m",
+        })
+        return 0, issues
     }
 
-    // Validate string identifiers
-    stringDefs := extractStringDefinitions(stringsSection)
-    identifiers := make(map[string]bool)
+    for _, variable := range strings.Split(match[1], ",") {
+        name := strings.TrimPrefix(strings.TrimSpace(variable), "$")
+        if events.placeholders[name] {
+            continue
+        }
+        message := fmt.Sprintf("Match variable $%s is not defined in the events section", name)
+        if events.eventVars[name] {
+            message = fmt.Sprintf("Match variable $%s is an event variable, not a placeholder", name)
+        }
+        issues = append(issues, models.ValidationIssue{
+            Message:     message,
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "match.$" + name,
+            Line:        section.line,
+            IssueCode:   "YARAL011",
+            Remediation: fmt.Sprintf("Assign a UDM field to $%s in the events section, e.g. $%s = $e.principal.hostname", name, name),
+        })
+    }
+    if pivot := match[5]; pivot != "" && !events.eventVars[pivot] {
+        issues = append(issues, models.ValidationIssue{
+            Message:     fmt.Sprintf("Sliding window pivot $%s is not an event variable", pivot),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "match.$" + pivot,
+            Line:        section.line,
+            IssueCode:   "YARAL011",
+            Remediation: "Pivot the window on an event variable of the events section",
+        })
+    }
+
+    count, _ := strconv.Atoi(match[2])
+    units := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour, "d": 24 * time.Hour}
+    window := time.Duration(count) * units[match[3]]
+    switch {
+    case window <= 0:
+        issues = append(issues, models.ValidationIssue{
+            Message:     "Match window must be positive",
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "match",
+            Line:        section.line,
+            IssueCode:   "YARAL010",
+            Remediation: "Use a window such as 10m, 1h or 1d",
+        })
+    case window > yaralMaxMatchWindow:
+        issues = append(issues, models.ValidationIssue{
+            Message:     fmt.Sprintf("Match window %s exceeds the maximum of %s", window, yaralMaxMatchWindow),
+            Severity:    models.ValidationSeverityMedium,
+            Location:    "match",
+            Line:        section.line,
+            IssueCode:   "YARAL012",
+            Remediation: "Shorten the match window to 48h or less",
+        })
+    }
+    return window, issues
+}
+
+// validateOutcomeSection checks outcome assignments and returns the outcome
+// variables. Rules with a match section aggregate over several events, so
+// their outcomes must use an aggregation function.
+func validateOutcomeSection(section *yaralSection, events *yaralEvents, hasMatch bool) (map[string]bool, []models.ValidationIssue) {
+    outcomes := make(map[string]bool)
+    issues := make([]models.ValidationIssue, 0)
+    if section == nil {
+        return outcomes, issues
+    }
+
+    type assignment struct {
+        name string
+        expr string
+        line int
+    }
+    var assignments []*assignment
+    for _, line := range section.lines {
+        if match := yaralOutcomeEntry.FindStringSubmatch(line.text); match != nil {
+            assignments = append(assignments, &assignment{name: match[1], expr: match[2], line: line.line})
+            continue
+        }
+        if len(assignments) > 0 {
+            // Continuation of a multi-line expression
+            last := assignments[len(assignments)-1]
+            last.expr += " " + line.text
+            continue
+        }
+        issues = append(issues, models.ValidationIssue{
+            Message:     fmt.Sprintf("Malformed outcome entry: %s", line.text),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "outcome",
+            Line:        line.line,
+            IssueCode:   "YARAL013",
+            Remediation: "Assign outcomes as $name = expression",
+        })
+    }
 
-    for _, def := range stringDefs {
-        identifier := extractStringIdentifier(def)
-        
-        // Check for duplicate identifiers
-        if identifiers[identifier] {
+    for _, a := range assignments {
+        if outcomes[a.name] || events.eventVars[a.name] || events.placeholders[a.name] {
             issues = append(issues, models.ValidationIssue{
-                Message:     fmt.Sprintf("Duplicate string identifier: %s", identifier),
+                Message:     fmt.Sprintf("Outcome variable $%s is already defined", a.name),
                 Severity:    models.ValidationSeverityHigh,
-                Location:    "strings." + identifier,
-                IssueCode:   "YARAL005",
-                Remediation: "Use unique identifiers for string definitions",
-                Timestamp:   time.Now(),
+                Location:    "outcome.$" + a.name,
+                Line:        a.line,
+                IssueCode:   "YARAL013",
+                Remediation: "Give every outcome variable a unique name",
             })
         }
-        identifiers[identifier] = true
+        outcomes[a.name] = true
 
-        // Validate pattern complexity
-        if complexity := calculatePatternComplexity(def); complexity > maxConditionComplexity {
+        expr := stripYARALLiterals(a.expr)
+        if hasMatch && yaralFieldRef.MatchString(expr) && !yaralAggregate.MatchString(expr) {
             issues = append(issues, models.ValidationIssue{
-                Message:     fmt.Sprintf("String pattern too complex: %s", identifier),
+                Message:     fmt.Sprintf("Outcome $%s reads event fields without an aggregation", a.name),
                 Severity:    models.ValidationSeverityMedium,
-                Location:    "strings." + identifier,
-                IssueCode:   "YARAL006",
-                Remediation: "Simplify pattern or split into multiple strings",
-                Timestamp:   time.Now(),
+                Location:    "outcome.$" + a.name,
+                Line:        a.line,
+                IssueCode:   "YARAL015",
+                Remediation: "Wrap the expression in an aggregation such as max(), sum(), count_distinct() or array_distinct()",
             })
         }
     }
-
-    return issues
+    return outcomes, issues
 }
 
-// validateConditionSection validates the condition section with logic analysis
-func validateConditionSection(conditionSection string) []models.ValidationIssue {
+// validateConditionSection checks that the condition only references declared
+// variables, uses every event variable and is no more complex than
+// maxComplexity
+func validateConditionSection(section *yaralSection, events *yaralEvents, outcomes map[string]bool, maxComplexity int) []models.ValidationIssue {
     issues := make([]models.ValidationIssue, 0)
 
-    if conditionSection == "" {
+    condition := section.text()
+    if strings.TrimSpace(condition) == "" {
         issues = append(issues, models.ValidationIssue{
             Message:     "Missing condition section",
             Severity:    models.ValidationSeverityHigh,
             Location:    "condition",
             IssueCode:   "YARAL007",
-            Remediation: "Add condition section with detection logic",
-            Timestamp:   time.Now(),
+            Remediation: "Add condition section with detection logic, e.g. condition: $e",
         })
         return issues
     }
 
-    // Validate boolean operators
-    if !hasValidBooleanOperators(conditionSection) {
+    referenced := make(map[string]bool)
+    for _, match := range yaralConditionVariable.FindAllStringSubmatch(stripYARALLiterals(condition), -1) {
+        referenced[match[1]] = true
+    }
+    for _, name := range sortedKeys(referenced) {
+        if events.eventVars[name] || events.placeholders[name] || outcomes[name] {
+            continue
+        }
         issues = append(issues, models.ValidationIssue{
-            Message:     "Invalid boolean operators in condition",
+            Message:     fmt.Sprintf("Condition references undefined variable $%s", name),
             Severity:    models.ValidationSeverityHigh,
             Location:    "condition",
+            Line:        section.line,
             IssueCode:   "YARAL008",
-            Remediation: "Use valid operators: and, or, not",
-            Timestamp:   time.Now(),
+            Remediation: "Reference event variables of the events section or outcome variables",
+        })
+    }
+    for _, name := range sortedKeys(events.eventVars) {
+        if referenced[name] {
+            continue
+        }
+        issues = append(issues, models.ValidationIssue{
+            Message:     fmt.Sprintf("Event variable $%s is not used in the condition", name),
+            Severity:    models.ValidationSeverityMedium,
+            Location:    "condition",
+            Line:        section.line,
+            IssueCode:   "YARAL014",
+            Remediation: fmt.Sprintf("Add $%s (or #%s for a count) to the condition", name, name),
         })
     }
 
     // Check condition complexity
-    if complexity := calculateConditionComplexity(conditionSection); complexity > maxConditionComplexity {
+    if complexity := calculateConditionComplexity(condition); complexity > maxComplexity {
         issues = append(issues, models.ValidationIssue{
             Message:     "Condition logic too complex",
             Severity:    models.ValidationSeverityMedium,
             Location:    "condition",
+            Line:        section.line,
             IssueCode:   "YARAL009",
             Remediation: "Simplify condition logic or split into multiple rules",
-            Timestamp:   time.Now(),
         })
     }
 
     return issues
 }
 
+// validateOptionsSection checks the `key = value` entries of the options section
+func validateOptionsSection(section *yaralSection) []models.ValidationIssue {
+    issues := make([]models.ValidationIssue, 0)
+    if section == nil {
+        return issues
+    }
+    for _, line := range section.lines {
+        if match := yaralMetaEntry.FindStringSubmatch(line.text); match != nil && yaralMetaValue.MatchString(match[2]) {
+            continue
+        }
+        issues = append(issues, models.ValidationIssue{
+            Message:     fmt.Sprintf("Malformed option: %s", line.text),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "options",
+            Line:        line.line,
+            IssueCode:   "YARAL013",
+            Remediation: "Write options as key = value, e.g. allow_zero_values = true",
+        })
+    }
+    return issues
+}
+
 // Helper functions
 
-func extractRuleSections(content string) map[string]string {
-    sections := make(map[string]string)
-    
-    // Extract rule name
-    if match := regexp.MustCompile(`rule\s+([\w_]+)`).FindStringSubmatch(content); len(match) > 1 {
-        sections["ruleName"] = match[1]
+// stripYARALComments blanks // and /* */ comments outside of string literals,
+// keeping line breaks so that line numbers are preserved
+func stripYARALComments(content string) string {
+    out := []byte(content)
+    for i := 0; i < len(out); i++ {
+        switch {
+        case out[i] == '"':
+            for i++; i < len(out) && out[i] != '"' && out[i] != '\n'; i++ {
+                if out[i] == '\\' {
+                    i++
+                }
+            }
+        case out[i] == '/' && i+1 < len(out) && out[i+1] == '/':
+            for ; i < len(out) && out[i] != '\n'; i++ {
+                out[i] = ' '
+            }
+        case out[i] == '/' && i+1 < len(out) && out[i+1] == '*':
+            for ; i < len(out) && !(out[i] == '*' && i+1 < len(out) && out[i+1] == '/'); i++ {
+                if out[i] != '\n' {
+                    out[i] = ' '
+                }
+            }
+            if i+1 < len(out) {
+                out[i], out[i+1] = ' ', ' '
+                i++
+            }
+        }
     }
+    return string(out)
+}
 
-    // Extract meta section
-    if match := regexp.MustCompile(`meta:\s*{([^}]+)}`).FindStringSubmatch(content); len(match) > 1 {
-        sections["meta"] = match[1]
-    }
+// stripYARALLiterals empties string and regular expression literals, which
+// may contain $ characters that are not variables
+func stripYARALLiterals(text string) string {
+    text = yaralStringLiteral.ReplaceAllString(text, `""`)
+    return yaralRegexLiteral.ReplaceAllString(text, `${1}//`)
+}
 
-    // Extract strings section
-    if match := regexp.MustCompile(`strings:\s*{([^}]+)}`).FindStringSubmatch(content); len(match) > 1 {
-        sections["strings"] = match[1]
+// matchingBrace returns the offset of the brace closing the one at open, or
+// -1 when it is not closed. Braces in string literals are ignored.
+func matchingBrace(content string, open int) int {
+    depth := 0
+    for i := open; i < len(content); i++ {
+        switch content[i] {
+        case '"':
+            for i++; i < len(content) && content[i] != '"'; i++ {
+                if content[i] == '\\' {
+                    i++
+                }
+            }
+        case '{':
+            depth++
+        case '}':
+            if depth--; depth == 0 {
+                return i
+            }
+        }
     }
+    return -1
+}
 
-    // Extract condition section
-    if match := regexp.MustCompile(`condition:\s*{([^}]+)}`).FindStringSubmatch(content); len(match) > 1 {
-        sections["condition"] = match[1]
-    }
+// lineAt returns the 1-based line number of offset
+func lineAt(content string, offset int) int {
+    return strings.Count(content[:offset], "\n") + 1
+}
 
-    return sections
+func toSet(values []string) map[string]bool {
+    set := make(map[string]bool, len(values))
+    for _, v := range values {
+        set[v] = true
+    }
+    return set
 }
 
 func isValidSeverity(severity string) bool {
     validSeverities := map[string]bool{
-        "low":      true,
-        "medium":   true,
-        "high":     true,
-        "critical": true,
+        "informational": true,
+        "low":           true,
+        "medium":        true,
+        "high":          true,
+        "critical":      true,
     }
     return validSeverities[strings.ToLower(strings.TrimSpace(severity))]
 }
 
-func calculatePatternComplexity(pattern string) int {
-    // Implement pattern complexity calculation
-    return len(strings.Split(pattern, " "))
-}
-
 func calculateConditionComplexity(condition string) int {
     // Count operators and function calls
-    operators := len(regexp.MustCompile(`(and|or|not)`).FindAllString(condition, -1))
+    operators := len(regexp.MustCompile(`\b(and|or|not)\b`).FindAllString(condition, -1))
     functions := len(regexp.MustCompile(`\w+\(`).FindAllString(condition, -1))
     return operators + functions
 }
-
-func hasValidBooleanOperators(condition string) bool {
-    validOperators := regexp.MustCompile(`\b(and|or|not)\b`)
-    return validOperators.MatchString(condition)
-}
-
-func extractMetaValue(metaSection, field string) string {
-    re := regexp.MustCompile(field + `:\s*"([^"]+)"`)
-    if match := re.FindStringSubmatch(metaSection); len(match) > 1 {
-        return match[1]
-    }
-    return ""
-}
-
-func extractStringDefinitions(stringsSection string) []string {
-    return strings.Split(stringsSection, "\n")
-}
-
-func extractStringIdentifier(stringDef string) string {
-    if match := regexp.MustCompile(`^\s*(\$\w+)\s*=`).FindStringSubmatch(stringDef); len(match) > 1 {
-        return match[1]
-    }
-    return ""
-}
\ No newline at end of file
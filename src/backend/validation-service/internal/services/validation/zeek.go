@@ -0,0 +1,925 @@
+// Package validation provides format-specific validation implementations
+package validation
+
+import (
+    "fmt"
+    "net"
+    "regexp"
+    "strconv"
+    "strings"
+
+    "validation-service/internal/models"
+    "validation-service/pkg/utils"
+)
+
+// Kinds of Zeek content
+const (
+    zeekKindSignatures = "signatures"
+    zeekKindScript     = "script"
+)
+
+// zeekTokenKind classifies the tokens of a signature file
+type zeekTokenKind int
+
+const (
+    zeekWord zeekTokenKind = iota
+    zeekString
+    zeekRegex
+    zeekOperator
+    zeekComma
+    zeekOpenBrace
+    zeekCloseBrace
+)
+
+// zeekToken is a token of a signature file with the line it is on
+type zeekToken struct {
+    kind zeekTokenKind
+    text string
+    line int
+}
+
+// zeekSignature is a parsed signature with the line it starts on
+type zeekSignature struct {
+    id         string
+    line       int
+    conditions []string
+    // constrained is set by conditions restricting the traffic a content
+    // condition is matched against
+    constrained bool
+    content     bool
+    events      int
+    enables     int
+    requires    []zeekToken
+}
+
+// zeekArgument is the shape of the arguments of a signature keyword
+type zeekArgument int
+
+const (
+    zeekArgCompare zeekArgument = iota
+    zeekArgHeader
+    zeekArgRegex
+    zeekArgString
+    zeekArgMIME
+    zeekArgSignature
+    zeekArgState
+    zeekArgIdentifier
+    zeekArgNone
+)
+
+// zeekKeyword describes a signature condition or action
+type zeekKeyword struct {
+    args   zeekArgument
+    action bool
+    // constrains is set for conditions restricting the traffic a signature
+    // is matched against
+    constrains bool
+    // values checks each value of a comparison or state list
+    values func(value string) bool
+}
+
+var (
+    // zeekKeywords are the conditions and actions of the signature language
+    zeekKeywords = map[string]zeekKeyword{
+        "header":                     {args: zeekArgHeader, constrains: true, values: zeekHeaderValue},
+        "src-ip":                     {args: zeekArgCompare, values: zeekAddress},
+        "dst-ip":                     {args: zeekArgCompare, values: zeekAddress},
+        "src-port":                   {args: zeekArgCompare, constrains: true, values: zeekPort},
+        "dst-port":                   {args: zeekArgCompare, constrains: true, values: zeekPort},
+        "ip-proto":                   {args: zeekArgCompare, constrains: true, values: zeekProtocol},
+        "payload-size":               {args: zeekArgCompare, values: zeekInteger},
+        "payload":                    {args: zeekArgRegex},
+        "http-request":               {args: zeekArgRegex, constrains: true},
+        "http-request-header":        {args: zeekArgRegex, constrains: true},
+        "http-request-body":          {args: zeekArgRegex, constrains: true},
+        "http-reply-header":          {args: zeekArgRegex, constrains: true},
+        "http-reply-body":            {args: zeekArgRegex, constrains: true},
+        "ftp":                        {args: zeekArgRegex, constrains: true},
+        "finger":                     {args: zeekArgRegex, constrains: true},
+        "file-magic":                 {args: zeekArgRegex, constrains: true},
+        "file-mime":                  {args: zeekArgMIME, constrains: true},
+        "requires-signature":         {args: zeekArgSignature},
+        "requires-reverse-signature": {args: zeekArgSignature},
+        "tcp-state":                  {args: zeekArgState, constrains: true, values: zeekTCPState},
+        "udp-state":                  {args: zeekArgState, constrains: true, values: zeekUDPState},
+        "same-ip":                    {args: zeekArgNone},
+        "eval":                       {args: zeekArgIdentifier},
+        "event":                      {args: zeekArgString, action: true},
+        "enable":                     {args: zeekArgString, action: true},
+    }
+
+    // zeekContentKeywords are the conditions matching a regular expression
+    // against content; a / after them opens a pattern
+    zeekContentKeywords = toSet([]string{
+        "payload", "http-request", "http-request-header", "http-request-body",
+        "http-reply-header", "http-reply-body", "ftp", "finger", "file-magic",
+    })
+
+    zeekComparisons = toSet([]string{"==", "!=", "<", "<=", ">", ">="})
+    zeekProtocols   = toSet([]string{"ip", "ip6", "tcp", "udp", "icmp", "icmp6"})
+    zeekTCPStates   = toSet([]string{"established", "originator", "responder"})
+    zeekUDPStates   = toSet([]string{"originator", "responder"})
+)
+
+var (
+    // zeekSignatureStart matches the opening of a signature
+    zeekSignatureStart = regexp.MustCompile(`(?m)^\s*signature\s+\S+\s*(?:\{|$)`)
+    // zeekSignatureID matches a signature identifier
+    zeekSignatureID = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+    // zeekHeaderField matches the header field of a header condition, such
+    // as tcp[13:1]
+    zeekHeaderField = regexp.MustCompile(`^(?:ip|ip6|tcp|udp|icmp|icmp6)\[\d+(?::[124])?\]$`)
+    // zeekScriptIdentifier matches a script-level identifier, which may stand
+    // for a value list
+    zeekScriptIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(?:::[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+    zeekModule          = regexp.MustCompile(`(?m)^\s*module\s+([A-Za-z_][A-Za-z0-9_]*)\s*;`)
+    zeekNoticeTypes     = regexp.MustCompile(`redef\s+enum\s+Notice::Type\s*\+=\s*\{([^}]*)\}`)
+    zeekNoticeCall      = regexp.MustCompile(`\bNOTICE\s*\(`)
+    zeekEventHandler    = regexp.MustCompile(`\b(?:event|hook)\s+([A-Za-z_][A-Za-z0-9_:]*)\s*\(`)
+    zeekNoteField       = regexp.MustCompile(`\$note\s*=\s*([A-Za-z_][A-Za-z0-9_:]*)`)
+    zeekMsgField        = regexp.MustCompile(`\$msg\s*=`)
+    zeekIdentifierField = regexp.MustCompile(`\$identifier\s*=`)
+)
+
+// ValidateZeekDetection validates a Zeek signature file or a Zeek script.
+// Signature files are checked for the syntax of their conditions and
+// actions; scripts for their structure and the notices they raise.
+func ValidateZeekDetection(detection *models.Detection) (*models.ValidationResult, error) {
+    result, err := models.NewValidationResult(detection)
+    if err != nil {
+        return nil, utils.WrapError(err, "failed to create validation result")
+    }
+    content, err := detection.GetContent()
+    if err != nil {
+        return nil, utils.WrapError(err, "failed to get detection content")
+    }
+    if format, _ := detection.GetFormat(); format != models.DetectionFormatZeek {
+        return nil, utils.ErrInvalidFormat
+    }
+    content = utils.SanitizeInput(content)
+
+    if zeekSignatureStart.MatchString(content) {
+        validateZeekSignatures(content, result)
+    } else {
+        validateZeekScript(content, result)
+    }
+    return result, nil
+}
+
+// validateZeekSignatures validates every signature of a signature file:
+// its conditions and actions, and signature references across the file
+func validateZeekSignatures(content string, result *models.ValidationResult) {
+    signatures, issues := parseZeekSignatures(content)
+    for i := range issues {
+        result.AddIssue(&issues[i])
+    }
+
+    ids := make(map[string]int, len(signatures))
+    events := 0
+    for _, sig := range signatures {
+        if first, dup := ids[sig.id]; dup {
+            result.AddIssue(&models.ValidationIssue{
+                Message:     fmt.Sprintf("Duplicate signature %s (first defined on line %d)", sig.id, first),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    "signature",
+                Line:        sig.line,
+                IssueCode:   "ZEEK002",
+                Remediation: "Give every signature a unique identifier",
+            })
+        } else {
+            ids[sig.id] = sig.line
+        }
+        events += sig.events
+
+        if len(sig.conditions) == 0 {
+            result.AddIssue(&models.ValidationIssue{
+                Message:     fmt.Sprintf("Signature %s has no conditions and matches nothing", sig.id),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    "signature." + sig.id,
+                Line:        sig.line,
+                IssueCode:   "ZEEK006",
+                Remediation: "Add a condition such as: payload /pattern/",
+            })
+        }
+        // Signatures that only enable an analyzer raise no event by design
+        if sig.events == 0 && sig.enables == 0 {
+            result.AddIssue(&models.ValidationIssue{
+                Message:     fmt.Sprintf("Signature %s has no event action, so matches do not raise signature_match", sig.id),
+                Severity:    models.ValidationSeverityMedium,
+                Location:    "signature." + sig.id,
+                Line:        sig.line,
+                IssueCode:   "ZEEK007",
+                Remediation: "Add an action such as: event \"description of the match\"",
+            })
+        }
+        if sig.content && !sig.constrained {
+            result.AddIssue(&models.ValidationIssue{
+                Message:     fmt.Sprintf("Signature %s matches content without a protocol, port or state condition", sig.id),
+                Severity:    models.ValidationSeverityLow,
+                Location:    "signature." + sig.id,
+                Line:        sig.line,
+                IssueCode:   "ZEEK009",
+                Remediation: "Restrict the signature with ip-proto, dst-port or tcp-state so it is not matched against all traffic",
+            })
+        }
+    }
+
+    // Required signatures are resolved within the loaded signature files, so
+    // one missing here must come from another file
+    for _, sig := range signatures {
+        for _, ref := range sig.requires {
+            if _, ok := ids[ref.text]; ok {
+                continue
+            }
+            result.AddIssue(&models.ValidationIssue{
+                Message:     fmt.Sprintf("Signature %s requires signature %s, which is not defined in this file", sig.id, ref.text),
+                Severity:    models.ValidationSeverityMedium,
+                Location:    "signature." + sig.id + ".requires-signature",
+                Line:        ref.line,
+                IssueCode:   "ZEEK008",
+                Remediation: fmt.Sprintf("Define signature %s or make sure the file defining it is loaded with this one", ref.text),
+            })
+        }
+    }
+
+    idList := make([]string, 0, len(signatures))
+    for _, sig := range signatures {
+        idList = append(idList, sig.id)
+    }
+    result.FormatSpecificDetails["kind"] = zeekKindSignatures
+    result.FormatSpecificDetails["signature_count"] = len(signatures)
+    result.FormatSpecificDetails["signatures"] = idList
+    result.FormatSpecificDetails["event_count"] = events
+}
+
+// parseZeekSignatures parses the signatures of a signature file. Issues are
+// returned for syntax errors and for invalid conditions and actions; a
+// signature is returned whenever its identifier could be read.
+func parseZeekSignatures(content string) ([]*zeekSignature, []models.ValidationIssue) {
+    tokens, issues := tokenizeZeekSignatures(content)
+    var signatures []*zeekSignature
+
+    for i := 0; i < len(tokens); {
+        tok := tokens[i]
+        if tok.kind != zeekWord || tok.text != "signature" {
+            issues = append(issues, models.ValidationIssue{
+                Message:     fmt.Sprintf("Unexpected %q outside a signature", tok.text),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    "signature",
+                Line:        tok.line,
+                IssueCode:   "ZEEK001",
+                Remediation: "Start every signature with: signature <id> {",
+            })
+            i = zeekNextSignature(tokens, i+1)
+            continue
+        }
+        i++
+
+        if i >= len(tokens) || tokens[i].kind != zeekWord || !zeekSignatureID.MatchString(tokens[i].text) {
+            issues = append(issues, models.ValidationIssue{
+                Message:     "Signature has no valid identifier",
+                Severity:    models.ValidationSeverityHigh,
+                Location:    "signature",
+                Line:        tok.line,
+                IssueCode:   "ZEEK001",
+                Remediation: "Name the signature with letters, digits, dashes and underscores: signature my-sig {",
+            })
+            i = zeekNextSignature(tokens, i)
+            continue
+        }
+        sig := &zeekSignature{id: tokens[i].text, line: tok.line}
+        signatures = append(signatures, sig)
+        i++
+
+        if i >= len(tokens) || tokens[i].kind != zeekOpenBrace {
+            issues = append(issues, models.ValidationIssue{
+                Message:     fmt.Sprintf("Signature %s is missing its opening brace", sig.id),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    "signature." + sig.id,
+                Line:        sig.line,
+                IssueCode:   "ZEEK001",
+                Remediation: fmt.Sprintf("Open the signature body: signature %s {", sig.id),
+            })
+            i = zeekNextSignature(tokens, i)
+            continue
+        }
+        i++
+
+        var bodyIssues []models.ValidationIssue
+        i, bodyIssues = parseZeekSignatureBody(tokens, i, sig)
+        issues = append(issues, bodyIssues...)
+    }
+    return signatures, issues
+}
+
+// parseZeekSignatureBody parses the conditions and actions of sig from
+// tokens[i], returning the index after its closing brace
+func parseZeekSignatureBody(tokens []zeekToken, i int, sig *zeekSignature) (int, []models.ValidationIssue) {
+    var issues []models.ValidationIssue
+    for {
+        if i >= len(tokens) || (tokens[i].kind == zeekWord && tokens[i].text == "signature") {
+            issues = append(issues, models.ValidationIssue{
+                Message:     fmt.Sprintf("Signature %s is not closed", sig.id),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    "signature." + sig.id,
+                Line:        sig.line,
+                IssueCode:   "ZEEK001",
+                Remediation: "Close the signature body with }",
+            })
+            return i, issues
+        }
+        tok := tokens[i]
+        if tok.kind == zeekCloseBrace {
+            return i + 1, issues
+        }
+
+        // The arguments of a keyword run up to the next keyword, or to the
+        // end of the line unless it ends in a comma or an operator
+        end := i + 1
+        for end < len(tokens) && tokens[end].kind != zeekCloseBrace && !zeekIsKeyword(tokens[end]) {
+            prev := tokens[end-1]
+            if end > i+1 && tokens[end].line != prev.line && prev.kind != zeekComma && prev.kind != zeekOperator {
+                break
+            }
+            end++
+        }
+        args := tokens[i+1 : end]
+        i = end
+
+        if tok.kind != zeekWord {
+            issues = append(issues, models.ValidationIssue{
+                Message:     fmt.Sprintf("Unexpected %q in signature %s", tok.text, sig.id),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    "signature." + sig.id,
+                Line:        tok.line,
+                IssueCode:   "ZEEK001",
+                Remediation: "Start every condition and action with its keyword",
+            })
+            continue
+        }
+        keyword, ok := zeekKeywords[tok.text]
+        if !ok {
+            issues = append(issues, models.ValidationIssue{
+                Message:     fmt.Sprintf("Unknown condition or action %q in signature %s", tok.text, sig.id),
+                Severity:    models.ValidationSeverityHigh,
+                Location:    "signature." + sig.id,
+                Line:        tok.line,
+                IssueCode:   "ZEEK003",
+                Remediation: "Use a signature keyword such as header, ip-proto, dst-port, payload, tcp-state, requires-signature or event",
+            })
+            continue
+        }
+
+        if keyword.action {
+            if tok.text == "event" {
+                sig.events++
+            } else {
+                sig.enables++
+            }
+        } else {
+            sig.conditions = append(sig.conditions, tok.text)
+            sig.constrained = sig.constrained || keyword.constrains
+            sig.content = sig.content || zeekContentKeywords[tok.text]
+        }
+        if issue := validateZeekArguments(sig, tok, keyword, args); issue != nil {
+            issues = append(issues, *issue)
+        }
+    }
+}
+
+// validateZeekArguments checks the arguments of a condition or action,
+// recording the signatures it requires on sig
+func validateZeekArguments(sig *zeekSignature, tok zeekToken, keyword zeekKeyword, args []zeekToken) *models.ValidationIssue {
+    location := "signature." + sig.id + "." + tok.text
+    invalid := func(code, format string, a ...interface{}) *models.ValidationIssue {
+        issue := &models.ValidationIssue{
+            Message:   fmt.Sprintf("Signature %s: ", sig.id) + fmt.Sprintf(format, a...),
+            Severity:  models.ValidationSeverityHigh,
+            Location:  location,
+            Line:      tok.line,
+            IssueCode: code,
+        }
+        switch code {
+        case "ZEEK005":
+            issue.Remediation = fmt.Sprintf("Match content with a regular expression: %s /pattern/", tok.text)
+        case "ZEEK010":
+            issue.Remediation = fmt.Sprintf("Quote the argument: %s \"...\"", tok.text)
+        default:
+            issue.Remediation = fmt.Sprintf("See the Zeek signature documentation for the syntax of %s", tok.text)
+        }
+        return issue
+    }
+
+    switch keyword.args {
+    case zeekArgNone:
+        if len(args) > 0 {
+            return invalid("ZEEK004", "%s takes no arguments", tok.text)
+        }
+
+    case zeekArgRegex:
+        if len(args) != 1 || args[0].kind != zeekRegex {
+            return invalid("ZEEK005", "%s expects a single /regular expression/", tok.text)
+        }
+        if _, err := regexp.Compile(args[0].text); err != nil {
+            return invalid("ZEEK005", "invalid %s pattern: %v", tok.text, err)
+        }
+
+    case zeekArgString:
+        if len(args) != 1 || args[0].kind != zeekString {
+            return invalid("ZEEK010", "%s expects a single quoted string", tok.text)
+        }
+
+    case zeekArgMIME:
+        if len(args) == 0 || args[0].kind != zeekString {
+            return invalid("ZEEK010", "%s expects a quoted MIME type", tok.text)
+        }
+        if len(args) == 1 {
+            break
+        }
+        if len(args) != 3 || args[1].kind != zeekComma || !zeekInteger(args[2].text) {
+            return invalid("ZEEK004", "%s expects a MIME type and an optional integer strength", tok.text)
+        }
+
+    case zeekArgIdentifier:
+        if len(args) != 1 || args[0].kind != zeekWord || !zeekScriptIdentifier.MatchString(args[0].text) {
+            return invalid("ZEEK004", "%s expects the name of a script function", tok.text)
+        }
+
+    case zeekArgSignature:
+        ref := args
+        if len(ref) > 0 && ref[0].kind == zeekOperator && ref[0].text == "!" {
+            ref = ref[1:]
+        }
+        if len(ref) != 1 || ref[0].kind != zeekWord || !zeekSignatureID.MatchString(ref[0].text) {
+            return invalid("ZEEK004", "%s expects a signature identifier, optionally negated with !", tok.text)
+        }
+        sig.requires = append(sig.requires, ref[0])
+
+    case zeekArgState:
+        values, ok := zeekValueList(args)
+        if !ok {
+            return invalid("ZEEK004", "%s expects a comma-separated list of states", tok.text)
+        }
+        for _, value := range values {
+            if !keyword.values(value) {
+                return invalid("ZEEK004", "invalid %s %q", tok.text, value)
+            }
+        }
+
+    case zeekArgHeader, zeekArgCompare:
+        if keyword.args == zeekArgHeader {
+            if len(args) == 0 || args[0].kind != zeekWord || !zeekHeaderField.MatchString(args[0].text) {
+                return invalid("ZEEK004", "header expects a field such as tcp[13:1]")
+            }
+            args = args[1:]
+            if len(args) > 1 && args[0].kind == zeekOperator && args[0].text == "&" {
+                if !zeekInteger(args[1].text) {
+                    return invalid("ZEEK004", "invalid header mask %q", args[1].text)
+                }
+                args = args[2:]
+            }
+        }
+        if len(args) == 0 || args[0].kind != zeekOperator || !zeekComparisons[args[0].text] {
+            return invalid("ZEEK004", "%s expects a comparison operator (==, !=, <, <=, >, >=)", tok.text)
+        }
+        values, ok := zeekValueList(args[1:])
+        if !ok {
+            return invalid("ZEEK004", "%s expects a comma-separated list of values", tok.text)
+        }
+        for _, value := range values {
+            if !keyword.values(value) {
+                return invalid("ZEEK004", "invalid %s value %q", tok.text, value)
+            }
+        }
+    }
+    return nil
+}
+
+// zeekValueList reads a non-empty comma-separated list of words
+func zeekValueList(args []zeekToken) ([]string, bool) {
+    if len(args) == 0 || len(args)%2 == 0 {
+        return nil, false
+    }
+    values := make([]string, 0, len(args)/2+1)
+    for i, arg := range args {
+        if i%2 == 1 {
+            if arg.kind != zeekComma {
+                return nil, false
+            }
+            continue
+        }
+        if arg.kind != zeekWord {
+            return nil, false
+        }
+        values = append(values, arg.text)
+    }
+    return values, true
+}
+
+// zeekIsKeyword reports whether tok starts a condition, an action or the
+// next signature
+func zeekIsKeyword(tok zeekToken) bool {
+    if tok.kind != zeekWord {
+        return false
+    }
+    _, ok := zeekKeywords[tok.text]
+    return ok || tok.text == "signature"
+}
+
+// zeekNextSignature returns the index of the next signature keyword from i
+func zeekNextSignature(tokens []zeekToken, i int) int {
+    for i < len(tokens) && (tokens[i].kind != zeekWord || tokens[i].text != "signature") {
+        i++
+    }
+    return i
+}
+
+// tokenizeZeekSignatures splits a signature file into tokens, dropping
+// comments. A / opens a regular expression after a content condition and
+// is part of a word, such as a CIDR, anywhere else.
+func tokenizeZeekSignatures(content string) ([]zeekToken, []models.ValidationIssue) {
+    var tokens []zeekToken
+    var issues []models.ValidationIssue
+    line := 1
+    for i := 0; i < len(content); {
+        c := content[i]
+        switch {
+        case c == '\n':
+            line++
+            i++
+        case c == ' ' || c == '\t' || c == '\r':
+            i++
+        case c == '#':
+            for i < len(content) && content[i] != '\n' {
+                i++
+            }
+        case c == '{':
+            tokens = append(tokens, zeekToken{kind: zeekOpenBrace, text: "{", line: line})
+            i++
+        case c == '}':
+            tokens = append(tokens, zeekToken{kind: zeekCloseBrace, text: "}", line: line})
+            i++
+        case c == ',':
+            tokens = append(tokens, zeekToken{kind: zeekComma, text: ",", line: line})
+            i++
+        case c == '"' || (c == '/' && zeekRegexAllowed(tokens)):
+            kind := zeekString
+            if c == '/' {
+                kind = zeekRegex
+            }
+            end := zeekDelimited(content, i)
+            if end < 0 {
+                issue := models.ValidationIssue{
+                    Message:     "Unterminated quoted string",
+                    Severity:    models.ValidationSeverityHigh,
+                    Location:    "signature",
+                    Line:        line,
+                    IssueCode:   "ZEEK001",
+                    Remediation: "Close the string with \" on the same line",
+                }
+                if kind == zeekRegex {
+                    issue.Message = "Unterminated regular expression"
+                    issue.IssueCode = "ZEEK005"
+                    issue.Remediation = "Close the pattern with / on the same line"
+                }
+                issues = append(issues, issue)
+                for i < len(content) && content[i] != '\n' {
+                    i++
+                }
+                continue
+            }
+            tokens = append(tokens, zeekToken{kind: kind, text: content[i+1 : end], line: line})
+            i = end + 1
+            // Patterns may be matched case-insensitively with a trailing i
+            if kind == zeekRegex && i < len(content) && content[i] == 'i' && !zeekWordByte(content, i+1) {
+                tokens[len(tokens)-1].text = "(?i)" + tokens[len(tokens)-1].text
+                i++
+            }
+        case strings.IndexByte("=!<>&", c) >= 0:
+            op := string(c)
+            if i+1 < len(content) && content[i+1] == '=' && c != '&' {
+                op += "="
+            }
+            tokens = append(tokens, zeekToken{kind: zeekOperator, text: op, line: line})
+            i += len(op)
+        default:
+            start := i
+            for zeekWordByte(content, i) {
+                i++
+            }
+            tokens = append(tokens, zeekToken{kind: zeekWord, text: content[start:i], line: line})
+        }
+    }
+    return tokens, issues
+}
+
+// zeekRegexAllowed reports whether a / opens a regular expression: after a
+// content condition
+func zeekRegexAllowed(tokens []zeekToken) bool {
+    if len(tokens) == 0 {
+        return false
+    }
+    last := tokens[len(tokens)-1]
+    return last.kind == zeekWord && zeekContentKeywords[last.text]
+}
+
+// zeekDelimited returns the index of the unescaped delimiter closing the
+// string or pattern opened at start, or -1 when the line ends first
+func zeekDelimited(content string, start int) int {
+    delim := content[start]
+    for i := start + 1; i < len(content); i++ {
+        switch content[i] {
+        case '\\':
+            i++
+        case '\n':
+            return -1
+        case delim:
+            return i
+        }
+    }
+    return -1
+}
+
+// zeekWordByte reports whether content[i] continues a word
+func zeekWordByte(content string, i int) bool {
+    if i >= len(content) {
+        return false
+    }
+    return strings.IndexByte(" \t\r\n{}\",#=!<>&", content[i]) < 0
+}
+
+func zeekInteger(value string) bool {
+    _, err := strconv.ParseUint(value, 0, 32)
+    return err == nil
+}
+
+func zeekPort(value string) bool {
+    if port, err := strconv.ParseUint(value, 10, 16); err == nil {
+        return port <= 65535
+    }
+    return zeekScriptIdentifier.MatchString(value)
+}
+
+func zeekAddress(value string) bool {
+    value = strings.Replace(strings.Replace(value, "[", "", 1), "]", "", 1)
+    if net.ParseIP(value) != nil {
+        return true
+    }
+    if _, _, err := net.ParseCIDR(value); err == nil {
+        return true
+    }
+    return zeekScriptIdentifier.MatchString(value)
+}
+
+func zeekHeaderValue(value string) bool {
+    return zeekInteger(value) || zeekAddress(value)
+}
+
+func zeekProtocol(value string) bool {
+    if zeekProtocols[strings.ToLower(value)] {
+        return true
+    }
+    proto, err := strconv.ParseUint(value, 10, 8)
+    return err == nil && proto <= 255
+}
+
+func zeekTCPState(value string) bool {
+    return zeekTCPStates[value]
+}
+
+func zeekUDPState(value string) bool {
+    return zeekUDPStates[value]
+}
+
+// validateZeekScript validates the structure of a script and the notices
+// it declares and raises
+func validateZeekScript(content string, result *models.ValidationResult) {
+    code, issue := zeekScriptCode(content)
+    if issue != nil {
+        result.AddIssue(issue)
+    }
+    if strings.TrimSpace(code) == "" {
+        result.AddIssue(&models.ValidationIssue{
+            Message:     "No Zeek signatures or script statements found",
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "script",
+            IssueCode:   "ZEEK001",
+            Remediation: "Add a signature block or script statements such as an event handler",
+        })
+        return
+    }
+    if issue := zeekBalanced(code); issue != nil {
+        result.AddIssue(issue)
+    }
+
+    module := ""
+    if m := zeekModule.FindStringSubmatch(code); m != nil {
+        module = m[1]
+    }
+
+    // Notice types declared by this script, with the line declaring them
+    declared := make(map[string]int)
+    var declarations [][]int
+    for _, m := range zeekNoticeTypes.FindAllStringSubmatchIndex(code, -1) {
+        declarations = append(declarations, m[:2])
+        offset := m[2]
+        for _, name := range strings.Split(code[m[2]:m[3]], ",") {
+            trimmed := strings.TrimSpace(name)
+            if trimmed != "" {
+                declared[trimmed] = lineAt(code, offset+strings.Index(name, trimmed))
+            }
+            offset += len(name) + 1
+        }
+    }
+
+    raised := make(map[string]bool)
+    notices := 0
+    for _, loc := range zeekNoticeCall.FindAllStringIndex(code, -1) {
+        notices++
+        line := lineAt(code, loc[0])
+        args := zeekCallArguments(code, loc[1])
+        // Notices built into a record variable cannot be checked here
+        if !strings.HasPrefix(strings.TrimSpace(args), "[") {
+            continue
+        }
+
+        add := func(severity, issueCode, message, remediation string) {
+            result.AddIssue(&models.ValidationIssue{
+                Message:     message,
+                Severity:    severity,
+                Location:    "NOTICE",
+                Line:        line,
+                IssueCode:   issueCode,
+                Remediation: remediation,
+            })
+        }
+
+        note := zeekNoteField.FindStringSubmatch(args)
+        switch {
+        case note == nil:
+            add(models.ValidationSeverityHigh, "ZEEK012", "NOTICE is raised without a $note type",
+                "Set the notice type: NOTICE([$note=MyNotice, $msg=\"...\"])")
+        default:
+            name := note[1]
+            if module != "" {
+                name = strings.TrimPrefix(name, module+"::")
+            }
+            // Types qualified with another module are declared by it
+            if strings.Contains(name, "::") {
+                break
+            }
+            raised[name] = true
+            if _, ok := declared[name]; !ok {
+                add(models.ValidationSeverityHigh, "ZEEK013",
+                    fmt.Sprintf("Notice type %s is not declared", note[1]),
+                    fmt.Sprintf("Declare it with: redef enum Notice::Type += { %s };", name))
+            }
+        }
+        if !zeekMsgField.MatchString(args) {
+            add(models.ValidationSeverityMedium, "ZEEK014", "NOTICE is raised without a $msg",
+                "Describe the notice with $msg so analysts can triage it")
+        }
+        if !zeekIdentifierField.MatchString(args) {
+            add(models.ValidationSeverityLow, "ZEEK015", "NOTICE is raised without an $identifier, so repeated notices are not suppressed",
+                "Set $identifier, for example to the connection endpoints, to suppress duplicates")
+        }
+    }
+
+    // A declared type referenced only by its declaration is never raised
+    // or acted on
+    rest := code
+    for i := len(declarations) - 1; i >= 0; i-- {
+        rest = rest[:declarations[i][0]] + rest[declarations[i][1]:]
+    }
+    for _, name := range sortedKeys(toSetKeys(declared)) {
+        if raised[name] || regexp.MustCompile(`\b`+regexp.QuoteMeta(name)+`\b`).MatchString(rest) {
+            continue
+        }
+        result.AddIssue(&models.ValidationIssue{
+            Message:     fmt.Sprintf("Notice type %s is declared but never raised", name),
+            Severity:    models.ValidationSeverityLow,
+            Location:    "Notice::Type",
+            Line:        declared[name],
+            IssueCode:   "ZEEK016",
+            Remediation: fmt.Sprintf("Raise %s with NOTICE or remove its declaration", name),
+        })
+    }
+
+    handlers := make(map[string]bool)
+    for _, m := range zeekEventHandler.FindAllStringSubmatch(code, -1) {
+        handlers[m[1]] = true
+    }
+    result.FormatSpecificDetails["kind"] = zeekKindScript
+    result.FormatSpecificDetails["module"] = module
+    result.FormatSpecificDetails["event_handlers"] = sortedKeys(handlers)
+    result.FormatSpecificDetails["notice_types"] = sortedKeys(toSetKeys(declared))
+    result.FormatSpecificDetails["notice_count"] = notices
+}
+
+// zeekScriptCode blanks the comments of a script and the contents of its
+// strings and pattern literals, keeping offsets and lines, so braces and
+// keywords inside them are not mistaken for code
+func zeekScriptCode(content string) (string, *models.ValidationIssue) {
+    code := []byte(content)
+    var issue *models.ValidationIssue
+    // last is the last code byte, used to tell a pattern from a division
+    last := byte(0)
+    for i := 0; i < len(code); i++ {
+        c := code[i]
+        switch {
+        case c == '#':
+            for ; i < len(code) && code[i] != '\n'; i++ {
+                code[i] = ' '
+            }
+        case c == '"' || (c == '/' && (last == 0 || strings.IndexByte("(,=!&|[{:;", last) >= 0)):
+            end := zeekDelimited(content, i)
+            if end < 0 {
+                if c == '/' {
+                    last = c
+                    continue
+                }
+                if issue == nil {
+                    issue = &models.ValidationIssue{
+                        Message:     "Unterminated string",
+                        Severity:    models.ValidationSeverityHigh,
+                        Location:    "script",
+                        Line:        lineAt(content, i),
+                        IssueCode:   "ZEEK011",
+                        Remediation: "Close the string with \" on the same line",
+                    }
+                }
+                end = strings.IndexByte(content[i:], '\n')
+                if end < 0 {
+                    end = len(content)
+                } else {
+                    end += i
+                }
+            }
+            for j := i + 1; j < end; j++ {
+                code[j] = ' '
+            }
+            i = end
+            last = c
+        case c != ' ' && c != '\t' && c != '\r' && c != '\n':
+            last = c
+        }
+    }
+    return string(code), issue
+}
+
+// zeekBalanced checks that the braces, parentheses and brackets of code are
+// balanced, reporting the first that is not
+func zeekBalanced(code string) *models.ValidationIssue {
+    pairs := map[byte]byte{'}': '{', ')': '(', ']': '['}
+    var stack []int
+    for i := 0; i < len(code); i++ {
+        c := code[i]
+        switch c {
+        case '{', '(', '[':
+            stack = append(stack, i)
+        case '}', ')', ']':
+            if len(stack) == 0 || code[stack[len(stack)-1]] != pairs[c] {
+                return &models.ValidationIssue{
+                    Message:     fmt.Sprintf("Unexpected %c", c),
+                    Severity:    models.ValidationSeverityHigh,
+                    Location:    "script",
+                    Line:        lineAt(code, i),
+                    IssueCode:   "ZEEK011",
+                    Remediation: "Balance the braces, parentheses and brackets of the script",
+                }
+            }
+            stack = stack[:len(stack)-1]
+        }
+    }
+    if len(stack) > 0 {
+        open := stack[len(stack)-1]
+        return &models.ValidationIssue{
+            Message:     fmt.Sprintf("Unclosed %c", code[open]),
+            Severity:    models.ValidationSeverityHigh,
+            Location:    "script",
+            Line:        lineAt(code, open),
+            IssueCode:   "ZEEK011",
+            Remediation: "Balance the braces, parentheses and brackets of the script",
+        }
+    }
+    return nil
+}
+
+// zeekCallArguments returns the arguments of the call whose parenthesis
+// opens just before start, up to the matching parenthesis or the end of code
+func zeekCallArguments(code string, start int) string {
+    depth := 1
+    for i := start; i < len(code); i++ {
+        switch code[i] {
+        case '(':
+            depth++
+        case ')':
+            depth--
+            if depth == 0 {
+                return code[start:i]
+            }
+        }
+    }
+    return code[start:]
+}
@@ -0,0 +1,274 @@
+// Package spool buffers request bodies that may exceed memory limits. Small
+// bodies stay in memory; larger ones are spooled to temporary files on disk,
+// bounded by per-upload, per-tenant and total disk quotas.
+package spool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.17.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+)
+
+// filePrefix names spool files so leftovers can be removed on startup
+const filePrefix = "spool-"
+
+// copyChunkSize is the unit in which disk quota is reserved while spooling
+const copyChunkSize = 256 * 1024
+
+var (
+	// ErrTooLarge is returned when a body exceeds the per-upload size limit
+	ErrTooLarge = errors.New("upload exceeds maximum size")
+	// ErrQuotaExceeded is returned when spooling would exceed a disk quota
+	ErrQuotaExceeded = errors.New("upload disk quota exceeded")
+)
+
+var (
+	diskUsage = promauto.NewGauge(prometheus.GaugeOpts{
+		Name:        "upload_spool_disk_bytes",
+		Help:        "Bytes currently spooled to disk by in-flight uploads",
+		ConstLabels: prometheus.Labels{"service": "validation"},
+	})
+	spooledUploads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:        "upload_spool_uploads_total",
+		Help:        "Uploads buffered by the spooler by storage medium",
+		ConstLabels: prometheus.Labels{"service": "validation"},
+	}, []string{"medium"})
+	rejectedUploads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:        "upload_spool_rejections_total",
+		Help:        "Uploads rejected by the spooler by reason",
+		ConstLabels: prometheus.Labels{"service": "validation"},
+	}, []string{"reason"})
+)
+
+// Config configures a Spooler
+type Config struct {
+	// Dir holds spool files; the system temporary directory when empty
+	Dir string
+	// MemoryThreshold is the largest body kept in memory
+	MemoryThreshold int64
+	// MaxSize is the largest accepted body
+	MaxSize int64
+	// TenantQuota bounds the disk space used by one tenant's in-flight uploads
+	TenantQuota int64
+	// TotalQuota bounds the disk space used by all in-flight uploads
+	TotalQuota int64
+}
+
+// Spooler buffers uploads in memory or on disk and tracks disk usage
+type Spooler struct {
+	cfg Config
+
+	mu    sync.Mutex
+	usage map[string]int64
+	total int64
+}
+
+// New creates a spooler, removing spool files left behind by a previous
+// process
+func New(cfg Config) (*Spooler, error) {
+	if cfg.Dir == "" {
+		cfg.Dir = filepath.Join(os.TempDir(), "validation-service-spool")
+	}
+	if cfg.MemoryThreshold <= 0 || cfg.MaxSize <= 0 || cfg.TenantQuota <= 0 || cfg.TotalQuota <= 0 {
+		return nil, fmt.Errorf("spool limits must be positive")
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating spool directory: %w", err)
+	}
+
+	leftovers, err := filepath.Glob(filepath.Join(cfg.Dir, filePrefix+"*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range leftovers {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("removing stale spool file: %w", err)
+		}
+	}
+
+	return &Spooler{cfg: cfg, usage: make(map[string]int64)}, nil
+}
+
+// MaxSize returns the largest accepted body
+func (s *Spooler) MaxSize() int64 {
+	return s.cfg.MaxSize
+}
+
+// Spool reads r to completion on behalf of tenantID. The returned File must
+// be closed to release its disk space.
+func (s *Spooler) Spool(ctx context.Context, tenantID string, r io.Reader) (*File, error) {
+	r = &contextReader{ctx: ctx, r: r}
+
+	// Read up to the memory threshold; one more byte tells whether to spill
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, r, s.cfg.MemoryThreshold+1)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if n > s.cfg.MaxSize {
+		rejectedUploads.WithLabelValues("size").Inc()
+		return nil, ErrTooLarge
+	}
+	if n <= s.cfg.MemoryThreshold {
+		spooledUploads.WithLabelValues("memory").Inc()
+		return &File{reader: bytes.NewReader(buf.Bytes()), size: n}, nil
+	}
+
+	f, err := os.CreateTemp(s.cfg.Dir, filePrefix+"*")
+	if err != nil {
+		return nil, fmt.Errorf("creating spool file: %w", err)
+	}
+	file := &File{spooler: s, tenantID: tenantID, file: f}
+
+	if err := file.fill(io.MultiReader(&buf, r)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	file.reader = io.NewSectionReader(f, 0, file.size)
+	spooledUploads.WithLabelValues("disk").Inc()
+	return file, nil
+}
+
+// reserve accounts n more bytes of disk usage to tenantID
+func (s *Spooler) reserve(tenantID string, n int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.usage[tenantID]+n > s.cfg.TenantQuota {
+		rejectedUploads.WithLabelValues("tenant_quota").Inc()
+		return ErrQuotaExceeded
+	}
+	if s.total+n > s.cfg.TotalQuota {
+		rejectedUploads.WithLabelValues("total_quota").Inc()
+		return ErrQuotaExceeded
+	}
+	s.usage[tenantID] += n
+	s.total += n
+	diskUsage.Add(float64(n))
+	return nil
+}
+
+// release returns n bytes of disk usage of tenantID
+func (s *Spooler) release(tenantID string, n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage[tenantID] -= n
+	if s.usage[tenantID] <= 0 {
+		delete(s.usage, tenantID)
+	}
+	s.total -= n
+	diskUsage.Sub(float64(n))
+}
+
+// File is a spooled upload. It is readable sequentially and at offsets, so
+// formats such as zip that need random access can be read from it.
+type File struct {
+	spooler  *Spooler
+	tenantID string
+	file     *os.File
+	reserved int64
+	size     int64
+
+	reader interface {
+		io.Reader
+		io.ReaderAt
+		io.Seeker
+	}
+	closeOnce sync.Once
+}
+
+// fill copies r into the spool file, reserving quota as it grows
+func (f *File) fill(r io.Reader) error {
+	chunk := make([]byte, copyChunkSize)
+	for {
+		n, err := r.Read(chunk)
+		if n > 0 {
+			if f.size+int64(n) > f.spooler.cfg.MaxSize {
+				rejectedUploads.WithLabelValues("size").Inc()
+				return ErrTooLarge
+			}
+			if err := f.spooler.reserve(f.tenantID, int64(n)); err != nil {
+				return err
+			}
+			f.reserved += int64(n)
+			if _, err := f.file.Write(chunk[:n]); err != nil {
+				return fmt.Errorf("writing spool file: %w", err)
+			}
+			f.size += int64(n)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Size returns the size of the upload
+func (f *File) Size() int64 {
+	return f.size
+}
+
+// OnDisk reports whether the upload was spooled to disk
+func (f *File) OnDisk() bool {
+	return f.file != nil
+}
+
+// Read implements io.Reader
+func (f *File) Read(p []byte) (int, error) {
+	return f.reader.Read(p)
+}
+
+// ReadAt implements io.ReaderAt
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	return f.reader.ReadAt(p, off)
+}
+
+// Seek implements io.Seeker
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	return f.reader.Seek(offset, whence)
+}
+
+// Close removes the spool file and releases its disk quota
+func (f *File) Close() error {
+	var err error
+	f.closeOnce.Do(func() {
+		if f.file == nil {
+			return
+		}
+		name := f.file.Name()
+		err = f.file.Close()
+		if removeErr := os.Remove(name); removeErr != nil && err == nil {
+			err = removeErr
+		}
+		f.spooler.release(f.tenantID, f.reserved)
+	})
+	return err
+}
+
+// contextReader stops reading once ctx is done, so abandoned uploads release
+// their disk space promptly
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read implements io.Reader
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,140 @@
+// Package storage defines persistence interfaces for detections and related
+// records used by the validation service.
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// blobDigestPrefix identifies the digest algorithm of a blob reference
+const blobDigestPrefix = "sha256:"
+
+// BlobRef identifies content-addressed rule content. Shared blobs are
+// referenced as "sha256:<hex>"; tenant-private blobs as "sha256:<hex>@<tenant>".
+type BlobRef string
+
+// NewBlobRef computes the reference of content stored in scope. An empty
+// scope denotes the shared, cross-tenant namespace.
+func NewBlobRef(content []byte, scope string) BlobRef {
+	sum := sha256.Sum256(content)
+	ref := blobDigestPrefix + hex.EncodeToString(sum[:])
+	if scope != "" {
+		ref += "@" + scope
+	}
+	return BlobRef(ref)
+}
+
+// Digest returns the content digest of the reference
+func (r BlobRef) Digest() string {
+	digest, _, _ := strings.Cut(string(r), "@")
+	return digest
+}
+
+// Scope returns the tenant scope of the reference, empty for shared blobs
+func (r BlobRef) Scope() string {
+	_, scope, _ := strings.Cut(string(r), "@")
+	return scope
+}
+
+// Validate checks the reference format
+func (r BlobRef) Validate() error {
+	digest := r.Digest()
+	if !strings.HasPrefix(digest, blobDigestPrefix) {
+		return fmt.Errorf("invalid blob reference %q", r)
+	}
+	if raw, err := hex.DecodeString(strings.TrimPrefix(digest, blobDigestPrefix)); err != nil || len(raw) != sha256.Size {
+		return fmt.Errorf("invalid blob digest %q", digest)
+	}
+	return nil
+}
+
+// BlobStats summarizes blob storage usage
+type BlobStats struct {
+	Blobs      int   `json:"blobs"`
+	Bytes      int64 `json:"bytes"`
+	References int64 `json:"references"`
+	// Unreferenced counts blobs awaiting garbage collection
+	Unreferenced int `json:"unreferenced"`
+}
+
+// BlobStore stores rule content once per scope and digest with reference
+// counting. Blobs whose reference count drops to zero are removed by
+// CollectGarbage once they have been unreferenced for the grace period, so a
+// concurrent Put of the same content can still revive them.
+type BlobStore interface {
+	// Put stores content in scope, or adds a reference to an existing blob
+	Put(ctx context.Context, content []byte, scope string) (BlobRef, error)
+	// Get returns the content of a blob
+	Get(ctx context.Context, ref BlobRef) ([]byte, error)
+	// Release drops one reference to a blob
+	Release(ctx context.Context, ref BlobRef) error
+	// CollectGarbage deletes blobs unreferenced for longer than grace and
+	// returns the number deleted
+	CollectGarbage(ctx context.Context, grace time.Duration) (int, error)
+	// Stats reports storage usage
+	Stats(ctx context.Context) (BlobStats, error)
+}
+
+// BlobCipher encrypts blob content at rest. Content is sealed with the
+// current data key of its scope and opened with the key it was sealed with.
+type BlobCipher interface {
+	Seal(ctx context.Context, scope string, plaintext []byte) (sealed []byte, keyID string, err error)
+	Open(ctx context.Context, scope, keyID string, sealed []byte) ([]byte, error)
+}
+
+// BlobReencrypter is implemented by blob stores that can progressively
+// re-encrypt content under the current data key of its scope. Blobs stored
+// before encryption was enabled have an empty key ID.
+type BlobReencrypter interface {
+	// BlobScopes lists the scopes holding blobs
+	BlobScopes(ctx context.Context) ([]string, error)
+	// StaleBlobs returns up to limit blobs of scope, ordered by reference and
+	// following after, that are not encrypted with keyID
+	StaleBlobs(ctx context.Context, scope, keyID string, after BlobRef, limit int) ([]BlobRef, error)
+	// ReencryptBlob re-encrypts a blob with the current key of its scope
+	ReencryptBlob(ctx context.Context, ref BlobRef) error
+}
+
+// SharingPolicy decides whether rule content may be stored in the shared
+// namespace, based on the license of the rule
+type SharingPolicy struct {
+	// SharedLicenses lists SPDX identifiers of licenses permitting content to
+	// be shared across tenants, e.g. "DRL-1.1" for the Sigma community rules
+	SharedLicenses []string
+}
+
+// Scope returns the blob scope for content of tenantID under license:
+// shared for permitted licenses, otherwise private to the tenant
+func (p SharingPolicy) Scope(tenantID, license string) string {
+	if license == "" {
+		return TenantScope(tenantID)
+	}
+	for _, shared := range p.SharedLicenses {
+		if strings.EqualFold(shared, license) {
+			return ""
+		}
+	}
+	return TenantScope(tenantID)
+}
+
+// tenantScopePrefix marks tenant-private blob scopes
+const tenantScopePrefix = "tenant:"
+
+// TenantScope encodes a tenant as a blob scope; the default tenant is
+// distinguished from the shared namespace
+func TenantScope(tenantID string) string {
+	return tenantScopePrefix + tenantID
+}
+
+// ScopeTenant decodes a blob scope, reporting shared for the shared namespace
+func ScopeTenant(scope string) (tenantID string, shared bool) {
+	if scope == "" {
+		return "", true
+	}
+	return strings.TrimPrefix(scope, tenantScopePrefix), false
+}
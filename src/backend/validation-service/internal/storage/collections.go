@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" // v1.4.0
+)
+
+// Collection limits
+const (
+	maxCollectionNameLength = 100
+	// MaxCollectionRules bounds the detections of a collection
+	MaxCollectionRules = 1000
+)
+
+// Collection is an ordered set of stored detections of a tenant that are
+// migrated together, with metadata shared by every rule
+type Collection struct {
+	ID          uuid.UUID `json:"id"`
+	TenantID    string    `json:"tenant_id,omitempty"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	// Metadata applies to every rule of the collection; a rule's own
+	// metadata takes precedence
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	// DetectionIDs are the detections of the collection in order
+	DetectionIDs []uuid.UUID `json:"detection_ids"`
+	CreatedBy    string      `json:"created_by,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+// Validate checks the name and detections of the collection
+func (c *Collection) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(c.Name) > maxCollectionNameLength {
+		return fmt.Errorf("name exceeds %d characters", maxCollectionNameLength)
+	}
+	if len(c.DetectionIDs) > MaxCollectionRules {
+		return fmt.Errorf("collection exceeds %d detections", MaxCollectionRules)
+	}
+	seen := make(map[uuid.UUID]bool, len(c.DetectionIDs))
+	for _, id := range c.DetectionIDs {
+		if id == uuid.Nil {
+			return errors.New("detection IDs must not be empty")
+		}
+		if seen[id] {
+			return fmt.Errorf("detection %s is listed more than once", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// CollectionStore persists the rule collections of tenants
+type CollectionStore interface {
+	// SaveCollection creates or replaces a collection. ErrAlreadyExists is
+	// returned when another collection of the tenant has the same name.
+	SaveCollection(ctx context.Context, collection *Collection) error
+	// GetCollection returns a collection of a tenant
+	GetCollection(ctx context.Context, tenantID string, id uuid.UUID) (*Collection, error)
+	// DeleteCollection removes a collection of a tenant; its detections
+	// are kept
+	DeleteCollection(ctx context.Context, tenantID string, id uuid.UUID) error
+	// ListCollections returns the collections of a tenant, oldest first
+	ListCollections(ctx context.Context, tenantID string) ([]*Collection, error)
+}
@@ -0,0 +1,151 @@
+// Package storage defines persistence interfaces for detections and related
+// records used by the validation service.
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid" // v1.4.0
+)
+
+// ContentAddressedDetectionStore wraps a DetectionStore so rule content is kept
+// in a BlobStore and the wrapped store only holds blob references. Identical
+// community rules imported by many tenants are stored once.
+type ContentAddressedDetectionStore struct {
+	DetectionStore
+	blobs  BlobStore
+	policy SharingPolicy
+}
+
+// NewContentAddressedDetectionStore creates a store keeping content in blobs
+func NewContentAddressedDetectionStore(store DetectionStore, blobs BlobStore, policy SharingPolicy) *ContentAddressedDetectionStore {
+	return &ContentAddressedDetectionStore{
+		DetectionStore: store,
+		blobs:          blobs,
+		policy:         policy,
+	}
+}
+
+// CreateDetection stores the content as a blob and the detection with a
+// reference to it. The caller's detection is left unchanged apart from
+// ContentRef and its version.
+func (s *ContentAddressedDetectionStore) CreateDetection(ctx context.Context, detection *StoredDetection) error {
+	if detection.Detection == nil {
+		return s.DetectionStore.CreateDetection(ctx, detection)
+	}
+	return s.store(ctx, detection, s.DetectionStore.CreateDetection)
+}
+
+// UpdateDetection stores the content of the new version as a blob. Earlier
+// versions keep their references, so their content stays available.
+func (s *ContentAddressedDetectionStore) UpdateDetection(ctx context.Context, detection *StoredDetection) error {
+	if detection.Detection == nil {
+		return s.DetectionStore.UpdateDetection(ctx, detection)
+	}
+	return s.store(ctx, detection, s.DetectionStore.UpdateDetection)
+}
+
+// store puts the content of detection in a blob and saves the detection
+// with a reference to it using save. The caller's detection is updated with
+// the reference and the version assigned by the wrapped store.
+func (s *ContentAddressedDetectionStore) store(ctx context.Context, detection *StoredDetection, save func(context.Context, *StoredDetection) error) error {
+	ref, err := s.blobs.Put(ctx, []byte(detection.Detection.Content), s.policy.Scope(detection.TenantID, detection.License))
+	if err != nil {
+		return fmt.Errorf("storing detection content: %w", err)
+	}
+
+	stripped := *detection
+	d := *detection.Detection
+	d.Content = ""
+	stripped.Detection = &d
+	stripped.ContentRef = ref
+
+	if err := save(ctx, &stripped); err != nil {
+		// Drop the reference taken above; the blob is collected if unused
+		if releaseErr := s.blobs.Release(ctx, ref); releaseErr != nil {
+			return fmt.Errorf("%w (releasing content: %v)", err, releaseErr)
+		}
+		return err
+	}
+	detection.ContentRef = ref
+	detection.Version = stripped.Version
+	detection.UpdatedAt = stripped.UpdatedAt
+	return nil
+}
+
+// GetDetection loads the detection and its content
+func (s *ContentAddressedDetectionStore) GetDetection(ctx context.Context, tenantID string, id uuid.UUID) (*StoredDetection, error) {
+	detection, err := s.DetectionStore.GetDetection(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.hydrate(ctx, detection); err != nil {
+		return nil, err
+	}
+	return detection, nil
+}
+
+// ListDetectionVersions loads every version of the detection and its
+// content
+func (s *ContentAddressedDetectionStore) ListDetectionVersions(ctx context.Context, tenantID string, id uuid.UUID) ([]*StoredDetection, error) {
+	versions, err := s.DetectionStore.ListDetectionVersions(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, version := range versions {
+		if err := s.hydrate(ctx, version); err != nil {
+			return nil, err
+		}
+	}
+	return versions, nil
+}
+
+// GetDetectionVersion loads one version of the detection and its content
+func (s *ContentAddressedDetectionStore) GetDetectionVersion(ctx context.Context, tenantID string, id uuid.UUID, version int) (*StoredDetection, error) {
+	detection, err := s.DetectionStore.GetDetectionVersion(ctx, tenantID, id, version)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.hydrate(ctx, detection); err != nil {
+		return nil, err
+	}
+	return detection, nil
+}
+
+// SearchDetections searches the wrapped store and loads content of the results
+func (s *ContentAddressedDetectionStore) SearchDetections(ctx context.Context, query DetectionQuery) ([]*StoredDetection, int, error) {
+	detections, total, err := s.DetectionStore.SearchDetections(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, detection := range detections {
+		if err := s.hydrate(ctx, detection); err != nil {
+			return nil, 0, err
+		}
+	}
+	return detections, total, nil
+}
+
+// ScanDetections scans the wrapped store, loading content for fn
+func (s *ContentAddressedDetectionStore) ScanDetections(ctx context.Context, fn func(*StoredDetection) error) error {
+	return s.DetectionStore.ScanDetections(ctx, func(detection *StoredDetection) error {
+		if err := s.hydrate(ctx, detection); err != nil {
+			return err
+		}
+		return fn(detection)
+	})
+}
+
+// hydrate replaces the content of a detection loaded from the wrapped store
+func (s *ContentAddressedDetectionStore) hydrate(ctx context.Context, detection *StoredDetection) error {
+	if detection.ContentRef == "" || detection.Detection == nil {
+		return nil
+	}
+	content, err := s.blobs.Get(ctx, detection.ContentRef)
+	if err != nil {
+		return fmt.Errorf("loading content of detection %s: %w", detection.ID(), err)
+	}
+	detection.Detection.Content = string(content)
+	return nil
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Field types of custom schema fields
+const (
+	FieldTypeString    = "string"
+	FieldTypeNumber    = "number"
+	FieldTypeBoolean   = "boolean"
+	FieldTypeIP        = "ip"
+	FieldTypeTimestamp = "timestamp"
+	FieldTypeObject    = "object"
+	FieldTypeArray     = "array"
+)
+
+// MaxSchemaFields bounds the number of fields of a custom schema
+const MaxSchemaFields = 2000
+
+var fieldTypes = map[string]bool{
+	FieldTypeString: true, FieldTypeNumber: true, FieldTypeBoolean: true, FieldTypeIP: true,
+	FieldTypeTimestamp: true, FieldTypeObject: true, FieldTypeArray: true,
+}
+
+// schemaNamePattern matches schema names: a table such as AcmeVPN_CL, a
+// Sigma logsource category or a product/service pair such as acme/vpn
+var schemaNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+(/[A-Za-z0-9_.-]+)?$`)
+
+// schemaFieldPattern matches field names, which may be dotted paths
+var schemaFieldPattern = regexp.MustCompile(`^[A-Za-z_@][A-Za-z0-9_@-]*(\.[A-Za-z_@][A-Za-z0-9_@-]*)*$`)
+
+// SchemaField is a field of a custom log source
+type SchemaField struct {
+	Name string `json:"name"`
+	// Type is one of the FieldType constants
+	Type string `json:"type"`
+}
+
+// CustomSchema describes a log source of a tenant that the built-in field
+// catalogs do not know, such as a custom table or an in-house Sigma
+// logsource. Its fields are merged with the built-in catalogs when the
+// tenant's detections are validated.
+type CustomSchema struct {
+	TenantID string `json:"tenant_id,omitempty"`
+	// Name is the table, Sigma logsource category or product/service pair
+	// the fields belong to
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Fields      []SchemaField `json:"fields"`
+	UpdatedBy   string        `json:"updated_by,omitempty"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// Validate checks the schema name and that its fields are named, typed and
+// listed once
+func (s *CustomSchema) Validate() error {
+	if !schemaNamePattern.MatchString(s.Name) {
+		return fmt.Errorf("invalid schema name %q: use letters, numbers, '_', '.' and '-', optionally as product/service", s.Name)
+	}
+	if len(s.Fields) == 0 {
+		return errors.New("fields are required")
+	}
+	if len(s.Fields) > MaxSchemaFields {
+		return fmt.Errorf("schema has %d fields, the maximum is %d", len(s.Fields), MaxSchemaFields)
+	}
+	seen := make(map[string]bool, len(s.Fields))
+	for _, field := range s.Fields {
+		if !schemaFieldPattern.MatchString(field.Name) {
+			return fmt.Errorf("invalid field name %q", field.Name)
+		}
+		if !fieldTypes[field.Type] {
+			return fmt.Errorf("field %s has unknown type %q: use string, number, boolean, ip, timestamp, object or array", field.Name, field.Type)
+		}
+		if seen[field.Name] {
+			return fmt.Errorf("field %s is listed more than once", field.Name)
+		}
+		seen[field.Name] = true
+	}
+	return nil
+}
+
+// CustomSchemaStore persists the custom log source schemas of each tenant
+type CustomSchemaStore interface {
+	// SaveCustomSchema creates or replaces the schema of a tenant with the
+	// same name
+	SaveCustomSchema(ctx context.Context, schema *CustomSchema) error
+	// GetCustomSchema returns a schema of a tenant, ErrNotFound when it has
+	// none of that name
+	GetCustomSchema(ctx context.Context, tenantID, name string) (*CustomSchema, error)
+	// ListCustomSchemas returns the schemas of a tenant ordered by name
+	ListCustomSchemas(ctx context.Context, tenantID string) ([]*CustomSchema, error)
+	// DeleteCustomSchema removes a schema of a tenant
+	DeleteCustomSchema(ctx context.Context, tenantID, name string) error
+}
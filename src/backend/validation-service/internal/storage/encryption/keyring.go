@@ -0,0 +1,239 @@
+// Package encryption encrypts stored rule content at rest with per-scope data
+// keys wrapped by the service master key, and rotates those keys.
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/storage"
+)
+
+const (
+	// keySize is the size of master and data keys (AES-256)
+	keySize = 32
+	// activeKeyTTL bounds how long a cached active key is used, so instances
+	// pick up keys rotated elsewhere
+	activeKeyTTL = time.Minute
+)
+
+// ErrInvalidMasterKey is returned for master keys of the wrong format or size
+var ErrInvalidMasterKey = errors.New("encryption key must be a base64-encoded 256-bit key")
+
+// ParseMasterKey decodes a base64-encoded 256-bit master key
+func ParseMasterKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != keySize {
+		return nil, ErrInvalidMasterKey
+	}
+	return key, nil
+}
+
+// Keyring seals and opens blob content with AES-256-GCM data keys. Each scope
+// has its own data keys, created on first use; data keys are stored wrapped
+// with the master key and cached unwrapped in memory. Keyring implements
+// storage.BlobCipher.
+type Keyring struct {
+	store  storage.KeyStore
+	master cipher.AEAD
+
+	mu     sync.RWMutex
+	aeads  map[string]cipher.AEAD
+	active map[string]cachedKey
+
+	// rotateMu serializes key creation within this instance
+	rotateMu sync.Mutex
+}
+
+// cachedKey is an active data key and when it was cached
+type cachedKey struct {
+	key      *storage.DataKey
+	cachedAt time.Time
+}
+
+// NewKeyring creates a keyring storing data keys in store
+func NewKeyring(store storage.KeyStore, masterKey []byte) (*Keyring, error) {
+	master, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("initializing master key: %w", err)
+	}
+	return &Keyring{
+		store:  store,
+		master: master,
+		aeads:  make(map[string]cipher.AEAD),
+		active: make(map[string]cachedKey),
+	}, nil
+}
+
+// Seal encrypts plaintext with the active data key of scope. The scope is
+// authenticated so content cannot be moved between scopes.
+func (k *Keyring) Seal(ctx context.Context, scope string, plaintext []byte) ([]byte, string, error) {
+	key, err := k.ActiveKey(ctx, scope)
+	if err != nil {
+		return nil, "", err
+	}
+	aead, err := k.aead(ctx, key.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, "", fmt.Errorf("generating nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, []byte(scope)), key.ID, nil
+}
+
+// Open decrypts content sealed with keyID
+func (k *Keyring) Open(ctx context.Context, scope, keyID string, sealed []byte) ([]byte, error) {
+	aead, err := k.aead(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed content too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, []byte(scope))
+}
+
+// ActiveKey returns the active data key of scope, creating the first version
+// if the scope has none
+func (k *Keyring) ActiveKey(ctx context.Context, scope string) (*storage.DataKey, error) {
+	k.mu.RLock()
+	cached, ok := k.active[scope]
+	k.mu.RUnlock()
+	if ok && time.Since(cached.cachedAt) < activeKeyTTL {
+		return cached.key, nil
+	}
+
+	key, err := k.store.ActiveKey(ctx, scope)
+	if errors.Is(err, storage.ErrNotFound) {
+		return k.createKey(ctx, scope, 1)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading active key of scope %q: %w", scope, err)
+	}
+	k.setActive(key)
+	return key, nil
+}
+
+// Rotate creates a new data key version for scope and makes it active. New
+// content is sealed with it immediately; existing content remains readable
+// with the previous keys until re-encrypted.
+func (k *Keyring) Rotate(ctx context.Context, scope string) (*storage.DataKey, error) {
+	current, err := k.store.ActiveKey(ctx, scope)
+	if errors.Is(err, storage.ErrNotFound) {
+		return k.createKey(ctx, scope, 1)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading active key of scope %q: %w", scope, err)
+	}
+	return k.createKey(ctx, scope, current.Version+1)
+}
+
+// createKey generates, wraps and stores a data key. When another instance
+// created the same version first, its key is used instead.
+func (k *Keyring) createKey(ctx context.Context, scope string, version int) (*storage.DataKey, error) {
+	k.rotateMu.Lock()
+	defer k.rotateMu.Unlock()
+
+	raw := make([]byte, keySize)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("generating data key: %w", err)
+	}
+	nonce := make([]byte, k.master.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+
+	key := &storage.DataKey{
+		ID:        uuid.NewString(),
+		Scope:     scope,
+		Version:   version,
+		CreatedAt: time.Now().UTC(),
+	}
+	key.Wrapped = k.master.Seal(nonce, nonce, raw, []byte(key.ID))
+
+	err := k.store.CreateKey(ctx, key)
+	if errors.Is(err, storage.ErrAlreadyExists) {
+		existing, loadErr := k.store.ActiveKey(ctx, scope)
+		if loadErr != nil {
+			return nil, fmt.Errorf("loading concurrently created key of scope %q: %w", scope, loadErr)
+		}
+		k.setActive(existing)
+		return existing, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storing data key of scope %q: %w", scope, err)
+	}
+
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, err
+	}
+	k.mu.Lock()
+	k.aeads[key.ID] = aead
+	k.mu.Unlock()
+	k.setActive(key)
+	return key, nil
+}
+
+// setActive caches key as the active key of its scope
+func (k *Keyring) setActive(key *storage.DataKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if current, ok := k.active[key.Scope]; ok && current.key.Version > key.Version {
+		return
+	}
+	k.active[key.Scope] = cachedKey{key: key, cachedAt: time.Now()}
+}
+
+// aead returns the unwrapped cipher of a data key
+func (k *Keyring) aead(ctx context.Context, keyID string) (cipher.AEAD, error) {
+	k.mu.RLock()
+	aead, ok := k.aeads[keyID]
+	k.mu.RUnlock()
+	if ok {
+		return aead, nil
+	}
+
+	key, err := k.store.GetKey(ctx, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("loading data key %s: %w", keyID, err)
+	}
+	if len(key.Wrapped) < k.master.NonceSize() {
+		return nil, fmt.Errorf("data key %s is malformed", keyID)
+	}
+	nonce, wrapped := key.Wrapped[:k.master.NonceSize()], key.Wrapped[k.master.NonceSize():]
+	raw, err := k.master.Open(nil, nonce, wrapped, []byte(key.ID))
+	if err != nil {
+		return nil, fmt.Errorf("unwrapping data key %s: %w", keyID, err)
+	}
+	if aead, err = newAEAD(raw); err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	k.aeads[keyID] = aead
+	k.mu.Unlock()
+	return aead, nil
+}
+
+// newAEAD creates an AES-GCM cipher for key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
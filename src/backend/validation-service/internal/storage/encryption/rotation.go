@@ -0,0 +1,229 @@
+package encryption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"validation-service/internal/storage"
+	"validation-service/pkg/logger"
+)
+
+const (
+	// reencryptBatchSize bounds the number of blobs listed per batch
+	reencryptBatchSize = 100
+	// maxReportedErrors bounds the failure messages kept per tenant
+	maxReportedErrors = 10
+	// maxReencryptPasses bounds the passes over a scope. Later passes pick up
+	// blobs written with the previous key by instances that had it cached.
+	maxReencryptPasses = 3
+)
+
+// ErrRotationRunning is returned when a rotation is requested while one is in progress
+var ErrRotationRunning = errors.New("key rotation already running")
+
+// TenantRotationStatus reports rotation progress for one tenant, or for the
+// shared namespace when Shared is set
+type TenantRotationStatus struct {
+	TenantID    string   `json:"tenant_id,omitempty"`
+	Shared      bool     `json:"shared,omitempty"`
+	KeyID       string   `json:"key_id,omitempty"`
+	KeyVersion  int      `json:"key_version,omitempty"`
+	Reencrypted int      `json:"reencrypted"`
+	Failed      int      `json:"failed"`
+	Done        bool     `json:"done"`
+	Errors      []string `json:"errors,omitempty"`
+}
+
+// RotationStatus reports the progress of the most recent rotation run
+type RotationStatus struct {
+	Running    bool                    `json:"running"`
+	StartedAt  *time.Time              `json:"started_at,omitempty"`
+	FinishedAt *time.Time              `json:"finished_at,omitempty"`
+	Tenants    []*TenantRotationStatus `json:"tenants"`
+	Error      string                  `json:"error,omitempty"`
+}
+
+// Rotator rotates data keys and re-encrypts existing blobs in the background.
+// Reads and writes continue during a rotation: new content is sealed with the
+// new key and existing content stays readable with the previous one.
+type Rotator struct {
+	keyring *Keyring
+	blobs   storage.BlobReencrypter
+	log     *logger.Logger
+
+	mu     sync.Mutex
+	status RotationStatus
+}
+
+// NewRotator creates a rotator for the blobs encrypted by keyring
+func NewRotator(keyring *Keyring, blobs storage.BlobReencrypter) *Rotator {
+	return &Rotator{
+		keyring: keyring,
+		blobs:   blobs,
+		log:     logger.GetLogger(),
+		status:  RotationStatus{Tenants: []*TenantRotationStatus{}},
+	}
+}
+
+// StartRotation rotates the data keys of the given tenants, and of the shared
+// namespace when includeShared is set, then re-encrypts their blobs. With no
+// tenants and includeShared unset every scope holding blobs is rotated.
+func (r *Rotator) StartRotation(tenantIDs []string, includeShared bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.status.Running {
+		return ErrRotationRunning
+	}
+	now := time.Now().UTC()
+	r.status = RotationStatus{Running: true, StartedAt: &now, Tenants: []*TenantRotationStatus{}}
+
+	var scopes []string
+	for _, tenantID := range tenantIDs {
+		scopes = append(scopes, storage.TenantScope(tenantID))
+	}
+	if includeShared {
+		scopes = append(scopes, "")
+	}
+
+	go func() {
+		err := r.rotate(context.Background(), scopes)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		finished := time.Now().UTC()
+		r.status.Running = false
+		r.status.FinishedAt = &finished
+		if err != nil {
+			r.status.Error = err.Error()
+			r.log.Error("Key rotation failed",
+				"error", err,
+			)
+			return
+		}
+		r.log.Info("Key rotation completed",
+			"scopes", len(r.status.Tenants),
+			"duration", finished.Sub(*r.status.StartedAt),
+		)
+	}()
+	return nil
+}
+
+// RotationStatus returns a snapshot of the current or last rotation run
+func (r *Rotator) RotationStatus() RotationStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := r.status
+	status.Tenants = make([]*TenantRotationStatus, len(r.status.Tenants))
+	for i, tenant := range r.status.Tenants {
+		copied := *tenant
+		copied.Errors = append([]string(nil), tenant.Errors...)
+		status.Tenants[i] = &copied
+	}
+	return status
+}
+
+// rotate rotates each scope in turn; an empty list rotates all scopes
+func (r *Rotator) rotate(ctx context.Context, scopes []string) error {
+	if len(scopes) == 0 {
+		var err error
+		if scopes, err = r.blobs.BlobScopes(ctx); err != nil {
+			return fmt.Errorf("listing blob scopes: %w", err)
+		}
+	}
+
+	for _, scope := range scopes {
+		progress := &TenantRotationStatus{}
+		progress.TenantID, progress.Shared = storage.ScopeTenant(scope)
+		r.mu.Lock()
+		r.status.Tenants = append(r.status.Tenants, progress)
+		r.mu.Unlock()
+
+		if err := r.rotateScope(ctx, scope, progress); err != nil {
+			r.update(func() {
+				progress.Failed++
+				progress.addError(err)
+			})
+			r.log.Error("Key rotation failed for scope",
+				"error", err,
+				"tenant_id", progress.TenantID,
+				"shared", progress.Shared,
+			)
+		}
+	}
+	return nil
+}
+
+// rotateScope creates a new key for scope and re-encrypts its blobs. Blobs
+// that fail are reported and skipped so one bad blob does not stall the run.
+func (r *Rotator) rotateScope(ctx context.Context, scope string, progress *TenantRotationStatus) error {
+	key, err := r.keyring.Rotate(ctx, scope)
+	if err != nil {
+		return err
+	}
+	r.update(func() {
+		progress.KeyID = key.ID
+		progress.KeyVersion = key.Version
+	})
+
+	failed := make(map[storage.BlobRef]bool)
+	for pass := 0; pass < maxReencryptPasses; pass++ {
+		reencrypted := 0
+		var after storage.BlobRef
+		for {
+			refs, err := r.blobs.StaleBlobs(ctx, scope, key.ID, after, reencryptBatchSize)
+			if err != nil {
+				return fmt.Errorf("listing stale blobs: %w", err)
+			}
+			if len(refs) == 0 {
+				break
+			}
+			after = refs[len(refs)-1]
+
+			for _, ref := range refs {
+				if failed[ref] {
+					continue
+				}
+				err := r.blobs.ReencryptBlob(ctx, ref)
+				if errors.Is(err, storage.ErrNotFound) {
+					// Collected since it was listed
+					continue
+				}
+				if err != nil {
+					failed[ref] = true
+					r.update(func() {
+						progress.Failed++
+						progress.addError(fmt.Errorf("%s: %w", ref.Digest(), err))
+					})
+					continue
+				}
+				reencrypted++
+				r.update(func() { progress.Reencrypted++ })
+			}
+		}
+		if reencrypted == 0 {
+			break
+		}
+	}
+
+	r.update(func() { progress.Done = true })
+	return nil
+}
+
+// update applies fn to the status under the status lock
+func (r *Rotator) update(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn()
+}
+
+// addError records a failure message, keeping the first few
+func (s *TenantRotationStatus) addError(err error) {
+	if len(s.Errors) < maxReportedErrors {
+		s.Errors = append(s.Errors, err.Error())
+	}
+}
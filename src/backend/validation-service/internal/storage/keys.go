@@ -0,0 +1,32 @@
+// Package storage defines persistence interfaces for detections and related
+// records used by the validation service.
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// DataKey is a content encryption key for one blob scope, stored wrapped
+// with the service master key. Each rotation adds a new version and makes it
+// the active key; earlier versions are kept to read existing content.
+type DataKey struct {
+	ID        string    `json:"id"`
+	Scope     string    `json:"scope"`
+	Version   int       `json:"version"`
+	Wrapped   []byte    `json:"-"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// KeyStore persists wrapped data keys
+type KeyStore interface {
+	// CreateKey stores key as the active key of its scope, deactivating the
+	// previous one. ErrAlreadyExists is returned when the scope already has a
+	// key with the same version.
+	CreateKey(ctx context.Context, key *DataKey) error
+	// GetKey returns a key by ID
+	GetKey(ctx context.Context, id string) (*DataKey, error)
+	// ActiveKey returns the active key of scope, or ErrNotFound
+	ActiveKey(ctx context.Context, scope string) (*DataKey, error)
+}
@@ -0,0 +1,215 @@
+// Package memory provides in-memory storage implementations suitable for
+// development, tests and single-instance deployments.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"validation-service/internal/storage"
+)
+
+// blob is stored content with its reference count
+type blob struct {
+	content []byte
+	// keyID is the data key content is encrypted with, empty for plaintext
+	keyID string
+	// size is the plaintext size of the content
+	size int64
+	refs int64
+	// releasedAt is when the reference count last dropped to zero
+	releasedAt time.Time
+}
+
+// BlobStore is an in-memory storage.BlobStore
+type BlobStore struct {
+	mu     sync.Mutex
+	blobs  map[storage.BlobRef]*blob
+	cipher storage.BlobCipher
+	now    func() time.Time
+}
+
+// NewBlobStore creates an empty in-memory blob store
+func NewBlobStore() *BlobStore {
+	return &BlobStore{
+		blobs: make(map[storage.BlobRef]*blob),
+		now:   time.Now,
+	}
+}
+
+// SetCipher enables encryption of new content at rest. Existing plaintext
+// content stays readable and is encrypted by ReencryptBlob.
+func (s *BlobStore) SetCipher(cipher storage.BlobCipher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cipher = cipher
+}
+
+// Put stores content or adds a reference to the existing blob
+func (s *BlobStore) Put(ctx context.Context, content []byte, scope string) (storage.BlobRef, error) {
+	ref := storage.NewBlobRef(content, scope)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.blobs[ref]; ok {
+		existing.refs++
+		return ref, nil
+	}
+	stored, keyID, err := s.seal(ctx, scope, content)
+	if err != nil {
+		return "", err
+	}
+	s.blobs[ref] = &blob{
+		content: stored,
+		keyID:   keyID,
+		size:    int64(len(content)),
+		refs:    1,
+	}
+	return ref, nil
+}
+
+// Get returns a copy of the blob content
+func (s *BlobStore) Get(ctx context.Context, ref storage.BlobRef) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.blobs[ref]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return s.open(ctx, ref.Scope(), stored)
+}
+
+// Release drops one reference to the blob
+func (s *BlobStore) Release(ctx context.Context, ref storage.BlobRef) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.blobs[ref]
+	if !ok || stored.refs == 0 {
+		return storage.ErrNotFound
+	}
+	stored.refs--
+	if stored.refs == 0 {
+		stored.releasedAt = s.now()
+	}
+	return nil
+}
+
+// CollectGarbage deletes blobs unreferenced for longer than grace
+func (s *BlobStore) CollectGarbage(ctx context.Context, grace time.Duration) (int, error) {
+	cutoff := s.now().Add(-grace)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := 0
+	for ref, stored := range s.blobs {
+		if stored.refs == 0 && !stored.releasedAt.After(cutoff) {
+			delete(s.blobs, ref)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Stats reports blob counts and sizes
+func (s *BlobStore) Stats(ctx context.Context) (storage.BlobStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var stats storage.BlobStats
+	for _, stored := range s.blobs {
+		stats.Blobs++
+		stats.Bytes += stored.size
+		stats.References += stored.refs
+		if stored.refs == 0 {
+			stats.Unreferenced++
+		}
+	}
+	return stats, nil
+}
+
+// BlobScopes lists the scopes holding blobs
+func (s *BlobStore) BlobScopes(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := make(map[string]bool)
+	scopes := []string{}
+	for ref := range s.blobs {
+		if scope := ref.Scope(); !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes, nil
+}
+
+// StaleBlobs returns blobs of scope not encrypted with keyID
+func (s *BlobStore) StaleBlobs(ctx context.Context, scope, keyID string, after storage.BlobRef, limit int) ([]storage.BlobRef, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var refs []storage.BlobRef
+	for ref, stored := range s.blobs {
+		if ref.Scope() == scope && ref > after && stored.keyID != keyID {
+			refs = append(refs, ref)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i] < refs[j] })
+	if len(refs) > limit {
+		refs = refs[:limit]
+	}
+	return refs, nil
+}
+
+// ReencryptBlob re-encrypts a blob with the current key of its scope
+func (s *BlobStore) ReencryptBlob(ctx context.Context, ref storage.BlobRef) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.blobs[ref]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	if s.cipher == nil {
+		return fmt.Errorf("blob encryption is not enabled")
+	}
+
+	content, err := s.open(ctx, ref.Scope(), stored)
+	if err != nil {
+		return err
+	}
+	sealed, keyID, err := s.seal(ctx, ref.Scope(), content)
+	if err != nil {
+		return err
+	}
+	stored.content = sealed
+	stored.keyID = keyID
+	return nil
+}
+
+// seal encrypts content when a cipher is configured
+func (s *BlobStore) seal(ctx context.Context, scope string, content []byte) ([]byte, string, error) {
+	if s.cipher == nil {
+		return append([]byte(nil), content...), "", nil
+	}
+	sealed, keyID, err := s.cipher.Seal(ctx, scope, content)
+	if err != nil {
+		return nil, "", fmt.Errorf("encrypting blob: %w", err)
+	}
+	return sealed, keyID, nil
+}
+
+// open returns a copy of the plaintext content of stored
+func (s *BlobStore) open(ctx context.Context, scope string, stored *blob) ([]byte, error) {
+	if stored.keyID == "" {
+		return append([]byte(nil), stored.content...), nil
+	}
+	if s.cipher == nil {
+		return nil, fmt.Errorf("blob is encrypted but encryption is not enabled")
+	}
+	content, err := s.cipher.Open(ctx, scope, stored.keyID, stored.content)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting blob: %w", err)
+	}
+	return content, nil
+}
@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/storage"
+)
+
+// CollectionStore is an in-memory storage.CollectionStore
+type CollectionStore struct {
+	mu          sync.Mutex
+	collections map[uuid.UUID]storage.Collection
+}
+
+// NewCollectionStore creates an empty in-memory collection store
+func NewCollectionStore() *CollectionStore {
+	return &CollectionStore{
+		collections: make(map[uuid.UUID]storage.Collection),
+	}
+}
+
+// SaveCollection stores a copy of the collection
+func (s *CollectionStore) SaveCollection(ctx context.Context, collection *storage.Collection) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.collections[collection.ID]
+	if ok && existing.TenantID != collection.TenantID {
+		return storage.ErrAlreadyExists
+	}
+	for id, other := range s.collections {
+		if id != collection.ID && other.TenantID == collection.TenantID && other.Name == collection.Name {
+			return storage.ErrAlreadyExists
+		}
+	}
+
+	stored := copyCollection(collection)
+	if ok {
+		stored.CreatedAt = existing.CreatedAt
+	}
+	s.collections[collection.ID] = stored
+	return nil
+}
+
+// GetCollection returns a copy of the stored collection
+func (s *CollectionStore) GetCollection(ctx context.Context, tenantID string, id uuid.UUID) (*storage.Collection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	collection, ok := s.collections[id]
+	if !ok || collection.TenantID != tenantID {
+		return nil, storage.ErrNotFound
+	}
+	stored := copyCollection(&collection)
+	return &stored, nil
+}
+
+// DeleteCollection removes the collection if it belongs to the tenant
+func (s *CollectionStore) DeleteCollection(ctx context.Context, tenantID string, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	collection, ok := s.collections[id]
+	if !ok || collection.TenantID != tenantID {
+		return storage.ErrNotFound
+	}
+	delete(s.collections, id)
+	return nil
+}
+
+// ListCollections returns the collections of the tenant, oldest first
+func (s *CollectionStore) ListCollections(ctx context.Context, tenantID string) ([]*storage.Collection, error) {
+	s.mu.Lock()
+	matches := make([]*storage.Collection, 0)
+	for _, collection := range s.collections {
+		if collection.TenantID == tenantID {
+			stored := copyCollection(&collection)
+			matches = append(matches, &stored)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.CreatedAt.Equal(b.CreatedAt) {
+			return a.ID.String() < b.ID.String()
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+	return matches, nil
+}
+
+// copyCollection copies the collection, its metadata and its detection IDs,
+// so callers cannot change the stored record. Metadata values are shared;
+// they are replaced as a whole and never changed in place.
+func copyCollection(collection *storage.Collection) storage.Collection {
+	stored := *collection
+	if collection.Metadata != nil {
+		stored.Metadata = make(map[string]interface{}, len(collection.Metadata))
+		for key, value := range collection.Metadata {
+			stored.Metadata[key] = value
+		}
+	}
+	stored.DetectionIDs = append([]uuid.UUID(nil), collection.DetectionIDs...)
+	return stored
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"validation-service/internal/storage"
+)
+
+// CustomSchemaStore is an in-memory storage.CustomSchemaStore
+type CustomSchemaStore struct {
+	mu sync.Mutex
+	// schemas are keyed by tenant ID and then by schema name
+	schemas map[string]map[string]storage.CustomSchema
+}
+
+// NewCustomSchemaStore creates an empty in-memory custom schema store
+func NewCustomSchemaStore() *CustomSchemaStore {
+	return &CustomSchemaStore{
+		schemas: make(map[string]map[string]storage.CustomSchema),
+	}
+}
+
+// SaveCustomSchema stores a copy of the schema
+func (s *CustomSchemaStore) SaveCustomSchema(ctx context.Context, schema *storage.CustomSchema) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tenantSchemas, ok := s.schemas[schema.TenantID]
+	if !ok {
+		tenantSchemas = make(map[string]storage.CustomSchema)
+		s.schemas[schema.TenantID] = tenantSchemas
+	}
+	stored := *schema
+	stored.Fields = append([]storage.SchemaField(nil), schema.Fields...)
+	tenantSchemas[schema.Name] = stored
+	return nil
+}
+
+// GetCustomSchema returns a copy of a schema of the tenant
+func (s *CustomSchemaStore) GetCustomSchema(ctx context.Context, tenantID, name string) (*storage.CustomSchema, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schema, ok := s.schemas[tenantID][name]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return &schema, nil
+}
+
+// ListCustomSchemas returns copies of the tenant's schemas ordered by name
+func (s *CustomSchemaStore) ListCustomSchemas(ctx context.Context, tenantID string) ([]*storage.CustomSchema, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schemas := make([]*storage.CustomSchema, 0, len(s.schemas[tenantID]))
+	for _, schema := range s.schemas[tenantID] {
+		schema := schema
+		schemas = append(schemas, &schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].Name < schemas[j].Name
+	})
+	return schemas, nil
+}
+
+// DeleteCustomSchema removes a schema of the tenant
+func (s *CustomSchemaStore) DeleteCustomSchema(ctx context.Context, tenantID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.schemas[tenantID][name]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(s.schemas[tenantID], name)
+	return nil
+}
@@ -0,0 +1,281 @@
+// Package memory provides in-memory storage implementations suitable for
+// development, tests and single-instance deployments.
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/storage"
+)
+
+// DetectionStore is an in-memory storage.DetectionStore
+type DetectionStore struct {
+	mu         sync.RWMutex
+	detections map[uuid.UUID]*storage.StoredDetection
+	// versions holds every version of each detection, oldest first
+	versions map[uuid.UUID][]*storage.StoredDetection
+}
+
+// NewDetectionStore creates an empty in-memory detection store
+func NewDetectionStore() *DetectionStore {
+	return &DetectionStore{
+		detections: make(map[uuid.UUID]*storage.StoredDetection),
+		versions:   make(map[uuid.UUID][]*storage.StoredDetection),
+	}
+}
+
+// CreateDetection stores a copy of detection
+func (s *DetectionStore) CreateDetection(ctx context.Context, detection *storage.StoredDetection) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := detection.ID()
+	if _, exists := s.detections[id]; exists {
+		return storage.ErrAlreadyExists
+	}
+	if detection.UpdatedAt.IsZero() {
+		detection.UpdatedAt = time.Now().UTC()
+	}
+	detection.Version = 1
+	s.detections[id] = cloneDetection(detection)
+	s.versions[id] = []*storage.StoredDetection{cloneDetection(detection)}
+	return nil
+}
+
+// UpdateDetection stores a copy of detection as the next version
+func (s *DetectionStore) UpdateDetection(ctx context.Context, detection *storage.StoredDetection) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := detection.ID()
+	current, ok := s.detections[id]
+	if !ok || current.TenantID != detection.TenantID {
+		return storage.ErrNotFound
+	}
+	if detection.Version != 0 && detection.Version != current.Version {
+		return storage.ErrVersionConflict
+	}
+	if detection.UpdatedAt.IsZero() {
+		detection.UpdatedAt = time.Now().UTC()
+	}
+	detection.Version = current.Version + 1
+	s.detections[id] = cloneDetection(detection)
+	s.versions[id] = append(s.versions[id], cloneDetection(detection))
+	return nil
+}
+
+// GetDetection returns a copy of the stored detection
+func (s *DetectionStore) GetDetection(ctx context.Context, tenantID string, id uuid.UUID) (*storage.StoredDetection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	detection, ok := s.detections[id]
+	if !ok || detection.TenantID != tenantID {
+		return nil, storage.ErrNotFound
+	}
+	return cloneDetection(detection), nil
+}
+
+// ListDetectionVersions returns copies of every version of the detection
+func (s *DetectionStore) ListDetectionVersions(ctx context.Context, tenantID string, id uuid.UUID) ([]*storage.StoredDetection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	detection, ok := s.detections[id]
+	if !ok || detection.TenantID != tenantID {
+		return nil, storage.ErrNotFound
+	}
+	versions := make([]*storage.StoredDetection, len(s.versions[id]))
+	for i, version := range s.versions[id] {
+		versions[i] = cloneDetection(version)
+	}
+	return versions, nil
+}
+
+// GetDetectionVersion returns a copy of one version of the detection
+func (s *DetectionStore) GetDetectionVersion(ctx context.Context, tenantID string, id uuid.UUID, version int) (*storage.StoredDetection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	detection, ok := s.detections[id]
+	if !ok || detection.TenantID != tenantID {
+		return nil, storage.ErrNotFound
+	}
+	versions := s.versions[id]
+	if version < 1 || version > len(versions) {
+		return nil, storage.ErrNotFound
+	}
+	return cloneDetection(versions[version-1]), nil
+}
+
+// UpdateTags applies op to every matching detection of the tenant
+func (s *DetectionStore) UpdateTags(ctx context.Context, op storage.TagOperation) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	updated := 0
+	for _, id := range op.DetectionIDs {
+		detection, ok := s.detections[id]
+		if !ok || detection.TenantID != op.TenantID {
+			continue
+		}
+		if detection.Tags == nil {
+			detection.Tags = make(map[string]string, len(op.Set))
+		}
+		for _, key := range op.Remove {
+			delete(detection.Tags, key)
+		}
+		for key, value := range op.Set {
+			detection.Tags[key] = value
+		}
+		detection.UpdatedAt = now
+		updated++
+	}
+	return updated, nil
+}
+
+// SearchDetections scans all detections of the tenant. Results are ordered by
+// most recently updated first.
+func (s *DetectionStore) SearchDetections(ctx context.Context, query storage.DetectionQuery) ([]*storage.StoredDetection, int, error) {
+	if err := query.Normalize(); err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.RLock()
+	matches := make([]*storage.StoredDetection, 0)
+	for _, detection := range s.detections {
+		if matchesQuery(detection, &query) {
+			matches = append(matches, cloneDetection(detection))
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].UpdatedAt.Equal(matches[j].UpdatedAt) {
+			return matches[i].ID().String() < matches[j].ID().String()
+		}
+		return matches[i].UpdatedAt.After(matches[j].UpdatedAt)
+	})
+
+	total := len(matches)
+	if query.Offset >= total {
+		return []*storage.StoredDetection{}, total, nil
+	}
+	end := query.Offset + query.Limit
+	if end > total {
+		end = total
+	}
+	return matches[query.Offset:end], total, nil
+}
+
+// ScanDetections calls fn with a copy of every stored detection. The store is
+// snapshotted first so fn may call back into the store.
+func (s *DetectionStore) ScanDetections(ctx context.Context, fn func(*storage.StoredDetection) error) error {
+	s.mu.RLock()
+	snapshot := make([]*storage.StoredDetection, 0, len(s.detections))
+	for _, detection := range s.detections {
+		snapshot = append(snapshot, cloneDetection(detection))
+	}
+	s.mu.RUnlock()
+
+	for _, detection := range snapshot {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(detection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchesQuery reports whether detection satisfies every criterion of query
+func matchesQuery(detection *storage.StoredDetection, query *storage.DetectionQuery) bool {
+	if detection.TenantID != query.TenantID {
+		return false
+	}
+	if query.Format != "" && (detection.Detection == nil || detection.Detection.Format != query.Format) {
+		return false
+	}
+	for key, value := range query.Tags {
+		tagValue, ok := detection.Tags[key]
+		if !ok || (value != "" && !strings.EqualFold(tagValue, value)) {
+			return false
+		}
+	}
+	if query.Technique != "" && !containsFold(detection.Techniques, query.Technique) {
+		return false
+	}
+	if query.MinConfidence != nil || query.MaxConfidence != nil {
+		if detection.ConfidenceScore == nil {
+			return false
+		}
+		score := *detection.ConfidenceScore
+		if query.MinConfidence != nil && score < *query.MinConfidence {
+			return false
+		}
+		if query.MaxConfidence != nil && score > *query.MaxConfidence {
+			return false
+		}
+	}
+	if query.Text != "" {
+		text := strings.ToLower(query.Text)
+		content := ""
+		if detection.Detection != nil {
+			content = detection.Detection.Content
+		}
+		if !strings.Contains(strings.ToLower(detection.Name), text) &&
+			!strings.Contains(strings.ToLower(detection.Description), text) &&
+			!strings.Contains(strings.ToLower(content), text) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold reports whether values contains target, ignoring case
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneDetection copies the mutable parts of a stored detection so callers
+// cannot modify store state
+func cloneDetection(detection *storage.StoredDetection) *storage.StoredDetection {
+	clone := *detection
+	if detection.Detection != nil {
+		d := *detection.Detection
+		clone.Detection = &d
+	}
+	clone.Tags = make(map[string]string, len(detection.Tags))
+	for key, value := range detection.Tags {
+		clone.Tags[key] = value
+	}
+	clone.Techniques = append([]string(nil), detection.Techniques...)
+	if detection.ConfidenceScore != nil {
+		score := *detection.ConfidenceScore
+		clone.ConfidenceScore = &score
+	}
+	if detection.Validation != nil {
+		validation := *detection.Validation
+		if detection.Validation.IssueCodes != nil {
+			validation.IssueCodes = make(map[string]int, len(detection.Validation.IssueCodes))
+			for code, count := range detection.Validation.IssueCodes {
+				validation.IssueCodes[code] = count
+			}
+		}
+		clone.Validation = &validation
+	}
+	return &clone
+}
@@ -0,0 +1,74 @@
+// Package memory provides in-memory storage implementations suitable for
+// development, tests and single-instance deployments.
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"validation-service/internal/storage"
+)
+
+// KeyStore is an in-memory storage.KeyStore
+type KeyStore struct {
+	mu     sync.RWMutex
+	keys   map[string]*storage.DataKey
+	active map[string]string
+}
+
+// NewKeyStore creates an empty in-memory key store
+func NewKeyStore() *KeyStore {
+	return &KeyStore{
+		keys:   make(map[string]*storage.DataKey),
+		active: make(map[string]string),
+	}
+}
+
+// CreateKey stores key as the active key of its scope
+func (s *KeyStore) CreateKey(ctx context.Context, key *storage.DataKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keys[key.ID]; ok {
+		return storage.ErrAlreadyExists
+	}
+	if currentID, ok := s.active[key.Scope]; ok {
+		current := s.keys[currentID]
+		if current.Version >= key.Version {
+			return storage.ErrAlreadyExists
+		}
+		current.Active = false
+	}
+
+	stored := *key
+	stored.Active = true
+	stored.Wrapped = append([]byte(nil), key.Wrapped...)
+	s.keys[key.ID] = &stored
+	s.active[key.Scope] = key.ID
+	key.Active = true
+	return nil
+}
+
+// GetKey returns a copy of the key
+func (s *KeyStore) GetKey(ctx context.Context, id string) (*storage.DataKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	copied := *key
+	return &copied, nil
+}
+
+// ActiveKey returns a copy of the active key of scope
+func (s *KeyStore) ActiveKey(ctx context.Context, scope string) (*storage.DataKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	id, ok := s.active[scope]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	copied := *s.keys[id]
+	return &copied, nil
+}
@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/storage"
+)
+
+// NotificationStore is an in-memory storage.NotificationStore
+type NotificationStore struct {
+	mu            sync.Mutex
+	subscriptions map[uuid.UUID]storage.Subscription
+	queued        map[uuid.UUID][]storage.NotificationEvent
+}
+
+// NewNotificationStore creates an empty in-memory notification store
+func NewNotificationStore() *NotificationStore {
+	return &NotificationStore{
+		subscriptions: make(map[uuid.UUID]storage.Subscription),
+		queued:        make(map[uuid.UUID][]storage.NotificationEvent),
+	}
+}
+
+// SaveSubscription stores a copy of the subscription
+func (s *NotificationStore) SaveSubscription(ctx context.Context, subscription *storage.Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.subscriptions[subscription.ID]; ok && existing.TenantID != subscription.TenantID {
+		return storage.ErrAlreadyExists
+	}
+	s.subscriptions[subscription.ID] = copySubscription(subscription)
+	return nil
+}
+
+// GetSubscription returns a copy of the stored subscription
+func (s *NotificationStore) GetSubscription(ctx context.Context, tenantID string, id uuid.UUID) (*storage.Subscription, error) {
+	s.mu.Lock()
+	subscription, ok := s.subscriptions[id]
+	s.mu.Unlock()
+
+	if !ok || subscription.TenantID != tenantID {
+		return nil, storage.ErrNotFound
+	}
+	return &subscription, nil
+}
+
+// DeleteSubscription removes the subscription if it belongs to the tenant
+func (s *NotificationStore) DeleteSubscription(ctx context.Context, tenantID string, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscription, ok := s.subscriptions[id]
+	if !ok || subscription.TenantID != tenantID {
+		return storage.ErrNotFound
+	}
+	delete(s.subscriptions, id)
+	delete(s.queued, id)
+	return nil
+}
+
+// ListSubscriptions returns the subscriptions of the user, oldest first
+func (s *NotificationStore) ListSubscriptions(ctx context.Context, tenantID, userID string) ([]*storage.Subscription, error) {
+	s.mu.Lock()
+	matches := make([]*storage.Subscription, 0)
+	for _, subscription := range s.subscriptions {
+		subscription := subscription
+		if subscription.TenantID == tenantID && (userID == "" || subscription.UserID == userID) {
+			matches = append(matches, &subscription)
+		}
+	}
+	s.mu.Unlock()
+
+	sortSubscriptions(matches)
+	return matches, nil
+}
+
+// ScanSubscriptions calls fn for a snapshot of every subscription
+func (s *NotificationStore) ScanSubscriptions(ctx context.Context, fn func(*storage.Subscription) error) error {
+	s.mu.Lock()
+	snapshot := make([]*storage.Subscription, 0, len(s.subscriptions))
+	for _, subscription := range s.subscriptions {
+		subscription := subscription
+		snapshot = append(snapshot, &subscription)
+	}
+	s.mu.Unlock()
+
+	sortSubscriptions(snapshot)
+	for _, subscription := range snapshot {
+		if err := fn(subscription); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueueDigestEvent appends a copy of the event to the subscription's queue
+func (s *NotificationStore) QueueDigestEvent(ctx context.Context, subscriptionID uuid.UUID, event *storage.NotificationEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subscriptions[subscriptionID]; !ok {
+		return storage.ErrNotFound
+	}
+	s.queued[subscriptionID] = append(s.queued[subscriptionID], *event)
+	return nil
+}
+
+// TakeDigestEvents empties the subscription's queue and records the digest
+func (s *NotificationStore) TakeDigestEvents(ctx context.Context, subscriptionID uuid.UUID, digestAt time.Time) ([]*storage.NotificationEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subscription, ok := s.subscriptions[subscriptionID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	subscription.LastDigestAt = &digestAt
+	s.subscriptions[subscriptionID] = subscription
+
+	queued := s.queued[subscriptionID]
+	delete(s.queued, subscriptionID)
+	events := make([]*storage.NotificationEvent, len(queued))
+	for i := range queued {
+		events[i] = &queued[i]
+	}
+	return events, nil
+}
+
+// copySubscription copies the subscription and its filter slices, so callers
+// cannot change the stored record
+func copySubscription(subscription *storage.Subscription) storage.Subscription {
+	stored := *subscription
+	stored.Filter.Formats = append([]string(nil), subscription.Filter.Formats...)
+	stored.Filter.Tenants = append([]string(nil), subscription.Filter.Tenants...)
+	return stored
+}
+
+// sortSubscriptions orders subscriptions oldest first
+func sortSubscriptions(subscriptions []*storage.Subscription) {
+	sort.Slice(subscriptions, func(i, j int) bool {
+		a, b := subscriptions[i], subscriptions[j]
+		if a.CreatedAt.Equal(b.CreatedAt) {
+			return a.ID.String() < b.ID.String()
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+}
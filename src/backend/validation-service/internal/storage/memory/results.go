@@ -0,0 +1,142 @@
+// Package memory provides in-memory storage implementations suitable for
+// development, tests and single-instance deployments.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/models"
+	"validation-service/internal/storage"
+)
+
+// storedResult is a serialized result with its owning tenant. Results are
+// kept serialized so callers never share mutable state with the store.
+type storedResult struct {
+	tenantID string
+	result   *models.ValidationResult
+	data     []byte
+}
+
+// ResultStore is an in-memory storage.ResultStore
+type ResultStore struct {
+	mu      sync.RWMutex
+	results map[uuid.UUID]*storedResult
+}
+
+// NewResultStore creates an empty in-memory result store
+func NewResultStore() *ResultStore {
+	return &ResultStore{
+		results: make(map[uuid.UUID]*storedResult),
+	}
+}
+
+// SaveResult stores a snapshot of result
+func (s *ResultStore) SaveResult(ctx context.Context, tenantID string, result *models.ValidationResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("serializing result: %w", err)
+	}
+	snapshot, err := decodeResult(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[result.ID] = &storedResult{tenantID: tenantID, result: snapshot, data: data}
+	return nil
+}
+
+// GetResult returns a copy of the stored result
+func (s *ResultStore) GetResult(ctx context.Context, tenantID string, id uuid.UUID) (*models.ValidationResult, error) {
+	s.mu.RLock()
+	stored, ok := s.results[id]
+	s.mu.RUnlock()
+
+	if !ok || stored.tenantID != tenantID {
+		return nil, storage.ErrNotFound
+	}
+	return decodeResult(stored.data)
+}
+
+// ListResults filters all results of the tenant, newest first
+func (s *ResultStore) ListResults(ctx context.Context, query storage.ResultQuery) ([]*models.ValidationResult, int, error) {
+	if err := query.Normalize(); err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.RLock()
+	matches := make([]*storedResult, 0)
+	for _, stored := range s.results {
+		if query.Matches(stored.tenantID, stored.result) {
+			matches = append(matches, stored)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i].result, matches[j].result
+		if a.CreatedAt.Equal(b.CreatedAt) {
+			return a.ID.String() < b.ID.String()
+		}
+		return a.CreatedAt.After(b.CreatedAt)
+	})
+
+	total := len(matches)
+	if query.Offset >= total {
+		return []*models.ValidationResult{}, total, nil
+	}
+	end := query.Offset + query.Limit
+	if end > total {
+		end = total
+	}
+
+	results := make([]*models.ValidationResult, 0, end-query.Offset)
+	for _, stored := range matches[query.Offset:end] {
+		result, err := decodeResult(stored.data)
+		if err != nil {
+			return nil, 0, err
+		}
+		results = append(results, result)
+	}
+	return results, total, nil
+}
+
+// ScanResults calls fn with a copy of every stored result. The store is not
+// locked while fn runs.
+func (s *ResultStore) ScanResults(ctx context.Context, fn func(tenantID string, result *models.ValidationResult) error) error {
+	s.mu.RLock()
+	snapshot := make([]*storedResult, 0, len(s.results))
+	for _, stored := range s.results {
+		snapshot = append(snapshot, stored)
+	}
+	s.mu.RUnlock()
+
+	for _, stored := range snapshot {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result, err := decodeResult(stored.data)
+		if err != nil {
+			return err
+		}
+		if err := fn(stored.tenantID, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeResult(data []byte) (*models.ValidationResult, error) {
+	var result models.ValidationResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("deserializing result: %w", err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/storage"
+)
+
+// ScheduleStore is an in-memory storage.ScheduleStore
+type ScheduleStore struct {
+	mu        sync.Mutex
+	schedules map[uuid.UUID]storage.Schedule
+}
+
+// NewScheduleStore creates an empty in-memory schedule store
+func NewScheduleStore() *ScheduleStore {
+	return &ScheduleStore{
+		schedules: make(map[uuid.UUID]storage.Schedule),
+	}
+}
+
+// SaveSchedule stores a copy of the schedule, keeping the run state of an
+// existing one
+func (s *ScheduleStore) SaveSchedule(ctx context.Context, schedule *storage.Schedule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.schedules[schedule.ID]
+	if ok && existing.TenantID != schedule.TenantID {
+		return storage.ErrAlreadyExists
+	}
+	for id, other := range s.schedules {
+		if id != schedule.ID && other.TenantID == schedule.TenantID && other.Name == schedule.Name {
+			return storage.ErrAlreadyExists
+		}
+	}
+
+	stored := copySchedule(schedule)
+	if ok {
+		stored.LastRun = existing.LastRun
+		stored.LeaseUntil = existing.LeaseUntil
+		stored.SkippedRuns = existing.SkippedRuns
+		stored.CreatedAt = existing.CreatedAt
+	}
+	s.schedules[schedule.ID] = stored
+	return nil
+}
+
+// GetSchedule returns a copy of the stored schedule
+func (s *ScheduleStore) GetSchedule(ctx context.Context, tenantID string, id uuid.UUID) (*storage.Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schedule, ok := s.schedules[id]
+	if !ok || schedule.TenantID != tenantID {
+		return nil, storage.ErrNotFound
+	}
+	stored := copySchedule(&schedule)
+	return &stored, nil
+}
+
+// DeleteSchedule removes the schedule if it belongs to the tenant
+func (s *ScheduleStore) DeleteSchedule(ctx context.Context, tenantID string, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schedule, ok := s.schedules[id]
+	if !ok || schedule.TenantID != tenantID {
+		return storage.ErrNotFound
+	}
+	delete(s.schedules, id)
+	return nil
+}
+
+// ListSchedules returns the schedules of the tenant, oldest first
+func (s *ScheduleStore) ListSchedules(ctx context.Context, tenantID string) ([]*storage.Schedule, error) {
+	s.mu.Lock()
+	matches := make([]*storage.Schedule, 0)
+	for _, schedule := range s.schedules {
+		if schedule.TenantID == tenantID {
+			stored := copySchedule(&schedule)
+			matches = append(matches, &stored)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.CreatedAt.Equal(b.CreatedAt) {
+			return a.ID.String() < b.ID.String()
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+	return matches, nil
+}
+
+// DueSchedules returns the unpaused schedules due at now, earliest first
+func (s *ScheduleStore) DueSchedules(ctx context.Context, now time.Time) ([]*storage.Schedule, error) {
+	s.mu.Lock()
+	due := make([]*storage.Schedule, 0)
+	for _, schedule := range s.schedules {
+		if !schedule.Paused && schedule.NextRunAt != nil && !schedule.NextRunAt.After(now) {
+			stored := copySchedule(&schedule)
+			due = append(due, &stored)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool {
+		a, b := due[i], due[j]
+		if a.NextRunAt.Equal(*b.NextRunAt) {
+			return a.ID.String() < b.ID.String()
+		}
+		return a.NextRunAt.Before(*b.NextRunAt)
+	})
+	return due, nil
+}
+
+// ClaimScheduleRun starts or skips the run due at due
+func (s *ScheduleStore) ClaimScheduleRun(ctx context.Context, id uuid.UUID, due, next time.Time, run *storage.ScheduleRun, leaseUntil time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schedule, ok := s.schedules[id]
+	if !ok {
+		return false, storage.ErrNotFound
+	}
+	if schedule.Paused || schedule.NextRunAt == nil || !schedule.NextRunAt.Equal(due) {
+		return false, nil
+	}
+
+	schedule.NextRunAt = &next
+	started := !schedule.Running(run.StartedAt)
+	if started {
+		current := *run
+		current.Counts = copyCounts(run.Counts)
+		schedule.LastRun = &current
+		schedule.LeaseUntil = &leaseUntil
+	} else {
+		schedule.SkippedRuns++
+	}
+	s.schedules[id] = schedule
+	return started, nil
+}
+
+// FinishScheduleRun records the outcome of the current run
+func (s *ScheduleStore) FinishScheduleRun(ctx context.Context, id uuid.UUID, run *storage.ScheduleRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schedule, ok := s.schedules[id]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	if schedule.LastRun == nil || !schedule.LastRun.ScheduledAt.Equal(run.ScheduledAt) {
+		return nil
+	}
+	finished := *run
+	finished.Counts = copyCounts(run.Counts)
+	schedule.LastRun = &finished
+	schedule.LeaseUntil = nil
+	s.schedules[id] = schedule
+	return nil
+}
+
+// copySchedule copies the schedule, its tags and its last run, so callers
+// cannot change the stored record
+func copySchedule(schedule *storage.Schedule) storage.Schedule {
+	stored := *schedule
+	if schedule.Job.Tags != nil {
+		stored.Job.Tags = make(map[string]string, len(schedule.Job.Tags))
+		for key, value := range schedule.Job.Tags {
+			stored.Job.Tags[key] = value
+		}
+	}
+	if schedule.LastRun != nil {
+		run := *schedule.LastRun
+		run.Counts = copyCounts(schedule.LastRun.Counts)
+		stored.LastRun = &run
+	}
+	return stored
+}
+
+// copyCounts copies the tallies of a run
+func copyCounts(counts map[string]int) map[string]int {
+	if counts == nil {
+		return nil
+	}
+	copied := make(map[string]int, len(counts))
+	for key, value := range counts {
+		copied[key] = value
+	}
+	return copied
+}
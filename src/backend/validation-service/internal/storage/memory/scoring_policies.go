@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"validation-service/internal/storage"
+)
+
+// ScoringPolicyStore is an in-memory storage.ScoringPolicyStore
+type ScoringPolicyStore struct {
+	mu       sync.Mutex
+	policies map[string]storage.ScoringPolicy
+}
+
+// NewScoringPolicyStore creates an empty in-memory scoring policy store
+func NewScoringPolicyStore() *ScoringPolicyStore {
+	return &ScoringPolicyStore{
+		policies: make(map[string]storage.ScoringPolicy),
+	}
+}
+
+// SaveScoringPolicy stores a copy of the policy
+func (s *ScoringPolicyStore) SaveScoringPolicy(ctx context.Context, policy *storage.ScoringPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policies[policy.TenantID] = *policy
+	return nil
+}
+
+// GetScoringPolicy returns a copy of the tenant's policy
+func (s *ScoringPolicyStore) GetScoringPolicy(ctx context.Context, tenantID string) (*storage.ScoringPolicy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	policy, ok := s.policies[tenantID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return &policy, nil
+}
+
+// DeleteScoringPolicy removes the tenant's policy
+func (s *ScoringPolicyStore) DeleteScoringPolicy(ctx context.Context, tenantID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.policies[tenantID]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(s.policies, tenantID)
+	return nil
+}
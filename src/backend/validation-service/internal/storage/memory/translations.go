@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/storage"
+)
+
+// TranslationMemoryStore is an in-memory storage.TranslationMemoryStore
+type TranslationMemoryStore struct {
+	mu       sync.RWMutex
+	mappings map[uuid.UUID]storage.ApprovedMapping
+}
+
+// NewTranslationMemoryStore creates an empty in-memory translation memory
+func NewTranslationMemoryStore() *TranslationMemoryStore {
+	return &TranslationMemoryStore{
+		mappings: make(map[uuid.UUID]storage.ApprovedMapping),
+	}
+}
+
+// SaveMapping stores a copy of mapping, superseding an earlier approval of
+// the same source expression between the same formats
+func (s *TranslationMemoryStore) SaveMapping(ctx context.Context, mapping *storage.ApprovedMapping) error {
+	key := storage.NormalizeExpression(mapping.SourceExpression)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, existing := range s.mappings {
+		if id != mapping.ID &&
+			existing.TenantID == mapping.TenantID &&
+			existing.SourceFormat == mapping.SourceFormat &&
+			existing.TargetFormat == mapping.TargetFormat &&
+			storage.NormalizeExpression(existing.SourceExpression) == key {
+			delete(s.mappings, id)
+		}
+	}
+	s.mappings[mapping.ID] = *mapping
+	return nil
+}
+
+// GetMapping returns a copy of the stored mapping
+func (s *TranslationMemoryStore) GetMapping(ctx context.Context, tenantID string, id uuid.UUID) (*storage.ApprovedMapping, error) {
+	s.mu.RLock()
+	mapping, ok := s.mappings[id]
+	s.mu.RUnlock()
+
+	if !ok || mapping.TenantID != tenantID {
+		return nil, storage.ErrNotFound
+	}
+	return &mapping, nil
+}
+
+// DeleteMapping removes the mapping if it belongs to the tenant
+func (s *TranslationMemoryStore) DeleteMapping(ctx context.Context, tenantID string, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mapping, ok := s.mappings[id]
+	if !ok || mapping.TenantID != tenantID {
+		return storage.ErrNotFound
+	}
+	delete(s.mappings, id)
+	return nil
+}
+
+// ListMappings filters all mappings of the tenant, most recent first
+func (s *TranslationMemoryStore) ListMappings(ctx context.Context, query storage.MappingQuery) ([]*storage.ApprovedMapping, int, error) {
+	if err := query.Normalize(); err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.RLock()
+	matches := make([]*storage.ApprovedMapping, 0)
+	for _, mapping := range s.mappings {
+		mapping := mapping
+		if query.Matches(&mapping) {
+			matches = append(matches, &mapping)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.ApprovedAt.Equal(b.ApprovedAt) {
+			return a.ID.String() < b.ID.String()
+		}
+		return a.ApprovedAt.After(b.ApprovedAt)
+	})
+
+	total := len(matches)
+	if query.Offset >= total {
+		return []*storage.ApprovedMapping{}, total, nil
+	}
+	end := query.Offset + query.Limit
+	if end > total {
+		end = total
+	}
+	return matches[query.Offset:end], total, nil
+}
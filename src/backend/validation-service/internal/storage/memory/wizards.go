@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/storage"
+)
+
+// MigrationWizardStore is an in-memory storage.MigrationWizardStore. Wizards
+// are kept serialized so callers never share their rules and results with
+// the store.
+type MigrationWizardStore struct {
+	mu      sync.RWMutex
+	wizards map[uuid.UUID][]byte
+}
+
+// NewMigrationWizardStore creates an empty in-memory migration wizard store
+func NewMigrationWizardStore() *MigrationWizardStore {
+	return &MigrationWizardStore{
+		wizards: make(map[uuid.UUID][]byte),
+	}
+}
+
+// CreateMigrationWizard stores a snapshot of wizard as version 1
+func (s *MigrationWizardStore) CreateMigrationWizard(ctx context.Context, wizard *storage.MigrationWizard) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.wizards[wizard.ID]; exists {
+		return storage.ErrAlreadyExists
+	}
+	return s.put(wizard, 1)
+}
+
+// GetMigrationWizard returns a copy of the stored wizard
+func (s *MigrationWizardStore) GetMigrationWizard(ctx context.Context, tenantID string, id uuid.UUID) (*storage.MigrationWizard, error) {
+	s.mu.RLock()
+	data, ok := s.wizards[id]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	wizard, err := decodeWizard(data)
+	if err != nil {
+		return nil, err
+	}
+	if wizard.TenantID != tenantID {
+		return nil, storage.ErrNotFound
+	}
+	return wizard, nil
+}
+
+// UpdateMigrationWizard stores a snapshot of wizard as the next version
+func (s *MigrationWizardStore) UpdateMigrationWizard(ctx context.Context, wizard *storage.MigrationWizard) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.wizards[wizard.ID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	current, err := decodeWizard(data)
+	if err != nil {
+		return err
+	}
+	if current.TenantID != wizard.TenantID {
+		return storage.ErrNotFound
+	}
+	if current.Version != wizard.Version {
+		return storage.ErrVersionConflict
+	}
+	return s.put(wizard, current.Version+1)
+}
+
+// DeleteMigrationWizard removes the wizard if it belongs to the tenant
+func (s *MigrationWizardStore) DeleteMigrationWizard(ctx context.Context, tenantID string, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.wizards[id]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	wizard, err := decodeWizard(data)
+	if err != nil {
+		return err
+	}
+	if wizard.TenantID != tenantID {
+		return storage.ErrNotFound
+	}
+	delete(s.wizards, id)
+	return nil
+}
+
+// ListMigrationWizards returns the wizards of the tenant without their rules
+// and results, oldest first
+func (s *MigrationWizardStore) ListMigrationWizards(ctx context.Context, tenantID string) ([]*storage.MigrationWizard, error) {
+	s.mu.RLock()
+	matches := make([]*storage.MigrationWizard, 0)
+	for _, data := range s.wizards {
+		wizard, err := decodeWizard(data)
+		if err != nil {
+			s.mu.RUnlock()
+			return nil, err
+		}
+		if wizard.TenantID == tenantID {
+			wizard.Rules = nil
+			wizard.Results = nil
+			matches = append(matches, wizard)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.CreatedAt.Equal(b.CreatedAt) {
+			return a.ID.String() < b.ID.String()
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+	return matches, nil
+}
+
+// put stores wizard as version, setting its version on success. The caller
+// holds the write lock.
+func (s *MigrationWizardStore) put(wizard *storage.MigrationWizard, version int) error {
+	stored := *wizard
+	stored.Version = version
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return fmt.Errorf("serializing migration wizard: %w", err)
+	}
+	s.wizards[wizard.ID] = data
+	wizard.Version = version
+	return nil
+}
+
+func decodeWizard(data []byte) (*storage.MigrationWizard, error) {
+	var wizard storage.MigrationWizard
+	if err := json.Unmarshal(data, &wizard); err != nil {
+		return nil, fmt.Errorf("deserializing migration wizard: %w", err)
+	}
+	return &wizard, nil
+}
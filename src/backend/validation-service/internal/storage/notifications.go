@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/models"
+	"validation-service/pkg/httpclient"
+)
+
+// Notification channel types
+const (
+	ChannelWebhook = "webhook"
+	ChannelSlack   = "slack"
+	ChannelEmail   = "email"
+)
+
+// Notification delivery modes. Immediate subscriptions receive every event as
+// it happens; digest subscriptions receive one summary of the events of the
+// past hour or day.
+const (
+	DeliveryImmediate    = "immediate"
+	DeliveryHourlyDigest = "hourly"
+	DeliveryDailyDigest  = "daily"
+)
+
+// NotificationChannel is where a subscription's notifications are delivered:
+// a webhook or Slack incoming webhook URL, or an email address
+type NotificationChannel struct {
+	Type   string `json:"type"`
+	Target string `json:"target"`
+	// Secret signs webhook deliveries; it is never returned by the API
+	Secret string `json:"secret,omitempty"`
+}
+
+// SubscriptionFilter selects the events a subscription receives. Empty
+// criteria match every event, except that subscriptions without tenants only
+// receive the events of their own tenant.
+type SubscriptionFilter struct {
+	Formats []string `json:"formats,omitempty"`
+	Tenants []string `json:"tenants,omitempty"`
+	// MinSeverity drops events less severe than high, medium or low
+	MinSeverity string `json:"min_severity,omitempty"`
+}
+
+// Subscription is a user's preference for receiving notifications on a
+// channel
+type Subscription struct {
+	ID       uuid.UUID           `json:"id"`
+	TenantID string              `json:"tenant_id,omitempty"`
+	UserID   string              `json:"user_id"`
+	Channel  NotificationChannel `json:"channel"`
+	Delivery string              `json:"delivery"`
+	Filter   SubscriptionFilter  `json:"filter"`
+	// LastDigestAt is when the last digest was composed; digests cover the
+	// events queued since
+	LastDigestAt *time.Time `json:"last_digest_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// severityRank orders issue severities for filtering; events without a
+// severity rank lowest
+var severityRank = map[string]int{
+	models.ValidationSeverityLow:    1,
+	models.ValidationSeverityMedium: 2,
+	models.ValidationSeverityHigh:   3,
+}
+
+// validateWebhookTarget checks that a webhook target is an https URL whose
+// host is not loopback, private or link-local. Names resolving to such
+// addresses are refused when delivering.
+func validateWebhookTarget(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return errors.New("webhook target must be an https URL")
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("webhook target must not be a local host")
+	}
+	if ip := net.ParseIP(host); ip != nil && !httpclient.IsPublicIP(ip) {
+		return errors.New("webhook target must not be a loopback, private or link-local address")
+	}
+	return nil
+}
+
+// Validate checks the channel, delivery mode and filter of the subscription
+func (s *Subscription) Validate() error {
+	switch s.Channel.Type {
+	case ChannelWebhook:
+		if err := validateWebhookTarget(s.Channel.Target); err != nil {
+			return err
+		}
+	case ChannelSlack:
+		if !strings.HasPrefix(s.Channel.Target, "https://hooks.slack.com/") {
+			return errors.New("slack target must be a Slack incoming webhook URL")
+		}
+	case ChannelEmail:
+		if at := strings.LastIndex(s.Channel.Target, "@"); at < 1 || at == len(s.Channel.Target)-1 {
+			return errors.New("email target must be an email address")
+		}
+	default:
+		return fmt.Errorf("unknown channel type: %q", s.Channel.Type)
+	}
+	switch s.Delivery {
+	case DeliveryImmediate, DeliveryHourlyDigest, DeliveryDailyDigest:
+	default:
+		return fmt.Errorf("unknown delivery mode: %q", s.Delivery)
+	}
+	if s.Filter.MinSeverity != "" && severityRank[s.Filter.MinSeverity] == 0 {
+		return fmt.Errorf("unknown severity: %q", s.Filter.MinSeverity)
+	}
+	if strings.TrimSpace(s.UserID) == "" {
+		return errors.New("user_id is required")
+	}
+	return nil
+}
+
+// Digest reports whether the subscription receives digests
+func (s *Subscription) Digest() bool {
+	return s.Delivery == DeliveryHourlyDigest || s.Delivery == DeliveryDailyDigest
+}
+
+// Matches reports whether the subscription receives the event
+func (s *Subscription) Matches(event *NotificationEvent) bool {
+	tenants := s.Filter.Tenants
+	if len(tenants) == 0 {
+		tenants = []string{s.TenantID}
+	}
+	if !containsOrEmpty(tenants, event.TenantID) {
+		return false
+	}
+	if event.Format != "" && !containsOrEmpty(s.Filter.Formats, event.Format) {
+		return false
+	}
+	return severityRank[event.Severity] >= severityRank[s.Filter.MinSeverity]
+}
+
+// containsOrEmpty reports whether values is empty or contains value
+func containsOrEmpty(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationEvent is something subscribers are told about, such as a
+// completed validation or import
+type NotificationEvent struct {
+	ID       uuid.UUID `json:"id"`
+	Type     string    `json:"type"`
+	TenantID string    `json:"tenant_id,omitempty"`
+	Format   string    `json:"format,omitempty"`
+	// Severity is the most severe issue of the event, if any
+	Severity   string            `json:"severity,omitempty"`
+	Title      string            `json:"title"`
+	Summary    string            `json:"summary,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	OccurredAt time.Time         `json:"occurred_at"`
+}
+
+// NotificationStore persists subscriptions and the events queued for their
+// next digest
+type NotificationStore interface {
+	// SaveSubscription creates or replaces a subscription
+	SaveSubscription(ctx context.Context, subscription *Subscription) error
+	// GetSubscription returns a subscription of a tenant
+	GetSubscription(ctx context.Context, tenantID string, id uuid.UUID) (*Subscription, error)
+	// DeleteSubscription removes a subscription of a tenant and its queued
+	// events
+	DeleteSubscription(ctx context.Context, tenantID string, id uuid.UUID) error
+	// ListSubscriptions returns the subscriptions of a user of a tenant,
+	// oldest first; every user's when userID is empty
+	ListSubscriptions(ctx context.Context, tenantID, userID string) ([]*Subscription, error)
+	// ScanSubscriptions calls fn for every subscription of every tenant
+	ScanSubscriptions(ctx context.Context, fn func(*Subscription) error) error
+	// QueueDigestEvent adds an event to the next digest of a subscription
+	QueueDigestEvent(ctx context.Context, subscriptionID uuid.UUID, event *NotificationEvent) error
+	// TakeDigestEvents removes and returns the queued events of a
+	// subscription, oldest first, and records the digest time. Concurrent
+	// callers never receive the same event.
+	TakeDigestEvents(ctx context.Context, subscriptionID uuid.UUID, digestAt time.Time) ([]*NotificationEvent, error)
+}
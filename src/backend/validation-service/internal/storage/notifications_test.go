@@ -0,0 +1,39 @@
+package storage
+
+import "testing"
+
+func TestValidateWebhookTarget(t *testing.T) {
+	tests := []struct {
+		target  string
+		wantErr bool
+	}{
+		{"https://hooks.example.com/detections", false},
+		{"https://hooks.example.com:8443/detections", false},
+		{"https://93.184.216.34/hook", false},
+		{"http://hooks.example.com/detections", true},
+		{"https:///detections", true},
+		{"hooks.example.com/detections", true},
+		{"https://localhost/hook", true},
+		{"https://api.localhost./hook", true},
+		{"https://127.0.0.1/hook", true},
+		{"https://[::1]:8443/hook", true},
+		{"https://10.0.0.5/hook", true},
+		{"https://192.168.1.10/hook", true},
+		{"https://169.254.169.254/latest/meta-data", true},
+		{"https://[fe80::1]/hook", true},
+		{"https://0.0.0.0/hook", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			s := &Subscription{
+				UserID:   "user-1",
+				Channel:  NotificationChannel{Type: ChannelWebhook, Target: tt.target},
+				Delivery: DeliveryImmediate,
+			}
+			err := s.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
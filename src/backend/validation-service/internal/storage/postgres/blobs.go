@@ -0,0 +1,259 @@
+// Package postgres implements storage backed by PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"validation-service/internal/storage"
+)
+
+// blobsSchema creates the content-addressed blob table. The scope column is
+// empty for blobs shared across tenants; key_id is empty for plaintext content.
+const blobsSchema = `
+CREATE TABLE IF NOT EXISTS detection_blobs (
+	digest      TEXT NOT NULL,
+	scope       TEXT NOT NULL DEFAULT '',
+	content     BYTEA NOT NULL,
+	key_id      TEXT NOT NULL DEFAULT '',
+	size_bytes  BIGINT NOT NULL,
+	ref_count   BIGINT NOT NULL DEFAULT 0,
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	released_at TIMESTAMPTZ,
+	PRIMARY KEY (digest, scope)
+);
+CREATE INDEX IF NOT EXISTS detection_blobs_unreferenced_idx ON detection_blobs (released_at) WHERE ref_count = 0;
+ALTER TABLE detection_blobs ADD COLUMN IF NOT EXISTS key_id TEXT NOT NULL DEFAULT '';
+`
+
+// BlobStore is a storage.BlobStore backed by PostgreSQL
+type BlobStore struct {
+	db     *sql.DB
+	cipher storage.BlobCipher
+}
+
+// NewBlobStore creates the store, ensuring its table exists
+func NewBlobStore(ctx context.Context, db *sql.DB) (*BlobStore, error) {
+	if _, err := db.ExecContext(ctx, blobsSchema); err != nil {
+		return nil, fmt.Errorf("creating blobs schema: %w", err)
+	}
+	return &BlobStore{db: db}, nil
+}
+
+// SetCipher enables encryption of new content at rest. Existing plaintext
+// content stays readable and is encrypted by ReencryptBlob.
+func (s *BlobStore) SetCipher(cipher storage.BlobCipher) {
+	s.cipher = cipher
+}
+
+// Put inserts the blob or increments the reference count of the existing one.
+// Content is only written on first insert.
+func (s *BlobStore) Put(ctx context.Context, content []byte, scope string) (storage.BlobRef, error) {
+	ref := storage.NewBlobRef(content, scope)
+	stored, keyID, err := s.seal(ctx, scope, content)
+	if err != nil {
+		return "", err
+	}
+	_, err = s.db.ExecContext(ctx, `
+INSERT INTO detection_blobs (digest, scope, content, key_id, size_bytes, ref_count)
+VALUES ($1, $2, $3, $4, $5, 1)
+ON CONFLICT (digest, scope) DO UPDATE SET
+	ref_count = detection_blobs.ref_count + 1,
+	released_at = NULL`,
+		ref.Digest(), scope, stored, keyID, len(content),
+	)
+	if err != nil {
+		return "", fmt.Errorf("storing blob: %w", err)
+	}
+	return ref, nil
+}
+
+// Get returns the blob content
+func (s *BlobStore) Get(ctx context.Context, ref storage.BlobRef) ([]byte, error) {
+	if err := ref.Validate(); err != nil {
+		return nil, err
+	}
+	content, keyID, err := s.load(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return s.open(ctx, ref.Scope(), keyID, content)
+}
+
+// Release decrements the reference count, recording when it reaches zero
+func (s *BlobStore) Release(ctx context.Context, ref storage.BlobRef) error {
+	if err := ref.Validate(); err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, `
+UPDATE detection_blobs SET
+	ref_count = ref_count - 1,
+	released_at = CASE WHEN ref_count = 1 THEN now() ELSE released_at END
+WHERE digest = $1 AND scope = $2 AND ref_count > 0`,
+		ref.Digest(), ref.Scope(),
+	)
+	if err != nil {
+		return fmt.Errorf("releasing blob: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// CollectGarbage deletes blobs unreferenced for longer than grace
+func (s *BlobStore) CollectGarbage(ctx context.Context, grace time.Duration) (int, error) {
+	res, err := s.db.ExecContext(ctx, `
+DELETE FROM detection_blobs
+WHERE ref_count = 0 AND released_at <= now() - make_interval(secs => $1)`,
+		grace.Seconds(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("collecting blobs: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(deleted), nil
+}
+
+// Stats reports blob counts and sizes
+func (s *BlobStore) Stats(ctx context.Context) (storage.BlobStats, error) {
+	var stats storage.BlobStats
+	err := s.db.QueryRowContext(ctx, `
+SELECT count(*), coalesce(sum(size_bytes), 0), coalesce(sum(ref_count), 0), count(*) FILTER (WHERE ref_count = 0)
+FROM detection_blobs`).Scan(&stats.Blobs, &stats.Bytes, &stats.References, &stats.Unreferenced)
+	if err != nil {
+		return stats, fmt.Errorf("loading blob stats: %w", err)
+	}
+	return stats, nil
+}
+
+// BlobScopes lists the scopes holding blobs
+func (s *BlobStore) BlobScopes(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT DISTINCT scope FROM detection_blobs ORDER BY scope`)
+	if err != nil {
+		return nil, fmt.Errorf("listing blob scopes: %w", err)
+	}
+	defer rows.Close()
+
+	scopes := []string{}
+	for rows.Next() {
+		var scope string
+		if err := rows.Scan(&scope); err != nil {
+			return nil, err
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, rows.Err()
+}
+
+// StaleBlobs returns blobs of scope not encrypted with keyID
+func (s *BlobStore) StaleBlobs(ctx context.Context, scope, keyID string, after storage.BlobRef, limit int) ([]storage.BlobRef, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT digest FROM detection_blobs
+WHERE scope = $1 AND key_id <> $2 AND digest > $3
+ORDER BY digest
+LIMIT $4`,
+		scope, keyID, after.Digest(), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing stale blobs: %w", err)
+	}
+	defer rows.Close()
+
+	var refs []storage.BlobRef
+	for rows.Next() {
+		var digest string
+		if err := rows.Scan(&digest); err != nil {
+			return nil, err
+		}
+		ref := storage.BlobRef(digest)
+		if scope != "" {
+			ref = storage.BlobRef(digest + "@" + scope)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// ReencryptBlob re-encrypts a blob with the current key of its scope. The
+// update only applies if the blob was not re-encrypted concurrently.
+func (s *BlobStore) ReencryptBlob(ctx context.Context, ref storage.BlobRef) error {
+	if s.cipher == nil {
+		return fmt.Errorf("blob encryption is not enabled")
+	}
+	stored, keyID, err := s.load(ctx, ref)
+	if err != nil {
+		return err
+	}
+	content, err := s.open(ctx, ref.Scope(), keyID, stored)
+	if err != nil {
+		return err
+	}
+	sealed, newKeyID, err := s.seal(ctx, ref.Scope(), content)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+UPDATE detection_blobs SET content = $1, key_id = $2
+WHERE digest = $3 AND scope = $4 AND key_id = $5`,
+		sealed, newKeyID, ref.Digest(), ref.Scope(), keyID,
+	)
+	if err != nil {
+		return fmt.Errorf("re-encrypting blob: %w", err)
+	}
+	return nil
+}
+
+// load returns the stored content of a blob and the key it is encrypted with
+func (s *BlobStore) load(ctx context.Context, ref storage.BlobRef) ([]byte, string, error) {
+	if err := ref.Validate(); err != nil {
+		return nil, "", err
+	}
+	var content []byte
+	var keyID string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT content, key_id FROM detection_blobs WHERE digest = $1 AND scope = $2`,
+		ref.Digest(), ref.Scope(),
+	).Scan(&content, &keyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("loading blob: %w", err)
+	}
+	return content, keyID, nil
+}
+
+// seal encrypts content when a cipher is configured
+func (s *BlobStore) seal(ctx context.Context, scope string, content []byte) ([]byte, string, error) {
+	if s.cipher == nil {
+		return content, "", nil
+	}
+	sealed, keyID, err := s.cipher.Seal(ctx, scope, content)
+	if err != nil {
+		return nil, "", fmt.Errorf("encrypting blob: %w", err)
+	}
+	return sealed, keyID, nil
+}
+
+// open decrypts stored content encrypted with keyID
+func (s *BlobStore) open(ctx context.Context, scope, keyID string, stored []byte) ([]byte, error) {
+	if keyID == "" {
+		return stored, nil
+	}
+	if s.cipher == nil {
+		return nil, fmt.Errorf("blob is encrypted but encryption is not enabled")
+	}
+	content, err := s.cipher.Open(ctx, scope, keyID, stored)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting blob: %w", err)
+	}
+	return content, nil
+}
@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"         // v1.4.0
+	"github.com/jackc/pgx/v5/pgconn" // v5.5.0
+
+	"validation-service/internal/storage"
+)
+
+// collectionsSchema creates the table of tenant rule collections. The
+// detection IDs are kept in order as a JSON array.
+const collectionsSchema = `
+CREATE TABLE IF NOT EXISTS validation_collections (
+	id            UUID PRIMARY KEY,
+	tenant_id     TEXT NOT NULL,
+	name          TEXT NOT NULL,
+	description   TEXT NOT NULL DEFAULT '',
+	metadata      JSONB,
+	detection_ids JSONB NOT NULL,
+	created_by    TEXT NOT NULL DEFAULT '',
+	created_at    TIMESTAMPTZ NOT NULL,
+	updated_at    TIMESTAMPTZ NOT NULL,
+	UNIQUE (tenant_id, name)
+);
+`
+
+const collectionColumns = `id, tenant_id, name, description, metadata, detection_ids, created_by, created_at, updated_at`
+
+// CollectionStore is a storage.CollectionStore backed by PostgreSQL
+type CollectionStore struct {
+	db *sql.DB
+}
+
+// NewCollectionStore creates the store, ensuring its table exists
+func NewCollectionStore(ctx context.Context, db *sql.DB) (*CollectionStore, error) {
+	if _, err := db.ExecContext(ctx, collectionsSchema); err != nil {
+		return nil, fmt.Errorf("creating collections schema: %w", err)
+	}
+	return &CollectionStore{db: db}, nil
+}
+
+// SaveCollection upserts the collection. A conflicting ID owned by another
+// tenant is left untouched.
+func (s *CollectionStore) SaveCollection(ctx context.Context, collection *storage.Collection) error {
+	var metadata []byte
+	if collection.Metadata != nil {
+		var err error
+		if metadata, err = json.Marshal(collection.Metadata); err != nil {
+			return fmt.Errorf("serializing collection metadata: %w", err)
+		}
+	}
+	detectionIDs, err := json.Marshal(collection.DetectionIDs)
+	if err != nil {
+		return fmt.Errorf("serializing collection detections: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO validation_collections (`+collectionColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			description = EXCLUDED.description,
+			metadata = EXCLUDED.metadata,
+			detection_ids = EXCLUDED.detection_ids,
+			updated_at = EXCLUDED.updated_at
+		WHERE validation_collections.tenant_id = EXCLUDED.tenant_id`,
+		collection.ID, collection.TenantID, collection.Name, collection.Description, metadata, detectionIDs,
+		collection.CreatedBy, collection.CreatedAt, collection.UpdatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return storage.ErrAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("saving collection: %w", err)
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return storage.ErrAlreadyExists
+	}
+	return nil
+}
+
+// GetCollection returns the collection with the given ID for a tenant
+func (s *CollectionStore) GetCollection(ctx context.Context, tenantID string, id uuid.UUID) (*storage.Collection, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT `+collectionColumns+` FROM validation_collections WHERE id = $1 AND tenant_id = $2`,
+		id, tenantID)
+
+	collection, err := scanCollection(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	return collection, err
+}
+
+// DeleteCollection removes a collection of a tenant
+func (s *CollectionStore) DeleteCollection(ctx context.Context, tenantID string, id uuid.UUID) error {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM validation_collections WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("deleting collection: %w", err)
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// ListCollections returns the collections of a tenant, oldest first
+func (s *CollectionStore) ListCollections(ctx context.Context, tenantID string) ([]*storage.Collection, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+collectionColumns+` FROM validation_collections WHERE tenant_id = $1 ORDER BY created_at, id`,
+		tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("listing collections: %w", err)
+	}
+	defer rows.Close()
+
+	collections := make([]*storage.Collection, 0)
+	for rows.Next() {
+		collection, err := scanCollection(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, collection)
+	}
+	return collections, rows.Err()
+}
+
+// scanCollection scans a row selected with collectionColumns
+func scanCollection(scan func(dest ...interface{}) error) (*storage.Collection, error) {
+	var collection storage.Collection
+	var metadata, detectionIDs []byte
+	if err := scan(
+		&collection.ID, &collection.TenantID, &collection.Name, &collection.Description, &metadata, &detectionIDs,
+		&collection.CreatedBy, &collection.CreatedAt, &collection.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if metadata != nil {
+		if err := json.Unmarshal(metadata, &collection.Metadata); err != nil {
+			return nil, fmt.Errorf("decoding collection metadata: %w", err)
+		}
+	}
+	if err := json.Unmarshal(detectionIDs, &collection.DetectionIDs); err != nil {
+		return nil, fmt.Errorf("decoding collection detections: %w", err)
+	}
+	return &collection, nil
+}
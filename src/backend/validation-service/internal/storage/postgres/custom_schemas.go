@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"validation-service/internal/storage"
+)
+
+// customSchemasSchema creates the table of tenant custom log source schemas
+const customSchemasSchema = `
+CREATE TABLE IF NOT EXISTS validation_custom_schemas (
+	tenant_id   TEXT NOT NULL,
+	name        TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	fields      JSONB NOT NULL,
+	updated_by  TEXT NOT NULL DEFAULT '',
+	updated_at  TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (tenant_id, name)
+);
+`
+
+const customSchemaColumns = `tenant_id, name, description, fields, updated_by, updated_at`
+
+// CustomSchemaStore is a storage.CustomSchemaStore backed by PostgreSQL
+type CustomSchemaStore struct {
+	db *sql.DB
+}
+
+// NewCustomSchemaStore creates the store, ensuring its table exists
+func NewCustomSchemaStore(ctx context.Context, db *sql.DB) (*CustomSchemaStore, error) {
+	if _, err := db.ExecContext(ctx, customSchemasSchema); err != nil {
+		return nil, fmt.Errorf("creating custom schemas schema: %w", err)
+	}
+	return &CustomSchemaStore{db: db}, nil
+}
+
+// SaveCustomSchema upserts the schema of a tenant
+func (s *CustomSchemaStore) SaveCustomSchema(ctx context.Context, schema *storage.CustomSchema) error {
+	fields, err := json.Marshal(schema.Fields)
+	if err != nil {
+		return fmt.Errorf("serializing schema fields: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO validation_custom_schemas (`+customSchemaColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (tenant_id, name) DO UPDATE SET
+			description = EXCLUDED.description,
+			fields = EXCLUDED.fields,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at`,
+		schema.TenantID, schema.Name, schema.Description, fields, schema.UpdatedBy, schema.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("saving custom schema: %w", err)
+	}
+	return nil
+}
+
+// GetCustomSchema returns a schema of a tenant
+func (s *CustomSchemaStore) GetCustomSchema(ctx context.Context, tenantID, name string) (*storage.CustomSchema, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT `+customSchemaColumns+` FROM validation_custom_schemas WHERE tenant_id = $1 AND name = $2`,
+		tenantID, name)
+	schema, err := scanCustomSchema(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading custom schema: %w", err)
+	}
+	return schema, nil
+}
+
+// ListCustomSchemas returns the schemas of a tenant ordered by name
+func (s *CustomSchemaStore) ListCustomSchemas(ctx context.Context, tenantID string) ([]*storage.CustomSchema, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+customSchemaColumns+` FROM validation_custom_schemas WHERE tenant_id = $1 ORDER BY name`,
+		tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("listing custom schemas: %w", err)
+	}
+	defer rows.Close()
+
+	schemas := make([]*storage.CustomSchema, 0)
+	for rows.Next() {
+		schema, err := scanCustomSchema(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("listing custom schemas: %w", err)
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas, rows.Err()
+}
+
+// DeleteCustomSchema removes a schema of a tenant
+func (s *CustomSchemaStore) DeleteCustomSchema(ctx context.Context, tenantID, name string) error {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM validation_custom_schemas WHERE tenant_id = $1 AND name = $2`, tenantID, name)
+	if err != nil {
+		return fmt.Errorf("deleting custom schema: %w", err)
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// scanCustomSchema scans a row selected with customSchemaColumns
+func scanCustomSchema(scan func(dest ...interface{}) error) (*storage.CustomSchema, error) {
+	var schema storage.CustomSchema
+	var fields []byte
+	if err := scan(&schema.TenantID, &schema.Name, &schema.Description, &fields, &schema.UpdatedBy, &schema.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(fields, &schema.Fields); err != nil {
+		return nil, fmt.Errorf("decoding schema fields: %w", err)
+	}
+	return &schema, nil
+}
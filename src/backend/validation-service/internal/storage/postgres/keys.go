@@ -0,0 +1,103 @@
+// Package postgres implements storage backed by PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn" // v5.5.0
+
+	"validation-service/internal/storage"
+)
+
+// keysSchema creates the data key table. At most one key per scope is active.
+const keysSchema = `
+CREATE TABLE IF NOT EXISTS blob_data_keys (
+	id          TEXT PRIMARY KEY,
+	scope       TEXT NOT NULL,
+	version     INTEGER NOT NULL,
+	wrapped_key BYTEA NOT NULL,
+	active      BOOLEAN NOT NULL,
+	created_at  TIMESTAMPTZ NOT NULL,
+	UNIQUE (scope, version)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS blob_data_keys_active_idx ON blob_data_keys (scope) WHERE active;
+`
+
+// uniqueViolation is the PostgreSQL error code for unique constraint violations
+const uniqueViolation = "23505"
+
+const keyColumns = `id, scope, version, wrapped_key, active, created_at`
+
+// KeyStore is a storage.KeyStore backed by PostgreSQL
+type KeyStore struct {
+	db *sql.DB
+}
+
+// NewKeyStore creates the store, ensuring its table exists
+func NewKeyStore(ctx context.Context, db *sql.DB) (*KeyStore, error) {
+	if _, err := db.ExecContext(ctx, keysSchema); err != nil {
+		return nil, fmt.Errorf("creating keys schema: %w", err)
+	}
+	return &KeyStore{db: db}, nil
+}
+
+// CreateKey stores key as the active key of its scope
+func (s *KeyStore) CreateKey(ctx context.Context, key *storage.DataKey) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE blob_data_keys SET active = FALSE WHERE scope = $1 AND active AND version < $2`,
+		key.Scope, key.Version,
+	); err != nil {
+		return fmt.Errorf("deactivating data key: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+INSERT INTO blob_data_keys (`+keyColumns+`)
+VALUES ($1, $2, $3, $4, TRUE, $5)`,
+		key.ID, key.Scope, key.Version, key.Wrapped, key.CreatedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return storage.ErrAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("storing data key: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	key.Active = true
+	return nil
+}
+
+// GetKey returns a key by ID
+func (s *KeyStore) GetKey(ctx context.Context, id string) (*storage.DataKey, error) {
+	return s.queryKey(ctx, `SELECT `+keyColumns+` FROM blob_data_keys WHERE id = $1`, id)
+}
+
+// ActiveKey returns the active key of scope
+func (s *KeyStore) ActiveKey(ctx context.Context, scope string) (*storage.DataKey, error) {
+	return s.queryKey(ctx, `SELECT `+keyColumns+` FROM blob_data_keys WHERE scope = $1 AND active`, scope)
+}
+
+// queryKey loads a single key
+func (s *KeyStore) queryKey(ctx context.Context, query string, arg interface{}) (*storage.DataKey, error) {
+	var key storage.DataKey
+	err := s.db.QueryRowContext(ctx, query, arg).Scan(
+		&key.ID, &key.Scope, &key.Version, &key.Wrapped, &key.Active, &key.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading data key: %w", err)
+	}
+	return &key, nil
+}
@@ -0,0 +1,355 @@
+// Package postgres implements storage backed by PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.17.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+
+	"validation-service/pkg/logger"
+)
+
+// migrationsSchema records the applied schema migrations
+const migrationsSchema = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version       INTEGER PRIMARY KEY,
+	description   TEXT NOT NULL,
+	applied_at    TIMESTAMPTZ NOT NULL,
+	rows_affected BIGINT NOT NULL,
+	duration_ms   BIGINT NOT NULL
+);
+`
+
+// migrationLockID is the advisory lock serializing migrations between
+// replicas starting at the same time
+const migrationLockID = 7305521
+
+// upgradeBatchSize is the number of records a data migration rewrites per
+// batch
+const upgradeBatchSize = 500
+
+var (
+	schemaVersion = promauto.NewGauge(prometheus.GaugeOpts{
+		Name:        "storage_schema_version",
+		Help:        "Version of the latest schema migration applied to the database",
+		ConstLabels: prometheus.Labels{"service": "validation"},
+	})
+	pendingMigrations = promauto.NewGauge(prometheus.GaugeOpts{
+		Name:        "storage_migrations_pending",
+		Help:        "Schema migrations known to this build and not yet applied",
+		ConstLabels: prometheus.Labels{"service": "validation"},
+	})
+	migrationRows = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:        "storage_migration_rows_total",
+		Help:        "Rows changed by schema migrations by version and mode",
+		ConstLabels: prometheus.Labels{"service": "validation"},
+	}, []string{"version", "mode"})
+	upgradedOnRead = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:        "storage_documents_upgraded_on_read_total",
+		Help:        "Stored documents upgraded from an older schema version when read",
+		ConstLabels: prometheus.Labels{"service": "validation"},
+	}, []string{"document"})
+)
+
+// ErrMigrationsPending is returned when the database schema is older than
+// this build and migrations are not applied automatically
+var ErrMigrationsPending = errors.New("database schema migrations are pending")
+
+// Migration is a versioned change to the schema or the stored data. Apply
+// runs in a transaction and reports the rows it changed through progress;
+// migrations are applied in version order and never change once released.
+type Migration struct {
+	Version     int
+	Description string
+	Apply       func(ctx context.Context, tx *sql.Tx, progress func(rows int64)) error
+}
+
+// migrations are the schema migrations known to this build, in order
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "create result, translation memory, blob and key tables",
+		Apply:       execMigration(resultsSchema, translationMemorySchema, blobsSchema, keysSchema),
+	},
+	{
+		Version:     2,
+		Description: "persist issue categories and result schema versions",
+		Apply: execMigration(`
+ALTER TABLE validation_issues ADD COLUMN IF NOT EXISTS category TEXT NOT NULL DEFAULT '';
+ALTER TABLE validation_results ADD COLUMN IF NOT EXISTS schema_version INTEGER NOT NULL DEFAULT 1;
+CREATE INDEX IF NOT EXISTS validation_results_schema_version_idx ON validation_results (schema_version);
+`),
+	},
+	{
+		Version:     3,
+		Description: "upgrade stored validation results to the current result version",
+		Apply:       upgradeStoredResults,
+	},
+	{
+		Version:     4,
+		Description: "create notification subscription and digest queue tables",
+		Apply:       execMigration(notificationsSchema),
+	},
+	{
+		Version:     5,
+		Description: "persist issue IDs and the links between issues",
+		Apply: execMigration(`
+ALTER TABLE validation_issues ADD COLUMN IF NOT EXISTS issue_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE validation_issues ADD COLUMN IF NOT EXISTS caused_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE validation_issues ADD COLUMN IF NOT EXISTS related_to JSONB;
+`),
+	},
+}
+
+// execMigration returns a migration applying SQL statements
+func execMigration(statements ...string) func(context.Context, *sql.Tx, func(int64)) error {
+	return func(ctx context.Context, tx *sql.Tx, progress func(int64)) error {
+		for _, statement := range statements {
+			res, err := tx.ExecContext(ctx, statement)
+			if err != nil {
+				return err
+			}
+			if rows, err := res.RowsAffected(); err == nil {
+				progress(rows)
+			}
+		}
+		return nil
+	}
+}
+
+// MigrateOptions controls a migration run
+type MigrateOptions struct {
+	// DryRun applies the pending migrations in a transaction that is rolled
+	// back, reporting what would change
+	DryRun bool
+	// TargetVersion stops after the given version; zero applies all
+	TargetVersion int
+}
+
+// AppliedMigration is a migration applied, or dry-run, by a migration run
+type AppliedMigration struct {
+	Version      int           `json:"version"`
+	Description  string        `json:"description"`
+	RowsAffected int64         `json:"rows_affected"`
+	Duration     time.Duration `json:"duration"`
+}
+
+// MigrationReport summarizes a migration run
+type MigrationReport struct {
+	DryRun      bool               `json:"dry_run"`
+	FromVersion int                `json:"from_version"`
+	ToVersion   int                `json:"to_version"`
+	Applied     []AppliedMigration `json:"applied"`
+}
+
+// Migrator applies the schema migrations of this build to a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	log        *logger.Logger
+}
+
+// NewMigrator creates a migrator for the database
+func NewMigrator(db *sql.DB) *Migrator {
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		log:        logger.GetLogger(),
+	}
+}
+
+// LatestVersion returns the version of the last migration known to this build
+func (m *Migrator) LatestVersion() int {
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the version of the latest migration applied to the
+// database and the migrations pending
+func (m *Migrator) Version(ctx context.Context) (int, []Migration, error) {
+	if _, err := m.db.ExecContext(ctx, migrationsSchema); err != nil {
+		return 0, nil, fmt.Errorf("creating migrations schema: %w", err)
+	}
+	current, err := appliedVersion(ctx, m.db)
+	if err != nil {
+		return 0, nil, err
+	}
+	pending := m.pending(current, 0)
+	schemaVersion.Set(float64(current))
+	pendingMigrations.Set(float64(len(pending)))
+	return current, pending, nil
+}
+
+// Verify returns ErrMigrationsPending when the database is behind this build
+func (m *Migrator) Verify(ctx context.Context) error {
+	current, pending, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: database is at version %d, this build needs %d",
+			ErrMigrationsPending, current, m.LatestVersion())
+	}
+	return nil
+}
+
+// Migrate applies the pending migrations up to the target version. Each
+// migration is applied and recorded in its own transaction, so a failure
+// leaves the database at the last successful version. A dry run applies
+// every pending migration in one transaction and rolls it back.
+func (m *Migrator) Migrate(ctx context.Context, opts MigrateOptions) (*MigrationReport, error) {
+	current, _, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &MigrationReport{DryRun: opts.DryRun, FromVersion: current, ToVersion: current, Applied: []AppliedMigration{}}
+
+	if opts.DryRun {
+		tx, err := m.db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+		if err := m.apply(ctx, tx, opts, report); err != nil {
+			return report, err
+		}
+		return report, nil
+	}
+
+	for {
+		tx, err := m.db.BeginTx(ctx, nil)
+		if err != nil {
+			return report, err
+		}
+		before := len(report.Applied)
+		err = m.applyNext(ctx, tx, opts, report)
+		if err == nil {
+			err = tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+		if err != nil {
+			return report, err
+		}
+		if len(report.Applied) == before {
+			break
+		}
+		schemaVersion.Set(float64(report.ToVersion))
+		pendingMigrations.Set(float64(len(m.pending(report.ToVersion, 0))))
+	}
+	return report, nil
+}
+
+// apply applies every pending migration in tx
+func (m *Migrator) apply(ctx context.Context, tx *sql.Tx, opts MigrateOptions, report *MigrationReport) error {
+	for {
+		before := len(report.Applied)
+		if err := m.applyNext(ctx, tx, opts, report); err != nil {
+			return err
+		}
+		if len(report.Applied) == before {
+			return nil
+		}
+	}
+}
+
+// applyNext applies the migration after the version recorded in tx, if any.
+// The version is read under the migration lock, so a replica that waited
+// for another to finish does not apply the same migration again.
+func (m *Migrator) applyNext(ctx context.Context, tx *sql.Tx, opts MigrateOptions, report *MigrationReport) error {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("acquiring migration lock: %w", err)
+	}
+	current, err := appliedVersion(ctx, tx)
+	if err != nil {
+		return err
+	}
+	pending := m.pending(current, opts.TargetVersion)
+	if len(pending) == 0 {
+		report.ToVersion = current
+		return nil
+	}
+	migration := pending[0]
+
+	mode := "apply"
+	if opts.DryRun {
+		mode = "dry_run"
+	}
+	version := strconv.Itoa(migration.Version)
+	m.log.Info("Applying schema migration",
+		"version", migration.Version,
+		"description", migration.Description,
+		"dry_run", opts.DryRun,
+	)
+
+	start := time.Now()
+	var rows int64
+	progress := func(n int64) {
+		rows += n
+		migrationRows.WithLabelValues(version, mode).Add(float64(n))
+		if n > 0 {
+			m.log.Info("Schema migration progress",
+				"version", migration.Version,
+				"rows_affected", rows,
+			)
+		}
+	}
+	if err := migration.Apply(ctx, tx, progress); err != nil {
+		return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+	}
+	duration := time.Since(start)
+
+	if _, err := tx.ExecContext(ctx, `
+INSERT INTO schema_migrations (version, description, applied_at, rows_affected, duration_ms)
+VALUES ($1, $2, now(), $3, $4)`,
+		migration.Version, migration.Description, rows, duration.Milliseconds(),
+	); err != nil {
+		return fmt.Errorf("recording migration %d: %w", migration.Version, err)
+	}
+
+	report.Applied = append(report.Applied, AppliedMigration{
+		Version:      migration.Version,
+		Description:  migration.Description,
+		RowsAffected: rows,
+		Duration:     duration,
+	})
+	report.ToVersion = migration.Version
+	return nil
+}
+
+// pending returns the migrations after version current, up to target when
+// set
+func (m *Migrator) pending(current, target int) []Migration {
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if migration.Version <= current || (target > 0 && migration.Version > target) {
+			continue
+		}
+		pending = append(pending, migration)
+	}
+	return pending
+}
+
+// appliedVersion returns the latest applied migration version, or zero for a
+// database without migrations
+func appliedVersion(ctx context.Context, q querier) (int, error) {
+	var version int
+	if err := q.QueryRowContext(ctx,
+		"SELECT COALESCE(MAX(version), 0) FROM schema_migrations",
+	).Scan(&version); err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+	return version, nil
+}
+
+// querier runs queries on a database or in a transaction
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
@@ -0,0 +1,257 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/storage"
+)
+
+// notificationsSchema creates the subscription table and the queue of events
+// awaiting the next digest of a subscription
+const notificationsSchema = `
+CREATE TABLE IF NOT EXISTS notification_subscriptions (
+	id             UUID PRIMARY KEY,
+	tenant_id      TEXT NOT NULL,
+	user_id        TEXT NOT NULL,
+	channel_type   TEXT NOT NULL,
+	channel_target TEXT NOT NULL,
+	channel_secret TEXT NOT NULL DEFAULT '',
+	delivery       TEXT NOT NULL,
+	filter         JSONB NOT NULL,
+	last_digest_at TIMESTAMPTZ,
+	created_at     TIMESTAMPTZ NOT NULL,
+	updated_at     TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS notification_subscriptions_user_idx ON notification_subscriptions (tenant_id, user_id);
+
+CREATE TABLE IF NOT EXISTS notification_digest_events (
+	seq             BIGSERIAL PRIMARY KEY,
+	subscription_id UUID NOT NULL REFERENCES notification_subscriptions (id) ON DELETE CASCADE,
+	event           JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS notification_digest_events_subscription_idx ON notification_digest_events (subscription_id, seq);
+`
+
+const subscriptionColumns = `id, tenant_id, user_id, channel_type, channel_target, channel_secret, delivery, filter, last_digest_at, created_at, updated_at`
+
+// NotificationStore is a storage.NotificationStore backed by PostgreSQL
+type NotificationStore struct {
+	db *sql.DB
+}
+
+// NewNotificationStore creates the store, ensuring its tables exist
+func NewNotificationStore(ctx context.Context, db *sql.DB) (*NotificationStore, error) {
+	if _, err := db.ExecContext(ctx, notificationsSchema); err != nil {
+		return nil, fmt.Errorf("creating notifications schema: %w", err)
+	}
+	return &NotificationStore{db: db}, nil
+}
+
+// SaveSubscription upserts the subscription. A conflicting ID owned by
+// another tenant is left untouched.
+func (s *NotificationStore) SaveSubscription(ctx context.Context, subscription *storage.Subscription) error {
+	filter, err := json.Marshal(subscription.Filter)
+	if err != nil {
+		return fmt.Errorf("serializing subscription filter: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO notification_subscriptions (`+subscriptionColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (id) DO UPDATE SET
+			channel_type = EXCLUDED.channel_type,
+			channel_target = EXCLUDED.channel_target,
+			channel_secret = EXCLUDED.channel_secret,
+			delivery = EXCLUDED.delivery,
+			filter = EXCLUDED.filter,
+			updated_at = EXCLUDED.updated_at
+		WHERE notification_subscriptions.tenant_id = EXCLUDED.tenant_id`,
+		subscription.ID, subscription.TenantID, subscription.UserID,
+		subscription.Channel.Type, subscription.Channel.Target, subscription.Channel.Secret,
+		subscription.Delivery, filter, subscription.LastDigestAt,
+		subscription.CreatedAt, subscription.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("saving subscription: %w", err)
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return storage.ErrAlreadyExists
+	}
+	return nil
+}
+
+// GetSubscription returns the subscription with the given ID for a tenant
+func (s *NotificationStore) GetSubscription(ctx context.Context, tenantID string, id uuid.UUID) (*storage.Subscription, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT `+subscriptionColumns+` FROM notification_subscriptions WHERE id = $1 AND tenant_id = $2`,
+		id, tenantID)
+
+	subscription, err := scanSubscription(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	return subscription, err
+}
+
+// DeleteSubscription removes a subscription of a tenant; its queued events
+// are removed with it
+func (s *NotificationStore) DeleteSubscription(ctx context.Context, tenantID string, id uuid.UUID) error {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM notification_subscriptions WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("deleting subscription: %w", err)
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// ListSubscriptions returns the subscriptions of a user, oldest first
+func (s *NotificationStore) ListSubscriptions(ctx context.Context, tenantID, userID string) ([]*storage.Subscription, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+subscriptionColumns+` FROM notification_subscriptions
+		WHERE tenant_id = $1 AND ($2 = '' OR user_id = $2)
+		ORDER BY created_at, id`,
+		tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("listing subscriptions: %w", err)
+	}
+	defer rows.Close()
+	return scanSubscriptions(rows)
+}
+
+// ScanSubscriptions calls fn for every subscription. Subscriptions are read
+// before fn is called, so no cursor is held open while fn delivers.
+func (s *NotificationStore) ScanSubscriptions(ctx context.Context, fn func(*storage.Subscription) error) error {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+subscriptionColumns+` FROM notification_subscriptions ORDER BY created_at, id`)
+	if err != nil {
+		return fmt.Errorf("scanning subscriptions: %w", err)
+	}
+	subscriptions, err := scanSubscriptions(rows)
+	rows.Close()
+	if err != nil {
+		return err
+	}
+	for _, subscription := range subscriptions {
+		if err := fn(subscription); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueueDigestEvent adds the event to the subscription's digest queue
+func (s *NotificationStore) QueueDigestEvent(ctx context.Context, subscriptionID uuid.UUID, event *storage.NotificationEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("serializing notification event: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx,
+		`INSERT INTO notification_digest_events (subscription_id, event) VALUES ($1, $2)`,
+		subscriptionID, data); err != nil {
+		return fmt.Errorf("queueing digest event: %w", err)
+	}
+	return nil
+}
+
+// TakeDigestEvents deletes and returns the queued events of the subscription
+// and records the digest time in one transaction. The subscription row is
+// locked, so replicas composing the same digest take disjoint events.
+func (s *NotificationStore) TakeDigestEvents(ctx context.Context, subscriptionID uuid.UUID, digestAt time.Time) ([]*storage.NotificationEvent, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE notification_subscriptions SET last_digest_at = $2 WHERE id = $1`, subscriptionID, digestAt)
+	if err != nil {
+		return nil, fmt.Errorf("recording digest: %w", err)
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return nil, storage.ErrNotFound
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`DELETE FROM notification_digest_events WHERE subscription_id = $1 RETURNING seq, event`, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("taking digest events: %w", err)
+	}
+	type queuedEvent struct {
+		seq   int64
+		event *storage.NotificationEvent
+	}
+	var queued []queuedEvent
+	for rows.Next() {
+		var seq int64
+		var data []byte
+		if err := rows.Scan(&seq, &data); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		var event storage.NotificationEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("decoding digest event: %w", err)
+		}
+		queued = append(queued, queuedEvent{seq: seq, event: &event})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(queued, func(i, j int) bool { return queued[i].seq < queued[j].seq })
+	events := make([]*storage.NotificationEvent, len(queued))
+	for i, q := range queued {
+		events[i] = q.event
+	}
+	return events, nil
+}
+
+// scanSubscriptions scans every row selected with subscriptionColumns
+func scanSubscriptions(rows *sql.Rows) ([]*storage.Subscription, error) {
+	subscriptions := make([]*storage.Subscription, 0)
+	for rows.Next() {
+		subscription, err := scanSubscription(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, rows.Err()
+}
+
+// scanSubscription scans a row selected with subscriptionColumns
+func scanSubscription(scan func(dest ...interface{}) error) (*storage.Subscription, error) {
+	var subscription storage.Subscription
+	var filter []byte
+	var lastDigestAt sql.NullTime
+	if err := scan(
+		&subscription.ID, &subscription.TenantID, &subscription.UserID,
+		&subscription.Channel.Type, &subscription.Channel.Target, &subscription.Channel.Secret,
+		&subscription.Delivery, &filter, &lastDigestAt,
+		&subscription.CreatedAt, &subscription.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(filter, &subscription.Filter); err != nil {
+		return nil, fmt.Errorf("decoding subscription filter: %w", err)
+	}
+	if lastDigestAt.Valid {
+		subscription.LastDigestAt = &lastDigestAt.Time
+	}
+	return &subscription, nil
+}
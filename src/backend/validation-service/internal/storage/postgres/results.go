@@ -0,0 +1,472 @@
+// Package postgres implements storage backed by PostgreSQL.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/models"
+	"validation-service/internal/storage"
+)
+
+// resultsSchema creates the validation result tables. Later changes are
+// schema migrations.
+const resultsSchema = `
+CREATE TABLE IF NOT EXISTS validation_results (
+	id                      UUID PRIMARY KEY,
+	tenant_id               TEXT NOT NULL,
+	created_at              TIMESTAMPTZ NOT NULL,
+	status                  TEXT NOT NULL,
+	confidence_score        DOUBLE PRECISION NOT NULL,
+	source_format           TEXT NOT NULL,
+	target_format           TEXT NOT NULL,
+	metadata                JSONB NOT NULL DEFAULT '{}',
+	format_specific_details JSONB NOT NULL DEFAULT '{}'
+);
+CREATE INDEX IF NOT EXISTS validation_results_tenant_created_idx ON validation_results (tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS validation_results_tenant_status_idx ON validation_results (tenant_id, status);
+
+CREATE TABLE IF NOT EXISTS validation_issues (
+	result_id      UUID NOT NULL REFERENCES validation_results (id) ON DELETE CASCADE,
+	position       INTEGER NOT NULL,
+	message        TEXT NOT NULL,
+	severity       TEXT NOT NULL,
+	location       TEXT NOT NULL,
+	line           INTEGER NOT NULL DEFAULT 0,
+	column_number  INTEGER NOT NULL DEFAULT 0,
+	issued_at      TIMESTAMPTZ NOT NULL,
+	issue_code     TEXT NOT NULL,
+	remediation    TEXT NOT NULL,
+	issue_metadata JSONB,
+	PRIMARY KEY (result_id, position)
+);
+CREATE INDEX IF NOT EXISTS validation_issues_code_idx ON validation_issues (issue_code);
+
+CREATE TABLE IF NOT EXISTS validation_history (
+	result_id   UUID NOT NULL REFERENCES validation_results (id) ON DELETE CASCADE,
+	position    INTEGER NOT NULL,
+	occurred_at TIMESTAMPTZ NOT NULL,
+	action      TEXT NOT NULL,
+	details     JSONB,
+	PRIMARY KEY (result_id, position)
+);
+`
+
+const resultColumns = `id, created_at, status, confidence_score, source_format, target_format, metadata, format_specific_details, schema_version`
+
+// ResultStore is a storage.ResultStore backed by PostgreSQL
+type ResultStore struct {
+	db *sql.DB
+}
+
+// NewResultStore creates the store, ensuring its tables exist. The columns
+// added since are created by the schema migrations, which must be applied
+// before the store is used.
+func NewResultStore(ctx context.Context, db *sql.DB) (*ResultStore, error) {
+	if _, err := db.ExecContext(ctx, resultsSchema); err != nil {
+		return nil, fmt.Errorf("creating results schema: %w", err)
+	}
+	return &ResultStore{db: db}, nil
+}
+
+// SaveResult upserts the result and replaces its issues and history
+func (s *ResultStore) SaveResult(ctx context.Context, tenantID string, result *models.ValidationResult) error {
+	metadata, err := json.Marshal(result.Metadata)
+	if err != nil {
+		return fmt.Errorf("serializing metadata: %w", err)
+	}
+	details, err := json.Marshal(result.FormatSpecificDetails)
+	if err != nil {
+		return fmt.Errorf("serializing format details: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+INSERT INTO validation_results (`+resultColumns+`, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (id) DO UPDATE SET
+	status = EXCLUDED.status,
+	confidence_score = EXCLUDED.confidence_score,
+	metadata = EXCLUDED.metadata,
+	format_specific_details = EXCLUDED.format_specific_details,
+	schema_version = EXCLUDED.schema_version
+WHERE validation_results.tenant_id = EXCLUDED.tenant_id`,
+		result.ID, result.CreatedAt, result.Status, result.ConfidenceScore,
+		result.SourceFormat, result.TargetFormat, metadata, details,
+		storage.ResultSchemaVersion, tenantID,
+	)
+	if err != nil {
+		return fmt.Errorf("saving result: %w", err)
+	}
+	// A conflicting ID owned by another tenant is left untouched
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return storage.ErrAlreadyExists
+	}
+
+	for _, table := range []string{"validation_issues", "validation_history"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE result_id = $1", result.ID); err != nil {
+			return fmt.Errorf("clearing %s: %w", table, err)
+		}
+	}
+
+	for i, issue := range result.Issues {
+		issueMetadata, err := json.Marshal(issue.IssueMetadata)
+		if err != nil {
+			return fmt.Errorf("serializing issue metadata: %w", err)
+		}
+		var relatedTo []byte
+		if len(issue.RelatedTo) > 0 {
+			if relatedTo, err = json.Marshal(issue.RelatedTo); err != nil {
+				return fmt.Errorf("serializing related issues: %w", err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO validation_issues
+	(result_id, position, message, severity, location, line, column_number, issued_at, issue_code, remediation, issue_metadata, category,
+	 issue_id, caused_by, related_to)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+			result.ID, i, issue.Message, issue.Severity, issue.Location, issue.Line, issue.Column,
+			issue.Timestamp, issue.IssueCode, issue.Remediation, issueMetadata, issue.Category,
+			issue.ID, issue.CausedBy, relatedTo,
+		); err != nil {
+			return fmt.Errorf("saving issue %d: %w", i, err)
+		}
+	}
+
+	for i, entry := range result.ValidationHistory {
+		entryDetails, err := json.Marshal(entry.Details)
+		if err != nil {
+			return fmt.Errorf("serializing history details: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO validation_history (result_id, position, occurred_at, action, details)
+VALUES ($1, $2, $3, $4, $5)`,
+			result.ID, i, entry.Timestamp, entry.Action, entryDetails,
+		); err != nil {
+			return fmt.Errorf("saving history entry %d: %w", i, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetResult loads a result with its issues and history
+func (s *ResultStore) GetResult(ctx context.Context, tenantID string, id uuid.UUID) (*models.ValidationResult, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT `+resultColumns+` FROM validation_results WHERE id = $1 AND tenant_id = $2`,
+		id, tenantID)
+
+	result, version, err := scanResult(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.TenantID = tenantID
+
+	results := []*models.ValidationResult{result}
+	if err := loadResultChildren(ctx, s.db, results); err != nil {
+		return nil, err
+	}
+	if err := upgradeResults(results, map[uuid.UUID]int{result.ID: version}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListResults filters results of the tenant, newest first
+func (s *ResultStore) ListResults(ctx context.Context, query storage.ResultQuery) ([]*models.ValidationResult, int, error) {
+	if err := query.Normalize(); err != nil {
+		return nil, 0, err
+	}
+
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{query.TenantID}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if query.Format != "" {
+		format := arg(query.Format)
+		conditions = append(conditions, fmt.Sprintf("(source_format = %[1]s OR target_format = %[1]s)", format))
+	}
+	if query.Status != "" {
+		conditions = append(conditions, "status = "+arg(query.Status))
+	}
+	if query.From != nil {
+		conditions = append(conditions, "created_at >= "+arg(*query.From))
+	}
+	if query.To != nil {
+		conditions = append(conditions, "created_at <= "+arg(*query.To))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := s.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM validation_results WHERE "+where, args...,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("counting results: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT %s FROM validation_results WHERE %s ORDER BY created_at DESC, id LIMIT %s OFFSET %s",
+		resultColumns, where, arg(query.Limit), arg(query.Offset)), args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("listing results: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]*models.ValidationResult, 0, query.Limit)
+	versions := make(map[uuid.UUID]int, query.Limit)
+	for rows.Next() {
+		result, version, err := scanResult(rows.Scan)
+		if err != nil {
+			return nil, 0, err
+		}
+		result.TenantID = query.TenantID
+		results = append(results, result)
+		versions[result.ID] = version
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := loadResultChildren(ctx, s.db, results); err != nil {
+		return nil, 0, err
+	}
+	if err := upgradeResults(results, versions); err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
+
+// ScanResults calls fn for every stored result in batches ordered by ID, so
+// no cursor is held open while fn saves results
+func (s *ResultStore) ScanResults(ctx context.Context, fn func(tenantID string, result *models.ValidationResult) error) error {
+	after := uuid.Nil
+	for {
+		rows, err := s.db.QueryContext(ctx,
+			`SELECT `+resultColumns+`, tenant_id FROM validation_results WHERE id > $1 ORDER BY id LIMIT $2`,
+			after, upgradeBatchSize)
+		if err != nil {
+			return fmt.Errorf("scanning results: %w", err)
+		}
+		var results []*models.ValidationResult
+		versions := make(map[uuid.UUID]int, upgradeBatchSize)
+		tenants := make(map[uuid.UUID]string, upgradeBatchSize)
+		for rows.Next() {
+			var tenantID string
+			result, version, err := scanResult(func(dest ...interface{}) error {
+				return rows.Scan(append(dest, &tenantID)...)
+			})
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			result.TenantID = tenantID
+			results = append(results, result)
+			versions[result.ID] = version
+			tenants[result.ID] = tenantID
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			return nil
+		}
+
+		if err := loadResultChildren(ctx, s.db, results); err != nil {
+			return err
+		}
+		if err := upgradeResults(results, versions); err != nil {
+			return err
+		}
+		for _, result := range results {
+			if err := fn(tenants[result.ID], result); err != nil {
+				return err
+			}
+		}
+		after = results[len(results)-1].ID
+	}
+}
+
+// scanResult scans a row selected with resultColumns and returns the result
+// with the schema version it was stored at
+func scanResult(scan func(dest ...interface{}) error) (*models.ValidationResult, int, error) {
+	var result models.ValidationResult
+	var metadata, details []byte
+	var version int
+	if err := scan(&result.ID, &result.CreatedAt, &result.Status, &result.ConfidenceScore,
+		&result.SourceFormat, &result.TargetFormat, &metadata, &details, &version); err != nil {
+		return nil, 0, err
+	}
+	if err := json.Unmarshal(metadata, &result.Metadata); err != nil {
+		return nil, 0, fmt.Errorf("decoding metadata of result %s: %w", result.ID, err)
+	}
+	if err := json.Unmarshal(details, &result.FormatSpecificDetails); err != nil {
+		return nil, 0, fmt.Errorf("decoding format details of result %s: %w", result.ID, err)
+	}
+	result.Issues = make([]models.ValidationIssue, 0)
+	result.ValidationHistory = make([]models.ValidationHistoryEntry, 0)
+	return &result, version, nil
+}
+
+// upgradeResults upgrades results stored at an older schema version. The
+// stored rows are rewritten by the result upgrade migration, not on read.
+func upgradeResults(results []*models.ValidationResult, versions map[uuid.UUID]int) error {
+	for _, result := range results {
+		upgraded, err := storage.UpgradeResult(versions[result.ID], result)
+		if err != nil {
+			return err
+		}
+		if upgraded {
+			upgradedOnRead.WithLabelValues("validation_result").Inc()
+		}
+	}
+	return nil
+}
+
+// loadResultChildren fills in issues and history for results in two queries
+func loadResultChildren(ctx context.Context, q querier, results []*models.ValidationResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*models.ValidationResult, len(results))
+	ids := make([]string, 0, len(results))
+	for _, result := range results {
+		byID[result.ID] = result
+		ids = append(ids, result.ID.String())
+	}
+	idArray := "{" + strings.Join(ids, ",") + "}"
+
+	issueRows, err := q.QueryContext(ctx, `
+SELECT result_id, message, severity, location, line, column_number, issued_at, issue_code, remediation, issue_metadata, category,
+	issue_id, caused_by, related_to
+FROM validation_issues WHERE result_id = ANY($1::uuid[]) ORDER BY result_id, position`, idArray)
+	if err != nil {
+		return fmt.Errorf("loading issues: %w", err)
+	}
+	defer issueRows.Close()
+
+	for issueRows.Next() {
+		var resultID uuid.UUID
+		var issue models.ValidationIssue
+		var issueMetadata, relatedTo []byte
+		if err := issueRows.Scan(&resultID, &issue.Message, &issue.Severity, &issue.Location,
+			&issue.Line, &issue.Column, &issue.Timestamp, &issue.IssueCode, &issue.Remediation,
+			&issueMetadata, &issue.Category, &issue.ID, &issue.CausedBy, &relatedTo); err != nil {
+			return err
+		}
+		if len(issueMetadata) > 0 {
+			if err := json.Unmarshal(issueMetadata, &issue.IssueMetadata); err != nil {
+				return fmt.Errorf("decoding issue metadata: %w", err)
+			}
+		}
+		if len(relatedTo) > 0 {
+			if err := json.Unmarshal(relatedTo, &issue.RelatedTo); err != nil {
+				return fmt.Errorf("decoding related issues: %w", err)
+			}
+		}
+		byID[resultID].Issues = append(byID[resultID].Issues, issue)
+	}
+	if err := issueRows.Err(); err != nil {
+		return err
+	}
+
+	historyRows, err := q.QueryContext(ctx, `
+SELECT result_id, occurred_at, action, details
+FROM validation_history WHERE result_id = ANY($1::uuid[]) ORDER BY result_id, position`, idArray)
+	if err != nil {
+		return fmt.Errorf("loading history: %w", err)
+	}
+	defer historyRows.Close()
+
+	for historyRows.Next() {
+		var resultID uuid.UUID
+		var entry models.ValidationHistoryEntry
+		var entryDetails []byte
+		if err := historyRows.Scan(&resultID, &entry.Timestamp, &entry.Action, &entryDetails); err != nil {
+			return err
+		}
+		if len(entryDetails) > 0 {
+			if err := json.Unmarshal(entryDetails, &entry.Details); err != nil {
+				return fmt.Errorf("decoding history details: %w", err)
+			}
+		}
+		byID[resultID].ValidationHistory = append(byID[resultID].ValidationHistory, entry)
+	}
+	return historyRows.Err()
+}
+
+// upgradeStoredResults is the data migration rewriting results stored at an
+// older schema version, in batches, so on-read upgrades become unnecessary
+func upgradeStoredResults(ctx context.Context, tx *sql.Tx, progress func(rows int64)) error {
+	for {
+		rows, err := tx.QueryContext(ctx,
+			`SELECT `+resultColumns+` FROM validation_results WHERE schema_version < $1 ORDER BY id LIMIT $2`,
+			storage.ResultSchemaVersion, upgradeBatchSize)
+		if err != nil {
+			return fmt.Errorf("selecting results to upgrade: %w", err)
+		}
+		var results []*models.ValidationResult
+		versions := make(map[uuid.UUID]int, upgradeBatchSize)
+		for rows.Next() {
+			result, version, err := scanResult(rows.Scan)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			results = append(results, result)
+			versions[result.ID] = version
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			return nil
+		}
+
+		if err := loadResultChildren(ctx, tx, results); err != nil {
+			return err
+		}
+		for _, result := range results {
+			if _, err := storage.UpgradeResult(versions[result.ID], result); err != nil {
+				return err
+			}
+			for i, issue := range result.Issues {
+				if issue.Category == "" {
+					continue
+				}
+				if _, err := tx.ExecContext(ctx,
+					`UPDATE validation_issues SET category = $3 WHERE result_id = $1 AND position = $2`,
+					result.ID, i, issue.Category,
+				); err != nil {
+					return fmt.Errorf("upgrading issues of result %s: %w", result.ID, err)
+				}
+			}
+			if _, err := tx.ExecContext(ctx,
+				`UPDATE validation_results SET schema_version = $2 WHERE id = $1`,
+				result.ID, storage.ResultSchemaVersion,
+			); err != nil {
+				return fmt.Errorf("upgrading result %s: %w", result.ID, err)
+			}
+		}
+		progress(int64(len(results)))
+	}
+}
@@ -0,0 +1,245 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"         // v1.4.0
+	"github.com/jackc/pgx/v5/pgconn" // v5.5.0
+
+	"validation-service/internal/storage"
+)
+
+// schedulesSchema creates the schedule table. The run state columns are only
+// written by run claims and completions, never by definition updates.
+const schedulesSchema = `
+CREATE TABLE IF NOT EXISTS validation_schedules (
+	id           UUID PRIMARY KEY,
+	tenant_id    TEXT NOT NULL,
+	name         TEXT NOT NULL,
+	cron         TEXT NOT NULL,
+	timezone     TEXT NOT NULL,
+	job          JSONB NOT NULL,
+	paused       BOOLEAN NOT NULL DEFAULT FALSE,
+	next_run_at  TIMESTAMPTZ,
+	last_run     JSONB,
+	lease_until  TIMESTAMPTZ,
+	skipped_runs INTEGER NOT NULL DEFAULT 0,
+	created_by   TEXT NOT NULL DEFAULT '',
+	created_at   TIMESTAMPTZ NOT NULL,
+	updated_at   TIMESTAMPTZ NOT NULL,
+	UNIQUE (tenant_id, name)
+);
+CREATE INDEX IF NOT EXISTS validation_schedules_due_idx ON validation_schedules (next_run_at) WHERE NOT paused;
+`
+
+const scheduleColumns = `id, tenant_id, name, cron, timezone, job, paused, next_run_at, last_run, lease_until, skipped_runs, created_by, created_at, updated_at`
+
+// ScheduleStore is a storage.ScheduleStore backed by PostgreSQL
+type ScheduleStore struct {
+	db *sql.DB
+}
+
+// NewScheduleStore creates the store, ensuring its table exists
+func NewScheduleStore(ctx context.Context, db *sql.DB) (*ScheduleStore, error) {
+	if _, err := db.ExecContext(ctx, schedulesSchema); err != nil {
+		return nil, fmt.Errorf("creating schedules schema: %w", err)
+	}
+	return &ScheduleStore{db: db}, nil
+}
+
+// SaveSchedule upserts the definition of the schedule. A conflicting ID
+// owned by another tenant is left untouched.
+func (s *ScheduleStore) SaveSchedule(ctx context.Context, schedule *storage.Schedule) error {
+	job, err := json.Marshal(schedule.Job)
+	if err != nil {
+		return fmt.Errorf("serializing schedule job: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO validation_schedules (id, tenant_id, name, cron, timezone, job, paused, next_run_at, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			cron = EXCLUDED.cron,
+			timezone = EXCLUDED.timezone,
+			job = EXCLUDED.job,
+			paused = EXCLUDED.paused,
+			next_run_at = EXCLUDED.next_run_at,
+			updated_at = EXCLUDED.updated_at
+		WHERE validation_schedules.tenant_id = EXCLUDED.tenant_id`,
+		schedule.ID, schedule.TenantID, schedule.Name, schedule.Cron, schedule.Timezone, job,
+		schedule.Paused, schedule.NextRunAt, schedule.CreatedBy, schedule.CreatedAt, schedule.UpdatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return storage.ErrAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("saving schedule: %w", err)
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return storage.ErrAlreadyExists
+	}
+	return nil
+}
+
+// GetSchedule returns the schedule with the given ID for a tenant
+func (s *ScheduleStore) GetSchedule(ctx context.Context, tenantID string, id uuid.UUID) (*storage.Schedule, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT `+scheduleColumns+` FROM validation_schedules WHERE id = $1 AND tenant_id = $2`,
+		id, tenantID)
+
+	schedule, err := scanSchedule(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	return schedule, err
+}
+
+// DeleteSchedule removes a schedule of a tenant
+func (s *ScheduleStore) DeleteSchedule(ctx context.Context, tenantID string, id uuid.UUID) error {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM validation_schedules WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("deleting schedule: %w", err)
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// ListSchedules returns the schedules of a tenant, oldest first
+func (s *ScheduleStore) ListSchedules(ctx context.Context, tenantID string) ([]*storage.Schedule, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+scheduleColumns+` FROM validation_schedules WHERE tenant_id = $1 ORDER BY created_at, id`,
+		tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("listing schedules: %w", err)
+	}
+	defer rows.Close()
+	return scanSchedules(rows)
+}
+
+// DueSchedules returns the unpaused schedules due at now, earliest first
+func (s *ScheduleStore) DueSchedules(ctx context.Context, now time.Time) ([]*storage.Schedule, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+scheduleColumns+` FROM validation_schedules
+		WHERE NOT paused AND next_run_at <= $1
+		ORDER BY next_run_at, id`,
+		now)
+	if err != nil {
+		return nil, fmt.Errorf("listing due schedules: %w", err)
+	}
+	defer rows.Close()
+	return scanSchedules(rows)
+}
+
+// ClaimScheduleRun starts or skips the run due at due. The schedule row is
+// locked, so of several replicas claiming the same due time one succeeds.
+func (s *ScheduleStore) ClaimScheduleRun(ctx context.Context, id uuid.UUID, due, next time.Time, run *storage.ScheduleRun, leaseUntil time.Time) (bool, error) {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return false, fmt.Errorf("serializing schedule run: %w", err)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var paused bool
+	var nextRunAt, currentLease sql.NullTime
+	err = tx.QueryRowContext(ctx,
+		`SELECT paused, next_run_at, lease_until FROM validation_schedules WHERE id = $1 FOR UPDATE`,
+		id).Scan(&paused, &nextRunAt, &currentLease)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, storage.ErrNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("locking schedule: %w", err)
+	}
+	if paused || !nextRunAt.Valid || !nextRunAt.Time.Equal(due) {
+		return false, nil
+	}
+
+	started := !currentLease.Valid || !currentLease.Time.After(run.StartedAt)
+	if started {
+		_, err = tx.ExecContext(ctx,
+			`UPDATE validation_schedules SET next_run_at = $2, last_run = $3, lease_until = $4 WHERE id = $1`,
+			id, next, data, leaseUntil)
+	} else {
+		_, err = tx.ExecContext(ctx,
+			`UPDATE validation_schedules SET next_run_at = $2, skipped_runs = skipped_runs + 1 WHERE id = $1`,
+			id, next)
+	}
+	if err != nil {
+		return false, fmt.Errorf("claiming schedule run: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return started, nil
+}
+
+// FinishScheduleRun records the outcome of the run if it is still the last
+// one
+func (s *ScheduleStore) FinishScheduleRun(ctx context.Context, id uuid.UUID, run *storage.ScheduleRun) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("serializing schedule run: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE validation_schedules SET last_run = $2, lease_until = NULL
+		WHERE id = $1 AND (last_run->>'scheduled_at')::timestamptz = $3`,
+		id, data, run.ScheduledAt); err != nil {
+		return fmt.Errorf("finishing schedule run: %w", err)
+	}
+	return nil
+}
+
+// scanSchedules scans every row selected with scheduleColumns
+func scanSchedules(rows *sql.Rows) ([]*storage.Schedule, error) {
+	schedules := make([]*storage.Schedule, 0)
+	for rows.Next() {
+		schedule, err := scanSchedule(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+// scanSchedule scans a row selected with scheduleColumns
+func scanSchedule(scan func(dest ...interface{}) error) (*storage.Schedule, error) {
+	var schedule storage.Schedule
+	var job, lastRun []byte
+	var nextRunAt, leaseUntil sql.NullTime
+	if err := scan(
+		&schedule.ID, &schedule.TenantID, &schedule.Name, &schedule.Cron, &schedule.Timezone, &job,
+		&schedule.Paused, &nextRunAt, &lastRun, &leaseUntil, &schedule.SkippedRuns,
+		&schedule.CreatedBy, &schedule.CreatedAt, &schedule.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(job, &schedule.Job); err != nil {
+		return nil, fmt.Errorf("decoding schedule job: %w", err)
+	}
+	if lastRun != nil {
+		schedule.LastRun = &storage.ScheduleRun{}
+		if err := json.Unmarshal(lastRun, schedule.LastRun); err != nil {
+			return nil, fmt.Errorf("decoding schedule run: %w", err)
+		}
+	}
+	if nextRunAt.Valid {
+		schedule.NextRunAt = &nextRunAt.Time
+	}
+	if leaseUntil.Valid {
+		schedule.LeaseUntil = &leaseUntil.Time
+	}
+	return &schedule, nil
+}
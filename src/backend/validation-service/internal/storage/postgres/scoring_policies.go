@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"validation-service/internal/storage"
+)
+
+// scoringPoliciesSchema creates the table of tenant scoring policies
+const scoringPoliciesSchema = `
+CREATE TABLE IF NOT EXISTS validation_scoring_policies (
+	tenant_id  TEXT PRIMARY KEY,
+	module     TEXT NOT NULL,
+	digest     TEXT NOT NULL,
+	updated_by TEXT NOT NULL DEFAULT '',
+	updated_at TIMESTAMPTZ NOT NULL
+);
+`
+
+// ScoringPolicyStore is a storage.ScoringPolicyStore backed by PostgreSQL
+type ScoringPolicyStore struct {
+	db *sql.DB
+}
+
+// NewScoringPolicyStore creates the store, ensuring its table exists
+func NewScoringPolicyStore(ctx context.Context, db *sql.DB) (*ScoringPolicyStore, error) {
+	if _, err := db.ExecContext(ctx, scoringPoliciesSchema); err != nil {
+		return nil, fmt.Errorf("creating scoring policies schema: %w", err)
+	}
+	return &ScoringPolicyStore{db: db}, nil
+}
+
+// SaveScoringPolicy upserts the policy of a tenant
+func (s *ScoringPolicyStore) SaveScoringPolicy(ctx context.Context, policy *storage.ScoringPolicy) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO validation_scoring_policies (tenant_id, module, digest, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			module = EXCLUDED.module,
+			digest = EXCLUDED.digest,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at`,
+		policy.TenantID, policy.Module, policy.Digest, policy.UpdatedBy, policy.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("saving scoring policy: %w", err)
+	}
+	return nil
+}
+
+// GetScoringPolicy returns the policy of a tenant
+func (s *ScoringPolicyStore) GetScoringPolicy(ctx context.Context, tenantID string) (*storage.ScoringPolicy, error) {
+	policy := &storage.ScoringPolicy{TenantID: tenantID}
+	err := s.db.QueryRowContext(ctx,
+		`SELECT module, digest, updated_by, updated_at FROM validation_scoring_policies WHERE tenant_id = $1`,
+		tenantID).Scan(&policy.Module, &policy.Digest, &policy.UpdatedBy, &policy.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading scoring policy: %w", err)
+	}
+	return policy, nil
+}
+
+// DeleteScoringPolicy removes the policy of a tenant
+func (s *ScoringPolicyStore) DeleteScoringPolicy(ctx context.Context, tenantID string) error {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM validation_scoring_policies WHERE tenant_id = $1`, tenantID)
+	if err != nil {
+		return fmt.Errorf("deleting scoring policy: %w", err)
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
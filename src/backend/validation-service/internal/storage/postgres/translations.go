@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/storage"
+)
+
+// translationMemorySchema creates the approved mapping table. source_key is
+// the normalized source expression; one mapping is kept per key.
+const translationMemorySchema = `
+CREATE TABLE IF NOT EXISTS approved_mappings (
+	id                UUID PRIMARY KEY,
+	tenant_id         TEXT NOT NULL,
+	source_format     TEXT NOT NULL,
+	target_format     TEXT NOT NULL,
+	source_key        TEXT NOT NULL,
+	source_expression TEXT NOT NULL,
+	target_expression TEXT NOT NULL,
+	target_table      TEXT NOT NULL DEFAULT '',
+	approved_by       TEXT NOT NULL,
+	approved_at       TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS approved_mappings_source_key_idx
+	ON approved_mappings (tenant_id, source_format, target_format, source_key);
+`
+
+const mappingColumns = `id, tenant_id, source_format, target_format, source_expression, target_expression, target_table, approved_by, approved_at`
+
+// TranslationMemoryStore is a storage.TranslationMemoryStore backed by PostgreSQL
+type TranslationMemoryStore struct {
+	db *sql.DB
+}
+
+// NewTranslationMemoryStore creates the store, ensuring its table exists
+func NewTranslationMemoryStore(ctx context.Context, db *sql.DB) (*TranslationMemoryStore, error) {
+	if _, err := db.ExecContext(ctx, translationMemorySchema); err != nil {
+		return nil, fmt.Errorf("creating translation memory schema: %w", err)
+	}
+	return &TranslationMemoryStore{db: db}, nil
+}
+
+// SaveMapping inserts the mapping, replacing an earlier approval of the same
+// source expression between the same formats
+func (s *TranslationMemoryStore) SaveMapping(ctx context.Context, mapping *storage.ApprovedMapping) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO approved_mappings (`+mappingColumns+`, source_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (tenant_id, source_format, target_format, source_key) DO UPDATE SET
+			id = EXCLUDED.id,
+			source_expression = EXCLUDED.source_expression,
+			target_expression = EXCLUDED.target_expression,
+			target_table = EXCLUDED.target_table,
+			approved_by = EXCLUDED.approved_by,
+			approved_at = EXCLUDED.approved_at`,
+		mapping.ID, mapping.TenantID, mapping.SourceFormat, mapping.TargetFormat,
+		mapping.SourceExpression, mapping.TargetExpression, mapping.TargetTable,
+		mapping.ApprovedBy, mapping.ApprovedAt,
+		storage.NormalizeExpression(mapping.SourceExpression))
+	if err != nil {
+		return fmt.Errorf("saving approved mapping: %w", err)
+	}
+	return nil
+}
+
+// GetMapping returns the mapping with the given ID for a tenant
+func (s *TranslationMemoryStore) GetMapping(ctx context.Context, tenantID string, id uuid.UUID) (*storage.ApprovedMapping, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT `+mappingColumns+` FROM approved_mappings WHERE id = $1 AND tenant_id = $2`,
+		id, tenantID)
+
+	mapping, err := scanMapping(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	return mapping, err
+}
+
+// DeleteMapping removes a mapping of a tenant
+func (s *TranslationMemoryStore) DeleteMapping(ctx context.Context, tenantID string, id uuid.UUID) error {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM approved_mappings WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("deleting approved mapping: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// ListMappings filters mappings of the tenant, most recently approved first
+func (s *TranslationMemoryStore) ListMappings(ctx context.Context, query storage.MappingQuery) ([]*storage.ApprovedMapping, int, error) {
+	if err := query.Normalize(); err != nil {
+		return nil, 0, err
+	}
+
+	conditions := []string{"tenant_id = $1"}
+	args := []interface{}{query.TenantID}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if query.SourceFormat != "" {
+		conditions = append(conditions, "source_format = "+arg(query.SourceFormat))
+	}
+	if query.TargetFormat != "" {
+		conditions = append(conditions, "target_format = "+arg(query.TargetFormat))
+	}
+	if query.SourceExpression != "" {
+		conditions = append(conditions, "source_key = "+arg(storage.NormalizeExpression(query.SourceExpression)))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := s.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM approved_mappings WHERE "+where, args...,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("counting approved mappings: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT %s FROM approved_mappings WHERE %s ORDER BY approved_at DESC, id LIMIT %s OFFSET %s",
+		mappingColumns, where, arg(query.Limit), arg(query.Offset)), args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("listing approved mappings: %w", err)
+	}
+	defer rows.Close()
+
+	mappings := make([]*storage.ApprovedMapping, 0, query.Limit)
+	for rows.Next() {
+		mapping, err := scanMapping(rows.Scan)
+		if err != nil {
+			return nil, 0, err
+		}
+		mappings = append(mappings, mapping)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return mappings, total, nil
+}
+
+// scanMapping scans a row selected with mappingColumns
+func scanMapping(scan func(dest ...interface{}) error) (*storage.ApprovedMapping, error) {
+	var mapping storage.ApprovedMapping
+	if err := scan(&mapping.ID, &mapping.TenantID, &mapping.SourceFormat, &mapping.TargetFormat,
+		&mapping.SourceExpression, &mapping.TargetExpression, &mapping.TargetTable,
+		&mapping.ApprovedBy, &mapping.ApprovedAt); err != nil {
+		return nil, err
+	}
+	return &mapping, nil
+}
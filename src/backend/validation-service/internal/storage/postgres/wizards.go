@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/storage"
+)
+
+// wizardsSchema creates the migration wizard table. The rules, targets, run,
+// results and deployment of a wizard are kept together as its JSON state.
+const wizardsSchema = `
+CREATE TABLE IF NOT EXISTS migration_wizards (
+	id         UUID PRIMARY KEY,
+	tenant_id  TEXT NOT NULL,
+	name       TEXT NOT NULL,
+	step       TEXT NOT NULL,
+	state      JSONB NOT NULL,
+	version    INTEGER NOT NULL,
+	created_by TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS migration_wizards_tenant_idx ON migration_wizards (tenant_id, created_at);
+`
+
+const wizardColumns = `id, tenant_id, name, step, state, version, created_by, created_at, updated_at`
+
+// wizardState is the JSON state of a wizard
+type wizardState struct {
+	Rules      []storage.WizardRule      `json:"rules,omitempty"`
+	Targets    []storage.WizardTarget    `json:"targets,omitempty"`
+	Run        *storage.WizardRun        `json:"run,omitempty"`
+	Results    []storage.WizardResult    `json:"results,omitempty"`
+	Deployment *storage.WizardDeployment `json:"deployment,omitempty"`
+}
+
+// MigrationWizardStore is a storage.MigrationWizardStore backed by
+// PostgreSQL
+type MigrationWizardStore struct {
+	db *sql.DB
+}
+
+// NewMigrationWizardStore creates the store, ensuring its table exists
+func NewMigrationWizardStore(ctx context.Context, db *sql.DB) (*MigrationWizardStore, error) {
+	if _, err := db.ExecContext(ctx, wizardsSchema); err != nil {
+		return nil, fmt.Errorf("creating migration wizards schema: %w", err)
+	}
+	return &MigrationWizardStore{db: db}, nil
+}
+
+// CreateMigrationWizard inserts the wizard as version 1
+func (s *MigrationWizardStore) CreateMigrationWizard(ctx context.Context, wizard *storage.MigrationWizard) error {
+	state, err := marshalWizardState(wizard)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO migration_wizards (`+wizardColumns+`)
+		VALUES ($1, $2, $3, $4, $5, 1, $6, $7, $8)
+		ON CONFLICT (id) DO NOTHING`,
+		wizard.ID, wizard.TenantID, wizard.Name, wizard.Step, state,
+		wizard.CreatedBy, wizard.CreatedAt, wizard.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("creating migration wizard: %w", err)
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return storage.ErrAlreadyExists
+	}
+	wizard.Version = 1
+	return nil
+}
+
+// GetMigrationWizard returns the wizard with the given ID for a tenant
+func (s *MigrationWizardStore) GetMigrationWizard(ctx context.Context, tenantID string, id uuid.UUID) (*storage.MigrationWizard, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT `+wizardColumns+` FROM migration_wizards WHERE id = $1 AND tenant_id = $2`,
+		id, tenantID)
+
+	wizard, err := scanWizard(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotFound
+	}
+	return wizard, err
+}
+
+// UpdateMigrationWizard replaces the wizard if it is still at the version
+// the update is based on
+func (s *MigrationWizardStore) UpdateMigrationWizard(ctx context.Context, wizard *storage.MigrationWizard) error {
+	state, err := marshalWizardState(wizard)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE migration_wizards SET name = $4, step = $5, state = $6, version = version + 1, updated_at = $7
+		WHERE id = $1 AND tenant_id = $2 AND version = $3`,
+		wizard.ID, wizard.TenantID, wizard.Version, wizard.Name, wizard.Step, state, wizard.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("updating migration wizard: %w", err)
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		// Tell a missing wizard from one changed since it was read
+		var exists bool
+		if err := s.db.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM migration_wizards WHERE id = $1 AND tenant_id = $2)`,
+			wizard.ID, wizard.TenantID).Scan(&exists); err != nil {
+			return fmt.Errorf("updating migration wizard: %w", err)
+		}
+		if !exists {
+			return storage.ErrNotFound
+		}
+		return storage.ErrVersionConflict
+	}
+	wizard.Version++
+	return nil
+}
+
+// DeleteMigrationWizard removes a wizard of a tenant
+func (s *MigrationWizardStore) DeleteMigrationWizard(ctx context.Context, tenantID string, id uuid.UUID) error {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM migration_wizards WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("deleting migration wizard: %w", err)
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// ListMigrationWizards returns the wizards of a tenant without their rules
+// and results, oldest first
+func (s *MigrationWizardStore) ListMigrationWizards(ctx context.Context, tenantID string) ([]*storage.MigrationWizard, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, tenant_id, name, step, state - 'rules' - 'results', version, created_by, created_at, updated_at
+		FROM migration_wizards WHERE tenant_id = $1 ORDER BY created_at, id`,
+		tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("listing migration wizards: %w", err)
+	}
+	defer rows.Close()
+
+	wizards := make([]*storage.MigrationWizard, 0)
+	for rows.Next() {
+		wizard, err := scanWizard(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		wizards = append(wizards, wizard)
+	}
+	return wizards, rows.Err()
+}
+
+// marshalWizardState serializes the state of a wizard
+func marshalWizardState(wizard *storage.MigrationWizard) ([]byte, error) {
+	state, err := json.Marshal(wizardState{
+		Rules:      wizard.Rules,
+		Targets:    wizard.Targets,
+		Run:        wizard.Run,
+		Results:    wizard.Results,
+		Deployment: wizard.Deployment,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("serializing migration wizard: %w", err)
+	}
+	return state, nil
+}
+
+// scanWizard scans a row selected with wizardColumns
+func scanWizard(scan func(dest ...interface{}) error) (*storage.MigrationWizard, error) {
+	var wizard storage.MigrationWizard
+	var data []byte
+	if err := scan(
+		&wizard.ID, &wizard.TenantID, &wizard.Name, &wizard.Step, &data, &wizard.Version,
+		&wizard.CreatedBy, &wizard.CreatedAt, &wizard.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	var state wizardState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("decoding migration wizard: %w", err)
+	}
+	wizard.Rules = state.Rules
+	wizard.Targets = state.Targets
+	wizard.Run = state.Run
+	wizard.Results = state.Results
+	wizard.Deployment = state.Deployment
+	return &wizard, nil
+}
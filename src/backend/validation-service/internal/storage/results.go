@@ -0,0 +1,77 @@
+// Package storage defines persistence interfaces for detections and related
+// records used by the validation service.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/models"
+)
+
+// ResultQuery filters persisted validation results. All set criteria must match.
+type ResultQuery struct {
+	TenantID string
+	// Format matches either the source or the target format
+	Format string
+	Status string
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+// Normalize applies default and maximum limits to the query
+func (q *ResultQuery) Normalize() error {
+	if q.Limit <= 0 {
+		q.Limit = DefaultSearchLimit
+	}
+	if q.Limit > MaxSearchLimit {
+		q.Limit = MaxSearchLimit
+	}
+	if q.Offset < 0 {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidQuery)
+	}
+	if q.From != nil && q.To != nil && q.From.After(*q.To) {
+		return fmt.Errorf("%w: from is after to", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// Matches reports whether a result created for tenantID satisfies the query
+func (q *ResultQuery) Matches(tenantID string, result *models.ValidationResult) bool {
+	if tenantID != q.TenantID {
+		return false
+	}
+	if q.Format != "" && result.SourceFormat != q.Format && result.TargetFormat != q.Format {
+		return false
+	}
+	if q.Status != "" && result.Status != q.Status {
+		return false
+	}
+	if q.From != nil && result.CreatedAt.Before(*q.From) {
+		return false
+	}
+	if q.To != nil && result.CreatedAt.After(*q.To) {
+		return false
+	}
+	return true
+}
+
+// ResultStore persists validation results with their issues and history
+type ResultStore interface {
+	// SaveResult persists a result on behalf of a tenant, replacing any
+	// previously saved result with the same ID
+	SaveResult(ctx context.Context, tenantID string, result *models.ValidationResult) error
+	// GetResult returns the result with the given ID for a tenant
+	GetResult(ctx context.Context, tenantID string, id uuid.UUID) (*models.ValidationResult, error)
+	// ListResults returns results matching the query, newest first, and the
+	// total number of matches before pagination
+	ListResults(ctx context.Context, query ResultQuery) ([]*models.ValidationResult, int, error)
+	// ScanResults calls fn for every stored result of every tenant, stopping
+	// at the first error returned by fn. fn may save the result it is given.
+	ScanResults(ctx context.Context, fn func(tenantID string, result *models.ValidationResult) error) error
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" // v1.4.0
+)
+
+// Scheduled job types. Revalidate validates the stored detections of the
+// tenant again and saves the results; rescore recomputes the confidence
+// scores of the tenant's stored results; review notifies subscribers of the
+// tenant's stored detections past their review-by or expiry date.
+const (
+	JobRevalidate = "revalidate"
+	JobRescore    = "rescore"
+	JobReview     = "review"
+)
+
+// Schedule run statuses
+const (
+	RunRunning   = "running"
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+)
+
+// maxScheduleNameLength bounds schedule names
+const maxScheduleNameLength = 100
+
+// ScheduleJob is the job a schedule runs and the records it covers
+type ScheduleJob struct {
+	Type string `json:"type"`
+	// Format limits the job to detections or results of one target format
+	Format string `json:"format,omitempty"`
+	// Tags limits re-validation and review checks to detections with every
+	// tag; an empty value matches any value for the key
+	Tags map[string]string `json:"tags,omitempty"`
+	// CollectionID limits re-validation to the detections of a collection,
+	// which are then also checked as a whole
+	CollectionID *uuid.UUID `json:"collection_id,omitempty"`
+}
+
+// ScheduleRun is the outcome of one run of a schedule
+type ScheduleRun struct {
+	Status string `json:"status"`
+	// ScheduledAt is the time the run was due
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	// Counts are the job's tallies, such as validated or rescored records
+	Counts map[string]int `json:"counts,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// Schedule is a recurring job of a tenant, run at the times of a cron
+// expression
+type Schedule struct {
+	ID       uuid.UUID   `json:"id"`
+	TenantID string      `json:"tenant_id,omitempty"`
+	Name     string      `json:"name"`
+	Cron     string      `json:"cron"`
+	Timezone string      `json:"timezone"`
+	Job      ScheduleJob `json:"job"`
+	Paused   bool        `json:"paused"`
+	// NextRunAt is when the schedule is next due; nil while paused
+	NextRunAt *time.Time   `json:"next_run_at,omitempty"`
+	LastRun   *ScheduleRun `json:"last_run,omitempty"`
+	// LeaseUntil is how long the running run blocks the next ones; a run
+	// that has not finished by then is presumed lost
+	LeaseUntil *time.Time `json:"-"`
+	// SkippedRuns counts the runs skipped because the previous run was
+	// still in progress
+	SkippedRuns int       `json:"skipped_runs"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Validate checks the name and job of the schedule. The cron expression and
+// timezone are checked by the scheduler that interprets them.
+func (s *Schedule) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(s.Name) > maxScheduleNameLength {
+		return fmt.Errorf("name exceeds %d characters", maxScheduleNameLength)
+	}
+	switch s.Job.Type {
+	case JobRevalidate:
+		if s.Job.CollectionID != nil && (s.Job.Format != "" || len(s.Job.Tags) > 0) {
+			return errors.New("collection_id cannot be combined with format or tags")
+		}
+		return nil
+	case JobReview:
+	case JobRescore:
+		if len(s.Job.Tags) > 0 {
+			return errors.New("tags only apply to revalidate and review jobs")
+		}
+	default:
+		return fmt.Errorf("unknown job type: %q", s.Job.Type)
+	}
+	if s.Job.CollectionID != nil {
+		return errors.New("collection_id only applies to revalidate jobs")
+	}
+	return nil
+}
+
+// Running reports whether a run of the schedule holds its lease at now
+func (s *Schedule) Running(now time.Time) bool {
+	return s.LeaseUntil != nil && s.LeaseUntil.After(now)
+}
+
+// ScheduleStore persists schedules and the state of their runs. Claiming a
+// run is atomic, so each due time of a schedule is run once across replicas.
+type ScheduleStore interface {
+	// SaveSchedule creates a schedule or replaces its definition, pause
+	// state and next run time; the run state of an existing schedule is
+	// kept. ErrAlreadyExists is returned when another schedule of the
+	// tenant has the same name.
+	SaveSchedule(ctx context.Context, schedule *Schedule) error
+	// GetSchedule returns a schedule of a tenant
+	GetSchedule(ctx context.Context, tenantID string, id uuid.UUID) (*Schedule, error)
+	// DeleteSchedule removes a schedule of a tenant
+	DeleteSchedule(ctx context.Context, tenantID string, id uuid.UUID) error
+	// ListSchedules returns the schedules of a tenant, oldest first
+	ListSchedules(ctx context.Context, tenantID string) ([]*Schedule, error)
+	// DueSchedules returns the unpaused schedules of every tenant whose next
+	// run is at or before now, earliest first
+	DueSchedules(ctx context.Context, now time.Time) ([]*Schedule, error)
+	// ClaimScheduleRun moves a schedule whose next run is at due on to next
+	// and starts run with a lease until leaseUntil. When the previous run
+	// still holds its lease, the due run is skipped and counted instead. It
+	// reports whether run was started; false without an error when the
+	// run was skipped or another replica claimed the due time first.
+	ClaimScheduleRun(ctx context.Context, id uuid.UUID, due, next time.Time, run *ScheduleRun, leaseUntil time.Time) (bool, error)
+	// FinishScheduleRun records the outcome of the run due at
+	// run.ScheduledAt and releases its lease. The outcome of a run superseded
+	// by a later one is dropped.
+	FinishScheduleRun(ctx context.Context, id uuid.UUID, run *ScheduleRun) error
+}
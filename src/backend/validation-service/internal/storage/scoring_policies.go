@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// ScoringPolicy is the Rego policy a tenant computes the status and
+// confidence score of its validations with
+type ScoringPolicy struct {
+	TenantID string `json:"tenant_id,omitempty"`
+	// Module is the Rego source of the policy
+	Module string `json:"module"`
+	// Digest is the SHA-256 of Module, recorded on the results it scored
+	Digest    string    `json:"digest"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ScoringPolicyStore persists the scoring policy of each tenant
+type ScoringPolicyStore interface {
+	// SaveScoringPolicy creates or replaces the policy of a tenant
+	SaveScoringPolicy(ctx context.Context, policy *ScoringPolicy) error
+	// GetScoringPolicy returns the policy of a tenant, ErrNotFound when it
+	// has none
+	GetScoringPolicy(ctx context.Context, tenantID string) (*ScoringPolicy, error)
+	// DeleteScoringPolicy removes the policy of a tenant
+	DeleteScoringPolicy(ctx context.Context, tenantID string) error
+}
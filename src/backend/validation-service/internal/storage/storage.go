@@ -0,0 +1,139 @@
+// Package storage defines persistence interfaces for detections and related
+// records used by the validation service.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.4.0
+
+	"validation-service/internal/models"
+)
+
+// Common storage errors
+var (
+	ErrNotFound      = errors.New("record not found")
+	ErrAlreadyExists = errors.New("record already exists")
+	ErrInvalidQuery  = errors.New("invalid query")
+	// ErrVersionConflict is returned when a detection was changed since the
+	// version an update was based on
+	ErrVersionConflict = errors.New("version conflict")
+)
+
+// Search limits
+const (
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 500
+)
+
+// StoredDetection is a detection persisted by the service together with the
+// metadata used to organize and search the rule corpus.
+type StoredDetection struct {
+	Detection       *models.Detection `json:"detection"`
+	TenantID        string            `json:"tenant_id,omitempty"`
+	Name            string            `json:"name"`
+	Description     string            `json:"description,omitempty"`
+	License         string            `json:"license,omitempty"`
+	ContentRef      BlobRef           `json:"content_ref,omitempty"`
+	Tags            map[string]string `json:"tags"`
+	Techniques      []string          `json:"techniques"`
+	ConfidenceScore *float64          `json:"confidence_score,omitempty"`
+	// Version numbers the content changes of the detection from 1; tag
+	// changes apply to the current version without creating a new one
+	Version   int       `json:"version"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Validation summarizes the validation of this version, if it was
+	// validated when stored
+	Validation *DetectionValidation `json:"validation,omitempty"`
+}
+
+// DetectionValidation summarizes the validation of a detection version
+type DetectionValidation struct {
+	ResultID        uuid.UUID `json:"result_id"`
+	Status          string    `json:"status"`
+	ConfidenceScore float64   `json:"confidence_score"`
+	Issues          int       `json:"issues"`
+	ValidatedAt     time.Time `json:"validated_at"`
+	// IssueCodes counts the issues of the result by issue code
+	IssueCodes map[string]int `json:"issue_codes,omitempty"`
+}
+
+// ID returns the detection ID of the stored record
+func (d *StoredDetection) ID() uuid.UUID {
+	if d.Detection == nil {
+		return uuid.Nil
+	}
+	return d.Detection.ID
+}
+
+// DetectionQuery selects stored detections. All set criteria must match.
+type DetectionQuery struct {
+	TenantID string
+	// Tags requires every key to be present with the given value; an empty
+	// value matches any value for the key
+	Tags          map[string]string
+	Format        string
+	Technique     string
+	MinConfidence *float64
+	MaxConfidence *float64
+	// Text is matched case-insensitively against name, description and content
+	Text   string
+	Limit  int
+	Offset int
+}
+
+// Normalize applies default and maximum limits to the query
+func (q *DetectionQuery) Normalize() error {
+	if q.Limit <= 0 {
+		q.Limit = DefaultSearchLimit
+	}
+	if q.Limit > MaxSearchLimit {
+		q.Limit = MaxSearchLimit
+	}
+	if q.Offset < 0 {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidQuery)
+	}
+	if q.MinConfidence != nil && q.MaxConfidence != nil && *q.MinConfidence > *q.MaxConfidence {
+		return fmt.Errorf("%w: min confidence exceeds max confidence", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// TagOperation describes a bulk tag change applied to a set of detections
+type TagOperation struct {
+	TenantID     string
+	DetectionIDs []uuid.UUID
+	Set          map[string]string
+	Remove       []string
+}
+
+// DetectionStore persists detections and their organizational metadata
+type DetectionStore interface {
+	// CreateDetection stores a new detection as version 1
+	CreateDetection(ctx context.Context, detection *StoredDetection) error
+	// UpdateDetection stores detection as the next version of an existing
+	// detection of its tenant. A non-zero detection.Version must be the
+	// current version, or ErrVersionConflict is returned; on success it is
+	// set to the new version.
+	UpdateDetection(ctx context.Context, detection *StoredDetection) error
+	// GetDetection returns the detection with the given ID for a tenant
+	GetDetection(ctx context.Context, tenantID string, id uuid.UUID) (*StoredDetection, error)
+	// ListDetectionVersions returns every version of a detection, oldest
+	// first
+	ListDetectionVersions(ctx context.Context, tenantID string, id uuid.UUID) ([]*StoredDetection, error)
+	// GetDetectionVersion returns one version of a detection
+	GetDetectionVersion(ctx context.Context, tenantID string, id uuid.UUID, version int) (*StoredDetection, error)
+	// UpdateTags applies a bulk tag operation and returns the number of
+	// detections changed. Unknown IDs are ignored.
+	UpdateTags(ctx context.Context, op TagOperation) (int, error)
+	// SearchDetections returns detections matching the query and the total
+	// number of matches before pagination
+	SearchDetections(ctx context.Context, query DetectionQuery) ([]*StoredDetection, int, error)
+	// ScanDetections calls fn for every stored detection of every tenant,
+	// stopping at the first error returned by fn
+	ScanDetections(ctx context.Context, fn func(*StoredDetection) error) error
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" // v1.4.0
+)
+
+// ApprovedMapping is a translation of a source expression that a reviewer
+// approved for a tenant, such as EventCode=4625 in SPL becoming
+// EventID == 4625 on the SecurityEvent table in KQL.
+type ApprovedMapping struct {
+	ID               uuid.UUID `json:"id"`
+	TenantID         string    `json:"tenant_id,omitempty"`
+	SourceFormat     string    `json:"source_format"`
+	TargetFormat     string    `json:"target_format"`
+	SourceExpression string    `json:"source_expression"`
+	TargetExpression string    `json:"target_expression"`
+	// TargetTable is the table or index the target expression applies to
+	TargetTable string    `json:"target_table,omitempty"`
+	ApprovedBy  string    `json:"approved_by"`
+	ApprovedAt  time.Time `json:"approved_at"`
+}
+
+// Validate checks that the mapping is complete
+func (m *ApprovedMapping) Validate() error {
+	switch {
+	case m.SourceFormat == "" || m.TargetFormat == "":
+		return errors.New("source_format and target_format are required")
+	case strings.TrimSpace(m.SourceExpression) == "":
+		return errors.New("source_expression is required")
+	case strings.TrimSpace(m.TargetExpression) == "":
+		return errors.New("target_expression is required")
+	case strings.TrimSpace(m.ApprovedBy) == "":
+		return errors.New("approved_by is required")
+	}
+	return nil
+}
+
+// MappingQuery filters approved mappings. All set criteria must match.
+type MappingQuery struct {
+	TenantID     string
+	SourceFormat string
+	TargetFormat string
+	// SourceExpression matches mappings whose source expression is equal,
+	// ignoring case and whitespace
+	SourceExpression string
+	Limit            int
+	Offset           int
+}
+
+// Normalize applies default and maximum limits to the query
+func (q *MappingQuery) Normalize() error {
+	if q.Limit <= 0 {
+		q.Limit = DefaultSearchLimit
+	}
+	if q.Limit > MaxSearchLimit {
+		q.Limit = MaxSearchLimit
+	}
+	if q.Offset < 0 {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// Matches reports whether the mapping satisfies the query
+func (q *MappingQuery) Matches(mapping *ApprovedMapping) bool {
+	if mapping.TenantID != q.TenantID {
+		return false
+	}
+	if q.SourceFormat != "" && mapping.SourceFormat != q.SourceFormat {
+		return false
+	}
+	if q.TargetFormat != "" && mapping.TargetFormat != q.TargetFormat {
+		return false
+	}
+	if q.SourceExpression != "" && NormalizeExpression(mapping.SourceExpression) != NormalizeExpression(q.SourceExpression) {
+		return false
+	}
+	return true
+}
+
+// NormalizeExpression lower-cases an expression and removes all whitespace,
+// so that EventCode = 4625 and eventcode=4625 compare equal
+func NormalizeExpression(expression string) string {
+	return strings.ToLower(strings.Join(strings.Fields(expression), ""))
+}
+
+// TranslationMemoryStore persists the approved mappings of each tenant
+type TranslationMemoryStore interface {
+	// SaveMapping stores a mapping, replacing an approved mapping of the same
+	// tenant, formats and source expression
+	SaveMapping(ctx context.Context, mapping *ApprovedMapping) error
+	// GetMapping returns the mapping with the given ID for a tenant
+	GetMapping(ctx context.Context, tenantID string, id uuid.UUID) (*ApprovedMapping, error)
+	// DeleteMapping removes a mapping of a tenant
+	DeleteMapping(ctx context.Context, tenantID string, id uuid.UUID) error
+	// ListMappings returns mappings matching the query, most recently
+	// approved first, and the total number of matches before pagination
+	ListMappings(ctx context.Context, query MappingQuery) ([]*ApprovedMapping, int, error)
+}
@@ -0,0 +1,75 @@
+// Package storage defines persistence interfaces for detections and related
+// records used by the validation service.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"validation-service/internal/models"
+)
+
+// ResultSchemaVersion is the version of validation results written by this
+// build. Results persisted at an older version are upgraded when read, so
+// stores never hand out documents in an outdated shape.
+//
+// Versions:
+//
+//	1  issues without a category
+//	2  optimization hints carry IssueCategoryOptimization
+const ResultSchemaVersion = 2
+
+// ErrUnknownSchemaVersion is returned for documents written by a newer build
+var ErrUnknownSchemaVersion = errors.New("unknown schema version")
+
+// resultUpgrades upgrade a result persisted at the version of the index to
+// the next version. Add an entry here and bump ResultSchemaVersion when the
+// shape of ValidationResult changes.
+var resultUpgrades = map[int]func(*models.ValidationResult){
+	1: upgradeResultV1,
+}
+
+// optimizationHintPrefixes are the issue codes of the optimization hints
+// recorded before results carried issue categories
+var optimizationHintPrefixes = []string{"BHINT"}
+
+// UpgradeResult upgrades a result persisted at version to ResultSchemaVersion
+// and reports whether it changed. Results newer than this build understands
+// are rejected rather than served with missing data.
+func UpgradeResult(version int, result *models.ValidationResult) (bool, error) {
+	if version > ResultSchemaVersion {
+		return false, fmt.Errorf("%w: result %s has version %d, this build reads up to %d",
+			ErrUnknownSchemaVersion, result.ID, version, ResultSchemaVersion)
+	}
+	if version < 1 {
+		version = 1
+	}
+	upgraded := version < ResultSchemaVersion
+	for ; version < ResultSchemaVersion; version++ {
+		upgrade, ok := resultUpgrades[version]
+		if !ok {
+			return false, fmt.Errorf("%w: no upgrade from result version %d", ErrUnknownSchemaVersion, version)
+		}
+		upgrade(result)
+	}
+	return upgraded, nil
+}
+
+// upgradeResultV1 categorizes the optimization hints of version 1 results,
+// which were stored without a category and would otherwise be reported as
+// correctness issues
+func upgradeResultV1(result *models.ValidationResult) {
+	for i := range result.Issues {
+		issue := &result.Issues[i]
+		if issue.Category != "" {
+			continue
+		}
+		for _, prefix := range optimizationHintPrefixes {
+			if strings.HasPrefix(issue.IssueCode, prefix) {
+				issue.Category = models.IssueCategoryOptimization
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" // v1.4.0
+)
+
+// Migration wizard steps in order. A wizard waits on one step at a time;
+// completing a step moves it to the next one.
+const (
+	// WizardStepUpload scans an uploaded archive of source rules and infers
+	// their formats
+	WizardStepUpload = "upload"
+	// WizardStepReview confirms or corrects the inferred formats and
+	// excludes rules from the migration
+	WizardStepReview = "review"
+	// WizardStepTargets chooses the target formats and the translation
+	// pipelines of each
+	WizardStepTargets = "targets"
+	// WizardStepRun translates every rule to every target and validates the
+	// translations
+	WizardStepRun = "run"
+	// WizardStepExport downloads the translations or deploys them as
+	// stored detections
+	WizardStepExport = "export"
+	// WizardStepCompleted is reached once the translations are deployed
+	WizardStepCompleted = "completed"
+)
+
+// WizardStatusFailed is the status of a wizard result whose rule could not
+// be translated or validated
+const WizardStatusFailed = "failed"
+
+// maxWizardNameLength bounds wizard names
+const maxWizardNameLength = 100
+
+// wizardSteps are the wizard steps in order
+var wizardSteps = []string{
+	WizardStepUpload, WizardStepReview, WizardStepTargets, WizardStepRun, WizardStepExport, WizardStepCompleted,
+}
+
+// WizardStepIndex returns the position of step in the wizard, or -1 for an
+// unknown step
+func WizardStepIndex(step string) int {
+	for i, s := range wizardSteps {
+		if s == step {
+			return i
+		}
+	}
+	return -1
+}
+
+// WizardRule is a source rule uploaded to a migration wizard
+type WizardRule struct {
+	// Path is the path of the rule file in the uploaded archive
+	Path    string `json:"path"`
+	Content string `json:"content,omitempty"`
+	// DetectedFormat is the format inferred from the file; empty when none
+	// could be inferred
+	DetectedFormat string `json:"detected_format,omitempty"`
+	// Candidates are the formats the rule may be in, most likely first
+	Candidates []string `json:"candidates,omitempty"`
+	// Format is the format the rule is translated from: the detected format
+	// until the review step sets it
+	Format string `json:"format,omitempty"`
+	// Excluded rules are not migrated
+	Excluded bool `json:"excluded,omitempty"`
+}
+
+// WizardTarget is a target format of a migration wizard
+type WizardTarget struct {
+	Format string `json:"format"`
+	// Pipelines are the translation service pipelines applied in order,
+	// such as the field mappings of a log source
+	Pipelines []string `json:"pipelines,omitempty"`
+}
+
+// WizardResult is the translation of one rule to one target format and the
+// outcome of its validation
+type WizardResult struct {
+	Path         string `json:"path"`
+	TargetFormat string `json:"target_format"`
+	Content      string `json:"content,omitempty"`
+	// TranslationConfidence is the confidence reported by the translation
+	// service
+	TranslationConfidence float64 `json:"translation_confidence,omitempty"`
+	// Status is the validation status, or WizardStatusFailed when the rule
+	// could not be translated or validated
+	Status          string  `json:"status"`
+	ConfidenceScore float64 `json:"confidence_score"`
+	Issues          int     `json:"issues"`
+	// ResultID identifies the saved validation result
+	ResultID *uuid.UUID `json:"result_id,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// WizardRun is the progress of the translation and validation run of a
+// wizard
+type WizardRun struct {
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// LeaseUntil is how long the run holds the wizard; a run that has not
+	// finished by then is presumed lost and can be resumed
+	LeaseUntil *time.Time `json:"lease_until,omitempty"`
+	// Total counts the rule and target pairs of the run and Completed those
+	// with a result
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	// Counts tallies the results by status
+	Counts map[string]int `json:"counts,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// WizardDeployment records the translations a wizard deployed as stored
+// detections
+type WizardDeployment struct {
+	DetectionIDs []uuid.UUID `json:"detection_ids"`
+	// Skipped counts the translations not deployed because they failed or
+	// their status was not accepted
+	Skipped    int       `json:"skipped"`
+	DeployedBy string    `json:"deployed_by,omitempty"`
+	DeployedAt time.Time `json:"deployed_at"`
+}
+
+// MigrationWizard is the state of a tenant's guided migration of a rule
+// repository to other formats. Every step is persisted, so a wizard can be
+// resumed from another session or replica.
+type MigrationWizard struct {
+	ID       uuid.UUID `json:"id"`
+	TenantID string    `json:"tenant_id,omitempty"`
+	Name     string    `json:"name"`
+	// Step is the step the wizard waits on
+	Step       string            `json:"step"`
+	Rules      []WizardRule      `json:"rules,omitempty"`
+	Targets    []WizardTarget    `json:"targets,omitempty"`
+	Run        *WizardRun        `json:"run,omitempty"`
+	Results    []WizardResult    `json:"results,omitempty"`
+	Deployment *WizardDeployment `json:"deployment,omitempty"`
+	// Version numbers the changes of the wizard from 1; an update must be
+	// based on the current version
+	Version   int       `json:"version"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks the name and step of the wizard
+func (w *MigrationWizard) Validate() error {
+	if strings.TrimSpace(w.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(w.Name) > maxWizardNameLength {
+		return fmt.Errorf("name exceeds %d characters", maxWizardNameLength)
+	}
+	if WizardStepIndex(w.Step) < 0 {
+		return fmt.Errorf("unknown wizard step: %q", w.Step)
+	}
+	return nil
+}
+
+// Reached reports whether the wizard has completed the steps before step
+func (w *MigrationWizard) Reached(step string) bool {
+	return WizardStepIndex(w.Step) >= WizardStepIndex(step)
+}
+
+// Running reports whether a run of the wizard holds its lease at now
+func (w *MigrationWizard) Running(now time.Time) bool {
+	return w.Run != nil && w.Run.Status == RunRunning && w.Run.LeaseUntil != nil && w.Run.LeaseUntil.After(now)
+}
+
+// Reset moves the wizard back to step, discarding the outcome of the later
+// steps
+func (w *MigrationWizard) Reset(step string) {
+	w.Step = step
+	index := WizardStepIndex(step)
+	if index <= WizardStepIndex(WizardStepTargets) {
+		w.Targets = nil
+	}
+	if index <= WizardStepIndex(WizardStepRun) {
+		w.Run = nil
+		w.Results = nil
+	}
+	w.Deployment = nil
+}
+
+// MigrationWizardStore persists the migration wizards of tenants. Updates
+// are checked against the version they are based on, so concurrent sessions
+// and runs do not overwrite each other's steps.
+type MigrationWizardStore interface {
+	// CreateMigrationWizard stores a new wizard as version 1
+	CreateMigrationWizard(ctx context.Context, wizard *MigrationWizard) error
+	// GetMigrationWizard returns a wizard of a tenant
+	GetMigrationWizard(ctx context.Context, tenantID string, id uuid.UUID) (*MigrationWizard, error)
+	// UpdateMigrationWizard replaces a wizard of its tenant. wizard.Version
+	// must be the current version, or ErrVersionConflict is returned; on
+	// success it is set to the new version.
+	UpdateMigrationWizard(ctx context.Context, wizard *MigrationWizard) error
+	// DeleteMigrationWizard removes a wizard of a tenant
+	DeleteMigrationWizard(ctx context.Context, tenantID string, id uuid.UUID) error
+	// ListMigrationWizards returns the wizards of a tenant without their
+	// rules and results, oldest first
+	ListMigrationWizards(ctx context.Context, tenantID string) ([]*MigrationWizard, error)
+}
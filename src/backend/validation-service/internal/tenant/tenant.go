@@ -0,0 +1,220 @@
+// Package tenant resolves the tenant a request is made on behalf of and
+// enforces the validation policy, rate limit and daily quota configured for
+// it. The tenant comes from the tenant claim of the caller's token, so it
+// cannot be chosen by the caller; the X-Tenant-ID header is only trusted for
+// administrators whose tokens have no claim.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate" // v0.0.0-20220922220347-f3bd1da661af
+
+	"validation-service/internal/config"
+)
+
+// Metrics labels of tenants without a configured policy and of requests
+// without a tenant, which keep label cardinality bounded by the configuration
+const (
+	otherLabel = "other"
+	noneLabel  = "none"
+)
+
+var (
+	// ErrTenantMismatch is returned when the requested tenant differs from
+	// the tenant claim of the token
+	ErrTenantMismatch = errors.New("requested tenant does not match the token's tenant")
+	// ErrTenantRequired is returned for tokens without a tenant claim when
+	// the claim is required
+	ErrTenantRequired = errors.New("token has no tenant claim")
+	// ErrRateLimited is returned when a tenant exceeds its request rate
+	ErrRateLimited = errors.New("tenant rate limit exceeded")
+	// ErrQuotaExceeded is returned when a tenant has used its daily quota
+	ErrQuotaExceeded = errors.New("tenant daily quota exceeded")
+)
+
+// Tenant is a resolved tenant and its effective policy
+type Tenant struct {
+	ID string
+	// Label identifies the tenant in metrics
+	Label  string
+	Policy config.TenantPolicy
+}
+
+// AllowsFormat reports whether the tenant may validate the target format
+func (t *Tenant) AllowsFormat(format string) bool {
+	if t == nil || len(t.Policy.AllowedFormats) == 0 {
+		return true
+	}
+	for _, allowed := range t.Policy.AllowedFormats {
+		if allowed == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Strict reports whether validations with warnings fail for the tenant
+func (t *Tenant) Strict() bool {
+	return t != nil && t.Policy.Strict != nil && *t.Policy.Strict
+}
+
+type contextKey struct{}
+
+// WithTenant returns a context carrying the tenant
+func WithTenant(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the tenant of the request
+func FromContext(ctx context.Context) (*Tenant, bool) {
+	t, ok := ctx.Value(contextKey{}).(*Tenant)
+	return t, ok && t != nil
+}
+
+// LabelFromContext returns the metrics label of the tenant of the request
+func LabelFromContext(ctx context.Context) string {
+	if t, ok := FromContext(ctx); ok {
+		return t.Label
+	}
+	return noneLabel
+}
+
+// IDFromContext returns the tenant ID of the request, or an empty string
+func IDFromContext(ctx context.Context) string {
+	if t, ok := FromContext(ctx); ok {
+		return t.ID
+	}
+	return ""
+}
+
+// Registry resolves tenants and tracks their request rates and daily usage.
+// Limits are enforced per replica. A nil Registry resolves tenants without
+// policies or limits.
+type Registry struct {
+	cfg config.TenancyConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	day      time.Time
+	limiters map[string]*rate.Limiter
+	usage    map[string]int
+}
+
+// NewRegistry creates a tenant registry for the tenancy configuration
+func NewRegistry(cfg config.TenancyConfig) *Registry {
+	return &Registry{
+		cfg:      cfg,
+		now:      time.Now,
+		limiters: make(map[string]*rate.Limiter),
+		usage:    make(map[string]int),
+	}
+}
+
+// ResolveID returns the tenant of a caller from the tenant claim of its token
+// and the tenant it requested through the X-Tenant-ID header. The claim is
+// authoritative. Callers without one act on behalf of the requested tenant
+// only when mayChoose is set, for administrators of every tenant; the
+// requested tenant of other callers is ignored.
+func (r *Registry) ResolveID(claimed, requested string, mayChoose bool) (string, error) {
+	if claimed != "" {
+		if requested != "" && requested != claimed {
+			return "", ErrTenantMismatch
+		}
+		return claimed, nil
+	}
+	if r != nil && r.cfg.RequireClaim {
+		return "", ErrTenantRequired
+	}
+	if !mayChoose {
+		return "", nil
+	}
+	return requested, nil
+}
+
+// Resolve returns the tenant with its configured policy overlaid on the
+// default policy
+func (r *Registry) Resolve(id string) *Tenant {
+	if r == nil {
+		return &Tenant{ID: id, Label: r.label(id)}
+	}
+	policy := r.cfg.Default
+	override, ok := r.cfg.Tenants[id]
+	if !ok {
+		return &Tenant{ID: id, Label: r.label(id), Policy: policy}
+	}
+	if len(override.AllowedFormats) > 0 {
+		policy.AllowedFormats = override.AllowedFormats
+	}
+	if override.Strict != nil {
+		policy.Strict = override.Strict
+	}
+	if override.MinConfidence != nil {
+		policy.MinConfidence = override.MinConfidence
+	}
+	if override.RateLimit > 0 {
+		policy.RateLimit = override.RateLimit
+		policy.Burst = override.Burst
+	}
+	if override.DailyQuota > 0 {
+		policy.DailyQuota = override.DailyQuota
+	}
+	policy.Gate = policy.Gate.Overlay(override.Gate)
+	return &Tenant{ID: id, Label: r.label(id), Policy: policy}
+}
+
+// Allow records a request of the tenant. It returns ErrQuotaExceeded or
+// ErrRateLimited, and how long until the request would be allowed, when the
+// tenant is over its limits.
+func (r *Registry) Allow(t *Tenant) (time.Duration, error) {
+	if r == nil || t == nil || (t.Policy.RateLimit <= 0 && t.Policy.DailyQuota <= 0) {
+		return 0, nil
+	}
+	now := r.now().UTC()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Usage and limiters start afresh every UTC day, which also drops the
+	// state of tenants no longer making requests
+	if day := now.Truncate(24 * time.Hour); !day.Equal(r.day) {
+		r.day = day
+		r.limiters = make(map[string]*rate.Limiter)
+		r.usage = make(map[string]int)
+	}
+
+	if t.Policy.DailyQuota > 0 && r.usage[t.ID] >= t.Policy.DailyQuota {
+		return r.day.Add(24 * time.Hour).Sub(now), ErrQuotaExceeded
+	}
+	if t.Policy.RateLimit > 0 {
+		limiter, ok := r.limiters[t.ID]
+		if !ok {
+			limiter = rate.NewLimiter(rate.Limit(t.Policy.RateLimit), t.Policy.Burst)
+			r.limiters[t.ID] = limiter
+		}
+		reservation := limiter.ReserveN(now, 1)
+		if delay := reservation.DelayFrom(now); delay > 0 {
+			reservation.CancelAt(now)
+			return delay, ErrRateLimited
+		}
+	}
+	r.usage[t.ID]++
+	return 0, nil
+}
+
+// label returns the metrics label of a tenant: its ID when it has a
+// configured policy, "other" otherwise and "none" for requests without a
+// tenant
+func (r *Registry) label(id string) string {
+	if id == "" {
+		return noneLabel
+	}
+	if r != nil {
+		if _, ok := r.cfg.Tenants[id]; ok {
+			return id
+		}
+	}
+	return otherLabel
+}
@@ -0,0 +1,111 @@
+package tenant
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"validation-service/internal/config"
+)
+
+func TestResolveID(t *testing.T) {
+	tests := []struct {
+		name         string
+		requireClaim bool
+		claimed      string
+		requested    string
+		mayChoose    bool
+		want         string
+		err          error
+	}{
+		{name: "claim", claimed: "acme", want: "acme"},
+		{name: "claim and matching header", claimed: "acme", requested: "acme", want: "acme"},
+		{name: "claim and other header", claimed: "acme", requested: "globex", err: ErrTenantMismatch},
+		{name: "claim and other header of administrator", claimed: "acme", requested: "globex", mayChoose: true, err: ErrTenantMismatch},
+		{name: "header without claim", requested: "globex", want: ""},
+		{name: "header of administrator without claim", requested: "globex", mayChoose: true, want: "globex"},
+		{name: "neither", want: ""},
+		{name: "claim required", requireClaim: true, requested: "globex", err: ErrTenantRequired},
+		{name: "claim required of administrator", requireClaim: true, requested: "globex", mayChoose: true, err: ErrTenantRequired},
+		{name: "claim required and present", requireClaim: true, claimed: "acme", want: "acme"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(config.TenancyConfig{RequireClaim: tt.requireClaim})
+			got, err := r.ResolveID(tt.claimed, tt.requested, tt.mayChoose)
+			if !errors.Is(err, tt.err) || got != tt.want {
+				t.Errorf("ResolveID() = %q, %v, want %q, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	strict, lenient := true, false
+	r := NewRegistry(config.TenancyConfig{
+		Default: config.TenantPolicy{AllowedFormats: []string{"splunk", "sigma"}, Strict: &lenient, RateLimit: 10, Burst: 20},
+		Tenants: map[string]config.TenantPolicy{
+			"acme": {AllowedFormats: []string{"kql"}, Strict: &strict, DailyQuota: 100},
+		},
+	})
+
+	acme := r.Resolve("acme")
+	if acme.Label != "acme" || !acme.Strict() || !acme.AllowsFormat("kql") || acme.AllowsFormat("splunk") {
+		t.Errorf("acme = %+v", acme)
+	}
+	if acme.Policy.RateLimit != 10 || acme.Policy.Burst != 20 || acme.Policy.DailyQuota != 100 {
+		t.Errorf("acme limits = %+v, want the default rate limit and its own quota", acme.Policy)
+	}
+
+	other := r.Resolve("globex")
+	if other.Label != "other" || other.Strict() || !other.AllowsFormat("splunk") || other.AllowsFormat("kql") {
+		t.Errorf("unconfigured tenant = %+v", other)
+	}
+	if label := r.Resolve("").Label; label != "none" {
+		t.Errorf("label without tenant = %q, want none", label)
+	}
+
+	var unconfigured *Registry
+	if got := unconfigured.Resolve("acme"); got.ID != "acme" || got.Label != "other" || !got.AllowsFormat("kql") {
+		t.Errorf("tenant of a nil registry = %+v", got)
+	}
+}
+
+func TestAllow(t *testing.T) {
+	r := NewRegistry(config.TenancyConfig{
+		Tenants: map[string]config.TenantPolicy{
+			"limited": {RateLimit: 1, Burst: 2},
+			"quota":   {DailyQuota: 2},
+		},
+	})
+	now := time.Date(2024, 3, 1, 23, 59, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	limited := r.Resolve("limited")
+	for i := 0; i < 2; i++ {
+		if _, err := r.Allow(limited); err != nil {
+			t.Fatalf("request %d within burst: %v", i+1, err)
+		}
+	}
+	if delay, err := r.Allow(limited); !errors.Is(err, ErrRateLimited) || delay <= 0 || delay > time.Second {
+		t.Errorf("request over burst = %v, %v, want ErrRateLimited within a second", delay, err)
+	}
+
+	quota := r.Resolve("quota")
+	r.Allow(quota)
+	r.Allow(quota)
+	if delay, err := r.Allow(quota); !errors.Is(err, ErrQuotaExceeded) || delay != time.Minute {
+		t.Errorf("request over quota = %v, %v, want ErrQuotaExceeded until midnight", delay, err)
+	}
+
+	// Usage starts afresh on the next UTC day
+	now = now.Add(2 * time.Minute)
+	if _, err := r.Allow(quota); err != nil {
+		t.Errorf("request on the next day: %v", err)
+	}
+
+	if _, err := r.Allow(r.Resolve("unlimited")); err != nil {
+		t.Errorf("tenant without limits: %v", err)
+	}
+}
@@ -0,0 +1,450 @@
+// Package httpclient provides the HTTP client shared by the outbound
+// integrations of the service, such as platform validation APIs, webhooks
+// and reference data downloads. Requests of every integration share one
+// pooled transport; each host has a circuit breaker that stops requests to
+// it after consecutive failures, and each integration has a retry budget
+// that bounds the retries of transient failures.
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.17.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.17.0
+)
+
+// ErrCircuitOpen is returned for requests to a host whose circuit is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// ErrDisallowedAddress is returned for connections of a public client to a
+// loopback, private, link-local or other non-public address
+var ErrDisallowedAddress = errors.New("address not allowed for outbound requests")
+
+// sharedAddressSpace is the carrier-grade NAT range, used by cloud
+// providers for internal networks
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// retryBudgetReserve is the number of retries an integration can make
+// before its requests have earned any
+const retryBudgetReserve = 10
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:        "outbound_http_requests_total",
+		Help:        "Outbound HTTP requests by integration and outcome",
+		ConstLabels: prometheus.Labels{"service": "validation"},
+	}, []string{"client", "outcome"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:        "outbound_http_request_duration_seconds",
+		Help:        "Duration of outbound HTTP requests by integration, per attempt",
+		Buckets:     prometheus.DefBuckets,
+		ConstLabels: prometheus.Labels{"service": "validation"},
+	}, []string{"client"})
+
+	retriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:        "outbound_http_retries_total",
+		Help:        "Retries of outbound HTTP requests by integration",
+		ConstLabels: prometheus.Labels{"service": "validation"},
+	}, []string{"client"})
+
+	retriesDenied = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:        "outbound_http_retries_denied_total",
+		Help:        "Retries of outbound HTTP requests not made because the retry budget was spent",
+		ConstLabels: prometheus.Labels{"service": "validation"},
+	}, []string{"client"})
+
+	circuitsOpened = promauto.NewCounter(prometheus.CounterOpts{
+		Name:        "outbound_http_circuits_opened_total",
+		Help:        "Circuit breakers of outbound hosts opened",
+		ConstLabels: prometheus.Labels{"service": "validation"},
+	})
+
+	circuitsOpen = promauto.NewGauge(prometheus.GaugeOpts{
+		Name:        "outbound_http_circuits_open",
+		Help:        "Outbound hosts whose circuit breaker is open",
+		ConstLabels: prometheus.Labels{"service": "validation"},
+	})
+)
+
+// Options configures the shared transport, circuit breakers and retries
+type Options struct {
+	// MaxIdleConns bounds the idle connections kept across hosts and
+	// MaxIdleConnsPerHost those kept to one host
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept
+	IdleConnTimeout time.Duration
+	// FailureThreshold consecutive failures of a host open its circuit;
+	// circuits never open when zero
+	FailureThreshold int
+	// OpenDuration is how long an open circuit rejects requests before a
+	// single request probes the host
+	OpenDuration time.Duration
+	// MaxRetries bounds the retries of a request
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, doubled for each
+	// further retry
+	RetryBackoff time.Duration
+	// RetryRatio is the number of retries each request earns for its
+	// integration, so retries stay a fraction of the requests made
+	RetryRatio float64
+}
+
+// Pool holds the transport, circuit breakers and retry budgets shared by
+// the clients it creates
+type Pool struct {
+	opts            Options
+	transport       *http.Transport
+	publicTransport *http.Transport
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+	budgets  map[string]*retryBudget
+}
+
+// New creates a pool with its own transports
+func New(opts Options) *Pool {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	publicDialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second, Control: checkPublicAddress}
+	return &Pool{
+		opts:            opts,
+		transport:       newTransport(opts, dialer, http.ProxyFromEnvironment),
+		publicTransport: newTransport(opts, publicDialer, nil),
+		breakers:        make(map[string]*breaker),
+		budgets:         make(map[string]*retryBudget),
+	}
+}
+
+// newTransport creates a pooled transport dialing through dialer
+func newTransport(opts Options, dialer *net.Dialer, proxy func(*http.Request) (*url.URL, error)) *http.Transport {
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          opts.MaxIdleConns,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// Client returns a client for the named integration whose requests,
+// retries included, time out after timeout. Clients of the same name share
+// a retry budget; name labels the metrics of their requests.
+func (p *Pool) Client(name string, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &roundTripper{pool: p, transport: p.transport, name: name, budget: p.budget(name)},
+	}
+}
+
+// PublicClient returns a client like Client that only connects to public
+// addresses, for URLs supplied by users such as webhook targets. Addresses
+// are checked when dialing, after name resolution and for every redirect,
+// so a name resolving to an internal host is refused too. Requests of
+// public clients are not sent through a proxy.
+func (p *Pool) PublicClient(name string, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &roundTripper{pool: p, transport: p.publicTransport, name: name, budget: p.budget(name)},
+	}
+}
+
+// CloseIdleConnections closes the idle connections of the transports
+func (p *Pool) CloseIdleConnections() {
+	p.transport.CloseIdleConnections()
+	p.publicTransport.CloseIdleConnections()
+}
+
+// IsPublicIP reports whether ip is a public unicast address: not loopback,
+// private, link-local, multicast, unspecified or in the shared address
+// space
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip))
+}
+
+// checkPublicAddress is the dialer control of public clients, refusing
+// connections to non-public addresses
+func checkPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrDisallowedAddress, host)
+	}
+	return nil
+}
+
+// breaker returns the circuit breaker of a host
+func (p *Pool) breaker(host string) *breaker {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	b, ok := p.breakers[host]
+	if !ok {
+		b = &breaker{threshold: p.opts.FailureThreshold, openDuration: p.opts.OpenDuration}
+		p.breakers[host] = b
+	}
+	return b
+}
+
+// budget returns the retry budget of an integration
+func (p *Pool) budget(name string) *retryBudget {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	b, ok := p.budgets[name]
+	if !ok {
+		b = &retryBudget{ratio: p.opts.RetryRatio, tokens: retryBudgetReserve}
+		p.budgets[name] = b
+	}
+	return b
+}
+
+// roundTripper sends the requests of one integration through the pool
+type roundTripper struct {
+	pool      *Pool
+	transport *http.Transport
+	name      string
+	budget    *retryBudget
+}
+
+// RoundTrip sends a request while the circuit of its host is closed,
+// retrying transient failures within the retry budget
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	circuit := rt.pool.breaker(req.URL.Host)
+	rt.budget.earn()
+
+	if !circuit.allow(time.Now()) {
+		requestsTotal.WithLabelValues(rt.name, "circuit_open").Inc()
+		return nil, fmt.Errorf("%w for %s", ErrCircuitOpen, req.URL.Host)
+	}
+
+	attemptReq := req
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		resp, err := rt.transport.RoundTrip(attemptReq)
+		requestDuration.WithLabelValues(rt.name).Observe(time.Since(start).Seconds())
+		requestsTotal.WithLabelValues(rt.name, outcome(resp, err)).Inc()
+
+		if err != nil && req.Context().Err() != nil {
+			// The caller gave up; the host is not to blame
+			circuit.release()
+			return nil, err
+		}
+		circuit.record(err == nil && resp.StatusCode < 500, time.Now())
+
+		if attempt >= rt.pool.opts.MaxRetries || !retryable(req, resp, err) {
+			return resp, err
+		}
+		next, rewindErr := rewind(req)
+		if rewindErr != nil {
+			return resp, err
+		}
+		if !rt.budget.spend() {
+			retriesDenied.WithLabelValues(rt.name).Inc()
+			return resp, err
+		}
+		// A failure that opened the circuit is returned as is
+		if !circuit.allow(time.Now()) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(backoff(rt.pool.opts.RetryBackoff, attempt))
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			circuit.release()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+		retriesTotal.WithLabelValues(rt.name).Inc()
+		attemptReq = next
+	}
+}
+
+// outcome classifies a response for the metrics
+func outcome(resp *http.Response, err error) string {
+	if err != nil {
+		return "error"
+	}
+	return strconv.Itoa(resp.StatusCode/100) + "xx"
+}
+
+// retryable reports whether a failure is transient and the request can be
+// sent again: connection failures of any request, since nothing was sent,
+// and other errors, 429, 502, 503 and 504 of idempotent requests. Refused
+// addresses are not retried.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		if errors.Is(err, ErrDisallowedAddress) {
+			return false
+		}
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return true
+		}
+		return idempotent(req.Method)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent(req.Method)
+	}
+	return false
+}
+
+// idempotent reports whether requests of a method can be repeated safely
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// rewind copies a request with a fresh body for a retry
+func rewind(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		next.Body = body
+	}
+	return next, nil
+}
+
+// backoff returns the wait before a retry, doubled for each attempt with up
+// to a quarter of jitter
+func backoff(base time.Duration, attempt int) time.Duration {
+	wait := base << attempt
+	if wait <= 0 {
+		return 0
+	}
+	return wait - time.Duration(rand.Int63n(int64(wait)/4+1))
+}
+
+// Circuit states
+const (
+	circuitClosed = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// breaker is the circuit breaker of a host. Once open it lets a single
+// probe through after openDuration; the probe closes the circuit when it
+// succeeds and opens it again when it fails.
+type breaker struct {
+	threshold    int
+	openDuration time.Duration
+
+	mu       sync.Mutex
+	state    int
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow reports whether a request may be sent to the host
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case circuitOpen:
+		if now.Sub(b.openedAt) < b.openDuration {
+			return false
+		}
+		b.state = circuitHalfOpen
+		b.probing = true
+		return true
+	case circuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// record counts the outcome of a request to the host
+func (b *breaker) record(ok bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if ok {
+		if b.state != circuitClosed {
+			circuitsOpen.Dec()
+		}
+		b.state = circuitClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.threshold <= 0 || (b.state == circuitClosed && b.failures < b.threshold) {
+		return
+	}
+	if b.state == circuitClosed {
+		circuitsOpen.Inc()
+		circuitsOpened.Inc()
+	}
+	b.state = circuitOpen
+	b.openedAt = now
+}
+
+// release ends a probe whose outcome says nothing about the host
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// retryBudget allows an integration ratio retries per request, plus a
+// reserve for when few requests have been made
+type retryBudget struct {
+	ratio float64
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+// earn credits the budget for a request
+func (b *retryBudget) earn() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += b.ratio
+	if b.tokens > retryBudgetReserve {
+		b.tokens = retryBudgetReserve
+	}
+}
+
+// spend takes a retry from the budget, reporting whether one was left
+func (b *retryBudget) spend() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,245 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"         // v1.24.0 - High-performance structured logging
+	"go.uber.org/zap/zapcore" // v1.24.0 - Core logging configuration
+)
+
+// Scope field keys. Log lines carrying one of these fields, usually through
+// FromContext, are written at debug level while their scope is boosted.
+const (
+	scopeFieldFormat = "format"
+	scopeFieldRoute  = "route"
+)
+
+// FormatScope returns the adaptive logging scope of a detection format
+func FormatScope(format string) string {
+	return scopeFieldFormat + ":" + format
+}
+
+// RouteScope returns the adaptive logging scope of an HTTP route pattern
+func RouteScope(route string) string {
+	return scopeFieldRoute + ":" + route
+}
+
+// debugBoosts holds the scopes logged at debug level regardless of the
+// global level, and when each boost ends
+var debugBoosts = struct {
+	mu    sync.RWMutex
+	until map[string]time.Time
+	// active is the number of entries in until, checked before locking so
+	// logging stays lock-free while nothing is boosted
+	active atomic.Int32
+}{until: make(map[string]time.Time)}
+
+// boostDebug logs scope at debug level until the given time
+func boostDebug(scope string, until time.Time) {
+	debugBoosts.mu.Lock()
+	defer debugBoosts.mu.Unlock()
+	debugBoosts.until[scope] = until
+	debugBoosts.active.Store(int32(len(debugBoosts.until)))
+}
+
+// expireDebugBoosts ends the boosts that are over and returns their scopes
+func expireDebugBoosts(now time.Time) []string {
+	if debugBoosts.active.Load() == 0 {
+		return nil
+	}
+	debugBoosts.mu.Lock()
+	defer debugBoosts.mu.Unlock()
+	var expired []string
+	for scope, until := range debugBoosts.until {
+		if !now.Before(until) {
+			delete(debugBoosts.until, scope)
+			expired = append(expired, scope)
+		}
+	}
+	debugBoosts.active.Store(int32(len(debugBoosts.until)))
+	return expired
+}
+
+// debugBoosted reports whether any of scopes is boosted
+func debugBoosted(scopes []string) bool {
+	if len(scopes) == 0 || debugBoosts.active.Load() == 0 {
+		return false
+	}
+	now := time.Now()
+	debugBoosts.mu.RLock()
+	defer debugBoosts.mu.RUnlock()
+	for _, scope := range scopes {
+		if until, ok := debugBoosts.until[scope]; ok && now.Before(until) {
+			return true
+		}
+	}
+	return false
+}
+
+// boostCore writes debug entries of boosted scopes that the wrapped core's
+// level would drop. Scopes are taken from the format and route fields added
+// with With.
+type boostCore struct {
+	zapcore.Core
+	scopes []string
+}
+
+// With adds fields to the wrapped core and records the scopes they name
+func (c *boostCore) With(fields []zap.Field) zapcore.Core {
+	scopes := c.scopes
+	for _, field := range fields {
+		if field.Type != zapcore.StringType {
+			continue
+		}
+		switch field.Key {
+		case scopeFieldFormat:
+			scopes = append(scopes[:len(scopes):len(scopes)], FormatScope(field.String))
+		case scopeFieldRoute:
+			scopes = append(scopes[:len(scopes):len(scopes)], RouteScope(field.String))
+		}
+	}
+	return &boostCore{Core: c.Core.With(fields), scopes: scopes}
+}
+
+// Enabled reports whether entries at level are written
+func (c *boostCore) Enabled(level zapcore.Level) bool {
+	return c.Core.Enabled(level) || debugBoosted(c.scopes)
+}
+
+// Check adds the core to ce when the entry is enabled
+func (c *boostCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Core.Enabled(entry.Level) {
+		return c.Core.Check(entry, ce)
+	}
+	if debugBoosted(c.scopes) {
+		return ce.AddCore(entry, c.Core)
+	}
+	return ce
+}
+
+// AdaptiveConfig sets when a scope is boosted to debug logging. A scope is
+// boosted once it has served MinRequests in the current window and its error
+// rate, or its share of requests slower than LatencySLO, reaches the
+// threshold.
+type AdaptiveConfig struct {
+	// Window is the period over which error and latency rates are measured
+	Window time.Duration
+	// MinRequests keeps rarely used scopes from being boosted by a single
+	// failure
+	MinRequests int
+	// ErrorRate is the share of failed requests that boosts a scope
+	ErrorRate float64
+	// LatencySLO is the latency objective; zero disables latency boosts
+	LatencySLO time.Duration
+	// SlowRate is the share of requests slower than LatencySLO that boosts a
+	// scope
+	SlowRate float64
+	// BoostDuration is how long a scope stays boosted
+	BoostDuration time.Duration
+	// MaxBoosts bounds the number of scopes boosted at once, so a
+	// service-wide incident does not turn on debug logging everywhere
+	MaxBoosts int
+}
+
+// AdaptiveDebug raises logging of a format or route to debug for a bounded
+// period while its error rate or latency breaches the configured thresholds
+type AdaptiveDebug struct {
+	cfg AdaptiveConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	windows map[string]*scopeWindow
+	boosted map[string]time.Time
+}
+
+// scopeWindow counts the outcomes of a scope in the current window
+type scopeWindow struct {
+	start  time.Time
+	total  int
+	failed int
+	slow   int
+}
+
+// NewAdaptiveDebug creates an adaptive debug controller and lets the global
+// logger write debug lines of boosted scopes. Call it after InitLogger and
+// before serving requests; loggers obtained earlier are not boosted.
+func NewAdaptiveDebug(cfg AdaptiveConfig) *AdaptiveDebug {
+	logger = GetLogger().WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &boostCore{Core: core}
+	}))
+	return &AdaptiveDebug{
+		cfg:     cfg,
+		now:     time.Now,
+		windows: make(map[string]*scopeWindow),
+		boosted: make(map[string]time.Time),
+	}
+}
+
+// Observe records the outcome of a request in scope and boosts the scope when
+// it breaches a threshold
+func (a *AdaptiveDebug) Observe(scope string, failed bool, latency time.Duration) {
+	now := a.now()
+	a.restore(now)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	window, ok := a.windows[scope]
+	if !ok || now.Sub(window.start) >= a.cfg.Window {
+		window = &scopeWindow{start: now}
+		a.windows[scope] = window
+	}
+	window.total++
+	if failed {
+		window.failed++
+	}
+	if a.cfg.LatencySLO > 0 && latency > a.cfg.LatencySLO {
+		window.slow++
+	}
+
+	if _, ok := a.boosted[scope]; ok || window.total < a.cfg.MinRequests {
+		return
+	}
+	errorRate := float64(window.failed) / float64(window.total)
+	slowRate := float64(window.slow) / float64(window.total)
+	reason := ""
+	switch {
+	case errorRate >= a.cfg.ErrorRate:
+		reason = "error_rate"
+	case a.cfg.LatencySLO > 0 && slowRate >= a.cfg.SlowRate:
+		reason = "latency"
+	default:
+		return
+	}
+	if a.cfg.MaxBoosts > 0 && len(a.boosted) >= a.cfg.MaxBoosts {
+		return
+	}
+
+	until := now.Add(a.cfg.BoostDuration)
+	a.boosted[scope] = until
+	boostDebug(scope, until)
+	GetLogger().Warn("Debug logging raised for unhealthy scope",
+		zap.String("scope", scope),
+		zap.String("reason", reason),
+		zap.Float64("error_rate", errorRate),
+		zap.Float64("slow_rate", slowRate),
+		zap.Int("requests", window.total),
+		zap.Time("until", until),
+	)
+}
+
+// restore ends the boosts that are over
+func (a *AdaptiveDebug) restore(now time.Time) {
+	for _, scope := range expireDebugBoosts(now) {
+		a.mu.Lock()
+		delete(a.boosted, scope)
+		// Measure the scope afresh so the incident that raised logging does
+		// not raise it again
+		delete(a.windows, scope)
+		a.mu.Unlock()
+		GetLogger().Info("Debug logging restored",
+			zap.String("scope", scope),
+		)
+	}
+}
@@ -0,0 +1,27 @@
+package logger
+
+import (
+	"os"
+
+	"go.uber.org/zap"         // v1.24.0 - High-performance structured logging
+	"go.uber.org/zap/zapcore" // v1.24.0 - Core logging configuration
+)
+
+// InitCLILogger initializes the global logger for command line tools. Log
+// lines are written to stderr at level, leaving stdout to the tool's output.
+// It has no effect once the logger is initialized.
+func InitCLILogger(level string) error {
+	if err := SetLevel(level); err != nil {
+		return err
+	}
+	initOnce.Do(func() {
+		core := zapcore.NewCore(
+			zapcore.NewConsoleEncoder(configureEncoder()),
+			zapcore.Lock(os.Stderr),
+			activeLevel,
+		)
+		logger = zap.New(core, zap.ErrorOutput(zapcore.Lock(os.Stderr)))
+		isInitialized.Store(true)
+	})
+	return nil
+}
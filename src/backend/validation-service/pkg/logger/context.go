@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace" // v1.21.0
+	"go.uber.org/zap"                // v1.24.0 - High-performance structured logging
+)
+
+// contextFieldsKey is the context key of the log fields of a request
+type contextFieldsKey struct{}
+
+// contextFields are the correlation fields attached to every log line written
+// through FromContext
+type contextFields struct {
+	correlationID string
+	tenantID      string
+	format        string
+	detectionID   string
+	route         string
+}
+
+// WithCorrelationID returns a context whose logger carries correlationID
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return withField(ctx, func(f *contextFields) { f.correlationID = correlationID })
+}
+
+// WithTenantID returns a context whose logger carries tenantID
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return withField(ctx, func(f *contextFields) { f.tenantID = tenantID })
+}
+
+// WithFormat returns a context whose logger carries the detection format
+func WithFormat(ctx context.Context, format string) context.Context {
+	return withField(ctx, func(f *contextFields) { f.format = format })
+}
+
+// WithDetectionID returns a context whose logger carries detectionID
+func WithDetectionID(ctx context.Context, detectionID string) context.Context {
+	return withField(ctx, func(f *contextFields) { f.detectionID = detectionID })
+}
+
+// WithRoute returns a context whose logger carries the HTTP route pattern
+func WithRoute(ctx context.Context, route string) context.Context {
+	return withField(ctx, func(f *contextFields) { f.route = route })
+}
+
+// FromContext returns the global logger annotated with the correlation ID,
+// tenant, format, detection ID and route stored in ctx, and the IDs of the
+// trace and span in ctx. Fields that are not set are omitted.
+func FromContext(ctx context.Context) *zap.Logger {
+	log := GetLogger()
+	if ctx == nil {
+		return log
+	}
+	fields, ok := ctx.Value(contextFieldsKey{}).(contextFields)
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !ok && !spanContext.IsValid() {
+		return log
+	}
+
+	zapFields := make([]zap.Field, 0, 7)
+	if spanContext.IsValid() {
+		zapFields = append(zapFields,
+			zap.String("trace_id", spanContext.TraceID().String()),
+			zap.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	if fields.correlationID != "" {
+		zapFields = append(zapFields, zap.String("correlation_id", fields.correlationID))
+	}
+	if fields.tenantID != "" {
+		zapFields = append(zapFields, zap.String("tenant_id", fields.tenantID))
+	}
+	if fields.format != "" {
+		zapFields = append(zapFields, zap.String("format", fields.format))
+	}
+	if fields.detectionID != "" {
+		zapFields = append(zapFields, zap.String("detection_id", fields.detectionID))
+	}
+	if fields.route != "" {
+		zapFields = append(zapFields, zap.String("route", fields.route))
+	}
+	return log.With(zapFields...)
+}
+
+// withField copies the fields of ctx, applies set and stores the result, so
+// derived contexts never affect their parents
+func withField(ctx context.Context, set func(*contextFields)) context.Context {
+	fields, _ := ctx.Value(contextFieldsKey{}).(contextFields)
+	set(&fields)
+	return context.WithValue(ctx, contextFieldsKey{}, fields)
+}
@@ -0,0 +1,27 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"         // v1.24.0 - High-performance structured logging
+	"go.uber.org/zap/zapcore" // v1.24.0 - Core logging configuration
+)
+
+// activeLevel is the level of the global logger. It can be changed while the
+// service runs, for example when the configuration is reloaded.
+var activeLevel = zap.NewAtomicLevelAt(defaultLogLevel)
+
+// SetLevel changes the level of the global logger, e.g. to "debug"
+func SetLevel(level string) error {
+	var parsed zapcore.Level
+	if err := parsed.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	activeLevel.SetLevel(parsed)
+	return nil
+}
+
+// Level returns the current level of the global logger
+func Level() string {
+	return activeLevel.Level().String()
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"          // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+)
+
+// OtherLabelValue is recorded in place of label values outside the allowlist
+// of their label
+const OtherLabelValue = "other"
+
+// labelValuesRejected counts label values replaced with OtherLabelValue, so
+// clients sending unknown values show up without each value becoming a
+// series of its own
+var labelValuesRejected = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "metrics_label_values_rejected_total",
+		Help: "Label values recorded as \"other\" because they are not in the allowlist of their label",
+		ConstLabels: prometheus.Labels{
+			serviceLabelName: serviceLabel,
+		},
+	},
+	[]string{"label"},
+)
+
+// LabelAllowlist bounds the values of a metric label. Label values taken from
+// user input, such as the format query parameter, would otherwise create a
+// series for every value sent.
+type LabelAllowlist struct {
+	label  string
+	mu     sync.RWMutex
+	values map[string]bool
+}
+
+// NewLabelAllowlist creates an allowlist of label holding values
+func NewLabelAllowlist(label string, values ...string) *LabelAllowlist {
+	a := &LabelAllowlist{label: label, values: make(map[string]bool, len(values))}
+	a.Allow(values...)
+	return a
+}
+
+// Allow adds values to the allowlist
+func (a *LabelAllowlist) Allow(values ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, value := range values {
+		a.values[value] = true
+	}
+}
+
+// Sanitize returns value when it is allowed and OtherLabelValue otherwise,
+// counting the rejection
+func (a *LabelAllowlist) Sanitize(value string) string {
+	a.mu.RLock()
+	allowed := a.values[value]
+	a.mu.RUnlock()
+	if allowed {
+		return value
+	}
+	labelValuesRejected.WithLabelValues(a.label).Inc()
+	return OtherLabelValue
+}
+
+// Values returns the allowed values in ascending order
+func (a *LabelAllowlist) Values() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	values := make([]string, 0, len(a.values))
+	for value := range a.values {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
@@ -0,0 +1,159 @@
+// Package metrics provides enterprise-grade Prometheus metrics collection and recording
+// functionality for the validation service with enhanced configuration and validation.
+// Version: 1.0.0
+package metrics
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.16.0
+	"github.com/prometheus/client_golang/prometheus/promauto" // v1.16.0
+	
+	"validation-service/pkg/logger"
+)
+
+// Global metrics collectors
+var (
+	validationRequests *prometheus.CounterVec
+	validationDuration *prometheus.HistogramVec
+	validationErrors   *prometheus.CounterVec
+)
+
+// Constants for metric labels and configuration
+const (
+	serviceLabel     = "validation"
+	formatLabel      = "format"
+	errorTypeLabel   = "error_type"
+	serviceLabelName = "service"
+)
+
+// Label allowlists; other values are recorded as OtherLabelValue
+var (
+	// formats contains the built-in detection formats and the placeholders
+	// recorded when no format is known. Plugin formats are added with
+	// AllowFormats.
+	formats = NewLabelAllowlist(formatLabel,
+		"splunk", "qradar", "sigma", "kql", "sentinel", "paloalto", "crowdstrike",
+		"yara", "yaral", "suricata", "snort", "zeek", "unknown", "shutdown",
+	)
+
+	// errorTypes contains supported error classifications
+	errorTypes = NewLabelAllowlist(errorTypeLabel,
+		"syntax", "format", "validation", "transformation", "internal", "configuration",
+	)
+)
+
+// AllowFormats adds detection formats, such as those of validator plugins,
+// to the values recorded in the format label
+func AllowFormats(values ...string) {
+	formats.Allow(values...)
+}
+
+// InitMetrics initializes and registers all Prometheus metrics collectors
+// with enhanced configuration and validation.
+func InitMetrics() error {
+	log := logger.GetLogger()
+	
+	// Initialize validation requests counter
+	validationRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "validation_requests_total",
+			Help: "Total number of validation requests by format",
+			ConstLabels: prometheus.Labels{
+				serviceLabelName: serviceLabel,
+			},
+		},
+		[]string{formatLabel},
+	)
+
+	// Initialize validation duration histogram with configured buckets
+	validationDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "validation_duration_seconds",
+			Help: "Duration of validation operations by format",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+			ConstLabels: prometheus.Labels{
+				serviceLabelName: serviceLabel,
+			},
+		},
+		[]string{formatLabel},
+	)
+
+	// Initialize validation errors counter
+	validationErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "validation_errors_total",
+			Help: "Total number of validation errors by format and error type",
+			ConstLabels: prometheus.Labels{
+				serviceLabelName: serviceLabel,
+			},
+		},
+		[]string{formatLabel, errorTypeLabel},
+	)
+
+	log.Info("Metrics collectors initialized successfully",
+		"requests_metric", "validation_requests_total",
+		"duration_metric", "validation_duration_seconds",
+		"errors_metric", "validation_errors_total",
+	)
+
+	return nil
+}
+
+// RecordValidationRequest records a validation request for a specific detection
+// format. Unknown formats are recorded as OtherLabelValue.
+func RecordValidationRequest(format string) error {
+	if validationRequests == nil {
+		return nil
+	}
+	format = formats.Sanitize(format)
+
+	validationRequests.WithLabelValues(format).Inc()
+	
+	logger.GetLogger().Debug("Recorded validation request",
+		"format", format,
+	)
+	
+	return nil
+}
+
+// RecordValidationDuration records the duration of a validation operation.
+// Unknown formats are recorded as OtherLabelValue.
+func RecordValidationDuration(format string, duration time.Duration) error {
+	if duration < 0 {
+		return fmt.Errorf("invalid duration: %v (must be non-negative)", duration)
+	}
+	if validationDuration == nil {
+		return nil
+	}
+	format = formats.Sanitize(format)
+
+	validationDuration.WithLabelValues(format).Observe(duration.Seconds())
+	
+	logger.GetLogger().Debug("Recorded validation duration",
+		"format", format,
+		"duration_seconds", duration.Seconds(),
+	)
+	
+	return nil
+}
+
+// RecordValidationError records a validation error occurrence. Unknown
+// formats and error types are recorded as OtherLabelValue.
+func RecordValidationError(format string, errorType string) error {
+	if validationErrors == nil {
+		return nil
+	}
+	format = formats.Sanitize(format)
+	errorType = errorTypes.Sanitize(errorType)
+
+	validationErrors.WithLabelValues(format, errorType).Inc()
+	
+	logger.GetLogger().Error("Recorded validation error",
+		"format", format,
+		"error_type", errorType,
+	)
+	
+	return nil
+}
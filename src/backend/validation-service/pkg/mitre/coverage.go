@@ -0,0 +1,123 @@
+package mitre
+
+import (
+	"regexp"
+	"sort"
+)
+
+var (
+	// techniqueRefPattern finds technique references such as T1059, T1059.001
+	// or Sigma style attack.t1059.001 tags
+	techniqueRefPattern = regexp.MustCompile(`(?i)\bt([0-9]{4})(?:\.([0-9]{3}))?\b`)
+	// tacticRefPattern finds tactic IDs such as TA0002
+	tacticRefPattern = regexp.MustCompile(`(?i)\bta([0-9]{4})\b`)
+	// tacticTagPattern finds Sigma tactic tags such as attack.initial_access.
+	// Technique, group (G0001) and software (S0001) tags contain digits and
+	// do not match.
+	tacticTagPattern = regexp.MustCompile(`(?i)\battack\.([a-z][a-z_-]*[a-z])\b`)
+)
+
+// References are the ATT&CK references found in detection content
+type References struct {
+	// Techniques are sorted, upper-case technique IDs
+	Techniques []string `json:"techniques"`
+	// Tactics are sorted tactic IDs and Sigma tactic shortnames
+	Tactics []string `json:"tactics"`
+}
+
+// Empty reports whether no references were found
+func (r References) Empty() bool {
+	return len(r.Techniques) == 0 && len(r.Tactics) == 0
+}
+
+// ExtractTechniques returns the sorted, de-duplicated technique IDs
+// referenced by content
+func ExtractTechniques(content string) []string {
+	seen := make(map[string]bool)
+	for _, match := range techniqueRefPattern.FindAllStringSubmatch(content, -1) {
+		id := "T" + match[1]
+		if match[2] != "" {
+			id += "." + match[2]
+		}
+		seen[id] = true
+	}
+	return sortedKeys(seen)
+}
+
+// ExtractReferences returns the technique and tactic references in content
+func ExtractReferences(content string) References {
+	tactics := make(map[string]bool)
+	for _, match := range tacticRefPattern.FindAllStringSubmatch(content, -1) {
+		tactics["TA"+match[1]] = true
+	}
+	for _, match := range tacticTagPattern.FindAllStringSubmatch(content, -1) {
+		tactics[normalizeShortName(match[1])] = true
+	}
+
+	return References{
+		Techniques: ExtractTechniques(content),
+		Tactics:    sortedKeys(tactics),
+	}
+}
+
+// Coverage describes the ATT&CK coverage of a detection
+type Coverage struct {
+	DatasetVersion string `json:"dataset_version,omitempty"`
+	// Techniques are the referenced techniques found in the dataset
+	Techniques []Technique `json:"techniques"`
+	// Tactics are the shortnames of the tactics covered by the referenced
+	// techniques and tactics
+	Tactics []string `json:"tactics"`
+	// UnknownTechniques and UnknownTactics are references missing from the
+	// dataset
+	UnknownTechniques []string `json:"unknown_techniques,omitempty"`
+	UnknownTactics    []string `json:"unknown_tactics,omitempty"`
+	// Retired lists the referenced techniques that are deprecated or revoked
+	Retired []string `json:"retired,omitempty"`
+}
+
+// Coverage resolves refs against the dataset
+func (d *Dataset) Coverage(refs References) Coverage {
+	coverage := Coverage{
+		DatasetVersion: d.version,
+		Techniques:     make([]Technique, 0, len(refs.Techniques)),
+	}
+	tactics := make(map[string]bool)
+
+	for _, id := range refs.Techniques {
+		technique, ok := d.Technique(id)
+		if !ok {
+			coverage.UnknownTechniques = append(coverage.UnknownTechniques, id)
+			continue
+		}
+		coverage.Techniques = append(coverage.Techniques, technique)
+		if technique.Retired() {
+			coverage.Retired = append(coverage.Retired, technique.ID)
+		}
+		for _, tactic := range technique.Tactics {
+			tactics[tactic] = true
+		}
+	}
+
+	for _, ref := range refs.Tactics {
+		tactic, ok := d.Tactic(ref)
+		if !ok {
+			coverage.UnknownTactics = append(coverage.UnknownTactics, ref)
+			continue
+		}
+		tactics[tactic.ShortName] = true
+	}
+
+	coverage.Tactics = sortedKeys(tactics)
+	return coverage
+}
+
+// sortedKeys returns the keys of set in ascending order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
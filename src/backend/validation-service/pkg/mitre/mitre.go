@@ -0,0 +1,237 @@
+// Package mitre loads the MITRE ATT&CK knowledge base from its STIX 2.x
+// bundle and resolves the technique and tactic references of detection rules
+// against it.
+package mitre
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// attackSourceName identifies ATT&CK IDs among a STIX object's external references
+const attackSourceName = "mitre-attack"
+
+// ErrEmptyDataset is returned when a bundle contains no ATT&CK techniques
+var ErrEmptyDataset = errors.New("ATT&CK dataset contains no techniques")
+
+var (
+	techniqueIDPattern = regexp.MustCompile(`^T[0-9]{4}(\.[0-9]{3})?$`)
+	tacticIDPattern    = regexp.MustCompile(`^TA[0-9]{4}$`)
+)
+
+// Technique is an ATT&CK technique or sub-technique
+type Technique struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Tactics are the shortnames of the tactics the technique belongs to,
+	// such as "execution"
+	Tactics    []string `json:"tactics"`
+	Deprecated bool     `json:"deprecated,omitempty"`
+	Revoked    bool     `json:"revoked,omitempty"`
+	// RevokedBy is the ID of the technique that replaced a revoked technique
+	RevokedBy string `json:"revoked_by,omitempty"`
+}
+
+// Retired reports whether the technique should no longer be referenced
+func (t Technique) Retired() bool {
+	return t.Deprecated || t.Revoked
+}
+
+// IsSubTechnique reports whether the technique is a sub-technique
+func (t Technique) IsSubTechnique() bool {
+	return strings.Contains(t.ID, ".")
+}
+
+// Tactic is an ATT&CK tactic
+type Tactic struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	ShortName string `json:"short_name"`
+}
+
+// Dataset is an immutable, indexed ATT&CK knowledge base. A Dataset is safe
+// for concurrent use.
+type Dataset struct {
+	version    string
+	techniques map[string]Technique
+	tactics    map[string]Tactic
+	// tacticsByShortName indexes tactics by shortname, e.g. "initial-access"
+	tacticsByShortName map[string]Tactic
+}
+
+// stixBundle is the subset of a STIX 2.x bundle read by Load
+type stixBundle struct {
+	Type    string       `json:"type"`
+	Objects []stixObject `json:"objects"`
+}
+
+// stixObject is the subset of the STIX object properties read by Load
+type stixObject struct {
+	ID                 string `json:"id"`
+	Type               string `json:"type"`
+	Name               string `json:"name"`
+	Revoked            bool   `json:"revoked"`
+	Deprecated         bool   `json:"x_mitre_deprecated"`
+	ShortName          string `json:"x_mitre_shortname"`
+	Version            string `json:"x_mitre_version"`
+	RelationshipType   string `json:"relationship_type"`
+	SourceRef          string `json:"source_ref"`
+	TargetRef          string `json:"target_ref"`
+	ExternalReferences []struct {
+		SourceName string `json:"source_name"`
+		ExternalID string `json:"external_id"`
+	} `json:"external_references"`
+	KillChainPhases []struct {
+		KillChainName string `json:"kill_chain_name"`
+		PhaseName     string `json:"phase_name"`
+	} `json:"kill_chain_phases"`
+}
+
+// attackID returns the ATT&CK ID of the object, such as T1059 or TA0002
+func (o *stixObject) attackID() string {
+	for _, ref := range o.ExternalReferences {
+		if ref.SourceName == attackSourceName {
+			return strings.ToUpper(ref.ExternalID)
+		}
+	}
+	return ""
+}
+
+// LoadFile loads a dataset from a STIX bundle file such as enterprise-attack.json
+func LoadFile(path string) (*Dataset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ATT&CK dataset: %w", err)
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// Load loads a dataset from a STIX 2.x bundle
+func Load(r io.Reader) (*Dataset, error) {
+	var bundle stixBundle
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse ATT&CK dataset: %w", err)
+	}
+	if bundle.Type != "bundle" {
+		return nil, fmt.Errorf("failed to parse ATT&CK dataset: expected a STIX bundle, got %q", bundle.Type)
+	}
+
+	ds := &Dataset{
+		techniques:         make(map[string]Technique),
+		tactics:            make(map[string]Tactic),
+		tacticsByShortName: make(map[string]Tactic),
+	}
+
+	// STIX IDs of techniques, used to resolve revoked-by relationships
+	stixIDs := make(map[string]string)
+	var revocations []stixObject
+
+	for i := range bundle.Objects {
+		obj := &bundle.Objects[i]
+		switch obj.Type {
+		case "x-mitre-collection":
+			ds.version = obj.Version
+		case "attack-pattern":
+			id := obj.attackID()
+			if !techniqueIDPattern.MatchString(id) {
+				continue
+			}
+			technique := Technique{
+				ID:         id,
+				Name:       obj.Name,
+				Deprecated: obj.Deprecated,
+				Revoked:    obj.Revoked,
+				Tactics:    []string{},
+			}
+			for _, phase := range obj.KillChainPhases {
+				if phase.KillChainName == attackSourceName {
+					technique.Tactics = append(technique.Tactics, phase.PhaseName)
+				}
+			}
+			sort.Strings(technique.Tactics)
+			ds.techniques[id] = technique
+			stixIDs[obj.ID] = id
+		case "x-mitre-tactic":
+			id := obj.attackID()
+			if !tacticIDPattern.MatchString(id) {
+				continue
+			}
+			tactic := Tactic{ID: id, Name: obj.Name, ShortName: obj.ShortName}
+			ds.tactics[id] = tactic
+			if tactic.ShortName != "" {
+				ds.tacticsByShortName[tactic.ShortName] = tactic
+			}
+		case "relationship":
+			if obj.RelationshipType == "revoked-by" {
+				revocations = append(revocations, *obj)
+			}
+		}
+	}
+
+	for _, rel := range revocations {
+		source, ok := stixIDs[rel.SourceRef]
+		if !ok {
+			continue
+		}
+		if target, ok := stixIDs[rel.TargetRef]; ok {
+			technique := ds.techniques[source]
+			technique.RevokedBy = target
+			ds.techniques[source] = technique
+		}
+	}
+
+	if len(ds.techniques) == 0 {
+		return nil, ErrEmptyDataset
+	}
+	return ds, nil
+}
+
+// Version returns the ATT&CK release of the dataset, such as "14.1"
+func (d *Dataset) Version() string {
+	return d.version
+}
+
+// Len returns the number of techniques and sub-techniques in the dataset
+func (d *Dataset) Len() int {
+	return len(d.techniques)
+}
+
+// Technique looks up a technique by ID. IDs are case-insensitive.
+func (d *Dataset) Technique(id string) (Technique, bool) {
+	technique, ok := d.techniques[strings.ToUpper(id)]
+	return technique, ok
+}
+
+// Tactic looks up a tactic by ID (TA0002) or shortname ("execution"). Sigma
+// style shortnames such as "initial_access" are accepted.
+func (d *Dataset) Tactic(ref string) (Tactic, bool) {
+	if tactic, ok := d.tactics[strings.ToUpper(ref)]; ok {
+		return tactic, true
+	}
+	tactic, ok := d.tacticsByShortName[normalizeShortName(ref)]
+	return tactic, ok
+}
+
+// IsTechniqueID reports whether id is syntactically an ATT&CK technique or
+// sub-technique ID, such as T1059 or T1059.001
+func IsTechniqueID(id string) bool {
+	return techniqueIDPattern.MatchString(id)
+}
+
+// IsTacticID reports whether id is syntactically an ATT&CK tactic ID
+func IsTacticID(id string) bool {
+	return tacticIDPattern.MatchString(id)
+}
+
+// normalizeShortName converts a tactic name in Sigma tag form to a STIX shortname
+func normalizeShortName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "_", "-")
+}